@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"ecommerce-website/internal/apidocs"
+)
+
+func main() {
+	root := flag.String("root", ".", "Module root directory")
+	out := flag.String("out", "internal/apidocs/annotations.json", "File the annotations are written to")
+	flag.Parse()
+
+	annotations, err := apidocs.Collect(*root)
+	if err != nil {
+		log.Fatal("Failed to collect API annotations:", err)
+	}
+	content, err := json.MarshalIndent(annotations, "", "  ")
+	if err != nil {
+		log.Fatal("Failed to encode API annotations:", err)
+	}
+	if err := os.WriteFile(*out, append(content, '\n'), 0o644); err != nil {
+		log.Fatal("Failed to write API annotations:", err)
+	}
+
+	log.Printf("Collected %d operations and %d schemas into %s", len(annotations.Operations), len(annotations.Schemas), *out)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/geo"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", geo.DefaultBackfillBatchSize, "Number of addresses loaded at a time")
+	all := flag.Bool("all", false, "Relocate every address, not only those never geocoded, e.g. after changing delivery zones")
+	throttle := flag.Duration("throttle", 0, "Pause between geocoding requests, e.g. 1s for the public Nominatim server")
+	flag.Parse()
+
+	// Load configuration
+	cfg := config.Load()
+
+	log.Println("Starting address geocoding backfill...")
+
+	// Initialize database connection
+	if err := database.Initialize(cfg); err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer database.Close()
+
+	geocoder, err := geo.NewGeocoder(cfg.GeocoderProvider, cfg.GeocoderURL, cfg.GeocoderAPIKey)
+	if err != nil {
+		log.Fatal("Invalid geocoder configuration:", err)
+	}
+	if geocoder == nil {
+		log.Println("GEOCODER_PROVIDER is not set; addresses are zoned by postal code only")
+	}
+
+	service := geo.NewService(database.GetDB(), geocoder)
+	progress, err := service.Backfill(context.Background(), geo.BackfillOptions{BatchSize: *batchSize, All: *all, Throttle: *throttle}, func(p geo.BackfillProgress) {
+		log.Printf("Processed %d of %d addresses (%d located, %d zoned, %d failed)", p.Finished, p.Total, p.Located, p.Zoned, p.Failed)
+	})
+	if err != nil {
+		log.Fatal("Failed to backfill addresses:", err)
+	}
+
+	log.Printf("Backfill completed: %d of %d addresses located, %d zoned, %d failed", progress.Located, progress.Total, progress.Zoned, progress.Failed)
+}
@@ -1,45 +1,88 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"text/tabwriter"
 
 	"ecommerce-website/internal/config"
 	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/migrate"
+	"ecommerce-website/migrations"
 )
 
 func main() {
 	var (
-		seed = flag.Bool("seed", false, "Run database seeding after migration")
-		drop = flag.Bool("drop", false, "Drop all tables before migration (DANGEROUS)")
+		to     = flag.Int64("to", -1, "Migrate up or down to this version instead of the latest; 0 reverts every migration")
+		down   = flag.Bool("down", false, "Revert the most recently applied migration")
+		status = flag.Bool("status", false, "List the migrations and when they were applied, then exit")
+		seed   = flag.Bool("seed", false, "Run database seeding after migration")
+		drop   = flag.Bool("drop", false, "Revert every migration before migrating (DANGEROUS)")
 	)
 	flag.Parse()
+	if *down && (*to >= 0 || *drop) {
+		log.Fatal("-down reverts one migration and cannot be combined with -to or -drop")
+	}
 
 	// Load configuration
 	cfg := config.Load()
 
-	log.Println("Starting database migration...")
-
 	// Initialize database connection
 	if err := database.Initialize(cfg); err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 	defer database.Close()
 
-	// Drop tables if requested (for development only)
-	if *drop {
-		log.Println("WARNING: Dropping all tables...")
-		if err := dropAllTables(); err != nil {
-			log.Fatal("Failed to drop tables:", err)
+	migrator, err := migrate.New(database.GetDB(), migrations.Files)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	ctx := context.Background()
+
+	if *status {
+		if err := printStatus(ctx, migrator); err != nil {
+			log.Fatal("Failed to get migration status:", err)
 		}
-		log.Println("All tables dropped successfully")
+		return
+	}
 
-		// Re-run migrations after dropping
-		if err := database.Initialize(cfg); err != nil {
-			log.Fatal("Failed to re-initialize database after drop:", err)
+	if *down {
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			log.Fatal("Failed to revert migration:", err)
+		}
+		if reverted == nil {
+			log.Println("No migration to revert")
+		} else {
+			log.Printf("Reverted %06d_%s", reverted.Version, reverted.Name)
+		}
+		return
+	}
+
+	// Revert everything first if requested (for development only)
+	if *drop {
+		log.Println("WARNING: Reverting all migrations...")
+		reverted, err := migrator.To(ctx, 0)
+		logMigrations("Reverted", reverted)
+		if err != nil {
+			log.Fatal("Failed to revert migrations:", err)
 		}
 	}
 
+	target := migrator.Latest()
+	if *to >= 0 {
+		target = *to
+	}
+	log.Printf("Migrating database to version %d...", target)
+	ran, err := migrator.To(ctx, target)
+	logMigrations("Ran", ran)
+	if err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
 	// Run seeding if requested
 	if *seed {
 		log.Println("Running database seeding...")
@@ -52,43 +95,25 @@ func main() {
 	log.Println("Migration completed successfully!")
 }
 
-func dropAllTables() error {
-	// Get database instance
-	db := database.DB
-
-	// First, drop all foreign key constraints
-	log.Println("Dropping foreign key constraints...")
-	constraints := []string{
-		"ALTER TABLE addresses DROP CONSTRAINT IF EXISTS fk_users_addresses CASCADE",
-		"ALTER TABLE orders DROP CONSTRAINT IF EXISTS fk_users_orders CASCADE",
-		"ALTER TABLE order_items DROP CONSTRAINT IF EXISTS fk_orders_order_items CASCADE",
-		"ALTER TABLE order_items DROP CONSTRAINT IF EXISTS fk_products_order_items CASCADE",
-		"ALTER TABLE products DROP CONSTRAINT IF EXISTS fk_categories_products CASCADE",
-		"ALTER TABLE categories DROP CONSTRAINT IF EXISTS fk_categories_children CASCADE",
-	}
-
-	for _, constraint := range constraints {
-		if err := db.Exec(constraint).Error; err != nil {
-			log.Printf("Warning: failed to drop constraint: %v", err)
-		}
+func logMigrations(verb string, migrations []migrate.Migration) {
+	for _, migration := range migrations {
+		log.Printf("%s %06d_%s", verb, migration.Version, migration.Name)
 	}
+}
 
-	// Drop tables in reverse order to handle foreign key constraints
-	tables := []string{
-		"order_items",
-		"orders",
-		"products",
-		"categories",
-		"addresses",
-		"users",
+func printStatus(ctx context.Context, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
 	}
-
-	for _, table := range tables {
-		if err := db.Exec("DROP TABLE IF EXISTS " + table + " CASCADE").Error; err != nil {
-			return err
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
 		}
-		log.Printf("Dropped table: %s", table)
+		fmt.Fprintf(w, "%06d\t%s\t%s\n", status.Version, status.Name, applied)
 	}
-
-	return nil
+	return w.Flush()
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/search"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", search.DefaultReindexBatchSize, "Number of products sent in each bulk request")
+	flag.Parse()
+
+	// Load configuration
+	cfg := config.Load()
+
+	log.Println("Starting product reindex...")
+
+	// Initialize database connection
+	if err := database.Initialize(cfg); err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer database.Close()
+
+	searchService := search.NewService(database.GetDB())
+
+	progress, err := searchService.ReindexAllProducts(*batchSize, func(p search.ReindexProgress) {
+		log.Printf("Indexed %d of %d products (%d failed)", p.Indexed+p.Failed, p.Total, p.Failed)
+	})
+	if err != nil {
+		log.Fatal("Failed to reindex products:", err)
+	}
+
+	log.Printf("Reindex completed: %d indexed, %d failed", progress.Indexed, progress.Failed)
+}
@@ -1,27 +1,67 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"ecommerce-website/internal/adminevents"
+	"ecommerce-website/internal/affiliates"
+	"ecommerce-website/internal/analytics"
+	"ecommerce-website/internal/apidocs"
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/audit"
 	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/campaigns"
 	"ecommerce-website/internal/cart"
+	"ecommerce-website/internal/compare"
 	"ecommerce-website/internal/config"
 	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/deliverability"
+	"ecommerce-website/internal/email"
 	"ecommerce-website/internal/errors"
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/finance"
+	"ecommerce-website/internal/health"
+	"ecommerce-website/internal/invariants"
+	"ecommerce-website/internal/inventory"
+	"ecommerce-website/internal/jobs"
 	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/maintenance"
+	"ecommerce-website/internal/media"
 	"ecommerce-website/internal/middleware"
+	"ecommerce-website/internal/migrate"
 	"ecommerce-website/internal/monitoring"
 	"ecommerce-website/internal/orders"
+	"ecommerce-website/internal/outbox"
 	"ecommerce-website/internal/payments"
 	"ecommerce-website/internal/products"
+	"ecommerce-website/internal/quotas"
+	"ecommerce-website/internal/recommendations"
+	"ecommerce-website/internal/reportqueries"
+	"ecommerce-website/internal/reports"
+	"ecommerce-website/internal/retention"
+	"ecommerce-website/internal/savedviews"
+	"ecommerce-website/internal/settings"
+	"ecommerce-website/internal/shipping"
+	"ecommerce-website/internal/sla"
+	"ecommerce-website/internal/tax"
 	"ecommerce-website/internal/users"
 	imageutils "ecommerce-website/internal/utils"
+	"ecommerce-website/internal/warranties"
+	"ecommerce-website/internal/webhooks"
+	"ecommerce-website/internal/wishlist"
+	"ecommerce-website/migrations"
 	"ecommerce-website/pkg/utils"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -33,12 +73,20 @@ func main() {
 	if cfg.Environment == "development" {
 		logLevel = logger.DEBUG
 	}
+	moduleLevels, moduleLevelsErr := logger.ParseModuleLevels(cfg.LogLevels)
 	logger.Initialize(logger.Config{
-		Level:       logLevel,
-		ServiceName: "ecommerce-api",
+		Level:           logLevel,
+		ServiceName:     "ecommerce-api",
+		ModuleLevels:    moduleLevels,
+		DebugSampleRate: int(cfg.LogDebugSampleRate),
 	})
 
 	log := logger.GetLogger()
+	if moduleLevelsErr != nil {
+		log.Warn("Ignoring invalid LOG_LEVELS configuration", map[string]interface{}{
+			"error": moduleLevelsErr.Error(),
+		})
+	}
 	log.Info("Starting ecommerce API server", map[string]interface{}{
 		"environment": cfg.Environment,
 		"port":        cfg.Port,
@@ -51,13 +99,11 @@ func main() {
 	if err := database.Initialize(cfg); err != nil {
 		log.Fatal("Failed to initialize database", err)
 	}
-	defer database.Close()
 
 	// Initialize Redis
 	if err := database.InitializeRedis(cfg); err != nil {
 		log.Fatal("Failed to initialize Redis", err)
 	}
-	defer database.CloseRedis()
 
 	// Seed database if SEED_DATA environment variable is set
 	if os.Getenv("SEED_DATA") == "true" {
@@ -69,109 +115,169 @@ func main() {
 	}
 
 	r := gin.New()
+	if cfg.TrustedProxies != "" {
+		if err := r.SetTrustedProxies(strings.Split(cfg.TrustedProxies, ",")); err != nil {
+			log.Fatal("Invalid TRUSTED_PROXIES", err)
+		}
+	}
 
 	// Add comprehensive middleware stack
 	r.Use(middleware.RequestIDMiddleware())
 	r.Use(middleware.LoggingMiddleware())
 	r.Use(middleware.ErrorHandlingMiddleware())
 
+	// Cancel slow requests; installed before compression so it sees whether a buffered response was written
+	r.Use(middleware.TimeoutMiddleware(time.Duration(cfg.RequestTimeoutSeconds) * time.Second))
+
+	// Compress large text and JSON responses such as product listings and search results
+	compression := middleware.DefaultCompressionConfig
+	compression.MinSize = int(cfg.CompressionMinSize)
+	r.Use(middleware.CompressionMiddleware(compression))
+
 	// Initialize image optimization config
 	imageutils.DefaultImageConfig.CDNBaseURL = cfg.CDNBaseURL
+	imageutils.DefaultImageConfig.SigningKey = cfg.CDNSigningKey
+	imageFormats, unsupportedFormats := media.ImageVariantFormats(strings.Split(cfg.ImageFormats, ","))
+	if len(unsupportedFormats) > 0 {
+		log.Warn("No encoder for image formats; uploaded images will not get variants in them", map[string]interface{}{
+			"formats": unsupportedFormats,
+		})
+	}
+	imageutils.DefaultImageConfig.VariantFormats = imageFormats
 
 	// Security middleware
 	r.Use(middleware.SecurityHeadersMiddleware())
+	r.Use(middleware.AdminIPAllowListMiddleware(middleware.ParseIPNets(cfg.AdminAllowIPs)))
 	r.Use(middleware.InputSanitizationMiddleware())
 	r.Use(middleware.ValidateContentLength(cfg.MaxRequestSize))
 
-	// Configure CORS middleware
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:3001", "http://192.168.1.5:8080", "http://127.0.0.1:3000", "http://0.0.0.0:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With", "Accept", "Accept-Encoding", "Accept-Language", "Connection", "Host"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Cache"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
+	// Configure CORS middleware; the configured part of the policy is applied again on SIGHUP
+	corsConfig, err := cfg.LoadCORS()
+	if err != nil {
+		log.Fatal("Failed to load CORS config", err)
+	}
+	corsPolicy, err := middleware.NewCORSPolicy(cors.Config{
+		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With", "Accept", "Accept-Encoding", "Accept-Language", "Connection", "Host", "If-Match", middleware.RequestTimestampHeader, middleware.RequestNonceHeader, quotas.StoreHeader},
+		ExposeHeaders: []string{"Content-Length", "Content-Type", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", middleware.RateLimitWarningHeader, middleware.RateLimitBackoffHeader, "X-Cache", "ETag", quotas.HeaderQuotaName, quotas.HeaderQuotaLimit, quotas.HeaderQuotaRemaining, quotas.HeaderQuotaReset},
+	}, corsConfig)
+	if err != nil {
+		log.Fatal("Invalid CORS config", err)
+	}
+	r.Use(corsPolicy.Middleware())
+	reloadCORSOnHangup(cfg, corsPolicy)
 
-	// General rate limiting
-	r.Use(middleware.RateLimitMiddleware(middleware.GeneralRateLimit))
+	// Initialize authentication service; every module authorizes requests through it
+	authService := auth.NewServiceWithMailer(database.GetDB(), cfg, email.NewServiceWithSettings(settings.NewService(database.GetDB())).WithLog(database.GetDB()))
+
+	// Rate limiting per route group, per signed-in user and otherwise per client IP
+	rateLimits, err := middleware.ParseRateLimitRules(cfg.RateLimits)
+	if err != nil {
+		log.Fatal("Invalid RATE_LIMITS", err)
+	}
+	r.Use(middleware.RouteRateLimitMiddleware(rateLimits, middleware.ClientKeyFunc(authService.BearerUserID)))
 
 	// Cache invalidation middleware
 	r.Use(middleware.CacheInvalidationMiddleware())
 
-	// Initialize authentication service
-	authService := auth.NewService(database.GetDB(), cfg)
-	authHandler := auth.NewHandler(authService)
-
-	// Initialize product service
-	productService := products.NewService(database.GetDB())
-	productHandler := products.NewHandler(productService)
-
-	// Initialize user service
-	userService := users.NewService(database.GetDB())
-	userHandler := users.NewHandler(userService)
-
-	// Initialize orders service
-	ordersService := orders.NewService(database.GetDB())
-	ordersHandler := orders.NewHandler(ordersService)
-
-	// Initialize payments service
-	paymentsService := payments.NewService(database.GetDB(), cfg.RazorpayKeyID, cfg.RazorpaySecret)
-	paymentsHandler := payments.NewHandler(paymentsService)
-
-	// Initialize error handling service
-	errorHandler := errors.NewHandler()
-
-	// Setup routes
+	// Register application modules. Maintenance comes first so its read-only middleware
+	// runs ahead of every other module's. The outbox hands the messages of committed changes to the job queue,
+	// whose workers deliver them.
+	jobQueue := jobs.NewQueue(database.GetRedisClient())
+	application := app.New(app.Deps{
+		Config: cfg,
+		DB:     database.GetDB(),
+		ReadDB: database.GetReadDB(),
+		Redis:  database.GetRedisClient(),
+		Auth:   authService,
+		Jobs:   jobQueue,
+		Outbox: outbox.NewDispatcher(database.GetDB()).RelayTo(jobQueue),
+	})
+	if err := application.Register(
+		maintenance.NewModule,
+		apidocs.NewModule,
+		// The auth service is provided in Deps, so the auth package cannot import app for a factory of its own
+		func(deps app.Deps) app.Module {
+			return auth.NewModule(deps.Auth, cart.NewService())
+		},
+		audit.NewModule,
+		quotas.NewModule,
+		media.NewModule,
+		products.NewModule,
+		users.NewModule,
+		settings.NewModule,
+		cart.NewModule,
+		compare.NewModule,
+		recommendations.NewModule,
+		wishlist.NewModule,
+		tax.NewModule,
+		shipping.NewModule,
+		orders.NewModule,
+		payments.NewModule,
+		finance.NewModule,
+		sla.NewModule,
+		warranties.NewModule,
+		reports.NewModule,
+		reportqueries.NewModule,
+		analytics.NewModule,
+		campaigns.NewModule,
+		deliverability.NewModule,
+		// The jobs package provides the queue in Deps, so it cannot import app for a factory of its own
+		func(deps app.Deps) app.Module {
+			return jobs.NewModule(deps.Jobs, deps.Auth, int(deps.Config.JobWorkers))
+		},
+		// Like the job queue, the outbox dispatcher is provided in Deps
+		func(deps app.Deps) app.Module {
+			return outbox.NewModule(deps.Outbox, deps.Auth)
+		},
+		inventory.NewModule,
+		adminevents.NewModule,
+		affiliates.NewModule,
+		retention.NewModule,
+		invariants.NewModule,
+		savedviews.NewModule,
+		webhooks.NewModule,
+		eventbus.NewModule,
+		errors.NewModule,
+	); err != nil {
+		log.Fatal("Failed to register modules", err)
+	}
+	if err := checkSchema(database.GetDB()); err != nil {
+		log.Fatal("Database schema is not up to date", err)
+	}
+	application.Mount(r)
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	application.StartJobs(jobsCtx)
+	log.Info("Modules registered", map[string]interface{}{"modules": application.Names()})
+
+	// Health probes: liveness checks nothing, readiness fails while Postgres or Redis is down
+	checks := []health.Check{
+		{Name: "postgres", Required: true, Ping: database.Ping},
+		{Name: "redis", Required: true, Ping: database.PingRedis},
+	}
+	if database.ReadDB != nil {
+		// Catalog reads fall back to nothing while replicas are down, so they gate readiness too
+		checks = append(checks, health.Check{Name: "postgres-replicas", Required: true, Ping: database.PingReplicas})
+	}
+	prober := health.NewProber(health.DefaultTimeout, append(checks, application.Dependencies()...)...)
+	r.GET("/health/live", prober.Live)
+	r.GET("/health/ready", prober.Ready)
 	r.GET("/health", func(c *gin.Context) {
+		report := prober.Check(c.Request.Context())
+		modules, healthy := application.Health(c.Request.Context())
+		status := report.Status
+		if !healthy && status == health.StatusOK {
+			status = health.StatusDegraded
+		}
 		utils.SuccessResponse(c, http.StatusOK, "Ecommerce API is running", gin.H{
-			"status":   "ok",
-			"database": "connected",
-			"redis":    "connected",
+			"status":       status,
+			"dependencies": report.Dependencies,
+			"modules":      modules,
 		})
 	})
 
-	// API routes group
-	api := r.Group("/api")
-
-	// Setup authentication routes with stricter rate limiting
-	authGroup := r.Group("/api/auth")
-	authGroup.Use(middleware.RateLimitMiddleware(middleware.AuthRateLimit))
-	auth.SetupRoutes(r, authHandler, authService)
-
-	// Setup product routes with caching
-	productGroup := r.Group("/api/products")
-	productGroup.Use(middleware.CacheMiddleware(middleware.ProductCatalogCache))
-	products.SetupRoutes(r, productHandler, authService)
-
-	// Setup category routes with caching
-	categoryGroup := r.Group("/api/categories")
-	categoryGroup.Use(middleware.CacheMiddleware(middleware.CategoryCache))
-
-	// Setup user routes
-	users.SetupRoutes(r, userHandler, authService)
-
-	// Setup cart routes
-	cart.RegisterRoutes(api)
-
-	// Setup orders routes
-	orders.SetupRoutes(r, ordersHandler, authService)
-
-	// Setup payments routes with stricter rate limiting
-	paymentGroup := r.Group("/api/payments")
-	paymentGroup.Use(middleware.RateLimitMiddleware(middleware.PaymentRateLimit))
-	payments.SetupRoutes(r, paymentsHandler, authService)
-
-	// Setup error handling and monitoring routes
-	errors.SetupRoutes(r, errorHandler, authService)
-
-	// Serve static files in development mode
+	// Point the root at the API documentation in development mode
 	if cfg.Environment == "development" {
-		log.Info("Development mode: serving static files from api/ directory", map[string]interface{}{
-			"static_path": "/api-docs",
-			"files_dir":   "./api",
-		})
-		r.Static("/api-docs", "./api")
 		r.GET("/", func(c *gin.Context) {
 			log.Debug("Root path accessed, redirecting to API documentation")
 			c.Redirect(http.StatusMovedPermanently, "/api-docs/")
@@ -183,18 +289,91 @@ func main() {
 		})
 	}
 
-	// Setup admin routes with admin rate limiting
-	adminGroup := r.Group("/api/admin")
-	adminGroup.Use(authService.AuthMiddleware())
-	adminGroup.Use(authService.AdminMiddleware())
-	adminGroup.Use(middleware.RateLimitMiddleware(middleware.AdminRateLimit))
-
 	log.Info("Server starting", map[string]interface{}{
 		"port":        cfg.Port,
 		"environment": cfg.Environment,
 	})
 
-	if err := r.Run(":" + cfg.Port); err != nil {
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case err := <-serverErr:
 		log.Fatal("Failed to start server", err)
+	case <-signals.Done():
+	}
+
+	shutdown(server, application, stopJobs, time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
+}
+
+// reloadCORSOnHangup loads the CORS policy again every time the process receives SIGHUP, so allowed origins
+// kept in CORS_CONFIG_FILE change without a restart. A policy that fails to load leaves the current one in place.
+func reloadCORSOnHangup(cfg *config.Config, policy *middleware.CORSPolicy) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		log := logger.GetLogger()
+		for range hangups {
+			corsConfig, err := cfg.LoadCORS()
+			if err == nil {
+				err = policy.Update(corsConfig)
+			}
+			if err != nil {
+				log.Warn("Failed to reload CORS config; keeping the current one", map[string]interface{}{"error": err.Error()})
+				continue
+			}
+			log.Info("Reloaded CORS config", map[string]interface{}{"origins": corsConfig.AllowedOrigins})
+		}
+	}()
+}
+
+// shutdown stops the server in order: it stops accepting connections and drains in-flight requests, stops
+// background jobs and waits for the ones running, and then closes Redis and the database they were using.
+// Everything shares one timeout; work still running when it expires is abandoned.
+func shutdown(server *http.Server, application *app.App, stopJobs context.CancelFunc, timeout time.Duration) {
+	log := logger.GetLogger()
+	log.Info("Shutting down", map[string]interface{}{"timeout": timeout.String()})
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Warn("In-flight requests did not finish before the shutdown timeout", map[string]interface{}{"error": err.Error()})
+	}
+	stopJobs()
+	if err := application.Stop(ctx); err != nil {
+		log.Warn("Background jobs did not finish before the shutdown timeout", map[string]interface{}{"error": err.Error()})
+	}
+	if err := database.CloseRedis(); err != nil {
+		log.Warn("Failed to close Redis", map[string]interface{}{"error": err.Error()})
+	}
+	if err := database.Close(); err != nil {
+		log.Warn("Failed to close the database", map[string]interface{}{"error": err.Error()})
+	}
+	log.Info("Server stopped")
+}
+
+// checkSchema refuses to serve a database with pending migrations, which the code may already rely on.
+// Migrations are applied with cmd/migrate rather than on boot so schema changes stay reviewable.
+func checkSchema(db *gorm.DB) error {
+	migrator, err := migrate.New(db, migrations.Files)
+	if err != nil {
+		return err
+	}
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d pending migrations, starting with %06d_%s; run go run ./cmd/migrate", len(pending), pending[0].Version, pending[0].Name)
 	}
+	return nil
 }
@@ -0,0 +1,63 @@
+package adminevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval is how often an idle stream sends a comment, so proxies keep the connection open
+const heartbeatInterval = 25 * time.Second
+
+// Handler streams admin events to dashboards
+type Handler struct {
+	hub       *Hub
+	heartbeat time.Duration
+}
+
+// NewHandler creates a handler streaming the events of hub
+func NewHandler(hub *Hub) *Handler {
+	return &Handler{hub: hub, heartbeat: heartbeatInterval}
+}
+
+// Stream handles GET /api/admin/events (admin only)
+// New orders, failed payments and low-stock products are pushed as server-sent events named after their
+// type, each carrying the event as JSON, until the dashboard disconnects.
+func (h *Handler) Stream(c *gin.Context) {
+	events, unsubscribe := h.hub.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // stop nginx from buffering the stream
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 5000\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
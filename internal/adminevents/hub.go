@@ -0,0 +1,194 @@
+package adminevents
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"ecommerce-website/internal/logger"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Event types pushed to admin dashboards
+const (
+	TypeOrderCreated  = "order.created"
+	TypePaymentFailed = "payment.failed"
+	TypeLowStock      = "inventory.low_stock"
+)
+
+const (
+	// Channel is the Redis pub/sub channel events are shared on between server instances
+	Channel = "admin:events"
+	// subscriberBuffer is how many events a slow dashboard may fall behind before events are dropped for it
+	subscriberBuffer = 64
+	// publishTimeout bounds handing an event to Redis
+	publishTimeout = 2 * time.Second
+)
+
+// Event is a notification for admin dashboards
+type Event struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// OrderCreated is pushed when a customer places an order
+type OrderCreated struct {
+	OrderID string  `json:"orderId"`
+	UserID  string  `json:"userId"`
+	Total   float64 `json:"total"`
+	Items   int     `json:"items"`
+}
+
+// PaymentFailed is pushed when the payment provider reports a failed payment for an order
+type PaymentFailed struct {
+	OrderID   string `json:"orderId"`
+	PaymentID string `json:"paymentId"`
+	Amount    int64  `json:"amount"` // in the smallest currency unit, like payments
+	Currency  string `json:"currency"`
+}
+
+// LowStock is pushed when an order takes a product down to its low-stock threshold
+type LowStock struct {
+	ProductID string `json:"productId"`
+	Name      string `json:"name"`
+	SKU       string `json:"sku"`
+	Inventory int    `json:"inventory"`
+	Threshold int    `json:"threshold"`
+}
+
+// Hub fans events out to the dashboards connected to this instance. With Redis, events are published on
+// Channel and every instance delivers what it receives there, so a dashboard sees events from the whole
+// cluster; without Redis, events only reach dashboards connected to the instance that published them.
+type Hub struct {
+	redis       *redis.Client
+	mu          sync.RWMutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewHub creates a hub sharing events through client, or within the process when client is nil
+func NewHub(client *redis.Client) *Hub {
+	return &Hub{redis: client, subscribers: make(map[chan Event]struct{})}
+}
+
+var (
+	defaultMu  sync.RWMutex
+	defaultHub = NewHub(nil)
+)
+
+// SetDefault makes hub the one Publish sends events through
+func SetDefault(hub *Hub) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultHub = hub
+}
+
+// Default returns the hub Publish sends events through
+func Default() *Hub {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultHub
+}
+
+// Publish pushes an event to admin dashboards through the default hub. Notifications are best effort:
+// failures are logged and never fail the caller.
+func Publish(eventType string, data interface{}) {
+	Default().Publish(eventType, data)
+}
+
+// Publish pushes an event to admin dashboards. When Redis cannot take it the event is still delivered to
+// the dashboards connected to this instance.
+func (h *Hub) Publish(eventType string, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		logger.Named("adminevents").Warn("Failed to encode admin event", map[string]interface{}{"type": eventType, "error": err.Error()})
+		return
+	}
+	event := Event{ID: uuid.New().String(), Type: eventType, Time: time.Now().UTC(), Data: body}
+
+	if h.redis != nil {
+		payload, err := json.Marshal(event)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+			err = h.redis.Publish(ctx, Channel, payload).Err()
+			cancel()
+			if err == nil {
+				return
+			}
+		}
+		logger.Named("adminevents").Warn("Failed to publish admin event to Redis", map[string]interface{}{"type": eventType, "error": err.Error()})
+	}
+	h.broadcast(event)
+}
+
+// Subscribe returns a channel receiving the events published from now on, and a function that stops them
+func (h *Hub) Subscribe() (<-chan Event, func()) {
+	events := make(chan Event, subscriberBuffer)
+	h.mu.Lock()
+	h.subscribers[events] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, events)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Subscribers returns how many dashboards are connected to this instance
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// broadcast delivers an event to the dashboards connected to this instance. A dashboard too far behind
+// misses the event rather than holding up the others.
+func (h *Hub) broadcast(event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for subscriber := range h.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// Relay delivers the events published on Redis by every instance to this instance's dashboards until ctx is
+// cancelled. It does nothing without Redis, where Publish delivers events itself.
+func (h *Hub) Relay(ctx context.Context) {
+	if h.redis == nil {
+		return
+	}
+	go func() {
+		pubsub := h.redis.Subscribe(ctx, Channel)
+		defer pubsub.Close()
+
+		// The channel reconnects by itself when the Redis connection drops
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event Event
+				if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+					logger.Named("adminevents").Warn("Ignored malformed admin event", map[string]interface{}{"error": err.Error()})
+					continue
+				}
+				h.broadcast(event)
+			}
+		}
+	}()
+}
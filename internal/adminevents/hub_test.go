@@ -0,0 +1,89 @@
+package adminevents
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_PublishWithoutRedis(t *testing.T) {
+	hub := NewHub(nil)
+	events, unsubscribe := hub.Subscribe()
+	other, stop := hub.Subscribe()
+	assert.Equal(t, 2, hub.Subscribers())
+
+	hub.Publish(TypeLowStock, LowStock{ProductID: "p1", Inventory: 2, Threshold: 5})
+	for _, subscriber := range []<-chan Event{events, other} {
+		select {
+		case event := <-subscriber:
+			assert.Equal(t, TypeLowStock, event.Type)
+			assert.NotEmpty(t, event.ID)
+			var data LowStock
+			require.NoError(t, json.Unmarshal(event.Data, &data))
+			assert.Equal(t, "p1", data.ProductID)
+		case <-time.After(time.Second):
+			t.Fatal("event not delivered")
+		}
+	}
+
+	stop()
+	stop()
+	assert.Equal(t, 1, hub.Subscribers())
+
+	// A dashboard that stops reading misses events instead of blocking the publisher
+	for i := 0; i < subscriberBuffer+10; i++ {
+		hub.Publish(TypeOrderCreated, OrderCreated{OrderID: "o1"})
+	}
+	assert.Len(t, events, subscriberBuffer)
+	unsubscribe()
+	assert.Zero(t, hub.Subscribers())
+}
+
+func TestHandler_Stream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hub := NewHub(nil)
+	handler := NewHandler(hub)
+	handler.heartbeat = 10 * time.Millisecond
+	router := gin.New()
+	router.GET("/api/admin/events", handler.Stream)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/events", nil).WithContext(ctx))
+	}()
+
+	require.Eventually(t, func() bool { return hub.Subscribers() == 1 }, time.Second, 5*time.Millisecond)
+	hub.Publish(TypePaymentFailed, PaymentFailed{OrderID: "o1", PaymentID: "pay-1", Amount: 4999, Currency: "INR"})
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Zero(t, hub.Subscribers(), "the subscription ends with the stream")
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "event: payment.failed\n")
+	assert.Contains(t, body, `"orderId":"o1"`)
+	assert.Contains(t, body, ": keepalive\n\n")
+
+	var data string
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(data), &event))
+	assert.Equal(t, TypePaymentFailed, event.Type)
+	assert.True(t, strings.HasPrefix(body, "retry: 5000\n\n"))
+}
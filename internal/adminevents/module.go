@@ -0,0 +1,40 @@
+package adminevents
+
+import (
+	"context"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module pushes order, payment and stock events to admin dashboards. Other packages publish events with
+// Publish; the module shares them between instances over Redis and streams them to dashboards.
+type Module struct {
+	hub         *Hub
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the admin events module and makes its hub the one events are published through
+func NewModule(deps app.Deps) app.Module {
+	hub := NewHub(deps.Redis)
+	SetDefault(hub)
+	return &Module{hub: hub, handler: NewHandler(hub), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "adminevents"
+}
+
+// StartJobs relays the events published by every instance to this instance's dashboards
+func (m *Module) StartJobs(ctx context.Context) {
+	m.hub.Relay(ctx)
+}
+
+// RegisterAPIRoutes sets up the admin event stream
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,19 @@
+package adminevents
+
+import (
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin event stream. The stream stays open until the dashboard disconnects, so
+// it is exempt from the request timeout.
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("/events", middleware.TimeoutMiddleware(0), handler.Stream)
+	}
+}
@@ -0,0 +1,83 @@
+package affiliates
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new affiliate API handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// CreateAPIKeyRequest represents the request body for issuing an API key
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// GetAvailability handles GET /api/public/products/:sku/availability (API key required)
+func (h *Handler) GetAvailability(c *gin.Context) {
+	availability, err := h.service.GetAvailability(c.Param("sku"))
+	if err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get availability", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Availability retrieved successfully", availability)
+}
+
+// CreateAPIKey handles POST /api/admin/api-keys (admin only)
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	created, err := h.service.CreateKey(req.Name)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create API key", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "API key created successfully; store it now, it will not be shown again", created)
+}
+
+// ListAPIKeys handles GET /api/admin/api-keys (admin only)
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.service.ListKeys()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list API keys", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API keys retrieved successfully", gin.H{"apiKeys": keys})
+}
+
+// RevokeAPIKey handles DELETE /api/admin/api-keys/:id (admin only)
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	if err := h.service.RevokeKey(c.Param("id")); err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "API_KEY_NOT_FOUND", "API key not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to revoke API key", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
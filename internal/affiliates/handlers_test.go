@@ -0,0 +1,130 @@
+package affiliates
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the affiliate API service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) CreateKey(name string) (*CreatedKey, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CreatedKey), args.Error(1)
+}
+
+func (m *MockService) ListKeys() ([]models.APIKey, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockService) RevokeKey(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockService) Authenticate(key string) (*models.APIKey, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockService) GetAvailability(sku string) (*Availability, error) {
+	args := m.Called(sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Availability), args.Error(1)
+}
+
+func TestHandler_GetAvailability(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		apiKey         string
+		sku            string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "valid key",
+			apiKey: "ak_valid",
+			sku:    "SKU-1",
+			setupMock: func(m *MockService) {
+				m.On("Authenticate", "ak_valid").Return(&models.APIKey{ID: "key-1"}, nil)
+				m.On("GetAvailability", "SKU-1").Return(&Availability{SKU: "SKU-1", Price: 10, InStock: true, URL: "https://shop/products/p1"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"in_stock":true`,
+		},
+		{
+			name:           "missing key",
+			sku:            "SKU-1",
+			setupMock:      func(m *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "MISSING_API_KEY",
+		},
+		{
+			name:   "revoked key",
+			apiKey: "ak_revoked",
+			sku:    "SKU-1",
+			setupMock: func(m *MockService) {
+				m.On("Authenticate", "ak_revoked").Return(nil, ErrInvalidAPIKey)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "INVALID_API_KEY",
+		},
+		{
+			name:   "unknown sku",
+			apiKey: "ak_valid",
+			sku:    "SKU-X",
+			setupMock: func(m *MockService) {
+				m.On("Authenticate", "ak_valid").Return(&models.APIKey{ID: "key-1"}, nil)
+				m.On("GetAvailability", "SKU-X").Return(nil, ErrProductNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "PRODUCT_NOT_FOUND",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := NewHandler(mockService)
+
+			router := gin.New()
+			router.GET("/api/public/products/:sku/availability", APIKeyMiddleware(mockService), handler.GetAvailability)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/public/products/"+tt.sku+"/availability", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
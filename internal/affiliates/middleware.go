@@ -0,0 +1,39 @@
+package affiliates
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader is the request header partners send their API key in
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyMiddleware requires a valid, active API key and stores its ID in the context
+func APIKeyMiddleware(service ServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "MISSING_API_KEY", "X-API-Key header is required", nil)
+			c.Abort()
+			return
+		}
+
+		apiKey, err := service.Authenticate(key)
+		if err != nil {
+			if errors.Is(err, ErrInvalidAPIKey) {
+				utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_API_KEY", "Invalid or revoked API key", nil)
+			} else {
+				utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to authenticate API key", nil)
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("api_key_id", apiKey.ID)
+		c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package affiliates
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires the public affiliate API into the application
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the affiliates module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB, deps.Config.StorefrontURL)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "affiliates"
+}
+
+// Models returns the API keys table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.APIKey{}}
+}
+
+// RegisterAPIRoutes sets up the public affiliate API with per-key rate limiting and caching
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.service, m.authService)
+}
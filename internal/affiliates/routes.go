@@ -0,0 +1,29 @@
+package affiliates
+
+import (
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the public affiliate API and admin API key routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, service ServiceInterface, authService *auth.Service) {
+	// Key is checked first so the per-key limiter and cache only see authenticated partners
+	public := api.Group("/public")
+	public.Use(APIKeyMiddleware(service))
+	public.Use(middleware.RateLimitMiddleware(middleware.AffiliateRateLimit))
+	public.Use(middleware.CacheMiddleware(middleware.AffiliateAvailabilityCache))
+	{
+		public.GET("/products/:sku/availability", handler.GetAvailability)
+	}
+
+	admin := api.Group("/admin/api-keys")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.ListAPIKeys)
+		admin.POST("", handler.CreateAPIKey)
+		admin.DELETE("/:id", handler.RevokeAPIKey)
+	}
+}
@@ -0,0 +1,176 @@
+package affiliates
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// keyPrefix marks plaintext keys so they are recognisable in partner configs and logs
+const keyPrefix = "ak_"
+
+var (
+	ErrInvalidAPIKey   = errors.New("invalid or revoked API key")
+	ErrAPIKeyNotFound  = errors.New("API key not found")
+	ErrProductNotFound = errors.New("product not found")
+)
+
+// ServiceInterface defines the interface for the affiliate API service
+type ServiceInterface interface {
+	CreateKey(name string) (*CreatedKey, error)
+	ListKeys() ([]models.APIKey, error)
+	RevokeKey(id string) error
+	Authenticate(key string) (*models.APIKey, error)
+	GetAvailability(sku string) (*Availability, error)
+}
+
+type Service struct {
+	db            *gorm.DB
+	storefrontURL string
+}
+
+// NewService creates a new affiliate API service; storefrontURL is the base of returned product links
+func NewService(db *gorm.DB, storefrontURL string) *Service {
+	return &Service{
+		db:            db,
+		storefrontURL: strings.TrimRight(storefrontURL, "/"),
+	}
+}
+
+// CreatedKey is returned once on creation and is the only time the plaintext key is visible
+type CreatedKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// Availability is the public price and stock snapshot of a product
+type Availability struct {
+	SKU      string  `json:"sku"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Currency string  `json:"currency"`
+	InStock  bool    `json:"in_stock"`
+	URL      string  `json:"url"`
+}
+
+// hashKey returns the stored representation of a plaintext API key
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateKey issues a new API key for a partner
+func (s *Service) CreateKey(name string) (*CreatedKey, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := keyPrefix + hex.EncodeToString(raw)
+
+	apiKey := models.APIKey{
+		Name:     name,
+		Prefix:   key[:len(keyPrefix)+8],
+		KeyHash:  hashKey(key),
+		IsActive: true,
+	}
+	if err := s.db.Create(&apiKey).Error; err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return &CreatedKey{APIKey: apiKey, Key: key}, nil
+}
+
+// ListKeys returns all API keys, newest first
+func (s *Service) ListKeys() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.db.Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey deactivates an API key
+func (s *Service) RevokeKey(id string) error {
+	result := s.db.Model(&models.APIKey{}).Where("id = ?", id).Update("is_active", false)
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke API key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a plaintext key to an active API key and records its use
+func (s *Service) Authenticate(key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var apiKey models.APIKey
+	if err := s.db.Where("key_hash = ? AND is_active = ?", hashKey(key), true).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to authenticate API key: %w", err)
+	}
+
+	s.touchLastUsed(&apiKey, time.Now())
+
+	return &apiKey, nil
+}
+
+// lastUsedResolution is how stale last_used_at may get before a request refreshes it, so
+// a busy partner does not cause a write on every call
+const lastUsedResolution = time.Minute
+
+// touchLastUsed records that a key was used, at most once per lastUsedResolution. A failed write
+// is logged rather than returned since it must not lock the partner out.
+func (s *Service) touchLastUsed(apiKey *models.APIKey, now time.Time) {
+	if apiKey.LastUsedAt != nil && now.Sub(*apiKey.LastUsedAt) < lastUsedResolution {
+		return
+	}
+
+	result := s.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", apiKey.ID, now.Add(-lastUsedResolution)).
+		UpdateColumn("last_used_at", now)
+	if result.Error != nil {
+		logger.Named("affiliates").Warn("Failed to record API key use", map[string]interface{}{
+			"api_key_id": apiKey.ID,
+			"error":      result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected > 0 {
+		apiKey.LastUsedAt = &now
+	}
+}
+
+// GetAvailability returns price and stock of an active product by SKU
+func (s *Service) GetAvailability(sku string) (*Availability, error) {
+	var product models.Product
+	if err := s.db.Where("sku = ? AND is_active = ?", sku, true).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	return &Availability{
+		SKU:      product.SKU,
+		Name:     product.Name,
+		Price:    product.Price,
+		Currency: "INR",
+		InStock:  product.Inventory > 0,
+		URL:      fmt.Sprintf("%s/products/%s", s.storefrontURL, product.ID),
+	}, nil
+}
@@ -0,0 +1,100 @@
+package affiliates
+
+import (
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestDB(t *testing.T) {
+	err := database.InitializeTest(&config.Config{})
+	require.NoError(t, err)
+}
+
+func TestService_KeyLifecycle(t *testing.T) {
+	setupTestDB(t)
+	service := NewService(database.GetDB(), "https://shop.example.com/")
+
+	created, err := service.CreateKey("PriceCompare")
+	require.NoError(t, err)
+	assert.True(t, len(created.Key) > len(keyPrefix))
+	assert.Equal(t, created.Key[:len(created.Prefix)], created.Prefix)
+	assert.NotEqual(t, created.Key, created.KeyHash)
+
+	apiKey, err := service.Authenticate(created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, apiKey.ID)
+	assert.NotNil(t, apiKey.LastUsedAt)
+
+	_, err = service.Authenticate("ak_wrong")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	require.NoError(t, service.RevokeKey(created.ID))
+	_, err = service.Authenticate(created.Key)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	assert.ErrorIs(t, service.RevokeKey("missing"), ErrAPIKeyNotFound)
+}
+
+func TestService_AuthenticateThrottlesLastUsed(t *testing.T) {
+	setupTestDB(t)
+	db := database.GetDB()
+	service := NewService(db, "https://shop.example.com/")
+
+	created, err := service.CreateKey("PriceCompare")
+	require.NoError(t, err)
+
+	lastUsed := func() time.Time {
+		var key models.APIKey
+		require.NoError(t, db.First(&key, "id = ?", created.ID).Error)
+		require.NotNil(t, key.LastUsedAt)
+		return *key.LastUsedAt
+	}
+
+	_, err = service.Authenticate(created.Key)
+	require.NoError(t, err)
+	first := lastUsed()
+
+	_, err = service.Authenticate(created.Key)
+	require.NoError(t, err)
+	assert.True(t, lastUsed().Equal(first), "a second request within the resolution does not write")
+
+	stale := time.Now().Add(-2 * lastUsedResolution)
+	require.NoError(t, db.Model(&models.APIKey{}).Where("id = ?", created.ID).UpdateColumn("last_used_at", stale).Error)
+	apiKey, err := service.Authenticate(created.Key)
+	require.NoError(t, err)
+	assert.True(t, lastUsed().After(stale))
+	assert.True(t, apiKey.LastUsedAt.After(stale))
+}
+
+func TestService_GetAvailability(t *testing.T) {
+	setupTestDB(t)
+	db := database.GetDB()
+	service := NewService(db, "https://shop.example.com/")
+
+	category := models.Category{Name: "Affiliates", Slug: "affiliates", IsActive: true}
+	require.NoError(t, db.Create(&category).Error)
+	inStock := models.Product{Name: "Phone", SKU: "AFF-PHONE", Price: 499.5, Inventory: 3, IsActive: true, CategoryID: category.ID}
+	soldOut := models.Product{Name: "Case", SKU: "AFF-CASE", Price: 20, Inventory: 0, IsActive: true, CategoryID: category.ID}
+	require.NoError(t, db.Create(&inStock).Error)
+	require.NoError(t, db.Create(&soldOut).Error)
+
+	availability, err := service.GetAvailability("AFF-PHONE")
+	require.NoError(t, err)
+	assert.Equal(t, 499.5, availability.Price)
+	assert.True(t, availability.InStock)
+	assert.Equal(t, "https://shop.example.com/products/"+inStock.ID, availability.URL)
+
+	availability, err = service.GetAvailability("AFF-CASE")
+	require.NoError(t, err)
+	assert.False(t, availability.InStock)
+
+	_, err = service.GetAvailability("AFF-MISSING")
+	assert.ErrorIs(t, err, ErrProductNotFound)
+}
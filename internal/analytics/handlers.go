@@ -0,0 +1,142 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new analytics handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// GetSummary handles GET /api/admin/analytics/summary (admin only)
+func (h *Handler) GetSummary(c *gin.Context) {
+	period, ok := parseRange(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.GetSummary(c.Request.Context(), period)
+	if err != nil {
+		analyticsError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Analytics summary retrieved successfully", summary)
+}
+
+// GetRevenue handles GET /api/admin/analytics/revenue (admin only)
+func (h *Handler) GetRevenue(c *gin.Context) {
+	period, ok := parseRange(c)
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", IntervalDay)
+
+	points, err := h.service.GetRevenue(c.Request.Context(), period, interval)
+	if err != nil {
+		analyticsError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Revenue retrieved successfully", gin.H{
+		"from":     period.From.Format("2006-01-02"),
+		"to":       period.To.Format("2006-01-02"),
+		"interval": interval,
+		"revenue":  points,
+	})
+}
+
+// GetNewCustomers handles GET /api/admin/analytics/customers (admin only)
+func (h *Handler) GetNewCustomers(c *gin.Context) {
+	period, ok := parseRange(c)
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", IntervalDay)
+
+	points, err := h.service.GetNewCustomers(c.Request.Context(), period, interval)
+	if err != nil {
+		analyticsError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "New customers retrieved successfully", gin.H{
+		"from":      period.From.Format("2006-01-02"),
+		"to":        period.To.Format("2006-01-02"),
+		"interval":  interval,
+		"customers": points,
+	})
+}
+
+// GetTopProducts handles GET /api/admin/analytics/top-products (admin only)
+func (h *Handler) GetTopProducts(c *gin.Context) {
+	period, ok := parseRange(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DefaultTopProducts)))
+
+	products, err := h.service.GetTopProducts(c.Request.Context(), period, limit)
+	if err != nil {
+		analyticsError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Top products retrieved successfully", gin.H{
+		"from":     period.From.Format("2006-01-02"),
+		"to":       period.To.Format("2006-01-02"),
+		"products": products,
+	})
+}
+
+// analyticsError maps service errors to responses
+func analyticsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrRangeTooLarge):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidInterval):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_INTERVAL", err.Error(), nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "ANALYTICS_FAILED", "Failed to compute analytics", err.Error())
+	}
+}
+
+// parseRange reads from/to query parameters (YYYY-MM-DD), defaulting to the last 30 days, and writes
+// a 400 response when they are invalid
+func parseRange(c *gin.Context) (Range, bool) {
+	to := time.Now().UTC()
+	period := Range{From: to.AddDate(0, 0, -30), To: to}
+
+	for _, param := range []struct {
+		name string
+		dest *time.Time
+	}{{"from", &period.From}, {"to", &period.To}} {
+		if v := c.Query(param.name); v != "" {
+			parsed, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", fmt.Sprintf("%s must be in YYYY-MM-DD format", param.name), nil)
+				return period, false
+			}
+			*param.dest = parsed
+		}
+	}
+
+	if period.From.After(period.To) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", "from must not be after to", nil)
+		return period, false
+	}
+	return period, true
+}
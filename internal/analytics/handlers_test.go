@@ -0,0 +1,86 @@
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the analytics service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetSummary(ctx context.Context, period Range) (*Summary, error) {
+	args := m.Called(ctx, period)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Summary), args.Error(1)
+}
+
+func (m *MockService) GetRevenue(ctx context.Context, period Range, interval string) ([]RevenuePoint, error) {
+	args := m.Called(ctx, period, interval)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]RevenuePoint), args.Error(1)
+}
+
+func (m *MockService) GetNewCustomers(ctx context.Context, period Range, interval string) ([]CustomerPoint, error) {
+	args := m.Called(ctx, period, interval)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]CustomerPoint), args.Error(1)
+}
+
+func (m *MockService) GetTopProducts(ctx context.Context, period Range, limit int) ([]TopProduct, error) {
+	args := m.Called(ctx, period, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]TopProduct), args.Error(1)
+}
+
+func TestHandler_GetRevenue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := new(MockService)
+	handler := NewHandler(service)
+	r := gin.New()
+	r.GET("/revenue", handler.GetRevenue)
+	r.GET("/top-products", handler.GetTopProducts)
+
+	march := Range{From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)}
+	service.On("GetRevenue", mock.Anything, march, IntervalWeek).
+		Return([]RevenuePoint{{Period: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Orders: 2, Revenue: 170, AverageOrderValue: 85}}, nil)
+	service.On("GetRevenue", mock.Anything, march, "year").Return(nil, ErrInvalidInterval)
+	service.On("GetTopProducts", mock.Anything, march, 5).Return([]TopProduct{{ProductID: "p1", UnitsSold: 3}}, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/revenue?from=2026-03-01&to=2026-03-31&interval=week", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"averageOrderValue":85`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/revenue?from=2026-03-01&to=2026-03-31&interval=year", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_INTERVAL")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/revenue?from=2026-04-01&to=2026-03-31", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/top-products?from=2026-03-01&to=2026-03-31&limit=5", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"unitsSold":3`)
+
+	service.AssertExpectations(t)
+}
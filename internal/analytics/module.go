@@ -0,0 +1,29 @@
+package analytics
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires the admin analytics dashboard into the application
+type Module struct {
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the analytics module
+func NewModule(deps app.Deps) app.Module {
+	return &Module{handler: NewHandler(NewService(deps.DB, deps.Redis)), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "analytics"
+}
+
+// RegisterAPIRoutes sets up the analytics routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
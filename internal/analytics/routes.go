@@ -0,0 +1,21 @@
+package analytics
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin dashboard analytics routes. The funnel report under the same prefix is
+// served by the reports module.
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	analytics := api.Group("/admin/analytics")
+	analytics.Use(authService.AuthMiddleware())
+	analytics.Use(authService.AdminMiddleware())
+	{
+		analytics.GET("/summary", handler.GetSummary)
+		analytics.GET("/revenue", handler.GetRevenue)
+		analytics.GET("/customers", handler.GetNewCustomers)
+		analytics.GET("/top-products", handler.GetTopProducts)
+	}
+}
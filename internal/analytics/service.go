@@ -0,0 +1,385 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/reports"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Reporting intervals
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// ValidIntervals lists the intervals series can be bucketed by
+var ValidIntervals = map[string]bool{
+	IntervalDay:   true,
+	IntervalWeek:  true,
+	IntervalMonth: true,
+}
+
+// MaxRangeDays bounds the period one analytics query may cover
+const MaxRangeDays = 366
+
+// Top products limits
+const (
+	DefaultTopProducts = 10
+	MaxTopProducts     = 100
+)
+
+// cacheTTL is how long computed analytics are kept in Redis
+const cacheTTL = 5 * time.Minute
+
+// cacheKeyPrefix namespaces analytics entries in Redis
+const cacheKeyPrefix = "analytics:"
+
+// ErrRangeTooLarge is returned when a query spans more than MaxRangeDays
+var ErrRangeTooLarge = fmt.Errorf("date range must not exceed %d days", MaxRangeDays)
+
+// ErrInvalidInterval is returned for an interval other than day, week or month
+var ErrInvalidInterval = errors.New("interval must be day, week or month")
+
+// salesOrderStatuses are the order statuses that count as sales: paid and everything after it
+var salesOrderStatuses = []string{"paid", "processing", "shipped", "delivered"}
+
+// Range is the period of an analytics query: whole UTC days from From to To inclusive
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// Summary holds the headline figures of the dashboard for a period
+type Summary struct {
+	From              time.Time             `json:"from"`
+	To                time.Time             `json:"to"`
+	Orders            int64                 `json:"orders"`
+	Revenue           float64               `json:"revenue"`
+	AverageOrderValue float64               `json:"averageOrderValue"`
+	NewCustomers      int64                 `json:"newCustomers"`
+	Funnel            *reports.FunnelReport `json:"funnel"`
+}
+
+// RevenuePoint is the sales of one day, week or month
+type RevenuePoint struct {
+	Period            time.Time `json:"period"`
+	Orders            int64     `json:"orders"`
+	Revenue           float64   `json:"revenue"`
+	AverageOrderValue float64   `json:"averageOrderValue"`
+}
+
+// CustomerPoint is the number of customers who signed up in one day, week or month
+type CustomerPoint struct {
+	Period       time.Time `json:"period"`
+	NewCustomers int64     `json:"newCustomers"`
+}
+
+// TopProduct is a best-selling product of a period
+type TopProduct struct {
+	ProductID string  `json:"productId"`
+	Name      string  `json:"name"`
+	SKU       string  `json:"sku"`
+	Orders    int64   `json:"orders"`
+	UnitsSold int64   `json:"unitsSold"`
+	Revenue   float64 `json:"revenue"`
+}
+
+// FunnelSource reports the checkout funnel of a period
+type FunnelSource interface {
+	GetFunnel(ctx context.Context, from, to time.Time) (*reports.FunnelReport, error)
+}
+
+// ServiceInterface defines the analytics service methods
+type ServiceInterface interface {
+	GetSummary(ctx context.Context, period Range) (*Summary, error)
+	GetRevenue(ctx context.Context, period Range, interval string) ([]RevenuePoint, error)
+	GetNewCustomers(ctx context.Context, period Range, interval string) ([]CustomerPoint, error)
+	GetTopProducts(ctx context.Context, period Range, limit int) ([]TopProduct, error)
+}
+
+// Service computes the admin dashboard figures from the orders and users tables, caching them in Redis
+// when a client is configured
+type Service struct {
+	db     *gorm.DB
+	redis  *redis.Client
+	funnel FunnelSource
+}
+
+// NewService creates an analytics service; redisClient may be nil to disable caching
+func NewService(db *gorm.DB, redisClient *redis.Client) *Service {
+	return &Service{db: db, redis: redisClient, funnel: reports.NewService(db)}
+}
+
+// bounds returns the half-open time window [start, end) of the period
+func (r Range) bounds() (time.Time, time.Time, error) {
+	start, end := utils.StartOfDay(r.From), utils.StartOfDay(r.To).AddDate(0, 0, 1)
+	if end.Sub(start) > MaxRangeDays*24*time.Hour {
+		return start, end, ErrRangeTooLarge
+	}
+	return start, end, nil
+}
+
+// key identifies the period in cache keys
+func (r Range) key() string {
+	return utils.StartOfDay(r.From).Format("20060102") + "-" + utils.StartOfDay(r.To).Format("20060102")
+}
+
+// periodStart returns the start of the day, ISO week (Monday) or month t falls in
+func periodStart(t time.Time, interval string) time.Time {
+	day := utils.StartOfDay(t)
+	switch interval {
+	case IntervalWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case IntervalMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// periods lists the starts of every period from start until end, so that empty periods show up as zero
+func periods(start, end time.Time, interval string) []time.Time {
+	var starts []time.Time
+	for p := periodStart(start, interval); p.Before(end); {
+		starts = append(starts, p)
+		switch interval {
+		case IntervalWeek:
+			p = p.AddDate(0, 0, 7)
+		case IntervalMonth:
+			p = p.AddDate(0, 1, 0)
+		default:
+			p = p.AddDate(0, 0, 1)
+		}
+	}
+	return starts
+}
+
+// roundMoney rounds an amount to two decimals
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// averageOrderValue divides revenue over orders, zero without orders
+func averageOrderValue(revenue float64, orders int64) float64 {
+	if orders == 0 {
+		return 0
+	}
+	return roundMoney(revenue / float64(orders))
+}
+
+// cached loads key from Redis into dest, or runs compute to fill dest and stores the result.
+// Redis errors only cost the cache; the figures are computed from the database either way.
+func (s *Service) cached(ctx context.Context, key string, dest interface{}, compute func() error) error {
+	if s.redis == nil {
+		return compute()
+	}
+
+	key = cacheKeyPrefix + key
+	if data, err := s.redis.Get(ctx, key).Bytes(); err == nil {
+		if json.Unmarshal(data, dest) == nil {
+			return nil
+		}
+	} else if err != redis.Nil {
+		logger.Named("analytics").Warn("Failed to read analytics cache", map[string]interface{}{"key": key, "error": err.Error()})
+	}
+
+	if err := compute(); err != nil {
+		return err
+	}
+	if data, err := json.Marshal(dest); err == nil {
+		if err := s.redis.Set(ctx, key, data, cacheTTL).Err(); err != nil {
+			logger.Named("analytics").Warn("Failed to write analytics cache", map[string]interface{}{"key": key, "error": err.Error()})
+		}
+	}
+	return nil
+}
+
+// orderRow is the part of an order the revenue figures are computed from
+type orderRow struct {
+	CreatedAt time.Time
+	Total     float64
+}
+
+// salesOrders returns the sales orders placed in [start, end)
+func (s *Service) salesOrders(ctx context.Context, start, end time.Time) ([]orderRow, error) {
+	var rows []orderRow
+	if err := s.db.WithContext(ctx).Model(&models.Order{}).
+		Select("created_at", "total").
+		Where("created_at >= ? AND created_at < ? AND status IN ?", start, end, salesOrderStatuses).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	return rows, nil
+}
+
+// GetSummary returns orders, revenue, average order value, new customers and the checkout funnel of the period
+func (s *Service) GetSummary(ctx context.Context, period Range) (*Summary, error) {
+	start, end, err := period.bounds()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+	err = s.cached(ctx, "summary:"+period.key(), summary, func() error {
+		*summary = Summary{From: start, To: end.AddDate(0, 0, -1)}
+
+		orders, err := s.salesOrders(ctx, start, end)
+		if err != nil {
+			return err
+		}
+		for _, order := range orders {
+			summary.Orders++
+			summary.Revenue += order.Total
+		}
+		summary.Revenue = roundMoney(summary.Revenue)
+		summary.AverageOrderValue = averageOrderValue(summary.Revenue, summary.Orders)
+
+		if err := s.db.WithContext(ctx).Model(&models.User{}).
+			Where("role = ? AND created_at >= ? AND created_at < ?", "customer", start, end).
+			Count(&summary.NewCustomers).Error; err != nil {
+			return fmt.Errorf("failed to count new customers: %w", err)
+		}
+
+		funnel, err := s.funnel.GetFunnel(ctx, period.From, period.To)
+		if err != nil {
+			return err
+		}
+		summary.Funnel = funnel
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// GetRevenue returns orders, revenue and average order value per day, week or month of the period
+func (s *Service) GetRevenue(ctx context.Context, period Range, interval string) ([]RevenuePoint, error) {
+	if !ValidIntervals[interval] {
+		return nil, ErrInvalidInterval
+	}
+	start, end, err := period.bounds()
+	if err != nil {
+		return nil, err
+	}
+
+	var points []RevenuePoint
+	err = s.cached(ctx, "revenue:"+interval+":"+period.key(), &points, func() error {
+		orders, err := s.salesOrders(ctx, start, end)
+		if err != nil {
+			return err
+		}
+
+		starts := periods(start, end, interval)
+		index := make(map[time.Time]int, len(starts))
+		points = make([]RevenuePoint, len(starts))
+		for i, p := range starts {
+			index[p] = i
+			points[i].Period = p
+		}
+		for _, order := range orders {
+			point := &points[index[periodStart(order.CreatedAt, interval)]]
+			point.Orders++
+			point.Revenue += order.Total
+		}
+		for i := range points {
+			points[i].Revenue = roundMoney(points[i].Revenue)
+			points[i].AverageOrderValue = averageOrderValue(points[i].Revenue, points[i].Orders)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// GetNewCustomers returns the customers who signed up per day, week or month of the period
+func (s *Service) GetNewCustomers(ctx context.Context, period Range, interval string) ([]CustomerPoint, error) {
+	if !ValidIntervals[interval] {
+		return nil, ErrInvalidInterval
+	}
+	start, end, err := period.bounds()
+	if err != nil {
+		return nil, err
+	}
+
+	var points []CustomerPoint
+	err = s.cached(ctx, "customers:"+interval+":"+period.key(), &points, func() error {
+		var signups []time.Time
+		if err := s.db.WithContext(ctx).Model(&models.User{}).
+			Where("role = ? AND created_at >= ? AND created_at < ?", "customer", start, end).
+			Pluck("created_at", &signups).Error; err != nil {
+			return fmt.Errorf("failed to get new customers: %w", err)
+		}
+
+		starts := periods(start, end, interval)
+		index := make(map[time.Time]int, len(starts))
+		points = make([]CustomerPoint, len(starts))
+		for i, p := range starts {
+			index[p] = i
+			points[i].Period = p
+		}
+		for _, createdAt := range signups {
+			points[index[periodStart(createdAt, interval)]].NewCustomers++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// GetTopProducts returns the products with the most units sold in the period
+func (s *Service) GetTopProducts(ctx context.Context, period Range, limit int) ([]TopProduct, error) {
+	if limit <= 0 || limit > MaxTopProducts {
+		limit = DefaultTopProducts
+	}
+	start, end, err := period.bounds()
+	if err != nil {
+		return nil, err
+	}
+
+	var products []TopProduct
+	err = s.cached(ctx, fmt.Sprintf("top-products:%d:%s", limit, period.key()), &products, func() error {
+		products = []TopProduct{}
+		if err := s.db.WithContext(ctx).Table("order_items").
+			Select(`order_items.product_id AS product_id,
+				products.name AS name,
+				products.sku AS sku,
+				COUNT(DISTINCT order_items.order_id) AS orders,
+				SUM(order_items.quantity) AS units_sold,
+				SUM(order_items.total) AS revenue`).
+			Joins("JOIN orders ON orders.id = order_items.order_id").
+			Joins("JOIN products ON products.id = order_items.product_id").
+			Where("orders.created_at >= ? AND orders.created_at < ? AND orders.status IN ?", start, end, salesOrderStatuses).
+			Group("order_items.product_id, products.name, products.sku").
+			Order("units_sold DESC, revenue DESC").
+			Limit(limit).
+			Scan(&products).Error; err != nil {
+			return fmt.Errorf("failed to get top products: %w", err)
+		}
+		for i := range products {
+			products[i].Revenue = roundMoney(products[i].Revenue)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
@@ -0,0 +1,134 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedAnalytics creates customers and orders around Monday 2026-03-02 and returns the phone's product ID
+func seedAnalytics(t *testing.T) string {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+
+	day := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	customers := []models.User{
+		{Email: "a@example.com", Password: "hashed", FirstName: "A", LastName: "Customer", Role: "customer", CreatedAt: day},
+		{Email: "b@example.com", Password: "hashed", FirstName: "B", LastName: "Customer", Role: "customer", CreatedAt: day.AddDate(0, 0, 8)},
+		{Email: "admin@example.com", Password: "hashed", FirstName: "A", LastName: "Admin", Role: "admin", CreatedAt: day},
+	}
+	require.NoError(t, db.Create(&customers).Error)
+
+	category := models.Category{Name: "Analytics", Slug: "analytics", IsActive: true}
+	require.NoError(t, db.Create(&category).Error)
+	phone := models.Product{Name: "Phone", SKU: "AN-PHONE", Price: 100, IsActive: true, CategoryID: category.ID}
+	cover := models.Product{Name: "Cover", SKU: "AN-COVER", Price: 10, IsActive: true, CategoryID: category.ID}
+	require.NoError(t, db.Create(&phone).Error)
+	require.NoError(t, db.Create(&cover).Error)
+
+	createOrder := func(status string, at time.Time, items map[*models.Product]int) {
+		total := 0.0
+		for product, qty := range items {
+			total += product.Price * float64(qty)
+		}
+		order := models.Order{UserID: customers[0].ID, Status: status, Subtotal: total, Total: total, CreatedAt: at}
+		require.NoError(t, db.Create(&order).Error)
+		for product, qty := range items {
+			require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: product.ID, Quantity: qty, Price: product.Price, Total: product.Price * float64(qty)}).Error)
+		}
+	}
+	createOrder("delivered", day, map[*models.Product]int{&phone: 1, &cover: 2})
+	createOrder("paid", day.Add(time.Hour), map[*models.Product]int{&cover: 5})
+	createOrder("shipped", day.AddDate(0, 0, 8), map[*models.Product]int{&phone: 2})
+	createOrder("cancelled", day, map[*models.Product]int{&phone: 9})
+	createOrder("pending", day, map[*models.Product]int{&cover: 9})
+	return phone.ID
+}
+
+func TestService_Analytics(t *testing.T) {
+	phoneID := seedAnalytics(t)
+	service := NewService(database.GetDB(), nil)
+	ctx := context.Background()
+	march := Range{From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)}
+
+	t.Run("summary", func(t *testing.T) {
+		summary, err := service.GetSummary(ctx, march)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), summary.Orders)
+		assert.Equal(t, 370.0, summary.Revenue)
+		assert.Equal(t, 123.33, summary.AverageOrderValue)
+		assert.Equal(t, int64(2), summary.NewCustomers)
+		require.NotNil(t, summary.Funnel)
+		assert.Len(t, summary.Funnel.Steps, 5)
+	})
+
+	t.Run("revenue by week", func(t *testing.T) {
+		points, err := service.GetRevenue(ctx, march, IntervalWeek)
+		require.NoError(t, err)
+		// March 2026 starts on a Sunday, so the first week is the one of Monday 23 February
+		require.Len(t, points, 6)
+		assert.Equal(t, time.Date(2026, 2, 23, 0, 0, 0, 0, time.UTC), points[0].Period)
+		assert.Equal(t, RevenuePoint{Period: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Orders: 2, Revenue: 170, AverageOrderValue: 85}, points[1])
+		assert.Equal(t, RevenuePoint{Period: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), Orders: 1, Revenue: 200, AverageOrderValue: 200}, points[2])
+		assert.Zero(t, points[3].Orders)
+	})
+
+	t.Run("revenue by month and day", func(t *testing.T) {
+		points, err := service.GetRevenue(ctx, march, IntervalMonth)
+		require.NoError(t, err)
+		require.Len(t, points, 1)
+		assert.Equal(t, 370.0, points[0].Revenue)
+
+		points, err = service.GetRevenue(ctx, march, IntervalDay)
+		require.NoError(t, err)
+		assert.Len(t, points, 31)
+	})
+
+	t.Run("new customers", func(t *testing.T) {
+		points, err := service.GetNewCustomers(ctx, march, IntervalWeek)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), points[1].NewCustomers)
+		assert.Equal(t, int64(1), points[2].NewCustomers)
+	})
+
+	t.Run("top products", func(t *testing.T) {
+		products, err := service.GetTopProducts(ctx, march, 10)
+		require.NoError(t, err)
+		require.Len(t, products, 2)
+		assert.Equal(t, "Cover", products[0].Name)
+		assert.Equal(t, int64(7), products[0].UnitsSold)
+		assert.Equal(t, TopProduct{ProductID: phoneID, Name: "Phone", SKU: "AN-PHONE", Orders: 2, UnitsSold: 3, Revenue: 300}, products[1])
+
+		products, err = service.GetTopProducts(ctx, march, 1)
+		require.NoError(t, err)
+		assert.Len(t, products, 1)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := service.GetRevenue(ctx, march, "year")
+		assert.ErrorIs(t, err, ErrInvalidInterval)
+		_, err = service.GetSummary(ctx, Range{From: march.From.AddDate(-2, 0, 0), To: march.To})
+		assert.ErrorIs(t, err, ErrRangeTooLarge)
+	})
+}
+
+func TestService_CacheUnavailable(t *testing.T) {
+	seedAnalytics(t)
+	// Nothing listens here; analytics are still computed from the database
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	service := NewService(database.GetDB(), client)
+
+	points, err := service.GetRevenue(context.Background(), Range{From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)}, IntervalMonth)
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, int64(3), points[0].Orders)
+}
@@ -0,0 +1,688 @@
+package apidocs
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Access a route requires
+const (
+	AuthPublic   = "public"
+	AuthOptional = "optional" // a signed-in caller gets their own data, anyone else the guest view
+	AuthUser     = "user"
+	AuthAdmin    = "admin"
+)
+
+var authRank = map[string]int{AuthPublic: 0, AuthOptional: 1, AuthUser: 2, AuthAdmin: 3}
+
+// authMiddleware maps the auth service middleware to the access they enforce
+var authMiddleware = map[string]string{
+	"OptionalAuthMiddleware": AuthOptional,
+	"AuthMiddleware":         AuthUser,
+	"AdminMiddleware":        AuthAdmin,
+	"RequireAdminMiddleware": AuthAdmin,
+}
+
+// Annotations describe the HTTP handlers of the code base. Collect reads them from the handlers' source:
+// summaries from doc comments, query parameters, request bodies and error codes from the handler bodies,
+// and access from the middleware of the routes that serve them.
+type Annotations struct {
+	// Operations by handler, named as gin names handler functions, e.g. "module/internal/cart.(*Handler).GetCart"
+	Operations map[string]Operation `json:"operations"`
+	// Schemas of the request body types, by component name
+	Schemas map[string]Schema `json:"schemas"`
+}
+
+// Operation describes one handler
+type Operation struct {
+	Summary     string              `json:"summary"`
+	Description string              `json:"description,omitempty"`
+	Auth        string              `json:"auth,omitempty"` // the least access any route serving the handler requires
+	Query       []QueryParam        `json:"query,omitempty"`
+	Body        string              `json:"body,omitempty"`   // component name of the JSON request body
+	Upload      []string            `json:"upload,omitempty"` // multipart file fields
+	Success     int                 `json:"success,omitempty"`
+	Errors      map[string][]string `json:"errors,omitempty"` // error codes by status
+}
+
+// QueryParam is a query string parameter a handler reads
+type QueryParam struct {
+	Name    string `json:"name"`
+	Default string `json:"default,omitempty"`
+}
+
+// Schema is a JSON schema object
+type Schema map[string]interface{}
+
+// statusCodes maps the net/http status constants handlers respond with to their codes
+var statusCodes = map[string]int{
+	"StatusOK":                    200,
+	"StatusCreated":               201,
+	"StatusAccepted":              202,
+	"StatusNoContent":             204,
+	"StatusPartialContent":        206,
+	"StatusMovedPermanently":      301,
+	"StatusFound":                 302,
+	"StatusSeeOther":              303,
+	"StatusNotModified":           304,
+	"StatusTemporaryRedirect":     307,
+	"StatusBadRequest":            400,
+	"StatusUnauthorized":          401,
+	"StatusPaymentRequired":       402,
+	"StatusForbidden":             403,
+	"StatusNotFound":              404,
+	"StatusMethodNotAllowed":      405,
+	"StatusConflict":              409,
+	"StatusGone":                  410,
+	"StatusPreconditionFailed":    412,
+	"StatusRequestEntityTooLarge": 413,
+	"StatusUnsupportedMediaType":  415,
+	"StatusUnprocessableEntity":   422,
+	"StatusLocked":                423,
+	"StatusPreconditionRequired":  428,
+	"StatusTooManyRequests":       429,
+	"StatusInternalServerError":   500,
+	"StatusNotImplemented":        501,
+	"StatusBadGateway":            502,
+	"StatusServiceUnavailable":    503,
+	"StatusGatewayTimeout":        504,
+}
+
+// sourceFile is a parsed file with the import paths of its imports by local name
+type sourceFile struct {
+	pkgPath string
+	file    *ast.File
+	imports map[string]string
+}
+
+// typeDecl is a named type declared in the code base
+type typeDecl struct {
+	spec *ast.TypeSpec
+	file *sourceFile
+}
+
+type collector struct {
+	module     string
+	files      []*sourceFile
+	types      map[string]typeDecl // by import path and name, "module/internal/models.Cart"
+	schemas    map[string]Schema
+	components map[string]string // component name -> qualified type name
+	names      map[string]string // qualified type name -> component name
+	auth       map[string]string // least access by package and method, "module/internal/cart.GetCart"
+}
+
+// Collect reads the annotations of the handlers under the internal and pkg directories of the module at root
+func Collect(root string) (*Annotations, error) {
+	module, err := modulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	c := &collector{
+		module:     module,
+		types:      make(map[string]typeDecl),
+		schemas:    make(map[string]Schema),
+		components: make(map[string]string),
+		names:      make(map[string]string),
+		auth:       make(map[string]string),
+	}
+	for _, dir := range []string{"internal", "pkg"} {
+		if err := c.parseTree(root, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range c.files {
+		for _, decl := range file.file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				c.collectRoutes(file, fn.Body)
+			}
+		}
+	}
+
+	annotations := &Annotations{Operations: make(map[string]Operation), Schemas: c.schemas}
+	for _, file := range c.files {
+		for _, decl := range file.file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Body == nil || !fn.Name.IsExported() || !c.isHandler(file, fn) {
+				continue
+			}
+			annotations.Operations[handlerName(file.pkgPath, fn)] = c.operation(file, fn)
+		}
+	}
+	return annotations, nil
+}
+
+func modulePath(goMod string) (string, error) {
+	content, err := os.ReadFile(goMod)
+	if err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "module ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "module ")), nil
+		}
+	}
+	return "", fmt.Errorf("no module path in %s", goMod)
+}
+
+// parseTree parses the non-test Go files under root/dir
+func (c *collector) parseTree(root, dir string) error {
+	fset := token.NewFileSet()
+	return filepath.WalkDir(filepath.Join(root, dir), func(file string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+			return nil
+		}
+		parsed, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(file))
+		if err != nil {
+			return err
+		}
+
+		source := &sourceFile{pkgPath: c.module + "/" + filepath.ToSlash(rel), file: parsed, imports: make(map[string]string)}
+		for _, spec := range parsed.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			name := path.Base(importPath)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			source.imports[name] = importPath
+		}
+		for _, decl := range parsed.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+				for _, spec := range gen.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					c.types[source.pkgPath+"."+typeSpec.Name.Name] = typeDecl{spec: typeSpec, file: source}
+				}
+			}
+		}
+		c.files = append(c.files, source)
+		return nil
+	})
+}
+
+// handlerName names a method as gin names handler functions
+func handlerName(pkgPath string, fn *ast.FuncDecl) string {
+	receiver := fn.Recv.List[0].Type
+	if star, ok := receiver.(*ast.StarExpr); ok {
+		return pkgPath + ".(*" + identName(star.X) + ")." + fn.Name.Name
+	}
+	return pkgPath + "." + identName(receiver) + "." + fn.Name.Name
+}
+
+func identName(expr ast.Expr) string {
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// isHandler reports whether fn takes just a *gin.Context
+func (c *collector) isHandler(file *sourceFile, fn *ast.FuncDecl) bool {
+	params := fn.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) > 1 || fn.Type.Results != nil {
+		return false
+	}
+	star, ok := params[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	selector, ok := star.X.(*ast.SelectorExpr)
+	return ok && selector.Sel.Name == "Context" && file.imports[identName(selector.X)] == "github.com/gin-gonic/gin"
+}
+
+// collectRoutes records the access each route registered in body requires. Router groups start public and
+// gain the access of the auth middleware they use, as do routes of the middleware registered with them.
+func (c *collector) collectRoutes(file *sourceFile, body *ast.BlockStmt) {
+	groups := make(map[string]string)
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			if len(node.Lhs) != 1 || len(node.Rhs) != 1 {
+				return true
+			}
+			if call, ok := node.Rhs[0].(*ast.CallExpr); ok {
+				if selector, ok := call.Fun.(*ast.SelectorExpr); ok && selector.Sel.Name == "Group" {
+					groups[identName(node.Lhs[0])] = stronger(groups[identName(selector.X)], middlewareAuth(call.Args))
+				}
+			}
+		case *ast.CallExpr:
+			selector, ok := node.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			group := identName(selector.X)
+			switch selector.Sel.Name {
+			case "Use":
+				groups[group] = stronger(groups[group], middlewareAuth(node.Args))
+			case "GET", "POST", "PUT", "PATCH", "DELETE", "Any", "Handle":
+				if len(node.Args) < 2 {
+					return true
+				}
+				handler, ok := node.Args[len(node.Args)-1].(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				access := stronger(groups[group], middlewareAuth(node.Args[:len(node.Args)-1]))
+				key := file.pkgPath + "." + handler.Sel.Name
+				if current, seen := c.auth[key]; !seen || authRank[access] < authRank[current] {
+					c.auth[key] = access
+				}
+			}
+		}
+		return true
+	})
+}
+
+// middlewareAuth returns the strongest access the auth middleware among args enforces
+func middlewareAuth(args []ast.Expr) string {
+	access := AuthPublic
+	for _, arg := range args {
+		if call, ok := arg.(*ast.CallExpr); ok {
+			if selector, ok := call.Fun.(*ast.SelectorExpr); ok {
+				access = stronger(access, authMiddleware[selector.Sel.Name])
+			}
+		}
+	}
+	return access
+}
+
+func stronger(a, b string) string {
+	if a == "" {
+		a = AuthPublic
+	}
+	if authRank[b] > authRank[a] {
+		return b
+	}
+	return a
+}
+
+// pageParams are the query parameters read by utils.PageParams
+var pageParams = []QueryParam{{Name: "page", Default: "1"}, {Name: "limit", Default: "20"}}
+
+// operation reads a handler's annotations from its doc comment and body
+func (c *collector) operation(file *sourceFile, fn *ast.FuncDecl) Operation {
+	op := Operation{Auth: c.auth[file.pkgPath+"."+fn.Name.Name]}
+	op.Summary, op.Description = describe(fn.Name.Name, fn.Doc.Text())
+
+	params := fn.Type.Params.List[0].Names
+	if len(params) == 0 {
+		return op
+	}
+	ctx := params[0].Name
+	seenQuery := make(map[string]bool)
+	errors := make(map[string]map[string]bool)
+
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		receiver := identName(selector.X)
+
+		if receiver == ctx {
+			switch selector.Sel.Name {
+			case "Query", "DefaultQuery", "GetQuery", "QueryArray":
+				if name, ok := stringLiteral(call.Args, 0); ok && !seenQuery[name] {
+					seenQuery[name] = true
+					param := QueryParam{Name: name}
+					if selector.Sel.Name == "DefaultQuery" {
+						param.Default, _ = stringLiteral(call.Args, 1)
+					}
+					op.Query = append(op.Query, param)
+				}
+			case "ShouldBindJSON", "BindJSON":
+				if len(call.Args) == 1 {
+					if ref, ok := call.Args[0].(*ast.UnaryExpr); ok && ref.Op == token.AND {
+						if typ := localType(fn.Body, identName(ref.X)); typ != nil {
+							op.Body = c.component(typ, file)
+						}
+					}
+				}
+			case "FormFile":
+				if name, ok := stringLiteral(call.Args, 0); ok {
+					op.Upload = append(op.Upload, name)
+				}
+			}
+			return true
+		}
+
+		if file.imports[receiver] != c.module+"/pkg/utils" {
+			return true
+		}
+		if selector.Sel.Name == "PageParams" {
+			for _, param := range pageParams {
+				if !seenQuery[param.Name] {
+					seenQuery[param.Name] = true
+					op.Query = append(op.Query, param)
+				}
+			}
+			return true
+		}
+		if len(call.Args) < 2 {
+			return true
+		}
+		status := statusCode(call.Args[1])
+		switch selector.Sel.Name {
+		case "SuccessResponse", "PaginatedResponse", "PaginatedResponseWith":
+			if status != 0 && (op.Success == 0 || status < op.Success) {
+				op.Success = status
+			}
+		case "ErrorResponse":
+			code, ok := stringLiteral(call.Args, 2)
+			if status == 0 || !ok {
+				return true
+			}
+			key := strconv.Itoa(status)
+			if errors[key] == nil {
+				errors[key] = make(map[string]bool)
+			}
+			errors[key][code] = true
+		}
+		return true
+	})
+
+	for status, codes := range errors {
+		if op.Errors == nil {
+			op.Errors = make(map[string][]string)
+		}
+		for code := range codes {
+			op.Errors[status] = append(op.Errors[status], code)
+		}
+		sort.Strings(op.Errors[status])
+	}
+	return op
+}
+
+// describe splits a handler's doc comment into a summary and a description. Comments naming the route
+// ("GetCart handles GET /api/cart") are summarized from the handler name.
+func describe(name, doc string) (summary, description string) {
+	doc = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(doc), name+" "))
+	first, rest, _ := strings.Cut(doc, "\n")
+	rest = strings.Join(strings.Fields(rest), " ")
+	if first == "" || strings.HasPrefix(first, "handles ") {
+		return humanize(name), rest
+	}
+	summary = strings.TrimSuffix(first, ".")
+	return strings.ToUpper(summary[:1]) + summary[1:], rest
+}
+
+// humanize turns a method name into a sentence, "GetProductByID" into "Get product by ID"
+func humanize(name string) string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		boundary := i == len(runes) ||
+			(unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1])) ||
+			(unicode.IsUpper(runes[i]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))
+		if !boundary {
+			continue
+		}
+		word := string(runes[start:i])
+		if len(words) > 0 && strings.ToUpper(word) != word {
+			word = strings.ToLower(word)
+		}
+		words = append(words, word)
+		start = i
+	}
+	return strings.Join(words, " ")
+}
+
+func stringLiteral(args []ast.Expr, i int) (string, bool) {
+	if i >= len(args) {
+		return "", false
+	}
+	literal, ok := args[i].(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(literal.Value)
+	return value, err == nil
+}
+
+func statusCode(expr ast.Expr) int {
+	switch expr := expr.(type) {
+	case *ast.SelectorExpr:
+		return statusCodes[expr.Sel.Name]
+	case *ast.BasicLit:
+		code, _ := strconv.Atoi(expr.Value)
+		return code
+	}
+	return 0
+}
+
+// localType finds the declared type of a variable in body: "var name T" or "name := T{...}"
+func localType(body *ast.BlockStmt, name string) ast.Expr {
+	var typ ast.Expr
+	ast.Inspect(body, func(node ast.Node) bool {
+		if typ != nil {
+			return false
+		}
+		switch node := node.(type) {
+		case *ast.ValueSpec:
+			for _, ident := range node.Names {
+				if ident.Name == name && node.Type != nil {
+					typ = node.Type
+				}
+			}
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				if identName(lhs) != name || i >= len(node.Rhs) {
+					continue
+				}
+				if literal, ok := node.Rhs[i].(*ast.CompositeLit); ok {
+					typ = literal.Type
+				}
+			}
+		}
+		return true
+	})
+	return typ
+}
+
+// component returns the component name of a named struct type, generating its schema on first use
+func (c *collector) component(expr ast.Expr, file *sourceFile) string {
+	qualified := c.qualify(expr, file)
+	decl, ok := c.types[qualified]
+	if !ok {
+		return ""
+	}
+	if _, ok := decl.spec.Type.(*ast.StructType); !ok {
+		return ""
+	}
+	if name, ok := c.names[qualified]; ok {
+		return name
+	}
+
+	pkgPath, typeName := qualified[:strings.LastIndex(qualified, ".")], decl.spec.Name.Name
+	name := path.Base(pkgPath) + "." + typeName
+	if _, taken := c.components[name]; taken {
+		name = strings.ReplaceAll(strings.TrimPrefix(pkgPath, c.module+"/"), "/", ".") + "." + typeName
+	}
+	c.components[name] = qualified
+	c.names[qualified] = name
+	c.schemas[name] = Schema{} // placeholder for recursive references
+	c.schemas[name] = c.structSchema(decl.spec.Type.(*ast.StructType), decl.file)
+	return name
+}
+
+// qualify returns the import path and name of the type expr names, or "" for other expressions
+func (c *collector) qualify(expr ast.Expr, file *sourceFile) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return file.pkgPath + "." + expr.Name
+	case *ast.SelectorExpr:
+		if importPath, ok := file.imports[identName(expr.X)]; ok {
+			return importPath + "." + expr.Sel.Name
+		}
+	case *ast.StarExpr:
+		return c.qualify(expr.X, file)
+	}
+	return ""
+}
+
+// structSchema describes the JSON encoding of a struct
+func (c *collector) structSchema(st *ast.StructType, file *sourceFile) Schema {
+	properties := Schema{}
+	var required []string
+	for _, field := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			value, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(value)
+		}
+		jsonName, jsonOptions, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		if len(field.Names) == 0 && jsonName == "" {
+			// Embedded struct fields are encoded inline
+			if embedded, ok := c.types[c.qualify(field.Type, file)]; ok {
+				if inner, ok := embedded.spec.Type.(*ast.StructType); ok {
+					schema := c.structSchema(inner, embedded.file)
+					for name, property := range schema["properties"].(Schema) {
+						properties[name] = property
+					}
+					if inherited, ok := schema["required"].([]string); ok {
+						required = append(required, inherited...)
+					}
+				}
+			}
+			continue
+		}
+
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(jsonName)}
+		}
+		for _, ident := range names {
+			if !ident.IsExported() && len(field.Names) > 0 {
+				continue
+			}
+			name := ident.Name
+			if jsonName != "" {
+				name = jsonName
+			}
+			property := c.schema(field.Type, file, 0)
+			if doc := strings.TrimSpace(strings.Join(strings.Fields(field.Doc.Text()+" "+field.Comment.Text()), " ")); doc != "" {
+				property = withDescription(property, doc)
+			}
+			properties[name] = property
+			if strings.Contains(tag.Get("binding"), "required") && !strings.Contains(jsonOptions, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// withDescription adds a description to a schema; references cannot carry one, so they are wrapped
+func withDescription(schema Schema, description string) Schema {
+	if _, ok := schema["$ref"]; ok {
+		return Schema{"allOf": []interface{}{schema}, "description": description}
+	}
+	described := Schema{"description": description}
+	for key, value := range schema {
+		described[key] = value
+	}
+	return described
+}
+
+// externalTypes are the schemas of types from outside the code base found in request bodies
+var externalTypes = map[string]Schema{
+	"time.Time":                   {"type": "string", "format": "date-time"},
+	"time.Duration":               {"type": "integer", "description": "nanoseconds"},
+	"encoding/json.RawMessage":    {},
+	"gorm.io/gorm.DeletedAt":      {"type": "string", "format": "date-time", "nullable": true},
+	"github.com/google/uuid.UUID": {"type": "string", "format": "uuid"},
+	"github.com/gin-gonic/gin.H":  {"type": "object"},
+}
+
+var basicTypes = map[string]Schema{
+	"string":  {"type": "string"},
+	"bool":    {"type": "boolean"},
+	"int":     {"type": "integer"},
+	"int8":    {"type": "integer"},
+	"int16":   {"type": "integer"},
+	"int32":   {"type": "integer", "format": "int32"},
+	"int64":   {"type": "integer", "format": "int64"},
+	"uint":    {"type": "integer", "minimum": 0},
+	"uint8":   {"type": "integer", "minimum": 0},
+	"uint16":  {"type": "integer", "minimum": 0},
+	"uint32":  {"type": "integer", "minimum": 0},
+	"uint64":  {"type": "integer", "minimum": 0},
+	"float32": {"type": "number", "format": "float"},
+	"float64": {"type": "number", "format": "double"},
+	"any":     {},
+}
+
+// schema describes the JSON encoding of a type expression. Named structs of the code base become
+// components; other named types are described by their underlying type.
+func (c *collector) schema(expr ast.Expr, file *sourceFile, depth int) Schema {
+	if depth > 8 {
+		return Schema{}
+	}
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if basic, ok := basicTypes[expr.Name]; ok {
+			return basic
+		}
+		return c.named(file.pkgPath+"."+expr.Name, depth)
+	case *ast.SelectorExpr:
+		qualified := c.qualify(expr, file)
+		if external, ok := externalTypes[qualified]; ok {
+			return external
+		}
+		return c.named(qualified, depth)
+	case *ast.StarExpr:
+		return c.schema(expr.X, file, depth+1)
+	case *ast.ArrayType:
+		if identName(expr.Elt) == "byte" {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": c.schema(expr.Elt, file, depth+1)}
+	case *ast.MapType:
+		return Schema{"type": "object", "additionalProperties": c.schema(expr.Value, file, depth+1)}
+	case *ast.StructType:
+		return c.structSchema(expr, file)
+	}
+	return Schema{}
+}
+
+func (c *collector) named(qualified string, depth int) Schema {
+	decl, ok := c.types[qualified]
+	if !ok {
+		return Schema{}
+	}
+	if _, ok := decl.spec.Type.(*ast.StructType); ok {
+		return Schema{"$ref": "#/components/schemas/" + c.component(decl.spec.Name, decl.file)}
+	}
+	return c.schema(decl.spec.Type, decl.file, depth+1)
+}
@@ -0,0 +1,155 @@
+package apidocs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+}
+
+func TestCollect(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module shop\n\ngo 1.23\n")
+	writeFile(t, filepath.Join(root, "pkg", "utils", "response.go"), `package utils
+
+func SuccessResponse(c interface{}, status int, message string, data interface{}) {}
+func ErrorResponse(c interface{}, status int, code, message string, details interface{}) {}
+func PageParams(c interface{}) (int, int) { return 1, 20 }
+`)
+	writeFile(t, filepath.Join(root, "internal", "models", "item.go"), `package models
+
+import "time"
+
+type Item struct {
+	ID    string    `+"`json:\"id\"`"+`
+	Added time.Time `+"`json:\"added\"`"+`
+}
+`)
+	writeFile(t, filepath.Join(root, "internal", "basket", "handlers.go"), `package basket
+
+import (
+	"net/http"
+
+	"shop/internal/models"
+	"shop/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct{}
+
+// AddRequest adds an item
+type AddRequest struct {
+	// Item to add
+	Item     models.Item `+"`json:\"item\" binding:\"required\"`"+`
+	Quantity int         `+"`json:\"quantity\" binding:\"required,min=1\"`"+`
+	Note     *string     `+"`json:\"note,omitempty\"`"+`
+	internal string
+}
+
+// GetBasket handles GET /api/basket
+// Guests get their session basket.
+func (h *Handler) GetBasket(c *gin.Context) {
+	_ = c.DefaultQuery("size", "medium")
+	_, _ = utils.PageParams(c)
+	utils.SuccessResponse(c, http.StatusOK, "ok", nil)
+}
+
+// AddItem adds an item to the basket.
+func (h *Handler) AddItem(ctx *gin.Context) {
+	var req AddRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Invalid", nil)
+		return
+	}
+	utils.ErrorResponse(ctx, http.StatusConflict, "OUT_OF_STOCK", "Gone", nil)
+	utils.ErrorResponse(ctx, http.StatusBadRequest, "EMPTY_NOTE", "Empty", nil)
+	utils.SuccessResponse(ctx, http.StatusCreated, "Added", nil)
+}
+
+// helper is not a handler
+func (h *Handler) Helper(c *gin.Context, extra int) {}
+`)
+	writeFile(t, filepath.Join(root, "internal", "basket", "routes.go"), `package basket
+
+import "github.com/gin-gonic/gin"
+
+func SetupRoutes(r *gin.Engine, handler *Handler, authService interface{ AuthMiddleware() gin.HandlerFunc }) {
+	r.GET("/api/basket", handler.GetBasket)
+	admin := r.Group("/api/admin")
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("/basket", handler.GetBasket)
+		admin.POST("/items", handler.AddItem)
+	}
+	r.POST("/api/basket/items", authService.AuthMiddleware(), handler.AddItem)
+}
+`)
+
+	annotations, err := Collect(root)
+	require.NoError(t, err)
+	require.Len(t, annotations.Operations, 2)
+
+	get := annotations.Operations["shop/internal/basket.(*Handler).GetBasket"]
+	assert.Equal(t, "Get basket", get.Summary)
+	assert.Equal(t, "Guests get their session basket.", get.Description)
+	assert.Equal(t, AuthPublic, get.Auth, "the least access of the routes serving the handler")
+	assert.Equal(t, []QueryParam{{Name: "size", Default: "medium"}, {Name: "page", Default: "1"}, {Name: "limit", Default: "20"}}, get.Query)
+	assert.Equal(t, 200, get.Success)
+
+	add := annotations.Operations["shop/internal/basket.(*Handler).AddItem"]
+	assert.Equal(t, "Adds an item to the basket", add.Summary)
+	assert.Equal(t, AuthUser, add.Auth)
+	assert.Equal(t, "basket.AddRequest", add.Body)
+	assert.Equal(t, 201, add.Success)
+	assert.Equal(t, map[string][]string{"400": {"EMPTY_NOTE", "INVALID_REQUEST"}, "409": {"OUT_OF_STOCK"}}, add.Errors)
+
+	schema, err := json.Marshal(annotations.Schemas)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"basket.AddRequest": {
+			"type": "object",
+			"required": ["item", "quantity"],
+			"properties": {
+				"item": {"allOf": [{"$ref": "#/components/schemas/models.Item"}], "description": "Item to add"},
+				"quantity": {"type": "integer"},
+				"note": {"type": "string"}
+			}
+		},
+		"models.Item": {
+			"type": "object",
+			"properties": {
+				"id": {"type": "string"},
+				"added": {"type": "string", "format": "date-time"}
+			}
+		}
+	}`, string(schema))
+}
+
+// The embedded annotations must match the handlers; run go generate ./internal/apidocs after changing them
+func TestAnnotationsUpToDate(t *testing.T) {
+	collected, err := Collect(filepath.Join("..", ".."))
+	require.NoError(t, err)
+	embedded, err := LoadAnnotations()
+	require.NoError(t, err)
+
+	want, err := json.Marshal(collected)
+	require.NoError(t, err)
+	got, err := json.Marshal(embedded)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got), "annotations.json is stale; run go generate ./internal/apidocs")
+}
+
+func TestHumanize(t *testing.T) {
+	assert.Equal(t, "Get product by ID", humanize("GetProductByID"))
+	assert.Equal(t, "Get SLA report", humanize("GetSLAReport"))
+	assert.Equal(t, "Me", humanize("Me"))
+}
@@ -0,0 +1,63 @@
+package apidocs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocumentPath is where the OpenAPI document is served
+const DocumentPath = "/api-docs/openapi.json"
+
+//go:embed index.html
+var viewerHTML []byte
+
+// Module serves the OpenAPI document of every route the server registers
+type Module struct {
+	engine   *gin.Engine
+	once     sync.Once
+	document []byte
+	err      error
+}
+
+// NewModule creates the API documentation module
+func NewModule(deps app.Deps) app.Module {
+	return &Module{}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "apidocs"
+}
+
+// RegisterRoutes serves the document and a viewer for it under /api-docs. The document is built on the
+// first request, when every route has been registered.
+func (m *Module) RegisterRoutes(r *gin.Engine) {
+	m.engine = r
+	r.GET(DocumentPath, m.serveDocument)
+	r.GET("/api-docs/", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", viewerHTML)
+	})
+}
+
+func (m *Module) serveDocument(c *gin.Context) {
+	m.once.Do(func() {
+		annotations, err := LoadAnnotations()
+		if err != nil {
+			m.err = err
+			return
+		}
+		m.document, m.err = json.Marshal(Build(m.engine.Routes(), annotations))
+	})
+	if m.err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "API_DOCS_ERROR", "Failed to build the API documentation", m.err.Error())
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", m.document)
+}
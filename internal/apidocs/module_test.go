@@ -0,0 +1,94 @@
+package apidocs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/app"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModule_ServesDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	module := NewModule(app.Deps{}).(*Module)
+	module.RegisterRoutes(r)
+
+	// Routes registered after the module are documented too, once per version without the unversioned alias
+	for _, api := range app.APIGroups(r) {
+		api.GET("/orders/:id/items/*path", func(c *gin.Context) {})
+		api.PUT("/admin/orders/:id", func(c *gin.Context) {})
+	}
+	r.Static("/assets", t.TempDir())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, DocumentPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var document Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+	assert.Equal(t, "3.0.3", document.OpenAPI)
+	assert.NotContains(t, document.Paths, "/assets/{filepath}")
+	assert.Contains(t, document.Paths, DocumentPath)
+
+	assert.NotContains(t, document.Paths, "/api/orders/{id}/items/{path}")
+	items := document.Paths["/api/v1/orders/{id}/items/{path}"]["get"]
+	require.NotNil(t, items)
+	assert.Equal(t, "getOrdersIdItemsPath", items.OperationID)
+	assert.Equal(t, []string{"orders"}, items.Tags)
+	assert.Len(t, items.Parameters, 2)
+	assert.Empty(t, items.Security)
+
+	update := document.Paths["/api/v1/admin/orders/{id}"]["put"]
+	require.NotNil(t, update)
+	assert.Equal(t, []string{"admin"}, update.Tags)
+	assert.Equal(t, []map[string][]string{{"BearerAuth": {}}}, update.Security)
+	assert.Contains(t, update.Responses, "403")
+	assert.Contains(t, document.Components.Schemas, "ErrorResponse")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api-docs/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `spec-url="openapi.json"`)
+}
+
+func TestBuild_AnnotatedHandlers(t *testing.T) {
+	routes := gin.RoutesInfo{
+		{Method: http.MethodPost, Path: "/api/v1/cart/add", Handler: "shop/internal/cart.(*Handler).AddItem-fm"},
+		{Method: http.MethodGet, Path: "/api/v1/orders/:id/slip", Handler: "shop/internal/orders.(*Handler).GetSlip-fm"},
+		{Method: http.MethodGet, Path: "/api/v1/admin/orders/:id/slip", Handler: "shop/internal/orders.(*Handler).GetSlip-fm"},
+	}
+	annotations := &Annotations{
+		Operations: map[string]Operation{
+			"shop/internal/cart.(*Handler).AddItem": {
+				Summary: "Adds an item", Auth: AuthOptional, Body: "models.AddItemRequest", Success: 200,
+				Errors: map[string][]string{"400": {"INVALID_REQUEST"}},
+			},
+			"shop/internal/orders.(*Handler).GetSlip": {Summary: "Get slip", Auth: AuthUser, Query: []QueryParam{{Name: "shipmentId"}}},
+		},
+		Schemas: map[string]Schema{"models.AddItemRequest": {"type": "object"}},
+	}
+
+	document := Build(routes, annotations)
+	add := document.Paths["/api/v1/cart/add"]["post"]
+	assert.Equal(t, "addItem", add.OperationID)
+	assert.Equal(t, "Adds an item", add.Summary)
+	assert.Equal(t, []map[string][]string{{}, {"BearerAuth": {}}}, add.Security)
+	assert.Equal(t, "#/components/schemas/models.AddItemRequest", add.RequestBody.Content["application/json"].Schema["$ref"])
+	assert.Equal(t, "Bad Request: INVALID_REQUEST", add.Responses["400"].Description)
+	assert.Contains(t, document.Components.Schemas, "models.AddItemRequest")
+
+	// The admin route of a shared handler is documented as an admin operation with its own ID
+	slip := document.Paths["/api/v1/orders/{id}/slip"]["get"]
+	adminSlip := document.Paths["/api/v1/admin/orders/{id}/slip"]["get"]
+	assert.Equal(t, "getSlip2", slip.OperationID)
+	assert.Equal(t, "getSlip", adminSlip.OperationID)
+	assert.Equal(t, "Requires an admin account.", adminSlip.Description)
+	assert.Empty(t, slip.Description)
+	assert.Equal(t, "shipmentId", slip.Parameters[1].Name)
+}
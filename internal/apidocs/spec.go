@@ -0,0 +1,368 @@
+package apidocs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:generate go run ../../cmd/apidocs -root ../.. -out annotations.json
+
+// annotationsJSON holds the handler annotations collected by go generate
+//
+//go:embed annotations.json
+var annotationsJSON []byte
+
+// LoadAnnotations returns the handler annotations embedded in the binary
+func LoadAnnotations() (*Annotations, error) {
+	var annotations Annotations
+	if err := json.Unmarshal(annotationsJSON, &annotations); err != nil {
+		return nil, fmt.Errorf("failed to load API annotations: %w", err)
+	}
+	return &annotations, nil
+}
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string                               `json:"openapi"`
+	Info       Info                                 `json:"info"`
+	Tags       []Tag                                `json:"tags"`
+	Paths      map[string]map[string]*PathOperation `json:"paths"`
+	Components Components                           `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathOperation is an operation of the document
+type PathOperation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags"`
+	Security    []map[string][]string `json:"security"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required,omitempty"`
+	Schema   Schema `json:"schema"`
+}
+
+// RequestBody is the body of a request
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body in one content type
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+// Components holds the reusable schemas
+type Components struct {
+	Schemas         map[string]Schema `json:"schemas"`
+	SecuritySchemes map[string]Schema `json:"securitySchemes"`
+}
+
+// envelopeSchemas describe the response envelope of pkg/utils
+var envelopeSchemas = map[string]Schema{
+	"SuccessResponse": {
+		"type":     "object",
+		"required": []string{"success", "timestamp"},
+		"properties": Schema{
+			"success":   Schema{"type": "boolean", "example": true},
+			"message":   Schema{"type": "string"},
+			"data":      Schema{},
+			"meta":      Schema{"$ref": "#/components/schemas/Meta"},
+			"timestamp": Schema{"type": "string", "format": "date-time"},
+		},
+	},
+	"ErrorResponse": {
+		"type":     "object",
+		"required": []string{"success", "error", "timestamp"},
+		"properties": Schema{
+			"success": Schema{"type": "boolean", "example": false},
+			"error": Schema{
+				"type":     "object",
+				"required": []string{"code", "message"},
+				"properties": Schema{
+					"code":    Schema{"type": "string"},
+					"message": Schema{"type": "string"},
+					"details": Schema{},
+				},
+			},
+			"meta":      Schema{"$ref": "#/components/schemas/Meta"},
+			"timestamp": Schema{"type": "string", "format": "date-time"},
+		},
+	},
+	"Meta": {
+		"type": "object",
+		"properties": Schema{
+			"requestId":  Schema{"type": "string"},
+			"apiVersion": Schema{"type": "string"},
+			"pagination": Schema{
+				"type": "object",
+				"properties": Schema{
+					"page":        Schema{"type": "integer"},
+					"limit":       Schema{"type": "integer"},
+					"total":       Schema{"type": "integer"},
+					"totalPages":  Schema{"type": "integer"},
+					"hasNext":     Schema{"type": "boolean"},
+					"hasPrevious": Schema{"type": "boolean"},
+				},
+			},
+			"rateLimit": Schema{
+				"type": "object",
+				"properties": Schema{
+					"limit":     Schema{"type": "integer"},
+					"remaining": Schema{"type": "integer"},
+					"reset":     Schema{"type": "integer"},
+				},
+			},
+		},
+	},
+}
+
+const bearerAuth = "BearerAuth"
+
+// Build documents the routes of a router. Handlers found in annotations are described by them; other routes,
+// such as inline functions, get a summary from their method and path. Static file routes and the deprecated
+// unversioned /api aliases of versioned routes are left out.
+func Build(routes gin.RoutesInfo, annotations *Annotations) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title: "Ecommerce Website API",
+			Description: "Generated from the routes the server registers and the annotations collected from their handlers. " +
+				"Responses are wrapped in the SuccessResponse or ErrorResponse envelope; the data of a success response " +
+				"depends on the operation.",
+			Version: "1.0.0",
+		},
+		Paths: make(map[string]map[string]*PathOperation),
+		Components: Components{
+			Schemas: make(map[string]Schema),
+			SecuritySchemes: map[string]Schema{
+				bearerAuth: {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+	for name, schema := range annotations.Schemas {
+		doc.Components.Schemas[name] = schema
+	}
+	for name, schema := range envelopeSchemas {
+		doc.Components.Schemas[name] = schema
+	}
+
+	sorted := append(gin.RoutesInfo{}, routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	tags := make(map[string]bool)
+	operationIDs := make(map[string]int)
+	for _, route := range sorted {
+		if route.Method == http.MethodHead || strings.Contains(route.Handler, "createStaticHandler") {
+			continue
+		}
+		if strings.HasPrefix(route.Path, "/api/") && !utils.IsVersionedPath(route.Path) {
+			continue
+		}
+		unversioned := utils.UnversionedPath(route.Path)
+		handler := strings.TrimSuffix(route.Handler, "-fm")
+		annotation, annotated := annotations.Operations[handler]
+		if !annotated {
+			annotation = Operation{Summary: route.Method + " " + route.Path}
+		}
+
+		path, parameters := openAPIPath(route.Path)
+		tag := routeTag(unversioned)
+		tags[tag] = true
+
+		operationID := operationName(handler, route.Method, unversioned)
+		operationIDs[operationID]++
+		if n := operationIDs[operationID]; n > 1 {
+			operationID += strconv.Itoa(n)
+		}
+
+		// Admin routes may share a handler with routes of lesser access
+		if strings.HasPrefix(unversioned, "/api/admin") && authRank[annotation.Auth] < authRank[AuthAdmin] {
+			annotation.Auth = AuthAdmin
+		}
+
+		op := &PathOperation{
+			OperationID: operationID,
+			Summary:     annotation.Summary,
+			Description: annotation.Description,
+			Tags:        []string{tag},
+			Security:    security(annotation.Auth),
+			Parameters:  parameters,
+			Responses:   responses(annotation),
+		}
+		if annotation.Auth == AuthAdmin {
+			op.Description = strings.TrimSpace("Requires an admin account. " + op.Description)
+		}
+		for _, query := range annotation.Query {
+			parameter := Parameter{Name: query.Name, In: "query", Schema: Schema{"type": "string"}}
+			if query.Default != "" {
+				parameter.Schema["default"] = query.Default
+			}
+			op.Parameters = append(op.Parameters, parameter)
+		}
+		op.RequestBody = requestBody(annotation)
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*PathOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	return doc
+}
+
+// openAPIPath converts a gin path to an OpenAPI path template and its path parameters
+func openAPIPath(ginPath string) (string, []Parameter) {
+	segments := strings.Split(ginPath, "/")
+	var parameters []Parameter
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			parameters = append(parameters, Parameter{Name: name, In: "path", Required: true, Schema: Schema{"type": "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+// routeTag groups routes by the first segment after /api, with every /api/admin route under admin
+func routeTag(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if segments[0] != "api" || len(segments) < 2 {
+		return "system"
+	}
+	return strings.TrimPrefix(segments[1], ":")
+}
+
+// operationName derives an operation ID from the handler method, or from the route for inline handlers
+func operationName(handler, method, path string) string {
+	if i := strings.LastIndex(handler, ")."); i >= 0 && !strings.Contains(handler[i:], "func") {
+		method := handler[i+2:]
+		return strings.ToLower(method[:1]) + method[1:]
+	}
+	name := strings.ToLower(method)
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Trim(segment, ":*")
+		if segment == "" || segment == "api" {
+			continue
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			name += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return name
+}
+
+// security returns the security requirement of an operation with the given access
+func security(access string) []map[string][]string {
+	bearer := map[string][]string{bearerAuth: {}}
+	switch access {
+	case AuthUser, AuthAdmin:
+		return []map[string][]string{bearer}
+	case AuthOptional:
+		return []map[string][]string{{}, bearer}
+	}
+	return []map[string][]string{}
+}
+
+func responses(annotation Operation) map[string]Response {
+	success := annotation.Success
+	if success == 0 {
+		success = http.StatusOK
+	}
+	result := map[string]Response{
+		strconv.Itoa(success): {
+			Description: http.StatusText(success),
+			Content:     map[string]MediaType{"application/json": {Schema: Schema{"$ref": "#/components/schemas/SuccessResponse"}}},
+		},
+	}
+	if annotation.Auth == AuthUser || annotation.Auth == AuthAdmin {
+		result["401"] = errorResponse(http.StatusUnauthorized, nil)
+	}
+	if annotation.Auth == AuthAdmin {
+		result["403"] = errorResponse(http.StatusForbidden, nil)
+	}
+	for status, codes := range annotation.Errors {
+		code, _ := strconv.Atoi(status)
+		result[status] = errorResponse(code, codes)
+	}
+	return result
+}
+
+func errorResponse(status int, codes []string) Response {
+	description := http.StatusText(status)
+	if len(codes) > 0 {
+		description += ": " + strings.Join(codes, ", ")
+	}
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: Schema{"$ref": "#/components/schemas/ErrorResponse"}}},
+	}
+}
+
+func requestBody(annotation Operation) *RequestBody {
+	if annotation.Body != "" {
+		return &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: Schema{"$ref": "#/components/schemas/" + annotation.Body}}},
+		}
+	}
+	if len(annotation.Upload) > 0 {
+		properties := Schema{}
+		for _, field := range annotation.Upload {
+			properties[field] = Schema{"type": "string", "format": "binary"}
+		}
+		return &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{"multipart/form-data": {Schema: Schema{
+				"type": "object", "properties": properties, "required": annotation.Upload,
+			}}},
+		}
+	}
+	return nil
+}
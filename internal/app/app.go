@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/health"
+	"ecommerce-website/internal/jobs"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/outbox"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Deps are the shared dependencies every module is built from
+type Deps struct {
+	Config *config.Config
+	DB     *gorm.DB
+	ReadDB *gorm.DB // read replicas for catalog and search reads; the primary when none are configured
+	Redis  *redis.Client
+	Auth   *auth.Service
+	Jobs   *jobs.Queue        // background job queue; modules register their job handlers when created
+	Outbox *outbox.Dispatcher // delivers side effects recorded in transactions; modules register their topics when created
+}
+
+// Module is a feature of the application. Besides a unique name a module implements any of
+// RouteRegistrar, APIRouteRegistrar, MiddlewareProvider, Migrator, JobRunner, Stopper, HealthChecker and
+// DependencyProvider to plug into startup, shutdown and the health probes.
+type Module interface {
+	Name() string
+}
+
+// Factory builds a module from the shared dependencies
+type Factory func(deps Deps) Module
+
+// RouteRegistrar is a module that serves HTTP routes outside the API, such as files and documentation
+type RouteRegistrar interface {
+	RegisterRoutes(r *gin.Engine)
+}
+
+// APIRouteRegistrar is a module that serves API routes. RegisterAPIRoutes is called once per API group
+// with paths relative to the group, e.g. "/orders" for /api/v1/orders; a module that changes a route
+// incompatibly registers the new behavior only for the versions that have it.
+type APIRouteRegistrar interface {
+	RegisterAPIRoutes(api *gin.RouterGroup, version string)
+}
+
+// APIGroup is the router group of one API version
+type APIGroup struct {
+	Version string
+	*gin.RouterGroup
+}
+
+// APIGroups returns a group per API version under /api/<version>, followed by the unversioned /api
+// prefix that keeps serving the stable version to clients from before versioning. Responses under the
+// unversioned prefix are marked deprecated and link to their versioned path.
+func APIGroups(r *gin.Engine) []APIGroup {
+	groups := make([]APIGroup, 0, len(utils.APIVersions)+1)
+	for _, version := range utils.APIVersions {
+		groups = append(groups, APIGroup{Version: version, RouterGroup: r.Group("/api/" + version)})
+	}
+	return append(groups, APIGroup{Version: utils.APIVersion, RouterGroup: r.Group("/api", deprecatedPrefix)})
+}
+
+// deprecatedPrefix points requests to the unversioned prefix at the stable version
+func deprecatedPrefix(c *gin.Context) {
+	successor := "/api/" + utils.APIVersion + strings.TrimPrefix(c.Request.URL.Path, "/api")
+	c.Header("Deprecation", "true")
+	c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+	c.Next()
+}
+
+// MiddlewareProvider is a module that adds global middleware. Middleware is installed in module
+// registration order, before any module routes are registered.
+type MiddlewareProvider interface {
+	Middleware() []gin.HandlerFunc
+}
+
+// Migrator is a module that owns database tables. Production schemas come from the SQL files in
+// migrations/, so a new or changed model also needs a migration there; the models are auto-migrated
+// for test databases. AutoMigrate is idempotent, so tables that are also part of the core schema may
+// be listed.
+type Migrator interface {
+	Models() []interface{}
+}
+
+// JobRunner is a module with background jobs; jobs stop when ctx is cancelled
+type JobRunner interface {
+	StartJobs(ctx context.Context)
+}
+
+// DependencyProvider is a module relying on an external service. Its checks join the readiness probe.
+type DependencyProvider interface {
+	Dependencies() []health.Check
+}
+
+// Stopper is a module whose background work must finish before the process exits. Stop is called after
+// the context given to StartJobs is cancelled and returns once the work is done or ctx expires.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// HealthChecker is a module that reports its own health
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// App is the registry of application modules
+type App struct {
+	deps    Deps
+	modules []Module
+	names   map[string]bool
+}
+
+// New creates an empty application around the shared dependencies. Without read replicas ReadDB is DB.
+func New(deps Deps) *App {
+	if deps.ReadDB == nil {
+		deps.ReadDB = deps.DB
+	}
+	return &App{deps: deps, names: make(map[string]bool)}
+}
+
+// Register builds and adds modules in order; module names must be unique
+func (a *App) Register(factories ...Factory) error {
+	for _, factory := range factories {
+		module := factory(a.deps)
+		if a.names[module.Name()] {
+			return fmt.Errorf("module %q registered twice", module.Name())
+		}
+		a.names[module.Name()] = true
+		a.modules = append(a.modules, module)
+	}
+	return nil
+}
+
+// Migrate creates or updates the tables of every Migrator module with AutoMigrate, for test databases
+func (a *App) Migrate(db *gorm.DB) error {
+	for _, module := range a.modules {
+		migrator, ok := module.(Migrator)
+		if !ok {
+			continue
+		}
+		if err := db.AutoMigrate(migrator.Models()...); err != nil {
+			return fmt.Errorf("failed to migrate module %s: %w", module.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Mount installs module middleware and then module routes on the router, API routes in every API group
+func (a *App) Mount(r *gin.Engine) {
+	for _, module := range a.modules {
+		if provider, ok := module.(MiddlewareProvider); ok {
+			r.Use(provider.Middleware()...)
+		}
+	}
+	for _, module := range a.modules {
+		if registrar, ok := module.(RouteRegistrar); ok {
+			registrar.RegisterRoutes(r)
+		}
+	}
+	groups := APIGroups(r)
+	for _, module := range a.modules {
+		registrar, ok := module.(APIRouteRegistrar)
+		if !ok {
+			continue
+		}
+		for _, group := range groups {
+			registrar.RegisterAPIRoutes(group.RouterGroup, group.Version)
+		}
+	}
+}
+
+// StartJobs starts the background jobs of every JobRunner module
+func (a *App) StartJobs(ctx context.Context) {
+	for _, module := range a.modules {
+		if runner, ok := module.(JobRunner); ok {
+			logger.Named("app").Info("Starting module jobs", map[string]interface{}{"module": module.Name()})
+			runner.StartJobs(ctx)
+		}
+	}
+}
+
+// Stop waits for every Stopper module, in reverse registration order, and returns the errors of those that
+// did not finish in time
+func (a *App) Stop(ctx context.Context) error {
+	var errs []error
+	for i := len(a.modules) - 1; i >= 0; i-- {
+		stopper, ok := a.modules[i].(Stopper)
+		if !ok {
+			continue
+		}
+		if err := stopper.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("module %s: %w", a.modules[i].Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Dependencies returns the dependency checks of every DependencyProvider module
+func (a *App) Dependencies() []health.Check {
+	var checks []health.Check
+	for _, module := range a.modules {
+		if provider, ok := module.(DependencyProvider); ok {
+			checks = append(checks, provider.Dependencies()...)
+		}
+	}
+	return checks
+}
+
+// Health runs every module health check and returns "ok" or the error per module name,
+// along with whether all checks passed
+func (a *App) Health(ctx context.Context) (map[string]string, bool) {
+	results := make(map[string]string)
+	healthy := true
+	for _, module := range a.modules {
+		checker, ok := module.(HealthChecker)
+		if !ok {
+			continue
+		}
+		if err := checker.HealthCheck(ctx); err != nil {
+			results[module.Name()] = err.Error()
+			healthy = false
+			continue
+		}
+		results[module.Name()] = "ok"
+	}
+	return results, healthy
+}
+
+// Names returns the registered module names, sorted
+func (a *App) Names() []string {
+	names := make([]string, 0, len(a.modules))
+	for _, module := range a.modules {
+		names = append(names, module.Name())
+	}
+	sort.Strings(names)
+	return names
+}
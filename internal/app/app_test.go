@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+type testModule struct {
+	name    string
+	trace   *[]string
+	healthy bool
+	started bool
+}
+
+func (m *testModule) Name() string {
+	return m.name
+}
+
+func (m *testModule) Middleware() []gin.HandlerFunc {
+	return []gin.HandlerFunc{func(c *gin.Context) {
+		*m.trace = append(*m.trace, m.name)
+		c.Next()
+	}}
+}
+
+func (m *testModule) RegisterRoutes(r *gin.Engine) {
+	r.GET("/"+m.name, func(c *gin.Context) { c.String(http.StatusOK, m.name) })
+}
+
+func (m *testModule) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	api.GET("/"+m.name, func(c *gin.Context) { c.String(http.StatusOK, m.name+" "+version) })
+}
+
+func (m *testModule) Models() []interface{} {
+	return []interface{}{&widget{}}
+}
+
+func (m *testModule) StartJobs(ctx context.Context) {
+	m.started = true
+}
+
+func (m *testModule) Stop(ctx context.Context) error {
+	*m.trace = append(*m.trace, "stop "+m.name)
+	if !m.healthy {
+		return errors.New("still running")
+	}
+	return nil
+}
+
+func (m *testModule) HealthCheck(ctx context.Context) error {
+	if !m.healthy {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+type namedModule string
+
+func (m namedModule) Name() string {
+	return string(m)
+}
+
+func TestApp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var trace []string
+	first := &testModule{name: "first", trace: &trace, healthy: true}
+	second := &testModule{name: "second", trace: &trace}
+
+	application := New(Deps{})
+	require.NoError(t, application.Register(
+		func(Deps) Module { return first },
+		func(Deps) Module { return second },
+		func(Deps) Module { return namedModule("plain") },
+	))
+	assert.Equal(t, []string{"first", "plain", "second"}, application.Names())
+
+	t.Run("rejects duplicate names", func(t *testing.T) {
+		err := application.Register(func(Deps) Module { return namedModule("first") })
+		assert.Error(t, err)
+	})
+
+	t.Run("mounts middleware in registration order", func(t *testing.T) {
+		r := gin.New()
+		application.Mount(r)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/second", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "second", w.Body.String())
+		assert.Equal(t, []string{"first", "second"}, trace)
+	})
+
+	t.Run("mounts API routes in every version", func(t *testing.T) {
+		r := gin.New()
+		application.Mount(r)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/first", nil))
+		assert.Equal(t, "first v1", w.Body.String())
+		assert.Empty(t, w.Header().Get("Deprecation"))
+
+		// The unversioned prefix serves the stable version and points at it
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/first", nil))
+		assert.Equal(t, "first v1", w.Body.String())
+		assert.Equal(t, "true", w.Header().Get("Deprecation"))
+		assert.Equal(t, `</api/v1/first>; rel="successor-version"`, w.Header().Get("Link"))
+	})
+
+	t.Run("migrates module models", func(t *testing.T) {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+		require.NoError(t, err)
+		require.NoError(t, application.Migrate(db))
+		assert.True(t, db.Migrator().HasTable(&widget{}))
+	})
+
+	t.Run("starts jobs and aggregates health", func(t *testing.T) {
+		application.StartJobs(context.Background())
+		assert.True(t, first.started)
+		assert.True(t, second.started)
+
+		results, healthy := application.Health(context.Background())
+		assert.False(t, healthy)
+		assert.Equal(t, map[string]string{"first": "ok", "second": "unavailable"}, results)
+	})
+
+	t.Run("stops modules in reverse order", func(t *testing.T) {
+		trace = nil
+		err := application.Stop(context.Background())
+		assert.ErrorContains(t, err, "module second: still running")
+		assert.Equal(t, []string{"stop second", "stop first"}, trace)
+	})
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new audit handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// ListLogs handles GET /api/admin/audit-logs (admin only). Entries can be filtered by actorId, action,
+// resourceType, resourceId and method; from and to are inclusive days in YYYY-MM-DD format.
+func (h *Handler) ListLogs(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+	query := Query{
+		ActorID:      c.Query("actorId"),
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resourceType"),
+		ResourceID:   c.Query("resourceId"),
+		Method:       strings.ToUpper(c.Query("method")),
+		Page:         page,
+		Limit:        limit,
+	}
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+		days int
+	}{{"from", &query.From, 0}, {"to", &query.To, 1}} {
+		if v := c.Query(param.name); v != "" {
+			parsed, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", fmt.Sprintf("%s must be in YYYY-MM-DD format", param.name), nil)
+				return
+			}
+			parsed = parsed.AddDate(0, 0, param.days)
+			*param.dest = &parsed
+		}
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", "from must not be after to", nil)
+		return
+	}
+
+	logs, total, err := h.service.List(c.Request.Context(), query)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_AUDIT_LOGS_FAILED", "Failed to get audit logs", err.Error())
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Audit logs retrieved successfully", "auditLogs", logs, utils.NewPagination(page, limit, total))
+}
+
+// GetLog handles GET /api/admin/audit-logs/:id (admin only)
+func (h *Handler) GetLog(c *gin.Context) {
+	entry, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrLogNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "AUDIT_LOG_NOT_FOUND", "Audit log entry not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_AUDIT_LOG_FAILED", "Failed to get audit log entry", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Audit log entry retrieved successfully", entry)
+}
@@ -0,0 +1,249 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxPayloadSize caps the request body kept in an audit entry; larger bodies are recorded as truncated
+const maxPayloadSize = 64 << 10
+
+// adminPrefix is the admin API, whose mutations are audited
+const adminPrefix = "/api/admin/"
+
+// redactedKeys are payload and snapshot fields never written to the audit log, matched case-insensitively
+// as substrings of the key
+var redactedKeys = []string{"password", "secret", "token", "otp", "totp", "cvv", "cardnumber", "apikey"}
+
+// ignoredFields change on every write and would only add noise to diffs
+var ignoredFields = map[string]bool{"updatedAt": true, "version": true}
+
+var (
+	resourcesMu sync.RWMutex
+	resources   = map[string]func() interface{}{
+		"products":  func() interface{} { return &models.Product{} },
+		"orders":    func() interface{} { return &models.Order{} },
+		"customers": func() interface{} { return &models.User{} },
+		"users":     func() interface{} { return &models.User{} },
+	}
+)
+
+// RegisterResource lets the audit log diff mutations of /api/admin/<name>/:id routes. newModel returns an
+// empty model the row is loaded into by ID before and after the mutation.
+func RegisterResource(name string, newModel func() interface{}) {
+	resourcesMu.Lock()
+	defer resourcesMu.Unlock()
+	resources[name] = newModel
+}
+
+// mutating reports whether a request method changes state
+func mutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// Middleware records every state-changing admin API request made by an admin, whether it succeeded or
+// not. The actor comes from the auth middleware of the route, so entries are written after the handler
+// ran. Mutations of a resource known to RegisterResource also record the fields they changed.
+func Middleware(service ServiceInterface, db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := utils.UnversionedPath(c.FullPath())
+		if !mutating(c.Request.Method) || !strings.HasPrefix(route, adminPrefix) {
+			c.Next()
+			return
+		}
+
+		resourceType, resourceID, action := describeRoute(c, route)
+		payload := readPayload(c)
+
+		var load func() map[string]interface{}
+		if newModel := resourceModel(resourceType); newModel != nil && resourceID != "" {
+			load = func() map[string]interface{} {
+				return snapshot(c.Request.Context(), db, newModel(), resourceID)
+			}
+		}
+		var before map[string]interface{}
+		if load != nil {
+			before = load()
+		}
+
+		c.Next()
+
+		if c.GetString("user_role") != "admin" {
+			return
+		}
+		entry := &models.AuditLog{
+			ActorID:      c.GetString("user_id"),
+			ActorEmail:   c.GetString("user_email"),
+			ActorRole:    c.GetString("admin_role"),
+			IP:           c.ClientIP(),
+			UserAgent:    c.Request.UserAgent(),
+			RequestID:    c.GetString("request_id"),
+			Method:       c.Request.Method,
+			Route:        route,
+			Path:         c.Request.URL.Path,
+			Action:       action,
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			Status:       c.Writer.Status(),
+			Payload:      payload,
+		}
+		if load != nil {
+			entry.Changes = diff(before, load())
+		}
+
+		if err := service.Record(context.WithoutCancel(c.Request.Context()), entry); err != nil {
+			logger.Named("audit").Error("Failed to record admin action", err, map[string]interface{}{
+				"actor_id": entry.ActorID,
+				"action":   entry.Action,
+				"path":     entry.Path,
+			})
+		}
+	}
+}
+
+// describeRoute derives the resource and action of a route: /api/admin/orders/:id/refund is a refund of
+// the order in the id parameter, POST /api/admin/products a product creation
+func describeRoute(c *gin.Context, route string) (resourceType, resourceID, action string) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(route, adminPrefix), "/"), "/")
+	resourceType = segments[0]
+
+	var subject []string
+	for i, segment := range segments[1:] {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			if i == 0 {
+				resourceID = c.Param(segment[1:])
+			}
+			continue
+		}
+		subject = append(subject, segment)
+	}
+	if len(subject) == 0 {
+		switch c.Request.Method {
+		case http.MethodPost:
+			subject = []string{"create"}
+		case http.MethodDelete:
+			subject = []string{"delete"}
+		default:
+			subject = []string{"update"}
+		}
+	}
+	return resourceType, resourceID, strings.Join(append([]string{resourceType}, subject...), ".")
+}
+
+func resourceModel(name string) func() interface{} {
+	resourcesMu.RLock()
+	defer resourcesMu.RUnlock()
+	return resources[name]
+}
+
+// readPayload returns the JSON request body with secrets redacted, leaving the body for the handler.
+// Other bodies, such as file uploads, are noted by content type only.
+func readPayload(c *gin.Context) models.JSONB {
+	if c.Request.Body == nil || c.Request.ContentLength == 0 {
+		return nil
+	}
+	contentType := c.ContentType()
+	if contentType != gin.MIMEJSON {
+		return models.JSONB{"contentType": contentType}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPayloadSize+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil {
+		return nil
+	}
+	if len(body) > maxPayloadSize {
+		return models.JSONB{"truncated": true, "size": c.Request.ContentLength}
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return models.JSONB{"invalidJson": true}
+	}
+	if object, ok := redact(value).(map[string]interface{}); ok {
+		return object
+	}
+	return models.JSONB{"body": redact(value)}
+}
+
+// redact replaces sensitive values in a decoded JSON value
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if sensitive(key) {
+				v[key] = "[REDACTED]"
+				continue
+			}
+			v[key] = redact(nested)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = redact(nested)
+		}
+	}
+	return value
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(strings.ReplaceAll(key, "_", ""))
+	for _, redacted := range redactedKeys {
+		if strings.Contains(key, redacted) {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot loads a resource row as its JSON representation, or nil when it does not exist
+func snapshot(ctx context.Context, db *gorm.DB, model interface{}, id string) map[string]interface{} {
+	if err := db.WithContext(ctx).First(model, "id = ?", id).Error; err != nil {
+		return nil
+	}
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return redact(fields).(map[string]interface{})
+}
+
+// diff lists the fields that differ between two snapshots. A resource created or deleted by the request
+// has every field change from or to null. It returns nil when nothing changed.
+func diff(before, after map[string]interface{}) models.JSONB {
+	changes := models.JSONB{}
+	for key, from := range before {
+		if to, ok := after[key]; (!ok || !reflect.DeepEqual(from, to)) && !ignoredFields[key] {
+			changes[key] = map[string]interface{}{"from": from, "to": after[key]}
+		}
+	}
+	for key, to := range after {
+		if _, ok := before[key]; !ok && !ignoredFields[key] {
+			changes[key] = map[string]interface{}{"from": nil, "to": to}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, service := setupAuditTest(t)
+	product := &models.Product{Name: "Phone", Price: 100, SKU: "PHONE-1", Inventory: 5, IsActive: true, CategoryID: "c1"}
+	require.NoError(t, db.Create(product).Error)
+
+	role := "admin"
+	r := gin.New()
+	r.Use(Middleware(service, db))
+	admin := r.Group("/api/v1/admin", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_email", "admin@example.com")
+		c.Set("user_role", role)
+	})
+	admin.PUT("/products/:id", func(c *gin.Context) {
+		var req struct {
+			Price float64 `json:"price"`
+		}
+		require.NoError(t, c.ShouldBindJSON(&req))
+		require.NoError(t, db.Model(&models.Product{}).Where("id = ?", c.Param("id")).Update("price", req.Price).Error)
+		c.Status(http.StatusOK)
+	})
+	admin.POST("/orders/:id/refund", func(c *gin.Context) {
+		c.Status(http.StatusBadRequest)
+	})
+	admin.GET("/products/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(method, path, body string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "admin-console")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}
+	entries := func() []models.AuditLog {
+		var logs []models.AuditLog
+		require.NoError(t, db.Order("created_at").Find(&logs).Error)
+		return logs
+	}
+
+	t.Run("records the changes of an update", func(t *testing.T) {
+		send(http.MethodPut, "/api/v1/admin/products/"+product.ID, `{"price": 120, "apiToken": "abc"}`)
+
+		logs := entries()
+		require.Len(t, logs, 1)
+		entry := logs[0]
+		assert.Equal(t, "admin-1", entry.ActorID)
+		assert.Equal(t, "admin-console", entry.UserAgent)
+		assert.Equal(t, "products.update", entry.Action)
+		assert.Equal(t, "products", entry.ResourceType)
+		assert.Equal(t, product.ID, entry.ResourceID)
+		assert.Equal(t, "/api/admin/products/:id", entry.Route)
+		assert.Equal(t, http.StatusOK, entry.Status)
+		assert.Equal(t, "[REDACTED]", entry.Payload["apiToken"])
+		assert.Equal(t, map[string]interface{}{"from": float64(100), "to": float64(120)}, entry.Changes["price"])
+		assert.NotContains(t, entry.Changes, "updatedAt")
+		assert.NotContains(t, entry.Changes, "version")
+		assert.Len(t, entry.Changes, 1)
+	})
+
+	t.Run("records failed actions without changes", func(t *testing.T) {
+		send(http.MethodPost, "/api/v1/admin/orders/o1/refund", `{"amount": 10}`)
+
+		logs := entries()
+		require.Len(t, logs, 2)
+		assert.Equal(t, "orders.refund", logs[1].Action)
+		assert.Equal(t, http.StatusBadRequest, logs[1].Status)
+		assert.Nil(t, logs[1].Changes)
+	})
+
+	t.Run("skips reads and non-admins", func(t *testing.T) {
+		send(http.MethodGet, "/api/v1/admin/products/"+product.ID, "")
+		role = "customer"
+		send(http.MethodPut, "/api/v1/admin/products/"+product.ID, `{"price": 90}`)
+		role = "admin"
+
+		assert.Len(t, entries(), 2)
+	})
+}
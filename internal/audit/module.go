@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Module records admin mutations and serves the audit log to admins
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+	db          *gorm.DB
+}
+
+// NewModule creates the audit module. It should be registered before the modules whose admin routes it
+// audits so its middleware wraps them.
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth, db: deps.DB}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "audit"
+}
+
+// Models returns the audit log table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.AuditLog{}}
+}
+
+// Middleware records admin mutations
+func (m *Module) Middleware() []gin.HandlerFunc {
+	return []gin.HandlerFunc{Middleware(m.service, m.db)}
+}
+
+// RegisterAPIRoutes sets up the admin audit log routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
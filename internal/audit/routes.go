@@ -0,0 +1,18 @@
+package audit
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin audit log routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin/audit-logs")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.ListLogs)
+		admin.GET("/:id", handler.GetLog)
+	}
+}
@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrLogNotFound is returned for an unknown audit log entry
+var ErrLogNotFound = errors.New("audit log entry not found")
+
+// Query filters the audit log. From and To bound the time of the entries, To exclusive.
+type Query struct {
+	ActorID      string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Method       string
+	From         *time.Time
+	To           *time.Time
+	Page         int
+	Limit        int
+}
+
+// ServiceInterface defines the audit log operations used by the middleware and handlers
+type ServiceInterface interface {
+	Record(ctx context.Context, entry *models.AuditLog) error
+	List(ctx context.Context, query Query) ([]models.AuditLog, int64, error)
+	Get(ctx context.Context, id string) (*models.AuditLog, error)
+}
+
+// Service stores and queries the audit log
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new audit service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Record stores an audit log entry
+func (s *Service) Record(ctx context.Context, entry *models.AuditLog) error {
+	if err := s.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to record audit log: %w", err)
+	}
+	return nil
+}
+
+// List returns the entries matching query, newest first
+func (s *Service) List(ctx context.Context, query Query) ([]models.AuditLog, int64, error) {
+	db := s.db.WithContext(ctx).Model(&models.AuditLog{})
+	if query.ActorID != "" {
+		db = db.Where("actor_id = ?", query.ActorID)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.ResourceType != "" {
+		db = db.Where("resource_type = ?", query.ResourceType)
+	}
+	if query.ResourceID != "" {
+		db = db.Where("resource_id = ?", query.ResourceID)
+	}
+	if query.Method != "" {
+		db = db.Where("method = ?", query.Method)
+	}
+	if query.From != nil {
+		db = db.Where("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("created_at < ?", *query.To)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+	logs := []models.AuditLog{}
+	if err := db.Order("created_at DESC").Offset((query.Page - 1) * query.Limit).Limit(query.Limit).Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+	return logs, total, nil
+}
+
+// Get returns one audit log entry
+func (s *Service) Get(ctx context.Context, id string) (*models.AuditLog, error) {
+	var entry models.AuditLog
+	if err := s.db.WithContext(ctx).First(&entry, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLogNotFound
+		}
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	return &entry, nil
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAuditTest(t *testing.T) (*gorm.DB, *Service) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}, &models.AuditLog{}))
+	return db, NewService(db)
+}
+
+func TestService_ListAndGet(t *testing.T) {
+	_, service := setupAuditTest(t)
+	ctx := context.Background()
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	entries := []*models.AuditLog{
+		{ActorID: "admin-1", Method: "PUT", Route: "/api/admin/products/:id", Path: "/api/v1/admin/products/p1", Action: "products.update", ResourceType: "products", ResourceID: "p1", CreatedAt: now.AddDate(0, 0, -2)},
+		{ActorID: "admin-1", Method: "POST", Route: "/api/admin/orders/:id/refund", Path: "/api/v1/admin/orders/o1/refund", Action: "orders.refund", ResourceType: "orders", ResourceID: "o1", CreatedAt: now.AddDate(0, 0, -1)},
+		{ActorID: "admin-2", Method: "DELETE", Route: "/api/admin/products/:id", Path: "/api/v1/admin/products/p1", Action: "products.delete", ResourceType: "products", ResourceID: "p1", CreatedAt: now},
+	}
+	for _, entry := range entries {
+		require.NoError(t, service.Record(ctx, entry))
+	}
+
+	t.Run("newest first", func(t *testing.T) {
+		logs, total, err := service.List(ctx, Query{Page: 1, Limit: 2})
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, total)
+		require.Len(t, logs, 2)
+		assert.Equal(t, "products.delete", logs[0].Action)
+		assert.Equal(t, "orders.refund", logs[1].Action)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		logs, total, err := service.List(ctx, Query{ResourceType: "products", ResourceID: "p1", ActorID: "admin-1", Page: 1, Limit: 20})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		assert.Equal(t, "products.update", logs[0].Action)
+
+		from, to := now.AddDate(0, 0, -1), now
+		logs, _, err = service.List(ctx, Query{From: &from, To: &to, Page: 1, Limit: 20})
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "orders.refund", logs[0].Action)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		entry, err := service.Get(ctx, entries[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "o1", entry.ResourceID)
+
+		_, err = service.Get(ctx, "missing")
+		assert.ErrorIs(t, err, ErrLogNotFound)
+	})
+}
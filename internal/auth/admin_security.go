@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/monitoring"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	customerAccessTokenTTL  = 15 * time.Minute
+	customerRefreshTokenTTL = 7 * 24 * time.Hour
+	defaultAdminAccessTTL   = 5 * time.Minute
+	defaultAdminRefreshTTL  = 8 * time.Hour
+)
+
+var (
+	ErrOTPRequired       = errors.New("two-factor code is required")
+	ErrInvalidOTP        = errors.New("invalid two-factor code")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not set up for this admin account")
+	ErrMFANotStarted     = errors.New("two-factor setup has not been started")
+)
+
+// TOTPSetup is what an authenticator app needs to add the account
+type TOTPSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+}
+
+// EnableTOTPRequest confirms two-factor setup with a code from the authenticator app
+type EnableTOTPRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// tokenTTLs returns the access and refresh token lifetimes of a role; admin tokens live shorter
+func (s *Service) tokenTTLs(role string) (time.Duration, time.Duration) {
+	if role != "admin" {
+		return customerAccessTokenTTL, customerRefreshTokenTTL
+	}
+	access, refresh := defaultAdminAccessTTL, defaultAdminRefreshTTL
+	if s.config.AdminAccessTokenTTLMinutes > 0 {
+		access = time.Duration(s.config.AdminAccessTokenTTLMinutes) * time.Minute
+	}
+	if s.config.AdminRefreshTokenTTLHours > 0 {
+		refresh = time.Duration(s.config.AdminRefreshTokenTTLHours) * time.Hour
+	}
+	return access, refresh
+}
+
+// checkOTP verifies a code from a user's authenticator app and records its time step. A code stays valid for
+// up to totpSkew steps, so the step is claimed in the update itself: a code, or one from an earlier step, is
+// accepted only once even by concurrent requests.
+func (s *Service) checkOTP(ctx context.Context, user *models.User, code string) error {
+	if code == "" {
+		return ErrOTPRequired
+	}
+	if user.TOTPSecret == nil {
+		return ErrInvalidOTP
+	}
+	step, ok := verifyTOTP(*user.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrInvalidOTP
+	}
+	result := s.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)", user.ID, step).
+		UpdateColumn("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidOTP
+	}
+	user.TOTPLastStep = &step
+	return nil
+}
+
+// checkEnvAdminOTP verifies a code of the environment admin's TOTP secret. That admin has no account row, so
+// the last accepted step is kept in memory, which stops replays against this instance.
+func (s *Service) checkEnvAdminOTP(code string) error {
+	if code == "" {
+		return ErrOTPRequired
+	}
+	step, ok := verifyTOTP(s.config.AdminTOTPSecret, code, time.Now())
+	if !ok {
+		return ErrInvalidOTP
+	}
+	s.envAdminOTPMu.Lock()
+	defer s.envAdminOTPMu.Unlock()
+	if step <= s.envAdminOTPStep {
+		return ErrInvalidOTP
+	}
+	s.envAdminOTPStep = step
+	return nil
+}
+
+// SetupTOTP starts two-factor setup with a new secret; it takes effect once confirmed with EnableTOTP
+func (s *Service) SetupTOTP(ctx context.Context, userID string) (*TOTPSetup, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Model(&user).Update("totp_secret", secret).Error; err != nil {
+		return nil, err
+	}
+	return &TOTPSetup{Secret: secret, OTPAuthURL: totpURL(secret, user.Email)}, nil
+}
+
+// EnableTOTP turns on two-factor authentication after the user proved their app produces valid codes
+func (s *Service) EnableTOTP(ctx context.Context, userID, code string) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if user.TOTPEnabled {
+		return ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == nil || *user.TOTPSecret == "" {
+		return ErrMFANotStarted
+	}
+	if err := s.checkOTP(ctx, &user, code); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&user).Update("totp_enabled", true).Error
+}
+
+// VerifyTOTP checks a code from the user's authenticator app and returns tokens marked as issued after a second
+// factor. A pending setup is completed by the first valid code, so the user need not sign in again; once two-factor
+// authentication is on, it steps up a session whose tokens were issued without the second factor.
+func (s *Service) VerifyTOTP(ctx context.Context, userID, code string) (*TokenPair, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		if err := s.EnableTOTP(ctx, userID, code); err != nil {
+			return nil, err
+		}
+	} else if err := s.checkOTP(ctx, &user, code); err != nil {
+		return nil, err
+	}
+	return s.generateTokens(&user, true)
+}
+
+// requireAdminMFA rejects admin tokens issued without a second factor while ADMIN_REQUIRE_MFA is on
+func (s *Service) requireAdminMFA(c *gin.Context) bool {
+	if !s.config.AdminRequireMFA {
+		return true
+	}
+	claims, _ := c.Get("claims")
+	if tokenClaims, ok := claims.(*Claims); ok && tokenClaims.MFA {
+		return true
+	}
+	utils.ErrorResponse(c, http.StatusForbidden, "MFA_REQUIRED", "Admin access requires signing in with two-factor authentication", nil)
+	c.Abort()
+	return false
+}
+
+// recordAdminLogin stores an admin sign-in and raises an audit alert when it comes from a network the
+// account has not signed in from before. Failures are logged and never fail the sign-in.
+func (s *Service) recordAdminLogin(ctx context.Context, user *models.User, mfa bool, ip, userAgent string) {
+	if ip == "" {
+		return
+	}
+	network := loginNetwork(ip)
+
+	var previous, fromNetwork int64
+	if err := s.db.WithContext(ctx).Model(&models.AdminLoginEvent{}).Where("email = ?", user.Email).Count(&previous).Error; err != nil {
+		logger.Named("auth").Warn("Failed to check admin login history", map[string]interface{}{"email": user.Email, "error": err.Error()})
+		return
+	}
+	if previous > 0 {
+		if err := s.db.WithContext(ctx).Model(&models.AdminLoginEvent{}).Where("email = ? AND network = ?", user.Email, network).Count(&fromNetwork).Error; err != nil {
+			logger.Named("auth").Warn("Failed to check admin login history", map[string]interface{}{"email": user.Email, "error": err.Error()})
+			return
+		}
+	}
+
+	event := models.AdminLoginEvent{
+		UserID:      user.ID,
+		Email:       user.Email,
+		IP:          ip,
+		Network:     network,
+		UserAgent:   userAgent,
+		MFA:         mfa,
+		NewLocation: previous > 0 && fromNetwork == 0,
+	}
+	if err := s.db.WithContext(ctx).Create(&event).Error; err != nil {
+		logger.Named("auth").Warn("Failed to record admin login", map[string]interface{}{"email": user.Email, "error": err.Error()})
+		return
+	}
+
+	if event.NewLocation {
+		monitoring.CreateAlert(monitoring.AlertWarning, "Admin login from new location",
+			fmt.Sprintf("%s signed in from %s, a network this account has not used before", user.Email, ip),
+			map[string]interface{}{"email": user.Email, "ip": ip, "network": network, "user_agent": userAgent, "mfa": mfa})
+	}
+}
+
+// loginNetwork groups client IPs by network (IPv4 /24, IPv6 /48) so address changes within one
+// connection do not count as a new location
+func loginNetwork(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
@@ -0,0 +1,281 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// rfc6238Secret is the SHA-1 test key of RFC 6238 ("12345678901234567890") in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 appendix B vectors, truncated to six digits
+	code, err := totpCode(rfc6238Secret, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+	code, err = totpCode(rfc6238Secret, time.Unix(1111111109, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "081804", code)
+
+	now := time.Unix(1111111109, 0)
+	valid := func(secret, code string, at time.Time) bool {
+		_, ok := verifyTOTP(secret, code, at)
+		return ok
+	}
+	step, ok := verifyTOTP(rfc6238Secret, "081804", now)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1111111109/30), step)
+	step, ok = verifyTOTP(rfc6238Secret, "081804", now.Add(30*time.Second))
+	assert.True(t, ok, "one step of drift is accepted")
+	assert.Equal(t, int64(1111111109/30), step, "the step the code was made for")
+	assert.False(t, valid(rfc6238Secret, "081804", now.Add(2*time.Minute)))
+	assert.False(t, valid(rfc6238Secret, "81804", now))
+	assert.False(t, valid("not base32!", "081804", now))
+
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+	assert.Contains(t, totpURL(secret, "admin@example.com"), "otpauth://totp/Ecommerce%20Admin:admin@example.com?")
+}
+
+func currentCode(t *testing.T, secret string) string {
+	code, err := totpCode(secret, time.Now())
+	require.NoError(t, err)
+	return code
+}
+
+// nextCode returns the code of the following time step, which is still accepted as clock drift
+func nextCode(t *testing.T, secret string) string {
+	code, err := totpCode(secret, time.Now().Add(totpStep))
+	require.NoError(t, err)
+	return code
+}
+
+func TestAuthService_TOTPLogin(t *testing.T) {
+	service, db := setupTestService(t)
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := TestUser{ID: "admin-1", Email: "ops@example.com", Password: string(hashed), FirstName: "Ops", LastName: "Admin", Role: "admin", IsActive: true}
+	require.NoError(t, db.Create(&user).Error)
+
+	// Without a second factor the tokens are not marked
+	_, tokens, err := service.Login(context.Background(), LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	claims, err := service.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.False(t, claims.MFA)
+
+	assert.ErrorIs(t, service.EnableTOTP(context.Background(), user.ID, "123456"), ErrMFANotStarted)
+	setup, err := service.SetupTOTP(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.EnableTOTP(context.Background(), user.ID, "000000"), ErrInvalidOTP)
+	code := currentCode(t, setup.Secret)
+	require.NoError(t, service.EnableTOTP(context.Background(), user.ID, code))
+	_, err = service.SetupTOTP(context.Background(), user.ID)
+	assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+
+	_, _, err = service.Login(context.Background(), LoginRequest{Email: user.Email, Password: "password123"})
+	assert.ErrorIs(t, err, ErrOTPRequired)
+	_, _, err = service.Login(context.Background(), LoginRequest{Email: user.Email, Password: "password123", OTP: "000000"})
+	assert.ErrorIs(t, err, ErrInvalidOTP)
+
+	_, _, err = service.Login(context.Background(), LoginRequest{Email: user.Email, Password: "password123", OTP: code})
+	assert.ErrorIs(t, err, ErrInvalidOTP, "a code cannot be used twice")
+
+	_, tokens, err = service.Login(context.Background(), LoginRequest{Email: user.Email, Password: "password123", OTP: nextCode(t, setup.Secret)})
+	require.NoError(t, err)
+	claims, err = service.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, claims.MFA)
+
+	// Refreshing keeps the second factor
+	refreshed, err := service.RefreshToken(context.Background(), tokens.RefreshToken)
+	require.NoError(t, err)
+	claims, err = service.ValidateToken(refreshed.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, claims.MFA)
+}
+
+func TestAuthService_VerifyTOTP(t *testing.T) {
+	service, db := setupTestService(t)
+	user := TestUser{ID: "admin-2", Email: "ops2@example.com", Password: "unused", FirstName: "Ops", LastName: "Admin", Role: "admin", IsActive: true}
+	require.NoError(t, db.Create(&user).Error)
+
+	_, err := service.VerifyTOTP(context.Background(), user.ID, "123456")
+	assert.ErrorIs(t, err, ErrMFANotStarted)
+
+	// The first valid code completes setup and returns tokens that include the second factor
+	setup, err := service.SetupTOTP(context.Background(), user.ID)
+	require.NoError(t, err)
+	_, err = service.VerifyTOTP(context.Background(), user.ID, "000000")
+	assert.ErrorIs(t, err, ErrInvalidOTP)
+	code := currentCode(t, setup.Secret)
+	tokens, err := service.VerifyTOTP(context.Background(), user.ID, code)
+	require.NoError(t, err)
+	claims, err := service.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, claims.MFA)
+
+	var stored TestUser
+	require.NoError(t, db.First(&stored, "id = ?", user.ID).Error)
+	assert.True(t, stored.TOTPEnabled)
+
+	// Afterwards it steps up a session
+	_, err = service.VerifyTOTP(context.Background(), user.ID, "")
+	assert.ErrorIs(t, err, ErrOTPRequired)
+	_, err = service.VerifyTOTP(context.Background(), user.ID, code)
+	assert.ErrorIs(t, err, ErrInvalidOTP, "a code cannot be used twice")
+	next := time.Now().Add(totpStep)
+	code, err = totpCode(setup.Secret, next)
+	require.NoError(t, err)
+	_, err = service.VerifyTOTP(context.Background(), user.ID, code)
+	require.NoError(t, err)
+	require.NoError(t, db.First(&stored, "id = ?", user.ID).Error)
+	require.NotNil(t, stored.TOTPLastStep)
+	assert.Equal(t, totpCounter(next), *stored.TOTPLastStep)
+}
+
+func TestAuthService_AdminLoginMFA(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	service.config.AdminRequireMFA = true
+	_, _, err := service.AdminLogin(context.Background(), AdminLoginRequest{Email: "admin@ecommerce.com", Password: "admin123456"})
+	assert.ErrorIs(t, err, ErrMFANotEnrolled)
+
+	service.config.AdminTOTPSecret = rfc6238Secret
+	_, _, err = service.AdminLogin(context.Background(), AdminLoginRequest{Email: "admin@ecommerce.com", Password: "admin123456"})
+	assert.ErrorIs(t, err, ErrOTPRequired)
+
+	code := currentCode(t, rfc6238Secret)
+	_, tokens, err := service.AdminLogin(context.Background(), AdminLoginRequest{Email: "admin@ecommerce.com", Password: "admin123456", OTP: code})
+	require.NoError(t, err)
+	_, _, err = service.AdminLogin(context.Background(), AdminLoginRequest{Email: "admin@ecommerce.com", Password: "admin123456", OTP: code})
+	assert.ErrorIs(t, err, ErrInvalidOTP, "a code cannot be used twice")
+	claims, err := service.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, claims.MFA)
+
+	// Admin tokens live shorter than customer tokens
+	assert.WithinDuration(t, time.Now().Add(defaultAdminAccessTTL), claims.ExpiresAt.Time, 5*time.Second)
+	refreshClaims, err := service.ValidateToken(tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(defaultAdminRefreshTTL), refreshClaims.ExpiresAt.Time, 5*time.Second)
+
+	service.config.AdminAccessTokenTTLMinutes = 2
+	customer, err := service.GenerateTokens(&models.User{ID: "c1", Email: "c@example.com", Role: "customer"})
+	require.NoError(t, err)
+	customerClaims, err := service.ValidateToken(customer.AccessToken)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(customerAccessTokenTTL), customerClaims.ExpiresAt.Time, 5*time.Second)
+	admin, err := service.GenerateTokens(&models.User{ID: "a1", Email: "a@example.com", Role: "admin"})
+	require.NoError(t, err)
+	adminClaims, err := service.ValidateToken(admin.AccessToken)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), adminClaims.ExpiresAt.Time, 5*time.Second)
+}
+
+func TestAdminMiddleware_RequiresMFA(t *testing.T) {
+	service, _ := setupTestService(t)
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/admin", service.AuthMiddleware(), service.AdminMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/role", service.AuthMiddleware(), service.RequireAdminMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	admin := &models.User{ID: "admin-user", Email: "admin@ecommerce.com", Role: "admin"}
+	plain, err := service.generateTokens(admin, false)
+	require.NoError(t, err)
+	withMFA, err := service.generateTokens(admin, true)
+	require.NoError(t, err)
+
+	call := func(path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Not enforced by default
+	assert.Equal(t, http.StatusOK, call("/admin", plain.AccessToken))
+
+	service.config.AdminRequireMFA = true
+	for _, path := range []string{"/admin", "/role"} {
+		assert.Equal(t, http.StatusForbidden, call(path, plain.AccessToken), path)
+		assert.Equal(t, http.StatusOK, call(path, withMFA.AccessToken), path)
+	}
+}
+
+func TestAdminMiddleware_RejectsRefreshTokens(t *testing.T) {
+	service, db := setupTestService(t)
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/admin", service.AuthMiddleware(), service.AdminMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	admin := models.User{Email: "refresh-admin@example.com", Password: "hashed", FirstName: "Ada", LastName: "Admin", Role: "admin", IsActive: true}
+	require.NoError(t, db.Create(&admin).Error)
+	tokens, err := service.GenerateTokens(&admin)
+	require.NoError(t, err)
+
+	// The refresh token outlives the short admin access token, so it must not stand in for it
+	assert.Equal(t, http.StatusOK, call(tokens.AccessToken))
+	assert.Equal(t, http.StatusUnauthorized, call(tokens.RefreshToken))
+
+	_, err = service.RefreshToken(context.Background(), tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "an access token cannot refresh the session")
+	refreshed, err := service.RefreshToken(context.Background(), tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, call(refreshed.AccessToken))
+}
+
+func TestAuthService_AdminLoginAudit(t *testing.T) {
+	service, db := setupTestService(t)
+
+	login := func(ip string) {
+		_, _, err := service.AdminLogin(context.Background(), AdminLoginRequest{Email: "admin@ecommerce.com", Password: "admin123456", ClientIP: ip, UserAgent: "test-agent"})
+		require.NoError(t, err)
+	}
+	login("203.0.113.10")
+	login("203.0.113.99")
+	login("198.51.100.4")
+
+	var events []models.AdminLoginEvent
+	require.NoError(t, db.Order("created_at, id").Find(&events).Error)
+	require.Len(t, events, 3)
+	newLocations := map[string]bool{}
+	for _, event := range events {
+		newLocations[event.IP] = event.NewLocation
+	}
+	// The first sign-in has nothing to compare with and the second shares its /24
+	assert.Equal(t, map[string]bool{"203.0.113.10": false, "203.0.113.99": false, "198.51.100.4": true}, newLocations)
+	assert.Equal(t, "198.51.100.0/24", loginNetwork("198.51.100.4"))
+	assert.Equal(t, "2001:db8:1::/48", loginNetwork("2001:db8:1:2::5"))
+
+	// Customer sign-ins are not audited
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&TestUser{ID: "cust-1", Email: "shopper@example.com", Password: string(hashed), FirstName: "S", LastName: "H", Role: "customer", IsActive: true}).Error)
+	_, _, err = service.Login(context.Background(), LoginRequest{Email: "shopper@example.com", Password: "password123", ClientIP: "192.0.2.1"})
+	require.NoError(t, err)
+	var count int64
+	require.NoError(t, db.Model(&models.AdminLoginEvent{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
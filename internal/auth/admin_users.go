@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// adminInviteTTL is how long an invited admin has to set a password
+const adminInviteTTL = 72 * time.Hour
+
+var (
+	ErrAdminInviteUnavailable = errors.New("admin invites need an email service")
+	ErrInvalidAdminRole       = errors.New("unknown admin role")
+	ErrAdminNotFound          = errors.New("admin user not found")
+	ErrCannotChangeSelf       = errors.New("admins cannot disable themselves or change their own role")
+	ErrLastSuperAdmin         = errors.New("at least one active super admin must remain")
+)
+
+// AdminInviteMailer emails an invited admin the link to set their password
+type AdminInviteMailer interface {
+	SendAdminInvite(user *models.User, token string, expiresAt time.Time) error
+}
+
+// InviteAdminRequest invites a new admin account
+type InviteAdminRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	FirstName string `json:"firstName" binding:"required"`
+	LastName  string `json:"lastName" binding:"required"`
+	AdminRole string `json:"adminRole" binding:"required"`
+}
+
+// UpdateAdminRoleRequest changes what an admin may manage
+type UpdateAdminRoleRequest struct {
+	AdminRole string `json:"adminRole" binding:"required"`
+}
+
+// validAdminRole reports whether role is one of AdminRoles
+func validAdminRole(role string) bool {
+	for _, known := range AdminRoles {
+		if role == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ListAdminUsers returns every admin account, active or disabled, oldest first
+func (s *Service) ListAdminUsers(ctx context.Context) ([]models.User, error) {
+	var admins []models.User
+	if err := s.db.WithContext(ctx).Where("role = ?", "admin").Order("created_at ASC").Find(&admins).Error; err != nil {
+		return nil, err
+	}
+	for i := range admins {
+		admins[i].Password = ""
+		admins[i].AdminRole = effectiveAdminRole(admins[i].AdminRole)
+	}
+	return admins, nil
+}
+
+// InviteAdminUser creates an admin account without a usable password and emails the invitee a link to
+// set one through the password reset flow. The link is valid for adminInviteTTL.
+func (s *Service) InviteAdminUser(ctx context.Context, req InviteAdminRequest) (*models.User, error) {
+	inviter, ok := s.mailer.(AdminInviteMailer)
+	if !ok {
+		return nil, ErrAdminInviteUnavailable
+	}
+	if !validAdminRole(req.AdminRole) {
+		return nil, ErrInvalidAdminRole
+	}
+
+	var existing models.User
+	if err := s.db.WithContext(ctx).Where("email = ?", req.Email).First(&existing).Error; err == nil {
+		return nil, ErrUserExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// The random password is never shown; the invitee replaces it when accepting the invite
+	placeholder := make([]byte, 32)
+	if _, err := rand.Read(placeholder); err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(placeholder)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		Email:     req.Email,
+		Password:  string(hashedPassword),
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Role:      "admin",
+		AdminRole: req.AdminRole,
+		IsActive:  true,
+	}
+	if err := s.db.WithContext(ctx).Create(&user).Error; err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(adminInviteTTL)
+	inviteClaims := Claims{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   "password_reset",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   user.ID,
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, inviteClaims).SignedString([]byte(s.config.JWTSecret))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(&user).Updates(map[string]interface{}{
+		"password_reset_token":  token,
+		"password_reset_expiry": expiresAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := inviter.SendAdminInvite(&user, token, expiresAt); err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+	return &user, nil
+}
+
+// UpdateAdminRole changes the role of another admin account
+func (s *Service) UpdateAdminRole(ctx context.Context, actorID, userID, role string) (*models.User, error) {
+	if !validAdminRole(role) {
+		return nil, ErrInvalidAdminRole
+	}
+	return s.changeAdmin(ctx, actorID, userID, map[string]interface{}{"admin_role": role})
+}
+
+// SetAdminActive disables or re-enables another admin account. A disabled admin can no longer sign in
+// or refresh their session.
+func (s *Service) SetAdminActive(ctx context.Context, actorID, userID string, active bool) (*models.User, error) {
+	return s.changeAdmin(ctx, actorID, userID, map[string]interface{}{"is_active": active})
+}
+
+// changeAdmin applies updates to an admin account other than the actor's, refusing changes that would leave
+// no active super admin account
+func (s *Service) changeAdmin(ctx context.Context, actorID, userID string, updates map[string]interface{}) (*models.User, error) {
+	if actorID == userID {
+		return nil, ErrCannotChangeSelf
+	}
+
+	var user models.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND role = ?", userID, "admin").First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAdminNotFound
+			}
+			return err
+		}
+		wasSuper := user.IsActive && effectiveAdminRole(user.AdminRole) == models.AdminRoleSuper
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if !wasSuper {
+			return nil
+		}
+
+		var superAdmins int64
+		if err := tx.Model(&models.User{}).
+			Where("role = ? AND is_active = ? AND admin_role IN ?", "admin", true, []string{models.AdminRoleSuper, ""}).
+			Count(&superAdmins).Error; err != nil {
+			return err
+		}
+		if superAdmins == 0 {
+			return ErrLastSuperAdmin
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+	user.AdminRole = effectiveAdminRole(user.AdminRole)
+	return &user, nil
+}
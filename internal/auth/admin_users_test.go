@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAdminAllowed(t *testing.T) {
+	assert.True(t, AdminAllowed("", "/api/admin/settings"), "admins from before roles keep full access")
+	assert.True(t, AdminAllowed(models.AdminRoleSuper, "/api/admin/users"))
+
+	assert.True(t, AdminAllowed(models.AdminRoleCatalogManager, "/api/admin/products/:id"))
+	assert.True(t, AdminAllowed(models.AdminRoleCatalogManager, "/api/categories"))
+	assert.False(t, AdminAllowed(models.AdminRoleCatalogManager, "/api/admin/orders"))
+	assert.False(t, AdminAllowed(models.AdminRoleCatalogManager, "/api/admin/productsx"))
+
+	assert.True(t, AdminAllowed(models.AdminRoleSupport, "/api/admin/orders/:id"))
+	assert.False(t, AdminAllowed(models.AdminRoleSupport, "/api/admin/orders/:id/refund"))
+	assert.False(t, AdminAllowed(models.AdminRoleSupport, "/api/admin/users"))
+}
+
+func TestAuthService_InviteAdminUser(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	_, err := service.InviteAdminUser(context.Background(), InviteAdminRequest{Email: "cat@example.com", FirstName: "Cat", LastName: "Alog", AdminRole: models.AdminRoleCatalogManager})
+	assert.ErrorIs(t, err, ErrAdminInviteUnavailable)
+
+	mailer := &capturingMailer{}
+	service.mailer = mailer
+	_, err = service.InviteAdminUser(context.Background(), InviteAdminRequest{Email: "cat@example.com", FirstName: "Cat", LastName: "Alog", AdminRole: "owner"})
+	assert.ErrorIs(t, err, ErrInvalidAdminRole)
+
+	user, err := service.InviteAdminUser(context.Background(), InviteAdminRequest{Email: "cat@example.com", FirstName: "Cat", LastName: "Alog", AdminRole: models.AdminRoleCatalogManager})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", user.Role)
+	require.Len(t, mailer.tokens, 1)
+	_, err = service.InviteAdminUser(context.Background(), InviteAdminRequest{Email: "cat@example.com", FirstName: "Cat", LastName: "Alog", AdminRole: models.AdminRoleSupport})
+	assert.ErrorIs(t, err, ErrUserExists)
+
+	// The invitee sets a password with the emailed token and signs in with the invited role
+	require.NoError(t, service.ResetPassword(context.Background(), ResetPasswordRequest{Token: mailer.tokens[0], Password: "catalog-pass"}))
+	signedIn, tokens, err := service.AdminLogin(context.Background(), AdminLoginRequest{Email: "cat@example.com", Password: "catalog-pass"})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, signedIn.ID)
+	claims, err := service.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, models.AdminRoleCatalogManager, claims.AdminRole)
+
+	_, _, err = service.AdminLogin(context.Background(), AdminLoginRequest{Email: "cat@example.com", Password: "wrong-pass"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthService_ChangeAdmin(t *testing.T) {
+	service, db := setupTestService(t)
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&TestUser{ID: "super-1", Email: "root@example.com", Password: string(hashed), FirstName: "Root", LastName: "Admin", Role: "admin", AdminRole: models.AdminRoleSuper, IsActive: true}).Error)
+	require.NoError(t, db.Create(&TestUser{ID: "support-1", Email: "help@example.com", Password: string(hashed), FirstName: "Help", LastName: "Desk", Role: "admin", AdminRole: models.AdminRoleSupport, IsActive: true}).Error)
+	require.NoError(t, db.Create(&TestUser{ID: "cust-1", Email: "shopper@example.com", Password: string(hashed), FirstName: "S", LastName: "H", Role: "customer", IsActive: true}).Error)
+
+	_, err = service.UpdateAdminRole(context.Background(), "super-1", "super-1", models.AdminRoleSupport)
+	assert.ErrorIs(t, err, ErrCannotChangeSelf)
+	_, err = service.UpdateAdminRole(context.Background(), "super-1", "cust-1", models.AdminRoleSupport)
+	assert.ErrorIs(t, err, ErrAdminNotFound)
+
+	// The environment admin cannot demote or disable the only super admin account
+	_, err = service.UpdateAdminRole(context.Background(), "admin-user", "super-1", models.AdminRoleSupport)
+	assert.ErrorIs(t, err, ErrLastSuperAdmin)
+	_, err = service.SetAdminActive(context.Background(), "admin-user", "super-1", false)
+	assert.ErrorIs(t, err, ErrLastSuperAdmin)
+
+	promoted, err := service.UpdateAdminRole(context.Background(), "super-1", "support-1", models.AdminRoleSuper)
+	require.NoError(t, err)
+	assert.Equal(t, models.AdminRoleSuper, promoted.AdminRole)
+
+	disabled, err := service.SetAdminActive(context.Background(), "support-1", "super-1", false)
+	require.NoError(t, err)
+	assert.False(t, disabled.IsActive)
+	_, _, err = service.AdminLogin(context.Background(), AdminLoginRequest{Email: "root@example.com", Password: "password123"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	admins, err := service.ListAdminUsers(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, admins, 2)
+}
+
+func TestAdminMiddleware_EnforcesAdminRole(t *testing.T) {
+	service, _ := setupTestService(t)
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	admin := r.Group("/api/admin", service.AuthMiddleware(), service.AdminMiddleware())
+	admin.GET("/products/:id", ok)
+	admin.GET("/orders/:id", ok)
+	admin.GET("/users", ok)
+
+	call := func(adminRole, path string) int {
+		tokens, err := service.generateTokens(&models.User{ID: "a1", Email: "a@example.com", Role: "admin", AdminRole: adminRole}, false)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call(models.AdminRoleCatalogManager, "/api/admin/products/p1"))
+	assert.Equal(t, http.StatusForbidden, call(models.AdminRoleCatalogManager, "/api/admin/orders/o1"))
+	assert.Equal(t, http.StatusOK, call(models.AdminRoleSupport, "/api/admin/orders/o1"))
+	assert.Equal(t, http.StatusForbidden, call(models.AdminRoleSupport, "/api/admin/users"))
+	assert.Equal(t, http.StatusOK, call(models.AdminRoleSuper, "/api/admin/users"))
+	assert.Equal(t, http.StatusOK, call("", "/api/admin/users"))
+}
@@ -1,15 +1,24 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
 	"ecommerce-website/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
+// CartMerger moves a guest session's cart into a user's cart
+type CartMerger interface {
+	MergeGuestCart(ctx context.Context, sessionID string, userID string) (*models.Cart, error)
+}
+
 type Handler struct {
-	service *Service
+	service    *Service
+	cartMerger CartMerger
 }
 
 func NewHandler(service *Service) *Handler {
@@ -18,6 +27,15 @@ func NewHandler(service *Service) *Handler {
 	}
 }
 
+// NewHandlerWithCartMerger creates a handler that merges the guest cart of the session_id cookie
+// into the user's cart on login
+func NewHandlerWithCartMerger(service *Service, cartMerger CartMerger) *Handler {
+	return &Handler{
+		service:    service,
+		cartMerger: cartMerger,
+	}
+}
+
 // Register handles user registration
 func (h *Handler) Register(c *gin.Context) {
 	var req RegisterRequest
@@ -26,7 +44,7 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.Register(req)
+	user, err := h.service.Register(c.Request.Context(), req)
 	if err != nil {
 		switch err {
 		case ErrUserExists:
@@ -50,23 +68,48 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	user, tokens, err := h.service.Login(req)
+	req.ClientIP, req.UserAgent = c.ClientIP(), c.Request.UserAgent()
+	user, tokens, err := h.service.Login(c.Request.Context(), req)
 	if err != nil {
 		switch err {
 		case ErrInvalidCredentials:
 			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password", nil)
+		case ErrOTPRequired:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "OTP_REQUIRED", "Two-factor code is required", nil)
+		case ErrInvalidOTP:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_OTP", "Invalid two-factor code", nil)
 		default:
 			utils.ErrorResponse(c, http.StatusInternalServerError, "LOGIN_FAILED", "Login failed", err.Error())
 		}
 		return
 	}
 
+	h.mergeGuestCart(c, user.ID)
+
 	utils.SuccessResponse(c, http.StatusOK, "Login successful", gin.H{
 		"user":   user,
 		"tokens": tokens,
 	})
 }
 
+// mergeGuestCart merges the guest cart of the request's session into the user's cart. Failures are
+// logged and never fail the login.
+func (h *Handler) mergeGuestCart(c *gin.Context, userID string) {
+	if h.cartMerger == nil {
+		return
+	}
+	sessionID, err := c.Cookie("session_id")
+	if err != nil || sessionID == "" {
+		return
+	}
+	if _, err := h.cartMerger.MergeGuestCart(c.Request.Context(), sessionID, userID); err != nil {
+		logger.Named("auth").Warn("Failed to merge guest cart on login", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+	}
+}
+
 // RefreshToken handles token refresh
 func (h *Handler) RefreshToken(c *gin.Context) {
 	var req struct {
@@ -78,7 +121,7 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.service.RefreshToken(req.RefreshToken)
+	tokens, err := h.service.RefreshToken(c.Request.Context(), req.RefreshToken)
 	if err != nil {
 		switch err {
 		case ErrInvalidToken:
@@ -113,7 +156,7 @@ func (h *Handler) Me(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.GetUserByID(userID.(string))
+	user, err := h.service.GetUserByID(c.Request.Context(), userID.(string))
 	if err != nil {
 		switch err {
 		case ErrUserNotFound:
@@ -137,7 +180,7 @@ func (h *Handler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	err := h.service.ForgotPassword(req)
+	err := h.service.ForgotPassword(c.Request.Context(), req)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "FORGOT_PASSWORD_FAILED", "Failed to process password reset request", err.Error())
 		return
@@ -156,7 +199,7 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	err := h.service.ResetPassword(req)
+	err := h.service.ResetPassword(c.Request.Context(), req)
 	if err != nil {
 		switch err {
 		case ErrInvalidToken:
@@ -182,7 +225,7 @@ func (h *Handler) VerifyEmail(c *gin.Context) {
 		return
 	}
 
-	err := h.service.VerifyEmail(token)
+	err := h.service.VerifyEmail(c.Request.Context(), token)
 	if err != nil {
 		switch err {
 		case ErrInvalidToken:
@@ -206,7 +249,7 @@ func (h *Handler) ResendEmailVerification(c *gin.Context) {
 		return
 	}
 
-	err := h.service.SendEmailVerification(userID.(string))
+	err := h.service.SendEmailVerification(c.Request.Context(), userID.(string))
 	if err != nil {
 		switch err {
 		case ErrUserNotFound:
@@ -222,7 +265,7 @@ func (h *Handler) ResendEmailVerification(c *gin.Context) {
 	})
 }
 
-// AdminLogin handles admin authentication using environment credentials
+// AdminLogin handles admin authentication against admin accounts or the environment credentials
 func (h *Handler) AdminLogin(c *gin.Context) {
 	var req AdminLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -230,11 +273,18 @@ func (h *Handler) AdminLogin(c *gin.Context) {
 		return
 	}
 
-	user, tokens, err := h.service.AdminLogin(req)
+	req.ClientIP, req.UserAgent = c.ClientIP(), c.Request.UserAgent()
+	user, tokens, err := h.service.AdminLogin(c.Request.Context(), req)
 	if err != nil {
 		switch err {
 		case ErrInvalidCredentials:
 			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid admin credentials", nil)
+		case ErrOTPRequired:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "OTP_REQUIRED", "Two-factor code is required", nil)
+		case ErrInvalidOTP:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_OTP", "Invalid two-factor code", nil)
+		case ErrMFANotEnrolled:
+			utils.ErrorResponse(c, http.StatusForbidden, "MFA_NOT_ENROLLED", "Two-factor authentication must be set up for the admin account", nil)
 		default:
 			utils.ErrorResponse(c, http.StatusInternalServerError, "LOGIN_FAILED", "Admin login failed", err.Error())
 		}
@@ -246,3 +296,224 @@ func (h *Handler) AdminLogin(c *gin.Context) {
 		"tokens": tokens,
 	})
 }
+
+// SetupTOTP handles POST /api/auth/2fa/setup
+func (h *Handler) SetupTOTP(c *gin.Context) {
+	setup, err := h.service.SetupTOTP(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		switch err {
+		case ErrUserNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found", nil)
+		case ErrMFAAlreadyEnabled:
+			utils.ErrorResponse(c, http.StatusConflict, "MFA_ALREADY_ENABLED", "Two-factor authentication is already enabled", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "MFA_SETUP_FAILED", "Failed to set up two-factor authentication", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Add the account to your authenticator app, then confirm with a code", setup)
+}
+
+// EnableTOTP handles POST /api/auth/2fa/enable
+func (h *Handler) EnableTOTP(c *gin.Context) {
+	var req EnableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	if err := h.service.EnableTOTP(c.Request.Context(), c.GetString("user_id"), req.Code); err != nil {
+		switch err {
+		case ErrUserNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found", nil)
+		case ErrMFAAlreadyEnabled:
+			utils.ErrorResponse(c, http.StatusConflict, "MFA_ALREADY_ENABLED", "Two-factor authentication is already enabled", nil)
+		case ErrMFANotStarted:
+			utils.ErrorResponse(c, http.StatusConflict, "MFA_SETUP_REQUIRED", "Start two-factor setup first", nil)
+		case ErrOTPRequired, ErrInvalidOTP:
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_OTP", "Invalid two-factor code", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "MFA_ENABLE_FAILED", "Failed to enable two-factor authentication", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication enabled; sign in again to get tokens that include it", nil)
+}
+
+// VerifyTOTP handles POST /api/auth/2fa/verify
+func (h *Handler) VerifyTOTP(c *gin.Context) {
+	var req EnableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	tokens, err := h.service.VerifyTOTP(c.Request.Context(), c.GetString("user_id"), req.Code)
+	if err != nil {
+		switch err {
+		case ErrUserNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found", nil)
+		case ErrMFANotStarted:
+			utils.ErrorResponse(c, http.StatusConflict, "MFA_SETUP_REQUIRED", "Start two-factor setup first", nil)
+		case ErrOTPRequired, ErrInvalidOTP:
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_OTP", "Invalid two-factor code", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "MFA_VERIFY_FAILED", "Failed to verify two-factor code", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor code verified", gin.H{
+		"tokens": tokens,
+	})
+}
+
+// RequestMagicLink emails a password-less sign-in link
+func (h *Handler) RequestMagicLink(c *gin.Context) {
+	var req MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	req.ClientIP = c.ClientIP()
+	req.Fingerprint = DeviceFingerprint(c.Request.UserAgent(), c.GetHeader(DeviceIDHeader))
+	if err := h.service.RequestMagicLink(c.Request.Context(), req); err != nil {
+		if err == ErrMagicLinkUnavailable {
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, "MAGIC_LINK_UNAVAILABLE", "Sign-in links are not available", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "MAGIC_LINK_FAILED", "Failed to send sign-in link", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sign-in link sent to your email", gin.H{
+		"message": "If an account with that email exists, you will receive a sign-in link",
+	})
+}
+
+// ConsumeMagicLink signs in with an emailed link
+func (h *Handler) ConsumeMagicLink(c *gin.Context) {
+	var req ConsumeMagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	req.Fingerprint = DeviceFingerprint(c.Request.UserAgent(), c.GetHeader(DeviceIDHeader))
+	user, tokens, err := h.service.ConsumeMagicLink(c.Request.Context(), req)
+	if err != nil {
+		switch err {
+		case ErrInvalidToken:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or already used sign-in link", nil)
+		case ErrExpiredToken:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "EXPIRED_TOKEN", "Sign-in link has expired", nil)
+		case ErrMagicLinkDeviceMismatch:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "MAGIC_LINK_DEVICE_MISMATCH", "Open the sign-in link on the device that requested it", nil)
+		case ErrOTPRequired:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "OTP_REQUIRED", "Two-factor code is required", nil)
+		case ErrInvalidOTP:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_OTP", "Invalid two-factor code", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "LOGIN_FAILED", "Login failed", err.Error())
+		}
+		return
+	}
+
+	h.mergeGuestCart(c, user.ID)
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", gin.H{
+		"user":   user,
+		"tokens": tokens,
+	})
+}
+
+// ListAdminUsers handles GET /api/admin/users
+func (h *Handler) ListAdminUsers(c *gin.Context) {
+	admins, err := h.service.ListAdminUsers(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "ADMIN_USERS_FETCH_FAILED", "Failed to fetch admin users", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Admin users retrieved successfully", gin.H{
+		"users": admins,
+		"roles": AdminRoles,
+	})
+}
+
+// InviteAdminUser handles POST /api/admin/users
+func (h *Handler) InviteAdminUser(c *gin.Context) {
+	var req InviteAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	user, err := h.service.InviteAdminUser(c.Request.Context(), req)
+	if err != nil {
+		h.adminUserError(c, err, "ADMIN_INVITE_FAILED", "Failed to invite admin user")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Admin user invited", gin.H{"user": user})
+}
+
+// UpdateAdminRole handles PUT /api/admin/users/:id/role
+func (h *Handler) UpdateAdminRole(c *gin.Context) {
+	var req UpdateAdminRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	user, err := h.service.UpdateAdminRole(c.Request.Context(), c.GetString("user_id"), c.Param("id"), req.AdminRole)
+	if err != nil {
+		h.adminUserError(c, err, "ADMIN_UPDATE_FAILED", "Failed to change admin role")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Admin role updated", gin.H{"user": user})
+}
+
+// DisableAdminUser handles POST /api/admin/users/:id/disable
+func (h *Handler) DisableAdminUser(c *gin.Context) {
+	h.setAdminActive(c, false, "Admin user disabled")
+}
+
+// EnableAdminUser handles POST /api/admin/users/:id/enable
+func (h *Handler) EnableAdminUser(c *gin.Context) {
+	h.setAdminActive(c, true, "Admin user enabled")
+}
+
+func (h *Handler) setAdminActive(c *gin.Context, active bool, message string) {
+	user, err := h.service.SetAdminActive(c.Request.Context(), c.GetString("user_id"), c.Param("id"), active)
+	if err != nil {
+		h.adminUserError(c, err, "ADMIN_UPDATE_FAILED", "Failed to update admin user")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, gin.H{"user": user})
+}
+
+// adminUserError maps admin user management errors to responses
+func (h *Handler) adminUserError(c *gin.Context, err error, code, message string) {
+	switch err {
+	case ErrUserExists:
+		utils.ErrorResponse(c, http.StatusConflict, "USER_EXISTS", "User with this email already exists", nil)
+	case ErrInvalidAdminRole:
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_ADMIN_ROLE", "Unknown admin role", gin.H{"roles": AdminRoles})
+	case ErrAdminNotFound:
+		utils.ErrorResponse(c, http.StatusNotFound, "ADMIN_NOT_FOUND", "Admin user not found", nil)
+	case ErrCannotChangeSelf:
+		utils.ErrorResponse(c, http.StatusConflict, "CANNOT_CHANGE_SELF", "You cannot disable yourself or change your own role", nil)
+	case ErrLastSuperAdmin:
+		utils.ErrorResponse(c, http.StatusConflict, "LAST_SUPER_ADMIN", "At least one active super admin must remain", nil)
+	case ErrAdminInviteUnavailable:
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "ADMIN_INVITE_UNAVAILABLE", "Admin invites need the email service", nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, code, message, err.Error())
+	}
+}
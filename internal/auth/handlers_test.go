@@ -2,6 +2,7 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -195,6 +196,58 @@ func TestHandler_Login(t *testing.T) {
 	}
 }
 
+// recordingCartMerger records guest cart merges
+type recordingCartMerger struct {
+	sessionID string
+	userID    string
+}
+
+func (m *recordingCartMerger) MergeGuestCart(ctx context.Context, sessionID string, userID string) (*models.Cart, error) {
+	m.sessionID, m.userID = sessionID, userID
+	return &models.Cart{SessionID: sessionID}, nil
+}
+
+func TestHandler_LoginMergesGuestCart(t *testing.T) {
+	plain, db := setupTestHandler(t)
+	gin.SetMode(gin.TestMode)
+	merger := &recordingCartMerger{}
+	handler := NewHandlerWithCartMerger(plain.service, merger)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	db.Create(&TestUser{
+		ID:        "cart-user-id",
+		Email:     "cart@example.com",
+		Password:  string(hashedPassword),
+		FirstName: "Cart",
+		LastName:  "User",
+		Role:      "customer",
+		IsActive:  true,
+	})
+
+	login := func(cookie *http.Cookie, password string) int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		jsonBody, _ := json.Marshal(LoginRequest{Email: "cart@example.com", Password: password})
+		c.Request = httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(jsonBody))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if cookie != nil {
+			c.Request.AddCookie(cookie)
+		}
+		handler.Login(c)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login(&http.Cookie{Name: "session_id", Value: "guest-session"}, "wrongpassword"))
+	assert.Empty(t, merger.userID, "failed logins do not merge")
+
+	assert.Equal(t, http.StatusOK, login(nil, "password123"))
+	assert.Empty(t, merger.userID, "nothing to merge without a session cookie")
+
+	assert.Equal(t, http.StatusOK, login(&http.Cookie{Name: "session_id", Value: "guest-session"}, "password123"))
+	assert.Equal(t, "guest-session", merger.sessionID)
+	assert.Equal(t, "cart-user-id", merger.userID)
+}
+
 func TestHandler_RefreshToken(t *testing.T) {
 	handler, db := setupTestHandler(t)
 	gin.SetMode(gin.TestMode)
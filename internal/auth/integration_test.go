@@ -35,7 +35,7 @@ func setupIntegrationTest(t *testing.T) (*gin.Engine, *gorm.DB) {
 	authHandler := NewHandler(authService)
 
 	// Setup routes
-	SetupRoutes(router, authHandler, authService)
+	SetupRoutes(router.Group("/api"), authHandler, authService)
 
 	return router, db
 }
@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	// magicLinkTTL is how long an emailed sign-in link stays valid
+	magicLinkTTL = 15 * time.Minute
+	// magicLinkWindow and magicLinkMaxPerWindow cap how many links one account is sent
+	magicLinkWindow       = 15 * time.Minute
+	magicLinkMaxPerWindow = 3
+	// magicLinkRole marks magic link tokens, like password_reset marks reset tokens
+	magicLinkRole = "magic_link"
+)
+
+// DeviceIDHeader carries the device ID the storefront keeps in local storage
+const DeviceIDHeader = "X-Device-Id"
+
+var (
+	ErrMagicLinkUnavailable    = errors.New("magic link sign-in is not available")
+	ErrMagicLinkDeviceMismatch = errors.New("magic link was requested from another device")
+)
+
+// MagicLinkMailer emails password-less sign-in links
+type MagicLinkMailer interface {
+	SendMagicLink(user *models.User, token string, expiresAt time.Time) error
+}
+
+// MagicLinkRequest asks for a sign-in link to be emailed
+type MagicLinkRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	ClientIP    string `json:"-"`
+	Fingerprint string `json:"-"`
+}
+
+// ConsumeMagicLinkRequest signs in with an emailed link
+type ConsumeMagicLinkRequest struct {
+	Token       string `json:"token" binding:"required"`
+	OTP         string `json:"otp,omitempty"`
+	Fingerprint string `json:"-"`
+}
+
+// NewServiceWithMailer creates an auth service that emails magic sign-in links through mailer
+func NewServiceWithMailer(db *gorm.DB, config *config.Config, mailer MagicLinkMailer) *Service {
+	service := NewService(db, config)
+	service.mailer = mailer
+	return service
+}
+
+// DeviceFingerprint identifies the device a request comes from by its user agent and the device ID
+// the storefront keeps in local storage. It is a hash, so neither is stored.
+func DeviceFingerprint(userAgent, deviceID string) string {
+	sum := sha256.Sum256([]byte(userAgent + "\n" + deviceID))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestMagicLink emails a customer a single-use sign-in link that only works on the requesting device.
+// Unknown emails, non-customer accounts and accounts that already received magicLinkMaxPerWindow links
+// recently get no email but the same answer, so the endpoint does not reveal which accounts exist.
+func (s *Service) RequestMagicLink(ctx context.Context, req MagicLinkRequest) error {
+	if s.mailer == nil {
+		return ErrMagicLinkUnavailable
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("email = ? AND is_active = ?", req.Email, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	// Staff accounts sign in with their password and second factor only
+	if user.Role != "customer" {
+		return nil
+	}
+
+	now := time.Now()
+	var recent int64
+	if err := s.db.WithContext(ctx).Model(&models.MagicLinkToken{}).
+		Where("user_id = ? AND created_at > ?", user.ID, now.Add(-magicLinkWindow)).
+		Count(&recent).Error; err != nil {
+		return err
+	}
+	if recent >= magicLinkMaxPerWindow {
+		logger.Named("auth").Warn("Magic link request limit reached", map[string]interface{}{"user_id": user.ID, "ip": req.ClientIP})
+		return nil
+	}
+
+	link := models.MagicLinkToken{
+		UserID:      user.ID,
+		Fingerprint: req.Fingerprint,
+		IP:          req.ClientIP,
+		ExpiresAt:   now.Add(magicLinkTTL),
+	}
+	if err := s.db.WithContext(ctx).Create(&link).Error; err != nil {
+		return err
+	}
+
+	claims := Claims{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   magicLinkRole,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        link.ID,
+			ExpiresAt: jwt.NewNumericDate(link.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   user.ID,
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.config.JWTSecret))
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.SendMagicLink(&user, token, link.ExpiresAt)
+}
+
+// ConsumeMagicLink signs a customer in with an emailed link and returns the normal token pair. The link
+// must be opened on the device that requested it; a used or expired link is rejected. Accounts with two-factor
+// authentication still need their code.
+func (s *Service) ConsumeMagicLink(ctx context.Context, req ConsumeMagicLinkRequest) (*models.User, *TokenPair, error) {
+	claims, err := s.ValidateToken(req.Token)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if claims.Role != magicLinkRole || claims.ID == "" {
+		return nil, nil, ErrInvalidToken
+	}
+
+	var link models.MagicLinkToken
+	if err := s.db.WithContext(ctx).First(&link, "id = ? AND user_id = ?", claims.ID, claims.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidToken
+		}
+		return nil, nil, err
+	}
+	now := time.Now()
+	if link.UsedAt != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if now.After(link.ExpiresAt) {
+		return nil, nil, ErrExpiredToken
+	}
+	if !hmac.Equal([]byte(link.Fingerprint), []byte(req.Fingerprint)) {
+		return nil, nil, ErrMagicLinkDeviceMismatch
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", link.UserID, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidToken
+		}
+		return nil, nil, err
+	}
+	if user.TOTPEnabled {
+		if err := s.checkOTP(ctx, &user, req.OTP); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Claim the link in the update itself so two concurrent requests cannot both sign in with it
+	result := s.db.WithContext(ctx).Model(&models.MagicLinkToken{}).Where("id = ? AND used_at IS NULL", link.ID).Update("used_at", now)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, ErrInvalidToken
+	}
+
+	tokens, err := s.generateTokens(&user, user.TOTPEnabled)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.recordActivity(ctx, user.ID)
+
+	user.Password = ""
+	return &user, tokens, nil
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capturingMailer keeps sent sign-in links and admin invites instead of emailing them
+type capturingMailer struct {
+	tokens []string
+}
+
+func (m *capturingMailer) SendMagicLink(user *models.User, token string, expiresAt time.Time) error {
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+
+func (m *capturingMailer) SendAdminInvite(user *models.User, token string, expiresAt time.Time) error {
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+
+func setupMagicLinkService(t *testing.T) (*Service, *capturingMailer, TestUser) {
+	service, db := setupTestService(t)
+	mailer := &capturingMailer{}
+	service.mailer = mailer
+
+	user := TestUser{ID: "customer-1", Email: "shopper@example.com", Password: "unused", FirstName: "Shop", LastName: "Per", Role: "customer", IsActive: true}
+	require.NoError(t, db.Create(&user).Error)
+	return service, mailer, user
+}
+
+func TestAuthService_MagicLink(t *testing.T) {
+	device := DeviceFingerprint("Mozilla/5.0", "device-1")
+
+	t.Run("link signs in once on the requesting device", func(t *testing.T) {
+		service, mailer, user := setupMagicLinkService(t)
+
+		require.NoError(t, service.RequestMagicLink(context.Background(), MagicLinkRequest{Email: user.Email, Fingerprint: device}))
+		require.Len(t, mailer.tokens, 1)
+
+		_, _, err := service.ConsumeMagicLink(context.Background(), ConsumeMagicLinkRequest{Token: mailer.tokens[0], Fingerprint: DeviceFingerprint("Mozilla/5.0", "device-2")})
+		assert.ErrorIs(t, err, ErrMagicLinkDeviceMismatch)
+
+		signedIn, tokens, err := service.ConsumeMagicLink(context.Background(), ConsumeMagicLinkRequest{Token: mailer.tokens[0], Fingerprint: device})
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, signedIn.ID)
+		claims, err := service.ValidateToken(tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "customer", claims.Role)
+
+		_, _, err = service.ConsumeMagicLink(context.Background(), ConsumeMagicLinkRequest{Token: mailer.tokens[0], Fingerprint: device})
+		assert.ErrorIs(t, err, ErrInvalidToken, "links are single-use")
+	})
+
+	t.Run("expired links and other tokens are rejected", func(t *testing.T) {
+		service, mailer, user := setupMagicLinkService(t)
+		require.NoError(t, service.RequestMagicLink(context.Background(), MagicLinkRequest{Email: user.Email, Fingerprint: device}))
+		require.NoError(t, service.db.Model(&models.MagicLinkToken{}).Where("user_id = ?", user.ID).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		_, _, err := service.ConsumeMagicLink(context.Background(), ConsumeMagicLinkRequest{Token: mailer.tokens[0], Fingerprint: device})
+		assert.ErrorIs(t, err, ErrExpiredToken)
+
+		account := models.User{ID: user.ID, Email: user.Email, Role: user.Role}
+		tokens, err := service.GenerateTokens(&account)
+		require.NoError(t, err)
+		_, _, err = service.ConsumeMagicLink(context.Background(), ConsumeMagicLinkRequest{Token: tokens.AccessToken, Fingerprint: device})
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("unknown, staff and over-limit accounts get no email", func(t *testing.T) {
+		service, mailer, user := setupMagicLinkService(t)
+		admin := TestUser{ID: "admin-1", Email: "ops@example.com", Password: "unused", FirstName: "Ops", LastName: "Admin", Role: "admin", IsActive: true}
+		require.NoError(t, service.db.Create(&admin).Error)
+
+		require.NoError(t, service.RequestMagicLink(context.Background(), MagicLinkRequest{Email: "nobody@example.com", Fingerprint: device}))
+		require.NoError(t, service.RequestMagicLink(context.Background(), MagicLinkRequest{Email: admin.Email, Fingerprint: device}))
+		assert.Empty(t, mailer.tokens)
+
+		for i := 0; i < magicLinkMaxPerWindow+2; i++ {
+			require.NoError(t, service.RequestMagicLink(context.Background(), MagicLinkRequest{Email: user.Email, Fingerprint: device}))
+		}
+		assert.Len(t, mailer.tokens, magicLinkMaxPerWindow)
+	})
+
+	t.Run("two-factor accounts still need their code", func(t *testing.T) {
+		service, mailer, user := setupMagicLinkService(t)
+		secret := rfc6238Secret
+		require.NoError(t, service.db.Model(&TestUser{}).Where("id = ?", user.ID).
+			Updates(map[string]interface{}{"totp_secret": secret, "totp_enabled": true}).Error)
+		require.NoError(t, service.RequestMagicLink(context.Background(), MagicLinkRequest{Email: user.Email, Fingerprint: device}))
+
+		_, _, err := service.ConsumeMagicLink(context.Background(), ConsumeMagicLinkRequest{Token: mailer.tokens[0], Fingerprint: device})
+		assert.ErrorIs(t, err, ErrOTPRequired)
+
+		_, tokens, err := service.ConsumeMagicLink(context.Background(), ConsumeMagicLinkRequest{Token: mailer.tokens[0], Fingerprint: device, OTP: currentCode(t, secret)})
+		require.NoError(t, err)
+		claims, err := service.ValidateToken(tokens.AccessToken)
+		require.NoError(t, err)
+		assert.True(t, claims.MFA)
+	})
+
+	t.Run("unavailable without a mailer", func(t *testing.T) {
+		service, _ := setupTestService(t)
+		assert.ErrorIs(t, service.RequestMagicLink(context.Background(), MagicLinkRequest{Email: "shopper@example.com"}), ErrMagicLinkUnavailable)
+	})
+}
+
+func TestHandler_MagicLink(t *testing.T) {
+	service, mailer, user := setupMagicLinkService(t)
+	handler := NewHandler(service)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/magic-link", handler.RequestMagicLink)
+	r.POST("/magic-link/consume", handler.ConsumeMagicLink)
+
+	send := func(path, body, deviceID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "Mozilla/5.0")
+		req.Header.Set(DeviceIDHeader, deviceID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/magic-link", `{"email":"nobody@example.com"}`, "device-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = send("/magic-link", `{"email":"`+user.Email+`"}`, "device-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, mailer.tokens, 1)
+
+	w = send("/magic-link/consume", `{"token":"`+mailer.tokens[0]+`"}`, "device-2")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "MAGIC_LINK_DEVICE_MISMATCH")
+
+	w = send("/magic-link/consume", `{"token":"`+mailer.tokens[0]+`"}`, "device-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "access_token")
+
+	w = send("/magic-link/consume", `{"token":"`+mailer.tokens[0]+`"}`, "device-1")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_TOKEN")
+}
+
+func TestPurposeTokensDoNotAuthenticate(t *testing.T) {
+	service, mailer, user := setupMagicLinkService(t)
+	ctx := context.Background()
+	require.NoError(t, service.RequestMagicLink(ctx, MagicLinkRequest{Email: user.Email, Fingerprint: DeviceFingerprint("Mozilla/5.0", "device-1")}))
+	require.Len(t, mailer.tokens, 1)
+	require.NoError(t, service.ForgotPassword(ctx, ForgotPasswordRequest{Email: user.Email}))
+	var reset string
+	require.NoError(t, service.db.Model(&models.User{}).Where("id = ?", user.ID).Pluck("password_reset_token", &reset).Error)
+	require.NotEmpty(t, reset)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/orders", service.AuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	get := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for name, token := range map[string]string{"magic link": mailer.tokens[0], "password reset": reset} {
+		w := get(token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+		assert.Contains(t, w.Body.String(), "INVALID_TOKEN", name)
+		_, err := service.RefreshToken(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidToken, "a %s token cannot be exchanged for a session", name)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		_, ok := service.BearerUserID(&gin.Context{Request: req})
+		assert.False(t, ok, "a %s token does not identify a user to rate limits", name)
+	}
+
+	account := models.User{ID: user.ID, Email: user.Email, Role: user.Role}
+	tokens, err := service.GenerateTokens(&account)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, get(tokens.AccessToken).Code)
+}
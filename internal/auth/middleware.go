@@ -28,7 +28,7 @@ func (s *Service) AuthMiddleware() gin.HandlerFunc {
 		}
 
 		token := tokenParts[1]
-		claims, err := s.ValidateToken(token)
+		claims, err := s.ValidateAccessToken(token)
 		if err != nil {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired token", nil)
 			c.Abort()
@@ -45,7 +45,8 @@ func (s *Service) AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// AdminMiddleware ensures the user has admin role
+// AdminMiddleware ensures the user has admin role, signed in with a second factor when that is required, and
+// that their admin role covers the route
 func (s *Service) AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("user_role")
@@ -60,6 +61,9 @@ func (s *Service) AdminMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if !s.requireAdminMFA(c) || !s.requireAdminRole(c) {
+			return
+		}
 
 		c.Next()
 	}
@@ -82,7 +86,7 @@ func (s *Service) OptionalAuthMiddleware() gin.HandlerFunc {
 		}
 
 		token := tokenParts[1]
-		claims, err := s.ValidateToken(token)
+		claims, err := s.ValidateAccessToken(token)
 		if err != nil {
 			c.Next()
 			return
@@ -98,6 +102,20 @@ func (s *Service) OptionalAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// BearerUserID returns the user a request's bearer token was issued to, when the token is valid. It lets
+// per-user rate limits apply ahead of the route's own authentication.
+func (s *Service) BearerUserID(c *gin.Context) (string, bool) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return "", false
+	}
+	claims, err := s.ValidateAccessToken(token)
+	if err != nil {
+		return "", false
+	}
+	return claims.UserID, true
+}
+
 // RequireRoleMiddleware ensures the user has one of the specified roles
 func (s *Service) RequireRoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -111,6 +129,9 @@ func (s *Service) RequireRoleMiddleware(allowedRoles ...string) gin.HandlerFunc
 		userRole := role.(string)
 		for _, allowedRole := range allowedRoles {
 			if userRole == allowedRole {
+				if userRole == "admin" && (!s.requireAdminMFA(c) || !s.requireAdminRole(c)) {
+					return
+				}
 				c.Next()
 				return
 			}
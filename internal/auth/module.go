@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Module serves the sign-in, second factor and admin account routes. The service is one of the shared
+// dependencies every module authorizes requests through; this package therefore cannot import app, and the
+// module is registered through a factory in main.
+type Module struct {
+	handler *Handler
+	service *Service
+}
+
+// NewModule creates the auth module. cartMerger moves a guest cart into the account on sign in.
+func NewModule(service *Service, cartMerger CartMerger) *Module {
+	return &Module{handler: NewHandlerWithCartMerger(service, cartMerger), service: service}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "auth"
+}
+
+// RegisterAPIRoutes sets up the authentication and admin account routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.service)
+}
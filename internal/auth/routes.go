@@ -1,12 +1,14 @@
 package auth
 
 import (
+	"ecommerce-website/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes configures authentication routes
-func SetupRoutes(router *gin.Engine, handler *Handler, authService *Service) {
-	auth := router.Group("/api/auth")
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *Service) {
+	auth := api.Group("/auth")
 	{
 		auth.POST("/register", handler.Register)
 		auth.POST("/login", handler.Login)
@@ -15,8 +17,25 @@ func SetupRoutes(router *gin.Engine, handler *Handler, authService *Service) {
 		auth.POST("/logout", handler.Logout)
 		auth.POST("/forgot-password", handler.ForgotPassword)
 		auth.POST("/reset-password", handler.ResetPassword)
+		auth.POST("/magic-link", middleware.RateLimitMiddleware(middleware.MagicLinkRateLimit), handler.RequestMagicLink)
+		auth.POST("/magic-link/consume", middleware.RateLimitMiddleware(middleware.MagicLinkRateLimit), handler.ConsumeMagicLink)
 		auth.GET("/verify-email", handler.VerifyEmail)
 		auth.GET("/me", authService.AuthMiddleware(), handler.Me)
 		auth.POST("/resend-verification", authService.AuthMiddleware(), handler.ResendEmailVerification)
+		auth.POST("/2fa/setup", authService.AuthMiddleware(), handler.SetupTOTP)
+		auth.POST("/2fa/enable", authService.AuthMiddleware(), middleware.RateLimitMiddleware(middleware.TwoFactorRateLimit), handler.EnableTOTP)
+		auth.POST("/2fa/verify", authService.AuthMiddleware(), middleware.RateLimitMiddleware(middleware.TwoFactorRateLimit), handler.VerifyTOTP)
+	}
+
+	// Admin account management; only super admins pass AdminMiddleware here
+	adminUsers := api.Group("/admin/users")
+	adminUsers.Use(authService.AuthMiddleware())
+	adminUsers.Use(authService.AdminMiddleware())
+	{
+		adminUsers.GET("", handler.ListAdminUsers)
+		adminUsers.POST("", handler.InviteAdminUser)
+		adminUsers.PUT("/:id/role", handler.UpdateAdminRole)
+		adminUsers.POST("/:id/disable", handler.DisableAdminUser)
+		adminUsers.POST("/:id/enable", handler.EnableAdminUser)
 	}
 }
@@ -1,10 +1,13 @@
 package auth
 
 import (
+	"context"
 	"errors"
+	"sync"
 	"time"
 
 	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/logger"
 	"ecommerce-website/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,12 +18,28 @@ import (
 type Service struct {
 	db     *gorm.DB
 	config *config.Config
+	mailer MagicLinkMailer
+	// envAdminOTPStep is the last TOTP step the environment admin signed in with
+	envAdminOTPMu   sync.Mutex
+	envAdminOTPStep int64
 }
 
+// Session token types. Access tokens authenticate requests; refresh tokens only get a new pair of tokens.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// TokenType tells the access and refresh tokens of a session apart
+	TokenType string `json:"token_type,omitempty"`
+	// AdminRole limits the admin areas an admin token may use
+	AdminRole string `json:"admin_role,omitempty"`
+	// MFA is set on tokens issued after a second factor was checked at sign-in
+	MFA bool `json:"mfa,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -40,6 +59,11 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	// OTP is the current authenticator code, required once two-factor authentication is enabled
+	OTP string `json:"otp,omitempty"`
+	// ClientIP and UserAgent describe where the sign-in came from; set by the handler for the admin login audit
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type ForgotPasswordRequest struct {
@@ -52,8 +76,11 @@ type ResetPasswordRequest struct {
 }
 
 type AdminLoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required"`
+	OTP       string `json:"otp,omitempty"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 var (
@@ -72,10 +99,10 @@ func NewService(db *gorm.DB, config *config.Config) *Service {
 }
 
 // Register creates a new user account
-func (s *Service) Register(req RegisterRequest) (*models.User, error) {
+func (s *Service) Register(ctx context.Context, req RegisterRequest) (*models.User, error) {
 	// Check if user already exists
 	var existingUser models.User
-	if err := s.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+	if err := s.db.WithContext(ctx).Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
 		return nil, ErrUserExists
 	}
 
@@ -96,12 +123,12 @@ func (s *Service) Register(req RegisterRequest) (*models.User, error) {
 		IsActive:  true,
 	}
 
-	if err := s.db.Create(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Create(&user).Error; err != nil {
 		return nil, err
 	}
 
 	// Send email verification
-	if err := s.SendEmailVerification(user.ID); err != nil {
+	if err := s.SendEmailVerification(ctx, user.ID); err != nil {
 		// Log error but don't fail registration
 		// In production, you might want to handle this differently
 	}
@@ -112,10 +139,10 @@ func (s *Service) Register(req RegisterRequest) (*models.User, error) {
 }
 
 // Login authenticates a user and returns tokens
-func (s *Service) Login(req LoginRequest) (*models.User, *TokenPair, error) {
+func (s *Service) Login(ctx context.Context, req LoginRequest) (*models.User, *TokenPair, error) {
 	// Find user by email
 	var user models.User
-	if err := s.db.Where("email = ? AND is_active = ?", req.Email, true).First(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("email = ? AND is_active = ?", req.Email, true).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil, ErrInvalidCredentials
 		}
@@ -127,12 +154,24 @@ func (s *Service) Login(req LoginRequest) (*models.User, *TokenPair, error) {
 		return nil, nil, ErrInvalidCredentials
 	}
 
+	// Check the second factor once it is enabled
+	if user.TOTPEnabled {
+		if err := s.checkOTP(ctx, &user, req.OTP); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Generate tokens
-	tokens, err := s.GenerateTokens(&user)
+	tokens, err := s.generateTokens(&user, user.TOTPEnabled)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if user.Role == "admin" {
+		s.recordAdminLogin(ctx, &user, user.TOTPEnabled, req.ClientIP, req.UserAgent)
+	}
+	s.recordActivity(ctx, user.ID)
+
 	// Remove password from response
 	user.Password = ""
 	return &user, tokens, nil
@@ -140,13 +179,23 @@ func (s *Service) Login(req LoginRequest) (*models.User, *TokenPair, error) {
 
 // GenerateTokens creates access and refresh tokens for a user
 func (s *Service) GenerateTokens(user *models.User) (*TokenPair, error) {
-	// Access token (15 minutes)
+	return s.generateTokens(user, false)
+}
+
+// generateTokens creates access and refresh tokens, marking them as issued after a second factor when mfa is set
+func (s *Service) generateTokens(user *models.User, mfa bool) (*TokenPair, error) {
+	accessTTL, refreshTTL := s.tokenTTLs(user.Role)
+
+	// Access token (15 minutes, shorter for admins)
 	accessClaims := Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenType: TokenTypeAccess,
+		AdminRole: user.AdminRole,
+		MFA:       mfa,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   user.ID,
 		},
@@ -158,13 +207,16 @@ func (s *Service) GenerateTokens(user *models.User) (*TokenPair, error) {
 		return nil, err
 	}
 
-	// Refresh token (7 days)
+	// Refresh token (7 days, shorter for admins)
 	refreshClaims := Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenType: TokenTypeRefresh,
+		AdminRole: user.AdminRole,
+		MFA:       mfa,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(refreshTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   user.ID,
 		},
@@ -202,30 +254,65 @@ func (s *Service) ValidateToken(tokenString string) (*Claims, error) {
 	return nil, ErrInvalidToken
 }
 
+// purposeTokenRoles mark the single-purpose tokens emailed in sign-in, reset and verification links. They are
+// signed like session tokens but only redeem their link: they never authenticate a request or refresh a session.
+var purposeTokenRoles = map[string]bool{magicLinkRole: true, "password_reset": true, "email_verification": true}
+
+// ValidateAccessToken validates the access token of a signed-in session. Refresh tokens outlive access tokens,
+// so they are rejected along with purpose tokens.
+func (s *Service) ValidateAccessToken(tokenString string) (*Claims, error) {
+	return s.validateSessionToken(tokenString, TokenTypeAccess)
+}
+
+// validateSessionToken validates a session token of the given type, rejecting purpose tokens
+func (s *Service) validateSessionToken(tokenString, tokenType string) (*Claims, error) {
+	claims, err := s.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if purposeTokenRoles[claims.Role] || claims.TokenType != tokenType {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
 // RefreshToken generates new tokens using a valid refresh token
-func (s *Service) RefreshToken(refreshTokenString string) (*TokenPair, error) {
-	claims, err := s.ValidateToken(refreshTokenString)
+func (s *Service) RefreshToken(ctx context.Context, refreshTokenString string) (*TokenPair, error) {
+	claims, err := s.validateSessionToken(refreshTokenString, TokenTypeRefresh)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get user from database to ensure they still exist and are active
 	var user models.User
-	if err := s.db.Where("id = ? AND is_active = ?", claims.UserID, true).First(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", claims.UserID, true).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 
-	// Generate new tokens
-	return s.GenerateTokens(&user)
+	// Generate new tokens; the second factor carries over while it stays enabled
+	tokens, err := s.generateTokens(&user, claims.MFA && user.TOTPEnabled)
+	if err != nil {
+		return nil, err
+	}
+	s.recordActivity(ctx, user.ID)
+	return tokens, nil
+}
+
+// recordActivity stamps when a user last signed in or refreshed their session, which data retention uses to find
+// inactive accounts. It leaves updated_at alone; failures are logged and never fail the sign-in.
+func (s *Service) recordActivity(ctx context.Context, userID string) {
+	if err := s.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).UpdateColumn("last_active_at", time.Now()).Error; err != nil {
+		logger.Named("auth").Warn("Failed to record user activity", map[string]interface{}{"user_id": userID, "error": err.Error()})
+	}
 }
 
 // GetUserByID retrieves a user by ID
-func (s *Service) GetUserByID(userID string) (*models.User, error) {
+func (s *Service) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
 	var user models.User
-	if err := s.db.Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
 		}
@@ -238,10 +325,10 @@ func (s *Service) GetUserByID(userID string) (*models.User, error) {
 }
 
 // ForgotPassword generates a password reset token and sends reset email
-func (s *Service) ForgotPassword(req ForgotPasswordRequest) error {
+func (s *Service) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error {
 	// Find user by email
 	var user models.User
-	if err := s.db.Where("email = ? AND is_active = ?", req.Email, true).First(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("email = ? AND is_active = ?", req.Email, true).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// Don't reveal if email exists or not for security
 			return nil
@@ -269,7 +356,7 @@ func (s *Service) ForgotPassword(req ForgotPasswordRequest) error {
 
 	// Store reset token and expiry in database
 	expiryTime := time.Now().Add(1 * time.Hour)
-	if err := s.db.Model(&user).Updates(map[string]interface{}{
+	if err := s.db.WithContext(ctx).Model(&user).Updates(map[string]interface{}{
 		"password_reset_token":  resetTokenString,
 		"password_reset_expiry": expiryTime,
 	}).Error; err != nil {
@@ -284,7 +371,7 @@ func (s *Service) ForgotPassword(req ForgotPasswordRequest) error {
 }
 
 // ResetPassword resets user password using a valid reset token
-func (s *Service) ResetPassword(req ResetPasswordRequest) error {
+func (s *Service) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
 	// Validate reset token
 	claims, err := s.ValidateToken(req.Token)
 	if err != nil {
@@ -298,7 +385,7 @@ func (s *Service) ResetPassword(req ResetPasswordRequest) error {
 
 	// Find user and verify reset token
 	var user models.User
-	if err := s.db.Where("id = ? AND is_active = ? AND password_reset_token = ?",
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ? AND password_reset_token = ?",
 		claims.UserID, true, req.Token).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrInvalidToken
@@ -318,7 +405,7 @@ func (s *Service) ResetPassword(req ResetPasswordRequest) error {
 	}
 
 	// Update password and clear reset token
-	if err := s.db.Model(&user).Updates(map[string]interface{}{
+	if err := s.db.WithContext(ctx).Model(&user).Updates(map[string]interface{}{
 		"password":              string(hashedPassword),
 		"password_reset_token":  nil,
 		"password_reset_expiry": nil,
@@ -330,9 +417,9 @@ func (s *Service) ResetPassword(req ResetPasswordRequest) error {
 }
 
 // SendEmailVerification generates and sends email verification token
-func (s *Service) SendEmailVerification(userID string) error {
+func (s *Service) SendEmailVerification(ctx context.Context, userID string) error {
 	var user models.User
-	if err := s.db.Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrUserNotFound
 		}
@@ -363,7 +450,7 @@ func (s *Service) SendEmailVerification(userID string) error {
 	}
 
 	// Store verification token in database
-	if err := s.db.Model(&user).Update("email_verification_token", verificationTokenString).Error; err != nil {
+	if err := s.db.WithContext(ctx).Model(&user).Update("email_verification_token", verificationTokenString).Error; err != nil {
 		return err
 	}
 
@@ -375,7 +462,7 @@ func (s *Service) SendEmailVerification(userID string) error {
 }
 
 // VerifyEmail verifies user email using verification token
-func (s *Service) VerifyEmail(token string) error {
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
 	// Validate verification token
 	claims, err := s.ValidateToken(token)
 	if err != nil {
@@ -389,7 +476,7 @@ func (s *Service) VerifyEmail(token string) error {
 
 	// Find user and verify token
 	var user models.User
-	if err := s.db.Where("id = ? AND is_active = ? AND email_verification_token = ?",
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ? AND email_verification_token = ?",
 		claims.UserID, true, token).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrInvalidToken
@@ -398,7 +485,7 @@ func (s *Service) VerifyEmail(token string) error {
 	}
 
 	// Mark email as verified and clear verification token
-	if err := s.db.Model(&user).Updates(map[string]interface{}{
+	if err := s.db.WithContext(ctx).Model(&user).Updates(map[string]interface{}{
 		"email_verified":           true,
 		"email_verification_token": nil,
 	}).Error; err != nil {
@@ -408,13 +495,33 @@ func (s *Service) VerifyEmail(token string) error {
 	return nil
 }
 
-// AdminLogin authenticates an admin using environment credentials
-func (s *Service) AdminLogin(req AdminLoginRequest) (*models.User, *TokenPair, error) {
+// AdminLogin authenticates an admin account, falling back to the environment credentials, which act as a
+// super admin for bootstrapping the first admin accounts
+func (s *Service) AdminLogin(ctx context.Context, req AdminLoginRequest) (*models.User, *TokenPair, error) {
+	var account models.User
+	err := s.db.WithContext(ctx).Where("email = ? AND role = ?", req.Email, "admin").First(&account).Error
+	if err == nil {
+		return s.adminAccountLogin(ctx, &account, req)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
 	// Check against environment credentials
-	if req.Email != s.config.AdminEmail || req.Password != s.config.AdminPassword {
+	if s.config.AdminEmail == "" || req.Email != s.config.AdminEmail || req.Password != s.config.AdminPassword {
 		return nil, nil, ErrInvalidCredentials
 	}
 
+	// The environment admin's second factor is the configured TOTP secret
+	mfa := s.config.AdminTOTPSecret != ""
+	if mfa {
+		if err := s.checkEnvAdminOTP(req.OTP); err != nil {
+			return nil, nil, err
+		}
+	} else if s.config.AdminRequireMFA {
+		return nil, nil, ErrMFANotEnrolled
+	}
+
 	// Create a virtual admin user for token generation
 	adminUser := &models.User{
 		ID:        "admin-user",
@@ -422,14 +529,45 @@ func (s *Service) AdminLogin(req AdminLoginRequest) (*models.User, *TokenPair, e
 		FirstName: "Admin",
 		LastName:  "User",
 		Role:      "admin",
+		AdminRole: models.AdminRoleSuper,
 		IsActive:  true,
 	}
 
 	// Generate tokens
-	tokens, err := s.GenerateTokens(adminUser)
+	tokens, err := s.generateTokens(adminUser, mfa)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	s.recordAdminLogin(ctx, adminUser, mfa, req.ClientIP, req.UserAgent)
 	return adminUser, tokens, nil
 }
+
+// adminAccountLogin signs in a stored admin account; disabled accounts are rejected like wrong passwords
+func (s *Service) adminAccountLogin(ctx context.Context, user *models.User, req AdminLoginRequest) (*models.User, *TokenPair, error) {
+	if !user.IsActive {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if user.TOTPEnabled {
+		if err := s.checkOTP(ctx, user, req.OTP); err != nil {
+			return nil, nil, err
+		}
+	} else if s.config.AdminRequireMFA {
+		return nil, nil, ErrMFANotEnrolled
+	}
+
+	tokens, err := s.generateTokens(user, user.TOTPEnabled)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.recordAdminLogin(ctx, user, user.TOTPEnabled, req.ClientIP, req.UserAgent)
+	s.recordActivity(ctx, user.ID)
+
+	user.Password = ""
+	user.AdminRole = effectiveAdminRole(user.AdminRole)
+	return user, tokens, nil
+}
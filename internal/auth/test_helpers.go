@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/models"
 
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
@@ -20,11 +21,21 @@ type TestUser struct {
 	LastName               string     `json:"lastName" gorm:"not null"`
 	Phone                  *string    `json:"phone,omitempty"`
 	Role                   string     `json:"role" gorm:"default:'customer'"`
+	AdminRole              string     `json:"adminRole,omitempty" gorm:"not null;default:''"`
 	IsActive               bool       `json:"isActive" gorm:"default:true"`
 	EmailVerified          bool       `json:"emailVerified" gorm:"default:false"`
 	EmailVerificationToken *string    `json:"-" gorm:"type:varchar(255)"`
 	PasswordResetToken     *string    `json:"-" gorm:"type:varchar(255)"`
 	PasswordResetExpiry    *time.Time `json:"-"`
+	MarketingOptOut        bool       `json:"marketingOptOut" gorm:"default:false"`
+	EmailBounced           bool       `json:"emailBounced" gorm:"default:false"`
+	OrderEmailOptOut       bool       `json:"orderEmailOptOut" gorm:"default:false"`
+	OrderSMSOptIn          bool       `json:"orderSmsOptIn" gorm:"default:false"`
+	TOTPSecret             *string    `json:"-" gorm:"type:varchar(64)"`
+	TOTPEnabled            bool       `json:"totpEnabled" gorm:"default:false"`
+	TOTPLastStep           *int64     `json:"-"`
+	LastActiveAt           *time.Time `json:"lastActiveAt,omitempty"`
+	AnonymizedAt           *time.Time `json:"anonymizedAt,omitempty"`
 	CreatedAt              time.Time  `json:"createdAt"`
 	UpdatedAt              time.Time  `json:"updatedAt"`
 }
@@ -38,7 +49,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	require.NoError(t, err)
 
 	// Migrate the schema
-	err = db.AutoMigrate(&TestUser{})
+	err = db.AutoMigrate(&TestUser{}, &models.AdminLoginEvent{}, &models.MagicLinkToken{})
 	require.NoError(t, err)
 
 	return db
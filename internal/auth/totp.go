@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// totpStep is the RFC 6238 time step
+	totpStep = 30 * time.Second
+	// totpDigits is the length of generated codes
+	totpDigits = 6
+	// totpSkew is how many steps before and after the current one are accepted, for clock drift
+	totpSkew = 1
+	// totpIssuer names the account in authenticator apps
+	totpIssuer = "Ecommerce Admin"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret returns a random base32 secret for an authenticator app
+func generateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// totpURL returns the otpauth:// URL authenticator apps scan to add the account
+func totpURL(secret, account string) string {
+	label := url.PathEscape(totpIssuer + ":" + account)
+	query := url.Values{"secret": {secret}, "issuer": {totpIssuer}}
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// totpCode computes the code of a base32 secret for the time step containing t
+func totpCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(strings.ReplaceAll(secret, " ", ""), "=")))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(totpCounter(t)))
+	h := hmac.New(sha1.New, key)
+	h.Write(counter[:])
+	sum := h.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// verifyTOTP returns the time step code is valid for with secret at now, allowing totpSkew steps of clock
+// drift, and false when it is not valid
+func verifyTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	for skew := -totpSkew; skew <= totpSkew; skew++ {
+		at := now.Add(time.Duration(skew) * totpStep)
+		expected, err := totpCode(secret, at)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return totpCounter(at), true
+		}
+	}
+	return 0, false
+}
+
+// totpCounter returns the RFC 6238 time step containing t
+func totpCounter(t time.Time) int64 {
+	return t.Unix() / int64(totpStep/time.Second)
+}
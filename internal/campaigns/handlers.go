@@ -0,0 +1,104 @@
+package campaigns
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// trackingPixel is a transparent 1x1 GIF returned for open tracking requests
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new campaign handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// CreateCampaign handles POST /api/admin/campaigns (admin only)
+func (h *Handler) CreateCampaign(c *gin.Context) {
+	var req CreateCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	campaign, err := h.service.CreateCampaign(req, c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTemplate):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_TEMPLATE", "Email template is invalid", err.Error())
+		case errors.Is(err, ErrNoRecipients):
+			utils.ErrorResponse(c, http.StatusUnprocessableEntity, "EMPTY_SEGMENT", "No customers match the segment", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "CREATE_CAMPAIGN_FAILED", "Failed to create campaign", err.Error())
+		}
+		return
+	}
+	utils.SuccessResponse(c, http.StatusAccepted, "Campaign queued successfully", campaign)
+}
+
+// ListCampaigns handles GET /api/admin/campaigns (admin only)
+func (h *Handler) ListCampaigns(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	campaigns, total, err := h.service.ListCampaigns(page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_CAMPAIGNS_FAILED", "Failed to get campaigns", err.Error())
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Campaigns retrieved successfully", "campaigns", campaigns, utils.NewPagination(page, limit, total))
+}
+
+// GetReport handles GET /api/admin/campaigns/:id/report (admin only)
+func (h *Handler) GetReport(c *gin.Context) {
+	report, err := h.service.GetReport(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrCampaignNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "CAMPAIGN_NOT_FOUND", "Campaign not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_CAMPAIGN_REPORT_FAILED", "Failed to get campaign report", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Campaign report retrieved successfully", report)
+}
+
+// CancelCampaign handles POST /api/admin/campaigns/:id/cancel (admin only)
+func (h *Handler) CancelCampaign(c *gin.Context) {
+	campaign, err := h.service.CancelCampaign(c.Param("id"), time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrCampaignNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "CAMPAIGN_NOT_FOUND", "Campaign not found", nil)
+		case errors.Is(err, ErrCampaignFinished):
+			utils.ErrorResponse(c, http.StatusConflict, "CAMPAIGN_FINISHED", "Campaign has already finished", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "CANCEL_CAMPAIGN_FAILED", "Failed to cancel campaign", err.Error())
+		}
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Campaign cancelled successfully", campaign)
+}
+
+// TrackOpen handles GET /api/campaigns/open/:token, the open tracking pixel embedded in campaign emails.
+// The pixel is returned whatever the token so it reveals nothing about recipients.
+func (h *Handler) TrackOpen(c *gin.Context) {
+	if err := h.service.RecordOpen(c.Param("token"), time.Now()); err != nil && !errors.Is(err, ErrInvalidToken) {
+		logger.Named("campaigns").Warn("Failed to record campaign open", map[string]interface{}{"error": err.Error()})
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/gif", trackingPixel)
+}
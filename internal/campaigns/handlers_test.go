@@ -0,0 +1,104 @@
+package campaigns
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the campaign service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) CreateCampaign(req CreateCampaignRequest, createdBy string) (*models.EmailCampaign, error) {
+	args := m.Called(req, createdBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EmailCampaign), args.Error(1)
+}
+
+func (m *MockService) ListCampaigns(page, limit int) ([]models.EmailCampaign, int64, error) {
+	args := m.Called(page, limit)
+	return args.Get(0).([]models.EmailCampaign), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockService) CancelCampaign(id string, now time.Time) (*models.EmailCampaign, error) {
+	args := m.Called(id, mock.Anything)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EmailCampaign), args.Error(1)
+}
+
+func (m *MockService) GetReport(id string) (*Report, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Report), args.Error(1)
+}
+
+func (m *MockService) RecordOpen(token string, now time.Time) error {
+	return m.Called(token, mock.Anything).Error(0)
+}
+
+func TestHandler_Campaigns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := new(MockService)
+	handler := NewHandler(service)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", "admin-1") })
+	r.POST("/campaigns", handler.CreateCampaign)
+	r.GET("/campaigns/:id/report", handler.GetReport)
+	r.GET("/open/:token", handler.TrackOpen)
+
+	req := CreateCampaignRequest{Name: "Sale", Subject: "Sale", Body: "Hi {{.FirstName}}", TrackOpens: true}
+	service.On("CreateCampaign", req, "admin-1").Return(&models.EmailCampaign{ID: "c1", Status: models.CampaignQueued, Recipients: 3}, nil)
+	empty := CreateCampaignRequest{Name: "Empty", Subject: "Sale", Body: "Hi"}
+	service.On("CreateCampaign", empty, "admin-1").Return(nil, ErrNoRecipients)
+	opened := int64(1)
+	service.On("GetReport", "c1").Return(&Report{Sent: 2, Opened: &opened, Conversions: 1}, nil)
+	service.On("GetReport", "missing").Return(nil, ErrCampaignNotFound)
+	service.On("RecordOpen", "token", mock.Anything).Return(ErrInvalidToken)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/campaigns", strings.NewReader(`{"name":"Sale","subject":"Sale","body":"Hi {{.FirstName}}","trackOpens":true}`)))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"recipients":3`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/campaigns", strings.NewReader(`{"name":"Empty","subject":"Sale","body":"Hi"}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "EMPTY_SEGMENT")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/campaigns", strings.NewReader(`{"name":"Sale","subject":"Sale","body":"Hi","batchSize":5000}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/campaigns/c1/report", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"opened":1`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/campaigns/missing/report", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/open/token", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/gif", w.Header().Get("Content-Type"))
+	assert.Equal(t, trackingPixel, w.Body.Bytes())
+
+	service.AssertExpectations(t)
+}
@@ -0,0 +1,51 @@
+package campaigns
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/email"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// workerInterval is how often the worker sends the next batch of running campaigns
+const workerInterval = 30 * time.Second
+
+// Module wires bulk customer email campaigns into the application
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the campaigns module
+func NewModule(deps app.Deps) app.Module {
+	mailer := email.NewServiceWithSettings(settings.NewService(deps.DB)).WithLog(deps.DB)
+	service := NewService(deps.DB, mailer, deps.Config.APIBaseURL, deps.Config.StorefrontURL)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "campaigns"
+}
+
+// Models returns the campaign tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.EmailCampaign{}, &models.EmailCampaignRecipient{}, &models.EmailSuppression{}}
+}
+
+// StartJobs sends queued campaigns in the background
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartWorker(ctx, workerInterval)
+}
+
+// RegisterAPIRoutes sets up the campaign routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
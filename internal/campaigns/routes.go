@@ -0,0 +1,22 @@
+package campaigns
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin campaign routes and the public open tracking pixel
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	api.GET("/campaigns/open/:token", handler.TrackOpen)
+
+	campaigns := api.Group("/admin/campaigns")
+	campaigns.Use(authService.AuthMiddleware())
+	campaigns.Use(authService.AdminMiddleware())
+	{
+		campaigns.POST("", handler.CreateCampaign)
+		campaigns.GET("", handler.ListCampaigns)
+		campaigns.GET("/:id/report", handler.GetReport)
+		campaigns.POST("/:id/cancel", handler.CancelCampaign)
+	}
+}
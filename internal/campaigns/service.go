@@ -0,0 +1,456 @@
+package campaigns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultBatchSize is how many recipients the worker handles per campaign and tick when not set
+	DefaultBatchSize = 100
+	// DefaultRatePerMinute throttles sending when the campaign does not set a rate
+	DefaultRatePerMinute = 60
+	// AttributionWindow is how long after an email an order counts as a conversion of the campaign
+	AttributionWindow = 7 * 24 * time.Hour
+)
+
+// Suppression reasons recorded on recipients besides those of the suppression list
+const (
+	SuppressedOptOut   = "marketing_opt_out"
+	SuppressedInactive = "inactive_account"
+)
+
+// conversionStatuses are the order statuses that count as a conversion
+var conversionStatuses = []string{"paid", "processing", "shipped", "delivered"}
+
+var (
+	ErrCampaignNotFound = errors.New("campaign not found")
+	ErrInvalidTemplate  = errors.New("invalid email template")
+	ErrNoRecipients     = errors.New("segment has no recipients")
+	ErrCampaignFinished = errors.New("campaign already finished")
+	ErrInvalidToken     = errors.New("invalid open tracking token")
+)
+
+// Mailer sends rendered campaign emails
+type Mailer interface {
+	SendCampaignEmail(to, subject, body string) error
+}
+
+// Segment selects the active customers a campaign is sent to. Unset fields do not filter, so an empty
+// segment is every active customer. Order filters only count paid and later orders.
+type Segment struct {
+	MinOrders *int64   `json:"minOrders,omitempty" binding:"omitempty,min=0"`
+	MaxOrders *int64   `json:"maxOrders,omitempty" binding:"omitempty,min=0"`
+	MinSpent  *float64 `json:"minSpent,omitempty" binding:"omitempty,min=0"`
+	// OrderedSince keeps customers with an order on or after the time; NoOrderSince those without one
+	OrderedSince   *time.Time `json:"orderedSince,omitempty"`
+	NoOrderSince   *time.Time `json:"noOrderSince,omitempty"`
+	SignedUpAfter  *time.Time `json:"signedUpAfter,omitempty"`
+	SignedUpBefore *time.Time `json:"signedUpBefore,omitempty"`
+}
+
+// CreateCampaignRequest queues a campaign. Body is an html/template rendered per recipient with
+// FirstName, LastName, Email and PreferencesURL.
+type CreateCampaignRequest struct {
+	Name          string  `json:"name" binding:"required,max=200"`
+	Subject       string  `json:"subject" binding:"required,max=200"`
+	Body          string  `json:"body" binding:"required"`
+	Segment       Segment `json:"segment"`
+	TrackOpens    bool    `json:"trackOpens"`
+	BatchSize     int     `json:"batchSize" binding:"omitempty,min=1,max=1000"`
+	RatePerMinute int     `json:"ratePerMinute" binding:"omitempty,min=1,max=600"`
+}
+
+// Report summarizes the delivery and outcome of a campaign. Opened is only set when opens are tracked.
+type Report struct {
+	Campaign           *models.EmailCampaign `json:"campaign"`
+	Recipients         int64                 `json:"recipients"`
+	Pending            int64                 `json:"pending"`
+	Sent               int64                 `json:"sent"`
+	Suppressed         int64                 `json:"suppressed"`
+	Failed             int64                 `json:"failed"`
+	SuppressedBy       map[string]int64      `json:"suppressedBy"`
+	Opened             *int64                `json:"opened,omitempty"`
+	OpenRate           *float64              `json:"openRate,omitempty"`
+	Conversions        int64                 `json:"conversions"`
+	ConvertedCustomers int64                 `json:"convertedCustomers"`
+	ConversionRevenue  float64               `json:"conversionRevenue"`
+	AttributionDays    int                   `json:"attributionDays"`
+}
+
+// ServiceInterface defines the campaign operations used by the handlers
+type ServiceInterface interface {
+	CreateCampaign(req CreateCampaignRequest, createdBy string) (*models.EmailCampaign, error)
+	ListCampaigns(page, limit int) ([]models.EmailCampaign, int64, error)
+	CancelCampaign(id string, now time.Time) (*models.EmailCampaign, error)
+	GetReport(id string) (*Report, error)
+	RecordOpen(token string, now time.Time) error
+}
+
+type Service struct {
+	db         *gorm.DB
+	mailer     Mailer
+	apiBaseURL string
+	storefront string
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// NewService creates a campaign service. apiBaseURL is where open tracking pixels are served from and
+// storefrontURL is linked from emails for customers to change their preferences.
+func NewService(db *gorm.DB, mailer Mailer, apiBaseURL, storefrontURL string) *Service {
+	return &Service{
+		db:         db,
+		mailer:     mailer,
+		apiBaseURL: strings.TrimSuffix(apiBaseURL, "/"),
+		storefront: strings.TrimSuffix(storefrontURL, "/"),
+		sleep:      sleepContext,
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// templateData is what campaign bodies are rendered with
+type templateData struct {
+	FirstName      string
+	LastName       string
+	Email          string
+	PreferencesURL string
+}
+
+// CreateCampaign validates the template, resolves the segment and queues the campaign for the worker.
+// Recipients already opted out or on the suppression list are recorded as suppressed straight away.
+func (s *Service) CreateCampaign(req CreateCampaignRequest, createdBy string) (*models.EmailCampaign, error) {
+	tmpl, err := template.New("campaign").Parse(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, templateData{}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	users, err := s.resolveSegment(req.Segment)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	segment, err := segmentJSON(req.Segment)
+	if err != nil {
+		return nil, err
+	}
+	campaign := &models.EmailCampaign{
+		Name:          req.Name,
+		Subject:       req.Subject,
+		Body:          req.Body,
+		Segment:       segment,
+		TrackOpens:    req.TrackOpens,
+		BatchSize:     req.BatchSize,
+		RatePerMinute: req.RatePerMinute,
+		Status:        models.CampaignQueued,
+		Recipients:    len(users),
+		CreatedBy:     createdBy,
+	}
+	if campaign.BatchSize == 0 {
+		campaign.BatchSize = DefaultBatchSize
+	}
+	if campaign.RatePerMinute == 0 {
+		campaign.RatePerMinute = DefaultRatePerMinute
+	}
+
+	suppressed, err := s.suppressedEmails(users)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(campaign).Error; err != nil {
+			return fmt.Errorf("failed to create campaign: %w", err)
+		}
+		recipients := make([]models.EmailCampaignRecipient, 0, len(users))
+		for _, user := range users {
+			recipient := models.EmailCampaignRecipient{CampaignID: campaign.ID, UserID: user.ID, Email: user.Email, Status: models.CampaignRecipientPending}
+			if reason := suppressionReason(&user, suppressed); reason != "" {
+				recipient.Status = models.CampaignRecipientSuppressed
+				recipient.SuppressionReason = reason
+			}
+			recipients = append(recipients, recipient)
+		}
+		if err := tx.CreateInBatches(&recipients, 500).Error; err != nil {
+			return fmt.Errorf("failed to create campaign recipients: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Named("campaigns").Info("Campaign queued", map[string]interface{}{"campaign_id": campaign.ID, "recipients": campaign.Recipients, "created_by": createdBy})
+	return campaign, nil
+}
+
+// resolveSegment returns the active customers matching segment
+func (s *Service) resolveSegment(segment Segment) ([]models.User, error) {
+	const paidOrders = "FROM orders WHERE orders.user_id = users.id AND orders.status IN ?"
+
+	db := s.db.Model(&models.User{}).Select("id, email, first_name, last_name, is_active, marketing_opt_out").
+		Where("role = ? AND is_active = ?", "customer", true)
+	if segment.MinOrders != nil {
+		db = db.Where("(SELECT COUNT(*) "+paidOrders+") >= ?", conversionStatuses, *segment.MinOrders)
+	}
+	if segment.MaxOrders != nil {
+		db = db.Where("(SELECT COUNT(*) "+paidOrders+") <= ?", conversionStatuses, *segment.MaxOrders)
+	}
+	if segment.MinSpent != nil {
+		db = db.Where("(SELECT COALESCE(SUM(orders.total), 0) "+paidOrders+") >= ?", conversionStatuses, *segment.MinSpent)
+	}
+	if segment.OrderedSince != nil {
+		db = db.Where("EXISTS (SELECT 1 "+paidOrders+" AND orders.created_at >= ?)", conversionStatuses, *segment.OrderedSince)
+	}
+	if segment.NoOrderSince != nil {
+		db = db.Where("NOT EXISTS (SELECT 1 "+paidOrders+" AND orders.created_at >= ?)", conversionStatuses, *segment.NoOrderSince)
+	}
+	if segment.SignedUpAfter != nil {
+		db = db.Where("created_at >= ?", *segment.SignedUpAfter)
+	}
+	if segment.SignedUpBefore != nil {
+		db = db.Where("created_at < ?", *segment.SignedUpBefore)
+	}
+
+	var users []models.User
+	if err := db.Order("created_at ASC").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve segment: %w", err)
+	}
+	return users, nil
+}
+
+// segmentJSON stores segment as the JSON document of the campaign
+func segmentJSON(segment Segment) (models.JSONB, error) {
+	raw, err := json.Marshal(segment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode segment: %w", err)
+	}
+	result := models.JSONB{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to encode segment: %w", err)
+	}
+	return result, nil
+}
+
+// suppressedEmails returns the suppression list reasons of the users' addresses, keyed by lower-cased email
+func (s *Service) suppressedEmails(users []models.User) (map[string]string, error) {
+	emails := make([]string, 0, len(users))
+	for _, user := range users {
+		emails = append(emails, strings.ToLower(user.Email))
+	}
+
+	result := map[string]string{}
+	for start := 0; start < len(emails); start += 500 {
+		end := start + 500
+		if end > len(emails) {
+			end = len(emails)
+		}
+		var suppressions []models.EmailSuppression
+		if err := s.db.Where("email IN ?", emails[start:end]).Find(&suppressions).Error; err != nil {
+			return nil, fmt.Errorf("failed to check suppressions: %w", err)
+		}
+		for _, suppression := range suppressions {
+			result[suppression.Email] = suppression.Reason
+		}
+	}
+	return result, nil
+}
+
+// suppressionReason returns why user must not get marketing email, or "" when they may
+func suppressionReason(user *models.User, suppressed map[string]string) string {
+	switch {
+	case !user.IsActive:
+		return SuppressedInactive
+	case user.MarketingOptOut:
+		return SuppressedOptOut
+	}
+	return suppressed[strings.ToLower(user.Email)]
+}
+
+// ListCampaigns returns campaigns, newest first
+func (s *Service) ListCampaigns(page, limit int) ([]models.EmailCampaign, int64, error) {
+	var total int64
+	if err := s.db.Model(&models.EmailCampaign{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count campaigns: %w", err)
+	}
+	campaigns := []models.EmailCampaign{}
+	if err := s.db.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&campaigns).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get campaigns: %w", err)
+	}
+	return campaigns, total, nil
+}
+
+// CancelCampaign stops a campaign that has not finished; recipients already emailed stay sent
+func (s *Service) CancelCampaign(id string, now time.Time) (*models.EmailCampaign, error) {
+	result := s.db.Model(&models.EmailCampaign{}).
+		Where("id = ? AND status IN ?", id, []string{models.CampaignQueued, models.CampaignSending}).
+		Updates(map[string]interface{}{"status": models.CampaignCancelled, "completed_at": now})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to cancel campaign: %w", result.Error)
+	}
+	campaign, err := s.getCampaign(id)
+	if err != nil {
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrCampaignFinished
+	}
+	return campaign, nil
+}
+
+// getCampaign loads a campaign by ID
+func (s *Service) getCampaign(id string) (*models.EmailCampaign, error) {
+	var campaign models.EmailCampaign
+	if err := s.db.First(&campaign, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, fmt.Errorf("failed to get campaign: %w", err)
+	}
+	return &campaign, nil
+}
+
+// RecordOpen marks the recipient of an open tracking token as having opened the email. Only the first
+// open is kept.
+func (s *Service) RecordOpen(token string, now time.Time) error {
+	result := s.db.Model(&models.EmailCampaignRecipient{}).
+		Where("open_token = ? AND status = ?", token, models.CampaignRecipientSent).
+		Update("opened_at", gorm.Expr("COALESCE(opened_at, ?)", now))
+	if result.Error != nil {
+		return fmt.Errorf("failed to record open: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// GetReport counts what happened to the recipients of a campaign. An order is a conversion when the
+// recipient placed it, and it reached paid, within AttributionWindow after their email was sent.
+func (s *Service) GetReport(id string) (*Report, error) {
+	campaign, err := s.getCampaign(id)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Campaign: campaign, SuppressedBy: map[string]int64{}, AttributionDays: int(AttributionWindow / (24 * time.Hour))}
+
+	var counts []struct {
+		Status            string
+		SuppressionReason string
+		Count             int64
+	}
+	if err := s.db.Model(&models.EmailCampaignRecipient{}).
+		Select("status, suppression_reason, COUNT(*) AS count").
+		Where("campaign_id = ?", id).
+		Group("status, suppression_reason").Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count recipients: %w", err)
+	}
+	for _, row := range counts {
+		report.Recipients += row.Count
+		switch row.Status {
+		case models.CampaignRecipientPending:
+			report.Pending += row.Count
+		case models.CampaignRecipientSent:
+			report.Sent += row.Count
+		case models.CampaignRecipientSuppressed:
+			report.Suppressed += row.Count
+			report.SuppressedBy[row.SuppressionReason] += row.Count
+		case models.CampaignRecipientFailed:
+			report.Failed += row.Count
+		}
+	}
+
+	if campaign.TrackOpens {
+		var opened int64
+		if err := s.db.Model(&models.EmailCampaignRecipient{}).
+			Where("campaign_id = ? AND opened_at IS NOT NULL", id).Count(&opened).Error; err != nil {
+			return nil, fmt.Errorf("failed to count opens: %w", err)
+		}
+		report.Opened = &opened
+		if report.Sent > 0 {
+			rate := float64(opened) / float64(report.Sent)
+			report.OpenRate = &rate
+		}
+	}
+
+	if err := s.attributeConversions(id, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// attributeConversions adds the orders placed by emailed recipients within the attribution window to report
+func (s *Service) attributeConversions(campaignID string, report *Report) error {
+	var recipients []models.EmailCampaignRecipient
+	if err := s.db.Select("user_id, sent_at").
+		Where("campaign_id = ? AND status = ? AND sent_at IS NOT NULL", campaignID, models.CampaignRecipientSent).
+		Find(&recipients).Error; err != nil {
+		return fmt.Errorf("failed to get campaign recipients: %w", err)
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	sentAt := make(map[string]time.Time, len(recipients))
+	userIDs := make([]string, 0, len(recipients))
+	earliest := *recipients[0].SentAt
+	for _, recipient := range recipients {
+		sentAt[recipient.UserID] = *recipient.SentAt
+		userIDs = append(userIDs, recipient.UserID)
+		if recipient.SentAt.Before(earliest) {
+			earliest = *recipient.SentAt
+		}
+	}
+
+	converted := map[string]bool{}
+	for start := 0; start < len(userIDs); start += 500 {
+		end := start + 500
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		var orders []models.Order
+		if err := s.db.Select("id, user_id, total, created_at").
+			Where("user_id IN ? AND status IN ? AND created_at >= ?", userIDs[start:end], conversionStatuses, earliest).
+			Find(&orders).Error; err != nil {
+			return fmt.Errorf("failed to get campaign orders: %w", err)
+		}
+		for _, order := range orders {
+			sent := sentAt[order.UserID]
+			if order.CreatedAt.Before(sent) || !order.CreatedAt.Before(sent.Add(AttributionWindow)) {
+				continue
+			}
+			report.Conversions++
+			report.ConversionRevenue += order.Total
+			converted[order.UserID] = true
+		}
+	}
+	report.ConvertedCustomers = int64(len(converted))
+	return nil
+}
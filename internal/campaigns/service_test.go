@@ -0,0 +1,217 @@
+package campaigns
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeMailer records campaign emails and fails for the addresses in fail
+type fakeMailer struct {
+	sent map[string]string
+	fail map[string]bool
+}
+
+func (m *fakeMailer) SendCampaignEmail(to, subject, body string) error {
+	if m.fail[to] {
+		return errors.New("smtp unavailable")
+	}
+	m.sent[to] = body
+	return nil
+}
+
+// newTestService returns a service over a fresh database that never actually waits between sends
+func newTestService(t *testing.T) (*Service, *fakeMailer, *[]time.Duration) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	mailer := &fakeMailer{sent: map[string]string{}, fail: map[string]bool{}}
+	service := NewService(database.GetDB(), mailer, "https://api.example.com/", "https://shop.example.com")
+	waits := []time.Duration{}
+	service.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return service, mailer, &waits
+}
+
+func createCustomer(t *testing.T, db *gorm.DB, email string, mutate func(*models.User)) models.User {
+	user := models.User{Email: email, Password: "hashed", FirstName: strings.Split(email, "@")[0], LastName: "Customer", Role: "customer", IsActive: true}
+	if mutate != nil {
+		mutate(&user)
+	}
+	// is_active defaults to true, so a false value is only kept by updating it after creation
+	active := user.IsActive
+	require.NoError(t, db.Create(&user).Error)
+	if !active {
+		require.NoError(t, db.Model(&user).Update("is_active", false).Error)
+	}
+	return user
+}
+
+func createOrder(t *testing.T, db *gorm.DB, userID, status string, total float64, at time.Time) {
+	require.NoError(t, db.Create(&models.Order{UserID: userID, Status: status, Subtotal: total, Total: total, CreatedAt: at}).Error)
+}
+
+func TestService_CreateCampaign_Segment(t *testing.T) {
+	service, _, _ := newTestService(t)
+	db := database.GetDB()
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	loyal := createCustomer(t, db, "loyal@example.com", nil)
+	lapsed := createCustomer(t, db, "lapsed@example.com", nil)
+	createCustomer(t, db, "new@example.com", nil)
+	createCustomer(t, db, "inactive@example.com", func(u *models.User) { u.IsActive = false })
+	createOrder(t, db, loyal.ID, "delivered", 300, march)
+	createOrder(t, db, loyal.ID, "paid", 200, march.AddDate(0, 1, 0))
+	createOrder(t, db, lapsed.ID, "delivered", 50, march.AddDate(-1, 0, 0))
+	createOrder(t, db, lapsed.ID, "cancelled", 500, march)
+
+	names := func(segment Segment) []string {
+		users, err := service.resolveSegment(segment)
+		require.NoError(t, err)
+		result := []string{}
+		for _, user := range users {
+			result = append(result, user.FirstName)
+		}
+		return result
+	}
+	one, two := int64(1), int64(2)
+	spent := 400.0
+
+	assert.ElementsMatch(t, []string{"loyal", "lapsed", "new"}, names(Segment{}))
+	assert.Equal(t, []string{"loyal"}, names(Segment{MinOrders: &two}))
+	assert.ElementsMatch(t, []string{"lapsed", "new"}, names(Segment{MaxOrders: &one}))
+	assert.Equal(t, []string{"loyal"}, names(Segment{MinSpent: &spent}))
+	assert.Equal(t, []string{"loyal"}, names(Segment{OrderedSince: &march}))
+	assert.Equal(t, []string{"lapsed"}, names(Segment{MinOrders: &one, NoOrderSince: &march}))
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := service.CreateCampaign(CreateCampaignRequest{Name: "Bad", Subject: "Hi", Body: "{{.Missing"}, "admin")
+		assert.ErrorIs(t, err, ErrInvalidTemplate)
+		_, err = service.CreateCampaign(CreateCampaignRequest{Name: "Bad", Subject: "Hi", Body: "{{.Unknown}}"}, "admin")
+		assert.ErrorIs(t, err, ErrInvalidTemplate)
+	})
+
+	t.Run("empty segment", func(t *testing.T) {
+		many := int64(10)
+		_, err := service.CreateCampaign(CreateCampaignRequest{Name: "None", Subject: "Hi", Body: "Hi", Segment: Segment{MinOrders: &many}}, "admin")
+		assert.ErrorIs(t, err, ErrNoRecipients)
+	})
+}
+
+func TestService_SendCampaign(t *testing.T) {
+	service, mailer, waits := newTestService(t)
+	db := database.GetDB()
+	ctx := context.Background()
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	alice := createCustomer(t, db, "alice@example.com", nil)
+	bob := createCustomer(t, db, "bob@example.com", nil)
+	createCustomer(t, db, "optout@example.com", func(u *models.User) { u.MarketingOptOut = true })
+	createCustomer(t, db, "Bounced@example.com", nil)
+	createCustomer(t, db, "broken@example.com", nil)
+	require.NoError(t, db.Create(&models.EmailSuppression{Email: "bounced@example.com", Reason: models.SuppressionHardBounce}).Error)
+	mailer.fail["broken@example.com"] = true
+
+	campaign, err := service.CreateCampaign(CreateCampaignRequest{
+		Name:          "Spring sale",
+		Subject:       "Spring sale",
+		Body:          `<p>Hi {{.FirstName}}</p><a href="{{.PreferencesURL}}">Preferences</a>`,
+		TrackOpens:    true,
+		BatchSize:     2,
+		RatePerMinute: 120,
+	}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.CampaignQueued, campaign.Status)
+	assert.Equal(t, 5, campaign.Recipients)
+
+	// Bob opts out after the campaign was queued
+	require.NoError(t, db.Model(&bob).Update("marketing_opt_out", true).Error)
+
+	// Three pending recipients in batches of two, then a tick to complete the campaign
+	total := 0
+	for i := 0; i < 3; i++ {
+		sent, err := service.ProcessCampaigns(ctx, now)
+		require.NoError(t, err)
+		total += sent
+	}
+	assert.Equal(t, 1, total)
+
+	require.Len(t, mailer.sent, 1)
+	body := mailer.sent["alice@example.com"]
+	assert.Contains(t, body, "<p>Hi alice</p>")
+	assert.Contains(t, body, "https://shop.example.com/account/preferences")
+	assert.Contains(t, body, "https://api.example.com/api/campaigns/open/")
+	assert.Empty(t, *waits)
+
+	var stored models.EmailCampaign
+	require.NoError(t, db.First(&stored, "id = ?", campaign.ID).Error)
+	assert.Equal(t, models.CampaignCompleted, stored.Status)
+	require.NotNil(t, stored.StartedAt)
+
+	var recipient models.EmailCampaignRecipient
+	require.NoError(t, db.First(&recipient, "campaign_id = ? AND user_id = ?", campaign.ID, alice.ID).Error)
+	require.NoError(t, service.RecordOpen(recipient.OpenToken, now.Add(time.Hour)))
+	require.NoError(t, service.RecordOpen(recipient.OpenToken, now.Add(2*time.Hour)))
+	assert.ErrorIs(t, service.RecordOpen("unknown", now), ErrInvalidToken)
+
+	createOrder(t, db, alice.ID, "paid", 80, now.Add(48*time.Hour))
+	createOrder(t, db, alice.ID, "delivered", 20, now.Add(-time.Hour))
+	createOrder(t, db, alice.ID, "paid", 30, now.Add(8*24*time.Hour))
+	createOrder(t, db, alice.ID, "cancelled", 99, now.Add(time.Hour))
+
+	report, err := service.GetReport(campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), report.Recipients)
+	assert.Equal(t, int64(1), report.Sent)
+	assert.Equal(t, int64(1), report.Failed)
+	assert.Equal(t, int64(3), report.Suppressed)
+	assert.Equal(t, map[string]int64{SuppressedOptOut: 2, models.SuppressionHardBounce: 1}, report.SuppressedBy)
+	require.NotNil(t, report.Opened)
+	assert.Equal(t, int64(1), *report.Opened)
+	assert.Equal(t, 1.0, *report.OpenRate)
+	assert.Equal(t, int64(1), report.Conversions)
+	assert.Equal(t, int64(1), report.ConvertedCustomers)
+	assert.Equal(t, 80.0, report.ConversionRevenue)
+	assert.Equal(t, 7, report.AttributionDays)
+
+	_, err = service.CancelCampaign(campaign.ID, now)
+	assert.ErrorIs(t, err, ErrCampaignFinished)
+	_, err = service.GetReport("missing")
+	assert.ErrorIs(t, err, ErrCampaignNotFound)
+}
+
+func TestService_SendCampaign_Throttle(t *testing.T) {
+	service, mailer, waits := newTestService(t)
+	db := database.GetDB()
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		createCustomer(t, db, email, nil)
+	}
+
+	campaign, err := service.CreateCampaign(CreateCampaignRequest{Name: "News", Subject: "News", Body: "Hello {{.FirstName}}", BatchSize: 2, RatePerMinute: 30}, "admin-1")
+	require.NoError(t, err)
+
+	sent, err := service.ProcessCampaigns(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []time.Duration{2 * time.Second}, *waits)
+	assert.NotContains(t, mailer.sent["a@example.com"], "/api/campaigns/open/")
+
+	cancelled, err := service.CancelCampaign(campaign.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, models.CampaignCancelled, cancelled.Status)
+
+	sent, err = service.ProcessCampaigns(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Len(t, mailer.sent, 2)
+}
@@ -0,0 +1,173 @@
+package campaigns
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+)
+
+// ProcessCampaigns sends the next batch of every queued or sending campaign, oldest campaign first, and
+// returns how many emails were sent
+func (s *Service) ProcessCampaigns(ctx context.Context, now time.Time) (int, error) {
+	var campaigns []models.EmailCampaign
+	if err := s.db.Where("status IN ?", []string{models.CampaignQueued, models.CampaignSending}).
+		Order("created_at ASC").Find(&campaigns).Error; err != nil {
+		return 0, fmt.Errorf("failed to get campaigns: %w", err)
+	}
+
+	sent := 0
+	for i := range campaigns {
+		count, err := s.processBatch(ctx, &campaigns[i], now)
+		sent += count
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// processBatch emails up to BatchSize pending recipients of campaign, waiting between sends to keep to its
+// rate. Preferences and the suppression list are checked again as they may have changed since the campaign
+// was queued. Each recipient is claimed before the email goes out so two workers never email them twice.
+func (s *Service) processBatch(ctx context.Context, campaign *models.EmailCampaign, now time.Time) (int, error) {
+	if campaign.Status == models.CampaignQueued {
+		if err := s.db.Model(&models.EmailCampaign{}).Where("id = ? AND status = ?", campaign.ID, models.CampaignQueued).
+			Updates(map[string]interface{}{"status": models.CampaignSending, "started_at": now}).Error; err != nil {
+			return 0, fmt.Errorf("failed to start campaign: %w", err)
+		}
+	}
+
+	var recipients []models.EmailCampaignRecipient
+	if err := s.db.Preload("User").Where("campaign_id = ? AND status = ?", campaign.ID, models.CampaignRecipientPending).
+		Order("created_at ASC").Limit(campaign.BatchSize).Find(&recipients).Error; err != nil {
+		return 0, fmt.Errorf("failed to get campaign recipients: %w", err)
+	}
+	if len(recipients) == 0 {
+		if err := s.db.Model(&models.EmailCampaign{}).Where("id = ? AND status = ?", campaign.ID, models.CampaignSending).
+			Updates(map[string]interface{}{"status": models.CampaignCompleted, "completed_at": now}).Error; err != nil {
+			return 0, fmt.Errorf("failed to complete campaign: %w", err)
+		}
+		logger.Named("campaigns").Info("Campaign completed", map[string]interface{}{"campaign_id": campaign.ID})
+		return 0, nil
+	}
+
+	tmpl, err := template.New("campaign").Parse(campaign.Body)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	users := make([]models.User, 0, len(recipients))
+	for _, recipient := range recipients {
+		if recipient.User != nil {
+			users = append(users, *recipient.User)
+		}
+	}
+	suppressed, err := s.suppressedEmails(users)
+	if err != nil {
+		return 0, err
+	}
+
+	delay := time.Minute / time.Duration(campaign.RatePerMinute)
+	sent := 0
+	for _, recipient := range recipients {
+		reason := SuppressedInactive
+		if recipient.User != nil {
+			reason = suppressionReason(recipient.User, suppressed)
+		}
+		if reason != "" {
+			if err := s.finishRecipient(&recipient, models.CampaignRecipientSuppressed, map[string]interface{}{"suppression_reason": reason}); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		body, err := s.render(tmpl, campaign, &recipient)
+		if err != nil {
+			if err := s.finishRecipient(&recipient, models.CampaignRecipientFailed, map[string]interface{}{"error": err.Error()}); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if sent > 0 {
+			if err := s.sleep(ctx, delay); err != nil {
+				return sent, err
+			}
+		}
+
+		result := s.db.Model(&models.EmailCampaignRecipient{}).
+			Where("id = ? AND status = ?", recipient.ID, models.CampaignRecipientPending).
+			Updates(map[string]interface{}{"status": models.CampaignRecipientSent, "sent_at": now})
+		if result.Error != nil {
+			return sent, fmt.Errorf("failed to claim campaign recipient: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		if err := s.mailer.SendCampaignEmail(recipient.Email, campaign.Subject, body); err != nil {
+			logger.Named("campaigns").Warn("Failed to send campaign email", map[string]interface{}{"campaign_id": campaign.ID, "recipient_id": recipient.ID, "error": err.Error()})
+			if err := s.db.Model(&models.EmailCampaignRecipient{}).Where("id = ?", recipient.ID).
+				Updates(map[string]interface{}{"status": models.CampaignRecipientFailed, "sent_at": nil, "error": err.Error()}).Error; err != nil {
+				return sent, fmt.Errorf("failed to record campaign failure: %w", err)
+			}
+			continue
+		}
+		sent++
+	}
+
+	logger.Named("campaigns").Info("Campaign batch sent", map[string]interface{}{"campaign_id": campaign.ID, "sent": sent})
+	return sent, nil
+}
+
+// finishRecipient moves a pending recipient to status without emailing them
+func (s *Service) finishRecipient(recipient *models.EmailCampaignRecipient, status string, fields map[string]interface{}) error {
+	fields["status"] = status
+	if err := s.db.Model(&models.EmailCampaignRecipient{}).
+		Where("id = ? AND status = ?", recipient.ID, models.CampaignRecipientPending).
+		Updates(fields).Error; err != nil {
+		return fmt.Errorf("failed to update campaign recipient: %w", err)
+	}
+	return nil
+}
+
+// render executes the campaign body for recipient and appends the open tracking pixel when enabled
+func (s *Service) render(tmpl *template.Template, campaign *models.EmailCampaign, recipient *models.EmailCampaignRecipient) (string, error) {
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, templateData{
+		FirstName:      recipient.User.FirstName,
+		LastName:       recipient.User.LastName,
+		Email:          recipient.Email,
+		PreferencesURL: s.storefront + "/account/preferences",
+	}); err != nil {
+		return "", fmt.Errorf("failed to execute email template: %w", err)
+	}
+	if campaign.TrackOpens {
+		fmt.Fprintf(&body, `<img src="%s/api/campaigns/open/%s" width="1" height="1" alt="" style="display:none">`,
+			s.apiBaseURL, template.HTMLEscapeString(recipient.OpenToken))
+	}
+	return body.String(), nil
+}
+
+// StartWorker sends campaign batches every interval until ctx is cancelled
+func (s *Service) StartWorker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if _, err := s.ProcessCampaigns(ctx, now); err != nil {
+					logger.Named("campaigns").Warn("Failed to process campaigns", map[string]interface{}{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
@@ -1,10 +1,12 @@
 package cart
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"ecommerce-website/internal/models"
+	imageutils "ecommerce-website/internal/utils"
 	"ecommerce-website/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -24,28 +26,34 @@ func NewHandler() *Handler {
 
 // GetCart retrieves the current cart
 func (h *Handler) GetCart(c *gin.Context) {
-	sessionID := h.getOrCreateSessionID(c)
+	cartID := h.cartID(c)
 	
-	cart, err := h.service.GetCartWithProducts(c.Request.Context(), sessionID)
+	cart, err := h.service.GetCartWithProducts(c.Request.Context(), cartID)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "CART_RETRIEVE_ERROR", "Failed to retrieve cart", err.Error())
 		return
 	}
 	
+	presentCart(c, cart)
+	utils.SetRevision(c, cart.Revision)
 	utils.SuccessResponse(c, http.StatusOK, "Cart retrieved successfully", cart)
 }
 
 // AddItem adds an item to the cart
 func (h *Handler) AddItem(c *gin.Context) {
-	sessionID := h.getOrCreateSessionID(c)
+	cartID := h.cartID(c)
 	
 	var req models.AddItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
 		return
 	}
+	before, ok := h.currentRevision(c, cartID)
+	if !ok {
+		return
+	}
 	
-	cart, err := h.service.AddItem(c.Request.Context(), sessionID, req.ProductID, req.Quantity)
+	cart, err := h.service.AddItem(c.Request.Context(), cartID, req.ProductID, req.Quantity, req.Source)
 	if err != nil {
 		errMsg := err.Error()
 		// Check if it's an inventory error
@@ -61,20 +69,24 @@ func (h *Handler) AddItem(c *gin.Context) {
 		return
 	}
 	
-	utils.SuccessResponse(c, http.StatusOK, "Item added to cart successfully", cart)
+	h.respondMutation(c, "Item added to cart successfully", before, cart, models.CartOpAdd, req.ProductID)
 }
 
 // UpdateItem updates the quantity of an item in the cart
 func (h *Handler) UpdateItem(c *gin.Context) {
-	sessionID := h.getOrCreateSessionID(c)
+	cartID := h.cartID(c)
 	
 	var req models.UpdateItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
 		return
 	}
+	before, ok := h.currentRevision(c, cartID)
+	if !ok {
+		return
+	}
 	
-	cart, err := h.service.UpdateItem(c.Request.Context(), sessionID, req.ProductID, req.Quantity)
+	cart, err := h.service.UpdateItem(c.Request.Context(), cartID, req.ProductID, req.Quantity)
 	if err != nil {
 		errMsg := err.Error()
 		if errMsg == "item not found in cart" {
@@ -89,20 +101,28 @@ func (h *Handler) UpdateItem(c *gin.Context) {
 		return
 	}
 	
-	utils.SuccessResponse(c, http.StatusOK, "Cart item updated successfully", cart)
+	op := models.CartOpUpdate
+	if req.Quantity == 0 {
+		op = models.CartOpRemove
+	}
+	h.respondMutation(c, "Cart item updated successfully", before, cart, op, req.ProductID)
 }
 
 // RemoveItem removes an item from the cart
 func (h *Handler) RemoveItem(c *gin.Context) {
-	sessionID := h.getOrCreateSessionID(c)
+	cartID := h.cartID(c)
 	
 	var req models.RemoveItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
 		return
 	}
+	before, ok := h.currentRevision(c, cartID)
+	if !ok {
+		return
+	}
 	
-	cart, err := h.service.RemoveItem(c.Request.Context(), sessionID, req.ProductID)
+	cart, err := h.service.RemoveItem(c.Request.Context(), cartID, req.ProductID)
 	if err != nil {
 		if err.Error() == "item not found in cart" {
 			utils.ErrorResponse(c, http.StatusNotFound, "ITEM_NOT_FOUND", "Item not found in cart", err.Error())
@@ -112,26 +132,165 @@ func (h *Handler) RemoveItem(c *gin.Context) {
 		return
 	}
 	
-	utils.SuccessResponse(c, http.StatusOK, "Item removed from cart successfully", cart)
+	h.respondMutation(c, "Item removed from cart successfully", before, cart, models.CartOpRemove, req.ProductID)
+}
+
+// SaveForLater moves an item from the cart to the saved-for-later list
+func (h *Handler) SaveForLater(c *gin.Context) {
+	cartID := h.cartID(c)
+	
+	var req models.SavedItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+	before, ok := h.currentRevision(c, cartID)
+	if !ok {
+		return
+	}
+	
+	cart, err := h.service.SaveForLater(c.Request.Context(), cartID, req.ProductID)
+	if err != nil {
+		if err.Error() == "item not found in cart" {
+			utils.ErrorResponse(c, http.StatusNotFound, "ITEM_NOT_FOUND", "Item not found in cart", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "CART_SAVE_FOR_LATER_ERROR", "Failed to save item for later", err.Error())
+		return
+	}
+	
+	h.respondMutation(c, "Item saved for later successfully", before, cart, models.CartOpSave, req.ProductID)
+}
+
+// MoveToCart moves an item saved for later back into the cart
+func (h *Handler) MoveToCart(c *gin.Context) {
+	cartID := h.cartID(c)
+	
+	var req models.SavedItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+	before, ok := h.currentRevision(c, cartID)
+	if !ok {
+		return
+	}
+	
+	cart, err := h.service.MoveToCart(c.Request.Context(), cartID, req.ProductID)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case errors.Is(err, ErrSavedItemNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "SAVED_ITEM_NOT_FOUND", "Item not saved for later", errMsg)
+		case errMsg == "product is not available":
+			utils.ErrorResponse(c, http.StatusBadRequest, "PRODUCT_NOT_AVAILABLE", "Product is not available", errMsg)
+		case strings.Contains(errMsg, "insufficient inventory"):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INSUFFICIENT_INVENTORY", errMsg, errMsg)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "CART_MOVE_ERROR", "Failed to move item to cart", errMsg)
+		}
+		return
+	}
+	
+	h.respondMutation(c, "Item moved to cart successfully", before, cart, models.CartOpMove, req.ProductID)
 }
 
 // ClearCart removes all items from the cart
 func (h *Handler) ClearCart(c *gin.Context) {
-	sessionID := h.getOrCreateSessionID(c)
+	cartID := h.cartID(c)
+	before, ok := h.currentRevision(c, cartID)
+	if !ok {
+		return
+	}
 	
-	if err := h.service.ClearCart(c.Request.Context(), sessionID); err != nil {
+	if err := h.service.ClearCart(c.Request.Context(), cartID); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "CART_CLEAR_ERROR", "Failed to clear cart", err.Error())
 		return
 	}
+	cart, err := h.service.GetCart(c.Request.Context(), cartID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "CART_RETRIEVE_ERROR", "Failed to retrieve cart", err.Error())
+		return
+	}
 	
-	utils.SuccessResponse(c, http.StatusOK, "Cart cleared successfully", nil)
+	h.respondMutation(c, "Cart cleared successfully", before, cart, models.CartOpClear, "")
+}
+
+// currentRevision returns the cart as it is before a mutation. When the request carries an If-Match revision
+// that is not the cart's current one, it responds 409 with the current cart and returns false.
+func (h *Handler) currentRevision(c *gin.Context, cartID string) (*models.Cart, bool) {
+	expected, hasExpected, err := utils.ExpectedRevision(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REVISION", "Invalid revision", err.Error())
+		return nil, false
+	}
+	if !hasExpected {
+		cart, err := h.service.GetCart(c.Request.Context(), cartID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "CART_RETRIEVE_ERROR", "Failed to retrieve cart", err.Error())
+			return nil, false
+		}
+		return cart, true
+	}
+
+	cart, err := h.service.CheckRevision(c.Request.Context(), cartID, expected)
+	if errors.Is(err, ErrStaleRevision) {
+		utils.SetRevision(c, cart.Revision)
+		utils.ErrorResponse(c, http.StatusConflict, "STALE_REVISION", "Cart has changed; apply the change to the current cart", cart)
+		return nil, false
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "CART_RETRIEVE_ERROR", "Failed to retrieve cart", err.Error())
+		return nil, false
+	}
+	return cart, true
+}
+
+// respondMutation responds with the recalculated cart and the change applied to the product's line
+func (h *Handler) respondMutation(c *gin.Context, message string, before, after *models.Cart, op, productID string) {
+	delta := models.CartDelta{Op: op, ProductID: productID}
+	if productID != "" {
+		if item := before.FindItem(productID); item != nil {
+			delta.PreviousQuantity = item.Quantity
+		}
+		if item := after.FindItem(productID); item != nil {
+			delta.Quantity = item.Quantity
+		}
+	} else {
+		delta.PreviousQuantity = before.GetItemCount()
+		delta.Quantity = after.GetItemCount()
+	}
+
+	presentCart(c, after)
+	utils.SetRevision(c, after.Revision)
+	utils.SuccessResponse(c, http.StatusOK, message, models.CartMutation{Cart: after, Delta: delta})
+}
+
+// presentCart fills the image URLs of the cart's products; ?size= picks the size images are delivered at
+func presentCart(c *gin.Context, cart *models.Cart) {
+	size := c.Query("size")
+	for i := range cart.Items {
+		cart.Items[i].Product.ImageURLs = imageutils.ImageURLs(cart.Items[i].Product.Images, size)
+	}
+	for i := range cart.SavedForLater {
+		cart.SavedForLater[i].Product.ImageURLs = imageutils.ImageURLs(cart.SavedForLater[i].Product.Images, size)
+	}
+}
+
+// cartID returns the cart the request works on: the signed-in user's persistent cart, or the guest session cart
+func (h *Handler) cartID(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return UserCartID(userID)
+	}
+	return h.getOrCreateSessionID(c)
 }
 
 // getOrCreateSessionID gets the session ID from cookie or creates a new one
 func (h *Handler) getOrCreateSessionID(c *gin.Context) string {
-	// Try to get session ID from cookie
+	// Try to get session ID from cookie; a forged user cart ID is treated as no session
 	sessionID, err := c.Cookie("session_id")
-	if err != nil || sessionID == "" {
+	_, isUserCart := CartOwner(sessionID)
+	if err != nil || sessionID == "" || isUserCart {
 		// Create new session ID
 		sessionID = uuid.New().String()
 		// Set cookie with 24 hour expiration
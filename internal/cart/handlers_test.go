@@ -22,7 +22,7 @@ func setupTestRouter() *gin.Engine {
 	
 	// Register cart routes
 	api := router.Group("/api")
-	RegisterRoutes(api)
+	RegisterRoutes(api, nil)
 	
 	return router
 }
@@ -138,6 +138,43 @@ func TestHandler_AddItem(t *testing.T) {
 		assert.Equal(t, 59.97, data["subtotal"]) // 19.99 * 3
 	})
 	
+	t.Run("should record item source", func(t *testing.T) {
+		product := createTestProduct(t, "Source Test", 5.00, 5)
+		
+		requestBody := models.AddItemRequest{
+			ProductID: product.ID,
+			Quantity:  1,
+			Source:    models.CartSourceRecommendation,
+		}
+		
+		jsonBody, _ := json.Marshal(requestBody)
+		req, _ := http.NewRequest("POST", "/api/cart/add", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"source":"recommendation"`)
+	})
+	
+	t.Run("should reject unknown source", func(t *testing.T) {
+		product := createTestProduct(t, "Bad Source Test", 5.00, 5)
+		
+		jsonBody, _ := json.Marshal(map[string]interface{}{
+			"productId": product.ID,
+			"quantity":  1,
+			"source":    "newsletter",
+		})
+		req, _ := http.NewRequest("POST", "/api/cart/add", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+	
 	t.Run("should return error for invalid request", func(t *testing.T) {
 		invalidRequest := map[string]interface{}{
 			"productId": "",
@@ -455,4 +492,49 @@ func TestHandler_ClearCart(t *testing.T) {
 		assert.True(t, response["success"].(bool))
 		assert.Equal(t, "Cart cleared successfully", response["message"])
 	})
-}
\ No newline at end of file
+}
+func TestHandler_RevisionedMutations(t *testing.T) {
+	redisClient := setupTestRedis(t)
+	defer cleanupTestData(t, redisClient)
+
+	originalClient := database.RedisClient
+	database.RedisClient = redisClient
+	defer func() { database.RedisClient = originalClient }()
+
+	router := setupTestRouter()
+	product := createTestProduct(t, "Revision Test", 10.00, 5)
+	createTestCart(t, redisClient, "revision-session", []models.CartItem{{ProductID: product.ID, Quantity: 1, Price: product.Price}})
+
+	update := func(quantity int, ifMatch string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		jsonBody, _ := json.Marshal(models.UpdateItemRequest{ProductID: product.ID, Quantity: quantity})
+		req, _ := http.NewRequest("PUT", "/api/cart/update", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", ifMatch)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "revision-session"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	w, response := update(3, "0")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	data := response["data"].(map[string]interface{})
+	assert.EqualValues(t, 1, data["revision"])
+	assert.Equal(t, 30.0, data["subtotal"])
+	delta := data["delta"].(map[string]interface{})
+	assert.Equal(t, models.CartOpUpdate, delta["op"])
+	assert.EqualValues(t, 3, delta["quantity"])
+	assert.EqualValues(t, 1, delta["previousQuantity"])
+
+	// A change based on the earlier revision is rejected with the current cart
+	w, response = update(2, "0")
+	require.Equal(t, http.StatusConflict, w.Code)
+	errorDetail := response["error"].(map[string]interface{})
+	assert.Equal(t, "STALE_REVISION", errorDetail["code"])
+	current := errorDetail["details"].(map[string]interface{})
+	assert.EqualValues(t, 1, current["revision"])
+	assert.Equal(t, 30.0, current["subtotal"])
+}
@@ -2,6 +2,7 @@ package cart
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -449,4 +450,127 @@ func TestCartPersistence(t *testing.T) {
 		items = data["items"].([]interface{})
 		assert.Len(t, items, 1)
 	})
-}
\ No newline at end of file
+}
+func TestCartIntegration_MergeGuestCart(t *testing.T) {
+	redisClient := setupTestRedis(t)
+	defer cleanupTestData(t, redisClient)
+
+	originalClient := database.RedisClient
+	database.RedisClient = redisClient
+	defer func() { database.RedisClient = originalClient }()
+
+	service := NewService()
+	ctx := context.Background()
+
+	shared := createTestProduct(t, "Merge Shared", 10, 5)
+	guestOnly := createTestProduct(t, "Merge Guest", 20, 10)
+
+	createTestCart(t, redisClient, "guest-session", []models.CartItem{
+		{ProductID: shared.ID, Quantity: 4, Price: shared.Price},
+		{ProductID: guestOnly.ID, Quantity: 1, Price: guestOnly.Price},
+	})
+	_, err := service.AddItem(ctx, UserCartID("user-1"), shared.ID, 3, "")
+	require.NoError(t, err)
+
+	merged, err := service.MergeGuestCart(ctx, "guest-session", "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, merged.UserID)
+	assert.Equal(t, "user-1", *merged.UserID)
+	assert.Equal(t, 5, merged.FindItem(shared.ID).Quantity, "duplicate quantities are summed up to the inventory")
+	assert.Equal(t, 1, merged.FindItem(guestOnly.ID).Quantity)
+
+	guest, err := service.GetCart(ctx, "guest-session")
+	require.NoError(t, err)
+	assert.True(t, guest.IsEmpty())
+
+	_, err = service.MergeGuestCart(ctx, UserCartID("user-2"), "user-1")
+	assert.ErrorIs(t, err, ErrInvalidGuestSession)
+}
+
+func TestCartIntegration_UserCartSurvivesRedis(t *testing.T) {
+	redisClient := setupTestRedis(t)
+	defer cleanupTestData(t, redisClient)
+	defer database.GetDB().Exec("DELETE FROM user_cart_items")
+
+	originalClient := database.RedisClient
+	database.RedisClient = redisClient
+	defer func() { database.RedisClient = originalClient }()
+
+	service := NewService()
+	ctx := context.Background()
+	product := createTestProduct(t, "Persistent Mug", 15, 10)
+
+	_, err := service.AddItem(ctx, UserCartID("user-1"), product.ID, 2, models.CartSourceSearch)
+	require.NoError(t, err)
+
+	// Losing the Redis key, as when it expires, does not lose the cart
+	require.NoError(t, redisClient.Del(ctx, cartKeyPrefix+UserCartID("user-1")).Err())
+	restored, err := service.GetCart(ctx, UserCartID("user-1"))
+	require.NoError(t, err)
+	require.Len(t, restored.Items, 1)
+	assert.Equal(t, 2, restored.Items[0].Quantity)
+	assert.Equal(t, models.CartSourceSearch, restored.Items[0].Source)
+	exists, err := redisClient.Exists(ctx, cartKeyPrefix+UserCartID("user-1")).Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, exists, "the saved cart is copied back into Redis")
+
+	require.NoError(t, service.ClearCart(ctx, UserCartID("user-1")))
+	cleared, err := service.GetCart(ctx, UserCartID("user-1"))
+	require.NoError(t, err)
+	assert.True(t, cleared.IsEmpty())
+	assert.Greater(t, cleared.Revision, restored.Revision, "clearing is a new revision")
+}
+
+func TestCartIntegration_SaveForLater(t *testing.T) {
+	redisClient := setupTestRedis(t)
+	defer cleanupTestData(t, redisClient)
+
+	originalClient := database.RedisClient
+	database.RedisClient = redisClient
+	defer func() { database.RedisClient = originalClient }()
+
+	service := NewService()
+	ctx := context.Background()
+	mug := createTestProduct(t, "Saved Mug", 12, 3)
+	lamp := createTestProduct(t, "Saved Lamp", 40, 5)
+
+	_, err := service.AddItem(ctx, "saved-session", mug.ID, 2, "")
+	require.NoError(t, err)
+	_, err = service.AddItem(ctx, "saved-session", lamp.ID, 1, "")
+	require.NoError(t, err)
+
+	cart, err := service.SaveForLater(ctx, "saved-session", mug.ID)
+	require.NoError(t, err)
+	assert.Nil(t, cart.FindItem(mug.ID))
+	require.NotNil(t, cart.FindSavedItem(mug.ID))
+	assert.Equal(t, 2, cart.FindSavedItem(mug.ID).Quantity)
+	assert.Equal(t, 40.0, cart.Subtotal, "saved lines are not counted in the totals")
+
+	_, err = service.SaveForLater(ctx, "saved-session", mug.ID)
+	assert.EqualError(t, err, "item not found in cart")
+
+	// Clearing the cart keeps what was saved for later
+	require.NoError(t, service.ClearCart(ctx, "saved-session"))
+	cart, err = service.GetCartWithProducts(ctx, "saved-session")
+	require.NoError(t, err)
+	assert.True(t, cart.IsEmpty())
+	require.Len(t, cart.SavedForLater, 1)
+	assert.Equal(t, "Saved Mug", cart.SavedForLater[0].Product.Name)
+
+	// Moving back checks inventory against the whole line
+	_, err = service.AddItem(ctx, "saved-session", mug.ID, 2, "")
+	require.NoError(t, err)
+	_, err = service.MoveToCart(ctx, "saved-session", mug.ID)
+	assert.ErrorContains(t, err, "insufficient inventory")
+	_, err = service.RemoveItem(ctx, "saved-session", mug.ID)
+	require.NoError(t, err)
+
+	cart, err = service.MoveToCart(ctx, "saved-session", mug.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cart.FindItem(mug.ID).Quantity)
+	assert.Empty(t, cart.SavedForLater)
+	assert.Equal(t, 24.0, cart.Subtotal)
+
+	_, err = service.MoveToCart(ctx, "saved-session", mug.ID)
+	assert.ErrorIs(t, err, ErrSavedItemNotFound)
+}
@@ -0,0 +1,48 @@
+package cart
+
+import (
+	"context"
+	"errors"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// Module wires the shopping cart into the application. Carts are kept in Redis; signed-in users' carts are
+// also saved to the database.
+type Module struct {
+	redis       *redis.Client
+	authService *auth.Service
+}
+
+// NewModule creates the cart module
+func NewModule(deps app.Deps) app.Module {
+	return &Module{redis: deps.Redis, authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "cart"
+}
+
+// Models returns the saved user cart table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.UserCart{}, &models.UserCartItem{}}
+}
+
+// HealthCheck reports whether the cart store is reachable
+func (m *Module) HealthCheck(ctx context.Context) error {
+	if m.redis == nil {
+		return errors.New("redis is not configured")
+	}
+	return m.redis.Ping(ctx).Err()
+}
+
+// RegisterAPIRoutes sets up the cart routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	RegisterRoutes(api, m.authService)
+}
@@ -0,0 +1,112 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// loadUserCart reads a user's cart from the database, returning nil when the user has never saved one
+func loadUserCart(ctx context.Context, db *gorm.DB, userID string) (*models.Cart, error) {
+	var header []models.UserCart
+	if err := db.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&header).Error; err != nil {
+		return nil, fmt.Errorf("failed to load saved cart: %w", err)
+	}
+	var rows []models.UserCartItem
+	if err := db.WithContext(ctx).Where("user_id = ?", userID).Order("position, created_at").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load saved cart: %w", err)
+	}
+	if len(header) == 0 && len(rows) == 0 {
+		return nil, nil
+	}
+
+	cart := &models.Cart{
+		SessionID:     UserCartID(userID),
+		UserID:        &userID,
+		Items:         []models.CartItem{},
+		SavedForLater: []models.CartItem{},
+	}
+	if len(header) > 0 {
+		cart.Revision = header[0].Revision
+		cart.CreatedAt = header[0].UpdatedAt
+	}
+	if len(rows) > 0 {
+		cart.CreatedAt = rows[0].CreatedAt
+	}
+	for _, row := range rows {
+		item := models.CartItem{ProductID: row.ProductID, Quantity: row.Quantity, Price: row.Price, Source: row.Source}
+		if row.Saved {
+			cart.SavedForLater = append(cart.SavedForLater, item)
+		} else {
+			cart.Items = append(cart.Items, item)
+		}
+		if row.CreatedAt.Before(cart.CreatedAt) {
+			cart.CreatedAt = row.CreatedAt
+		}
+	}
+	cart.CalculateTotals()
+	return cart, nil
+}
+
+// storeUserCart replaces a user's stored cart lines with the lines of cart, including those saved for later, and
+// records its revision. Lines keep their first-added time.
+func storeUserCart(ctx context.Context, db *gorm.DB, userID string, cart *models.Cart) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		header := models.UserCart{UserID: userID, Revision: cart.Revision}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"revision", "updated_at"}),
+		}).Create(&header).Error; err != nil {
+			return fmt.Errorf("failed to save cart: %w", err)
+		}
+
+		var existing []models.UserCartItem
+		if err := tx.Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to load saved cart: %w", err)
+		}
+		added := make(map[string]time.Time, len(existing))
+		for _, row := range existing {
+			added[row.ProductID] = row.CreatedAt
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserCartItem{}).Error; err != nil {
+			return fmt.Errorf("failed to save cart: %w", err)
+		}
+		if len(cart.Items) == 0 && len(cart.SavedForLater) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		rows := make([]models.UserCartItem, 0, len(cart.Items)+len(cart.SavedForLater))
+		appendRows := func(items []models.CartItem, saved bool) {
+			for i, item := range items {
+				createdAt, ok := added[item.ProductID]
+				if !ok {
+					createdAt = now
+				}
+				rows = append(rows, models.UserCartItem{
+					UserID:    userID,
+					ProductID: item.ProductID,
+					Quantity:  item.Quantity,
+					Price:     item.Price,
+					Source:    item.Source,
+					Position:  i,
+					Saved:     saved,
+					CreatedAt: createdAt,
+					UpdatedAt: now,
+				})
+			}
+		}
+		appendRows(cart.Items, false)
+		appendRows(cart.SavedForLater, true)
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to save cart: %w", err)
+		}
+		return nil
+	})
+}
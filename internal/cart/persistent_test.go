@@ -0,0 +1,79 @@
+package cart
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestUserCartPersistence(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.UserCart{}, &models.UserCartItem{}))
+	ctx := context.Background()
+
+	saved, err := loadUserCart(ctx, db, "u1")
+	require.NoError(t, err)
+	assert.Nil(t, saved, "a user without saved lines has no saved cart")
+
+	cart := &models.Cart{SessionID: UserCartID("u1"), Revision: 1, Items: []models.CartItem{
+		{ProductID: "mug", Quantity: 2, Price: 12.5, Source: models.CartSourceSearch},
+		{ProductID: "card", Quantity: 1, Price: 3},
+	}}
+	require.NoError(t, storeUserCart(ctx, db, "u1", cart))
+	require.NoError(t, storeUserCart(ctx, db, "u2", &models.Cart{SessionID: UserCartID("u2"), Items: []models.CartItem{{ProductID: "mug", Quantity: 1, Price: 12.5}}}))
+
+	saved, err = loadUserCart(ctx, db, "u1")
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, UserCartID("u1"), saved.SessionID)
+	require.NotNil(t, saved.UserID)
+	assert.Equal(t, "u1", *saved.UserID)
+	require.Len(t, saved.Items, 2)
+	assert.Equal(t, "mug", saved.Items[0].ProductID, "lines keep their order")
+	assert.Equal(t, models.CartSourceSearch, saved.Items[0].Source)
+	assert.Equal(t, 28.0, saved.Subtotal)
+	assert.EqualValues(t, 1, saved.Revision)
+
+	var first models.UserCartItem
+	require.NoError(t, db.First(&first, "user_id = ? AND product_id = ?", "u1", "mug").Error)
+
+	// Saving again replaces the lines but keeps when each was first added
+	cart.Items = []models.CartItem{{ProductID: "card", Quantity: 4, Price: 3}, {ProductID: "mug", Quantity: 1, Price: 12.5}}
+	require.NoError(t, storeUserCart(ctx, db, "u1", cart))
+	saved, err = loadUserCart(ctx, db, "u1")
+	require.NoError(t, err)
+	require.Len(t, saved.Items, 2)
+	assert.Equal(t, "card", saved.Items[0].ProductID)
+	assert.Equal(t, 4, saved.Items[0].Quantity)
+	var again models.UserCartItem
+	require.NoError(t, db.First(&again, "user_id = ? AND product_id = ?", "u1", "mug").Error)
+	assert.True(t, first.CreatedAt.Equal(again.CreatedAt))
+
+	// Lines saved for later are stored with the cart and come back on their own list
+	cart.SavedForLater = []models.CartItem{{ProductID: "lamp", Quantity: 1, Price: 40}}
+	require.NoError(t, storeUserCart(ctx, db, "u1", cart))
+	saved, err = loadUserCart(ctx, db, "u1")
+	require.NoError(t, err)
+	require.Len(t, saved.Items, 2)
+	require.Len(t, saved.SavedForLater, 1)
+	assert.Equal(t, "lamp", saved.SavedForLater[0].ProductID)
+	assert.Equal(t, 24.5, saved.Subtotal, "saved lines are not counted in the totals")
+
+	// An emptied cart keeps its revision, so a restored cart never goes back to an earlier one
+	require.NoError(t, storeUserCart(ctx, db, "u1", &models.Cart{SessionID: UserCartID("u1"), Revision: 3, Items: []models.CartItem{}}))
+	saved, err = loadUserCart(ctx, db, "u1")
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.True(t, saved.IsEmpty())
+	assert.EqualValues(t, 3, saved.Revision)
+	saved, err = loadUserCart(ctx, db, "u2")
+	require.NoError(t, err)
+	assert.NotNil(t, saved, "other users' carts are untouched")
+}
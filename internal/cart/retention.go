@@ -0,0 +1,59 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// purgeScanCount is how many keys each SCAN step asks Redis for
+const purgeScanCount = 200
+
+// PurgeGuestCarts finds guest carts last updated before cutoff and, unless dryRun is set, deletes them. It
+// returns the session IDs of the carts found. Signed-in users' carts are left alone.
+func (s *Service) PurgeGuestCarts(ctx context.Context, cutoff time.Time, dryRun bool) ([]string, error) {
+	var sessions []string
+	iter := s.redisClient.Scan(ctx, 0, cartKeyPrefix+"*", purgeScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		sessionID := strings.TrimPrefix(key, cartKeyPrefix)
+		if _, isUserCart := CartOwner(sessionID); isUserCart {
+			continue
+		}
+
+		data, err := s.redisClient.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue // expired since the scan
+		}
+		if err != nil {
+			return sessions, fmt.Errorf("failed to get cart from Redis: %w", err)
+		}
+		var cart models.Cart
+		if err := json.Unmarshal([]byte(data), &cart); err != nil {
+			logger.Named("cart").Warn("Skipping unreadable cart", map[string]interface{}{"session_id": sessionID, "error": err.Error()})
+			continue
+		}
+		if !cart.UpdatedAt.Before(cutoff) {
+			continue
+		}
+
+		if !dryRun {
+			if err := s.redisClient.Del(ctx, key).Err(); err != nil {
+				return sessions, fmt.Errorf("failed to delete guest cart: %w", err)
+			}
+		}
+		sessions = append(sessions, sessionID)
+	}
+	if err := iter.Err(); err != nil {
+		return sessions, fmt.Errorf("failed to scan carts: %w", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,41 @@
+package cart
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_PurgeGuestCarts(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.FlushDB(context.Background())
+	service := &Service{redisClient: client}
+	ctx := context.Background()
+
+	now := time.Now()
+	userID := "user-1"
+	for _, cart := range []*models.Cart{
+		{SessionID: "stale", UpdatedAt: now.AddDate(0, 0, -40)},
+		{SessionID: "fresh", UpdatedAt: now.AddDate(0, 0, -1)},
+		{SessionID: UserCartID(userID), UserID: &userID, UpdatedAt: now.AddDate(0, 0, -40)},
+	} {
+		require.NoError(t, service.SaveCart(ctx, cart))
+	}
+	cutoff := now.AddDate(0, 0, -30)
+
+	sessions, err := service.PurgeGuestCarts(ctx, cutoff, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stale"}, sessions)
+	assert.Equal(t, int64(3), client.DBSize(ctx).Val(), "a dry run deletes nothing")
+
+	sessions, err = service.PurgeGuestCarts(ctx, cutoff, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stale"}, sessions)
+	assert.Equal(t, int64(0), client.Exists(ctx, cartKeyPrefix+"stale").Val())
+	assert.Equal(t, int64(1), client.Exists(ctx, cartKeyPrefix+UserCartID(userID)).Val())
+}
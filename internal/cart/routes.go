@@ -1,19 +1,27 @@
 package cart
 
 import (
+	"ecommerce-website/internal/auth"
+
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterRoutes registers cart routes
-func RegisterRoutes(router *gin.RouterGroup) {
+// RegisterRoutes registers cart routes. With an auth service, requests carrying a valid token
+// use the user's persistent cart instead of the guest session cart.
+func RegisterRoutes(router *gin.RouterGroup, authService *auth.Service) {
 	handler := NewHandler()
-	
+
 	cartGroup := router.Group("/cart")
+	if authService != nil {
+		cartGroup.Use(authService.OptionalAuthMiddleware())
+	}
 	{
 		cartGroup.GET("", handler.GetCart)
 		cartGroup.POST("/add", handler.AddItem)
 		cartGroup.PUT("/update", handler.UpdateItem)
 		cartGroup.DELETE("/remove", handler.RemoveItem)
 		cartGroup.DELETE("/clear", handler.ClearCart)
+		cartGroup.POST("/save-for-later", handler.SaveForLater)
+		cartGroup.POST("/move-to-cart", handler.MoveToCart)
 	}
-}
\ No newline at end of file
+}
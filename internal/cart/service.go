@@ -3,10 +3,13 @@ package cart
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/logger"
 	"ecommerce-website/internal/models"
 
 	"github.com/redis/go-redis/v9"
@@ -17,17 +20,39 @@ type ServiceInterface interface {
 	GetCart(ctx context.Context, sessionID string) (*models.Cart, error)
 	GetCartWithProducts(ctx context.Context, sessionID string) (*models.Cart, error)
 	ClearCart(ctx context.Context, sessionID string) error
-	AddItem(ctx context.Context, sessionID string, productID string, quantity int) (*models.Cart, error)
+	AddItem(ctx context.Context, sessionID string, productID string, quantity int, source string) (*models.Cart, error)
 	UpdateItem(ctx context.Context, sessionID string, productID string, quantity int) (*models.Cart, error)
 	RemoveItem(ctx context.Context, sessionID string, productID string) (*models.Cart, error)
 	SaveCart(ctx context.Context, cart *models.Cart) error
 }
 
 const (
-	cartKeyPrefix = "cart:"
-	cartTTL       = 24 * time.Hour // Cart expires after 24 hours
+	cartKeyPrefix  = "cart:"
+	cartTTL        = 24 * time.Hour // Cart expires after 24 hours
+	userCartPrefix = "user:"
+	userCartTTL    = 30 * 24 * time.Hour // Signed-in users' carts outlive the session
 )
 
+var (
+	// ErrInvalidGuestSession is returned when a user cart ID is passed where a guest session ID is expected
+	ErrInvalidGuestSession = errors.New("invalid guest session")
+	// ErrStaleRevision is returned when a change is based on an earlier revision of the cart
+	ErrStaleRevision = errors.New("cart has changed since the revision the change was based on")
+	// ErrSavedItemNotFound is returned when a product is not on the saved-for-later list
+	ErrSavedItemNotFound = errors.New("item not saved for later")
+)
+
+// UserCartID returns the cart ID of a signed-in user's persistent cart. Cart methods taking a
+// session ID accept it in place of a guest session ID.
+func UserCartID(userID string) string {
+	return userCartPrefix + userID
+}
+
+// CartOwner returns the user a cart ID belongs to, if it is a user cart
+func CartOwner(cartID string) (string, bool) {
+	return strings.CutPrefix(cartID, userCartPrefix)
+}
+
 type Service struct {
 	redisClient *redis.Client
 }
@@ -39,20 +64,44 @@ func NewService() *Service {
 	}
 }
 
-// GetCart retrieves a cart from Redis by session ID
+// GetCart retrieves a cart from Redis by session ID. A user cart missing from Redis, or unreachable while
+// Redis is down, is read from the database, and copied back into Redis when it was only missing.
 func (s *Service) GetCart(ctx context.Context, sessionID string) (*models.Cart, error) {
 	key := cartKeyPrefix + sessionID
 
 	data, err := s.redisClient.Get(ctx, key).Result()
 	if err != nil {
+		if userID, ok := CartOwner(sessionID); ok {
+			saved, loadErr := loadUserCart(ctx, database.GetDB(), userID)
+			if loadErr != nil {
+				return nil, loadErr
+			}
+			if saved != nil {
+				if err == redis.Nil {
+					if encoded, marshalErr := json.Marshal(saved); marshalErr == nil {
+						if setErr := s.redisClient.Set(ctx, key, encoded, userCartTTL).Err(); setErr != nil {
+							logger.Named("cart").Warn("Failed to restore saved cart to Redis", map[string]interface{}{"user_id": userID, "error": setErr.Error()})
+						}
+					}
+				} else {
+					logger.Named("cart").Warn("Serving saved cart while Redis is unavailable", map[string]interface{}{"user_id": userID, "error": err.Error()})
+				}
+				return saved, nil
+			}
+		}
 		if err == redis.Nil {
 			// Cart doesn't exist, return empty cart
-			return &models.Cart{
-				SessionID: sessionID,
-				Items:     []models.CartItem{},
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
-			}, nil
+			cart := &models.Cart{
+				SessionID:     sessionID,
+				Items:         []models.CartItem{},
+				SavedForLater: []models.CartItem{},
+				CreatedAt:     time.Now(),
+				UpdatedAt:     time.Now(),
+			}
+			if userID, ok := CartOwner(sessionID); ok {
+				cart.UserID = &userID
+			}
+			return cart, nil
 		}
 		return nil, fmt.Errorf("failed to get cart from Redis: %w", err)
 	}
@@ -61,28 +110,44 @@ func (s *Service) GetCart(ctx context.Context, sessionID string) (*models.Cart,
 	if err := json.Unmarshal([]byte(data), &cart); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal cart data: %w", err)
 	}
+	if cart.SavedForLater == nil {
+		cart.SavedForLater = []models.CartItem{}
+	}
 
 	return &cart, nil
 }
 
-// SaveCart saves a cart to Redis
+// SaveCart saves a changed cart to Redis as its next revision. User carts are saved to the database first, so
+// they survive the Redis key.
 func (s *Service) SaveCart(ctx context.Context, cart *models.Cart) error {
 	key := cartKeyPrefix + cart.SessionID
+	cart.Revision++
+	if userID, ok := CartOwner(cart.SessionID); ok {
+		if err := storeUserCart(ctx, database.GetDB(), userID, cart); err != nil {
+			cart.Revision--
+			return err
+		}
+	}
 
 	data, err := json.Marshal(cart)
 	if err != nil {
 		return fmt.Errorf("failed to marshal cart data: %w", err)
 	}
 
-	if err := s.redisClient.Set(ctx, key, data, cartTTL).Err(); err != nil {
+	ttl := cartTTL
+	if cart.UserID != nil {
+		ttl = userCartTTL
+	}
+	if err := s.redisClient.Set(ctx, key, data, ttl).Err(); err != nil {
 		return fmt.Errorf("failed to save cart to Redis: %w", err)
 	}
 
 	return nil
 }
 
-// AddItem adds an item to the cart
-func (s *Service) AddItem(ctx context.Context, sessionID string, productID string, quantity int) (*models.Cart, error) {
+// AddItem adds an item to the cart. source attributes a new line to the surface it was added from;
+// existing lines keep their original source.
+func (s *Service) AddItem(ctx context.Context, sessionID string, productID string, quantity int, source string) (*models.Cart, error) {
 	// Get current cart
 	cart, err := s.GetCart(ctx, sessionID)
 	if err != nil {
@@ -119,6 +184,7 @@ func (s *Service) AddItem(ctx context.Context, sessionID string, productID strin
 			ProductID: productID,
 			Quantity:  quantity,
 			Price:     product.Price,
+			Source:    source,
 			Product:   *product,
 		}
 		cart.Items = append(cart.Items, cartItem)
@@ -203,10 +269,174 @@ func (s *Service) RemoveItem(ctx context.Context, sessionID string, productID st
 	return cart, nil
 }
 
-// ClearCart removes all items from the cart
+// ClearCart removes all items from the cart, including the saved copy of a user cart. Items saved for later
+// stay. The emptied cart is kept as a new revision, so revisions never go back.
 func (s *Service) ClearCart(ctx context.Context, sessionID string) error {
-	key := cartKeyPrefix + sessionID
-	return s.redisClient.Del(ctx, key).Err()
+	cart, err := s.GetCart(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if cart.IsEmpty() {
+		return nil
+	}
+	cart.Items = []models.CartItem{}
+	cart.CalculateTotals()
+	return s.SaveCart(ctx, cart)
+}
+
+// SaveForLater moves a cart line to the saved-for-later list. A product already saved for later gets the
+// line's quantity added.
+func (s *Service) SaveForLater(ctx context.Context, sessionID string, productID string) (*models.Cart, error) {
+	cart, err := s.GetCart(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := cart.FindItem(productID)
+	if item == nil {
+		return nil, fmt.Errorf("item not found in cart")
+	}
+	line := *item
+	cart.RemoveItem(productID)
+	if saved := cart.FindSavedItem(productID); saved != nil {
+		saved.Quantity += line.Quantity
+		saved.Price = line.Price
+	} else {
+		cart.SavedForLater = append(cart.SavedForLater, line)
+	}
+
+	cart.CalculateTotals()
+	if err := s.SaveCart(ctx, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// MoveToCart moves a line saved for later back into the cart at the current price. The product must still
+// be available with enough inventory for the line, added to any quantity already in the cart.
+func (s *Service) MoveToCart(ctx context.Context, sessionID string, productID string) (*models.Cart, error) {
+	cart, err := s.GetCart(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := cart.FindSavedItem(productID)
+	if saved == nil {
+		return nil, ErrSavedItemNotFound
+	}
+	product, err := s.getProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	if !product.IsActive {
+		return nil, fmt.Errorf("product is not available")
+	}
+
+	quantity := saved.Quantity
+	existing := cart.FindItem(productID)
+	if existing != nil {
+		quantity += existing.Quantity
+	}
+	if product.Inventory < quantity {
+		return nil, fmt.Errorf("insufficient inventory: only %d items available", product.Inventory)
+	}
+
+	if existing != nil {
+		existing.Quantity = quantity
+		existing.Price = product.Price
+	} else {
+		line := *saved
+		line.Price = product.Price
+		line.Product = *product
+		cart.Items = append(cart.Items, line)
+	}
+	cart.RemoveSavedItem(productID)
+
+	cart.CalculateTotals()
+	if err := s.SaveCart(ctx, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// CheckRevision returns ErrStaleRevision with the current cart when it is no longer at revision expected
+func (s *Service) CheckRevision(ctx context.Context, sessionID string, expected int64) (*models.Cart, error) {
+	cart, err := s.GetCart(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.Revision != expected {
+		return cart, ErrStaleRevision
+	}
+	return cart, nil
+}
+
+// MergeGuestCart moves a guest session's cart into the user's persistent cart when the user signs in.
+// Quantities of products in both carts are added up and capped at the available inventory; products
+// that are gone or inactive are dropped. Items the guest saved for later join the user's list unless the
+// user already has the product. The guest cart is emptied and the user cart returned.
+func (s *Service) MergeGuestCart(ctx context.Context, sessionID string, userID string) (*models.Cart, error) {
+	if _, ok := CartOwner(sessionID); ok {
+		return nil, ErrInvalidGuestSession
+	}
+
+	guest, err := s.GetCart(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	userCart, err := s.GetCart(ctx, UserCartID(userID))
+	if err != nil {
+		return nil, err
+	}
+	if guest.IsEmpty() && len(guest.SavedForLater) == 0 {
+		return userCart, nil
+	}
+
+	for _, item := range guest.Items {
+		product, err := s.getProduct(item.ProductID)
+		if err != nil || !product.IsActive {
+			continue
+		}
+
+		existing := userCart.FindItem(item.ProductID)
+		quantity := item.Quantity
+		if existing != nil {
+			quantity += existing.Quantity
+		}
+		quantity = min(quantity, product.Inventory)
+		if quantity <= 0 {
+			continue
+		}
+
+		if existing != nil {
+			existing.Quantity = quantity
+			existing.Price = product.Price
+			continue
+		}
+		item.Quantity = quantity
+		item.Price = product.Price
+		item.Product = *product
+		userCart.Items = append(userCart.Items, item)
+	}
+	for _, item := range guest.SavedForLater {
+		if userCart.FindItem(item.ProductID) != nil || userCart.FindSavedItem(item.ProductID) != nil {
+			continue
+		}
+		userCart.SavedForLater = append(userCart.SavedForLater, item)
+	}
+
+	userCart.CalculateTotals()
+	if err := s.SaveCart(ctx, userCart); err != nil {
+		return nil, err
+	}
+	guest.Items = []models.CartItem{}
+	guest.SavedForLater = []models.CartItem{}
+	guest.CalculateTotals()
+	if err := s.SaveCart(ctx, guest); err != nil {
+		return nil, fmt.Errorf("failed to empty guest cart: %w", err)
+	}
+
+	return userCart, nil
 }
 
 // GetCartWithProducts retrieves a cart and populates product details
@@ -222,12 +452,24 @@ func (s *Service) GetCartWithProducts(ctx context.Context, sessionID string) (*m
 		if err != nil {
 			// If product is not found, we might want to remove it from cart
 			// For now, we'll just skip it
+			logger.Named("cart").Debug("Skipping cart item whose product could not be loaded", map[string]interface{}{
+				"session_id": sessionID,
+				"product_id": cart.Items[i].ProductID,
+			})
 			continue
 		}
 		cart.Items[i].Product = *product
 		// Update price in case it changed
 		cart.Items[i].Price = product.Price
 	}
+	for i := range cart.SavedForLater {
+		product, err := s.getProduct(cart.SavedForLater[i].ProductID)
+		if err != nil {
+			continue
+		}
+		cart.SavedForLater[i].Product = *product
+		cart.SavedForLater[i].Price = product.Price
+	}
 
 	// Recalculate totals in case prices changed
 	cart.CalculateTotals()
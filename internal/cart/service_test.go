@@ -267,3 +267,14 @@ func TestCart_CalculateTotal(t *testing.T) {
 		})
 	}
 }
+
+func TestUserCartID(t *testing.T) {
+	cartID := UserCartID("user-1")
+
+	owner, ok := CartOwner(cartID)
+	assert.True(t, ok)
+	assert.Equal(t, "user-1", owner)
+
+	_, ok = CartOwner("3f1c2a9e-guest-session")
+	assert.False(t, ok)
+}
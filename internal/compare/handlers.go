@@ -0,0 +1,95 @@
+package compare
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new comparison handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// AddRequest represents the request body for adding a product to the comparison
+type AddRequest struct {
+	ProductID string `json:"productId" binding:"required"`
+}
+
+// GetComparison handles GET /api/compare
+func (h *Handler) GetComparison(c *gin.Context) {
+	comparison, err := h.service.GetComparison(c.Request.Context(), h.getOrCreateSessionID(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "COMPARE_FETCH_ERROR", "Failed to get comparison", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Comparison retrieved successfully", comparison)
+}
+
+// AddProduct handles POST /api/compare
+func (h *Handler) AddProduct(c *gin.Context) {
+	var req AddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	ids, err := h.service.Add(c.Request.Context(), h.getOrCreateSessionID(c), req.ProductID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrProductNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+		case errors.Is(err, ErrComparisonFull):
+			utils.ErrorResponse(c, http.StatusConflict, "COMPARISON_FULL", "Comparison is full", gin.H{"maxProducts": MaxProducts})
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "COMPARE_ADD_ERROR", "Failed to add product to comparison", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product added to comparison", gin.H{"productIds": ids})
+}
+
+// RemoveProduct handles DELETE /api/compare/:productId
+func (h *Handler) RemoveProduct(c *gin.Context) {
+	ids, err := h.service.Remove(c.Request.Context(), h.getOrCreateSessionID(c), c.Param("productId"))
+	if err != nil {
+		if errors.Is(err, ErrNotCompared) {
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_COMPARED", "Product is not in the comparison", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "COMPARE_REMOVE_ERROR", "Failed to remove product from comparison", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product removed from comparison", gin.H{"productIds": ids})
+}
+
+// ClearComparison handles DELETE /api/compare
+func (h *Handler) ClearComparison(c *gin.Context) {
+	if err := h.service.Clear(c.Request.Context(), h.getOrCreateSessionID(c)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "COMPARE_CLEAR_ERROR", "Failed to clear comparison", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Comparison cleared successfully", nil)
+}
+
+// getOrCreateSessionID gets the session ID from the cookie shared with the cart, creating one if missing
+func (h *Handler) getOrCreateSessionID(c *gin.Context) string {
+	sessionID, err := c.Cookie("session_id")
+	if err != nil || sessionID == "" {
+		sessionID = uuid.New().String()
+		c.SetCookie("session_id", sessionID, 86400, "/", "", false, true)
+	}
+	return sessionID
+}
@@ -0,0 +1,27 @@
+package compare
+
+import (
+	"ecommerce-website/internal/app"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires session-based product comparison into the application
+type Module struct {
+	handler *Handler
+}
+
+// NewModule creates the compare module
+func NewModule(deps app.Deps) app.Module {
+	return &Module{handler: NewHandler(NewService(deps.DB, deps.Redis))}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "compare"
+}
+
+// RegisterAPIRoutes sets up the comparison routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	RegisterRoutes(api, m.handler)
+}
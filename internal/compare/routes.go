@@ -0,0 +1,16 @@
+package compare
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registers product comparison routes
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
+	compareGroup := router.Group("/compare")
+	{
+		compareGroup.GET("", handler.GetComparison)
+		compareGroup.POST("", handler.AddProduct)
+		compareGroup.DELETE("", handler.ClearComparison)
+		compareGroup.DELETE("/:productId", handler.RemoveProduct)
+	}
+}
@@ -0,0 +1,273 @@
+package compare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	compareKeyPrefix = "compare:"
+	compareTTL       = 24 * time.Hour // Comparison sets expire with the session cookie
+
+	// MaxProducts is the number of products a session can compare at once
+	MaxProducts = 4
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrComparisonFull  = errors.New("comparison is full")
+	ErrNotCompared     = errors.New("product is not in the comparison")
+)
+
+// Comparison is a session's compared products with their specifications aligned attribute by attribute
+type Comparison struct {
+	Products   []models.Product `json:"products"`
+	Attributes []AttributeRow   `json:"attributes"`
+}
+
+// AttributeRow is one attribute across every compared product. Values line up with Comparison.Products;
+// a product without the attribute has a null value.
+type AttributeRow struct {
+	Key     string        `json:"key"`
+	Label   string        `json:"label"`
+	Unit    string        `json:"unit,omitempty"`
+	Values  []interface{} `json:"values"`
+	Differs bool          `json:"differs"`
+}
+
+// ServiceInterface defines the interface for the comparison service
+type ServiceInterface interface {
+	Add(ctx context.Context, sessionID, productID string) ([]string, error)
+	Remove(ctx context.Context, sessionID, productID string) ([]string, error)
+	Clear(ctx context.Context, sessionID string) error
+	GetComparison(ctx context.Context, sessionID string) (*Comparison, error)
+}
+
+type Service struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+
+	mu    sync.Mutex
+	local map[string][]string // used when Redis is not configured
+}
+
+// NewService creates a new comparison service. Compared product IDs are kept per session in Redis,
+// or in memory when no Redis client is given.
+func NewService(db *gorm.DB, redisClient *redis.Client) *Service {
+	return &Service{
+		db:          db,
+		redisClient: redisClient,
+		local:       make(map[string][]string),
+	}
+}
+
+// Add puts a product into the session's comparison; adding a product twice is a no-op
+func (s *Service) Add(ctx context.Context, sessionID, productID string) ([]string, error) {
+	var product models.Product
+	if err := s.db.Select("id").Where("id = ? AND is_active = ?", productID, true).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch product: %w", err)
+	}
+
+	ids, err := s.load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if id == productID {
+			return ids, nil
+		}
+	}
+	if len(ids) >= MaxProducts {
+		return nil, ErrComparisonFull
+	}
+
+	ids = append(ids, productID)
+	if err := s.save(ctx, sessionID, ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Remove takes a product out of the session's comparison
+func (s *Service) Remove(ctx context.Context, sessionID, productID string) ([]string, error) {
+	ids, err := s.load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != productID {
+			remaining = append(remaining, id)
+		}
+	}
+	if len(remaining) == len(ids) {
+		return nil, ErrNotCompared
+	}
+
+	if err := s.save(ctx, sessionID, remaining); err != nil {
+		return nil, err
+	}
+	return remaining, nil
+}
+
+// Clear empties the session's comparison
+func (s *Service) Clear(ctx context.Context, sessionID string) error {
+	return s.save(ctx, sessionID, nil)
+}
+
+// GetComparison loads the session's compared products in the order they were added and aligns their
+// specifications. Products that were deleted or deactivated since being added are left out.
+func (s *Service) GetComparison(ctx context.Context, sessionID string) (*Comparison, error) {
+	ids, err := s.load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return buildComparison(nil), nil
+	}
+
+	var found []models.Product
+	if err := s.db.Preload("Category").Where("id IN ? AND is_active = ?", ids, true).Find(&found).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch products: %w", err)
+	}
+	byID := make(map[string]models.Product, len(found))
+	for _, product := range found {
+		byID[product.ID] = product
+	}
+
+	products := make([]models.Product, 0, len(found))
+	for _, id := range ids {
+		if product, ok := byID[id]; ok {
+			products = append(products, product)
+		}
+	}
+	return buildComparison(products), nil
+}
+
+// buildComparison aligns product specifications. Attributes follow the category schemas of the products in
+// comparison order; specification keys outside every schema come last, sorted by key.
+func buildComparison(products []models.Product) *Comparison {
+	if products == nil {
+		products = []models.Product{}
+	}
+
+	var schema []models.CategoryAttribute
+	known := make(map[string]bool)
+	for _, product := range products {
+		for _, attribute := range product.Category.Attributes {
+			if !known[attribute.Key] {
+				known[attribute.Key] = true
+				schema = append(schema, attribute)
+			}
+		}
+	}
+
+	var extra []string
+	for _, product := range products {
+		for key := range product.Specifications {
+			if !known[key] {
+				known[key] = true
+				extra = append(extra, key)
+			}
+		}
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		schema = append(schema, models.CategoryAttribute{Key: key, Label: key})
+	}
+
+	rows := make([]AttributeRow, 0, len(schema))
+	for _, attribute := range schema {
+		row := AttributeRow{
+			Key:    attribute.Key,
+			Label:  attribute.Label,
+			Unit:   attribute.Unit,
+			Values: make([]interface{}, len(products)),
+		}
+		for i, product := range products {
+			row.Values[i] = product.Specifications[attribute.Key]
+		}
+		row.Differs = differs(row.Values)
+		rows = append(rows, row)
+	}
+
+	return &Comparison{Products: products, Attributes: rows}
+}
+
+// differs reports whether the values are not all the same
+func differs(values []interface{}) bool {
+	for i := 1; i < len(values); i++ {
+		if fmt.Sprint(values[i]) != fmt.Sprint(values[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads the compared product IDs of a session
+func (s *Service) load(ctx context.Context, sessionID string) ([]string, error) {
+	if s.redisClient == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return append([]string(nil), s.local[sessionID]...), nil
+	}
+
+	data, err := s.redisClient.Get(ctx, compareKeyPrefix+sessionID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get comparison from Redis: %w", err)
+	}
+
+	var ids []string
+	if err := json.Unmarshal([]byte(data), &ids); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal comparison: %w", err)
+	}
+	return ids, nil
+}
+
+// save writes the compared product IDs of a session, deleting the set once it is empty
+func (s *Service) save(ctx context.Context, sessionID string, ids []string) error {
+	if s.redisClient == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(ids) == 0 {
+			delete(s.local, sessionID)
+		} else {
+			s.local[sessionID] = ids
+		}
+		return nil
+	}
+
+	key := compareKeyPrefix + sessionID
+	if len(ids) == 0 {
+		if err := s.redisClient.Del(ctx, key).Err(); err != nil {
+			return fmt.Errorf("failed to clear comparison in Redis: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("failed to marshal comparison: %w", err)
+	}
+	if err := s.redisClient.Set(ctx, key, data, compareTTL).Err(); err != nil {
+		return fmt.Errorf("failed to save comparison to Redis: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package compare
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCompareTest(t *testing.T) (*gorm.DB, *Service) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}))
+	return db, NewService(db, nil)
+}
+
+func createCompareProduct(t *testing.T, db *gorm.DB, categoryID, sku string, specs models.JSONB) *models.Product {
+	product := &models.Product{
+		Name:           sku,
+		Price:          100,
+		SKU:            sku,
+		Inventory:      5,
+		IsActive:       true,
+		CategoryID:     categoryID,
+		Specifications: specs,
+	}
+	require.NoError(t, db.Create(product).Error)
+	return product
+}
+
+func TestService_AddAndRemove(t *testing.T) {
+	db, service := setupCompareTest(t)
+	ctx := context.Background()
+	category := &models.Category{Name: "Phones", Slug: "phones", IsActive: true}
+	require.NoError(t, db.Create(category).Error)
+
+	var ids []string
+	for _, sku := range []string{"P1", "P2", "P3", "P4", "P5"} {
+		ids = append(ids, createCompareProduct(t, db, category.ID, sku, nil).ID)
+	}
+
+	for _, id := range ids[:MaxProducts] {
+		_, err := service.Add(ctx, "session-1", id)
+		require.NoError(t, err)
+	}
+	compared, err := service.Add(ctx, "session-1", ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, ids[:MaxProducts], compared, "re-adding a product is a no-op")
+
+	_, err = service.Add(ctx, "session-1", ids[4])
+	assert.ErrorIs(t, err, ErrComparisonFull)
+
+	_, err = service.Add(ctx, "session-1", "missing")
+	assert.ErrorIs(t, err, ErrProductNotFound)
+
+	compared, err = service.Remove(ctx, "session-1", ids[1])
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids[0], ids[2], ids[3]}, compared)
+
+	_, err = service.Remove(ctx, "session-1", ids[1])
+	assert.ErrorIs(t, err, ErrNotCompared)
+
+	comparison, err := service.GetComparison(ctx, "session-2")
+	require.NoError(t, err)
+	assert.Empty(t, comparison.Products, "sessions do not share comparisons")
+
+	require.NoError(t, service.Clear(ctx, "session-1"))
+	comparison, err = service.GetComparison(ctx, "session-1")
+	require.NoError(t, err)
+	assert.Empty(t, comparison.Products)
+}
+
+func TestService_GetComparisonAlignsSpecifications(t *testing.T) {
+	db, service := setupCompareTest(t)
+	ctx := context.Background()
+	category := &models.Category{
+		Name:     "Phones",
+		Slug:     "phones",
+		IsActive: true,
+		Attributes: models.AttributeSchema{
+			{Key: "screen", Label: "Screen size", Unit: "in"},
+			{Key: "battery", Label: "Battery", Unit: "mAh"},
+		},
+	}
+	require.NoError(t, db.Create(category).Error)
+
+	first := createCompareProduct(t, db, category.ID, "A", models.JSONB{"screen": 6.1, "battery": 4000.0, "color": "black"})
+	second := createCompareProduct(t, db, category.ID, "B", models.JSONB{"screen": 6.1, "battery": 5000.0})
+	retired := createCompareProduct(t, db, category.ID, "C", models.JSONB{"screen": 5.0})
+
+	for _, product := range []*models.Product{second, first, retired} {
+		_, err := service.Add(ctx, "session", product.ID)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Delete(retired).Error)
+
+	comparison, err := service.GetComparison(ctx, "session")
+	require.NoError(t, err)
+
+	require.Len(t, comparison.Products, 2)
+	assert.Equal(t, second.ID, comparison.Products[0].ID, "products keep the order they were added in")
+	assert.Equal(t, first.ID, comparison.Products[1].ID)
+
+	require.Len(t, comparison.Attributes, 3)
+	screen, battery, color := comparison.Attributes[0], comparison.Attributes[1], comparison.Attributes[2]
+
+	assert.Equal(t, "Screen size", screen.Label)
+	assert.Equal(t, "in", screen.Unit)
+	assert.Equal(t, []interface{}{6.1, 6.1}, screen.Values)
+	assert.False(t, screen.Differs)
+
+	assert.Equal(t, "battery", battery.Key)
+	assert.Equal(t, []interface{}{5000.0, 4000.0}, battery.Values)
+	assert.True(t, battery.Differs)
+
+	assert.Equal(t, "color", color.Key, "attributes outside the schema come last")
+	assert.Equal(t, "color", color.Label)
+	assert.Equal(t, []interface{}{nil, "black"}, color.Values)
+	assert.True(t, color.Differs)
+}
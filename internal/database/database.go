@@ -1,8 +1,12 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"ecommerce-website/internal/config"
 	"ecommerce-website/internal/models"
@@ -15,7 +19,8 @@ import (
 
 var DB *gorm.DB
 
-// Initialize sets up the database connection and runs migrations
+// Initialize sets up the database connection. The schema is managed by the migrations in migrations/,
+// applied with cmd/migrate.
 func Initialize(cfg *config.Config) error {
 	var err error
 
@@ -36,97 +41,27 @@ func Initialize(cfg *config.Config) error {
 		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
-	// Configure connection pool
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
+	configurePool(sqlDB, cfg)
 
 	log.Println("Database connection established successfully")
 
-	// Run migrations
-	if err := runMigrations(); err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+	if err := initializeReplicas(cfg, gormConfig); err != nil {
+		return err
 	}
-
-	log.Println("Database migrations completed successfully")
 	return nil
 }
 
-// runMigrations runs all database migrations
-func runMigrations() error {
-	// Enable UUID extension
-	if err := DB.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"").Error; err != nil {
-		return fmt.Errorf("failed to create uuid extension: %w", err)
-	}
-
-	// Auto-migrate all models
-	err := DB.AutoMigrate(
-		&models.User{},
-		&models.Address{},
-		&models.Category{},
-		&models.Product{},
-		&models.Order{},
-		&models.OrderItem{},
-		&models.Payment{},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to auto-migrate: %w", err)
-	}
-
-	// Create additional indexes for better performance
-	if err := createIndexes(); err != nil {
-		return fmt.Errorf("failed to create indexes: %w", err)
-	}
-
-	return nil
-}
-
-// createIndexes creates additional database indexes for performance
-func createIndexes() error {
-	indexes := []string{
-		// User indexes
-		"CREATE INDEX IF NOT EXISTS idx_users_email_active ON users(email, is_active)",
-		"CREATE INDEX IF NOT EXISTS idx_users_role ON users(role)",
-
-		// Address indexes
-		"CREATE INDEX IF NOT EXISTS idx_addresses_user_type ON addresses(user_id, type)",
-		"CREATE INDEX IF NOT EXISTS idx_addresses_default ON addresses(user_id, is_default)",
-
-		// Category indexes
-		"CREATE INDEX IF NOT EXISTS idx_categories_parent_active ON categories(parent_id, is_active)",
-		"CREATE INDEX IF NOT EXISTS idx_categories_sort_order ON categories(sort_order)",
-
-		// Product indexes
-		"CREATE INDEX IF NOT EXISTS idx_products_category_active ON products(category_id, is_active)",
-		"CREATE INDEX IF NOT EXISTS idx_products_price_active ON products(price, is_active)",
-		"CREATE INDEX IF NOT EXISTS idx_products_inventory_active ON products(inventory, is_active)",
-		"CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin(name gin_trgm_ops)",
-
-		// Order indexes
-		"CREATE INDEX IF NOT EXISTS idx_orders_user_status ON orders(user_id, status)",
-		"CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at)",
-		"CREATE INDEX IF NOT EXISTS idx_orders_total ON orders(total)",
-
-		// OrderItem indexes
-		"CREATE INDEX IF NOT EXISTS idx_order_items_order_product ON order_items(order_id, product_id)",
-
-		// Payment indexes
-		"CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments(order_id)",
-		"CREATE INDEX IF NOT EXISTS idx_payments_razorpay_order_id ON payments(razorpay_order_id)",
-		"CREATE INDEX IF NOT EXISTS idx_payments_status ON payments(status)",
-	}
-
-	for _, index := range indexes {
-		if err := DB.Exec(index).Error; err != nil {
-			// Log warning but don't fail if index creation fails (might already exist)
-			log.Printf("Warning: failed to create index: %s - %v", index, err)
-		}
-	}
-
-	return nil
+// configurePool applies the connection pool settings to a primary or replica connection
+func configurePool(sqlDB *sql.DB, cfg *config.Config) {
+	sqlDB.SetMaxOpenConns(int(cfg.DBMaxOpenConns))
+	sqlDB.SetMaxIdleConns(int(cfg.DBMaxIdleConns))
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.DBConnMaxLifetimeSeconds) * time.Second)
+	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.DBConnMaxIdleTimeSeconds) * time.Second)
 }
 
-// Close closes the database connection
+// Close closes the database connection and the replica connections
 func Close() error {
+	closeReplicas()
 	if DB != nil {
 		sqlDB, err := DB.DB()
 		if err != nil {
@@ -137,6 +72,18 @@ func Close() error {
 	return nil
 }
 
+// Ping checks that the database answers
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database is not initialized")
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return DB
@@ -170,12 +117,72 @@ func runTestMigrations() error {
 	// Auto-migrate all models
 	err := DB.AutoMigrate(
 		&models.User{},
+		&models.AdminLoginEvent{},
+		&models.MagicLinkToken{},
 		&models.Address{},
 		&models.Category{},
 		&models.Product{},
+		&models.ProductDocument{},
+		&models.ProductTranslation{},
+		&models.Promotion{},
+		&models.PromotionProduct{},
+		&models.PromotionCode{},
+		&models.ProductLink{},
 		&models.Order{},
 		&models.OrderItem{},
 		&models.Payment{},
+		&models.PaymentEvent{},
+		&models.Refund{},
+		&models.RefundItem{},
+		&models.OrderAdjustment{},
+		&models.StoreCreditEntry{},
+		&models.FinanceJournalEntry{},
+		&models.SLAPolicy{},
+		&models.SLAAlert{},
+		&models.Warranty{},
+		&models.WarrantyClaim{},
+		&models.WarrantyClaimEvent{},
+		&models.CustomerMerge{},
+		&models.ProductPrice{},
+		&models.ProductListing{},
+		&models.UserCart{},
+		&models.UserCartItem{},
+		&models.EmailCampaign{},
+		&models.EmailCampaignRecipient{},
+		&models.EmailSuppression{},
+		&models.EmailLog{},
+		&models.EmailEvent{},
+		&models.ProductDailyStat{},
+		&models.FunnelEvent{},
+		&models.ReportQuery{},
+		&models.APIKey{},
+		&models.ReturnRequest{},
+		&models.Shipment{},
+		&models.ShipmentItem{},
+		&models.Setting{},
+		&models.InventoryReservation{},
+		&models.ProfileCompletionEvent{},
+		&models.OrderEvent{},
+		&models.OrderStatusHistory{},
+		&models.OrderComment{},
+		&models.DraftOrder{},
+		&models.DraftOrderItem{},
+		&models.OrderItemSerial{},
+		&models.WishlistItem{},
+		&models.WishlistRevision{},
+		&models.TaxRule{},
+		&models.ShippingMethod{},
+		&models.DeliveryZone{},
+		&models.RetentionRule{},
+		&models.RetentionRun{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.BusEvent{},
+		&models.InvariantRun{},
+		&models.InvariantViolation{},
+		&models.SavedView{},
+		&models.AuditLog{},
+		&models.OutboxMessage{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to auto-migrate test database: %w", err)
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 
@@ -42,6 +43,14 @@ func CloseRedis() error {
 	return nil
 }
 
+// PingRedis checks that Redis answers
+func PingRedis(ctx context.Context) error {
+	if RedisClient == nil {
+		return errors.New("redis is not initialized")
+	}
+	return RedisClient.Ping(ctx).Err()
+}
+
 // GetRedisClient returns the Redis client instance
 func GetRedisClient() *redis.Client {
 	return RedisClient
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"ecommerce-website/internal/config"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// ReadDB serves read-only queries that tolerate replication lag, such as the storefront catalog and search.
+// It spreads queries over the read replicas and is nil when none are configured; use GetReadDB.
+var ReadDB *gorm.DB
+
+var replicas *replicaPool
+
+// GetReadDB returns the replica handle for catalog and search reads, or the primary without replicas.
+// Writes and reads that must see them, such as loading a row to update it, always use GetDB.
+func GetReadDB() *gorm.DB {
+	if ReadDB != nil {
+		return ReadDB
+	}
+	return DB
+}
+
+// initializeReplicas connects to the replicas in DATABASE_REPLICA_URLS behind a single handle
+func initializeReplicas(cfg *config.Config, gormConfig *gorm.Config) error {
+	var dsns []string
+	for _, dsn := range strings.Split(cfg.DatabaseReplicaURLs, ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			dsns = append(dsns, dsn)
+		}
+	}
+	if len(dsns) == 0 {
+		return nil
+	}
+
+	pool := &replicaPool{}
+	for i, dsn := range dsns {
+		replica, err := gorm.Open(postgres.Open(dsn), gormConfig)
+		if err != nil {
+			pool.close()
+			return fmt.Errorf("failed to connect to read replica %d: %w", i+1, err)
+		}
+		sqlDB, err := replica.DB()
+		if err != nil {
+			pool.close()
+			return fmt.Errorf("failed to get sql.DB of read replica %d: %w", i+1, err)
+		}
+		configurePool(sqlDB, cfg)
+		pool.dbs = append(pool.dbs, sqlDB)
+	}
+
+	readDB, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), gormConfig)
+	if err != nil {
+		pool.close()
+		return fmt.Errorf("failed to set up read replicas: %w", err)
+	}
+	ReadDB, replicas = readDB, pool
+	log.Printf("Connected to %d read replicas", len(pool.dbs))
+	return nil
+}
+
+func closeReplicas() {
+	if replicas != nil {
+		replicas.close()
+		ReadDB, replicas = nil, nil
+	}
+}
+
+// PingReplicas checks that every read replica answers. It succeeds when no replicas are configured.
+func PingReplicas(ctx context.Context) error {
+	if replicas == nil {
+		return nil
+	}
+	var errs []error
+	for i, db := range replicas.dbs {
+		if err := db.PingContext(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("replica %d: %w", i+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// replicaPool is a gorm connection pool sending each statement to the next replica in turn. It is not
+// used for transactions, which a replica handle has no business running.
+type replicaPool struct {
+	dbs  []*sql.DB
+	next atomic.Uint64
+}
+
+func (p *replicaPool) pick() *sql.DB {
+	return p.dbs[(p.next.Add(1)-1)%uint64(len(p.dbs))]
+}
+
+func (p *replicaPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.pick().PrepareContext(ctx, query)
+}
+
+func (p *replicaPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.pick().ExecContext(ctx, query, args...)
+}
+
+func (p *replicaPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.pick().QueryContext(ctx, query, args...)
+}
+
+func (p *replicaPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.pick().QueryRowContext(ctx, query, args...)
+}
+
+// GetDBConn lets gorm's DB() reach a replica connection
+func (p *replicaPool) GetDBConn() (*sql.DB, error) {
+	return p.pick(), nil
+}
+
+func (p *replicaPool) close() {
+	for _, db := range p.dbs {
+		_ = db.Close()
+	}
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openReplica(t *testing.T, name string) *sql.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	_, err = sqlDB.Exec("CREATE TABLE replica (name TEXT)")
+	require.NoError(t, err)
+	_, err = sqlDB.Exec("INSERT INTO replica (name) VALUES (?)", name)
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+func TestReplicaPool_RoundRobin(t *testing.T) {
+	pool := &replicaPool{dbs: []*sql.DB{openReplica(t, "a"), openReplica(t, "b")}}
+
+	var served []string
+	for i := 0; i < 4; i++ {
+		var name string
+		require.NoError(t, pool.QueryRowContext(context.Background(), "SELECT name FROM replica").Scan(&name))
+		served = append(served, name)
+	}
+	assert.Equal(t, []string{"a", "b", "a", "b"}, served)
+}
+
+func TestGetReadDB_FallsBackToPrimary(t *testing.T) {
+	primary := setupTestDB(t)
+	previousDB, previousReadDB := DB, ReadDB
+	t.Cleanup(func() { DB, ReadDB = previousDB, previousReadDB })
+
+	DB, ReadDB = primary, nil
+	assert.Same(t, primary, GetReadDB())
+	assert.NoError(t, PingReplicas(context.Background()))
+
+	replica := setupTestDB(t)
+	ReadDB = replica
+	assert.Same(t, replica, GetReadDB())
+}
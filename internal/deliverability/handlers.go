@@ -0,0 +1,128 @@
+package deliverability
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new deliverability handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// RecordBounce handles POST /api/webhooks/email/bounce (email provider webhook)
+func (h *Handler) RecordBounce(c *gin.Context) {
+	var req BounceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	event, err := h.service.RecordBounce(req, time.Now())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "RECORD_BOUNCE_FAILED", "Failed to record bounce", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Bounce recorded successfully", event)
+}
+
+// RecordComplaint handles POST /api/webhooks/email/complaint (email provider webhook)
+func (h *Handler) RecordComplaint(c *gin.Context) {
+	var req ComplaintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	event, err := h.service.RecordComplaint(req, time.Now())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "RECORD_COMPLAINT_FAILED", "Failed to record complaint", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Complaint recorded successfully", event)
+}
+
+// ListLog handles GET /api/admin/email/log (admin only)
+func (h *Handler) ListLog(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+	status := c.Query("status")
+	switch status {
+	case "", models.EmailLogSent, models.EmailLogFailed, models.EmailLogSuppressed, models.EmailLogBounced, models.EmailLogComplained:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Unknown email status", nil)
+		return
+	}
+
+	logs, total, err := h.service.ListLog(LogQuery{Status: status, Kind: c.Query("kind"), Email: c.Query("email"), Page: page, Limit: limit})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_EMAIL_LOG_FAILED", "Failed to get email log", err.Error())
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Email log retrieved successfully", "emails", logs, utils.NewPagination(page, limit, total))
+}
+
+// GetStats handles GET /api/admin/email/log/stats (admin only). from and to are inclusive days and default
+// to the last 30 days.
+func (h *Handler) GetStats(c *gin.Context) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -30), today
+	for _, param := range []struct {
+		name string
+		dest *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if v := c.Query(param.name); v != "" {
+			parsed, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", fmt.Sprintf("%s must be in YYYY-MM-DD format", param.name), nil)
+				return
+			}
+			*param.dest = parsed
+		}
+	}
+
+	stats, err := h.service.GetStats(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		if errors.Is(err, ErrInvalidRange) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", "from must not be after to", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_EMAIL_STATS_FAILED", "Failed to get deliverability stats", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Deliverability stats retrieved successfully", stats)
+}
+
+// ListSuppressions handles GET /api/admin/email/suppressions (admin only)
+func (h *Handler) ListSuppressions(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+	suppressions, total, err := h.service.ListSuppressions(page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_SUPPRESSIONS_FAILED", "Failed to get suppressed addresses", err.Error())
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Suppressed addresses retrieved successfully", "suppressions", suppressions, utils.NewPagination(page, limit, total))
+}
+
+// RemoveSuppression handles DELETE /api/admin/email/suppressions/:email (admin only)
+func (h *Handler) RemoveSuppression(c *gin.Context) {
+	if err := h.service.RemoveSuppression(c.Param("email")); err != nil {
+		if errors.Is(err, ErrSuppressionNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "SUPPRESSION_NOT_FOUND", "Address is not suppressed", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "REMOVE_SUPPRESSION_FAILED", "Failed to remove suppression", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Suppression removed successfully", nil)
+}
@@ -0,0 +1,119 @@
+package deliverability
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the deliverability service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) RecordBounce(req BounceRequest, now time.Time) (*models.EmailEvent, error) {
+	args := m.Called(req, mock.Anything)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EmailEvent), args.Error(1)
+}
+
+func (m *MockService) RecordComplaint(req ComplaintRequest, now time.Time) (*models.EmailEvent, error) {
+	args := m.Called(req, mock.Anything)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EmailEvent), args.Error(1)
+}
+
+func (m *MockService) ListLog(query LogQuery) ([]models.EmailLog, int64, error) {
+	args := m.Called(query)
+	return args.Get(0).([]models.EmailLog), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockService) GetStats(from, to time.Time) (*Stats, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Stats), args.Error(1)
+}
+
+func (m *MockService) ListSuppressions(page, limit int) ([]models.EmailSuppression, int64, error) {
+	args := m.Called(page, limit)
+	return args.Get(0).([]models.EmailSuppression), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockService) RemoveSuppression(email string) error {
+	return m.Called(email).Error(0)
+}
+
+func TestHandler_Webhooks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := new(MockService)
+	r := gin.New()
+	r.Use(webhookTokenMiddleware("secret"))
+	handler := NewHandler(service)
+	r.POST("/bounce", handler.RecordBounce)
+
+	req := BounceRequest{Email: "jane@example.com", BounceType: models.BounceHard, MessageID: "<m1@example.com>"}
+	service.On("RecordBounce", req, mock.Anything).Return(&models.EmailEvent{Type: models.EmailEventBounce, Email: "jane@example.com"}, nil)
+	body := `{"email":"jane@example.com","bounceType":"hard","messageId":"<m1@example.com>"}`
+
+	for _, tc := range []struct {
+		name   string
+		token  string
+		body   string
+		status int
+	}{
+		{"missing token", "", body, http.StatusUnauthorized},
+		{"wrong token", "nope", body, http.StatusUnauthorized},
+		{"unknown bounce type", "secret", `{"email":"jane@example.com","bounceType":"transient"}`, http.StatusBadRequest},
+		{"recorded", "secret", body, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/bounce", strings.NewReader(tc.body))
+			if tc.token != "" {
+				request.Header.Set(WebhookTokenHeader, tc.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+	service.AssertExpectations(t)
+}
+
+func TestHandler_GetStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := new(MockService)
+	r := gin.New()
+	r.GET("/stats", NewHandler(service).GetStats)
+
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	service.On("GetStats", from, time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)).Return(&Stats{Delivered: 10, BounceRate: 0.1}, nil)
+	service.On("GetStats", time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC)).Return(nil, ErrInvalidRange)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats?from=2026-05-01&to=2026-05-31", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"bounceRate":0.1`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats?from=2026-06-02&to=2026-06-01", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats?from=May", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	service.AssertExpectations(t)
+}
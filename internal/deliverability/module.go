@@ -0,0 +1,36 @@
+package deliverability
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires outbound email logging, bounce and complaint handling into the application
+type Module struct {
+	handler       *Handler
+	authService   *auth.Service
+	webhookSecret string
+}
+
+// NewModule creates the deliverability module
+func NewModule(deps app.Deps) app.Module {
+	return &Module{handler: NewHandler(NewService(deps.DB)), authService: deps.Auth, webhookSecret: deps.Config.EmailWebhookSecret}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "deliverability"
+}
+
+// Models returns the email log tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.EmailLog{}, &models.EmailEvent{}}
+}
+
+// RegisterAPIRoutes sets up the webhook and admin email log routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService, m.webhookSecret)
+}
@@ -0,0 +1,48 @@
+package deliverability
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookTokenHeader carries the shared secret of email provider notifications
+const WebhookTokenHeader = "X-Email-Webhook-Token"
+
+// SetupRoutes sets up the email provider webhooks and the admin email log routes. Webhook callers
+// authenticate with the shared secret; without one the webhooks reject every call.
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service, webhookSecret string) {
+	webhooks := api.Group("/webhooks/email")
+	webhooks.Use(webhookTokenMiddleware(webhookSecret))
+	{
+		webhooks.POST("/bounce", handler.RecordBounce)
+		webhooks.POST("/complaint", handler.RecordComplaint)
+	}
+
+	admin := api.Group("/admin/email")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("/log", handler.ListLog)
+		admin.GET("/log/stats", handler.GetStats)
+		admin.GET("/suppressions", handler.ListSuppressions)
+		admin.DELETE("/suppressions/:email", handler.RemoveSuppression)
+	}
+}
+
+// webhookTokenMiddleware rejects requests whose webhook token does not match secret
+func webhookTokenMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader(WebhookTokenHeader)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_WEBHOOK_TOKEN", "Invalid webhook token", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,308 @@
+package deliverability
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSuppressionNotFound = errors.New("email suppression not found")
+	ErrInvalidRange        = errors.New("invalid date range")
+)
+
+// BounceRequest is a bounce notification from the email provider. Hard bounces (unknown mailbox or
+// domain) suppress the address; soft bounces (full mailbox, greylisting) are only recorded.
+type BounceRequest struct {
+	Email      string     `json:"email" binding:"required,email"`
+	BounceType string     `json:"bounceType" binding:"required,oneof=hard soft"`
+	MessageID  string     `json:"messageId" binding:"max=255"`
+	Reason     string     `json:"reason" binding:"max=2000"`
+	OccurredAt *time.Time `json:"occurredAt"`
+}
+
+// ComplaintRequest is a spam complaint (feedback loop report) from the email provider
+type ComplaintRequest struct {
+	Email        string     `json:"email" binding:"required,email"`
+	MessageID    string     `json:"messageId" binding:"max=255"`
+	FeedbackType string     `json:"feedbackType" binding:"max=100"`
+	OccurredAt   *time.Time `json:"occurredAt"`
+}
+
+// LogQuery filters the admin email log
+type LogQuery struct {
+	Status string
+	Kind   string
+	Email  string
+	Page   int
+	Limit  int
+}
+
+// Stats summarizes email deliverability over a period. Rates are relative to the emails handed to the
+// provider, i.e. those not failed or suppressed before sending.
+type Stats struct {
+	From                time.Time `json:"from"`
+	To                  time.Time `json:"to"`
+	Delivered           int64     `json:"delivered"`
+	Failed              int64     `json:"failed"`
+	Suppressed          int64     `json:"suppressed"`
+	HardBounces         int64     `json:"hardBounces"`
+	SoftBounces         int64     `json:"softBounces"`
+	Complaints          int64     `json:"complaints"`
+	BounceRate          float64   `json:"bounceRate"`
+	ComplaintRate       float64   `json:"complaintRate"`
+	SuppressedAddresses int64     `json:"suppressedAddresses"`
+}
+
+// ServiceInterface defines the deliverability operations used by the handlers
+type ServiceInterface interface {
+	RecordBounce(req BounceRequest, now time.Time) (*models.EmailEvent, error)
+	RecordComplaint(req ComplaintRequest, now time.Time) (*models.EmailEvent, error)
+	ListLog(query LogQuery) ([]models.EmailLog, int64, error)
+	GetStats(from, to time.Time) (*Stats, error)
+	ListSuppressions(page, limit int) ([]models.EmailSuppression, int64, error)
+	RemoveSuppression(email string) error
+}
+
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new deliverability service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// RecordBounce stores a bounce and marks the email it belongs to as bounced. A hard bounce also suppresses
+// the address for every future email and flags the customer's profile.
+func (s *Service) RecordBounce(req BounceRequest, now time.Time) (*models.EmailEvent, error) {
+	event := &models.EmailEvent{
+		Type:       models.EmailEventBounce,
+		BounceType: req.BounceType,
+		Email:      strings.ToLower(strings.TrimSpace(req.Email)),
+		MessageID:  req.MessageID,
+		Reason:     req.Reason,
+		OccurredAt: occurredAt(req.OccurredAt, now),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.recordEvent(tx, event, models.EmailLogBounced); err != nil {
+			return err
+		}
+		if req.BounceType != models.BounceHard {
+			return nil
+		}
+
+		// A hard bounce outranks an earlier complaint, as it also stops transactional email
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "email"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reason", "detail"}),
+		}).Create(&models.EmailSuppression{Email: event.Email, Reason: models.SuppressionHardBounce, Detail: req.Reason}).Error; err != nil {
+			return fmt.Errorf("failed to suppress address: %w", err)
+		}
+		if err := tx.Model(&models.User{}).Where("LOWER(email) = ?", event.Email).
+			Update("email_bounced", true).Error; err != nil {
+			return fmt.Errorf("failed to flag user: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Named("deliverability").Info("Email bounce recorded", map[string]interface{}{"email": event.Email, "bounce_type": event.BounceType})
+	return event, nil
+}
+
+// RecordComplaint stores a spam complaint, marks the email it belongs to and stops marketing email to the
+// address: it is suppressed and the customer is opted out
+func (s *Service) RecordComplaint(req ComplaintRequest, now time.Time) (*models.EmailEvent, error) {
+	event := &models.EmailEvent{
+		Type:       models.EmailEventComplaint,
+		Email:      strings.ToLower(strings.TrimSpace(req.Email)),
+		MessageID:  req.MessageID,
+		Reason:     req.FeedbackType,
+		OccurredAt: occurredAt(req.OccurredAt, now),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.recordEvent(tx, event, models.EmailLogComplained); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.EmailSuppression{Email: event.Email, Reason: models.SuppressionComplaint, Detail: req.FeedbackType}).Error; err != nil {
+			return fmt.Errorf("failed to suppress address: %w", err)
+		}
+		if err := tx.Model(&models.User{}).Where("LOWER(email) = ?", event.Email).
+			Update("marketing_opt_out", true).Error; err != nil {
+			return fmt.Errorf("failed to opt user out: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Named("deliverability").Info("Email complaint recorded", map[string]interface{}{"email": event.Email})
+	return event, nil
+}
+
+// recordEvent links event to the customer with its address, stores it and moves the logged email with its
+// message ID to logStatus
+func (s *Service) recordEvent(tx *gorm.DB, event *models.EmailEvent, logStatus string) error {
+	var user models.User
+	err := tx.Select("id").Where("LOWER(email) = ?", event.Email).First(&user).Error
+	switch {
+	case err == nil:
+		event.UserID = &user.ID
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if err := tx.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to record email event: %w", err)
+	}
+	if event.MessageID != "" {
+		if err := tx.Model(&models.EmailLog{}).Where("message_id = ?", event.MessageID).
+			Update("status", logStatus).Error; err != nil {
+			return fmt.Errorf("failed to update email log: %w", err)
+		}
+	}
+	return nil
+}
+
+// occurredAt returns when the provider says an event happened, or now when it does not say
+func occurredAt(at *time.Time, now time.Time) time.Time {
+	if at == nil || at.IsZero() {
+		return now
+	}
+	return *at
+}
+
+// ListLog returns logged emails, newest first
+func (s *Service) ListLog(query LogQuery) ([]models.EmailLog, int64, error) {
+	db := s.db.Model(&models.EmailLog{})
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.Kind != "" {
+		db = db.Where("kind = ?", query.Kind)
+	}
+	if query.Email != "" {
+		db = db.Where("recipient = ?", strings.ToLower(strings.TrimSpace(query.Email)))
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count email log: %w", err)
+	}
+	logs := []models.EmailLog{}
+	if err := db.Order("created_at DESC").Offset((query.Page - 1) * query.Limit).Limit(query.Limit).Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get email log: %w", err)
+	}
+	return logs, total, nil
+}
+
+// GetStats counts the emails logged and the bounces and complaints received in [from, to)
+func (s *Service) GetStats(from, to time.Time) (*Stats, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidRange
+	}
+	stats := &Stats{From: from, To: to}
+
+	var statuses []struct {
+		Status string
+		Count  int64
+	}
+	if err := s.db.Model(&models.EmailLog{}).Select("status, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("status").Scan(&statuses).Error; err != nil {
+		return nil, fmt.Errorf("failed to count email log: %w", err)
+	}
+	for _, row := range statuses {
+		switch row.Status {
+		case models.EmailLogSent, models.EmailLogBounced, models.EmailLogComplained:
+			stats.Delivered += row.Count
+		case models.EmailLogFailed:
+			stats.Failed += row.Count
+		case models.EmailLogSuppressed:
+			stats.Suppressed += row.Count
+		}
+	}
+
+	var events []struct {
+		Type       string
+		BounceType string
+		Count      int64
+	}
+	if err := s.db.Model(&models.EmailEvent{}).Select("type, bounce_type, COUNT(*) AS count").
+		Where("occurred_at >= ? AND occurred_at < ?", from, to).
+		Group("type, bounce_type").Scan(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to count email events: %w", err)
+	}
+	for _, row := range events {
+		switch {
+		case row.Type == models.EmailEventComplaint:
+			stats.Complaints += row.Count
+		case row.BounceType == models.BounceHard:
+			stats.HardBounces += row.Count
+		default:
+			stats.SoftBounces += row.Count
+		}
+	}
+
+	if stats.Delivered > 0 {
+		stats.BounceRate = rate(stats.HardBounces+stats.SoftBounces, stats.Delivered)
+		stats.ComplaintRate = rate(stats.Complaints, stats.Delivered)
+	}
+	if err := s.db.Model(&models.EmailSuppression{}).Count(&stats.SuppressedAddresses).Error; err != nil {
+		return nil, fmt.Errorf("failed to count suppressions: %w", err)
+	}
+	return stats, nil
+}
+
+// rate returns part/whole rounded to four decimals
+func rate(part, whole int64) float64 {
+	return math.Round(float64(part)/float64(whole)*10000) / 10000
+}
+
+// ListSuppressions returns suppressed addresses, newest first
+func (s *Service) ListSuppressions(page, limit int) ([]models.EmailSuppression, int64, error) {
+	var total int64
+	if err := s.db.Model(&models.EmailSuppression{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count suppressions: %w", err)
+	}
+	suppressions := []models.EmailSuppression{}
+	if err := s.db.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&suppressions).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get suppressions: %w", err)
+	}
+	return suppressions, total, nil
+}
+
+// RemoveSuppression lets email reach an address again, e.g. after the customer fixed their mailbox, and
+// clears the bounce flag of their profile. A complaint opt-out stays until the customer opts back in.
+func (s *Service) RemoveSuppression(email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("email = ?", email).Delete(&models.EmailSuppression{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to remove suppression: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrSuppressionNotFound
+		}
+		if err := tx.Model(&models.User{}).Where("LOWER(email) = ?", email).
+			Update("email_bounced", false).Error; err != nil {
+			return fmt.Errorf("failed to clear bounce flag: %w", err)
+		}
+		return nil
+	})
+}
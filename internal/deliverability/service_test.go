@@ -0,0 +1,109 @@
+package deliverability
+
+import (
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_BouncesAndComplaints(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	service := NewService(db)
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	user := models.User{Email: "Jane@Example.com", Password: "hashed", FirstName: "Jane", LastName: "Doe", Role: "customer", IsActive: true}
+	require.NoError(t, db.Create(&user).Error)
+	logs := []models.EmailLog{
+		{Kind: "order_status", Recipient: "jane@example.com", MessageID: "<m1@example.com>", Status: models.EmailLogSent, CreatedAt: now},
+		{Kind: "campaign", Recipient: "jane@example.com", MessageID: "<m2@example.com>", Status: models.EmailLogSent, CreatedAt: now},
+		{Kind: "campaign", Recipient: "bob@example.com", MessageID: "<m3@example.com>", Status: models.EmailLogSent, CreatedAt: now},
+		{Kind: "campaign", Recipient: "bob@example.com", MessageID: "<m4@example.com>", Status: models.EmailLogSent, CreatedAt: now},
+		{Kind: "refund", Recipient: "ann@example.com", Status: models.EmailLogFailed, CreatedAt: now},
+	}
+	require.NoError(t, db.Create(&logs).Error)
+
+	t.Run("soft bounce is only recorded", func(t *testing.T) {
+		event, err := service.RecordBounce(BounceRequest{Email: "jane@example.com", BounceType: models.BounceSoft, MessageID: "<m1@example.com>"}, now)
+		require.NoError(t, err)
+		require.NotNil(t, event.UserID)
+		assert.Equal(t, user.ID, *event.UserID)
+
+		var count int64
+		require.NoError(t, db.Model(&models.EmailSuppression{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("complaint opts out", func(t *testing.T) {
+		_, err := service.RecordComplaint(ComplaintRequest{Email: "jane@example.com", MessageID: "<m2@example.com>", FeedbackType: "abuse"}, now)
+		require.NoError(t, err)
+
+		var stored models.User
+		require.NoError(t, db.First(&stored, "id = ?", user.ID).Error)
+		assert.True(t, stored.MarketingOptOut)
+		assert.False(t, stored.EmailBounced)
+
+		var log models.EmailLog
+		require.NoError(t, db.First(&log, "message_id = ?", "<m2@example.com>").Error)
+		assert.Equal(t, models.EmailLogComplained, log.Status)
+	})
+
+	t.Run("hard bounce suppresses and flags", func(t *testing.T) {
+		_, err := service.RecordBounce(BounceRequest{Email: "JANE@example.com", BounceType: models.BounceHard, Reason: "550 mailbox unavailable"}, now)
+		require.NoError(t, err)
+
+		var suppression models.EmailSuppression
+		require.NoError(t, db.First(&suppression, "email = ?", "jane@example.com").Error)
+		assert.Equal(t, models.SuppressionHardBounce, suppression.Reason)
+
+		var stored models.User
+		require.NoError(t, db.First(&stored, "id = ?", user.ID).Error)
+		assert.True(t, stored.EmailBounced)
+
+		// An address without an account is still suppressed
+		event, err := service.RecordBounce(BounceRequest{Email: "bob@example.com", BounceType: models.BounceHard, MessageID: "<m3@example.com>"}, now)
+		require.NoError(t, err)
+		assert.Nil(t, event.UserID)
+	})
+
+	t.Run("log and stats", func(t *testing.T) {
+		bounced, total, err := service.ListLog(LogQuery{Status: models.EmailLogBounced, Page: 1, Limit: 20})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, bounced, 2)
+
+		_, total, err = service.ListLog(LogQuery{Email: "BOB@example.com", Page: 1, Limit: 20})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+
+		stats, err := service.GetStats(now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), stats.Delivered)
+		assert.Equal(t, int64(1), stats.Failed)
+		assert.Equal(t, int64(2), stats.HardBounces)
+		assert.Equal(t, int64(1), stats.SoftBounces)
+		assert.Equal(t, int64(1), stats.Complaints)
+		assert.Equal(t, 0.75, stats.BounceRate)
+		assert.Equal(t, 0.25, stats.ComplaintRate)
+		assert.Equal(t, int64(2), stats.SuppressedAddresses)
+
+		_, err = service.GetStats(now, now)
+		assert.ErrorIs(t, err, ErrInvalidRange)
+	})
+
+	t.Run("remove suppression", func(t *testing.T) {
+		require.NoError(t, service.RemoveSuppression("Jane@example.com"))
+		var stored models.User
+		require.NoError(t, db.First(&stored, "id = ?", user.ID).Error)
+		assert.False(t, stored.EmailBounced)
+		assert.True(t, stored.MarketingOptOut)
+
+		assert.ErrorIs(t, service.RemoveSuppression("jane@example.com"), ErrSuppressionNotFound)
+	})
+}
@@ -0,0 +1,70 @@
+package email
+
+import (
+	"fmt"
+	"strings"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of email recorded in the email log
+const (
+	KindOrderConfirmation = "order_confirmation"
+	KindOrderStatus       = "order_status"
+	KindReviewRequest     = "review_request"
+	KindRefund            = "refund"
+	KindMagicLink         = "magic_link"
+	KindAdminInvite       = "admin_invite"
+	KindCampaign          = "campaign"
+	KindDraftOrderInvoice = "draft_order_invoice"
+)
+
+// WithLog makes the service record every email it sends in the email log, and skip addresses that
+// hard-bounced. It returns the service for chaining after a constructor.
+func (s *Service) WithLog(db *gorm.DB) *Service {
+	s.db = db
+	return s
+}
+
+// hardBounced reports whether to is suppressed after a hard bounce
+func (s *Service) hardBounced(to string) bool {
+	if s.db == nil {
+		return false
+	}
+	var count int64
+	if err := s.db.Model(&models.EmailSuppression{}).
+		Where("email = ? AND reason = ?", strings.ToLower(to), models.SuppressionHardBounce).
+		Count(&count).Error; err != nil {
+		logger.Named("email").Warn("Failed to check email suppression", map[string]interface{}{"to": to, "error": err.Error()})
+		return false
+	}
+	return count > 0
+}
+
+// record stores the outcome of an email in the email log. Failing to log never fails the email.
+func (s *Service) record(kind, to, subject, messageID, status string, sendErr error) {
+	if s.db == nil {
+		return
+	}
+	entry := models.EmailLog{Kind: kind, Recipient: strings.ToLower(to), Subject: subject, MessageID: messageID, Status: status}
+	if sendErr != nil {
+		entry.Error = sendErr.Error()
+	}
+	if err := s.db.Create(&entry).Error; err != nil {
+		logger.Named("email").Warn("Failed to record email log", map[string]interface{}{"to": to, "error": err.Error()})
+	}
+}
+
+// newMessageID returns a unique Message-ID header value in the domain of from. Providers echo it in
+// bounce and complaint notifications, which ties them to the email log.
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+	return fmt.Sprintf("<%s@%s>", uuid.New().String(), domain)
+}
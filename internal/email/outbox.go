@@ -0,0 +1,131 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/outbox"
+
+	"gorm.io/gorm"
+)
+
+// Outbox topics of queued emails
+const (
+	TopicOrderConfirmation = "email.order_confirmation"
+	TopicOrderStatus       = "email.order_status"
+	TopicRefund            = "email.refund"
+)
+
+// orderConfirmationMessage is the payload of a queued order confirmation email
+type orderConfirmationMessage struct {
+	OrderID string `json:"orderId"`
+}
+
+// orderStatusMessage is the payload of a queued order status email
+type orderStatusMessage struct {
+	OrderID   string `json:"orderId"`
+	OldStatus string `json:"oldStatus"`
+	NewStatus string `json:"newStatus"`
+}
+
+// refundMessage is the payload of a queued refund email
+type refundMessage struct {
+	OrderID  string `json:"orderId"`
+	RefundID string `json:"refundId"`
+}
+
+// QueueOrderConfirmation records the order confirmation email in tx, the transaction that placed the order
+func QueueOrderConfirmation(tx *gorm.DB, orderID string) error {
+	return outbox.Enqueue(tx, TopicOrderConfirmation, orderConfirmationMessage{OrderID: orderID})
+}
+
+// QueueOrderStatusUpdate records the order status email in tx, the transaction that changed the status
+func QueueOrderStatusUpdate(tx *gorm.DB, orderID, oldStatus, newStatus string) error {
+	return outbox.Enqueue(tx, TopicOrderStatus, orderStatusMessage{OrderID: orderID, OldStatus: oldStatus, NewStatus: newStatus})
+}
+
+// QueueRefundNotification records the refund email in tx, the transaction that recorded the refund
+func QueueRefundNotification(tx *gorm.DB, orderID, refundID string) error {
+	return outbox.Enqueue(tx, TopicRefund, refundMessage{OrderID: orderID, RefundID: refundID})
+}
+
+// outboxSender sends order confirmation, order status and refund emails recorded in the outbox, so a slow or unavailable mail
+// server neither delays nor fails the request that changed the order, and an email goes out exactly when the
+// change is committed. Messages carry IDs only; the order is loaded again when the email goes out. Review
+// requests are already sent by a background sweeper and go out directly.
+type outboxSender struct {
+	sender ServiceInterface
+	db     *gorm.DB
+}
+
+// RegisterOutbox makes dispatcher send the queued order confirmation, order status and refund emails with sender
+func RegisterOutbox(dispatcher *outbox.Dispatcher, sender ServiceInterface, db *gorm.DB) {
+	s := &outboxSender{sender: sender, db: db}
+	dispatcher.Register(TopicOrderConfirmation, s.sendOrderConfirmation)
+	dispatcher.Register(TopicOrderStatus, s.sendOrderStatus)
+	dispatcher.Register(TopicRefund, s.sendRefund)
+}
+
+func (s *outboxSender) sendOrderConfirmation(ctx context.Context, payload json.RawMessage) error {
+	var message orderConfirmationMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return fmt.Errorf("invalid order confirmation email message: %w", err)
+	}
+	order, err := s.loadOrder(ctx, message.OrderID)
+	if err != nil || order == nil {
+		return err
+	}
+	return s.sender.SendOrderConfirmation(order)
+}
+
+func (s *outboxSender) sendOrderStatus(ctx context.Context, payload json.RawMessage) error {
+	var message orderStatusMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return fmt.Errorf("invalid order status email message: %w", err)
+	}
+	order, err := s.loadOrder(ctx, message.OrderID)
+	if err != nil || order == nil {
+		return err
+	}
+	return s.sender.SendOrderStatusUpdate(order, message.OldStatus, message.NewStatus)
+}
+
+func (s *outboxSender) sendRefund(ctx context.Context, payload json.RawMessage) error {
+	var message refundMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return fmt.Errorf("invalid refund email message: %w", err)
+	}
+	order, err := s.loadOrder(ctx, message.OrderID)
+	if err != nil || order == nil {
+		return err
+	}
+	var refund models.Refund
+	if err := s.db.WithContext(ctx).Preload("Items").First(&refund, "id = ?", message.RefundID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Named("email").Warn("Refund of queued email no longer exists", map[string]interface{}{"refund_id": message.RefundID})
+			return nil
+		}
+		return fmt.Errorf("failed to load refund: %w", err)
+	}
+	return s.sender.SendRefundNotification(order, &refund)
+}
+
+// loadOrder loads an order with what the email templates show. An order that no longer exists returns
+// nil, as retrying cannot bring it back.
+func (s *outboxSender) loadOrder(ctx context.Context, orderID string) (*models.Order, error) {
+	var order models.Order
+	err := s.db.WithContext(ctx).Preload("User").Preload("Items.Product").Preload("Items.Serials").Preload("Shipments").
+		First(&order, "id = ?", orderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Named("email").Warn("Order of queued email no longer exists", map[string]interface{}{"order_id": orderID})
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order: %w", err)
+	}
+	return &order, nil
+}
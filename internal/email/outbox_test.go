@@ -0,0 +1,93 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/outbox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// recordingSender records the emails it is asked to send and fails while err is set
+type recordingSender struct {
+	confirmations []string
+	statuses      []string
+	refunds       []string
+	err           error
+}
+
+func (s *recordingSender) SendOrderConfirmation(order *models.Order) error {
+	s.confirmations = append(s.confirmations, order.User.Email)
+	return nil
+}
+
+func (s *recordingSender) SendOrderStatusUpdate(order *models.Order, oldStatus, newStatus string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.statuses = append(s.statuses, order.User.Email+":"+oldStatus+"->"+newStatus)
+	return nil
+}
+
+func (s *recordingSender) SendReviewRequest(order *models.Order, reviewToken string) error {
+	return nil
+}
+
+func (s *recordingSender) SendRefundNotification(order *models.Order, refund *models.Refund) error {
+	s.refunds = append(s.refunds, refund.ID)
+	return nil
+}
+
+func TestOutboxEmails(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	ctx := context.Background()
+
+	user := models.User{Email: "asha@example.com", Password: "hashed", FirstName: "Asha", LastName: "Rao"}
+	require.NoError(t, db.Create(&user).Error)
+	order := models.Order{UserID: user.ID, Status: "shipped", Total: 100}
+	require.NoError(t, db.Create(&order).Error)
+	refund := models.Refund{OrderID: order.ID, PaymentID: "pay-1", Amount: 5000, Status: models.RefundStatusProcessed}
+	require.NoError(t, db.Create(&refund).Error)
+
+	sender := &recordingSender{}
+	dispatcher := outbox.NewDispatcher(db)
+	RegisterOutbox(dispatcher, sender, db)
+
+	// Emails are only recorded; the order is loaded again when the message is delivered
+	require.NoError(t, QueueOrderStatusUpdate(db, order.ID, "paid", "shipped"))
+	require.NoError(t, QueueRefundNotification(db, order.ID, refund.ID))
+	require.NoError(t, QueueOrderStatusUpdate(db, "deleted-order", "paid", "cancelled"))
+	require.NoError(t, QueueOrderConfirmation(db, order.ID))
+	assert.Empty(t, sender.statuses)
+
+	delivered, err := dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, delivered)
+	assert.Equal(t, []string{"asha@example.com"}, sender.confirmations)
+	assert.Equal(t, []string{"asha@example.com:paid->shipped"}, sender.statuses)
+	assert.Equal(t, []string{refund.ID}, sender.refunds)
+
+	// A rolled back change sends nothing
+	require.Error(t, db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, QueueOrderStatusUpdate(tx, order.ID, "shipped", "delivered"))
+		return errors.New("rolled back")
+	}))
+
+	// A failed send stays recorded for a retry
+	sender.err = errors.New("smtp unavailable")
+	require.NoError(t, QueueOrderStatusUpdate(db, order.ID, "shipped", "delivered"))
+	delivered, err = dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	stats, err := dispatcher.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, outbox.Stats{Pending: 1, Delivered: 4}, *stats)
+}
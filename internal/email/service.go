@@ -2,19 +2,30 @@ package email
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"fmt"
 	"html/template"
-	"log"
 	"net/smtp"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
+	"ecommerce-website/internal/invoices"
+	"ecommerce-website/internal/logger"
 	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"gorm.io/gorm"
 )
 
 // ServiceInterface defines the interface for email service
 type ServiceInterface interface {
+	SendOrderConfirmation(order *models.Order) error
 	SendOrderStatusUpdate(order *models.Order, oldStatus, newStatus string) error
+	SendReviewRequest(order *models.Order, reviewToken string) error
+	SendRefundNotification(order *models.Order, refund *models.Refund) error
 }
 
 type Service struct {
@@ -23,22 +34,31 @@ type Service struct {
 	smtpUsername string
 	smtpPassword string
 	fromEmail    string
+	storefront   string
 	enabled      bool
+	settings     settings.Reader
+	db           *gorm.DB // email log, see WithLog
 }
 
-// NewService creates a new email service
+// NewService creates a new email service that uses setting defaults
 func NewService() *Service {
+	return NewServiceWithSettings(settings.NewService(nil))
+}
+
+// NewServiceWithSettings creates a new email service reading business settings (such as the support address) from reader
+func NewServiceWithSettings(reader settings.Reader) *Service {
 	smtpHost := os.Getenv("SMTP_HOST")
 	smtpPort := os.Getenv("SMTP_PORT")
 	smtpUsername := os.Getenv("SMTP_USERNAME")
 	smtpPassword := os.Getenv("SMTP_PASSWORD")
 	fromEmail := os.Getenv("FROM_EMAIL")
+	storefront := strings.TrimSuffix(os.Getenv("STOREFRONT_URL"), "/")
 
 	// Email service is enabled only if all required env vars are set
 	enabled := smtpHost != "" && smtpPort != "" && smtpUsername != "" && smtpPassword != "" && fromEmail != ""
 
 	if !enabled {
-		log.Println("Email service disabled: missing SMTP configuration")
+		logger.Named("email").Info("Email service disabled: missing SMTP configuration")
 	}
 
 	return &Service{
@@ -47,30 +67,363 @@ func NewService() *Service {
 		smtpUsername: smtpUsername,
 		smtpPassword: smtpPassword,
 		fromEmail:    fromEmail,
+		storefront:   storefront,
 		enabled:      enabled,
+		settings:     reader,
+	}
+}
+
+// orderConfirmationEmailData is the template data of an order confirmation email
+type orderConfirmationEmailData struct {
+	Order         *models.Order
+	RecipientName string
+	SupportEmail  string
+}
+
+// SendOrderConfirmation confirms a placed order to the purchaser, with the invoice attached as a PDF
+func (s *Service) SendOrderConfirmation(order *models.Order) error {
+	if !s.enabled {
+		logger.Named("email").Debug("Email service disabled, skipping order confirmation", map[string]interface{}{"order_id": order.ID})
+		return nil
+	}
+
+	ctx := context.Background()
+	tmpl, err := template.New("order_confirmation").Parse(orderConfirmationTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse email template: %w", err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, orderConfirmationEmailData{
+		Order:         order,
+		RecipientName: fmt.Sprintf("%s %s", order.User.FirstName, order.User.LastName),
+		SupportEmail:  s.settings.GetString(ctx, settings.KeySupportEmail),
+	}); err != nil {
+		return fmt.Errorf("failed to execute email template: %w", err)
 	}
+
+	invoice, err := invoices.Render(order, invoices.CompanyFromSettings(ctx, s.settings))
+	if err != nil {
+		return err
+	}
+	if err := s.send(KindOrderConfirmation, order.User.Email, fmt.Sprintf("Order Confirmation - Order #%s", order.ID[:8]), body.String(),
+		attachment{Filename: invoices.Filename(order), ContentType: "application/pdf", Data: invoice}); err != nil {
+		return err
+	}
+	logger.Named("email").Info("Order confirmation email sent", map[string]interface{}{"to": order.User.Email, "order_id": order.ID})
+	return nil
+}
+
+// giftRecipientStatuses are the order statuses the recipient of a gift order is notified about
+var giftRecipientStatuses = map[string]bool{
+	"shipped":   true,
+	"delivered": true,
+}
+
+// orderStatusEmailData is the template data of an order status email
+type orderStatusEmailData struct {
+	Order         *models.Order
+	RecipientName string
+	OldStatus     string
+	NewStatus     string
+	StatusMessage string
+	HidePrices    bool
+	SupportEmail  string
 }
 
-// SendOrderStatusUpdate sends an email notification when order status changes
+// SendOrderStatusUpdate sends an email notification when order status changes.
+// For gift orders the recipient is also notified on shipment and delivery, without prices.
 func (s *Service) SendOrderStatusUpdate(order *models.Order, oldStatus, newStatus string) error {
 	if !s.enabled {
-		log.Printf("Email service disabled, skipping order status update notification for order %s", order.ID)
+		logger.Named("email").Debug("Email service disabled, skipping order status update notification", map[string]interface{}{"order_id": order.ID})
+		return nil
+	}
+
+	subject := fmt.Sprintf("Order Update - Order #%s", order.ID[:8])
+	supportEmail := s.settings.GetString(context.Background(), settings.KeySupportEmail)
+
+	// The purchaser receives the full email including prices, unless they turned order status emails off
+	if !order.User.OrderEmailOptOut {
+		body, err := renderOrderStatusEmail(orderStatusEmailData{
+			Order:         order,
+			RecipientName: fmt.Sprintf("%s %s", order.User.FirstName, order.User.LastName),
+			OldStatus:     oldStatus,
+			NewStatus:     newStatus,
+			StatusMessage: getStatusMessage(newStatus),
+			SupportEmail:  supportEmail,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.send(KindOrderStatus, order.User.Email, subject, body); err != nil {
+			return err
+		}
+		logger.Named("email").Info("Order status update email sent", map[string]interface{}{"to": order.User.Email, "order_id": order.ID})
+	}
+
+	if !order.IsGift || order.GiftRecipientEmail == nil || *order.GiftRecipientEmail == "" || !giftRecipientStatuses[newStatus] {
 		return nil
 	}
 
-	// Prepare email data
-	data := struct {
-		Order         *models.Order
-		OldStatus     string
-		NewStatus     string
-		StatusMessage string
-	}{
+	body, err := renderOrderStatusEmail(orderStatusEmailData{
 		Order:         order,
+		RecipientName: fmt.Sprintf("%s %s", order.ShippingAddress.FirstName, order.ShippingAddress.LastName),
 		OldStatus:     oldStatus,
 		NewStatus:     newStatus,
 		StatusMessage: getStatusMessage(newStatus),
+		HidePrices:    true,
+		SupportEmail:  supportEmail,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.send(KindOrderStatus, *order.GiftRecipientEmail, "A gift is on its way to you", body); err != nil {
+		return err
+	}
+	logger.Named("email").Info("Gift recipient notification sent", map[string]interface{}{"to": *order.GiftRecipientEmail, "order_id": order.ID})
+
+	return nil
+}
+
+// reviewRequestEmailData is the template data of a review request email
+type reviewRequestEmailData struct {
+	Order         *models.Order
+	RecipientName string
+	ReviewURL     string
+	SupportEmail  string
+}
+
+// SendReviewRequest asks the purchaser of a delivered order to review the items they received. The
+// email links to the storefront review form, signed in by reviewToken; without a token or storefront
+// URL the items are listed without a link.
+func (s *Service) SendReviewRequest(order *models.Order, reviewToken string) error {
+	if !s.enabled {
+		logger.Named("email").Debug("Email service disabled, skipping review request", map[string]interface{}{"order_id": order.ID})
+		return nil
+	}
+
+	body, err := renderReviewRequestEmail(reviewRequestEmailData{
+		Order:         order,
+		RecipientName: fmt.Sprintf("%s %s", order.User.FirstName, order.User.LastName),
+		ReviewURL:     reviewURL(s.storefront, order.ID, reviewToken),
+		SupportEmail:  s.settings.GetString(context.Background(), settings.KeySupportEmail),
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.send(KindReviewRequest, order.User.Email, fmt.Sprintf("How was your order #%s?", order.ID[:8]), body); err != nil {
+		return err
+	}
+	logger.Named("email").Info("Review request email sent", map[string]interface{}{"to": order.User.Email, "order_id": order.ID})
+	return nil
+}
+
+// refundEmailData is the template data of a refund email
+type refundEmailData struct {
+	Order         *models.Order
+	RecipientName string
+	Amount        float64
+	FullRefund    bool
+	Reason        string
+	SupportEmail  string
+}
+
+// SendRefundNotification tells the purchaser that money was refunded for their order
+func (s *Service) SendRefundNotification(order *models.Order, refund *models.Refund) error {
+	if !s.enabled {
+		logger.Named("email").Debug("Email service disabled, skipping refund notification", map[string]interface{}{"order_id": order.ID})
+		return nil
+	}
+
+	tmpl, err := template.New("refund").Parse(refundTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse email template: %w", err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, refundEmailData{
+		Order:         order,
+		RecipientName: fmt.Sprintf("%s %s", order.User.FirstName, order.User.LastName),
+		Amount:        float64(refund.Amount) / 100,
+		FullRefund:    order.Status == "refunded",
+		Reason:        refund.Reason,
+		SupportEmail:  s.settings.GetString(context.Background(), settings.KeySupportEmail),
+	}); err != nil {
+		return fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	if err := s.send(KindRefund, order.User.Email, fmt.Sprintf("Refund for order #%s", order.ID[:8]), body.String()); err != nil {
+		return err
+	}
+	logger.Named("email").Info("Refund email sent", map[string]interface{}{"to": order.User.Email, "order_id": order.ID, "refund_id": refund.ID})
+	return nil
+}
+
+// magicLinkEmailData is the template data of a sign-in link email
+type magicLinkEmailData struct {
+	RecipientName string
+	LoginURL      string
+	ExpiresIn     int
+	SupportEmail  string
+}
+
+// SendMagicLink emails a password-less sign-in link to the storefront, where token is exchanged for a session
+func (s *Service) SendMagicLink(user *models.User, token string, expiresAt time.Time) error {
+	if !s.enabled {
+		logger.Named("email").Debug("Email service disabled, skipping sign-in link", map[string]interface{}{"user_id": user.ID})
+		return nil
+	}
+	if s.storefront == "" {
+		return fmt.Errorf("sign-in links need STOREFRONT_URL")
+	}
+
+	tmpl, err := template.New("magic_link").Parse(magicLinkTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse email template: %w", err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, magicLinkEmailData{
+		RecipientName: fmt.Sprintf("%s %s", user.FirstName, user.LastName),
+		LoginURL:      fmt.Sprintf("%s/login/magic-link?token=%s", s.storefront, url.QueryEscape(token)),
+		ExpiresIn:     int(time.Until(expiresAt).Round(time.Minute).Minutes()),
+		SupportEmail:  s.settings.GetString(context.Background(), settings.KeySupportEmail),
+	}); err != nil {
+		return fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	if err := s.send(KindMagicLink, user.Email, "Your sign-in link", body.String()); err != nil {
+		return err
+	}
+	logger.Named("email").Info("Sign-in link email sent", map[string]interface{}{"to": user.Email})
+	return nil
+}
+
+// adminInviteEmailData is the template data of an admin invite email
+type adminInviteEmailData struct {
+	RecipientName string
+	AcceptURL     string
+	Role          string
+	ExpiresIn     int
+}
+
+// SendAdminInvite emails an invited admin the link to set their password, where token is used as a password reset token
+func (s *Service) SendAdminInvite(user *models.User, token string, expiresAt time.Time) error {
+	if !s.enabled {
+		logger.Named("email").Debug("Email service disabled, skipping admin invite", map[string]interface{}{"user_id": user.ID})
+		return nil
+	}
+	if s.storefront == "" {
+		return fmt.Errorf("admin invites need STOREFRONT_URL")
 	}
 
+	tmpl, err := template.New("admin_invite").Parse(adminInviteTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse email template: %w", err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, adminInviteEmailData{
+		RecipientName: fmt.Sprintf("%s %s", user.FirstName, user.LastName),
+		AcceptURL:     fmt.Sprintf("%s/admin/accept-invite?token=%s", s.storefront, url.QueryEscape(token)),
+		Role:          strings.ReplaceAll(user.AdminRole, "_", " "),
+		ExpiresIn:     int(time.Until(expiresAt).Round(time.Hour).Hours()),
+	}); err != nil {
+		return fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	if err := s.send(KindAdminInvite, user.Email, "You have been invited to the store admin", body.String()); err != nil {
+		return err
+	}
+	logger.Named("email").Info("Admin invite email sent", map[string]interface{}{"to": user.Email})
+	return nil
+}
+
+// draftOrderInvoiceEmailData is the template data of a draft order payment link email
+type draftOrderInvoiceEmailData struct {
+	Draft         *models.DraftOrder
+	RecipientName string
+	PayURL        string
+	SupportEmail  string
+}
+
+// SendDraftOrderInvoice emails a customer the draft order an admin put together for them, with its quoted
+// prices and a link to the storefront page where they sign in and pay for it
+func (s *Service) SendDraftOrderInvoice(draft *models.DraftOrder) error {
+	if !s.enabled {
+		logger.Named("email").Debug("Email service disabled, skipping draft order invoice", map[string]interface{}{"draft_order_id": draft.ID})
+		return nil
+	}
+	if s.storefront == "" {
+		return fmt.Errorf("draft order payment links need STOREFRONT_URL")
+	}
+
+	body, err := renderDraftOrderInvoiceEmail(draftOrderInvoiceEmailData{
+		Draft:         draft,
+		RecipientName: fmt.Sprintf("%s %s", draft.User.FirstName, draft.User.LastName),
+		PayURL:        draftOrderURL(s.storefront, draft.ID),
+		SupportEmail:  s.settings.GetString(context.Background(), settings.KeySupportEmail),
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.send(KindDraftOrderInvoice, draft.User.Email, fmt.Sprintf("Your order #%s is ready to pay", draft.ID[:8]), body); err != nil {
+		return err
+	}
+	logger.Named("email").Info("Draft order invoice email sent", map[string]interface{}{"to": draft.User.Email, "draft_order_id": draft.ID})
+	return nil
+}
+
+// SendCampaignEmail sends an already rendered marketing campaign email
+func (s *Service) SendCampaignEmail(to, subject, body string) error {
+	if !s.enabled {
+		logger.Named("email").Debug("Email service disabled, skipping campaign email", map[string]interface{}{"to": to})
+		return nil
+	}
+	return s.send(KindCampaign, to, subject, body)
+}
+
+// reviewURL returns the storefront review form link of an order, or "" when it cannot be built
+func reviewURL(storefront, orderID, reviewToken string) string {
+	if storefront == "" || reviewToken == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/orders/%s/review?token=%s", storefront, url.PathEscape(orderID), url.QueryEscape(reviewToken))
+}
+
+// draftOrderURL returns the storefront page where a customer pays for a draft order
+func draftOrderURL(storefront, draftID string) string {
+	return fmt.Sprintf("%s/draft-orders/%s", storefront, url.PathEscape(draftID))
+}
+
+// renderDraftOrderInvoiceEmail executes the draft order payment link email template
+func renderDraftOrderInvoiceEmail(data draftOrderInvoiceEmailData) (string, error) {
+	tmpl, err := template.New("draft_order_invoice").Parse(draftOrderInvoiceTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return "", fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	return body.String(), nil
+}
+
+// renderReviewRequestEmail executes the review request email template
+func renderReviewRequestEmail(data reviewRequestEmailData) (string, error) {
+	tmpl, err := template.New("review_request").Parse(reviewRequestTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return "", fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	return body.String(), nil
+}
+
+// renderOrderStatusEmail executes the order status email template
+func renderOrderStatusEmail(data orderStatusEmailData) (string, error) {
 	// Parse email template with custom functions
 	tmpl, err := template.New("order_status_update").Funcs(template.FuncMap{
 		"title": func(s string) string {
@@ -81,38 +434,82 @@ func (s *Service) SendOrderStatusUpdate(order *models.Order, oldStatus, newStatu
 		},
 	}).Parse(orderStatusUpdateTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to parse email template: %w", err)
+		return "", fmt.Errorf("failed to parse email template: %w", err)
 	}
 
 	// Execute template
 	var body bytes.Buffer
 	if err := tmpl.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute email template: %w", err)
+		return "", fmt.Errorf("failed to execute email template: %w", err)
 	}
 
-	// Prepare email message
-	subject := fmt.Sprintf("Order Update - Order #%s", order.ID[:8])
-	message := fmt.Sprintf("From: %s\r\n", s.fromEmail) +
-		fmt.Sprintf("To: %s\r\n", order.User.Email) +
-		fmt.Sprintf("Subject: %s\r\n", subject) +
-		"MIME-Version: 1.0\r\n" +
-		"Content-Type: text/html; charset=UTF-8\r\n" +
-		"\r\n" +
-		body.String()
+	return body.String(), nil
+}
+
+// attachment is a file attached to an email
+type attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// send delivers an HTML email, with any attachments, over SMTP and records it in the email log. Addresses that
+// hard-bounced are skipped as the email would only bounce again.
+func (s *Service) send(kind, to, subject, body string, attachments ...attachment) error {
+	if s.hardBounced(to) {
+		logger.Named("email").Info("Skipping email to hard-bounced address", map[string]interface{}{"to": to, "kind": kind})
+		s.record(kind, to, subject, "", models.EmailLogSuppressed, nil)
+		return nil
+	}
+
+	messageID := newMessageID(s.fromEmail)
+	message := buildMessage(s.fromEmail, to, subject, messageID, body, attachments)
 
-	// Send email
 	auth := smtp.PlainAuth("", s.smtpUsername, s.smtpPassword, s.smtpHost)
 	addr := fmt.Sprintf("%s:%s", s.smtpHost, s.smtpPort)
 
-	err = smtp.SendMail(addr, auth, s.fromEmail, []string{order.User.Email}, []byte(message))
-	if err != nil {
+	if err := smtp.SendMail(addr, auth, s.fromEmail, []string{to}, []byte(message)); err != nil {
+		s.record(kind, to, subject, messageID, models.EmailLogFailed, err)
 		return fmt.Errorf("failed to send email: %w", err)
 	}
-
-	log.Printf("Order status update email sent to %s for order %s", order.User.Email, order.ID)
+	s.record(kind, to, subject, messageID, models.EmailLogSent, nil)
 	return nil
 }
 
+// buildMessage writes the headers and body of an email. With attachments the HTML body becomes the first
+// part of a multipart/mixed message and each attachment a base64 encoded part after it.
+func buildMessage(from, to, subject, messageID, body string, attachments []attachment) string {
+	headers := fmt.Sprintf("From: %s\r\n", from) +
+		fmt.Sprintf("To: %s\r\n", to) +
+		fmt.Sprintf("Subject: %s\r\n", subject) +
+		fmt.Sprintf("Message-ID: %s\r\n", messageID) +
+		"MIME-Version: 1.0\r\n"
+	if len(attachments) == 0 {
+		return headers + "Content-Type: text/html; charset=UTF-8\r\n" + "\r\n" + body
+	}
+
+	boundary := "mixed-" + strings.Trim(strings.SplitN(messageID, "@", 2)[0], "<")
+	var message strings.Builder
+	message.WriteString(headers)
+	message.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary))
+	message.WriteString(fmt.Sprintf("--%s\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s\r\n", boundary, body))
+	for _, file := range attachments {
+		message.WriteString(fmt.Sprintf("--%s\r\n", boundary))
+		message.WriteString(fmt.Sprintf("Content-Type: %s; name=%q\r\n", file.ContentType, file.Filename))
+		message.WriteString("Content-Transfer-Encoding: base64\r\n")
+		message.WriteString(fmt.Sprintf("Content-Disposition: attachment; filename=%q\r\n\r\n", file.Filename))
+		// RFC 2045 limits encoded lines to 76 characters
+		encoded := base64.StdEncoding.EncodeToString(file.Data)
+		for len(encoded) > 76 {
+			message.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		message.WriteString(encoded + "\r\n")
+	}
+	message.WriteString(fmt.Sprintf("--%s--\r\n", boundary))
+	return message.String()
+}
+
 // getStatusMessage returns a user-friendly message for each order status
 func getStatusMessage(status string) string {
 	messages := map[string]string{
@@ -130,6 +527,58 @@ func getStatusMessage(status string) string {
 	return "Your order status has been updated."
 }
 
+// Email template for order confirmations
+const orderConfirmationTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Order confirmation</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f9fa; padding: 20px; text-align: center; }
+        .content { padding: 20px; }
+        .order-details { background-color: #f8f9fa; padding: 15px; margin: 20px 0; border-radius: 5px; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Thank you for your order</h1>
+        </div>
+        
+        <div class="content">
+            <p>Hello {{.RecipientName}},</p>
+            
+            <p>We have received your order and will let you know when it ships. Your invoice is attached to this email.</p>
+            
+            <div class="order-details">
+                <h3>Order Details</h3>
+                <p><strong>Order ID:</strong> #{{.Order.ID}}</p>
+                <p><strong>Order Date:</strong> {{.Order.CreatedAt.Format "January 2, 2006"}}</p>
+                <p><strong>Total Amount:</strong> ${{printf "%.2f" .Order.Total}}</p>
+                
+                <h4>Items:</h4>
+                <ul>
+                {{range .Order.Items}}
+                    <li>{{.Product.Name}} - Quantity: {{.Quantity}} - ${{printf "%.2f" .Total}}</li>
+                {{end}}
+                </ul>
+            </div>
+            
+            <p>If you have any questions about your order, please contact our customer support team{{if .SupportEmail}} at <a href="mailto:{{.SupportEmail}}">{{.SupportEmail}}</a>{{end}}.</p>
+        </div>
+        
+        <div class="footer">
+            <p>This is an automated message. Please do not reply to this email.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
 // Email template for order status updates
 const orderStatusUpdateTemplate = `
 <!DOCTYPE html>
@@ -154,7 +603,7 @@ const orderStatusUpdateTemplate = `
         </div>
         
         <div class="content">
-            <p>Hello {{.Order.User.FirstName}} {{.Order.User.LastName}},</p>
+            <p>Hello {{.RecipientName}},</p>
             
             <div class="status-update">
                 <h3>Your order status has been updated!</h3>
@@ -163,14 +612,21 @@ const orderStatusUpdateTemplate = `
                 <p>{{.StatusMessage}}</p>
             </div>
             
+            {{if and .HidePrices .Order.GiftMessage}}
+            <div class="order-details">
+                <h3>Gift Message</h3>
+                <p>{{.Order.GiftMessage}}</p>
+            </div>
+            {{end}}
+
             <div class="order-details">
                 <h3>Order Details</h3>
-                <p><strong>Order Total:</strong> ${{printf "%.2f" .Order.Total}}</p>
+                {{if not .HidePrices}}<p><strong>Order Total:</strong> ${{printf "%.2f" .Order.Total}}</p>{{end}}
                 <p><strong>Order Date:</strong> {{.Order.CreatedAt.Format "January 2, 2006"}}</p>
                 
                 <h4>Items:</h4>
                 {{range .Order.Items}}
-                <p>• {{.Product.Name}} (Qty: {{.Quantity}}) - ${{printf "%.2f" .Total}}</p>
+                <p>• {{.Product.Name}} (Qty: {{.Quantity}}){{if not $.HidePrices}} - ${{printf "%.2f" .Total}}{{end}}</p>
                 {{end}}
                 
                 <h4>Shipping Address:</h4>
@@ -183,7 +639,7 @@ const orderStatusUpdateTemplate = `
                 </p>
             </div>
             
-            <p>If you have any questions about your order, please don't hesitate to contact our customer support team.</p>
+            <p>If you have any questions about your order, please don't hesitate to contact our customer support team{{if .SupportEmail}} at <a href="mailto:{{.SupportEmail}}">{{.SupportEmail}}</a>{{end}}.</p>
             
             <p>Thank you for your business!</p>
         </div>
@@ -195,3 +651,229 @@ const orderStatusUpdateTemplate = `
 </body>
 </html>
 `
+
+// Email template for review requests sent some days after delivery
+const reviewRequestTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>How was your order?</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f9fa; padding: 20px; text-align: center; }
+        .content { padding: 20px; }
+        .order-details { background-color: #f8f9fa; padding: 15px; margin: 20px 0; }
+        .button { display: inline-block; background-color: #007bff; color: #fff; padding: 10px 20px; border-radius: 5px; text-decoration: none; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>How was your order?</h1>
+        </div>
+        
+        <div class="content">
+            <p>Hello {{.RecipientName}},</p>
+            
+            <p>Your order #{{.Order.ID}} was delivered{{if .Order.DeliveredAt}} on {{.Order.DeliveredAt.Format "January 2, 2006"}}{{end}}. We would love to hear what you think of it.</p>
+            
+            <div class="order-details">
+                <h3>Review your items</h3>
+                {{range .Order.Items}}
+                <p>• {{.Product.Name}}</p>
+                {{end}}
+                {{if .ReviewURL}}<p><a class="button" href="{{.ReviewURL}}">Write a review</a></p>{{end}}
+            </div>
+            
+            <p>If anything went wrong with your order, please contact our customer support team{{if .SupportEmail}} at <a href="mailto:{{.SupportEmail}}">{{.SupportEmail}}</a>{{end}}.</p>
+            
+            <p>Thank you for shopping with us!</p>
+        </div>
+        
+        <div class="footer">
+            <p>This is an automated message. Please do not reply to this email.</p>
+            <p>You received this email because you ordered from us. To stop review requests, turn off marketing emails in your profile.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+// Email template for refunds issued by an admin
+const refundTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Refund issued</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f9fa; padding: 20px; text-align: center; }
+        .content { padding: 20px; }
+        .status-update { background-color: #d4edda; border: 1px solid #c3e6cb; padding: 15px; margin: 20px 0; border-radius: 5px; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Refund issued</h1>
+        </div>
+        
+        <div class="content">
+            <p>Hello {{.RecipientName}},</p>
+            
+            <div class="status-update">
+                <p><strong>Order ID:</strong> #{{.Order.ID}}</p>
+                <p><strong>Refund Amount:</strong> ${{printf "%.2f" .Amount}}</p>
+                {{if .Reason}}<p><strong>Reason:</strong> {{.Reason}}</p>{{end}}
+                <p>{{if .FullRefund}}Your order has been fully refunded.{{else}}Part of your order has been refunded.{{end}} The money goes back to your original payment method and usually arrives within 5-7 business days.</p>
+            </div>
+            
+            <p>If you have any questions about your refund, please contact our customer support team{{if .SupportEmail}} at <a href="mailto:{{.SupportEmail}}">{{.SupportEmail}}</a>{{end}}.</p>
+        </div>
+        
+        <div class="footer">
+            <p>This is an automated message. Please do not reply to this email.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+// Email template for password-less sign-in links
+const magicLinkTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Your sign-in link</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f9fa; padding: 20px; text-align: center; }
+        .content { padding: 20px; }
+        .button { display: inline-block; background-color: #007bff; color: #fff; padding: 10px 20px; border-radius: 5px; text-decoration: none; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Sign in to your account</h1>
+        </div>
+        
+        <div class="content">
+            <p>Hello {{.RecipientName}},</p>
+            
+            <p>Use the button below to sign in. The link works once, for the next {{.ExpiresIn}} minutes, and only on the device you requested it from.</p>
+            
+            <p><a class="button" href="{{.LoginURL}}">Sign in</a></p>
+            
+            <p>If you did not ask to sign in, you can ignore this email{{if .SupportEmail}} or let us know at <a href="mailto:{{.SupportEmail}}">{{.SupportEmail}}</a>{{end}}.</p>
+        </div>
+        
+        <div class="footer">
+            <p>This is an automated message. Please do not reply to this email.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+// Email template for admin account invites
+const adminInviteTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Admin invite</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f9fa; padding: 20px; text-align: center; }
+        .content { padding: 20px; }
+        .button { display: inline-block; background-color: #007bff; color: #fff; padding: 10px 20px; border-radius: 5px; text-decoration: none; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>You have been invited</h1>
+        </div>
+        
+        <div class="content">
+            <p>Hello {{.RecipientName}},</p>
+            
+            <p>An admin account with the {{.Role}} role was created for you. Choose a password within the next {{.ExpiresIn}} hours to start using it.</p>
+            
+            <p><a class="button" href="{{.AcceptURL}}">Set your password</a></p>
+        </div>
+        
+        <div class="footer">
+            <p>This is an automated message. Please do not reply to this email.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+// Email template for the payment links of draft orders
+const draftOrderInvoiceTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Your order is ready to pay</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f9fa; padding: 20px; text-align: center; }
+        .content { padding: 20px; }
+        .order-details { background-color: #f8f9fa; padding: 15px; margin: 20px 0; border-radius: 5px; }
+        .button { display: inline-block; background-color: #007bff; color: #fff; padding: 10px 20px; border-radius: 5px; text-decoration: none; }
+        .footer { text-align: center; padding: 20px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Your order is ready to pay</h1>
+        </div>
+        
+        <div class="content">
+            <p>Hello {{.RecipientName}},</p>
+            
+            <p>We have put together the order we discussed with you. Review it and pay online to have it shipped.</p>
+            
+            <div class="order-details">
+                <h3>Order Details</h3>
+                <ul>
+                {{range .Draft.Items}}
+                    <li>{{.Product.Name}} - Quantity: {{.Quantity}} - ${{printf "%.2f" .Total}}</li>
+                {{end}}
+                </ul>
+                <p><strong>Subtotal:</strong> ${{printf "%.2f" .Draft.Subtotal}}</p>
+                {{if .Draft.Discount}}<p><strong>Discount{{with .Draft.DiscountReason}} ({{.}}){{end}}:</strong> -${{printf "%.2f" .Draft.Discount}}</p>{{end}}
+                <p><strong>Tax:</strong> ${{printf "%.2f" .Draft.Tax}}</p>
+                <p><strong>Shipping:</strong> ${{printf "%.2f" .Draft.Shipping}}</p>
+                <p><strong>Total Amount:</strong> ${{printf "%.2f" .Draft.Total}}</p>
+            </div>
+            
+            <p><a class="button" href="{{.PayURL}}">Pay for your order</a></p>
+            
+            <p>If you have any questions about your order, please contact our customer support team{{if .SupportEmail}} at <a href="mailto:{{.SupportEmail}}">{{.SupportEmail}}</a>{{end}}.</p>
+        </div>
+        
+        <div class="footer">
+            <p>This is an automated message. Please do not reply to this email.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
@@ -1,13 +1,19 @@
 package email
 
 import (
+	"encoding/base64"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
 	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewService(t *testing.T) {
@@ -102,6 +108,23 @@ func TestService_SendOrderStatusUpdate_Disabled(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestService_SendRefundNotification_Disabled(t *testing.T) {
+	os.Clearenv()
+	service := NewService()
+
+	order := &models.Order{ID: "test-order-id", Status: "refunded", User: models.User{Email: "customer@example.com"}}
+	err := service.SendRefundNotification(order, &models.Refund{Amount: 2500})
+	assert.NoError(t, err)
+}
+
+func TestService_SendMagicLink_Disabled(t *testing.T) {
+	os.Clearenv()
+	service := NewService()
+
+	err := service.SendMagicLink(&models.User{ID: "user-1", Email: "customer@example.com"}, "token", time.Now().Add(15*time.Minute))
+	assert.NoError(t, err)
+}
+
 func TestGetStatusMessage(t *testing.T) {
 	tests := []struct {
 		status          string
@@ -194,3 +217,139 @@ func TestService_SendOrderStatusUpdate_TemplateExecution(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to send email")
 }
+
+func TestRenderOrderStatusEmail_HidePrices(t *testing.T) {
+	message := "Enjoy!"
+	order := &models.Order{
+		ID:          "gift-order-id",
+		Total:       99.99,
+		IsGift:      true,
+		GiftMessage: &message,
+		CreatedAt:   time.Now(),
+		Items: []models.OrderItem{
+			{Product: models.Product{Name: "Test Product"}, Quantity: 1, Total: 99.99},
+		},
+	}
+
+	purchaser, err := renderOrderStatusEmail(orderStatusEmailData{Order: order, RecipientName: "John Doe", NewStatus: "shipped"})
+	assert.NoError(t, err)
+	assert.Contains(t, purchaser, "99.99")
+	assert.NotContains(t, purchaser, "Enjoy!")
+
+	recipient, err := renderOrderStatusEmail(orderStatusEmailData{Order: order, RecipientName: "Ana Lee", NewStatus: "shipped", HidePrices: true})
+	assert.NoError(t, err)
+	assert.Contains(t, recipient, "Hello Ana Lee")
+	assert.Contains(t, recipient, "Test Product (Qty: 1)")
+	assert.Contains(t, recipient, "Enjoy!")
+	assert.NotContains(t, recipient, "99.99")
+}
+
+func TestRenderReviewRequestEmail(t *testing.T) {
+	deliveredAt := time.Date(2024, time.March, 5, 10, 0, 0, 0, time.UTC)
+	order := &models.Order{
+		ID:          "delivered-order-id",
+		DeliveredAt: &deliveredAt,
+		Items: []models.OrderItem{
+			{ProductID: "prod-1", Product: models.Product{Name: "Test Product"}, Quantity: 1},
+		},
+	}
+
+	link := reviewURL("https://shop.example.com", order.ID, "a.b+c")
+	assert.Equal(t, "https://shop.example.com/orders/delivered-order-id/review?token=a.b%2Bc", link)
+	assert.Empty(t, reviewURL("", order.ID, "token"))
+	assert.Empty(t, reviewURL("https://shop.example.com", order.ID, ""))
+
+	body, err := renderReviewRequestEmail(reviewRequestEmailData{Order: order, RecipientName: "John Doe", ReviewURL: link})
+	assert.NoError(t, err)
+	assert.Contains(t, body, "Hello John Doe")
+	assert.Contains(t, body, "delivered on March 5, 2024")
+	assert.Contains(t, body, "Test Product")
+	assert.Contains(t, body, `href="https://shop.example.com/orders/delivered-order-id/review?token=a.b%2Bc"`)
+
+	body, err = renderReviewRequestEmail(reviewRequestEmailData{Order: order, RecipientName: "John Doe"})
+	assert.NoError(t, err)
+	assert.NotContains(t, body, "Write a review")
+}
+
+func TestRenderDraftOrderInvoiceEmail(t *testing.T) {
+	reason := "loyal customer"
+	draft := &models.DraftOrder{
+		ID:             "draft-order-id",
+		Items:          []models.DraftOrderItem{{ProductID: "prod-1", Product: models.Product{Name: "Test Product"}, Quantity: 2, Price: 50, Total: 100}},
+		Subtotal:       100,
+		Discount:       10,
+		DiscountReason: &reason,
+		Tax:            16.2,
+		Total:          106.2,
+	}
+
+	link := draftOrderURL("https://shop.example.com", draft.ID)
+	assert.Equal(t, "https://shop.example.com/draft-orders/draft-order-id", link)
+
+	body, err := renderDraftOrderInvoiceEmail(draftOrderInvoiceEmailData{Draft: draft, RecipientName: "John Doe", PayURL: link})
+	assert.NoError(t, err)
+	assert.Contains(t, body, "Hello John Doe")
+	assert.Contains(t, body, "Test Product - Quantity: 2 - $100.00")
+	assert.Contains(t, body, "Discount (loyal customer):</strong> -$10.00")
+	assert.Contains(t, body, "Total Amount:</strong> $106.20")
+	assert.Contains(t, body, `href="https://shop.example.com/draft-orders/draft-order-id"`)
+
+	draft.Discount = 0
+	body, err = renderDraftOrderInvoiceEmail(draftOrderInvoiceEmailData{Draft: draft, RecipientName: "John Doe", PayURL: link})
+	assert.NoError(t, err)
+	assert.NotContains(t, body, "Discount")
+}
+
+func TestService_SendLogged(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	require.NoError(t, db.Create(&models.EmailSuppression{Email: "gone@example.com", Reason: models.SuppressionHardBounce}).Error)
+	require.NoError(t, db.Create(&models.EmailSuppression{Email: "angry@example.com", Reason: models.SuppressionComplaint}).Error)
+
+	// Nothing listens on the SMTP port, so every email that is attempted fails
+	service := &Service{smtpHost: "127.0.0.1", smtpPort: "1", fromEmail: "shop@example.com", enabled: true, settings: settings.NewService(nil)}
+	service.WithLog(db)
+
+	require.NoError(t, service.send(KindCampaign, "Gone@example.com", "Hello", "<p>Hi</p>"))
+	assert.Error(t, service.send(KindOrderStatus, "angry@example.com", "Your order", "<p>Hi</p>"))
+
+	var logs []models.EmailLog
+	require.NoError(t, db.Order("status ASC").Find(&logs).Error)
+	require.Len(t, logs, 2)
+	assert.Equal(t, models.EmailLogFailed, logs[0].Status)
+	assert.Equal(t, KindOrderStatus, logs[0].Kind)
+	assert.True(t, strings.HasSuffix(logs[0].MessageID, "@example.com>"))
+	assert.NotEmpty(t, logs[0].Error)
+	assert.Equal(t, models.EmailLogSuppressed, logs[1].Status)
+	assert.Equal(t, "gone@example.com", logs[1].Recipient)
+}
+
+func TestService_SendOrderConfirmation_Disabled(t *testing.T) {
+	os.Clearenv()
+	service := NewService()
+
+	order := &models.Order{ID: "test-order-id", Status: "pending", User: models.User{Email: "customer@example.com"}}
+	assert.NoError(t, service.SendOrderConfirmation(order))
+}
+
+func TestBuildMessage(t *testing.T) {
+	plain := buildMessage("shop@example.com", "asha@example.com", "Hello", "<id@example.com>", "<p>Hi</p>", nil)
+	assert.Contains(t, plain, "Content-Type: text/html; charset=UTF-8\r\n\r\n<p>Hi</p>")
+	assert.NotContains(t, plain, "multipart")
+
+	data := []byte(strings.Repeat("%PDF-1.4 invoice ", 10))
+	message := buildMessage("shop@example.com", "asha@example.com", "Hello", "<id@example.com>", "<p>Hi</p>",
+		[]attachment{{Filename: "invoice-INV-1.pdf", ContentType: "application/pdf", Data: data}})
+	assert.Contains(t, message, `Content-Type: multipart/mixed; boundary="mixed-id"`)
+	assert.Contains(t, message, "--mixed-id\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n<p>Hi</p>\r\n")
+	assert.Contains(t, message, `Content-Disposition: attachment; filename="invoice-INV-1.pdf"`)
+	assert.True(t, strings.HasSuffix(message, "--mixed-id--\r\n"))
+
+	encoded := message[strings.Index(message, "filename=\"invoice-INV-1.pdf\"\r\n\r\n")+len("filename=\"invoice-INV-1.pdf\"\r\n\r\n") : strings.Index(message, "--mixed-id--")]
+	for _, line := range strings.Split(strings.TrimSuffix(encoded, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 76)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(encoded, "\r\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, data, decoded)
+}
@@ -96,6 +96,12 @@ func (h *Handler) GetErrorMetrics(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Error metrics retrieved", metrics)
 }
 
+// GetRateLimitMetrics returns rate limit warnings and rejections per client
+func (h *Handler) GetRateLimitMetrics(c *gin.Context) {
+	metrics := middleware.GetRateLimitMetrics()
+	utils.SuccessResponse(c, http.StatusOK, "Rate limit metrics retrieved", metrics)
+}
+
 // GetAlerts returns monitoring alerts
 func (h *Handler) GetAlerts(c *gin.Context) {
 	alerts := monitoring.GetAlerts()
@@ -124,6 +130,61 @@ func (h *Handler) ResolveAlert(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Alert resolved successfully", nil)
 }
 
+// LogLevelRequest represents a runtime log level change for a module
+type LogLevelRequest struct {
+	Module     string `json:"module" binding:"required"`
+	Level      string `json:"level,omitempty"`
+	SampleRate *int   `json:"sampleRate,omitempty"`
+}
+
+// GetLogLevels returns the per-module log level overrides
+func (h *Handler) GetLogLevels(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Log levels retrieved", h.logger.ModuleSettings())
+}
+
+// UpdateLogLevel changes the log level and/or debug sample rate of a module
+func (h *Handler) UpdateLogLevel(c *gin.Context) {
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	if req.Level == "" && req.SampleRate == nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Either level or sampleRate is required", nil)
+		return
+	}
+
+	if req.Level != "" {
+		if err := h.logger.SetModuleLevel(req.Module, logger.LogLevel(strings.ToUpper(req.Level))); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_LOG_LEVEL", err.Error(), nil)
+			return
+		}
+	}
+
+	if req.SampleRate != nil {
+		if err := h.logger.SetModuleSampleRate(req.Module, *req.SampleRate); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SAMPLE_RATE", err.Error(), nil)
+			return
+		}
+	}
+
+	logger.WithRequest(c).Info("Module log settings updated", map[string]interface{}{
+		"module":      req.Module,
+		"level":       req.Level,
+		"sample_rate": req.SampleRate,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Log level updated successfully", h.logger.ModuleSettings())
+}
+
+// ResetLogLevel removes the runtime overrides of a module
+func (h *Handler) ResetLogLevel(c *gin.Context) {
+	module := c.Param("module")
+	h.logger.ResetModule(module)
+	utils.SuccessResponse(c, http.StatusOK, "Log level reset successfully", h.logger.ModuleSettings())
+}
+
 // HealthCheck returns system health status
 func (h *Handler) HealthCheck(c *gin.Context) {
 	monitor := monitoring.GetMonitor()
@@ -318,3 +318,67 @@ func TestContainsCriticalKeywords(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateLogLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := setupTestHandler()
+
+	r := gin.New()
+	r.GET("/api/admin/monitoring/log-levels", handler.GetLogLevels)
+	r.PUT("/api/admin/monitoring/log-levels", handler.UpdateLogLevel)
+	r.DELETE("/api/admin/monitoring/log-levels/:module", handler.ResetLogLevel)
+
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "valid level",
+			requestBody:    map[string]interface{}{"module": "search", "level": "debug", "sampleRate": 10},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid level",
+			requestBody:    map[string]interface{}{"module": "search", "level": "loud"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_LOG_LEVEL",
+		},
+		{
+			name:           "missing level and sample rate",
+			requestBody:    map[string]interface{}{"module": "search"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "VALIDATION_ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("PUT", "/api/admin/monitoring/log-levels", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response utils.ApiResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Error.Code)
+			}
+		})
+	}
+
+	settings := handler.logger.ModuleSettings()
+	assert.Len(t, settings, 1)
+	assert.Equal(t, logger.DEBUG, settings[0].Level)
+	assert.Equal(t, 10, settings[0].SampleRate)
+
+	req := httptest.NewRequest("DELETE", "/api/admin/monitoring/log-levels/search", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, handler.logger.ModuleSettings())
+}
@@ -0,0 +1,29 @@
+package errors
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires client error reporting and the monitoring endpoints into the application
+type Module struct {
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the error handling module
+func NewModule(deps app.Deps) app.Module {
+	return &Module{handler: NewHandler(), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "errors"
+}
+
+// RegisterAPIRoutes sets up the error handling and monitoring routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -7,21 +7,25 @@ import (
 )
 
 // SetupRoutes sets up error handling routes
-func SetupRoutes(r *gin.Engine, handler *Handler, authService *auth.Service) {
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
 	// Public error logging endpoint (for client-side errors)
-	r.POST("/api/errors/client", handler.LogClientError)
+	api.POST("/errors/client", handler.LogClientError)
 
 	// Public health check endpoint
-	r.GET("/api/health", handler.HealthCheck)
+	api.GET("/health", handler.HealthCheck)
 
 	// Admin-only monitoring endpoints
-	admin := r.Group("/api/admin/monitoring")
+	admin := api.Group("/admin/monitoring")
 	admin.Use(authService.AuthMiddleware())
 	admin.Use(authService.AdminMiddleware())
 	{
 		admin.GET("/metrics", handler.GetErrorMetrics)
+		admin.GET("/rate-limits", handler.GetRateLimitMetrics)
 		admin.GET("/alerts", handler.GetAlerts)
 		admin.GET("/system", handler.GetSystemMetrics)
 		admin.POST("/alerts/:id/resolve", handler.ResolveAlert)
+		admin.GET("/log-levels", handler.GetLogLevels)
+		admin.PUT("/log-levels", handler.UpdateLogLevel)
+		admin.DELETE("/log-levels/:module", handler.ResetLogLevel)
 	}
 }
@@ -0,0 +1,253 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Store events published to the bus
+const (
+	EventOrderCreated     = "order.created"
+	EventOrderPaid        = "order.paid"
+	EventProductUpdated   = "product.updated"
+	EventProductDeleted   = "product.deleted"
+	EventInventoryChanged = "inventory.changed"
+)
+
+// SchemaVersions is the current payload schema version of each event type. A version is bumped whenever a
+// field is removed or changes meaning; adding a field keeps the version.
+var SchemaVersions = map[string]int{
+	EventOrderCreated:     1,
+	EventOrderPaid:        1,
+	EventProductUpdated:   1,
+	EventProductDeleted:   1,
+	EventInventoryChanged: 1,
+}
+
+// Reasons an inventory.changed event reports
+const (
+	InventoryOrderPlaced    = "order_placed"
+	InventoryOrderCancelled = "order_cancelled"
+	InventoryRefundRestock  = "refund_restock"
+	InventoryReturnRestock  = "return_restock"
+	InventoryAdjusted       = "admin_adjustment"
+)
+
+// source names this service in every envelope
+const source = "ecommerce-api"
+
+// enabled is set when a bus driver is configured. Until then the Publish functions only run in-process handlers
+// and record nothing, so stores without a bus keep no event backlog.
+var enabled atomic.Bool
+
+// Enable turns event recording on or off
+func Enable(on bool) {
+	enabled.Store(on)
+}
+
+// Enabled reports whether events are being recorded for the bus
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Handler consumes an event in process. It runs inside the transaction that records the event, so an error
+// rolls the change back; key is the ID of the order or product the event is about.
+type Handler func(tx *gorm.DB, event, key string) error
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = map[string][]Handler{}
+)
+
+// Subscribe registers an in-process handler for event, such as a read model kept current from store events.
+// Handlers run whether or not a bus driver is configured.
+func Subscribe(event string, handler Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[event] = append(handlers[event], handler)
+}
+
+// notify runs the in-process handlers of event
+func notify(tx *gorm.DB, event, key string) error {
+	handlersMu.RLock()
+	subscribed := handlers[event]
+	handlersMu.RUnlock()
+	for _, handler := range subscribed {
+		if err := handler(tx, event, key); err != nil {
+			return fmt.Errorf("%s handler failed: %w", event, err)
+		}
+	}
+	return nil
+}
+
+// Envelope is the message published for every event. Consumers dispatch on Type and Version, and use ID to
+// drop events they have already handled, since an event may be published more than once.
+type Envelope struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Version    int         `json:"version"`
+	Source     string      `json:"source"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// OrderV1 is the payload of order.created and order.paid, version 1
+type OrderV1 struct {
+	ID              string              `json:"id"`
+	UserID          string              `json:"userId"`
+	Status          string              `json:"status"`
+	Subtotal        float64             `json:"subtotal"`
+	Discount        float64             `json:"discount"`
+	Tax             float64             `json:"tax"`
+	Shipping        float64             `json:"shipping"`
+	Total           float64             `json:"total"`
+	ShippingAddress models.OrderAddress `json:"shippingAddress"`
+	Items           []OrderItemV1       `json:"items"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
+}
+
+// OrderItemV1 is an order line
+type OrderItemV1 struct {
+	ProductID string  `json:"productId"`
+	Quantity  int     `json:"quantity"`
+	Price     float64 `json:"price"`
+	Total     float64 `json:"total"`
+}
+
+// ProductV1 is the payload of product.updated, version 1
+type ProductV1 struct {
+	ID             string    `json:"id"`
+	SKU            string    `json:"sku"`
+	Name           string    `json:"name"`
+	Price          float64   `json:"price"`
+	CompareAtPrice *float64  `json:"compareAtPrice,omitempty"`
+	Inventory      int       `json:"inventory"`
+	IsActive       bool      `json:"isActive"`
+	CategoryID     string    `json:"categoryId"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// ProductDeletedV1 is the payload of product.deleted, version 1
+type ProductDeletedV1 struct {
+	ID  string `json:"id"`
+	SKU string `json:"sku"`
+}
+
+// InventoryChangedV1 is the payload of inventory.changed, version 1. Inventory is the level after the change.
+type InventoryChangedV1 struct {
+	ProductID string `json:"productId"`
+	SKU       string `json:"sku"`
+	Inventory int    `json:"inventory"`
+	Delta     int    `json:"delta"`
+	Reason    string `json:"reason"`
+}
+
+// PublishOrder records order.created or order.paid for an order. It must run in the transaction that makes the
+// change: the order is read as that transaction sees it, and nothing is published for a change rolled back.
+func PublishOrder(tx *gorm.DB, event, orderID string) error {
+	if err := notify(tx, event, orderID); err != nil || !Enabled() {
+		return err
+	}
+	var order models.Order
+	if err := tx.Preload("Items").First(&order, "id = ?", orderID).Error; err != nil {
+		return fmt.Errorf("failed to load order for the event bus: %w", err)
+	}
+	data := OrderV1{
+		ID:              order.ID,
+		UserID:          order.UserID,
+		Status:          order.Status,
+		Subtotal:        order.Subtotal,
+		Discount:        order.Discount,
+		Tax:             order.Tax,
+		Shipping:        order.Shipping,
+		Total:           order.Total,
+		ShippingAddress: order.ShippingAddress,
+		Items:           make([]OrderItemV1, 0, len(order.Items)),
+		CreatedAt:       order.CreatedAt,
+		UpdatedAt:       order.UpdatedAt,
+	}
+	for _, item := range order.Items {
+		data.Items = append(data.Items, OrderItemV1{ProductID: item.ProductID, Quantity: item.Quantity, Price: item.Price, Total: item.Total})
+	}
+	return record(tx, event, order.ID, data)
+}
+
+// PublishProduct records product.updated for a created or changed product
+func PublishProduct(db *gorm.DB, product *models.Product) error {
+	if err := notify(db, EventProductUpdated, product.ID); err != nil || !Enabled() {
+		return err
+	}
+	return record(db, EventProductUpdated, product.ID, ProductV1{
+		ID:             product.ID,
+		SKU:            product.SKU,
+		Name:           product.Name,
+		Price:          product.Price,
+		CompareAtPrice: product.CompareAtPrice,
+		Inventory:      product.Inventory,
+		IsActive:       product.IsActive,
+		CategoryID:     product.CategoryID,
+		UpdatedAt:      product.UpdatedAt,
+	})
+}
+
+// PublishProductDeleted records product.deleted for a product removed from the catalog
+func PublishProductDeleted(db *gorm.DB, product *models.Product) error {
+	if err := notify(db, EventProductDeleted, product.ID); err != nil || !Enabled() {
+		return err
+	}
+	return record(db, EventProductDeleted, product.ID, ProductDeletedV1{ID: product.ID, SKU: product.SKU})
+}
+
+// PublishInventoryChanged records inventory.changed for a product whose stock moved by delta. Like PublishOrder
+// it must run in the transaction that moves the stock, after the update, so the level it reports is the new one.
+func PublishInventoryChanged(tx *gorm.DB, productID string, delta int, reason string) error {
+	if delta == 0 {
+		return nil
+	}
+	if err := notify(tx, EventInventoryChanged, productID); err != nil || !Enabled() {
+		return err
+	}
+	var product models.Product
+	if err := tx.Select("id, sku, inventory").First(&product, "id = ?", productID).Error; err != nil {
+		return fmt.Errorf("failed to load product for the event bus: %w", err)
+	}
+	return record(tx, EventInventoryChanged, product.ID, InventoryChangedV1{
+		ProductID: product.ID,
+		SKU:       product.SKU,
+		Inventory: product.Inventory,
+		Delta:     delta,
+		Reason:    reason,
+	})
+}
+
+// record stores an event for the relay to publish. key is the ID of the order or product the event is about,
+// which Kafka uses to keep the events of one entity in order.
+func record(db *gorm.DB, event, key string, data interface{}) error {
+	now := time.Now()
+	id := uuid.New().String()
+	version := SchemaVersions[event]
+	body, err := json.Marshal(Envelope{ID: id, Type: event, Version: version, Source: source, OccurredAt: now, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event, err)
+	}
+	if err := db.Create(&models.BusEvent{
+		ID:            id,
+		Type:          event,
+		Version:       version,
+		Key:           key,
+		Payload:       string(body),
+		NextAttemptAt: &now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record %s event: %w", event, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+)
+
+const (
+	// relayInterval is how often the relay publishes due events
+	relayInterval = 2 * time.Second
+	// pruneInterval is how often published events past retention are deleted
+	pruneInterval = time.Hour
+	// stalledAfter is how old the oldest unpublished event may get before the module reports itself unhealthy
+	stalledAfter = 5 * time.Minute
+)
+
+// Module relays order and catalog events to Kafka or NATS when EVENT_BUS_DRIVER is set. Other modules record
+// events with the Publish functions; without a driver those record nothing.
+type Module struct {
+	service *Service
+}
+
+// NewModule creates the event bus module. An invalid driver or URL is logged and leaves the bus disabled
+// rather than stopping the store.
+func NewModule(deps app.Deps) app.Module {
+	publisher, err := NewPublisher(deps.Config.EventBusDriver, deps.Config.EventBusURL)
+	if err != nil {
+		logger.Named("eventbus").Warn("Event bus disabled by invalid configuration", map[string]interface{}{"error": err.Error()})
+	}
+	if publisher == nil {
+		Enable(false)
+		return &Module{}
+	}
+	Enable(true)
+	return &Module{service: NewService(deps.DB, publisher, deps.Config.EventBusTopicPrefix)}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "eventbus"
+}
+
+// Models returns the bus event table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.BusEvent{}}
+}
+
+// StartJobs relays recorded events in the background when a bus is configured
+func (m *Module) StartJobs(ctx context.Context) {
+	if m.service != nil {
+		m.service.StartRelay(ctx, relayInterval, pruneInterval)
+	}
+}
+
+// HealthCheck reports the bus unhealthy when events have waited longer than stalledAfter to be published
+func (m *Module) HealthCheck(ctx context.Context) error {
+	if m.service == nil {
+		return nil
+	}
+	var oldest models.BusEvent
+	result := m.service.db.WithContext(ctx).Where("published_at IS NULL").Order("created_at ASC").Limit(1).Find(&oldest)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 && m.service.now().Sub(oldest.CreatedAt) > stalledAfter {
+		return fmt.Errorf("event bus publishing stalled since %s: %s", oldest.CreatedAt.Format(time.RFC3339), oldest.LastError)
+	}
+	return nil
+}
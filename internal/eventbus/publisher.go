@@ -0,0 +1,275 @@
+package eventbus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bus drivers selectable with EVENT_BUS_DRIVER
+const (
+	DriverNATS  = "nats"
+	DriverKafka = "kafka"
+)
+
+const (
+	// publishTimeout bounds one publish, including connecting to the broker
+	publishTimeout = 10 * time.Second
+	// responseExcerpt caps the bytes of a failed REST proxy response kept as the error
+	responseExcerpt = 512
+)
+
+var ErrUnknownDriver = errors.New("unknown event bus driver")
+
+// Message is one event as handed to a broker. Topic is the NATS subject or Kafka topic; Key is the Kafka
+// record key.
+type Message struct {
+	Topic string
+	Key   string
+	Body  []byte
+}
+
+// Publisher sends messages to a broker. Publish returns once the broker has accepted the message.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// NewPublisher creates the publisher for driver, or returns nil when driver is empty and no bus is configured
+func NewPublisher(driver, rawURL string) (Publisher, error) {
+	switch driver {
+	case "":
+		return nil, nil
+	case DriverNATS:
+		return newNATSPublisher(rawURL)
+	case DriverKafka:
+		return newKafkaPublisher(rawURL)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, driver)
+	}
+}
+
+// Topic returns the subject or topic an event type is published to, such as "ecommerce.order.created"
+func Topic(prefix, event string) string {
+	if prefix == "" {
+		return event
+	}
+	return prefix + "." + event
+}
+
+// natsPublisher publishes with the NATS core protocol over one connection that is opened on first use and
+// reopened after any error. Each publish is followed by a PING so it returns only once the server has
+// processed the message.
+type natsPublisher struct {
+	addr    string
+	connect []byte
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newNATSPublisher creates a publisher for a nats://[user:password@]host:port URL; a user without a password
+// is sent as an auth token
+func newNATSPublisher(rawURL string) (*natsPublisher, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "nats" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL %q", rawURL)
+	}
+	addr := parsed.Host
+	if parsed.Port() == "" {
+		addr = net.JoinHostPort(parsed.Hostname(), "4222")
+	}
+
+	options := map[string]interface{}{"verbose": false, "pedantic": false, "name": source, "lang": "go", "version": "1"}
+	if parsed.User != nil {
+		if password, ok := parsed.User.Password(); ok {
+			options["user"] = parsed.User.Username()
+			options["pass"] = password
+		} else {
+			options["auth_token"] = parsed.User.Username()
+		}
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	return &natsPublisher{addr: addr, connect: []byte("CONNECT " + string(connect) + "\r\n")}, nil
+}
+
+// Publish sends msg to the subject msg.Topic
+func (p *natsPublisher) Publish(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.open(ctx); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(publishTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := p.conn.SetDeadline(deadline); err != nil {
+		p.reset()
+		return err
+	}
+
+	var frame bytes.Buffer
+	fmt.Fprintf(&frame, "PUB %s %d\r\n", msg.Topic, len(msg.Body))
+	frame.Write(msg.Body)
+	frame.WriteString("\r\nPING\r\n")
+	if _, err := p.conn.Write(frame.Bytes()); err != nil {
+		p.reset()
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	if err := p.awaitPong(); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+// open connects and handshakes unless a connection is already open
+func (p *natsPublisher) open(ctx context.Context) error {
+	if p.conn != nil {
+		return nil
+	}
+	dialer := net.Dialer{Timeout: publishTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	p.conn = conn
+	p.reader = bufio.NewReader(conn)
+
+	if err := conn.SetDeadline(time.Now().Add(publishTimeout)); err != nil {
+		p.reset()
+		return err
+	}
+	info, err := p.reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(info, "INFO ") {
+		p.reset()
+		return fmt.Errorf("unexpected NATS greeting: %q", strings.TrimSpace(info))
+	}
+	if _, err := conn.Write(append(p.connect, "PING\r\n"...)); err != nil {
+		p.reset()
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	if err := p.awaitPong(); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+// awaitPong reads server lines until the PONG answering our PING, answering server PINGs on the way
+func (p *natsPublisher) awaitPong() error {
+	for {
+		line, err := p.reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read from NATS: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return fmt.Errorf("failed to answer NATS ping: %w", err)
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// reset drops the connection so the next publish reconnects
+func (p *natsPublisher) reset() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn = nil
+	p.reader = nil
+}
+
+// Close closes the connection
+func (p *natsPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset()
+	return nil
+}
+
+// kafkaPublisher publishes through a Kafka REST proxy speaking the v2 API, POSTing each message as a JSON
+// record to /topics/<topic>
+type kafkaPublisher struct {
+	baseURL string
+	client  *http.Client
+}
+
+// newKafkaPublisher creates a publisher for the REST proxy at an http(s) base URL
+func newKafkaPublisher(rawURL string) (*kafkaPublisher, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Kafka REST proxy URL %q", rawURL)
+	}
+	return &kafkaPublisher{baseURL: strings.TrimRight(rawURL, "/"), client: &http.Client{Timeout: publishTimeout}}, nil
+}
+
+// Publish produces msg to the topic msg.Topic, keyed by msg.Key
+func (p *kafkaPublisher) Publish(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{{"key": msg.Key, "value": json.RawMessage(msg.Body)}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/topics/"+url.PathEscape(msg.Topic), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build Kafka request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to Kafka: %w", err)
+	}
+	defer resp.Body.Close()
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, responseExcerpt))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Kafka REST proxy responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(excerpt)))
+	}
+
+	// The proxy answers 200 even when a record was rejected, reporting it in the record's offset entry
+	var produced struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.Unmarshal(excerpt, &produced); err == nil {
+		for _, offset := range produced.Offsets {
+			if offset.ErrorCode != nil || offset.Error != "" {
+				return fmt.Errorf("Kafka rejected the record: %s", offset.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// Close releases idle connections to the proxy
+func (p *kafkaPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,113 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNATS accepts one connection, speaks enough of the NATS protocol for publishing and sends every
+// protocol line it receives, payloads included, on lines
+func fakeNATS(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	lines := make(chan string, 16)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte(`INFO {"server_id":"test"}` + "\r\n"))
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			lines <- line
+			if line == "PING" {
+				conn.Write([]byte("PONG\r\n"))
+			}
+		}
+	}()
+	return "nats://token@" + listener.Addr().String(), lines
+}
+
+func TestNATSPublisher_Publish(t *testing.T) {
+	natsURL, lines := fakeNATS(t)
+	publisher, err := NewPublisher(DriverNATS, natsURL)
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	require.NoError(t, publisher.Publish(context.Background(), Message{Topic: "ecommerce.order.paid", Key: "order-1", Body: []byte(`{"id":"1"}`)}))
+
+	connect := <-lines
+	require.True(t, strings.HasPrefix(connect, "CONNECT "))
+	var options map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(connect, "CONNECT ")), &options))
+	assert.Equal(t, "token", options["auth_token"])
+	assert.Equal(t, "PING", <-lines)
+	assert.Equal(t, "PUB ecommerce.order.paid 10", <-lines)
+	assert.Equal(t, `{"id":"1"}`, <-lines)
+	assert.Equal(t, "PING", <-lines)
+}
+
+func TestKafkaPublisher_Publish(t *testing.T) {
+	var path, contentType string
+	var body map[string][]map[string]json.RawMessage
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		contentType = r.Header.Get("Content-Type")
+		raw, _ := io.ReadAll(r.Body)
+		json.Unmarshal(raw, &body)
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":7}]}`))
+	}))
+	defer proxy.Close()
+
+	publisher, err := NewPublisher(DriverKafka, proxy.URL)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(context.Background(), Message{Topic: "ecommerce.product.updated", Key: "product-1", Body: []byte(`{"id":"1"}`)}))
+
+	assert.Equal(t, "/topics/ecommerce.product.updated", path)
+	assert.Equal(t, "application/vnd.kafka.json.v2+json", contentType)
+	require.Len(t, body["records"], 1)
+	assert.JSONEq(t, `"product-1"`, string(body["records"][0]["key"]))
+	assert.JSONEq(t, `{"id":"1"}`, string(body["records"][0]["value"]))
+}
+
+func TestKafkaPublisher_RejectedRecord(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"offsets":[{"error_code":40403,"error":"topic not found"}]}`))
+	}))
+	defer proxy.Close()
+
+	publisher, err := NewPublisher(DriverKafka, proxy.URL)
+	require.NoError(t, err)
+	err = publisher.Publish(context.Background(), Message{Topic: "missing", Body: []byte(`{}`)})
+	assert.ErrorContains(t, err, "topic not found")
+}
+
+func TestNewPublisher_Configuration(t *testing.T) {
+	publisher, err := NewPublisher("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, publisher)
+
+	_, err = NewPublisher("rabbitmq", "amqp://localhost")
+	assert.ErrorIs(t, err, ErrUnknownDriver)
+
+	_, err = NewPublisher(DriverNATS, "http://localhost:4222")
+	assert.Error(t, err)
+}
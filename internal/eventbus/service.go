@@ -0,0 +1,137 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// baseBackoff is the delay before retrying a failed publish; each further retry doubles it up to maxBackoff.
+	// Events keep being retried until they are published, since consumers rely on seeing every one.
+	baseBackoff = 5 * time.Second
+	maxBackoff  = 10 * time.Minute
+	// claimTimeout is how long a claimed event waits before another pass may publish it again, in case the
+	// instance publishing it stops before recording the outcome
+	claimTimeout = time.Minute
+	// publishBatch caps the events one relay pass publishes
+	publishBatch = 200
+	// retainPublished is how long published events are kept before they are pruned
+	retainPublished = 7 * 24 * time.Hour
+)
+
+// Service relays recorded events to the bus
+type Service struct {
+	db        *gorm.DB
+	publisher Publisher
+	prefix    string
+	now       func() time.Time
+}
+
+// NewService creates a relay publishing through publisher to topics named with prefix
+func NewService(db *gorm.DB, publisher Publisher, prefix string) *Service {
+	return &Service{db: db, publisher: publisher, prefix: prefix, now: time.Now}
+}
+
+// PublishDue publishes due events oldest first and returns how many were published. Each event is claimed
+// before it is published so relays running at the same time never publish it twice. A pass stops at the first
+// failure, leaving later events for the next pass so the events of an entity are not published out of order.
+func (s *Service) PublishDue(ctx context.Context) (int, error) {
+	now := s.now()
+	var due []models.BusEvent
+	if err := s.db.WithContext(ctx).
+		Where("published_at IS NULL AND next_attempt_at <= ?", now).
+		Order("created_at ASC").Limit(publishBatch).Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to load due bus events: %w", err)
+	}
+
+	published := 0
+	for _, event := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		claimedUntil := now.Add(claimTimeout)
+		result := s.db.WithContext(ctx).Model(&models.BusEvent{}).
+			Where("id = ? AND published_at IS NULL AND attempts = ?", event.ID, event.Attempts).
+			Updates(map[string]interface{}{"attempts": event.Attempts + 1, "next_attempt_at": claimedUntil})
+		if result.Error != nil {
+			return published, fmt.Errorf("failed to claim bus event: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		event.Attempts++
+
+		ok, err := s.attempt(ctx, &event)
+		if err != nil {
+			return published, err
+		}
+		if !ok {
+			break
+		}
+		published++
+	}
+	return published, nil
+}
+
+// attempt publishes a claimed event and records the outcome, reporting whether it was published
+func (s *Service) attempt(ctx context.Context, event *models.BusEvent) (bool, error) {
+	publishErr := s.publisher.Publish(ctx, Message{Topic: Topic(s.prefix, event.Type), Key: event.Key, Body: []byte(event.Payload)})
+
+	var updates map[string]interface{}
+	if publishErr == nil {
+		updates = map[string]interface{}{"published_at": s.now(), "next_attempt_at": nil, "last_error": ""}
+	} else {
+		retryAt := s.now().Add(utils.Backoff(event.Attempts, baseBackoff, maxBackoff))
+		updates = map[string]interface{}{"next_attempt_at": retryAt, "last_error": publishErr.Error()}
+		logger.Named("eventbus").Warn("Failed to publish event, retrying", map[string]interface{}{
+			"event_id": event.ID, "type": event.Type, "attempt": event.Attempts, "retry_at": retryAt, "error": publishErr.Error(),
+		})
+	}
+	if err := s.db.Model(event).Updates(updates).Error; err != nil {
+		return false, fmt.Errorf("failed to record bus event: %w", err)
+	}
+	return publishErr == nil, nil
+}
+
+// Prune deletes events published before the retention window
+func (s *Service) Prune(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Where("published_at < ?", s.now().Add(-retainPublished)).Delete(&models.BusEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune bus events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// StartRelay publishes due events every interval and prunes old ones every pruneInterval until ctx is
+// cancelled, then closes the publisher
+func (s *Service) StartRelay(ctx context.Context, interval, pruneInterval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		pruneTicker := time.NewTicker(pruneInterval)
+		defer pruneTicker.Stop()
+		defer s.publisher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.PublishDue(ctx); err != nil && ctx.Err() == nil {
+					logger.Named("eventbus").Warn("Failed to publish bus events", map[string]interface{}{"error": err.Error()})
+				}
+			case <-pruneTicker.C:
+				if _, err := s.Prune(ctx); err != nil && ctx.Err() == nil {
+					logger.Named("eventbus").Warn("Failed to prune bus events", map[string]interface{}{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,171 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// recorder is a publisher that remembers the messages it accepted and fails while err is set
+type recorder struct {
+	messages []Message
+	err      error
+}
+
+func (r *recorder) Publish(ctx context.Context, msg Message) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+func (r *recorder) Close() error { return nil }
+
+func setupTestService(t *testing.T) (*Service, *recorder) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	Enable(true)
+	t.Cleanup(func() { Enable(false) })
+	publisher := &recorder{}
+	return NewService(database.GetDB(), publisher, "ecommerce"), publisher
+}
+
+func TestPublish_DisabledRecordsNothing(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	Enable(false)
+
+	require.NoError(t, PublishProduct(db, &models.Product{ID: "product-1", SKU: "SKU-1"}))
+
+	var count int64
+	require.NoError(t, db.Model(&models.BusEvent{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestService_PublishDue(t *testing.T) {
+	service, publisher := setupTestService(t)
+	db := database.GetDB()
+
+	order := models.Order{UserID: "user-1", Status: "paid", Subtotal: 20, Total: 20}
+	require.NoError(t, db.Create(&order).Error)
+	require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: "product-1", Quantity: 2, Price: 10, Total: 20}).Error)
+	product := models.Product{Name: "Lamp", SKU: "LAMP-1", Price: 10, Inventory: 3, CategoryID: "category-1"}
+	require.NoError(t, db.Create(&product).Error)
+
+	require.NoError(t, PublishOrder(db, EventOrderPaid, order.ID))
+	require.NoError(t, PublishInventoryChanged(db, product.ID, -2, InventoryOrderPlaced))
+
+	published, err := service.PublishDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	require.Len(t, publisher.messages, 2)
+
+	paid := publisher.messages[0]
+	assert.Equal(t, "ecommerce.order.paid", paid.Topic)
+	assert.Equal(t, order.ID, paid.Key)
+	var envelope struct {
+		Type    string  `json:"type"`
+		Version int     `json:"version"`
+		Data    OrderV1 `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(paid.Body, &envelope))
+	assert.Equal(t, EventOrderPaid, envelope.Type)
+	assert.Equal(t, 1, envelope.Version)
+	assert.Equal(t, "paid", envelope.Data.Status)
+	require.Len(t, envelope.Data.Items, 1)
+
+	stock := publisher.messages[1]
+	assert.Equal(t, "ecommerce.inventory.changed", stock.Topic)
+	var inventory struct {
+		Data InventoryChangedV1 `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(stock.Body, &inventory))
+	assert.Equal(t, InventoryChangedV1{ProductID: product.ID, SKU: "LAMP-1", Inventory: 3, Delta: -2, Reason: InventoryOrderPlaced}, inventory.Data)
+
+	// Published events are not published again
+	published, err = service.PublishDue(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published)
+}
+
+func TestService_PublishDueRetriesInOrder(t *testing.T) {
+	service, publisher := setupTestService(t)
+	db := database.GetDB()
+
+	require.NoError(t, PublishProduct(db, &models.Product{ID: "product-1", SKU: "SKU-1"}))
+	require.NoError(t, PublishProduct(db, &models.Product{ID: "product-2", SKU: "SKU-2"}))
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	// A failure stops the pass so the later event is not published ahead of the earlier one
+	publisher.err = errors.New("broker unavailable")
+	published, err := service.PublishDue(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published)
+
+	var failed models.BusEvent
+	require.NoError(t, db.Where("key = ?", "product-1").First(&failed).Error)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, "broker unavailable", failed.LastError)
+	require.NotNil(t, failed.NextAttemptAt)
+	assert.WithinDuration(t, now.Add(baseBackoff), *failed.NextAttemptAt, time.Second)
+
+	// Once the backoff has passed both go out, oldest first
+	publisher.err = nil
+	now = now.Add(baseBackoff + time.Second)
+	published, err = service.PublishDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	require.Len(t, publisher.messages, 2)
+	assert.Equal(t, "product-1", publisher.messages[0].Key)
+	assert.Equal(t, "product-2", publisher.messages[1].Key)
+}
+
+func TestService_Prune(t *testing.T) {
+	service, _ := setupTestService(t)
+	db := database.GetDB()
+
+	old := time.Now().Add(-retainPublished - time.Hour)
+	recent := time.Now()
+	require.NoError(t, db.Create(&models.BusEvent{Type: EventProductUpdated, Version: 1, Key: "a", Payload: "{}", PublishedAt: &old}).Error)
+	require.NoError(t, db.Create(&models.BusEvent{Type: EventProductUpdated, Version: 1, Key: "b", Payload: "{}", PublishedAt: &recent}).Error)
+	require.NoError(t, db.Create(&models.BusEvent{Type: EventProductUpdated, Version: 1, Key: "c", Payload: "{}"}).Error)
+
+	pruned, err := service.Prune(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pruned)
+}
+
+func TestSubscribe_RunsWithoutBus(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	Enable(false)
+
+	var seen []string
+	Subscribe(EventProductDeleted, func(tx *gorm.DB, event, key string) error {
+		seen = append(seen, event+":"+key)
+		return nil
+	})
+	t.Cleanup(func() {
+		handlersMu.Lock()
+		delete(handlers, EventProductDeleted)
+		handlersMu.Unlock()
+	})
+
+	require.NoError(t, PublishProductDeleted(db, &models.Product{ID: "product-1", SKU: "SKU-1"}))
+	assert.Equal(t, []string{"product.deleted:product-1"}, seen)
+
+	var count int64
+	require.NoError(t, db.Model(&models.BusEvent{}).Count(&count).Error)
+	assert.Zero(t, count, "nothing is recorded for the bus without a driver")
+}
@@ -0,0 +1,113 @@
+package finance
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/payments"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the admin order adjustment and finance journal endpoints
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new finance handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// CreateAdjustment handles POST /api/admin/orders/:id/adjustments (admin only)
+func (h *Handler) CreateAdjustment(c *gin.Context) {
+	var req AdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	adjustment, err := h.service.CreateAdjustment(c.Param("id"), req, c.GetString("user_id"))
+	if err != nil {
+		adjustmentError(c, err)
+		return
+	}
+
+	if adjustment.Status == models.AdjustmentStatusPendingApproval {
+		utils.SuccessResponse(c, http.StatusAccepted, "Adjustment awaiting approval by a second admin", adjustment)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusCreated, "Adjustment applied successfully", adjustment)
+}
+
+// ListAdjustments handles GET /api/admin/orders/:id/adjustments (admin only)
+func (h *Handler) ListAdjustments(c *gin.Context) {
+	adjustments, err := h.service.ListAdjustments(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_ADJUSTMENTS_FAILED", "Failed to get adjustments", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Adjustments retrieved successfully", adjustments)
+}
+
+// ApproveAdjustment handles POST /api/admin/orders/:id/adjustments/:adjustmentId/approve (admin only)
+func (h *Handler) ApproveAdjustment(c *gin.Context) {
+	adjustment, err := h.service.ApproveAdjustment(c.Param("id"), c.Param("adjustmentId"), c.GetString("user_id"))
+	if err != nil {
+		adjustmentError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Adjustment approved and applied", adjustment)
+}
+
+// RejectAdjustment handles POST /api/admin/orders/:id/adjustments/:adjustmentId/reject (admin only)
+func (h *Handler) RejectAdjustment(c *gin.Context) {
+	adjustment, err := h.service.RejectAdjustment(c.Param("id"), c.Param("adjustmentId"), c.GetString("user_id"))
+	if err != nil {
+		adjustmentError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Adjustment rejected", adjustment)
+}
+
+// ListJournal handles GET /api/admin/finance/journal (admin only)
+func (h *Handler) ListJournal(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	entries, total, err := h.service.ListJournal(JournalQuery{Journal: c.Query("journal"), OrderID: c.Query("orderId"), Page: page, Limit: limit})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_JOURNAL_FAILED", "Failed to get journal entries", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(c, http.StatusOK, "Journal entries retrieved successfully", "entries", entries, utils.NewPagination(page, limit, total))
+}
+
+// adjustmentError writes the response for a failed adjustment action
+func adjustmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrOrderNotFound), errors.Is(err, payments.ErrOrderNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+	case errors.Is(err, ErrAdjustmentNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "ADJUSTMENT_NOT_FOUND", "Adjustment not found", nil)
+	case errors.Is(err, ErrInvalidReasonCode), errors.Is(err, ErrAdjustmentExceedsOrder), errors.Is(err, payments.ErrRefundExceedsBalance):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_ADJUSTMENT", err.Error(), nil)
+	case errors.Is(err, ErrSelfApproval):
+		utils.ErrorResponse(c, http.StatusForbidden, "SECOND_APPROVER_REQUIRED", err.Error(), nil)
+	case errors.Is(err, ErrAdjustmentNotPending):
+		utils.ErrorResponse(c, http.StatusConflict, "ADJUSTMENT_NOT_PENDING", err.Error(), nil)
+	case errors.Is(err, payments.ErrOrderNotRefundable):
+		utils.ErrorResponse(c, http.StatusConflict, "ORDER_NOT_REFUNDABLE", "Order has no captured payment to refund", nil)
+	case errors.Is(err, payments.ErrAlreadyRefunded):
+		utils.ErrorResponse(c, http.StatusConflict, "ORDER_ALREADY_REFUNDED", "Order is already fully refunded", nil)
+	case errors.Is(err, payments.ErrRefundFailed):
+		utils.ErrorResponse(c, http.StatusBadGateway, "REFUND_FAILED", "Payment provider refused the refund", err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "ADJUSTMENT_FAILED", "Failed to process adjustment", err.Error())
+	}
+}
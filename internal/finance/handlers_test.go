@@ -0,0 +1,64 @@
+package finance
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_AdjustmentApproval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, refunds, order := setupTestService(t)
+	handler := NewHandler(service)
+
+	adminID := "admin-1"
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", adminID) })
+	r.POST("/api/admin/orders/:id/adjustments", handler.CreateAdjustment)
+	r.POST("/api/admin/orders/:id/adjustments/:adjustmentId/approve", handler.ApproveAdjustment)
+
+	send := func(url, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	base := "/api/admin/orders/" + order.ID + "/adjustments"
+
+	w := send(base, `{"type":"goodwill_credit","amount":20,"reasonCode":"because"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_ADJUSTMENT")
+
+	w = send(base, `{"type":"goodwill_credit","amount":20,"reasonCode":"service_issue"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = send(base, `{"type":"returnless_refund","amount":200,"reasonCode":"item_not_received"}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var response struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	approve := base + "/" + response.Data.ID + "/approve"
+
+	w = send(approve, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "SECOND_APPROVER_REQUIRED")
+
+	adminID = "admin-2"
+	w = send(approve, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []float64{200}, refunds.amounts)
+
+	w = send(approve, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "ADJUSTMENT_NOT_PENDING")
+}
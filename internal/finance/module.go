@@ -0,0 +1,38 @@
+package finance
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/payments"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires order adjustments, store credit and the finance journals into the application
+type Module struct {
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the finance module; returnless refunds go through Razorpay
+func NewModule(deps app.Deps) app.Module {
+	refunds := payments.NewService(deps.DB, deps.Config.RazorpayKeyID, deps.Config.RazorpaySecret)
+	service := NewService(deps.DB, refunds)
+	return &Module{handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "finance"
+}
+
+// Models returns the adjustment, store credit and journal tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.OrderAdjustment{}, &models.StoreCreditEntry{}, &models.FinanceJournalEntry{}}
+}
+
+// RegisterAPIRoutes sets up the admin adjustment and journal routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,24 @@
+package finance
+
+import (
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin order adjustment and finance journal routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		// Adjustments move money, so double submits are rejected like refunds
+		sensitive := middleware.ReplayProtectionMiddleware(middleware.SensitiveReplayProtection)
+		admin.POST("/orders/:id/adjustments", sensitive, handler.CreateAdjustment)
+		admin.GET("/orders/:id/adjustments", handler.ListAdjustments)
+		admin.POST("/orders/:id/adjustments/:adjustmentId/approve", sensitive, handler.ApproveAdjustment)
+		admin.POST("/orders/:id/adjustments/:adjustmentId/reject", handler.RejectAdjustment)
+		admin.GET("/finance/journal", handler.ListJournal)
+	}
+}
@@ -0,0 +1,325 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/payments"
+	"ecommerce-website/internal/settings"
+	"ecommerce-website/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrderNotFound          = errors.New("order not found")
+	ErrAdjustmentNotFound     = errors.New("adjustment not found")
+	ErrInvalidReasonCode      = errors.New("invalid reason code")
+	ErrAdjustmentExceedsOrder = errors.New("adjustments exceed the order total")
+	ErrAdjustmentNotPending   = errors.New("adjustment is not awaiting approval")
+	ErrSelfApproval           = errors.New("adjustments must be approved by a second admin")
+)
+
+// ReasonCodes are the reasons an order may be adjusted for
+var ReasonCodes = map[string]bool{
+	"item_not_received":  true,
+	"damaged_in_transit": true,
+	"wrong_item":         true,
+	"missing_parts":      true,
+	"late_delivery":      true,
+	"quality_issue":      true,
+	"service_issue":      true,
+	"price_adjustment":   true,
+}
+
+// AdjustmentRequest asks for a returnless refund or a goodwill store credit on an order
+type AdjustmentRequest struct {
+	Type       string  `json:"type" binding:"required,oneof=returnless_refund goodwill_credit"`
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	ReasonCode string  `json:"reasonCode" binding:"required"`
+	Note       string  `json:"note" binding:"max=500"`
+}
+
+// JournalQuery filters finance journal entries
+type JournalQuery struct {
+	Journal string
+	OrderID string
+	Page    int
+	Limit   int
+}
+
+// Refunder issues refunds with the payment provider
+type Refunder interface {
+	RefundOrder(orderID string, req payments.RefundRequest, adminID string) (*models.Refund, error)
+}
+
+// ServiceInterface defines the finance service methods
+type ServiceInterface interface {
+	CreateAdjustment(orderID string, req AdjustmentRequest, adminID string) (*models.OrderAdjustment, error)
+	ApproveAdjustment(orderID, adjustmentID, adminID string) (*models.OrderAdjustment, error)
+	RejectAdjustment(orderID, adjustmentID, adminID string) (*models.OrderAdjustment, error)
+	ListAdjustments(orderID string) ([]models.OrderAdjustment, error)
+	ListJournal(query JournalQuery) ([]models.FinanceJournalEntry, int64, error)
+}
+
+// Service applies order adjustments and keeps the store credit ledger and finance journals
+type Service struct {
+	db       *gorm.DB
+	refunds  Refunder
+	settings settings.Reader
+}
+
+// NewService creates a finance service that refunds through refunds
+func NewService(db *gorm.DB, refunds Refunder) *Service {
+	return &Service{db: db, refunds: refunds, settings: settings.NewService(db)}
+}
+
+// CreateAdjustment records a returnless refund or goodwill credit on an order. Adjustments up to the approval
+// threshold are applied at once; larger ones wait for a second admin to approve them.
+func (s *Service) CreateAdjustment(orderID string, req AdjustmentRequest, adminID string) (*models.OrderAdjustment, error) {
+	if !ReasonCodes[req.ReasonCode] {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidReasonCode, req.ReasonCode)
+	}
+
+	var order models.Order
+	if err := s.db.Select("id, user_id, total").First(&order, "id = ?", orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to load order: %w", err)
+	}
+
+	// Adjustments that are applied or awaiting approval count against the order total together
+	var adjusted int64
+	if err := s.db.Model(&models.OrderAdjustment{}).
+		Where("order_id = ? AND status <> ?", order.ID, models.AdjustmentStatusRejected).
+		Select("COALESCE(SUM(amount), 0)").Scan(&adjusted).Error; err != nil {
+		return nil, fmt.Errorf("failed to get order adjustments: %w", err)
+	}
+	amount := utils.ToPaise(req.Amount)
+	if left := utils.ToPaise(order.Total) - adjusted; amount > left {
+		return nil, fmt.Errorf("%w: %.2f left to adjust", ErrAdjustmentExceedsOrder, float64(left)/100)
+	}
+
+	adjustment := models.OrderAdjustment{
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		Type:        req.Type,
+		Amount:      amount,
+		Currency:    "INR",
+		ReasonCode:  req.ReasonCode,
+		Note:        req.Note,
+		Status:      models.AdjustmentStatusPendingApproval,
+		RequestedBy: adminID,
+	}
+	threshold := utils.ToPaise(s.settings.GetFloat(context.Background(), settings.KeyAdjustmentApproval))
+	if amount > threshold {
+		if err := s.db.Create(&adjustment).Error; err != nil {
+			return nil, fmt.Errorf("failed to save adjustment: %w", err)
+		}
+		return &adjustment, nil
+	}
+
+	// Small adjustments are applied straight away; one the provider refuses is not kept
+	adjustment.Status = models.AdjustmentStatusApplied
+	if err := s.db.Create(&adjustment).Error; err != nil {
+		return nil, fmt.Errorf("failed to save adjustment: %w", err)
+	}
+	if err := s.apply(&adjustment, nil); err != nil {
+		if deleteErr := s.db.Delete(&models.OrderAdjustment{}, "id = ?", adjustment.ID).Error; deleteErr != nil {
+			logger.Named("finance").Error("Failed to discard unapplied adjustment", deleteErr, map[string]interface{}{"adjustment_id": adjustment.ID})
+		}
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+// ApproveAdjustment applies an adjustment awaiting approval. The approver must be another admin than the one
+// who requested it. If applying fails the adjustment goes back to awaiting approval.
+func (s *Service) ApproveAdjustment(orderID, adjustmentID, adminID string) (*models.OrderAdjustment, error) {
+	adjustment, err := s.getAdjustment(orderID, adjustmentID)
+	if err != nil {
+		return nil, err
+	}
+	if adjustment.Status != models.AdjustmentStatusPendingApproval {
+		return nil, fmt.Errorf("%w: adjustment is %s", ErrAdjustmentNotPending, adjustment.Status)
+	}
+	if adjustment.RequestedBy == adminID {
+		return nil, ErrSelfApproval
+	}
+
+	// Claim the adjustment in the update itself so two approvals cannot both apply it
+	now := time.Now()
+	result := s.db.Model(&models.OrderAdjustment{}).
+		Where("id = ? AND status = ?", adjustment.ID, models.AdjustmentStatusPendingApproval).
+		Updates(map[string]interface{}{"status": models.AdjustmentStatusApplied, "reviewed_by": adminID, "reviewed_at": now})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to approve adjustment: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAdjustmentNotPending
+	}
+	adjustment.Status = models.AdjustmentStatusApplied
+	adjustment.ReviewedBy = &adminID
+	adjustment.ReviewedAt = &now
+
+	if err := s.apply(adjustment, &adminID); err != nil {
+		if revertErr := s.db.Model(&models.OrderAdjustment{}).Where("id = ?", adjustment.ID).
+			Updates(map[string]interface{}{"status": models.AdjustmentStatusPendingApproval, "reviewed_by": nil, "reviewed_at": nil}).Error; revertErr != nil {
+			logger.Named("finance").Error("Failed to return adjustment to approval", revertErr, map[string]interface{}{"adjustment_id": adjustment.ID})
+		}
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+// RejectAdjustment declines an adjustment awaiting approval
+func (s *Service) RejectAdjustment(orderID, adjustmentID, adminID string) (*models.OrderAdjustment, error) {
+	adjustment, err := s.getAdjustment(orderID, adjustmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := s.db.Model(&models.OrderAdjustment{}).
+		Where("id = ? AND status = ?", adjustment.ID, models.AdjustmentStatusPendingApproval).
+		Updates(map[string]interface{}{"status": models.AdjustmentStatusRejected, "reviewed_by": adminID, "reviewed_at": now})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to reject adjustment: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: adjustment is %s", ErrAdjustmentNotPending, adjustment.Status)
+	}
+
+	adjustment.Status = models.AdjustmentStatusRejected
+	adjustment.ReviewedBy = &adminID
+	adjustment.ReviewedAt = &now
+	return adjustment, nil
+}
+
+// ListAdjustments returns the adjustments of an order, newest first
+func (s *Service) ListAdjustments(orderID string) ([]models.OrderAdjustment, error) {
+	var adjustments []models.OrderAdjustment
+	if err := s.db.Where("order_id = ?", orderID).Order("created_at DESC").Find(&adjustments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get adjustments: %w", err)
+	}
+	return adjustments, nil
+}
+
+// ListJournal returns finance journal entries, newest first
+func (s *Service) ListJournal(query JournalQuery) ([]models.FinanceJournalEntry, int64, error) {
+	db := s.db.Model(&models.FinanceJournalEntry{})
+	if query.Journal != "" {
+		db = db.Where("journal = ?", query.Journal)
+	}
+	if query.OrderID != "" {
+		db = db.Where("order_id = ?", query.OrderID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count journal entries: %w", err)
+	}
+
+	var entries []models.FinanceJournalEntry
+	if err := db.Order("created_at DESC").Limit(query.Limit).Offset((query.Page - 1) * query.Limit).Find(&entries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get journal entries: %w", err)
+	}
+	return entries, total, nil
+}
+
+// getAdjustment loads an adjustment of an order
+func (s *Service) getAdjustment(orderID, adjustmentID string) (*models.OrderAdjustment, error) {
+	var adjustment models.OrderAdjustment
+	if err := s.db.First(&adjustment, "id = ? AND order_id = ?", adjustmentID, orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAdjustmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get adjustment: %w", err)
+	}
+	return &adjustment, nil
+}
+
+// apply refunds or credits the customer and posts the adjustment to its finance journal
+func (s *Service) apply(adjustment *models.OrderAdjustment, approvedBy *string) error {
+	switch adjustment.Type {
+	case models.AdjustmentTypeReturnlessRefund:
+		return s.applyRefund(adjustment, approvedBy)
+	case models.AdjustmentTypeGoodwillCredit:
+		return s.applyCredit(adjustment, approvedBy)
+	}
+	return fmt.Errorf("unknown adjustment type %s", adjustment.Type)
+}
+
+// applyRefund refunds the adjustment amount without a return. Once the provider has refunded, the refund is
+// never undone: a failure to record it is logged for finance to reconcile rather than returned.
+func (s *Service) applyRefund(adjustment *models.OrderAdjustment, approvedBy *string) error {
+	actor := adjustment.RequestedBy
+	if approvedBy != nil {
+		actor = *approvedBy
+	}
+	refund, err := s.refunds.RefundOrder(adjustment.OrderID, payments.RefundRequest{
+		Amount: float64(adjustment.Amount) / 100,
+		Reason: "returnless refund: " + adjustment.ReasonCode,
+	}, actor)
+	if err != nil {
+		return err
+	}
+
+	adjustment.RefundID = &refund.ID
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OrderAdjustment{}).Where("id = ?", adjustment.ID).Update("refund_id", refund.ID).Error; err != nil {
+			return err
+		}
+		return tx.Create(journalEntry(adjustment, models.JournalRefunds, refund.ID, approvedBy)).Error
+	})
+	if err != nil {
+		logger.Named("finance").Error("Failed to record returnless refund", err, map[string]interface{}{"adjustment_id": adjustment.ID, "refund_id": refund.ID})
+	}
+	return nil
+}
+
+// applyCredit grants the adjustment amount as store credit
+func (s *Service) applyCredit(adjustment *models.OrderAdjustment, approvedBy *string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		credit := models.StoreCreditEntry{
+			UserID:       adjustment.UserID,
+			Amount:       adjustment.Amount,
+			Currency:     adjustment.Currency,
+			OrderID:      &adjustment.OrderID,
+			AdjustmentID: &adjustment.ID,
+			Reason:       "goodwill credit: " + adjustment.ReasonCode,
+			CreatedBy:    adjustment.RequestedBy,
+		}
+		if err := tx.Create(&credit).Error; err != nil {
+			return fmt.Errorf("failed to grant store credit: %w", err)
+		}
+		if err := tx.Model(&models.OrderAdjustment{}).Where("id = ?", adjustment.ID).Update("store_credit_id", credit.ID).Error; err != nil {
+			return fmt.Errorf("failed to update adjustment: %w", err)
+		}
+		if err := tx.Create(journalEntry(adjustment, models.JournalStoreCredit, credit.ID, approvedBy)).Error; err != nil {
+			return fmt.Errorf("failed to post journal entry: %w", err)
+		}
+		adjustment.StoreCreditID = &credit.ID
+		return nil
+	})
+}
+
+// journalEntry is the finance journal entry of an applied adjustment
+func journalEntry(adjustment *models.OrderAdjustment, journal, reference string, approvedBy *string) *models.FinanceJournalEntry {
+	return &models.FinanceJournalEntry{
+		Journal:     journal,
+		EntryType:   adjustment.Type,
+		OrderID:     &adjustment.OrderID,
+		Amount:      adjustment.Amount,
+		Currency:    adjustment.Currency,
+		ReasonCode:  adjustment.ReasonCode,
+		Reference:   reference,
+		RequestedBy: adjustment.RequestedBy,
+		ApprovedBy:  approvedBy,
+	}
+}
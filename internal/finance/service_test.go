@@ -0,0 +1,148 @@
+package finance
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/payments"
+	"ecommerce-website/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRefunder records refunds instead of calling the payment provider
+type fakeRefunder struct {
+	amounts []float64
+	err     error
+}
+
+func (f *fakeRefunder) RefundOrder(orderID string, req payments.RefundRequest, adminID string) (*models.Refund, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.amounts = append(f.amounts, req.Amount)
+	return &models.Refund{ID: fmt.Sprintf("refund-%d", len(f.amounts)), OrderID: orderID, Amount: int64(req.Amount * 100), CreatedBy: adminID}, nil
+}
+
+func setupTestService(t *testing.T) (*Service, *fakeRefunder, models.Order) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+
+	// Adjustments above 100.00 need a second approver
+	require.NoError(t, db.Create(&models.Setting{
+		Key: settings.KeyAdjustmentApproval, Scope: models.SettingScopeGlobal, Type: models.SettingTypeFloat, Value: "100",
+	}).Error)
+
+	user := models.User{Email: "finance@example.com", Password: "hashed", FirstName: "Fin", LastName: "Ance"}
+	require.NoError(t, db.Create(&user).Error)
+	order := models.Order{UserID: user.ID, Status: "delivered", Subtotal: 500, Total: 500}
+	require.NoError(t, db.Create(&order).Error)
+
+	refunds := &fakeRefunder{}
+	return NewService(db, refunds), refunds, order
+}
+
+func journal(t *testing.T, name string) []models.FinanceJournalEntry {
+	var entries []models.FinanceJournalEntry
+	require.NoError(t, database.GetDB().Where("journal = ?", name).Find(&entries).Error)
+	return entries
+}
+
+func TestService_GoodwillCreditBelowThresholdIsApplied(t *testing.T) {
+	service, refunds, order := setupTestService(t)
+
+	adjustment, err := service.CreateAdjustment(order.ID, AdjustmentRequest{
+		Type: models.AdjustmentTypeGoodwillCredit, Amount: 50, ReasonCode: "late_delivery", Note: "courier delay",
+	}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.AdjustmentStatusApplied, adjustment.Status)
+	require.NotNil(t, adjustment.StoreCreditID)
+	assert.Empty(t, refunds.amounts)
+
+	var credit models.StoreCreditEntry
+	require.NoError(t, database.GetDB().First(&credit, "id = ?", *adjustment.StoreCreditID).Error)
+	assert.Equal(t, order.UserID, credit.UserID)
+	assert.Equal(t, int64(5000), credit.Amount)
+
+	entries := journal(t, models.JournalStoreCredit)
+	require.Len(t, entries, 1)
+	assert.Equal(t, credit.ID, entries[0].Reference)
+	assert.Equal(t, "late_delivery", entries[0].ReasonCode)
+	assert.Nil(t, entries[0].ApprovedBy)
+}
+
+func TestService_LargeRefundNeedsSecondApprover(t *testing.T) {
+	service, refunds, order := setupTestService(t)
+
+	adjustment, err := service.CreateAdjustment(order.ID, AdjustmentRequest{
+		Type: models.AdjustmentTypeReturnlessRefund, Amount: 250, ReasonCode: "damaged_in_transit",
+	}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.AdjustmentStatusPendingApproval, adjustment.Status)
+	assert.Empty(t, refunds.amounts)
+	assert.Empty(t, journal(t, models.JournalRefunds))
+
+	_, err = service.ApproveAdjustment(order.ID, adjustment.ID, "admin-1")
+	assert.True(t, errors.Is(err, ErrSelfApproval))
+
+	refunds.err = fmt.Errorf("%w: gateway down", payments.ErrRefundFailed)
+	_, err = service.ApproveAdjustment(order.ID, adjustment.ID, "admin-2")
+	assert.True(t, errors.Is(err, payments.ErrRefundFailed))
+	adjustments, err := service.ListAdjustments(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AdjustmentStatusPendingApproval, adjustments[0].Status, "a refused refund can be approved again")
+
+	refunds.err = nil
+	approved, err := service.ApproveAdjustment(order.ID, adjustment.ID, "admin-2")
+	require.NoError(t, err)
+	assert.Equal(t, models.AdjustmentStatusApplied, approved.Status)
+	assert.Equal(t, []float64{250}, refunds.amounts)
+	require.NotNil(t, approved.RefundID)
+
+	entries := journal(t, models.JournalRefunds)
+	require.Len(t, entries, 1)
+	assert.Equal(t, *approved.RefundID, entries[0].Reference)
+	assert.Equal(t, int64(25000), entries[0].Amount)
+	require.NotNil(t, entries[0].ApprovedBy)
+	assert.Equal(t, "admin-2", *entries[0].ApprovedBy)
+
+	_, err = service.ApproveAdjustment(order.ID, adjustment.ID, "admin-3")
+	assert.True(t, errors.Is(err, ErrAdjustmentNotPending))
+}
+
+func TestService_RejectAndLimits(t *testing.T) {
+	service, refunds, order := setupTestService(t)
+
+	_, err := service.CreateAdjustment(order.ID, AdjustmentRequest{Type: models.AdjustmentTypeGoodwillCredit, Amount: 10, ReasonCode: "felt_like_it"}, "admin-1")
+	assert.True(t, errors.Is(err, ErrInvalidReasonCode))
+
+	_, err = service.CreateAdjustment("missing", AdjustmentRequest{Type: models.AdjustmentTypeGoodwillCredit, Amount: 10, ReasonCode: "service_issue"}, "admin-1")
+	assert.True(t, errors.Is(err, ErrOrderNotFound))
+
+	pending, err := service.CreateAdjustment(order.ID, AdjustmentRequest{Type: models.AdjustmentTypeReturnlessRefund, Amount: 450, ReasonCode: "wrong_item"}, "admin-1")
+	require.NoError(t, err)
+
+	// Pending adjustments count against the order total
+	_, err = service.CreateAdjustment(order.ID, AdjustmentRequest{Type: models.AdjustmentTypeGoodwillCredit, Amount: 60, ReasonCode: "service_issue"}, "admin-1")
+	assert.True(t, errors.Is(err, ErrAdjustmentExceedsOrder))
+
+	rejected, err := service.RejectAdjustment(order.ID, pending.ID, "admin-2")
+	require.NoError(t, err)
+	assert.Equal(t, models.AdjustmentStatusRejected, rejected.Status)
+	_, err = service.ApproveAdjustment(order.ID, pending.ID, "admin-2")
+	assert.True(t, errors.Is(err, ErrAdjustmentNotPending))
+	assert.Empty(t, refunds.amounts)
+
+	// A rejected adjustment frees its amount; a refused immediate refund is not kept
+	refunds.err = fmt.Errorf("%w: gateway down", payments.ErrRefundFailed)
+	_, err = service.CreateAdjustment(order.ID, AdjustmentRequest{Type: models.AdjustmentTypeReturnlessRefund, Amount: 60, ReasonCode: "service_issue"}, "admin-1")
+	assert.True(t, errors.Is(err, payments.ErrRefundFailed))
+	adjustments, err := service.ListAdjustments(order.ID)
+	require.NoError(t, err)
+	assert.Len(t, adjustments, 1)
+}
@@ -0,0 +1,144 @@
+package formatting
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Defaults used when a store has not configured its own locale or unit system
+const (
+	DefaultLocale     = "en-IN"
+	DefaultUnitSystem = UnitsMetric
+)
+
+// BaseCurrency is the currency catalog prices are stored and charged in. Prices are always shown in it: the
+// locale only changes how the amount is written, as there are no exchange rates to convert it with.
+const BaseCurrency = "INR"
+
+// Unit systems for weights
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// localeRule describes how a locale writes numbers and places the currency symbol
+type localeRule struct {
+	decimal      string
+	group        string
+	indian       bool // group as 12,34,567 instead of 1,234,567
+	symbolAfter  bool // "12,50 €" instead of "€12.50"
+	symbolSpaced bool // put a non-breaking space between the symbol and the amount
+}
+
+var locales = map[string]localeRule{
+	"en-IN": {decimal: ".", group: ",", indian: true},
+	"en-US": {decimal: ".", group: ","},
+	"en-GB": {decimal: ".", group: ","},
+	"de-DE": {decimal: ",", group: ".", symbolAfter: true, symbolSpaced: true},
+	"fr-FR": {decimal: ",", group: " ", symbolAfter: true, symbolSpaced: true},
+	"ja-JP": {decimal: ".", group: ","},
+}
+
+// Base currency symbol and the number of minor digits prices are shown with
+const (
+	baseSymbol   = "₹"
+	baseDecimals = 2
+)
+
+// Formatter turns raw catalog values into display strings for one locale and unit system.
+// Unknown locales and unit systems fall back to the defaults.
+type Formatter struct {
+	Locale     string
+	UnitSystem string
+	locale     localeRule
+}
+
+// New creates a formatter
+func New(locale, unitSystem string) Formatter {
+	f := Formatter{Locale: locale, UnitSystem: unitSystem}
+	var ok bool
+	if f.locale, ok = locales[f.Locale]; !ok {
+		f.Locale, f.locale = DefaultLocale, locales[DefaultLocale]
+	}
+	if f.UnitSystem != UnitsImperial {
+		f.UnitSystem = UnitsMetric
+	}
+	return f
+}
+
+// Price formats an amount of the base currency in the formatter's locale, e.g. "₹1,23,456.00" or "1.234,50 ₹"
+func (f Formatter) Price(amount float64) string {
+	number := f.number(math.Abs(amount), baseDecimals)
+	var b strings.Builder
+	if amount < 0 {
+		b.WriteString("-")
+	}
+	space := ""
+	if f.locale.symbolSpaced {
+		space = " "
+	}
+	if f.locale.symbolAfter {
+		b.WriteString(number + space + baseSymbol)
+	} else {
+		b.WriteString(baseSymbol + space + number)
+	}
+	return b.String()
+}
+
+// Weight formats a weight given in grams: grams or kilograms for metric stores, ounces or pounds for imperial ones
+func (f Formatter) Weight(grams float64) string {
+	if f.UnitSystem == UnitsImperial {
+		ounces := grams / 28.349523125
+		if ounces < 16 {
+			return f.trimmed(ounces, 1) + " oz"
+		}
+		return f.trimmed(ounces/16, 2) + " lb"
+	}
+	if grams < 1000 {
+		return f.trimmed(grams, 0) + " g"
+	}
+	return f.trimmed(grams/1000, 2) + " kg"
+}
+
+// trimmed formats a number with at most the given decimals, dropping trailing zeros
+func (f Formatter) trimmed(value float64, decimals int) string {
+	number := f.number(value, decimals)
+	if decimals == 0 {
+		return number
+	}
+	number = strings.TrimRight(number, "0")
+	return strings.TrimSuffix(number, f.locale.decimal)
+}
+
+// number writes a non-negative value with the locale's separators and a fixed number of decimals
+func (f Formatter) number(value float64, decimals int) string {
+	raw := strconv.FormatFloat(value, 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(raw, ".")
+
+	grouped := groupDigits(integer, f.locale.group, f.locale.indian)
+	if decimals == 0 {
+		return grouped
+	}
+	return grouped + f.locale.decimal + fraction
+}
+
+// groupDigits inserts group separators into a run of digits
+func groupDigits(digits, separator string, indian bool) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	head, tail := digits[:len(digits)-3], digits[len(digits)-3:]
+	size := 3
+	if indian {
+		size = 2
+	}
+
+	var groups []string
+	for len(head) > size {
+		groups = append([]string{head[len(head)-size:]}, groups...)
+		head = head[:len(head)-size]
+	}
+	groups = append([]string{head}, groups...)
+	return strings.Join(append(groups, tail), separator)
+}
@@ -0,0 +1,60 @@
+package formatting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatter_Price(t *testing.T) {
+	tests := []struct {
+		locale string
+		amount float64
+		want   string
+	}{
+		{"en-IN", 1234567.5, "₹12,34,567.50"},
+		{"en-IN", 999, "₹999.00"},
+		{"en-US", 1234567.5, "₹1,234,567.50"},
+		{"en-GB", 12.3, "₹12.30"},
+		{"de-DE", 1234.5, "1.234,50\u00a0₹"},
+		{"fr-FR", 1234.5, "1\u202f234,50\u00a0₹"},
+		{"ja-JP", 1234.4, "₹1,234.40"},
+		{"en-US", -5, "-₹5.00"},
+		{"xx-XX", 100, "₹100.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			assert.Equal(t, tt.want, New(tt.locale, "").Price(tt.amount))
+		})
+	}
+}
+
+// Prices are stored in rupees and nothing converts them, so no locale may label them with another currency
+func TestFormatter_PriceKeepsBaseCurrency(t *testing.T) {
+	for locale := range locales {
+		price := New(locale, "").Price(1299)
+		assert.Contains(t, price, "₹", locale)
+		assert.NotRegexp(t, `[$€£¥]`, price, locale)
+	}
+}
+
+func TestFormatter_Weight(t *testing.T) {
+	metric := New("en-IN", UnitsMetric)
+	assert.Equal(t, "500 g", metric.Weight(500))
+	assert.Equal(t, "1 kg", metric.Weight(1000))
+	assert.Equal(t, "1.25 kg", metric.Weight(1250))
+
+	imperial := New("en-US", UnitsImperial)
+	assert.Equal(t, "8 oz", imperial.Weight(226.796))
+	assert.Equal(t, "2.2 lb", imperial.Weight(1000))
+
+	german := New("de-DE", UnitsMetric)
+	assert.Equal(t, "1,5 kg", german.Weight(1500))
+}
+
+func TestNew_Defaults(t *testing.T) {
+	f := New("", "")
+	assert.Equal(t, DefaultLocale, f.Locale)
+	assert.Equal(t, DefaultUnitSystem, f.UnitSystem)
+}
@@ -0,0 +1,191 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Geocoding providers selectable with GEOCODER_PROVIDER
+const (
+	ProviderNominatim = "nominatim"
+	ProviderGoogle    = "google"
+)
+
+const (
+	// geocodeTimeout bounds one lookup, so a slow provider cannot hold up saving an address for long
+	geocodeTimeout = 5 * time.Second
+	// responseExcerpt caps the bytes of a failed provider response kept as the error
+	responseExcerpt = 512
+
+	defaultNominatimURL = "https://nominatim.openstreetmap.org"
+	defaultGoogleURL    = "https://maps.googleapis.com"
+	userAgent           = "ecommerce-api geocoder"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown geocoding provider")
+	ErrNoMatch         = errors.New("address could not be geocoded")
+)
+
+// Point is a location in decimal degrees
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Query is the address to locate
+type Query struct {
+	Address1   string
+	City       string
+	State      string
+	PostalCode string
+	Country    string // two-letter code
+}
+
+// Geocoder turns an address into a location, returning ErrNoMatch when the provider does not know it
+type Geocoder interface {
+	Geocode(ctx context.Context, q Query) (*Point, error)
+}
+
+// NewGeocoder creates the geocoder for provider, or returns nil when provider is empty and addresses are
+// not geocoded. baseURL overrides the provider's public endpoint.
+func NewGeocoder(provider, baseURL, apiKey string) (Geocoder, error) {
+	client := &http.Client{Timeout: geocodeTimeout}
+	switch provider {
+	case "":
+		return nil, nil
+	case ProviderNominatim:
+		if baseURL == "" {
+			baseURL = defaultNominatimURL
+		}
+		return &nominatimGeocoder{baseURL: strings.TrimRight(baseURL, "/"), client: client}, nil
+	case ProviderGoogle:
+		if apiKey == "" {
+			return nil, errors.New("the google geocoder needs GEOCODER_API_KEY")
+		}
+		if baseURL == "" {
+			baseURL = defaultGoogleURL
+		}
+		return &googleGeocoder{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, client: client}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, provider)
+	}
+}
+
+// getJSON fetches endpoint and decodes its JSON body into out
+func getJSON(ctx context.Context, client *http.Client, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build geocoding request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to geocode: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, responseExcerpt))
+		return fmt.Errorf("geocoder responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(excerpt)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to read geocoding response: %w", err)
+	}
+	return nil
+}
+
+// nominatimGeocoder looks addresses up with the structured search of an OpenStreetMap Nominatim server
+type nominatimGeocoder struct {
+	baseURL string
+	client  *http.Client
+}
+
+// Geocode returns the best match of a structured search
+func (g *nominatimGeocoder) Geocode(ctx context.Context, q Query) (*Point, error) {
+	params := url.Values{}
+	params.Set("format", "jsonv2")
+	params.Set("limit", "1")
+	params.Set("street", q.Address1)
+	params.Set("city", q.City)
+	params.Set("state", q.State)
+	params.Set("postalcode", q.PostalCode)
+	params.Set("countrycodes", strings.ToLower(q.Country))
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := getJSON(ctx, g.client, g.baseURL+"/search?"+params.Encode(), &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNoMatch
+	}
+	lat, latErr := strconv.ParseFloat(results[0].Lat, 64)
+	lng, lngErr := strconv.ParseFloat(results[0].Lon, 64)
+	if latErr != nil || lngErr != nil {
+		return nil, fmt.Errorf("geocoder returned an invalid location %q,%q", results[0].Lat, results[0].Lon)
+	}
+	return &Point{Lat: lat, Lng: lng}, nil
+}
+
+// googleGeocoder looks addresses up with the Google Geocoding API
+type googleGeocoder struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// Geocode returns the first result, restricted to the address's country
+func (g *googleGeocoder) Geocode(ctx context.Context, q Query) (*Point, error) {
+	params := url.Values{}
+	params.Set("address", strings.Join(nonEmpty(q.Address1, q.City, q.State, q.PostalCode), ", "))
+	params.Set("components", "country:"+q.Country)
+	params.Set("key", g.apiKey)
+
+	var response struct {
+		Status       string `json:"status"`
+		ErrorMessage string `json:"error_message"`
+		Results      []struct {
+			Geometry struct {
+				Location Point `json:"location"`
+			} `json:"geometry"`
+		} `json:"results"`
+	}
+	if err := getJSON(ctx, g.client, g.baseURL+"/maps/api/geocode/json?"+params.Encode(), &response); err != nil {
+		return nil, err
+	}
+	switch response.Status {
+	case "OK":
+	case "ZERO_RESULTS":
+		return nil, ErrNoMatch
+	default:
+		return nil, fmt.Errorf("geocoder responded with %s: %s", response.Status, response.ErrorMessage)
+	}
+	if len(response.Results) == 0 {
+		return nil, ErrNoMatch
+	}
+	location := response.Results[0].Geometry.Location
+	return &location, nil
+}
+
+// nonEmpty returns the trimmed values that are not blank
+func nonEmpty(values ...string) []string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,72 @@
+package geo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGeocoder(t *testing.T) {
+	geocoder, err := NewGeocoder("", "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, geocoder)
+
+	_, err = NewGeocoder("carrier-pigeon", "", "")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+	_, err = NewGeocoder(ProviderGoogle, "", "")
+	assert.Error(t, err, "google needs an API key")
+}
+
+func TestNominatimGeocoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/search", r.URL.Path)
+		assert.Equal(t, "in", r.URL.Query().Get("countrycodes"))
+		if r.URL.Query().Get("postalcode") == "000000" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"lat":"12.9716","lon":"77.5946"}]`))
+	}))
+	defer server.Close()
+
+	geocoder, err := NewGeocoder(ProviderNominatim, server.URL+"/", "")
+	require.NoError(t, err)
+	point, err := geocoder.Geocode(context.Background(), Query{Address1: "MG Road", City: "Bengaluru", PostalCode: "560001", Country: "IN"})
+	require.NoError(t, err)
+	assert.Equal(t, Point{Lat: 12.9716, Lng: 77.5946}, *point)
+
+	_, err = geocoder.Geocode(context.Background(), Query{PostalCode: "000000", Country: "IN"})
+	assert.ErrorIs(t, err, ErrNoMatch)
+}
+
+func TestGoogleGeocoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/maps/api/geocode/json", r.URL.Path)
+		assert.Equal(t, "secret", r.URL.Query().Get("key"))
+		assert.Equal(t, "country:US", r.URL.Query().Get("components"))
+		switch r.URL.Query().Get("address") {
+		case "1 Market St, San Francisco, CA, 94105":
+			_, _ = w.Write([]byte(`{"status":"OK","results":[{"geometry":{"location":{"lat":37.79,"lng":-122.39}}}]}`))
+		case "Nowhere":
+			_, _ = w.Write([]byte(`{"status":"ZERO_RESULTS","results":[]}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":"OVER_QUERY_LIMIT","error_message":"slow down"}`))
+		}
+	}))
+	defer server.Close()
+
+	geocoder, err := NewGeocoder(ProviderGoogle, server.URL, "secret")
+	require.NoError(t, err)
+	point, err := geocoder.Geocode(context.Background(), Query{Address1: "1 Market St", City: "San Francisco", State: "CA", PostalCode: "94105", Country: "US"})
+	require.NoError(t, err)
+	assert.Equal(t, Point{Lat: 37.79, Lng: -122.39}, *point)
+
+	_, err = geocoder.Geocode(context.Background(), Query{Address1: "Nowhere", Country: "US"})
+	assert.ErrorIs(t, err, ErrNoMatch)
+	_, err = geocoder.Geocode(context.Background(), Query{Address1: "Busy", Country: "US"})
+	assert.ErrorContains(t, err, "OVER_QUERY_LIMIT")
+}
@@ -0,0 +1,155 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultBackfillBatchSize is how many addresses a backfill loads at a time
+const DefaultBackfillBatchSize = 100
+
+// Service locates addresses and assigns them to delivery zones
+type Service struct {
+	db       *gorm.DB
+	geocoder Geocoder
+	now      func() time.Time
+}
+
+// NewService creates a locator geocoding with geocoder, which may be nil to assign zones by postal code only
+func NewService(db *gorm.DB, geocoder Geocoder) *Service {
+	return &Service{db: db, geocoder: geocoder, now: time.Now}
+}
+
+// Zones returns the active delivery zones
+func (s *Service) Zones(ctx context.Context) ([]models.DeliveryZone, error) {
+	var zones []models.DeliveryZone
+	if err := s.db.WithContext(ctx).Where("is_active = ?", true).Find(&zones).Error; err != nil {
+		return nil, fmt.Errorf("failed to load delivery zones: %w", err)
+	}
+	return zones, nil
+}
+
+// Locate geocodes an address and sets its location and delivery zone; it does not save the address. A
+// failed lookup is logged and leaves the address without a location, still zoned by its postal code, so
+// addresses can be saved while the provider is down.
+func (s *Service) Locate(ctx context.Context, address *models.Address) error {
+	address.Latitude, address.Longitude, address.GeocodedAt = nil, nil, nil
+	var point *Point
+	if s.geocoder != nil {
+		lookupCtx, cancel := context.WithTimeout(ctx, geocodeTimeout)
+		found, err := s.geocoder.Geocode(lookupCtx, Query{
+			Address1:   address.Address1,
+			City:       address.City,
+			State:      address.State,
+			PostalCode: address.PostalCode,
+			Country:    address.Country,
+		})
+		cancel()
+		switch {
+		case err == nil:
+			point = found
+			now := s.now()
+			address.Latitude, address.Longitude, address.GeocodedAt = &found.Lat, &found.Lng, &now
+		case errors.Is(err, ErrNoMatch):
+		default:
+			logger.Named("geo").Warn("Failed to geocode address", map[string]interface{}{"address_id": address.ID, "error": err.Error()})
+		}
+	}
+
+	zones, err := s.Zones(ctx)
+	if err != nil {
+		return err
+	}
+	address.DeliveryZoneID = nil
+	if zone := MatchZone(zones, address.Country, address.PostalCode, point); zone != nil {
+		address.DeliveryZoneID = &zone.ID
+	}
+	return nil
+}
+
+// BackfillOptions selects the addresses a backfill locates
+type BackfillOptions struct {
+	BatchSize int
+	// All relocates every address, not only those never geocoded, as after zones are redrawn
+	All bool
+	// Throttle is waited between lookups, for providers with a request rate limit
+	Throttle time.Duration
+}
+
+// BackfillProgress counts the addresses a backfill has handled
+type BackfillProgress struct {
+	Total    int64 `json:"total"`
+	Located  int64 `json:"located"`
+	Zoned    int64 `json:"zoned"`
+	Failed   int64 `json:"failed"`
+	Finished int64 `json:"finished"`
+}
+
+// Backfill locates and zones existing addresses, reporting progress after each batch. Addresses that cannot be
+// geocoded are zoned by postal code and counted as not located.
+func (s *Service) Backfill(ctx context.Context, opts BackfillOptions, report func(BackfillProgress)) (BackfillProgress, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBackfillBatchSize
+	}
+	query := s.db.WithContext(ctx).Model(&models.Address{})
+	if !opts.All {
+		query = query.Where("geocoded_at IS NULL")
+	}
+
+	var progress BackfillProgress
+	if err := query.Count(&progress.Total).Error; err != nil {
+		return progress, err
+	}
+
+	// Pages are keyed on ID so addresses updated during the run are neither skipped nor repeated
+	lastID := ""
+	for {
+		var batch []models.Address
+		if err := query.Session(&gorm.Session{}).Where("id > ?", lastID).Order("id").Limit(opts.BatchSize).Find(&batch).Error; err != nil {
+			return progress, err
+		}
+		if len(batch) == 0 {
+			return progress, nil
+		}
+		for i := range batch {
+			address := &batch[i]
+			lastID = address.ID
+			if err := ctx.Err(); err != nil {
+				return progress, err
+			}
+			if err := s.Locate(ctx, address); err != nil {
+				return progress, err
+			}
+			if err := s.db.WithContext(ctx).Model(address).UpdateColumns(map[string]interface{}{
+				"latitude":         address.Latitude,
+				"longitude":        address.Longitude,
+				"geocoded_at":      address.GeocodedAt,
+				"delivery_zone_id": address.DeliveryZoneID,
+			}).Error; err != nil {
+				progress.Failed++
+				logger.Named("geo").Warn("Failed to save located address", map[string]interface{}{"address_id": address.ID, "error": err.Error()})
+			} else {
+				if address.GeocodedAt != nil {
+					progress.Located++
+				}
+				if address.DeliveryZoneID != nil {
+					progress.Zoned++
+				}
+			}
+			progress.Finished++
+			if opts.Throttle > 0 && s.geocoder != nil {
+				time.Sleep(opts.Throttle)
+			}
+		}
+		if report != nil {
+			report(progress)
+		}
+	}
+}
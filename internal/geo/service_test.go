@@ -0,0 +1,114 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeGeocoder locates addresses by postal code
+type fakeGeocoder struct {
+	points map[string]Point
+	err    error
+	calls  int
+}
+
+func (f *fakeGeocoder) Geocode(ctx context.Context, q Query) (*Point, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	point, ok := f.points[q.PostalCode]
+	if !ok {
+		return nil, ErrNoMatch
+	}
+	return &point, nil
+}
+
+func setupGeoTest(t *testing.T, geocoder Geocoder) (*Service, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Address{}, &models.DeliveryZone{}))
+	return NewService(db, geocoder), db
+}
+
+func float(value float64) *float64 {
+	return &value
+}
+
+func TestMatchZone(t *testing.T) {
+	zones := []models.DeliveryZone{
+		{ID: "in", Country: "IN", IsActive: true, Priority: 10},
+		{ID: "blr-pin", Country: "IN", PostalPrefixes: models.StringArray{"5600"}, IsActive: true},
+		{ID: "blr-city", Country: "IN", CenterLat: float(12.97), CenterLng: float(77.59), RadiusKm: 25, IsActive: true},
+		{ID: "retired", Country: "IN", PostalPrefixes: models.StringArray{"4000"}, IsActive: false},
+	}
+
+	assert.Equal(t, "blr-pin", MatchZone(zones, "in", "560 001", nil).ID, "postal codes match ignoring spaces")
+	assert.Equal(t, "blr-city", MatchZone(zones, "IN", "562157", &Point{Lat: 13.1, Lng: 77.6}).ID)
+	assert.Equal(t, "in", MatchZone(zones, "IN", "562157", nil).ID, "without a location radius zones cannot match")
+	assert.Equal(t, "in", MatchZone(zones, "IN", "400001", &Point{Lat: 19.07, Lng: 72.87}).ID, "inactive zones are skipped")
+	assert.Nil(t, MatchZone(zones, "US", "94105", nil))
+
+	assert.InDelta(t, 845, DistanceKm(Point{Lat: 12.97, Lng: 77.59}, Point{Lat: 19.07, Lng: 72.87}), 5)
+}
+
+func TestService_Locate(t *testing.T) {
+	geocoder := &fakeGeocoder{points: map[string]Point{"562157": {Lat: 13.2, Lng: 77.7}}}
+	service, db := setupGeoTest(t, geocoder)
+	ctx := context.Background()
+	require.NoError(t, db.Create(&models.DeliveryZone{Name: "Bengaluru", Country: "IN", CenterLat: float(12.97), CenterLng: float(77.59), RadiusKm: 40, IsActive: true}).Error)
+
+	address := models.Address{Address1: "Airport Road", City: "Bengaluru", PostalCode: "562157", Country: "IN"}
+	require.NoError(t, service.Locate(ctx, &address))
+	require.NotNil(t, address.Latitude)
+	assert.Equal(t, 13.2, *address.Latitude)
+	assert.NotNil(t, address.GeocodedAt)
+	require.NotNil(t, address.DeliveryZoneID)
+
+	// A failing provider leaves the address without a location rather than failing the save
+	geocoder.err = errors.New("provider down")
+	require.NoError(t, service.Locate(ctx, &address))
+	assert.Nil(t, address.Latitude)
+	assert.Nil(t, address.GeocodedAt)
+	assert.Nil(t, address.DeliveryZoneID)
+}
+
+func TestService_Backfill(t *testing.T) {
+	geocoder := &fakeGeocoder{points: map[string]Point{"560001": {Lat: 12.97, Lng: 77.59}}}
+	service, db := setupGeoTest(t, geocoder)
+	ctx := context.Background()
+	zone := models.DeliveryZone{Name: "Bengaluru", Country: "IN", PostalPrefixes: models.StringArray{"560"}, IsActive: true}
+	require.NoError(t, db.Create(&zone).Error)
+
+	for _, code := range []string{"560001", "560002", "110001"} {
+		require.NoError(t, db.Create(&models.Address{UserID: "u1", Type: "shipping", FirstName: "A", LastName: "B", Address1: "1 Main", City: "C", State: "S", PostalCode: code, Country: "IN"}).Error)
+	}
+
+	reports := 0
+	progress, err := service.Backfill(ctx, BackfillOptions{BatchSize: 2}, func(BackfillProgress) { reports++ })
+	require.NoError(t, err)
+	assert.Equal(t, BackfillProgress{Total: 3, Located: 1, Zoned: 2, Finished: 3}, progress)
+	assert.Equal(t, 2, reports, "progress is reported once per batch")
+
+	var zoned int64
+	require.NoError(t, db.Model(&models.Address{}).Where("delivery_zone_id = ?", zone.ID).Count(&zoned).Error)
+	assert.EqualValues(t, 2, zoned)
+
+	// Only addresses never geocoded are retried unless all are asked for
+	geocoder.calls = 0
+	progress, err = service.Backfill(ctx, BackfillOptions{}, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, progress.Total)
+	assert.Equal(t, 2, geocoder.calls)
+	progress, err = service.Backfill(ctx, BackfillOptions{All: true}, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, progress.Total)
+}
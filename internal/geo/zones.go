@@ -0,0 +1,71 @@
+package geo
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"ecommerce-website/internal/models"
+)
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle distance between two points
+func DistanceKm(a, b Point) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(b.Lat - a.Lat)
+	dLng := toRad(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// normalizePostalCode uppercases a postal code and drops spaces and dashes, so "560 001" matches prefix "5600"
+func normalizePostalCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// Covers reports whether zone contains an address in country with postalCode, located at point when it
+// has been geocoded
+func Covers(zone *models.DeliveryZone, country, postalCode string, point *Point) bool {
+	if !strings.EqualFold(zone.Country, strings.TrimSpace(country)) {
+		return false
+	}
+	byRadius := zone.RadiusKm > 0 && zone.CenterLat != nil && zone.CenterLng != nil
+	if len(zone.PostalPrefixes) == 0 && !byRadius {
+		return true
+	}
+
+	code := normalizePostalCode(postalCode)
+	for _, prefix := range zone.PostalPrefixes {
+		if prefix = normalizePostalCode(prefix); prefix != "" && strings.HasPrefix(code, prefix) {
+			return true
+		}
+	}
+	return byRadius && point != nil && DistanceKm(*point, Point{Lat: *zone.CenterLat, Lng: *zone.CenterLng}) <= zone.RadiusKm
+}
+
+// MatchZone returns the zone an address falls in: of the zones covering it, the one with the lowest
+// priority, then the smallest radius, then the lowest ID. It returns nil when no zone covers the address.
+func MatchZone(zones []models.DeliveryZone, country, postalCode string, point *Point) *models.DeliveryZone {
+	candidates := make([]*models.DeliveryZone, 0, 1)
+	for i := range zones {
+		if zones[i].IsActive && Covers(&zones[i], country, postalCode, point) {
+			candidates = append(candidates, &zones[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if a.RadiusKm != b.RadiusKm {
+			return a.RadiusKm < b.RadiusKm
+		}
+		return a.ID < b.ID
+	})
+	return candidates[0]
+}
@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultTimeout bounds each dependency check of a probe
+const DefaultTimeout = 2 * time.Second
+
+// Dependency statuses and overall probe statuses
+const (
+	StatusUp          = "up"
+	StatusDown        = "down"
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"    // an optional dependency is down
+	StatusUnavailable = "unavailable" // a required dependency is down
+)
+
+// ErrTimeout is reported for a dependency that did not answer within the probe timeout
+var ErrTimeout = errors.New("check timed out")
+
+// Check is an external service the API depends on
+type Check struct {
+	Name     string
+	Required bool // the service is not ready while a required dependency is down
+	Ping     func(ctx context.Context) error
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of checking every dependency
+type Report struct {
+	Status       string            `json:"status"`
+	Dependencies map[string]Result `json:"dependencies"`
+}
+
+// Ready reports whether every required dependency is up
+func (r *Report) Ready() bool {
+	return r.Status != StatusUnavailable
+}
+
+// Prober checks the dependencies of the service for the health endpoints
+type Prober struct {
+	checks  []Check
+	timeout time.Duration
+	started time.Time
+}
+
+// NewProber creates a prober giving each check up to timeout
+func NewProber(timeout time.Duration, checks ...Check) *Prober {
+	return &Prober{checks: checks, timeout: timeout, started: time.Now()}
+}
+
+// Check runs every check concurrently. A check that does not return within the timeout is reported down,
+// even if its Ping ignores the context.
+func (p *Prober) Check(ctx context.Context) *Report {
+	report := &Report{Status: StatusOK, Dependencies: make(map[string]Result, len(p.checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range p.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := p.run(ctx, check)
+			mu.Lock()
+			report.Dependencies[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Dependencies {
+		switch {
+		case result.Status == StatusUp:
+		case result.Required:
+			report.Status = StatusUnavailable
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (p *Prober) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Ping(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrTimeout
+	}
+
+	result := Result{
+		Status:    StatusUp,
+		Required:  check.Required,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Live handles GET /health/live
+//
+// Answers as long as the process serves requests. No dependency is checked, so an outage of the database
+// does not get healthy instances restarted.
+func (p *Prober) Live(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Service is alive", gin.H{
+		"status":        StatusOK,
+		"uptimeSeconds": int64(time.Since(p.started).Seconds()),
+	})
+}
+
+// Ready handles GET /health/ready
+//
+// Checks every dependency and answers 503 while a required one is down, so load balancers stop sending
+// traffic to the instance.
+func (p *Prober) Ready(c *gin.Context) {
+	report := p.Check(c.Request.Context())
+	if !report.Ready() {
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "NOT_READY", "A required dependency is unavailable", report)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Service is ready", report)
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func up(ctx context.Context) error { return nil }
+
+func down(ctx context.Context) error { return errors.New("connection refused") }
+
+// hang ignores its context, as some client libraries do
+func hang(ctx context.Context) error {
+	time.Sleep(time.Second)
+	return nil
+}
+
+func TestProber_Check(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   []Check
+		expected string
+	}{
+		{"all up", []Check{{Name: "postgres", Required: true, Ping: up}, {Name: "elasticsearch", Ping: up}}, StatusOK},
+		{"optional down", []Check{{Name: "postgres", Required: true, Ping: up}, {Name: "elasticsearch", Ping: down}}, StatusDegraded},
+		{"required down", []Check{{Name: "postgres", Required: true, Ping: down}, {Name: "elasticsearch", Ping: down}}, StatusUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewProber(DefaultTimeout, tt.checks...).Check(context.Background())
+			assert.Equal(t, tt.expected, report.Status)
+			assert.Len(t, report.Dependencies, len(tt.checks))
+		})
+	}
+
+	t.Run("times out slow checks", func(t *testing.T) {
+		start := time.Now()
+		report := NewProber(20*time.Millisecond, Check{Name: "redis", Required: true, Ping: hang}).Check(context.Background())
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.Equal(t, StatusUnavailable, report.Status)
+		assert.Equal(t, Result{Status: StatusDown, Required: true, LatencyMs: report.Dependencies["redis"].LatencyMs, Error: ErrTimeout.Error()}, report.Dependencies["redis"])
+		assert.GreaterOrEqual(t, report.Dependencies["redis"].LatencyMs, 20.0)
+	})
+}
+
+func TestProber_Endpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	required := &Check{Name: "postgres", Required: true, Ping: up}
+	prober := NewProber(DefaultTimeout, Check{Name: "postgres", Required: true, Ping: func(ctx context.Context) error { return required.Ping(ctx) }})
+	r := gin.New()
+	r.GET("/health/live", prober.Live)
+	r.GET("/health/ready", prober.Ready)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/health/ready")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data Report `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, StatusUp, response.Data.Dependencies["postgres"].Status)
+
+	// A required dependency going down fails readiness but not liveness
+	required.Ping = down
+	w = get("/health/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "NOT_READY")
+	assert.Contains(t, w.Body.String(), "connection refused")
+	assert.Equal(t, http.StatusOK, get("/health/live").Code)
+}
@@ -0,0 +1,51 @@
+package invariants
+
+import (
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the admin invariant checker endpoints
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new invariant checker handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// Run handles POST /api/admin/invariants/run (admin only), running the checks right away
+func (h *Handler) Run(c *gin.Context) {
+	run, err := h.service.Run(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INVARIANT_RUN_FAILED", "Invariant checks failed", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Invariant checks finished", run)
+}
+
+// ListRuns handles GET /api/admin/invariants/runs (admin only)
+func (h *Handler) ListRuns(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+	runs, total, err := h.service.ListRuns(page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_INVARIANT_RUNS_FAILED", "Failed to get invariant runs", err.Error())
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Invariant runs retrieved successfully", "runs", runs, utils.NewPagination(page, limit, total))
+}
+
+// ListViolations handles GET /api/admin/invariants/violations (admin only), optionally for one run or invariant
+func (h *Handler) ListViolations(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+	violations, total, err := h.service.ListViolations(c.Query("runId"), c.Query("invariant"), page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_INVARIANT_VIOLATIONS_FAILED", "Failed to get invariant violations", err.Error())
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Invariant violations retrieved successfully", "violations", violations, utils.NewPagination(page, limit, total))
+}
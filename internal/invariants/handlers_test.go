@@ -0,0 +1,83 @@
+package invariants
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the invariant checker
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Run(ctx context.Context) (*models.InvariantRun, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.InvariantRun), args.Error(1)
+}
+
+func (m *MockService) ListRuns(page, limit int) ([]models.InvariantRun, int64, error) {
+	args := m.Called(page, limit)
+	return args.Get(0).([]models.InvariantRun), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockService) ListViolations(runID, invariant string, page, limit int) ([]models.InvariantViolation, int64, error) {
+	args := m.Called(runID, invariant, page, limit)
+	return args.Get(0).([]models.InvariantViolation), args.Get(1).(int64), args.Error(2)
+}
+
+func TestHandler_Run(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("Run").Return(&models.InvariantRun{ID: "run-1", Violations: 2}, nil).Once()
+	mockService.On("Run").Return(nil, errors.New("database down")).Once()
+
+	r := gin.New()
+	r.POST("/api/admin/invariants/run", NewHandler(mockService).Run)
+
+	send := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/admin/invariants/run", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"violations":2`)
+
+	w = send()
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "INVARIANT_RUN_FAILED")
+}
+
+func TestHandler_ListViolations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("ListViolations", "run-1", models.InvariantItemsSubtotal, 2, 20).
+		Return([]models.InvariantViolation{{ID: "v1", Invariant: models.InvariantItemsSubtotal, EntityID: "order-1"}}, int64(21), nil)
+
+	r := gin.New()
+	r.GET("/api/admin/invariants/violations", NewHandler(mockService).ListViolations)
+
+	req, _ := http.NewRequest("GET", "/api/admin/invariants/violations?runId=run-1&invariant=items_subtotal&page=2&limit=500", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"entityId":"order-1"`)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,48 @@
+package invariants
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkInterval is how often the checker runs
+const checkInterval = 24 * time.Hour
+
+// Module wires the nightly order, payment and inventory invariant checker into the application
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the invariants module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "invariants"
+}
+
+// Models returns the checker run and violation tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.InvariantRun{}, &models.InvariantViolation{}}
+}
+
+// StartJobs runs the checks nightly in the background
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartChecker(ctx, checkInterval)
+}
+
+// RegisterAPIRoutes sets up the admin invariant routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,19 @@
+package invariants
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin invariant checker routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin/invariants")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.POST("/run", handler.Run)
+		admin.GET("/runs", handler.ListRuns)
+		admin.GET("/violations", handler.ListViolations)
+	}
+}
@@ -0,0 +1,292 @@
+package invariants
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/monitoring"
+	"ecommerce-website/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// lookback is how far back a run scans orders and payments; it overlaps the previous nightly run so
+	// nothing is missed when a run fails
+	lookback = 7 * 24 * time.Hour
+	// alertSampleSize caps the affected IDs an alert lists
+	alertSampleSize = 20
+)
+
+// capturedStatuses are the payment statuses whose amount was captured from the customer
+var capturedStatuses = map[string]bool{
+	models.PaymentStatusPaid:           true,
+	models.PaymentStatusRefundRequired: true,
+	models.PaymentStatusRefunded:       true,
+}
+
+// ServiceInterface defines the invariant checker operations used by the handlers
+type ServiceInterface interface {
+	Run(ctx context.Context) (*models.InvariantRun, error)
+	ListRuns(page, limit int) ([]models.InvariantRun, int64, error)
+	ListViolations(runID, invariant string, page, limit int) ([]models.InvariantViolation, int64, error)
+}
+
+// Service checks that orders, payments and inventory stay consistent
+type Service struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewService creates an invariant checker
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, now: time.Now}
+}
+
+// check finds the violations of one invariant among records changed since
+type check struct {
+	name string
+	find func(ctx context.Context, since time.Time) ([]models.InvariantViolation, error)
+}
+
+func (s *Service) checks() []check {
+	return []check{
+		{models.InvariantItemsSubtotal, s.itemsSubtotal},
+		{models.InvariantRefundWithinCaptured, s.refundWithinCaptured},
+		{models.InvariantNonNegativeInventory, s.nonNegativeInventory},
+		{models.InvariantOrderItemHasOrder, s.orderItemHasOrder},
+	}
+}
+
+// Run runs every check, stores the violations found and raises one critical alert per violated check.
+// A failing check is recorded on the run and does not stop the others.
+func (s *Service) Run(ctx context.Context) (*models.InvariantRun, error) {
+	started := s.now()
+	run := models.InvariantRun{Since: started.Add(-lookback), StartedAt: started}
+	if err := s.db.WithContext(ctx).Create(&run).Error; err != nil {
+		return nil, err
+	}
+
+	for _, c := range s.checks() {
+		violations, err := c.find(ctx, run.Since)
+		if err != nil {
+			run.Error += fmt.Sprintf("%s: %v; ", c.name, err)
+			continue
+		}
+		if len(violations) == 0 {
+			continue
+		}
+		for i := range violations {
+			violations[i].RunID = run.ID
+			violations[i].Invariant = c.name
+		}
+		if err := s.db.WithContext(ctx).CreateInBatches(violations, 100).Error; err != nil {
+			run.Error += fmt.Sprintf("%s: %v; ", c.name, err)
+			continue
+		}
+		run.Violations += int64(len(violations))
+		alert(run.ID, c.name, violations)
+	}
+
+	run.FinishedAt = s.now()
+	if err := s.db.WithContext(ctx).Model(&run).Updates(map[string]interface{}{
+		"violations":  run.Violations,
+		"error":       run.Error,
+		"finished_at": run.FinishedAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// alert raises a critical alert listing the IDs affected by violations of a check
+func alert(runID, name string, violations []models.InvariantViolation) {
+	ids := make([]string, 0, alertSampleSize)
+	for _, violation := range violations {
+		if len(ids) == alertSampleSize {
+			break
+		}
+		ids = append(ids, violation.EntityID)
+	}
+	monitoring.CreateAlert(monitoring.AlertCritical, "Invariant violated: "+name,
+		fmt.Sprintf("%d records break the %s check", len(violations), name),
+		map[string]interface{}{"run_id": runID, "invariant": name, "count": len(violations), "ids": ids})
+}
+
+// itemsSubtotal finds recent orders whose subtotal differs from the sum of their item totals
+func (s *Service) itemsSubtotal(ctx context.Context, since time.Time) ([]models.InvariantViolation, error) {
+	var rows []struct {
+		ID       string
+		Subtotal float64
+		Items    float64
+	}
+	if err := s.db.WithContext(ctx).Table("orders").
+		Select("orders.id, orders.subtotal, COALESCE(SUM(order_items.total), 0) AS items").
+		Joins("LEFT JOIN order_items ON order_items.order_id = orders.id").
+		Where("orders.created_at >= ?", since).
+		Group("orders.id, orders.subtotal").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var violations []models.InvariantViolation
+	for _, row := range rows {
+		if utils.ToPaise(row.Subtotal) != utils.ToPaise(row.Items) {
+			violations = append(violations, models.InvariantViolation{
+				EntityID: row.ID,
+				Detail:   fmt.Sprintf("subtotal %.2f, item totals %.2f", row.Subtotal, row.Items),
+			})
+		}
+	}
+	return violations, nil
+}
+
+// refundWithinCaptured finds payments updated or refunded recently whose refunds exceed what they captured.
+// A payment that never captured anything captured 0.
+func (s *Service) refundWithinCaptured(ctx context.Context, since time.Time) ([]models.InvariantViolation, error) {
+	var payments []models.Payment
+	if err := s.db.WithContext(ctx).
+		Where("updated_at >= ? OR id IN (?)", since, s.db.Model(&models.Refund{}).Select("payment_id").Where("created_at >= ?", since)).
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	if len(payments) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(payments))
+	for i, payment := range payments {
+		ids[i] = payment.ID
+	}
+	var sums []struct {
+		PaymentID string
+		Amount    int64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.Refund{}).
+		Select("payment_id, SUM(amount) AS amount").
+		Where("payment_id IN ? AND status <> ?", ids, models.RefundStatusFailed).
+		Group("payment_id").
+		Scan(&sums).Error; err != nil {
+		return nil, err
+	}
+	refunds := make(map[string]int64, len(sums))
+	for _, sum := range sums {
+		refunds[sum.PaymentID] = sum.Amount
+	}
+
+	var violations []models.InvariantViolation
+	for _, payment := range payments {
+		var captured int64
+		if capturedStatuses[payment.Status] {
+			captured = payment.Amount
+		}
+		refunded := refunds[payment.ID]
+		if payment.AmountRefunded > refunded {
+			refunded = payment.AmountRefunded
+		}
+		if refunded > captured {
+			violations = append(violations, models.InvariantViolation{
+				EntityID: payment.ID,
+				Detail:   fmt.Sprintf("order %s: refunded %d paise, captured %d paise (status %s)", payment.OrderID, refunded, captured, payment.Status),
+			})
+		}
+	}
+	return violations, nil
+}
+
+// nonNegativeInventory finds products with negative inventory
+func (s *Service) nonNegativeInventory(ctx context.Context, since time.Time) ([]models.InvariantViolation, error) {
+	var products []models.Product
+	if err := s.db.WithContext(ctx).Select("id, sku, inventory").Where("inventory < 0").Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	violations := make([]models.InvariantViolation, len(products))
+	for i, product := range products {
+		violations[i] = models.InvariantViolation{
+			EntityID: product.ID,
+			Detail:   fmt.Sprintf("SKU %s has inventory %d", product.SKU, product.Inventory),
+		}
+	}
+	return violations, nil
+}
+
+// orderItemHasOrder finds order items whose order no longer exists
+func (s *Service) orderItemHasOrder(ctx context.Context, since time.Time) ([]models.InvariantViolation, error) {
+	var items []models.OrderItem
+	if err := s.db.WithContext(ctx).
+		Select("order_items.id, order_items.order_id").
+		Joins("LEFT JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.id IS NULL").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	violations := make([]models.InvariantViolation, len(items))
+	for i, item := range items {
+		violations[i] = models.InvariantViolation{
+			EntityID: item.ID,
+			Detail:   fmt.Sprintf("order %s does not exist", item.OrderID),
+		}
+	}
+	return violations, nil
+}
+
+// ListRuns returns checker runs, newest first
+func (s *Service) ListRuns(page, limit int) ([]models.InvariantRun, int64, error) {
+	var total int64
+	if err := s.db.Model(&models.InvariantRun{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var runs []models.InvariantRun
+	if err := s.db.Order("started_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
+// ListViolations returns violations, newest first, optionally of one run or invariant
+func (s *Service) ListViolations(runID, invariant string, page, limit int) ([]models.InvariantViolation, int64, error) {
+	query := s.db.Model(&models.InvariantViolation{})
+	if runID != "" {
+		query = query.Where("run_id = ?", runID)
+	}
+	if invariant != "" {
+		query = query.Where("invariant = ?", invariant)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var violations []models.InvariantViolation
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&violations).Error; err != nil {
+		return nil, 0, err
+	}
+	return violations, total, nil
+}
+
+// StartChecker runs the checks every interval until ctx is cancelled
+func (s *Service) StartChecker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run, err := s.Run(ctx)
+				if err != nil {
+					logger.Named("invariants").Warn("Failed to run invariant checks", map[string]interface{}{"error": err.Error()})
+					continue
+				}
+				logger.Named("invariants").Info("Invariant checks finished", map[string]interface{}{"run_id": run.ID, "violations": run.Violations})
+			}
+		}
+	}()
+}
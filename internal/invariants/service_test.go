@@ -0,0 +1,92 @@
+package invariants
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestService(t *testing.T) *Service {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	return NewService(database.GetDB())
+}
+
+// createOrder creates an order with one item per item total
+func createOrder(t *testing.T, subtotal float64, itemTotals ...float64) models.Order {
+	db := database.GetDB()
+	order := models.Order{UserID: "user-1", Status: "paid", Subtotal: subtotal, Total: subtotal}
+	require.NoError(t, db.Create(&order).Error)
+	for _, total := range itemTotals {
+		require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: "product-1", Quantity: 1, Price: total, Total: total}).Error)
+	}
+	return order
+}
+
+func TestService_Run(t *testing.T) {
+	service := setupTestService(t)
+	db := database.GetDB()
+
+	// Consistent records
+	good := createOrder(t, 30.30, 10.10, 20.20)
+	require.NoError(t, db.Create(&models.Payment{OrderID: good.ID, RazorpayOrderID: "rp-good", Amount: 3030, Status: models.PaymentStatusPaid, AmountRefunded: 1000}).Error)
+	require.NoError(t, db.Create(&models.Product{Name: "Lamp", SKU: "LAMP-1", Price: 10, Inventory: 0}).Error)
+
+	// Broken records
+	mismatched := createOrder(t, 50, 10)
+	over := models.Payment{OrderID: good.ID, RazorpayOrderID: "rp-over", Amount: 1000, Status: models.PaymentStatusPaid}
+	require.NoError(t, db.Create(&over).Error)
+	require.NoError(t, db.Create(&models.Refund{OrderID: good.ID, PaymentID: over.ID, Amount: 1500, Status: models.RefundStatusProcessed}).Error)
+	uncaptured := models.Payment{OrderID: good.ID, RazorpayOrderID: "rp-failed", Amount: 1000, Status: models.PaymentStatusFailed, AmountRefunded: 500}
+	require.NoError(t, db.Create(&uncaptured).Error)
+	negative := models.Product{Name: "Chair", SKU: "CHAIR-1", Price: 10}
+	require.NoError(t, db.Create(&negative).Error)
+	require.NoError(t, db.Model(&negative).UpdateColumn("inventory", -2).Error)
+	orphan := models.OrderItem{OrderID: "deleted-order", ProductID: "product-1", Quantity: 1, Price: 5, Total: 5}
+	require.NoError(t, db.Create(&orphan).Error)
+
+	run, err := service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, run.Error)
+	assert.Equal(t, int64(5), run.Violations)
+
+	violations, total, err := service.ListViolations(run.ID, "", 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	found := map[string][]string{}
+	for _, violation := range violations {
+		found[violation.Invariant] = append(found[violation.Invariant], violation.EntityID)
+	}
+	assert.Equal(t, []string{mismatched.ID}, found[models.InvariantItemsSubtotal])
+	assert.ElementsMatch(t, []string{over.ID, uncaptured.ID}, found[models.InvariantRefundWithinCaptured])
+	assert.Equal(t, []string{negative.ID}, found[models.InvariantNonNegativeInventory])
+	assert.Equal(t, []string{orphan.ID}, found[models.InvariantOrderItemHasOrder])
+
+	onlyInventory, total, err := service.ListViolations("", models.InvariantNonNegativeInventory, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, negative.ID, onlyInventory[0].EntityID)
+
+	runs, total, err := service.ListRuns(1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, run.ID, runs[0].ID)
+}
+
+func TestService_RunSkipsOldOrders(t *testing.T) {
+	service := setupTestService(t)
+	db := database.GetDB()
+
+	old := createOrder(t, 50, 10)
+	require.NoError(t, db.Model(&old).UpdateColumn("created_at", time.Now().Add(-30*24*time.Hour)).Error)
+
+	run, err := service.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), run.Violations)
+}
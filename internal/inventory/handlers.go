@@ -0,0 +1,83 @@
+package inventory
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new inventory reservation handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// ForceReleaseRequest represents the optional request body for force-releasing a reservation
+type ForceReleaseRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// GetProductReservations handles GET /api/admin/products/:id/reservations (admin only)
+func (h *Handler) GetProductReservations(c *gin.Context) {
+	reservations, err := h.service.GetProductReservations(c.Param("id"), c.Query("includeInactive") == "true")
+	if err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_RESERVATIONS_FAILED", "Failed to get reservations", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reservations retrieved successfully", reservations)
+}
+
+// ForceReleaseReservation handles POST /api/admin/products/:id/reservations/:reservationId/release (admin only)
+func (h *Handler) ForceReleaseReservation(c *gin.Context) {
+	var req ForceReleaseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+			return
+		}
+	}
+
+	adminID, _ := c.Get("user_id")
+	adminIDStr, _ := adminID.(string)
+
+	reservation, err := h.service.ForceRelease(c.Param("id"), c.Param("reservationId"), adminIDStr, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrReservationNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "RESERVATION_NOT_FOUND", "Reservation not found", nil)
+		case errors.Is(err, ErrReservationInactive):
+			utils.ErrorResponse(c, http.StatusConflict, "RESERVATION_INACTIVE", "Reservation has already been released", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "RELEASE_RESERVATION_FAILED", "Failed to release reservation", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reservation released successfully", reservation)
+}
+
+// GetLowStock handles GET /api/admin/inventory/low-stock (admin only)
+func (h *Handler) GetLowStock(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	products, total, err := h.service.LowStock(page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_LOW_STOCK_FAILED", "Failed to get low-stock products", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(c, http.StatusOK, "Low-stock products retrieved successfully", "products", products, utils.NewPagination(page, limit, total))
+}
@@ -0,0 +1,132 @@
+package inventory
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the inventory reservation service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Reserve(productID string, quantity int, holderType, holderID string, userID *string, ttl time.Duration) (*models.InventoryReservation, error) {
+	args := m.Called(productID, quantity, holderType, holderID, userID, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.InventoryReservation), args.Error(1)
+}
+
+func (m *MockService) Release(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockService) GetProductReservations(productID string, includeInactive bool) (*ProductReservations, error) {
+	args := m.Called(productID, includeInactive)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ProductReservations), args.Error(1)
+}
+
+func (m *MockService) ForceRelease(productID, reservationID, adminID, reason string) (*models.InventoryReservation, error) {
+	args := m.Called(productID, reservationID, adminID, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.InventoryReservation), args.Error(1)
+}
+
+func (m *MockService) ReleaseExpired() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockService) LowStock(page, limit int) ([]LowStockProduct, int64, error) {
+	args := m.Called(page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]LowStockProduct), args.Get(1).(int64), args.Error(2)
+}
+
+func setupTestRouter(service *MockService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(service)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Next()
+	})
+	router.GET("/api/admin/products/:id/reservations", handler.GetProductReservations)
+	router.POST("/api/admin/products/:id/reservations/:reservationId/release", handler.ForceReleaseReservation)
+	router.GET("/api/admin/inventory/low-stock", handler.GetLowStock)
+	return router
+}
+
+func TestHandler_GetProductReservations(t *testing.T) {
+	mockService := new(MockService)
+	router := setupTestRouter(mockService)
+
+	mockService.On("GetProductReservations", "p1", true).Return(&ProductReservations{ProductID: "p1", Inventory: 5, Reserved: 2, Available: 3}, nil)
+	mockService.On("GetProductReservations", "missing", false).Return(nil, ErrProductNotFound)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/products/p1/reservations?includeInactive=true", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"available":3`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/products/missing/reservations", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_ForceReleaseReservation(t *testing.T) {
+	mockService := new(MockService)
+	router := setupTestRouter(mockService)
+
+	mockService.On("ForceRelease", "p1", "r1", "admin-1", "customer abandoned checkout").Return(&models.InventoryReservation{ID: "r1", ReleaseReason: "customer abandoned checkout"}, nil)
+	mockService.On("ForceRelease", "p1", "r2", "admin-1", "").Return(nil, ErrReservationInactive)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/products/p1/reservations/r1/release", strings.NewReader(`{"reason":"customer abandoned checkout"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/products/p1/reservations/r2/release", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "RESERVATION_INACTIVE")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetLowStock(t *testing.T) {
+	mockService := new(MockService)
+	router := setupTestRouter(mockService)
+
+	mockService.On("LowStock", 2, 20).Return([]LowStockProduct{{ProductID: "p1", Name: "Phone", SKU: "PH-1", Inventory: 1, Threshold: 5}}, int64(21), nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/inventory/low-stock?page=2&limit=500", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"threshold":5`)
+	assert.Contains(t, w.Body.String(), `"total":21`)
+
+	mockService.AssertExpectations(t)
+}
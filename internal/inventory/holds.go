@@ -0,0 +1,71 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RenewHolds keeps the stock a holder reserved held for another ttl, inside tx. Only the latest reservation of
+// each product counts: an active one is extended, while one that expired or was released is placed again if the
+// unreserved stock still covers it, failing with ErrInsufficientStock otherwise. Holders without reservations
+// renew nothing.
+func RenewHolds(tx *gorm.DB, holderType, holderID string, ttl time.Duration) error {
+	var reservations []models.InventoryReservation
+	if err := tx.Where("holder_type = ? AND holder_id = ?", holderType, holderID).
+		Order("created_at DESC").Find(&reservations).Error; err != nil {
+		return fmt.Errorf("failed to get reservations: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	renewed := make(map[string]bool, len(reservations))
+	for _, reservation := range reservations {
+		if renewed[reservation.ProductID] {
+			continue
+		}
+		renewed[reservation.ProductID] = true
+
+		if reservation.IsActive(now) {
+			if reservation.ExpiresAt.Before(expiresAt) {
+				if err := tx.Model(&models.InventoryReservation{}).Where("id = ?", reservation.ID).
+					Update("expires_at", expiresAt).Error; err != nil {
+					return fmt.Errorf("failed to extend reservation: %w", err)
+				}
+			}
+			continue
+		}
+
+		// The lapsed hold may have let other holders take the stock, so it is checked like a new reservation
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", reservation.ProductID).First(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		reserved, err := reservedQuantity(tx, product.ID, now)
+		if err != nil {
+			return err
+		}
+		if product.Inventory-reserved < reservation.Quantity {
+			return fmt.Errorf("%w: %d of %s available", ErrInsufficientStock, product.Inventory-reserved, product.Name)
+		}
+		if err := tx.Create(&models.InventoryReservation{
+			ProductID:  reservation.ProductID,
+			Quantity:   reservation.Quantity,
+			HolderType: holderType,
+			HolderID:   holderID,
+			UserID:     reservation.UserID,
+			ExpiresAt:  expiresAt,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to create reservation: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package inventory
+
+import (
+	"fmt"
+
+	"ecommerce-website/internal/adminevents"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/monitoring"
+)
+
+// LowStockProduct is an active product whose inventory is at or below its low-stock threshold
+type LowStockProduct struct {
+	ProductID string `json:"productId"`
+	Name      string `json:"name"`
+	SKU       string `json:"sku"`
+	Inventory int    `json:"inventory"`
+	Threshold int    `json:"threshold"`
+}
+
+// LowStock returns the page of active products at or below their low-stock threshold, emptiest first
+func (s *Service) LowStock(page, limit int) ([]LowStockProduct, int64, error) {
+	query := s.db.Model(&models.Product{}).
+		Where("is_active = ? AND inventory <= COALESCE(low_stock_threshold, ?)", true, models.DefaultLowStockThreshold)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count low-stock products: %w", err)
+	}
+
+	var products []models.Product
+	if err := query.Order("inventory ASC, name ASC").Limit(limit).Offset((page - 1) * limit).Find(&products).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get low-stock products: %w", err)
+	}
+
+	result := make([]LowStockProduct, len(products))
+	for i := range products {
+		result[i] = LowStockProduct{
+			ProductID: products[i].ID,
+			Name:      products[i].Name,
+			SKU:       products[i].SKU,
+			Inventory: products[i].Inventory,
+			Threshold: products[i].StockThreshold(),
+		}
+	}
+	return result, total, nil
+}
+
+// CrossedLowStock reports whether inventory going from before to after takes the product down to its
+// low-stock threshold
+func CrossedLowStock(product *models.Product, before, after int) bool {
+	threshold := product.StockThreshold()
+	return before > threshold && after <= threshold
+}
+
+// AlertLowStock raises a monitoring alert and notifies admin dashboards for a product whose inventory has
+// fallen to its low-stock threshold
+func AlertLowStock(product *models.Product) {
+	adminevents.Publish(adminevents.TypeLowStock, adminevents.LowStock{
+		ProductID: product.ID,
+		Name:      product.Name,
+		SKU:       product.SKU,
+		Inventory: product.Inventory,
+		Threshold: product.StockThreshold(),
+	})
+	monitoring.CreateAlert(monitoring.AlertWarning, "Product low on stock",
+		fmt.Sprintf("%s (%s) is down to %d in stock, at or below its threshold of %d", product.Name, product.SKU, product.Inventory, product.StockThreshold()),
+		map[string]interface{}{
+			"product_id": product.ID,
+			"sku":        product.SKU,
+			"inventory":  product.Inventory,
+			"threshold":  product.StockThreshold(),
+		})
+}
@@ -0,0 +1,48 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sweepInterval is how often expired reservations are released in the background
+const sweepInterval = time.Minute
+
+// Module wires inventory reservations into the application
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the inventory module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "inventory"
+}
+
+// Models returns the reservations table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.InventoryReservation{}}
+}
+
+// StartJobs closes expired holds in the background
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartExpirySweeper(ctx, sweepInterval)
+}
+
+// RegisterAPIRoutes sets up the admin reservation routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
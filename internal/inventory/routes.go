@@ -0,0 +1,26 @@
+package inventory
+
+import (
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin inventory reservation and low-stock routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	inventory := api.Group("/admin/inventory")
+	inventory.Use(authService.AuthMiddleware())
+	inventory.Use(authService.AdminMiddleware())
+	{
+		inventory.GET("/low-stock", handler.GetLowStock)
+	}
+
+	admin := api.Group("/admin/products/:id/reservations")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.GetProductReservations)
+		admin.POST("/:reservationId/release", middleware.ReplayProtectionMiddleware(middleware.SensitiveReplayProtection), handler.ForceReleaseReservation)
+	}
+}
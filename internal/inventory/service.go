@@ -0,0 +1,252 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Holder types of reservations
+const (
+	HolderCart  = "cart"
+	HolderOrder = "order"
+)
+
+// Release reasons recorded on reservations
+const (
+	ReleaseReasonReleased = "released"
+	ReleaseReasonExpired  = "expired"
+	ReleaseReasonForced   = "force_released"
+)
+
+var (
+	ErrProductNotFound     = errors.New("product not found")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationInactive = errors.New("reservation is no longer active")
+	ErrInsufficientStock   = errors.New("insufficient unreserved stock")
+	ErrInvalidReservation  = errors.New("invalid reservation")
+)
+
+// ServiceInterface defines the interface for the inventory reservation service
+type ServiceInterface interface {
+	Reserve(productID string, quantity int, holderType, holderID string, userID *string, ttl time.Duration) (*models.InventoryReservation, error)
+	Release(id string) error
+	GetProductReservations(productID string, includeInactive bool) (*ProductReservations, error)
+	ForceRelease(productID, reservationID, adminID, reason string) (*models.InventoryReservation, error)
+	ReleaseExpired() (int64, error)
+	LowStock(page, limit int) ([]LowStockProduct, int64, error)
+}
+
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new inventory reservation service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// ProductReservations summarises the holds on a product's stock
+type ProductReservations struct {
+	ProductID    string                        `json:"productId"`
+	Inventory    int                           `json:"inventory"`
+	Reserved     int                           `json:"reserved"`
+	Available    int                           `json:"available"`
+	Reservations []models.InventoryReservation `json:"reservations"`
+}
+
+// activeScope restricts a query to reservations that still hold stock
+func activeScope(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("released_at IS NULL AND expires_at > ?", now)
+	}
+}
+
+// reservedQuantity sums the active holds on a product
+func reservedQuantity(db *gorm.DB, productID string, now time.Time) (int, error) {
+	var reserved int
+	if err := db.Model(&models.InventoryReservation{}).Scopes(activeScope(now)).
+		Where("product_id = ?", productID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&reserved).Error; err != nil {
+		return 0, fmt.Errorf("failed to sum reservations: %w", err)
+	}
+	return reserved, nil
+}
+
+// Reserve places a hold of quantity units on a product for ttl, failing if unreserved stock is insufficient
+func (s *Service) Reserve(productID string, quantity int, holderType, holderID string, userID *string, ttl time.Duration) (*models.InventoryReservation, error) {
+	if quantity <= 0 || ttl <= 0 || holderID == "" {
+		return nil, ErrInvalidReservation
+	}
+
+	var reservation models.InventoryReservation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the product row so concurrent reservations for it queue up instead of both
+		// counting the same free stock
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", productID).First(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+
+		now := time.Now()
+		reserved, err := reservedQuantity(tx, productID, now)
+		if err != nil {
+			return err
+		}
+		if product.Inventory-reserved < quantity {
+			return fmt.Errorf("%w: %d available", ErrInsufficientStock, product.Inventory-reserved)
+		}
+
+		reservation = models.InventoryReservation{
+			ProductID:  productID,
+			Quantity:   quantity,
+			HolderType: holderType,
+			HolderID:   holderID,
+			UserID:     userID,
+			ExpiresAt:  now.Add(ttl),
+		}
+		if err := tx.Create(&reservation).Error; err != nil {
+			return fmt.Errorf("failed to create reservation: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+// release marks an active reservation as released
+func (s *Service) release(reservation *models.InventoryReservation, reason string, releasedBy *string) error {
+	now := time.Now()
+	result := s.db.Model(&models.InventoryReservation{}).
+		Where("id = ? AND released_at IS NULL", reservation.ID).
+		Updates(map[string]interface{}{
+			"released_at":    now,
+			"release_reason": reason,
+			"released_by":    releasedBy,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to release reservation: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrReservationInactive
+	}
+
+	reservation.ReleasedAt = &now
+	reservation.ReleaseReason = reason
+	reservation.ReleasedBy = releasedBy
+	return nil
+}
+
+// Release ends a reservation on behalf of its holder
+func (s *Service) Release(id string) error {
+	var reservation models.InventoryReservation
+	if err := s.db.Where("id = ?", id).First(&reservation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrReservationNotFound
+		}
+		return fmt.Errorf("failed to get reservation: %w", err)
+	}
+	return s.release(&reservation, ReleaseReasonReleased, nil)
+}
+
+// GetProductReservations returns the holds on a product with their holders; inactive ones only when requested
+func (s *Service) GetProductReservations(productID string, includeInactive bool) (*ProductReservations, error) {
+	var product models.Product
+	if err := s.db.Where("id = ?", productID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	now := time.Now()
+	query := s.db.Preload("User").Where("product_id = ?", productID)
+	if !includeInactive {
+		query = query.Scopes(activeScope(now))
+	}
+
+	var reservations []models.InventoryReservation
+	if err := query.Order("expires_at ASC").Find(&reservations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reservations: %w", err)
+	}
+
+	reserved, err := reservedQuantity(s.db, productID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProductReservations{
+		ProductID:    product.ID,
+		Inventory:    product.Inventory,
+		Reserved:     reserved,
+		Available:    product.Inventory - reserved,
+		Reservations: reservations,
+	}, nil
+}
+
+// ForceRelease lets an admin release a stuck reservation of a product
+func (s *Service) ForceRelease(productID, reservationID, adminID, reason string) (*models.InventoryReservation, error) {
+	var reservation models.InventoryReservation
+	if err := s.db.Where("id = ? AND product_id = ?", reservationID, productID).First(&reservation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReservationNotFound
+		}
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	if reason == "" {
+		reason = ReleaseReasonForced
+	}
+	if err := s.release(&reservation, reason, &adminID); err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+// ReleaseExpired marks reservations past their expiry as released and returns how many were closed
+func (s *Service) ReleaseExpired() (int64, error) {
+	now := time.Now()
+	result := s.db.Model(&models.InventoryReservation{}).
+		Where("released_at IS NULL AND expires_at <= ?", now).
+		Updates(map[string]interface{}{
+			"released_at":    now,
+			"release_reason": ReleaseReasonExpired,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to release expired reservations: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// StartExpirySweeper periodically closes expired reservations until ctx is cancelled
+func (s *Service) StartExpirySweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.ReleaseExpired(); err != nil {
+					logger.Named("inventory").Warn("Failed to release expired reservations", map[string]interface{}{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,120 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTestService(t *testing.T) (*Service, *gorm.DB, *models.Product) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Category{}, &models.Product{}, &models.InventoryReservation{}))
+
+	product := &models.Product{Name: "Phone", SKU: "INV-PHONE", Price: 100, Inventory: 5, IsActive: true, CategoryID: "cat"}
+	require.NoError(t, db.Create(product).Error)
+
+	return NewService(db), db, product
+}
+
+func TestService_ReserveAndList(t *testing.T) {
+	service, db, product := setupTestService(t)
+
+	user := &models.User{Email: "holder@example.com", Password: "hashed", FirstName: "Hold", LastName: "Er"}
+	require.NoError(t, db.Create(user).Error)
+
+	_, err := service.Reserve(product.ID, 3, HolderCart, "session-1", &user.ID, time.Minute)
+	require.NoError(t, err)
+
+	_, err = service.Reserve(product.ID, 3, HolderCart, "session-2", nil, time.Minute)
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+
+	_, err = service.Reserve("missing", 1, HolderCart, "session-2", nil, time.Minute)
+	assert.ErrorIs(t, err, ErrProductNotFound)
+
+	summary, err := service.GetProductReservations(product.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Reserved)
+	assert.Equal(t, 2, summary.Available)
+	require.Len(t, summary.Reservations, 1)
+	assert.Equal(t, "holder@example.com", summary.Reservations[0].User.Email)
+}
+
+func TestService_ForceReleaseAndExpiry(t *testing.T) {
+	service, db, product := setupTestService(t)
+
+	stuck, err := service.Reserve(product.ID, 2, HolderOrder, "order-1", nil, time.Hour)
+	require.NoError(t, err)
+	expired, err := service.Reserve(product.ID, 1, HolderCart, "session-1", nil, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(expired).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	released, err := service.ForceRelease(product.ID, stuck.ID, "admin-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, ReleaseReasonForced, released.ReleaseReason)
+	assert.Equal(t, "admin-1", *released.ReleasedBy)
+
+	_, err = service.ForceRelease(product.ID, stuck.ID, "admin-1", "")
+	assert.ErrorIs(t, err, ErrReservationInactive)
+	_, err = service.ForceRelease("other-product", expired.ID, "admin-1", "")
+	assert.ErrorIs(t, err, ErrReservationNotFound)
+
+	// Expired holds no longer count even before the sweeper closes them
+	summary, err := service.GetProductReservations(product.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, summary.Reserved)
+	assert.Empty(t, summary.Reservations)
+
+	count, err := service.ReleaseExpired()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	history, err := service.GetProductReservations(product.ID, true)
+	require.NoError(t, err)
+	require.Len(t, history.Reservations, 2)
+	for _, r := range history.Reservations {
+		assert.NotNil(t, r.ReleasedAt)
+	}
+}
+
+func TestService_LowStock(t *testing.T) {
+	service, db, phone := setupTestService(t)
+	threshold := 2
+	products := []*models.Product{
+		{Name: "Cable", SKU: "INV-CABLE", Price: 5, Inventory: 1, IsActive: true, CategoryID: "cat"},
+		{Name: "Case", SKU: "INV-CASE", Price: 10, Inventory: 3, LowStockThreshold: &threshold, IsActive: true, CategoryID: "cat"},
+		{Name: "Charger", SKU: "INV-CHARGER", Price: 20, Inventory: 0, IsActive: true, CategoryID: "cat"},
+		{Name: "Dock", SKU: "INV-DOCK", Price: 30, Inventory: 0, IsActive: true, CategoryID: "cat"},
+	}
+	for _, product := range products {
+		require.NoError(t, db.Create(product).Error)
+	}
+	require.NoError(t, db.Model(products[3]).Update("is_active", false).Error)
+
+	// The phone is at the default threshold of 5; the case is above its own threshold of 2
+	low, total, err := service.LowStock(1, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	require.Len(t, low, 2)
+	assert.Equal(t, "Charger", low[0].Name, "emptiest first")
+	assert.Equal(t, "Cable", low[1].Name)
+
+	low, _, err = service.LowStock(2, 2)
+	require.NoError(t, err)
+	require.Len(t, low, 1)
+	assert.Equal(t, phone.ID, low[0].ProductID)
+	assert.Equal(t, models.DefaultLowStockThreshold, low[0].Threshold)
+
+	assert.True(t, CrossedLowStock(products[1], 3, 2))
+	assert.False(t, CrossedLowStock(products[1], 2, 1), "already below the threshold")
+	assert.False(t, CrossedLowStock(phone, 9, 6))
+}
@@ -0,0 +1,162 @@
+// Package invoices renders the customer invoice of an order as a PDF document. The layout is a text
+// template, so the wording and order of the sections can change without touching the PDF writer.
+package invoices
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/pdf"
+	"ecommerce-website/internal/settings"
+)
+
+// Company is the seller printed at the top of an invoice
+type Company struct {
+	Name    string
+	Address string // may span several lines
+	TaxID   string
+	Email   string
+}
+
+// CompanyFromSettings reads the seller details from the invoice and support email settings
+func CompanyFromSettings(ctx context.Context, reader settings.Reader) Company {
+	return Company{
+		Name:    reader.GetString(ctx, settings.KeyInvoiceCompanyName),
+		Address: reader.GetString(ctx, settings.KeyInvoiceCompanyAddress),
+		TaxID:   reader.GetString(ctx, settings.KeyInvoiceTaxID),
+		Email:   reader.GetString(ctx, settings.KeySupportEmail),
+	}
+}
+
+// Number returns the invoice number of an order, derived from the order ID so it never changes
+func Number(order *models.Order) string {
+	id := strings.ReplaceAll(order.ID, "-", "")
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return "INV-" + strings.ToUpper(id)
+}
+
+// Filename returns the file name invoices of an order are downloaded and attached as
+func Filename(order *models.Order) string {
+	return fmt.Sprintf("invoice-%s.pdf", Number(order))
+}
+
+// Render renders the invoice of an order, with its items, products and shipments loaded, as a PDF document
+func Render(order *models.Order, company Company) ([]byte, error) {
+	lines, err := Lines(order, company)
+	if err != nil {
+		return nil, err
+	}
+	return pdf.Render(lines, pdf.Letter), nil
+}
+
+// shipTo is a delivery address printed on an invoice
+type shipTo struct {
+	Label string // names the shipment when an order ships to several addresses
+	Lines []string
+}
+
+// invoiceData is the data of the invoice template
+type invoiceData struct {
+	Company Company
+	Number  string
+	Order   *models.Order
+	BillTo  []string
+	ShipTo  []shipTo
+}
+
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"money": func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+	"percent": func(rate float64) string {
+		return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", rate*100), "0"), ".") + "%"
+	},
+	"date": func(t time.Time) string { return t.Format("January 2, 2006") },
+}).Parse(invoiceLayout))
+
+// invoiceLayout is the text of an invoice, one PDF line per template line
+const invoiceLayout = `{{with .Company}}{{if or .Name .Address .Email .TaxID}}{{if .Name}}{{.Name}}
+{{end}}{{if .Address}}{{.Address}}
+{{end}}{{if .Email}}{{.Email}}
+{{end}}{{if .TaxID}}Tax ID: {{.TaxID}}
+{{end}}
+{{end}}{{end}}INVOICE
+
+Invoice Number: {{.Number}}
+Order: #{{.Order.ID}}
+Invoice Date: {{date .Order.CreatedAt}}
+
+Bill To:
+{{range .BillTo}}  {{.}}
+{{end}}{{range .ShipTo}}
+Ship To{{if .Label}} ({{.Label}}){{end}}:
+{{range .Lines}}  {{.}}
+{{end}}{{end}}
+Items:
+{{range .Order.Items}}  {{.Product.Name}} (SKU: {{.Product.SKU}})  Qty: {{.Quantity}}  @ {{money .Price}} = {{money .Total}}
+{{end}}
+Subtotal: {{money .Order.Subtotal}}
+{{if .Order.Discount}}Discount: -{{money .Order.Discount}}
+{{end}}{{range .Order.TaxBreakdown}}{{.Name}}{{if .Jurisdiction}} ({{.Jurisdiction}}){{end}} {{percent .Rate}}: {{money .Amount}}
+{{else}}Tax: {{money .Order.Tax}}
+{{end}}Shipping{{with .Order.ShippingMethodName}} ({{.}}){{end}}: {{money .Order.Shipping}}
+Total: {{money .Order.Total}}`
+
+// Lines lays out the text content of an invoice
+func Lines(order *models.Order, company Company) ([]string, error) {
+	data := invoiceData{
+		Company: company,
+		Number:  Number(order),
+		Order:   order,
+		BillTo:  addressLines(order.BillingAddress),
+		ShipTo:  shipToAddresses(order),
+	}
+
+	var text bytes.Buffer
+	if err := invoiceTemplate.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render invoice: %w", err)
+	}
+	return strings.Split(strings.ReplaceAll(text.String(), "\r\n", "\n"), "\n"), nil
+}
+
+// shipToAddresses returns the delivery addresses of an order: one per shipment group when it ships to
+// several addresses, or else its shipping address. Parcels of a group share its address.
+func shipToAddresses(order *models.Order) []shipTo {
+	var groups []models.Shipment
+	seen := make(map[string]bool, len(order.Shipments))
+	for _, shipment := range order.Shipments {
+		if !seen[shipment.GroupKey] {
+			seen[shipment.GroupKey] = true
+			groups = append(groups, shipment)
+		}
+	}
+	if len(groups) < 2 {
+		return []shipTo{{Lines: addressLines(order.ShippingAddress)}}
+	}
+	addresses := make([]shipTo, len(groups))
+	for i, shipment := range groups {
+		addresses[i] = shipTo{Label: fmt.Sprintf("shipment %d of %d", i+1, len(groups)), Lines: addressLines(shipment.Address)}
+	}
+	return addresses
+}
+
+// addressLines returns the printed lines of an address
+func addressLines(addr models.OrderAddress) []string {
+	lines := []string{strings.TrimSpace(addr.FirstName + " " + addr.LastName)}
+	if addr.Company != nil && *addr.Company != "" {
+		lines = append(lines, *addr.Company)
+	}
+	lines = append(lines, addr.Address1)
+	if addr.Address2 != nil && *addr.Address2 != "" {
+		lines = append(lines, *addr.Address2)
+	}
+	return append(lines,
+		fmt.Sprintf("%s, %s %s", addr.City, addr.State, addr.PostalCode),
+		addr.Country,
+	)
+}
@@ -0,0 +1,104 @@
+package invoices
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSettings map[string]string
+
+func (f fakeSettings) GetString(ctx context.Context, key string) string { return f[key] }
+func (f fakeSettings) GetInt(ctx context.Context, key string) int64     { return 0 }
+func (f fakeSettings) GetFloat(ctx context.Context, key string) float64 { return 0 }
+func (f fakeSettings) GetBool(ctx context.Context, key string) bool     { return false }
+
+func invoiceTestOrder() *models.Order {
+	method := "Express"
+	return &models.Order{
+		ID:        "3f2a9c1e-77b0-4d1a-9e2f-0c5d8e6a1b2c",
+		Subtotal:  120,
+		Tax:       21.6,
+		Shipping:  40,
+		Total:     181.6,
+		CreatedAt: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		TaxBreakdown: models.TaxBreakdown{
+			{Name: "CGST", Jurisdiction: "IN-MH", Rate: 0.09, Amount: 10.8},
+			{Name: "SGST", Jurisdiction: "IN-MH", Rate: 0.09, Amount: 10.8},
+		},
+		ShippingMethodName: &method,
+		BillingAddress: models.OrderAddress{
+			FirstName: "Sam", LastName: "Rao", Address1: "9 Hill Rd",
+			City: "Mumbai", State: "MH", PostalCode: "400001", Country: "IN",
+		},
+		ShippingAddress: models.OrderAddress{
+			FirstName: "Ana", LastName: "Lee", Address1: "1 Main St",
+			City: "Pune", State: "MH", PostalCode: "411001", Country: "IN",
+		},
+		Items: []models.OrderItem{
+			{Product: models.Product{Name: "Mug", SKU: "MUG-1"}, Quantity: 2, Price: 60, Total: 120},
+		},
+	}
+}
+
+func TestLines(t *testing.T) {
+	company := CompanyFromSettings(context.Background(), fakeSettings{
+		settings.KeyInvoiceCompanyName:    "Kiro Stores",
+		settings.KeyInvoiceCompanyAddress: "12 Market St\nPune 411001",
+		settings.KeyInvoiceTaxID:          "27AAAAA0000A1Z5",
+		settings.KeySupportEmail:          "help@kiro.test",
+	})
+
+	order := invoiceTestOrder()
+	lines, err := Lines(order, company)
+	require.NoError(t, err)
+	text := strings.Join(lines, "\n")
+
+	assert.Equal(t, "Kiro Stores", lines[0])
+	assert.Contains(t, text, "12 Market St\nPune 411001\nhelp@kiro.test\nTax ID: 27AAAAA0000A1Z5")
+	assert.Contains(t, text, "Invoice Number: INV-3F2A9C1E77B0")
+	assert.Contains(t, text, "Invoice Date: March 10, 2025")
+	assert.Contains(t, text, "Bill To:\n  Sam Rao\n  9 Hill Rd\n  Mumbai, MH 400001\n  IN")
+	assert.Contains(t, text, "Ship To:\n  Ana Lee\n  1 Main St\n  Pune, MH 411001\n  IN")
+	assert.Contains(t, text, "  Mug (SKU: MUG-1)  Qty: 2  @ 60.00 = 120.00")
+	assert.Contains(t, text, "Subtotal: 120.00\nCGST (IN-MH) 9%: 10.80\nSGST (IN-MH) 9%: 10.80\nShipping (Express): 40.00\nTotal: 181.60")
+	assert.NotContains(t, text, "Tax: 21.60", "the breakdown replaces the single tax line")
+
+	order.TaxBreakdown = nil
+	order.ShippingMethodName = nil
+	order.Shipments = []models.Shipment{
+		{GroupKey: "default", Address: order.ShippingAddress},
+		{GroupKey: "office", Address: order.BillingAddress},
+		{GroupKey: "default", Address: order.ShippingAddress}, // a parcel shipped ahead of its group
+	}
+	lines, err = Lines(order, Company{})
+	require.NoError(t, err)
+	text = strings.Join(lines, "\n")
+
+	assert.Equal(t, "INVOICE", lines[0], "the company header is left out when no details are set")
+	assert.Contains(t, text, "Tax: 21.60\nShipping: 40.00")
+	assert.Contains(t, text, "Ship To (shipment 1 of 2):\n  Ana Lee")
+	assert.Contains(t, text, "Ship To (shipment 2 of 2):\n  Sam Rao")
+	assert.NotContains(t, text, "Discount")
+
+	order.Discount = 20
+	lines, err = Lines(order, Company{})
+	require.NoError(t, err)
+	assert.Contains(t, strings.Join(lines, "\n"), "Subtotal: 120.00\nDiscount: -20.00\nTax: 21.60")
+}
+
+func TestRender(t *testing.T) {
+	order := invoiceTestOrder()
+	doc, err := Render(order, Company{Name: "Kiro Stores"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(doc), "%PDF-1.4"))
+	assert.Contains(t, string(doc), "(Kiro Stores) Tj")
+	assert.Equal(t, "invoice-INV-3F2A9C1E77B0.pdf", Filename(order))
+}
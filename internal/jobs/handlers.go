@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceInterface defines the queue operations used by the admin handlers
+type ServiceInterface interface {
+	Stats(ctx context.Context) (*Stats, error)
+	DeadJobs(ctx context.Context, limit int) ([]Job, error)
+	RetryDead(ctx context.Context, id string) (*Job, error)
+	DiscardDead(ctx context.Context, id string) error
+}
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new job queue handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// GetStats handles GET /api/admin/jobs (admin only)
+func (h *Handler) GetStats(c *gin.Context) {
+	stats, err := h.service.Stats(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "JOB_QUEUE_UNAVAILABLE", "Job queue is unavailable", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Job queue stats retrieved successfully", stats)
+}
+
+// ListDead handles GET /api/admin/jobs/dead (admin only)
+func (h *Handler) ListDead(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	jobs, err := h.service.DeadJobs(c.Request.Context(), limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "JOB_QUEUE_UNAVAILABLE", "Job queue is unavailable", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Dead jobs retrieved successfully", jobs)
+}
+
+// RetryDead handles POST /api/admin/jobs/dead/:id/retry (admin only)
+func (h *Handler) RetryDead(c *gin.Context) {
+	job, err := h.service.RetryDead(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "JOB_NOT_FOUND", "Dead job not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "JOB_QUEUE_UNAVAILABLE", "Job queue is unavailable", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusAccepted, "Job queued for retry", job)
+}
+
+// DiscardDead handles DELETE /api/admin/jobs/dead/:id (admin only)
+func (h *Handler) DiscardDead(c *gin.Context) {
+	if err := h.service.DiscardDead(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "JOB_NOT_FOUND", "Dead job not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "JOB_QUEUE_UNAVAILABLE", "Job queue is unavailable", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Dead job discarded", nil)
+}
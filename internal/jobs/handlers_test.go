@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_DeadJobs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	queue, _ := newTestQueue()
+	ctx := context.Background()
+	queue.Register("index", func(ctx context.Context, payload json.RawMessage) error { return errors.New("timeout") })
+	job, err := queue.Enqueue(ctx, "index", nil)
+	require.NoError(t, err)
+	job.Attempts = DefaultMaxAttempts
+	require.NoError(t, queue.store.bury(ctx, *job))
+
+	handler := NewHandler(queue)
+	r := gin.New()
+	r.GET("/jobs", handler.GetStats)
+	r.GET("/jobs/dead", handler.ListDead)
+	r.POST("/jobs/dead/:id/retry", handler.RetryDead)
+	r.DELETE("/jobs/dead/:id", handler.DiscardDead)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"dead":1`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/dead", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), job.ID)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs/dead/"+job.ID+"/retry", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"attempts":0`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/jobs/dead/"+job.ID, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "JOB_NOT_FOUND")
+}
@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"context"
+
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module runs the job queue workers and serves the queue admin routes. The queue itself is one of the
+// shared dependencies so other modules can enqueue jobs; this package therefore cannot import app,
+// and the module is registered through a factory in main.
+type Module struct {
+	queue       *Queue
+	handler     *Handler
+	authService *auth.Service
+	workers     int
+}
+
+// NewModule creates the jobs module running workers workers
+func NewModule(queue *Queue, authService *auth.Service, workers int) *Module {
+	return &Module{queue: queue, handler: NewHandler(queue), authService: authService, workers: workers}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "jobs"
+}
+
+// StartJobs starts the queue workers; modules register their job handlers when they are created,
+// before any job starts
+func (m *Module) StartJobs(ctx context.Context) {
+	if err := m.queue.Start(ctx, m.workers); err != nil {
+		logger.Named("jobs").Warn("Job workers not started", map[string]interface{}{"workers": m.workers, "error": err.Error()})
+	}
+}
+
+// Stop waits for the workers to finish the jobs they were running when the job context was cancelled
+func (m *Module) Stop(ctx context.Context) error {
+	return m.queue.Wait(ctx)
+}
+
+// HealthCheck reports whether the job queue store is reachable
+func (m *Module) HealthCheck(ctx context.Context) error {
+	return m.queue.Ping(ctx)
+}
+
+// RegisterAPIRoutes sets up the queue admin routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
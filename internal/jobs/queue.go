@@ -0,0 +1,243 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultMaxAttempts is how often a job runs before it is moved to the dead-letter queue
+	DefaultMaxAttempts = 5
+	// jobTimeout bounds a single run of a job
+	jobTimeout = time.Minute
+	// pollWait is how long an idle worker waits for a job before checking for due retries again
+	pollWait = time.Second
+	// baseBackoff is the delay before the first retry; each further retry doubles it up to maxBackoff
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+)
+
+var (
+	ErrJobNotFound   = errors.New("job not found")
+	ErrUnknownType   = errors.New("no run function registered for job type")
+	ErrInvalidWorker = errors.New("worker count must be positive")
+)
+
+// Job is a unit of background work with its JSON payload and delivery state
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	EnqueuedAt  time.Time       `json:"enqueuedAt"`
+	LastError   string          `json:"lastError,omitempty"`
+	FailedAt    *time.Time      `json:"failedAt,omitempty"`
+}
+
+// RunFunc runs a job from its payload. A returned error retries the job with backoff.
+type RunFunc func(ctx context.Context, payload json.RawMessage) error
+
+// Stats counts the jobs in each state of the queue
+type Stats struct {
+	Ready   int64 `json:"ready"`
+	Delayed int64 `json:"delayed"`
+	Dead    int64 `json:"dead"`
+}
+
+// Queue runs jobs in the background with retries and a dead-letter queue. Jobs are kept in Redis so
+// every instance shares them; a job is delivered at most once per attempt, and one that is running
+// when its process dies is not retried.
+type Queue struct {
+	store   store
+	mu      sync.RWMutex
+	runs    map[string]RunFunc
+	now     func() time.Time
+	workers sync.WaitGroup
+}
+
+// NewQueue creates a queue kept in Redis. Without a client, jobs are kept in memory and only run by
+// this process, which is meant for tests and local development.
+func NewQueue(client *redis.Client) *Queue {
+	var s store = newMemoryStore()
+	if client != nil {
+		s = newRedisStore(client)
+	}
+	return &Queue{store: s, runs: make(map[string]RunFunc), now: time.Now}
+}
+
+// Register sets how jobs of a type run. Registering a type again replaces its run function.
+func (q *Queue) Register(jobType string, run RunFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.runs[jobType] = run
+}
+
+func (q *Queue) runFunc(jobType string) (RunFunc, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	run, ok := q.runs[jobType]
+	return run, ok
+}
+
+// Enqueue adds a job with the JSON encoding of payload
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s job: %w", jobType, err)
+	}
+	job := Job{
+		ID:          uuid.New().String(),
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: DefaultMaxAttempts,
+		EnqueuedAt:  q.now(),
+	}
+	if err := q.store.push(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to enqueue %s job: %w", jobType, err)
+	}
+	return &job, nil
+}
+
+// Start runs workers goroutines that process jobs until ctx is cancelled. Workers stop taking jobs once
+// ctx is cancelled but finish the ones they are running; Wait returns when they have.
+func (q *Queue) Start(ctx context.Context, workers int) error {
+	if workers < 1 {
+		return ErrInvalidWorker
+	}
+	for i := 0; i < workers; i++ {
+		q.workers.Add(1)
+		go func() {
+			defer q.workers.Done()
+			for ctx.Err() == nil {
+				if _, err := q.ProcessNext(ctx, pollWait); err != nil && ctx.Err() == nil {
+					logger.Named("jobs").Warn("Job queue unavailable", map[string]interface{}{"error": err.Error()})
+					sleep(ctx, pollWait)
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// Wait blocks until the workers have stopped after their context was cancelled, or until ctx expires
+func (q *Queue) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("job workers still running: %w", ctx.Err())
+	}
+}
+
+// ProcessNext moves due retries back to the queue and runs the next job, waiting up to wait for one.
+// It reports whether a job ran; job failures are handled by retrying or burying the job, so the
+// returned error only reports queue problems. Once taken, a job runs and is retried or buried even if ctx
+// is cancelled meanwhile, so stopping a worker does not lose the job it holds.
+func (q *Queue) ProcessNext(ctx context.Context, wait time.Duration) (bool, error) {
+	if err := q.store.promote(ctx, q.now()); err != nil {
+		return false, err
+	}
+	job, err := q.store.pop(ctx, wait)
+	if err != nil || job == nil {
+		return false, err
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	job.Attempts++
+	runErr := q.run(ctx, job)
+	if runErr == nil {
+		return true, nil
+	}
+
+	job.LastError = runErr.Error()
+	fields := map[string]interface{}{"job_id": job.ID, "type": job.Type, "attempt": job.Attempts, "error": runErr.Error()}
+	if job.Attempts >= job.MaxAttempts || errors.Is(runErr, ErrUnknownType) {
+		failedAt := q.now()
+		job.FailedAt = &failedAt
+		logger.Named("jobs").Error("Job moved to the dead-letter queue", runErr, fields)
+		return true, q.store.bury(ctx, *job)
+	}
+
+	retryAt := q.now().Add(utils.Backoff(job.Attempts, baseBackoff, maxBackoff))
+	fields["retry_at"] = retryAt
+	logger.Named("jobs").Warn("Job failed, retrying", fields)
+	return true, q.store.schedule(ctx, *job, retryAt)
+}
+
+// run calls the job's run function, turning a panic into an error so one bad job cannot stop a worker
+func (q *Queue) run(ctx context.Context, job *Job) (err error) {
+	runJob, ok := q.runFunc(job.Type)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, job.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+	return runJob(ctx, job.Payload)
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// Stats counts the jobs waiting, waiting for a retry and dead
+func (q *Queue) Stats(ctx context.Context) (*Stats, error) {
+	return q.store.stats(ctx)
+}
+
+// DeadJobs returns up to limit dead jobs, most recently failed first
+func (q *Queue) DeadJobs(ctx context.Context, limit int) ([]Job, error) {
+	return q.store.dead(ctx, limit)
+}
+
+// RetryDead moves a dead job back to the queue with a fresh set of attempts
+func (q *Queue) RetryDead(ctx context.Context, id string) (*Job, error) {
+	job, err := q.store.unbury(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	job.Attempts = 0
+	job.FailedAt = nil
+	if err := q.store.push(ctx, *job); err != nil {
+		return nil, fmt.Errorf("failed to requeue job: %w", err)
+	}
+	return job, nil
+}
+
+// DiscardDead deletes a dead job for good
+func (q *Queue) DiscardDead(ctx context.Context, id string) error {
+	_, err := q.store.unbury(ctx, id)
+	return err
+}
+
+// Ping checks that the queue store is reachable
+func (q *Queue) Ping(ctx context.Context) error {
+	return q.store.ping(ctx)
+}
@@ -0,0 +1,206 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestQueue returns an in-memory queue whose clock the test moves
+func newTestQueue() (*Queue, *time.Time) {
+	queue := NewQueue(nil)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	queue.now = func() time.Time { return now }
+	return queue, &now
+}
+
+func TestQueue_RunsJobs(t *testing.T) {
+	queue, _ := newTestQueue()
+	ctx := context.Background()
+
+	var got []string
+	queue.Register("greet", func(ctx context.Context, payload json.RawMessage) error {
+		var data struct{ Name string }
+		require.NoError(t, json.Unmarshal(payload, &data))
+		got = append(got, data.Name)
+		return nil
+	})
+
+	_, err := queue.Enqueue(ctx, "greet", map[string]string{"name": "asha"})
+	require.NoError(t, err)
+	_, err = queue.Enqueue(ctx, "greet", map[string]string{"name": "ravi"})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		ran, err := queue.ProcessNext(ctx, 0)
+		require.NoError(t, err)
+		assert.True(t, ran)
+	}
+	assert.Equal(t, []string{"asha", "ravi"}, got, "jobs run in the order they were queued")
+
+	ran, err := queue.ProcessNext(ctx, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.False(t, ran)
+}
+
+func TestQueue_RetriesAndDeadLetters(t *testing.T) {
+	queue, now := newTestQueue()
+	ctx := context.Background()
+
+	attempts := 0
+	queue.Register("flaky", func(ctx context.Context, payload json.RawMessage) error {
+		attempts++
+		return errors.New("smtp unavailable")
+	})
+	job, err := queue.Enqueue(ctx, "flaky", nil)
+	require.NoError(t, err)
+
+	_, err = queue.ProcessNext(ctx, 0)
+	require.NoError(t, err)
+	stats, err := queue.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Delayed: 1}, *stats)
+
+	// The retry is not due before its backoff passed
+	*now = now.Add(baseBackoff - time.Second)
+	ran, err := queue.ProcessNext(ctx, 0)
+	require.NoError(t, err)
+	assert.False(t, ran)
+
+	for attempts < DefaultMaxAttempts {
+		*now = now.Add(maxBackoff)
+		ran, err := queue.ProcessNext(ctx, 0)
+		require.NoError(t, err)
+		require.True(t, ran)
+	}
+
+	dead, err := queue.DeadJobs(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, job.ID, dead[0].ID)
+	assert.Equal(t, DefaultMaxAttempts, dead[0].Attempts)
+	assert.Equal(t, "smtp unavailable", dead[0].LastError)
+	require.NotNil(t, dead[0].FailedAt)
+
+	retried, err := queue.RetryDead(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Zero(t, retried.Attempts)
+	stats, err = queue.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Ready: 1}, *stats)
+
+	_, err = queue.RetryDead(ctx, job.ID)
+	assert.ErrorIs(t, err, ErrJobNotFound)
+	assert.ErrorIs(t, queue.DiscardDead(ctx, "missing"), ErrJobNotFound)
+}
+
+func TestQueue_UnknownTypesAndPanics(t *testing.T) {
+	queue, _ := newTestQueue()
+	ctx := context.Background()
+
+	queue.Register("boom", func(ctx context.Context, payload json.RawMessage) error { panic("nil order") })
+	_, err := queue.Enqueue(ctx, "unknown", nil)
+	require.NoError(t, err)
+	_, err = queue.Enqueue(ctx, "boom", nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := queue.ProcessNext(ctx, 0)
+		require.NoError(t, err)
+	}
+
+	dead, err := queue.DeadJobs(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1, "jobs without a run function are dead right away")
+	assert.Equal(t, "unknown", dead[0].Type)
+
+	stats, err := queue.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Delayed, "a panicking job is retried like a failed one")
+}
+
+func TestQueue_StopDrainsRunningJobs(t *testing.T) {
+	queue, _ := newTestQueue()
+	ctx, stop := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var jobErr error
+	queue.Register("export", func(ctx context.Context, payload json.RawMessage) error {
+		close(started)
+		<-release
+		jobErr = ctx.Err()
+		return nil
+	})
+	_, err := queue.Enqueue(ctx, "export", nil)
+	require.NoError(t, err)
+	require.NoError(t, queue.Start(ctx, 1))
+	<-started
+
+	// The running job keeps the worker busy past the cancellation and past a short wait
+	stop()
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, queue.Wait(short))
+
+	close(release)
+	require.NoError(t, queue.Wait(context.Background()))
+	assert.NoError(t, jobErr, "a job taken before the stop runs with a live context")
+	stats, err := queue.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Stats{}, *stats)
+}
+
+func TestQueue_Redis(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 1})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skip("Redis is not available, skipping Redis-dependent tests")
+	}
+	defer client.Close()
+	ctx := context.Background()
+	require.NoError(t, client.Del(ctx, readyKey, delayedKey, deadKey).Err())
+	defer client.Del(ctx, readyKey, delayedKey, deadKey)
+
+	queue := NewQueue(client)
+	now := time.Now()
+	queue.now = func() time.Time { return now }
+	fail := true
+	queue.Register("sync", func(ctx context.Context, payload json.RawMessage) error {
+		if fail {
+			return errors.New("search unavailable")
+		}
+		return nil
+	})
+
+	job, err := queue.Enqueue(ctx, "sync", map[string]string{"productId": "p1"})
+	require.NoError(t, err)
+	ran, err := queue.ProcessNext(ctx, time.Second)
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	stats, err := queue.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Delayed: 1}, *stats)
+
+	fail = false
+	now = now.Add(baseBackoff)
+	ran, err = queue.ProcessNext(ctx, time.Second)
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	stats, err = queue.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{}, *stats)
+
+	require.NoError(t, queue.store.bury(ctx, *job))
+	dead, err := queue.DeadJobs(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	require.NoError(t, queue.DiscardDead(ctx, job.ID))
+}
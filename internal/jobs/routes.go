@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin routes to inspect the job queue and handle dead jobs
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin/jobs")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.GetStats)
+		admin.GET("/dead", handler.ListDead)
+		admin.POST("/dead/:id/retry", handler.RetryDead)
+		admin.DELETE("/dead/:id", handler.DiscardDead)
+	}
+}
@@ -0,0 +1,296 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys of the queue: a list of ready jobs, a sorted set of retries scored by when they are
+// due, and a hash of dead jobs by ID
+const (
+	readyKey   = "jobs:ready"
+	delayedKey = "jobs:delayed"
+	deadKey    = "jobs:dead"
+)
+
+// store keeps the jobs of a queue
+type store interface {
+	push(ctx context.Context, job Job) error
+	// pop takes the next ready job, waiting up to wait; it returns nil when there is none
+	pop(ctx context.Context, wait time.Duration) (*Job, error)
+	schedule(ctx context.Context, job Job, at time.Time) error
+	// promote moves retries due at now to the ready jobs
+	promote(ctx context.Context, now time.Time) error
+	bury(ctx context.Context, job Job) error
+	dead(ctx context.Context, limit int) ([]Job, error)
+	// unbury removes a dead job and returns it
+	unbury(ctx context.Context, id string) (*Job, error)
+	stats(ctx context.Context) (*Stats, error)
+	ping(ctx context.Context) error
+}
+
+// redisStore keeps jobs in Redis
+type redisStore struct {
+	client *redis.Client
+}
+
+func newRedisStore(client *redis.Client) *redisStore {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) push(ctx context.Context, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.client.LPush(ctx, readyKey, data).Err()
+}
+
+func (s *redisStore) pop(ctx context.Context, wait time.Duration) (*Job, error) {
+	result, err := s.client.BRPop(ctx, wait, readyKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(result[1])
+}
+
+func (s *redisStore) schedule(ctx context.Context, job Job, at time.Time) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.client.ZAdd(ctx, delayedKey, redis.Z{Score: float64(at.UnixMilli()), Member: string(data)}).Err()
+}
+
+func (s *redisStore) promote(ctx context.Context, now time.Time) error {
+	due, err := s.client.ZRangeByScore(ctx, delayedKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return err
+	}
+	for _, member := range due {
+		// Only the instance that removes the retry requeues it
+		removed, err := s.client.ZRem(ctx, delayedKey, member).Result()
+		if err != nil {
+			return err
+		}
+		if removed == 1 {
+			if err := s.client.LPush(ctx, readyKey, member).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *redisStore) bury(ctx context.Context, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, deadKey, job.ID, data).Err()
+}
+
+func (s *redisStore) dead(ctx context.Context, limit int) ([]Job, error) {
+	values, err := s.client.HVals(ctx, deadKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(values))
+	for _, value := range values {
+		job, err := decodeJob(value)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return newestFailed(jobs, limit), nil
+}
+
+func (s *redisStore) unbury(ctx context.Context, id string) (*Job, error) {
+	value, err := s.client.HGet(ctx, deadKey, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	removed, err := s.client.HDel(ctx, deadKey, id).Result()
+	if err != nil {
+		return nil, err
+	}
+	if removed == 0 {
+		return nil, ErrJobNotFound // taken by a concurrent retry
+	}
+	return decodeJob(value)
+}
+
+func (s *redisStore) stats(ctx context.Context) (*Stats, error) {
+	pipe := s.client.Pipeline()
+	ready := pipe.LLen(ctx, readyKey)
+	delayed := pipe.ZCard(ctx, delayedKey)
+	dead := pipe.HLen(ctx, deadKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return &Stats{Ready: ready.Val(), Delayed: delayed.Val(), Dead: dead.Val()}, nil
+}
+
+func (s *redisStore) ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+func decodeJob(data string) (*Job, error) {
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+	return &job, nil
+}
+
+// newestFailed sorts dead jobs most recently failed first and keeps limit of them
+func newestFailed(jobs []Job, limit int) []Job {
+	sort.Slice(jobs, func(i, j int) bool {
+		a, b := jobs[i].FailedAt, jobs[j].FailedAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.After(*b)
+	})
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs
+}
+
+// memoryStore keeps jobs in this process
+type memoryStore struct {
+	mu      sync.Mutex
+	ready   []Job
+	delayed map[string]delayedJob
+	buried  map[string]Job
+	notify  chan struct{}
+}
+
+type delayedJob struct {
+	job Job
+	at  time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{delayed: make(map[string]delayedJob), buried: make(map[string]Job), notify: make(chan struct{}, 1)}
+}
+
+func (s *memoryStore) push(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	s.ready = append(s.ready, job)
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *memoryStore) pop(ctx context.Context, wait time.Duration) (*Job, error) {
+	if job := s.take(); job != nil {
+		return job, nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	case <-timer.C:
+		return nil, nil
+	case <-s.notify:
+		return s.take(), nil
+	}
+}
+
+func (s *memoryStore) take() *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.ready) == 0 {
+		return nil
+	}
+	job := s.ready[0]
+	s.ready = s.ready[1:]
+	return &job
+}
+
+func (s *memoryStore) schedule(ctx context.Context, job Job, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delayed[job.ID] = delayedJob{job: job, at: at}
+	return nil
+}
+
+func (s *memoryStore) promote(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	var due []Job
+	for id, delayed := range s.delayed {
+		if !delayed.at.After(now) {
+			due = append(due, delayed.job)
+			delete(s.delayed, id)
+		}
+	}
+	s.mu.Unlock()
+	for _, job := range due {
+		if err := s.push(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) bury(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buried[job.ID] = job
+	return nil
+}
+
+func (s *memoryStore) dead(ctx context.Context, limit int) ([]Job, error) {
+	s.mu.Lock()
+	jobs := make([]Job, 0, len(s.buried))
+	for _, job := range s.buried {
+		jobs = append(jobs, job)
+	}
+	s.mu.Unlock()
+	return newestFailed(jobs, limit), nil
+}
+
+func (s *memoryStore) unbury(ctx context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.buried[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	delete(s.buried, id)
+	return &job, nil
+}
+
+func (s *memoryStore) stats(ctx context.Context) (*Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &Stats{Ready: int64(len(s.ready)), Delayed: int64(len(s.delayed)), Dead: int64(len(s.buried))}, nil
+}
+
+func (s *memoryStore) ping(ctx context.Context) error {
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,6 +31,7 @@ type LogEntry struct {
 	Level      LogLevel               `json:"level"`
 	Message    string                 `json:"message"`
 	Service    string                 `json:"service"`
+	Module     string                 `json:"module,omitempty"`
 	RequestID  string                 `json:"request_id,omitempty"`
 	UserID     string                 `json:"user_id,omitempty"`
 	Method     string                 `json:"method,omitempty"`
@@ -47,6 +50,8 @@ type Logger struct {
 	output      io.Writer
 	level       LogLevel
 	serviceName string
+	module      string
+	modules     *moduleRegistry
 }
 
 // Config holds logger configuration
@@ -54,6 +59,69 @@ type Config struct {
 	Level       LogLevel
 	ServiceName string
 	Output      io.Writer
+	// ModuleLevels overrides the level for individual modules (e.g. search=DEBUG)
+	ModuleLevels map[string]LogLevel
+	// DebugSampleRate keeps one of every N debug entries per module; 0 or 1 keeps all
+	DebugSampleRate int
+}
+
+// ModuleSettings describes the runtime log settings of a module
+type ModuleSettings struct {
+	Module     string   `json:"module"`
+	Level      LogLevel `json:"level"`
+	SampleRate int      `json:"sampleRate"`
+}
+
+// moduleRegistry holds per-module levels and debug sampling state shared by all module loggers
+type moduleRegistry struct {
+	mu          sync.RWMutex
+	levels      map[string]LogLevel
+	sampleRates map[string]int
+	defaultRate int
+	counters    map[string]uint64
+}
+
+func newModuleRegistry(levels map[string]LogLevel, defaultRate int) *moduleRegistry {
+	r := &moduleRegistry{
+		levels:      make(map[string]LogLevel),
+		sampleRates: make(map[string]int),
+		defaultRate: defaultRate,
+		counters:    make(map[string]uint64),
+	}
+	for module, level := range levels {
+		r.levels[strings.ToLower(module)] = level
+	}
+	return r
+}
+
+// levelFor returns the module's level override, if any
+func (r *moduleRegistry) levelFor(module string) (LogLevel, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	level, ok := r.levels[module]
+	return level, ok
+}
+
+// sampleRateFor returns the effective debug sample rate for a module
+func (r *moduleRegistry) sampleRateFor(module string) int {
+	if rate, ok := r.sampleRates[module]; ok {
+		return rate
+	}
+	return r.defaultRate
+}
+
+// sample reports whether the next debug entry for the module should be written
+func (r *moduleRegistry) sample(module string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rate := r.sampleRateFor(module)
+	if rate <= 1 {
+		return true
+	}
+	count := r.counters[module]
+	r.counters[module] = count + 1
+	return count%uint64(rate) == 0
 }
 
 var defaultLogger *Logger
@@ -74,7 +142,116 @@ func Initialize(config Config) {
 		output:      config.Output,
 		level:       config.Level,
 		serviceName: config.ServiceName,
+		modules:     newModuleRegistry(config.ModuleLevels, config.DebugSampleRate),
+	}
+}
+
+// Module returns a logger whose entries are tagged with the module name and
+// filtered by that module's runtime level
+func (l *Logger) Module(name string) *Logger {
+	return &Logger{
+		output:      l.output,
+		level:       l.level,
+		serviceName: l.serviceName,
+		module:      strings.ToLower(name),
+		modules:     l.modules,
+	}
+}
+
+// Named returns the module logger of the default logger. Packages call it when they log rather
+// than keeping the result, so their entries follow the logger set up by Initialize at startup.
+func Named(module string) *Logger {
+	return GetLogger().Module(module)
+}
+
+// SetModuleLevel changes the level of a module at runtime
+func (l *Logger) SetModuleLevel(module string, level LogLevel) error {
+	if !IsValidLevel(level) {
+		return fmt.Errorf("invalid log level: %s", level)
+	}
+	l.modules.mu.Lock()
+	defer l.modules.mu.Unlock()
+	l.modules.levels[strings.ToLower(module)] = level
+	return nil
+}
+
+// SetModuleSampleRate changes how many debug entries of a module are kept (one of every rate)
+func (l *Logger) SetModuleSampleRate(module string, rate int) error {
+	if rate < 0 {
+		return fmt.Errorf("invalid sample rate: %d", rate)
+	}
+	l.modules.mu.Lock()
+	defer l.modules.mu.Unlock()
+	l.modules.sampleRates[strings.ToLower(module)] = rate
+	return nil
+}
+
+// ResetModule removes the runtime overrides of a module
+func (l *Logger) ResetModule(module string) {
+	module = strings.ToLower(module)
+	l.modules.mu.Lock()
+	defer l.modules.mu.Unlock()
+	delete(l.modules.levels, module)
+	delete(l.modules.sampleRates, module)
+	delete(l.modules.counters, module)
+}
+
+// ModuleSettings returns the current overrides for all configured modules
+func (l *Logger) ModuleSettings() []ModuleSettings {
+	l.modules.mu.RLock()
+	defer l.modules.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var settings []ModuleSettings
+	add := func(module string) {
+		if seen[module] {
+			return
+		}
+		seen[module] = true
+		level, ok := l.modules.levels[module]
+		if !ok {
+			level = l.level
+		}
+		settings = append(settings, ModuleSettings{
+			Module:     module,
+			Level:      level,
+			SampleRate: l.modules.sampleRateFor(module),
+		})
+	}
+	for module := range l.modules.levels {
+		add(module)
+	}
+	for module := range l.modules.sampleRates {
+		add(module)
+	}
+	return settings
+}
+
+// IsValidLevel reports whether level is a known log level
+func IsValidLevel(level LogLevel) bool {
+	_, ok := levelPriority[level]
+	return ok
+}
+
+// ParseModuleLevels parses a "module=level,module=level" specification
+func ParseModuleLevels(spec string) (map[string]LogLevel, error) {
+	levels := make(map[string]LogLevel)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid module level entry: %q", part)
+		}
+		level := LogLevel(strings.ToUpper(strings.TrimSpace(kv[1])))
+		if !IsValidLevel(level) {
+			return nil, fmt.Errorf("invalid log level for module %s: %s", kv[0], kv[1])
+		}
+		levels[strings.ToLower(strings.TrimSpace(kv[0]))] = level
 	}
+	return levels, nil
 }
 
 // GetLogger returns the default logger instance
@@ -85,16 +262,30 @@ func GetLogger() *Logger {
 	return defaultLogger
 }
 
-// shouldLog determines if a message should be logged based on level
+var levelPriority = map[LogLevel]int{
+	DEBUG: 0,
+	INFO:  1,
+	WARN:  2,
+	ERROR: 3,
+	FATAL: 4,
+}
+
+// shouldLog determines if a message should be logged based on level,
+// the module override and debug sampling
 func (l *Logger) shouldLog(level LogLevel) bool {
-	levels := map[LogLevel]int{
-		DEBUG: 0,
-		INFO:  1,
-		WARN:  2,
-		ERROR: 3,
-		FATAL: 4,
+	minLevel := l.level
+	if l.modules != nil && l.module != "" {
+		if override, ok := l.modules.levelFor(l.module); ok {
+			minLevel = override
+		}
+	}
+	if levelPriority[level] < levelPriority[minLevel] {
+		return false
+	}
+	if level == DEBUG && l.modules != nil {
+		return l.modules.sample(l.module)
 	}
-	return levels[level] >= levels[l.level]
+	return true
 }
 
 // log writes a log entry
@@ -108,6 +299,7 @@ func (l *Logger) log(level LogLevel, message string, fields map[string]interface
 		Level:     level,
 		Message:   message,
 		Service:   l.serviceName,
+		Module:    l.module,
 		Fields:    fields,
 	}
 
@@ -276,3 +276,87 @@ func TestShouldLog(t *testing.T) {
 		})
 	}
 }
+
+func TestModuleLevels(t *testing.T) {
+	logBuffer := &bytes.Buffer{}
+	Initialize(Config{
+		Level:        INFO,
+		Output:       logBuffer,
+		ModuleLevels: map[string]LogLevel{"search": DEBUG},
+	})
+
+	searchLog := GetLogger().Module("search")
+	cartLog := GetLogger().Module("cart")
+
+	searchLog.Debug("search debug")
+	cartLog.Debug("cart debug")
+	assert.Contains(t, logBuffer.String(), "search debug")
+	assert.NotContains(t, logBuffer.String(), "cart debug")
+
+	var entry LogEntry
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(logBuffer.String())), &entry))
+	assert.Equal(t, "search", entry.Module)
+
+	// Runtime change applies to existing module loggers
+	logBuffer.Reset()
+	assert.NoError(t, GetLogger().SetModuleLevel("cart", DEBUG))
+	cartLog.Debug("cart debug enabled")
+	assert.Contains(t, logBuffer.String(), "cart debug enabled")
+
+	assert.Error(t, GetLogger().SetModuleLevel("cart", LogLevel("VERBOSE")))
+
+	GetLogger().ResetModule("cart")
+	logBuffer.Reset()
+	cartLog.Debug("cart debug after reset")
+	assert.Empty(t, logBuffer.String())
+}
+
+func TestDebugSampling(t *testing.T) {
+	logBuffer := &bytes.Buffer{}
+	Initialize(Config{
+		Level:           DEBUG,
+		Output:          logBuffer,
+		DebugSampleRate: 5,
+	})
+
+	log := GetLogger().Module("search")
+	for i := 0; i < 20; i++ {
+		log.Debug("sampled")
+		log.Info("not sampled")
+	}
+
+	assert.Equal(t, 4, strings.Count(logBuffer.String(), `"message":"sampled"`))
+	assert.Equal(t, 20, strings.Count(logBuffer.String(), `"message":"not sampled"`))
+}
+
+func TestParseModuleLevels(t *testing.T) {
+	levels, err := ParseModuleLevels("search=debug, Cart=info")
+	assert.NoError(t, err)
+	assert.Equal(t, DEBUG, levels["search"])
+	assert.Equal(t, INFO, levels["cart"])
+
+	_, err = ParseModuleLevels("search")
+	assert.Error(t, err)
+
+	_, err = ParseModuleLevels("search=loud")
+	assert.Error(t, err)
+
+	levels, err = ParseModuleLevels("")
+	assert.NoError(t, err)
+	assert.Empty(t, levels)
+}
+
+func TestNamedFollowsInitialize(t *testing.T) {
+	first := &bytes.Buffer{}
+	Initialize(Config{Level: INFO, Output: first})
+	Named("orders").Info("before")
+
+	second := &bytes.Buffer{}
+	Initialize(Config{Level: INFO, Output: second, ModuleLevels: map[string]LogLevel{"orders": ERROR}})
+	Named("orders").Warn("suppressed")
+	Named("cart").Warn("written")
+
+	assert.Contains(t, first.String(), `"module":"orders"`)
+	assert.NotContains(t, second.String(), "suppressed")
+	assert.Contains(t, second.String(), `"module":"cart"`)
+}
@@ -0,0 +1,55 @@
+package maintenance
+
+import (
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new maintenance handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetStatus handles GET /api/maintenance so storefronts can show a maintenance banner
+func (h *Handler) GetStatus(c *gin.Context) {
+	state := h.service.GetState(c.Request.Context())
+	utils.SuccessResponse(c, http.StatusOK, "Maintenance status retrieved successfully", gin.H{
+		"readOnly":          state.ReadOnly,
+		"message":           state.Message,
+		"retryAfterSeconds": state.RetryAfterSeconds,
+	})
+}
+
+// GetState handles GET /api/admin/maintenance (admin only)
+func (h *Handler) GetState(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Maintenance state retrieved successfully", h.service.GetState(c.Request.Context()))
+}
+
+// UpdateState handles PUT /api/admin/maintenance (admin only)
+func (h *Handler) UpdateState(c *gin.Context) {
+	var req UpdateStateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	adminEmail, _ := c.Get("user_email")
+	updatedBy, _ := adminEmail.(string)
+
+	state, err := h.service.SetState(c.Request.Context(), &req, updatedBy)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_MAINTENANCE_FAILED", "Failed to update maintenance state", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Maintenance state updated successfully", state)
+}
@@ -0,0 +1,104 @@
+package maintenance
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// blockedPrefixes are shopper flows that are closed entirely in read-only mode, reads included
+var blockedPrefixes = []string{"/api/cart", "/api/orders", "/api/payments"}
+
+// allowedPrefixes stay open so admins can sign in and switch maintenance off again, and so
+// payments the gateway captured and deliveries carriers reported before or during maintenance still reach their orders
+var allowedPrefixes = []string{"/api/admin", "/api/auth", "/api/payments/webhook", "/api/deliveries/confirm"}
+
+// Middleware rejects writes and cart/checkout traffic with a 503 MAINTENANCE error while the store is
+// read-only. Catalog reads keep working; admin traffic and allow-listed IPs pass through.
+func Middleware(service ServiceInterface, authService *auth.Service, allowIPs []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(allowIPs))
+	for _, ip := range allowIPs {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			allowed[ip] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		if !affectsRequest(c.Request) {
+			c.Next()
+			return
+		}
+
+		state := service.GetState(c.Request.Context())
+		if !state.ReadOnly || allowed[c.ClientIP()] || isAdminRequest(c, authService) {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", strconv.Itoa(state.RetryAfterSeconds))
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "MAINTENANCE", state.Message, gin.H{
+			"readOnly":          true,
+			"retryAfterSeconds": state.RetryAfterSeconds,
+		})
+		c.Abort()
+	}
+}
+
+// affectsRequest reports whether read-only mode applies to a request
+func affectsRequest(r *http.Request) bool {
+	path := utils.UnversionedPath(r.URL.Path)
+	if !strings.HasPrefix(path, "/api/") {
+		return false
+	}
+	for _, prefix := range allowedPrefixes {
+		if hasPathPrefix(path, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range blockedPrefixes {
+		if hasPathPrefix(path, prefix) {
+			return true
+		}
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// hasPathPrefix matches prefix as whole path segments, so /api/cart does not match /api/cartography
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// isAdminRequest checks the bearer token, if any, for the admin role. Route-level auth has not run yet
+// when this global middleware executes, so the token is validated here.
+func isAdminRequest(c *gin.Context, authService *auth.Service) bool {
+	if authService == nil {
+		return false
+	}
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || token == "" {
+		return false
+	}
+	claims, err := authService.ValidateAccessToken(token)
+	return err == nil && claims.Role == "admin"
+}
+
+// ParseAllowIPs splits a comma-separated IP allow-list, dropping invalid entries
+func ParseAllowIPs(value string) []string {
+	var ips []string
+	for _, part := range strings.Split(value, ",") {
+		if ip := strings.TrimSpace(part); net.ParseIP(ip) != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
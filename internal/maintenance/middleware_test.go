@@ -0,0 +1,133 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMaintenanceRouter(t *testing.T, readOnly bool, allowIPs []string) (*gin.Engine, *Service, *auth.Service) {
+	gin.SetMode(gin.TestMode)
+
+	service := NewService(nil, readOnly, time.Minute)
+	authService := auth.NewService(nil, &config.Config{JWTSecret: "test-secret"})
+
+	r := gin.New()
+	r.Use(Middleware(service, authService, allowIPs))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/products", ok)
+	r.POST("/api/products", ok)
+	r.GET("/api/cart", ok)
+	r.POST("/api/orders/create", ok)
+	r.POST("/api/payments/verify", ok)
+	r.POST("/api/payments/webhook", ok)
+	r.POST("/api/auth/login", ok)
+	r.PUT("/api/admin/maintenance", ok)
+	r.GET("/health", ok)
+
+	return r, service, authService
+}
+
+func serve(r *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_ReadOnly(t *testing.T) {
+	r, _, authService := setupMaintenanceRouter(t, true, nil)
+
+	t.Run("catalog browsing keeps working", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/api/products", "").Code)
+		assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/health", "").Code)
+	})
+
+	t.Run("cart and checkout are unavailable", func(t *testing.T) {
+		w := serve(r, http.MethodGet, "/api/cart", "")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+		var body struct {
+			Success bool `json:"success"`
+			Error   struct {
+				Code    string                 `json:"code"`
+				Message string                 `json:"message"`
+				Details map[string]interface{} `json:"details"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "MAINTENANCE", body.Error.Code)
+		assert.Equal(t, DefaultMessage, body.Error.Message)
+		assert.Equal(t, float64(60), body.Error.Details["retryAfterSeconds"])
+
+		assert.Equal(t, http.StatusServiceUnavailable, serve(r, http.MethodPost, "/api/orders/create", "").Code)
+	})
+
+	t.Run("payment webhooks are still processed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(r, http.MethodPost, "/api/payments/webhook", "").Code)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(r, http.MethodPost, "/api/payments/verify", "").Code)
+	})
+
+	t.Run("other writes are unavailable", func(t *testing.T) {
+		assert.Equal(t, http.StatusServiceUnavailable, serve(r, http.MethodPost, "/api/products", "").Code)
+	})
+
+	t.Run("admins pass through", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(r, http.MethodPost, "/api/auth/login", "").Code)
+		assert.Equal(t, http.StatusOK, serve(r, http.MethodPut, "/api/admin/maintenance", "").Code)
+
+		admin, err := authService.GenerateTokens(&models.User{ID: "admin-1", Role: "admin"})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, serve(r, http.MethodPost, "/api/products", admin.AccessToken).Code)
+
+		customer, err := authService.GenerateTokens(&models.User{ID: "user-1", Role: "customer"})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(r, http.MethodPost, "/api/orders/create", customer.AccessToken).Code)
+	})
+}
+
+func TestMiddleware_AllowListAndSwitch(t *testing.T) {
+	r, service, _ := setupMaintenanceRouter(t, false, []string{"203.0.113.5"})
+
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/api/cart", "").Code)
+
+	readOnly := true
+	state, err := service.SetState(context.Background(), &UpdateStateRequest{ReadOnly: &readOnly, Message: "Back at noon"}, "admin@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, int(DefaultRetryAfter.Seconds()), state.RetryAfterSeconds)
+
+	w := serve(r, http.MethodGet, "/api/cart", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "Back at noon")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cart", nil)
+	req.RemoteAddr = "203.0.113.5:1234"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	readOnly = false
+	_, err = service.SetState(context.Background(), &UpdateStateRequest{ReadOnly: &readOnly}, "admin@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/api/cart", "").Code)
+}
+
+func TestParseAllowIPs(t *testing.T) {
+	assert.Equal(t, []string{"10.0.0.1", "::1"}, ParseAllowIPs(" 10.0.0.1, not-an-ip,,::1"))
+	assert.Empty(t, ParseAllowIPs(""))
+}
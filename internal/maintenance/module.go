@@ -0,0 +1,45 @@
+package maintenance
+
+import (
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires read-only maintenance mode, switchable at runtime by admins, into the application.
+// Register it first so its middleware runs ahead of every other module's.
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+	allowIPs    []string
+}
+
+// NewModule creates the maintenance module from the maintenance configuration
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.Redis, deps.Config.MaintenanceMode, time.Duration(deps.Config.MaintenanceRetryAfter)*time.Second)
+	return &Module{
+		service:     service,
+		handler:     NewHandler(service),
+		authService: deps.Auth,
+		allowIPs:    ParseAllowIPs(deps.Config.MaintenanceAllowIPs),
+	}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "maintenance"
+}
+
+// Middleware rejects writes while the store is read-only
+func (m *Module) Middleware() []gin.HandlerFunc {
+	return []gin.HandlerFunc{Middleware(m.service, m.authService, m.allowIPs)}
+}
+
+// RegisterAPIRoutes sets up the maintenance status and admin switch routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
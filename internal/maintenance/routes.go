@@ -0,0 +1,20 @@
+package maintenance
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the maintenance status and admin switch routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	api.GET("/maintenance", handler.GetStatus)
+
+	admin := api.Group("/admin/maintenance")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.GetState)
+		admin.PUT("", handler.UpdateState)
+	}
+}
@@ -0,0 +1,117 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// stateKey holds the switch in Redis so every API instance sees the same mode
+const stateKey = "maintenance:state"
+
+// DefaultMessage is shown to shoppers when no custom message is set
+const DefaultMessage = "We're performing scheduled maintenance. Browsing still works; checkout will be back shortly."
+
+// DefaultRetryAfter is the Retry-After hint used when none is configured
+const DefaultRetryAfter = 5 * time.Minute
+
+// State is the current maintenance switch
+type State struct {
+	ReadOnly          bool      `json:"readOnly"`
+	Message           string    `json:"message"`
+	RetryAfterSeconds int       `json:"retryAfterSeconds"`
+	UpdatedBy         string    `json:"updatedBy,omitempty"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// UpdateStateRequest represents the request to switch read-only mode on or off
+type UpdateStateRequest struct {
+	ReadOnly          *bool  `json:"readOnly" binding:"required"`
+	Message           string `json:"message" binding:"max=500"`
+	RetryAfterSeconds int    `json:"retryAfterSeconds" binding:"min=0,max=86400"`
+}
+
+// ServiceInterface defines the interface for the maintenance service
+type ServiceInterface interface {
+	GetState(ctx context.Context) State
+	SetState(ctx context.Context, req *UpdateStateRequest, updatedBy string) (*State, error)
+}
+
+type Service struct {
+	redisClient *redis.Client
+
+	mu    sync.RWMutex
+	local State // used when Redis is unavailable or has no state stored yet
+}
+
+// NewService creates a new maintenance service. readOnly is the configured mode at startup;
+// a switch made through the admin endpoint overrides it until changed again.
+func NewService(redisClient *redis.Client, readOnly bool, retryAfter time.Duration) *Service {
+	if retryAfter <= 0 {
+		retryAfter = DefaultRetryAfter
+	}
+	return &Service{
+		redisClient: redisClient,
+		local: State{
+			ReadOnly:          readOnly,
+			Message:           DefaultMessage,
+			RetryAfterSeconds: int(retryAfter.Seconds()),
+			UpdatedBy:         "config",
+			UpdatedAt:         time.Now(),
+		},
+	}
+}
+
+// GetState returns the current maintenance state, falling back to the local state when Redis is unavailable
+func (s *Service) GetState(ctx context.Context) State {
+	if s.redisClient != nil {
+		data, err := s.redisClient.Get(ctx, stateKey).Result()
+		if err == nil {
+			var state State
+			if json.Unmarshal([]byte(data), &state) == nil {
+				return state
+			}
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.local
+}
+
+// SetState switches read-only mode on or off for every instance
+func (s *Service) SetState(ctx context.Context, req *UpdateStateRequest, updatedBy string) (*State, error) {
+	state := State{
+		ReadOnly:          *req.ReadOnly,
+		Message:           req.Message,
+		RetryAfterSeconds: req.RetryAfterSeconds,
+		UpdatedBy:         updatedBy,
+		UpdatedAt:         time.Now(),
+	}
+	if state.Message == "" {
+		state.Message = DefaultMessage
+	}
+	if state.RetryAfterSeconds == 0 {
+		state.RetryAfterSeconds = int(DefaultRetryAfter.Seconds())
+	}
+
+	if s.redisClient != nil {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal maintenance state: %w", err)
+		}
+		if err := s.redisClient.Set(ctx, stateKey, data, 0).Err(); err != nil {
+			return nil, fmt.Errorf("failed to save maintenance state: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.local = state
+	s.mu.Unlock()
+
+	return &state, nil
+}
@@ -0,0 +1,227 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // accepted upload formats
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+	"strings"
+
+	imageutils "ecommerce-website/internal/utils"
+)
+
+// MaxImageSize is the largest image upload accepted, kept under the default request size limit
+const MaxImageSize = 8 << 20
+
+// maxImagePixels caps the decoded size of an upload, so a small file cannot expand into a huge bitmap
+const maxImagePixels = 50_000_000
+
+var (
+	ErrImageTooLarge    = errors.New("image is too large")
+	ErrUnsupportedImage = errors.New("unsupported image")
+)
+
+// originalExtensions maps the accepted upload formats to the extension the original is stored with
+var originalExtensions = map[string]string{
+	"jpeg": ".jpg",
+	"png":  ".png",
+	"gif":  ".gif",
+}
+
+// ImageEncoder writes img in one image format at quality, from 1 to 100; lossless formats ignore it
+type ImageEncoder func(w io.Writer, img image.Image, quality int) error
+
+// imageEncoders are the formats variants can be stored in. The standard library has no WebP or AVIF encoder;
+// builds linking one register it with RegisterImageEncoder.
+var imageEncoders = map[string]ImageEncoder{
+	"jpeg": encodeJPEG,
+	"png": func(w io.Writer, img image.Image, _ int) error {
+		return png.Encode(w, img)
+	},
+}
+
+// RegisterImageEncoder makes format available to the image pipeline. Call it from an init function.
+func RegisterImageEncoder(format string, encoder ImageEncoder) {
+	imageEncoders[format] = encoder
+}
+
+// ImageVariantFormats returns the formats of formats that have an encoder, in order, and the ones that do
+// not. JPEG, which every client can display, is always stored and appended when missing.
+func ImageVariantFormats(formats []string) (supported, unsupported []string) {
+	seen := make(map[string]bool)
+	for _, format := range append(formats, "jpeg") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format == "" || seen[format] {
+			continue
+		}
+		seen[format] = true
+		if _, ok := imageEncoders[format]; ok {
+			supported = append(supported, format)
+		} else {
+			unsupported = append(unsupported, format)
+		}
+	}
+	return supported, unsupported
+}
+
+// encodeJPEG flattens transparent areas onto white, which JPEG cannot represent
+func encodeJPEG(w io.Writer, img image.Image, quality int) error {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+	return jpeg.Encode(w, flat, &jpeg.Options{Quality: quality})
+}
+
+// ImageVariant is one stored rendition of an uploaded image
+type ImageVariant struct {
+	Size   string `json:"size"`
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Key    string `json:"key"`
+}
+
+// ImagePipeline stores uploaded images with a variant for every image size in every configured format, so
+// images are delivered at the size they are shown without resizing on request
+type ImagePipeline struct {
+	store Store
+}
+
+// NewImagePipeline creates a pipeline storing images in store
+func NewImagePipeline(store Store) *ImagePipeline {
+	return &ImagePipeline{store: store}
+}
+
+// Store decodes an uploaded JPEG, PNG or GIF image and stores it under dir, next to its variants in the
+// formats of imageutils.DefaultImageConfig.VariantFormats. It returns the key of the original. Nothing is
+// left behind when storing any file fails.
+func (p *ImagePipeline) Store(dir string, content io.Reader) (string, []ImageVariant, error) {
+	data, err := io.ReadAll(io.LimitReader(content, MaxImageSize+1))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > MaxImageSize {
+		return "", nil, ErrImageTooLarge
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	ext, ok := originalExtensions[format]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedImage, format)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return "", nil, ErrImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+
+	original := imageutils.ImageOriginalKey(dir, ext)
+	stored := []string{}
+	fail := func(err error) (string, []ImageVariant, error) {
+		for _, key := range stored {
+			p.store.Delete(key)
+		}
+		return "", nil, err
+	}
+	if err := p.store.Put(original, bytes.NewReader(data)); err != nil {
+		return fail(err)
+	}
+	stored = append(stored, original)
+
+	formats, _ := ImageVariantFormats(imageutils.DefaultImageConfig.VariantFormats)
+	var variants []ImageVariant
+	for _, size := range imageutils.ImageSizes {
+		width, height := fit(img.Bounds().Dx(), img.Bounds().Dy(), size.Width, size.Height)
+		scaled := resize(img, width, height)
+		for _, format := range formats {
+			var buf bytes.Buffer
+			if err := imageEncoders[format](&buf, scaled, size.Quality); err != nil {
+				return fail(fmt.Errorf("failed to encode %s %s variant: %w", size.Name, format, err))
+			}
+			key := imageutils.ImageVariantKey(original, size.Name, format)
+			if err := p.store.Put(key, &buf); err != nil {
+				return fail(err)
+			}
+			stored = append(stored, key)
+			variants = append(variants, ImageVariant{Size: size.Name, Format: format, Width: width, Height: height, Key: key})
+		}
+	}
+	return original, variants, nil
+}
+
+// Delete removes an uploaded image and every variant it may have been stored with
+func (p *ImagePipeline) Delete(original string) error {
+	formats := make([]string, 0, len(imageEncoders))
+	for format := range imageEncoders {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	if err := p.store.Delete(original); err != nil {
+		return err
+	}
+	for _, size := range imageutils.ImageSizes {
+		for _, format := range formats {
+			if err := p.store.Delete(imageutils.ImageVariantKey(original, size.Name, format)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fit scales width and height down to fit within maxWidth and maxHeight, keeping the aspect ratio
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+	if width*maxHeight > height*maxWidth {
+		return maxWidth, max(1, height*maxWidth/width)
+	}
+	return max(1, width*maxHeight/height), maxHeight
+}
+
+// resize scales img down to width by height, averaging the source pixels each target pixel covers
+func resize(img image.Image, width, height int) *image.NRGBA {
+	bounds := img.Bounds()
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			// Colors are premultiplied by alpha, so transparent pixels do not darken the average
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			if a == 0 {
+				continue
+			}
+			scaled.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r * 0xffff / a >> 8),
+				G: uint8(g * 0xffff / a >> 8),
+				B: uint8(b * 0xffff / a >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return scaled
+}
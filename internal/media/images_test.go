@@ -0,0 +1,89 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	imageutils "ecommerce-website/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPNG encodes a width by height image, opaque red on the left half and transparent on the right
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width/2; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestImagePipeline(t *testing.T) {
+	dir := t.TempDir()
+	pipeline := NewImagePipeline(NewLocalStore(dir, ""))
+
+	original, variants, err := pipeline.Store("products/p1/images/a1", bytes.NewReader(testPNG(t, 400, 200)))
+	require.NoError(t, err)
+	assert.Equal(t, "products/p1/images/a1/original.png", original)
+	require.Len(t, variants, len(imageutils.ImageSizes))
+
+	thumbnail := variants[0]
+	assert.Equal(t, ImageVariant{Size: "thumbnail", Format: "jpeg", Width: 150, Height: 75, Key: "products/p1/images/a1/thumbnail.jpeg"}, thumbnail)
+	// Images are never enlarged
+	assert.Equal(t, 400, variants[2].Width)
+	assert.Equal(t, 200, variants[2].Height)
+
+	file, err := os.Open(filepath.Join(dir, filepath.FromSlash(thumbnail.Key)))
+	require.NoError(t, err)
+	decoded, err := jpeg.Decode(file)
+	file.Close()
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 150, 75), decoded.Bounds())
+	r, g, b, _ := decoded.At(10, 10).RGBA()
+	assert.True(t, r>>8 > 200 && g>>8 < 60 && b>>8 < 60, "opaque red is kept")
+	r, g, b, _ = decoded.At(140, 10).RGBA()
+	assert.True(t, r>>8 > 200 && g>>8 > 200 && b>>8 > 200, "transparency is flattened onto white")
+
+	require.NoError(t, pipeline.Delete(original))
+	entries, err := os.ReadDir(filepath.Join(dir, "products", "p1", "images", "a1"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, _, err = pipeline.Store("products/p1/images/a2", strings.NewReader("%PDF-1.4"))
+	assert.ErrorIs(t, err, ErrUnsupportedImage)
+	_, _, err = pipeline.Store("products/p1/images/a3", io.MultiReader(bytes.NewReader(testPNG(t, 10, 10)), strings.NewReader(strings.Repeat(" ", MaxImageSize))))
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
+
+func TestImagePipeline_RegisteredFormats(t *testing.T) {
+	RegisterImageEncoder("webp", imageEncoders["png"])
+	previous := imageutils.DefaultImageConfig.VariantFormats
+	t.Cleanup(func() {
+		delete(imageEncoders, "webp")
+		imageutils.DefaultImageConfig.VariantFormats = previous
+	})
+
+	supported, unsupported := ImageVariantFormats([]string{" WebP", "avif", "webp"})
+	assert.Equal(t, []string{"webp", "jpeg"}, supported)
+	assert.Equal(t, []string{"avif"}, unsupported)
+	imageutils.DefaultImageConfig.VariantFormats = supported
+
+	_, variants, err := NewImagePipeline(NewLocalStore(t.TempDir(), "")).Store("images/b1", bytes.NewReader(testPNG(t, 40, 40)))
+	require.NoError(t, err)
+	require.Len(t, variants, 2*len(imageutils.ImageSizes))
+	assert.Equal(t, "images/b1/thumbnail.webp", variants[0].Key)
+	assert.Equal(t, "images/b1/thumbnail.jpeg", variants[1].Key)
+}
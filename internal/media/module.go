@@ -0,0 +1,53 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"ecommerce-website/internal/app"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module serves stored media files
+type Module struct {
+	store *LocalStore
+}
+
+// NewModule creates the media module on the configured media directory
+func NewModule(deps app.Deps) app.Module {
+	return &Module{store: NewLocalStore(deps.Config.MediaDir, deps.Config.CDNBaseURL)}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "media"
+}
+
+// RegisterRoutes serves the media directory. Keys are never reused for different content,
+// so files may be cached for good by browsers and the CDN.
+func (m *Module) RegisterRoutes(r *gin.Engine) {
+	files := r.Group(PathPrefix, func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Next()
+	})
+	files.StaticFS("/", gin.Dir(m.store.Dir(), false))
+}
+
+// HealthCheck reports whether the media directory is available
+func (m *Module) HealthCheck(ctx context.Context) error {
+	info, err := os.Stat(m.store.Dir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Created on the first upload
+			return nil
+		}
+		return fmt.Errorf("media directory unavailable: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("media directory %s is not a directory", m.store.Dir())
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PathPrefix is where the application serves stored media; a CDN configured in front of the
+// application pulls from the same path
+const PathPrefix = "/media"
+
+var ErrInvalidKey = errors.New("invalid media key")
+
+// Store keeps uploaded files and hands out the URLs they are delivered from
+type Store interface {
+	// Put stores the content under key, replacing any file already stored there
+	Put(key string, content io.Reader) error
+	// Delete removes the file stored under key; a missing file is not an error
+	Delete(key string) error
+	// URL returns the public URL of the file stored under key
+	URL(key string) string
+}
+
+// LocalStore keeps media on the local disk and serves it under PathPrefix, through the CDN when one is configured
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore creates a store writing to dir whose URLs start with baseURL (the CDN origin, or empty for
+// URLs relative to the application)
+func NewLocalStore(dir, baseURL string) *LocalStore {
+	return &LocalStore{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Dir returns the directory the store writes to
+func (s *LocalStore) Dir() string {
+	return s.dir
+}
+
+// Put writes content to a temporary file and moves it into place, so readers never see a partial file
+func (s *LocalStore) Put(key string, content io.Reader) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create media directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create media file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write media file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write media file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write media file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to store media file: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the file stored under key
+func (s *LocalStore) Delete(key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete media file: %w", err)
+	}
+	return nil
+}
+
+// URL returns the delivery URL of key
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + PathPrefix + "/" + key
+}
+
+// path maps key to a file inside the store directory; keys are slash-separated and may not leave it
+func (s *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "..") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,39 @@
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalStore(dir, "https://cdn.example.com/")
+
+	require.NoError(t, store.Put("products/p1/manual.pdf", strings.NewReader("first")))
+	require.NoError(t, store.Put("products/p1/manual.pdf", strings.NewReader("second")))
+
+	content, err := os.ReadFile(filepath.Join(dir, "products", "p1", "manual.pdf"))
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+	assert.Equal(t, "https://cdn.example.com/media/products/p1/manual.pdf", store.URL("products/p1/manual.pdf"))
+	assert.Equal(t, "/media/a.pdf", NewLocalStore(dir, "").URL("a.pdf"))
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Join(dir, "products", "p1"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, store.Delete("products/p1/manual.pdf"))
+	require.NoError(t, store.Delete("products/p1/manual.pdf"))
+	_, err = os.Stat(filepath.Join(dir, "products", "p1", "manual.pdf"))
+	assert.True(t, os.IsNotExist(err))
+
+	for _, key := range []string{"", "/etc/passwd", "../outside.pdf", "a/../../outside.pdf", "a//b.pdf", `a\b.pdf`} {
+		assert.ErrorIs(t, store.Put(key, strings.NewReader("x")), ErrInvalidKey, key)
+	}
+}
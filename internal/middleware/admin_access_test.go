@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIPNets(t *testing.T) {
+	nets := ParseIPNets(" 10.0.0.0/8, 203.0.113.7 ,bogus, 2001:db8::/32,")
+	assert.Len(t, nets, 3)
+	assert.Equal(t, "203.0.113.7/32", nets[1].String())
+	assert.Empty(t, ParseIPNets(""))
+}
+
+func TestAdminIPAllowListMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(AdminIPAllowListMiddleware(ParseIPNets("10.0.0.0/8,203.0.113.7")))
+	for _, path := range []string{"/api/admin/orders", "/api/v1/admin/orders", "/api/auth/admin/login", "/api/administrators", "/api/products"} {
+		r.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		expected   int
+	}{
+		{"admin route from allowed range", "/api/admin/orders", "10.1.2.3:5000", http.StatusOK},
+		{"admin login from allowed IP", "/api/auth/admin/login", "203.0.113.7:5000", http.StatusOK},
+		{"admin route from other IP", "/api/admin/orders", "198.51.100.1:5000", http.StatusForbidden},
+		{"versioned admin route from other IP", "/api/v1/admin/orders", "198.51.100.1:5000", http.StatusForbidden},
+		{"admin login from other IP", "/api/auth/admin/login", "198.51.100.1:5000", http.StatusForbidden},
+		{"similar prefix is not admin", "/api/administrators", "198.51.100.1:5000", http.StatusOK},
+		{"storefront route", "/api/products", "198.51.100.1:5000", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+
+	// Without an allow-list every IP reaches admin routes
+	open := gin.New()
+	open.Use(AdminIPAllowListMiddleware(nil))
+	open.GET("/api/admin/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/orders", nil)
+	req.RemoteAddr = "198.51.100.1:5000"
+	w := httptest.NewRecorder()
+	open.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
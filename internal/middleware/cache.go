@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"ecommerce-website/internal/database"
+	"ecommerce-website/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -40,6 +41,11 @@ func ProductCacheKeyFunc(c *gin.Context) string {
 	return fmt.Sprintf("cache:products:%s", hash)
 }
 
+// AffiliateCacheKeyFunc generates cache key for affiliate availability lookups, shared across API keys
+func AffiliateCacheKeyFunc(c *gin.Context) string {
+	return fmt.Sprintf("cache:affiliate:%s", c.Request.URL.Path)
+}
+
 // CacheMiddleware creates a caching middleware
 func CacheMiddleware(config CacheConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -179,6 +185,12 @@ var (
 		TTL:     10 * time.Minute,
 		KeyFunc: DefaultCacheKeyFunc,
 	}
+
+	// Affiliate availability cache: 1 minute, short so stock stays fresh
+	AffiliateAvailabilityCache = CacheConfig{
+		TTL:     time.Minute,
+		KeyFunc: AffiliateCacheKeyFunc,
+	}
 )
 
 // CacheInvalidationMiddleware invalidates relevant caches after write operations
@@ -192,9 +204,7 @@ func CacheInvalidationMiddleware() gin.HandlerFunc {
 
 			// Invalidate product-related caches
 			if contains(path, []string{"/products", "/categories"}) {
-				go InvalidateCache("cache:products:*")
-				go InvalidateCache("cache:public:/api/products*")
-				go InvalidateCache("cache:public:/api/categories*")
+				InvalidateCatalogCache()
 			}
 
 			// Invalidate user-related caches
@@ -207,14 +217,37 @@ func CacheInvalidationMiddleware() gin.HandlerFunc {
 			// Invalidate order-related caches
 			if contains(path, []string{"/orders"}) {
 				if userID, exists := c.Get("user_id"); exists {
-					go InvalidateCache(fmt.Sprintf("cache:user:%s:/api/orders*", userID))
-					go InvalidateCache(fmt.Sprintf("cache:user:%s:/api/users/orders*", userID))
+					for _, prefix := range apiPrefixes() {
+						go InvalidateCache(fmt.Sprintf("cache:user:%s:%s/orders*", userID, prefix))
+						go InvalidateCache(fmt.Sprintf("cache:user:%s:%s/users/orders*", userID, prefix))
+					}
 				}
 			}
 		}
 	}
 }
 
+// InvalidateCatalogCache drops the cached product, category and affiliate availability responses in the
+// background, for catalog changes made outside a request such as promotions starting and ending
+func InvalidateCatalogCache() {
+	go InvalidateCache("cache:products:*")
+	for _, prefix := range apiPrefixes() {
+		go InvalidateCache("cache:public:" + prefix + "/products*")
+		go InvalidateCache("cache:public:" + prefix + "/categories*")
+	}
+	go InvalidateCache("cache:affiliate:*")
+}
+
+// apiPrefixes are the path prefixes the API is served under. Cached responses are kept per prefix, as
+// versions may shape their responses differently.
+func apiPrefixes() []string {
+	prefixes := []string{"/api"}
+	for _, version := range utils.APIVersions {
+		prefixes = append(prefixes, "/api/"+version)
+	}
+	return prefixes
+}
+
 func contains(str string, substrings []string) bool {
 	for _, substring := range substrings {
 		if len(str) >= len(substring) && str[:len(substring)] == substring {
@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompressionConfig controls which responses CompressionMiddleware compresses
+type CompressionConfig struct {
+	MinSize      int      // responses smaller than this many bytes are sent as they are
+	ContentTypes []string // media types worth compressing; images and archives are compressed already
+}
+
+// DefaultCompressionConfig compresses text and JSON responses of 1KB and more
+var DefaultCompressionConfig = CompressionConfig{
+	MinSize: 1024,
+	ContentTypes: []string{
+		"application/json",
+		"application/problem+json",
+		"application/javascript",
+		"application/xml",
+		"image/svg+xml",
+		"text/html",
+		"text/plain",
+		"text/css",
+		"text/csv",
+	},
+}
+
+// CompressionEncoder creates a writer compressing into w. Closing it finishes the compressed stream
+// without closing w.
+type CompressionEncoder func(w io.Writer) (io.WriteCloser, error)
+
+var (
+	compressionMu        sync.RWMutex
+	compressionEncoders  = map[string]CompressionEncoder{"gzip": gzipEncoder}
+	compressionEncodings = []string{"gzip"} // most preferred first
+)
+
+// RegisterCompressionEncoder adds a content encoding, such as brotli under "br". Encodings registered later
+// are preferred over earlier ones when a client accepts them equally.
+func RegisterCompressionEncoder(encoding string, encoder CompressionEncoder) {
+	compressionMu.Lock()
+	defer compressionMu.Unlock()
+	if _, ok := compressionEncoders[encoding]; !ok {
+		compressionEncodings = append([]string{encoding}, compressionEncodings...)
+	}
+	compressionEncoders[encoding] = encoder
+}
+
+// gzipWriters pools gzip writers, whose compression state is large to allocate per response
+var gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+
+// pooledGzipWriter returns its writer to the pool when closed
+type pooledGzipWriter struct {
+	*gzip.Writer
+}
+
+func (w pooledGzipWriter) Close() error {
+	err := w.Writer.Close()
+	gzipWriters.Put(w.Writer)
+	return err
+}
+
+func gzipEncoder(w io.Writer) (io.WriteCloser, error) {
+	writer := gzipWriters.Get().(*gzip.Writer)
+	writer.Reset(w)
+	return pooledGzipWriter{writer}, nil
+}
+
+// negotiateEncoding picks the registered encoding a client accepts with the highest quality, or "" when it
+// accepts none. Ties go to the encoding registered last.
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if name == "*" {
+			wildcard = quality
+			continue
+		}
+		qualities[name] = quality
+	}
+
+	compressionMu.RLock()
+	defer compressionMu.RUnlock()
+	best, bestQuality := "", 0.0
+	for _, encoding := range compressionEncodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = wildcard
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// compressibleType reports whether a Content-Type header names one of the configured media types
+func (config CompressionConfig) compressibleType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, candidate := range config.ContentTypes {
+		if mediaType == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// CompressionMiddleware compresses responses of the configured content types once they reach MinSize bytes,
+// in the best encoding the client accepts. The start of each response is buffered until its size is known
+// to pass the threshold, the handler finishes or it flushes. Responses that already have a content encoding
+// are left alone, and strong ETags are weakened as the compressed bytes differ from the ones they name.
+func CompressionMiddleware(config CompressionConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{
+			ResponseWriter: c.Writer,
+			config:         config,
+			encoding:       negotiateEncoding(c.GetHeader("Accept-Encoding")),
+		}
+		c.Writer = writer
+		defer func() {
+			writer.close()
+			c.Writer = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// compressWriter buffers the start of a response until it knows whether to compress it
+type compressWriter struct {
+	gin.ResponseWriter
+	config   CompressionConfig
+	encoding string
+	buf      []byte
+	decided  bool
+	encoder  io.WriteCloser
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.config.MinSize {
+			return len(data), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends what has been written so far, compressed or not depending on how much there is
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide sets the response headers and writes the buffered bytes, through an encoder when the response
+// is compressed
+func (w *compressWriter) decide() error {
+	w.decided = true
+	buf := w.buf
+	w.buf = nil
+
+	header := w.Header()
+	eligible := !w.ResponseWriter.Written() && header.Get("Content-Encoding") == "" &&
+		w.config.compressibleType(header.Get("Content-Type"))
+	if eligible {
+		// Caches must keep compressed and plain copies apart, whether or not this one is compressed
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if !eligible || w.encoding == "" || len(buf) < w.config.MinSize {
+		if len(buf) == 0 {
+			return nil
+		}
+		_, err := w.ResponseWriter.Write(buf)
+		return err
+	}
+
+	compressionMu.RLock()
+	newEncoder := compressionEncoders[w.encoding]
+	compressionMu.RUnlock()
+	encoder, err := newEncoder(w.ResponseWriter)
+	if err != nil {
+		_, err = w.ResponseWriter.Write(buf)
+		return err
+	}
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	w.encoder = encoder
+	_, err = encoder.Write(buf)
+	return err
+}
+
+// close writes a response that stayed below the threshold and finishes a compressed one
+func (w *compressWriter) close() {
+	if !w.decided {
+		if err := w.decide(); err != nil {
+			return
+		}
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+		w.encoder = nil
+	}
+}
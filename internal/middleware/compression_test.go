@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCompressionRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(CompressionMiddleware(DefaultCompressionConfig))
+	r.GET("/products", func(c *gin.Context) {
+		c.Header("ETag", `"v1"`)
+		c.JSON(http.StatusOK, gin.H{"description": strings.Repeat("large catalog ", 200)})
+	})
+	r.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	r.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", make([]byte, 4096))
+	})
+	return r
+}
+
+func sendCompressionRequest(r *gin.Engine, path, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	r := setupCompressionRouter()
+
+	t.Run("compresses large JSON", func(t *testing.T) {
+		w := sendCompressionRequest(r, "/products", "gzip, deflate, br")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Equal(t, `W/"v1"`, w.Header().Get("ETag"))
+
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "large catalog")
+		assert.Less(t, w.Body.Len(), len(body))
+	})
+
+	t.Run("leaves small responses alone", func(t *testing.T) {
+		w := sendCompressionRequest(r, "/small", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.JSONEq(t, `{"ok":true}`, w.Body.String())
+	})
+
+	t.Run("skips other content types", func(t *testing.T) {
+		w := sendCompressionRequest(r, "/image", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Header().Get("Vary"))
+		assert.Equal(t, 4096, w.Body.Len())
+	})
+
+	t.Run("sends plain responses to clients without gzip", func(t *testing.T) {
+		for _, acceptEncoding := range []string{"", "identity", "gzip;q=0"} {
+			w := sendCompressionRequest(r, "/products", acceptEncoding)
+			assert.Empty(t, w.Header().Get("Content-Encoding"), acceptEncoding)
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.Contains(t, w.Body.String(), "large catalog")
+		}
+	})
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"gzip":                 "gzip",
+		"GZIP;q=0.5":           "gzip",
+		"br":                   "",
+		"*":                    "gzip",
+		"*, gzip;q=0":          "",
+		"deflate, gzip;q=bad":  "",
+		"identity, gzip;q=0.1": "gzip",
+	}
+	for header, expected := range tests {
+		assert.Equal(t, expected, negotiateEncoding(header), header)
+	}
+}
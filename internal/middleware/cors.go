@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"ecommerce-website/internal/config"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidCORSPolicy is returned for a CORS policy the middleware cannot apply
+var ErrInvalidCORSPolicy = errors.New("invalid CORS policy")
+
+// CORSPolicy applies the API's cross-origin policy. The methods and headers the API itself relies on are
+// fixed when it is created; the allowed origins, extra headers, credentials and max age come from the
+// configuration and can be replaced with Update while the server runs.
+type CORSPolicy struct {
+	base    cors.Config
+	handler atomic.Pointer[gin.HandlerFunc]
+}
+
+// NewCORSPolicy creates a CORS policy allowing base's methods and headers and applying policy on top
+func NewCORSPolicy(base cors.Config, policy config.CORS) (*CORSPolicy, error) {
+	p := &CORSPolicy{base: base}
+	if err := p.Update(policy); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Update replaces the configured part of the policy for the requests that follow. An invalid policy is
+// rejected and the current one stays in place.
+func (p *CORSPolicy) Update(policy config.CORS) error {
+	if len(policy.AllowedOrigins) == 0 {
+		return fmt.Errorf("%w: no allowed origins", ErrInvalidCORSPolicy)
+	}
+	for _, origin := range policy.AllowedOrigins {
+		if origin == "*" {
+			if policy.AllowCredentials {
+				return fmt.Errorf("%w: every origin cannot be allowed with credentials", ErrInvalidCORSPolicy)
+			}
+			continue
+		}
+		if strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("%w: %q has more than one wildcard", ErrInvalidCORSPolicy, origin)
+		}
+	}
+
+	cfg := p.base
+	cfg.AllowOrigins = policy.AllowedOrigins
+	cfg.AllowWildcard = true
+	cfg.AllowHeaders = append(append([]string{}, p.base.AllowHeaders...), policy.AllowedHeaders...)
+	cfg.AllowCredentials = policy.AllowCredentials
+	cfg.MaxAge = time.Duration(policy.MaxAgeSeconds) * time.Second
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidCORSPolicy, err.Error())
+	}
+
+	handler := cors.New(cfg)
+	p.handler.Store(&handler)
+	return nil
+}
+
+// Middleware applies the current policy to each request
+func (p *CORSPolicy) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		(*p.handler.Load())(c)
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/config"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	base := cors.Config{AllowMethods: []string{"GET", "POST"}, AllowHeaders: []string{"Content-Type", "Authorization"}}
+	policy, err := NewCORSPolicy(base, config.CORS{
+		AllowedOrigins:   []string{"https://shop.example.com", "https://*.example.com"},
+		AllowedHeaders:   []string{"X-Tenant"},
+		AllowCredentials: true,
+		MaxAgeSeconds:    600,
+	})
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(policy.Middleware())
+	r.GET("/api/products", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/products", nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", "GET")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "https://shop.example.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://shop.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+	w = request(http.MethodOptions, "https://admin.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code, "wildcard subdomains are allowed")
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "X-Tenant")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization", "the API's own headers stay allowed")
+
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "https://example.org").Code)
+
+	t.Run("update", func(t *testing.T) {
+		assert.ErrorIs(t, policy.Update(config.CORS{}), ErrInvalidCORSPolicy)
+		assert.ErrorIs(t, policy.Update(config.CORS{AllowedOrigins: []string{"*"}, AllowCredentials: true}), ErrInvalidCORSPolicy)
+		assert.ErrorIs(t, policy.Update(config.CORS{AllowedOrigins: []string{"https://*.*.example.com"}}), ErrInvalidCORSPolicy)
+		assert.ErrorIs(t, policy.Update(config.CORS{AllowedOrigins: []string{"example.org"}}), ErrInvalidCORSPolicy)
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "https://shop.example.com").Code, "rejected updates keep the current policy")
+
+		require.NoError(t, policy.Update(config.CORS{AllowedOrigins: []string{"https://example.org"}}))
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "https://example.org").Code)
+		assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "https://shop.example.com").Code)
+	})
+}
@@ -1,9 +1,13 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"ecommerce-website/internal/database"
@@ -13,6 +17,19 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+	// RateLimitWarningHeader is set once a client has used 90% or more of its limit
+	RateLimitWarningHeader = "X-RateLimit-Warning"
+	// RateLimitBackoffHeader recommends how many seconds a warned client should wait between requests
+	// to spread its remaining requests over the rest of the window
+	RateLimitBackoffHeader = "X-RateLimit-Backoff"
+
+	// rateLimitWarningRatio is the share of the limit left when warnings start
+	rateLimitWarningRatio = 0.1
+	// maxTrackedRateLimitClients bounds the per-client metrics; further clients only count in the totals
+	maxTrackedRateLimitClients = 1000
+)
+
 // RateLimitConfig defines rate limiting configuration
 type RateLimitConfig struct {
 	Requests int                       // Number of requests allowed
@@ -33,74 +50,290 @@ func AuthenticatedUserKeyFunc(c *gin.Context) string {
 	return fmt.Sprintf("rate_limit:ip:%s", c.ClientIP())
 }
 
-// RateLimitMiddleware creates a rate limiting middleware
+// APIKeyKeyFunc generates a rate limit key based on the authenticated API key, otherwise IP
+func APIKeyKeyFunc(c *gin.Context) string {
+	if keyID, exists := c.Get("api_key_id"); exists {
+		return fmt.Sprintf("rate_limit:api_key:%s", keyID)
+	}
+	return fmt.Sprintf("rate_limit:ip:%s", c.ClientIP())
+}
+
+// ClientKeyFunc keys limits by the API key or user a request is authenticated as, and by client IP
+// otherwise. identify resolves the user of requests whose route has not authenticated them yet, such as
+// when the limiter runs ahead of every route; it must only return verified identities.
+func ClientKeyFunc(identify func(*gin.Context) (string, bool)) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		if keyID, exists := c.Get("api_key_id"); exists {
+			return fmt.Sprintf("rate_limit:api_key:%s", keyID)
+		}
+		if userID, exists := c.Get("user_id"); exists {
+			return fmt.Sprintf("rate_limit:user:%s", userID)
+		}
+		if identify != nil {
+			if userID, ok := identify(c); ok {
+				return fmt.Sprintf("rate_limit:user:%s", userID)
+			}
+		}
+		return fmt.Sprintf("rate_limit:ip:%s", c.ClientIP())
+	}
+}
+
+// tokenBucketScript takes a token from the bucket at KEYS[1], holding ARGV[1] tokens and refilling them all
+// over ARGV[2] milliseconds. It runs on the Redis clock so every server replica sees the same bucket. It
+// returns whether a token was taken, the whole tokens left, and the milliseconds until the bucket is full
+// again and until the next token.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+local rate = capacity / window
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local taken = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	taken = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], window)
+local untilToken = 0
+if tokens < 1 then
+	untilToken = math.ceil((1 - tokens) / rate)
+end
+return {taken, math.floor(tokens), math.ceil((capacity - tokens) / rate), untilToken}
+`)
+
+// bucketState is the outcome of taking a token from a client's bucket
+type bucketState struct {
+	Taken      bool
+	Remaining  int
+	UntilFull  time.Duration
+	UntilToken time.Duration
+}
+
+// takeToken takes a token from the bucket at key
+func takeToken(ctx context.Context, rdb *redis.Client, key string, config RateLimitConfig) (bucketState, error) {
+	result, err := tokenBucketScript.Run(ctx, rdb, []string{key}, config.Requests, config.Window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return bucketState{}, err
+	}
+	if len(result) != 4 {
+		return bucketState{}, fmt.Errorf("unexpected token bucket result %v", result)
+	}
+	return bucketState{
+		Taken:      result[0] == 1,
+		Remaining:  int(result[1]),
+		UntilFull:  time.Duration(result[2]) * time.Millisecond,
+		UntilToken: time.Duration(result[3]) * time.Millisecond,
+	}, nil
+}
+
+// RateLimitMiddleware limits each client to config.Requests requests per config.Window with a token bucket
+// kept in Redis, so the limit holds across server replicas and bursts of up to the whole limit are allowed
+// after a quiet period. X-RateLimit-Remaining is the number of requests the client can make right away and
+// X-RateLimit-Reset when its bucket is full again. Requests go through unlimited while Redis is unavailable.
 func RateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		key := config.KeyFunc(c)
+		applyRateLimit(c, config.KeyFunc(c), config)
+	}
+}
 
-		// Get Redis client
-		rdb := database.GetRedisClient()
-		if rdb == nil {
-			// If Redis is not available, allow the request to proceed
-			c.Next()
-			return
-		}
+// applyRateLimit takes a token from the bucket at key, answering 429 RATE_LIMIT_EXCEEDED when it is empty
+func applyRateLimit(c *gin.Context, key string, config RateLimitConfig) {
+	rdb := database.GetRedisClient()
+	if rdb == nil {
+		// If Redis is not available, allow the request to proceed
+		c.Next()
+		return
+	}
 
-		ctx := c.Request.Context()
+	bucket, err := takeToken(c.Request.Context(), rdb, key, config)
+	if err != nil {
+		// If Redis error, allow the request to proceed
+		c.Next()
+		return
+	}
 
-		// Get current count
-		current, err := rdb.Get(ctx, key).Result()
-		if err != nil && err != redis.Nil {
-			// If Redis error, allow the request to proceed
-			c.Next()
-			return
-		}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(config.Requests))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(bucket.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(bucket.UntilFull).Unix(), 10))
 
-		var count int
-		if current != "" {
-			count, _ = strconv.Atoi(current)
-		}
+	if !bucket.Taken {
+		RecordRateLimitRejection(key)
+		retryAfter := max(int(math.Ceil(bucket.UntilToken.Seconds())), 1)
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		utils.ErrorResponse(c, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED",
+			fmt.Sprintf("Rate limit exceeded. Try again in %ds", retryAfter), nil)
+		c.Abort()
+		return
+	}
+
+	// Warn clients close to the limit so they can slow down before getting 429s
+	if nearRateLimit(bucket.Remaining, config.Requests) {
+		RecordRateLimitWarning(key)
+		c.Header(RateLimitWarningHeader, fmt.Sprintf("%d of %d requests remaining", bucket.Remaining, config.Requests))
+		c.Header(RateLimitBackoffHeader, strconv.Itoa(backoffSeconds(bucket.Remaining, bucket.UntilFull)))
+	}
 
-		// Check if rate limit exceeded
-		if count >= config.Requests {
-			// Get TTL to inform client when they can retry
-			ttl, _ := rdb.TTL(ctx, key).Result()
+	c.Next()
+}
 
-			c.Header("X-RateLimit-Limit", strconv.Itoa(config.Requests))
-			c.Header("X-RateLimit-Remaining", "0")
-			c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
+// RateLimitRule limits the requests under a path prefix
+type RateLimitRule struct {
+	PathPrefix string
+	Requests   int
+	Window     time.Duration
+}
 
-			utils.ErrorResponse(c, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED",
-				fmt.Sprintf("Rate limit exceeded. Try again in %v", ttl), nil)
-			c.Abort()
-			return
+// ParseRateLimitRules parses comma-separated rules of the form "/api/auth=5/1m", each a path prefix and the
+// requests allowed per window
+func ParseRateLimitRules(value string) ([]RateLimitRule, error) {
+	var rules []RateLimitRule
+	for _, part := range strings.Split(value, ",") {
+		entry := strings.TrimSpace(part)
+		if entry == "" {
+			continue
+		}
+		prefix, limit, ok := strings.Cut(entry, "=")
+		requests, window, ok2 := strings.Cut(limit, "/")
+		if !ok || !ok2 || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid rate limit %q: expected /path=requests/window", entry)
 		}
+		count, err := strconv.Atoi(strings.TrimSpace(requests))
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid rate limit %q: requests must be a positive number", entry)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(window))
+		if err != nil || duration < time.Millisecond {
+			return nil, fmt.Errorf("invalid rate limit %q: window must be a duration such as 1m", entry)
+		}
+		rules = append(rules, RateLimitRule{PathPrefix: strings.TrimSpace(prefix), Requests: count, Window: duration})
+	}
+	return rules, nil
+}
 
-		// Increment counter
-		pipe := rdb.Pipeline()
-		pipe.Incr(ctx, key)
-		if count == 0 {
-			// Set expiration only for the first request
-			pipe.Expire(ctx, key, config.Window)
+// matchRateLimitRule returns the rule with the longest prefix covering path, matching whole path segments
+func matchRateLimitRule(rules []RateLimitRule, path string) (RateLimitRule, bool) {
+	var match RateLimitRule
+	found := false
+	for _, rule := range rules {
+		prefix := strings.TrimSuffix(rule.PathPrefix, "/")
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		if !found || len(rule.PathPrefix) > len(match.PathPrefix) {
+			match, found = rule, true
 		}
-		_, err = pipe.Exec(ctx)
-		if err != nil {
-			// If Redis error, allow the request to proceed
+	}
+	return match, found
+}
+
+// RouteRateLimitMiddleware limits each client per route group: a request is counted against the rule with
+// the longest path prefix covering it, versioned API paths counting as their unversioned ones, and each rule
+// keeps its own bucket per client. Requests no rule covers are not limited.
+func RouteRateLimitMiddleware(rules []RateLimitRule, keyFunc func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, ok := matchRateLimitRule(rules, utils.UnversionedPath(c.Request.URL.Path))
+		if !ok {
 			c.Next()
 			return
 		}
+		applyRateLimit(c, keyFunc(c)+":"+rule.PathPrefix, RateLimitConfig{Requests: rule.Requests, Window: rule.Window})
+	}
+}
 
-		// Set rate limit headers
-		remaining := config.Requests - count - 1
-		if remaining < 0 {
-			remaining = 0
-		}
+// nearRateLimit reports whether a client with remaining requests left is within 10% of its limit
+func nearRateLimit(remaining, limit int) bool {
+	return float64(remaining) <= float64(limit)*rateLimitWarningRatio
+}
 
-		c.Header("X-RateLimit-Limit", strconv.Itoa(config.Requests))
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+// backoffSeconds spreads the remaining requests evenly over the time left in the window.
+// With nothing left the client should wait for the window to reset.
+func backoffSeconds(remaining int, untilReset time.Duration) int {
+	seconds := untilReset.Seconds() / float64(remaining+1)
+	return max(int(math.Ceil(seconds)), 1)
+}
 
-		c.Next()
+// ClientRateLimitStats counts how often one rate limit key was warned and rejected
+type ClientRateLimitStats struct {
+	Warnings   int64     `json:"warnings"`
+	Rejections int64     `json:"rejections"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// RateLimitMetrics tracks rate limit warnings and rejections for monitoring
+type RateLimitMetrics struct {
+	TotalWarnings   int64                           `json:"total_warnings"`
+	TotalRejections int64                           `json:"total_rejections"`
+	Clients         map[string]ClientRateLimitStats `json:"clients"`
+	LastUpdated     time.Time                       `json:"last_updated"`
+}
+
+var (
+	rateLimitMetricsMu sync.Mutex
+	rateLimitMetrics   = &RateLimitMetrics{Clients: make(map[string]ClientRateLimitStats)}
+)
+
+// RecordRateLimitWarning records a warning sent to the client of a rate limit key
+func RecordRateLimitWarning(key string) {
+	recordRateLimit(key, false)
+}
+
+// RecordRateLimitRejection records a request rejected for the client of a rate limit key
+func RecordRateLimitRejection(key string) {
+	recordRateLimit(key, true)
+}
+
+func recordRateLimit(key string, rejected bool) {
+	rateLimitMetricsMu.Lock()
+	defer rateLimitMetricsMu.Unlock()
+
+	now := time.Now()
+	rateLimitMetrics.LastUpdated = now
+	if rejected {
+		rateLimitMetrics.TotalRejections++
+	} else {
+		rateLimitMetrics.TotalWarnings++
+	}
+
+	stats, tracked := rateLimitMetrics.Clients[key]
+	if !tracked && len(rateLimitMetrics.Clients) >= maxTrackedRateLimitClients {
+		return
+	}
+	if rejected {
+		stats.Rejections++
+	} else {
+		stats.Warnings++
 	}
+	stats.LastSeen = now
+	rateLimitMetrics.Clients[key] = stats
+}
+
+// GetRateLimitMetrics returns a copy of the current rate limit metrics
+func GetRateLimitMetrics() *RateLimitMetrics {
+	rateLimitMetricsMu.Lock()
+	defer rateLimitMetricsMu.Unlock()
+
+	metrics := *rateLimitMetrics
+	metrics.Clients = make(map[string]ClientRateLimitStats, len(rateLimitMetrics.Clients))
+	for key, stats := range rateLimitMetrics.Clients {
+		metrics.Clients[key] = stats
+	}
+	return &metrics
+}
+
+// ResetRateLimitMetrics resets rate limit metrics (useful for testing)
+func ResetRateLimitMetrics() {
+	rateLimitMetricsMu.Lock()
+	defer rateLimitMetricsMu.Unlock()
+	rateLimitMetrics = &RateLimitMetrics{Clients: make(map[string]ClientRateLimitStats)}
 }
 
 // Common rate limit configurations
@@ -112,24 +345,30 @@ var (
 		KeyFunc:  DefaultKeyFunc,
 	}
 
-	// Authentication rate limit: 5 attempts per minute
-	AuthRateLimit = RateLimitConfig{
+	// Magic link rate limit: 5 requests per 15 minutes per client, counted separately for requesting
+	// and consuming links
+	MagicLinkRateLimit = RateLimitConfig{
 		Requests: 5,
-		Window:   time.Minute,
-		KeyFunc:  DefaultKeyFunc,
+		Window:   15 * time.Minute,
+		KeyFunc: func(c *gin.Context) string {
+			return fmt.Sprintf("rate_limit:%s:%s", utils.UnversionedPath(c.FullPath()), c.ClientIP())
+		},
 	}
 
-	// Admin operations rate limit: 50 requests per minute
-	AdminRateLimit = RateLimitConfig{
-		Requests: 50,
-		Window:   time.Minute,
-		KeyFunc:  AuthenticatedUserKeyFunc,
+	// Two-factor rate limit: 5 attempts per 15 minutes per signed-in user, so the six digits of a code
+	// cannot be guessed. It runs after the auth middleware, which sets the user.
+	TwoFactorRateLimit = RateLimitConfig{
+		Requests: 5,
+		Window:   15 * time.Minute,
+		KeyFunc: func(c *gin.Context) string {
+			return fmt.Sprintf("rate_limit:%s:user:%s", utils.UnversionedPath(c.FullPath()), c.GetString("user_id"))
+		},
 	}
 
-	// Payment operations rate limit: 10 requests per minute
-	PaymentRateLimit = RateLimitConfig{
-		Requests: 10,
+	// Affiliate public API rate limit: 30 requests per minute per API key
+	AffiliateRateLimit = RateLimitConfig{
+		Requests: 30,
 		Window:   time.Minute,
-		KeyFunc:  AuthenticatedUserKeyFunc,
+		KeyFunc:  APIKeyKeyFunc,
 	}
 )
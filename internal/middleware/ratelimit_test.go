@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNearRateLimit(t *testing.T) {
+	assert.False(t, nearRateLimit(11, 100))
+	assert.True(t, nearRateLimit(10, 100))
+	assert.True(t, nearRateLimit(0, 100))
+	assert.False(t, nearRateLimit(1, 5), "small limits only warn on the last request")
+	assert.True(t, nearRateLimit(0, 5))
+}
+
+func TestBackoffSeconds(t *testing.T) {
+	assert.Equal(t, 5, backoffSeconds(9, 50*time.Second))
+	assert.Equal(t, 4, backoffSeconds(9, 31*time.Second), "rounds up")
+	assert.Equal(t, 40, backoffSeconds(0, 40*time.Second), "waits for the reset when nothing is left")
+	assert.Equal(t, 1, backoffSeconds(10, 100*time.Millisecond), "never recommends less than a second")
+}
+
+func TestRateLimitMetrics(t *testing.T) {
+	ResetRateLimitMetrics()
+	defer ResetRateLimitMetrics()
+
+	RecordRateLimitWarning("rate_limit:1.2.3.4")
+	RecordRateLimitWarning("rate_limit:1.2.3.4")
+	RecordRateLimitRejection("rate_limit:1.2.3.4")
+	RecordRateLimitWarning("rate_limit:user:u1")
+
+	metrics := GetRateLimitMetrics()
+	assert.Equal(t, int64(3), metrics.TotalWarnings)
+	assert.Equal(t, int64(1), metrics.TotalRejections)
+	assert.Equal(t, int64(2), metrics.Clients["rate_limit:1.2.3.4"].Warnings)
+	assert.Equal(t, int64(1), metrics.Clients["rate_limit:1.2.3.4"].Rejections)
+	assert.Equal(t, int64(1), metrics.Clients["rate_limit:user:u1"].Warnings)
+
+	metrics.Clients["rate_limit:user:u1"] = ClientRateLimitStats{}
+	assert.Equal(t, int64(1), GetRateLimitMetrics().Clients["rate_limit:user:u1"].Warnings, "callers get a copy")
+
+	for i := 0; i < maxTrackedRateLimitClients; i++ {
+		RecordRateLimitWarning(fmt.Sprintf("rate_limit:client-%d", i))
+	}
+	metrics = GetRateLimitMetrics()
+	assert.Len(t, metrics.Clients, maxTrackedRateLimitClients)
+	assert.Equal(t, int64(3+maxTrackedRateLimitClients), metrics.TotalWarnings, "untracked clients still count in the totals")
+}
+
+func TestParseRateLimitRules(t *testing.T) {
+	rules, err := ParseRateLimitRules(" /=100/1m, /api/auth/login=5/30s ,")
+	require.NoError(t, err)
+	assert.Equal(t, []RateLimitRule{
+		{PathPrefix: "/", Requests: 100, Window: time.Minute},
+		{PathPrefix: "/api/auth/login", Requests: 5, Window: 30 * time.Second},
+	}, rules)
+
+	for _, value := range []string{"api=5/1m", "/api=0/1m", "/api=5", "/api=5/soon"} {
+		_, err := ParseRateLimitRules(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestMatchRateLimitRule(t *testing.T) {
+	rules, err := ParseRateLimitRules("/=100/1m,/api/admin=50/1m,/api/admin/reports=5/1m")
+	require.NoError(t, err)
+
+	for path, prefix := range map[string]string{
+		"/health":                 "/",
+		"/api/admin":              "/api/admin",
+		"/api/admin/orders":       "/api/admin",
+		"/api/administrators":     "/",
+		"/api/admin/reports/sale": "/api/admin/reports",
+	} {
+		rule, ok := matchRateLimitRule(rules, path)
+		require.True(t, ok, path)
+		assert.Equal(t, prefix, rule.PathPrefix, path)
+	}
+
+	_, ok := matchRateLimitRule(rules[1:], "/api/products")
+	assert.False(t, ok, "paths no rule covers are not limited")
+}
+
+func TestClientKeyFunc(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	identify := func(c *gin.Context) (string, bool) {
+		if c.GetHeader("Authorization") == "Bearer valid" {
+			return "u1", true
+		}
+		return "", false
+	}
+	keyFunc := ClientKeyFunc(identify)
+
+	newContext := func(authorization string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/products", nil)
+		c.Request.RemoteAddr = "203.0.113.7:5000"
+		c.Request.Header.Set("Authorization", authorization)
+		return c
+	}
+
+	assert.Equal(t, "rate_limit:ip:203.0.113.7", keyFunc(newContext("Bearer forged")))
+	assert.Equal(t, "rate_limit:user:u1", keyFunc(newContext("Bearer valid")))
+
+	c := newContext("")
+	c.Set("user_id", "u2")
+	assert.Equal(t, "rate_limit:user:u2", keyFunc(c))
+	c.Set("api_key_id", "key-1")
+	assert.Equal(t, "rate_limit:api_key:key-1", keyFunc(c), "API keys take precedence")
+}
+
+func TestTwoFactorRateLimitKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var keys []string
+	r.POST("/api/v1/auth/2fa/verify", func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-User"))
+		keys = append(keys, TwoFactorRateLimit.KeyFunc(c))
+	})
+
+	for _, user := range []string{"u1", "u2"} {
+		req := httptest.NewRequest("POST", "/api/v1/auth/2fa/verify", nil)
+		req.Header.Set("X-User", user)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, []string{"rate_limit:/api/auth/2fa/verify:user:u1", "rate_limit:/api/auth/2fa/verify:user:u2"}, keys,
+		"attempts are counted per user, whichever address they come from")
+}
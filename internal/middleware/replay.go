@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ecommerce-website/internal/database"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestTimestampHeader carries the client's Unix time (seconds) when the request was signed off
+	RequestTimestampHeader = "X-Request-Timestamp"
+	// RequestNonceHeader carries a client-generated value that is unique per request
+	RequestNonceHeader = "X-Request-Nonce"
+)
+
+// ReplayProtectionConfig defines replay protection configuration
+type ReplayProtectionConfig struct {
+	MaxSkew time.Duration             // How far the request timestamp may drift from server time
+	KeyFunc func(*gin.Context) string // Function to scope nonces, so clients cannot burn each other's nonces
+}
+
+// SensitiveReplayProtection guards payment confirmation and destructive admin actions
+var SensitiveReplayProtection = ReplayProtectionConfig{
+	MaxSkew: 5 * time.Minute,
+	KeyFunc: ReplayScopeKeyFunc,
+}
+
+// ReplayScopeKeyFunc scopes nonces to the authenticated user, otherwise to the client IP
+func ReplayScopeKeyFunc(c *gin.Context) string {
+	if userID, exists := c.Get("user_id"); exists {
+		return fmt.Sprintf("replay_nonce:user:%s", userID)
+	}
+	return fmt.Sprintf("replay_nonce:ip:%s", c.ClientIP())
+}
+
+// ReplayProtectionMiddleware rejects stale requests and requests whose nonce was already used.
+// Nonces are remembered in Redis for twice the allowed skew, which covers every timestamp that
+// would still be accepted.
+//
+// The timestamp and nonce are not signed, so this only guards against accidental double submits
+// (retried taps, resent forms, naive client retries). A client that can build the request can also
+// pick fresh values, so it must never be the only check on a route: callers stay authenticated by
+// their bearer token and, for payment verification, by the Razorpay signature and intent token.
+func ReplayProtectionMiddleware(config ReplayProtectionConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		nonce := c.GetHeader(RequestNonceHeader)
+		rawTimestamp := c.GetHeader(RequestTimestampHeader)
+		if nonce == "" || rawTimestamp == "" {
+			utils.ErrorResponse(c, http.StatusBadRequest, "REPLAY_HEADERS_MISSING",
+				fmt.Sprintf("%s and %s headers are required", RequestTimestampHeader, RequestNonceHeader), nil)
+			c.Abort()
+			return
+		}
+		if len(nonce) < 16 || len(nonce) > 128 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST_NONCE", "Request nonce must be 16 to 128 characters", nil)
+			c.Abort()
+			return
+		}
+
+		timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST_TIMESTAMP", "Request timestamp must be Unix time in seconds", nil)
+			c.Abort()
+			return
+		}
+
+		now := time.Now()
+		if skew := requestSkew(timestamp, now); math.Abs(skew.Seconds()) > config.MaxSkew.Seconds() {
+			// Tell the client how far off its clock is so it can correct instead of retrying blindly
+			utils.ErrorResponse(c, http.StatusUnauthorized, "REQUEST_TIMESTAMP_SKEWED",
+				"Request timestamp is outside the allowed window; check the device clock", gin.H{
+					"serverTime":     now.Unix(),
+					"skewSeconds":    int64(skew.Seconds()),
+					"maxSkewSeconds": int64(config.MaxSkew.Seconds()),
+				})
+			c.Abort()
+			return
+		}
+
+		rdb := database.GetRedisClient()
+		if rdb == nil {
+			// If Redis is not available, the timestamp check still bounds the replay window
+			c.Next()
+			return
+		}
+
+		key := config.KeyFunc(c) + ":" + nonce
+		fresh, err := rdb.SetNX(c.Request.Context(), key, timestamp, 2*config.MaxSkew).Result()
+		if err != nil {
+			// If Redis error, allow the request to proceed
+			c.Next()
+			return
+		}
+		if !fresh {
+			utils.ErrorResponse(c, http.StatusConflict, "REQUEST_REPLAYED", "This request has already been processed", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requestSkew returns how far ahead (positive) or behind (negative) of now a request timestamp is
+func requestSkew(timestamp int64, now time.Time) time.Duration {
+	return time.Unix(timestamp, 0).Sub(now.Truncate(time.Second))
+}
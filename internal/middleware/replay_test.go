@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupReplayRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.POST("/confirm", ReplayProtectionMiddleware(SensitiveReplayProtection), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func sendReplayRequest(r *gin.Engine, timestamp, nonce string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/confirm", nil)
+	if timestamp != "" {
+		req.Header.Set(RequestTimestampHeader, timestamp)
+	}
+	if nonce != "" {
+		req.Header.Set(RequestNonceHeader, nonce)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body.Error.Code
+}
+
+func TestReplayProtectionMiddleware_Validation(t *testing.T) {
+	previous := database.RedisClient
+	database.RedisClient = nil
+	defer func() { database.RedisClient = previous }()
+
+	r := setupReplayRouter()
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := "3f1c9a7e-5b2d-4e8f"
+
+	tests := []struct {
+		name      string
+		timestamp string
+		nonce     string
+		status    int
+		code      string
+	}{
+		{"missing headers", "", "", http.StatusBadRequest, "REPLAY_HEADERS_MISSING"},
+		{"short nonce", now, "abc", http.StatusBadRequest, "INVALID_REQUEST_NONCE"},
+		{"malformed timestamp", "yesterday", nonce, http.StatusBadRequest, "INVALID_REQUEST_TIMESTAMP"},
+		{"stale timestamp", strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10), nonce, http.StatusUnauthorized, "REQUEST_TIMESTAMP_SKEWED"},
+		{"clock ahead", strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10), nonce, http.StatusUnauthorized, "REQUEST_TIMESTAMP_SKEWED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := sendReplayRequest(r, tt.timestamp, tt.nonce)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.code, errorCode(t, w))
+		})
+	}
+
+	t.Run("skew details help clients correct their clock", func(t *testing.T) {
+		w := sendReplayRequest(r, strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10), nonce)
+		assert.Contains(t, w.Body.String(), `"maxSkewSeconds":300`)
+		assert.Contains(t, w.Body.String(), `"serverTime"`)
+	})
+
+	t.Run("fresh request passes", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, sendReplayRequest(r, now, nonce).Code)
+	})
+}
+
+func TestReplayProtectionMiddleware_RejectsReplayedNonce(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 1})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skip("Redis is not available, skipping Redis-dependent tests")
+	}
+	previous := database.RedisClient
+	database.RedisClient = client
+	defer func() {
+		database.RedisClient = previous
+		client.Close()
+	}()
+
+	r := setupReplayRouter()
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := "replay-test-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	assert.Equal(t, http.StatusOK, sendReplayRequest(r, now, nonce).Code)
+
+	w := sendReplayRequest(r, now, nonce)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "REQUEST_REPLAYED", errorCode(t, w))
+}
+
+func TestRequestSkew(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	assert.Equal(t, 90*time.Second, requestSkew(now.Add(90*time.Second).Unix(), now))
+	assert.Equal(t, -30*time.Second, requestSkew(now.Add(-30*time.Second).Unix(), now))
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultRequestTimeout bounds a request unless its route sets another timeout
+	DefaultRequestTimeout = 30 * time.Second
+	// LongRequestTimeout is for routes that stream exports or accept uploads
+	LongRequestTimeout = 2 * time.Minute
+
+	// timeoutParentKey holds the request context from before any timeout, so a route timeout replaces
+	// the global one instead of only being able to shorten it
+	timeoutParentKey = "timeout_parent_context"
+)
+
+// TimeoutMiddleware gives the handlers of a request timeout to finish. The deadline is set on the request
+// context, which services pass to database and Redis calls, so slow queries are cancelled; handlers that
+// fail because of it answer 504 (see utils.ErrorResponse), as does a request that ends past its deadline
+// without a response. Installed again on a route, the later timeout replaces the earlier one. A timeout of
+// zero or less leaves requests unbounded, lifting an earlier timeout for routes that stream until the
+// client leaves.
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parent := c.Request.Context()
+		if stored, ok := c.Get(timeoutParentKey); ok {
+			parent = stored.(context.Context)
+		} else {
+			c.Set(timeoutParentKey, parent)
+		}
+		if timeout <= 0 {
+			c.Request = c.Request.WithContext(parent)
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			utils.ErrorResponse(c, http.StatusGatewayTimeout, utils.CodeRequestTimeout, "The request took too long to process", nil)
+			c.Abort()
+		}
+	}
+}
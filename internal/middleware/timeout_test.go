@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTimeoutRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	// slow stands in for a handler whose database call is cancelled at the deadline
+	slow := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			utils.ErrorResponse(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to run query", c.Request.Context().Err().Error())
+		case <-time.After(50 * time.Millisecond):
+			utils.SuccessResponse(c, http.StatusOK, "Done", nil)
+		}
+	}
+
+	r := gin.New()
+	r.Use(TimeoutMiddleware(20 * time.Millisecond))
+	r.GET("/slow", slow)
+	r.GET("/export", TimeoutMiddleware(time.Second), slow)
+	r.GET("/stream", TimeoutMiddleware(0), slow)
+	r.GET("/silent", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+	return r
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	r := setupTimeoutRouter()
+	send := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("answers 504 when a handler fails at the deadline", func(t *testing.T) {
+		w := send("/slow")
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+		var response utils.ApiResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, utils.CodeRequestTimeout, response.Error.Code)
+	})
+
+	t.Run("answers 504 when a handler writes nothing", func(t *testing.T) {
+		w := send("/silent")
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	})
+
+	t.Run("route timeout replaces the global one", func(t *testing.T) {
+		w := send("/export")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("route without a timeout lifts the global one", func(t *testing.T) {
+		w := send("/stream")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,141 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"ecommerce-website/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testFiles = fstest.MapFS{
+	"000001_create_widgets.up.sql":    {Data: []byte("CREATE TABLE widgets (id TEXT PRIMARY KEY);")},
+	"000001_create_widgets.down.sql":  {Data: []byte("DROP TABLE widgets;")},
+	"000002_add_widget_name.up.sql":   {Data: []byte("ALTER TABLE widgets ADD COLUMN name TEXT;\nCREATE INDEX idx_widgets_name ON widgets(name);")},
+	"000002_add_widget_name.down.sql": {Data: []byte("DROP INDEX idx_widgets_name;\nALTER TABLE widgets DROP COLUMN name;")},
+	"000003_create_gadgets.up.sql":    {Data: []byte("CREATE TABLE gadgets (id TEXT PRIMARY KEY);")},
+	"000003_create_gadgets.down.sql":  {Data: []byte("DROP TABLE gadgets;")},
+	"README.md":                       {Data: []byte("not a migration")},
+}
+
+func setupMigrator(t *testing.T) (*gorm.DB, *Migrator) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	migrator, err := New(db, testFiles)
+	require.NoError(t, err)
+	return db, migrator
+}
+
+func versions(migrations []Migration) []int64 {
+	result := make([]int64, len(migrations))
+	for i, migration := range migrations {
+		result[i] = migration.Version
+	}
+	return result
+}
+
+func TestMigrator(t *testing.T) {
+	db, migrator := setupMigrator(t)
+	ctx := context.Background()
+
+	t.Run("applies up to a version", func(t *testing.T) {
+		ran, err := migrator.To(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, versions(ran))
+		assert.True(t, db.Migrator().HasColumn("widgets", "name"))
+		assert.False(t, db.Migrator().HasTable("gadgets"))
+
+		pending, err := migrator.Pending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3}, versions(pending))
+	})
+
+	t.Run("reports status", func(t *testing.T) {
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 3)
+		assert.Equal(t, "create_widgets", statuses[0].Name)
+		assert.NotNil(t, statuses[1].AppliedAt)
+		assert.Nil(t, statuses[2].AppliedAt)
+	})
+
+	t.Run("applies the rest", func(t *testing.T) {
+		ran, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3}, versions(ran))
+		assert.True(t, db.Migrator().HasTable("gadgets"))
+
+		ran, err = migrator.Up(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, ran)
+	})
+
+	t.Run("reverts the latest", func(t *testing.T) {
+		reverted, err := migrator.Down(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, reverted)
+		assert.EqualValues(t, 3, reverted.Version)
+		assert.False(t, db.Migrator().HasTable("gadgets"))
+	})
+
+	t.Run("reverts down to a version", func(t *testing.T) {
+		ran, err := migrator.To(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2}, versions(ran))
+		assert.False(t, db.Migrator().HasColumn("widgets", "name"))
+
+		ran, err = migrator.To(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1}, versions(ran))
+		assert.False(t, db.Migrator().HasTable("widgets"))
+
+		reverted, err := migrator.Down(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, reverted)
+	})
+
+	t.Run("rejects unknown versions", func(t *testing.T) {
+		_, err := migrator.To(ctx, 7)
+		assert.ErrorIs(t, err, ErrUnknownVersion)
+	})
+}
+
+func TestMigrator_FailedMigrationIsNotRecorded(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	migrator, err := New(db, fstest.MapFS{
+		"000001_create_widgets.up.sql":   testFiles["000001_create_widgets.up.sql"],
+		"000001_create_widgets.down.sql": testFiles["000001_create_widgets.down.sql"],
+		"000002_broken.up.sql":           {Data: []byte("ALTER TABLE missing ADD COLUMN name TEXT;")},
+		"000002_broken.down.sql":         {Data: []byte("SELECT 1;")},
+	})
+	require.NoError(t, err)
+
+	ran, err := migrator.Up(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, []int64{1}, versions(ran))
+
+	pending, err := migrator.Pending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, versions(pending))
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("requires a down file", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"000001_create_widgets.up.sql": testFiles["000001_create_widgets.up.sql"]})
+		assert.ErrorContains(t, err, "needs both an up and a down file")
+	})
+
+	t.Run("loads the schema migrations", func(t *testing.T) {
+		loaded, err := Load(migrations.Files)
+		require.NoError(t, err)
+		require.NotEmpty(t, loaded)
+		assert.EqualValues(t, 1, loaded[0].Version)
+	})
+}
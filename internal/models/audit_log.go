@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLog records one mutation made by an admin: who made it from where, the route and resource it
+// touched, the request payload with secrets redacted and, for resources the audit module can load, the
+// fields it changed as {"field": {"from": ..., "to": ...}}. Entries are never updated or deleted.
+type AuditLog struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	ActorID      string    `json:"actorId" gorm:"not null;index"`
+	ActorEmail   string    `json:"actorEmail"`
+	ActorRole    string    `json:"actorRole" gorm:"type:varchar(30)"`
+	IP           string    `json:"ip" gorm:"type:varchar(45)"`
+	UserAgent    string    `json:"userAgent"`
+	RequestID    string    `json:"requestId,omitempty"`
+	Method       string    `json:"method" gorm:"type:varchar(10);not null"`
+	Route        string    `json:"route" gorm:"not null"`
+	Path         string    `json:"path" gorm:"not null"`
+	Action       string    `json:"action" gorm:"type:varchar(100);not null;index"`
+	ResourceType string    `json:"resourceType" gorm:"type:varchar(50);index:idx_audit_logs_resource"`
+	ResourceID   string    `json:"resourceId,omitempty" gorm:"index:idx_audit_logs_resource"`
+	Status       int       `json:"status"`
+	Payload      JSONB     `json:"payload,omitempty" gorm:"type:jsonb"`
+	Changes      JSONB     `json:"changes,omitempty" gorm:"type:jsonb"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"index"`
+}
+
+// BeforeCreate hook to generate UUID
+func (l *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BusEvent is a store event waiting to be published to the event bus, or already published. It is written in
+// the transaction that makes the change it describes, so only committed changes are ever published. Payload is
+// the exact message body, so every attempt publishes the same bytes.
+type BusEvent struct {
+	ID            string     `json:"id" gorm:"primaryKey"`
+	Type          string     `json:"type" gorm:"type:varchar(50);not null;index"`
+	Version       int        `json:"version" gorm:"not null"`
+	Key           string     `json:"key" gorm:"type:varchar(100);not null"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty" gorm:"index"`
+	LastError     string     `json:"lastError,omitempty" gorm:"type:text"`
+	PublishedAt   *time.Time `json:"publishedAt,omitempty" gorm:"index"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"index"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *BusEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
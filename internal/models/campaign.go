@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Email campaign statuses. A campaign is queued when created, sending once the worker picks it up and
+// completed when every recipient has been handled.
+const (
+	CampaignQueued    = "queued"
+	CampaignSending   = "sending"
+	CampaignCompleted = "completed"
+	CampaignCancelled = "cancelled"
+)
+
+// Campaign recipient statuses
+const (
+	CampaignRecipientPending    = "pending"
+	CampaignRecipientSent       = "sent"
+	CampaignRecipientSuppressed = "suppressed"
+	CampaignRecipientFailed     = "failed"
+)
+
+// EmailCampaign is a templated marketing email sent to a customer segment in throttled batches
+type EmailCampaign struct {
+	ID            string     `json:"id" gorm:"primaryKey"`
+	Name          string     `json:"name" gorm:"not null"`
+	Subject       string     `json:"subject" gorm:"not null"`
+	Body          string     `json:"body" gorm:"type:text;not null"` // html/template source
+	Segment       JSONB      `json:"segment" gorm:"type:jsonb"`
+	TrackOpens    bool       `json:"trackOpens"`
+	BatchSize     int        `json:"batchSize" gorm:"not null"`
+	RatePerMinute int        `json:"ratePerMinute" gorm:"not null"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	Recipients    int        `json:"recipients"`
+	CreatedBy     string     `json:"createdBy" gorm:"not null"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"`
+	CompletedAt   *time.Time `json:"completedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (c *EmailCampaign) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// EmailCampaignRecipient is a customer a campaign is addressed to and what happened to their email
+type EmailCampaignRecipient struct {
+	ID                string     `json:"id" gorm:"primaryKey"`
+	CampaignID        string     `json:"campaignId" gorm:"not null;uniqueIndex:idx_campaign_recipient,priority:1;index:idx_campaign_recipient_status,priority:1"`
+	UserID            string     `json:"userId" gorm:"not null;uniqueIndex:idx_campaign_recipient,priority:2"`
+	Email             string     `json:"email" gorm:"not null"`
+	Status            string     `json:"status" gorm:"type:varchar(20);not null;index:idx_campaign_recipient_status,priority:2"`
+	SuppressionReason string     `json:"suppressionReason,omitempty"`
+	Error             string     `json:"error,omitempty" gorm:"type:text"`
+	OpenToken         string     `json:"-" gorm:"uniqueIndex;not null"`
+	SentAt            *time.Time `json:"sentAt,omitempty"`
+	OpenedAt          *time.Time `json:"openedAt,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	User              *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// BeforeCreate hook to generate UUID and open tracking token
+func (r *EmailCampaignRecipient) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	if r.OpenToken == "" {
+		r.OpenToken = uuid.New().String()
+	}
+	return nil
+}
@@ -10,25 +10,40 @@ type CartItem struct {
 	Quantity  int     `json:"quantity"`
 	Price     float64 `json:"price"`
 	Total     float64 `json:"total"`
+	Source    string  `json:"source,omitempty"` // discovery surface the line was first added from
 	Product   Product `json:"product,omitempty"`
 }
 
-// Cart represents a shopping cart
+// Discovery surfaces a cart line can be attributed to
+const (
+	CartSourceSearch         = "search"
+	CartSourceRecommendation = "recommendation"
+	CartSourceCollection     = "collection"
+	CartSourceDirectLink     = "direct_link"
+	CartSourceWishlist       = "wishlist"
+)
+
+// Cart represents a shopping cart. Revision goes up by one with every change, so clients can tell whether
+// an optimistic update was based on the current cart. SavedForLater holds lines set aside by the shopper;
+// they are kept with the cart but not counted in its totals or checked out.
 type Cart struct {
-	SessionID string     `json:"sessionId"`
-	UserID    *string    `json:"userId,omitempty"`
-	Items     []CartItem `json:"items"`
-	Subtotal  float64    `json:"subtotal"`
-	Tax       float64    `json:"tax"`
-	Total     float64    `json:"total"`
-	CreatedAt time.Time  `json:"createdAt"`
-	UpdatedAt time.Time  `json:"updatedAt"`
+	SessionID     string     `json:"sessionId"`
+	UserID        *string    `json:"userId,omitempty"`
+	Revision      int64      `json:"revision"`
+	Items         []CartItem `json:"items"`
+	SavedForLater []CartItem `json:"savedForLater"`
+	Subtotal      float64    `json:"subtotal"`
+	Tax           float64    `json:"tax"`
+	Total         float64    `json:"total"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
 }
 
 // AddItemRequest represents the request to add an item to cart
 type AddItemRequest struct {
 	ProductID string `json:"productId" binding:"required"`
 	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	Source    string `json:"source,omitempty" binding:"omitempty,oneof=search recommendation collection direct_link"`
 }
 
 // UpdateItemRequest represents the request to update an item in cart
@@ -42,6 +57,36 @@ type RemoveItemRequest struct {
 	ProductID string `json:"productId" binding:"required"`
 }
 
+// SavedItemRequest represents the request to move an item between the cart and the saved-for-later list
+type SavedItemRequest struct {
+	ProductID string `json:"productId" binding:"required"`
+}
+
+// Cart change operations reported in a CartDelta
+const (
+	CartOpAdd    = "add"
+	CartOpUpdate = "update"
+	CartOpRemove = "remove"
+	CartOpClear  = "clear"
+	CartOpSave   = "save_for_later"
+	CartOpMove   = "move_to_cart"
+)
+
+// CartDelta describes the change a cart mutation applied
+type CartDelta struct {
+	Op               string `json:"op"`
+	ProductID        string `json:"productId,omitempty"`
+	Quantity         int    `json:"quantity"`         // quantity of the line after the change
+	PreviousQuantity int    `json:"previousQuantity"` // quantity of the line before the change
+}
+
+// CartMutation is the response to a cart mutation: the recalculated cart, including its new revision, and the
+// change that produced it
+type CartMutation struct {
+	*Cart
+	Delta CartDelta `json:"delta"`
+}
+
 // CalculateTotals calculates and updates cart totals
 func (c *Cart) CalculateTotals() {
 	c.Subtotal = 0
@@ -49,6 +94,9 @@ func (c *Cart) CalculateTotals() {
 		c.Items[i].Total = c.Items[i].Price * float64(c.Items[i].Quantity)
 		c.Subtotal += c.Items[i].Total
 	}
+	for i := range c.SavedForLater {
+		c.SavedForLater[i].Total = c.SavedForLater[i].Price * float64(c.SavedForLater[i].Quantity)
+	}
 	
 	// For now, tax is 0 - this could be calculated based on location
 	c.Tax = 0
@@ -77,6 +125,27 @@ func (c *Cart) RemoveItem(productID string) bool {
 	return false
 }
 
+// FindSavedItem finds an item saved for later by product ID
+func (c *Cart) FindSavedItem(productID string) *CartItem {
+	for i := range c.SavedForLater {
+		if c.SavedForLater[i].ProductID == productID {
+			return &c.SavedForLater[i]
+		}
+	}
+	return nil
+}
+
+// RemoveSavedItem removes an item saved for later by product ID
+func (c *Cart) RemoveSavedItem(productID string) bool {
+	for i, item := range c.SavedForLater {
+		if item.ProductID == productID {
+			c.SavedForLater = append(c.SavedForLater[:i], c.SavedForLater[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 // IsEmpty returns true if the cart has no items
 func (c *Cart) IsEmpty() bool {
 	return len(c.Items) == 0
@@ -89,4 +158,26 @@ func (c *Cart) GetItemCount() int {
 		count += item.Quantity
 	}
 	return count
-}
\ No newline at end of file
+}
+
+// UserCart records the revision of a signed-in user's saved cart, which outlives its lines when it is emptied
+type UserCart struct {
+	UserID    string    `json:"userId" gorm:"primaryKey"`
+	Revision  int64     `json:"revision" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UserCartItem is one line of a signed-in user's cart as stored in the database. Redis holds the working copy
+// of the cart; these rows keep it when the Redis key expires or is lost, so the cart follows the user across
+// devices.
+type UserCartItem struct {
+	UserID    string    `json:"userId" gorm:"primaryKey"`
+	ProductID string    `json:"productId" gorm:"primaryKey"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	Price     float64   `json:"price" gorm:"not null"`
+	Source    string    `json:"source,omitempty" gorm:"type:varchar(30)"`
+	Position  int       `json:"position" gorm:"not null;default:0"` // order of the line in its list
+	Saved     bool      `json:"saved" gorm:"not null;default:false"` // saved for later rather than in the cart
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +18,10 @@ type Category struct {
 	ParentID    *string    `json:"parentId,omitempty"`
 	IsActive    bool       `json:"isActive" gorm:"default:true"`
 	SortOrder   int        `json:"sortOrder" gorm:"default:0"`
+	ReturnPolicy ReturnPolicy `json:"returnPolicy" gorm:"embedded;embeddedPrefix:policy_"`
+	Attributes  AttributeSchema `json:"attributes" gorm:"type:jsonb"`
+	SerialTracked bool       `json:"serialTracked" gorm:"default:false"` // units need a serial number or IMEI when they ship
+	WarrantyMonths int       `json:"warrantyMonths" gorm:"default:0"` // manufacturer warranty from delivery; 0 means no warranty
 	CreatedAt   time.Time  `json:"createdAt"`
 	UpdatedAt   time.Time  `json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index"`
@@ -29,4 +36,85 @@ func (c *Category) BeforeCreate(tx *gorm.DB) error {
 		c.ID = uuid.New().String()
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// ReturnPolicy holds the return and cancellation rules applied to products of a category
+type ReturnPolicy struct {
+	Returnable              bool `json:"returnable" gorm:"default:true"`
+	ReturnWindowDays        int  `json:"returnWindowDays" gorm:"default:7"`
+	CancellationCutoffHours int  `json:"cancellationCutoffHours" gorm:"default:24"`
+}
+
+// DefaultReturnPolicy is applied to categories created without an explicit policy
+func DefaultReturnPolicy() ReturnPolicy {
+	return ReturnPolicy{
+		Returnable:              true,
+		ReturnWindowDays:        7,
+		CancellationCutoffHours: 24,
+	}
+}
+
+// ReturnDeadline returns the last moment an item delivered at deliveredAt can be returned
+func (p ReturnPolicy) ReturnDeadline(deliveredAt time.Time) time.Time {
+	return deliveredAt.AddDate(0, 0, p.ReturnWindowDays)
+}
+
+// CancellationDeadline returns the last moment an order placed at placedAt can be cancelled by the customer
+func (p ReturnPolicy) CancellationDeadline(placedAt time.Time) time.Time {
+	return placedAt.Add(time.Duration(p.CancellationCutoffHours) * time.Hour)
+}
+
+// Value types of a category attribute
+const (
+	AttributeTypeText    = "text"
+	AttributeTypeNumber  = "number"
+	AttributeTypeEnum    = "enum"
+	AttributeTypeBoolean = "boolean"
+)
+
+// CategoryAttribute describes one specification key products of a category are expected to carry
+type CategoryAttribute struct {
+	Key     string   `json:"key"`
+	Label   string   `json:"label"`
+	Unit    string   `json:"unit,omitempty"`
+	Type    string   `json:"type,omitempty"`    // one of the AttributeType values; empty means text
+	Options []string `json:"options,omitempty"` // allowed values of an enum attribute
+}
+
+// ValueType returns the type of the attribute, treating attributes saved before types existed as text
+func (a CategoryAttribute) ValueType() string {
+	if a.Type == "" {
+		return AttributeTypeText
+	}
+	return a.Type
+}
+
+// AttributeSchema is the ordered list of attributes of a category, stored as JSONB
+type AttributeSchema []CategoryAttribute
+
+// Value implements the driver.Valuer interface
+func (a AttributeSchema) Value() (driver.Value, error) {
+	if a == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements the sql.Scanner interface
+func (a *AttributeSchema) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = AttributeSchema{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return errors.New("cannot scan into AttributeSchema")
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CustomerMerge is the audit record of a duplicate customer account merged into another. The duplicate
+// is kept, deactivated, so the merge can be traced back; Moved counts the records reassigned per table.
+type CustomerMerge struct {
+	ID              string    `json:"id" gorm:"primaryKey"`
+	PrimaryUserID   string    `json:"primaryUserId" gorm:"not null;index"`
+	DuplicateUserID string    `json:"duplicateUserId" gorm:"not null;uniqueIndex"`
+	DuplicateEmail  string    `json:"duplicateEmail" gorm:"not null"`
+	Reasons         string    `json:"reasons,omitempty"` // comma-separated match reasons seen when merging
+	Note            string    `json:"note,omitempty" gorm:"type:text"`
+	Moved           JSONB     `json:"moved" gorm:"type:jsonb"`
+	MergedBy        string    `json:"mergedBy" gorm:"not null"`
+	CreatedAt       time.Time `json:"createdAt" gorm:"index"`
+}
+
+// BeforeCreate hook to generate UUID
+func (m *CustomerMerge) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Draft order statuses
+const (
+	DraftOrderOpen      = "open"      // being put together by an admin
+	DraftOrderInvoiced  = "invoiced"  // the payment link was emailed to the customer
+	DraftOrderCompleted = "completed" // the customer paid and the draft became an order
+	DraftOrderCancelled = "cancelled"
+)
+
+// Draft order discount types
+const (
+	DraftDiscountPercent = "percentage" // DiscountValue percent off the subtotal
+	DraftDiscountAmount  = "amount"     // DiscountValue off the subtotal
+)
+
+// DraftOrder is an order an admin puts together on behalf of a customer, such as one taken over the phone.
+// Its line prices and totals are quoted to the customer in the payment link email; the customer checks out
+// at those prices, which places OrderID, and the draft is completed when that order is paid.
+type DraftOrder struct {
+	ID     string `json:"id" gorm:"primaryKey"`
+	UserID string `json:"userId" gorm:"not null;index"`
+	// CreatedBy is the admin who created the draft
+	CreatedBy       string           `json:"createdBy" gorm:"not null"`
+	Status          string           `json:"status" gorm:"type:varchar(20);not null;default:'open';index"`
+	ShippingAddress OrderAddress     `json:"shippingAddress" gorm:"embedded;embeddedPrefix:shipping_"`
+	BillingAddress  OrderAddress     `json:"billingAddress" gorm:"embedded;embeddedPrefix:billing_"`
+	Notes           *string          `json:"notes,omitempty"`
+	DiscountType    string           `json:"discountType,omitempty" gorm:"type:varchar(20);not null;default:''"`
+	DiscountValue   float64          `json:"discountValue" gorm:"default:0"`
+	DiscountReason  *string          `json:"discountReason,omitempty"`
+	Subtotal        float64          `json:"subtotal" gorm:"not null;default:0"`
+	Discount        float64          `json:"discount" gorm:"not null;default:0"`
+	Tax             float64          `json:"tax" gorm:"not null;default:0"`
+	TaxBreakdown    TaxBreakdown     `json:"taxBreakdown" gorm:"type:jsonb"`
+	Shipping        float64          `json:"shipping" gorm:"not null;default:0"`
+	Total           float64          `json:"total" gorm:"not null;default:0"`
+	OrderID         *string          `json:"orderId,omitempty" gorm:"index"`
+	InvoiceSentAt   *time.Time       `json:"invoiceSentAt,omitempty"`
+	CompletedAt     *time.Time       `json:"completedAt,omitempty"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	UpdatedAt       time.Time        `json:"updatedAt"`
+	User            User             `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Items           []DraftOrderItem `json:"items,omitempty" gorm:"foreignKey:DraftOrderID"`
+}
+
+// BeforeCreate hook to generate UUID
+func (d *DraftOrder) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// DraftOrderItem is a line of a draft order. Price is the unit price quoted to the customer: the product's
+// price when the line was added, unless the admin set another one.
+type DraftOrderItem struct {
+	ID           string  `json:"id" gorm:"primaryKey"`
+	DraftOrderID string  `json:"draftOrderId" gorm:"not null;index"`
+	ProductID    string  `json:"productId" gorm:"not null"`
+	Quantity     int     `json:"quantity" gorm:"not null"`
+	Price        float64 `json:"price" gorm:"not null"`
+	Total        float64 `json:"total" gorm:"not null"`
+	Product      Product `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+}
+
+// BeforeCreate hook to generate UUID
+func (i *DraftOrderItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Email log statuses. Sent emails move to bounced or complained when the provider reports so.
+const (
+	EmailLogSent       = "sent"
+	EmailLogFailed     = "failed"
+	EmailLogSuppressed = "suppressed"
+	EmailLogBounced    = "bounced"
+	EmailLogComplained = "complained"
+)
+
+// Email event types and bounce types reported by the email provider
+const (
+	EmailEventBounce    = "bounce"
+	EmailEventComplaint = "complaint"
+	BounceHard          = "hard"
+	BounceSoft          = "soft"
+)
+
+// EmailLog records an outbound email
+type EmailLog struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Kind      string    `json:"kind" gorm:"type:varchar(30);not null;index"`
+	Recipient string    `json:"recipient" gorm:"not null;index"` // lower-cased
+	Subject   string    `json:"subject"`
+	MessageID string    `json:"messageId,omitempty" gorm:"index"`
+	Status    string    `json:"status" gorm:"type:varchar(20);not null;index"`
+	Error     string    `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (l *EmailLog) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// EmailEvent is a bounce or complaint notification received from the email provider
+type EmailEvent struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	Type       string    `json:"type" gorm:"type:varchar(20);not null;index"`
+	BounceType string    `json:"bounceType,omitempty" gorm:"type:varchar(10)"`
+	Email      string    `json:"email" gorm:"not null;index"` // lower-cased
+	MessageID  string    `json:"messageId,omitempty"`
+	Reason     string    `json:"reason,omitempty" gorm:"type:text"`
+	UserID     *string   `json:"userId,omitempty" gorm:"index"`
+	OccurredAt time.Time `json:"occurredAt" gorm:"index"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *EmailEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Email suppression reasons
+const (
+	SuppressionHardBounce = "hard_bounce"
+	SuppressionComplaint  = "complaint"
+	SuppressionManual     = "manual"
+)
+
+// EmailSuppression is an address marketing email must not be sent to, e.g. because it bounced
+type EmailSuppression struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Email     string    `json:"email" gorm:"uniqueIndex;not null"` // lower-cased
+	Reason    string    `json:"reason" gorm:"type:varchar(20);not null"`
+	Detail    string    `json:"detail,omitempty" gorm:"type:text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (s *EmailSuppression) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
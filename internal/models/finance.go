@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Order adjustment types: money back without a return, or store credit as a goodwill gesture
+const (
+	AdjustmentTypeReturnlessRefund = "returnless_refund"
+	AdjustmentTypeGoodwillCredit   = "goodwill_credit"
+)
+
+// Order adjustment statuses
+const (
+	AdjustmentStatusPendingApproval = "pending_approval"
+	AdjustmentStatusApplied         = "applied"
+	AdjustmentStatusRejected        = "rejected"
+)
+
+// Finance journals adjustments are posted to
+const (
+	JournalRefunds     = "refunds"
+	JournalStoreCredit = "store_credit"
+)
+
+// OrderAdjustment is an admin action that compensates a customer for an order without a return.
+// Adjustments above the approval threshold wait for a second admin before they are applied.
+type OrderAdjustment struct {
+	ID         string `json:"id" gorm:"primaryKey"`
+	OrderID    string `json:"orderId" gorm:"not null;index"`
+	UserID     string `json:"userId" gorm:"not null;index"`
+	Type       string `json:"type" gorm:"type:varchar(30);not null"`
+	Amount     int64  `json:"amount" gorm:"not null"` // Amount in paise
+	Currency   string `json:"currency" gorm:"default:'INR'"`
+	ReasonCode string `json:"reasonCode" gorm:"type:varchar(40);not null;index"`
+	Note       string `json:"note,omitempty"`
+	Status     string `json:"status" gorm:"type:varchar(20);not null;index"`
+	// RequestedBy is the admin who created the adjustment; ReviewedBy approved or rejected it
+	RequestedBy   string     `json:"requestedBy" gorm:"not null"`
+	ReviewedBy    *string    `json:"reviewedBy,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewedAt,omitempty"`
+	RefundID      *string    `json:"refundId,omitempty"`
+	StoreCreditID *string    `json:"storeCreditId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (a *OrderAdjustment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// StoreCreditEntry is one movement of a customer's store credit; the balance is the sum of the entries
+type StoreCreditEntry struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	UserID       string    `json:"userId" gorm:"not null;index"`
+	Amount       int64     `json:"amount" gorm:"not null"` // Amount in paise; grants are positive
+	Currency     string    `json:"currency" gorm:"default:'INR'"`
+	OrderID      *string   `json:"orderId,omitempty" gorm:"index"`
+	AdjustmentID *string   `json:"adjustmentId,omitempty" gorm:"index"`
+	Reason       string    `json:"reason,omitempty"`
+	CreatedBy    string    `json:"createdBy,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *StoreCreditEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// FinanceJournalEntry records money leaving the store, or owed by it, for finance reconciliation
+type FinanceJournalEntry struct {
+	ID         string  `json:"id" gorm:"primaryKey"`
+	Journal    string  `json:"journal" gorm:"type:varchar(20);not null;index"`
+	EntryType  string  `json:"entryType" gorm:"type:varchar(30);not null"`
+	OrderID    *string `json:"orderId,omitempty" gorm:"index"`
+	Amount     int64   `json:"amount" gorm:"not null"` // Amount in paise
+	Currency   string  `json:"currency" gorm:"default:'INR'"`
+	ReasonCode string  `json:"reasonCode,omitempty"`
+	// Reference is the refund or store credit entry the journal entry records
+	Reference   string    `json:"reference"`
+	RequestedBy string    `json:"requestedBy,omitempty"`
+	ApprovedBy  *string   `json:"approvedBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"index"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *FinanceJournalEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
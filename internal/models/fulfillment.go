@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Fulfillment statuses of an order item
+const (
+	FulfillmentUnfulfilled      = "unfulfilled"
+	FulfillmentPartiallyShipped = "partially_shipped"
+	FulfillmentShipped          = "shipped"
+	FulfillmentDelivered        = "delivered"
+)
+
+// ShipmentItem is a quantity of an order item packed in a shipment
+type ShipmentItem struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	ShipmentID  string    `json:"shipmentId" gorm:"not null;index"`
+	OrderItemID string    `json:"orderItemId" gorm:"not null;index"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (si *ShipmentItem) BeforeCreate(tx *gorm.DB) error {
+	if si.ID == "" {
+		si.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// ItemFulfillment is how much of an order item has shipped and been delivered
+type ItemFulfillment struct {
+	Status    string `json:"status"`
+	Shipped   int    `json:"shipped"`
+	Delivered int    `json:"delivered"`
+}
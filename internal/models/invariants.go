@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Invariant checks, the money and stock rules the invariant checker verifies
+const (
+	InvariantItemsSubtotal        = "items_subtotal"         // an order's subtotal equals the sum of its item totals
+	InvariantRefundWithinCaptured = "refund_within_captured" // a payment never refunds more than it captured
+	InvariantNonNegativeInventory = "non_negative_inventory" // product inventory never drops below zero
+	InvariantOrderItemHasOrder    = "order_item_has_order"   // every order item belongs to an existing order
+)
+
+// InvariantRun is the report of one invariant checker run. Since is the start of the window of recent orders
+// and payments it scanned; inventory and order items are always scanned in full.
+type InvariantRun struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	Since      time.Time `json:"since"`
+	Violations int64     `json:"violations"`
+	Error      string    `json:"error,omitempty" gorm:"type:text"`
+	StartedAt  time.Time `json:"startedAt" gorm:"index"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (r *InvariantRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// InvariantViolation is one record an invariant checker run found breaking an invariant. EntityID is the order,
+// payment, product or order item concerned.
+type InvariantViolation struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	RunID     string    `json:"runId" gorm:"not null;index"`
+	Invariant string    `json:"invariant" gorm:"type:varchar(40);not null;index"`
+	EntityID  string    `json:"entityId" gorm:"not null;index"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (v *InvariantViolation) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -13,16 +13,29 @@ type Order struct {
 	Status          string    `json:"status" gorm:"type:varchar(20);default:'pending';index"`
 	Subtotal        float64   `json:"subtotal" gorm:"not null"`
 	Tax             float64   `json:"tax" gorm:"default:0"`
+	Discount        float64   `json:"discount" gorm:"default:0"` // manual discount of draft orders or promotion code discount, taken off before tax
+	TaxBreakdown    TaxBreakdown `json:"taxBreakdown" gorm:"type:jsonb"`
 	Shipping        float64   `json:"shipping" gorm:"default:0"`
+	ShippingMethodID   *string `json:"shippingMethodId,omitempty" gorm:"index"`
+	ShippingMethodName *string `json:"shippingMethodName,omitempty"`
 	Total           float64   `json:"total" gorm:"not null;index"`
 	ShippingAddress OrderAddress `json:"shippingAddress" gorm:"embedded;embeddedPrefix:shipping_"`
 	BillingAddress  OrderAddress `json:"billingAddress" gorm:"embedded;embeddedPrefix:billing_"`
 	PaymentIntentID string    `json:"paymentIntentId"`
 	Notes           *string   `json:"notes,omitempty"`
+	IsGift             bool    `json:"isGift" gorm:"default:false"`
+	GiftMessage        *string `json:"giftMessage,omitempty"`
+	GiftRecipientEmail *string `json:"giftRecipientEmail,omitempty"`
+	DeliveredAt        *time.Time `json:"deliveredAt,omitempty"`
+	CancellationReason *string    `json:"cancellationReason,omitempty"`
+	ReviewRequestStatus string     `json:"reviewRequestStatus,omitempty" gorm:"type:varchar(20);not null;default:''"`
+	ReviewRequestSentAt *time.Time `json:"reviewRequestSentAt,omitempty"`
 	CreatedAt       time.Time `json:"createdAt"`
 	UpdatedAt       time.Time `json:"updatedAt"`
 	User            User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Items           []OrderItem `json:"items,omitempty" gorm:"foreignKey:OrderID"`
+	Shipments       []Shipment  `json:"shipments,omitempty" gorm:"foreignKey:OrderID"`
+	Comments        []OrderComment `json:"comments,omitempty" gorm:"foreignKey:OrderID"`
 }
 
 // BeforeCreate hook to generate UUID
@@ -53,6 +66,11 @@ type OrderItem struct {
 	Quantity  int       `json:"quantity" gorm:"not null"`
 	Price     float64   `json:"price" gorm:"not null"` // Price at time of order
 	Total     float64   `json:"total" gorm:"not null"`
+	Discount  float64   `json:"discount" gorm:"not null;default:0"` // share of the order discount taken off this line
+	Source    string    `json:"source,omitempty" gorm:"type:varchar(30);index"` // cart line attribution
+	ShipmentID *string  `json:"shipmentId,omitempty" gorm:"index"`
+	Serials   []OrderItemSerial `json:"serials,omitempty" gorm:"foreignKey:OrderItemID"` // recorded for serial-tracked categories
+	Fulfillment *ItemFulfillment `json:"fulfillment,omitempty" gorm:"-"` // filled in when the order is read with its shipments
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	Order     Order     `json:"order,omitempty" gorm:"foreignKey:OrderID"`
@@ -72,4 +90,103 @@ func (oi *OrderItem) BeforeCreate(tx *gorm.DB) error {
 func (oi *OrderItem) BeforeUpdate(tx *gorm.DB) error {
 	oi.Total = oi.Price * float64(oi.Quantity)
 	return nil
-}
\ No newline at end of file
+}
+
+// NetPrice returns what one unit was paid for: its price less its share of the line discount
+func (oi *OrderItem) NetPrice() float64 {
+	if oi.Quantity <= 0 {
+		return oi.Price
+	}
+	return oi.Price - oi.Discount/float64(oi.Quantity)
+}
+
+// Statuses of a return request. Approving a return issues its return label; completing it once the goods
+// arrive back refunds them and puts them back in stock.
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusCompleted = "completed"
+)
+
+// ReturnRequest records items of a delivered order that the customer is sending back
+type ReturnRequest struct {
+	ID              string     `json:"id" gorm:"primaryKey"`
+	OrderID         string     `json:"orderId" gorm:"not null;index"`
+	OrderItemID     string     `json:"orderItemId" gorm:"not null;index"`
+	UserID          string     `json:"userId" gorm:"not null;index"`
+	Quantity        int        `json:"quantity" gorm:"not null"`
+	Reason          string     `json:"reason"`
+	Status          string     `json:"status" gorm:"type:varchar(20);default:'requested';index"`
+	RMANumber       *string    `json:"rmaNumber,omitempty" gorm:"type:varchar(20);uniqueIndex"` // assigned on approval
+	Carrier         *string    `json:"carrier,omitempty" gorm:"type:varchar(50)"`
+	TrackingNumber  *string    `json:"trackingNumber,omitempty"`
+	ReviewedBy      *string    `json:"reviewedBy,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewedAt,omitempty"`
+	RejectionReason *string    `json:"rejectionReason,omitempty"`
+	RefundID        *string    `json:"refundId,omitempty"`
+	Restocked       bool       `json:"restocked" gorm:"default:false"`
+	CompletedAt     *time.Time `json:"completedAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (r *ReturnRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Shipment is one ship-to address group within an order; each group is packed and tracked separately.
+// A group may leave the warehouse in several parcels: each parcel shipped before the rest of its group
+// is a shipment of its own with the same group key, listing the quantities it carries in ShippedItems.
+type Shipment struct {
+	ID             string       `json:"id" gorm:"primaryKey"`
+	OrderID        string       `json:"orderId" gorm:"not null;index"`
+	GroupKey       string       `json:"groupKey" gorm:"not null"`
+	Address        OrderAddress `json:"address" gorm:"embedded;embeddedPrefix:address_"`
+	Shipping       float64      `json:"shipping" gorm:"default:0"`
+	DeliveryZoneID *string      `json:"deliveryZoneId,omitempty" gorm:"index"`
+	Status         string       `json:"status" gorm:"type:varchar(20);default:'pending';index"`
+	Carrier        *string      `json:"carrier,omitempty" gorm:"type:varchar(50)"`
+	TrackingNumber *string      `json:"trackingNumber,omitempty"`
+	ShippedAt      *time.Time   `json:"shippedAt,omitempty"`
+	DeliveredAt    *time.Time   `json:"deliveredAt,omitempty"`
+	DeliveryProof  DeliveryProof `json:"deliveryProof" gorm:"embedded;embeddedPrefix:delivery_"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	UpdatedAt      time.Time    `json:"updatedAt"`
+	Items          []OrderItem  `json:"items,omitempty" gorm:"foreignKey:ShipmentID"`
+	ShippedItems   []ShipmentItem `json:"shippedItems,omitempty" gorm:"foreignKey:ShipmentID"`
+}
+
+// Outcomes of the review request of a delivered order; an empty status has not been handled yet
+const (
+	ReviewRequestSent      = "sent"
+	ReviewRequestOptedOut  = "opted_out"
+	ReviewRequestThrottled = "throttled"
+)
+
+// Sources of a delivery confirmation
+const (
+	DeliverySourceCarrier = "carrier"
+	DeliverySourceDriver  = "driver"
+	DeliverySourceAdmin   = "admin"
+)
+
+// DeliveryProof is the evidence recorded when a shipment is confirmed delivered
+type DeliveryProof struct {
+	Source        string  `json:"source,omitempty" gorm:"type:varchar(20)"`
+	PhotoURL      *string `json:"photoUrl,omitempty"`
+	OTPVerified   bool    `json:"otpVerified"`
+	RecipientName *string `json:"recipientName,omitempty"`
+}
+
+// BeforeCreate hook to generate UUID
+func (sh *Shipment) BeforeCreate(tx *gorm.DB) error {
+	if sh.ID == "" {
+		sh.ID = uuid.New().String()
+	}
+	return nil
+}
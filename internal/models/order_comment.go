@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Visibilities of an order comment
+const (
+	OrderCommentInternal = "internal"
+	OrderCommentCustomer = "customer"
+)
+
+// OrderComment is a note an admin leaves on an order. Internal comments are only shown to admins; customer
+// comments are also shown on the customer's view of the order.
+type OrderComment struct {
+	ID      string `json:"id" gorm:"primaryKey"`
+	OrderID string `json:"orderId" gorm:"not null;index"`
+	// AuthorID is the admin who wrote the comment; it is left out of the customer's view
+	AuthorID   *string   `json:"authorId,omitempty"`
+	Body       string    `json:"body" gorm:"type:text;not null"`
+	Visibility string    `json:"visibility" gorm:"type:varchar(20);not null;default:'internal'"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (c *OrderComment) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
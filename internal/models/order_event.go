@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderEvent is one entry of an order's append-only change log. Sequence numbers start at 1 and
+// increase by one per order, so replaying the events in sequence order rebuilds the order.
+type OrderEvent struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	OrderID   string    `json:"orderId" gorm:"not null;uniqueIndex:idx_order_events_order_sequence"`
+	Sequence  int       `json:"sequence" gorm:"not null;uniqueIndex:idx_order_events_order_sequence"`
+	Type      string    `json:"type" gorm:"type:varchar(40);not null;index"`
+	Data      JSONB     `json:"data" gorm:"type:jsonb"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *OrderEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Roles of whoever moved an order to a new status
+const (
+	OrderActorCustomer = "customer"
+	OrderActorAdmin    = "admin"
+	OrderActorCarrier  = "carrier"
+	OrderActorSystem   = "system"
+)
+
+// OrderStatusHistory is one status transition of an order. Unlike the optional event log it is always
+// kept, so customers and admins can see how an order got to its current status.
+type OrderStatusHistory struct {
+	ID      string `json:"id" gorm:"primaryKey"`
+	OrderID string `json:"orderId" gorm:"not null;index"`
+	// OldStatus is empty for the entry recorded when the order was created
+	OldStatus string `json:"oldStatus" gorm:"type:varchar(20)"`
+	NewStatus string `json:"newStatus" gorm:"type:varchar(20);not null"`
+	// ActorID is the user who made the change; it is empty for system, carrier and driver changes
+	ActorID   *string   `json:"actorId,omitempty"`
+	ActorRole string    `json:"actorRole" gorm:"type:varchar(20);not null"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TableName keeps the audit trail in order_status_history
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+// BeforeCreate hook to generate UUID
+func (h *OrderStatusHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == "" {
+		h.ID = uuid.New().String()
+	}
+	return nil
+}
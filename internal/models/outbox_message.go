@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OutboxMessage is a side effect of a change, such as updating the search index or sending an email, recorded
+// in the transaction of the change and delivered afterwards by the outbox dispatcher. A message is delivered
+// when DeliveredAt is set and given up on when FailedAt is set; until then it is retried at NextAttemptAt.
+type OutboxMessage struct {
+	ID            string     `json:"id" gorm:"primaryKey"`
+	Topic         string     `json:"topic" gorm:"type:varchar(50);not null;index"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty" gorm:"index"`
+	LastError     string     `json:"lastError,omitempty" gorm:"type:text"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty" gorm:"index"`
+	FailedAt      *time.Time `json:"failedAt,omitempty" gorm:"index"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"index"`
+}
+
+// BeforeCreate hook to generate UUID
+func (m *OutboxMessage) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -8,19 +8,22 @@ import (
 )
 
 type Payment struct {
-	ID                string    `json:"id" gorm:"primaryKey"`
-	OrderID           string    `json:"orderId" gorm:"not null;index"`
-	RazorpayOrderID   string    `json:"razorpayOrderId" gorm:"unique;not null"`
-	RazorpayPaymentID *string   `json:"razorpayPaymentId,omitempty" gorm:"unique"`
-	RazorpaySignature *string   `json:"razorpaySignature,omitempty"`
-	Amount            int64     `json:"amount" gorm:"not null"` // Amount in paise
-	Currency          string    `json:"currency" gorm:"default:'INR'"`
-	Status            string    `json:"status" gorm:"type:varchar(20);default:'created';index"`
-	Method            *string   `json:"method,omitempty"`
-	Description       *string   `json:"description,omitempty"`
-	CreatedAt         time.Time `json:"createdAt"`
-	UpdatedAt         time.Time `json:"updatedAt"`
-	Order             Order     `json:"order,omitempty" gorm:"foreignKey:OrderID"`
+	ID                string     `json:"id" gorm:"primaryKey"`
+	OrderID           string     `json:"orderId" gorm:"not null;index"`
+	RazorpayOrderID   string     `json:"razorpayOrderId" gorm:"unique;not null"`
+	RazorpayPaymentID *string    `json:"razorpayPaymentId,omitempty" gorm:"unique"`
+	RazorpaySignature *string    `json:"razorpaySignature,omitempty"`
+	Amount            int64      `json:"amount" gorm:"not null"` // Amount in paise
+	Currency          string     `json:"currency" gorm:"default:'INR'"`
+	Status            string     `json:"status" gorm:"type:varchar(20);default:'created';index"`
+	Provider          string     `json:"provider" gorm:"type:varchar(20);not null;default:'primary';index"` // provider account the intent was created with
+	Method            *string    `json:"method,omitempty"`
+	AmountRefunded    int64      `json:"amountRefunded" gorm:"not null;default:0"` // Amount in paise
+	Description       *string    `json:"description,omitempty"`
+	ExpiresAt         *time.Time `json:"expiresAt,omitempty" gorm:"index"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	Order             Order      `json:"order,omitempty" gorm:"foreignKey:OrderID"`
 }
 
 // BeforeCreate hook to generate UUID
@@ -33,8 +36,109 @@ func (p *Payment) BeforeCreate(tx *gorm.DB) error {
 
 // Payment status constants
 const (
+	// PaymentStatusCreating marks an intent claimed while its gateway order is created; it cannot be paid yet
+	PaymentStatusCreating  = "creating"
 	PaymentStatusCreated   = "created"
 	PaymentStatusPaid      = "paid"
 	PaymentStatusFailed    = "failed"
 	PaymentStatusCancelled = "cancelled"
+	// PaymentStatusRefundRequired marks money captured on an intent that may no longer pay its order
+	PaymentStatusRefundRequired = "refund_required"
+	PaymentStatusRefunded       = "refunded"
+)
+
+// Payment provider accounts. The canary is a secondary account, or new credentials, that a share of new
+// intents is rolled out to during a gateway switchover.
+const (
+	PaymentProviderPrimary = "primary"
+	PaymentProviderCanary  = "canary"
+)
+
+// IsExpired reports whether an unpaid payment intent has passed its expiry time
+func (p *Payment) IsExpired(now time.Time) bool {
+	return p.ExpiresAt != nil && now.After(*p.ExpiresAt)
+}
+
+// PaymentEvent records a payment provider webhook delivery so redelivered events are applied only once
+type PaymentEvent struct {
+	ID                string     `json:"id" gorm:"primaryKey"`
+	EventID           string     `json:"eventId" gorm:"uniqueIndex;not null"`
+	Event             string     `json:"event" gorm:"type:varchar(50);not null;index"`
+	RazorpayOrderID   *string    `json:"razorpayOrderId,omitempty" gorm:"index"`
+	RazorpayPaymentID *string    `json:"razorpayPaymentId,omitempty"`
+	Payload           string     `json:"payload" gorm:"type:text;not null"`
+	Status            string     `json:"status" gorm:"type:varchar(20);not null;default:'received'"`
+	Error             *string    `json:"error,omitempty" gorm:"type:text"`
+	Attempts          int        `json:"attempts" gorm:"not null;default:0"`
+	ProcessedAt       *time.Time `json:"processedAt,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *PaymentEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Payment event status constants
+const (
+	PaymentEventReceived  = "received"
+	PaymentEventProcessed = "processed"
+	PaymentEventIgnored   = "ignored"
+	PaymentEventFailed    = "failed"
+)
+
+// Refund records money returned to the customer through the payment provider
+type Refund struct {
+	ID               string       `json:"id" gorm:"primaryKey"`
+	OrderID          string       `json:"orderId" gorm:"not null;index"`
+	PaymentID        string       `json:"paymentId" gorm:"not null;index"`
+	ProviderRefundID *string      `json:"providerRefundId,omitempty" gorm:"unique"`
+	Amount           int64        `json:"amount" gorm:"not null"` // Amount in paise
+	Currency         string       `json:"currency" gorm:"default:'INR'"`
+	Status           string       `json:"status" gorm:"type:varchar(20);not null;index"`
+	Reason           string       `json:"reason,omitempty"`
+	CreatedBy        string       `json:"createdBy,omitempty"`
+	CreatedAt        time.Time    `json:"createdAt"`
+	UpdatedAt        time.Time    `json:"updatedAt"`
+	Items            []RefundItem `json:"items,omitempty" gorm:"foreignKey:RefundID"`
+}
+
+// BeforeCreate hook to generate UUID
+func (r *Refund) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// RefundItem records the quantity of an order item a refund covers and whether it went back in stock
+type RefundItem struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	RefundID    string    `json:"refundId" gorm:"not null;index"`
+	OrderItemID string    `json:"orderItemId" gorm:"not null;index"`
+	ProductID   string    `json:"productId" gorm:"not null"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	Restocked   bool      `json:"restocked"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (i *RefundItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Refund status constants. A creating refund is claimed but not yet sent to the payment provider; a failed
+// one was refused by the provider and refunds nothing.
+const (
+	RefundStatusCreating  = "creating"
+	RefundStatusPending   = "pending"
+	RefundStatusProcessed = "processed"
+	RefundStatusFailed    = "failed"
 )
@@ -110,17 +110,45 @@ type Product struct {
 	CompareAtPrice *float64    `json:"compareAtPrice,omitempty"`
 	SKU            string      `json:"sku" gorm:"uniqueIndex;not null"`
 	Inventory      int         `json:"inventory" gorm:"default:0;index"`
+	// Inventory at or below which the product is low on stock; DefaultLowStockThreshold when unset
+	LowStockThreshold *int     `json:"lowStockThreshold,omitempty"`
 	IsActive       bool        `json:"isActive" gorm:"default:true;index"`
 	CategoryID     string      `json:"categoryId" gorm:"not null;index"`
 	Images         StringArray `json:"images" gorm:"type:text[]"`
+	// Lowercase labels grouping products across categories, such as for promotions
+	Tags           StringArray `json:"tags" gorm:"type:text[]"`
 	Specifications JSONB       `json:"specifications" gorm:"type:jsonb"`
 	SEOTitle       *string     `json:"seoTitle,omitempty"`
 	SEODescription *string     `json:"seoDescription,omitempty"`
+	WeightGrams    *float64    `json:"weightGrams,omitempty"`
+	// Display strings formatted for the requesting store's locale; filled by the catalog API, never stored
+	PriceDisplay          string `json:"priceDisplay,omitempty" gorm:"-"`
+	CompareAtPriceDisplay string `json:"compareAtPriceDisplay,omitempty" gorm:"-"`
+	WeightDisplay         string `json:"weightDisplay,omitempty" gorm:"-"`
+	// Locale of the name, description and SEO fields when a translation was picked for the request; filled by the catalog API, never stored
+	Locale string `json:"locale,omitempty" gorm:"-"`
+	// Delivery URLs of Images at the size the request asked for; filled by the API, never stored
+	ImageURLs []string `json:"imageUrls,omitempty" gorm:"-"`
+	// Version goes up with every change to the product; admin updates based on an older version are rejected
+	Version        int64       `json:"version" gorm:"not null;default:1"`
 	CreatedAt      time.Time   `json:"createdAt"`
 	UpdatedAt      time.Time   `json:"updatedAt"`
 	DeletedAt      gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index"`
 	Category       Category    `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
 	OrderItems     []OrderItem `json:"orderItems,omitempty" gorm:"foreignKey:ProductID"`
+	Documents      []ProductDocument `json:"documents,omitempty" gorm:"foreignKey:ProductID"`
+	Translations   []ProductTranslation `json:"translations,omitempty" gorm:"foreignKey:ProductID"`
+}
+
+// DefaultLowStockThreshold is the inventory at or below which a product without its own threshold is low on stock
+const DefaultLowStockThreshold = 5
+
+// StockThreshold returns the inventory at or below which the product is low on stock
+func (p *Product) StockThreshold() int {
+	if p.LowStockThreshold != nil {
+		return *p.LowStockThreshold
+	}
+	return DefaultLowStockThreshold
 }
 
 // BeforeCreate hook to generate UUID
@@ -129,4 +157,15 @@ func (p *Product) BeforeCreate(tx *gorm.DB) error {
 		p.ID = uuid.New().String()
 	}
 	return nil
+}
+
+// BeforeUpdate bumps the version with every column update of a product, so stock taken by orders and
+// refunds invalidates the version an admin is editing as much as another admin's edit does
+func (p *Product) BeforeUpdate(tx *gorm.DB) error {
+	if updates, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		if _, set := updates["version"]; !set {
+			updates["version"] = gorm.Expr("version + 1")
+		}
+	}
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProductDocument is a file attached to a product, such as a spec sheet or manual, kept in the media store
+type ProductDocument struct {
+	ID          string `json:"id" gorm:"primaryKey"`
+	ProductID   string `json:"productId" gorm:"not null;index"`
+	Kind        string `json:"kind" gorm:"type:varchar(20);not null"`
+	Title       string `json:"title" gorm:"not null"`
+	FileName    string `json:"fileName" gorm:"not null"`
+	ContentType string `json:"contentType" gorm:"type:varchar(100);not null"`
+	SizeBytes   int64  `json:"sizeBytes" gorm:"not null"`
+	StorageKey  string `json:"-" gorm:"not null;uniqueIndex"`
+	SortOrder   int    `json:"sortOrder" gorm:"not null;default:0"`
+	// Delivery URL of the file; filled from the media store when the document is returned, never stored
+	URL       string    `json:"url" gorm:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (d *ProductDocument) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Product document kinds
+const (
+	DocumentKindSpecSheet = "spec_sheet"
+	DocumentKindManual    = "manual"
+	DocumentKindFAQ       = "faq"
+	DocumentKindOther     = "other"
+)
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Product link types
+const (
+	LinkRelated   = "related"    // similar products shown alongside the product
+	LinkCrossSell = "cross_sell" // products that go with the product, such as accessories
+	LinkUpsell    = "upsell"     // better or pricier alternatives to the product
+)
+
+// ProductLink is a related product curated by an admin for a product. Links point one way: a product listed
+// as an upsell of another does not list that one back.
+type ProductLink struct {
+	ID               string    `json:"id" gorm:"primaryKey"`
+	ProductID        string    `json:"productId" gorm:"not null;uniqueIndex:idx_product_links_product_related_type"`
+	RelatedProductID string    `json:"relatedProductId" gorm:"not null;index;uniqueIndex:idx_product_links_product_related_type"`
+	Type             string    `json:"type" gorm:"type:varchar(20);not null;uniqueIndex:idx_product_links_product_related_type"`
+	Position         int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+	RelatedProduct   *Product  `json:"relatedProduct,omitempty" gorm:"foreignKey:RelatedProductID"`
+}
+
+// BeforeCreate hook to generate UUID
+func (l *ProductLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ProductListing is the denormalized row the storefront product list is served from: one per active
+// product, with its category and stock flags precomputed so listing needs no joins or preloads. Rows are
+// refreshed from product and inventory events and rebuilt periodically; nothing else writes them.
+type ProductListing struct {
+	ProductID      string   `json:"productId" gorm:"primaryKey"`
+	Name           string   `json:"name" gorm:"not null;index"`
+	Description    string   `json:"description"`
+	SKU            string   `json:"sku" gorm:"not null"`
+	Price          float64  `json:"price" gorm:"not null"`
+	CompareAtPrice *float64 `json:"compareAtPrice,omitempty"`
+	// EffectivePrice is what a customer pays today; DiscountPercent is how far it is below the compare-at price
+	EffectivePrice  float64     `json:"effectivePrice" gorm:"not null;index"`
+	DiscountPercent int         `json:"discountPercent" gorm:"not null;default:0"`
+	Inventory       int         `json:"inventory" gorm:"not null;default:0"`
+	InStock         bool        `json:"inStock" gorm:"not null;index"`
+	LowStock        bool        `json:"lowStock" gorm:"not null"`
+	CategoryID      string      `json:"categoryId" gorm:"not null;index"`
+	CategoryName    string      `json:"categoryName"`
+	CategorySlug    string      `json:"categorySlug"`
+	Images          StringArray `json:"images" gorm:"type:text[]"`
+	Specifications  JSONB       `json:"specifications" gorm:"type:jsonb"`
+	SEOTitle        *string     `json:"seoTitle,omitempty"`
+	SEODescription  *string     `json:"seoDescription,omitempty"`
+	WeightGrams     *float64    `json:"weightGrams,omitempty"`
+	// ProductCreatedAt and ProductUpdatedAt are the product's own timestamps; RefreshedAt is when the row was built
+	ProductCreatedAt time.Time `json:"productCreatedAt" gorm:"index"`
+	ProductUpdatedAt time.Time `json:"productUpdatedAt"`
+	RefreshedAt      time.Time `json:"refreshedAt" gorm:"index"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProductPrice is an entry in a product's price history: the price that applied from EffectiveFrom
+// until the product's next entry
+type ProductPrice struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	ProductID      string    `json:"productId" gorm:"not null;index:idx_product_prices_effective,priority:1"`
+	Price          float64   `json:"price" gorm:"not null"`
+	CompareAtPrice *float64  `json:"compareAtPrice,omitempty"`
+	EffectiveFrom  time.Time `json:"effectiveFrom" gorm:"not null;index:idx_product_prices_effective,priority:2"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (p *ProductPrice) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
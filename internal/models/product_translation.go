@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProductTranslation is the name, description and SEO fields of a product in one locale. Locales are BCP 47
+// tags such as "fr" or "fr-FR"; a product has at most one translation per locale.
+type ProductTranslation struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	ProductID      string    `json:"productId" gorm:"not null;uniqueIndex:idx_product_translations_product_locale"`
+	Locale         string    `json:"locale" gorm:"type:varchar(20);not null;uniqueIndex:idx_product_translations_product_locale"`
+	Name           string    `json:"name" gorm:"not null"`
+	Description    string    `json:"description"`
+	SEOTitle       *string   `json:"seoTitle,omitempty"`
+	SEODescription *string   `json:"seoDescription,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (t *ProductTranslation) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
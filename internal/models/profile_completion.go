@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProfileCompletionEvent records a progressive profiling interaction for analytics
+type ProfileCompletionEvent struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"userId" gorm:"not null;index"`
+	Field     string    `json:"field" gorm:"type:varchar(30);not null;index"`  // phone, default_address
+	Action    string    `json:"action" gorm:"type:varchar(20);not null;index"` // shown, dismissed, completed
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *ProfileCompletionEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
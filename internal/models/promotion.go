@@ -0,0 +1,144 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Promotion statuses
+const (
+	PromotionScheduled = "scheduled"
+	PromotionActive    = "active"
+	PromotionEnded     = "ended"
+	PromotionCancelled = "cancelled"
+)
+
+// Promotion discount types
+const (
+	PromotionPercentOff = "percentage" // DiscountValue percent off the price
+	PromotionAmountOff  = "amount"     // DiscountValue off the price
+)
+
+// Promotion is a flash sale: a discount on the products of some categories or with some tags between StartsAt
+// and EndsAt. Sale prices are written to the products when the promotion starts and restored when it ends.
+// A promotion that requires a code leaves prices alone; its discount is taken at checkout from orders that
+// redeem one of its codes.
+type Promotion struct {
+	ID            string             `json:"id" gorm:"primaryKey"`
+	Name          string             `json:"name" gorm:"not null"`
+	Description   string             `json:"description"`
+	DiscountType  string             `json:"discountType" gorm:"not null"`
+	DiscountValue float64            `json:"discountValue" gorm:"not null"`
+	CategoryIDs   StringArray        `json:"categoryIds" gorm:"type:text[]"`
+	Tags          StringArray        `json:"tags" gorm:"type:text[]"`
+	RequiresCode  bool               `json:"requiresCode" gorm:"not null;default:false"`
+	StartsAt      time.Time          `json:"startsAt" gorm:"not null;index"`
+	EndsAt        time.Time          `json:"endsAt" gorm:"not null;index"`
+	Status        string             `json:"status" gorm:"not null;default:scheduled;index"`
+	StartedAt     *time.Time         `json:"startedAt,omitempty"`
+	EndedAt       *time.Time         `json:"endedAt,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
+	Products      []PromotionProduct `json:"products,omitempty" gorm:"foreignKey:PromotionID"`
+}
+
+// BeforeCreate hook to generate UUID
+func (p *Promotion) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// SalePrice returns the price of a product at price during the promotion, rounded to cents
+func (p *Promotion) SalePrice(price float64) float64 {
+	if p.DiscountType == PromotionPercentOff {
+		price -= price * p.DiscountValue / 100
+	} else {
+		price -= p.DiscountValue
+	}
+	return math.Round(price*100) / 100
+}
+
+// Covers reports whether a product is in one of the promotion's categories or has one of its tags
+func (p *Promotion) Covers(product *Product) bool {
+	for _, categoryID := range p.CategoryIDs {
+		if product.CategoryID == categoryID {
+			return true
+		}
+	}
+	for _, tag := range p.Tags {
+		for _, productTag := range product.Tags {
+			if productTag == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PromotionProduct is a product on sale in a running promotion, with the prices it goes back to when the
+// promotion ends. A product is on one sale at a time.
+type PromotionProduct struct {
+	ID                     string    `json:"id" gorm:"primaryKey"`
+	PromotionID            string    `json:"promotionId" gorm:"not null;index"`
+	ProductID              string    `json:"productId" gorm:"not null;uniqueIndex"`
+	OriginalPrice          float64   `json:"originalPrice" gorm:"not null"`
+	OriginalCompareAtPrice *float64  `json:"originalCompareAtPrice,omitempty"`
+	SalePrice              float64   `json:"salePrice" gorm:"not null"`
+	CreatedAt              time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (p *PromotionProduct) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// PromotionCode is a single-use code redeeming a promotion that requires one. Codes are generated in batches,
+// for example one per influencer or print run, and stay used once an order redeemed them, even if the order
+// is cancelled later.
+type PromotionCode struct {
+	ID            string     `json:"id" gorm:"primaryKey"`
+	PromotionID   string     `json:"promotionId" gorm:"not null;index"`
+	BatchID       string     `json:"batchId" gorm:"not null;index"`
+	Code          string     `json:"code" gorm:"not null;uniqueIndex"`
+	IsActive      bool       `json:"isActive" gorm:"not null;default:true"`
+	UsedAt        *time.Time `json:"usedAt,omitempty"`
+	UsedBy        *string    `json:"usedBy,omitempty"`               // user whose order redeemed the code
+	OrderID       *string    `json:"orderId,omitempty" gorm:"index"` // order that redeemed the code
+	DeactivatedAt *time.Time `json:"deactivatedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// Promotion code statuses, derived from IsActive and UsedAt
+const (
+	PromotionCodeActive   = "active"   // can be redeemed
+	PromotionCodeUsed     = "used"     // redeemed by an order
+	PromotionCodeInactive = "inactive" // deactivated before it was used
+)
+
+// Status returns whether the code is active, used or inactive
+func (c *PromotionCode) Status() string {
+	switch {
+	case c.UsedAt != nil:
+		return PromotionCodeUsed
+	case !c.IsActive:
+		return PromotionCodeInactive
+	default:
+		return PromotionCodeActive
+	}
+}
+
+// BeforeCreate hook to generate UUID
+func (c *PromotionCode) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProductDailyStat is a pre-aggregated per-product, per-day row used by admin reports
+type ProductDailyStat struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	ProductID     string    `json:"productId" gorm:"not null;uniqueIndex:idx_product_daily_stats_product_date"`
+	Date          time.Time `json:"date" gorm:"not null;uniqueIndex:idx_product_daily_stats_product_date;index"`
+	Views         int64     `json:"views" gorm:"default:0"`
+	Orders        int64     `json:"orders" gorm:"default:0"`
+	UnitsSold     int64     `json:"unitsSold" gorm:"default:0"`
+	RefundedUnits int64     `json:"refundedUnits" gorm:"default:0"`
+	Revenue       float64   `json:"revenue" gorm:"default:0"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (s *ProductDailyStat) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// FunnelEvent is one cart or checkout step taken by a shopper. Guest events carry the cart session;
+// once the session is seen signed in, its events are stitched to the user.
+type FunnelEvent struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Step      string    `json:"step" gorm:"type:varchar(30);not null;index"`
+	SessionID *string   `json:"sessionId,omitempty" gorm:"type:varchar(64);index"`
+	UserID    *string   `json:"userId,omitempty" gorm:"index"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *FunnelEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Funnel steps, in funnel order; cart removals are tracked but are not a funnel step
+const (
+	FunnelStepCartAdd        = "cart_add"
+	FunnelStepCheckoutStart  = "checkout_start"
+	FunnelStepAddressSubmit  = "address_submit"
+	FunnelStepPaymentAttempt = "payment_attempt"
+	FunnelStepOrderSuccess   = "order_success"
+	FunnelEventCartRemove    = "cart_remove"
+)
+
+// Report query statuses
+const (
+	ReportQueryQueued    = "queued"    // waiting for a runner
+	ReportQueryRunning   = "running"   // being built
+	ReportQuerySucceeded = "succeeded" // Result holds the report
+	ReportQueryFailed    = "failed"    // Error says why
+)
+
+// ReportQuery is an expensive admin report built in the background. The admin who submitted it polls it by ID
+// and fetches the result once it has succeeded; Result is the JSON encoded report table.
+type ReportQuery struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	AdminID     string     `json:"adminId" gorm:"not null;index"`
+	Kind        string     `json:"kind" gorm:"type:varchar(50);not null"`
+	From        time.Time  `json:"from" gorm:"not null"`
+	To          time.Time  `json:"to" gorm:"not null"`
+	Granularity string     `json:"granularity,omitempty" gorm:"type:varchar(10)"`
+	Limit       int        `json:"limit,omitempty"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;default:'queued';index"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	Result      string     `json:"-" gorm:"type:text"`
+	ResultRows  int        `json:"resultRows"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"index"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (q *ReportQuery) BeforeCreate(tx *gorm.DB) error {
+	if q.ID == "" {
+		q.ID = uuid.New().String()
+	}
+	return nil
+}
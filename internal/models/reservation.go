@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InventoryReservation is a time-limited hold on product stock, e.g. for a cart or an unpaid order
+type InventoryReservation struct {
+	ID            string     `json:"id" gorm:"primaryKey"`
+	ProductID     string     `json:"productId" gorm:"not null;index"`
+	Quantity      int        `json:"quantity" gorm:"not null"`
+	HolderType    string     `json:"holderType" gorm:"type:varchar(20);not null"`
+	HolderID      string     `json:"holderId" gorm:"not null;index"`
+	UserID        *string    `json:"userId,omitempty" gorm:"index"`
+	ExpiresAt     time.Time  `json:"expiresAt" gorm:"not null;index"`
+	ReleasedAt    *time.Time `json:"releasedAt,omitempty" gorm:"index"`
+	ReleaseReason string     `json:"releaseReason,omitempty"`
+	ReleasedBy    *string    `json:"releasedBy,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	User          *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// BeforeCreate hook to generate UUID
+func (r *InventoryReservation) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// IsActive reports whether the reservation still holds stock at now
+func (r *InventoryReservation) IsActive(now time.Time) bool {
+	return r.ReleasedAt == nil && now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Retention rule targets, the data a rule removes or anonymizes once it is older than the rule's retention period
+const (
+	RetentionGuestCarts       = "guest_carts"       // guest carts not updated since
+	RetentionInactiveAccounts = "inactive_accounts" // customer accounts without sign-ins or orders since, anonymized
+	RetentionEmailLogs        = "email_logs"        // email log entries and provider bounce and complaint events
+	RetentionFunnelEvents     = "funnel_events"     // cart and checkout funnel events
+)
+
+// RetentionRule is how long data of one target is kept. A rule in dry-run mode only reports what it would
+// remove.
+type RetentionRule struct {
+	Target        string    `json:"target" gorm:"primaryKey;type:varchar(40)"`
+	RetentionDays int       `json:"retentionDays" gorm:"not null"`
+	Enabled       bool      `json:"enabled" gorm:"not null"`
+	DryRun        bool      `json:"dryRun" gorm:"not null"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// RetentionRun is the report of one run of a retention rule. Matched counts the records older than Cutoff;
+// Affected those actually removed or anonymized, which is 0 for a dry run. Sample lists some of the matched IDs.
+type RetentionRun struct {
+	ID         string      `json:"id" gorm:"primaryKey"`
+	Target     string      `json:"target" gorm:"type:varchar(40);not null;index"`
+	DryRun     bool        `json:"dryRun" gorm:"not null"`
+	Cutoff     time.Time   `json:"cutoff"`
+	Matched    int64       `json:"matched"`
+	Affected   int64       `json:"affected"`
+	Sample     StringArray `json:"sample" gorm:"type:text[]"`
+	Error      string      `json:"error,omitempty" gorm:"type:text"`
+	StartedAt  time.Time   `json:"startedAt" gorm:"index"`
+	FinishedAt time.Time   `json:"finishedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (r *RetentionRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Admin grids a saved view can belong to
+const (
+	SavedViewOrders    = "orders"
+	SavedViewProducts  = "products"
+	SavedViewCustomers = "customers"
+)
+
+// SavedView is a named filter and sort preset an admin keeps for an admin list endpoint. State holds the
+// grid's query parameters as the admin panel serialized them; shared views are listed for other staff too,
+// but only the owner can change them.
+type SavedView struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	OwnerID    string    `json:"ownerId" gorm:"not null;uniqueIndex:idx_saved_view_owner_name"`
+	OwnerEmail string    `json:"ownerEmail"`
+	Resource   string    `json:"resource" gorm:"type:varchar(20);not null;index;uniqueIndex:idx_saved_view_owner_name"`
+	Name       string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_saved_view_owner_name"`
+	State      JSONB     `json:"state" gorm:"type:jsonb"`
+	Shared     bool      `json:"shared" gorm:"not null;default:false;index"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (v *SavedView) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+	return nil
+}
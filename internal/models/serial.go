@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderItemSerial is the serial number or IMEI of one unit of an order item, recorded when its shipment ships.
+// A serial identifies a single unit of a product, so it is unique per product.
+type OrderItemSerial struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	OrderID     string    `json:"orderId" gorm:"not null;index"`
+	OrderItemID string    `json:"orderItemId" gorm:"not null;index"`
+	ShipmentID  string    `json:"shipmentId" gorm:"not null;index"`
+	ProductID   string    `json:"productId" gorm:"not null;uniqueIndex:idx_product_serial"`
+	Serial      string    `json:"serial" gorm:"type:varchar(64);not null;uniqueIndex:idx_product_serial;index"`
+	RecordedBy  string    `json:"recordedBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (s *OrderItemSerial) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Setting scopes: global values apply everywhere, store values override them for one storefront
+const (
+	SettingScopeGlobal = "global"
+	SettingScopeStore  = "store"
+)
+
+// Setting value types; the value is always stored as text and parsed on read
+const (
+	SettingTypeString = "string"
+	SettingTypeInt    = "int"
+	SettingTypeFloat  = "float"
+	SettingTypeBool   = "bool"
+	SettingTypeJSON   = "json"
+)
+
+// Setting is a business-tunable configuration value managed by admins at runtime
+type Setting struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	Key         string    `json:"key" gorm:"type:varchar(100);not null;uniqueIndex:idx_settings_scope_key"`
+	Scope       string    `json:"scope" gorm:"type:varchar(20);not null;uniqueIndex:idx_settings_scope_key"`
+	ScopeID     string    `json:"scopeId" gorm:"type:varchar(100);not null;uniqueIndex:idx_settings_scope_key"` // store identifier for store scope, empty for global
+	Type        string    `json:"type" gorm:"type:varchar(20);not null"`
+	Value       string    `json:"value" gorm:"type:text;not null"`
+	Description string    `json:"description"`
+	UpdatedBy   string    `json:"updatedBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (s *Setting) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
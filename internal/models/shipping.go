@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Types of shipping method pricing
+const (
+	// ShippingTypeFlat charges Rate per shipment
+	ShippingTypeFlat = "flat"
+	// ShippingTypeWeight charges Rate plus PerKg for every started kilogram of the shipment
+	ShippingTypeWeight = "weight"
+	// ShippingTypeFreeOverThreshold charges Rate unless the order subtotal reaches FreeThreshold
+	ShippingTypeFreeOverThreshold = "free_over_threshold"
+)
+
+// ShippingMethod is a way an order can be shipped, offered to the countries and delivery zones it lists
+// (or everywhere when they are empty) and priced by its type
+type ShippingMethod struct {
+	ID            string      `json:"id" gorm:"primaryKey"`
+	Name          string      `json:"name" gorm:"not null"`
+	Description   string      `json:"description"`
+	Type          string      `json:"type" gorm:"type:varchar(30);not null"`
+	Rate          float64     `json:"rate" gorm:"not null;default:0"`
+	PerKg         float64     `json:"perKg" gorm:"not null;default:0"`
+	FreeThreshold float64     `json:"freeThreshold" gorm:"not null;default:0"`
+	Countries     StringArray `json:"countries" gorm:"type:text[]"`
+	Zones         StringArray `json:"zones" gorm:"type:text[]"` // delivery zone IDs the method is limited to; empty for every zone
+	SortOrder     int         `json:"sortOrder" gorm:"not null;default:0"`
+	IsActive      bool        `json:"isActive" gorm:"default:true;index"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	UpdatedAt     time.Time   `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (m *ShippingMethod) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// DeliveryZone is an area deliveries are planned and priced for. An address is in a zone when it is in the
+// zone's country and either its postal code starts with one of PostalPrefixes or its geocoded location lies
+// within RadiusKm of the zone's center. A zone with neither covers its whole country. When zones overlap the
+// one with the lowest Priority wins.
+type DeliveryZone struct {
+	ID             string      `json:"id" gorm:"primaryKey"`
+	Name           string      `json:"name" gorm:"not null"`
+	Country        string      `json:"country" gorm:"type:varchar(2);not null;index"`
+	PostalPrefixes StringArray `json:"postalPrefixes" gorm:"type:text[]"`
+	CenterLat      *float64    `json:"centerLat,omitempty"`
+	CenterLng      *float64    `json:"centerLng,omitempty"`
+	RadiusKm       float64     `json:"radiusKm" gorm:"not null;default:0"`
+	Surcharge      float64     `json:"surcharge" gorm:"not null;default:0"` // added to every shipping rate into the zone
+	Priority       int         `json:"priority" gorm:"not null;default:0"`
+	IsActive       bool        `json:"isActive" gorm:"default:true;index"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (z *DeliveryZone) BeforeCreate(tx *gorm.DB) error {
+	if z.ID == "" {
+		z.ID = uuid.New().String()
+	}
+	return nil
+}
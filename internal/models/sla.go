@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SLA alert levels, raised once per order and policy
+const (
+	SLAAlertAtRisk   = "at_risk"
+	SLAAlertBreached = "breached"
+)
+
+// SLAPolicy is a fulfillment target, such as shipping within 48 hours of payment. The timer of an order starts
+// when it enters StartStatus and stops when it reaches TargetStatus or a later status.
+type SLAPolicy struct {
+	ID           string `json:"id" gorm:"primaryKey"`
+	Name         string `json:"name" gorm:"not null"`
+	StartStatus  string `json:"startStatus" gorm:"type:varchar(20);not null"`
+	TargetStatus string `json:"targetStatus" gorm:"type:varchar(20);not null"`
+	TargetHours  int    `json:"targetHours" gorm:"not null"`
+	// WarningHours is how long before the deadline an order is reported as at risk; 0 disables the warning
+	WarningHours int       `json:"warningHours" gorm:"default:0"`
+	Active       bool      `json:"active" gorm:"default:true"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (p *SLAPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// SLAAlert records that admins were alerted about an order approaching or breaching a policy, so each alert is raised once
+type SLAAlert struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	PolicyID  string    `json:"policyId" gorm:"not null;uniqueIndex:idx_sla_alert"`
+	OrderID   string    `json:"orderId" gorm:"not null;uniqueIndex:idx_sla_alert"`
+	Level     string    `json:"level" gorm:"type:varchar(20);not null;uniqueIndex:idx_sla_alert"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (a *SLAAlert) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TaxRule charges a tax on orders shipped to a country, or to one state of it when State is set.
+// Every active rule matching an address applies, so a state rule adds to the country's rules.
+type TaxRule struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	Country   string    `json:"country" gorm:"type:varchar(2);not null;index:idx_tax_rules_region"`
+	State     string    `json:"state" gorm:"not null;default:'';index:idx_tax_rules_region"`
+	Rate      float64   `json:"rate" gorm:"not null"`
+	IsActive  bool      `json:"isActive" gorm:"default:true;index"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (r *TaxRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TaxLine is one tax charged on an order
+type TaxLine struct {
+	Name         string  `json:"name"`
+	Jurisdiction string  `json:"jurisdiction"`
+	Rate         float64 `json:"rate"`
+	Amount       float64 `json:"amount"`
+}
+
+// TaxBreakdown lists the taxes making up an order's tax, stored as JSONB
+type TaxBreakdown []TaxLine
+
+// Value implements the driver.Valuer interface
+func (b TaxBreakdown) Value() (driver.Value, error) {
+	if b == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements the sql.Scanner interface
+func (b *TaxBreakdown) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*b = TaxBreakdown{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, b)
+	case string:
+		return json.Unmarshal([]byte(v), b)
+	default:
+		return errors.New("cannot scan into TaxBreakdown")
+	}
+}
@@ -15,17 +15,34 @@ type User struct {
 	LastName             string     `json:"lastName" gorm:"not null"`
 	Phone                *string    `json:"phone,omitempty"`
 	Role                 string     `json:"role" gorm:"type:varchar(20);default:'customer'"`
+	AdminRole            string     `json:"adminRole,omitempty" gorm:"type:varchar(30);not null;default:''"` // what an admin may manage; empty for customers
 	IsActive             bool       `json:"isActive" gorm:"default:true"`
 	EmailVerified        bool       `json:"emailVerified" gorm:"default:false"`
 	EmailVerificationToken *string  `json:"-" gorm:"type:varchar(255)"`
 	PasswordResetToken   *string    `json:"-" gorm:"type:varchar(255)"`
 	PasswordResetExpiry  *time.Time `json:"-"`
+	MarketingOptOut      bool       `json:"marketingOptOut" gorm:"default:false"` // no marketing or review request emails
+	EmailBounced         bool       `json:"emailBounced" gorm:"default:false"`    // the address hard-bounced, so no email reaches it
+	OrderEmailOptOut     bool       `json:"orderEmailOptOut" gorm:"default:false"` // no order status emails; confirmations and refunds still go out
+	OrderSMSOptIn        bool       `json:"orderSmsOptIn" gorm:"default:false"`    // order status texts to Phone
+	TOTPSecret           *string    `json:"-" gorm:"type:varchar(64)"`
+	TOTPEnabled          bool       `json:"totpEnabled" gorm:"default:false"` // sign-in requires a TOTP code
+	TOTPLastStep         *int64     `json:"-"` // time step of the last accepted TOTP code, which cannot be used again
+	LastActiveAt         *time.Time `json:"lastActiveAt,omitempty"` // last sign-in or session refresh
+	AnonymizedAt         *time.Time `json:"anonymizedAt,omitempty"` // personal data was removed by data retention
 	CreatedAt            time.Time  `json:"createdAt"`
 	UpdatedAt            time.Time  `json:"updatedAt"`
 	Addresses            []Address  `json:"addresses,omitempty" gorm:"foreignKey:UserID"`
 	Orders               []Order    `json:"orders,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// Admin roles limit which admin areas an admin account may use
+const (
+	AdminRoleSuper          = "super_admin"     // every admin area, including admin accounts
+	AdminRoleCatalogManager = "catalog_manager" // products, categories, inventory and search
+	AdminRoleSupport        = "support"         // orders, customers and warranty claims
+)
+
 // BeforeCreate hook to generate UUID
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == "" {
@@ -49,9 +66,14 @@ type Address struct {
 	Country    string    `json:"country" gorm:"not null"`
 	Phone      *string   `json:"phone,omitempty"`
 	IsDefault  bool      `json:"isDefault" gorm:"default:false"`
-	CreatedAt  time.Time `json:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt"`
-	User       User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	// Location is filled in by geocoding when the address is saved; DeliveryZoneID is the zone it falls in
+	Latitude       *float64   `json:"latitude,omitempty"`
+	Longitude      *float64   `json:"longitude,omitempty"`
+	GeocodedAt     *time.Time `json:"geocodedAt,omitempty"`
+	DeliveryZoneID *string    `json:"deliveryZoneId,omitempty" gorm:"index"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	User           User       `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
 // BeforeCreate hook to generate UUID
@@ -60,4 +82,45 @@ func (a *Address) BeforeCreate(tx *gorm.DB) error {
 		a.ID = uuid.New().String()
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// AdminLoginEvent records a successful sign-in with the admin role, so sign-ins from new locations stand out
+type AdminLoginEvent struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"userId" gorm:"not null"`
+	Email       string    `json:"email" gorm:"not null;index"`
+	IP          string    `json:"ip" gorm:"type:varchar(45);not null"`
+	Network     string    `json:"network" gorm:"type:varchar(50);not null"` // IPv4 /24 or IPv6 /48 the IP belongs to
+	UserAgent   string    `json:"userAgent"`
+	MFA         bool      `json:"mfa" gorm:"not null;default:false"`
+	NewLocation bool      `json:"newLocation" gorm:"not null;default:false"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"index"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *AdminLoginEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// MagicLinkToken is a single-use password-less sign-in link emailed to a customer. The link carries a
+// signed token naming the row; the row makes it single-use and ties it to the requesting device.
+type MagicLinkToken struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	UserID      string     `json:"userId" gorm:"not null;index"`
+	Fingerprint string     `json:"-" gorm:"type:varchar(64);not null"`
+	IP          string     `json:"ip" gorm:"type:varchar(45)"`
+	ExpiresAt   time.Time  `json:"expiresAt" gorm:"not null"`
+	UsedAt      *time.Time `json:"usedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"index"`
+}
+
+// BeforeCreate hook to generate UUID
+func (t *MagicLinkToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Warranty statuses, derived from the expiry date
+const (
+	WarrantyActive  = "active"
+	WarrantyExpired = "expired"
+)
+
+// Warranty claim statuses. A claim is submitted by the customer, reviewed by an admin, then either
+// rejected or approved and finally resolved once the unit is repaired or replaced.
+const (
+	WarrantyClaimSubmitted = "submitted"
+	WarrantyClaimInReview  = "in_review"
+	WarrantyClaimApproved  = "approved"
+	WarrantyClaimRejected  = "rejected"
+	WarrantyClaimResolved  = "resolved"
+)
+
+// Warranty covers the delivered units of an order item in a category with a warranty period.
+// It is registered automatically once the item is delivered and runs from delivery.
+type Warranty struct {
+	ID          string            `json:"id" gorm:"primaryKey"`
+	UserID      string            `json:"userId" gorm:"not null;index"`
+	OrderID     string            `json:"orderId" gorm:"not null;index"`
+	OrderItemID string            `json:"orderItemId" gorm:"not null;uniqueIndex"`
+	ProductID   string            `json:"productId" gorm:"not null;index"`
+	Quantity    int               `json:"quantity" gorm:"not null"`
+	Months      int               `json:"months" gorm:"not null"`
+	StartsAt    time.Time         `json:"startsAt" gorm:"not null"`
+	ExpiresAt   time.Time         `json:"expiresAt" gorm:"not null;index"`
+	Status      string            `json:"status" gorm:"-"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	Product     Product           `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Serials     []OrderItemSerial `json:"serials,omitempty" gorm:"foreignKey:OrderItemID;references:OrderItemID"`
+	Claims      []WarrantyClaim   `json:"claims,omitempty" gorm:"foreignKey:WarrantyID"`
+}
+
+// BeforeCreate hook to generate UUID
+func (w *Warranty) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// StatusAt returns whether the warranty is active or expired at now
+func (w *Warranty) StatusAt(now time.Time) string {
+	if now.Before(w.ExpiresAt) {
+		return WarrantyActive
+	}
+	return WarrantyExpired
+}
+
+// WarrantyClaim is a customer's request to have a unit under warranty repaired or replaced
+type WarrantyClaim struct {
+	ID          string               `json:"id" gorm:"primaryKey"`
+	WarrantyID  string               `json:"warrantyId" gorm:"not null;index"`
+	UserID      string               `json:"userId" gorm:"not null;index"`
+	Serial      string               `json:"serial,omitempty" gorm:"type:varchar(64)"`
+	Description string               `json:"description" gorm:"type:text;not null"`
+	Status      string               `json:"status" gorm:"type:varchar(20);not null;index"`
+	Resolution  string               `json:"resolution,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	UpdatedAt   time.Time            `json:"updatedAt"`
+	Warranty    *Warranty            `json:"warranty,omitempty" gorm:"foreignKey:WarrantyID"`
+	Events      []WarrantyClaimEvent `json:"events,omitempty" gorm:"foreignKey:ClaimID"`
+}
+
+// BeforeCreate hook to generate UUID
+func (c *WarrantyClaim) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// WarrantyClaimEvent records a status change of a warranty claim and who made it
+type WarrantyClaimEvent struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	ClaimID   string    `json:"claimId" gorm:"not null;index"`
+	Status    string    `json:"status" gorm:"type:varchar(20);not null"`
+	Note      string    `json:"note,omitempty" gorm:"type:text"`
+	ActorID   string    `json:"actorId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *WarrantyClaimEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
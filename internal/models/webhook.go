@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"   // waiting for its first or next attempt
+	WebhookDeliveryDelivered = "delivered" // the endpoint answered with a 2xx status
+	WebhookDeliveryFailed    = "failed"    // every attempt failed; only an admin redelivery sends it again
+)
+
+// WebhookEndpoint is an external URL that receives signed store events of the types it subscribes to
+type WebhookEndpoint struct {
+	ID          string      `json:"id" gorm:"primaryKey"`
+	URL         string      `json:"url" gorm:"not null"`
+	Description string      `json:"description"`
+	Events      StringArray `json:"events" gorm:"type:text[]"`
+	Secret      string      `json:"-" gorm:"not null"`
+	IsActive    bool        `json:"isActive" gorm:"default:true;index"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (e *WebhookEndpoint) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// WebhookDelivery is one event sent to one endpoint. Payload is the exact request body that is signed, so
+// every attempt sends the same bytes.
+type WebhookDelivery struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	EndpointID     string     `json:"endpointId" gorm:"not null;index"`
+	Event          string     `json:"event" gorm:"type:varchar(50);not null"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty" gorm:"index"`
+	ResponseStatus int        `json:"responseStatus,omitempty"`
+	LastError      string     `json:"lastError,omitempty" gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// BeforeCreate hook to generate UUID
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
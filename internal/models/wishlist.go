@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WishlistItem is a product saved for later, owned by a signed-in user or, before sign-in, by a guest session
+type WishlistItem struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    *string   `json:"userId,omitempty" gorm:"uniqueIndex:idx_wishlist_user_product"`
+	SessionID *string   `json:"-" gorm:"uniqueIndex:idx_wishlist_session_product"`
+	ProductID string    `json:"productId" gorm:"not null;uniqueIndex:idx_wishlist_user_product;uniqueIndex:idx_wishlist_session_product"`
+	CreatedAt time.Time `json:"createdAt"`
+	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+// BeforeCreate hook to generate UUID
+func (w *WishlistItem) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// WishlistRevision counts the changes to one owner's wishlist, so clients can tell whether an optimistic
+// update was based on the current list. Owner is "user:<id>" or "session:<id>".
+type WishlistRevision struct {
+	Owner     string    `json:"owner" gorm:"primaryKey"`
+	Revision  int64     `json:"revision" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
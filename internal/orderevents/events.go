@@ -0,0 +1,129 @@
+package orderevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"gorm.io/gorm"
+)
+
+// Event types recorded for an order
+const (
+	TypeOrderCreated          = "order.created"
+	TypeOrderStatusChanged    = "order.status_changed"
+	TypeShipmentStatusChanged = "order.shipment_status_changed"
+)
+
+// OrderCreated is recorded when checkout creates an order
+type OrderCreated struct {
+	UserID    string         `json:"userId"`
+	Status    string         `json:"status"`
+	Subtotal  float64        `json:"subtotal"`
+	Tax       float64        `json:"tax"`
+	Shipping  float64        `json:"shipping"`
+	Total     float64        `json:"total"`
+	Items     []ItemData     `json:"items"`
+	Shipments []ShipmentData `json:"shipments"`
+}
+
+// ItemData is an order line as it was created
+type ItemData struct {
+	ItemID     string  `json:"itemId"`
+	ProductID  string  `json:"productId"`
+	ShipmentID *string `json:"shipmentId,omitempty"`
+	Quantity   int     `json:"quantity"`
+	Price      float64 `json:"price"`
+}
+
+// ShipmentData is a shipment as it was created
+type ShipmentData struct {
+	ShipmentID string  `json:"shipmentId"`
+	GroupKey   string  `json:"groupKey"`
+	Status     string  `json:"status"`
+	Shipping   float64 `json:"shipping"`
+}
+
+// OrderStatusChanged is recorded when the order status moves. CascadeShipments is set when the
+// change also moved the order's shipments along with it (admin status updates and cancellations).
+type OrderStatusChanged struct {
+	From             string `json:"from"`
+	To               string `json:"to"`
+	Reason           string `json:"reason,omitempty"`
+	CascadeShipments bool   `json:"cascadeShipments"`
+}
+
+// ShipmentStatusChanged is recorded when one shipment moves through fulfillment
+type ShipmentStatusChanged struct {
+	ShipmentID     string                `json:"shipmentId"`
+	From           string                `json:"from"`
+	To             string                `json:"to"`
+	TrackingNumber *string               `json:"trackingNumber,omitempty"`
+	DeliveryProof  *models.DeliveryProof `json:"deliveryProof,omitempty"`
+}
+
+// Enabled reports whether order changes are recorded in the event log
+func Enabled(ctx context.Context, reader settings.Reader) bool {
+	return reader.GetBool(ctx, settings.KeyOrderEventSourcing)
+}
+
+// Append adds an event to the end of an order's log. It must run in the transaction that makes the
+// change so the log and the order row never disagree; a concurrent append for the same order fails
+// on the (order_id, sequence) unique index and rolls that transaction back.
+func Append(tx *gorm.DB, orderID, eventType string, data interface{}) error {
+	payload, err := toJSONB(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	var last int
+	if err := tx.Model(&models.OrderEvent{}).Where("order_id = ?", orderID).
+		Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+		return fmt.Errorf("failed to read order event sequence: %w", err)
+	}
+
+	event := models.OrderEvent{
+		OrderID:  orderID,
+		Sequence: last + 1,
+		Type:     eventType,
+		Data:     payload,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to append %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// Load returns an order's events in sequence order
+func Load(db *gorm.DB, orderID string) ([]models.OrderEvent, error) {
+	var events []models.OrderEvent
+	if err := db.Where("order_id = ?", orderID).Order("sequence ASC").Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to load order events: %w", err)
+	}
+	return events, nil
+}
+
+// toJSONB converts a typed event payload into the stored map form
+func toJSONB(data interface{}) (models.JSONB, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var payload models.JSONB
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// decode converts a stored event payload back into its typed form
+func decode(payload models.JSONB, v interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,162 @@
+package orderevents
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoEvents         = errors.New("order has no recorded events")
+	ErrIncompleteStream = errors.New("order event stream does not start with order creation")
+	ErrUnknownEventType = errors.New("unknown order event type")
+)
+
+// Projection is the order state rebuilt from its events
+type Projection struct {
+	OrderID     string            `json:"orderId"`
+	Version     int               `json:"version"`
+	UserID      string            `json:"userId"`
+	Status      string            `json:"status"`
+	Subtotal    float64           `json:"subtotal"`
+	Tax         float64           `json:"tax"`
+	Shipping    float64           `json:"shipping"`
+	Total       float64           `json:"total"`
+	DeliveredAt *time.Time        `json:"deliveredAt,omitempty"`
+	Items       []ItemData        `json:"items"`
+	Shipments   map[string]string `json:"shipments"` // shipment ID -> status
+}
+
+// Project replays events in sequence order. A positive upTo stops after that sequence number,
+// showing the order as it was at that point.
+func Project(events []models.OrderEvent, upTo int) (*Projection, error) {
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+	if events[0].Type != TypeOrderCreated {
+		return nil, ErrIncompleteStream
+	}
+
+	p := &Projection{OrderID: events[0].OrderID, Shipments: make(map[string]string)}
+	for _, event := range events {
+		if upTo > 0 && event.Sequence > upTo {
+			break
+		}
+		if err := p.apply(event); err != nil {
+			return nil, fmt.Errorf("event %d: %w", event.Sequence, err)
+		}
+		p.Version = event.Sequence
+	}
+	return p, nil
+}
+
+// apply folds one event into the projection
+func (p *Projection) apply(event models.OrderEvent) error {
+	switch event.Type {
+	case TypeOrderCreated:
+		var data OrderCreated
+		if err := decode(event.Data, &data); err != nil {
+			return err
+		}
+		p.UserID, p.Status = data.UserID, data.Status
+		p.Subtotal, p.Tax, p.Shipping, p.Total = data.Subtotal, data.Tax, data.Shipping, data.Total
+		p.Items = data.Items
+		for _, shipment := range data.Shipments {
+			p.Shipments[shipment.ShipmentID] = shipment.Status
+		}
+	case TypeOrderStatusChanged:
+		var data OrderStatusChanged
+		if err := decode(event.Data, &data); err != nil {
+			return err
+		}
+		if data.To == "delivered" && p.Status != "delivered" {
+			at := event.CreatedAt
+			p.DeliveredAt = &at
+		}
+		p.Status = data.To
+		if data.CascadeShipments {
+			p.cascadeShipments(data.To)
+		}
+	case TypeShipmentStatusChanged:
+		var data ShipmentStatusChanged
+		if err := decode(event.Data, &data); err != nil {
+			return err
+		}
+		p.Shipments[data.ShipmentID] = data.To
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, event.Type)
+	}
+	return nil
+}
+
+// cascadeShipments moves shipments along with an order status change the way the orders service does
+func (p *Projection) cascadeShipments(status string) {
+	for id, current := range p.Shipments {
+		switch {
+		case (status == "shipped" || status == "delivered") && current == "pending":
+			p.Shipments[id] = "shipped"
+		case (status == "cancelled" || status == "refunded") && current == "pending":
+			p.Shipments[id] = "cancelled"
+		}
+		if status == "delivered" && p.Shipments[id] == "shipped" {
+			p.Shipments[id] = "delivered"
+		}
+	}
+}
+
+// Drift lists the fields where the stored order disagrees with the projection, sorted by name
+func Drift(p *Projection, order *models.Order) []string {
+	drift := []string{}
+	if order.Status != p.Status {
+		drift = append(drift, "status")
+	}
+	if order.Subtotal != p.Subtotal {
+		drift = append(drift, "subtotal")
+	}
+	if order.Tax != p.Tax {
+		drift = append(drift, "tax")
+	}
+	if order.Shipping != p.Shipping {
+		drift = append(drift, "shipping")
+	}
+	if order.Total != p.Total {
+		drift = append(drift, "total")
+	}
+	for _, shipment := range order.Shipments {
+		if status, ok := p.Shipments[shipment.ID]; !ok || status != shipment.Status {
+			drift = append(drift, "shipments."+shipment.ID)
+		}
+	}
+	sort.Strings(drift)
+	return drift
+}
+
+// Materialize overwrites the order row and its shipment statuses with the projection
+func Materialize(tx *gorm.DB, p *Projection) error {
+	updates := map[string]interface{}{
+		"status":   p.Status,
+		"subtotal": p.Subtotal,
+		"tax":      p.Tax,
+		"shipping": p.Shipping,
+		"total":    p.Total,
+	}
+	if p.DeliveredAt != nil {
+		updates["delivered_at"] = *p.DeliveredAt
+	}
+	if err := tx.Model(&models.Order{}).Where("id = ?", p.OrderID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to materialize order: %w", err)
+	}
+
+	for id, status := range p.Shipments {
+		if err := tx.Model(&models.Shipment{}).Where("id = ? AND order_id = ?", id, p.OrderID).
+			Update("status", status).Error; err != nil {
+			return fmt.Errorf("failed to materialize shipment: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package orderevents
+
+import (
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(t *testing.T, sequence int, eventType string, data interface{}) models.OrderEvent {
+	payload, err := toJSONB(data)
+	require.NoError(t, err)
+	return models.OrderEvent{OrderID: "order-1", Sequence: sequence, Type: eventType, Data: payload, CreatedAt: time.Now()}
+}
+
+func TestProject(t *testing.T) {
+	events := []models.OrderEvent{
+		testEvent(t, 1, TypeOrderCreated, OrderCreated{
+			UserID: "user-1", Status: "pending", Subtotal: 30, Shipping: 50, Total: 80,
+			Items:     []ItemData{{ItemID: "item-1", ProductID: "mug", Quantity: 3, Price: 10}},
+			Shipments: []ShipmentData{{ShipmentID: "a", Status: "pending"}, {ShipmentID: "b", Status: "pending"}},
+		}),
+		testEvent(t, 2, TypeOrderStatusChanged, OrderStatusChanged{From: "pending", To: "paid"}),
+		testEvent(t, 3, TypeShipmentStatusChanged, ShipmentStatusChanged{ShipmentID: "a", From: "pending", To: "shipped"}),
+		testEvent(t, 4, TypeOrderStatusChanged, OrderStatusChanged{From: "paid", To: "shipped"}),
+		testEvent(t, 5, TypeOrderStatusChanged, OrderStatusChanged{From: "shipped", To: "delivered", CascadeShipments: true}),
+	}
+
+	p, err := Project(events, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 5, p.Version)
+	assert.Equal(t, "delivered", p.Status)
+	assert.Equal(t, 80.0, p.Total)
+	assert.NotNil(t, p.DeliveredAt)
+	assert.Equal(t, map[string]string{"a": "delivered", "b": "delivered"}, p.Shipments)
+	require.Len(t, p.Items, 1)
+
+	t.Run("stops at upTo", func(t *testing.T) {
+		p, err := Project(events, 4)
+		require.NoError(t, err)
+		assert.Equal(t, 4, p.Version)
+		assert.Equal(t, "shipped", p.Status)
+		assert.Nil(t, p.DeliveredAt)
+		assert.Equal(t, map[string]string{"a": "shipped", "b": "pending"}, p.Shipments, "shipment-driven order changes leave other shipments alone")
+	})
+
+	t.Run("drift against the stored order", func(t *testing.T) {
+		order := &models.Order{Status: "shipped", Subtotal: 30, Shipping: 50, Total: 80, Shipments: []models.Shipment{
+			{ID: "a", Status: "delivered"}, {ID: "b", Status: "pending"},
+		}}
+		assert.Equal(t, []string{"shipments.b", "status"}, Drift(p, order))
+	})
+
+	t.Run("rejects incomplete streams", func(t *testing.T) {
+		_, err := Project(nil, 0)
+		assert.ErrorIs(t, err, ErrNoEvents)
+		_, err = Project(events[1:], 0)
+		assert.ErrorIs(t, err, ErrIncompleteStream)
+		_, err = Project(append(events[:1:1], testEvent(t, 2, "order.unknown", nil)), 0)
+		assert.ErrorIs(t, err, ErrUnknownEventType)
+	})
+}
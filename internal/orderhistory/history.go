@@ -0,0 +1,60 @@
+package orderhistory
+
+import (
+	"fmt"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Actor is whoever moves an order to a new status
+type Actor struct {
+	ID   string
+	Role string
+}
+
+var (
+	// System is the actor of changes made by payment provider events and background jobs
+	System = Actor{Role: models.OrderActorSystem}
+	// Carrier is the carrier webhook or driver app confirming a delivery
+	Carrier = Actor{Role: models.OrderActorCarrier}
+)
+
+// Customer is a customer acting on their own order
+func Customer(userID string) Actor {
+	return Actor{ID: userID, Role: models.OrderActorCustomer}
+}
+
+// Admin is a store admin
+func Admin(userID string) Actor {
+	return Actor{ID: userID, Role: models.OrderActorAdmin}
+}
+
+// Record adds a status transition to an order's history. It must run in the transaction that changes the
+// status so the history never disagrees with the order row. oldStatus is empty when the order is created.
+func Record(tx *gorm.DB, orderID, oldStatus, newStatus string, actor Actor, note string) error {
+	entry := models.OrderStatusHistory{
+		OrderID:   orderID,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		ActorRole: actor.Role,
+		Note:      note,
+	}
+	if actor.ID != "" {
+		entry.ActorID = &actor.ID
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record order status history: %w", err)
+	}
+	return nil
+}
+
+// Load returns an order's status transitions, oldest first
+func Load(db *gorm.DB, orderID string) ([]models.OrderStatusHistory, error) {
+	var history []models.OrderStatusHistory
+	if err := db.Where("order_id = ?", orderID).Order("created_at ASC").Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to load order status history: %w", err)
+	}
+	return history, nil
+}
@@ -0,0 +1,95 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSavedAddressNotFound = errors.New("saved address not found")
+	ErrConflictingAddress   = errors.New("give either an address or a saved address ID, not both")
+	ErrMissingAddress       = errors.New("no address given and no default address saved")
+)
+
+// isZeroAddress reports whether no address was sent
+func isZeroAddress(addr models.OrderAddress) bool {
+	return addr == models.OrderAddress{}
+}
+
+// resolveCheckoutAddresses fills in the order addresses a checkout or draft order did not send. An address
+// given by ID is copied from the user's saved addresses, so later edits to the saved address never change the
+// order. With neither an address nor an ID the user's default address of that type is used; billing then
+// falls back to the shipping address.
+func (s *Service) resolveCheckoutAddresses(ctx context.Context, userID string, shipping, billing *models.OrderAddress, shippingID, billingID *string) error {
+	resolvedShipping, err := s.checkoutAddress(ctx, userID, "shipping", *shipping, shippingID)
+	if err != nil {
+		return err
+	}
+	if resolvedShipping == nil {
+		return fmt.Errorf("%w: shipping", ErrMissingAddress)
+	}
+	*shipping = *resolvedShipping
+
+	resolvedBilling, err := s.checkoutAddress(ctx, userID, "billing", *billing, billingID)
+	if err != nil {
+		return err
+	}
+	if resolvedBilling == nil {
+		resolvedBilling = resolvedShipping
+	}
+	*billing = *resolvedBilling
+	return nil
+}
+
+// checkoutAddress returns the address of one type a checkout uses: the one sent, the saved one named by id or
+// the user's default. It returns nil when there is none.
+func (s *Service) checkoutAddress(ctx context.Context, userID, addressType string, sent models.OrderAddress, id *string) (*models.OrderAddress, error) {
+	if id != nil {
+		if !isZeroAddress(sent) {
+			return nil, fmt.Errorf("%w: %s", ErrConflictingAddress, addressType)
+		}
+		var saved models.Address
+		if err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", *id, userID).First(&saved).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrSavedAddressNotFound, *id)
+			}
+			return nil, fmt.Errorf("failed to get saved address: %w", err)
+		}
+		return snapshotAddress(&saved), nil
+	}
+	if !isZeroAddress(sent) {
+		return &sent, nil
+	}
+
+	var saved models.Address
+	result := s.db.WithContext(ctx).Where("user_id = ? AND type = ? AND is_default = ?", userID, addressType, true).
+		Order("updated_at DESC").Limit(1).Find(&saved)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get default address: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return snapshotAddress(&saved), nil
+}
+
+// snapshotAddress copies a saved address onto an order
+func snapshotAddress(saved *models.Address) *models.OrderAddress {
+	return &models.OrderAddress{
+		FirstName:  saved.FirstName,
+		LastName:   saved.LastName,
+		Company:    saved.Company,
+		Address1:   saved.Address1,
+		Address2:   saved.Address2,
+		City:       saved.City,
+		State:      saved.State,
+		PostalCode: saved.PostalCode,
+		Country:    saved.Country,
+		Phone:      saved.Phone,
+	}
+}
@@ -0,0 +1,88 @@
+package orders
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderService_CreateOrderWithSavedAddresses(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+	ctx := context.Background()
+
+	user := helpers.CreateTestUser(t, "saved-address@example.com")
+	other := helpers.CreateTestUser(t, "someone-else@example.com")
+	category := helpers.CreateTestCategory(t, "saved-address")
+	product := helpers.CreateTestProduct(t, category.ID, 50, 10)
+
+	cartService.On("GetCartWithProducts", mock.Anything, "saved-session").Return(&models.Cart{
+		SessionID: "saved-session",
+		Items:     []models.CartItem{{ProductID: product.ID, Quantity: 1, Price: 10}},
+	}, nil)
+	cartService.On("ClearCart", mock.Anything, "saved-session").Return(nil)
+
+	save := func(userID, addressType, city string, isDefault bool) *models.Address {
+		address := &models.Address{UserID: userID, Type: addressType, FirstName: "Asha", LastName: "Rao", Address1: "1 MG Road",
+			City: city, State: "KA", PostalCode: "560001", Country: "IN", IsDefault: isDefault}
+		require.NoError(t, db.Create(address).Error)
+		return address
+	}
+	request := func() *CreateOrderRequest {
+		return &CreateOrderRequest{SessionID: "saved-session", PaymentIntentID: "pi_saved"}
+	}
+
+	t.Run("rejects unusable addresses", func(t *testing.T) {
+		_, err := service.CreateOrder(ctx, user.ID, request())
+		assert.ErrorIs(t, err, ErrMissingAddress, "no address and no default")
+
+		req := request()
+		req.ShippingAddressID = &save(other.ID, "shipping", "Pune", true).ID
+		_, err = service.CreateOrder(ctx, user.ID, req)
+		assert.ErrorIs(t, err, ErrSavedAddressNotFound, "another user's address")
+
+		req = request()
+		req.ShippingAddressID = &save(user.ID, "shipping", "Mysuru", false).ID
+		req.ShippingAddress = helpers.GetValidOrderAddress()
+		_, err = service.CreateOrder(ctx, user.ID, req)
+		assert.ErrorIs(t, err, ErrConflictingAddress)
+	})
+
+	t.Run("snapshots saved addresses", func(t *testing.T) {
+		shipping := save(user.ID, "shipping", "Bengaluru", false)
+		billing := save(user.ID, "billing", "Chennai", false)
+		req := request()
+		req.ShippingAddressID = &shipping.ID
+		req.BillingAddressID = &billing.ID
+		order, err := service.CreateOrder(ctx, user.ID, req)
+		require.NoError(t, err)
+		assert.Equal(t, "Bengaluru", order.ShippingAddress.City)
+		assert.Equal(t, "Chennai", order.BillingAddress.City)
+		assert.Equal(t, "Bengaluru", order.Shipments[0].Address.City)
+
+		// Editing the saved address later leaves the order as it was placed
+		require.NoError(t, db.Model(shipping).Update("city", "Hubballi").Error)
+		var stored models.Order
+		require.NoError(t, db.First(&stored, "id = ?", order.ID).Error)
+		assert.Equal(t, "Bengaluru", stored.ShippingAddress.City)
+	})
+
+	t.Run("uses the default addresses", func(t *testing.T) {
+		save(user.ID, "shipping", "Kochi", true)
+		order, err := service.CreateOrder(ctx, user.ID, request())
+		require.NoError(t, err)
+		assert.Equal(t, "Kochi", order.ShippingAddress.City)
+		assert.Equal(t, "Kochi", order.BillingAddress.City, "billing falls back to the shipping address")
+
+		save(user.ID, "billing", "Madurai", true)
+		order, err = service.CreateOrder(ctx, user.ID, request())
+		require.NoError(t, err)
+		assert.Equal(t, "Madurai", order.BillingAddress.City)
+	})
+}
@@ -31,6 +31,15 @@ func TestIntegration_AdminOrderManagement(t *testing.T) {
 		&models.Product{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.Shipment{},
+		&models.ShipmentItem{},
+		&models.OrderStatusHistory{},
+		&models.OrderComment{},
+		&models.OrderItemSerial{},
+		&models.Setting{},
+		&models.TaxRule{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
 	)
 	require.NoError(t, err)
 
@@ -53,7 +62,7 @@ func TestIntegration_AdminOrderManagement(t *testing.T) {
 	ordersHandler := NewHandler(ordersService)
 
 	// Setup routes
-	SetupRoutes(router, ordersHandler, authService)
+	SetupRoutes(router.Group("/api"), ordersHandler, authService)
 
 	// Create test admin user
 	adminUser := &models.User{
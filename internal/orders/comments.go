@@ -0,0 +1,145 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrEmptyComment    = errors.New("comment body is empty")
+)
+
+// CreateOrderCommentRequest adds a comment to an order
+type CreateOrderCommentRequest struct {
+	Body string `json:"body" binding:"required,max=2000"`
+	// Visibility is internal (admins only, the default) or customer (also shown to the customer)
+	Visibility string `json:"visibility" binding:"omitempty,oneof=internal customer"`
+}
+
+// UpdateOrderCommentRequest edits a comment; fields left out are kept
+type UpdateOrderCommentRequest struct {
+	Body *string `json:"body,omitempty" binding:"omitempty,max=2000"`
+	// Visibility is internal (admins only) or customer (also shown to the customer)
+	Visibility *string `json:"visibility,omitempty" binding:"omitempty,oneof=internal customer"`
+}
+
+// preloadComments loads the comments of an order oldest first. A non-empty userID is a customer, who only
+// sees the comments meant for them and not which admin wrote them.
+func preloadComments(query *gorm.DB, userID string) *gorm.DB {
+	if userID == "" {
+		return query.Preload("Comments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		})
+	}
+	return query.Preload("Comments", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "order_id", "body", "visibility", "created_at", "updated_at").
+			Where("visibility = ?", models.OrderCommentCustomer).Order("created_at ASC")
+	})
+}
+
+// GetOrderComments returns every comment on an order, internal ones included, oldest first
+func (s *Service) GetOrderComments(ctx context.Context, orderID string) ([]models.OrderComment, error) {
+	if err := s.requireOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	var comments []models.OrderComment
+	if err := s.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at ASC").Find(&comments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get order comments: %w", err)
+	}
+	return comments, nil
+}
+
+// AddOrderComment adds an admin's comment to an order. Comments are internal unless req asks for them to be
+// shown to the customer.
+func (s *Service) AddOrderComment(ctx context.Context, orderID, adminID string, req *CreateOrderCommentRequest) (*models.OrderComment, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, ErrEmptyComment
+	}
+	if err := s.requireOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	comment := models.OrderComment{OrderID: orderID, Body: body, Visibility: req.Visibility}
+	if comment.Visibility == "" {
+		comment.Visibility = models.OrderCommentInternal
+	}
+	if adminID != "" {
+		comment.AuthorID = &adminID
+	}
+	if err := s.db.WithContext(ctx).Create(&comment).Error; err != nil {
+		return nil, fmt.Errorf("failed to create order comment: %w", err)
+	}
+	return &comment, nil
+}
+
+// UpdateOrderComment edits the body or visibility of a comment on an order
+func (s *Service) UpdateOrderComment(ctx context.Context, orderID, commentID string, req *UpdateOrderCommentRequest) (*models.OrderComment, error) {
+	comment, err := s.findComment(ctx, orderID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if req.Body != nil {
+		body := strings.TrimSpace(*req.Body)
+		if body == "" {
+			return nil, ErrEmptyComment
+		}
+		updates["body"] = body
+	}
+	if req.Visibility != nil {
+		updates["visibility"] = *req.Visibility
+	}
+	if len(updates) == 0 {
+		return comment, nil
+	}
+	if err := s.db.WithContext(ctx).Model(comment).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update order comment: %w", err)
+	}
+	return s.findComment(ctx, orderID, commentID)
+}
+
+// DeleteOrderComment removes a comment from an order
+func (s *Service) DeleteOrderComment(ctx context.Context, orderID, commentID string) error {
+	result := s.db.WithContext(ctx).Where("id = ? AND order_id = ?", commentID, orderID).Delete(&models.OrderComment{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete order comment: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrCommentNotFound
+	}
+	return nil
+}
+
+// findComment loads a comment, which must belong to the given order
+func (s *Service) findComment(ctx context.Context, orderID, commentID string) (*models.OrderComment, error) {
+	var comment models.OrderComment
+	if err := s.db.WithContext(ctx).Where("id = ? AND order_id = ?", commentID, orderID).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to get order comment: %w", err)
+	}
+	return &comment, nil
+}
+
+// requireOrder returns the "order not found" error when the order does not exist
+func (s *Service) requireOrder(ctx context.Context, orderID string) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Order{}).Where("id = ?", orderID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("order not found")
+	}
+	return nil
+}
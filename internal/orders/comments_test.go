@@ -0,0 +1,113 @@
+package orders
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_OrderComments(t *testing.T) {
+	_, service, helpers, _ := setupPolicyTest(t)
+	ctx := context.Background()
+
+	user := helpers.CreateTestUser(t, "comments@example.com")
+	order := helpers.CreateTestOrder(t, user.ID, "pending")
+
+	internal, err := service.AddOrderComment(ctx, order.ID, "admin-1", &CreateOrderCommentRequest{Body: "  fraud check passed  "})
+	require.NoError(t, err)
+	assert.Equal(t, "fraud check passed", internal.Body)
+	assert.Equal(t, models.OrderCommentInternal, internal.Visibility)
+	require.NotNil(t, internal.AuthorID)
+	assert.Equal(t, "admin-1", *internal.AuthorID)
+
+	visible, err := service.AddOrderComment(ctx, order.ID, "admin-1", &CreateOrderCommentRequest{Body: "Packed with extra care", Visibility: models.OrderCommentCustomer})
+	require.NoError(t, err)
+
+	t.Run("admins see every comment", func(t *testing.T) {
+		comments, err := service.GetOrderComments(ctx, order.ID)
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+		assert.Equal(t, internal.ID, comments[0].ID)
+		assert.Equal(t, visible.ID, comments[1].ID)
+
+		adminView, err := service.GetOrder(ctx, order.ID, "")
+		require.NoError(t, err)
+		assert.Len(t, adminView.Comments, 2)
+	})
+
+	t.Run("customers only see visible comments without their authors", func(t *testing.T) {
+		customerView, err := service.GetOrder(ctx, order.ID, user.ID)
+		require.NoError(t, err)
+		require.Len(t, customerView.Comments, 1)
+		assert.Equal(t, "Packed with extra care", customerView.Comments[0].Body)
+		assert.Nil(t, customerView.Comments[0].AuthorID)
+	})
+
+	t.Run("updates change body and visibility", func(t *testing.T) {
+		customer := models.OrderCommentCustomer
+		updated, err := service.UpdateOrderComment(ctx, order.ID, internal.ID, &UpdateOrderCommentRequest{Visibility: &customer})
+		require.NoError(t, err)
+		assert.Equal(t, models.OrderCommentCustomer, updated.Visibility)
+		assert.Equal(t, "fraud check passed", updated.Body)
+
+		blank := "   "
+		_, err = service.UpdateOrderComment(ctx, order.ID, internal.ID, &UpdateOrderCommentRequest{Body: &blank})
+		assert.ErrorIs(t, err, ErrEmptyComment)
+		_, err = service.UpdateOrderComment(ctx, "other-order", internal.ID, &UpdateOrderCommentRequest{Body: &blank})
+		assert.ErrorIs(t, err, ErrCommentNotFound)
+	})
+
+	t.Run("deletes only comments of the order", func(t *testing.T) {
+		assert.ErrorIs(t, service.DeleteOrderComment(ctx, "other-order", visible.ID), ErrCommentNotFound)
+		require.NoError(t, service.DeleteOrderComment(ctx, order.ID, visible.ID))
+		assert.ErrorIs(t, service.DeleteOrderComment(ctx, order.ID, visible.ID), ErrCommentNotFound)
+	})
+
+	t.Run("rejects blank comments and missing orders", func(t *testing.T) {
+		_, err := service.AddOrderComment(ctx, order.ID, "admin-1", &CreateOrderCommentRequest{Body: " \n "})
+		assert.ErrorIs(t, err, ErrEmptyComment)
+		_, err = service.AddOrderComment(ctx, "missing", "admin-1", &CreateOrderCommentRequest{Body: "hello"})
+		assert.EqualError(t, err, "order not found")
+		_, err = service.GetOrderComments(ctx, "missing")
+		assert.EqualError(t, err, "order not found")
+	})
+}
+
+func TestHandler_AddOrderComment(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupTestRouter()
+	router.POST("/api/admin/orders/:id/comments", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		handler.AddOrderComment(c)
+	})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/admin/orders/order-1/comments", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	comment := &models.OrderComment{ID: "comment-1", OrderID: "order-1", Body: "hello", Visibility: models.OrderCommentCustomer}
+	mockService.On("AddOrderComment", mock.Anything, "order-1", "admin-1", &CreateOrderCommentRequest{Body: "hello", Visibility: "customer"}).Return(comment, nil).Once()
+	assert.Equal(t, http.StatusCreated, post(`{"body":"hello","visibility":"customer"}`).Code)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"body":"hello","visibility":"everyone"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{}`).Code)
+
+	mockService.On("AddOrderComment", mock.Anything, "order-1", "admin-1", mock.Anything).Return(nil, ErrEmptyComment).Once()
+	assert.Equal(t, http.StatusBadRequest, post(`{"body":"  "}`).Code)
+
+	mockService.AssertExpectations(t)
+}
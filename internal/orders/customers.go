@@ -0,0 +1,88 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// recentOrderCount is how many of a customer's latest orders the customer detail includes
+const recentOrderCount = 5
+
+var ErrCustomerNotFound = errors.New("customer not found")
+
+// uncountedStatuses are the order statuses left out of a customer's lifetime value
+var uncountedStatuses = []string{"cancelled", "refunded"}
+
+// CustomerDetail is a customer's profile and addresses with their order statistics and latest orders
+type CustomerDetail struct {
+	Customer     models.User    `json:"customer"`
+	Stats        CustomerStats  `json:"stats"`
+	RecentOrders []models.Order `json:"recentOrders"`
+}
+
+// CustomerStats aggregates a customer's orders. LifetimeValue is the total of the orders that were not
+// cancelled or refunded, less partial refunds processed on them.
+type CustomerStats struct {
+	OrderCount    int64      `json:"orderCount"`
+	LifetimeValue float64    `json:"lifetimeValue"`
+	LastOrderAt   *time.Time `json:"lastOrderAt,omitempty"`
+}
+
+// GetCustomer returns a customer's profile, addresses, order statistics and latest orders (admin only)
+func (s *Service) GetCustomer(ctx context.Context, customerID string) (*CustomerDetail, error) {
+	db := s.db.WithContext(ctx)
+
+	var customer models.User
+	if err := db.Preload("Addresses").Where("id = ? AND role = ?", customerID, "customer").First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+	customer.Password = ""
+
+	detail := &CustomerDetail{Customer: customer, RecentOrders: []models.Order{}}
+
+	var stats struct {
+		OrderCount    int64
+		LifetimeValue float64
+	}
+	if err := db.Model(&models.Order{}).
+		Select("COUNT(*) AS order_count, COALESCE(SUM(CASE WHEN status NOT IN ? THEN total ELSE 0 END), 0) AS lifetime_value", uncountedStatuses).
+		Where("user_id = ?", customerID).
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate customer orders: %w", err)
+	}
+	detail.Stats.OrderCount = stats.OrderCount
+
+	var refunded int64
+	if err := db.Model(&models.Refund{}).
+		Select("COALESCE(SUM(refunds.amount), 0)").
+		Joins("JOIN orders ON orders.id = refunds.order_id").
+		Where("orders.user_id = ? AND orders.status NOT IN ? AND refunds.status = ?", customerID, uncountedStatuses, models.RefundStatusProcessed).
+		Scan(&refunded).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate customer refunds: %w", err)
+	}
+	// Refunds are recorded in paise
+	detail.Stats.LifetimeValue = stats.LifetimeValue - float64(refunded)/100
+
+	if err := db.Preload("Items.Product").
+		Where("user_id = ?", customerID).
+		Order("created_at DESC").
+		Limit(recentOrderCount).
+		Find(&detail.RecentOrders).Error; err != nil {
+		return nil, fmt.Errorf("failed to get recent orders: %w", err)
+	}
+	if len(detail.RecentOrders) > 0 {
+		lastOrderAt := detail.RecentOrders[0].CreatedAt
+		detail.Stats.LastOrderAt = &lastOrderAt
+	}
+
+	return detail, nil
+}
@@ -0,0 +1,63 @@
+package orders
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderService_GetCustomer(t *testing.T) {
+	db, service, helpers, _ := setupPolicyTest(t)
+	require.NoError(t, db.AutoMigrate(&models.Payment{}, &models.Refund{}))
+	ctx := context.Background()
+
+	customer := helpers.CreateTestUser(t, "customer@example.com")
+	require.NoError(t, db.Create(&models.Address{UserID: customer.ID, Type: "shipping", FirstName: "Test", LastName: "User",
+		Address1: "1 Main St", City: "Pune", State: "MH", PostalCode: "411001", Country: "IN"}).Error)
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, order := range []struct {
+		status string
+		total  float64
+	}{{"delivered", 100}, {"shipped", 50}, {"cancelled", 80}, {"refunded", 30}, {"delivered", 20}, {"pending", 10}} {
+		created := helpers.CreateTestOrder(t, customer.ID, order.status)
+		require.NoError(t, db.Model(created).Updates(map[string]interface{}{"total": order.total, "created_at": start.AddDate(0, 0, i)}).Error)
+		if i == 0 {
+			require.NoError(t, db.Create(&models.Refund{OrderID: created.ID, PaymentID: "pay", Amount: 1500, Status: models.RefundStatusProcessed}).Error)
+			require.NoError(t, db.Create(&models.Refund{OrderID: created.ID, PaymentID: "pay", Amount: 700, Status: models.RefundStatusPending}).Error)
+		}
+	}
+	other := helpers.CreateTestUser(t, "other@example.com")
+	helpers.CreateTestOrder(t, other.ID, "delivered")
+
+	detail, err := service.GetCustomer(ctx, customer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, customer.ID, detail.Customer.ID)
+	assert.Empty(t, detail.Customer.Password)
+	assert.Len(t, detail.Customer.Addresses, 1)
+	assert.Equal(t, int64(6), detail.Stats.OrderCount)
+	assert.InDelta(t, 165, detail.Stats.LifetimeValue, 0.001, "cancelled and refunded orders and processed refunds are left out")
+	require.NotNil(t, detail.Stats.LastOrderAt)
+	assert.True(t, start.AddDate(0, 0, 5).Equal(*detail.Stats.LastOrderAt))
+	require.Len(t, detail.RecentOrders, recentOrderCount)
+	assert.Equal(t, "pending", detail.RecentOrders[0].Status)
+
+	newcomer := helpers.CreateTestUser(t, "new@example.com")
+	detail, err = service.GetCustomer(ctx, newcomer.ID)
+	require.NoError(t, err)
+	assert.Zero(t, detail.Stats.OrderCount)
+	assert.Zero(t, detail.Stats.LifetimeValue)
+	assert.Nil(t, detail.Stats.LastOrderAt)
+	assert.Empty(t, detail.RecentOrders)
+
+	admin := helpers.CreateTestAdmin(t, "admin@example.com")
+	_, err = service.GetCustomer(ctx, admin.ID)
+	assert.ErrorIs(t, err, ErrCustomerNotFound)
+	_, err = service.GetCustomer(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCustomerNotFound)
+}
@@ -0,0 +1,452 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/orderevents"
+	"ecommerce-website/internal/tax"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDraftOrderNotFound      = errors.New("draft order not found")
+	ErrDraftOrderLocked        = errors.New("draft order can no longer be changed")
+	ErrDraftOrderNotPayable    = errors.New("draft order is not awaiting payment")
+	ErrDraftItemUnavailable    = errors.New("draft order product is not available")
+	ErrInvalidDiscount         = errors.New("invalid discount")
+	ErrDraftInvoiceUnavailable = errors.New("draft order invoices need an email service")
+)
+
+// DraftInvoiceMailer emails customers the payment link of a draft order
+type DraftInvoiceMailer interface {
+	SendDraftOrderInvoice(draft *models.DraftOrder) error
+}
+
+// DraftOrderItemRequest is a line of a draft order
+type DraftOrderItemRequest struct {
+	ProductID string `json:"productId" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	// Price is the unit price quoted for this line; omit it to quote the product's current price
+	Price *float64 `json:"price,omitempty" binding:"omitempty,min=0"`
+}
+
+// DraftOrderRequest creates a draft order for a customer, or replaces the contents of one
+type DraftOrderRequest struct {
+	CustomerID        string                  `json:"customerId" binding:"required"`
+	Items             []DraftOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	ShippingAddress   models.OrderAddress     `json:"shippingAddress"`             // omit to use ShippingAddressID or the customer's default shipping address
+	BillingAddress    models.OrderAddress     `json:"billingAddress"`              // omit to use BillingAddressID, the customer's default billing address or the shipping address
+	ShippingAddressID *string                 `json:"shippingAddressId,omitempty"` // a saved address of the customer, copied onto the draft
+	BillingAddressID  *string                 `json:"billingAddressId,omitempty"`  // a saved address of the customer, copied onto the draft
+	Notes             *string                 `json:"notes,omitempty"`
+	// DiscountType is percentage or amount; leave it empty for no discount
+	DiscountType string `json:"discountType,omitempty" binding:"omitempty,oneof=percentage amount"`
+	// DiscountValue is the percentage or amount taken off the subtotal
+	DiscountValue float64 `json:"discountValue" binding:"min=0"`
+	// DiscountReason is shown to the customer next to the discount
+	DiscountReason *string `json:"discountReason,omitempty" binding:"omitempty,max=200"`
+	// Shipping is the shipping charge quoted to the customer
+	Shipping float64 `json:"shipping" binding:"min=0"`
+}
+
+// DraftOrderFilters narrows the admin draft order list
+type DraftOrderFilters struct {
+	Status     string
+	CustomerID string
+}
+
+// editableDraftStatuses are the draft statuses an admin may still change a draft in
+var editableDraftStatuses = map[string]bool{
+	models.DraftOrderOpen:     true,
+	models.DraftOrderInvoiced: true,
+}
+
+// CreateDraftOrder puts together an open draft order for a customer, priced at the quoted line prices
+func (s *Service) CreateDraftOrder(ctx context.Context, adminID string, req *DraftOrderRequest) (*models.DraftOrder, error) {
+	draft := models.DraftOrder{CreatedBy: adminID, Status: models.DraftOrderOpen}
+	if err := s.buildDraft(ctx, &draft, req); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items", "User").Create(&draft).Error; err != nil {
+			return fmt.Errorf("failed to create draft order: %w", err)
+		}
+		return createDraftItems(tx, draft.ID, draft.Items)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetDraftOrder(ctx, draft.ID, "")
+}
+
+// UpdateDraftOrder replaces the customer, lines, addresses, discount and shipping of a draft order that was
+// not checked out yet. A change to an invoiced draft takes it back to open, so the customer cannot pay for it
+// until the updated payment link is sent.
+func (s *Service) UpdateDraftOrder(ctx context.Context, draftID string, req *DraftOrderRequest) (*models.DraftOrder, error) {
+	var draft models.DraftOrder
+	if err := s.db.WithContext(ctx).First(&draft, "id = ?", draftID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDraftOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get draft order: %w", err)
+	}
+	if err := s.buildDraft(ctx, &draft, req); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.DraftOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, status, order_id").First(&current, "id = ?", draftID).Error; err != nil {
+			return fmt.Errorf("failed to get draft order: %w", err)
+		}
+		if !editableDraftStatuses[current.Status] || current.OrderID != nil {
+			return fmt.Errorf("%w: draft order is %s", ErrDraftOrderLocked, current.Status)
+		}
+
+		draft.Status = models.DraftOrderOpen
+		if err := tx.Omit("Items", "User", "CreatedBy", "CreatedAt").Save(&draft).Error; err != nil {
+			return fmt.Errorf("failed to update draft order: %w", err)
+		}
+		if err := tx.Where("draft_order_id = ?", draftID).Delete(&models.DraftOrderItem{}).Error; err != nil {
+			return fmt.Errorf("failed to replace draft order items: %w", err)
+		}
+		return createDraftItems(tx, draftID, draft.Items)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetDraftOrder(ctx, draftID, "")
+}
+
+// buildDraft sets the customer, addresses, lines and prices of a draft from req. Lines are quoted at the
+// product's current price unless the request sets one; withdrawn products cannot be added.
+func (s *Service) buildDraft(ctx context.Context, draft *models.DraftOrder, req *DraftOrderRequest) error {
+	var customer models.User
+	if err := s.db.WithContext(ctx).Select("id").Where("id = ? AND role = ?", req.CustomerID, "customer").First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCustomerNotFound
+		}
+		return fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	draft.UserID = customer.ID
+	draft.ShippingAddress = req.ShippingAddress
+	draft.BillingAddress = req.BillingAddress
+	if err := s.resolveCheckoutAddresses(ctx, customer.ID, &draft.ShippingAddress, &draft.BillingAddress, req.ShippingAddressID, req.BillingAddressID); err != nil {
+		return err
+	}
+
+	draft.Items = make([]models.DraftOrderItem, 0, len(req.Items))
+	for _, line := range req.Items {
+		var product models.Product
+		if err := s.db.WithContext(ctx).Select("id, name, price, is_active").First(&product, "id = ?", line.ProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: product %s does not exist", ErrDraftItemUnavailable, line.ProductID)
+			}
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		if !product.IsActive {
+			return fmt.Errorf("%w: product %s is no longer available", ErrDraftItemUnavailable, product.Name)
+		}
+
+		price := product.Price
+		if line.Price != nil {
+			price = *line.Price
+		}
+		draft.Items = append(draft.Items, models.DraftOrderItem{
+			ProductID: product.ID,
+			Quantity:  line.Quantity,
+			Price:     price,
+			Total:     price * float64(line.Quantity),
+		})
+	}
+
+	draft.Notes = req.Notes
+	draft.DiscountType = req.DiscountType
+	draft.DiscountValue = req.DiscountValue
+	draft.DiscountReason = req.DiscountReason
+	draft.Shipping = req.Shipping
+	if draft.DiscountType == "" {
+		draft.DiscountValue = 0
+		draft.DiscountReason = nil
+	}
+	return s.priceDraft(ctx, draft)
+}
+
+// priceDraft totals a draft's lines, takes the discount off the subtotal and taxes what is left by the rules
+// of the shipping address. A draft must leave something to pay, since its order is paid like any other.
+func (s *Service) priceDraft(ctx context.Context, draft *models.DraftOrder) error {
+	draft.Subtotal = 0
+	for _, item := range draft.Items {
+		draft.Subtotal += item.Total
+	}
+
+	switch draft.DiscountType {
+	case models.DraftDiscountPercent:
+		if draft.DiscountValue > 100 {
+			return fmt.Errorf("%w: a percentage discount cannot exceed 100", ErrInvalidDiscount)
+		}
+		draft.Discount = math.Round(draft.Subtotal*draft.DiscountValue) / 100
+	case models.DraftDiscountAmount:
+		if draft.DiscountValue > draft.Subtotal {
+			return fmt.Errorf("%w: the discount exceeds the subtotal of %.2f", ErrInvalidDiscount, draft.Subtotal)
+		}
+		draft.Discount = draft.DiscountValue
+	default:
+		draft.Discount = 0
+	}
+
+	taxTable, err := s.taxes.Table(ctx)
+	if err != nil {
+		return err
+	}
+	draft.TaxBreakdown, draft.Tax = taxTable.Calculate([]tax.Charge{{Address: draft.ShippingAddress, Amount: draft.Subtotal - draft.Discount}})
+	draft.Total = draft.Subtotal - draft.Discount + draft.Tax + draft.Shipping
+	if draft.Total <= 0 {
+		return fmt.Errorf("%w: the discount leaves nothing to pay", ErrInvalidDiscount)
+	}
+	return nil
+}
+
+// allocateDiscount splits a discount across lines in proportion to their totals, rounded to cents. The last
+// line takes the rounding remainder, so the shares add up to the discount.
+func allocateDiscount(totals []float64, discount float64) []float64 {
+	shares := make([]float64, len(totals))
+	subtotal := 0.0
+	for _, total := range totals {
+		subtotal += total
+	}
+	if subtotal <= 0 || discount <= 0 {
+		return shares
+	}
+	left := discount
+	for i, total := range totals {
+		if i == len(totals)-1 {
+			shares[i] = math.Round(left*100) / 100
+			break
+		}
+		shares[i] = math.Round(discount*total/subtotal*100) / 100
+		left -= shares[i]
+	}
+	return shares
+}
+
+// createDraftItems stores the lines of a draft order
+func createDraftItems(tx *gorm.DB, draftID string, items []models.DraftOrderItem) error {
+	for i := range items {
+		items[i].ID = ""
+		items[i].DraftOrderID = draftID
+		if err := tx.Omit("Product").Create(&items[i]).Error; err != nil {
+			return fmt.Errorf("failed to create draft order item: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetDraftOrder returns a draft order with its lines and customer. A non-empty userID is a customer, who
+// only sees their own drafts once the payment link was sent.
+func (s *Service) GetDraftOrder(ctx context.Context, draftID, userID string) (*models.DraftOrder, error) {
+	query := s.db.WithContext(ctx).Preload("Items.Product").Preload("User").Where("id = ?", draftID)
+	if userID != "" {
+		query = query.Where("user_id = ? AND status IN ?", userID, []string{models.DraftOrderInvoiced, models.DraftOrderCompleted})
+	}
+
+	var draft models.DraftOrder
+	if err := query.First(&draft).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDraftOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get draft order: %w", err)
+	}
+	return &draft, nil
+}
+
+// GetDraftOrders returns the draft orders matching filters with pagination, newest first (admin only)
+func (s *Service) GetDraftOrders(ctx context.Context, page, limit int, filters DraftOrderFilters) ([]models.DraftOrder, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.DraftOrder{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.CustomerID != "" {
+		query = query.Where("user_id = ?", filters.CustomerID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count draft orders: %w", err)
+	}
+
+	drafts := []models.DraftOrder{}
+	if err := query.Preload("Items.Product").Preload("User").Order("created_at DESC").
+		Limit(limit).Offset((page - 1) * limit).Find(&drafts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get draft orders: %w", err)
+	}
+	return drafts, total, nil
+}
+
+// SendDraftOrderInvoice emails the customer the quoted draft order with a link to pay for it, and marks the
+// draft invoiced so the customer can check it out. Sending it again resends the link.
+func (s *Service) SendDraftOrderInvoice(ctx context.Context, draftID string) (*models.DraftOrder, error) {
+	mailer, ok := s.emailService.(DraftInvoiceMailer)
+	if !ok {
+		return nil, ErrDraftInvoiceUnavailable
+	}
+
+	draft, err := s.GetDraftOrder(ctx, draftID, "")
+	if err != nil {
+		return nil, err
+	}
+	if !editableDraftStatuses[draft.Status] || draft.OrderID != nil {
+		return nil, fmt.Errorf("%w: draft order is %s", ErrDraftOrderLocked, draft.Status)
+	}
+	if err := mailer.SendDraftOrderInvoice(draft); err != nil {
+		return nil, fmt.Errorf("failed to send draft order invoice: %w", err)
+	}
+
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&models.DraftOrder{}).
+		Where("id = ? AND status IN ? AND order_id IS NULL", draftID, []string{models.DraftOrderOpen, models.DraftOrderInvoiced}).
+		Updates(map[string]interface{}{"status": models.DraftOrderInvoiced, "invoice_sent_at": now})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update draft order: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: draft order changed while the invoice was sent", ErrDraftOrderLocked)
+	}
+	draft.Status = models.DraftOrderInvoiced
+	draft.InvoiceSentAt = &now
+	return draft, nil
+}
+
+// CancelDraftOrder cancels a draft order that was not checked out yet
+func (s *Service) CancelDraftOrder(ctx context.Context, draftID string) (*models.DraftOrder, error) {
+	result := s.db.WithContext(ctx).Model(&models.DraftOrder{}).
+		Where("id = ? AND status IN ? AND order_id IS NULL", draftID, []string{models.DraftOrderOpen, models.DraftOrderInvoiced}).
+		Update("status", models.DraftOrderCancelled)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to cancel draft order: %w", result.Error)
+	}
+
+	draft, err := s.GetDraftOrder(ctx, draftID, "")
+	if err != nil {
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: draft order is %s", ErrDraftOrderLocked, draft.Status)
+	}
+	return draft, nil
+}
+
+// CheckoutDraftOrder places the pending order of an invoiced draft for its customer, at the quoted prices,
+// taking the stock of its lines. The customer then pays for the order like any other, and the draft is
+// completed when the payment lands. Checking out again returns the order already placed, unless it was
+// cancelled, in which case a new one is placed.
+func (s *Service) CheckoutDraftOrder(ctx context.Context, draftID, userID string) (*models.Order, error) {
+	recordEvents := orderevents.Enabled(ctx, s.settings)
+
+	var order models.Order
+	var lowStock []models.Product
+	quantity := 0
+	placed := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var draft models.DraftOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").
+			First(&draft, "id = ? AND user_id = ?", draftID, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDraftOrderNotFound
+			}
+			return fmt.Errorf("failed to get draft order: %w", err)
+		}
+		if draft.Status != models.DraftOrderInvoiced {
+			return fmt.Errorf("%w: draft order is %s", ErrDraftOrderNotPayable, draft.Status)
+		}
+		if draft.OrderID != nil {
+			if err := tx.Select("id, status").First(&order, "id = ?", *draft.OrderID).Error; err != nil {
+				return fmt.Errorf("failed to get draft order's order: %w", err)
+			}
+			if order.Status != "cancelled" {
+				return nil
+			}
+			order = models.Order{}
+		}
+
+		lines := make([]models.CartItem, len(draft.Items))
+		for i, item := range draft.Items {
+			lines[i] = models.CartItem{ProductID: item.ProductID, Quantity: item.Quantity}
+			quantity += item.Quantity
+		}
+		var err error
+		if _, lowStock, err = takeStock(tx, lines); err != nil {
+			return err
+		}
+
+		order = models.Order{
+			UserID:          draft.UserID,
+			Status:          "pending",
+			Subtotal:        draft.Subtotal,
+			Discount:        draft.Discount,
+			Tax:             draft.Tax,
+			TaxBreakdown:    draft.TaxBreakdown,
+			Shipping:        draft.Shipping,
+			Total:           draft.Total,
+			ShippingAddress: draft.ShippingAddress,
+			BillingAddress:  draft.BillingAddress,
+			Notes:           draft.Notes,
+		}
+		shipments := []models.Shipment{{GroupKey: DefaultShipmentGroup, Address: draft.ShippingAddress, Shipping: draft.Shipping, Status: "pending"}}
+		// The draft discount is spread over the lines so refunding a line pays back what was paid for it
+		totals := make([]float64, len(draft.Items))
+		for i, item := range draft.Items {
+			totals[i] = item.Total
+		}
+		discounts := allocateDiscount(totals, draft.Discount)
+		items := make([]models.OrderItem, len(draft.Items))
+		for i, item := range draft.Items {
+			items[i] = models.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity, Price: item.Price, Total: item.Total, Discount: discounts[i]}
+		}
+		if err := s.saveOrder(tx, &order, shipments, [][]models.OrderItem{items}, recordEvents, "order placed from draft order"); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.DraftOrder{}).Where("id = ?", draft.ID).Update("order_id", order.ID).Error; err != nil {
+			return fmt.Errorf("failed to link draft order: %w", err)
+		}
+		placed = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !placed {
+		return s.GetOrder(ctx, order.ID, userID)
+	}
+
+	announceOrder(&order, quantity, lowStock)
+	if err := s.db.WithContext(ctx).Preload("Items.Product").Preload("Shipments").Preload("User").Where("id = ?", order.ID).First(&order).Error; err != nil {
+		return nil, fmt.Errorf("failed to load created order: %w", err)
+	}
+	s.confirmOrder(&order)
+	return &order, nil
+}
+
+// SubscribeDraftOrders completes draft orders when the order placed from them is paid
+func SubscribeDraftOrders() {
+	eventbus.Subscribe(eventbus.EventOrderPaid, completeDraftOrder)
+}
+
+// completeDraftOrder completes the draft an order was placed from, inside the transaction marking it paid
+func completeDraftOrder(tx *gorm.DB, event, orderID string) error {
+	if err := tx.Model(&models.DraftOrder{}).Where("order_id = ? AND status = ?", orderID, models.DraftOrderInvoiced).
+		Updates(map[string]interface{}{"status": models.DraftOrderCompleted, "completed_at": time.Now()}).Error; err != nil {
+		return fmt.Errorf("failed to complete draft order: %w", err)
+	}
+	return nil
+}
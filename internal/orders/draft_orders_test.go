@@ -0,0 +1,215 @@
+package orders
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_DraftOrders(t *testing.T) {
+	db, service, helpers, emailService := setupPolicyTest(t)
+	ctx := context.Background()
+	emailService.On("SendDraftOrderInvoice", mock.Anything).Return(nil)
+	require.NoError(t, db.Create(&models.TaxRule{Name: "Sales tax", Country: "US", Rate: 0.1, IsActive: true}).Error)
+
+	customer := helpers.CreateTestUser(t, "phone-order@example.com")
+	admin := helpers.CreateTestAdmin(t, "sales@example.com")
+	category := helpers.CreateTestCategory(t, "drafts")
+	mug := createPolicyProduct(t, db, category.ID, "MUG-SKU")
+	lamp := createPolicyProduct(t, db, category.ID, "LAMP-SKU")
+
+	quotedPrice := 20.0
+	reason := "phone order goodwill"
+	request := func() *DraftOrderRequest {
+		return &DraftOrderRequest{
+			CustomerID: customer.ID,
+			Items: []DraftOrderItemRequest{
+				{ProductID: mug.ID, Quantity: 2},
+				{ProductID: lamp.ID, Quantity: 1, Price: &quotedPrice},
+			},
+			ShippingAddress: helpers.GetValidOrderAddress(),
+			DiscountType:    models.DraftDiscountPercent,
+			DiscountValue:   10,
+			DiscountReason:  &reason,
+			Shipping:        5,
+		}
+	}
+
+	draft, err := service.CreateDraftOrder(ctx, admin.ID, request())
+	require.NoError(t, err)
+	assert.Equal(t, models.DraftOrderOpen, draft.Status)
+	assert.Equal(t, admin.ID, draft.CreatedBy)
+	require.Len(t, draft.Items, 2)
+	assert.Equal(t, 40.0, draft.Subtotal, "two mugs at the list price and the lamp at the quoted price")
+	assert.Equal(t, 4.0, draft.Discount)
+	assert.InDelta(t, 3.6, draft.Tax, 0.001, "tax is charged on the discounted subtotal")
+	assert.InDelta(t, 44.6, draft.Total, 0.001)
+	assert.Equal(t, "Anytown", draft.BillingAddress.City, "billing falls back to the shipping address")
+
+	t.Run("rejects invalid drafts", func(t *testing.T) {
+		req := request()
+		req.CustomerID = admin.ID
+		_, err := service.CreateDraftOrder(ctx, admin.ID, req)
+		assert.ErrorIs(t, err, ErrCustomerNotFound)
+
+		req = request()
+		req.DiscountType = models.DraftDiscountAmount
+		req.DiscountValue = 500
+		_, err = service.CreateDraftOrder(ctx, admin.ID, req)
+		assert.ErrorIs(t, err, ErrInvalidDiscount)
+
+		req = request()
+		req.DiscountValue = 100
+		req.Shipping = 0
+		_, err = service.CreateDraftOrder(ctx, admin.ID, req)
+		assert.ErrorIs(t, err, ErrInvalidDiscount, "a draft that leaves nothing to pay cannot go through payment")
+
+		withdrawn := createPolicyProduct(t, db, category.ID, "WITHDRAWN-SKU")
+		require.NoError(t, db.Model(withdrawn).Update("is_active", false).Error)
+		req = request()
+		req.Items = append(req.Items, DraftOrderItemRequest{ProductID: withdrawn.ID, Quantity: 1})
+		_, err = service.CreateDraftOrder(ctx, admin.ID, req)
+		assert.ErrorIs(t, err, ErrDraftItemUnavailable)
+	})
+
+	t.Run("customers cannot see or pay open drafts", func(t *testing.T) {
+		_, err := service.GetDraftOrder(ctx, draft.ID, customer.ID)
+		assert.ErrorIs(t, err, ErrDraftOrderNotFound)
+		_, err = service.CheckoutDraftOrder(ctx, draft.ID, customer.ID)
+		assert.ErrorIs(t, err, ErrDraftOrderNotPayable)
+	})
+
+	t.Run("editing an invoiced draft reopens it", func(t *testing.T) {
+		invoiced, err := service.SendDraftOrderInvoice(ctx, draft.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.DraftOrderInvoiced, invoiced.Status)
+		assert.NotNil(t, invoiced.InvoiceSentAt)
+
+		req := request()
+		req.Items = req.Items[:1]
+		req.DiscountType = ""
+		updated, err := service.UpdateDraftOrder(ctx, draft.ID, req)
+		require.NoError(t, err)
+		assert.Equal(t, models.DraftOrderOpen, updated.Status)
+		require.Len(t, updated.Items, 1)
+		assert.Equal(t, 20.0, updated.Subtotal)
+		assert.Zero(t, updated.Discount)
+		assert.Nil(t, updated.DiscountReason)
+
+		_, err = service.UpdateDraftOrder(ctx, draft.ID, request())
+		require.NoError(t, err)
+		_, err = service.SendDraftOrderInvoice(ctx, draft.ID)
+		require.NoError(t, err)
+		emailService.AssertNumberOfCalls(t, "SendDraftOrderInvoice", 2)
+	})
+
+	t.Run("checkout places the quoted order and payment completes the draft", func(t *testing.T) {
+		seen, err := service.GetDraftOrder(ctx, draft.ID, customer.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.DraftOrderInvoiced, seen.Status)
+
+		_, err = service.CheckoutDraftOrder(ctx, draft.ID, "someone-else")
+		assert.ErrorIs(t, err, ErrDraftOrderNotFound)
+
+		order, err := service.CheckoutDraftOrder(ctx, draft.ID, customer.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", order.Status)
+		assert.Equal(t, customer.ID, order.UserID)
+		assert.Equal(t, 40.0, order.Subtotal)
+		assert.Equal(t, 4.0, order.Discount)
+		assert.InDelta(t, 44.6, order.Total, 0.001)
+		require.Len(t, order.Items, 2)
+		for _, item := range order.Items {
+			assert.Equal(t, 2.0, item.Discount, "the discount is spread over the lines by their totals")
+		}
+		require.Len(t, order.Shipments, 1)
+		assert.Equal(t, 5.0, order.Shipments[0].Shipping)
+
+		var reloaded models.Product
+		require.NoError(t, db.First(&reloaded, "id = ?", mug.ID).Error)
+		assert.Equal(t, 3, reloaded.Inventory)
+
+		again, err := service.CheckoutDraftOrder(ctx, draft.ID, customer.ID)
+		require.NoError(t, err)
+		assert.Equal(t, order.ID, again.ID, "checking out twice returns the placed order")
+
+		_, err = service.UpdateDraftOrder(ctx, draft.ID, request())
+		assert.ErrorIs(t, err, ErrDraftOrderLocked)
+		_, err = service.CancelDraftOrder(ctx, draft.ID)
+		assert.ErrorIs(t, err, ErrDraftOrderLocked)
+
+		require.NoError(t, completeDraftOrder(db, eventbus.EventOrderPaid, order.ID))
+		completed, err := service.GetDraftOrder(ctx, draft.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, models.DraftOrderCompleted, completed.Status)
+		require.NotNil(t, completed.OrderID)
+		assert.Equal(t, order.ID, *completed.OrderID)
+		assert.NotNil(t, completed.CompletedAt)
+	})
+
+	t.Run("cancelled drafts are listed by status", func(t *testing.T) {
+		other, err := service.CreateDraftOrder(ctx, admin.ID, request())
+		require.NoError(t, err)
+		cancelled, err := service.CancelDraftOrder(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.DraftOrderCancelled, cancelled.Status)
+		_, err = service.SendDraftOrderInvoice(ctx, other.ID)
+		assert.ErrorIs(t, err, ErrDraftOrderLocked)
+
+		drafts, total, err := service.GetDraftOrders(ctx, 1, 10, DraftOrderFilters{Status: models.DraftOrderCancelled})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, drafts, 1)
+		assert.Equal(t, other.ID, drafts[0].ID)
+	})
+}
+
+func TestHandler_CreateDraftOrder(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupTestRouter()
+	router.POST("/api/admin/draft-orders", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		handler.CreateDraftOrder(c)
+	})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/admin/draft-orders", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	mockService.On("CreateDraftOrder", mock.Anything, "admin-1", mock.MatchedBy(func(req *DraftOrderRequest) bool {
+		return req.CustomerID == "customer-1"
+	})).Return(&models.DraftOrder{ID: "draft-1", Status: models.DraftOrderOpen}, nil).Once()
+	assert.Equal(t, http.StatusCreated, post(`{"customerId":"customer-1","items":[{"productId":"p1","quantity":2}]}`).Code)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"customerId":"customer-1","items":[]}`).Code, "a draft needs items")
+	assert.Equal(t, http.StatusBadRequest, post(`{"customerId":"customer-1","items":[{"productId":"p1","quantity":1}],"discountType":"coupon"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"customerId":"customer-1","items":[{"productId":"p1","quantity":1}],"shippingAddress":{"city":"Pune"}}`).Code)
+
+	mockService.On("CreateDraftOrder", mock.Anything, "admin-1", mock.Anything).Return(nil, ErrInvalidDiscount).Once()
+	w := post(`{"customerId":"customer-2","items":[{"productId":"p1","quantity":1}],"discountType":"amount","discountValue":500}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_DISCOUNT")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAllocateDiscount(t *testing.T) {
+	assert.Equal(t, []float64{2, 2}, allocateDiscount([]float64{20, 20}, 4))
+	assert.Equal(t, []float64{3.33, 3.33, 3.34}, allocateDiscount([]float64{10, 10, 10}, 10))
+	assert.Equal(t, []float64{0, 0}, allocateDiscount([]float64{20, 20}, 0))
+}
@@ -0,0 +1,105 @@
+package orders
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/orderevents"
+	"ecommerce-website/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_OrderEventLog(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	require.NoError(t, db.AutoMigrate(&models.OrderEvent{}))
+	require.NoError(t, db.Create(&models.Setting{
+		Key: settings.KeyOrderEventSourcing, Scope: models.SettingScopeGlobal, Type: models.SettingTypeBool, Value: "true",
+	}).Error)
+
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+
+	user := helpers.CreateTestUser(t, "events@example.com")
+	category := helpers.CreateTestCategory(t, "events")
+	product := createPolicyProduct(t, db, category.ID, "EVENT-SKU")
+
+	cart := &models.Cart{SessionID: "event-session", Items: []models.CartItem{{ProductID: product.ID, Quantity: 2, Price: 10}}}
+	cartService.On("GetCartWithProducts", mock.Anything, "event-session").Return(cart, nil)
+	cartService.On("ClearCart", mock.Anything, "event-session").Return(nil)
+
+	friend := helpers.GetValidOrderAddress()
+	friend.FirstName = "Friend"
+	req := helpers.GetValidCreateOrderRequest("event-session")
+	req.ShippingGroups = []ShippingGroupRequest{{Key: "friend", Address: friend}}
+	req.ItemAssignments = []ItemAssignmentRequest{{ProductID: product.ID, GroupKey: "friend", Quantity: 1}}
+
+	order, err := service.CreateOrder(context.Background(), user.ID, req)
+	require.NoError(t, err)
+	require.Len(t, order.Shipments, 2)
+
+	_, err = service.UpdateOrderStatus(context.Background(), order.ID, "processing", "admin-1", "")
+	require.NoError(t, err)
+	_, err = service.UpdateShipmentStatus(context.Background(), order.ID, order.Shipments[0].ID, &UpdateShipmentStatusRequest{Status: "shipped"}, "admin-1")
+	require.NoError(t, err)
+	_, err = service.UpdateOrderStatus(context.Background(), order.ID, "delivered", "admin-1", "")
+	require.NoError(t, err)
+
+	events, err := service.GetOrderEvents(context.Background(), order.ID)
+	require.NoError(t, err)
+	var types []string
+	for i, event := range events {
+		assert.Equal(t, i+1, event.Sequence)
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{
+		orderevents.TypeOrderCreated,
+		orderevents.TypeOrderStatusChanged,
+		orderevents.TypeShipmentStatusChanged,
+		orderevents.TypeOrderStatusChanged,
+		orderevents.TypeOrderStatusChanged,
+	}, types)
+
+	// The status history follows the same transitions, starting from checkout
+	history, err := service.GetOrderHistory(context.Background(), order.ID, user.ID)
+	require.NoError(t, err)
+	var statuses []string
+	for _, entry := range history {
+		statuses = append(statuses, entry.OldStatus+">"+entry.NewStatus)
+	}
+	assert.Equal(t, []string{">pending", "pending>processing", "processing>shipped", "shipped>delivered"}, statuses)
+
+	replay, err := service.ReplayOrderEvents(context.Background(), order.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "delivered", replay.Projection.Status)
+	assert.Empty(t, replay.Drift)
+
+	replay, err = service.ReplayOrderEvents(context.Background(), order.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", replay.Projection.Status)
+	assert.Contains(t, replay.Drift, "status")
+
+	t.Run("rebuild repairs a tampered row", func(t *testing.T) {
+		require.NoError(t, db.Model(&models.Order{}).Where("id = ?", order.ID).Update("status", "refunded").Error)
+
+		replay, err := service.ReplayOrderEvents(context.Background(), order.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"status"}, replay.Drift)
+
+		rebuilt, err := service.RebuildOrderFromEvents(context.Background(), order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "delivered", rebuilt.Status)
+	})
+
+	t.Run("orders from before the log have no history", func(t *testing.T) {
+		legacy := helpers.CreateTestOrder(t, user.ID, "paid")
+		_, err := service.ReplayOrderEvents(context.Background(), legacy.ID, 0)
+		assert.ErrorIs(t, err, orderevents.ErrNoEvents)
+
+		_, err = service.GetOrderEvents(context.Background(), "missing")
+		assert.EqualError(t, err, "order not found")
+	})
+}
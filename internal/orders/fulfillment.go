@@ -0,0 +1,281 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/orderevents"
+	"ecommerce-website/internal/orderhistory"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidShipmentItems = errors.New("invalid shipment items")
+
+// CreateShipmentRequest ships quantities of an order's items in one parcel. Serials are recorded when the
+// parcel carries everything left of its shipment group.
+type CreateShipmentRequest struct {
+	Carrier        string                `json:"carrier" binding:"required,max=50"`
+	TrackingNumber *string               `json:"trackingNumber,omitempty" binding:"omitempty,max=100"`
+	Items          []ShipmentItemRequest `json:"items" binding:"required,min=1,dive"`
+	Serials        []ItemSerialsRequest  `json:"serials,omitempty" binding:"omitempty,dive"`
+}
+
+// ShipmentItemRequest is a quantity of one order item packed in a parcel
+type ShipmentItemRequest struct {
+	OrderItemID string `json:"orderItemId" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required,min=1"`
+}
+
+// OrderTracking is the fulfillment of an order as its customer sees it: every shipment with its carrier
+// and tracking number, and how much of each item has shipped
+type OrderTracking struct {
+	OrderID   string            `json:"orderId"`
+	Status    string            `json:"status"`
+	Shipments []models.Shipment `json:"shipments"`
+	Items     []TrackedItem     `json:"items"`
+}
+
+// TrackedItem is the fulfillment of one order item
+type TrackedItem struct {
+	OrderItemID string                 `json:"orderItemId"`
+	ProductID   string                 `json:"productId"`
+	ProductName string                 `json:"productName"`
+	Quantity    int                    `json:"quantity"`
+	Fulfillment models.ItemFulfillment `json:"fulfillment"`
+}
+
+// CreateShipment ships quantities of an order's items in one parcel (admin only). All items must come from the
+// same pending shipment group and at most what is left of them may ship. A parcel carrying everything left of
+// its group ships the group's shipment itself; any other parcel becomes a new shipment of the group, so the
+// rest of the group can follow later. The order becomes shipped with its first parcel.
+func (s *Service) CreateShipment(ctx context.Context, orderID string, req *CreateShipmentRequest, adminID string) (*models.Order, error) {
+	var oldStatus, newStatus string
+	recordEvents := orderevents.Enabled(context.Background(), s.settings)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Preload("Items.Product.Category").Preload("Shipments.ShippedItems").
+			Where("id = ?", orderID).First(&order).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrShipmentNotFound
+			}
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if !shippableOrderStatuses[order.Status] {
+			return fmt.Errorf("%w: order is %s", ErrInvalidShipmentStatus, order.Status)
+		}
+
+		group, quantities, err := parcelGroup(&order, req.Items)
+		if err != nil {
+			return err
+		}
+
+		// Units already sent in earlier parcels of the group are no longer left to ship
+		packed := make(map[string]int)
+		for _, shipment := range order.Shipments {
+			for _, item := range shipment.ShippedItems {
+				packed[item.OrderItemID] += item.Quantity
+			}
+		}
+		completes := true
+		for _, item := range order.Items {
+			if item.ShipmentID == nil || *item.ShipmentID != group.ID {
+				continue
+			}
+			left := item.Quantity - packed[item.ID]
+			quantity := quantities[item.ID]
+			if quantity > left {
+				return fmt.Errorf("%w: %d of %s left to ship", ErrInvalidShipmentItems, left, item.Product.Name)
+			}
+			if quantity < left {
+				completes = false
+			}
+		}
+
+		now := time.Now()
+		actor := orderhistory.Admin(adminID)
+		shipment := group
+		if completes {
+			if err := recordShipmentSerials(tx, orderID, group.ID, req.Serials, adminID, true); err != nil {
+				return err
+			}
+			updates := map[string]interface{}{"status": "shipped", "carrier": req.Carrier, "shipped_at": now}
+			if req.TrackingNumber != nil {
+				updates["tracking_number"] = *req.TrackingNumber
+			}
+			if err := tx.Model(group).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update shipment: %w", err)
+			}
+		} else {
+			// Serials are recorded per shipment group, so serial-tracked units ship with the rest of their group
+			if len(req.Serials) > 0 {
+				return fmt.Errorf("%w: serials are recorded when the whole shipment ships", ErrInvalidSerials)
+			}
+			for _, item := range order.Items {
+				if quantities[item.ID] > 0 && item.Product.Category.SerialTracked {
+					return fmt.Errorf("%w: %s must ship with the rest of its shipment", ErrInvalidShipmentItems, item.Product.Name)
+				}
+			}
+			carrier := req.Carrier
+			parcel := models.Shipment{
+				OrderID:        orderID,
+				GroupKey:       group.GroupKey,
+				Address:        group.Address,
+				DeliveryZoneID: group.DeliveryZoneID,
+				Status:         "shipped",
+				Carrier:        &carrier,
+				TrackingNumber: req.TrackingNumber,
+				ShippedAt:      &now,
+			}
+			if err := tx.Create(&parcel).Error; err != nil {
+				return fmt.Errorf("failed to create shipment: %w", err)
+			}
+			order.Shipments = append(order.Shipments, parcel)
+			shipment = &order.Shipments[len(order.Shipments)-1]
+		}
+
+		for _, item := range req.Items {
+			if err := tx.Create(&models.ShipmentItem{ShipmentID: shipment.ID, OrderItemID: item.OrderItemID, Quantity: item.Quantity}).Error; err != nil {
+				return fmt.Errorf("failed to record shipment item: %w", err)
+			}
+		}
+		if recordEvents {
+			from := ""
+			if completes {
+				from = group.Status
+			}
+			if err := orderevents.Append(tx, orderID, orderevents.TypeShipmentStatusChanged, orderevents.ShipmentStatusChanged{
+				ShipmentID: shipment.ID, From: from, To: "shipped", TrackingNumber: req.TrackingNumber,
+			}); err != nil {
+				return err
+			}
+		}
+		shipment.Status = "shipped"
+
+		oldStatus = order.Status
+		newStatus, err = s.followShipments(tx, &order, shipmentChange{Status: "shipped", At: now, Actor: actor},
+			"shipment "+shipment.ID+" shipped via "+req.Carrier, recordEvents)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if oldStatus != newStatus {
+		return s.reloadAndNotify(ctx, orderID, oldStatus, newStatus)
+	}
+	return s.GetOrder(ctx, orderID, "")
+}
+
+// parcelGroup returns the pending shipment group every requested item belongs to, along with the requested
+// quantity of each order item
+func parcelGroup(order *models.Order, items []ShipmentItemRequest) (*models.Shipment, map[string]int, error) {
+	groupOf := make(map[string]*string, len(order.Items))
+	for _, item := range order.Items {
+		groupOf[item.ID] = item.ShipmentID
+	}
+
+	quantities := make(map[string]int, len(items))
+	var groupID string
+	for _, item := range items {
+		shipmentID, ok := groupOf[item.OrderItemID]
+		if !ok || shipmentID == nil {
+			return nil, nil, fmt.Errorf("%w: item %s is not in this order", ErrInvalidShipmentItems, item.OrderItemID)
+		}
+		if quantities[item.OrderItemID] > 0 {
+			return nil, nil, fmt.Errorf("%w: item %s is listed twice", ErrInvalidShipmentItems, item.OrderItemID)
+		}
+		if groupID != "" && groupID != *shipmentID {
+			return nil, nil, fmt.Errorf("%w: a parcel ships items of one shipment", ErrInvalidShipmentItems)
+		}
+		groupID = *shipmentID
+		quantities[item.OrderItemID] = item.Quantity
+	}
+
+	for i := range order.Shipments {
+		if order.Shipments[i].ID != groupID {
+			continue
+		}
+		if order.Shipments[i].Status != "pending" {
+			return nil, nil, fmt.Errorf("%w: shipment is already %s", ErrInvalidShipmentStatus, order.Shipments[i].Status)
+		}
+		return &order.Shipments[i], quantities, nil
+	}
+	return nil, nil, ErrShipmentNotFound
+}
+
+// GetShipments returns the shipments of a customer's order with the fulfillment of each item
+func (s *Service) GetShipments(ctx context.Context, orderID, userID string) (*OrderTracking, error) {
+	order, err := s.GetOrder(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	tracking := &OrderTracking{
+		OrderID:   order.ID,
+		Status:    order.Status,
+		Shipments: order.Shipments,
+		Items:     make([]TrackedItem, 0, len(order.Items)),
+	}
+	if tracking.Shipments == nil {
+		tracking.Shipments = []models.Shipment{}
+	}
+	for _, item := range order.Items {
+		tracked := TrackedItem{OrderItemID: item.ID, ProductID: item.ProductID, ProductName: item.Product.Name, Quantity: item.Quantity}
+		if item.Fulfillment != nil {
+			tracked.Fulfillment = *item.Fulfillment
+		}
+		tracking.Items = append(tracking.Items, tracked)
+	}
+	return tracking, nil
+}
+
+// annotateFulfillment fills in how much of each item of an order, loaded with its shipments and their shipped
+// items, has shipped and been delivered. Quantities not listed in any parcel travel with their shipment group,
+// and items of orders placed before shipment groups follow the order itself.
+func annotateFulfillment(order *models.Order) {
+	statuses := make(map[string]string, len(order.Shipments))
+	listed := make(map[string]int)
+	shipped := make(map[string]int)
+	delivered := make(map[string]int)
+	for _, shipment := range order.Shipments {
+		statuses[shipment.ID] = shipment.Status
+		for _, item := range shipment.ShippedItems {
+			listed[item.OrderItemID] += item.Quantity
+			if shipment.Status == "shipped" || shipment.Status == "delivered" {
+				shipped[item.OrderItemID] += item.Quantity
+			}
+			if shipment.Status == "delivered" {
+				delivered[item.OrderItemID] += item.Quantity
+			}
+		}
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		status := order.Status
+		if item.ShipmentID != nil {
+			status = statuses[*item.ShipmentID]
+		}
+		rest := item.Quantity - listed[item.ID]
+		if status == "shipped" || status == "delivered" {
+			shipped[item.ID] += rest
+		}
+		if status == "delivered" {
+			delivered[item.ID] += rest
+		}
+
+		fulfillment := models.ItemFulfillment{Status: models.FulfillmentUnfulfilled, Shipped: shipped[item.ID], Delivered: delivered[item.ID]}
+		switch {
+		case fulfillment.Delivered >= item.Quantity:
+			fulfillment.Status = models.FulfillmentDelivered
+		case fulfillment.Shipped >= item.Quantity:
+			fulfillment.Status = models.FulfillmentShipped
+		case fulfillment.Shipped > 0:
+			fulfillment.Status = models.FulfillmentPartiallyShipped
+		}
+		item.Fulfillment = &fulfillment
+	}
+}
@@ -0,0 +1,229 @@
+package orders
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_CreateShipment(t *testing.T) {
+	db, service, helpers, _ := setupPolicyTest(t)
+	user := helpers.CreateTestUser(t, "parcels@example.com")
+	other := helpers.CreateTestUser(t, "stranger@example.com")
+	category := helpers.CreateTestCategory(t, "parcels")
+	mugProduct := createPolicyProduct(t, db, category.ID, "PARCEL-MUG")
+	cardProduct := createPolicyProduct(t, db, category.ID, "PARCEL-CARD")
+
+	order := models.Order{UserID: user.ID, Status: "pending", Subtotal: 32, Total: 32}
+	require.NoError(t, db.Create(&order).Error)
+	group := models.Shipment{OrderID: order.ID, GroupKey: DefaultShipmentGroup, Status: "pending"}
+	require.NoError(t, db.Create(&group).Error)
+	mug := models.OrderItem{OrderID: order.ID, ProductID: mugProduct.ID, Quantity: 3, Price: 10, Total: 30, ShipmentID: &group.ID}
+	card := models.OrderItem{OrderID: order.ID, ProductID: cardProduct.ID, Quantity: 1, Price: 2, Total: 2, ShipmentID: &group.ID}
+	require.NoError(t, db.Create(&mug).Error)
+	require.NoError(t, db.Create(&card).Error)
+
+	fulfillment := func(order *models.Order, itemID string) models.ItemFulfillment {
+		for _, item := range order.Items {
+			if item.ID == itemID {
+				require.NotNil(t, item.Fulfillment)
+				return *item.Fulfillment
+			}
+		}
+		t.Fatalf("item %s not in order", itemID)
+		return models.ItemFulfillment{}
+	}
+	parcelOf := func(items ...ShipmentItemRequest) *CreateShipmentRequest {
+		return &CreateShipmentRequest{Carrier: "UPS", TrackingNumber: stringPtr("1Z-1"), Items: items}
+	}
+
+	_, err := service.CreateShipment(context.Background(), order.ID, parcelOf(ShipmentItemRequest{OrderItemID: mug.ID, Quantity: 2}), "admin-1")
+	assert.ErrorIs(t, err, ErrInvalidShipmentStatus, "unpaid orders must not ship")
+	require.NoError(t, db.Model(&order).Update("status", "paid").Error)
+
+	updated, err := service.CreateShipment(context.Background(), order.ID, parcelOf(ShipmentItemRequest{OrderItemID: mug.ID, Quantity: 2}), "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, "shipped", updated.Status, "the first parcel ships the order")
+	require.Len(t, updated.Shipments, 2)
+	assert.Equal(t, models.ItemFulfillment{Status: models.FulfillmentPartiallyShipped, Shipped: 2}, fulfillment(updated, mug.ID))
+	assert.Equal(t, models.ItemFulfillment{Status: models.FulfillmentUnfulfilled}, fulfillment(updated, card.ID))
+
+	var parcel models.Shipment
+	for _, sh := range updated.Shipments {
+		if sh.ID != group.ID {
+			parcel = sh
+		}
+	}
+	assert.Equal(t, "shipped", parcel.Status)
+	assert.Equal(t, DefaultShipmentGroup, parcel.GroupKey)
+	assert.Equal(t, "UPS", *parcel.Carrier)
+	require.Len(t, parcel.ShippedItems, 1)
+	assert.Equal(t, 2, parcel.ShippedItems[0].Quantity)
+
+	t.Run("packing slips split the group", func(t *testing.T) {
+		slip, err := shipmentSlipOrder(updated, parcel.ID)
+		require.NoError(t, err)
+		require.Len(t, slip.Items, 1)
+		assert.Equal(t, 2, slip.Items[0].Quantity)
+		assert.Equal(t, 20.0, slip.Items[0].Total)
+
+		slip, err = shipmentSlipOrder(updated, group.ID)
+		require.NoError(t, err)
+		left := make(map[string]int)
+		for _, item := range slip.Items {
+			left[item.ID] = item.Quantity
+		}
+		assert.Equal(t, map[string]int{mug.ID: 1, card.ID: 1}, left, "one mug and the card are left")
+	})
+
+	t.Run("rejects invalid parcels", func(t *testing.T) {
+		_, err := service.CreateShipment(context.Background(), order.ID, parcelOf(ShipmentItemRequest{OrderItemID: mug.ID, Quantity: 2}), "admin-1")
+		assert.ErrorIs(t, err, ErrInvalidShipmentItems, "only one mug is left")
+
+		_, err = service.CreateShipment(context.Background(), order.ID, parcelOf(ShipmentItemRequest{OrderItemID: "missing", Quantity: 1}), "admin-1")
+		assert.ErrorIs(t, err, ErrInvalidShipmentItems)
+
+		_, err = service.CreateShipment(context.Background(), order.ID, parcelOf(
+			ShipmentItemRequest{OrderItemID: card.ID, Quantity: 1}, ShipmentItemRequest{OrderItemID: card.ID, Quantity: 1}), "admin-1")
+		assert.ErrorIs(t, err, ErrInvalidShipmentItems)
+	})
+
+	// The rest of the group ships the group's own shipment
+	updated, err = service.CreateShipment(context.Background(), order.ID, parcelOf(
+		ShipmentItemRequest{OrderItemID: mug.ID, Quantity: 1}, ShipmentItemRequest{OrderItemID: card.ID, Quantity: 1}), "admin-1")
+	require.NoError(t, err)
+	require.Len(t, updated.Shipments, 2)
+	assert.Equal(t, models.ItemFulfillment{Status: models.FulfillmentShipped, Shipped: 3}, fulfillment(updated, mug.ID))
+	assert.Equal(t, models.ItemFulfillment{Status: models.FulfillmentShipped, Shipped: 1}, fulfillment(updated, card.ID))
+
+	_, err = service.CreateShipment(context.Background(), order.ID, parcelOf(ShipmentItemRequest{OrderItemID: card.ID, Quantity: 1}), "admin-1")
+	assert.ErrorIs(t, err, ErrInvalidShipmentStatus, "nothing is left to ship")
+
+	updated, err = service.UpdateShipmentStatus(context.Background(), order.ID, parcel.ID, &UpdateShipmentStatusRequest{Status: "delivered"}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, "shipped", updated.Status)
+	assert.Equal(t, models.ItemFulfillment{Status: models.FulfillmentShipped, Shipped: 3, Delivered: 2}, fulfillment(updated, mug.ID))
+
+	updated, err = service.UpdateShipmentStatus(context.Background(), order.ID, group.ID, &UpdateShipmentStatusRequest{Status: "delivered"}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, "delivered", updated.Status, "the order is delivered with its last parcel")
+	assert.Equal(t, models.FulfillmentDelivered, fulfillment(updated, mug.ID).Status)
+
+	t.Run("customers track their own orders", func(t *testing.T) {
+		tracking, err := service.GetShipments(context.Background(), order.ID, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "delivered", tracking.Status)
+		assert.Len(t, tracking.Shipments, 2)
+		require.Len(t, tracking.Items, 2)
+		for _, item := range tracking.Items {
+			assert.Equal(t, models.FulfillmentDelivered, item.Fulfillment.Status)
+		}
+
+		_, err = service.GetShipments(context.Background(), order.ID, other.ID)
+		assert.EqualError(t, err, "order not found")
+	})
+}
+
+func TestService_CreateShipmentSerialTracked(t *testing.T) {
+	db, service, helpers, _ := setupPolicyTest(t)
+	user := helpers.CreateTestUser(t, "serial-parcels@example.com")
+	category := helpers.CreateTestCategory(t, "phones")
+	require.NoError(t, db.Model(category).Update("serial_tracked", true).Error)
+	phone := createPolicyProduct(t, db, category.ID, "PARCEL-PHONE")
+
+	order := models.Order{UserID: user.ID, Status: "paid", Subtotal: 20, Total: 20}
+	require.NoError(t, db.Create(&order).Error)
+	group := models.Shipment{OrderID: order.ID, GroupKey: DefaultShipmentGroup, Status: "pending"}
+	require.NoError(t, db.Create(&group).Error)
+	item := models.OrderItem{OrderID: order.ID, ProductID: phone.ID, Quantity: 2, Price: 10, Total: 20, ShipmentID: &group.ID}
+	require.NoError(t, db.Create(&item).Error)
+
+	req := &CreateShipmentRequest{Carrier: "DHL", Items: []ShipmentItemRequest{{OrderItemID: item.ID, Quantity: 1}}}
+	_, err := service.CreateShipment(context.Background(), order.ID, req, "admin-1")
+	assert.ErrorIs(t, err, ErrInvalidShipmentItems, "serial-tracked units ship with their whole shipment")
+
+	req.Items[0].Quantity = 2
+	_, err = service.CreateShipment(context.Background(), order.ID, req, "admin-1")
+	assert.ErrorIs(t, err, ErrSerialsRequired)
+
+	req.Serials = []ItemSerialsRequest{{OrderItemID: item.ID, Serials: []string{"IMEI-1", "IMEI-2"}}}
+	updated, err := service.CreateShipment(context.Background(), order.ID, req, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, "shipped", updated.Status)
+	require.Len(t, updated.Items, 1)
+	assert.Len(t, updated.Items[0].Serials, 2)
+}
+
+func TestHandler_CreateShipment(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupTestRouter()
+	router.POST("/api/admin/orders/:id/shipments", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		handler.CreateShipment(c)
+	})
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/admin/orders/order-1/shipments", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	valid := `{"carrier":"UPS","trackingNumber":"1Z-1","items":[{"orderItemId":"item-1","quantity":2}]}`
+
+	assert.Equal(t, http.StatusBadRequest, create(`{"items":[{"orderItemId":"item-1","quantity":2}]}`).Code, "carrier is required")
+	assert.Equal(t, http.StatusBadRequest, create(`{"carrier":"UPS","items":[]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, create(`{"carrier":"UPS","items":[{"orderItemId":"item-1","quantity":0}]}`).Code)
+
+	mockService.On("CreateShipment", mock.Anything, "order-1", mock.MatchedBy(func(req *CreateShipmentRequest) bool {
+		return req.Carrier == "UPS" && len(req.Items) == 1 && req.Items[0].Quantity == 2
+	}), "admin-1").Return(&models.Order{ID: "order-1", Status: "shipped"}, nil).Once()
+	assert.Equal(t, http.StatusCreated, create(valid).Code)
+
+	mockService.On("CreateShipment", mock.Anything, "order-1", mock.Anything, "admin-1").Return(nil, ErrInvalidShipmentItems).Once()
+	assert.Equal(t, http.StatusBadRequest, create(valid).Code)
+
+	mockService.On("CreateShipment", mock.Anything, "order-1", mock.Anything, "admin-1").Return(nil, ErrInvalidShipmentStatus).Once()
+	assert.Equal(t, http.StatusConflict, create(valid).Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetShipments(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupTestRouter()
+	router.GET("/api/orders/:id/shipments", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		c.Set("user_role", "customer")
+		handler.GetShipments(c)
+	})
+
+	mockService.On("GetShipments", mock.Anything, "order-1", "user-1").Return(&OrderTracking{OrderID: "order-1", Status: "shipped"}, nil)
+	mockService.On("GetShipments", mock.Anything, "missing", "user-1").Return(nil, assert.AnError)
+	mockService.On("GetShipments", mock.Anything, "other", "user-1").Return(nil, errors.New("order not found"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/orders/order-1/shipments", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"orderId":"order-1"`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/orders/other/shipments", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/orders/missing/shipments", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockService.AssertExpectations(t)
+}
@@ -1,11 +1,18 @@
 package orders
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
+	"ecommerce-website/internal/invoices"
 	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/orderevents"
+	"ecommerce-website/internal/payments"
+	"ecommerce-website/internal/shipping"
 	"ecommerce-website/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -37,15 +44,19 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	// Validate required address fields
-	if err := validateOrderAddress(req.ShippingAddress); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SHIPPING_ADDRESS", "Invalid shipping address", err.Error())
-		return
+	// Validate required fields of the addresses sent in full; saved addresses are resolved by the service
+	if !isZeroAddress(req.ShippingAddress) {
+		if err := validateOrderAddress(req.ShippingAddress); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SHIPPING_ADDRESS", "Invalid shipping address", err.Error())
+			return
+		}
 	}
 
-	if err := validateOrderAddress(req.BillingAddress); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_BILLING_ADDRESS", "Invalid billing address", err.Error())
-		return
+	if !isZeroAddress(req.BillingAddress) {
+		if err := validateOrderAddress(req.BillingAddress); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_BILLING_ADDRESS", "Invalid billing address", err.Error())
+			return
+		}
 	}
 
 	// Create order
@@ -56,7 +67,21 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		case "cart is empty":
 			utils.ErrorResponse(c, http.StatusBadRequest, "EMPTY_CART", "Cart is empty", nil)
 		default:
-			if contains(err.Error(), "insufficient inventory") {
+			if errors.Is(err, ErrBelowMinimumOrder) {
+				utils.ErrorResponse(c, http.StatusUnprocessableEntity, "BELOW_MINIMUM_ORDER", err.Error(), nil)
+			} else if errors.Is(err, ErrSavedAddressNotFound) {
+				utils.ErrorResponse(c, http.StatusBadRequest, "ADDRESS_NOT_FOUND", err.Error(), nil)
+			} else if errors.Is(err, ErrConflictingAddress) || errors.Is(err, ErrMissingAddress) {
+				utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_ADDRESS", err.Error(), nil)
+			} else if errors.Is(err, ErrInvalidShippingGroup) || errors.Is(err, ErrInvalidItemAssignment) {
+				utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SHIPPING_GROUPS", err.Error(), nil)
+			} else if errors.Is(err, shipping.ErrMethodNotFound) || errors.Is(err, shipping.ErrMethodUnavailable) {
+				utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SHIPPING_METHOD", err.Error(), nil)
+			} else if errors.Is(err, ErrInvalidPromoCode) {
+				utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_PROMO_CODE", err.Error(), nil)
+			} else if errors.Is(err, ErrPromoCodeNotApplicable) {
+				utils.ErrorResponse(c, http.StatusBadRequest, "PROMO_CODE_NOT_APPLICABLE", err.Error(), nil)
+			} else if contains(err.Error(), "insufficient inventory") {
 				utils.ErrorResponse(c, http.StatusConflict, "INSUFFICIENT_INVENTORY", err.Error(), nil)
 			} else if contains(err.Error(), "no longer available") {
 				utils.ErrorResponse(c, http.StatusConflict, "PRODUCT_UNAVAILABLE", err.Error(), nil)
@@ -71,6 +96,7 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 }
 
 // GetOrder handles GET /api/orders/:id
+// Customers see the comments admins made visible to them; admins see every comment.
 func (h *Handler) GetOrder(c *gin.Context) {
 	orderID := c.Param("id")
 	if orderID == "" {
@@ -101,7 +127,7 @@ func (h *Handler) GetOrder(c *gin.Context) {
 		filterUserID = userIDStr
 	}
 
-	order, err := h.service.GetOrder(orderID, filterUserID)
+	order, err := h.service.GetOrder(c.Request.Context(), orderID, filterUserID)
 	if err != nil {
 		if err.Error() == "order not found" {
 			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
@@ -114,6 +140,287 @@ func (h *Handler) GetOrder(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Order retrieved successfully", order)
 }
 
+// GetPackingSlip handles GET /api/orders/:id/packing-slip and GET /api/admin/orders/:id/packing-slip.
+// Gift orders get the price-hidden variant; ?shipmentId= limits the slip to one shipment.
+func (h *Handler) GetPackingSlip(c *gin.Context) {
+	orderID := c.Param("id")
+
+	userID, exists := c.Get("user_id")
+	userRole, _ := c.Get("user_role")
+	isAdmin := userRole == "admin"
+
+	if !isAdmin && !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	// Admin can print any slip, regular users only those of their own orders
+	var filterUserID string
+	if !isAdmin {
+		filterUserID = userID.(string)
+	}
+
+	order, err := h.service.GetOrder(c.Request.Context(), orderID, filterUserID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "GET_ORDER_FAILED", "Failed to get order", err.Error())
+		}
+		return
+	}
+
+	// Multi-address orders print one slip per shipment
+	if shipmentID := c.Query("shipmentId"); shipmentID != "" {
+		if order, err = shipmentSlipOrder(order, shipmentID); err != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, "SHIPMENT_NOT_FOUND", "Shipment not found", nil)
+			return
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=packing-slip-%s.pdf", order.ID))
+	c.Data(http.StatusOK, "application/pdf", RenderPackingSlip(order))
+}
+
+// GetInvoice handles GET /api/orders/:id/invoice and GET /api/admin/orders/:id/invoice.
+// Returns the invoice of the order as a PDF document.
+func (h *Handler) GetInvoice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	userRole, _ := c.Get("user_role")
+	isAdmin := userRole == "admin"
+
+	if !isAdmin && !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	// Admin can download any invoice, regular users only those of their own orders
+	var filterUserID string
+	if !isAdmin {
+		filterUserID = userID.(string)
+	}
+
+	order, invoice, err := h.service.GetInvoice(c.Request.Context(), c.Param("id"), filterUserID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "GET_INVOICE_FAILED", "Failed to render invoice", err.Error())
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", invoices.Filename(order)))
+	c.Data(http.StatusOK, "application/pdf", invoice)
+}
+
+// CancelOrder handles POST /api/orders/:id/cancel
+func (h *Handler) CancelOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	// The body is optional; it only carries the cancellation reason
+	var req CancelOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	order, err := h.service.CancelOrder(c.Request.Context(), c.Param("id"), userID.(string), &req)
+	if err != nil {
+		switch {
+		case err.Error() == "order not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+		case errors.Is(err, ErrOrderNotCancellable):
+			utils.ErrorResponse(c, http.StatusConflict, "ORDER_NOT_CANCELLABLE", err.Error(), nil)
+		case errors.Is(err, ErrCancellationWindowClosed):
+			utils.ErrorResponse(c, http.StatusConflict, "CANCELLATION_WINDOW_CLOSED", err.Error(), nil)
+		case errors.Is(err, payments.ErrRefundFailed):
+			utils.ErrorResponse(c, http.StatusBadGateway, "REFUND_FAILED", "The order could not be refunded, so it was not cancelled", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "CANCEL_ORDER_FAILED", "Failed to cancel order", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Order cancelled successfully", order)
+}
+
+// GetOrderHistory handles GET /api/orders/:id/history. Admins see the history of any order, customers
+// only of their own.
+func (h *Handler) GetOrderHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	var filterUserID string
+	if userRole, _ := c.Get("user_role"); userRole != "admin" {
+		filterUserID = userID.(string)
+	}
+
+	history, err := h.service.GetOrderHistory(c.Request.Context(), c.Param("id"), filterUserID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_ORDER_HISTORY_FAILED", "Failed to get order history", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Order history retrieved successfully", history)
+}
+
+// GetOrderComments handles GET /api/admin/orders/:id/comments (admin only)
+// Lists internal and customer-visible comments, oldest first.
+func (h *Handler) GetOrderComments(c *gin.Context) {
+	comments, err := h.service.GetOrderComments(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.commentError(c, err, "GET_ORDER_COMMENTS_FAILED", "Failed to get order comments")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Order comments retrieved successfully", comments)
+}
+
+// AddOrderComment handles POST /api/admin/orders/:id/comments (admin only)
+// Comments are internal unless visibility is customer, which also shows them on the customer's order.
+func (h *Handler) AddOrderComment(c *gin.Context) {
+	var req CreateOrderCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	comment, err := h.service.AddOrderComment(c.Request.Context(), c.Param("id"), c.GetString("user_id"), &req)
+	if err != nil {
+		h.commentError(c, err, "ADD_ORDER_COMMENT_FAILED", "Failed to add order comment")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Order comment added successfully", comment)
+}
+
+// UpdateOrderComment handles PUT /api/admin/orders/:id/comments/:commentId (admin only)
+func (h *Handler) UpdateOrderComment(c *gin.Context) {
+	var req UpdateOrderCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	comment, err := h.service.UpdateOrderComment(c.Request.Context(), c.Param("id"), c.Param("commentId"), &req)
+	if err != nil {
+		h.commentError(c, err, "UPDATE_ORDER_COMMENT_FAILED", "Failed to update order comment")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Order comment updated successfully", comment)
+}
+
+// DeleteOrderComment handles DELETE /api/admin/orders/:id/comments/:commentId (admin only)
+func (h *Handler) DeleteOrderComment(c *gin.Context) {
+	if err := h.service.DeleteOrderComment(c.Request.Context(), c.Param("id"), c.Param("commentId")); err != nil {
+		h.commentError(c, err, "DELETE_ORDER_COMMENT_FAILED", "Failed to delete order comment")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Order comment deleted successfully", nil)
+}
+
+// commentError writes the response for a failed order comment operation
+func (h *Handler) commentError(c *gin.Context, err error, code, message string) {
+	switch {
+	case err.Error() == "order not found":
+		utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+	case errors.Is(err, ErrCommentNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "COMMENT_NOT_FOUND", "Comment not found", nil)
+	case errors.Is(err, ErrEmptyComment):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Comment body cannot be blank", nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, code, message, err.Error())
+	}
+}
+
+// LookupWarranty handles GET /api/warranty/lookup?serial= for the customer who bought the unit
+func (h *Handler) LookupWarranty(c *gin.Context) {
+	serial := c.Query("serial")
+	if serial == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "MISSING_SERIAL", "Serial number is required", nil)
+		return
+	}
+
+	record, err := h.service.LookupWarranty(c.Request.Context(), serial, c.GetString("user_id"))
+	if err != nil {
+		if errors.Is(err, ErrSerialNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "SERIAL_NOT_FOUND", "No purchase found for this serial number", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "WARRANTY_LOOKUP_FAILED", "Failed to look up serial number", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase found", record)
+}
+
+// SearchSerials handles GET /api/admin/serials?q= (admin only)
+func (h *Handler) SearchSerials(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	records, total, err := h.service.SearchSerials(c.Request.Context(), c.Query("q"), page, limit)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSerials) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "A search term is required", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "SEARCH_SERIALS_FAILED", "Failed to search serial numbers", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(c, http.StatusOK, "Serial numbers retrieved successfully", "serials", records, utils.NewPagination(page, limit, total))
+}
+
+// CreateReturnRequest handles POST /api/orders/:id/returns
+func (h *Handler) CreateReturnRequest(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	var req CreateReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	returns, err := h.service.CreateReturnRequest(c.Request.Context(), c.Param("id"), userID.(string), &req)
+	if err != nil {
+		switch {
+		case err.Error() == "order not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+		case errors.Is(err, ErrOrderNotDelivered):
+			utils.ErrorResponse(c, http.StatusConflict, "ORDER_NOT_DELIVERED", err.Error(), nil)
+		case errors.Is(err, ErrInvalidReturnItem):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_RETURN_ITEM", err.Error(), nil)
+		case errors.Is(err, ErrItemNotReturnable):
+			utils.ErrorResponse(c, http.StatusConflict, "ITEM_NOT_RETURNABLE", err.Error(), nil)
+		case errors.Is(err, ErrReturnWindowClosed):
+			utils.ErrorResponse(c, http.StatusConflict, "RETURN_WINDOW_CLOSED", err.Error(), nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "CREATE_RETURN_FAILED", "Failed to create return request", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Return request created successfully", gin.H{"returns": returns})
+}
+
 // GetUserOrders handles GET /api/orders (for authenticated users)
 func (h *Handler) GetUserOrders(c *gin.Context) {
 	// Get user ID from context
@@ -135,35 +442,49 @@ func (h *Handler) GetUserOrders(c *gin.Context) {
 		limit = 10
 	}
 
-	orders, total, err := h.service.GetUserOrders(userID.(string), page, limit)
+	orders, total, err := h.service.GetUserOrders(c.Request.Context(), userID.(string), page, limit)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_ORDERS_FAILED", "Failed to get orders", err.Error())
 		return
 	}
 
-	// Calculate pagination info
-	totalPages := (int(total) + limit - 1) / limit
-
-	response := gin.H{
-		"orders": orders,
-		"pagination": gin.H{
-			"page":       page,
-			"limit":      limit,
-			"total":      total,
-			"totalPages": totalPages,
-		},
-	}
-
-	utils.SuccessResponse(c, http.StatusOK, "Orders retrieved successfully", response)
+	utils.PaginatedResponse(c, http.StatusOK, "Orders retrieved successfully", "orders", orders, utils.NewPagination(page, limit, total))
 }
 
-// GetAllOrders handles GET /api/admin/orders (admin only)
+// GetAllOrders handles GET /api/admin/orders (admin only). Orders can be filtered by status and userId, and
+// searched with q across order ID prefix, customer name or email and item SKU, or with orderId, customer and sku
+// individually; from and to are inclusive days in YYYY-MM-DD format.
 func (h *Handler) GetAllOrders(c *gin.Context) {
 	// Parse pagination parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	status := c.Query("status")
-	userID := c.Query("userId")
+	filters := OrderFilters{
+		Status:   c.Query("status"),
+		UserID:   c.Query("userId"),
+		Query:    c.Query("q"),
+		OrderID:  c.Query("orderId"),
+		Customer: c.Query("customer"),
+		SKU:      c.Query("sku"),
+	}
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+		days int
+	}{{"from", &filters.From, 0}, {"to", &filters.To, 1}} {
+		if v := c.Query(param.name); v != "" {
+			parsed, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", fmt.Sprintf("%s must be in YYYY-MM-DD format", param.name), nil)
+				return
+			}
+			parsed = parsed.AddDate(0, 0, param.days)
+			*param.dest = &parsed
+		}
+	}
+	if filters.From != nil && filters.To != nil && !filters.From.Before(*filters.To) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", "from must not be after to", nil)
+		return
+	}
 
 	// Validate pagination parameters
 	if page < 1 {
@@ -173,26 +494,13 @@ func (h *Handler) GetAllOrders(c *gin.Context) {
 		limit = 10
 	}
 
-	orders, total, err := h.service.GetAllOrders(page, limit, status, userID)
+	orders, total, err := h.service.GetAllOrders(c.Request.Context(), page, limit, filters)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_ORDERS_FAILED", "Failed to get orders", err.Error())
 		return
 	}
 
-	// Calculate pagination info
-	totalPages := (int(total) + limit - 1) / limit
-
-	response := gin.H{
-		"orders": orders,
-		"pagination": gin.H{
-			"page":       page,
-			"limit":      limit,
-			"total":      total,
-			"totalPages": totalPages,
-		},
-	}
-
-	utils.SuccessResponse(c, http.StatusOK, "Orders retrieved successfully", response)
+	utils.PaginatedResponse(c, http.StatusOK, "Orders retrieved successfully", "orders", orders, utils.NewPagination(page, limit, total))
 }
 
 // GetAllCustomers handles GET /api/admin/customers (admin only)
@@ -210,26 +518,28 @@ func (h *Handler) GetAllCustomers(c *gin.Context) {
 		limit = 10
 	}
 
-	customers, total, err := h.service.GetAllCustomers(page, limit, search)
+	customers, total, err := h.service.GetAllCustomers(c.Request.Context(), page, limit, search)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_CUSTOMERS_FAILED", "Failed to get customers", err.Error())
 		return
 	}
 
-	// Calculate pagination info
-	totalPages := (int(total) + limit - 1) / limit
+	utils.PaginatedResponse(c, http.StatusOK, "Customers retrieved successfully", "customers", customers, utils.NewPagination(page, limit, total))
+}
 
-	response := gin.H{
-		"customers": customers,
-		"pagination": gin.H{
-			"page":       page,
-			"limit":      limit,
-			"total":      total,
-			"totalPages": totalPages,
-		},
+// GetCustomer handles GET /api/admin/customers/:id (admin only)
+func (h *Handler) GetCustomer(c *gin.Context) {
+	customer, err := h.service.GetCustomer(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrCustomerNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "CUSTOMER_NOT_FOUND", "Customer not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_CUSTOMER_FAILED", "Failed to get customer", err.Error())
+		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Customers retrieved successfully", response)
+	utils.SuccessResponse(c, http.StatusOK, "Customer retrieved successfully", customer)
 }
 
 // UpdateOrderStatus handles PUT /api/admin/orders/:id/status (admin only)
@@ -242,6 +552,7 @@ func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 
 	var req struct {
 		Status string `json:"status" binding:"required"`
+		Note   string `json:"note" binding:"max=500"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -249,12 +560,16 @@ func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	order, err := h.service.UpdateOrderStatus(orderID, req.Status)
+	adminID, _ := c.Get("user_id")
+	adminIDStr, _ := adminID.(string)
+	order, err := h.service.UpdateOrderStatus(c.Request.Context(), orderID, req.Status, adminIDStr, req.Note)
 	if err != nil {
 		if err.Error() == "order not found" {
 			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
 		} else if contains(err.Error(), "invalid order status") {
 			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_STATUS", err.Error(), nil)
+		} else if errors.Is(err, ErrSerialsRequired) {
+			utils.ErrorResponse(c, http.StatusConflict, "SERIALS_REQUIRED", err.Error(), nil)
 		} else {
 			utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_STATUS_FAILED", "Failed to update order status", err.Error())
 		}
@@ -264,6 +579,468 @@ func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Order status updated successfully", order)
 }
 
+// GetReturns handles GET /api/orders/:id/returns. It lists the customer's return requests for the order.
+func (h *Handler) GetReturns(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	returns, err := h.service.GetReturns(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		if err.Error() == "order not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_RETURNS_FAILED", "Failed to get returns", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Returns retrieved successfully", gin.H{"returns": returns})
+}
+
+// GetAllReturns handles GET /api/admin/returns?status= (admin only). It lists return requests oldest first.
+func (h *Handler) GetAllReturns(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	returns, total, err := h.service.GetAllReturns(c.Request.Context(), c.Query("status"), page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_RETURNS_FAILED", "Failed to get returns", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(c, http.StatusOK, "Returns retrieved successfully", "returns", returns, utils.NewPagination(page, limit, total))
+}
+
+// ApproveReturn handles POST /api/admin/returns/:id/approve (admin only). It issues the return label.
+func (h *Handler) ApproveReturn(c *gin.Context) {
+	var req ApproveReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	ret, err := h.service.ApproveReturn(c.Request.Context(), c.Param("id"), &req, c.GetString("user_id"))
+	if err != nil {
+		h.returnError(c, err, "APPROVE_RETURN_FAILED", "Failed to approve return")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Return approved successfully", ret)
+}
+
+// RejectReturn handles POST /api/admin/returns/:id/reject (admin only)
+func (h *Handler) RejectReturn(c *gin.Context) {
+	var req RejectReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	ret, err := h.service.RejectReturn(c.Request.Context(), c.Param("id"), &req, c.GetString("user_id"))
+	if err != nil {
+		h.returnError(c, err, "REJECT_RETURN_FAILED", "Failed to reject return")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Return rejected successfully", ret)
+}
+
+// CompleteReturn handles POST /api/admin/returns/:id/complete (admin only). It refunds and restocks the
+// returned units once they arrive back.
+func (h *Handler) CompleteReturn(c *gin.Context) {
+	var req CompleteReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	ret, err := h.service.CompleteReturn(c.Request.Context(), c.Param("id"), &req, c.GetString("user_id"))
+	if err != nil {
+		h.returnError(c, err, "COMPLETE_RETURN_FAILED", "Failed to complete return")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Return completed successfully", ret)
+}
+
+// GetReturnLabel handles GET /api/orders/:id/returns/:returnId/label and GET /api/admin/returns/:id/label.
+// Customers only get the labels of their own returns.
+func (h *Handler) GetReturnLabel(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	var filterUserID string
+	if userRole, _ := c.Get("user_role"); userRole != "admin" {
+		filterUserID = userID.(string)
+	}
+	returnID := c.Param("returnId")
+	if returnID == "" {
+		returnID = c.Param("id")
+	}
+
+	ret, label, err := h.service.GetReturnLabel(c.Request.Context(), returnID, filterUserID)
+	if err != nil {
+		h.returnError(c, err, "GET_RETURN_LABEL_FAILED", "Failed to get return label")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=return-label-%s.pdf", *ret.RMANumber))
+	c.Data(http.StatusOK, "application/pdf", label)
+}
+
+// returnError maps a return workflow error to its response
+func (h *Handler) returnError(c *gin.Context, err error, code, message string) {
+	switch {
+	case errors.Is(err, ErrReturnNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "RETURN_NOT_FOUND", "Return not found", nil)
+	case errors.Is(err, ErrInvalidReturnStatus):
+		utils.ErrorResponse(c, http.StatusConflict, "INVALID_RETURN_STATUS", err.Error(), nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, code, message, err.Error())
+	}
+}
+
+// UpdateShipmentStatus handles PUT /api/admin/orders/:id/shipments/:shipmentId/status (admin only)
+func (h *Handler) UpdateShipmentStatus(c *gin.Context) {
+	var req UpdateShipmentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	order, err := h.service.UpdateShipmentStatus(c.Request.Context(), c.Param("id"), c.Param("shipmentId"), &req, c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrShipmentNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "SHIPMENT_NOT_FOUND", "Shipment not found", nil)
+		case errors.Is(err, ErrInvalidShipmentStatus):
+			utils.ErrorResponse(c, http.StatusConflict, "INVALID_SHIPMENT_STATUS", err.Error(), nil)
+		case errors.Is(err, ErrInvalidSerials):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SERIALS", err.Error(), nil)
+		case errors.Is(err, ErrSerialsRequired):
+			utils.ErrorResponse(c, http.StatusConflict, "SERIALS_REQUIRED", err.Error(), nil)
+		case errors.Is(err, ErrDuplicateSerial):
+			utils.ErrorResponse(c, http.StatusConflict, "DUPLICATE_SERIAL", err.Error(), nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_SHIPMENT_FAILED", "Failed to update shipment status", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Shipment status updated successfully", order)
+}
+
+// CreateShipment handles POST /api/admin/orders/:id/shipments (admin only). It ships quantities of the
+// order's items in one parcel with its carrier and tracking number.
+func (h *Handler) CreateShipment(c *gin.Context) {
+	var req CreateShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	order, err := h.service.CreateShipment(c.Request.Context(), c.Param("id"), &req, c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrShipmentNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "SHIPMENT_NOT_FOUND", "Shipment not found", nil)
+		case errors.Is(err, ErrInvalidShipmentItems):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SHIPMENT_ITEMS", err.Error(), nil)
+		case errors.Is(err, ErrInvalidShipmentStatus):
+			utils.ErrorResponse(c, http.StatusConflict, "INVALID_SHIPMENT_STATUS", err.Error(), nil)
+		case errors.Is(err, ErrInvalidSerials):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SERIALS", err.Error(), nil)
+		case errors.Is(err, ErrSerialsRequired):
+			utils.ErrorResponse(c, http.StatusConflict, "SERIALS_REQUIRED", err.Error(), nil)
+		case errors.Is(err, ErrDuplicateSerial):
+			utils.ErrorResponse(c, http.StatusConflict, "DUPLICATE_SERIAL", err.Error(), nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "CREATE_SHIPMENT_FAILED", "Failed to create shipment", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Shipment created successfully", order)
+}
+
+// GetShipments handles GET /api/orders/:id/shipments. It returns the order's shipments with their carrier
+// and tracking number, and the fulfillment of each item. Admins see any order, customers only their own.
+func (h *Handler) GetShipments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	var filterUserID string
+	if userRole, _ := c.Get("user_role"); userRole != "admin" {
+		filterUserID = userID.(string)
+	}
+
+	tracking, err := h.service.GetShipments(c.Request.Context(), c.Param("id"), filterUserID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_SHIPMENTS_FAILED", "Failed to get shipments", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Shipments retrieved successfully", tracking)
+}
+
+// ConfirmDelivery handles POST /api/deliveries/confirm (carrier webhook and driver app)
+func (h *Handler) ConfirmDelivery(c *gin.Context) {
+	var req ConfirmDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	order, err := h.service.ConfirmDelivery(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrShipmentNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "SHIPMENT_NOT_FOUND", "Shipment not found", nil)
+		case errors.Is(err, ErrInvalidDeliveryConfirmation):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DELIVERY_CONFIRMATION", err.Error(), nil)
+		case errors.Is(err, ErrInvalidShipmentStatus):
+			utils.ErrorResponse(c, http.StatusConflict, "INVALID_SHIPMENT_STATUS", err.Error(), nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "CONFIRM_DELIVERY_FAILED", "Failed to confirm delivery", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Delivery confirmed successfully", order)
+}
+
+// GetReviewForm handles GET /api/orders/:id/review-form?token=... for the link in a review request email
+func (h *Handler) GetReviewForm(c *gin.Context) {
+	form, err := h.service.GetReviewForm(c.Request.Context(), c.Param("id"), c.Query("token"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidReviewToken) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_REVIEW_LINK", "Review link is invalid or has expired", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "REVIEW_FORM_FAILED", "Failed to load review form", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Review form retrieved successfully", form)
+}
+
+// GetOrderEvents handles GET /api/admin/orders/:id/events (admin only)
+func (h *Handler) GetOrderEvents(c *gin.Context) {
+	events, err := h.service.GetOrderEvents(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondOrderEventsError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Order events retrieved successfully", gin.H{"events": events})
+}
+
+// ReplayOrderEvents handles GET /api/admin/orders/:id/events/replay (admin only)
+func (h *Handler) ReplayOrderEvents(c *gin.Context) {
+	upTo, err := strconv.Atoi(c.DefaultQuery("upTo", "0"))
+	if err != nil || upTo < 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SEQUENCE", "upTo must be a non-negative event sequence number", nil)
+		return
+	}
+
+	replay, err := h.service.ReplayOrderEvents(c.Request.Context(), c.Param("id"), upTo)
+	if err != nil {
+		respondOrderEventsError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Order replayed successfully", replay)
+}
+
+// RebuildOrderFromEvents handles POST /api/admin/orders/:id/events/rebuild (admin only)
+func (h *Handler) RebuildOrderFromEvents(c *gin.Context) {
+	order, err := h.service.RebuildOrderFromEvents(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondOrderEventsError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Order rebuilt from events successfully", order)
+}
+
+// respondOrderEventsError maps event log errors to responses
+func respondOrderEventsError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "order not found":
+		utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+	case errors.Is(err, orderevents.ErrNoEvents), errors.Is(err, orderevents.ErrIncompleteStream):
+		utils.ErrorResponse(c, http.StatusConflict, "ORDER_EVENTS_UNAVAILABLE", "Order has no complete event history", err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "ORDER_EVENTS_FAILED", "Failed to read order events", err.Error())
+	}
+}
+
+// CreateDraftOrder handles POST /api/admin/draft-orders (admin only)
+// Puts together an order on behalf of a customer, such as one taken over the phone.
+func (h *Handler) CreateDraftOrder(c *gin.Context) {
+	var req DraftOrderRequest
+	if !bindDraftOrderRequest(c, &req) {
+		return
+	}
+
+	draft, err := h.service.CreateDraftOrder(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		h.draftOrderError(c, err, "CREATE_DRAFT_ORDER_FAILED", "Failed to create draft order")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Draft order created successfully", draft)
+}
+
+// GetDraftOrders handles GET /api/admin/draft-orders (admin only)
+func (h *Handler) GetDraftOrders(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	filters := DraftOrderFilters{Status: c.Query("status"), CustomerID: c.Query("customerId")}
+	drafts, total, err := h.service.GetDraftOrders(c.Request.Context(), page, limit, filters)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_DRAFT_ORDERS_FAILED", "Failed to get draft orders", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(c, http.StatusOK, "Draft orders retrieved successfully", "draftOrders", drafts, utils.NewPagination(page, limit, total))
+}
+
+// GetDraftOrder handles GET /api/draft-orders/:id and GET /api/admin/draft-orders/:id
+// Customers see their own drafts once the payment link was sent; admins see any draft.
+func (h *Handler) GetDraftOrder(c *gin.Context) {
+	var filterUserID string
+	if userRole, _ := c.Get("user_role"); userRole != "admin" {
+		filterUserID = c.GetString("user_id")
+	}
+
+	draft, err := h.service.GetDraftOrder(c.Request.Context(), c.Param("id"), filterUserID)
+	if err != nil {
+		h.draftOrderError(c, err, "GET_DRAFT_ORDER_FAILED", "Failed to get draft order")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Draft order retrieved successfully", draft)
+}
+
+// UpdateDraftOrder handles PUT /api/admin/draft-orders/:id (admin only)
+// Replaces the draft's contents; an invoiced draft goes back to open until its payment link is sent again.
+func (h *Handler) UpdateDraftOrder(c *gin.Context) {
+	var req DraftOrderRequest
+	if !bindDraftOrderRequest(c, &req) {
+		return
+	}
+
+	draft, err := h.service.UpdateDraftOrder(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.draftOrderError(c, err, "UPDATE_DRAFT_ORDER_FAILED", "Failed to update draft order")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Draft order updated successfully", draft)
+}
+
+// SendDraftOrderInvoice handles POST /api/admin/draft-orders/:id/send-invoice (admin only)
+// Emails the customer a link to pay for the draft.
+func (h *Handler) SendDraftOrderInvoice(c *gin.Context) {
+	draft, err := h.service.SendDraftOrderInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.draftOrderError(c, err, "SEND_DRAFT_ORDER_INVOICE_FAILED", "Failed to send draft order invoice")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Draft order invoice sent successfully", draft)
+}
+
+// CancelDraftOrder handles POST /api/admin/draft-orders/:id/cancel (admin only)
+func (h *Handler) CancelDraftOrder(c *gin.Context) {
+	draft, err := h.service.CancelDraftOrder(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.draftOrderError(c, err, "CANCEL_DRAFT_ORDER_FAILED", "Failed to cancel draft order")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Draft order cancelled successfully", draft)
+}
+
+// CheckoutDraftOrder handles POST /api/draft-orders/:id/checkout
+// Places the pending order of an invoiced draft at its quoted prices; the customer then pays for it through
+// /api/payments/create-order, and the draft is completed once it is paid.
+func (h *Handler) CheckoutDraftOrder(c *gin.Context) {
+	order, err := h.service.CheckoutDraftOrder(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case contains(err.Error(), "insufficient inventory"):
+			utils.ErrorResponse(c, http.StatusConflict, "INSUFFICIENT_INVENTORY", err.Error(), nil)
+		case contains(err.Error(), "no longer available"):
+			utils.ErrorResponse(c, http.StatusConflict, "PRODUCT_UNAVAILABLE", err.Error(), nil)
+		default:
+			h.draftOrderError(c, err, "CHECKOUT_DRAFT_ORDER_FAILED", "Failed to check out draft order")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Order created successfully", order)
+}
+
+// bindDraftOrderRequest binds a draft order request and validates the addresses sent in full, writing the
+// error response when it fails
+func bindDraftOrderRequest(c *gin.Context, req *DraftOrderRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return false
+	}
+	if !isZeroAddress(req.ShippingAddress) {
+		if err := validateOrderAddress(req.ShippingAddress); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SHIPPING_ADDRESS", "Invalid shipping address", err.Error())
+			return false
+		}
+	}
+	if !isZeroAddress(req.BillingAddress) {
+		if err := validateOrderAddress(req.BillingAddress); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_BILLING_ADDRESS", "Invalid billing address", err.Error())
+			return false
+		}
+	}
+	return true
+}
+
+// draftOrderError writes the response for a failed draft order operation
+func (h *Handler) draftOrderError(c *gin.Context, err error, code, message string) {
+	switch {
+	case errors.Is(err, ErrDraftOrderNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "DRAFT_ORDER_NOT_FOUND", "Draft order not found", nil)
+	case errors.Is(err, ErrCustomerNotFound):
+		utils.ErrorResponse(c, http.StatusBadRequest, "CUSTOMER_NOT_FOUND", "Customer not found", nil)
+	case errors.Is(err, ErrDraftOrderLocked), errors.Is(err, ErrDraftOrderNotPayable):
+		utils.ErrorResponse(c, http.StatusConflict, "INVALID_DRAFT_ORDER_STATUS", err.Error(), nil)
+	case errors.Is(err, ErrDraftItemUnavailable):
+		utils.ErrorResponse(c, http.StatusBadRequest, "PRODUCT_UNAVAILABLE", err.Error(), nil)
+	case errors.Is(err, ErrInvalidDiscount):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DISCOUNT", err.Error(), nil)
+	case errors.Is(err, ErrSavedAddressNotFound):
+		utils.ErrorResponse(c, http.StatusBadRequest, "ADDRESS_NOT_FOUND", err.Error(), nil)
+	case errors.Is(err, ErrConflictingAddress), errors.Is(err, ErrMissingAddress):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_ADDRESS", err.Error(), nil)
+	case errors.Is(err, ErrDraftInvoiceUnavailable):
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "EMAIL_UNAVAILABLE", err.Error(), nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, code, message, err.Error())
+	}
+}
+
 // validateOrderAddress validates required fields in an order address
 func validateOrderAddress(addr models.OrderAddress) error {
 	if addr.FirstName == "" {
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/payments"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -31,37 +34,289 @@ func (m *MockService) CreateOrder(ctx context.Context, userID string, req *Creat
 	return args.Get(0).(*models.Order), args.Error(1)
 }
 
-func (m *MockService) GetOrder(orderID string, userID string) (*models.Order, error) {
-	args := m.Called(orderID, userID)
+func (m *MockService) GetOrder(ctx context.Context, orderID string, userID string) (*models.Order, error) {
+	args := m.Called(ctx, orderID, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Order), args.Error(1)
 }
 
-func (m *MockService) GetUserOrders(userID string, page, limit int) ([]models.Order, int64, error) {
-	args := m.Called(userID, page, limit)
+func (m *MockService) GetInvoice(ctx context.Context, orderID, userID string) (*models.Order, []byte, error) {
+	args := m.Called(ctx, orderID, userID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.Order), args.Get(1).([]byte), args.Error(2)
+}
+
+func (m *MockService) GetUserOrders(ctx context.Context, userID string, page, limit int) ([]models.Order, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
 	return args.Get(0).([]models.Order), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockService) GetAllOrders(page, limit int, status, userID string) ([]models.Order, int64, error) {
-	args := m.Called(page, limit, status, userID)
+func (m *MockService) GetAllOrders(ctx context.Context, page, limit int, filters OrderFilters) ([]models.Order, int64, error) {
+	args := m.Called(ctx, page, limit, filters)
 	return args.Get(0).([]models.Order), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockService) UpdateOrderStatus(orderID string, status string) (*models.Order, error) {
-	args := m.Called(orderID, status)
+func (m *MockService) UpdateOrderStatus(ctx context.Context, orderID, status, adminID, note string) (*models.Order, error) {
+	args := m.Called(ctx, orderID, status, adminID, note)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Order), args.Error(1)
 }
 
-func (m *MockService) GetAllCustomers(page, limit int, search string) ([]models.User, int64, error) {
-	args := m.Called(page, limit, search)
+func (m *MockService) GetAllCustomers(ctx context.Context, page, limit int, search string) ([]models.User, int64, error) {
+	args := m.Called(ctx, page, limit, search)
 	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockService) GetCustomer(ctx context.Context, customerID string) (*CustomerDetail, error) {
+	args := m.Called(ctx, customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CustomerDetail), args.Error(1)
+}
+
+func (m *MockService) CancelOrder(ctx context.Context, orderID, userID string, req *CancelOrderRequest) (*models.Order, error) {
+	args := m.Called(ctx, orderID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockService) GetOrderHistory(ctx context.Context, orderID, userID string) ([]models.OrderStatusHistory, error) {
+	args := m.Called(ctx, orderID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OrderStatusHistory), args.Error(1)
+}
+
+func (m *MockService) SearchSerials(ctx context.Context, query string, page, limit int) ([]SerialRecord, int64, error) {
+	args := m.Called(ctx, query, page, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]SerialRecord), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockService) LookupWarranty(ctx context.Context, serial, userID string) (*SerialRecord, error) {
+	args := m.Called(ctx, serial, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*SerialRecord), args.Error(1)
+}
+
+func (m *MockService) CreateReturnRequest(ctx context.Context, orderID, userID string, req *CreateReturnRequest) ([]models.ReturnRequest, error) {
+	args := m.Called(ctx, orderID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ReturnRequest), args.Error(1)
+}
+
+func (m *MockService) GetOrderComments(ctx context.Context, orderID string) ([]models.OrderComment, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OrderComment), args.Error(1)
+}
+
+func (m *MockService) AddOrderComment(ctx context.Context, orderID, adminID string, req *CreateOrderCommentRequest) (*models.OrderComment, error) {
+	args := m.Called(ctx, orderID, adminID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OrderComment), args.Error(1)
+}
+
+func (m *MockService) UpdateOrderComment(ctx context.Context, orderID, commentID string, req *UpdateOrderCommentRequest) (*models.OrderComment, error) {
+	args := m.Called(ctx, orderID, commentID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OrderComment), args.Error(1)
+}
+
+func (m *MockService) DeleteOrderComment(ctx context.Context, orderID, commentID string) error {
+	return m.Called(ctx, orderID, commentID).Error(0)
+}
+
+func (m *MockService) CreateDraftOrder(ctx context.Context, adminID string, req *DraftOrderRequest) (*models.DraftOrder, error) {
+	args := m.Called(ctx, adminID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DraftOrder), args.Error(1)
+}
+
+func (m *MockService) UpdateDraftOrder(ctx context.Context, draftID string, req *DraftOrderRequest) (*models.DraftOrder, error) {
+	args := m.Called(ctx, draftID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DraftOrder), args.Error(1)
+}
+
+func (m *MockService) GetDraftOrder(ctx context.Context, draftID, userID string) (*models.DraftOrder, error) {
+	args := m.Called(ctx, draftID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DraftOrder), args.Error(1)
+}
+
+func (m *MockService) GetDraftOrders(ctx context.Context, page, limit int, filters DraftOrderFilters) ([]models.DraftOrder, int64, error) {
+	args := m.Called(ctx, page, limit, filters)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.DraftOrder), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockService) SendDraftOrderInvoice(ctx context.Context, draftID string) (*models.DraftOrder, error) {
+	args := m.Called(ctx, draftID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DraftOrder), args.Error(1)
+}
+
+func (m *MockService) CancelDraftOrder(ctx context.Context, draftID string) (*models.DraftOrder, error) {
+	args := m.Called(ctx, draftID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DraftOrder), args.Error(1)
+}
+
+func (m *MockService) CheckoutDraftOrder(ctx context.Context, draftID, userID string) (*models.Order, error) {
+	args := m.Called(ctx, draftID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockService) GetOrderEvents(ctx context.Context, orderID string) ([]models.OrderEvent, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OrderEvent), args.Error(1)
+}
+
+func (m *MockService) ReplayOrderEvents(ctx context.Context, orderID string, upTo int) (*OrderReplay, error) {
+	args := m.Called(ctx, orderID, upTo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*OrderReplay), args.Error(1)
+}
+
+func (m *MockService) RebuildOrderFromEvents(ctx context.Context, orderID string) (*models.Order, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockService) GetReturns(ctx context.Context, orderID, userID string) ([]models.ReturnRequest, error) {
+	args := m.Called(ctx, orderID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ReturnRequest), args.Error(1)
+}
+
+func (m *MockService) GetAllReturns(ctx context.Context, status string, page, limit int) ([]models.ReturnRequest, int64, error) {
+	args := m.Called(ctx, status, page, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.ReturnRequest), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockService) ApproveReturn(ctx context.Context, returnID string, req *ApproveReturnRequest, adminID string) (*models.ReturnRequest, error) {
+	args := m.Called(ctx, returnID, req, adminID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReturnRequest), args.Error(1)
+}
+
+func (m *MockService) RejectReturn(ctx context.Context, returnID string, req *RejectReturnRequest, adminID string) (*models.ReturnRequest, error) {
+	args := m.Called(ctx, returnID, req, adminID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReturnRequest), args.Error(1)
+}
+
+func (m *MockService) CompleteReturn(ctx context.Context, returnID string, req *CompleteReturnRequest, adminID string) (*models.ReturnRequest, error) {
+	args := m.Called(ctx, returnID, req, adminID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReturnRequest), args.Error(1)
+}
+
+func (m *MockService) GetReturnLabel(ctx context.Context, returnID, userID string) (*models.ReturnRequest, []byte, error) {
+	args := m.Called(ctx, returnID, userID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.ReturnRequest), args.Get(1).([]byte), args.Error(2)
+}
+
+func (m *MockService) UpdateShipmentStatus(ctx context.Context, orderID, shipmentID string, req *UpdateShipmentStatusRequest, adminID string) (*models.Order, error) {
+	args := m.Called(ctx, orderID, shipmentID, req, adminID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockService) CreateShipment(ctx context.Context, orderID string, req *CreateShipmentRequest, adminID string) (*models.Order, error) {
+	args := m.Called(ctx, orderID, req, adminID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockService) GetShipments(ctx context.Context, orderID, userID string) (*OrderTracking, error) {
+	args := m.Called(ctx, orderID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*OrderTracking), args.Error(1)
+}
+
+func (m *MockService) ConfirmDelivery(ctx context.Context, req *ConfirmDeliveryRequest) (*models.Order, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockService) GetReviewForm(ctx context.Context, orderID, token string) (*ReviewForm, error) {
+	args := m.Called(ctx, orderID, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ReviewForm), args.Error(1)
+}
+
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -215,7 +470,7 @@ func TestHandler_GetOrder(t *testing.T) {
 					Subtotal: 99.99,
 					Total:    99.99,
 				}
-				mockService.On("GetOrder", "test-order-id", "test-user-id").Return(order, nil)
+				mockService.On("GetOrder", mock.Anything, "test-order-id", "test-user-id").Return(order, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -223,7 +478,7 @@ func TestHandler_GetOrder(t *testing.T) {
 			name:    "order not found",
 			orderID: "non-existent-id",
 			mockSetup: func() {
-				mockService.On("GetOrder", "non-existent-id", "test-user-id").Return(nil, assert.AnError)
+				mockService.On("GetOrder", mock.Anything, "non-existent-id", "test-user-id").Return(nil, assert.AnError)
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
@@ -283,7 +538,7 @@ func TestHandler_GetUserOrders(t *testing.T) {
 						Total:    99.99,
 					},
 				}
-				mockService.On("GetUserOrders", "test-user-id", 1, 10).Return(orders, int64(1), nil)
+				mockService.On("GetUserOrders", mock.Anything, "test-user-id", 1, 10).Return(orders, int64(1), nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -292,7 +547,7 @@ func TestHandler_GetUserOrders(t *testing.T) {
 			queryParams: "",
 			mockSetup: func() {
 				orders := []models.Order{}
-				mockService.On("GetUserOrders", "test-user-id", 1, 10).Return(orders, int64(0), nil)
+				mockService.On("GetUserOrders", mock.Anything, "test-user-id", 1, 10).Return(orders, int64(0), nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -355,7 +610,7 @@ func TestHandler_UpdateOrderStatus(t *testing.T) {
 					Subtotal: 99.99,
 					Total:    99.99,
 				}
-				mockService.On("UpdateOrderStatus", "test-order-id", "processing").Return(order, nil)
+				mockService.On("UpdateOrderStatus", mock.Anything, "test-order-id", "processing", "admin-user-id", "").Return(order, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -490,7 +745,7 @@ func TestHandler_GetAllOrders(t *testing.T) {
 						Total:    99.99,
 					},
 				}
-				mockService.On("GetAllOrders", 1, 10, "pending", "test-user-id").Return(orders, int64(1), nil)
+				mockService.On("GetAllOrders", mock.Anything, 1, 10, OrderFilters{Status: "pending", UserID: "test-user-id"}).Return(orders, int64(1), nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -499,7 +754,7 @@ func TestHandler_GetAllOrders(t *testing.T) {
 			queryParams: "",
 			mockSetup: func() {
 				orders := []models.Order{}
-				mockService.On("GetAllOrders", 1, 10, "", "").Return(orders, int64(0), nil)
+				mockService.On("GetAllOrders", mock.Anything, 1, 10, OrderFilters{}).Return(orders, int64(0), nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -507,10 +762,32 @@ func TestHandler_GetAllOrders(t *testing.T) {
 			name:        "service error",
 			queryParams: "?page=1&limit=10",
 			mockSetup: func() {
-				mockService.On("GetAllOrders", 1, 10, "", "").Return([]models.Order{}, int64(0), assert.AnError)
+				mockService.On("GetAllOrders", mock.Anything, 1, 10, OrderFilters{}).Return([]models.Order{}, int64(0), assert.AnError)
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name:        "search with inclusive date range",
+			queryParams: "?q=alice&sku=HS-1&from=2024-03-01&to=2024-03-31",
+			mockSetup: func() {
+				from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+				to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+				mockService.On("GetAllOrders", mock.Anything, 1, 10, OrderFilters{Query: "alice", SKU: "HS-1", From: &from, To: &to}).Return([]models.Order{}, int64(0), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid date",
+			queryParams:    "?from=01-03-2024",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "from after to",
+			queryParams:    "?from=2024-03-02&to=2024-03-01",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -569,7 +846,7 @@ func TestHandler_GetAllCustomers(t *testing.T) {
 						IsActive:  true,
 					},
 				}
-				mockService.On("GetAllCustomers", 1, 10, "john").Return(customers, int64(1), nil)
+				mockService.On("GetAllCustomers", mock.Anything, 1, 10, "john").Return(customers, int64(1), nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -578,7 +855,7 @@ func TestHandler_GetAllCustomers(t *testing.T) {
 			queryParams: "",
 			mockSetup: func() {
 				customers := []models.User{}
-				mockService.On("GetAllCustomers", 1, 10, "").Return(customers, int64(0), nil)
+				mockService.On("GetAllCustomers", mock.Anything, 1, 10, "").Return(customers, int64(0), nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -586,7 +863,7 @@ func TestHandler_GetAllCustomers(t *testing.T) {
 			name:        "service error",
 			queryParams: "?page=1&limit=10",
 			mockSetup: func() {
-				mockService.On("GetAllCustomers", 1, 10, "").Return([]models.User{}, int64(0), assert.AnError)
+				mockService.On("GetAllCustomers", mock.Anything, 1, 10, "").Return([]models.User{}, int64(0), assert.AnError)
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
@@ -596,7 +873,7 @@ func TestHandler_GetAllCustomers(t *testing.T) {
 			mockSetup: func() {
 				customers := []models.User{}
 				// Should use corrected values: page=1, limit=10
-				mockService.On("GetAllCustomers", 1, 10, "").Return(customers, int64(0), nil)
+				mockService.On("GetAllCustomers", mock.Anything, 1, 10, "").Return(customers, int64(0), nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -625,3 +902,76 @@ func TestHandler_GetAllCustomers(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_ConfirmDelivery(t *testing.T) {
+	mockService := new(MockService)
+	router := setupTestRouter()
+	SetupDeliveryRoutes(router.Group("/api"), NewHandler(mockService), "carrier-secret")
+
+	confirm := func(token string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/api/deliveries/confirm", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(DeliveryTokenHeader, token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	valid := map[string]interface{}{"orderId": "order-1", "shipmentId": "shipment-1", "source": "carrier", "otpVerified": true}
+
+	assert.Equal(t, http.StatusUnauthorized, confirm("", valid).Code)
+	assert.Equal(t, http.StatusUnauthorized, confirm("wrong", valid).Code)
+	assert.Equal(t, http.StatusBadRequest, confirm("carrier-secret", map[string]interface{}{"orderId": "order-1", "shipmentId": "shipment-1", "source": "customer"}).Code)
+	assert.Equal(t, http.StatusBadRequest, confirm("carrier-secret", map[string]interface{}{"orderId": "order-1", "shipmentId": "shipment-1", "source": "driver", "photoUrl": "not a url"}).Code)
+
+	mockService.On("ConfirmDelivery", mock.Anything, mock.MatchedBy(func(req *ConfirmDeliveryRequest) bool {
+		return req.OrderID == "order-1" && req.OTPVerified
+	})).Return(&models.Order{ID: "order-1", Status: "delivered"}, nil).Once()
+	assert.Equal(t, http.StatusOK, confirm("carrier-secret", valid).Code)
+
+	mockService.On("ConfirmDelivery", mock.Anything, mock.Anything).Return(nil, ErrInvalidShipmentStatus).Once()
+	assert.Equal(t, http.StatusConflict, confirm("carrier-secret", valid).Code)
+	mockService.AssertExpectations(t)
+
+	t.Run("disabled without a secret", func(t *testing.T) {
+		router := setupTestRouter()
+		SetupDeliveryRoutes(router.Group("/api"), NewHandler(mockService), "")
+		req, _ := http.NewRequest("POST", "/api/deliveries/confirm", bytes.NewBufferString(`{}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestHandler_CancelOrder(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupTestRouter()
+	router.POST("/api/orders/:id/cancel", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		handler.CancelOrder(c)
+	})
+
+	cancel := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/orders/order-1/cancel", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	mockService.On("CancelOrder", mock.Anything, "order-1", "user-1", &CancelOrderRequest{}).
+		Return(&models.Order{ID: "order-1", Status: "cancelled"}, nil).Once()
+	assert.Equal(t, http.StatusOK, cancel("").Code)
+
+	mockService.On("CancelOrder", mock.Anything, "order-1", "user-1", &CancelOrderRequest{Reason: "changed my mind"}).
+		Return(nil, fmt.Errorf("%w: gateway down", payments.ErrRefundFailed)).Once()
+	w := cancel(`{"reason":"changed my mind"}`)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, w.Body.String(), "REFUND_FAILED")
+
+	assert.Equal(t, http.StatusBadRequest, cancel(`{"reason":`).Code)
+	mockService.AssertExpectations(t)
+}
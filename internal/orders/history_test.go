@@ -0,0 +1,92 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_GetOrderHistory(t *testing.T) {
+	db, service, helpers, _ := setupPolicyTest(t)
+
+	user := helpers.CreateTestUser(t, "history@example.com")
+	category := createPolicyCategory(t, db, helpers, "history", models.DefaultReturnPolicy())
+	product := createPolicyProduct(t, db, category.ID, "HISTORY-SKU")
+	order := helpers.CreateTestOrder(t, user.ID, "pending")
+	helpers.CreateTestOrderItem(t, order.ID, product.ID, 1, 10)
+
+	_, err := service.UpdateOrderStatus(context.Background(), order.ID, "processing", "admin-1", "stock confirmed")
+	require.NoError(t, err)
+	_, err = service.UpdateOrderStatus(context.Background(), order.ID, "processing", "admin-1", "")
+	require.NoError(t, err)
+	_, err = service.CancelOrder(context.Background(), order.ID, user.ID, &CancelOrderRequest{Reason: "found it cheaper"})
+	require.NoError(t, err)
+
+	history, err := service.GetOrderHistory(context.Background(), order.ID, "")
+	require.NoError(t, err)
+	require.Len(t, history, 2, "unchanged statuses are not recorded")
+	assert.Equal(t, "pending", history[0].OldStatus)
+	assert.Equal(t, "processing", history[0].NewStatus)
+	assert.Equal(t, models.OrderActorAdmin, history[0].ActorRole)
+	require.NotNil(t, history[0].ActorID)
+	assert.Equal(t, "admin-1", *history[0].ActorID)
+	assert.Equal(t, "stock confirmed", history[0].Note)
+	assert.Equal(t, "cancelled", history[1].NewStatus)
+	assert.Equal(t, models.OrderActorCustomer, history[1].ActorRole)
+	assert.Equal(t, "found it cheaper", history[1].Note)
+
+	t.Run("customers see their own orders without staff IDs", func(t *testing.T) {
+		history, err := service.GetOrderHistory(context.Background(), order.ID, user.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Nil(t, history[0].ActorID)
+		assert.Equal(t, models.OrderActorAdmin, history[0].ActorRole)
+		require.NotNil(t, history[1].ActorID)
+		assert.Equal(t, user.ID, *history[1].ActorID)
+
+		_, err = service.GetOrderHistory(context.Background(), order.ID, "someone-else")
+		assert.EqualError(t, err, "order not found")
+	})
+}
+
+func TestHandler_GetOrderHistory(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupTestRouter()
+	router.GET("/api/orders/:id/history", func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Set("user_role", c.GetHeader("X-Test-Role"))
+		handler.GetOrderHistory(c)
+	})
+
+	get := func(userID, role string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/orders/order-1/history", nil)
+		req.Header.Set("X-Test-User", userID)
+		req.Header.Set("X-Test-Role", role)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	history := []models.OrderStatusHistory{{OrderID: "order-1", NewStatus: "pending", ActorRole: models.OrderActorCustomer}}
+	mockService.On("GetOrderHistory", mock.Anything, "order-1", "").Return(history, nil).Once()
+	assert.Equal(t, http.StatusOK, get("admin-1", "admin").Code)
+
+	mockService.On("GetOrderHistory", mock.Anything, "order-1", "user-1").Return(history, nil).Once()
+	assert.Equal(t, http.StatusOK, get("user-1", "customer").Code)
+
+	mockService.On("GetOrderHistory", mock.Anything, "order-1", "user-2").Return(nil, errors.New("order not found")).Once()
+	w := get("user-2", "customer")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "ORDER_NOT_FOUND")
+	mockService.AssertExpectations(t)
+}
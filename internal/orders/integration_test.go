@@ -32,6 +32,15 @@ func setupIntegrationTest(t *testing.T) (*gorm.DB, *gin.Engine, *auth.Service, *
 		&models.Product{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.Shipment{},
+		&models.ShipmentItem{},
+		&models.OrderStatusHistory{},
+		&models.OrderComment{},
+		&models.OrderItemSerial{},
+		&models.Setting{},
+		&models.TaxRule{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
 	)
 	require.NoError(t, err)
 
@@ -53,7 +62,7 @@ func setupIntegrationTest(t *testing.T) (*gorm.DB, *gin.Engine, *auth.Service, *
 	ordersHandler := NewHandler(ordersService)
 
 	// Setup routes
-	SetupRoutes(router, ordersHandler, authService)
+	SetupRoutes(router.Group("/api"), ordersHandler, authService)
 
 	return db, router, authService, mockCartService
 }
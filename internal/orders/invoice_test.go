@@ -0,0 +1,74 @@
+package orders
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderService_CreateOrderSendsConfirmation(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+
+	user := helpers.CreateTestUser(t, "invoice@example.com")
+	other := helpers.CreateTestUser(t, "other@example.com")
+	category := helpers.CreateTestCategory(t, "invoices")
+	product := createPolicyProduct(t, db, category.ID, "INVOICE-SKU")
+
+	cart := &models.Cart{SessionID: "invoice-session", Items: []models.CartItem{{ProductID: product.ID, Quantity: 2, Price: 10}}}
+	cartService.On("GetCartWithProducts", mock.Anything, "invoice-session").Return(cart, nil)
+	cartService.On("ClearCart", mock.Anything, "invoice-session").Return(nil)
+
+	order, err := service.CreateOrder(context.Background(), user.ID, helpers.GetValidCreateOrderRequest("invoice-session"))
+	require.NoError(t, err)
+	emailService.AssertCalled(t, "SendOrderConfirmation", mock.MatchedBy(func(confirmed *models.Order) bool {
+		return confirmed.ID == order.ID && confirmed.User.Email == "invoice@example.com" && len(confirmed.Items) == 1
+	}))
+
+	invoiced, invoice, err := service.GetInvoice(context.Background(), order.ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, order.ID, invoiced.ID)
+	assert.Contains(t, string(invoice), "(INVOICE) Tj")
+	assert.Contains(t, string(invoice), "INVOICE-SKU")
+
+	_, _, err = service.GetInvoice(context.Background(), order.ID, other.ID)
+	assert.EqualError(t, err, "order not found", "customers only get the invoices of their own orders")
+}
+
+func TestHandler_GetInvoice(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupTestRouter()
+
+	router.GET("/api/orders/:id/invoice", func(c *gin.Context) {
+		c.Set("user_id", "test-user-id")
+		c.Set("user_role", "customer")
+		handler.GetInvoice(c)
+	})
+
+	order := &models.Order{ID: "3f2a9c1e-77b0-4d1a-9e2f-0c5d8e6a1b2c"}
+	mockService.On("GetInvoice", mock.Anything, order.ID, "test-user-id").Return(order, []byte("%PDF-1.4"), nil)
+	mockService.On("GetInvoice", mock.Anything, "missing", "test-user-id").Return(nil, nil, assert.AnError)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/orders/"+order.ID+"/invoice", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=invoice-INV-3F2A9C1E77B0.pdf", w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "%PDF-1.4", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/orders/missing/invoice", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,71 @@
+package orders
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/payments"
+	"ecommerce-website/internal/sms"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reviewRequestInterval is how often delivered orders are checked for due review requests
+const reviewRequestInterval = time.Hour
+
+// Module wires checkout, order management and fulfillment into the application
+type Module struct {
+	service        *Service
+	handler        *Handler
+	authService    *auth.Service
+	deliverySecret string
+}
+
+// NewModule creates the orders module. Status updates are also texted when SMS_DRIVER is set; an invalid SMS
+// configuration is logged and leaves texts off. Draft orders are completed when their order is paid.
+func NewModule(deps app.Deps) app.Module {
+	service := NewServiceWithReviewLinks(deps.DB, deps.Config.JWTSecret)
+	service.refunds = payments.NewService(deps.DB, deps.Config.RazorpayKeyID, deps.Config.RazorpaySecret)
+	notifier, err := sms.NewNotifier(sms.Config{
+		Driver:     deps.Config.SMSDriver,
+		AccountID:  deps.Config.SMSAccountID,
+		AuthToken:  deps.Config.SMSAuthToken,
+		From:       deps.Config.SMSFrom,
+		TemplateID: deps.Config.SMSTemplateID,
+		BaseURL:    deps.Config.SMSBaseURL,
+	})
+	if err != nil {
+		logger.Named("sms").Warn("Order texts disabled by invalid configuration", map[string]interface{}{"error": err.Error()})
+	}
+	service.WithSMS(notifier)
+	SubscribeDraftOrders()
+	if deps.Outbox != nil {
+		service.WithOutbox(deps.Outbox)
+	}
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth, deliverySecret: deps.Config.DeliveryWebhookSecret}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "orders"
+}
+
+// Models returns the order tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.Order{}, &models.OrderItem{}, &models.Shipment{}, &models.ShipmentItem{}, &models.ReturnRequest{}, &models.OrderEvent{}, &models.OrderStatusHistory{}, &models.OrderComment{}, &models.OrderItemSerial{}, &models.DraftOrder{}, &models.DraftOrderItem{}}
+}
+
+// StartJobs sends review requests for delivered orders in the background
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartReviewRequestSweeper(ctx, reviewRequestInterval)
+}
+
+// RegisterAPIRoutes sets up the customer, admin and delivery confirmation order routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+	SetupDeliveryRoutes(api, m.handler, m.deliverySecret)
+}
@@ -0,0 +1,61 @@
+package orders
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/outbox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier keeps the texts it is asked to send
+type recordingNotifier struct {
+	sent []string
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, to, body string) error {
+	n.sent = append(n.sent, to+": "+body)
+	return nil
+}
+
+func TestOrderService_TextsStatusUpdates(t *testing.T) {
+	db, service, helpers, emailService := setupPolicyTest(t)
+	require.NoError(t, db.AutoMigrate(&models.OutboxMessage{}))
+	notifier := &recordingNotifier{}
+	service.WithSMS(notifier)
+	ctx := context.Background()
+
+	texted := helpers.CreateTestUser(t, "texted@example.com")
+	require.NoError(t, db.Model(texted).Updates(map[string]interface{}{"phone": "+919876543210", "order_sms_opt_in": true}).Error)
+	emailed := helpers.CreateTestUser(t, "emailed@example.com")
+	require.NoError(t, db.Model(emailed).Update("phone", "+919800000000").Error)
+
+	order := helpers.CreateTestOrder(t, texted.ID, "paid")
+	_, err := service.UpdateOrderStatus(ctx, order.ID, "processing", "admin-1", "")
+	require.NoError(t, err)
+	other := helpers.CreateTestOrder(t, emailed.ID, "paid")
+	_, err = service.UpdateOrderStatus(ctx, other.ID, "processing", "admin-1", "")
+	require.NoError(t, err)
+
+	require.Len(t, notifier.sent, 1, "only customers who opted in are texted")
+	assert.Equal(t, "+919876543210: Order #"+order.ID[:8]+": being prepared for shipment.", notifier.sent[0])
+	emailService.AssertNumberOfCalls(t, "SendOrderStatusUpdate", 2)
+
+	// With an outbox the text is recorded with the change and sent by the dispatcher
+	dispatcher := outbox.NewDispatcher(db)
+	service.WithOutbox(dispatcher)
+	_, err = service.UpdateOrderStatus(ctx, order.ID, "shipped", "admin-1", "")
+	require.NoError(t, err)
+	assert.Len(t, notifier.sent, 1)
+
+	var topics []string
+	require.NoError(t, db.Model(&models.OutboxMessage{}).Order("topic").Pluck("topic", &topics).Error)
+	assert.Equal(t, []string{"email.order_status", "sms.order_status"}, topics)
+	_, err = dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	require.Len(t, notifier.sent, 2)
+	assert.Contains(t, notifier.sent[1], "shipped and on its way to you.")
+}
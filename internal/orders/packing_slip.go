@@ -0,0 +1,81 @@
+package orders
+
+import (
+	"fmt"
+	"strings"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/pdf"
+)
+
+// PackingSlipHidesPrices reports whether the packing slip of an order must omit prices.
+// Gift orders ship with a price-hidden slip; the purchaser's invoice is unaffected.
+func PackingSlipHidesPrices(order *models.Order) bool {
+	return order.IsGift
+}
+
+// RenderPackingSlip renders the packing slip of an order as a PDF document
+func RenderPackingSlip(order *models.Order) []byte {
+	return pdf.Render(packingSlipLines(order, PackingSlipHidesPrices(order)), pdf.Letter)
+}
+
+// packingSlipLines lays out the text content of a packing slip
+func packingSlipLines(order *models.Order, hidePrices bool) []string {
+	title := "PACKING SLIP"
+	if hidePrices {
+		title = "GIFT RECEIPT"
+	}
+
+	addr := order.ShippingAddress
+	lines := []string{
+		title,
+		"",
+		fmt.Sprintf("Order #%s", order.ID),
+		fmt.Sprintf("Order Date: %s", order.CreatedAt.Format("January 2, 2006")),
+		"",
+		"Ship To:",
+		fmt.Sprintf("  %s %s", addr.FirstName, addr.LastName),
+		"  " + addr.Address1,
+	}
+	if addr.Address2 != nil && *addr.Address2 != "" {
+		lines = append(lines, "  "+*addr.Address2)
+	}
+	lines = append(lines,
+		fmt.Sprintf("  %s, %s %s", addr.City, addr.State, addr.PostalCode),
+		"  "+addr.Country,
+		"",
+		"Items:",
+	)
+
+	for _, item := range order.Items {
+		line := fmt.Sprintf("  %s (SKU: %s)  Qty: %d", item.Product.Name, item.Product.SKU, item.Quantity)
+		if !hidePrices {
+			line += fmt.Sprintf("  @ %.2f = %.2f", item.Price, item.Total)
+		}
+		lines = append(lines, line)
+		for _, serial := range item.Serials {
+			lines = append(lines, "    Serial: "+serial.Serial)
+		}
+	}
+
+	lines = append(lines, "")
+	if hidePrices {
+		if order.GiftMessage != nil && *order.GiftMessage != "" {
+			lines = append(lines, "Gift Message:")
+			for _, messageLine := range strings.Split(strings.ReplaceAll(*order.GiftMessage, "\r\n", "\n"), "\n") {
+				lines = append(lines, "  "+messageLine)
+			}
+			lines = append(lines, "")
+		}
+		lines = append(lines, "Prices are not shown because this order was sent as a gift.")
+	} else {
+		lines = append(lines,
+			fmt.Sprintf("Subtotal: %.2f", order.Subtotal),
+			fmt.Sprintf("Tax: %.2f", order.Tax),
+			fmt.Sprintf("Shipping: %.2f", order.Shipping),
+			fmt.Sprintf("Total: %.2f", order.Total),
+		)
+	}
+
+	return lines
+}
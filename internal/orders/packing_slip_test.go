@@ -0,0 +1,84 @@
+package orders
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func packingSlipTestOrder(isGift bool) *models.Order {
+	message := "Happy birthday (from Sam)"
+	return &models.Order{
+		ID:          "slip-order-id",
+		UserID:      "test-user-id",
+		Subtotal:    120,
+		Total:       120,
+		IsGift:      isGift,
+		GiftMessage: &message,
+		CreatedAt:   time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		ShippingAddress: models.OrderAddress{
+			FirstName: "Ana", LastName: "Lee", Address1: "1 Main St",
+			City: "Pune", State: "MH", PostalCode: "411001", Country: "IN",
+		},
+		Items: []models.OrderItem{
+			{Product: models.Product{Name: "Mug", SKU: "MUG-1"}, Quantity: 2, Price: 60, Total: 120},
+		},
+	}
+}
+
+func TestPackingSlipLines(t *testing.T) {
+	order := packingSlipTestOrder(false)
+	standard := strings.Join(packingSlipLines(order, PackingSlipHidesPrices(order)), "\n")
+	assert.Contains(t, standard, "PACKING SLIP")
+	assert.Contains(t, standard, "@ 60.00 = 120.00")
+	assert.Contains(t, standard, "Total: 120.00")
+	assert.NotContains(t, standard, "Happy birthday")
+
+	gift := packingSlipTestOrder(true)
+	hidden := strings.Join(packingSlipLines(gift, PackingSlipHidesPrices(gift)), "\n")
+	assert.Contains(t, hidden, "GIFT RECEIPT")
+	assert.Contains(t, hidden, "Mug (SKU: MUG-1)  Qty: 2")
+	assert.Contains(t, hidden, "Happy birthday")
+	assert.NotContains(t, hidden, "60.00")
+	assert.NotContains(t, hidden, "120.00")
+
+	multiline := "Dear Ana,\r\nHappy birthday!"
+	gift.GiftMessage = &multiline
+	assert.Contains(t, packingSlipLines(gift, true), "  Happy birthday!", "every message line is indented")
+}
+
+func TestHandler_GetPackingSlip(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupTestRouter()
+
+	router.GET("/api/orders/:id/packing-slip", func(c *gin.Context) {
+		c.Set("user_id", "test-user-id")
+		c.Set("user_role", "customer")
+		handler.GetPackingSlip(c)
+	})
+
+	mockService.On("GetOrder", mock.Anything, "slip-order-id", "test-user-id").Return(packingSlipTestOrder(true), nil)
+	mockService.On("GetOrder", mock.Anything, "missing", "test-user-id").Return(nil, assert.AnError)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/orders/slip-order-id/packing-slip", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "GIFT RECEIPT")
+	assert.NotContains(t, w.Body.String(), "60.00")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/orders/missing/packing-slip", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,286 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/email"
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/orderevents"
+	"ecommerce-website/internal/orderhistory"
+	"ecommerce-website/internal/payments"
+	"ecommerce-website/internal/webhooks"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrderNotCancellable      = errors.New("order can no longer be cancelled")
+	ErrCancellationWindowClosed = errors.New("cancellation window has closed")
+	ErrOrderNotDelivered        = errors.New("only delivered orders can be returned")
+	ErrInvalidReturnItem        = errors.New("invalid return item")
+	ErrItemNotReturnable        = errors.New("item is not returnable")
+	ErrReturnWindowClosed       = errors.New("return window has closed")
+)
+
+// cancellableStatuses are the order statuses a customer may still cancel from, before the order ships
+var cancellableStatuses = map[string]bool{
+	"pending":    true,
+	"paid":       true,
+	"processing": true,
+}
+
+// Refunder refunds paid orders their customers cancel and items they return. A refund is claimed inside the
+// cancellation or return transaction and issued with the payment provider once that committed.
+type Refunder interface {
+	// ClaimCancellationRefund claims a refund of the order inside the cancellation transaction tx; it returns
+	// nil for orders without a captured payment
+	ClaimCancellationRefund(tx *gorm.DB, orderID, userID, reason string) (*payments.RefundClaim, error)
+	// ClaimReturnRefund claims a refund of returned units of an order item inside the return transaction tx,
+	// restocking them when restock is set; it returns nil for orders without a captured payment
+	ClaimReturnRefund(tx *gorm.DB, orderID, orderItemID string, quantity int, restock bool, adminID, reason string) (*payments.RefundClaim, error)
+	// IssueRefund sends a claimed refund to the payment provider; record runs in the transaction recording it
+	IssueRefund(claim *payments.RefundClaim, record func(tx *gorm.DB, refund *models.Refund) error) (*models.Refund, error)
+}
+
+// CancelOrderRequest represents the request to cancel an order
+type CancelOrderRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// cancellableStatusList returns cancellableStatuses as a slice for IN queries
+func cancellableStatusList() []string {
+	statuses := make([]string, 0, len(cancellableStatuses))
+	for status := range cancellableStatuses {
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// CreateReturnRequest represents the request to return items of a delivered order
+type CreateReturnRequest struct {
+	Items []ReturnItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// ReturnItemRequest is one order item being returned
+type ReturnItemRequest struct {
+	OrderItemID string `json:"orderItemId" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required,min=1"`
+	Reason      string `json:"reason" binding:"max=500"`
+}
+
+// cancellationDeadline returns the earliest cancellation deadline across the categories of the order's items
+func cancellationDeadline(order *models.Order) time.Time {
+	deadline := time.Time{}
+	for _, item := range order.Items {
+		itemDeadline := item.Product.Category.ReturnPolicy.CancellationDeadline(order.CreatedAt)
+		if deadline.IsZero() || itemDeadline.Before(deadline) {
+			deadline = itemDeadline
+		}
+	}
+	return deadline
+}
+
+// deliveredAt returns when the order was delivered, falling back to its last update for orders delivered before tracking
+func deliveredAt(order *models.Order) time.Time {
+	if order.DeliveredAt != nil {
+		return *order.DeliveredAt
+	}
+	return order.UpdatedAt
+}
+
+// loadOrderWithPolicies loads an order of the user with the category policy of every item
+func (s *Service) loadOrderWithPolicies(db *gorm.DB, orderID, userID string) (*models.Order, error) {
+	var order models.Order
+	if err := db.Preload("Items.Product.Category").Preload("User").
+		Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	return &order, nil
+}
+
+// CancelOrder cancels a customer's order if its status and the strictest category cancellation cutoff allow it,
+// restores its stock and refunds it if it was paid. The refund is sent to the payment provider once the
+// cancellation committed; one the provider refuses leaves the order cancelled and is marked failed for an
+// admin to refund again.
+func (s *Service) CancelOrder(ctx context.Context, orderID, userID string, req *CancelOrderRequest) (*models.Order, error) {
+	order, err := s.loadOrderWithPolicies(s.db.WithContext(ctx), orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cancellableStatuses[order.Status] {
+		return nil, fmt.Errorf("%w: order is %s", ErrOrderNotCancellable, order.Status)
+	}
+	if deadline := cancellationDeadline(order); time.Now().After(deadline) {
+		return nil, fmt.Errorf("%w: orders could be cancelled until %s", ErrCancellationWindowClosed, deadline.Format(time.RFC3339))
+	}
+
+	updates := map[string]interface{}{"status": "cancelled"}
+	var reason *string
+	if req != nil && req.Reason != "" {
+		reason = &req.Reason
+		updates["cancellation_reason"] = req.Reason
+	}
+
+	oldStatus := order.Status
+	recordEvents := orderevents.Enabled(context.Background(), s.settings)
+	var claim *payments.RefundClaim
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Re-check the status in the update itself so a concurrent cancellation or status change
+		// between the read above and this write cannot restore the same stock twice
+		result := tx.Model(&models.Order{}).
+			Where("id = ? AND status IN ?", order.ID, cancellableStatusList()).
+			Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to cancel order: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: order status changed", ErrOrderNotCancellable)
+		}
+
+		// Put the cancelled quantities back in stock
+		for _, item := range order.Items {
+			if err := tx.Model(&models.Product{}).Where("id = ?", item.ProductID).
+				Update("inventory", gorm.Expr("inventory + ?", item.Quantity)).Error; err != nil {
+				return fmt.Errorf("failed to restore inventory: %w", err)
+			}
+			if err := eventbus.PublishInventoryChanged(tx, item.ProductID, item.Quantity, eventbus.InventoryOrderCancelled); err != nil {
+				return err
+			}
+		}
+		if err := syncShipments(tx, order.ID, "cancelled"); err != nil {
+			return err
+		}
+		note := "cancelled by customer"
+		if reason != nil {
+			note = *reason
+		}
+		if err := orderhistory.Record(tx, order.ID, oldStatus, "cancelled", orderhistory.Customer(userID), note); err != nil {
+			return err
+		}
+		if err := webhooks.PublishOrder(tx, order.ID, oldStatus); err != nil {
+			return err
+		}
+		if err := s.queueStatusNotifications(tx, order.ID, oldStatus, "cancelled"); err != nil {
+			return err
+		}
+		if recordEvents {
+			if err := orderevents.Append(tx, order.ID, orderevents.TypeOrderStatusChanged, orderevents.OrderStatusChanged{
+				From: oldStatus, To: "cancelled", Reason: "cancelled by customer", CascadeShipments: true,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if s.refunds != nil {
+			var err error
+			claim, err = s.refunds.ClaimCancellationRefund(tx, order.ID, userID, note)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	order.Status = "cancelled"
+	order.CancellationReason = reason
+
+	var refund *models.Refund
+	if claim != nil {
+		refund, err = s.refunds.IssueRefund(claim, func(tx *gorm.DB, refund *models.Refund) error {
+			if s.outbox == nil {
+				return nil
+			}
+			return email.QueueRefundNotification(tx, order.ID, refund.ID)
+		})
+		if err != nil {
+			logger.Named("orders").Error("Failed to refund cancelled order", err, map[string]interface{}{"order_id": order.ID, "refund_id": claim.Refund.ID})
+		}
+	}
+
+	// With an outbox the notifications were recorded with the cancellation
+	if s.outbox != nil {
+		s.outbox.Notify()
+		return order, nil
+	}
+	s.sendStatusNotifications(order, oldStatus, order.Status)
+	if refund != nil {
+		if err := s.emailService.SendRefundNotification(order, refund); err != nil {
+			logger.Named("orders").Warn("Failed to send refund email", map[string]interface{}{"order_id": order.ID, "refund_id": refund.ID, "error": err.Error()})
+		}
+	}
+
+	return order, nil
+}
+
+// CreateReturnRequest records a return of delivered items, enforcing the category return policy of each item
+func (s *Service) CreateReturnRequest(ctx context.Context, orderID, userID string, req *CreateReturnRequest) ([]models.ReturnRequest, error) {
+	order, err := s.loadOrderWithPolicies(s.db.WithContext(ctx), orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != "delivered" {
+		return nil, ErrOrderNotDelivered
+	}
+
+	items := make(map[string]models.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		items[item.ID] = item
+	}
+
+	// Quantities already requested for return count against what is left to return, unless they were rejected
+	var existing []models.ReturnRequest
+	if err := s.db.WithContext(ctx).Where("order_id = ? AND status <> ?", order.ID, models.ReturnStatusRejected).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to get existing returns: %w", err)
+	}
+	returned := make(map[string]int)
+	for _, r := range existing {
+		returned[r.OrderItemID] += r.Quantity
+	}
+
+	now := time.Now()
+	var returns []models.ReturnRequest
+	for _, requested := range req.Items {
+		item, ok := items[requested.OrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not part of this order", ErrInvalidReturnItem, requested.OrderItemID)
+		}
+
+		policy := item.Product.Category.ReturnPolicy
+		if !policy.Returnable {
+			return nil, fmt.Errorf("%w: %s", ErrItemNotReturnable, item.Product.Name)
+		}
+		if deadline := policy.ReturnDeadline(deliveredAt(order)); now.After(deadline) {
+			return nil, fmt.Errorf("%w: %s could be returned until %s", ErrReturnWindowClosed, item.Product.Name, deadline.Format(time.RFC3339))
+		}
+
+		returned[item.ID] += requested.Quantity
+		if returned[item.ID] > item.Quantity {
+			return nil, fmt.Errorf("%w: cannot return more than %d of %s", ErrInvalidReturnItem, item.Quantity, item.Product.Name)
+		}
+
+		returns = append(returns, models.ReturnRequest{
+			OrderID:     order.ID,
+			OrderItemID: item.ID,
+			UserID:      userID,
+			Quantity:    requested.Quantity,
+			Reason:      requested.Reason,
+			Status:      models.ReturnStatusRequested,
+		})
+	}
+
+	if err := s.db.WithContext(ctx).Create(&returns).Error; err != nil {
+		return nil, fmt.Errorf("failed to create return request: %w", err)
+	}
+
+	return returns, nil
+}
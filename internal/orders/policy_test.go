@@ -0,0 +1,342 @@
+package orders
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/payments"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupPolicyTest(t *testing.T) (*gorm.DB, *Service, *TestHelpers, *MockEmailService) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Category{},
+		&models.Product{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.Shipment{},
+		&models.ShipmentItem{},
+		&models.OrderStatusHistory{},
+		&models.OrderComment{},
+		&models.DraftOrder{},
+		&models.DraftOrderItem{},
+		&models.OrderItemSerial{},
+		&models.Setting{},
+		&models.TaxRule{},
+		&models.ShippingMethod{},
+		&models.DeliveryZone{},
+		&models.Address{},
+		&models.ReturnRequest{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.Promotion{},
+		&models.PromotionCode{},
+	))
+
+	emailService := &MockEmailService{}
+	emailService.On("SendOrderConfirmation", mock.Anything).Return(nil)
+	emailService.On("SendOrderStatusUpdate", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	return db, NewServiceWithDependencies(db, &MockCartService{}, emailService), NewTestHelpers(db), emailService
+}
+
+// createPolicyCategory creates a category and stores its policy with zero values intact
+func createPolicyCategory(t *testing.T, db *gorm.DB, helpers *TestHelpers, name string, policy models.ReturnPolicy) *models.Category {
+	category := helpers.CreateTestCategory(t, name)
+	require.NoError(t, db.Model(category).Updates(map[string]interface{}{
+		"policy_returnable":                policy.Returnable,
+		"policy_return_window_days":        policy.ReturnWindowDays,
+		"policy_cancellation_cutoff_hours": policy.CancellationCutoffHours,
+	}).Error)
+	return category
+}
+
+func createPolicyProduct(t *testing.T, db *gorm.DB, categoryID, sku string) *models.Product {
+	product := &models.Product{Name: sku, SKU: sku, Price: 10, Inventory: 5, IsActive: true, CategoryID: categoryID}
+	require.NoError(t, db.Create(product).Error)
+	return product
+}
+
+func TestService_CancelOrder(t *testing.T) {
+	db, service, helpers, _ := setupPolicyTest(t)
+
+	user := helpers.CreateTestUser(t, "cancel@example.com")
+	standard := createPolicyCategory(t, db, helpers, "standard", models.DefaultReturnPolicy())
+	strict := createPolicyCategory(t, db, helpers, "strict", models.ReturnPolicy{Returnable: true, ReturnWindowDays: 7, CancellationCutoffHours: 1})
+	product := createPolicyProduct(t, db, standard.ID, "STANDARD-SKU")
+	strictProduct := createPolicyProduct(t, db, strict.ID, "STRICT-SKU")
+
+	t.Run("within cutoff restores inventory", func(t *testing.T) {
+		order := helpers.CreateTestOrder(t, user.ID, "pending")
+		helpers.CreateTestOrderItem(t, order.ID, product.ID, 2, 10)
+
+		cancelled, err := service.CancelOrder(context.Background(), order.ID, user.ID, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", cancelled.Status)
+
+		var reloaded models.Product
+		require.NoError(t, db.First(&reloaded, "id = ?", product.ID).Error)
+		assert.Equal(t, 7, reloaded.Inventory)
+	})
+
+	t.Run("strictest category cutoff applies", func(t *testing.T) {
+		order := helpers.CreateTestOrder(t, user.ID, "pending")
+		helpers.CreateTestOrderItem(t, order.ID, product.ID, 1, 10)
+		helpers.CreateTestOrderItem(t, order.ID, strictProduct.ID, 1, 10)
+		require.NoError(t, db.Model(order).UpdateColumn("created_at", time.Now().Add(-2*time.Hour)).Error)
+
+		_, err := service.CancelOrder(context.Background(), order.ID, user.ID, nil)
+		assert.ErrorIs(t, err, ErrCancellationWindowClosed)
+	})
+
+	t.Run("shipped orders cannot be cancelled", func(t *testing.T) {
+		order := helpers.CreateTestOrder(t, user.ID, "shipped")
+		helpers.CreateTestOrderItem(t, order.ID, product.ID, 1, 10)
+
+		_, err := service.CancelOrder(context.Background(), order.ID, user.ID, nil)
+		assert.ErrorIs(t, err, ErrOrderNotCancellable)
+	})
+
+	t.Run("status change after the check does not restore stock", func(t *testing.T) {
+		order := helpers.CreateTestOrder(t, user.ID, "pending")
+		helpers.CreateTestOrderItem(t, order.ID, product.ID, 1, 10)
+
+		var before models.Product
+		require.NoError(t, db.First(&before, "id = ?", product.ID).Error)
+
+		// Ship the order right before the cancellation writes, as a concurrent admin update would
+		shipped := false
+		require.NoError(t, db.Callback().Update().Before("gorm:update").Register("test:ship_first", func(tx *gorm.DB) {
+			if !shipped {
+				shipped = true
+				tx.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Exec("UPDATE orders SET status = ? WHERE id = ?", "shipped", order.ID)
+			}
+		}))
+		defer db.Callback().Update().Remove("test:ship_first")
+
+		_, err := service.CancelOrder(context.Background(), order.ID, user.ID, nil)
+		assert.ErrorIs(t, err, ErrOrderNotCancellable)
+
+		var after models.Product
+		require.NoError(t, db.First(&after, "id = ?", product.ID).Error)
+		assert.Equal(t, before.Inventory, after.Inventory)
+	})
+}
+
+// fakeRefunder records cancellation and return refunds instead of calling the payment provider
+type fakeRefunder struct {
+	db       *gorm.DB
+	orders   []string
+	reasons  []string
+	restocks []bool
+	err      error // fails the claim
+	issueErr error // the payment provider refuses the refund
+}
+
+func (f *fakeRefunder) ClaimCancellationRefund(tx *gorm.DB, orderID, userID, reason string) (*payments.RefundClaim, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.orders = append(f.orders, orderID)
+	f.reasons = append(f.reasons, reason)
+	return &payments.RefundClaim{Refund: &models.Refund{ID: "refund-" + orderID, OrderID: orderID, Amount: 2000, Status: models.RefundStatusCreating}}, nil
+}
+
+func (f *fakeRefunder) ClaimReturnRefund(tx *gorm.DB, orderID, orderItemID string, quantity int, restock bool, adminID, reason string) (*payments.RefundClaim, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.orders = append(f.orders, orderID)
+	f.reasons = append(f.reasons, reason)
+	f.restocks = append(f.restocks, restock)
+	return &payments.RefundClaim{Refund: &models.Refund{ID: "refund-" + orderItemID, OrderID: orderID, Amount: int64(quantity) * 1000, Status: models.RefundStatusCreating}}, nil
+}
+
+func (f *fakeRefunder) IssueRefund(claim *payments.RefundClaim, record func(tx *gorm.DB, refund *models.Refund) error) (*models.Refund, error) {
+	if f.issueErr != nil {
+		return nil, fmt.Errorf("%w: %v", payments.ErrRefundFailed, f.issueErr)
+	}
+	refund := *claim.Refund
+	refund.Status = models.RefundStatusPending
+	if record != nil {
+		if err := f.db.Transaction(func(tx *gorm.DB) error { return record(tx, &refund) }); err != nil {
+			return nil, err
+		}
+	}
+	return &refund, nil
+}
+
+func TestService_CancelOrderRefundsAndRecordsReason(t *testing.T) {
+	db, service, helpers, emailService := setupPolicyTest(t)
+	emailService.On("SendRefundNotification", mock.Anything, mock.Anything).Return(nil)
+	refunder := &fakeRefunder{db: db}
+	service.refunds = refunder
+
+	user := helpers.CreateTestUser(t, "cancel-refund@example.com")
+	category := createPolicyCategory(t, db, helpers, "refunded", models.DefaultReturnPolicy())
+	product := createPolicyProduct(t, db, category.ID, "REFUND-SKU")
+
+	t.Run("paid order is refunded with the reason", func(t *testing.T) {
+		order := helpers.CreateTestOrder(t, user.ID, "paid")
+		helpers.CreateTestOrderItem(t, order.ID, product.ID, 2, 10)
+
+		cancelled, err := service.CancelOrder(context.Background(), order.ID, user.ID, &CancelOrderRequest{Reason: "ordered by mistake"})
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", cancelled.Status)
+		assert.Equal(t, []string{order.ID}, refunder.orders)
+		assert.Equal(t, []string{"ordered by mistake"}, refunder.reasons)
+		emailService.AssertCalled(t, "SendRefundNotification", mock.Anything, mock.Anything)
+
+		var reloaded models.Order
+		require.NoError(t, db.First(&reloaded, "id = ?", order.ID).Error)
+		require.NotNil(t, reloaded.CancellationReason)
+		assert.Equal(t, "ordered by mistake", *reloaded.CancellationReason)
+	})
+
+	t.Run("refund provider failure keeps the cancellation", func(t *testing.T) {
+		refunder.issueErr = errors.New("gateway down")
+		defer func() { refunder.issueErr = nil }()
+		emailService.Calls = nil
+
+		order := helpers.CreateTestOrder(t, user.ID, "paid")
+		helpers.CreateTestOrderItem(t, order.ID, product.ID, 1, 10)
+
+		cancelled, err := service.CancelOrder(context.Background(), order.ID, user.ID, &CancelOrderRequest{Reason: "found it cheaper"})
+		require.NoError(t, err, "the failed refund is left for an admin to issue again")
+		assert.Equal(t, "cancelled", cancelled.Status)
+		var reloaded models.Order
+		require.NoError(t, db.First(&reloaded, "id = ?", order.ID).Error)
+		assert.Equal(t, "cancelled", reloaded.Status)
+		emailService.AssertNotCalled(t, "SendRefundNotification", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed refund claim leaves the order as it was", func(t *testing.T) {
+		refunder.err = errors.New("gateway down")
+		defer func() { refunder.err = nil }()
+
+		order := helpers.CreateTestOrder(t, user.ID, "processing")
+		helpers.CreateTestOrderItem(t, order.ID, product.ID, 1, 10)
+		var before models.Product
+		require.NoError(t, db.First(&before, "id = ?", product.ID).Error)
+
+		_, err := service.CancelOrder(context.Background(), order.ID, user.ID, &CancelOrderRequest{Reason: "too slow"})
+		assert.Error(t, err)
+
+		var reloaded models.Order
+		require.NoError(t, db.First(&reloaded, "id = ?", order.ID).Error)
+		assert.Equal(t, "processing", reloaded.Status)
+		assert.Nil(t, reloaded.CancellationReason)
+		var after models.Product
+		require.NoError(t, db.First(&after, "id = ?", product.ID).Error)
+		assert.Equal(t, before.Inventory, after.Inventory)
+	})
+}
+
+func TestService_CreateReturnRequest(t *testing.T) {
+	db, service, helpers, _ := setupPolicyTest(t)
+
+	user := helpers.CreateTestUser(t, "returns@example.com")
+	standard := createPolicyCategory(t, db, helpers, "standard", models.DefaultReturnPolicy())
+	final := createPolicyCategory(t, db, helpers, "final-sale", models.ReturnPolicy{Returnable: false, CancellationCutoffHours: 24})
+	product := createPolicyProduct(t, db, standard.ID, "STANDARD-SKU")
+	finalProduct := createPolicyProduct(t, db, final.ID, "FINAL-SKU")
+
+	deliveredOrder := func(deliveredAt time.Time) (*models.Order, *models.OrderItem, *models.OrderItem) {
+		order := helpers.CreateTestOrder(t, user.ID, "delivered")
+		require.NoError(t, db.Model(order).Update("delivered_at", deliveredAt).Error)
+		return order,
+			helpers.CreateTestOrderItem(t, order.ID, product.ID, 2, 10),
+			helpers.CreateTestOrderItem(t, order.ID, finalProduct.ID, 1, 10)
+	}
+
+	t.Run("returnable item within window", func(t *testing.T) {
+		order, item, _ := deliveredOrder(time.Now().AddDate(0, 0, -3))
+
+		returns, err := service.CreateReturnRequest(context.Background(), order.ID, user.ID, &CreateReturnRequest{
+			Items: []ReturnItemRequest{{OrderItemID: item.ID, Quantity: 1, Reason: "too small"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, returns, 1)
+		assert.Equal(t, "requested", returns[0].Status)
+
+		// Only one unit is left to return
+		_, err = service.CreateReturnRequest(context.Background(), order.ID, user.ID, &CreateReturnRequest{
+			Items: []ReturnItemRequest{{OrderItemID: item.ID, Quantity: 2}},
+		})
+		assert.ErrorIs(t, err, ErrInvalidReturnItem)
+	})
+
+	t.Run("non-returnable category", func(t *testing.T) {
+		order, _, finalItem := deliveredOrder(time.Now())
+
+		_, err := service.CreateReturnRequest(context.Background(), order.ID, user.ID, &CreateReturnRequest{
+			Items: []ReturnItemRequest{{OrderItemID: finalItem.ID, Quantity: 1}},
+		})
+		assert.ErrorIs(t, err, ErrItemNotReturnable)
+	})
+
+	t.Run("window closed", func(t *testing.T) {
+		order, item, _ := deliveredOrder(time.Now().AddDate(0, 0, -8))
+
+		_, err := service.CreateReturnRequest(context.Background(), order.ID, user.ID, &CreateReturnRequest{
+			Items: []ReturnItemRequest{{OrderItemID: item.ID, Quantity: 1}},
+		})
+		assert.ErrorIs(t, err, ErrReturnWindowClosed)
+	})
+
+	t.Run("order not delivered", func(t *testing.T) {
+		order := helpers.CreateTestOrder(t, user.ID, "shipped")
+
+		_, err := service.CreateReturnRequest(context.Background(), order.ID, user.ID, &CreateReturnRequest{
+			Items: []ReturnItemRequest{{OrderItemID: "any", Quantity: 1}},
+		})
+		assert.ErrorIs(t, err, ErrOrderNotDelivered)
+	})
+}
+
+func TestService_CancelOrderLogsThroughOrdersModule(t *testing.T) {
+	db, _, helpers, _ := setupPolicyTest(t)
+	emailService := &MockEmailService{}
+	emailService.On("SendOrderStatusUpdate", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("smtp down"))
+	service := NewServiceWithDependencies(db, &MockCartService{}, emailService)
+
+	user := helpers.CreateTestUser(t, "cancel-log@example.com")
+	category := createPolicyCategory(t, db, helpers, "logged", models.DefaultReturnPolicy())
+	product := createPolicyProduct(t, db, category.ID, "LOG-SKU")
+
+	cancel := func() string {
+		order := helpers.CreateTestOrder(t, user.ID, "pending")
+		helpers.CreateTestOrderItem(t, order.ID, product.ID, 1, 10)
+		_, err := service.CancelOrder(context.Background(), order.ID, user.ID, nil)
+		require.NoError(t, err)
+		return order.ID
+	}
+
+	output := &bytes.Buffer{}
+	logger.Initialize(logger.Config{Level: logger.INFO, Output: output})
+	defer logger.Initialize(logger.Config{})
+
+	orderID := cancel()
+	assert.Contains(t, output.String(), `"module":"orders"`)
+	assert.Contains(t, output.String(), orderID)
+
+	// Raising the orders module level silences its warnings without touching other modules
+	output.Reset()
+	require.NoError(t, logger.GetLogger().SetModuleLevel("orders", logger.ERROR))
+	cancel()
+	assert.Empty(t, output.String())
+}
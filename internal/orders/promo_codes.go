@@ -0,0 +1,76 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPromoCode       = errors.New("promo code is not valid")
+	ErrPromoCodeNotApplicable = errors.New("promo code does not apply to the items in the cart")
+)
+
+// promoCode is a promotion code an order redeems, with the promotion it gives
+type promoCode struct {
+	code      models.PromotionCode
+	promotion models.Promotion
+}
+
+// loadPromoCode finds a code that can be redeemed at now: it is active and unused, and its promotion requires a
+// code and is running
+func loadPromoCode(tx *gorm.DB, code string, now time.Time) (*promoCode, error) {
+	var promo promoCode
+	if err := tx.First(&promo.code, "code = ?", strings.ToUpper(strings.TrimSpace(code))).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidPromoCode
+		}
+		return nil, fmt.Errorf("failed to fetch promo code: %w", err)
+	}
+	if promo.code.Status() != models.PromotionCodeActive {
+		return nil, fmt.Errorf("%w: it was already used or deactivated", ErrInvalidPromoCode)
+	}
+	if err := tx.First(&promo.promotion, "id = ?", promo.code.PromotionID).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch promotion: %w", err)
+	}
+	promotion := &promo.promotion
+	if !promotion.RequiresCode || promotion.Status != models.PromotionActive || now.Before(promotion.StartsAt) || !now.Before(promotion.EndsAt) {
+		return nil, fmt.Errorf("%w: its promotion is not running", ErrInvalidPromoCode)
+	}
+	return &promo, nil
+}
+
+// unitDiscounts returns how much the promotion takes off one unit of each stocked product it covers
+func (p *promoCode) unitDiscounts(lines []stockedLine) map[string]float64 {
+	discounts := make(map[string]float64)
+	for _, line := range lines {
+		if !p.promotion.Covers(&line.Product) {
+			continue
+		}
+		if discount := line.Product.Price - math.Max(p.promotion.SalePrice(line.Product.Price), 0); discount > 0 {
+			discounts[line.ProductID] = discount
+		}
+	}
+	return discounts
+}
+
+// redeem marks the code used by order. Only an unused active code is updated, so of two checkouts redeeming
+// the same code only the first is placed.
+func (p *promoCode) redeem(tx *gorm.DB, order *models.Order, now time.Time) error {
+	result := tx.Model(&models.PromotionCode{}).
+		Where("id = ? AND is_active = ? AND used_at IS NULL", p.code.ID, true).
+		Updates(map[string]interface{}{"used_at": now, "used_by": order.UserID, "order_id": order.ID})
+	if result.Error != nil {
+		return fmt.Errorf("failed to redeem promo code: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: it was already used or deactivated", ErrInvalidPromoCode)
+	}
+	return nil
+}
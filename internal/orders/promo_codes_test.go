@@ -0,0 +1,108 @@
+package orders
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ecommerce-website/internal/models"
+)
+
+func TestOrderService_CreateOrderWithPromoCode(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+
+	user := helpers.CreateTestUser(t, "promo@example.com")
+	audio := helpers.CreateTestCategory(t, "promo-audio")
+	video := helpers.CreateTestCategory(t, "promo-video")
+	headphones := createPolicyProduct(t, db, audio.ID, "PROMO-AUDIO")
+	projector := createPolicyProduct(t, db, video.ID, "PROMO-VIDEO")
+
+	now := time.Now()
+	campaign := models.Promotion{
+		Name: "Influencer", DiscountType: models.PromotionPercentOff, DiscountValue: 25, RequiresCode: true,
+		CategoryIDs: models.StringArray{audio.ID}, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Status: models.PromotionActive,
+	}
+	require.NoError(t, db.Create(&campaign).Error)
+	books := models.Promotion{
+		Name: "Books", DiscountType: models.PromotionAmountOff, DiscountValue: 5, RequiresCode: true,
+		CategoryIDs: models.StringArray{"books"}, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Status: models.PromotionActive,
+	}
+	require.NoError(t, db.Create(&books).Error)
+	usedAt := now.Add(-time.Minute)
+	for _, code := range []models.PromotionCode{
+		{PromotionID: campaign.ID, BatchID: "batch-1", Code: "SAVE-0001", IsActive: true},
+		{PromotionID: campaign.ID, BatchID: "batch-1", Code: "USED-0001", IsActive: true, UsedAt: &usedAt},
+		{PromotionID: books.ID, BatchID: "batch-2", Code: "BOOK-0001", IsActive: true},
+	} {
+		require.NoError(t, db.Create(&code).Error)
+	}
+	inactive := models.PromotionCode{PromotionID: campaign.ID, BatchID: "batch-1", Code: "OFF-0001", IsActive: true}
+	require.NoError(t, db.Create(&inactive).Error)
+	require.NoError(t, db.Model(&inactive).Update("is_active", false).Error)
+
+	cartService.On("GetCartWithProducts", mock.Anything, "promo-session").Return(&models.Cart{
+		SessionID: "promo-session",
+		Items: []models.CartItem{
+			{ProductID: headphones.ID, Quantity: 2, Price: 10},
+			{ProductID: projector.ID, Quantity: 1, Price: 10},
+		},
+	}, nil)
+	cartService.On("ClearCart", mock.Anything, "promo-session").Return(nil)
+
+	checkout := func(code string) (*models.Order, error) {
+		req := helpers.GetValidCreateOrderRequest("promo-session")
+		req.PromoCode = &code
+		return service.CreateOrder(context.Background(), user.ID, req)
+	}
+
+	t.Run("rejects codes that cannot be redeemed", func(t *testing.T) {
+		for _, code := range []string{"MISSING", "USED-0001", "OFF-0001"} {
+			_, err := checkout(code)
+			assert.ErrorIs(t, err, ErrInvalidPromoCode, code)
+		}
+		_, err := checkout("BOOK-0001")
+		assert.ErrorIs(t, err, ErrPromoCodeNotApplicable)
+	})
+
+	t.Run("discounts the covered items and uses the code", func(t *testing.T) {
+		order, err := checkout(" save-0001 ")
+		require.NoError(t, err)
+		assert.Equal(t, 30.0, order.Subtotal)
+		assert.Equal(t, 5.0, order.Discount, "a quarter off the two headphones only")
+		assert.InDelta(t, order.Subtotal-order.Discount+order.Tax+order.Shipping, order.Total, 0.001)
+		require.Len(t, order.Items, 2)
+		for _, item := range order.Items {
+			if item.ProductID == headphones.ID {
+				assert.Equal(t, 5.0, item.Discount, "the discount is kept on the line it was taken off")
+				assert.Equal(t, 7.5, item.NetPrice())
+			} else {
+				assert.Zero(t, item.Discount)
+			}
+		}
+
+		var code models.PromotionCode
+		require.NoError(t, db.First(&code, "code = ?", "SAVE-0001").Error)
+		assert.Equal(t, models.PromotionCodeUsed, code.Status())
+		require.NotNil(t, code.OrderID)
+		assert.Equal(t, order.ID, *code.OrderID)
+		require.NotNil(t, code.UsedBy)
+		assert.Equal(t, user.ID, *code.UsedBy)
+
+		_, err = checkout("SAVE-0001")
+		assert.ErrorIs(t, err, ErrInvalidPromoCode, "a code is used once")
+	})
+
+	t.Run("ended promotion", func(t *testing.T) {
+		fresh := models.PromotionCode{PromotionID: campaign.ID, BatchID: "batch-1", Code: "SAVE-0002", IsActive: true}
+		require.NoError(t, db.Create(&fresh).Error)
+		require.NoError(t, db.Model(&campaign).Update("status", models.PromotionEnded).Error)
+		_, err := checkout("SAVE-0002")
+		assert.ErrorIs(t, err, ErrInvalidPromoCode)
+	})
+}
@@ -0,0 +1,343 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/email"
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/payments"
+	"ecommerce-website/internal/pdf"
+	"ecommerce-website/internal/settings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReturnNotFound      = errors.New("return not found")
+	ErrInvalidReturnStatus = errors.New("invalid return status")
+)
+
+// ApproveReturnRequest approves a return and issues its label with the carrier the customer ships it with
+type ApproveReturnRequest struct {
+	Carrier        string  `json:"carrier" binding:"required,max=50"`
+	TrackingNumber *string `json:"trackingNumber,omitempty" binding:"omitempty,max=100"`
+}
+
+// RejectReturnRequest rejects a return with the reason shown to the customer
+type RejectReturnRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// CompleteReturnRequest completes a return whose goods arrived back at the warehouse
+type CompleteReturnRequest struct {
+	// Restock puts the returned units back in stock; defaults to true, set false for damaged goods
+	Restock *bool `json:"restock"`
+}
+
+// RMANumber returns the return merchandise authorization number of a return, printed on its label
+func RMANumber(returnID string) string {
+	id := strings.ToUpper(strings.ReplaceAll(returnID, "-", ""))
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return "RMA-" + id
+}
+
+// GetReturns returns the return requests of a customer's order, newest first
+func (s *Service) GetReturns(ctx context.Context, orderID, userID string) ([]models.ReturnRequest, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Order{}).Where("id = ? AND user_id = ?", orderID, userID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	returns := []models.ReturnRequest{}
+	if err := s.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at DESC").Find(&returns).Error; err != nil {
+		return nil, fmt.Errorf("failed to get returns: %w", err)
+	}
+	return returns, nil
+}
+
+// GetAllReturns returns the return requests in a status, or all of them, oldest first so the queue is worked
+// in order (admin only)
+func (s *Service) GetAllReturns(ctx context.Context, status string, page, limit int) ([]models.ReturnRequest, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.ReturnRequest{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count returns: %w", err)
+	}
+	returns := []models.ReturnRequest{}
+	if err := query.Order("created_at ASC").Limit(limit).Offset((page - 1) * limit).Find(&returns).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get returns: %w", err)
+	}
+	return returns, total, nil
+}
+
+// ApproveReturn approves a requested return (admin only), assigning its RMA number and the carrier and
+// tracking number of its return label
+func (s *Service) ApproveReturn(ctx context.Context, returnID string, req *ApproveReturnRequest, adminID string) (*models.ReturnRequest, error) {
+	return s.reviewReturn(ctx, returnID, func(ret *models.ReturnRequest) map[string]interface{} {
+		return map[string]interface{}{
+			"status":          models.ReturnStatusApproved,
+			"rma_number":      RMANumber(ret.ID),
+			"carrier":         req.Carrier,
+			"tracking_number": req.TrackingNumber,
+			"reviewed_by":     adminID,
+			"reviewed_at":     time.Now(),
+		}
+	})
+}
+
+// RejectReturn rejects a requested return (admin only); its units can be requested for return again
+func (s *Service) RejectReturn(ctx context.Context, returnID string, req *RejectReturnRequest, adminID string) (*models.ReturnRequest, error) {
+	return s.reviewReturn(ctx, returnID, func(ret *models.ReturnRequest) map[string]interface{} {
+		return map[string]interface{}{
+			"status":           models.ReturnStatusRejected,
+			"rejection_reason": req.Reason,
+			"reviewed_by":      adminID,
+			"reviewed_at":      time.Now(),
+		}
+	})
+}
+
+// reviewReturn applies the updates of an admin decision to a return that is still requested
+func (s *Service) reviewReturn(ctx context.Context, returnID string, updates func(ret *models.ReturnRequest) map[string]interface{}) (*models.ReturnRequest, error) {
+	var ret models.ReturnRequest
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockReturn(tx, returnID, &ret); err != nil {
+			return err
+		}
+		if ret.Status != models.ReturnStatusRequested {
+			return fmt.Errorf("%w: return is already %s", ErrInvalidReturnStatus, ret.Status)
+		}
+		if err := tx.Model(&ret).Updates(updates(&ret)).Error; err != nil {
+			return fmt.Errorf("failed to update return: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).First(&ret, "id = ?", returnID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get return: %w", err)
+	}
+	return &ret, nil
+}
+
+// CompleteReturn completes an approved return once its goods arrived back (admin only): the returned units
+// are refunded and, unless restock is turned off, put back in stock. Orders without a captured payment are
+// only restocked. The refund is claimed in a short transaction that holds the return with it, and the return
+// completes with the refund once the payment provider issued it; a refused refund leaves the return approved.
+func (s *Service) CompleteReturn(ctx context.Context, returnID string, req *CompleteReturnRequest, adminID string) (*models.ReturnRequest, error) {
+	restock := req.Restock == nil || *req.Restock
+	completion := func() map[string]interface{} {
+		return map[string]interface{}{"status": models.ReturnStatusCompleted, "restocked": restock, "completed_at": time.Now()}
+	}
+	var ret models.ReturnRequest
+	var claim *payments.RefundClaim
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockReturn(tx, returnID, &ret); err != nil {
+			return err
+		}
+		if ret.Status != models.ReturnStatusApproved {
+			return fmt.Errorf("%w: return is %s", ErrInvalidReturnStatus, ret.Status)
+		}
+		if ret.RefundID != nil {
+			return fmt.Errorf("%w: its refund is being issued", ErrInvalidReturnStatus)
+		}
+
+		reason := "return"
+		if ret.RMANumber != nil {
+			reason = "return " + *ret.RMANumber
+		}
+		if s.refunds != nil {
+			var err error
+			if claim, err = s.refunds.ClaimReturnRefund(tx, ret.OrderID, ret.OrderItemID, ret.Quantity, restock, adminID, reason); err != nil {
+				return err
+			}
+		}
+		// The refund restocks what it covers and completes the return once issued
+		if claim != nil {
+			if err := tx.Model(&ret).Update("refund_id", claim.Refund.ID).Error; err != nil {
+				return fmt.Errorf("failed to update return: %w", err)
+			}
+			return nil
+		}
+		if restock {
+			var item models.OrderItem
+			if err := tx.Select("id, product_id").First(&item, "id = ?", ret.OrderItemID).Error; err != nil {
+				return fmt.Errorf("failed to get order item: %w", err)
+			}
+			if err := tx.Model(&models.Product{}).Where("id = ?", item.ProductID).
+				Update("inventory", gorm.Expr("inventory + ?", ret.Quantity)).Error; err != nil {
+				return fmt.Errorf("failed to restore inventory: %w", err)
+			}
+			if err := eventbus.PublishInventoryChanged(tx, item.ProductID, ret.Quantity, eventbus.InventoryReturnRestock); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&ret).Updates(completion()).Error; err != nil {
+			return fmt.Errorf("failed to update return: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claim != nil {
+		refund, err := s.refunds.IssueRefund(claim, func(tx *gorm.DB, refund *models.Refund) error {
+			if err := tx.Model(&ret).Updates(completion()).Error; err != nil {
+				return fmt.Errorf("failed to update return: %w", err)
+			}
+			if s.outbox != nil {
+				return email.QueueRefundNotification(tx, ret.OrderID, refund.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			// A refused refund releases the return so it can be completed again
+			if errors.Is(err, payments.ErrRefundFailed) {
+				if releaseErr := s.db.WithContext(ctx).Model(&models.ReturnRequest{}).
+					Where("id = ? AND refund_id = ?", ret.ID, claim.Refund.ID).Update("refund_id", nil).Error; releaseErr != nil {
+					logger.Named("orders").Warn("Failed to release return refund", map[string]interface{}{"return_id": ret.ID, "error": releaseErr.Error()})
+				}
+			}
+			return nil, err
+		}
+		s.notifyReturnRefund(ctx, ret.OrderID, refund)
+	}
+	if err := s.db.WithContext(ctx).First(&ret, "id = ?", returnID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get return: %w", err)
+	}
+	return &ret, nil
+}
+
+// notifyReturnRefund emails the customer about the refund of a return; with an outbox it was recorded with the
+// return. Failures are logged and never undo the return.
+func (s *Service) notifyReturnRefund(ctx context.Context, orderID string, refund *models.Refund) {
+	if s.outbox != nil {
+		s.outbox.Notify()
+		return
+	}
+	var order models.Order
+	if err := s.db.WithContext(ctx).Preload("User").Preload("Items.Product").First(&order, "id = ?", orderID).Error; err != nil {
+		logger.Named("orders").Warn("Failed to load order for refund email", map[string]interface{}{"order_id": orderID, "error": err.Error()})
+		return
+	}
+	if err := s.emailService.SendRefundNotification(&order, refund); err != nil {
+		logger.Named("orders").Warn("Failed to send refund email", map[string]interface{}{"order_id": orderID, "refund_id": refund.ID, "error": err.Error()})
+	}
+}
+
+// lockReturn loads a return for update into ret
+func lockReturn(tx *gorm.DB, returnID string, ret *models.ReturnRequest) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(ret, "id = ?", returnID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrReturnNotFound
+		}
+		return fmt.Errorf("failed to get return: %w", err)
+	}
+	return nil
+}
+
+// GetReturnLabel renders the label of an approved return as a PDF document. A non-empty userID limits it to
+// that customer's returns.
+func (s *Service) GetReturnLabel(ctx context.Context, returnID, userID string) (*models.ReturnRequest, []byte, error) {
+	query := s.db.WithContext(ctx).Where("id = ?", returnID)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	var ret models.ReturnRequest
+	if err := query.First(&ret).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrReturnNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get return: %w", err)
+	}
+	if ret.Status != models.ReturnStatusApproved {
+		return nil, nil, fmt.Errorf("%w: labels are issued for approved returns, this one is %s", ErrInvalidReturnStatus, ret.Status)
+	}
+
+	var item models.OrderItem
+	if err := s.db.WithContext(ctx).Preload("Product").Preload("Order").First(&item, "id = ?", ret.OrderItemID).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get order item: %w", err)
+	}
+	// The goods come back from where they were delivered
+	from := item.Order.ShippingAddress
+	if item.ShipmentID != nil {
+		var shipment models.Shipment
+		if err := s.db.WithContext(ctx).First(&shipment, "id = ?", *item.ShipmentID).Error; err == nil {
+			from = shipment.Address
+		}
+	}
+	return &ret, pdf.Render(returnLabelLines(&ret, &item, from, s.returnAddress(ctx)), pdf.Letter), nil
+}
+
+// returnAddress returns the lines of the address returns are shipped to: the returns warehouse when set,
+// or else the seller address printed on invoices
+func (s *Service) returnAddress(ctx context.Context) []string {
+	address := s.settings.GetString(ctx, settings.KeyReturnAddress)
+	if address == "" {
+		address = s.settings.GetString(ctx, settings.KeyInvoiceCompanyAddress)
+	}
+	var lines []string
+	if name := s.settings.GetString(ctx, settings.KeyInvoiceCompanyName); name != "" {
+		lines = append(lines, name)
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(address, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// returnLabelLines lays out the text content of a return label
+func returnLabelLines(ret *models.ReturnRequest, item *models.OrderItem, from models.OrderAddress, to []string) []string {
+	rma := RMANumber(ret.ID)
+	if ret.RMANumber != nil {
+		rma = *ret.RMANumber
+	}
+	lines := []string{
+		"RETURN LABEL",
+		"",
+		"RMA: " + rma,
+		fmt.Sprintf("Order #%s", ret.OrderID),
+	}
+	if ret.Carrier != nil {
+		lines = append(lines, "Carrier: "+*ret.Carrier)
+	}
+	if ret.TrackingNumber != nil && *ret.TrackingNumber != "" {
+		lines = append(lines, "Tracking Number: "+*ret.TrackingNumber)
+	}
+
+	lines = append(lines, "", "From:", fmt.Sprintf("  %s %s", from.FirstName, from.LastName), "  "+from.Address1)
+	if from.Address2 != nil && *from.Address2 != "" {
+		lines = append(lines, "  "+*from.Address2)
+	}
+	lines = append(lines, fmt.Sprintf("  %s, %s %s", from.City, from.State, from.PostalCode), "  "+from.Country, "", "To:")
+	for _, line := range to {
+		lines = append(lines, "  "+line)
+	}
+
+	lines = append(lines, "", "Items:",
+		fmt.Sprintf("  %s (SKU: %s)  Qty: %d", item.Product.Name, item.Product.SKU, ret.Quantity))
+	if ret.Reason != "" {
+		lines = append(lines, "  Reason: "+ret.Reason)
+	}
+	return append(lines, "", "Write the RMA number on the outside of the package.")
+}
@@ -0,0 +1,191 @@
+package orders
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/payments"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ReturnWorkflow(t *testing.T) {
+	db, service, helpers, emailService := setupPolicyTest(t)
+	emailService.On("SendRefundNotification", mock.Anything, mock.Anything).Return(nil)
+	refunder := &fakeRefunder{db: db}
+	service.refunds = refunder
+
+	user := helpers.CreateTestUser(t, "rma@example.com")
+	other := helpers.CreateTestUser(t, "rma-other@example.com")
+	category := createPolicyCategory(t, db, helpers, "rma", models.DefaultReturnPolicy())
+	product := createPolicyProduct(t, db, category.ID, "RMA-SKU")
+
+	order := helpers.CreateTestOrder(t, user.ID, "delivered")
+	require.NoError(t, db.Model(order).Update("delivered_at", time.Now().AddDate(0, 0, -2)).Error)
+	item := helpers.CreateTestOrderItem(t, order.ID, product.ID, 3, 10)
+
+	request := func(quantity int) models.ReturnRequest {
+		returns, err := service.CreateReturnRequest(context.Background(), order.ID, user.ID, &CreateReturnRequest{
+			Items: []ReturnItemRequest{{OrderItemID: item.ID, Quantity: quantity, Reason: "wrong size"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, returns, 1)
+		return returns[0]
+	}
+
+	t.Run("rejected returns free their units", func(t *testing.T) {
+		ret := request(3)
+		rejected, err := service.RejectReturn(context.Background(), ret.ID, &RejectReturnRequest{Reason: "outside policy"}, "admin-1")
+		require.NoError(t, err)
+		assert.Equal(t, models.ReturnStatusRejected, rejected.Status)
+		assert.Equal(t, "outside policy", *rejected.RejectionReason)
+		assert.Equal(t, "admin-1", *rejected.ReviewedBy)
+
+		_, err = service.ApproveReturn(context.Background(), ret.ID, &ApproveReturnRequest{Carrier: "UPS"}, "admin-1")
+		assert.ErrorIs(t, err, ErrInvalidReturnStatus, "a reviewed return is not reviewed again")
+		_, _, err = service.GetReturnLabel(context.Background(), ret.ID, user.ID)
+		assert.ErrorIs(t, err, ErrInvalidReturnStatus)
+	})
+
+	ret := request(2)
+	_, err := service.CompleteReturn(context.Background(), ret.ID, &CompleteReturnRequest{}, "admin-1")
+	assert.ErrorIs(t, err, ErrInvalidReturnStatus, "returns are approved before they complete")
+
+	approved, err := service.ApproveReturn(context.Background(), ret.ID, &ApproveReturnRequest{Carrier: "UPS", TrackingNumber: stringPtr("1Z-RET")}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ReturnStatusApproved, approved.Status)
+	require.NotNil(t, approved.RMANumber)
+	assert.Equal(t, RMANumber(ret.ID), *approved.RMANumber)
+
+	t.Run("label", func(t *testing.T) {
+		_, _, err := service.GetReturnLabel(context.Background(), ret.ID, other.ID)
+		assert.ErrorIs(t, err, ErrReturnNotFound, "customers only get the labels of their own returns")
+
+		_, label, err := service.GetReturnLabel(context.Background(), ret.ID, user.ID)
+		require.NoError(t, err)
+		assert.Contains(t, string(label), "(RMA: "+*approved.RMANumber+") Tj")
+		assert.Contains(t, string(label), "(Tracking Number: 1Z-RET) Tj")
+		assert.Contains(t, string(label), "RMA-SKU")
+	})
+
+	completed, err := service.CompleteReturn(context.Background(), ret.ID, &CompleteReturnRequest{}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ReturnStatusCompleted, completed.Status)
+	assert.True(t, completed.Restocked)
+	require.NotNil(t, completed.RefundID)
+	assert.Equal(t, "refund-"+item.ID, *completed.RefundID)
+	assert.Equal(t, []string{"return " + *approved.RMANumber}, refunder.reasons)
+	assert.Equal(t, []bool{true}, refunder.restocks)
+	emailService.AssertCalled(t, "SendRefundNotification", mock.Anything, mock.Anything)
+
+	returns, err := service.GetReturns(context.Background(), order.ID, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, returns, 2)
+	_, err = service.GetReturns(context.Background(), order.ID, other.ID)
+	assert.EqualError(t, err, "order not found")
+
+	queue, total, err := service.GetAllReturns(context.Background(), models.ReturnStatusCompleted, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, queue, 1)
+	assert.Equal(t, ret.ID, queue[0].ID)
+
+	t.Run("refused refund leaves the return approved", func(t *testing.T) {
+		extra := helpers.CreateTestOrderItem(t, order.ID, product.ID, 1, 10)
+		returns, err := service.CreateReturnRequest(context.Background(), order.ID, user.ID, &CreateReturnRequest{
+			Items: []ReturnItemRequest{{OrderItemID: extra.ID, Quantity: 1, Reason: "faulty"}},
+		})
+		require.NoError(t, err)
+		ret := returns[0]
+		_, err = service.ApproveReturn(context.Background(), ret.ID, &ApproveReturnRequest{Carrier: "UPS"}, "admin-1")
+		require.NoError(t, err)
+
+		refunder.issueErr = errors.New("gateway down")
+		_, err = service.CompleteReturn(context.Background(), ret.ID, &CompleteReturnRequest{}, "admin-1")
+		refunder.issueErr = nil
+		assert.ErrorIs(t, err, payments.ErrRefundFailed)
+		var reloaded models.ReturnRequest
+		require.NoError(t, db.First(&reloaded, "id = ?", ret.ID).Error)
+		assert.Equal(t, models.ReturnStatusApproved, reloaded.Status)
+		assert.Nil(t, reloaded.RefundID, "the refund is released so the return can be completed again")
+
+		completed, err := service.CompleteReturn(context.Background(), ret.ID, &CompleteReturnRequest{}, "admin-1")
+		require.NoError(t, err)
+		assert.Equal(t, models.ReturnStatusCompleted, completed.Status)
+		require.NotNil(t, completed.RefundID)
+		assert.Equal(t, "refund-"+extra.ID, *completed.RefundID)
+	})
+
+	t.Run("orders without a refund are restocked", func(t *testing.T) {
+		service.refunds = nil
+		ret := request(1)
+		_, err := service.ApproveReturn(context.Background(), ret.ID, &ApproveReturnRequest{Carrier: "DHL"}, "admin-1")
+		require.NoError(t, err)
+
+		completed, err := service.CompleteReturn(context.Background(), ret.ID, &CompleteReturnRequest{}, "admin-1")
+		require.NoError(t, err)
+		assert.True(t, completed.Restocked)
+		assert.Nil(t, completed.RefundID)
+
+		var stock models.Product
+		require.NoError(t, db.First(&stock, "id = ?", product.ID).Error)
+		assert.Equal(t, 6, stock.Inventory)
+	})
+}
+
+func TestHandler_ReturnWorkflow(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupTestRouter()
+	admin := router.Group("/api/admin", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+	})
+	admin.POST("/returns/:id/approve", handler.ApproveReturn)
+	admin.POST("/returns/:id/complete", handler.CompleteReturn)
+	admin.GET("/returns/:id/label", handler.GetReturnLabel)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post("/api/admin/returns/ret-1/approve", `{}`).Code, "carrier is required")
+
+	rma := "RMA-ABC"
+	mockService.On("ApproveReturn", mock.Anything, "ret-1", &ApproveReturnRequest{Carrier: "UPS"}, "admin-1").
+		Return(&models.ReturnRequest{ID: "ret-1", Status: models.ReturnStatusApproved, RMANumber: &rma}, nil).Once()
+	assert.Equal(t, http.StatusOK, post("/api/admin/returns/ret-1/approve", `{"carrier":"UPS"}`).Code)
+
+	mockService.On("ApproveReturn", mock.Anything, "missing", mock.Anything, "admin-1").Return(nil, ErrReturnNotFound).Once()
+	assert.Equal(t, http.StatusNotFound, post("/api/admin/returns/missing/approve", `{"carrier":"UPS"}`).Code)
+
+	restock := false
+	mockService.On("CompleteReturn", mock.Anything, "ret-1", &CompleteReturnRequest{Restock: &restock}, "admin-1").
+		Return(&models.ReturnRequest{ID: "ret-1", Status: models.ReturnStatusCompleted}, nil).Once()
+	assert.Equal(t, http.StatusOK, post("/api/admin/returns/ret-1/complete", `{"restock":false}`).Code)
+
+	mockService.On("CompleteReturn", mock.Anything, "ret-2", &CompleteReturnRequest{}, "admin-1").Return(nil, ErrInvalidReturnStatus).Once()
+	assert.Equal(t, http.StatusConflict, post("/api/admin/returns/ret-2/complete", "").Code, "the body is optional")
+
+	mockService.On("GetReturnLabel", mock.Anything, "ret-1", "").
+		Return(&models.ReturnRequest{ID: "ret-1", RMANumber: &rma}, []byte("%PDF-1.4"), nil).Once()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/returns/ret-1/label", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attachment; filename=return-label-RMA-ABC.pdf", w.Header().Get("Content-Disposition"))
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,200 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	// reviewRequestBatchSize caps how many review requests one sweep handles
+	reviewRequestBatchSize = 100
+	// reviewLinkTTL is how long the link in a review request opens the review form
+	reviewLinkTTL = 30 * 24 * time.Hour
+	// reviewTokenAudience scopes review link tokens so they are never accepted as session tokens
+	reviewTokenAudience = "order-review"
+)
+
+var ErrInvalidReviewToken = errors.New("invalid or expired review link")
+
+// ReviewForm is what the review form needs to render for the customer who followed a review link
+type ReviewForm struct {
+	OrderID     string           `json:"orderId"`
+	FirstName   string           `json:"firstName"`
+	DeliveredAt *time.Time       `json:"deliveredAt,omitempty"`
+	Items       []ReviewFormItem `json:"items"`
+}
+
+// ReviewFormItem is a product of the order the customer can review
+type ReviewFormItem struct {
+	ProductID string `json:"productId"`
+	Name      string `json:"name"`
+	Image     string `json:"image,omitempty"`
+}
+
+// reviewClaims identify the order and customer a review link was sent for
+type reviewClaims struct {
+	OrderID string `json:"orderId"`
+	jwt.RegisteredClaims
+}
+
+// SendDueReviewRequests handles every order delivered at least the configured number of days before now
+// whose review request is still open, and returns how many emails were sent. Customers who opted out of
+// marketing email, or who were asked about another order within the configured interval, are skipped for
+// good. Each order is claimed before its email goes out so concurrent sweeps never ask twice; a failed
+// email releases the claim and is retried on the next sweep.
+func (s *Service) SendDueReviewRequests(ctx context.Context, now time.Time) (int, error) {
+	delayDays := s.settings.GetInt(ctx, settings.KeyReviewRequestDelay)
+	if delayDays <= 0 {
+		return 0, nil
+	}
+	intervalDays := s.settings.GetInt(ctx, settings.KeyReviewRequestInterval)
+
+	var due []models.Order
+	if err := s.db.Preload("Items.Product").Preload("User").
+		Where("status = ? AND delivered_at <= ? AND review_request_status = ? AND review_request_sent_at IS NULL",
+			"delivered", now.AddDate(0, 0, -int(delayDays)), "").
+		Order("delivered_at").Limit(reviewRequestBatchSize).Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to get orders due a review request: %w", err)
+	}
+
+	sent := 0
+	for i := range due {
+		order := &due[i]
+
+		status := models.ReviewRequestSent
+		if order.User.MarketingOptOut {
+			status = models.ReviewRequestOptedOut
+		} else if intervalDays > 0 {
+			var recent int64
+			if err := s.db.Model(&models.Order{}).
+				Where("user_id = ? AND review_request_sent_at > ?", order.UserID, now.AddDate(0, 0, -int(intervalDays))).
+				Count(&recent).Error; err != nil {
+				return sent, fmt.Errorf("failed to check recent review requests: %w", err)
+			}
+			if recent > 0 {
+				status = models.ReviewRequestThrottled
+			}
+		}
+
+		updates := map[string]interface{}{"review_request_status": status}
+		if status == models.ReviewRequestSent {
+			updates["review_request_sent_at"] = now
+		}
+		claim := s.db.Model(&models.Order{}).Where("id = ? AND review_request_status = ?", order.ID, "").Updates(updates)
+		if claim.Error != nil {
+			return sent, fmt.Errorf("failed to claim review request: %w", claim.Error)
+		}
+		if claim.RowsAffected == 0 || status != models.ReviewRequestSent {
+			continue
+		}
+
+		if err := s.sendReviewRequest(order, now); err != nil {
+			logger.Named("orders").Warn("Failed to send review request email", map[string]interface{}{"order_id": order.ID, "error": err.Error()})
+			if err := s.db.Model(&models.Order{}).Where("id = ?", order.ID).
+				Updates(map[string]interface{}{"review_request_status": "", "review_request_sent_at": nil}).Error; err != nil {
+				return sent, fmt.Errorf("failed to release review request: %w", err)
+			}
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// sendReviewRequest emails the customer a review request with a link that opens the review form
+// without signing in; without a signing secret the email goes out without the link
+func (s *Service) sendReviewRequest(order *models.Order, now time.Time) error {
+	token := ""
+	if len(s.reviewSecret) > 0 {
+		var err error
+		if token, err = s.reviewToken(order, now); err != nil {
+			return err
+		}
+	}
+	return s.emailService.SendReviewRequest(order, token)
+}
+
+// reviewToken signs a review link token for the customer of order
+func (s *Service) reviewToken(order *models.Order, now time.Time) (string, error) {
+	claims := reviewClaims{
+		OrderID: order.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   order.UserID,
+			Audience:  jwt.ClaimStrings{reviewTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(reviewLinkTTL)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.reviewSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign review link: %w", err)
+	}
+	return token, nil
+}
+
+// GetReviewForm returns the review form of a delivered order to the holder of its review link
+func (s *Service) GetReviewForm(ctx context.Context, orderID, token string) (*ReviewForm, error) {
+	if len(s.reviewSecret) == 0 {
+		return nil, ErrInvalidReviewToken
+	}
+	var claims reviewClaims
+	parsed, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return s.reviewSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(reviewTokenAudience))
+	if err != nil || !parsed.Valid || claims.OrderID != orderID {
+		return nil, ErrInvalidReviewToken
+	}
+
+	var order models.Order
+	if err := s.db.WithContext(ctx).Preload("Items.Product").Preload("User").
+		Where("id = ? AND user_id = ? AND status = ?", orderID, claims.Subject, "delivered").First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidReviewToken
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	form := &ReviewForm{OrderID: order.ID, FirstName: order.User.FirstName, DeliveredAt: order.DeliveredAt, Items: []ReviewFormItem{}}
+	seen := make(map[string]bool, len(order.Items))
+	for _, item := range order.Items {
+		// Split shipments can hold the same product more than once
+		if seen[item.ProductID] {
+			continue
+		}
+		seen[item.ProductID] = true
+		formItem := ReviewFormItem{ProductID: item.ProductID, Name: item.Product.Name}
+		if len(item.Product.Images) > 0 {
+			formItem.Image = item.Product.Images[0]
+		}
+		form.Items = append(form.Items, formItem)
+	}
+	return form, nil
+}
+
+// StartReviewRequestSweeper periodically sends due review requests until ctx is cancelled
+func (s *Service) StartReviewRequestSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if _, err := s.SendDueReviewRequests(ctx, now); err != nil {
+					logger.Named("orders").Warn("Failed to send review requests", map[string]interface{}{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
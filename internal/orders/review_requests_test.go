@@ -0,0 +1,147 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createDeliveredOrder creates an order of user delivered daysAgo days before now
+func createDeliveredOrder(t *testing.T, db *gorm.DB, userID string, now time.Time, daysAgo int) models.Order {
+	deliveredAt := now.AddDate(0, 0, -daysAgo)
+	order := models.Order{UserID: userID, Status: "delivered", Subtotal: 10, Total: 10, DeliveredAt: &deliveredAt}
+	require.NoError(t, db.Create(&order).Error)
+	return order
+}
+
+// reviewRequestOf returns the stored review request status and time of an order
+func reviewRequestOf(t *testing.T, db *gorm.DB, orderID string) (string, *time.Time) {
+	var order models.Order
+	require.NoError(t, db.First(&order, "id = ?", orderID).Error)
+	return order.ReviewRequestStatus, order.ReviewRequestSentAt
+}
+
+func TestService_SendDueReviewRequests(t *testing.T) {
+	db, service, helpers, emailService := setupPolicyTest(t)
+	now := time.Now()
+
+	due := createDeliveredOrder(t, db, helpers.CreateTestUser(t, "due@example.com").ID, now, 8)
+	recent := createDeliveredOrder(t, db, helpers.CreateTestUser(t, "recent@example.com").ID, now, 2)
+	failing := createDeliveredOrder(t, db, helpers.CreateTestUser(t, "failing@example.com").ID, now, 10)
+
+	emailService.On("SendReviewRequest", mock.MatchedBy(func(o *models.Order) bool { return o.ID == failing.ID }), "").Return(errors.New("smtp down")).Once()
+	emailService.On("SendReviewRequest", mock.Anything, "").Return(nil)
+
+	sent, err := service.SendDueReviewRequests(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	status, sentAt := reviewRequestOf(t, db, due.ID)
+	assert.Equal(t, models.ReviewRequestSent, status)
+	assert.NotNil(t, sentAt)
+	status, _ = reviewRequestOf(t, db, recent.ID)
+	assert.Empty(t, status, "orders delivered recently are asked later")
+	status, sentAt = reviewRequestOf(t, db, failing.ID)
+	assert.Empty(t, status, "failed emails are retried on the next sweep")
+	assert.Nil(t, sentAt)
+
+	sent, err = service.SendDueReviewRequests(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "only the failed request is sent again")
+
+	_, err = settings.NewService(db).SetSetting(settings.KeyReviewRequestDelay, &settings.SetSettingRequest{Value: "0"}, "admin@example.com")
+	require.NoError(t, err)
+	createDeliveredOrder(t, db, helpers.CreateTestUser(t, "disabled@example.com").ID, now, 30)
+	sent, err = NewServiceWithDependencies(db, new(MockCartService), emailService).SendDueReviewRequests(context.Background(), now)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "a zero delay disables review requests")
+}
+
+func TestService_SendDueReviewRequestsRespectsPreferencesAndFrequency(t *testing.T) {
+	db, service, helpers, emailService := setupPolicyTest(t)
+	emailService.On("SendReviewRequest", mock.Anything, mock.Anything).Return(nil)
+	now := time.Now()
+
+	optedOut := helpers.CreateTestUser(t, "opted-out@example.com")
+	require.NoError(t, db.Model(optedOut).Update("marketing_opt_out", true).Error)
+	skipped := createDeliveredOrder(t, db, optedOut.ID, now, 9)
+
+	// A frequent shopper is asked about the oldest delivery only
+	frequent := helpers.CreateTestUser(t, "frequent@example.com")
+	first := createDeliveredOrder(t, db, frequent.ID, now, 12)
+	second := createDeliveredOrder(t, db, frequent.ID, now, 8)
+
+	sent, err := service.SendDueReviewRequests(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	status, sentAt := reviewRequestOf(t, db, skipped.ID)
+	assert.Equal(t, models.ReviewRequestOptedOut, status)
+	assert.Nil(t, sentAt)
+	status, _ = reviewRequestOf(t, db, first.ID)
+	assert.Equal(t, models.ReviewRequestSent, status)
+	status, _ = reviewRequestOf(t, db, second.ID)
+	assert.Equal(t, models.ReviewRequestThrottled, status)
+
+	// Once the interval has passed the customer can be asked again
+	later := now.AddDate(0, 0, 31)
+	third := createDeliveredOrder(t, db, frequent.ID, later, 7)
+	sent, err = service.SendDueReviewRequests(context.Background(), later)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	status, _ = reviewRequestOf(t, db, third.ID)
+	assert.Equal(t, models.ReviewRequestSent, status)
+	emailService.AssertNumberOfCalls(t, "SendReviewRequest", 2)
+}
+
+func TestService_ReviewLinks(t *testing.T) {
+	db, service, helpers, emailService := setupPolicyTest(t)
+	service.reviewSecret = []byte("review-secret")
+	now := time.Now()
+
+	user := helpers.CreateTestUser(t, "review-link@example.com")
+	category := helpers.CreateTestCategory(t, "review-link")
+	product := createPolicyProduct(t, db, category.ID, "REVIEW-SKU")
+	order := createDeliveredOrder(t, db, user.ID, now, 10)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: product.ID, Quantity: 1, Price: 10}).Error)
+	}
+
+	var token string
+	emailService.On("SendReviewRequest", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		token = args.String(1)
+	}).Return(nil)
+	sent, err := service.SendDueReviewRequests(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, sent)
+	require.NotEmpty(t, token)
+
+	form, err := service.GetReviewForm(context.Background(), order.ID, token)
+	require.NoError(t, err)
+	assert.Equal(t, order.ID, form.OrderID)
+	assert.Equal(t, user.FirstName, form.FirstName)
+	assert.Equal(t, []ReviewFormItem{{ProductID: product.ID, Name: product.Name}}, form.Items, "each product is reviewed once")
+
+	other := createDeliveredOrder(t, db, user.ID, now, 1)
+	_, err = service.GetReviewForm(context.Background(), other.ID, token)
+	assert.ErrorIs(t, err, ErrInvalidReviewToken, "a link opens only its own order")
+	_, err = service.GetReviewForm(context.Background(), order.ID, token+"x")
+	assert.ErrorIs(t, err, ErrInvalidReviewToken)
+
+	expired, err := service.reviewToken(&order, now.Add(-reviewLinkTTL-time.Minute))
+	require.NoError(t, err)
+	_, err = service.GetReviewForm(context.Background(), order.ID, expired)
+	assert.ErrorIs(t, err, ErrInvalidReviewToken)
+
+	_, err = NewServiceWithDependencies(db, new(MockCartService), emailService).GetReviewForm(context.Background(), order.ID, token)
+	assert.ErrorIs(t, err, ErrInvalidReviewToken, "links are rejected without a signing secret")
+}
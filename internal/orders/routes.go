@@ -1,16 +1,19 @@
 package orders
 
 import (
+	"crypto/subtle"
+	"net/http"
+
 	"ecommerce-website/internal/auth"
+	"ecommerce-website/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes sets up the order routes
-func SetupRoutes(r *gin.Engine, handler *Handler, authService *auth.Service) {
-	api := r.Group("/api")
-
-	// Public routes (none for orders)
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	// Public routes: the signed link in review request emails opens the review form without signing in
+	api.GET("/orders/:id/review-form", handler.GetReviewForm)
 
 	// Protected routes (require authentication)
 	protected := api.Group("")
@@ -19,7 +22,18 @@ func SetupRoutes(r *gin.Engine, handler *Handler, authService *auth.Service) {
 		// User order routes
 		protected.POST("/orders/create", handler.CreateOrder)
 		protected.GET("/orders/:id", handler.GetOrder)
+		protected.GET("/orders/:id/packing-slip", handler.GetPackingSlip)
+		protected.GET("/orders/:id/invoice", handler.GetInvoice)
+		protected.GET("/orders/:id/history", handler.GetOrderHistory)
+		protected.GET("/orders/:id/shipments", handler.GetShipments)
+		protected.POST("/orders/:id/cancel", handler.CancelOrder)
+		protected.POST("/orders/:id/returns", handler.CreateReturnRequest)
+		protected.GET("/orders/:id/returns", handler.GetReturns)
+		protected.GET("/orders/:id/returns/:returnId/label", handler.GetReturnLabel)
 		protected.GET("/orders", handler.GetUserOrders)
+		protected.GET("/warranty/lookup", handler.LookupWarranty)
+		protected.GET("/draft-orders/:id", handler.GetDraftOrder)
+		protected.POST("/draft-orders/:id/checkout", handler.CheckoutDraftOrder)
 	}
 
 	// Admin routes (require admin role)
@@ -29,6 +43,52 @@ func SetupRoutes(r *gin.Engine, handler *Handler, authService *auth.Service) {
 	{
 		admin.GET("/orders", handler.GetAllOrders)
 		admin.PUT("/orders/:id/status", handler.UpdateOrderStatus)
+		admin.POST("/orders/:id/shipments", handler.CreateShipment)
+		admin.PUT("/orders/:id/shipments/:shipmentId/status", handler.UpdateShipmentStatus)
+		admin.GET("/orders/:id/packing-slip", handler.GetPackingSlip)
+		admin.GET("/orders/:id/invoice", handler.GetInvoice)
+		admin.GET("/orders/:id/comments", handler.GetOrderComments)
+		admin.POST("/orders/:id/comments", handler.AddOrderComment)
+		admin.PUT("/orders/:id/comments/:commentId", handler.UpdateOrderComment)
+		admin.DELETE("/orders/:id/comments/:commentId", handler.DeleteOrderComment)
+		admin.GET("/orders/:id/events", handler.GetOrderEvents)
+		admin.GET("/orders/:id/events/replay", handler.ReplayOrderEvents)
+		admin.POST("/orders/:id/events/rebuild", handler.RebuildOrderFromEvents)
+		admin.GET("/draft-orders", handler.GetDraftOrders)
+		admin.POST("/draft-orders", handler.CreateDraftOrder)
+		admin.GET("/draft-orders/:id", handler.GetDraftOrder)
+		admin.PUT("/draft-orders/:id", handler.UpdateDraftOrder)
+		admin.POST("/draft-orders/:id/send-invoice", handler.SendDraftOrderInvoice)
+		admin.POST("/draft-orders/:id/cancel", handler.CancelDraftOrder)
+		admin.GET("/returns", handler.GetAllReturns)
+		admin.POST("/returns/:id/approve", handler.ApproveReturn)
+		admin.POST("/returns/:id/reject", handler.RejectReturn)
+		admin.POST("/returns/:id/complete", handler.CompleteReturn)
+		admin.GET("/returns/:id/label", handler.GetReturnLabel)
+		admin.GET("/serials", handler.SearchSerials)
 		admin.GET("/customers", handler.GetAllCustomers)
+		admin.GET("/customers/:id", handler.GetCustomer)
+	}
+}
+
+// DeliveryTokenHeader carries the shared secret of delivery confirmations
+const DeliveryTokenHeader = "X-Delivery-Token"
+
+// SetupDeliveryRoutes sets up the delivery confirmation route used by the carrier webhook and the
+// driver app. Callers authenticate with the shared secret; without one the route rejects every call.
+func SetupDeliveryRoutes(api *gin.RouterGroup, handler *Handler, secret string) {
+	api.POST("/deliveries/confirm", deliveryTokenMiddleware(secret), handler.ConfirmDelivery)
+}
+
+// deliveryTokenMiddleware rejects requests whose delivery token does not match secret
+func deliveryTokenMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader(DeliveryTokenHeader)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_DELIVERY_TOKEN", "Invalid delivery token", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
 	}
 }
@@ -0,0 +1,68 @@
+package orders
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderFilters narrows the admin order list. Empty fields are ignored; text is matched case-insensitively.
+type OrderFilters struct {
+	Status string
+	UserID string
+	// Query matches orders by ID prefix, customer name or email, or the SKU of one of their items
+	Query    string
+	OrderID  string // order ID prefix
+	Customer string // part of the customer's name or email
+	SKU      string // SKU prefix of one of the order's items
+	From     *time.Time
+	To       *time.Time // exclusive
+}
+
+// apply adds the filters to an orders query
+func (f OrderFilters) apply(query *gorm.DB) *gorm.DB {
+	if f.Status != "" {
+		query = query.Where("orders.status = ?", f.Status)
+	}
+	if f.UserID != "" {
+		query = query.Where("orders.user_id = ?", f.UserID)
+	}
+	if f.OrderID != "" {
+		query = query.Where("orders.id LIKE ?", prefixPattern(f.OrderID))
+	}
+	if f.Customer != "" {
+		query = query.Where(customerCondition, containsPattern(f.Customer), containsPattern(f.Customer))
+	}
+	if f.SKU != "" {
+		query = query.Where(skuCondition, prefixPattern(f.SKU))
+	}
+	if f.Query != "" {
+		prefix, contains := prefixPattern(f.Query), containsPattern(f.Query)
+		query = query.Where("(orders.id LIKE ? OR "+customerCondition+" OR "+skuCondition+")", prefix, contains, contains, prefix)
+	}
+	if f.From != nil {
+		query = query.Where("orders.created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("orders.created_at < ?", *f.To)
+	}
+	return query
+}
+
+const (
+	// customerCondition matches orders of customers whose email or full name contains a pattern
+	customerCondition = "orders.user_id IN (SELECT id FROM users WHERE LOWER(email) LIKE ? OR LOWER(first_name || ' ' || last_name) LIKE ?)"
+	// skuCondition matches orders with an item whose product SKU matches a pattern
+	skuCondition = "EXISTS (SELECT 1 FROM order_items JOIN products ON products.id = order_items.product_id WHERE order_items.order_id = orders.id AND LOWER(products.sku) LIKE ?)"
+)
+
+// prefixPattern is a LIKE pattern matching text starting with s
+func prefixPattern(s string) string {
+	return strings.ToLower(strings.TrimSpace(s)) + "%"
+}
+
+// containsPattern is a LIKE pattern matching text containing s
+func containsPattern(s string) string {
+	return "%" + strings.ToLower(strings.TrimSpace(s)) + "%"
+}
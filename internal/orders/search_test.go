@@ -0,0 +1,58 @@
+package orders
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderService_GetAllOrdersSearch(t *testing.T) {
+	db, service, helpers, _ := setupPolicyTest(t)
+	ctx := context.Background()
+
+	category := helpers.CreateTestCategory(t, "Search")
+	headset := createPolicyProduct(t, db, category.ID, "HS-100")
+	charger := createPolicyProduct(t, db, category.ID, "CH-200")
+
+	alice := helpers.CreateTestUser(t, "alice@example.com")
+	require.NoError(t, db.Model(alice).Updates(map[string]interface{}{"first_name": "Alice", "last_name": "Walker"}).Error)
+	bob := helpers.CreateTestUser(t, "bob@shop.test")
+
+	aliceOrder := helpers.CreateTestOrder(t, alice.ID, "pending")
+	helpers.CreateTestOrderItem(t, aliceOrder.ID, headset.ID, 1, 10)
+	bobOrder := helpers.CreateTestOrder(t, bob.ID, "shipped")
+	helpers.CreateTestOrderItem(t, bobOrder.ID, charger.ID, 1, 10)
+	oldOrder := helpers.CreateTestOrder(t, bob.ID, "delivered")
+	helpers.CreateTestOrderItem(t, oldOrder.ID, headset.ID, 2, 10)
+	require.NoError(t, db.Model(oldOrder).Update("created_at", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)).Error)
+
+	search := func(filters OrderFilters) []string {
+		orders, total, err := service.GetAllOrders(ctx, 1, 10, filters)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(orders)), total)
+		ids := make([]string, len(orders))
+		for i, order := range orders {
+			ids[i] = order.ID
+		}
+		return ids
+	}
+
+	assert.Len(t, search(OrderFilters{}), 3)
+	assert.Equal(t, []string{aliceOrder.ID}, search(OrderFilters{OrderID: aliceOrder.ID[:8]}))
+	assert.Equal(t, []string{aliceOrder.ID}, search(OrderFilters{Customer: "alice walk"}))
+	assert.ElementsMatch(t, []string{bobOrder.ID, oldOrder.ID}, search(OrderFilters{Customer: "SHOP.TEST"}))
+	assert.ElementsMatch(t, []string{aliceOrder.ID, oldOrder.ID}, search(OrderFilters{SKU: "hs-"}))
+	assert.Equal(t, []string{oldOrder.ID}, search(OrderFilters{SKU: "hs-", UserID: bob.ID}))
+	assert.Equal(t, []string{bobOrder.ID}, search(OrderFilters{Query: "ch-2"}))
+	assert.Equal(t, []string{aliceOrder.ID}, search(OrderFilters{Query: "ALICE@"}))
+	assert.Equal(t, []string{bobOrder.ID}, search(OrderFilters{Query: "bob", Status: "shipped"}))
+	assert.Empty(t, search(OrderFilters{Query: "nobody"}))
+
+	from := time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	assert.Equal(t, []string{oldOrder.ID}, search(OrderFilters{From: &from, To: &to}))
+	assert.ElementsMatch(t, []string{aliceOrder.ID, bobOrder.ID}, search(OrderFilters{From: &to}))
+}
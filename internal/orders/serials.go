@@ -0,0 +1,187 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSerials  = errors.New("invalid serial numbers")
+	ErrSerialsRequired = errors.New("serial numbers are required")
+	ErrDuplicateSerial = errors.New("serial number is already recorded")
+	ErrSerialNotFound  = errors.New("serial number not found")
+)
+
+// maxSerialLength is the longest serial number or IMEI accepted
+const maxSerialLength = 64
+
+// ItemSerialsRequest records the serial numbers of the units of one order item packed in a shipment
+type ItemSerialsRequest struct {
+	OrderItemID string   `json:"orderItemId" binding:"required"`
+	Serials     []string `json:"serials" binding:"required,min=1"`
+}
+
+// SerialRecord is a recorded serial number with the order, customer and shipment it belongs to
+type SerialRecord struct {
+	Serial        string     `json:"serial"`
+	ProductID     string     `json:"productId"`
+	ProductName   string     `json:"productName"`
+	SKU           string     `json:"sku"`
+	OrderID       string     `json:"orderId"`
+	OrderItemID   string     `json:"orderItemId"`
+	ShipmentID    string     `json:"shipmentId"`
+	CustomerID    string     `json:"customerId,omitempty"`
+	CustomerEmail string     `json:"customerEmail,omitempty"`
+	OrderedAt     time.Time  `json:"orderedAt"`
+	ShippedAt     *time.Time `json:"shippedAt,omitempty"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// recordShipmentSerials saves the serials supplied for items of a shipment that is leaving the warehouse.
+// When required, every unit of a serial-tracked item in the shipment must end up with a serial.
+func recordShipmentSerials(tx *gorm.DB, orderID, shipmentID string, requests []ItemSerialsRequest, recordedBy string, required bool) error {
+	var items []models.OrderItem
+	if err := tx.Preload("Product.Category").Preload("Serials").
+		Where("order_id = ? AND shipment_id = ?", orderID, shipmentID).Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to get shipment items: %w", err)
+	}
+	byID := make(map[string]*models.OrderItem, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+
+	for _, req := range requests {
+		item, ok := byID[req.OrderItemID]
+		if !ok {
+			return fmt.Errorf("%w: item %s is not in this shipment", ErrInvalidSerials, req.OrderItemID)
+		}
+		if !item.Product.Category.SerialTracked {
+			return fmt.Errorf("%w: %s is not serial-tracked", ErrInvalidSerials, item.Product.Name)
+		}
+		if len(item.Serials)+len(req.Serials) > item.Quantity {
+			return fmt.Errorf("%w: %s has %d units", ErrInvalidSerials, item.Product.Name, item.Quantity)
+		}
+
+		serials := make([]string, 0, len(req.Serials))
+		seen := make(map[string]bool, len(req.Serials))
+		for _, serial := range req.Serials {
+			serial = strings.TrimSpace(serial)
+			if serial == "" || len(serial) > maxSerialLength {
+				return fmt.Errorf("%w: serials must be 1 to %d characters", ErrInvalidSerials, maxSerialLength)
+			}
+			if seen[serial] {
+				return fmt.Errorf("%w: %s is listed twice", ErrDuplicateSerial, serial)
+			}
+			seen[serial] = true
+			serials = append(serials, serial)
+		}
+
+		var taken []string
+		if err := tx.Model(&models.OrderItemSerial{}).
+			Where("product_id = ? AND serial IN ?", item.ProductID, serials).Pluck("serial", &taken).Error; err != nil {
+			return fmt.Errorf("failed to check serial numbers: %w", err)
+		}
+		if len(taken) > 0 {
+			return fmt.Errorf("%w: %s", ErrDuplicateSerial, strings.Join(taken, ", "))
+		}
+
+		for _, serial := range serials {
+			record := models.OrderItemSerial{
+				OrderID:     orderID,
+				OrderItemID: item.ID,
+				ShipmentID:  shipmentID,
+				ProductID:   item.ProductID,
+				Serial:      serial,
+				RecordedBy:  recordedBy,
+			}
+			if err := tx.Create(&record).Error; err != nil {
+				return fmt.Errorf("failed to record serial number: %w", err)
+			}
+			item.Serials = append(item.Serials, record)
+		}
+	}
+
+	if required {
+		return checkSerials(items)
+	}
+	return nil
+}
+
+// checkSerials fails unless every unit of the serial-tracked items has a serial
+func checkSerials(items []models.OrderItem) error {
+	for _, item := range items {
+		if item.Product.Category.SerialTracked && len(item.Serials) < item.Quantity {
+			return fmt.Errorf("%w: %s needs %d more", ErrSerialsRequired, item.Product.Name, item.Quantity-len(item.Serials))
+		}
+	}
+	return nil
+}
+
+// checkPendingShipmentSerials fails if shipping the order's pending shipments would send serial-tracked units
+// without serials; they must be shipped one shipment at a time with their serials instead
+func checkPendingShipmentSerials(tx *gorm.DB, orderID string) error {
+	var items []models.OrderItem
+	if err := tx.Preload("Product.Category").Preload("Serials").
+		Where("order_id = ? AND shipment_id IN (?)", orderID,
+			tx.Model(&models.Shipment{}).Select("id").Where("order_id = ? AND status = ?", orderID, "pending")).
+		Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to get shipment items: %w", err)
+	}
+	return checkSerials(items)
+}
+
+// serialRecords selects serial numbers with their order, product, shipment and customer
+func (s *Service) serialRecords(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(ctx).Table("order_item_serials AS s").
+		Select("s.serial, s.product_id, p.name AS product_name, p.sku, s.order_id, s.order_item_id, s.shipment_id, " +
+			"o.user_id AS customer_id, u.email AS customer_email, o.created_at AS ordered_at, sh.shipped_at, sh.delivered_at").
+		Joins("JOIN products p ON p.id = s.product_id").
+		Joins("JOIN orders o ON o.id = s.order_id").
+		Joins("JOIN users u ON u.id = o.user_id").
+		Joins("LEFT JOIN shipments sh ON sh.id = s.shipment_id")
+}
+
+// SearchSerials finds recorded serial numbers starting with query, ignoring case (admin only), newest first
+func (s *Service) SearchSerials(ctx context.Context, query string, page, limit int) ([]SerialRecord, int64, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, 0, fmt.Errorf("%w: a search term is required", ErrInvalidSerials)
+	}
+	pattern := strings.ToLower(query) + "%"
+
+	var total int64
+	if err := s.db.WithContext(ctx).Model(&models.OrderItemSerial{}).Where("LOWER(serial) LIKE ?", pattern).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count serial numbers: %w", err)
+	}
+
+	records := []SerialRecord{}
+	if err := s.serialRecords(ctx).Where("LOWER(s.serial) LIKE ?", pattern).
+		Order("s.created_at DESC").Limit(limit).Offset((page - 1) * limit).
+		Scan(&records).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to search serial numbers: %w", err)
+	}
+	return records, total, nil
+}
+
+// LookupWarranty returns the purchase of the unit with serial so a customer can claim warranty on it; customers
+// only find units of their own orders
+func (s *Service) LookupWarranty(ctx context.Context, serial, userID string) (*SerialRecord, error) {
+	var records []SerialRecord
+	if err := s.serialRecords(ctx).Where("s.serial = ? AND o.user_id = ?", strings.TrimSpace(serial), userID).
+		Order("s.created_at DESC").Scan(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up serial number: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrSerialNotFound
+	}
+	record := records[0]
+	record.CustomerID, record.CustomerEmail = "", ""
+	return &record, nil
+}
@@ -0,0 +1,110 @@
+package orders
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ShipmentSerials(t *testing.T) {
+	db, service, helpers, _ := setupPolicyTest(t)
+
+	user := helpers.CreateTestUser(t, "serials@example.com")
+	phones := helpers.CreateTestCategory(t, "phones")
+	require.NoError(t, db.Model(phones).Update("serial_tracked", true).Error)
+	phone := createPolicyProduct(t, db, phones.ID, "PHONE-SKU")
+	cases := createPolicyProduct(t, db, helpers.CreateTestCategory(t, "cases").ID, "CASE-SKU")
+
+	newOrder := func() (*models.Order, models.Shipment, *models.OrderItem) {
+		order := helpers.CreateTestOrder(t, user.ID, "paid")
+		shipment := models.Shipment{OrderID: order.ID, GroupKey: DefaultShipmentGroup, Status: "pending"}
+		require.NoError(t, db.Create(&shipment).Error)
+		item := helpers.CreateTestOrderItem(t, order.ID, phone.ID, 2, 10)
+		accessory := helpers.CreateTestOrderItem(t, order.ID, cases.ID, 1, 5)
+		require.NoError(t, db.Model(&models.OrderItem{}).Where("id IN ?", []string{item.ID, accessory.ID}).Update("shipment_id", shipment.ID).Error)
+		return order, shipment, item
+	}
+	ship := func(order *models.Order, shipment models.Shipment, serials ...ItemSerialsRequest) (*models.Order, error) {
+		return service.UpdateShipmentStatus(context.Background(), order.ID, shipment.ID, &UpdateShipmentStatusRequest{Status: "shipped", Serials: serials}, "admin-1")
+	}
+
+	order, shipment, item := newOrder()
+
+	_, err := ship(order, shipment)
+	assert.ErrorIs(t, err, ErrSerialsRequired)
+	_, err = service.UpdateOrderStatus(context.Background(), order.ID, "shipped", "admin-1", "")
+	assert.ErrorIs(t, err, ErrSerialsRequired, "shipping the whole order cannot skip serials")
+
+	_, err = ship(order, shipment, ItemSerialsRequest{OrderItemID: item.ID, Serials: []string{"IMEI-1"}})
+	assert.ErrorIs(t, err, ErrSerialsRequired, "every unit needs a serial")
+	_, err = ship(order, shipment, ItemSerialsRequest{OrderItemID: item.ID, Serials: []string{"IMEI-1", "IMEI-1"}})
+	assert.ErrorIs(t, err, ErrDuplicateSerial)
+	_, err = ship(order, shipment, ItemSerialsRequest{OrderItemID: item.ID, Serials: []string{"IMEI-1", "IMEI-2", "IMEI-3"}})
+	assert.ErrorIs(t, err, ErrInvalidSerials)
+
+	var count int64
+	db.Model(&models.OrderItemSerial{}).Count(&count)
+	assert.Zero(t, count, "rejected shipments record no serials")
+
+	shipped, err := ship(order, shipment, ItemSerialsRequest{OrderItemID: item.ID, Serials: []string{" IMEI-1 ", "IMEI-2"}})
+	require.NoError(t, err)
+	assert.Equal(t, "shipped", shipped.Status)
+	for _, shippedItem := range shipped.Items {
+		if shippedItem.ID == item.ID {
+			require.Len(t, shippedItem.Serials, 2)
+			assert.Equal(t, "IMEI-1", shippedItem.Serials[0].Serial)
+		}
+	}
+	lines := packingSlipLines(shipped, false)
+	assert.Contains(t, lines, "    Serial: IMEI-1")
+	assert.Contains(t, lines, "    Serial: IMEI-2")
+
+	t.Run("serials are unique per product", func(t *testing.T) {
+		other, otherShipment, otherItem := newOrder()
+		_, err := ship(other, otherShipment, ItemSerialsRequest{OrderItemID: otherItem.ID, Serials: []string{"IMEI-2", "IMEI-9"}})
+		assert.ErrorIs(t, err, ErrDuplicateSerial)
+	})
+
+	t.Run("admins search by prefix and customers look up their own units", func(t *testing.T) {
+		records, total, err := service.SearchSerials(context.Background(), "imei", 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		require.Len(t, records, 2)
+		assert.Equal(t, order.ID, records[0].OrderID)
+		assert.Equal(t, "serials@example.com", records[0].CustomerEmail)
+		assert.NotNil(t, records[0].ShippedAt)
+
+		record, err := service.LookupWarranty(context.Background(), "IMEI-1", user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "PHONE-SKU", record.SKU)
+		assert.Empty(t, record.CustomerEmail)
+
+		_, err = service.LookupWarranty(context.Background(), "IMEI-1", "someone-else")
+		assert.ErrorIs(t, err, ErrSerialNotFound)
+	})
+}
+
+func TestHandler_SearchSerials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("SearchSerials", mock.Anything, "IMEI", 1, 20).Return([]SerialRecord{{Serial: "IMEI-1", OrderID: "order-1"}}, int64(1), nil)
+
+	r := gin.New()
+	r.GET("/api/admin/serials", NewHandler(mockService).SearchSerials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/admin/serials?q=IMEI", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "IMEI-1")
+	mockService.AssertExpectations(t)
+}
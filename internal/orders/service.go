@@ -2,70 +2,217 @@ package orders
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
+	"time"
 
+	"ecommerce-website/internal/adminevents"
 	"ecommerce-website/internal/cart"
 	"ecommerce-website/internal/email"
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/inventory"
+	"ecommerce-website/internal/invoices"
+	"ecommerce-website/internal/logger"
 	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/orderevents"
+	"ecommerce-website/internal/orderhistory"
+	"ecommerce-website/internal/outbox"
+	"ecommerce-website/internal/settings"
+	"ecommerce-website/internal/shipping"
+	"ecommerce-website/internal/sms"
+	"ecommerce-website/internal/tax"
+	"ecommerce-website/internal/webhooks"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ServiceInterface defines the interface for orders service
 type ServiceInterface interface {
 	CreateOrder(ctx context.Context, userID string, req *CreateOrderRequest) (*models.Order, error)
-	GetOrder(orderID string, userID string) (*models.Order, error)
-	GetUserOrders(userID string, page, limit int) ([]models.Order, int64, error)
-	GetAllOrders(page, limit int, status, userID string) ([]models.Order, int64, error)
-	UpdateOrderStatus(orderID string, status string) (*models.Order, error)
-	GetAllCustomers(page, limit int, search string) ([]models.User, int64, error)
+	GetOrder(ctx context.Context, orderID string, userID string) (*models.Order, error)
+	GetInvoice(ctx context.Context, orderID, userID string) (*models.Order, []byte, error)
+	GetUserOrders(ctx context.Context, userID string, page, limit int) ([]models.Order, int64, error)
+	GetAllOrders(ctx context.Context, page, limit int, filters OrderFilters) ([]models.Order, int64, error)
+	UpdateOrderStatus(ctx context.Context, orderID, status, adminID, note string) (*models.Order, error)
+	GetAllCustomers(ctx context.Context, page, limit int, search string) ([]models.User, int64, error)
+	GetCustomer(ctx context.Context, customerID string) (*CustomerDetail, error)
+	CancelOrder(ctx context.Context, orderID, userID string, req *CancelOrderRequest) (*models.Order, error)
+	GetOrderHistory(ctx context.Context, orderID, userID string) ([]models.OrderStatusHistory, error)
+	GetOrderComments(ctx context.Context, orderID string) ([]models.OrderComment, error)
+	AddOrderComment(ctx context.Context, orderID, adminID string, req *CreateOrderCommentRequest) (*models.OrderComment, error)
+	UpdateOrderComment(ctx context.Context, orderID, commentID string, req *UpdateOrderCommentRequest) (*models.OrderComment, error)
+	DeleteOrderComment(ctx context.Context, orderID, commentID string) error
+	CreateDraftOrder(ctx context.Context, adminID string, req *DraftOrderRequest) (*models.DraftOrder, error)
+	UpdateDraftOrder(ctx context.Context, draftID string, req *DraftOrderRequest) (*models.DraftOrder, error)
+	GetDraftOrder(ctx context.Context, draftID, userID string) (*models.DraftOrder, error)
+	GetDraftOrders(ctx context.Context, page, limit int, filters DraftOrderFilters) ([]models.DraftOrder, int64, error)
+	SendDraftOrderInvoice(ctx context.Context, draftID string) (*models.DraftOrder, error)
+	CancelDraftOrder(ctx context.Context, draftID string) (*models.DraftOrder, error)
+	CheckoutDraftOrder(ctx context.Context, draftID, userID string) (*models.Order, error)
+	SearchSerials(ctx context.Context, query string, page, limit int) ([]SerialRecord, int64, error)
+	LookupWarranty(ctx context.Context, serial, userID string) (*SerialRecord, error)
+	CreateReturnRequest(ctx context.Context, orderID, userID string, req *CreateReturnRequest) ([]models.ReturnRequest, error)
+	GetReturns(ctx context.Context, orderID, userID string) ([]models.ReturnRequest, error)
+	GetAllReturns(ctx context.Context, status string, page, limit int) ([]models.ReturnRequest, int64, error)
+	ApproveReturn(ctx context.Context, returnID string, req *ApproveReturnRequest, adminID string) (*models.ReturnRequest, error)
+	RejectReturn(ctx context.Context, returnID string, req *RejectReturnRequest, adminID string) (*models.ReturnRequest, error)
+	CompleteReturn(ctx context.Context, returnID string, req *CompleteReturnRequest, adminID string) (*models.ReturnRequest, error)
+	GetReturnLabel(ctx context.Context, returnID, userID string) (*models.ReturnRequest, []byte, error)
+	UpdateShipmentStatus(ctx context.Context, orderID, shipmentID string, req *UpdateShipmentStatusRequest, adminID string) (*models.Order, error)
+	CreateShipment(ctx context.Context, orderID string, req *CreateShipmentRequest, adminID string) (*models.Order, error)
+	GetShipments(ctx context.Context, orderID, userID string) (*OrderTracking, error)
+	ConfirmDelivery(ctx context.Context, req *ConfirmDeliveryRequest) (*models.Order, error)
+	GetReviewForm(ctx context.Context, orderID, token string) (*ReviewForm, error)
+	GetOrderEvents(ctx context.Context, orderID string) ([]models.OrderEvent, error)
+	ReplayOrderEvents(ctx context.Context, orderID string, upTo int) (*OrderReplay, error)
+	RebuildOrderFromEvents(ctx context.Context, orderID string) (*models.Order, error)
 }
 
 type Service struct {
 	db           *gorm.DB
 	cartService  cart.ServiceInterface
 	emailService email.ServiceInterface
+	settings     settings.Reader
+	taxes        tax.ServiceInterface
+	shipping     shipping.ServiceInterface
+	reviewSecret []byte             // signs review request links; links are left out when empty
+	refunds      Refunder           // refunds cancelled orders and completed returns; no refunds are issued when nil
+	outbox       *outbox.Dispatcher // records status and refund emails with the change when set
+	smsService   *sms.Service       // texts status updates to customers who opted in; none are sent when nil
 }
 
 // NewService creates a new orders service
 func NewService(db *gorm.DB) *Service {
+	settingsService := settings.NewService(db)
+	cartService := cart.NewService()
 	return &Service{
 		db:           db,
-		cartService:  cart.NewService(),
-		emailService: email.NewService(),
+		cartService:  cartService,
+		emailService: email.NewServiceWithSettings(settingsService).WithLog(db),
+		settings:     settingsService,
+		taxes:        tax.NewService(db, settingsService),
+		shipping:     shipping.NewService(db, cartService),
 	}
 }
 
+// NewServiceWithReviewLinks creates a new orders service whose review request emails link to the
+// review form, signed with secret
+func NewServiceWithReviewLinks(db *gorm.DB, secret string) *Service {
+	service := NewService(db)
+	service.reviewSecret = []byte(secret)
+	return service
+}
+
 // NewServiceWithCartService creates a new orders service with a provided cart service
 func NewServiceWithCartService(db *gorm.DB, cartService cart.ServiceInterface) *Service {
+	settingsService := settings.NewService(db)
 	return &Service{
 		db:           db,
 		cartService:  cartService,
 		emailService: email.NewService(),
+		settings:     settingsService,
+		taxes:        tax.NewService(db, settingsService),
+		shipping:     shipping.NewService(db, cartService),
 	}
 }
 
 // NewServiceWithDependencies creates a new orders service with all dependencies
 func NewServiceWithDependencies(db *gorm.DB, cartService cart.ServiceInterface, emailService email.ServiceInterface) *Service {
+	settingsService := settings.NewService(db)
 	return &Service{
 		db:           db,
 		cartService:  cartService,
 		emailService: emailService,
+		settings:     settingsService,
+		taxes:        tax.NewService(db, settingsService),
+		shipping:     shipping.NewService(db, cartService),
+	}
+}
+
+// WithOutbox makes the service send order status and refund emails through the outbox: they are recorded in
+// the transaction of the change and retried until sent. It returns the service for chaining after a constructor.
+func (s *Service) WithOutbox(dispatcher *outbox.Dispatcher) *Service {
+	s.outbox = dispatcher
+	email.RegisterOutbox(dispatcher, s.emailService, s.db)
+	if s.smsService.Enabled() {
+		sms.RegisterOutbox(dispatcher, s.smsService, s.db)
+	}
+	return s
+}
+
+// WithSMS makes the service text order status updates with notifier, alongside the status emails, to customers
+// who opted in to texts. A nil notifier sends none. It returns the service for chaining after a constructor.
+func (s *Service) WithSMS(notifier sms.Notifier) *Service {
+	s.smsService = sms.NewService(notifier)
+	if s.outbox != nil && s.smsService.Enabled() {
+		sms.RegisterOutbox(s.outbox, s.smsService, s.db)
+	}
+	return s
+}
+
+// queueStatusNotifications records the order status email, and the text when SMS is configured, in tx, the
+// transaction of a status change. Without an outbox it records nothing and they are sent once the change is
+// committed. Customer preferences are checked when they go out.
+func (s *Service) queueStatusNotifications(tx *gorm.DB, orderID, oldStatus, newStatus string) error {
+	if s.outbox == nil {
+		return nil
+	}
+	if err := email.QueueOrderStatusUpdate(tx, orderID, oldStatus, newStatus); err != nil {
+		return err
+	}
+	if s.smsService.Enabled() {
+		return sms.QueueOrderStatusUpdate(tx, orderID, oldStatus, newStatus)
+	}
+	return nil
+}
+
+// sendStatusNotifications emails and texts the customer about a committed status change when there is no
+// outbox to send them. Failures are logged and never undo the change.
+func (s *Service) sendStatusNotifications(order *models.Order, oldStatus, newStatus string) {
+	if err := s.emailService.SendOrderStatusUpdate(order, oldStatus, newStatus); err != nil {
+		logger.Named("orders").Warn("Failed to send order status update email", map[string]interface{}{"order_id": order.ID, "error": err.Error()})
+	}
+	if err := s.smsService.SendOrderStatusUpdate(order, oldStatus, newStatus); err != nil {
+		logger.Named("orders").Warn("Failed to send order status update SMS", map[string]interface{}{"order_id": order.ID, "error": err.Error()})
 	}
 }
 
+// ErrBelowMinimumOrder is returned when the cart subtotal is below the configured checkout minimum
+var ErrBelowMinimumOrder = errors.New("order is below the minimum order amount")
+
 // CreateOrderRequest represents the request to create an order
 type CreateOrderRequest struct {
-	SessionID       string              `json:"sessionId" binding:"required"`
-	ShippingAddress models.OrderAddress `json:"shippingAddress" binding:"required"`
-	BillingAddress  models.OrderAddress `json:"billingAddress" binding:"required"`
-	PaymentIntentID string              `json:"paymentIntentId" binding:"required"`
-	Notes           *string             `json:"notes,omitempty"`
+	SessionID         string              `json:"sessionId" binding:"required"` // cart ID: the guest session or the user's cart
+	ShippingAddress   models.OrderAddress `json:"shippingAddress"`              // omit to use ShippingAddressID or the default saved shipping address
+	BillingAddress    models.OrderAddress `json:"billingAddress"`               // omit to use BillingAddressID, the default saved billing address or the shipping address
+	ShippingAddressID *string             `json:"shippingAddressId,omitempty"`  // a saved address of the user, copied onto the order
+	BillingAddressID  *string             `json:"billingAddressId,omitempty"`   // a saved address of the user, copied onto the order
+	PaymentIntentID   string              `json:"paymentIntentId" binding:"required"`
+	Notes             *string             `json:"notes,omitempty"`
+	// Gift orders ship with a price-hidden packing slip and notify the recipient without prices
+	IsGift             bool    `json:"isGift"`
+	GiftMessage        *string `json:"giftMessage,omitempty" binding:"omitempty,max=500"`
+	GiftRecipientEmail *string `json:"giftRecipientEmail,omitempty" binding:"omitempty,email"`
+	// Items can be split across extra addresses; unassigned units ship to ShippingAddress
+	ShippingGroups  []ShippingGroupRequest  `json:"shippingGroups,omitempty" binding:"omitempty,dive"`
+	ItemAssignments []ItemAssignmentRequest `json:"itemAssignments,omitempty" binding:"omitempty,dive"`
+	// Every shipment is priced by the chosen method; without one the store's default shipping fees apply
+	ShippingMethodID *string `json:"shippingMethodId,omitempty"`
+	// A single-use promotion code, taking its promotion's discount off the items it covers before tax
+	PromoCode *string `json:"promoCode,omitempty" binding:"omitempty,max=64"`
 }
 
 // CreateOrder creates a new order from cart items
 func (s *Service) CreateOrder(ctx context.Context, userID string, req *CreateOrderRequest) (*models.Order, error) {
+	// A user's persistent cart can only be checked out by that user
+	if owner, ok := cart.CartOwner(req.SessionID); ok && owner != userID {
+		return nil, fmt.Errorf("cart is empty")
+	}
+
 	// Get cart with products
 	cart, err := s.cartService.GetCartWithProducts(ctx, req.SessionID)
 	if err != nil {
@@ -77,6 +224,40 @@ func (s *Service) CreateOrder(ctx context.Context, userID string, req *CreateOrd
 		return nil, fmt.Errorf("cart is empty")
 	}
 
+	// Copy saved or default addresses onto the request before planning shipments to them
+	if err := s.resolveCheckoutAddresses(ctx, userID, &req.ShippingAddress, &req.BillingAddress, req.ShippingAddressID, req.BillingAddressID); err != nil {
+		return nil, err
+	}
+
+	// Split the cart into shipment groups before touching inventory
+	plannedShipments, err := planShipments(req, cart.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the business settings that price the order before taking a connection for the transaction
+	minimumOrder := s.settings.GetFloat(ctx, settings.KeyMinOrderAmount)
+	shipmentFee := s.settings.GetFloat(ctx, settings.KeyAdditionalShipmentFee)
+	freeShippingThreshold := s.settings.GetFloat(ctx, settings.KeyFreeShippingThreshold)
+	recordEvents := orderevents.Enabled(ctx, s.settings)
+	taxTable, err := s.taxes.Table(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var shippingMethod *models.ShippingMethod
+	zones := make([]*models.DeliveryZone, len(plannedShipments))
+	if req.ShippingMethodID != nil {
+		if shippingMethod, err = s.shipping.Method(ctx, *req.ShippingMethodID); err != nil {
+			return nil, err
+		}
+		// The method is priced for the delivery zone of each shipment's address
+		for i, planned := range plannedShipments {
+			if zones[i], err = s.shipping.ZoneFor(ctx, userID, planned.Address); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Start database transaction
 	tx := s.db.Begin()
 	defer func() {
@@ -85,78 +266,132 @@ func (s *Service) CreateOrder(ctx context.Context, userID string, req *CreateOrd
 		}
 	}()
 
-	// Validate inventory and calculate totals
-	var orderItems []models.OrderItem
+	// Validate inventory and take the stock of every cart line
+	stocked, lowStock, err := takeStock(tx, cart.Items)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	prices := make(map[string]float64, len(stocked))
+	weights := make(map[string]float64, len(stocked))
 	var subtotal float64
-
-	for _, cartItem := range cart.Items {
-		// Get current product to check inventory
-		var product models.Product
-		if err := tx.Where("id = ?", cartItem.ProductID).First(&product).Error; err != nil {
-			tx.Rollback()
-			return nil, fmt.Errorf("product not found: %s", cartItem.ProductID)
+	for _, line := range stocked {
+		prices[line.ProductID] = line.Product.Price // Use current price from database
+		if line.Product.WeightGrams != nil {
+			weights[line.ProductID] = *line.Product.WeightGrams
 		}
+		subtotal += line.Product.Price * float64(line.Quantity)
+	}
 
-		// Check if product is active
-		if !product.IsActive {
+	// A promo code discounts the lines its promotion covers, and must cover at least one
+	now := time.Now()
+	var promo *promoCode
+	var discounts map[string]float64
+	if req.PromoCode != nil && strings.TrimSpace(*req.PromoCode) != "" {
+		if promo, err = loadPromoCode(tx, *req.PromoCode, now); err != nil {
 			tx.Rollback()
-			return nil, fmt.Errorf("product %s is no longer available", product.Name)
+			return nil, err
 		}
-
-		// Check inventory
-		if product.Inventory < cartItem.Quantity {
+		if discounts = promo.unitDiscounts(stocked); len(discounts) == 0 {
 			tx.Rollback()
-			return nil, fmt.Errorf("insufficient inventory for product %s: only %d available", product.Name, product.Inventory)
+			return nil, ErrPromoCodeNotApplicable
 		}
+	}
 
-		// Update inventory
-		if err := tx.Model(&product).Update("inventory", product.Inventory-cartItem.Quantity).Error; err != nil {
-			tx.Rollback()
-			return nil, fmt.Errorf("failed to update inventory for product %s: %w", product.Name, err)
+	// Reject orders below the configured checkout minimum
+	if minimumOrder > 0 && subtotal < minimumOrder {
+		tx.Rollback()
+		return nil, fmt.Errorf("%w: subtotal %.2f is below %.2f", ErrBelowMinimumOrder, subtotal, minimumOrder)
+	}
+
+	// Build one order item per cart line and shipment group. Shipping is charged per group by the
+	// chosen method, or else by the extra-shipment fee unless the subtotal reaches the free-shipping threshold
+	if freeShippingThreshold > 0 && subtotal >= freeShippingThreshold {
+		shipmentFee = 0
+	}
+	shipments := make([]models.Shipment, len(plannedShipments))
+	shipmentItems := make([][]models.OrderItem, len(plannedShipments))
+	shippingTotal := 0.0
+	discount := 0.0
+	taxCharges := make([]tax.Charge, len(plannedShipments))
+	for i, planned := range plannedShipments {
+		taxCharges[i].Address = planned.Address
+		shipments[i] = models.Shipment{
+			GroupKey: planned.Key,
+			Address:  planned.Address,
+			Shipping: shipmentShipping(i, shipmentFee),
+			Status:   "pending",
 		}
-
-		// Create order item
-		orderItem := models.OrderItem{
-			ProductID: cartItem.ProductID,
-			Quantity:  cartItem.Quantity,
-			Price:     product.Price, // Use current price from database
-			Total:     product.Price * float64(cartItem.Quantity),
+		weight := 0.0
+		for _, line := range planned.Lines {
+			weight += weights[line.CartItem.ProductID] * float64(line.Quantity)
+			price := prices[line.CartItem.ProductID]
+			lineDiscount := math.Round(discounts[line.CartItem.ProductID]*float64(line.Quantity)*100) / 100
+			shipmentItems[i] = append(shipmentItems[i], models.OrderItem{
+				ProductID: line.CartItem.ProductID,
+				Quantity:  line.Quantity,
+				Price:     price,
+				Total:     price * float64(line.Quantity),
+				Discount:  lineDiscount,
+				Source:    line.CartItem.Source,
+			})
+			discount += lineDiscount
+			taxCharges[i].Amount += price*float64(line.Quantity) - lineDiscount
+		}
+		if shippingMethod != nil {
+			cost, ok := shipping.Price(shippingMethod, shipping.Parcel{Country: planned.Address.Country, Zone: zones[i], Subtotal: subtotal, WeightGrams: weight})
+			if !ok {
+				tx.Rollback()
+				return nil, fmt.Errorf("%w: %s does not ship to %s %s", shipping.ErrMethodUnavailable, shippingMethod.Name, planned.Address.Country, planned.Address.PostalCode)
+			}
+			shipments[i].Shipping = cost
+			if zones[i] != nil {
+				shipments[i].DeliveryZoneID = &zones[i].ID
+			}
 		}
-		orderItems = append(orderItems, orderItem)
-		subtotal += orderItem.Total
+		shippingTotal += shipments[i].Shipping
 	}
 
-	// Calculate tax and shipping (for now, these are 0)
-	tax := 0.0
-	shipping := 0.0
-	total := subtotal + tax + shipping
+	// Each shipment is taxed by the rules of the address it ships to
+	taxBreakdown, taxAmount := taxTable.Calculate(taxCharges)
+	total := subtotal - discount + taxAmount + shippingTotal
 
 	// Create order
 	order := models.Order{
 		UserID:          userID,
 		Status:          "pending",
 		Subtotal:        subtotal,
-		Tax:             tax,
-		Shipping:        shipping,
+		Discount:        discount,
+		Tax:             taxAmount,
+		TaxBreakdown:    taxBreakdown,
+		Shipping:        shippingTotal,
 		Total:           total,
 		ShippingAddress: req.ShippingAddress,
 		BillingAddress:  req.BillingAddress,
 		PaymentIntentID: req.PaymentIntentID,
 		Notes:           req.Notes,
+		IsGift:          req.IsGift,
 	}
 
-	// Save order
-	if err := tx.Create(&order).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to create order: %w", err)
+	if shippingMethod != nil {
+		order.ShippingMethodID = &shippingMethod.ID
+		order.ShippingMethodName = &shippingMethod.Name
+	}
+
+	// Gift details are only kept on gift orders
+	if req.IsGift {
+		order.GiftMessage = req.GiftMessage
+		order.GiftRecipientEmail = req.GiftRecipientEmail
 	}
 
-	// Save order items
-	for i := range orderItems {
-		orderItems[i].OrderID = order.ID
-		if err := tx.Create(&orderItems[i]).Error; err != nil {
+	if err := s.saveOrder(tx, &order, shipments, shipmentItems, recordEvents, "order placed"); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if promo != nil {
+		if err := promo.redeem(tx, &order, now); err != nil {
 			tx.Rollback()
-			return nil, fmt.Errorf("failed to create order item: %w", err)
+			return nil, err
 		}
 	}
 
@@ -164,26 +399,156 @@ func (s *Service) CreateOrder(ctx context.Context, userID string, req *CreateOrd
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	quantity := 0
+	for _, item := range cart.Items {
+		quantity += item.Quantity
+	}
+	announceOrder(&order, quantity, lowStock)
 
 	// Clear cart after successful order creation
 	if err := s.cartService.ClearCart(ctx, req.SessionID); err != nil {
 		// Log error but don't fail the order creation
-		fmt.Printf("Warning: failed to clear cart after order creation: %v\n", err)
+		logger.Named("orders").Warn("Failed to clear cart after order creation", map[string]interface{}{"error": err.Error()})
 	}
 
-	// Load order with items and user for response
-	if err := s.db.Preload("Items.Product").Preload("User").Where("id = ?", order.ID).First(&order).Error; err != nil {
+	// Load order with items, shipments and user for response
+	if err := s.db.Preload("Items.Product").Preload("Shipments").Preload("User").Where("id = ?", order.ID).First(&order).Error; err != nil {
 		return nil, fmt.Errorf("failed to load created order: %w", err)
 	}
 
+	s.confirmOrder(&order)
+
 	return &order, nil
 }
 
+// stockedLine is an order line whose stock was taken, with its product as read before the stock was taken
+type stockedLine struct {
+	models.CartItem
+	Product models.Product
+}
+
+// takeStock checks that every line can be sold and takes its stock inside tx, holding the product rows until
+// the transaction ends. It returns the lines in the order their products were locked and the products it
+// took down to their low-stock threshold.
+func takeStock(tx *gorm.DB, lines []models.CartItem) ([]stockedLine, []models.Product, error) {
+	var lowStock []models.Product
+
+	// Lock the products in a fixed order so concurrent checkouts sharing products queue up rather than deadlock
+	stocked := make([]stockedLine, len(lines))
+	for i, line := range lines {
+		stocked[i].CartItem = line
+	}
+	sort.SliceStable(stocked, func(i, j int) bool { return stocked[i].ProductID < stocked[j].ProductID })
+	for i := range stocked {
+		line := &stocked[i]
+		// Get current product to check inventory, holding its row until the order is placed
+		product := &line.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", line.ProductID).First(product).Error; err != nil {
+			return nil, nil, fmt.Errorf("product not found: %s", line.ProductID)
+		}
+
+		// Check if product is active
+		if !product.IsActive {
+			return nil, nil, fmt.Errorf("product %s is no longer available", product.Name)
+		}
+
+		// Check inventory
+		if product.Inventory < line.Quantity {
+			return nil, nil, fmt.Errorf("insufficient inventory for product %s: only %d available", product.Name, product.Inventory)
+		}
+
+		// Update inventory
+		remaining := product.Inventory - line.Quantity
+		if inventory.CrossedLowStock(product, product.Inventory, remaining) {
+			low := *product
+			low.Inventory = remaining
+			lowStock = append(lowStock, low)
+		}
+		// Take the stock atomically as well, so it never goes below zero where the database cannot lock rows
+		result := tx.Model(product).Where("inventory >= ?", line.Quantity).
+			Update("inventory", gorm.Expr("inventory - ?", line.Quantity))
+		if result.Error != nil {
+			return nil, nil, fmt.Errorf("failed to update inventory for product %s: %w", product.Name, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil, nil, fmt.Errorf("insufficient inventory for product %s", product.Name)
+		}
+		if err := eventbus.PublishInventoryChanged(tx, product.ID, -line.Quantity, eventbus.InventoryOrderPlaced); err != nil {
+			return nil, nil, err
+		}
+	}
+	return stocked, lowStock, nil
+}
+
+// saveOrder stores a new order placed by its customer with its shipments and items inside tx. It records the
+// order in the status history, the event log when recordEvents is set, for webhooks and the event bus, and
+// queues the confirmation email when there is an outbox.
+func (s *Service) saveOrder(tx *gorm.DB, order *models.Order, shipments []models.Shipment, shipmentItems [][]models.OrderItem, recordEvents bool, note string) error {
+	if err := tx.Create(order).Error; err != nil {
+		return fmt.Errorf("failed to create order: %w", err)
+	}
+	if err := orderhistory.Record(tx, order.ID, "", order.Status, orderhistory.Customer(order.UserID), note); err != nil {
+		return err
+	}
+
+	// Save shipments and their order items
+	for i := range shipments {
+		shipments[i].OrderID = order.ID
+		if err := tx.Create(&shipments[i]).Error; err != nil {
+			return fmt.Errorf("failed to create shipment: %w", err)
+		}
+		for j := range shipmentItems[i] {
+			shipmentItems[i][j].OrderID = order.ID
+			shipmentItems[i][j].ShipmentID = &shipments[i].ID
+			if err := tx.Create(&shipmentItems[i][j]).Error; err != nil {
+				return fmt.Errorf("failed to create order item: %w", err)
+			}
+		}
+	}
+
+	if recordEvents {
+		if err := orderevents.Append(tx, order.ID, orderevents.TypeOrderCreated, orderCreatedEvent(order, shipments, shipmentItems)); err != nil {
+			return err
+		}
+	}
+	if err := webhooks.PublishOrder(tx, order.ID, ""); err != nil {
+		return err
+	}
+	if err := eventbus.PublishOrder(tx, eventbus.EventOrderCreated, order.ID); err != nil {
+		return err
+	}
+	if s.outbox != nil {
+		return email.QueueOrderConfirmation(tx, order.ID)
+	}
+	return nil
+}
+
+// announceOrder tells admin dashboards about a committed order of quantity units and the products it took
+// down to their low-stock threshold
+func announceOrder(order *models.Order, quantity int, lowStock []models.Product) {
+	adminevents.Publish(adminevents.TypeOrderCreated, adminevents.OrderCreated{OrderID: order.ID, UserID: order.UserID, Total: order.Total, Items: quantity})
+	for i := range lowStock {
+		inventory.AlertLowStock(&lowStock[i])
+	}
+}
+
+// confirmOrder emails the confirmation of a committed order with the invoice attached; with an outbox it was
+// recorded with the order
+func (s *Service) confirmOrder(order *models.Order) {
+	if s.outbox != nil {
+		s.outbox.Notify()
+	} else if err := s.emailService.SendOrderConfirmation(order); err != nil {
+		// Log error but don't fail the order creation
+		logger.Named("orders").Warn("Failed to send order confirmation email", map[string]interface{}{"order_id": order.ID, "error": err.Error()})
+	}
+}
+
 // GetOrder retrieves an order by ID
-func (s *Service) GetOrder(orderID string, userID string) (*models.Order, error) {
+func (s *Service) GetOrder(ctx context.Context, orderID string, userID string) (*models.Order, error) {
 	var order models.Order
 
-	query := s.db.Preload("Items.Product").Preload("User")
+	query := s.db.WithContext(ctx).Preload("Items.Product").Preload("Items.Serials").Preload("Shipments.ShippedItems").Preload("User")
+	query = preloadComments(query, userID)
 
 	// If not admin, filter by user ID
 	if userID != "" {
@@ -196,17 +561,61 @@ func (s *Service) GetOrder(orderID string, userID string) (*models.Order, error)
 		}
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
+	annotateFulfillment(&order)
 
 	return &order, nil
 }
 
+// GetInvoice renders the invoice of an order as a PDF document. A non-empty userID limits it to that
+// customer's orders.
+func (s *Service) GetInvoice(ctx context.Context, orderID, userID string) (*models.Order, []byte, error) {
+	order, err := s.GetOrder(ctx, orderID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	invoice, err := invoices.Render(order, invoices.CompanyFromSettings(ctx, s.settings))
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, invoice, nil
+}
+
+// GetOrderHistory returns the status transitions of an order, oldest first. A non-empty userID limits it to
+// that customer's orders, and hides who made changes other than the customer, such as admins.
+func (s *Service) GetOrderHistory(ctx context.Context, orderID, userID string) ([]models.OrderStatusHistory, error) {
+	query := s.db.WithContext(ctx).Model(&models.Order{}).Where("id = ?", orderID)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	history, err := orderhistory.Load(s.db.WithContext(ctx), orderID)
+	if err != nil {
+		return nil, err
+	}
+	if userID != "" {
+		for i := range history {
+			if history[i].ActorID != nil && *history[i].ActorID != userID {
+				history[i].ActorID = nil
+			}
+		}
+	}
+	return history, nil
+}
+
 // GetUserOrders retrieves all orders for a user with pagination
-func (s *Service) GetUserOrders(userID string, page, limit int) ([]models.Order, int64, error) {
+func (s *Service) GetUserOrders(ctx context.Context, userID string, page, limit int) ([]models.Order, int64, error) {
 	var orders []models.Order
 	var total int64
 
 	// Count total orders
-	if err := s.db.Model(&models.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+	if err := s.db.WithContext(ctx).Model(&models.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count orders: %w", err)
 	}
 
@@ -214,7 +623,7 @@ func (s *Service) GetUserOrders(userID string, page, limit int) ([]models.Order,
 	offset := (page - 1) * limit
 
 	// Get orders with pagination
-	if err := s.db.Preload("Items.Product").
+	if err := s.db.WithContext(ctx).Preload("Items.Product").
 		Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Limit(limit).
@@ -226,8 +635,8 @@ func (s *Service) GetUserOrders(userID string, page, limit int) ([]models.Order,
 	return orders, total, nil
 }
 
-// UpdateOrderStatus updates the status of an order (admin only)
-func (s *Service) UpdateOrderStatus(orderID string, status string) (*models.Order, error) {
+// UpdateOrderStatus updates the status of an order (admin only), recording the admin and note in its status history
+func (s *Service) UpdateOrderStatus(ctx context.Context, orderID, status, adminID, note string) (*models.Order, error) {
 	// Validate status
 	validStatuses := map[string]bool{
 		"pending":    true,
@@ -244,7 +653,7 @@ func (s *Service) UpdateOrderStatus(orderID string, status string) (*models.Orde
 
 	// Get current order to check existing status
 	var currentOrder models.Order
-	if err := s.db.Preload("Items.Product").Preload("User").Where("id = ?", orderID).First(&currentOrder).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Items.Product").Preload("User").Where("id = ?", orderID).First(&currentOrder).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("order not found")
 		}
@@ -252,49 +661,82 @@ func (s *Service) UpdateOrderStatus(orderID string, status string) (*models.Orde
 	}
 
 	oldStatus := currentOrder.Status
+	recordEvents := orderevents.Enabled(context.Background(), s.settings)
 
-	// Update order status
-	result := s.db.Model(&models.Order{}).Where("id = ?", orderID).Update("status", status)
-	if result.Error != nil {
-		return nil, fmt.Errorf("failed to update order status: %w", result.Error)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if status == "shipped" || status == "delivered" {
+			if err := checkPendingShipmentSerials(tx, orderID); err != nil {
+				return err
+			}
+		}
+		if err := applyOrderStatus(tx, orderID, oldStatus, status); err != nil {
+			return err
+		}
+		if err := syncShipments(tx, orderID, status); err != nil {
+			return err
+		}
+		if oldStatus != status {
+			if err := orderhistory.Record(tx, orderID, oldStatus, status, orderhistory.Admin(adminID), note); err != nil {
+				return err
+			}
+			if err := webhooks.PublishOrder(tx, orderID, oldStatus); err != nil {
+				return err
+			}
+			if err := s.queueStatusNotifications(tx, orderID, oldStatus, status); err != nil {
+				return err
+			}
+		}
+		if recordEvents {
+			return orderevents.Append(tx, orderID, orderevents.TypeOrderStatusChanged, orderevents.OrderStatusChanged{
+				From: oldStatus, To: status, Reason: "admin update", CascadeShipments: true,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Get updated order
+	return s.reloadAndNotify(ctx, orderID, oldStatus, status)
+}
+
+// applyOrderStatus writes a new order status, recording the delivery time that return windows start from
+func applyOrderStatus(tx *gorm.DB, orderID, oldStatus, status string) error {
+	updates := map[string]interface{}{"status": status}
+	if status == "delivered" && oldStatus != status {
+		updates["delivered_at"] = time.Now()
+	}
+	if err := tx.Model(&models.Order{}).Where("id = ?", orderID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+	return nil
+}
+
+// reloadAndNotify returns the order after a status change and notifies the customer if it changed
+func (s *Service) reloadAndNotify(ctx context.Context, orderID, oldStatus, status string) (*models.Order, error) {
 	var order models.Order
-	if err := s.db.Preload("Items.Product").Preload("User").Where("id = ?", orderID).First(&order).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Items.Product").Preload("Items.Serials").Preload("User").Preload("Shipments.ShippedItems").Where("id = ?", orderID).First(&order).Error; err != nil {
 		return nil, fmt.Errorf("failed to get updated order: %w", err)
 	}
+	annotateFulfillment(&order)
 
-	// Send email notification if status changed
-	if oldStatus != status {
-		if err := s.emailService.SendOrderStatusUpdate(&order, oldStatus, status); err != nil {
-			// Log error but don't fail the status update
-			fmt.Printf("Warning: failed to send order status update email: %v\n", err)
-		}
+	// Notify the customer if status changed; with an outbox the notifications were recorded with the change
+	if oldStatus != status && s.outbox != nil {
+		s.outbox.Notify()
+	} else if oldStatus != status {
+		s.sendStatusNotifications(&order, oldStatus, status)
 	}
 
 	return &order, nil
 }
 
-// GetAllOrders retrieves all orders with pagination (admin only)
-func (s *Service) GetAllOrders(page, limit int, status, userID string) ([]models.Order, int64, error) {
+// GetAllOrders retrieves the orders matching filters with pagination, newest first (admin only)
+func (s *Service) GetAllOrders(ctx context.Context, page, limit int, filters OrderFilters) ([]models.Order, int64, error) {
 	var orders []models.Order
 	var total int64
 
-	query := s.db.Model(&models.Order{})
-
-	// Filter by status if provided
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-
-	// Filter by user ID if provided
-	if userID != "" {
-		query = query.Where("user_id = ?", userID)
-	}
-
 	// Count total orders
-	if err := query.Count(&total).Error; err != nil {
+	if err := filters.apply(s.db.WithContext(ctx).Model(&models.Order{})).Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count orders: %w", err)
 	}
 
@@ -302,14 +744,7 @@ func (s *Service) GetAllOrders(page, limit int, status, userID string) ([]models
 	offset := (page - 1) * limit
 
 	// Get orders with pagination
-	query = s.db.Preload("Items.Product").Preload("User")
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-	if userID != "" {
-		query = query.Where("user_id = ?", userID)
-	}
-
+	query := filters.apply(s.db.WithContext(ctx).Preload("Items.Product").Preload("User"))
 	if err := query.Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -321,11 +756,11 @@ func (s *Service) GetAllOrders(page, limit int, status, userID string) ([]models
 }
 
 // GetAllCustomers retrieves all customers with pagination and search (admin only)
-func (s *Service) GetAllCustomers(page, limit int, search string) ([]models.User, int64, error) {
+func (s *Service) GetAllCustomers(ctx context.Context, page, limit int, search string) ([]models.User, int64, error) {
 	var customers []models.User
 	var total int64
 
-	query := s.db.Model(&models.User{}).Where("role = ? AND is_active = ?", "customer", true)
+	query := s.db.WithContext(ctx).Model(&models.User{}).Where("role = ? AND is_active = ?", "customer", true)
 
 	// Add search functionality
 	if search != "" {
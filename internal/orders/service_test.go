@@ -1,13 +1,20 @@
 package orders
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"ecommerce-website/internal/cart"
 	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/monitoring"
+	"ecommerce-website/internal/settings"
+	"ecommerce-website/internal/shipping"
+	"ecommerce-website/internal/tax"
 )
 
 // Since we're testing the actual service implementation, we'll use the real service
@@ -195,3 +202,239 @@ func TestValidateOrderStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderService_CreateOrderCarriesItemSource(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+
+	user := helpers.CreateTestUser(t, "source@example.com")
+	category := helpers.CreateTestCategory(t, "sources")
+	product := helpers.CreateTestProduct(t, category.ID, 5, 10)
+
+	cart := &models.Cart{
+		SessionID: "source-session",
+		Items: []models.CartItem{
+			{ProductID: product.ID, Quantity: 2, Price: 10, Source: models.CartSourceSearch},
+		},
+	}
+	cartService.On("GetCartWithProducts", mock.Anything, "source-session").Return(cart, nil)
+	cartService.On("ClearCart", mock.Anything, "source-session").Return(nil)
+
+	order, err := service.CreateOrder(context.Background(), user.ID, helpers.GetValidCreateOrderRequest("source-session"))
+	require.NoError(t, err)
+	require.Len(t, order.Items, 1)
+	assert.Equal(t, models.CartSourceSearch, order.Items[0].Source)
+}
+
+func TestOrderService_CreateOrderAlertsLowStock(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+
+	user := helpers.CreateTestUser(t, "low-stock@example.com")
+	category := helpers.CreateTestCategory(t, "low-stock")
+	product := helpers.CreateTestProduct(t, category.ID, 8, 10)
+	threshold := 6
+	require.NoError(t, db.Model(product).Update("low_stock_threshold", threshold).Error)
+
+	cartService.On("GetCartWithProducts", mock.Anything, "low-stock-session").Return(&models.Cart{
+		SessionID: "low-stock-session",
+		Items:     []models.CartItem{{ProductID: product.ID, Quantity: 2, Price: 10}},
+	}, nil)
+	cartService.On("ClearCart", mock.Anything, "low-stock-session").Return(nil)
+
+	lowStockAlerts := func() int {
+		count := 0
+		for _, alert := range monitoring.GetAlerts() {
+			if alert.Title == "Product low on stock" && alert.Metadata["product_id"] == product.ID {
+				count++
+			}
+		}
+		return count
+	}
+
+	_, err := service.CreateOrder(context.Background(), user.ID, helpers.GetValidCreateOrderRequest("low-stock-session"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, lowStockAlerts(), "going from 8 to 6 crosses the threshold")
+
+	_, err = service.CreateOrder(context.Background(), user.ID, helpers.GetValidCreateOrderRequest("low-stock-session"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, lowStockAlerts(), "a product already below its threshold is not alerted again")
+}
+
+func TestOrderService_CreateOrderFromUserCart(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+
+	owner := helpers.CreateTestUser(t, "cart-owner@example.com")
+	other := helpers.CreateTestUser(t, "cart-other@example.com")
+	category := helpers.CreateTestCategory(t, "user-carts")
+	product := helpers.CreateTestProduct(t, category.ID, 5, 10)
+
+	cartID := cart.UserCartID(owner.ID)
+	cartService.On("GetCartWithProducts", mock.Anything, cartID).Return(&models.Cart{
+		SessionID: cartID,
+		UserID:    &owner.ID,
+		Items:     []models.CartItem{{ProductID: product.ID, Quantity: 1, Price: 10}},
+	}, nil)
+	cartService.On("ClearCart", mock.Anything, cartID).Return(nil)
+
+	_, err := service.CreateOrder(context.Background(), other.ID, helpers.GetValidCreateOrderRequest(cartID))
+	assert.EqualError(t, err, "cart is empty", "another user's cart cannot be checked out")
+	cartService.AssertNotCalled(t, "GetCartWithProducts", mock.Anything, cartID)
+
+	order, err := service.CreateOrder(context.Background(), owner.ID, helpers.GetValidCreateOrderRequest(cartID))
+	require.NoError(t, err)
+	assert.Len(t, order.Items, 1)
+}
+
+func TestOrderService_CreateOrderTaxesEachShipmentAddress(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+	taxes := tax.NewService(db, settings.NewService(db))
+
+	_, err := taxes.CreateRule(&tax.RuleRequest{Name: "Sales tax", Country: "US", State: "CA", Rate: 0.1})
+	require.NoError(t, err)
+
+	user := helpers.CreateTestUser(t, "tax@example.com")
+	category := helpers.CreateTestCategory(t, "taxes")
+	product := createPolicyProduct(t, db, category.ID, "TAX-SKU")
+
+	cartService.On("GetCartWithProducts", mock.Anything, "tax-session").Return(&models.Cart{
+		SessionID: "tax-session",
+		Items:     []models.CartItem{{ProductID: product.ID, Quantity: 3, Price: 10}},
+	}, nil)
+	cartService.On("ClearCart", mock.Anything, "tax-session").Return(nil)
+
+	// One unit ships to Oregon, which has no rule and no default rate
+	oregon := helpers.GetValidOrderAddress()
+	oregon.State = "OR"
+	req := helpers.GetValidCreateOrderRequest("tax-session")
+	req.ShippingGroups = []ShippingGroupRequest{{Key: "oregon", Address: oregon}}
+	req.ItemAssignments = []ItemAssignmentRequest{{ProductID: product.ID, GroupKey: "oregon", Quantity: 1}}
+
+	order, err := service.CreateOrder(context.Background(), user.ID, req)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, order.Tax)
+	assert.Equal(t, models.TaxBreakdown{{Name: "Sales tax", Jurisdiction: "US-CA", Rate: 0.1, Amount: 2}}, order.TaxBreakdown)
+
+	var stored models.Order
+	require.NoError(t, db.First(&stored, "id = ?", order.ID).Error)
+	assert.Equal(t, order.TaxBreakdown, stored.TaxBreakdown)
+}
+
+func TestOrderService_CreateOrderWithShippingMethod(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+	methods := shipping.NewService(db, cartService)
+
+	express, err := methods.CreateMethod(&shipping.MethodRequest{Name: "Express", Type: models.ShippingTypeWeight, Rate: 5, PerKg: 2})
+	require.NoError(t, err)
+	domestic, err := methods.CreateMethod(&shipping.MethodRequest{Name: "Domestic", Type: models.ShippingTypeFlat, Rate: 1, Countries: []string{"IN"}})
+	require.NoError(t, err)
+
+	user := helpers.CreateTestUser(t, "shipping-method@example.com")
+	category := helpers.CreateTestCategory(t, "shipping-method")
+	product := createPolicyProduct(t, db, category.ID, "SHIP-METHOD-SKU")
+	require.NoError(t, db.Model(product).Update("weight_grams", 600).Error)
+
+	cartService.On("GetCartWithProducts", mock.Anything, "method-session").Return(&models.Cart{
+		SessionID: "method-session",
+		Items:     []models.CartItem{{ProductID: product.ID, Quantity: 3, Price: 10}},
+	}, nil)
+	cartService.On("ClearCart", mock.Anything, "method-session").Return(nil)
+
+	req := helpers.GetValidCreateOrderRequest("method-session")
+	req.ShippingMethodID = &domestic.ID
+	_, err = service.CreateOrder(context.Background(), user.ID, req)
+	assert.ErrorIs(t, err, shipping.ErrMethodUnavailable, "domestic shipping does not reach the US")
+
+	missing := "missing"
+	req.ShippingMethodID = &missing
+	_, err = service.CreateOrder(context.Background(), user.ID, req)
+	assert.ErrorIs(t, err, shipping.ErrMethodNotFound)
+
+	// Two units ship to one address (1.2 kg) and one to another (0.6 kg); each parcel pays by weight
+	friend := helpers.GetValidOrderAddress()
+	friend.FirstName = "Friend"
+	req.ShippingMethodID = &express.ID
+	req.ShippingGroups = []ShippingGroupRequest{{Key: "friend", Address: friend}}
+	req.ItemAssignments = []ItemAssignmentRequest{{ProductID: product.ID, GroupKey: "friend", Quantity: 1}}
+	order, err := service.CreateOrder(context.Background(), user.ID, req)
+	require.NoError(t, err)
+	assert.Equal(t, 16.0, order.Shipping)
+	assert.Equal(t, 46.0, order.Total)
+
+	var stored models.Order
+	require.NoError(t, db.Preload("Shipments").First(&stored, "id = ?", order.ID).Error)
+	require.NotNil(t, stored.ShippingMethodID)
+	assert.Equal(t, express.ID, *stored.ShippingMethodID)
+	assert.Equal(t, "Express", *stored.ShippingMethodName)
+	costs := map[string]float64{}
+	for _, sh := range stored.Shipments {
+		costs[sh.GroupKey] = sh.Shipping
+	}
+	assert.Equal(t, map[string]float64{DefaultShipmentGroup: 9, "friend": 7}, costs)
+}
+
+func TestOrderService_CreateOrderAppliesSettings(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+	adminSettings := settings.NewService(db)
+
+	user := helpers.CreateTestUser(t, "settings@example.com")
+	category := helpers.CreateTestCategory(t, "settings")
+	product := createPolicyProduct(t, db, category.ID, "SETTINGS-SKU")
+
+	newCart := func(sessionID string, quantity int) {
+		cartService.On("GetCartWithProducts", mock.Anything, sessionID).Return(&models.Cart{
+			SessionID: sessionID,
+			Items:     []models.CartItem{{ProductID: product.ID, Quantity: quantity, Price: 10}},
+		}, nil)
+		cartService.On("ClearCart", mock.Anything, sessionID).Return(nil)
+	}
+	set := func(key, value string) {
+		_, err := adminSettings.SetSetting(key, &settings.SetSettingRequest{Value: value}, "admin@example.com")
+		require.NoError(t, err)
+	}
+
+	set(settings.KeyTaxRate, "0.18")
+	set(settings.KeyMinOrderAmount, "15")
+	set(settings.KeyAdditionalShipmentFee, "40")
+	set(settings.KeyFreeShippingThreshold, "25")
+
+	t.Run("below minimum is rejected", func(t *testing.T) {
+		newCart("settings-min", 1)
+		_, err := service.CreateOrder(context.Background(), user.ID, helpers.GetValidCreateOrderRequest("settings-min"))
+		assert.ErrorIs(t, err, ErrBelowMinimumOrder)
+	})
+
+	split := func(sessionID string) *CreateOrderRequest {
+		friend := helpers.GetValidOrderAddress()
+		req := helpers.GetValidCreateOrderRequest(sessionID)
+		req.ShippingGroups = []ShippingGroupRequest{{Key: "friend", Address: friend}}
+		req.ItemAssignments = []ItemAssignmentRequest{{ProductID: product.ID, GroupKey: "friend", Quantity: 1}}
+		return req
+	}
+
+	t.Run("tax and per-shipment fee come from settings", func(t *testing.T) {
+		newCart("settings-fee", 2)
+		order, err := service.CreateOrder(context.Background(), user.ID, split("settings-fee"))
+		require.NoError(t, err)
+		assert.Equal(t, 3.6, order.Tax)
+		assert.Equal(t, 40.0, order.Shipping)
+		assert.Equal(t, 63.6, order.Total)
+	})
+
+	t.Run("free shipping from the threshold", func(t *testing.T) {
+		newCart("settings-free", 3)
+		order, err := service.CreateOrder(context.Background(), user.ID, split("settings-free"))
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, order.Shipping)
+	})
+}
@@ -0,0 +1,396 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/orderevents"
+	"ecommerce-website/internal/orderhistory"
+	"ecommerce-website/internal/webhooks"
+
+	"gorm.io/gorm"
+)
+
+// DefaultShipmentGroup is the group items ship to when they are not assigned elsewhere; it uses the order's shipping address
+const DefaultShipmentGroup = "default"
+
+var (
+	ErrInvalidShippingGroup  = errors.New("invalid shipping group")
+	ErrInvalidItemAssignment = errors.New("invalid item assignment")
+	ErrShipmentNotFound      = errors.New("shipment not found")
+	ErrInvalidShipmentStatus = errors.New("invalid shipment status")
+
+	ErrInvalidDeliveryConfirmation = errors.New("invalid delivery confirmation")
+)
+
+// ShippingGroupRequest is an extra ship-to address that cart items can be assigned to
+type ShippingGroupRequest struct {
+	Key     string              `json:"key" binding:"required,max=50"`
+	Address models.OrderAddress `json:"address" binding:"required"`
+}
+
+// ItemAssignmentRequest sends quantity units of a cart product to a shipping group
+type ItemAssignmentRequest struct {
+	ProductID string `json:"productId" binding:"required"`
+	GroupKey  string `json:"groupKey" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+}
+
+// UpdateShipmentStatusRequest represents the request to move a shipment through fulfillment. Serials are
+// recorded when the shipment leaves the warehouse and are required for every unit of serial-tracked items.
+type UpdateShipmentStatusRequest struct {
+	Status         string               `json:"status" binding:"required,oneof=shipped delivered"`
+	TrackingNumber *string              `json:"trackingNumber,omitempty"`
+	Serials        []ItemSerialsRequest `json:"serials,omitempty" binding:"omitempty,dive"`
+}
+
+// ConfirmDeliveryRequest is a delivery reported by the carrier webhook or the driver app
+type ConfirmDeliveryRequest struct {
+	OrderID       string     `json:"orderId" binding:"required"`
+	ShipmentID    string     `json:"shipmentId" binding:"required"`
+	Source        string     `json:"source" binding:"required,oneof=carrier driver"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+	PhotoURL      *string    `json:"photoUrl,omitempty" binding:"omitempty,url,max=500"`
+	OTPVerified   bool       `json:"otpVerified"`
+	RecipientName *string    `json:"recipientName,omitempty" binding:"omitempty,max=100"`
+}
+
+// plannedShipment is a shipment group with the cart quantities routed to it
+type plannedShipment struct {
+	Key     string
+	Address models.OrderAddress
+	Lines   []plannedLine
+}
+
+// plannedLine is a quantity of one cart line within a shipment group
+type plannedLine struct {
+	CartItem models.CartItem
+	Quantity int
+}
+
+// planShipments splits the cart across the requested shipping groups. Units of a cart line that are
+// not assigned ship to the default group; groups left without items are dropped.
+func planShipments(req *CreateOrderRequest, items []models.CartItem) ([]plannedShipment, error) {
+	groups := []plannedShipment{{Key: DefaultShipmentGroup, Address: req.ShippingAddress}}
+	groupIndex := map[string]int{DefaultShipmentGroup: 0}
+	for _, group := range req.ShippingGroups {
+		if _, exists := groupIndex[group.Key]; exists {
+			return nil, fmt.Errorf("%w: duplicate group %q", ErrInvalidShippingGroup, group.Key)
+		}
+		if err := validateOrderAddress(group.Address); err != nil {
+			return nil, fmt.Errorf("%w: group %q: %v", ErrInvalidShippingGroup, group.Key, err)
+		}
+		groupIndex[group.Key] = len(groups)
+		groups = append(groups, plannedShipment{Key: group.Key, Address: group.Address})
+	}
+
+	remaining := make(map[string]int, len(items))
+	for _, item := range items {
+		remaining[item.ProductID] += item.Quantity
+	}
+
+	assigned := make(map[string]map[string]int)
+	for _, assignment := range req.ItemAssignments {
+		if _, ok := groupIndex[assignment.GroupKey]; !ok {
+			return nil, fmt.Errorf("%w: unknown group %q", ErrInvalidItemAssignment, assignment.GroupKey)
+		}
+		left, inCart := remaining[assignment.ProductID]
+		if !inCart {
+			return nil, fmt.Errorf("%w: product %s is not in the cart", ErrInvalidItemAssignment, assignment.ProductID)
+		}
+		if assignment.Quantity > left {
+			return nil, fmt.Errorf("%w: more units of product %s assigned than are in the cart", ErrInvalidItemAssignment, assignment.ProductID)
+		}
+		remaining[assignment.ProductID] = left - assignment.Quantity
+		if assigned[assignment.GroupKey] == nil {
+			assigned[assignment.GroupKey] = make(map[string]int)
+		}
+		assigned[assignment.GroupKey][assignment.ProductID] += assignment.Quantity
+	}
+
+	for _, item := range items {
+		for key, idx := range groupIndex {
+			quantity := assigned[key][item.ProductID]
+			if key == DefaultShipmentGroup {
+				quantity += remaining[item.ProductID]
+				remaining[item.ProductID] = 0
+			}
+			if quantity > 0 {
+				groups[idx].Lines = append(groups[idx].Lines, plannedLine{CartItem: item, Quantity: quantity})
+			}
+		}
+	}
+
+	planned := make([]plannedShipment, 0, len(groups))
+	for _, group := range groups {
+		if len(group.Lines) > 0 {
+			planned = append(planned, group)
+		}
+	}
+	return planned, nil
+}
+
+// shipmentShipping returns the shipping charge of the n-th shipment (zero-based) of an order. Single-address
+// orders keep shipping free; splitting an order across addresses pays fee for each extra parcel.
+func shipmentShipping(n int, fee float64) float64 {
+	if n == 0 {
+		return 0
+	}
+	return fee
+}
+
+// shipmentSlipOrder narrows an order to one of its shipments for printing: the shipment's
+// address and only the items packed in it. A parcel packs the quantities it lists; its group
+// packs whatever of the group's items no other parcel carried.
+func shipmentSlipOrder(order *models.Order, shipmentID string) (*models.Order, error) {
+	for _, shipment := range order.Shipments {
+		if shipment.ID != shipmentID {
+			continue
+		}
+		packed, elsewhere := make(map[string]int), make(map[string]int)
+		for _, other := range order.Shipments {
+			for _, item := range other.ShippedItems {
+				if other.ID == shipmentID {
+					packed[item.OrderItemID] += item.Quantity
+				} else {
+					elsewhere[item.OrderItemID] += item.Quantity
+				}
+			}
+		}
+
+		slip := *order
+		slip.ShippingAddress = shipment.Address
+		slip.Items = nil
+		for _, item := range order.Items {
+			quantity := packed[item.ID]
+			if item.ShipmentID != nil && *item.ShipmentID == shipmentID {
+				quantity = item.Quantity - elsewhere[item.ID]
+			}
+			if quantity <= 0 {
+				continue
+			}
+			if quantity != item.Quantity {
+				item.Discount = item.Discount * float64(quantity) / float64(item.Quantity)
+				item.Quantity = quantity
+				item.Total = item.Price * float64(quantity)
+			}
+			slip.Items = append(slip.Items, item)
+		}
+		return &slip, nil
+	}
+	return nil, ErrShipmentNotFound
+}
+
+// UpdateShipmentStatus marks one shipment of an order shipped or delivered (admin only). The order
+// follows its shipments: it becomes shipped once any shipment ships and delivered once all are delivered.
+func (s *Service) UpdateShipmentStatus(ctx context.Context, orderID, shipmentID string, req *UpdateShipmentStatusRequest, adminID string) (*models.Order, error) {
+	change := shipmentChange{
+		Status:         req.Status,
+		TrackingNumber: req.TrackingNumber,
+		At:             time.Now(),
+		Actor:          orderhistory.Admin(adminID),
+		Serials:        req.Serials,
+		RequireSerials: true,
+	}
+	if req.Status == "delivered" {
+		change.Proof = &models.DeliveryProof{Source: models.DeliverySourceAdmin}
+	}
+	return s.moveShipment(ctx, orderID, shipmentID, change)
+}
+
+// ConfirmDelivery records a delivery reported by the carrier or the driver app, along with its proof
+func (s *Service) ConfirmDelivery(ctx context.Context, req *ConfirmDeliveryRequest) (*models.Order, error) {
+	at := time.Now()
+	if req.DeliveredAt != nil {
+		if req.DeliveredAt.After(at) {
+			return nil, fmt.Errorf("%w: deliveredAt is in the future", ErrInvalidDeliveryConfirmation)
+		}
+		at = *req.DeliveredAt
+	}
+	return s.moveShipment(ctx, req.OrderID, req.ShipmentID, shipmentChange{
+		Status: "delivered",
+		At:     at,
+		Actor:  orderhistory.Carrier,
+		Proof: &models.DeliveryProof{
+			Source:        req.Source,
+			PhotoURL:      req.PhotoURL,
+			OTPVerified:   req.OTPVerified,
+			RecipientName: req.RecipientName,
+		},
+	})
+}
+
+// shipmentChange is one step of a shipment through fulfillment
+type shipmentChange struct {
+	Status         string
+	TrackingNumber *string
+	At             time.Time
+	Proof          *models.DeliveryProof
+	Actor          orderhistory.Actor
+	Serials        []ItemSerialsRequest
+	// RequireSerials rejects a shipment leaving the warehouse without the serials of its serial-tracked
+	// units. Carrier confirmations report goods that already left, so they are not held to it.
+	RequireSerials bool
+}
+
+// moveShipment applies change to one shipment of an order and moves the order along with its shipments
+func (s *Service) moveShipment(ctx context.Context, orderID, shipmentID string, change shipmentChange) (*models.Order, error) {
+	var oldStatus, newStatus string
+	recordEvents := orderevents.Enabled(context.Background(), s.settings)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Preload("Shipments").Where("id = ?", orderID).First(&order).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrShipmentNotFound
+			}
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		var shipment *models.Shipment
+		for i := range order.Shipments {
+			if order.Shipments[i].ID == shipmentID {
+				shipment = &order.Shipments[i]
+			}
+		}
+		if shipment == nil {
+			return ErrShipmentNotFound
+		}
+		if !shippableOrderStatuses[order.Status] {
+			return fmt.Errorf("%w: order is %s", ErrInvalidShipmentStatus, order.Status)
+		}
+		if (shipment.Status != "pending" && shipment.Status != "shipped") || (shipment.Status == "shipped" && change.Status == "shipped") {
+			return fmt.Errorf("%w: shipment is already %s", ErrInvalidShipmentStatus, shipment.Status)
+		}
+
+		if shipment.Status == "pending" {
+			if len(change.Serials) > 0 || change.RequireSerials {
+				if err := recordShipmentSerials(tx, orderID, shipment.ID, change.Serials, change.Actor.ID, change.RequireSerials); err != nil {
+					return err
+				}
+			}
+		} else if len(change.Serials) > 0 {
+			return fmt.Errorf("%w: serials are recorded when the shipment ships", ErrInvalidSerials)
+		}
+
+		updates := map[string]interface{}{"status": change.Status}
+		if change.TrackingNumber != nil {
+			updates["tracking_number"] = *change.TrackingNumber
+		}
+		if shipment.ShippedAt == nil {
+			updates["shipped_at"] = change.At
+		}
+		if change.Status == "delivered" {
+			updates["delivered_at"] = change.At
+			if change.Proof != nil {
+				updates["delivery_source"] = change.Proof.Source
+				updates["delivery_photo_url"] = change.Proof.PhotoURL
+				updates["delivery_otp_verified"] = change.Proof.OTPVerified
+				updates["delivery_recipient_name"] = change.Proof.RecipientName
+			}
+		}
+		if err := tx.Model(shipment).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update shipment: %w", err)
+		}
+		if recordEvents {
+			if err := orderevents.Append(tx, orderID, orderevents.TypeShipmentStatusChanged, orderevents.ShipmentStatusChanged{
+				ShipmentID: shipment.ID, From: shipment.Status, To: change.Status, TrackingNumber: change.TrackingNumber, DeliveryProof: change.Proof,
+			}); err != nil {
+				return err
+			}
+		}
+		shipment.Status = change.Status
+
+		oldStatus = order.Status
+		var err error
+		newStatus, err = s.followShipments(tx, &order, change, "shipment "+shipment.ID+" "+change.Status, recordEvents)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if oldStatus != newStatus {
+		return s.reloadAndNotify(ctx, orderID, oldStatus, newStatus)
+	}
+	return s.GetOrder(ctx, orderID, "")
+}
+
+// followShipments moves an order along with its shipments inside tx, once their statuses were changed by
+// change: the order is delivered once every shipment is and shipped until then. It returns the order's new status.
+func (s *Service) followShipments(tx *gorm.DB, order *models.Order, change shipmentChange, note string, recordEvents bool) (string, error) {
+	oldStatus, newStatus := order.Status, "delivered"
+	for _, sh := range order.Shipments {
+		if sh.Status != "delivered" {
+			newStatus = "shipped"
+			break
+		}
+	}
+	if oldStatus == newStatus {
+		return newStatus, nil
+	}
+	if err := applyOrderStatus(tx, order.ID, oldStatus, newStatus); err != nil {
+		return "", err
+	}
+	if err := orderhistory.Record(tx, order.ID, oldStatus, newStatus, change.Actor, note); err != nil {
+		return "", err
+	}
+	// A confirmed delivery may be reported late; the order was delivered when its last shipment was
+	if newStatus == "delivered" {
+		if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Update("delivered_at", change.At).Error; err != nil {
+			return "", fmt.Errorf("failed to update order delivery time: %w", err)
+		}
+	}
+	if err := webhooks.PublishOrder(tx, order.ID, oldStatus); err != nil {
+		return "", err
+	}
+	if err := s.queueStatusNotifications(tx, order.ID, oldStatus, newStatus); err != nil {
+		return "", err
+	}
+	if recordEvents {
+		if err := orderevents.Append(tx, order.ID, orderevents.TypeOrderStatusChanged, orderevents.OrderStatusChanged{
+			From: oldStatus, To: newStatus, Reason: "shipment " + change.Status,
+		}); err != nil {
+			return "", err
+		}
+	}
+	return newStatus, nil
+}
+
+// shippableOrderStatuses are the order statuses whose shipments may still move; pending orders
+// are unpaid, delivered orders are finished and cancelled or refunded orders must not ship
+var shippableOrderStatuses = map[string]bool{
+	"paid":       true,
+	"processing": true,
+	"shipped":    true,
+}
+
+// syncShipments moves an order's shipments along with an order-level status change so the two
+// never disagree: shipping or delivering the order ships or delivers every outstanding shipment,
+// and cancelling or refunding it cancels the ones that have not left the warehouse.
+func syncShipments(tx *gorm.DB, orderID, status string) error {
+	now := time.Now()
+	shipments := tx.Model(&models.Shipment{}).Where("order_id = ?", orderID)
+
+	switch status {
+	case "shipped", "delivered":
+		if err := shipments.Session(&gorm.Session{}).Where("status = ?", "pending").
+			Updates(map[string]interface{}{"status": "shipped", "shipped_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to ship shipments: %w", err)
+		}
+		if status == "delivered" {
+			if err := shipments.Session(&gorm.Session{}).Where("status = ?", "shipped").
+				Updates(map[string]interface{}{"status": "delivered", "delivered_at": now}).Error; err != nil {
+				return fmt.Errorf("failed to deliver shipments: %w", err)
+			}
+		}
+	case "cancelled", "refunded":
+		if err := shipments.Session(&gorm.Session{}).Where("status = ?", "pending").
+			Update("status", "cancelled").Error; err != nil {
+			return fmt.Errorf("failed to cancel shipments: %w", err)
+		}
+	}
+	return nil
+}
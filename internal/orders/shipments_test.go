@@ -0,0 +1,263 @@
+package orders
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanShipments(t *testing.T) {
+	helpers := NewTestHelpers(nil)
+	grandma := helpers.GetValidOrderAddress()
+	grandma.FirstName = "Grandma"
+	items := []models.CartItem{
+		{ProductID: "mug", Quantity: 3, Price: 10},
+		{ProductID: "card", Quantity: 1, Price: 2},
+	}
+
+	t.Run("unassigned units ship to the default address", func(t *testing.T) {
+		req := helpers.GetValidCreateOrderRequest("s")
+		req.ShippingGroups = []ShippingGroupRequest{{Key: "grandma", Address: grandma}}
+		req.ItemAssignments = []ItemAssignmentRequest{{ProductID: "mug", GroupKey: "grandma", Quantity: 2}}
+
+		planned, err := planShipments(req, items)
+		require.NoError(t, err)
+		require.Len(t, planned, 2)
+		assert.Equal(t, DefaultShipmentGroup, planned[0].Key)
+		assert.Equal(t, []plannedLine{{CartItem: items[0], Quantity: 1}, {CartItem: items[1], Quantity: 1}}, planned[0].Lines)
+		assert.Equal(t, "Grandma", planned[1].Address.FirstName)
+		assert.Equal(t, []plannedLine{{CartItem: items[0], Quantity: 2}}, planned[1].Lines)
+	})
+
+	t.Run("empty default group is dropped", func(t *testing.T) {
+		req := helpers.GetValidCreateOrderRequest("s")
+		req.ShippingGroups = []ShippingGroupRequest{{Key: "grandma", Address: grandma}}
+		req.ItemAssignments = []ItemAssignmentRequest{
+			{ProductID: "mug", GroupKey: "grandma", Quantity: 3},
+			{ProductID: "card", GroupKey: "grandma", Quantity: 1},
+		}
+
+		planned, err := planShipments(req, items)
+		require.NoError(t, err)
+		require.Len(t, planned, 1)
+		assert.Equal(t, "grandma", planned[0].Key)
+	})
+
+	t.Run("rejects invalid assignments", func(t *testing.T) {
+		cases := map[string]func(req *CreateOrderRequest){
+			"unknown group": func(req *CreateOrderRequest) {
+				req.ItemAssignments = []ItemAssignmentRequest{{ProductID: "mug", GroupKey: "office", Quantity: 1}}
+			},
+			"not in cart": func(req *CreateOrderRequest) {
+				req.ItemAssignments = []ItemAssignmentRequest{{ProductID: "pen", GroupKey: "grandma", Quantity: 1}}
+			},
+			"too many units": func(req *CreateOrderRequest) {
+				req.ItemAssignments = []ItemAssignmentRequest{{ProductID: "card", GroupKey: "grandma", Quantity: 2}}
+			},
+		}
+		for name, mutate := range cases {
+			req := helpers.GetValidCreateOrderRequest("s")
+			req.ShippingGroups = []ShippingGroupRequest{{Key: "grandma", Address: grandma}}
+			mutate(req)
+			_, err := planShipments(req, items)
+			assert.ErrorIs(t, err, ErrInvalidItemAssignment, name)
+		}
+	})
+
+	t.Run("rejects duplicate or incomplete groups", func(t *testing.T) {
+		req := helpers.GetValidCreateOrderRequest("s")
+		req.ShippingGroups = []ShippingGroupRequest{{Key: DefaultShipmentGroup, Address: grandma}}
+		_, err := planShipments(req, items)
+		assert.ErrorIs(t, err, ErrInvalidShippingGroup)
+
+		req.ShippingGroups = []ShippingGroupRequest{{Key: "grandma", Address: models.OrderAddress{FirstName: "Grandma"}}}
+		_, err = planShipments(req, items)
+		assert.ErrorIs(t, err, ErrInvalidShippingGroup)
+	})
+}
+
+func TestService_MultiAddressOrder(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	cartService := new(MockCartService)
+	service := NewServiceWithDependencies(db, cartService, emailService)
+
+	user := helpers.CreateTestUser(t, "shipments@example.com")
+	category := helpers.CreateTestCategory(t, "shipments")
+	product := createPolicyProduct(t, db, category.ID, "SHIP-SKU")
+
+	cart := &models.Cart{
+		SessionID: "ship-session",
+		Items:     []models.CartItem{{ProductID: product.ID, Quantity: 3, Price: 10}},
+	}
+	cartService.On("GetCartWithProducts", mock.Anything, "ship-session").Return(cart, nil)
+	cartService.On("ClearCart", mock.Anything, "ship-session").Return(nil)
+
+	friend := helpers.GetValidOrderAddress()
+	friend.FirstName = "Friend"
+	req := helpers.GetValidCreateOrderRequest("ship-session")
+	req.ShippingGroups = []ShippingGroupRequest{{Key: "friend", Address: friend}}
+	req.ItemAssignments = []ItemAssignmentRequest{{ProductID: product.ID, GroupKey: "friend", Quantity: 1}}
+
+	order, err := service.CreateOrder(context.Background(), user.ID, req)
+	require.NoError(t, err)
+	require.Len(t, order.Shipments, 2)
+	require.Len(t, order.Items, 2)
+	assert.Equal(t, 30.0, order.Subtotal)
+	assert.Equal(t, 50.0, order.Shipping)
+	assert.Equal(t, 80.0, order.Total)
+
+	var stock models.Product
+	require.NoError(t, db.First(&stock, "id = ?", product.ID).Error)
+	assert.Equal(t, 2, stock.Inventory)
+
+	t.Run("packing slip covers one shipment", func(t *testing.T) {
+		var friendShipment models.Shipment
+		for _, sh := range order.Shipments {
+			if sh.GroupKey == "friend" {
+				friendShipment = sh
+			}
+		}
+		slip, err := shipmentSlipOrder(order, friendShipment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Friend", slip.ShippingAddress.FirstName)
+		require.Len(t, slip.Items, 1)
+		assert.Equal(t, 1, slip.Items[0].Quantity)
+
+		_, err = shipmentSlipOrder(order, "missing")
+		assert.ErrorIs(t, err, ErrShipmentNotFound)
+	})
+
+	t.Run("order follows its shipments", func(t *testing.T) {
+		first, second := order.Shipments[0], order.Shipments[1]
+
+		_, err := service.UpdateShipmentStatus(context.Background(), order.ID, first.ID, &UpdateShipmentStatusRequest{Status: "shipped"}, "admin-1")
+		assert.ErrorIs(t, err, ErrInvalidShipmentStatus, "unpaid orders must not ship")
+
+		require.NoError(t, db.Model(&models.Order{}).Where("id = ?", order.ID).Update("status", "paid").Error)
+
+		updated, err := service.UpdateShipmentStatus(context.Background(), order.ID, first.ID, &UpdateShipmentStatusRequest{Status: "shipped", TrackingNumber: stringPtr("TRK-1")}, "admin-1")
+		require.NoError(t, err)
+		assert.Equal(t, "shipped", updated.Status)
+
+		updated, err = service.UpdateShipmentStatus(context.Background(), order.ID, first.ID, &UpdateShipmentStatusRequest{Status: "delivered"}, "admin-1")
+		require.NoError(t, err)
+		assert.Equal(t, "shipped", updated.Status)
+
+		_, err = service.UpdateShipmentStatus(context.Background(), order.ID, first.ID, &UpdateShipmentStatusRequest{Status: "delivered"}, "admin-1")
+		assert.ErrorIs(t, err, ErrInvalidShipmentStatus)
+
+		updated, err = service.UpdateShipmentStatus(context.Background(), order.ID, second.ID, &UpdateShipmentStatusRequest{Status: "delivered"}, "admin-1")
+		require.NoError(t, err)
+		assert.Equal(t, "delivered", updated.Status)
+		assert.NotNil(t, updated.DeliveredAt)
+
+		_, err = service.UpdateShipmentStatus(context.Background(), order.ID, second.ID, &UpdateShipmentStatusRequest{Status: "shipped"}, "admin-1")
+		assert.ErrorIs(t, err, ErrInvalidShipmentStatus, "delivered orders must not move back to shipped")
+
+		_, err = service.UpdateShipmentStatus(context.Background(), order.ID, "missing", &UpdateShipmentStatusRequest{Status: "shipped"}, "admin-1")
+		assert.ErrorIs(t, err, ErrShipmentNotFound)
+	})
+}
+
+func TestService_OrderStatusSyncsShipments(t *testing.T) {
+	db, _, helpers, emailService := setupPolicyTest(t)
+	service := NewServiceWithDependencies(db, new(MockCartService), emailService)
+	user := helpers.CreateTestUser(t, "sync@example.com")
+
+	newOrder := func(status string) (models.Order, []models.Shipment) {
+		order := models.Order{UserID: user.ID, Status: status, Subtotal: 10, Total: 10}
+		require.NoError(t, db.Create(&order).Error)
+		shipments := []models.Shipment{
+			{OrderID: order.ID, GroupKey: DefaultShipmentGroup, Status: "pending"},
+			{OrderID: order.ID, GroupKey: "friend", Status: "pending"},
+		}
+		require.NoError(t, db.Create(&shipments).Error)
+		return order, shipments
+	}
+	statuses := func(orderID string) []string {
+		var shipments []models.Shipment
+		require.NoError(t, db.Where("order_id = ?", orderID).Order("group_key").Find(&shipments).Error)
+		var result []string
+		for _, sh := range shipments {
+			result = append(result, sh.Status)
+		}
+		return result
+	}
+
+	t.Run("cancelled orders cancel pending shipments and refuse to ship", func(t *testing.T) {
+		order, shipments := newOrder("paid")
+		_, err := service.UpdateOrderStatus(context.Background(), order.ID, "cancelled", "admin-1", "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cancelled", "cancelled"}, statuses(order.ID))
+
+		_, err = service.UpdateShipmentStatus(context.Background(), order.ID, shipments[0].ID, &UpdateShipmentStatusRequest{Status: "shipped"}, "admin-1")
+		assert.ErrorIs(t, err, ErrInvalidShipmentStatus)
+	})
+
+	t.Run("delivering the order delivers every shipment", func(t *testing.T) {
+		order, shipments := newOrder("paid")
+		_, err := service.UpdateShipmentStatus(context.Background(), order.ID, shipments[0].ID, &UpdateShipmentStatusRequest{Status: "shipped"}, "admin-1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"shipped", "pending"}, statuses(order.ID), "shipping one parcel leaves the other pending")
+
+		updated, err := service.UpdateOrderStatus(context.Background(), order.ID, "delivered", "admin-1", "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"delivered", "delivered"}, statuses(order.ID))
+		for _, sh := range updated.Shipments {
+			assert.NotNil(t, sh.ShippedAt)
+			assert.NotNil(t, sh.DeliveredAt)
+		}
+	})
+}
+
+func TestService_ConfirmDelivery(t *testing.T) {
+	db, service, helpers, _ := setupPolicyTest(t)
+	user := helpers.CreateTestUser(t, "delivery@example.com")
+
+	order := models.Order{UserID: user.ID, Status: "shipped", Subtotal: 10, Total: 10}
+	require.NoError(t, db.Create(&order).Error)
+	shipment := models.Shipment{OrderID: order.ID, GroupKey: DefaultShipmentGroup, Status: "shipped"}
+	require.NoError(t, db.Create(&shipment).Error)
+
+	future := time.Now().Add(time.Hour)
+	_, err := service.ConfirmDelivery(context.Background(), &ConfirmDeliveryRequest{OrderID: order.ID, ShipmentID: shipment.ID, Source: "carrier", DeliveredAt: &future})
+	assert.ErrorIs(t, err, ErrInvalidDeliveryConfirmation)
+
+	_, err = service.ConfirmDelivery(context.Background(), &ConfirmDeliveryRequest{OrderID: order.ID, ShipmentID: "missing", Source: "carrier"})
+	assert.ErrorIs(t, err, ErrShipmentNotFound)
+
+	deliveredAt := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	updated, err := service.ConfirmDelivery(context.Background(), &ConfirmDeliveryRequest{
+		OrderID:       order.ID,
+		ShipmentID:    shipment.ID,
+		Source:        models.DeliverySourceDriver,
+		DeliveredAt:   &deliveredAt,
+		PhotoURL:      stringPtr("https://cdn.example.com/pod/1.jpg"),
+		OTPVerified:   true,
+		RecipientName: stringPtr("Front desk"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "delivered", updated.Status)
+	require.NotNil(t, updated.DeliveredAt)
+	assert.True(t, deliveredAt.Equal(*updated.DeliveredAt), "the order is delivered when the driver reported it")
+
+	// The customer's order detail carries the proof
+	detail, err := service.GetOrder(context.Background(), order.ID, user.ID)
+	require.NoError(t, err)
+	require.Len(t, detail.Shipments, 1)
+	assert.Equal(t, models.DeliveryProof{
+		Source:        models.DeliverySourceDriver,
+		PhotoURL:      stringPtr("https://cdn.example.com/pod/1.jpg"),
+		OTPVerified:   true,
+		RecipientName: stringPtr("Front desk"),
+	}, detail.Shipments[0].DeliveryProof)
+
+	_, err = service.ConfirmDelivery(context.Background(), &ConfirmDeliveryRequest{OrderID: order.ID, ShipmentID: shipment.ID, Source: "carrier"})
+	assert.ErrorIs(t, err, ErrInvalidShipmentStatus, "a delivery is confirmed once")
+}
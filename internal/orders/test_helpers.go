@@ -38,8 +38,8 @@ func (m *MockCartService) ClearCart(ctx context.Context, sessionID string) error
 	return args.Error(0)
 }
 
-func (m *MockCartService) AddItem(ctx context.Context, sessionID string, productID string, quantity int) (*models.Cart, error) {
-	args := m.Called(ctx, sessionID, productID, quantity)
+func (m *MockCartService) AddItem(ctx context.Context, sessionID string, productID string, quantity int, source string) (*models.Cart, error) {
+	args := m.Called(ctx, sessionID, productID, quantity, source)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -72,11 +72,31 @@ type MockEmailService struct {
 	mock.Mock
 }
 
+func (m *MockEmailService) SendOrderConfirmation(order *models.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
 func (m *MockEmailService) SendOrderStatusUpdate(order *models.Order, oldStatus, newStatus string) error {
 	args := m.Called(order, oldStatus, newStatus)
 	return args.Error(0)
 }
 
+func (m *MockEmailService) SendReviewRequest(order *models.Order, reviewToken string) error {
+	args := m.Called(order, reviewToken)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendRefundNotification(order *models.Order, refund *models.Refund) error {
+	args := m.Called(order, refund)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendDraftOrderInvoice(draft *models.DraftOrder) error {
+	args := m.Called(draft)
+	return args.Error(0)
+}
+
 // TestHelpers provides utility functions for testing orders
 type TestHelpers struct {
 	db *gorm.DB
@@ -0,0 +1,63 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceInterface defines the outbox operations used by the admin handlers
+type ServiceInterface interface {
+	Stats(ctx context.Context) (*Stats, error)
+	Failed(ctx context.Context, topic string, page, limit int) ([]models.OutboxMessage, int64, error)
+	Retry(ctx context.Context, id string) (*models.OutboxMessage, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new outbox handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// GetStats handles GET /api/admin/outbox (admin only)
+func (h *Handler) GetStats(c *gin.Context) {
+	stats, err := h.service.Stats(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "OUTBOX_STATS_FAILED", "Failed to retrieve outbox stats", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Outbox stats retrieved successfully", stats)
+}
+
+// ListFailedMessages handles GET /api/admin/outbox/failed (admin only)
+func (h *Handler) ListFailedMessages(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+	messages, total, err := h.service.Failed(c.Request.Context(), c.Query("topic"), page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "OUTBOX_LIST_FAILED", "Failed to retrieve failed outbox messages", err.Error())
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Failed outbox messages retrieved successfully", "messages", messages, utils.NewPagination(page, limit, total))
+}
+
+// RetryFailedMessage handles POST /api/admin/outbox/failed/:id/retry (admin only)
+func (h *Handler) RetryFailedMessage(c *gin.Context) {
+	message, err := h.service.Retry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrMessageNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "OUTBOX_MESSAGE_NOT_FOUND", "Failed outbox message not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "OUTBOX_RETRY_FAILED", "Failed to retry outbox message", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusAccepted, "Outbox message queued for retry", message)
+}
@@ -0,0 +1,69 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// dispatchInterval is how often the dispatcher looks for due messages it was not notified of, such as
+	// retries and messages recorded by other instances
+	dispatchInterval = 2 * time.Second
+	// pruneInterval is how often delivered messages past retention are deleted
+	pruneInterval = time.Hour
+	// stalledAfter is how old the oldest pending message may get before the module reports itself unhealthy
+	stalledAfter = 10 * time.Minute
+)
+
+// Module runs the outbox dispatcher and serves the outbox admin routes. The dispatcher is one of the shared
+// dependencies so other modules can register their topics; this package therefore cannot import app, and the
+// module is registered through a factory in main.
+type Module struct {
+	dispatcher  *Dispatcher
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the outbox module
+func NewModule(dispatcher *Dispatcher, authService *auth.Service) *Module {
+	return &Module{dispatcher: dispatcher, handler: NewHandler(dispatcher), authService: authService}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "outbox"
+}
+
+// Models returns the outbox table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.OutboxMessage{}}
+}
+
+// StartJobs delivers recorded messages in the background; modules register their topics when they are created,
+// before the dispatcher starts
+func (m *Module) StartJobs(ctx context.Context) {
+	m.dispatcher.Start(ctx, dispatchInterval, pruneInterval)
+}
+
+// HealthCheck reports the outbox unhealthy when a message has waited longer than stalledAfter to be delivered
+func (m *Module) HealthCheck(ctx context.Context) error {
+	oldest, err := m.dispatcher.oldestPending(ctx)
+	if err != nil {
+		return err
+	}
+	if oldest != nil && m.dispatcher.now().Sub(oldest.CreatedAt) > stalledAfter {
+		return fmt.Errorf("outbox delivery stalled since %s: %s", oldest.CreatedAt.Format(time.RFC3339), oldest.LastError)
+	}
+	return nil
+}
+
+// RegisterAPIRoutes sets up the outbox admin routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,308 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ecommerce-website/internal/jobs"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxAttempts is how often a message is tried before it is given up on and left for an admin to retry
+	MaxAttempts = 10
+	// baseBackoff is the delay before retrying a failed message; each further retry doubles it up to maxBackoff
+	baseBackoff = 5 * time.Second
+	maxBackoff  = 30 * time.Minute
+	// claimTimeout is how long a claimed message waits before another pass may deliver it again, in case the
+	// instance delivering it stops before recording the outcome
+	claimTimeout = 2 * time.Minute
+	// deliverTimeout bounds a single delivery
+	deliverTimeout = time.Minute
+	// dispatchBatch caps the messages one dispatch pass delivers
+	dispatchBatch = 100
+	// retainDelivered is how long delivered messages are kept before they are pruned
+	retainDelivered = 7 * 24 * time.Hour
+)
+
+var (
+	ErrMessageNotFound = errors.New("outbox message not found")
+	ErrUnknownTopic    = errors.New("no deliver function registered for outbox topic")
+)
+
+// DeliverFunc delivers a message from its JSON payload. A returned error retries the message with backoff.
+type DeliverFunc func(ctx context.Context, payload json.RawMessage) error
+
+// Enqueue records a message with the JSON encoding of payload. Call it with the transaction of the change the
+// message belongs to, so the message is delivered if and only if the change is committed.
+func Enqueue(tx *gorm.DB, topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s outbox message: %w", topic, err)
+	}
+	now := time.Now()
+	message := models.OutboxMessage{Topic: topic, Payload: string(data), NextAttemptAt: &now}
+	if err := tx.Create(&message).Error; err != nil {
+		return fmt.Errorf("failed to record %s outbox message: %w", topic, err)
+	}
+	return nil
+}
+
+// Stats counts the messages in each state of the outbox
+type Stats struct {
+	Pending   int64 `json:"pending"`
+	Failed    int64 `json:"failed"`
+	Delivered int64 `json:"delivered"`
+}
+
+// Dispatcher delivers recorded messages with the functions registered for their topics. Messages are kept
+// in the database, so every instance shares them. A message is delivered at least once, so deliver functions
+// must tolerate seeing one again.
+type Dispatcher struct {
+	db     *gorm.DB
+	queue  *jobs.Queue
+	mu     sync.RWMutex
+	topics map[string]DeliverFunc
+	wake   chan struct{}
+	now    func() time.Time
+}
+
+// NewDispatcher creates a dispatcher for the messages recorded in db
+func NewDispatcher(db *gorm.DB) *Dispatcher {
+	return &Dispatcher{db: db, topics: make(map[string]DeliverFunc), wake: make(chan struct{}, 1), now: time.Now}
+}
+
+// RelayTo makes the dispatcher hand each message to queue as a job of the message's topic rather than deliver it
+// itself: the outbox makes sure the side effects of a committed change reach the queue, and the queue workers run
+// them with their retries and dead-letter queue. Call it before any topic is registered. It returns the
+// dispatcher for chaining after NewDispatcher.
+func (d *Dispatcher) RelayTo(queue *jobs.Queue) *Dispatcher {
+	d.queue = queue
+	return d
+}
+
+// Register sets how messages of a topic are delivered. Registering a topic again replaces its deliver function.
+// When the dispatcher relays to a job queue, deliver runs as the topic's job and the message is delivered once
+// it is queued.
+func (d *Dispatcher) Register(topic string, deliver DeliverFunc) {
+	if d.queue != nil {
+		queue := d.queue
+		queue.Register(topic, jobs.RunFunc(deliver))
+		deliver = func(ctx context.Context, payload json.RawMessage) error {
+			_, err := queue.Enqueue(ctx, topic, payload)
+			return err
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.topics[topic] = deliver
+}
+
+func (d *Dispatcher) deliverFunc(topic string) (DeliverFunc, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	deliver, ok := d.topics[topic]
+	return deliver, ok
+}
+
+// Notify wakes the dispatcher of this process after a change recorded messages, so they are delivered without
+// waiting for the next pass
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// DispatchDue delivers due messages oldest first and returns how many were delivered. Each message is claimed
+// before it is delivered so dispatchers running at the same time never deliver it together.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	now := d.now()
+	var due []models.OutboxMessage
+	if err := d.db.WithContext(ctx).
+		Where("delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", now).
+		Order("created_at ASC").Limit(dispatchBatch).Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to load due outbox messages: %w", err)
+	}
+
+	delivered := 0
+	for _, message := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		result := d.db.WithContext(ctx).Model(&models.OutboxMessage{}).
+			Where("id = ? AND delivered_at IS NULL AND attempts = ?", message.ID, message.Attempts).
+			Updates(map[string]interface{}{"attempts": message.Attempts + 1, "next_attempt_at": now.Add(claimTimeout)})
+		if result.Error != nil {
+			return delivered, fmt.Errorf("failed to claim outbox message: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		message.Attempts++
+
+		ok, err := d.attempt(ctx, &message)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// attempt delivers a claimed message and records the outcome, reporting whether it was delivered
+func (d *Dispatcher) attempt(ctx context.Context, message *models.OutboxMessage) (bool, error) {
+	deliverErr := d.deliver(context.WithoutCancel(ctx), message)
+
+	var updates map[string]interface{}
+	fields := map[string]interface{}{"message_id": message.ID, "topic": message.Topic, "attempt": message.Attempts}
+	switch {
+	case deliverErr == nil:
+		updates = map[string]interface{}{"delivered_at": d.now(), "next_attempt_at": nil, "last_error": ""}
+	case message.Attempts >= MaxAttempts:
+		updates = map[string]interface{}{"failed_at": d.now(), "next_attempt_at": nil, "last_error": deliverErr.Error()}
+		logger.Named("outbox").Error("Outbox message failed, giving up", deliverErr, fields)
+	default:
+		retryAt := d.now().Add(utils.Backoff(message.Attempts, baseBackoff, maxBackoff))
+		updates = map[string]interface{}{"next_attempt_at": retryAt, "last_error": deliverErr.Error()}
+		fields["retry_at"] = retryAt
+		fields["error"] = deliverErr.Error()
+		logger.Named("outbox").Warn("Outbox message failed, retrying", fields)
+	}
+	if err := d.db.Model(message).Updates(updates).Error; err != nil {
+		return false, fmt.Errorf("failed to record outbox message: %w", err)
+	}
+	return deliverErr == nil, nil
+}
+
+// deliver calls the deliver function of the message's topic, turning a panic into an error so one bad message cannot stop the
+// dispatcher
+func (d *Dispatcher) deliver(ctx context.Context, message *models.OutboxMessage) (err error) {
+	deliverMessage, ok := d.deliverFunc(message.Topic)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTopic, message.Topic)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("outbox delivery panicked: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, deliverTimeout)
+	defer cancel()
+	return deliverMessage(ctx, json.RawMessage(message.Payload))
+}
+
+// Prune deletes messages delivered before the retention window
+func (d *Dispatcher) Prune(ctx context.Context) (int64, error) {
+	result := d.db.WithContext(ctx).Where("delivered_at < ?", d.now().Add(-retainDelivered)).Delete(&models.OutboxMessage{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune outbox messages: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Start delivers due messages every interval, and whenever Notify is called, and prunes delivered ones every
+// pruneInterval until ctx is cancelled
+func (d *Dispatcher) Start(ctx context.Context, interval, pruneInterval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		pruneTicker := time.NewTicker(pruneInterval)
+		defer pruneTicker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-d.wake:
+			case <-pruneTicker.C:
+				if _, err := d.Prune(ctx); err != nil && ctx.Err() == nil {
+					logger.Named("outbox").Warn("Failed to prune outbox messages", map[string]interface{}{"error": err.Error()})
+				}
+				continue
+			}
+			if _, err := d.DispatchDue(ctx); err != nil && ctx.Err() == nil {
+				logger.Named("outbox").Warn("Failed to dispatch outbox messages", map[string]interface{}{"error": err.Error()})
+			}
+		}
+	}()
+}
+
+// Stats counts the pending, failed and retained delivered messages
+func (d *Dispatcher) Stats(ctx context.Context) (*Stats, error) {
+	var stats Stats
+	db := d.db.WithContext(ctx).Model(&models.OutboxMessage{})
+	if err := db.Session(&gorm.Session{}).Where("delivered_at IS NULL AND failed_at IS NULL").Count(&stats.Pending).Error; err != nil {
+		return nil, fmt.Errorf("failed to count pending outbox messages: %w", err)
+	}
+	if err := db.Session(&gorm.Session{}).Where("failed_at IS NOT NULL").Count(&stats.Failed).Error; err != nil {
+		return nil, fmt.Errorf("failed to count failed outbox messages: %w", err)
+	}
+	if err := db.Session(&gorm.Session{}).Where("delivered_at IS NOT NULL").Count(&stats.Delivered).Error; err != nil {
+		return nil, fmt.Errorf("failed to count delivered outbox messages: %w", err)
+	}
+	return &stats, nil
+}
+
+// Failed returns a page of the messages given up on, most recently failed first, and their total
+func (d *Dispatcher) Failed(ctx context.Context, topic string, page, limit int) ([]models.OutboxMessage, int64, error) {
+	query := d.db.WithContext(ctx).Model(&models.OutboxMessage{}).Where("failed_at IS NOT NULL")
+	if topic != "" {
+		query = query.Where("topic = ?", topic)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count failed outbox messages: %w", err)
+	}
+	var messages []models.OutboxMessage
+	if err := query.Order("failed_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&messages).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list failed outbox messages: %w", err)
+	}
+	return messages, total, nil
+}
+
+// Retry gives a failed message a fresh set of attempts, starting right away
+func (d *Dispatcher) Retry(ctx context.Context, id string) (*models.OutboxMessage, error) {
+	now := d.now()
+	result := d.db.WithContext(ctx).Model(&models.OutboxMessage{}).
+		Where("id = ? AND failed_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"attempts": 0, "failed_at": nil, "next_attempt_at": now})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to retry outbox message: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrMessageNotFound
+	}
+	var message models.OutboxMessage
+	if err := d.db.WithContext(ctx).First(&message, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("failed to load outbox message: %w", err)
+	}
+	d.Notify()
+	return &message, nil
+}
+
+// oldestPending returns the oldest message still waiting to be delivered, or nil when there is none
+func (d *Dispatcher) oldestPending(ctx context.Context) (*models.OutboxMessage, error) {
+	var oldest models.OutboxMessage
+	result := d.db.WithContext(ctx).Where("delivered_at IS NULL AND failed_at IS NULL").Order("created_at ASC").Limit(1).Find(&oldest)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &oldest, nil
+}
@@ -0,0 +1,187 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/jobs"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupTestDispatcher(t *testing.T) (*Dispatcher, *time.Time) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	dispatcher := NewDispatcher(database.GetDB())
+	now := time.Now()
+	dispatcher.now = func() time.Time { return now }
+	return dispatcher, &now
+}
+
+func TestEnqueue_RolledBackWithTheChange(t *testing.T) {
+	dispatcher, _ := setupTestDispatcher(t)
+	db := database.GetDB()
+
+	require.NoError(t, Enqueue(db, "search.sync", map[string]string{"id": "p1"}))
+	require.Error(t, db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, Enqueue(tx, "search.sync", map[string]string{"id": "p2"}))
+		return errors.New("change failed")
+	}))
+
+	stats, err := dispatcher.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Pending: 1}, *stats)
+}
+
+func TestDispatcher_DispatchDue(t *testing.T) {
+	dispatcher, now := setupTestDispatcher(t)
+	db := database.GetDB()
+	ctx := context.Background()
+
+	var delivered []string
+	failing := errors.New("elasticsearch unavailable")
+	dispatcher.Register("search.sync", func(ctx context.Context, payload json.RawMessage) error {
+		var message struct{ ID string }
+		require.NoError(t, json.Unmarshal(payload, &message))
+		if message.ID == "broken" {
+			return failing
+		}
+		delivered = append(delivered, message.ID)
+		return nil
+	})
+	dispatcher.Register("panics", func(ctx context.Context, payload json.RawMessage) error { panic("bad message") })
+
+	require.NoError(t, Enqueue(db, "search.sync", map[string]string{"id": "p1"}))
+	require.NoError(t, Enqueue(db, "search.sync", map[string]string{"id": "broken"}))
+	require.NoError(t, Enqueue(db, "panics", nil))
+	require.NoError(t, Enqueue(db, "unknown", nil))
+	require.NoError(t, Enqueue(db, "search.sync", map[string]string{"id": "p2"}))
+	*now = time.Now()
+
+	// A failing message does not hold up the ones after it
+	count, err := dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{"p1", "p2"}, delivered)
+
+	var broken models.OutboxMessage
+	require.NoError(t, db.Where("payload LIKE ?", "%broken%").First(&broken).Error)
+	assert.Equal(t, 1, broken.Attempts)
+	assert.Equal(t, failing.Error(), broken.LastError)
+	assert.WithinDuration(t, now.Add(baseBackoff), *broken.NextAttemptAt, time.Second)
+
+	// Nothing is due until the backoff passed
+	count, err = dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Messages that keep failing are given up on after MaxAttempts
+	for i := 1; i < MaxAttempts; i++ {
+		*now = now.Add(maxBackoff)
+		_, err := dispatcher.DispatchDue(ctx)
+		require.NoError(t, err)
+	}
+	stats, err := dispatcher.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Failed: 3, Delivered: 2}, *stats)
+	failed, total, err := dispatcher.Failed(ctx, "panics", 1, 20)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+	assert.Contains(t, failed[0].LastError, "panicked")
+
+	// A retried message gets a fresh set of attempts
+	delivered = nil
+	require.NoError(t, db.Model(&broken).Update("payload", `{"id":"fixed"}`).Error)
+	retried, err := dispatcher.Retry(ctx, broken.ID)
+	require.NoError(t, err)
+	assert.Zero(t, retried.Attempts)
+	count, err = dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"fixed"}, delivered)
+
+	_, err = dispatcher.Retry(ctx, broken.ID)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	// Delivered messages are pruned after the retention window
+	*now = now.Add(retainDelivered + time.Hour)
+	pruned, err := dispatcher.Prune(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, pruned)
+}
+
+func TestDispatcher_RelayToQueue(t *testing.T) {
+	dispatcher, now := setupTestDispatcher(t)
+	queue := jobs.NewQueue(nil)
+	dispatcher.RelayTo(queue)
+	db := database.GetDB()
+	ctx := context.Background()
+
+	var delivered []string
+	dispatcher.Register("search.sync", func(ctx context.Context, payload json.RawMessage) error {
+		var message struct{ ID string }
+		require.NoError(t, json.Unmarshal(payload, &message))
+		delivered = append(delivered, message.ID)
+		return nil
+	})
+	require.NoError(t, Enqueue(db, "search.sync", map[string]string{"id": "p1"}))
+	*now = time.Now()
+
+	// The message is delivered once it is queued; the queue workers run it
+	count, err := dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Empty(t, delivered)
+	queued, err := queue.Stats(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, queued.Ready)
+
+	ran, err := queue.ProcessNext(ctx, 0)
+	require.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, []string{"p1"}, delivered)
+}
+
+func TestHandler_FailedMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dispatcher, _ := setupTestDispatcher(t)
+	db := database.GetDB()
+	failedAt := time.Now()
+	message := models.OutboxMessage{Topic: "email.refund", Payload: "{}", Attempts: MaxAttempts, FailedAt: &failedAt, LastError: "smtp unavailable"}
+	require.NoError(t, db.Create(&message).Error)
+
+	handler := NewHandler(dispatcher)
+	r := gin.New()
+	r.GET("/outbox", handler.GetStats)
+	r.GET("/outbox/failed", handler.ListFailedMessages)
+	r.POST("/outbox/failed/:id/retry", handler.RetryFailedMessage)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/outbox", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"failed":1`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/outbox/failed?topic=email.refund", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), message.ID)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/outbox/failed/"+message.ID+"/retry", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"attempts":0`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/outbox/failed/"+message.ID+"/retry", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,19 @@
+package outbox
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin routes to inspect the outbox and retry failed messages
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin/outbox")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.GetStats)
+		admin.GET("/failed", handler.ListFailedMessages)
+		admin.POST("/failed/:id/retry", handler.RetryFailedMessage)
+	}
+}
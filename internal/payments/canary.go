@@ -0,0 +1,282 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/monitoring"
+	"ecommerce-website/internal/settings"
+
+	"github.com/razorpay/razorpay-go"
+)
+
+const (
+	// canaryWindow bounds the payments the canary's error rate is computed over
+	canaryWindow = time.Hour
+	// rolloutActor is recorded as the author of an automatic fallback in the settings history
+	rolloutActor = "payments-canary"
+)
+
+// orderGateway creates orders with the payment provider
+type orderGateway interface {
+	// CreateOrder returns the provider order ID
+	CreateOrder(data map[string]interface{}) (string, error)
+}
+
+// razorpayOrders creates orders through the Razorpay API
+type razorpayOrders struct {
+	client *razorpay.Client
+}
+
+// CreateOrder creates a Razorpay order
+func (r razorpayOrders) CreateOrder(data map[string]interface{}) (string, error) {
+	order, err := r.client.Order.Create(data, nil)
+	if err != nil {
+		return "", err
+	}
+	id, ok := order["id"].(string)
+	if !ok {
+		return "", errors.New("razorpay order has no id")
+	}
+	return id, nil
+}
+
+// provider is a payment provider account with the secrets that sign its payments and webhooks
+type provider struct {
+	name          string
+	keyID         string
+	secret        string
+	webhookSecret string
+	orders        orderGateway
+	refunds       refundGateway
+}
+
+// WithCanary adds a secondary provider account. The payments.canary_percent setting routes that share of new
+// payment intents to it. It returns the service for chaining after a constructor.
+func (s *Service) WithCanary(keyID, keySecret, webhookSecret string) *Service {
+	client := razorpay.NewClient(keyID, keySecret)
+	s.canary = &provider{
+		name:          models.PaymentProviderCanary,
+		keyID:         keyID,
+		secret:        keySecret,
+		webhookSecret: webhookSecret,
+		orders:        razorpayOrders{client: client},
+		refunds:       razorpayRefunds{client: client},
+	}
+	return s
+}
+
+// provider returns the named account. Payments of a canary that is no longer configured are handled with the
+// primary account.
+func (s *Service) provider(name string) provider {
+	if name == models.PaymentProviderCanary && s.canary != nil {
+		return *s.canary
+	}
+	return provider{
+		name:          models.PaymentProviderPrimary,
+		keyID:         s.keyID,
+		secret:        s.secret,
+		webhookSecret: s.webhookSecret,
+		orders:        s.orders,
+		refunds:       s.refunds,
+	}
+}
+
+// pickProvider chooses the account a new intent is created with. The canary takes its rolled out share while
+// it stays healthy; a canary whose error rate is over the limit is rolled back and the primary is used.
+func (s *Service) pickProvider(ctx context.Context) provider {
+	primary := s.provider(models.PaymentProviderPrimary)
+	if s.canary == nil {
+		return primary
+	}
+	percent := s.settings.GetInt(ctx, settings.KeyPaymentCanaryPercent)
+	if percent <= 0 || s.random()*100 >= float64(percent) {
+		return primary
+	}
+
+	status, err := s.RolloutStatus(ctx)
+	if err != nil {
+		logger.Named("payments").Warn("Failed to check canary health, using the primary account", map[string]interface{}{"error": err.Error()})
+		return primary
+	}
+	if canary := status.provider(models.PaymentProviderCanary); canary.unhealthy(status) {
+		s.rollBack(status, canary)
+		return primary
+	}
+	return *s.canary
+}
+
+// ProviderStats are the outcomes of the intents one provider account created since the rollout window began.
+// Rates are over settled attempts: captured and failed payments plus orders the provider refused to create.
+type ProviderStats struct {
+	Provider      string   `json:"provider"`
+	Intents       int64    `json:"intents"`
+	Captured      int64    `json:"captured"`
+	Failed        int64    `json:"failed"`
+	GatewayErrors int64    `json:"gatewayErrors"`
+	SuccessRate   *float64 `json:"successRate"`
+	ErrorRate     *float64 `json:"errorRate"`
+}
+
+// attempts returns the settled attempts of the provider
+func (p ProviderStats) attempts() int64 {
+	return p.Captured + p.Failed + p.GatewayErrors
+}
+
+// unhealthy reports whether the provider has enough attempts to judge and fails more of them than allowed
+func (p ProviderStats) unhealthy(status *RolloutStatus) bool {
+	return p.ErrorRate != nil && p.attempts() >= status.MinAttempts && *p.ErrorRate > status.MaxErrorRate
+}
+
+// RolloutStatus is the canary rollout configuration with the stats of each provider account. Stats start at
+// the later of the window start and the last change to the rollout percentage, so a re-enabled canary is not
+// judged on failures from before.
+type RolloutStatus struct {
+	CanaryConfigured bool            `json:"canaryConfigured"`
+	CanaryPercent    int64           `json:"canaryPercent"`
+	MaxErrorRate     float64         `json:"maxErrorRate"`
+	MinAttempts      int64           `json:"minAttempts"`
+	Since            time.Time       `json:"since"`
+	Providers        []ProviderStats `json:"providers"`
+}
+
+// provider returns the stats of the named account
+func (r *RolloutStatus) provider(name string) ProviderStats {
+	for _, stats := range r.Providers {
+		if stats.Provider == name {
+			return stats
+		}
+	}
+	return ProviderStats{Provider: name}
+}
+
+// RolloutStatus returns the canary rollout configuration and the success rate of each provider account
+func (s *Service) RolloutStatus(ctx context.Context) (*RolloutStatus, error) {
+	now := time.Now()
+	status := &RolloutStatus{
+		CanaryConfigured: s.canary != nil,
+		CanaryPercent:    s.settings.GetInt(ctx, settings.KeyPaymentCanaryPercent),
+		MaxErrorRate:     s.settings.GetFloat(ctx, settings.KeyPaymentCanaryMaxError),
+		MinAttempts:      s.settings.GetInt(ctx, settings.KeyPaymentCanaryMinCount),
+		Since:            now.Add(-canaryWindow),
+	}
+	setting, err := s.settings.GetSetting(settings.KeyPaymentCanaryPercent, models.SettingScopeGlobal, "")
+	if err != nil && !errors.Is(err, settings.ErrSettingNotFound) {
+		return nil, err
+	}
+	if setting != nil && setting.UpdatedAt.After(status.Since) {
+		status.Since = setting.UpdatedAt
+	}
+
+	var rows []struct {
+		Provider string
+		Status   string
+		Count    int64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.Payment{}).
+		Select("provider, status, COUNT(*) AS count").
+		Where("created_at >= ?", status.Since).
+		Group("provider, status").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count payments by provider: %w", err)
+	}
+
+	for _, name := range []string{models.PaymentProviderPrimary, models.PaymentProviderCanary} {
+		stats := ProviderStats{Provider: name, GatewayErrors: s.gatewayErrors.count(name, status.Since)}
+		for _, row := range rows {
+			if row.Provider != name {
+				continue
+			}
+			stats.Intents += row.Count
+			switch row.Status {
+			case models.PaymentStatusPaid, models.PaymentStatusRefunded, models.PaymentStatusRefundRequired:
+				stats.Captured += row.Count
+			case models.PaymentStatusFailed:
+				stats.Failed += row.Count
+			}
+		}
+		if attempts := stats.attempts(); attempts > 0 {
+			success := float64(stats.Captured) / float64(attempts)
+			failure := 1 - success
+			stats.SuccessRate, stats.ErrorRate = &success, &failure
+		}
+		status.Providers = append(status.Providers, stats)
+	}
+	return status, nil
+}
+
+// rollBack sends every new intent to the primary account by resetting the rollout percentage, and alerts admins
+func (s *Service) rollBack(status *RolloutStatus, canary ProviderStats) {
+	message := fmt.Sprintf("Canary error rate %.1f%% over %d attempts exceeded %.1f%%; new payments use the primary account",
+		*canary.ErrorRate*100, canary.attempts(), status.MaxErrorRate*100)
+	if _, err := s.settings.SetSetting(settings.KeyPaymentCanaryPercent, &settings.SetSettingRequest{
+		Value:       "0",
+		Description: message,
+	}, rolloutActor); err != nil {
+		logger.Named("payments").Error("Failed to roll back the payment canary", err, nil)
+		return
+	}
+	logger.Named("payments").Warn("Payment canary rolled back", map[string]interface{}{
+		"error_rate": *canary.ErrorRate,
+		"attempts":   canary.attempts(),
+		"percent":    status.CanaryPercent,
+	})
+	monitoring.CreateAlert(monitoring.AlertCritical, "Payment canary rolled back", message, map[string]interface{}{
+		"error_rate": *canary.ErrorRate,
+		"attempts":   canary.attempts(),
+		"percent":    status.CanaryPercent,
+	})
+}
+
+// recordGatewayError notes that a provider account refused to create an order
+func (s *Service) recordGatewayError(name string, err error) {
+	logger.Named("payments").Warn("Payment provider refused to create an order", map[string]interface{}{"provider": name, "error": err.Error()})
+	s.gatewayErrors.record(name, time.Now())
+}
+
+// gatewayErrorLog remembers when each provider account refused to create an order. Refusals leave no payment
+// behind, so they are kept in memory and each instance judges the canary on the refusals it saw itself.
+type gatewayErrorLog struct {
+	mu sync.Mutex
+	at map[string][]time.Time
+}
+
+func newGatewayErrorLog() *gatewayErrorLog {
+	return &gatewayErrorLog{at: make(map[string][]time.Time)}
+}
+
+func (l *gatewayErrorLog) record(name string, at time.Time) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Refusals older than the window can no longer count
+	kept := l.at[name][:0]
+	for _, t := range l.at[name] {
+		if at.Sub(t) < canaryWindow {
+			kept = append(kept, t)
+		}
+	}
+	l.at[name] = append(kept, at)
+}
+
+func (l *gatewayErrorLog) count(name string, since time.Time) int64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var count int64
+	for _, t := range l.at[name] {
+		if !t.Before(since) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,159 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOrders creates numbered provider orders instead of calling the payment provider
+type fakeOrders struct {
+	prefix string
+	calls  int
+	err    error
+	during func() // runs while the gateway order is created
+}
+
+func (f *fakeOrders) CreateOrder(data map[string]interface{}) (string, error) {
+	f.calls++
+	if f.during != nil {
+		f.during()
+	}
+	if f.err != nil {
+		return "", f.err
+	}
+	return fmt.Sprintf("order_%s_%d", f.prefix, f.calls), nil
+}
+
+func setupCanaryService(t *testing.T) (*Service, *fakeOrders, *fakeOrders) {
+	setupTestDB(t)
+	primary, canary := &fakeOrders{prefix: "primary"}, &fakeOrders{prefix: "canary"}
+	service := NewService(database.GetDB(), "key_primary", "secret_primary").WithCanary("key_canary", "secret_canary", "hook_canary")
+	service.orders = primary
+	service.canary.orders = canary
+	service.random = func() float64 { return 0.1 }
+	return service, primary, canary
+}
+
+func createPendingOrder(t *testing.T) models.Order {
+	db := database.GetDB()
+	user := models.User{Email: uuid.New().String() + "@example.com", Password: "hashed", FirstName: "Test", LastName: "User"}
+	require.NoError(t, db.Create(&user).Error)
+	order := models.Order{UserID: user.ID, Status: "pending", Subtotal: 100, Total: 100}
+	require.NoError(t, db.Create(&order).Error)
+	return order
+}
+
+func setSetting(t *testing.T, service *Service, key, value string) {
+	_, err := service.settings.SetSetting(key, &settings.SetSettingRequest{Value: value}, "admin-1")
+	require.NoError(t, err)
+}
+
+func sign(secret, message string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(message))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func TestService_CanaryRollout(t *testing.T) {
+	service, primary, canary := setupCanaryService(t)
+
+	response, err := service.CreateOrder(CreateOrderRequest{OrderID: createPendingOrder(t).ID})
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentProviderPrimary, response.Provider, "nothing is rolled out by default")
+	assert.Equal(t, "key_primary", response.KeyID)
+
+	setSetting(t, service, settings.KeyPaymentCanaryPercent, "25")
+	response, err = service.CreateOrder(CreateOrderRequest{OrderID: createPendingOrder(t).ID})
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentProviderCanary, response.Provider)
+	assert.Equal(t, "key_canary", response.KeyID)
+	assert.Equal(t, "order_canary_1", response.RazorpayOrderID)
+
+	service.random = func() float64 { return 0.3 }
+	response, err = service.CreateOrder(CreateOrderRequest{OrderID: createPendingOrder(t).ID})
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentProviderPrimary, response.Provider, "intents outside the rolled out share stay on the primary")
+	assert.Equal(t, 2, primary.calls)
+	assert.Equal(t, 1, canary.calls)
+
+	t.Run("canary payments are signed with the canary account", func(t *testing.T) {
+		var payment models.Payment
+		require.NoError(t, database.GetDB().First(&payment, "razorpay_order_id = ?", "order_canary_1").Error)
+		req := VerifyPaymentRequest{
+			RazorpayOrderID:   payment.RazorpayOrderID,
+			RazorpayPaymentID: "pay_canary_1",
+			RazorpaySignature: sign("secret_primary", payment.RazorpayOrderID+"|pay_canary_1"),
+			ClientToken:       service.clientToken(&payment),
+		}
+		assert.EqualError(t, service.VerifyPayment(req), "invalid payment signature")
+
+		req.RazorpaySignature = sign("secret_canary", payment.RazorpayOrderID+"|pay_canary_1")
+		require.NoError(t, service.VerifyPayment(req))
+
+		body := []byte(`{"event":"payment.failed"}`)
+		assert.True(t, service.verifyWebhookSignature(body, sign("hook_canary", string(body))))
+		assert.False(t, service.verifyWebhookSignature(body, sign("hook_other", string(body))))
+	})
+
+	t.Run("a canary refusing the order falls back to the primary", func(t *testing.T) {
+		service.random = func() float64 { return 0.1 }
+		canary.err = errors.New("authentication failed")
+		response, err := service.CreateOrder(CreateOrderRequest{OrderID: createPendingOrder(t).ID})
+		require.NoError(t, err)
+		assert.Equal(t, models.PaymentProviderPrimary, response.Provider)
+
+		status, err := service.RolloutStatus(context.Background())
+		require.NoError(t, err)
+		stats := status.provider(models.PaymentProviderCanary)
+		assert.Equal(t, int64(1), stats.GatewayErrors)
+		assert.Equal(t, int64(1), stats.Captured)
+		require.NotNil(t, stats.ErrorRate)
+		assert.InDelta(t, 0.5, *stats.ErrorRate, 0.001)
+	})
+}
+
+func TestService_CanaryRollsBack(t *testing.T) {
+	service, _, _ := setupCanaryService(t)
+	db := database.GetDB()
+	setSetting(t, service, settings.KeyPaymentCanaryMinCount, "3")
+	setSetting(t, service, settings.KeyPaymentCanaryPercent, "50")
+
+	for i, status := range []string{models.PaymentStatusFailed, models.PaymentStatusFailed, models.PaymentStatusPaid} {
+		order := createPendingOrder(t)
+		require.NoError(t, db.Create(&models.Payment{
+			OrderID: order.ID, RazorpayOrderID: fmt.Sprintf("order_old_%d", i), Amount: 10000, Status: status, Provider: models.PaymentProviderCanary,
+		}).Error)
+	}
+
+	response, err := service.CreateOrder(CreateOrderRequest{OrderID: createPendingOrder(t).ID})
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentProviderPrimary, response.Provider, "a failing canary takes no more payments")
+
+	setting, err := service.settings.GetSetting(settings.KeyPaymentCanaryPercent, models.SettingScopeGlobal, "")
+	require.NoError(t, err)
+	assert.Equal(t, "0", setting.Value)
+	assert.Equal(t, rolloutActor, setting.UpdatedBy)
+	assert.Contains(t, setting.Description, "66.7%")
+
+	// Failures from before the rollback do not count against a re-enabled canary
+	setSetting(t, service, settings.KeyPaymentCanaryPercent, "50")
+	status, err := service.RolloutStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), status.provider(models.PaymentProviderCanary).attempts())
+	response, err = service.CreateOrder(CreateOrderRequest{OrderID: createPendingOrder(t).ID})
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentProviderCanary, response.Provider)
+}
@@ -1,6 +1,7 @@
 package payments
 
 import (
+	"errors"
 	"net/http"
 
 	"ecommerce-website/pkg/utils"
@@ -33,6 +34,10 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 
 	payment, err := h.service.CreateOrder(req)
 	if err != nil {
+		if errors.Is(err, ErrIntentInProgress) {
+			utils.ErrorResponse(c, http.StatusConflict, "PAYMENT_IN_PROGRESS", "A payment is already being created for this order", nil)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "PAYMENT_ORDER_FAILED", "Failed to create payment order", err.Error())
 		return
 	}
@@ -40,6 +45,35 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusCreated, "Payment order created successfully", payment)
 }
 
+// RetryPayment handles POST /api/orders/:id/retry-payment.
+// Creates a fresh payment intent for an unpaid order whose payment failed or was abandoned.
+func (h *Handler) RetryPayment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	payment, err := h.service.RetryPayment(c.Param("id"), userID.(string))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrOrderNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+		case errors.Is(err, ErrOrderNotPayable):
+			utils.ErrorResponse(c, http.StatusConflict, "ORDER_NOT_PAYABLE", "Order is not awaiting payment", err.Error())
+		case errors.Is(err, ErrOrderUnavailable):
+			utils.ErrorResponse(c, http.StatusConflict, "ORDER_ITEMS_UNAVAILABLE", "Order items are no longer available", err.Error())
+		case errors.Is(err, ErrIntentInProgress):
+			utils.ErrorResponse(c, http.StatusConflict, "PAYMENT_IN_PROGRESS", "A payment is already being created for this order", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "PAYMENT_ORDER_FAILED", "Failed to create payment order", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Payment order created successfully", payment)
+}
+
 // VerifyPayment verifies the payment signature and updates payment status
 func (h *Handler) VerifyPayment(c *gin.Context) {
 	var req VerifyPaymentRequest
@@ -73,18 +107,71 @@ func (h *Handler) GetPaymentStatus(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Payment status retrieved successfully", payment)
 }
 
-// HandleWebhook handles Razorpay webhook events
+// HandleWebhook verifies, records and applies a signed Razorpay webhook delivery; redelivered events are acknowledged without being applied again
 func (h *Handler) HandleWebhook(c *gin.Context) {
-	var payload map[string]interface{}
-	if err := c.ShouldBindJSON(&payload); err != nil {
+	body, err := c.GetRawData()
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_WEBHOOK_PAYLOAD", "Invalid webhook payload", err.Error())
 		return
 	}
 
-	if err := h.service.HandleWebhook(payload); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "WEBHOOK_PROCESSING_FAILED", "Failed to process webhook", err.Error())
+	result, err := h.service.ProcessWebhook(body, c.GetHeader("X-Razorpay-Signature"), c.GetHeader("X-Razorpay-Event-Id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidWebhookSignature):
+			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_WEBHOOK_SIGNATURE", "Invalid webhook signature", nil)
+		case errors.Is(err, ErrInvalidWebhookPayload):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_WEBHOOK_PAYLOAD", "Invalid webhook payload", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "WEBHOOK_PROCESSING_FAILED", "Failed to process webhook", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "event": result})
+}
+
+// RefundOrder handles POST /api/admin/orders/:id/refund (admin only)
+func (h *Handler) RefundOrder(c *gin.Context) {
+	var req RefundRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request", err.Error())
+			return
+		}
+	}
+
+	adminID, _ := c.Get("user_id")
+	adminIDStr, _ := adminID.(string)
+
+	refund, err := h.service.RefundOrder(c.Param("id"), req, adminIDStr)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrOrderNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+		case errors.Is(err, ErrOrderNotRefundable):
+			utils.ErrorResponse(c, http.StatusConflict, "ORDER_NOT_REFUNDABLE", "Order has no captured payment to refund", nil)
+		case errors.Is(err, ErrAlreadyRefunded):
+			utils.ErrorResponse(c, http.StatusConflict, "ORDER_ALREADY_REFUNDED", "Order is already fully refunded", nil)
+		case errors.Is(err, ErrInvalidRefund), errors.Is(err, ErrRefundExceedsBalance):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REFUND", "Invalid refund", err.Error())
+		case errors.Is(err, ErrRefundFailed):
+			utils.ErrorResponse(c, http.StatusBadGateway, "REFUND_FAILED", "Payment provider refused the refund", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "REFUND_FAILED", "Failed to refund order", err.Error())
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	utils.SuccessResponse(c, http.StatusCreated, "Refund issued successfully", refund)
+}
+
+// GetRollout handles GET /api/admin/payments/rollout (admin only)
+func (h *Handler) GetRollout(c *gin.Context) {
+	status, err := h.service.RolloutStatus(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_PAYMENT_ROLLOUT_FAILED", "Failed to get payment rollout", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Payment rollout retrieved successfully", status)
 }
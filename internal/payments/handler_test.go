@@ -22,6 +22,7 @@ func setupTestHandler(t *testing.T) (*Handler, *gin.Engine) {
 	require.NoError(t, err)
 
 	service := NewService(database.GetDB(), "test_key_id", "test_secret")
+	service.webhookSecret = "test_webhook_secret"
 	handler := NewHandler(service)
 
 	gin.SetMode(gin.TestMode)
@@ -202,6 +203,7 @@ func TestHandler_HandleWebhook(t *testing.T) {
 	tests := []struct {
 		name           string
 		requestBody    map[string]interface{}
+		unsigned       bool
 		expectedStatus int
 	}{
 		{
@@ -224,6 +226,14 @@ func TestHandler_HandleWebhook(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK, // Should succeed for unknown events
 		},
+		{
+			name: "unsigned webhook",
+			requestBody: map[string]interface{}{
+				"event": "unknown.event",
+			},
+			unsigned:       true,
+			expectedStatus: http.StatusUnauthorized,
+		},
 		{
 			name:           "invalid JSON",
 			requestBody:    nil,
@@ -233,14 +243,14 @@ func TestHandler_HandleWebhook(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var req *http.Request
+			body := []byte("invalid json")
 			if tt.requestBody != nil {
-				jsonBody, _ := json.Marshal(tt.requestBody)
-				req, _ = http.NewRequest("POST", "/payments/webhook", bytes.NewBuffer(jsonBody))
-				req.Header.Set("Content-Type", "application/json")
-			} else {
-				req, _ = http.NewRequest("POST", "/payments/webhook", bytes.NewBuffer([]byte("invalid json")))
-				req.Header.Set("Content-Type", "application/json")
+				body, _ = json.Marshal(tt.requestBody)
+			}
+			req, _ := http.NewRequest("POST", "/payments/webhook", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			if !tt.unsigned {
+				req.Header.Set("X-Razorpay-Signature", signWebhookForTest("test_webhook_secret", body))
 			}
 
 			w := httptest.NewRecorder()
@@ -250,3 +260,48 @@ func TestHandler_HandleWebhook(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_RetryPayment(t *testing.T) {
+	handler, r := setupTestHandler(t)
+	handler.service.orders = &fakeOrders{prefix: "retry"}
+
+	order := createPendingOrder(t)
+	paid := createPendingOrder(t)
+	require.NoError(t, database.GetDB().Model(&paid).Update("status", "paid").Error)
+
+	r.POST("/orders/:id/retry-payment", func(c *gin.Context) {
+		c.Set("user_id", order.UserID)
+		handler.RetryPayment(c)
+	})
+
+	tests := []struct {
+		orderID        string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{order.ID, http.StatusCreated, ""},
+		{paid.ID, http.StatusNotFound, "ORDER_NOT_FOUND"},
+		{"missing", http.StatusNotFound, "ORDER_NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders/"+tt.orderID+"/retry-payment", nil))
+		assert.Equal(t, tt.expectedStatus, w.Code, tt.orderID)
+		if tt.expectedCode != "" {
+			assert.Contains(t, w.Body.String(), tt.expectedCode)
+		}
+	}
+
+	claim := models.Payment{OrderID: order.ID, RazorpayOrderID: "claim_retry", Amount: 10000, Currency: "INR", Status: models.PaymentStatusCreating}
+	require.NoError(t, database.GetDB().Create(&claim).Error)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders/"+order.ID+"/retry-payment", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "PAYMENT_IN_PROGRESS")
+
+	require.NoError(t, database.GetDB().Model(&order).Update("status", "shipped").Error)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders/"+order.ID+"/retry-payment", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "ORDER_NOT_PAYABLE")
+}
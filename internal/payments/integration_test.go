@@ -46,12 +46,13 @@ func setupIntegrationTest(t *testing.T) (*gin.Engine, *auth.Service, string) {
 
 	// Create payment service and handler
 	paymentService := NewService(database.GetDB(), cfg.RazorpayKeyID, cfg.RazorpaySecret)
+	paymentService.webhookSecret = "test_webhook_secret"
 	paymentHandler := NewHandler(paymentService)
 
 	// Setup router
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	SetupRoutes(r, paymentHandler, authService)
+	SetupRoutes(r.Group("/api"), paymentHandler, authService)
 
 	return r, authService, tokens.AccessToken
 }
@@ -138,18 +139,30 @@ func TestPaymentIntegration_WebhookEndpoint(t *testing.T) {
 	}
 
 	jsonBody, _ := json.Marshal(webhookPayload)
-	req, _ := http.NewRequest("POST", "/api/payments/webhook", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	deliver := func(eventID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/payments/webhook", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Razorpay-Signature", signWebhookForTest("test_webhook_secret", jsonBody))
+		req.Header.Set("X-Razorpay-Event-Id", eventID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
 
+	w := deliver("evt_integration_1")
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 	assert.Equal(t, "ok", response["status"])
+	assert.Equal(t, models.PaymentEventIgnored, response["event"].(map[string]interface{})["status"])
+
+	// Redelivery is acknowledged as a duplicate
+	w = deliver("evt_integration_1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, true, response["event"].(map[string]interface{})["duplicate"])
 }
 
 func TestPaymentIntegration_AuthenticationRequired(t *testing.T) {
@@ -0,0 +1,46 @@
+package payments
+
+import (
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires Razorpay payments into the application
+type Module struct {
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the payments module from the Razorpay configuration
+func NewModule(deps app.Deps) app.Module {
+	service := NewServiceWithIntentTTL(deps.DB, deps.Config.RazorpayKeyID, deps.Config.RazorpaySecret,
+		time.Duration(deps.Config.PaymentIntentTTLMinutes)*time.Minute)
+	service.webhookSecret = deps.Config.RazorpayWebhookSecret
+	if deps.Config.RazorpayCanaryKeyID != "" {
+		service.WithCanary(deps.Config.RazorpayCanaryKeyID, deps.Config.RazorpayCanarySecret, deps.Config.RazorpayCanaryHookSecret)
+	}
+	if deps.Outbox != nil {
+		service.WithOutbox(deps.Outbox)
+	}
+	return &Module{handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "payments"
+}
+
+// Models returns the payments, webhook event and refund tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.Payment{}, &models.PaymentEvent{}, &models.Refund{}, &models.RefundItem{}}
+}
+
+// RegisterAPIRoutes sets up the payment, webhook, refund and rollout routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,402 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+
+	"ecommerce-website/internal/email"
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/orderhistory"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/razorpay/razorpay-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOrderNotFound        = errors.New("order not found")
+	ErrOrderNotRefundable   = errors.New("order has no captured payment to refund")
+	ErrAlreadyRefunded      = errors.New("order is already fully refunded")
+	ErrInvalidRefund        = errors.New("invalid refund")
+	ErrRefundExceedsBalance = errors.New("refund exceeds the amount left to refund")
+	ErrRefundFailed         = errors.New("payment provider refused the refund")
+)
+
+// RefundRequest describes a refund of an order. Without items or amount the whole remaining balance is
+// refunded and every item not refunded yet goes back in stock. Items refund what was paid for them unless an
+// amount is given; an amount alone refunds money without returning anything to stock.
+type RefundRequest struct {
+	Amount float64             `json:"amount" binding:"omitempty,gt=0"`
+	Items  []RefundItemRequest `json:"items" binding:"omitempty,dive"`
+	Reason string              `json:"reason" binding:"max=255"`
+	// Restock puts refunded items back in stock; defaults to true, set false for damaged returns
+	Restock *bool `json:"restock"`
+}
+
+// RefundItemRequest is a quantity of an order item covered by a refund
+type RefundItemRequest struct {
+	OrderItemID string `json:"orderItemId" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required,min=1"`
+}
+
+// refundGateway issues refunds with the payment provider
+type refundGateway interface {
+	// Refund returns the provider refund ID and status
+	Refund(paymentID string, amount int64, notes map[string]interface{}) (string, string, error)
+}
+
+// razorpayRefunds issues refunds through the Razorpay API
+type razorpayRefunds struct {
+	client *razorpay.Client
+}
+
+// Refund refunds amount paise of a captured Razorpay payment
+func (r razorpayRefunds) Refund(paymentID string, amount int64, notes map[string]interface{}) (string, string, error) {
+	refund, err := r.client.Payment.Refund(paymentID, int(amount), map[string]interface{}{"notes": notes}, nil)
+	if err != nil {
+		return "", "", err
+	}
+	id, _ := refund["id"].(string)
+	status, _ := refund["status"].(string)
+	return id, status, nil
+}
+
+// RefundClaim is a refund claimed with the order and payment locked, waiting for IssueRefund to send it to the
+// payment provider
+type RefundClaim struct {
+	Refund *models.Refund
+	// markRefunded moves the order to refunded, with note in its history, once nothing is left to refund
+	markRefunded bool
+	note         string
+}
+
+// RefundOrder refunds an order through the payment provider, puts the refunded items back in stock, and
+// marks the payment and order refunded once nothing is left to refund. The refund is claimed in a short
+// transaction with the order and payment locked, so concurrent refunds cannot exceed what was paid, and the
+// provider is called after it committed.
+func (s *Service) RefundOrder(orderID string, req RefundRequest, adminID string) (*models.Refund, error) {
+	var claimed *models.Refund
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		claimed, err = s.claimRefund(tx, orderID, req, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claim := &RefundClaim{Refund: claimed, markRefunded: true, note: "refunded by admin"}
+	refund, err := s.IssueRefund(claim, func(tx *gorm.DB, refund *models.Refund) error {
+		if s.outbox == nil {
+			return nil
+		}
+		return email.QueueRefundNotification(tx, orderID, refund.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifyRefund(orderID, refund)
+	return refund, nil
+}
+
+// ClaimCancellationRefund claims a refund of the whole remaining balance of an order its customer is cancelling,
+// inside the cancellation transaction tx, for IssueRefund to send once the cancellation committed. The order
+// keeps its cancelled status and its stock is left alone, as cancelling restores it. Orders without a captured
+// payment need no refund and return nil.
+func (s *Service) ClaimCancellationRefund(tx *gorm.DB, orderID, userID, reason string) (*RefundClaim, error) {
+	refund, err := s.claimRefund(tx, orderID, RefundRequest{Reason: reason}, userID)
+	if errors.Is(err, ErrOrderNotRefundable) || errors.Is(err, ErrAlreadyRefunded) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &RefundClaim{Refund: refund}, nil
+}
+
+// ClaimReturnRefund claims a refund of quantity units of an order item whose return is being completed, inside
+// the return's transaction tx, for IssueRefund to send once that committed; the units go back in stock when
+// restock is set. The order is marked refunded once nothing is left to refund. Orders without a captured payment
+// need no refund and return nil.
+func (s *Service) ClaimReturnRefund(tx *gorm.DB, orderID, orderItemID string, quantity int, restock bool, adminID, reason string) (*RefundClaim, error) {
+	refund, err := s.claimRefund(tx, orderID, RefundRequest{
+		Items:   []RefundItemRequest{{OrderItemID: orderItemID, Quantity: quantity}},
+		Reason:  reason,
+		Restock: &restock,
+	}, adminID)
+	if errors.Is(err, ErrOrderNotRefundable) || errors.Is(err, ErrAlreadyRefunded) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &RefundClaim{Refund: refund, markRefunded: true, note: reason}, nil
+}
+
+// claimRefund validates a refund of an order inside tx and records it as creating, reserving its amount on the
+// payment so refunds claimed meanwhile only see what is left. Nothing goes back in stock until IssueRefund.
+func (s *Service) claimRefund(tx *gorm.DB, orderID string, req RefundRequest, adminID string) (*models.Refund, error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&order, "id = ?", orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to load order: %w", err)
+	}
+
+	var payment models.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status IN ?", orderID, []string{models.PaymentStatusPaid, models.PaymentStatusRefunded}).
+		Order("created_at DESC").First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotRefundable
+		}
+		return nil, fmt.Errorf("failed to load payment: %w", err)
+	}
+	if payment.RazorpayPaymentID == nil || *payment.RazorpayPaymentID == "" {
+		return nil, ErrOrderNotRefundable
+	}
+	remaining := payment.Amount - payment.AmountRefunded
+	if payment.Status == models.PaymentStatusRefunded || remaining <= 0 {
+		return nil, ErrAlreadyRefunded
+	}
+
+	lines, err := refundLines(tx, &order, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	var amount int64
+	switch {
+	case req.Amount > 0:
+		amount = utils.ToPaise(req.Amount)
+	case len(req.Items) > 0:
+		for _, line := range lines {
+			amount += utils.ToPaise(line.price * float64(line.Quantity))
+		}
+	default:
+		amount = remaining
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: nothing to refund", ErrInvalidRefund)
+	}
+	if amount > remaining {
+		return nil, fmt.Errorf("%w: %.2f left to refund", ErrRefundExceedsBalance, float64(remaining)/100)
+	}
+	if req.Amount > 0 && len(req.Items) == 0 {
+		// A money-only refund covers no items
+		lines = nil
+	}
+
+	// Cancelled orders had their stock restored on cancellation
+	restock := (req.Restock == nil || *req.Restock) && order.Status != "cancelled"
+
+	refund := models.Refund{
+		OrderID:   orderID,
+		PaymentID: payment.ID,
+		Amount:    amount,
+		Currency:  payment.Currency,
+		Status:    models.RefundStatusCreating,
+		Reason:    req.Reason,
+		CreatedBy: adminID,
+	}
+	for _, line := range lines {
+		line.Restocked = restock
+		refund.Items = append(refund.Items, line.RefundItem)
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		return nil, fmt.Errorf("failed to save refund: %w", err)
+	}
+	if err := tx.Model(&payment).Update("amount_refunded", payment.AmountRefunded+amount).Error; err != nil {
+		return nil, fmt.Errorf("failed to update payment record: %w", err)
+	}
+	return &refund, nil
+}
+
+// IssueRefund sends a claimed refund to the payment provider, outside any transaction, then records it in a
+// second one: the refunded items go back in stock, and the payment, and the order when the claim says so, are
+// marked refunded once nothing is left to refund. record, when set, runs in the same transaction. A refund the
+// provider refuses is marked failed and its amount released, so it can be claimed again.
+func (s *Service) IssueRefund(claim *RefundClaim, record func(tx *gorm.DB, refund *models.Refund) error) (*models.Refund, error) {
+	refund := *claim.Refund
+	var payment models.Payment
+	if err := s.db.First(&payment, "id = ?", refund.PaymentID).Error; err != nil {
+		s.failRefund(&refund)
+		return nil, fmt.Errorf("failed to load payment: %w", err)
+	}
+	providerID, providerStatus, err := s.provider(payment.Provider).refunds.Refund(*payment.RazorpayPaymentID, refund.Amount, map[string]interface{}{
+		"order_id":  refund.OrderID,
+		"refund_id": refund.ID,
+		"reason":    refund.Reason,
+	})
+	if err != nil {
+		s.failRefund(&refund)
+		return nil, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+
+	refund.Status = models.RefundStatusPending
+	if providerStatus == models.RefundStatusProcessed {
+		refund.Status = models.RefundStatusProcessed
+	}
+	if providerID != "" {
+		refund.ProviderRefundID = &providerID
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Refund{}).Where("id = ? AND status = ?", refund.ID, models.RefundStatusCreating).
+			Updates(map[string]interface{}{"status": refund.Status, "provider_refund_id": refund.ProviderRefundID})
+		if result.Error != nil {
+			return fmt.Errorf("failed to save refund: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("refund %s is no longer being created", refund.ID)
+		}
+
+		for _, item := range refund.Items {
+			if !item.Restocked {
+				continue
+			}
+			if err := tx.Model(&models.Product{}).Where("id = ?", item.ProductID).
+				Update("inventory", gorm.Expr("inventory + ?", item.Quantity)).Error; err != nil {
+				return fmt.Errorf("failed to restore inventory: %w", err)
+			}
+			if err := eventbus.PublishInventoryChanged(tx, item.ProductID, item.Quantity, eventbus.InventoryRefundRestock); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "id = ?", payment.ID).Error; err != nil {
+			return fmt.Errorf("failed to load payment: %w", err)
+		}
+		if payment.AmountRefunded >= payment.Amount {
+			if err := tx.Model(&payment).Update("status", models.PaymentStatusRefunded).Error; err != nil {
+				return fmt.Errorf("failed to update payment record: %w", err)
+			}
+			if claim.markRefunded {
+				if err := s.setOrderStatus(tx, refund.OrderID, "refunded", claim.note, orderhistory.Admin(refund.CreatedBy)); err != nil {
+					return err
+				}
+			}
+		}
+		if record != nil {
+			return record(tx, &refund)
+		}
+		return nil
+	})
+	if err != nil {
+		// The provider already refunded the money; the claim keeps its amount reserved so it is not refunded again
+		logger.Named("payments").Error("Failed to record issued refund", err, map[string]interface{}{"refund_id": refund.ID, "provider_refund_id": providerID})
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// failRefund marks a claimed refund the provider refused failed and releases its amount on the payment.
+// Failures are logged; the claim then keeps its amount reserved until it is looked into.
+func (s *Service) failRefund(refund *models.Refund) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Refund{}).Where("id = ? AND status = ?", refund.ID, models.RefundStatusCreating).
+			Update("status", models.RefundStatusFailed)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Model(&models.RefundItem{}).Where("refund_id = ?", refund.ID).Update("restocked", false).Error; err != nil {
+			return err
+		}
+		// Another refund may have marked the payment refunded counting this amount
+		return tx.Model(&models.Payment{}).Where("id = ?", refund.PaymentID).Updates(map[string]interface{}{
+			"amount_refunded": gorm.Expr("amount_refunded - ?", refund.Amount),
+			"status":          gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END", models.PaymentStatusRefunded, models.PaymentStatusPaid),
+		}).Error
+	})
+	if err != nil {
+		logger.Named("payments").Error("Failed to release refused refund", err, map[string]interface{}{"refund_id": refund.ID, "payment_id": refund.PaymentID})
+	}
+}
+
+// refundLine is an order item quantity being refunded, with the price it was bought at after discounts
+type refundLine struct {
+	models.RefundItem
+	price float64
+}
+
+// refundLines resolves the requested items against the order, refusing quantities beyond what is left
+// to refund. Without requested items every quantity not refunded yet is covered.
+func refundLines(tx *gorm.DB, order *models.Order, requested []RefundItemRequest) ([]refundLine, error) {
+	var previous []struct {
+		OrderItemID string
+		Quantity    int
+	}
+	if err := tx.Model(&models.RefundItem{}).
+		Select("refund_items.order_item_id, SUM(refund_items.quantity) AS quantity").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refunds.order_id = ? AND refunds.status <> ?", order.ID, models.RefundStatusFailed).
+		Group("refund_items.order_item_id").
+		Scan(&previous).Error; err != nil {
+		return nil, fmt.Errorf("failed to load previous refunds: %w", err)
+	}
+	refunded := make(map[string]int, len(previous))
+	for _, p := range previous {
+		refunded[p.OrderItemID] = p.Quantity
+	}
+
+	items := make(map[string]models.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		items[item.ID] = item
+	}
+
+	var lines []refundLine
+	if len(requested) == 0 {
+		for _, item := range order.Items {
+			if left := item.Quantity - refunded[item.ID]; left > 0 {
+				lines = append(lines, newRefundLine(item, left))
+			}
+		}
+		return lines, nil
+	}
+
+	wanted := make(map[string]int, len(requested))
+	for _, r := range requested {
+		item, ok := items[r.OrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: item %s is not part of this order", ErrInvalidRefund, r.OrderItemID)
+		}
+		wanted[r.OrderItemID] += r.Quantity
+		if left := item.Quantity - refunded[item.ID]; wanted[r.OrderItemID] > left {
+			return nil, fmt.Errorf("%w: only %d of item %s left to refund", ErrInvalidRefund, left, item.ID)
+		}
+	}
+	for _, item := range order.Items {
+		if quantity := wanted[item.ID]; quantity > 0 {
+			lines = append(lines, newRefundLine(item, quantity))
+		}
+	}
+	return lines, nil
+}
+
+// newRefundLine covers quantity of an order item
+func newRefundLine(item models.OrderItem, quantity int) refundLine {
+	return refundLine{
+		RefundItem: models.RefundItem{OrderItemID: item.ID, ProductID: item.ProductID, Quantity: quantity},
+		price:      item.NetPrice(),
+	}
+}
+
+// notifyRefund emails the customer about a refund; failures are logged and never undo the refund. With an
+// outbox the email was recorded with the refund.
+func (s *Service) notifyRefund(orderID string, refund *models.Refund) {
+	if s.outbox != nil {
+		s.outbox.Notify()
+		return
+	}
+	var order models.Order
+	if err := s.db.Preload("User").Preload("Items.Product").First(&order, "id = ?", orderID).Error; err != nil {
+		logger.Named("payments").Warn("Failed to load order for refund email", map[string]interface{}{"order_id": orderID, "error": err.Error()})
+		return
+	}
+	if err := s.emailService.SendRefundNotification(&order, refund); err != nil {
+		logger.Named("payments").Warn("Failed to send refund email", map[string]interface{}{"order_id": orderID, "refund_id": refund.ID, "error": err.Error()})
+	}
+}
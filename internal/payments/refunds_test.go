@@ -0,0 +1,419 @@
+package payments
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeRefunds records refunds instead of calling the payment provider
+type fakeRefunds struct {
+	calls  []int64
+	err    error
+	during func() // runs while the refund is issued
+}
+
+func (f *fakeRefunds) Refund(paymentID string, amount int64, notes map[string]interface{}) (string, string, error) {
+	if f.during != nil {
+		f.during()
+	}
+	if f.err != nil {
+		return "", "", f.err
+	}
+	f.calls = append(f.calls, amount)
+	return fmt.Sprintf("rfnd_%d", len(f.calls)), "pending", nil
+}
+
+// recordingEmail records refund notifications
+type recordingEmail struct {
+	refunds []*models.Refund
+}
+
+func (e *recordingEmail) SendOrderConfirmation(order *models.Order) error {
+	return nil
+}
+
+func (e *recordingEmail) SendOrderStatusUpdate(order *models.Order, oldStatus, newStatus string) error {
+	return nil
+}
+
+func (e *recordingEmail) SendReviewRequest(order *models.Order, reviewToken string) error {
+	return nil
+}
+
+func (e *recordingEmail) SendRefundNotification(order *models.Order, refund *models.Refund) error {
+	e.refunds = append(e.refunds, refund)
+	return nil
+}
+
+// createPaidOrder stores a paid order of two items (2 x 100.00 and 1 x 50.00) and returns it with its items
+func createPaidOrder(t *testing.T, status string) (models.Order, []models.OrderItem) {
+	db := database.GetDB()
+	user := models.User{Email: "refund-" + status + "@example.com", Password: "hashedpassword", FirstName: "Refund", LastName: "User"}
+	require.NoError(t, db.Create(&user).Error)
+
+	order := models.Order{UserID: user.ID, Status: status, Subtotal: 250, Total: 250}
+	require.NoError(t, db.Create(&order).Error)
+
+	var items []models.OrderItem
+	for i, line := range []struct {
+		price    float64
+		quantity int
+	}{{100, 2}, {50, 1}} {
+		product := models.Product{Name: fmt.Sprintf("Product %d", i), Price: line.price, SKU: fmt.Sprintf("REFUND-%s-%d", status, i), Inventory: 5, CategoryID: "cat"}
+		require.NoError(t, db.Create(&product).Error)
+		item := models.OrderItem{OrderID: order.ID, ProductID: product.ID, Quantity: line.quantity, Price: line.price}
+		require.NoError(t, db.Create(&item).Error)
+		items = append(items, item)
+	}
+
+	paymentID := "pay_" + order.ID
+	payment := models.Payment{
+		OrderID:           order.ID,
+		RazorpayOrderID:   "order_" + order.ID,
+		RazorpayPaymentID: &paymentID,
+		Amount:            25000,
+		Currency:          "INR",
+		Status:            models.PaymentStatusPaid,
+	}
+	require.NoError(t, db.Create(&payment).Error)
+	return order, items
+}
+
+func setupRefundService(t *testing.T) (*Service, *fakeRefunds, *recordingEmail) {
+	setupTestDB(t)
+	service := NewService(database.GetDB(), "test_key_id", "test_secret")
+	gateway := &fakeRefunds{}
+	emails := &recordingEmail{}
+	service.refunds = gateway
+	service.emailService = emails
+	return service, gateway, emails
+}
+
+func productInventory(t *testing.T, productID string) int {
+	var product models.Product
+	require.NoError(t, database.GetDB().First(&product, "id = ?", productID).Error)
+	return product.Inventory
+}
+
+func orderStatus(t *testing.T, orderID string) string {
+	var order models.Order
+	require.NoError(t, database.GetDB().First(&order, "id = ?", orderID).Error)
+	return order.Status
+}
+
+func TestService_RefundOrder(t *testing.T) {
+	t.Run("full refund restocks every item and refunds the order", func(t *testing.T) {
+		service, gateway, emails := setupRefundService(t)
+		order, items := createPaidOrder(t, "delivered")
+
+		refund, err := service.RefundOrder(order.ID, RefundRequest{Reason: "damaged"}, "admin-1")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(25000), refund.Amount)
+		assert.Equal(t, []int64{25000}, gateway.calls)
+		require.NotNil(t, refund.ProviderRefundID)
+		assert.Equal(t, "rfnd_1", *refund.ProviderRefundID)
+		assert.Len(t, refund.Items, 2)
+		assert.Equal(t, 7, productInventory(t, items[0].ProductID))
+		assert.Equal(t, 6, productInventory(t, items[1].ProductID))
+		assert.Equal(t, "refunded", orderStatus(t, order.ID))
+
+		var payment models.Payment
+		require.NoError(t, database.GetDB().First(&payment, "order_id = ?", order.ID).Error)
+		assert.Equal(t, models.PaymentStatusRefunded, payment.Status)
+		assert.Equal(t, int64(25000), payment.AmountRefunded)
+		assert.Len(t, emails.refunds, 1)
+
+		var history models.OrderStatusHistory
+		require.NoError(t, database.GetDB().Where("order_id = ? AND new_status = ?", order.ID, "refunded").First(&history).Error)
+		assert.Equal(t, "delivered", history.OldStatus)
+		assert.Equal(t, models.OrderActorAdmin, history.ActorRole)
+
+		_, err = service.RefundOrder(order.ID, RefundRequest{}, "admin-1")
+		assert.True(t, errors.Is(err, ErrAlreadyRefunded))
+	})
+
+	t.Run("partial item refunds add up to a full refund", func(t *testing.T) {
+		service, gateway, _ := setupRefundService(t)
+		order, items := createPaidOrder(t, "shipped")
+
+		refund, err := service.RefundOrder(order.ID, RefundRequest{Items: []RefundItemRequest{{OrderItemID: items[0].ID, Quantity: 1}}}, "admin-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(10000), refund.Amount)
+		assert.Equal(t, 6, productInventory(t, items[0].ProductID))
+		assert.Equal(t, "shipped", orderStatus(t, order.ID))
+
+		_, err = service.RefundOrder(order.ID, RefundRequest{Items: []RefundItemRequest{{OrderItemID: items[0].ID, Quantity: 2}}}, "admin-1")
+		assert.True(t, errors.Is(err, ErrInvalidRefund), "only one unit of the item is left to refund")
+
+		refund, err = service.RefundOrder(order.ID, RefundRequest{}, "admin-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(15000), refund.Amount)
+		require.Len(t, refund.Items, 2)
+		assert.Equal(t, 1, refund.Items[0].Quantity)
+		assert.Equal(t, 7, productInventory(t, items[0].ProductID))
+		assert.Equal(t, "refunded", orderStatus(t, order.ID))
+		assert.Equal(t, []int64{10000, 15000}, gateway.calls)
+	})
+
+	t.Run("amount-only refund does not restock", func(t *testing.T) {
+		service, _, _ := setupRefundService(t)
+		order, items := createPaidOrder(t, "delivered")
+
+		refund, err := service.RefundOrder(order.ID, RefundRequest{Amount: 20}, "admin-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2000), refund.Amount)
+		assert.Empty(t, refund.Items)
+		assert.Equal(t, 5, productInventory(t, items[0].ProductID))
+
+		_, err = service.RefundOrder(order.ID, RefundRequest{Amount: 240}, "admin-1")
+		assert.True(t, errors.Is(err, ErrRefundExceedsBalance))
+	})
+
+	t.Run("cancelled orders are not restocked twice", func(t *testing.T) {
+		service, _, _ := setupRefundService(t)
+		order, items := createPaidOrder(t, "cancelled")
+
+		refund, err := service.RefundOrder(order.ID, RefundRequest{}, "admin-1")
+		require.NoError(t, err)
+		assert.False(t, refund.Items[0].Restocked)
+		assert.Equal(t, 5, productInventory(t, items[0].ProductID))
+		assert.Equal(t, "refunded", orderStatus(t, order.ID))
+	})
+
+	t.Run("provider failure only records the failed refund", func(t *testing.T) {
+		service, gateway, emails := setupRefundService(t)
+		gateway.err = errors.New("gateway down")
+		order, items := createPaidOrder(t, "delivered")
+
+		_, err := service.RefundOrder(order.ID, RefundRequest{}, "admin-1")
+		assert.True(t, errors.Is(err, ErrRefundFailed))
+		assert.Equal(t, 5, productInventory(t, items[0].ProductID))
+		assert.Equal(t, "delivered", orderStatus(t, order.ID))
+		assert.Empty(t, emails.refunds)
+
+		var refunds []models.Refund
+		require.NoError(t, database.GetDB().Preload("Items").Find(&refunds).Error)
+		require.Len(t, refunds, 1)
+		assert.Equal(t, models.RefundStatusFailed, refunds[0].Status)
+		assert.Nil(t, refunds[0].ProviderRefundID)
+		for _, item := range refunds[0].Items {
+			assert.False(t, item.Restocked)
+		}
+		var payment models.Payment
+		require.NoError(t, database.GetDB().First(&payment, "order_id = ?", order.ID).Error)
+		assert.Equal(t, models.PaymentStatusPaid, payment.Status)
+		assert.Zero(t, payment.AmountRefunded, "the claimed amount is released")
+
+		gateway.err = nil
+		refund, err := service.RefundOrder(order.ID, RefundRequest{}, "admin-1")
+		require.NoError(t, err, "a failed refund can be issued again")
+		assert.Equal(t, int64(25000), refund.Amount)
+		assert.Len(t, refund.Items, 2)
+	})
+
+	t.Run("the provider is called outside the transaction", func(t *testing.T) {
+		service, gateway, _ := setupRefundService(t)
+		order, _ := createPaidOrder(t, "delivered")
+		db := database.GetDB()
+
+		// The claim is committed before the provider is called, so the order and payment are not locked
+		// meanwhile and a concurrent refund only sees what the claim left
+		gateway.during = func() {
+			gateway.during = nil
+			var claim models.Refund
+			require.NoError(t, db.First(&claim, "order_id = ? AND status = ?", order.ID, models.RefundStatusCreating).Error)
+			assert.Equal(t, int64(10000), claim.Amount)
+			_, err := service.RefundOrder(order.ID, RefundRequest{Amount: 200}, "admin-2")
+			assert.ErrorIs(t, err, ErrRefundExceedsBalance)
+		}
+		refund, err := service.RefundOrder(order.ID, RefundRequest{Amount: 100}, "admin-1")
+		require.NoError(t, err)
+		assert.Equal(t, models.RefundStatusPending, refund.Status)
+		assert.Equal(t, []int64{10000}, gateway.calls)
+
+		var payment models.Payment
+		require.NoError(t, db.First(&payment, "order_id = ?", order.ID).Error)
+		assert.Equal(t, int64(10000), payment.AmountRefunded)
+		assert.Equal(t, models.PaymentStatusPaid, payment.Status)
+	})
+
+	t.Run("unpaid orders cannot be refunded", func(t *testing.T) {
+		service, _, _ := setupRefundService(t)
+		order, _ := createPaidOrder(t, "delivered")
+		require.NoError(t, database.GetDB().Model(&models.Payment{}).Where("order_id = ?", order.ID).Update("status", models.PaymentStatusCreated).Error)
+
+		_, err := service.RefundOrder(order.ID, RefundRequest{}, "admin-1")
+		assert.True(t, errors.Is(err, ErrOrderNotRefundable))
+
+		_, err = service.RefundOrder("missing", RefundRequest{}, "admin-1")
+		assert.True(t, errors.Is(err, ErrOrderNotFound))
+	})
+}
+
+func TestService_ClaimCancellationRefund(t *testing.T) {
+	service, gateway, _ := setupRefundService(t)
+	order, items := createPaidOrder(t, "cancelled")
+
+	db := database.GetDB()
+	var claim *RefundClaim
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		claim, err = service.ClaimCancellationRefund(tx, order.ID, order.UserID, "changed my mind")
+		return err
+	}))
+	require.NotNil(t, claim)
+	assert.Empty(t, gateway.calls, "the provider is only called once the cancellation committed")
+	refund, err := service.IssueRefund(claim, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(25000), refund.Amount)
+	assert.Equal(t, order.UserID, refund.CreatedBy)
+	assert.Equal(t, "changed my mind", refund.Reason)
+	assert.Equal(t, []int64{25000}, gateway.calls)
+	assert.Equal(t, 5, productInventory(t, items[0].ProductID), "cancelling already restored the stock")
+	assert.Equal(t, "cancelled", orderStatus(t, order.ID))
+
+	unpaid, _ := createPaidOrder(t, "pending")
+	require.NoError(t, db.Model(&models.Payment{}).Where("order_id = ?", unpaid.ID).Update("status", models.PaymentStatusCreated).Error)
+	claim, err = service.ClaimCancellationRefund(db, unpaid.ID, unpaid.UserID, "")
+	require.NoError(t, err)
+	assert.Nil(t, claim)
+	assert.Len(t, gateway.calls, 1)
+}
+
+func TestService_ClaimReturnRefund(t *testing.T) {
+	service, gateway, _ := setupRefundService(t)
+	order, items := createPaidOrder(t, "delivered")
+	db := database.GetDB()
+
+	refundReturn := func(item models.OrderItem, quantity int, restock bool) (*models.Refund, error) {
+		var claim *RefundClaim
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			claim, err = service.ClaimReturnRefund(tx, order.ID, item.ID, quantity, restock, "admin-1", "return RMA-1")
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return service.IssueRefund(claim, nil)
+	}
+
+	refund, err := refundReturn(items[0], 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10000), refund.Amount)
+	assert.Equal(t, "return RMA-1", refund.Reason)
+	assert.Equal(t, 6, productInventory(t, items[0].ProductID))
+	assert.Equal(t, "delivered", orderStatus(t, order.ID), "the order keeps its status while money is left")
+
+	_, err = refundReturn(items[0], 2, true)
+	assert.ErrorIs(t, err, ErrInvalidRefund, "only one unit is left to refund")
+
+	_, err = refundReturn(items[0], 1, true)
+	require.NoError(t, err)
+	refund, err = refundReturn(items[1], 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, 5, productInventory(t, items[1].ProductID), "damaged returns are not restocked")
+	assert.Equal(t, "refunded", orderStatus(t, order.ID), "returning everything refunds the order")
+	assert.Equal(t, []int64{10000, 10000, 5000}, gateway.calls)
+}
+
+func TestService_RefundDiscountedItems(t *testing.T) {
+	service, gateway, _ := setupRefundService(t)
+	order, items := createPaidOrder(t, "delivered")
+	db := database.GetDB()
+
+	// A promo code took 30.00 off the two units of the first line
+	require.NoError(t, db.Model(&items[0]).UpdateColumn("discount", 30).Error)
+	require.NoError(t, db.Model(&order).UpdateColumns(map[string]interface{}{"discount": 30, "total": 220}).Error)
+	require.NoError(t, db.Model(&models.Payment{}).Where("order_id = ?", order.ID).UpdateColumn("amount", 22000).Error)
+
+	refund, err := service.RefundOrder(order.ID, RefundRequest{Items: []RefundItemRequest{{OrderItemID: items[0].ID, Quantity: 1}}}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(8500), refund.Amount, "a unit refunds its price less its share of the discount")
+
+	var claim *RefundClaim
+	err = db.Transaction(func(tx *gorm.DB) error {
+		claim, err = service.ClaimReturnRefund(tx, order.ID, items[0].ID, 1, true, "admin-1", "return RMA-2")
+		return err
+	})
+	require.NoError(t, err)
+	refund, err = service.IssueRefund(claim, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8500), refund.Amount)
+
+	refund, err = service.RefundOrder(order.ID, RefundRequest{Items: []RefundItemRequest{{OrderItemID: items[1].ID, Quantity: 1}}}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), refund.Amount, "refunding every item pays back exactly what was charged")
+	assert.Equal(t, []int64{8500, 8500, 5000}, gateway.calls)
+	assert.Equal(t, "refunded", orderStatus(t, order.ID))
+}
+
+func TestService_RefundWebhookMarksRefundProcessed(t *testing.T) {
+	service, _, _ := setupRefundService(t)
+	order, _ := createPaidOrder(t, "delivered")
+
+	refund, err := service.RefundOrder(order.ID, RefundRequest{Amount: 50}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.RefundStatusPending, refund.Status)
+
+	err = service.HandleWebhook(map[string]interface{}{
+		"event": "refund.processed",
+		"payload": map[string]interface{}{
+			"refund":  map[string]interface{}{"entity": map[string]interface{}{"id": *refund.ProviderRefundID}},
+			"payment": map[string]interface{}{"entity": map[string]interface{}{"order_id": "order_" + order.ID, "amount_refunded": float64(5000)}},
+		},
+	})
+	require.NoError(t, err)
+
+	var stored models.Refund
+	require.NoError(t, database.GetDB().First(&stored, "id = ?", refund.ID).Error)
+	assert.Equal(t, models.RefundStatusProcessed, stored.Status)
+	assert.Equal(t, "delivered", orderStatus(t, order.ID))
+}
+
+func TestHandler_RefundOrder(t *testing.T) {
+	service, _, _ := setupRefundService(t)
+	handler := NewHandler(service)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/orders/:id/refund", handler.RefundOrder)
+
+	order, items := createPaidOrder(t, "delivered")
+
+	send := func(orderID string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, "/admin/orders/"+orderID+"/refund", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(order.ID, RefundRequest{Items: []RefundItemRequest{{OrderItemID: items[1].ID, Quantity: 1}}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = send(order.ID, RefundRequest{Items: []RefundItemRequest{{OrderItemID: items[1].ID, Quantity: 1}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_REFUND")
+
+	w = send(order.ID, map[string]interface{}{"amount": -5})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_REQUEST")
+
+	w = send("missing", RefundRequest{})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
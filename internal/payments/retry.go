@@ -0,0 +1,99 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ecommerce-website/internal/inventory"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOrderNotPayable  = errors.New("order is not awaiting payment")
+	ErrOrderUnavailable = errors.New("order items are no longer available")
+)
+
+// retryableStatuses are the order statuses whose payment a customer may retry
+var retryableStatuses = map[string]bool{
+	"pending":        true,
+	"payment_failed": true,
+}
+
+// RetryPayment creates a fresh payment intent for an unpaid order of userID, for example after its payment
+// failed. The order's items must still be on sale and the stock it holds is renewed for the life of the new
+// intent. Once the new intent has its gateway order, open intents of the order are cancelled so only the new
+// one can pay it, and the order's payment reference is moved to the new intent.
+func (s *Service) RetryPayment(orderID, userID string) (*PaymentResponse, error) {
+	account := s.pickProvider(context.Background())
+	var claim *models.Payment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").
+			First(&order, "id = ? AND user_id = ?", orderID, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return fmt.Errorf("failed to load order: %w", err)
+		}
+		if !retryableStatuses[order.Status] {
+			return fmt.Errorf("%w: order is %s", ErrOrderNotPayable, order.Status)
+		}
+
+		// A capture may arrive before the webhook moves the order, so a paid intent also ends retries
+		var paid int64
+		if err := tx.Model(&models.Payment{}).Where("order_id = ? AND status = ?", order.ID, models.PaymentStatusPaid).
+			Count(&paid).Error; err != nil {
+			return fmt.Errorf("failed to check payments: %w", err)
+		}
+		if paid > 0 {
+			return fmt.Errorf("%w: order is already paid", ErrOrderNotPayable)
+		}
+
+		if err := revalidateItems(tx, &order); err != nil {
+			return err
+		}
+		if err := inventory.RenewHolds(tx, inventory.HolderOrder, order.ID, s.intentTTL); err != nil {
+			if errors.Is(err, inventory.ErrInsufficientStock) {
+				return fmt.Errorf("%w: %v", ErrOrderUnavailable, err)
+			}
+			return err
+		}
+
+		var err error
+		claim, _, err = s.claimIntent(tx, order.ID, utils.ToPaise(order.Total), "Payment retry", account, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.completeIntent(claim, account, func(tx *gorm.DB, payment *models.Payment) error {
+		if err := tx.Model(&models.Order{}).Where("id = ?", payment.OrderID).
+			Update("payment_intent_id", payment.RazorpayOrderID).Error; err != nil {
+			return fmt.Errorf("failed to update order payment reference: %w", err)
+		}
+		return nil
+	})
+}
+
+// revalidateItems checks that every product of an order can still be sold. Its stock was taken when the order
+// was placed, so only products deleted or withdrawn since then fail the check.
+func revalidateItems(tx *gorm.DB, order *models.Order) error {
+	for _, item := range order.Items {
+		var product models.Product
+		if err := tx.Unscoped().Select("id, name, is_active, deleted_at").First(&product, "id = ?", item.ProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: product %s no longer exists", ErrOrderUnavailable, item.ProductID)
+			}
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		if !product.IsActive || product.DeletedAt.Valid {
+			return fmt.Errorf("%w: product %s is no longer available", ErrOrderUnavailable, product.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package payments
+
+import (
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/inventory"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_RetryPayment(t *testing.T) {
+	setupTestDB(t)
+	db := database.GetDB()
+	service := NewService(db, "test_key_id", "test_secret")
+	gateway := &fakeOrders{prefix: "retry"}
+	service.orders = gateway
+
+	order := createPendingOrder(t)
+	require.NoError(t, db.Model(&order).Update("status", "payment_failed").Error)
+	product := models.Product{Name: "Lamp", Price: 50, SKU: "RETRY-LAMP", Inventory: 5, IsActive: true, CategoryID: "cat"}
+	require.NoError(t, db.Create(&product).Error)
+	require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: product.ID, Quantity: 2, Price: 50, Total: 100}).Error)
+
+	expiresAt := time.Now().Add(10 * time.Minute)
+	failed := models.Payment{OrderID: order.ID, RazorpayOrderID: "order_failed", Amount: 10000, Currency: "INR", Status: models.PaymentStatusFailed}
+	open := models.Payment{OrderID: order.ID, RazorpayOrderID: "order_open", Amount: 10000, Currency: "INR", Status: models.PaymentStatusCreated, ExpiresAt: &expiresAt}
+	require.NoError(t, db.Create(&failed).Error)
+	require.NoError(t, db.Create(&open).Error)
+	require.NoError(t, db.Create(&models.InventoryReservation{ProductID: product.ID, Quantity: 2, HolderType: inventory.HolderOrder,
+		HolderID: order.ID, ExpiresAt: time.Now().Add(-time.Minute)}).Error)
+
+	activeHolds := func() []models.InventoryReservation {
+		var holds []models.InventoryReservation
+		require.NoError(t, db.Where("holder_id = ? AND released_at IS NULL AND expires_at > ?", order.ID, time.Now()).Find(&holds).Error)
+		return holds
+	}
+
+	_, err := service.RetryPayment(order.ID, "someone-else")
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+
+	response, err := service.RetryPayment(order.ID, order.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, "order_retry_1", response.RazorpayOrderID)
+	assert.Equal(t, int64(10000), response.Amount)
+	assert.False(t, response.Reused)
+
+	var reloaded models.Order
+	require.NoError(t, db.First(&reloaded, "id = ?", order.ID).Error)
+	assert.Equal(t, "order_retry_1", reloaded.PaymentIntentID)
+	require.NoError(t, db.First(&open, "id = ?", open.ID).Error)
+	assert.Equal(t, models.PaymentStatusCancelled, open.Status, "the open intent can no longer pay the order")
+	require.Len(t, activeHolds(), 1, "the lapsed hold is placed again")
+
+	// Retrying again replaces the previous retry's intent and extends the hold instead of adding one
+	response, err = service.RetryPayment(order.ID, order.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, "order_retry_2", response.RazorpayOrderID)
+	var previous models.Payment
+	require.NoError(t, db.First(&previous, "razorpay_order_id = ?", "order_retry_1").Error)
+	assert.Equal(t, models.PaymentStatusCancelled, previous.Status)
+	assert.Len(t, activeHolds(), 1)
+
+	// Stock another holder took while the hold had lapsed fails the retry
+	require.NoError(t, db.Model(&models.InventoryReservation{}).Where("holder_id = ?", order.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	require.NoError(t, db.Create(&models.InventoryReservation{ProductID: product.ID, Quantity: 4, HolderType: inventory.HolderCart,
+		HolderID: "cart-1", ExpiresAt: time.Now().Add(time.Hour)}).Error)
+	_, err = service.RetryPayment(order.ID, order.UserID)
+	assert.ErrorIs(t, err, ErrOrderUnavailable)
+	require.NoError(t, db.Where("holder_id = ?", "cart-1").Delete(&models.InventoryReservation{}).Error)
+
+	require.NoError(t, db.Model(&product).Update("is_active", false).Error)
+	_, err = service.RetryPayment(order.ID, order.UserID)
+	assert.ErrorIs(t, err, ErrOrderUnavailable)
+	require.NoError(t, db.Model(&product).Update("is_active", true).Error)
+
+	require.NoError(t, db.Model(&models.Payment{}).Where("razorpay_order_id = ?", "order_retry_2").Update("status", models.PaymentStatusPaid).Error)
+	_, err = service.RetryPayment(order.ID, order.UserID)
+	assert.ErrorIs(t, err, ErrOrderNotPayable, "a captured payment ends retries")
+
+	require.NoError(t, db.Model(&order).Update("status", "paid").Error)
+	_, err = service.RetryPayment(order.ID, order.UserID)
+	assert.ErrorIs(t, err, ErrOrderNotPayable)
+	assert.Equal(t, 2, gateway.calls)
+}
@@ -2,21 +2,20 @@ package payments
 
 import (
 	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(r *gin.Engine, handler *Handler, authService *auth.Service) {
-	api := r.Group("/api")
-
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
 	// Payment routes
 	payments := api.Group("/payments")
 	{
 		// Create payment order (requires authentication)
 		payments.POST("/create-order", authService.AuthMiddleware(), handler.CreateOrder)
 
-		// Verify payment (requires authentication)
-		payments.POST("/verify", authService.AuthMiddleware(), handler.VerifyPayment)
+		// Verify payment (requires authentication and a fresh nonce so a captured confirmation cannot be replayed)
+		payments.POST("/verify", authService.AuthMiddleware(), middleware.ReplayProtectionMiddleware(middleware.SensitiveReplayProtection), handler.VerifyPayment)
 
 		// Get payment status (requires authentication)
 		payments.GET("/status/:orderId", authService.AuthMiddleware(), handler.GetPaymentStatus)
@@ -24,4 +23,19 @@ func SetupRoutes(r *gin.Engine, handler *Handler, authService *auth.Service) {
 		// Webhook endpoint (no authentication required)
 		payments.POST("/webhook", handler.HandleWebhook)
 	}
+
+	// Retry payment of an unpaid order with a fresh intent (requires authentication)
+	api.POST("/orders/:id/retry-payment", authService.AuthMiddleware(), handler.RetryPayment)
+
+	// Admin routes (require admin role)
+	admin := api.Group("/admin")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		// Refunds move money, so double submits are rejected like payment confirmations
+		admin.POST("/orders/:id/refund", middleware.ReplayProtectionMiddleware(middleware.SensitiveReplayProtection), handler.RefundOrder)
+
+		// Canary rollout of a secondary payment account with per-account success rates
+		admin.GET("/payments/rollout", handler.GetRollout)
+	}
 }
@@ -1,22 +1,62 @@
 package payments
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
-
+	"math/rand"
+	"strconv"
+	"time"
+
+	"ecommerce-website/internal/adminevents"
+	"ecommerce-website/internal/email"
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/logger"
 	"ecommerce-website/internal/models"
-
+	"ecommerce-website/internal/orderevents"
+	"ecommerce-website/internal/orderhistory"
+	"ecommerce-website/internal/outbox"
+	"ecommerce-website/internal/settings"
+	"ecommerce-website/internal/webhooks"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/google/uuid"
 	"github.com/razorpay/razorpay-go"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultIntentTTL is how long a created payment intent stays payable before a new one is issued
+const DefaultIntentTTL = 15 * time.Minute
+
+const (
+	// intentClaimTimeout is how long an intent may wait for its gateway order. An older claim was left by a
+	// request that stopped, and the next request for the order replaces it.
+	intentClaimTimeout = time.Minute
+	// claimedOrderPrefix marks the placeholder gateway order ID of a claimed intent
+	claimedOrderPrefix = "claim_"
 )
 
+// ErrIntentInProgress is returned while another request is creating the gateway order of an order's intent
+var ErrIntentInProgress = errors.New("a payment is already being created for this order")
+
 type Service struct {
-	db     *gorm.DB
-	client *razorpay.Client
-	secret string
+	db            *gorm.DB
+	keyID         string
+	secret        string
+	webhookSecret string
+	intentTTL     time.Duration
+	settings      settings.ServiceInterface
+	orders        orderGateway
+	refunds       refundGateway
+	emailService  email.ServiceInterface
+	canary        *provider // secondary account new intents are gradually rolled out to; nil without one
+	random        func() float64
+	gatewayErrors *gatewayErrorLog
+	outbox        *outbox.Dispatcher // records refund emails with the refund when set
 }
 
 type CreateOrderRequest struct {
@@ -30,65 +70,218 @@ type VerifyPaymentRequest struct {
 	RazorpayOrderID   string `json:"razorpay_order_id" binding:"required"`
 	RazorpayPaymentID string `json:"razorpay_payment_id" binding:"required"`
 	RazorpaySignature string `json:"razorpay_signature" binding:"required"`
+	ClientToken       string `json:"client_token" binding:"required"`
 }
 
 type PaymentResponse struct {
-	ID              string `json:"id"`
-	RazorpayOrderID string `json:"razorpay_order_id"`
-	Amount          int64  `json:"amount"`
-	Currency        string `json:"currency"`
-	Status          string `json:"status"`
+	ID              string    `json:"id"`
+	RazorpayOrderID string    `json:"razorpay_order_id"`
+	Amount          int64     `json:"amount"`
+	Currency        string    `json:"currency"`
+	Status          string    `json:"status"`
+	ClientToken     string    `json:"client_token"`
+	KeyID           string    `json:"key_id"`   // key the checkout must be opened with for this intent
+	Provider        string    `json:"provider"` // provider account the intent was created with
+	ExpiresAt       time.Time `json:"expires_at"`
+	Reused          bool      `json:"reused"`
 }
 
 func NewService(db *gorm.DB, keyID, keySecret string) *Service {
+	return NewServiceWithIntentTTL(db, keyID, keySecret, DefaultIntentTTL)
+}
+
+// NewServiceWithIntentTTL creates a payments service whose intents expire after the given duration
+func NewServiceWithIntentTTL(db *gorm.DB, keyID, keySecret string, intentTTL time.Duration) *Service {
+	if intentTTL <= 0 {
+		intentTTL = DefaultIntentTTL
+	}
 	client := razorpay.NewClient(keyID, keySecret)
+	settingsService := settings.NewService(db)
 	return &Service{
-		db:     db,
-		client: client,
-		secret: keySecret,
+		db:            db,
+		keyID:         keyID,
+		secret:        keySecret,
+		intentTTL:     intentTTL,
+		settings:      settingsService,
+		orders:        razorpayOrders{client: client},
+		refunds:       razorpayRefunds{client: client},
+		emailService:  email.NewServiceWithSettings(settingsService).WithLog(db),
+		random:        rand.Float64,
+		gatewayErrors: newGatewayErrorLog(),
 	}
 }
 
+// WithOutbox makes the service send refund emails through the outbox: they are recorded in the transaction of
+// the refund and retried until sent. It returns the service for chaining after a constructor.
+func (s *Service) WithOutbox(dispatcher *outbox.Dispatcher) *Service {
+	s.outbox = dispatcher
+	email.RegisterOutbox(dispatcher, s.emailService, s.db)
+	return s
+}
+
+// CreateOrder creates (or reuses) a Razorpay order for the given store order.
+// The charged amount is always taken from the order total; an open intent for the
+// same order and amount is returned as-is, while stale intents are cancelled so
+// they can no longer be verified.
+//
+// The gateway is never called inside a transaction. The intent is claimed in a short transaction with the
+// order row locked, so a concurrent retry for the same order finds the claim instead of creating a second
+// gateway order; the gateway order is then created and recorded on the claimed intent in a second
+// transaction. A new gateway order goes to the canary account for the rolled out share of intents.
 func (s *Service) CreateOrder(req CreateOrderRequest) (*PaymentResponse, error) {
-	// Verify the order exists and get its details
-	var order models.Order
-	if err := s.db.First(&order, "id = ?", req.OrderID).Error; err != nil {
-		return nil, fmt.Errorf("order not found: %w", err)
+	account := s.pickProvider(context.Background())
+	var claim *models.Payment
+	var reused *PaymentResponse
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", req.OrderID).Error; err != nil {
+			return fmt.Errorf("order not found: %w", err)
+		}
+
+		// Convert amount to paise (Razorpay expects amount in smallest currency unit)
+		amountInPaise := utils.ToPaise(order.Total)
+		if req.Amount != 0 && utils.ToPaise(req.Amount) != amountInPaise {
+			return fmt.Errorf("amount mismatch: order total is %.2f", order.Total)
+		}
+
+		var err error
+		claim, reused, err = s.claimIntent(tx, order.ID, amountInPaise, req.Description, account, true)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused != nil {
+		return reused, nil
+	}
+	return s.completeIntent(claim, account, nil)
+}
+
+// claimIntent returns the open intent of an order when reuse is set and one charges amount and has not
+// expired. Otherwise it claims a new intent for completeIntent to create the gateway order of. tx must hold
+// the order row locked. An intent another request is still creating fails the claim with ErrIntentInProgress.
+func (s *Service) claimIntent(tx *gorm.DB, orderID string, amount int64, description string, account provider, reuse bool) (*models.Payment, *PaymentResponse, error) {
+	now := time.Now()
+	var openPayments []models.Payment
+	if err := tx.Where("order_id = ? AND status IN ?", orderID, []string{models.PaymentStatusCreated, models.PaymentStatusCreating}).
+		Order("created_at DESC").Find(&openPayments).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load existing payments: %w", err)
 	}
 
-	// Convert amount to paise (Razorpay expects amount in smallest currency unit)
-	amountInPaise := int64(req.Amount * 100)
+	for i := range openPayments {
+		existing := &openPayments[i]
+		switch {
+		case existing.Status == models.PaymentStatusCreating && now.Sub(existing.CreatedAt) < intentClaimTimeout:
+			return nil, nil, ErrIntentInProgress
+		case existing.Status == models.PaymentStatusCreating:
+			// The request that claimed it stopped before recording a gateway order
+			if err := tx.Model(existing).Update("status", models.PaymentStatusCancelled).Error; err != nil {
+				return nil, nil, fmt.Errorf("failed to release abandoned payment: %w", err)
+			}
+		case reuse && existing.Amount == amount && !existing.IsExpired(now):
+			return nil, s.toPaymentResponse(existing, true), nil
+		}
+	}
+
+	// The gateway order ID is required and unique, so the claim holds a placeholder until it has one
+	id := uuid.New().String()
+	claim := models.Payment{
+		ID:              id,
+		OrderID:         orderID,
+		RazorpayOrderID: claimedOrderPrefix + id,
+		Amount:          amount,
+		Currency:        "INR",
+		Status:          models.PaymentStatusCreating,
+		Provider:        account.name,
+		Description:     &description,
+	}
+	if err := tx.Create(&claim).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to save payment record: %w", err)
+	}
+	return &claim, nil, nil
+}
 
-	// Create Razorpay order
+// completeIntent creates the gateway order of a claimed intent with account, falling back to the primary
+// account when the canary fails, and records it. Recording cancels the other open intents of the order so
+// only the new one can pay it; record, when set, runs in the same transaction. The claim is released when the
+// gateway order cannot be created.
+func (s *Service) completeIntent(claim *models.Payment, account provider, record func(tx *gorm.DB, payment *models.Payment) error) (*PaymentResponse, error) {
 	data := map[string]interface{}{
-		"amount":   amountInPaise,
-		"currency": "INR",
-		"receipt":  req.OrderID,
+		"amount":   claim.Amount,
+		"currency": claim.Currency,
+		"receipt":  claim.OrderID,
 	}
 
-	if req.Description != "" {
+	if claim.Description != nil && *claim.Description != "" {
 		data["notes"] = map[string]interface{}{
-			"description": req.Description,
+			"description": *claim.Description,
 		}
 	}
 
-	razorpayOrder, err := s.client.Order.Create(data, nil)
+	razorpayOrderID, err := account.orders.CreateOrder(data)
+	if err != nil && account.name != models.PaymentProviderPrimary {
+		// The customer should not see a canary failure; the intent is created with the primary instead
+		s.recordGatewayError(account.name, err)
+		account = s.provider(models.PaymentProviderPrimary)
+		razorpayOrderID, err = account.orders.CreateOrder(data)
+	}
 	if err != nil {
+		s.recordGatewayError(account.name, err)
+		s.releaseClaim(claim)
 		return nil, fmt.Errorf("failed to create Razorpay order: %w", err)
 	}
 
-	// Save payment record in database
-	payment := models.Payment{
-		OrderID:         req.OrderID,
-		RazorpayOrderID: razorpayOrder["id"].(string),
-		Amount:          amountInPaise,
-		Currency:        "INR",
-		Status:          models.PaymentStatusCreated,
-		Description:     &req.Description,
+	expiresAt := time.Now().Add(s.intentTTL)
+	claim.RazorpayOrderID = razorpayOrderID
+	claim.Provider = account.name
+	claim.Status = models.PaymentStatusCreated
+	claim.ExpiresAt = &expiresAt
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Payment{}).
+			Where("id = ? AND status = ?", claim.ID, models.PaymentStatusCreating).
+			Updates(map[string]interface{}{
+				"razorpay_order_id": claim.RazorpayOrderID,
+				"provider":          claim.Provider,
+				"status":            claim.Status,
+				"expires_at":        claim.ExpiresAt,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to save payment record: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			// Claims are only taken over once they are older than intentClaimTimeout
+			return fmt.Errorf("%w: the claim on payment %s was released", ErrIntentInProgress, claim.ID)
+		}
+
+		if err := tx.Model(&models.Payment{}).
+			Where("order_id = ? AND id <> ? AND status = ?", claim.OrderID, claim.ID, models.PaymentStatusCreated).
+			Update("status", models.PaymentStatusCancelled).Error; err != nil {
+			return fmt.Errorf("failed to invalidate previous payment: %w", err)
+		}
+		if record != nil {
+			return record(tx, claim)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return s.toPaymentResponse(claim, false), nil
+}
+
+// releaseClaim deletes a claimed intent whose gateway order was not created, so the order can be paid again
+// straight away
+func (s *Service) releaseClaim(claim *models.Payment) {
+	if err := s.db.Where("id = ? AND status = ?", claim.ID, models.PaymentStatusCreating).Delete(&models.Payment{}).Error; err != nil {
+		logger.Named("payments").Warn("Failed to release payment claim", map[string]interface{}{"payment_id": claim.ID, "error": err.Error()})
+	}
+}
 
-	if err := s.db.Create(&payment).Error; err != nil {
-		return nil, fmt.Errorf("failed to save payment record: %w", err)
+// toPaymentResponse builds the client-facing view of a payment intent
+func (s *Service) toPaymentResponse(payment *models.Payment, reused bool) *PaymentResponse {
+	var expiresAt time.Time
+	if payment.ExpiresAt != nil {
+		expiresAt = *payment.ExpiresAt
 	}
 
 	return &PaymentResponse{
@@ -97,33 +290,79 @@ func (s *Service) CreateOrder(req CreateOrderRequest) (*PaymentResponse, error)
 		Amount:          payment.Amount,
 		Currency:        payment.Currency,
 		Status:          payment.Status,
-	}, nil
+		ClientToken:     s.clientToken(payment),
+		KeyID:           s.provider(payment.Provider).keyID,
+		Provider:        payment.Provider,
+		ExpiresAt:       expiresAt,
+		Reused:          reused,
+	}
 }
 
-func (s *Service) VerifyPayment(req VerifyPaymentRequest) error {
-	// Verify signature
-	if !s.verifySignature(req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature) {
-		return errors.New("invalid payment signature")
+// clientToken signs the intent's identity, amount and expiry so the client cannot alter them
+func (s *Service) clientToken(payment *models.Payment) string {
+	var expiresAt int64
+	if payment.ExpiresAt != nil {
+		expiresAt = payment.ExpiresAt.Unix()
 	}
 
-	// Find payment record
+	message := payment.ID + "|" + payment.RazorpayOrderID + "|" +
+		strconv.FormatInt(payment.Amount, 10) + "|" + strconv.FormatInt(expiresAt, 10)
+	h := hmac.New(sha256.New, []byte(s.secret))
+	h.Write([]byte(message))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *Service) VerifyPayment(req VerifyPaymentRequest) error {
+	// Find payment record; the provider account it was created with signs the payment
 	var payment models.Payment
 	if err := s.db.First(&payment, "razorpay_order_id = ?", req.RazorpayOrderID).Error; err != nil {
 		return fmt.Errorf("payment record not found: %w", err)
 	}
 
-	// Update payment record
-	payment.RazorpayPaymentID = &req.RazorpayPaymentID
-	payment.RazorpaySignature = &req.RazorpaySignature
-	payment.Status = models.PaymentStatusPaid
+	// Verify signature
+	if !s.verifySignature(payment.Provider, req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature) {
+		return errors.New("invalid payment signature")
+	}
 
-	if err := s.db.Save(&payment).Error; err != nil {
-		return fmt.Errorf("failed to update payment record: %w", err)
+	// Only an open intent can be paid. The capture webhook may already have recorded this very payment; any
+	// other intent was replaced, failed, or is owed a refund and must not mark the order as paid.
+	alreadyPaid := payment.Status == models.PaymentStatusPaid &&
+		payment.RazorpayPaymentID != nil && *payment.RazorpayPaymentID == req.RazorpayPaymentID
+	switch {
+	case alreadyPaid:
+	case payment.Status == models.PaymentStatusCancelled:
+		return errors.New("payment intent has been superseded")
+	case payment.Status != models.PaymentStatusCreated:
+		return fmt.Errorf("payment intent is %s", payment.Status)
+	case payment.IsExpired(time.Now()):
+		return errors.New("payment intent has expired")
+	}
+
+	if !hmac.Equal([]byte(req.ClientToken), []byte(s.clientToken(&payment))) {
+		return errors.New("invalid client token")
+	}
+	if alreadyPaid {
+		return nil
+	}
+
+	// The status is checked again in the update so an intent cancelled or failed meanwhile stays unpaid
+	result := s.db.Model(&models.Payment{}).
+		Where("id = ? AND status = ?", payment.ID, models.PaymentStatusCreated).
+		Updates(map[string]interface{}{
+			"razorpay_payment_id": req.RazorpayPaymentID,
+			"razorpay_signature":  req.RazorpaySignature,
+			"status":              models.PaymentStatusPaid,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update payment record: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("payment intent changed while it was verified")
 	}
 
 	// Update order status
-	if err := s.db.Model(&models.Order{}).Where("id = ?", payment.OrderID).Update("status", "paid").Error; err != nil {
-		return fmt.Errorf("failed to update order status: %w", err)
+	if err := s.setOrderStatus(s.db, payment.OrderID, "paid", "payment verified", orderhistory.System); err != nil {
+		return err
 	}
 
 	return nil
@@ -137,42 +376,48 @@ func (s *Service) GetPaymentByOrderID(orderID string) (*models.Payment, error) {
 	return &payment, nil
 }
 
-func (s *Service) verifySignature(orderID, paymentID, signature string) bool {
+// verifySignature checks a checkout signature under the secret of the named provider account
+func (s *Service) verifySignature(providerName, orderID, paymentID, signature string) bool {
 	// Create the expected signature
 	message := orderID + "|" + paymentID
-	h := hmac.New(sha256.New, []byte(s.secret))
+	h := hmac.New(sha256.New, []byte(s.provider(providerName).secret))
 	h.Write([]byte(message))
 	expectedSignature := hex.EncodeToString(h.Sum(nil))
 
 	return hmac.Equal([]byte(signature), []byte(expectedSignature))
 }
 
+// HandleWebhook applies a webhook event to payments and orders without recording the delivery;
+// ProcessWebhook is the entry point for signed provider deliveries
 func (s *Service) HandleWebhook(payload map[string]interface{}) error {
+	_, err := s.applyEvent(s.db, payload)
+	return err
+}
+
+// applyEvent applies a webhook event through db and reports whether the event type is one payments acts on
+func (s *Service) applyEvent(db *gorm.DB, payload map[string]interface{}) (bool, error) {
 	event, ok := payload["event"].(string)
 	if !ok {
-		return errors.New("invalid webhook payload: missing event")
+		return false, errors.New("invalid webhook payload: missing event")
 	}
 
 	switch event {
 	case "payment.captured":
-		return s.handlePaymentCaptured(payload)
+		return true, s.handlePaymentCaptured(db, payload)
 	case "payment.failed":
-		return s.handlePaymentFailed(payload)
+		return true, s.handlePaymentFailed(db, payload)
+	case "refund.processed":
+		return true, s.handleRefundProcessed(db, payload)
 	default:
 		// Ignore other events
-		return nil
+		return false, nil
 	}
 }
 
-func (s *Service) handlePaymentCaptured(payload map[string]interface{}) error {
-	paymentData, ok := payload["payload"].(map[string]interface{})
-	if !ok {
-		return errors.New("invalid webhook payload: missing payment data")
-	}
-
-	payment, ok := paymentData["payment"].(map[string]interface{})
-	if !ok {
-		return errors.New("invalid webhook payload: missing payment entity")
+func (s *Service) handlePaymentCaptured(db *gorm.DB, payload map[string]interface{}) error {
+	payment, err := eventEntity(payload, "payment")
+	if err != nil {
+		return err
 	}
 
 	orderID, ok := payment["order_id"].(string)
@@ -187,38 +432,77 @@ func (s *Service) handlePaymentCaptured(payload map[string]interface{}) error {
 
 	// Update payment record
 	var paymentRecord models.Payment
-	if err := s.db.First(&paymentRecord, "razorpay_order_id = ?", orderID).Error; err != nil {
+	if err := db.First(&paymentRecord, "razorpay_order_id = ?", orderID).Error; err != nil {
 		return fmt.Errorf("payment record not found: %w", err)
 	}
 
+	// A capture repeated under another event, or after the payment was verified, changes nothing
+	if paymentRecord.Status == models.PaymentStatusPaid || paymentRecord.Status == models.PaymentStatusRefunded {
+		return nil
+	}
+
 	paymentRecord.RazorpayPaymentID = &paymentID
-	paymentRecord.Status = models.PaymentStatusPaid
 
 	if method, ok := payment["method"].(string); ok {
 		paymentRecord.Method = &method
 	}
 
-	if err := s.db.Save(&paymentRecord).Error; err != nil {
+	// Money captured on a superseded intent, after the order was already paid through
+	// another intent, or for an order that moved on without it, is kept off the order
+	// and flagged so it can be refunded
+	superseded, err := s.isSupersededCapture(db, &paymentRecord)
+	if err != nil {
+		return err
+	}
+	if superseded {
+		paymentRecord.Status = models.PaymentStatusRefundRequired
+		if err := db.Save(&paymentRecord).Error; err != nil {
+			return fmt.Errorf("failed to update payment record: %w", err)
+		}
+		return nil
+	}
+
+	paymentRecord.Status = models.PaymentStatusPaid
+	if err := db.Save(&paymentRecord).Error; err != nil {
 		return fmt.Errorf("failed to update payment record: %w", err)
 	}
 
 	// Update order status
-	if err := s.db.Model(&models.Order{}).Where("id = ?", paymentRecord.OrderID).Update("status", "paid").Error; err != nil {
-		return fmt.Errorf("failed to update order status: %w", err)
+	if err := s.setOrderStatus(db, paymentRecord.OrderID, "paid", "payment captured", orderhistory.System); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-func (s *Service) handlePaymentFailed(payload map[string]interface{}) error {
-	paymentData, ok := payload["payload"].(map[string]interface{})
-	if !ok {
-		return errors.New("invalid webhook payload: missing payment data")
+// isSupersededCapture reports whether a captured payment belongs to an intent that may no longer pay the order:
+// a cancelled intent, any intent of an order another payment has already paid, or an order no longer awaiting payment
+func (s *Service) isSupersededCapture(db *gorm.DB, payment *models.Payment) (bool, error) {
+	if payment.Status == models.PaymentStatusCancelled || payment.Status == models.PaymentStatusRefundRequired {
+		return true, nil
 	}
 
-	payment, ok := paymentData["payment"].(map[string]interface{})
-	if !ok {
-		return errors.New("invalid webhook payload: missing payment entity")
+	var paidElsewhere int64
+	if err := db.Model(&models.Payment{}).
+		Where("order_id = ? AND id <> ? AND status = ?", payment.OrderID, payment.ID, models.PaymentStatusPaid).
+		Count(&paidElsewhere).Error; err != nil {
+		return false, fmt.Errorf("failed to check other payments: %w", err)
+	}
+	if paidElsewhere > 0 {
+		return true, nil
+	}
+
+	var order models.Order
+	if err := db.Select("id, status").First(&order, "id = ?", payment.OrderID).Error; err != nil {
+		return false, fmt.Errorf("failed to get order: %w", err)
+	}
+	return !canMoveOrder(order.Status, "paid"), nil
+}
+
+func (s *Service) handlePaymentFailed(db *gorm.DB, payload map[string]interface{}) error {
+	payment, err := eventEntity(payload, "payment")
+	if err != nil {
+		return err
 	}
 
 	orderID, ok := payment["order_id"].(string)
@@ -228,20 +512,85 @@ func (s *Service) handlePaymentFailed(payload map[string]interface{}) error {
 
 	// Update payment record
 	var paymentRecord models.Payment
-	if err := s.db.First(&paymentRecord, "razorpay_order_id = ?", orderID).Error; err != nil {
+	if err := db.First(&paymentRecord, "razorpay_order_id = ?", orderID).Error; err != nil {
 		return fmt.Errorf("payment record not found: %w", err)
 	}
 
+	// A failure on a superseded intent says nothing about the order's current intent, and a failed
+	// attempt reported after the intent was paid or already failed changes nothing
+	if paymentRecord.Status != models.PaymentStatusCreated {
+		return nil
+	}
+
 	paymentRecord.Status = models.PaymentStatusFailed
 
-	if err := s.db.Save(&paymentRecord).Error; err != nil {
+	if err := db.Save(&paymentRecord).Error; err != nil {
 		return fmt.Errorf("failed to update payment record: %w", err)
 	}
 
 	// Update order status
-	if err := s.db.Model(&models.Order{}).Where("id = ?", paymentRecord.OrderID).Update("status", "payment_failed").Error; err != nil {
-		return fmt.Errorf("failed to update order status: %w", err)
+	if err := s.setOrderStatus(db, paymentRecord.OrderID, "payment_failed", "payment failed", orderhistory.System); err != nil {
+		return err
 	}
 
+	adminevents.Publish(adminevents.TypePaymentFailed, adminevents.PaymentFailed{
+		OrderID:   paymentRecord.OrderID,
+		PaymentID: paymentRecord.ID,
+		Amount:    paymentRecord.Amount,
+		Currency:  paymentRecord.Currency,
+	})
 	return nil
 }
+
+// orderTransitions lists the order statuses each payment outcome may move an order from, so late and
+// repeated events never rewind an order that has moved on
+var orderTransitions = map[string][]string{
+	"paid":           {"pending", "payment_failed"},
+	"payment_failed": {"pending"},
+	"refunded":       {"paid", "processing", "shipped", "delivered", "cancelled"},
+}
+
+// canMoveOrder reports whether a payment outcome may move an order in the given status
+func canMoveOrder(from, to string) bool {
+	for _, status := range orderTransitions[to] {
+		if status == from {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrderStatus moves the store order to a payment outcome, recording the change in the status history and, when
+// enabled, the order event log; orders that cannot take the outcome from their current status are left unchanged
+func (s *Service) setOrderStatus(db *gorm.DB, orderID, status, reason string, actor orderhistory.Actor) error {
+	recordEvents := orderevents.Enabled(context.Background(), s.settings)
+	return db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, status").First(&order, "id = ?", orderID).Error; err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+		if !canMoveOrder(order.Status, status) {
+			return nil
+		}
+		if err := tx.Model(&models.Order{}).Where("id = ?", orderID).Update("status", status).Error; err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+		if err := orderhistory.Record(tx, orderID, order.Status, status, actor, reason); err != nil {
+			return err
+		}
+		if err := webhooks.PublishOrder(tx, orderID, order.Status); err != nil {
+			return err
+		}
+		if status == "paid" {
+			if err := eventbus.PublishOrder(tx, eventbus.EventOrderPaid, orderID); err != nil {
+				return err
+			}
+		}
+		if recordEvents {
+			return orderevents.Append(tx, orderID, orderevents.TypeOrderStatusChanged, orderevents.OrderStatusChanged{
+				From: order.Status, To: status, Reason: reason,
+			})
+		}
+		return nil
+	})
+}
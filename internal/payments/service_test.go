@@ -1,7 +1,13 @@
 package payments
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
 	"ecommerce-website/internal/config"
 	"ecommerce-website/internal/database"
@@ -83,6 +89,164 @@ func TestService_CreateOrder(t *testing.T) {
 	}
 }
 
+func TestService_CreateOrderReusesOpenIntent(t *testing.T) {
+	setupTestDB(t)
+
+	user := models.User{
+		Email:     "reuse@example.com",
+		Password:  "hashedpassword",
+		FirstName: "Test",
+		LastName:  "User",
+	}
+	require.NoError(t, database.GetDB().Create(&user).Error)
+
+	order := models.Order{
+		UserID:   user.ID,
+		Status:   "pending",
+		Subtotal: 100.0,
+		Total:    110.0,
+	}
+	require.NoError(t, database.GetDB().Create(&order).Error)
+
+	service := NewService(database.GetDB(), "test_key_id", "test_secret")
+
+	t.Run("reuses unexpired intent with same amount", func(t *testing.T) {
+		expiresAt := time.Now().Add(10 * time.Minute)
+		existing := models.Payment{
+			OrderID:         order.ID,
+			RazorpayOrderID: "order_open123",
+			Amount:          11000,
+			Currency:        "INR",
+			Status:          models.PaymentStatusCreated,
+			ExpiresAt:       &expiresAt,
+		}
+		require.NoError(t, database.GetDB().Create(&existing).Error)
+
+		resp, err := service.CreateOrder(CreateOrderRequest{OrderID: order.ID, Amount: 110.0})
+		require.NoError(t, err)
+		assert.True(t, resp.Reused)
+		assert.Equal(t, existing.ID, resp.ID)
+		assert.Equal(t, int64(11000), resp.Amount)
+		assert.NotEmpty(t, resp.ClientToken)
+	})
+
+	t.Run("invalidates expired intent", func(t *testing.T) {
+		var existing models.Payment
+		require.NoError(t, database.GetDB().First(&existing, "razorpay_order_id = ?", "order_open123").Error)
+		expired := time.Now().Add(-time.Minute)
+		require.NoError(t, database.GetDB().Model(&existing).Update("expires_at", expired).Error)
+
+		// Creating the replacement gateway order fails without credentials, which rolls the whole
+		// attempt back; the stale intent stays unpayable because it has expired
+		_, err := service.CreateOrder(CreateOrderRequest{OrderID: order.ID, Amount: 110.0})
+		assert.Error(t, err)
+
+		require.NoError(t, database.GetDB().First(&existing, "id = ?", existing.ID).Error)
+		assert.True(t, existing.IsExpired(time.Now()))
+
+		paymentID := "pay_expired"
+		err = service.VerifyPayment(VerifyPaymentRequest{
+			RazorpayOrderID:   "order_open123",
+			RazorpayPaymentID: paymentID,
+			RazorpaySignature: signForTest("test_secret", "order_open123", paymentID),
+			ClientToken:       service.clientToken(&existing),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expired")
+	})
+
+	t.Run("rejects amount that differs from order total", func(t *testing.T) {
+		_, err := service.CreateOrder(CreateOrderRequest{OrderID: order.ID, Amount: 1.0})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "amount mismatch")
+	})
+
+	t.Run("superseded intent cannot be verified", func(t *testing.T) {
+		superseded := models.Payment{
+			OrderID:         order.ID,
+			RazorpayOrderID: "order_superseded123",
+			Amount:          11000,
+			Currency:        "INR",
+			Status:          models.PaymentStatusCancelled,
+		}
+		require.NoError(t, database.GetDB().Create(&superseded).Error)
+
+		paymentID := "pay_superseded"
+		err := service.VerifyPayment(VerifyPaymentRequest{
+			RazorpayOrderID:   "order_superseded123",
+			RazorpayPaymentID: paymentID,
+			RazorpaySignature: signForTest("test_secret", "order_superseded123", paymentID),
+			ClientToken:       service.clientToken(&superseded),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "superseded")
+	})
+}
+
+func TestService_CreateOrderClaimsIntent(t *testing.T) {
+	setupTestDB(t)
+	db := database.GetDB()
+	service := NewService(db, "test_key_id", "test_secret")
+	gateway := &fakeOrders{prefix: "claim"}
+	service.orders = gateway
+	order := createPendingOrder(t)
+
+	// The claim is committed before the gateway is called, so the order is not locked meanwhile and a
+	// concurrent request for it finds the claim instead of creating a second gateway order
+	gateway.during = func() {
+		gateway.during = nil
+		var claim models.Payment
+		require.NoError(t, db.First(&claim, "order_id = ? AND status = ?", order.ID, models.PaymentStatusCreating).Error)
+		_, err := service.CreateOrder(CreateOrderRequest{OrderID: order.ID})
+		assert.ErrorIs(t, err, ErrIntentInProgress)
+	}
+	response, err := service.CreateOrder(CreateOrderRequest{OrderID: order.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "order_claim_1", response.RazorpayOrderID)
+	assert.Equal(t, models.PaymentStatusCreated, response.Status)
+	assert.False(t, response.Reused)
+
+	var first models.Payment
+	require.NoError(t, db.First(&first, "id = ?", response.ID).Error)
+	assert.Equal(t, "order_claim_1", first.RazorpayOrderID)
+	assert.Equal(t, models.PaymentStatusCreated, first.Status)
+	require.NotNil(t, first.ExpiresAt)
+
+	claims := func() int64 {
+		var count int64
+		require.NoError(t, db.Model(&models.Payment{}).Where("order_id = ? AND status = ?", order.ID, models.PaymentStatusCreating).Count(&count).Error)
+		return count
+	}
+
+	t.Run("a gateway failure releases the claim", func(t *testing.T) {
+		require.NoError(t, db.Model(&first).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+		gateway.err = errors.New("gateway unavailable")
+		_, err := service.CreateOrder(CreateOrderRequest{OrderID: order.ID})
+		assert.Error(t, err)
+		gateway.err = nil
+		assert.Zero(t, claims())
+	})
+
+	t.Run("a recent claim blocks the order and an abandoned one is replaced", func(t *testing.T) {
+		pending := models.Payment{OrderID: order.ID, RazorpayOrderID: "claim_pending", Amount: 10000, Currency: "INR",
+			Status: models.PaymentStatusCreating}
+		require.NoError(t, db.Create(&pending).Error)
+		_, err := service.CreateOrder(CreateOrderRequest{OrderID: order.ID})
+		assert.ErrorIs(t, err, ErrIntentInProgress)
+
+		require.NoError(t, db.Model(&pending).Update("created_at", time.Now().Add(-2*intentClaimTimeout)).Error)
+		response, err := service.CreateOrder(CreateOrderRequest{OrderID: order.ID})
+		require.NoError(t, err)
+		assert.Equal(t, "order_claim_3", response.RazorpayOrderID)
+		assert.Zero(t, claims())
+
+		require.NoError(t, db.First(&pending, "id = ?", pending.ID).Error)
+		assert.Equal(t, models.PaymentStatusCancelled, pending.Status)
+		require.NoError(t, db.First(&first, "id = ?", first.ID).Error)
+		assert.Equal(t, models.PaymentStatusCancelled, first.Status, "the expired intent is replaced by the new one")
+	})
+}
+
 func TestService_VerifySignature(t *testing.T) {
 	service := NewService(database.GetDB(), "test_key_id", "test_secret")
 
@@ -111,7 +275,7 @@ func TestService_VerifySignature(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := service.verifySignature(tt.orderID, tt.paymentID, tt.signature)
+			got := service.verifySignature(models.PaymentProviderPrimary, tt.orderID, tt.paymentID, tt.signature)
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -167,3 +331,288 @@ func TestService_HandleWebhook(t *testing.T) {
 		})
 	}
 }
+
+func signForTest(secret, orderID, paymentID string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(orderID + "|" + paymentID))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func TestService_VerifyPaymentRules(t *testing.T) {
+	setupTestDB(t)
+	db := database.GetDB()
+	service := NewService(db, "test_key_id", "test_secret")
+
+	user := models.User{Email: "verify-rules@example.com", Password: "hashedpassword", FirstName: "Test", LastName: "User"}
+	require.NoError(t, db.Create(&user).Error)
+
+	newIntent := func(razorpayOrderID string, expiresIn time.Duration) (*models.Order, *models.Payment) {
+		order := models.Order{UserID: user.ID, Status: "pending", Subtotal: 100, Total: 100}
+		require.NoError(t, db.Create(&order).Error)
+		expiresAt := time.Now().Add(expiresIn)
+		payment := models.Payment{OrderID: order.ID, RazorpayOrderID: razorpayOrderID, Amount: 10000, Currency: "INR", Status: models.PaymentStatusCreated, ExpiresAt: &expiresAt}
+		require.NoError(t, db.Create(&payment).Error)
+		return &order, &payment
+	}
+	verify := func(payment *models.Payment, paymentID, clientToken string) error {
+		return service.VerifyPayment(VerifyPaymentRequest{
+			RazorpayOrderID:   payment.RazorpayOrderID,
+			RazorpayPaymentID: paymentID,
+			RazorpaySignature: signForTest("test_secret", payment.RazorpayOrderID, paymentID),
+			ClientToken:       clientToken,
+		})
+	}
+
+	t.Run("expired intent cannot be verified", func(t *testing.T) {
+		_, payment := newIntent("order_expired_rules", -time.Minute)
+		err := verify(payment, "pay_expired_rules", service.clientToken(payment))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expired")
+	})
+
+	t.Run("client token is required and must match", func(t *testing.T) {
+		_, payment := newIntent("order_token_rules", 10*time.Minute)
+		assert.Error(t, verify(payment, "pay_token_rules", ""))
+		assert.Error(t, verify(payment, "pay_token_rules", "forged"))
+	})
+
+	t.Run("open intent with its token marks the order paid", func(t *testing.T) {
+		order, payment := newIntent("order_ok_rules", 10*time.Minute)
+		require.NoError(t, verify(payment, "pay_ok_rules", service.clientToken(payment)))
+
+		require.NoError(t, db.First(order, "id = ?", order.ID).Error)
+		assert.Equal(t, "paid", order.Status)
+	})
+
+	t.Run("only open intents can be paid", func(t *testing.T) {
+		for _, status := range []string{
+			models.PaymentStatusCreating,
+			models.PaymentStatusFailed,
+			models.PaymentStatusCancelled,
+			models.PaymentStatusRefundRequired,
+			models.PaymentStatusRefunded,
+		} {
+			order, payment := newIntent("order_"+status+"_rules", 10*time.Minute)
+			require.NoError(t, db.Model(payment).Update("status", status).Error)
+
+			assert.Error(t, verify(payment, "pay_"+status+"_rules", service.clientToken(payment)), status)
+			require.NoError(t, db.First(payment, "id = ?", payment.ID).Error)
+			assert.Equal(t, status, payment.Status)
+			assert.Nil(t, payment.RazorpayPaymentID, status)
+			require.NoError(t, db.First(order, "id = ?", order.ID).Error)
+			assert.Equal(t, "pending", order.Status, status)
+		}
+	})
+
+	t.Run("a payment already captured verifies again", func(t *testing.T) {
+		_, payment := newIntent("order_captured_rules", 10*time.Minute)
+		require.NoError(t, verify(payment, "pay_captured_rules", service.clientToken(payment)))
+		require.NoError(t, db.First(payment, "id = ?", payment.ID).Error)
+
+		assert.NoError(t, verify(payment, "pay_captured_rules", service.clientToken(payment)))
+		assert.Error(t, verify(payment, "pay_other_rules", service.clientToken(payment)), "a paid intent takes no second payment")
+	})
+}
+
+func TestService_WebhookCaptureOnSupersededIntent(t *testing.T) {
+	setupTestDB(t)
+	db := database.GetDB()
+	service := NewService(db, "test_key_id", "test_secret")
+
+	user := models.User{Email: "webhook-superseded@example.com", Password: "hashedpassword", FirstName: "Test", LastName: "User"}
+	require.NoError(t, db.Create(&user).Error)
+	order := models.Order{UserID: user.ID, Status: "pending", Subtotal: 100, Total: 100}
+	require.NoError(t, db.Create(&order).Error)
+
+	stale := models.Payment{OrderID: order.ID, RazorpayOrderID: "order_stale_wh", Amount: 10000, Currency: "INR", Status: models.PaymentStatusCancelled}
+	current := models.Payment{OrderID: order.ID, RazorpayOrderID: "order_current_wh", Amount: 10000, Currency: "INR", Status: models.PaymentStatusCreated}
+	require.NoError(t, db.Create(&stale).Error)
+	require.NoError(t, db.Create(&current).Error)
+
+	captured := func(razorpayOrderID, paymentID string) map[string]interface{} {
+		return map[string]interface{}{
+			"event": "payment.captured",
+			"payload": map[string]interface{}{
+				"payment": map[string]interface{}{"id": paymentID, "order_id": razorpayOrderID, "method": "upi"},
+			},
+		}
+	}
+
+	t.Run("capture on a cancelled intent is flagged for refund", func(t *testing.T) {
+		require.NoError(t, service.HandleWebhook(captured("order_stale_wh", "pay_stale_wh")))
+
+		require.NoError(t, db.First(&stale, "id = ?", stale.ID).Error)
+		assert.Equal(t, models.PaymentStatusRefundRequired, stale.Status)
+		require.NoError(t, db.First(&order, "id = ?", order.ID).Error)
+		assert.Equal(t, "pending", order.Status)
+	})
+
+	t.Run("current intent pays the order", func(t *testing.T) {
+		require.NoError(t, service.HandleWebhook(captured("order_current_wh", "pay_current_wh")))
+
+		require.NoError(t, db.First(&order, "id = ?", order.ID).Error)
+		assert.Equal(t, "paid", order.Status)
+	})
+
+	t.Run("second capture after the order is paid is flagged for refund", func(t *testing.T) {
+		late := models.Payment{OrderID: order.ID, RazorpayOrderID: "order_late_wh", Amount: 10000, Currency: "INR", Status: models.PaymentStatusCreated}
+		require.NoError(t, db.Create(&late).Error)
+
+		require.NoError(t, service.HandleWebhook(captured("order_late_wh", "pay_late_wh")))
+		require.NoError(t, db.First(&late, "id = ?", late.ID).Error)
+		assert.Equal(t, models.PaymentStatusRefundRequired, late.Status)
+	})
+}
+
+func signWebhookForTest(secret string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func TestService_ProcessWebhook(t *testing.T) {
+	setupTestDB(t)
+	db := database.GetDB()
+	service := NewService(db, "test_key_id", "test_secret")
+	service.webhookSecret = "test_webhook_secret"
+
+	user := models.User{Email: "webhook-events@example.com", Password: "hashedpassword", FirstName: "Test", LastName: "User"}
+	require.NoError(t, db.Create(&user).Error)
+
+	newIntent := func(razorpayOrderID string) (*models.Order, *models.Payment) {
+		order := models.Order{UserID: user.ID, Status: "pending", Subtotal: 100, Total: 100}
+		require.NoError(t, db.Create(&order).Error)
+		payment := models.Payment{OrderID: order.ID, RazorpayOrderID: razorpayOrderID, Amount: 10000, Currency: "INR", Status: models.PaymentStatusCreated}
+		require.NoError(t, db.Create(&payment).Error)
+		return &order, &payment
+	}
+	deliver := func(eventID, event string, payment map[string]interface{}) (*WebhookResult, error) {
+		body, err := json.Marshal(map[string]interface{}{
+			"event":   event,
+			"payload": map[string]interface{}{"payment": map[string]interface{}{"entity": payment}},
+		})
+		require.NoError(t, err)
+		return service.ProcessWebhook(body, signWebhookForTest("test_webhook_secret", body), eventID)
+	}
+	orderStatus := func(order *models.Order) string {
+		require.NoError(t, db.First(order, "id = ?", order.ID).Error)
+		return order.Status
+	}
+
+	t.Run("rejects unsigned and mis-signed deliveries", func(t *testing.T) {
+		body := []byte(`{"event":"payment.captured"}`)
+		_, err := service.ProcessWebhook(body, "", "evt_unsigned")
+		assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+		_, err = service.ProcessWebhook(body, signWebhookForTest("other_secret", body), "evt_unsigned")
+		assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+
+		unconfigured := NewService(db, "test_key_id", "test_secret")
+		_, err = unconfigured.ProcessWebhook(body, signWebhookForTest("", body), "evt_unsigned")
+		assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+
+		var count int64
+		require.NoError(t, db.Model(&models.PaymentEvent{}).Where("event_id = ?", "evt_unsigned").Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("redelivered capture is applied once", func(t *testing.T) {
+		order, _ := newIntent("order_evt_capture")
+		captured := map[string]interface{}{"id": "pay_evt_capture", "order_id": "order_evt_capture", "method": "card", "amount": 10000}
+
+		result, err := deliver("evt_capture", "payment.captured", captured)
+		require.NoError(t, err)
+		assert.Equal(t, models.PaymentEventProcessed, result.Status)
+		assert.False(t, result.Duplicate)
+		assert.Equal(t, "paid", orderStatus(order))
+
+		// The order moves on; the redelivery must not pull it back to paid
+		require.NoError(t, db.Model(order).Update("status", "shipped").Error)
+		result, err = deliver("evt_capture", "payment.captured", captured)
+		require.NoError(t, err)
+		assert.True(t, result.Duplicate)
+		assert.Equal(t, "shipped", orderStatus(order))
+
+		// The same capture under a different event id changes nothing either
+		_, err = deliver("evt_capture_order_paid", "payment.captured", captured)
+		require.NoError(t, err)
+		assert.Equal(t, "shipped", orderStatus(order))
+
+		var stored models.PaymentEvent
+		require.NoError(t, db.First(&stored, "event_id = ?", "evt_capture").Error)
+		assert.Equal(t, 1, stored.Attempts)
+		assert.Equal(t, "order_evt_capture", *stored.RazorpayOrderID)
+		assert.Equal(t, "pay_evt_capture", *stored.RazorpayPaymentID)
+		assert.NotNil(t, stored.ProcessedAt)
+	})
+
+	t.Run("failure reported after capture leaves the order paid", func(t *testing.T) {
+		order, payment := newIntent("order_evt_late_fail")
+		_, err := deliver("evt_late_fail_capture", "payment.captured", map[string]interface{}{"id": "pay_evt_late_ok", "order_id": "order_evt_late_fail"})
+		require.NoError(t, err)
+		_, err = deliver("evt_late_fail", "payment.failed", map[string]interface{}{"id": "pay_evt_late_bad", "order_id": "order_evt_late_fail"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "paid", orderStatus(order))
+		require.NoError(t, db.First(payment, "id = ?", payment.ID).Error)
+		assert.Equal(t, models.PaymentStatusPaid, payment.Status)
+	})
+
+	t.Run("failed event is recorded and retried on redelivery", func(t *testing.T) {
+		captured := map[string]interface{}{"id": "pay_evt_retry", "order_id": "order_evt_retry"}
+		_, err := deliver("evt_retry", "payment.captured", captured)
+		require.Error(t, err)
+
+		var stored models.PaymentEvent
+		require.NoError(t, db.First(&stored, "event_id = ?", "evt_retry").Error)
+		assert.Equal(t, models.PaymentEventFailed, stored.Status)
+		require.NotNil(t, stored.Error)
+
+		// The intent shows up before the provider redelivers
+		order, _ := newIntent("order_evt_retry")
+		result, err := deliver("evt_retry", "payment.captured", captured)
+		require.NoError(t, err)
+		assert.Equal(t, models.PaymentEventProcessed, result.Status)
+		assert.False(t, result.Duplicate)
+		assert.Equal(t, "paid", orderStatus(order))
+
+		require.NoError(t, db.First(&stored, "event_id = ?", "evt_retry").Error)
+		assert.Equal(t, 2, stored.Attempts)
+		assert.Nil(t, stored.Error)
+	})
+
+	t.Run("refunds mark the order refunded once fully refunded", func(t *testing.T) {
+		order, payment := newIntent("order_evt_refund")
+		_, err := deliver("evt_refund_capture", "payment.captured", map[string]interface{}{"id": "pay_evt_refund", "order_id": "order_evt_refund"})
+		require.NoError(t, err)
+
+		_, err = deliver("evt_refund_partial", "refund.processed", map[string]interface{}{"id": "pay_evt_refund", "order_id": "order_evt_refund", "amount_refunded": 4000})
+		require.NoError(t, err)
+		require.NoError(t, db.First(payment, "id = ?", payment.ID).Error)
+		assert.Equal(t, models.PaymentStatusPaid, payment.Status)
+		assert.Equal(t, int64(4000), payment.AmountRefunded)
+		assert.Equal(t, "paid", orderStatus(order))
+
+		_, err = deliver("evt_refund_full", "refund.processed", map[string]interface{}{"id": "pay_evt_refund", "order_id": "order_evt_refund", "amount_refunded": 10000})
+		require.NoError(t, err)
+		require.NoError(t, db.First(payment, "id = ?", payment.ID).Error)
+		assert.Equal(t, models.PaymentStatusRefunded, payment.Status)
+		assert.Equal(t, "refunded", orderStatus(order))
+
+		// The earlier partial refund arriving late does not lower the refunded amount
+		_, err = deliver("evt_refund_partial_late", "refund.processed", map[string]interface{}{"id": "pay_evt_refund", "order_id": "order_evt_refund", "amount_refunded": 4000})
+		require.NoError(t, err)
+		require.NoError(t, db.First(payment, "id = ?", payment.ID).Error)
+		assert.Equal(t, int64(10000), payment.AmountRefunded)
+	})
+
+	t.Run("capture for a cancelled order is flagged for refund", func(t *testing.T) {
+		order, payment := newIntent("order_evt_cancelled")
+		require.NoError(t, db.Model(order).Update("status", "cancelled").Error)
+
+		_, err := deliver("evt_cancelled_capture", "payment.captured", map[string]interface{}{"id": "pay_evt_cancelled", "order_id": "order_evt_cancelled"})
+		require.NoError(t, err)
+		require.NoError(t, db.First(payment, "id = ?", payment.ID).Error)
+		assert.Equal(t, models.PaymentStatusRefundRequired, payment.Status)
+		assert.Equal(t, "cancelled", orderStatus(order))
+	})
+}
@@ -0,0 +1,211 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/orderhistory"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrInvalidWebhookPayload   = errors.New("invalid webhook payload")
+)
+
+// WebhookResult describes how a webhook delivery was handled
+type WebhookResult struct {
+	EventID   string `json:"eventId"`
+	Status    string `json:"status"`
+	Duplicate bool   `json:"duplicate"`
+}
+
+// ProcessWebhook verifies a Razorpay webhook delivery, records it in payment_events and applies it.
+// eventID is the provider's X-Razorpay-Event-Id; deliveries without one are keyed by a hash of the body.
+// An event already processed (or ignored) is acknowledged as a duplicate without being applied again.
+// A failed event is recorded with its error and returned so the provider redelivers it; its payment and
+// order changes are rolled back so the next attempt starts from the same state.
+func (s *Service) ProcessWebhook(body []byte, signature, eventID string) (*WebhookResult, error) {
+	if !s.verifyWebhookSignature(body, signature) {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+	}
+	event, ok := payload["event"].(string)
+	if !ok || event == "" {
+		return nil, fmt.Errorf("%w: missing event", ErrInvalidWebhookPayload)
+	}
+	if eventID == "" {
+		sum := sha256.Sum256(body)
+		eventID = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	record := models.PaymentEvent{EventID: eventID, Event: event, Payload: string(body), Status: models.PaymentEventReceived}
+	if payment, err := eventEntity(payload, "payment"); err == nil {
+		if orderID, ok := payment["order_id"].(string); ok {
+			record.RazorpayOrderID = &orderID
+		}
+		if paymentID, ok := payment["id"].(string); ok {
+			record.RazorpayPaymentID = &paymentID
+		}
+	}
+
+	result := &WebhookResult{EventID: eventID}
+	var applyErr error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "event_id"}}, DoNothing: true}).
+			Create(&record).Error; err != nil {
+			return fmt.Errorf("failed to record webhook event: %w", err)
+		}
+		// Concurrent deliveries of the same event wait here until the first one is done
+		var stored models.PaymentEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stored, "event_id = ?", eventID).Error; err != nil {
+			return fmt.Errorf("failed to load webhook event: %w", err)
+		}
+		if stored.Status == models.PaymentEventProcessed || stored.Status == models.PaymentEventIgnored {
+			result.Status = stored.Status
+			result.Duplicate = true
+			return nil
+		}
+
+		var handled bool
+		applyErr = tx.Transaction(func(inner *gorm.DB) error {
+			var err error
+			handled, err = s.applyEvent(inner, payload)
+			return err
+		})
+
+		updates := map[string]interface{}{"attempts": stored.Attempts + 1}
+		switch {
+		case applyErr != nil:
+			result.Status = models.PaymentEventFailed
+			updates["error"] = applyErr.Error()
+		case handled:
+			result.Status = models.PaymentEventProcessed
+			updates["error"] = nil
+			updates["processed_at"] = time.Now()
+		default:
+			result.Status = models.PaymentEventIgnored
+			updates["error"] = nil
+			updates["processed_at"] = time.Now()
+		}
+		updates["status"] = result.Status
+		if err := tx.Model(&stored).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update webhook event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if applyErr != nil {
+		return result, applyErr
+	}
+	return result, nil
+}
+
+// verifyWebhookSignature checks the hex HMAC-SHA256 of the raw body under the webhook secret of the
+// primary or canary account; without a configured secret every delivery is rejected
+func (s *Service) verifyWebhookSignature(body []byte, signature string) bool {
+	if signature == "" {
+		return false
+	}
+	secrets := []string{s.webhookSecret}
+	if s.canary != nil {
+		secrets = append(secrets, s.canary.webhookSecret)
+	}
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		h := hmac.New(sha256.New, []byte(secret))
+		h.Write(body)
+		if hmac.Equal([]byte(signature), []byte(hex.EncodeToString(h.Sum(nil)))) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventEntity returns the named entity of a webhook payload. Razorpay wraps entities as
+// {"payment": {"entity": {...}}}; a bare entity is accepted as well.
+func eventEntity(payload map[string]interface{}, name string) (map[string]interface{}, error) {
+	data, ok := payload["payload"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid webhook payload: missing %s data", name)
+	}
+	wrapper, ok := data[name].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid webhook payload: missing %s entity", name)
+	}
+	if entity, ok := wrapper["entity"].(map[string]interface{}); ok {
+		return entity, nil
+	}
+	return wrapper, nil
+}
+
+// handleRefundProcessed marks the refund processed, records the refunded amount on the payment and marks
+// a fully refunded payment and the order it paid as refunded; partial refunds only update the refunded amount
+func (s *Service) handleRefundProcessed(db *gorm.DB, payload map[string]interface{}) error {
+	payment, err := eventEntity(payload, "payment")
+	if err != nil {
+		return err
+	}
+
+	orderID, ok := payment["order_id"].(string)
+	if !ok {
+		return errors.New("invalid webhook payload: missing order_id")
+	}
+	amountRefunded, ok := payment["amount_refunded"].(float64)
+	if !ok {
+		return errors.New("invalid webhook payload: missing amount_refunded")
+	}
+
+	var paymentRecord models.Payment
+	if err := db.First(&paymentRecord, "razorpay_order_id = ?", orderID).Error; err != nil {
+		return fmt.Errorf("payment record not found: %w", err)
+	}
+
+	// Refunds issued from the admin panel are recorded when issued and may still be pending
+	if refund, err := eventEntity(payload, "refund"); err == nil {
+		if refundID, ok := refund["id"].(string); ok {
+			if err := db.Model(&models.Refund{}).Where("provider_refund_id = ?", refundID).
+				Update("status", models.RefundStatusProcessed).Error; err != nil {
+				return fmt.Errorf("failed to update refund: %w", err)
+			}
+		}
+	}
+
+	refunded := int64(amountRefunded)
+	// Refund events can arrive out of order; the refunded amount only ever grows
+	if refunded <= paymentRecord.AmountRefunded {
+		return nil
+	}
+
+	updates := map[string]interface{}{"amount_refunded": refunded}
+	fullRefund := refunded >= paymentRecord.Amount
+	paidOrder := paymentRecord.Status == models.PaymentStatusPaid
+	if fullRefund {
+		updates["status"] = models.PaymentStatusRefunded
+	}
+	if err := db.Model(&paymentRecord).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update payment record: %w", err)
+	}
+
+	// Refunds of captures flagged refund_required never paid the order, so the order is left alone
+	if fullRefund && paidOrder {
+		return s.setOrderStatus(db, paymentRecord.OrderID, "refunded", "payment refunded", orderhistory.System)
+	}
+	return nil
+}
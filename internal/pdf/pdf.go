@@ -0,0 +1,193 @@
+// Package pdf writes plain text documents, such as packing slips and reports, as minimal PDF files using
+// the fonts built into every PDF reader
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Layout is the page size, margins and font of a document. Sizes are in points.
+type Layout struct {
+	PageWidth  int
+	PageHeight int
+	Margin     int
+	Font       string // a standard Type 1 font, e.g. Helvetica or Courier
+	FontSize   int
+	LineHeight int
+	// MaxLineChars is how many characters fit between the margins; longer lines are wrapped
+	MaxLineChars int
+}
+
+var (
+	// Letter is US Letter portrait in Helvetica. Helvetica is proportional, so MaxLineChars is sized
+	// for wide text rather than average text.
+	Letter = Layout{PageWidth: 612, PageHeight: 792, Margin: 50, Font: "Helvetica", FontSize: 11, LineHeight: 16, MaxLineChars: 85}
+	// LetterLandscapeMono is US Letter landscape in Courier, whose fixed-width characters keep the
+	// columns of tables aligned
+	LetterLandscapeMono = Layout{PageWidth: 792, PageHeight: 612, Margin: 36, Font: "Courier", FontSize: 8, LineHeight: 11, MaxLineChars: 150}
+)
+
+// LinesPerPage returns how many lines fit between the top and bottom margins
+func (l Layout) LinesPerPage() int {
+	return (l.PageHeight - 2*l.Margin) / l.LineHeight
+}
+
+// Render writes lines of plain text into a minimal multi-page PDF laid out by layout
+func Render(lines []string, layout Layout) []byte {
+	lines = wrapLines(lines, layout.MaxLineChars)
+
+	perPage := layout.LinesPerPage()
+	var pages [][]string
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects: 1 catalog, 2 page tree, 3 font, then a page and content stream per page
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", layout.Font),
+	)
+
+	for i, pageLines := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", layout.FontSize, layout.LineHeight, layout.Margin, layout.PageHeight-layout.Margin)
+		for _, line := range pageLines {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", escapeText(line))
+		}
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				layout.PageWidth, layout.PageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// wrapLines splits lines on embedded newlines and word-wraps anything wider than width.
+// Continuation lines keep the original indent plus two spaces so wrapped entries stay grouped.
+func wrapLines(lines []string, width int) []string {
+	var wrapped []string
+	for _, line := range lines {
+		for _, part := range strings.Split(strings.ReplaceAll(line, "\r\n", "\n"), "\n") {
+			wrapped = append(wrapped, wrapLine(strings.ReplaceAll(part, "\t", "    "), width)...)
+		}
+	}
+	return wrapped
+}
+
+// wrapLine word-wraps a single line, splitting words that are wider than a whole line
+func wrapLine(line string, width int) []string {
+	if utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+
+	indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+	if len(indent) >= width/2 {
+		indent = ""
+	}
+	continuation := indent + "  "
+
+	var result []string
+	current, prefix := indent, indent
+	for _, word := range strings.Fields(line) {
+		for word != "" {
+			used := utf8.RuneCountInString(current)
+			separator := 0
+			if current != prefix {
+				separator = 1
+			}
+			if used+separator+utf8.RuneCountInString(word) <= width {
+				if separator == 1 {
+					current += " "
+				}
+				current += word
+				break
+			}
+			if current != prefix {
+				result = append(result, current)
+				current, prefix = continuation, continuation
+				continue
+			}
+			// The word alone is wider than a line, so split it
+			runes := []rune(word)
+			room := width - used
+			result = append(result, current+string(runes[:room]))
+			word = string(runes[room:])
+			current, prefix = continuation, continuation
+		}
+	}
+	if current != prefix {
+		result = append(result, current)
+	}
+	return result
+}
+
+// winAnsiSpecials maps the characters WinAnsiEncoding places in 0x80-0x9F; Latin-1 characters
+// (0xA0-0xFF) share their Unicode code point and byte value
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// textSubstitutes spells out common characters that WinAnsiEncoding cannot show
+var textSubstitutes = map[rune]string{
+	'₹': "Rs.",
+}
+
+// escapeText escapes a string for a PDF literal in WinAnsiEncoding. Accented Latin letters and
+// typographic punctuation are written as octal byte escapes; characters the encoding lacks are
+// spelled out where possible and otherwise replaced with '?'.
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r >= 32 && r <= 126:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case winAnsiSpecials[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", winAnsiSpecials[r])
+		case textSubstitutes[r] != "":
+			b.WriteString(textSubstitutes[r])
+		case r < 32:
+			b.WriteRune(' ')
+		default:
+			b.WriteRune('?')
+		}
+	}
+	return b.String()
+}
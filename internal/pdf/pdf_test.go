@@ -0,0 +1,51 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	lines := make([]string, Letter.LinesPerPage()+5)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	lines[0] = "Café (escaped) \\"
+
+	doc := string(Render(lines, Letter))
+	assert.True(t, strings.HasPrefix(doc, "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(doc, "%%EOF\n"))
+	assert.Contains(t, doc, "/Count 2")
+	assert.Contains(t, doc, `(Caf\351 \(escaped\) \\) Tj`)
+}
+
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `Jos\351 M\374ller`, escapeText("José Müller"))
+	assert.Equal(t, `\223Gift\224 \226 Rs.499`, escapeText("“Gift” – ₹499"))
+	assert.Equal(t, "a b", escapeText("a\x01b"))
+	assert.Equal(t, "?", escapeText("中"))
+}
+
+func TestWrapLines(t *testing.T) {
+	long := "  " + strings.Repeat("word ", 30)
+	lines := wrapLines([]string{"Gift Message:", "  Dear Ana,\nHappy birthday!", long, strings.Repeat("x", 100)}, 40)
+
+	assert.Equal(t, "Gift Message:", lines[0])
+	assert.Equal(t, "  Dear Ana,", lines[1])
+	assert.Equal(t, "Happy birthday!", lines[2])
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), 40, line)
+	}
+	assert.True(t, strings.HasPrefix(lines[3], "  word"))
+	assert.True(t, strings.HasPrefix(lines[4], "    word"), "continuation lines are indented")
+	assert.Equal(t, strings.Repeat("x", 40), lines[len(lines)-3])
+}
+
+func TestRender_Layout(t *testing.T) {
+	doc := string(Render([]string{strings.Repeat("x", 120)}, LetterLandscapeMono))
+	assert.Contains(t, doc, "/BaseFont /Courier")
+	assert.Contains(t, doc, "/MediaBox [0 0 792 612]")
+	assert.Contains(t, doc, "("+strings.Repeat("x", 120)+") Tj", "the line fits without wrapping")
+}
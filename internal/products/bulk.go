@@ -0,0 +1,335 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// bulkBatchSize is the number of products changed in each transaction of a bulk operation
+	bulkBatchSize = 100
+	// maxBulkProducts is the most products one bulk operation can change
+	maxBulkProducts = 5000
+)
+
+// Bulk product actions
+const (
+	BulkActivate    = "activate"
+	BulkDeactivate  = "deactivate"
+	BulkAdjustPrice = "adjust_price"
+	BulkSetCategory = "set_category"
+	BulkDelete      = "delete"
+)
+
+// Price adjustment types
+const (
+	PriceAdjustmentPercentage = "percentage"
+	PriceAdjustmentFixed      = "fixed"
+)
+
+var (
+	ErrInvalidBulkRequest = errors.New("invalid bulk request")
+	ErrInvalidAdjustment  = errors.New("price adjustment would take the price to zero or below")
+)
+
+// PriceAdjustment changes prices by a percentage (10 raises them by 10%, -10 lowers them) or by a fixed amount
+type PriceAdjustment struct {
+	Type  string  `json:"type" binding:"required,oneof=percentage fixed"`
+	Value float64 `json:"value" binding:"required"`
+}
+
+// apply returns the adjusted price, rounded to cents
+func (a PriceAdjustment) apply(price float64) float64 {
+	if a.Type == PriceAdjustmentPercentage {
+		price += price * a.Value / 100
+	} else {
+		price += a.Value
+	}
+	return math.Round(price*100) / 100
+}
+
+// BulkProductFilter selects the live products a bulk operation changes
+type BulkProductFilter struct {
+	CategoryID *string  `json:"categoryId,omitempty"`
+	MinPrice   *float64 `json:"minPrice,omitempty"`
+	MaxPrice   *float64 `json:"maxPrice,omitempty"`
+	InStock    *bool    `json:"inStock,omitempty"`
+	IsActive   *bool    `json:"isActive,omitempty"`
+	Search     *string  `json:"search,omitempty"`
+}
+
+func (f BulkProductFilter) empty() bool {
+	return f.CategoryID == nil && f.MinPrice == nil && f.MaxPrice == nil && f.InStock == nil && f.IsActive == nil &&
+		(f.Search == nil || *f.Search == "")
+}
+
+// BulkProductRequest applies one action to the products listed in IDs or matching Filter
+type BulkProductRequest struct {
+	Action          string             `json:"action" binding:"required,oneof=activate deactivate adjust_price set_category delete"`
+	IDs             []string           `json:"ids,omitempty" binding:"omitempty,max=5000"`
+	Filter          *BulkProductFilter `json:"filter,omitempty"`
+	PriceAdjustment *PriceAdjustment   `json:"priceAdjustment,omitempty"` // for adjust_price
+	CategoryID      *string            `json:"categoryId,omitempty"`      // for set_category
+	Force           bool               `json:"force,omitempty"`           // delete products still referenced by open orders or active carts
+}
+
+func (r *BulkProductRequest) validate() error {
+	if (len(r.IDs) > 0) == (r.Filter != nil) {
+		return fmt.Errorf("%w: give either ids or a filter", ErrInvalidBulkRequest)
+	}
+	if r.Filter != nil && r.Filter.empty() {
+		return fmt.Errorf("%w: the filter needs at least one condition", ErrInvalidBulkRequest)
+	}
+	if r.Action == BulkAdjustPrice && r.PriceAdjustment == nil {
+		return fmt.Errorf("%w: adjust_price needs a priceAdjustment", ErrInvalidBulkRequest)
+	}
+	if r.Action == BulkSetCategory && (r.CategoryID == nil || *r.CategoryID == "") {
+		return fmt.Errorf("%w: set_category needs a categoryId", ErrInvalidBulkRequest)
+	}
+	return nil
+}
+
+// BulkItemResult reports how a bulk operation went for one product
+type BulkItemResult struct {
+	ProductID string `json:"productId"`
+	Success   bool   `json:"success"`
+	Code      string `json:"code,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BulkProductResult reports a bulk operation product by product
+type BulkProductResult struct {
+	Action    string           `json:"action"`
+	Matched   int              `json:"matched"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// BulkUpdateProducts applies an action to many products. The products are changed in batches, each in its
+// own transaction, and a product that cannot be changed is reported and skipped without failing the rest.
+func (s *Service) BulkUpdateProducts(ctx context.Context, req BulkProductRequest) (*BulkProductResult, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if req.Action == BulkSetCategory {
+		var category models.Category
+		if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", *req.CategoryID, true).First(&category).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Errorf("category not found")
+			}
+			return nil, fmt.Errorf("failed to verify category: %w", err)
+		}
+	}
+
+	ids, err := s.bulkProductIDs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkProductResult{Action: req.Action, Matched: len(ids), Results: make([]BulkItemResult, 0, len(ids))}
+	for start := 0; start < len(ids); start += bulkBatchSize {
+		end := min(start+bulkBatchSize, len(ids))
+		batch, err := s.bulkBatch(ctx, req, ids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		result.Results = append(result.Results, batch...)
+	}
+	for _, item := range result.Results {
+		if item.Success {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	if result.Succeeded > 0 {
+		s.invalidateCategoryIndex()
+	}
+	return result, nil
+}
+
+// bulkProductIDs returns the IDs a bulk operation changes: the listed ones without duplicates, or those of
+// the live products matching its filter
+func (s *Service) bulkProductIDs(ctx context.Context, req BulkProductRequest) ([]string, error) {
+	if req.Filter == nil {
+		seen := make(map[string]bool, len(req.IDs))
+		ids := make([]string, 0, len(req.IDs))
+		for _, id := range req.IDs {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	filters := AdminProductFilters{
+		CategoryID: req.Filter.CategoryID,
+		MinPrice:   req.Filter.MinPrice,
+		MaxPrice:   req.Filter.MaxPrice,
+		InStock:    req.Filter.InStock,
+		IsActive:   req.Filter.IsActive,
+		Search:     req.Filter.Search,
+	}
+	var ids []string
+	query := applyAdminProductFilters(s.db.WithContext(ctx).Model(&models.Product{}), filters)
+	if err := query.Order("id").Limit(maxBulkProducts+1).Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch products: %w", err)
+	}
+	if len(ids) > maxBulkProducts {
+		return nil, fmt.Errorf("%w: the filter matches more than %d products", ErrInvalidBulkRequest, maxBulkProducts)
+	}
+	return ids, nil
+}
+
+// bulkBatch applies a bulk action to a batch of products in one transaction. Each product is changed under
+// a savepoint, so one that fails is rolled back on its own and reported.
+func (s *Service) bulkBatch(ctx context.Context, req BulkProductRequest, ids []string) ([]BulkItemResult, error) {
+	var products []models.Product
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch products: %w", err)
+	}
+	byID := make(map[string]*models.Product, len(products))
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+	}
+
+	results := make([]BulkItemResult, len(ids))
+	for i, id := range ids {
+		results[i].ProductID = id
+		product, ok := byID[id]
+		if !ok {
+			results[i].Code, results[i].Error = "PRODUCT_NOT_FOUND", "product not found"
+			continue
+		}
+		if err := s.checkBulkItem(ctx, req, product); err != nil {
+			results[i].Code, results[i].Error = bulkErrorCode(err), err.Error()
+		}
+	}
+
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range results {
+			if results[i].Code != "" {
+				continue
+			}
+			product := byID[results[i].ProductID]
+			if err := tx.Transaction(func(item *gorm.DB) error {
+				return s.applyBulkItem(item, req, product, now)
+			}); err != nil {
+				results[i].Code, results[i].Error = bulkErrorCode(err), err.Error()
+			}
+		}
+		return nil
+	})
+	for i := range results {
+		switch {
+		case results[i].Code != "":
+		case err != nil:
+			results[i].Code, results[i].Error = "BULK_BATCH_ERROR", err.Error()
+		default:
+			results[i].Success = true
+		}
+	}
+	if err == nil {
+		s.syncBulkBatch(ctx, req, results, byID)
+	}
+	return results, nil
+}
+
+// checkBulkItem rejects a product the action cannot be applied to, before its batch's transaction starts
+func (s *Service) checkBulkItem(ctx context.Context, req BulkProductRequest, product *models.Product) error {
+	switch req.Action {
+	case BulkSetCategory:
+		return s.validateProductSpecifications(ctx, *product, UpdateProductRequest{CategoryID: req.CategoryID})
+	case BulkDelete:
+		references, err := s.ProductReferences(ctx, product.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if len(references) > 0 && !req.Force {
+			return &ProductInUseError{ProductID: product.ID, References: references}
+		}
+	}
+	return nil
+}
+
+// applyBulkItem applies a bulk action to one product in tx
+func (s *Service) applyBulkItem(tx *gorm.DB, req BulkProductRequest, product *models.Product, now time.Time) error {
+	switch req.Action {
+	case BulkActivate, BulkDeactivate:
+		if err := updateVersioned(tx, product, nil, map[string]interface{}{"is_active": req.Action == BulkActivate}); err != nil {
+			return err
+		}
+	case BulkSetCategory:
+		if err := updateVersioned(tx, product, nil, map[string]interface{}{"category_id": *req.CategoryID}); err != nil {
+			return err
+		}
+	case BulkAdjustPrice:
+		price := req.PriceAdjustment.apply(product.Price)
+		if price <= 0 {
+			return fmt.Errorf("%w: %.2f", ErrInvalidAdjustment, price)
+		}
+		if price == product.Price {
+			return nil
+		}
+		if err := ensurePriceBaseline(tx, product); err != nil {
+			return err
+		}
+		if err := updateVersioned(tx, product, nil, map[string]interface{}{"price": price}); err != nil {
+			return err
+		}
+		product.Price = price
+		if err := recordPrice(tx, product, now); err != nil {
+			return err
+		}
+	case BulkDelete:
+		if err := tx.Delete(product).Error; err != nil {
+			return fmt.Errorf("failed to delete product: %w", err)
+		}
+		if err := s.queueChange(tx, product.ID, false); err != nil {
+			return err
+		}
+		return eventbus.PublishProductDeleted(tx, product)
+	}
+	return s.queueChange(tx, product.ID, true)
+}
+
+// syncBulkBatch updates the search index and announces the products a committed batch changed
+func (s *Service) syncBulkBatch(ctx context.Context, req BulkProductRequest, results []BulkItemResult, byID map[string]*models.Product) {
+	var changed []string
+	for _, item := range results {
+		if !item.Success {
+			continue
+		}
+		if req.Action == BulkDelete {
+			s.syncSearch(byID[item.ProductID], true)
+		} else {
+			changed = append(changed, item.ProductID)
+		}
+	}
+	s.syncProducts(ctx, changed)
+}
+
+// bulkErrorCode returns the error code reported for a product a bulk operation could not change
+func bulkErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrProductInUse):
+		return "PRODUCT_IN_USE"
+	case errors.Is(err, ErrInvalidSpecifications):
+		return "INVALID_SPECIFICATIONS"
+	case errors.Is(err, ErrInvalidAdjustment):
+		return "INVALID_PRICE"
+	default:
+		return "BULK_ITEM_ERROR"
+	}
+}
@@ -0,0 +1,149 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_BulkUpdateProducts(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	service := NewService(db)
+	helpers := NewTestHelpers(db)
+	ctx := context.Background()
+
+	audio := helpers.CreateTestCategory("cat-audio", "Audio", "audio")
+	video := helpers.CreateTestCategory("cat-video", "Video", "video")
+	helpers.CreateTestProduct("p1", "Headphones", "SKU-1", audio.ID, 100, 5)
+	helpers.CreateTestProduct("p2", "Speaker", "SKU-2", audio.ID, 40, 5)
+	helpers.CreateTestProduct("p3", "Projector", "SKU-3", video.ID, 300, 5)
+
+	t.Run("rejects requests without a single selection", func(t *testing.T) {
+		for _, req := range []BulkProductRequest{
+			{Action: BulkDeactivate},
+			{Action: BulkDeactivate, IDs: []string{"p1"}, Filter: &BulkProductFilter{CategoryID: &audio.ID}},
+			{Action: BulkDeactivate, Filter: &BulkProductFilter{}},
+			{Action: BulkAdjustPrice, IDs: []string{"p1"}},
+			{Action: BulkSetCategory, IDs: []string{"p1"}},
+		} {
+			_, err := service.BulkUpdateProducts(ctx, req)
+			assert.ErrorIs(t, err, ErrInvalidBulkRequest)
+		}
+	})
+
+	t.Run("deactivates by ID and reports missing products", func(t *testing.T) {
+		result, err := service.BulkUpdateProducts(ctx, BulkProductRequest{Action: BulkDeactivate, IDs: []string{"p1", "missing", "p1", "p2"}})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Matched, "duplicates are changed once")
+		assert.Equal(t, 2, result.Succeeded)
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, BulkItemResult{ProductID: "missing", Code: "PRODUCT_NOT_FOUND", Error: "product not found"}, result.Results[1])
+
+		var active int64
+		require.NoError(t, db.Model(&models.Product{}).Where("is_active = ?", true).Count(&active).Error)
+		assert.Equal(t, int64(1), active)
+	})
+
+	t.Run("adjusts prices by filter", func(t *testing.T) {
+		inactive := false
+		result, err := service.BulkUpdateProducts(ctx, BulkProductRequest{
+			Action:          BulkAdjustPrice,
+			Filter:          &BulkProductFilter{CategoryID: &audio.ID, IsActive: &inactive},
+			PriceAdjustment: &PriceAdjustment{Type: PriceAdjustmentPercentage, Value: -12.5},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Succeeded)
+
+		var p1 models.Product
+		require.NoError(t, db.First(&p1, "id = ?", "p1").Error)
+		assert.Equal(t, 87.5, p1.Price)
+
+		var history []models.ProductPrice
+		require.NoError(t, db.Where("product_id = ?", "p1").Order("effective_from").Find(&history).Error)
+		require.Len(t, history, 2, "the price before and after the adjustment are kept")
+		assert.Equal(t, 100.0, history[0].Price)
+		assert.Equal(t, 87.5, history[1].Price)
+
+		// A fixed adjustment that would take a price to zero fails for that product alone
+		result, err = service.BulkUpdateProducts(ctx, BulkProductRequest{
+			Action:          BulkAdjustPrice,
+			IDs:             []string{"p1", "p2"},
+			PriceAdjustment: &PriceAdjustment{Type: PriceAdjustmentFixed, Value: -50},
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Results[0].Success)
+		assert.Equal(t, "INVALID_PRICE", result.Results[1].Code)
+		var p2 models.Product
+		require.NoError(t, db.First(&p2, "id = ?", "p2").Error)
+		assert.Equal(t, 35.0, p2.Price, "the failed product is left as it was")
+	})
+
+	t.Run("moves products to another category", func(t *testing.T) {
+		_, err := service.BulkUpdateProducts(ctx, BulkProductRequest{Action: BulkSetCategory, IDs: []string{"p1"}, CategoryID: stringPtr("missing")})
+		assert.EqualError(t, err, "category not found")
+
+		result, err := service.BulkUpdateProducts(ctx, BulkProductRequest{Action: BulkSetCategory, IDs: []string{"p1", "p2"}, CategoryID: &video.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Succeeded)
+		var count int64
+		require.NoError(t, db.Model(&models.Product{}).Where("category_id = ?", video.ID).Count(&count).Error)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("deletes products not in use", func(t *testing.T) {
+		order := &models.Order{UserID: "user-1", Status: "processing", Subtotal: 300, Total: 300}
+		require.NoError(t, db.Create(order).Error)
+		require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: "p3", Quantity: 1, Price: 300, Total: 300}).Error)
+
+		result, err := service.BulkUpdateProducts(ctx, BulkProductRequest{Action: BulkDelete, Filter: &BulkProductFilter{CategoryID: &video.ID}})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Matched)
+		assert.Equal(t, 2, result.Succeeded)
+		assert.Equal(t, "p3", result.Results[2].ProductID)
+		assert.Equal(t, "PRODUCT_IN_USE", result.Results[2].Code)
+
+		var live []string
+		require.NoError(t, db.Model(&models.Product{}).Pluck("id", &live).Error)
+		assert.Equal(t, []string{"p3"}, live)
+
+		result, err = service.BulkUpdateProducts(ctx, BulkProductRequest{Action: BulkDelete, IDs: []string{"p3"}, Force: true})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Succeeded, "force deletes products still in use")
+	})
+}
+
+func TestHandler_BulkUpdateProducts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	helpers := NewTestHelpers(db)
+	category := helpers.CreateTestCategory("cat-bulk", "Audio", "audio")
+	helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 100, 5)
+
+	router := gin.New()
+	router.POST("/api/admin/products/bulk", NewHandler(NewService(db)).BulkUpdateProducts)
+	post := func(body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/products/bulk", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"action":"archive","ids":["p1"]}`))
+	assert.Equal(t, http.StatusBadRequest, post(`{"action":"adjust_price","ids":["p1"],"priceAdjustment":{"type":"ratio","value":2}}`))
+	assert.Equal(t, http.StatusBadRequest, post(`{"action":"deactivate"}`))
+	assert.Equal(t, http.StatusNotFound, post(`{"action":"set_category","ids":["p1"],"categoryId":"missing"}`))
+	assert.Equal(t, http.StatusOK, post(`{"action":"adjust_price","ids":["p1"],"priceAdjustment":{"type":"fixed","value":5}}`))
+}
@@ -0,0 +1,256 @@
+package products
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"ecommerce-website/internal/models"
+)
+
+// CategorySuggestion is a category proposed for a new product
+type CategorySuggestion struct {
+	CategoryID   string  `json:"categoryId"`
+	CategoryName string  `json:"categoryName"`
+	Score        float64 `json:"score"`
+	MatchedCount int     `json:"matchedCount"`
+}
+
+const (
+	// suggestionNeighbours is how many of the most similar products vote for a category
+	suggestionNeighbours   = 10
+	defaultSuggestionLimit = 3
+	// categoryIndexTTL bounds how long catalog vectors are reused; product writes also drop them
+	categoryIndexTTL = 5 * time.Minute
+)
+
+// stopWords are ignored when building term vectors
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "for": true, "with": true, "of": true,
+	"in": true, "on": true, "to": true, "by": true, "is": true, "at": true, "or": true,
+}
+
+// tokenize lowercases text and splits it into alphanumeric terms
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if len(f) < 2 || stopWords[f] {
+			continue
+		}
+		terms = append(terms, f)
+	}
+	return terms
+}
+
+// termFrequencies counts terms; the name is weighted twice as much as the description
+func termFrequencies(name, description string) map[string]float64 {
+	tf := make(map[string]float64)
+	for _, t := range tokenize(name) {
+		tf[t] += 2
+	}
+	for _, t := range tokenize(description) {
+		tf[t]++
+	}
+	return tf
+}
+
+// tfidfVector weights term frequencies by inverse document frequency and normalises the result
+func tfidfVector(tf map[string]float64, idf map[string]float64) map[string]float64 {
+	vec := make(map[string]float64, len(tf))
+	var norm float64
+	for term, freq := range tf {
+		weight, ok := idf[term]
+		if !ok {
+			continue
+		}
+		w := freq * weight
+		vec[term] = w
+		norm += w * w
+	}
+	if norm == 0 {
+		return vec
+	}
+	norm = math.Sqrt(norm)
+	for term := range vec {
+		vec[term] /= norm
+	}
+	return vec
+}
+
+// cosine returns the dot product of two normalised vectors
+func cosine(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var dot float64
+	for term, w := range a {
+		dot += w * b[term]
+	}
+	return dot
+}
+
+// categoryIndex is the TF-IDF model of the active catalog that suggestions are scored against
+type categoryIndex struct {
+	idf       map[string]float64
+	products  []indexedProduct
+	expiresAt time.Time
+}
+
+// indexedProduct is the normalised TF-IDF vector of one catalog product
+type indexedProduct struct {
+	categoryID string
+	vector     map[string]float64
+}
+
+// categoryIndexCache holds the most recently built index; the zero value is empty
+type categoryIndexCache struct {
+	mu    sync.Mutex
+	index *categoryIndex
+}
+
+// loadCategoryIndex returns the cached catalog index, rebuilding it when it expired or was invalidated
+func (s *Service) loadCategoryIndex(ctx context.Context) (*categoryIndex, error) {
+	s.categoryIndex.mu.Lock()
+	defer s.categoryIndex.mu.Unlock()
+
+	if idx := s.categoryIndex.index; idx != nil && time.Now().Before(idx.expiresAt) {
+		return idx, nil
+	}
+
+	var catalog []models.Product
+	if err := s.db.WithContext(ctx).Select("id, name, description, category_id").
+		Where("is_active = ?", true).
+		Find(&catalog).Error; err != nil {
+		return nil, fmt.Errorf("failed to load catalog: %w", err)
+	}
+
+	// Document frequencies over the catalog
+	docs := make([]map[string]float64, len(catalog))
+	df := make(map[string]int)
+	for i, p := range catalog {
+		docs[i] = termFrequencies(p.Name, p.Description)
+		for term := range docs[i] {
+			df[term]++
+		}
+	}
+
+	idf := make(map[string]float64, len(df))
+	n := float64(len(catalog))
+	for term, count := range df {
+		idf[term] = math.Log((n+1)/(float64(count)+1)) + 1
+	}
+
+	idx := &categoryIndex{
+		idf:       idf,
+		products:  make([]indexedProduct, len(catalog)),
+		expiresAt: time.Now().Add(categoryIndexTTL),
+	}
+	for i, p := range catalog {
+		idx.products[i] = indexedProduct{categoryID: p.CategoryID, vector: tfidfVector(docs[i], idf)}
+	}
+
+	s.categoryIndex.index = idx
+	return idx, nil
+}
+
+// invalidateCategoryIndex drops the cached catalog index after products changed
+func (s *Service) invalidateCategoryIndex() {
+	s.categoryIndex.mu.Lock()
+	defer s.categoryIndex.mu.Unlock()
+	s.categoryIndex.index = nil
+}
+
+// SuggestCategories proposes categories for a new product based on TF-IDF similarity
+// of its name and description to the existing catalog
+func (s *Service) SuggestCategories(ctx context.Context, name, description string, limit int) ([]CategorySuggestion, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if limit <= 0 {
+		limit = defaultSuggestionLimit
+	}
+
+	idx, err := s.loadCategoryIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(idx.products) == 0 {
+		return []CategorySuggestion{}, nil
+	}
+
+	query := tfidfVector(termFrequencies(name, description), idx.idf)
+	if len(query) == 0 {
+		return []CategorySuggestion{}, nil
+	}
+
+	type neighbour struct {
+		categoryID string
+		score      float64
+	}
+	neighbours := make([]neighbour, 0, len(idx.products))
+	for _, p := range idx.products {
+		if score := cosine(query, p.vector); score > 0 {
+			neighbours = append(neighbours, neighbour{categoryID: p.categoryID, score: score})
+		}
+	}
+
+	sort.Slice(neighbours, func(i, j int) bool { return neighbours[i].score > neighbours[j].score })
+	if len(neighbours) > suggestionNeighbours {
+		neighbours = neighbours[:suggestionNeighbours]
+	}
+
+	// Each neighbour votes for its category with its similarity score
+	votes := make(map[string]*CategorySuggestion)
+	var total float64
+	for _, nb := range neighbours {
+		sug, ok := votes[nb.categoryID]
+		if !ok {
+			sug = &CategorySuggestion{CategoryID: nb.categoryID}
+			votes[nb.categoryID] = sug
+		}
+		sug.Score += nb.score
+		sug.MatchedCount++
+		total += nb.score
+	}
+
+	suggestions := make([]CategorySuggestion, 0, len(votes))
+	categoryIDs := make([]string, 0, len(votes))
+	for _, sug := range votes {
+		sug.Score = math.Round(sug.Score/total*1000) / 1000
+		suggestions = append(suggestions, *sug)
+		categoryIDs = append(categoryIDs, sug.CategoryID)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score == suggestions[j].Score {
+			return suggestions[i].CategoryID < suggestions[j].CategoryID
+		}
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	var categories []models.Category
+	if err := s.db.WithContext(ctx).Where("id IN ?", categoryIDs).Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	names := make(map[string]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+	for i := range suggestions {
+		suggestions[i].CategoryName = names[suggestions[i].CategoryID]
+	}
+
+	return suggestions, nil
+}
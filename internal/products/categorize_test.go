@@ -0,0 +1,99 @@
+package products
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupCategorizeTest(t *testing.T) (*Service, *TestHelpers) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductPrice{}))
+
+	return NewService(db), NewTestHelpers(db)
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"wireless", "bluetooth", "headphones", "2024"}, tokenize("The Wireless, Bluetooth-Headphones (2024)!"))
+	assert.Empty(t, tokenize("a of the"))
+}
+
+func TestService_SuggestCategories(t *testing.T) {
+	service, helpers := setupCategorizeTest(t)
+
+	audio := helpers.CreateTestCategory("cat-audio", "Audio", "audio")
+	kitchen := helpers.CreateTestCategory("cat-kitchen", "Kitchen", "kitchen")
+
+	helpers.CreateTestProduct("p1", "Wireless Bluetooth Headphones", "SKU-1", audio.ID, 99, 5)
+	helpers.CreateTestProduct("p2", "Noise Cancelling Headphones", "SKU-2", audio.ID, 199, 5)
+	helpers.CreateTestProduct("p3", "Bluetooth Speaker", "SKU-3", audio.ID, 59, 5)
+	helpers.CreateTestProduct("p4", "Stainless Steel Chef Knife", "SKU-4", kitchen.ID, 39, 5)
+	helpers.CreateTestProduct("p5", "Nonstick Frying Pan", "SKU-5", kitchen.ID, 29, 5)
+
+	t.Run("suggests most similar category first", func(t *testing.T) {
+		suggestions, err := service.SuggestCategories(context.Background(), "Over-ear Bluetooth Headphones", "", 3)
+		require.NoError(t, err)
+		require.NotEmpty(t, suggestions)
+		assert.Equal(t, audio.ID, suggestions[0].CategoryID)
+		assert.Equal(t, "Audio", suggestions[0].CategoryName)
+		assert.Equal(t, 3, suggestions[0].MatchedCount)
+	})
+
+	t.Run("uses description terms", func(t *testing.T) {
+		suggestions, err := service.SuggestCategories(context.Background(), "Santoku", "forged steel knife for the kitchen", 1)
+		require.NoError(t, err)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, kitchen.ID, suggestions[0].CategoryID)
+	})
+
+	t.Run("no overlap returns empty list", func(t *testing.T) {
+		suggestions, err := service.SuggestCategories(context.Background(), "Garden Hose", "", 3)
+		require.NoError(t, err)
+		assert.Empty(t, suggestions)
+	})
+
+	t.Run("name is required", func(t *testing.T) {
+		_, err := service.SuggestCategories(context.Background(), " ", "", 3)
+		assert.Error(t, err)
+	})
+}
+
+func TestService_SuggestCategoriesReusesIndex(t *testing.T) {
+	service, helpers := setupCategorizeTest(t)
+
+	audio := helpers.CreateTestCategory("cat-audio", "Audio", "audio")
+	garden := helpers.CreateTestCategory("cat-garden", "Garden", "garden")
+	helpers.CreateTestProduct("p1", "Bluetooth Headphones", "SKU-1", audio.ID, 99, 5)
+
+	suggestions, err := service.SuggestCategories(context.Background(), "Garden Hose", "", 3)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+	first := service.categoryIndex.index
+	require.NotNil(t, first)
+
+	// Writes that bypass the service are not seen until the index expires
+	helpers.CreateTestProduct("p2", "Garden Hose Reel", "SKU-2", garden.ID, 49, 5)
+	suggestions, err = service.SuggestCategories(context.Background(), "Garden Hose", "", 3)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+	assert.Same(t, first, service.categoryIndex.index)
+
+	// Product writes through the service rebuild it
+	_, err = service.CreateProduct(context.Background(), CreateProductRequest{Name: "Expandable Garden Hose", SKU: "SKU-3", Price: 25, CategoryID: garden.ID})
+	require.NoError(t, err)
+	suggestions, err = service.SuggestCategories(context.Background(), "Garden Hose", "", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, suggestions)
+	assert.Equal(t, garden.ID, suggestions[0].CategoryID)
+	assert.Equal(t, 2, suggestions[0].MatchedCount)
+}
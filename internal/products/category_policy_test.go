@@ -0,0 +1,116 @@
+package products
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_CategoryReturnPolicy(t *testing.T) {
+	service, _ := setupCategorizeTest(t)
+
+	standard, err := service.CreateCategory(context.Background(), CreateCategoryRequest{Name: "Books", Slug: "books"})
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultReturnPolicy(), standard.ReturnPolicy)
+
+	// Zero values must survive the column defaults
+	finalSale, err := service.CreateCategory(context.Background(), CreateCategoryRequest{
+		Name: "Clearance",
+		Slug: "clearance",
+		ReturnPolicy: &CategoryPolicyRequest{
+			Returnable:              boolPtr(false),
+			CancellationCutoffHours: intPtr(0),
+		},
+	})
+	require.NoError(t, err)
+
+	stored, err := service.GetCategoryByID(context.Background(), finalSale.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.ReturnPolicy.Returnable)
+	assert.Equal(t, 7, stored.ReturnPolicy.ReturnWindowDays)
+	assert.Equal(t, 0, stored.ReturnPolicy.CancellationCutoffHours)
+
+	updated, err := service.UpdateCategoryPolicy(context.Background(), standard.ID, CategoryPolicyRequest{ReturnWindowDays: intPtr(30)})
+	require.NoError(t, err)
+	assert.True(t, updated.ReturnPolicy.Returnable)
+	assert.Equal(t, 30, updated.ReturnPolicy.ReturnWindowDays)
+
+	_, err = service.UpdateCategoryPolicy(context.Background(), "missing", CategoryPolicyRequest{})
+	assert.EqualError(t, err, "category not found")
+}
+
+func TestService_CategoryAttributes(t *testing.T) {
+	service, _ := setupCategorizeTest(t)
+
+	category, err := service.CreateCategory(context.Background(), CreateCategoryRequest{
+		Name:       "Laptops",
+		Slug:       "laptops",
+		Attributes: []CategoryAttributeRequest{{Key: "ram", Label: "Memory", Unit: "GB"}, {Key: "cpu"}},
+	})
+	require.NoError(t, err)
+
+	stored, err := service.GetCategoryByID(context.Background(), category.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AttributeSchema{
+		{Key: "ram", Label: "Memory", Unit: "GB"},
+		{Key: "cpu", Label: "cpu"},
+	}, stored.Attributes)
+
+	updated, err := service.UpdateCategoryAttributes(context.Background(), category.ID, UpdateCategoryAttributesRequest{
+		Attributes: []CategoryAttributeRequest{{Key: "weight", Unit: "kg"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.AttributeSchema{{Key: "weight", Label: "weight", Unit: "kg"}}, updated.Attributes)
+
+	_, err = service.UpdateCategoryAttributes(context.Background(), category.ID, UpdateCategoryAttributesRequest{
+		Attributes: []CategoryAttributeRequest{{Key: "ram"}, {Key: " ram "}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidAttributeSchema)
+
+	_, err = service.UpdateCategoryAttributes(context.Background(), "missing", UpdateCategoryAttributesRequest{})
+	assert.EqualError(t, err, "category not found")
+}
+
+func TestService_CategorySerialTracking(t *testing.T) {
+	service, _ := setupCategorizeTest(t)
+
+	category, err := service.CreateCategory(context.Background(), CreateCategoryRequest{Name: "Phones", Slug: "phones", SerialTracked: true})
+	require.NoError(t, err)
+	stored, err := service.GetCategoryByID(context.Background(), category.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.SerialTracked)
+
+	updated, err := service.UpdateCategorySerialTracking(context.Background(), category.ID, false)
+	require.NoError(t, err)
+	assert.False(t, updated.SerialTracked)
+	stored, err = service.GetCategoryByID(context.Background(), category.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.SerialTracked)
+
+	_, err = service.UpdateCategorySerialTracking(context.Background(), "missing", true)
+	assert.EqualError(t, err, "category not found")
+}
+
+func TestService_CategoryWarranty(t *testing.T) {
+	service, _ := setupCategorizeTest(t)
+
+	category, err := service.CreateCategory(context.Background(), CreateCategoryRequest{Name: "Laptops", Slug: "laptops", WarrantyMonths: 24})
+	require.NoError(t, err)
+	stored, err := service.GetCategoryByID(context.Background(), category.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 24, stored.WarrantyMonths)
+
+	updated, err := service.UpdateCategoryWarranty(context.Background(), category.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, updated.WarrantyMonths)
+	stored, err = service.GetCategoryByID(context.Background(), category.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.WarrantyMonths)
+
+	_, err = service.UpdateCategoryWarranty(context.Background(), "missing", 12)
+	assert.EqualError(t, err, "category not found")
+}
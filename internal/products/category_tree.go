@@ -0,0 +1,266 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryCycle       = errors.New("category cannot be moved under itself or one of its descendants")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+	ErrCategoryHasProducts = errors.New("category has products")
+)
+
+// GetCategoryTree returns the active categories nested under their parents, ordered by sort order and name.
+// Categories below an inactive parent are left out with it.
+func (s *Service) GetCategoryTree(ctx context.Context) ([]*CategoryTreeNode, error) {
+	var categories []models.Category
+	if err := s.reader.WithContext(ctx).Where("is_active = ?", true).
+		Order("sort_order ASC, name ASC").
+		Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+	}
+
+	var counts []struct {
+		CategoryID string
+		Count      int64
+	}
+	if err := s.reader.WithContext(ctx).Model(&models.Product{}).
+		Select("category_id, COUNT(*) as count").
+		Where("is_active = ?", true).
+		Group("category_id").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count category products: %w", err)
+	}
+	productCounts := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		productCounts[count.CategoryID] = count.Count
+	}
+
+	return buildCategoryTree(categories, productCounts), nil
+}
+
+// buildCategoryTree nests categories, already in display order, under their parents and sums the product
+// counts of each subtree
+func buildCategoryTree(categories []models.Category, productCounts map[string]int64) []*CategoryTreeNode {
+	nodes := make(map[string]*CategoryTreeNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryTreeNode{
+			ID:           category.ID,
+			Name:         category.Name,
+			Slug:         category.Slug,
+			Description:  category.Description,
+			ParentID:     category.ParentID,
+			SortOrder:    category.SortOrder,
+			ProductCount: productCounts[category.ID],
+			Children:     []*CategoryTreeNode{},
+		}
+	}
+
+	roots := []*CategoryTreeNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	var total func(node *CategoryTreeNode) int64
+	total = func(node *CategoryTreeNode) int64 {
+		for _, child := range node.Children {
+			node.ProductCount += total(child)
+		}
+		return node.ProductCount
+	}
+	for _, root := range roots {
+		total(root)
+	}
+	return roots
+}
+
+// findCategory loads a category whether or not it is active
+func findCategory(tx *gorm.DB, id string) (*models.Category, error) {
+	var category models.Category
+	if err := tx.Where("id = ?", id).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("category not found")
+		}
+		return nil, fmt.Errorf("failed to fetch category: %w", err)
+	}
+	return &category, nil
+}
+
+// UpdateCategory changes the name, slug, description, visibility or sort order of a category. Listings of
+// its products pick up a new name or slug right away.
+func (s *Service) UpdateCategory(ctx context.Context, id string, req UpdateCategoryRequest) (*models.Category, error) {
+	var category *models.Category
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if category, err = findCategory(tx, id); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if req.Name != nil {
+			updates["name"] = *req.Name
+		}
+		if req.Slug != nil && *req.Slug != category.Slug {
+			var count int64
+			if err := tx.Model(&models.Category{}).Unscoped().Where("slug = ? AND id <> ?", *req.Slug, id).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to check slug uniqueness: %w", err)
+			}
+			if count > 0 {
+				return fmt.Errorf("category with slug already exists")
+			}
+			updates["slug"] = *req.Slug
+		}
+		if req.Description != nil {
+			updates["description"] = *req.Description
+		}
+		if req.IsActive != nil {
+			updates["is_active"] = *req.IsActive
+		}
+		if req.SortOrder != nil {
+			updates["sort_order"] = *req.SortOrder
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(category).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update category: %w", err)
+		}
+		if category, err = findCategory(tx, id); err != nil {
+			return err
+		}
+		if req.Name != nil || req.Slug != nil {
+			if err := tx.Model(&models.ProductListing{}).Where("category_id = ?", id).Updates(map[string]interface{}{
+				"category_name": category.Name,
+				"category_slug": category.Slug,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to update product listings: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateCategoryIndex()
+	return category, nil
+}
+
+// ReorderCategories sets the sort order of several categories in one transaction; it changes none of them
+// when any is missing
+func (s *Service) ReorderCategories(ctx context.Context, orders []CategoryOrder) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, order := range orders {
+			result := tx.Model(&models.Category{}).Where("id = ?", order.ID).Update("sort_order", *order.SortOrder)
+			if result.Error != nil {
+				return fmt.Errorf("failed to reorder categories: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("category not found")
+			}
+		}
+		return nil
+	})
+}
+
+// MoveCategory moves a category and its subtree under a new parent, or to the top level when parentID is
+// nil. Moving a category under itself or one of its descendants would detach the subtree from the tree and
+// is refused.
+func (s *Service) MoveCategory(ctx context.Context, id string, parentID *string) (*models.Category, error) {
+	var category *models.Category
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if category, err = findCategory(tx, id); err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			// Walk up from the new parent; reaching the category means the parent is inside its subtree
+			visited := map[string]bool{}
+			for ancestorID := parentID; ancestorID != nil; {
+				if *ancestorID == id {
+					return ErrCategoryCycle
+				}
+				if visited[*ancestorID] {
+					break
+				}
+				visited[*ancestorID] = true
+
+				ancestor, err := findCategory(tx, *ancestorID)
+				if err != nil {
+					if err.Error() != "category not found" {
+						return err
+					}
+					if ancestorID == parentID {
+						return fmt.Errorf("parent category not found")
+					}
+					// A deleted ancestor ends the chain without reaching the category
+					break
+				}
+				ancestorID = ancestor.ParentID
+			}
+		}
+
+		if err := tx.Model(category).Update("parent_id", parentID).Error; err != nil {
+			return fmt.Errorf("failed to move category: %w", err)
+		}
+		category.ParentID = parentID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory soft deletes a category. Categories that still have subcategories or products must be
+// emptied first, so nothing is left pointing at a deleted category.
+func (s *Service) DeleteCategory(ctx context.Context, id string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		category, err := findCategory(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var children int64
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return fmt.Errorf("failed to count subcategories: %w", err)
+		}
+		if children > 0 {
+			return ErrCategoryHasChildren
+		}
+
+		var products int64
+		if err := tx.Model(&models.Product{}).Where("category_id = ?", id).Count(&products).Error; err != nil {
+			return fmt.Errorf("failed to count category products: %w", err)
+		}
+		if products > 0 {
+			return ErrCategoryHasProducts
+		}
+
+		if err := tx.Delete(category).Error; err != nil {
+			return fmt.Errorf("failed to delete category: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateCategoryIndex()
+	return nil
+}
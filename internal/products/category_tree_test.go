@@ -0,0 +1,116 @@
+package products
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_CategoryTree(t *testing.T) {
+	service, helpers := setupCategorizeTest(t)
+	ctx := context.Background()
+	require.NoError(t, helpers.db.AutoMigrate(&models.ProductListing{}))
+
+	electronics, err := service.CreateCategory(ctx, CreateCategoryRequest{Name: "Electronics", Slug: "electronics", SortOrder: intPtr(2)})
+	require.NoError(t, err)
+	books, err := service.CreateCategory(ctx, CreateCategoryRequest{Name: "Books", Slug: "books", SortOrder: intPtr(1)})
+	require.NoError(t, err)
+	audio, err := service.CreateCategory(ctx, CreateCategoryRequest{Name: "Audio", Slug: "audio", ParentID: &electronics.ID})
+	require.NoError(t, err)
+	headphones, err := service.CreateCategory(ctx, CreateCategoryRequest{Name: "Headphones", Slug: "headphones", ParentID: &audio.ID})
+	require.NoError(t, err)
+
+	helpers.CreateTestProduct("p1", "Speaker", "SKU-1", audio.ID, 59, 5)
+	helpers.CreateTestProduct("p2", "Earbuds", "SKU-2", headphones.ID, 99, 5)
+	helpers.CreateTestProduct("p3", "Studio Headphones", "SKU-3", headphones.ID, 199, 5)
+	helpers.CreateTestProduct("p4", "Charger", "SKU-4", electronics.ID, 19, 5)
+
+	tree, err := service.GetCategoryTree(ctx)
+	require.NoError(t, err)
+	require.Len(t, tree, 2)
+	assert.Equal(t, "Books", tree[0].Name)
+	assert.Equal(t, int64(0), tree[0].ProductCount)
+	assert.Empty(t, tree[0].Children)
+	assert.Equal(t, int64(4), tree[1].ProductCount)
+	require.Len(t, tree[1].Children, 1)
+	assert.Equal(t, int64(3), tree[1].Children[0].ProductCount)
+	require.Len(t, tree[1].Children[0].Children, 1)
+	assert.Equal(t, int64(2), tree[1].Children[0].Children[0].ProductCount)
+
+	t.Run("reorder", func(t *testing.T) {
+		require.NoError(t, service.ReorderCategories(ctx, []CategoryOrder{
+			{ID: electronics.ID, SortOrder: intPtr(0)},
+			{ID: books.ID, SortOrder: intPtr(5)},
+		}))
+		tree, err := service.GetCategoryTree(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "Electronics", tree[0].Name)
+
+		err = service.ReorderCategories(ctx, []CategoryOrder{
+			{ID: books.ID, SortOrder: intPtr(9)},
+			{ID: "missing", SortOrder: intPtr(1)},
+		})
+		assert.EqualError(t, err, "category not found")
+		stored, err := service.GetCategoryByID(ctx, books.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 5, stored.SortOrder)
+	})
+
+	t.Run("move", func(t *testing.T) {
+		_, err := service.MoveCategory(ctx, electronics.ID, &headphones.ID)
+		assert.ErrorIs(t, err, ErrCategoryCycle)
+		_, err = service.MoveCategory(ctx, audio.ID, &audio.ID)
+		assert.ErrorIs(t, err, ErrCategoryCycle)
+		missing := "missing"
+		_, err = service.MoveCategory(ctx, audio.ID, &missing)
+		assert.EqualError(t, err, "parent category not found")
+
+		moved, err := service.MoveCategory(ctx, headphones.ID, nil)
+		require.NoError(t, err)
+		assert.Nil(t, moved.ParentID)
+		tree, err := service.GetCategoryTree(ctx)
+		require.NoError(t, err)
+		assert.Len(t, tree, 3)
+
+		moved, err = service.MoveCategory(ctx, headphones.ID, &audio.ID)
+		require.NoError(t, err)
+		assert.Equal(t, audio.ID, *moved.ParentID)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		require.NoError(t, helpers.db.Create(&models.ProductListing{ProductID: "p1", Name: "Speaker", CategoryID: audio.ID, CategoryName: "Audio", CategorySlug: "audio"}).Error)
+
+		updated, err := service.UpdateCategory(ctx, audio.ID, UpdateCategoryRequest{Name: stringPtr("Sound"), Slug: stringPtr("sound")})
+		require.NoError(t, err)
+		assert.Equal(t, "Sound", updated.Name)
+		assert.Equal(t, "sound", updated.Slug)
+
+		var listing models.ProductListing
+		require.NoError(t, helpers.db.First(&listing, "product_id = ?", "p1").Error)
+		assert.Equal(t, "Sound", listing.CategoryName)
+		assert.Equal(t, "sound", listing.CategorySlug)
+
+		_, err = service.UpdateCategory(ctx, audio.ID, UpdateCategoryRequest{Slug: stringPtr("books")})
+		assert.EqualError(t, err, "category with slug already exists")
+		_, err = service.UpdateCategory(ctx, "missing", UpdateCategoryRequest{Name: stringPtr("Missing")})
+		assert.EqualError(t, err, "category not found")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.ErrorIs(t, service.DeleteCategory(ctx, audio.ID), ErrCategoryHasChildren)
+		assert.ErrorIs(t, service.DeleteCategory(ctx, headphones.ID), ErrCategoryHasProducts)
+		assert.EqualError(t, service.DeleteCategory(ctx, "missing"), "category not found")
+
+		require.NoError(t, service.DeleteCategory(ctx, books.ID))
+		_, err := service.GetCategoryByID(ctx, books.ID)
+		assert.EqualError(t, err, "category not found")
+
+		var deleted models.Category
+		require.NoError(t, helpers.db.Unscoped().First(&deleted, "id = ?", books.ID).Error)
+		assert.True(t, deleted.DeletedAt.Valid)
+	})
+}
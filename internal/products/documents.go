@@ -0,0 +1,166 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxDocumentSize is the largest product document accepted, kept under the default request size limit
+const MaxDocumentSize = 8 << 20
+
+var (
+	ErrDocumentStorageUnavailable = errors.New("document storage is not configured")
+	ErrDocumentTooLarge           = errors.New("document is too large")
+	ErrUnsupportedDocumentType    = errors.New("unsupported document type")
+	ErrInvalidDocument            = errors.New("invalid document")
+	ErrDocumentNotFound           = errors.New("document not found")
+)
+
+// documentTypes maps the content types accepted for product documents to the extension they are stored with
+var documentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+}
+
+// documentKinds are the accepted product document kinds
+var documentKinds = map[string]bool{
+	models.DocumentKindSpecSheet: true,
+	models.DocumentKindManual:    true,
+	models.DocumentKindFAQ:       true,
+	models.DocumentKindOther:     true,
+}
+
+// UploadDocumentRequest describes a document attached to a product; the title defaults to the file name
+type UploadDocumentRequest struct {
+	Kind      string
+	Title     string
+	FileName  string
+	SortOrder int
+}
+
+// AddProductDocument stores content in the media store and attaches it to the product. The content type
+// is detected from the content itself, never taken from the client.
+func (s *Service) AddProductDocument(ctx context.Context, productID string, req UploadDocumentRequest, content io.Reader) (*models.ProductDocument, error) {
+	if s.media == nil {
+		return nil, ErrDocumentStorageUnavailable
+	}
+
+	kind := strings.TrimSpace(req.Kind)
+	if kind == "" {
+		kind = models.DocumentKindOther
+	}
+	if !documentKinds[kind] {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidDocument, kind)
+	}
+	fileName := filepath.Base(strings.TrimSpace(req.FileName))
+	if fileName == "." || fileName == string(filepath.Separator) {
+		fileName = ""
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	}
+	if title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidDocument)
+	}
+
+	var product models.Product
+	if err := s.db.WithContext(ctx).Select("id").First(&product, "id = ?", productID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("failed to fetch product: %w", err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, MaxDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidDocument)
+	}
+	if len(data) > MaxDocumentSize {
+		return nil, ErrDocumentTooLarge
+	}
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return nil, ErrUnsupportedDocumentType
+	}
+	ext, ok := documentTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDocumentType, contentType)
+	}
+	if fileName == "" {
+		fileName = title + ext
+	}
+
+	document := models.ProductDocument{
+		ProductID:   productID,
+		Kind:        kind,
+		Title:       title,
+		FileName:    fileName,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		StorageKey:  "products/" + productID + "/documents/" + uuid.New().String() + ext,
+		SortOrder:   req.SortOrder,
+	}
+	if err := s.media.Put(document.StorageKey, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to store document: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Create(&document).Error; err != nil {
+		s.removeDocumentFile(document.StorageKey)
+		return nil, fmt.Errorf("failed to save document: %w", err)
+	}
+
+	document.URL = s.media.URL(document.StorageKey)
+	return &document, nil
+}
+
+// DeleteProductDocument detaches a document from the product and removes its file
+func (s *Service) DeleteProductDocument(ctx context.Context, productID, documentID string) error {
+	var document models.ProductDocument
+	if err := s.db.WithContext(ctx).First(&document, "id = ? AND product_id = ?", documentID, productID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrDocumentNotFound
+		}
+		return fmt.Errorf("failed to fetch document: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Delete(&document).Error; err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	s.removeDocumentFile(document.StorageKey)
+	return nil
+}
+
+// removeDocumentFile deletes a stored document file; a file left behind only costs disk space
+func (s *Service) removeDocumentFile(key string) {
+	if s.media == nil {
+		return
+	}
+	if err := s.media.Delete(key); err != nil {
+		logger.Named("products").Warn("Failed to delete document file", map[string]interface{}{"key": key, "error": err.Error()})
+	}
+}
+
+// fillDocumentURLs sets the delivery URL of each of the product's documents
+func (s *Service) fillDocumentURLs(product *models.Product) {
+	if s.media == nil {
+		return
+	}
+	for i := range product.Documents {
+		product.Documents[i].URL = s.media.URL(product.Documents[i].StorageKey)
+	}
+}
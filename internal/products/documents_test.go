@@ -0,0 +1,159 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ecommerce-website/internal/media"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testPDF = "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\ntrailer << /Root 1 0 R >>\n%%EOF\n"
+
+func setupDocumentsTest(t *testing.T) (*Service, *TestHelpers, string) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductDocument{}))
+
+	dir := t.TempDir()
+	return NewServiceWithMedia(db, media.NewLocalStore(dir, "https://cdn.example.com")), NewTestHelpers(db), dir
+}
+
+func TestService_ProductDocuments(t *testing.T) {
+	service, helpers, dir := setupDocumentsTest(t)
+	category := helpers.CreateTestCategory("cat-docs", "Appliances", "appliances")
+	helpers.CreateTestProduct("prod-docs", "Dishwasher", "SKU-DOCS", category.ID, 499, 3)
+
+	manual, err := service.AddProductDocument(context.Background(), "prod-docs", UploadDocumentRequest{Kind: models.DocumentKindManual, FileName: "Dishwasher Manual.pdf", SortOrder: 2},
+		strings.NewReader(testPDF))
+	require.NoError(t, err)
+	assert.Equal(t, "Dishwasher Manual", manual.Title)
+	assert.Equal(t, "application/pdf", manual.ContentType)
+	assert.Equal(t, int64(len(testPDF)), manual.SizeBytes)
+	assert.True(t, strings.HasPrefix(manual.URL, "https://cdn.example.com/media/products/prod-docs/documents/"))
+	assert.True(t, strings.HasSuffix(manual.URL, ".pdf"))
+
+	stored, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(manual.StorageKey)))
+	require.NoError(t, err)
+	assert.Equal(t, testPDF, string(stored))
+
+	// The client's file name does not decide the type; plain text is accepted as text
+	faq, err := service.AddProductDocument(context.Background(), "prod-docs", UploadDocumentRequest{Kind: models.DocumentKindFAQ, Title: "FAQ", FileName: "faq.pdf"},
+		strings.NewReader("Q: Does it need a hot water connection?\nA: No.\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", faq.ContentType)
+	assert.True(t, strings.HasSuffix(faq.StorageKey, ".txt"))
+
+	product, err := service.GetProductByID(context.Background(), "prod-docs")
+	require.NoError(t, err)
+	require.Len(t, product.Documents, 2)
+	assert.Equal(t, faq.ID, product.Documents[0].ID)
+	assert.Equal(t, manual.URL, product.Documents[1].URL)
+
+	t.Run("rejects invalid uploads", func(t *testing.T) {
+		_, err := service.AddProductDocument(context.Background(), "prod-docs", UploadDocumentRequest{FileName: "photo.pdf"}, bytes.NewReader([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")))
+		assert.ErrorIs(t, err, ErrUnsupportedDocumentType)
+
+		_, err = service.AddProductDocument(context.Background(), "prod-docs", UploadDocumentRequest{FileName: "huge.pdf"},
+			io.MultiReader(strings.NewReader("%PDF-1.4\n"), bytes.NewReader(make([]byte, MaxDocumentSize))))
+		assert.ErrorIs(t, err, ErrDocumentTooLarge)
+
+		_, err = service.AddProductDocument(context.Background(), "prod-docs", UploadDocumentRequest{FileName: "empty.pdf"}, strings.NewReader(""))
+		assert.ErrorIs(t, err, ErrInvalidDocument)
+
+		_, err = service.AddProductDocument(context.Background(), "prod-docs", UploadDocumentRequest{Kind: "brochure", FileName: "a.pdf"}, strings.NewReader(testPDF))
+		assert.ErrorIs(t, err, ErrInvalidDocument)
+
+		_, err = service.AddProductDocument(context.Background(), "missing", UploadDocumentRequest{FileName: "a.pdf"}, strings.NewReader(testPDF))
+		assert.EqualError(t, err, "product not found")
+
+		_, err = NewService(service.db).AddProductDocument(context.Background(), "prod-docs", UploadDocumentRequest{FileName: "a.pdf"}, strings.NewReader(testPDF))
+		assert.ErrorIs(t, err, ErrDocumentStorageUnavailable)
+	})
+
+	t.Run("delete removes the document and its file", func(t *testing.T) {
+		assert.ErrorIs(t, service.DeleteProductDocument(context.Background(), "other-product", manual.ID), ErrDocumentNotFound)
+
+		require.NoError(t, service.DeleteProductDocument(context.Background(), "prod-docs", manual.ID))
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(manual.StorageKey)))
+		assert.True(t, os.IsNotExist(err))
+
+		product, err := service.GetProductByID(context.Background(), "prod-docs")
+		require.NoError(t, err)
+		require.Len(t, product.Documents, 1)
+		assert.Equal(t, faq.ID, product.Documents[0].ID)
+	})
+}
+
+func TestHandler_UploadProductDocument(t *testing.T) {
+	service, helpers, _ := setupDocumentsTest(t)
+	category := helpers.CreateTestCategory("cat-upload", "Appliances", "appliances")
+	helpers.CreateTestProduct("prod-upload", "Oven", "SKU-UPLOAD", category.ID, 799, 2)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handler := NewHandler(service)
+	r.POST("/products/:id/documents", handler.UploadProductDocument)
+	r.DELETE("/products/:id/documents/:documentId", handler.DeleteProductDocument)
+
+	upload := func(productID, fileName, content string, fields map[string]string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		for name, value := range fields {
+			require.NoError(t, form.WriteField(name, value))
+		}
+		part, err := form.CreateFormFile("file", fileName)
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/products/"+productID+"/documents", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload("prod-upload", "spec.pdf", testPDF, map[string]string{"kind": "spec_sheet", "title": "Spec sheet", "sortOrder": "1"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response struct {
+		Data models.ProductDocument `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Spec sheet", response.Data.Title)
+	assert.Equal(t, models.DocumentKindSpecSheet, response.Data.Kind)
+	assert.Equal(t, 1, response.Data.SortOrder)
+	assert.NotEmpty(t, response.Data.URL)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, upload("prod-upload", "image.pdf", "GIF89a....", nil).Code)
+	assert.Equal(t, http.StatusNotFound, upload("missing", "spec.pdf", testPDF, nil).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload("prod-upload", "big.pdf", testPDF+strings.Repeat(" ", MaxDocumentSize), nil).Code)
+
+	req := httptest.NewRequest(http.MethodDelete, "/products/prod-upload/documents/"+response.Data.ID, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/products/prod-upload/documents/"+response.Data.ID, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -1,9 +1,18 @@
 package products
 
 import (
+	"encoding/csv"
+	"errors"
 	"net/http"
 	"strconv"
-
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/formatting"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/media"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/search"
 	"ecommerce-website/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +26,37 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
+// formatter returns the display formatter of the store the request belongs to
+func (h *Handler) formatter(c *gin.Context) formatting.Formatter {
+	return h.service.DisplayFormatter(c.Request.Context(), c.GetString("store_id"))
+}
+
+// presentProduct fills the display strings and image URLs of a product for the request; ?size= picks the
+// size images are delivered at
+func (h *Handler) presentProduct(c *gin.Context, product *models.Product) {
+	localizeProduct(h.formatter(c), product)
+	fillImageURLs(product, c.Query("size"))
+}
+
+// presentProducts fills the display strings and image URLs of every product for the request
+func (h *Handler) presentProducts(c *gin.Context, products []models.Product) {
+	formatter, size := h.formatter(c), c.Query("size")
+	for i := range products {
+		localizeProduct(formatter, &products[i])
+		fillImageURLs(&products[i], size)
+	}
+}
+
+// translateProducts resolves products to the translation the request's Accept-Language prefers. Failing to
+// translate answers the request in the catalog's own language.
+func (h *Handler) translateProducts(c *gin.Context, products []models.Product) {
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	accepted := AcceptedLocales(c.GetHeader("Accept-Language"))
+	if err := h.service.TranslateProducts(c.Request.Context(), products, accepted, h.formatter(c).Locale); err != nil {
+		logTranslationError(err)
+	}
+}
+
 // GetProducts handles GET /api/products
 func (h *Handler) GetProducts(c *gin.Context) {
 	// Parse pagination parameters
@@ -64,13 +104,15 @@ func (h *Handler) GetProducts(c *gin.Context) {
 	}
 
 	// Get products
-	response, err := h.service.GetProducts(filters, sort, pagination)
+	response, err := h.service.GetProducts(c.Request.Context(), filters, sort, pagination)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "FETCH_PRODUCTS_ERROR", "Failed to fetch products", err.Error())
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Products retrieved successfully", response)
+	h.translateProducts(c, response.Products)
+	h.presentProducts(c, response.Products)
+	utils.PaginatedResponse(c, http.StatusOK, "Products retrieved successfully", "products", response.Products, response.Pagination)
 }
 
 // GetProductByID handles GET /api/products/:id
@@ -81,7 +123,7 @@ func (h *Handler) GetProductByID(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.GetProductByID(id)
+	product, err := h.service.GetProductByID(c.Request.Context(), id)
 	if err != nil {
 		if err.Error() == "product not found" {
 			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
@@ -91,6 +133,14 @@ func (h *Handler) GetProductByID(c *gin.Context) {
 		return
 	}
 
+	translated := []models.Product{*product}
+	h.translateProducts(c, translated)
+	product = &translated[0]
+	if product.Locale != "" {
+		c.Header("Content-Language", product.Locale)
+	}
+
+	h.presentProduct(c, product)
 	utils.SuccessResponse(c, http.StatusOK, "Product retrieved successfully", product)
 }
 
@@ -111,13 +161,14 @@ func (h *Handler) SearchProducts(c *gin.Context) {
 		PageSize: pageSize,
 	}
 
-	response, err := h.service.SearchProducts(query, pagination)
+	response, err := h.service.SearchProducts(c.Request.Context(), query, pagination)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "SEARCH_PRODUCTS_ERROR", "Failed to search products", err.Error())
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Search completed successfully", response)
+	h.presentProducts(c, response.Products)
+	utils.PaginatedResponse(c, http.StatusOK, "Search completed successfully", "products", response.Products, response.Pagination)
 }
 
 // AdvancedSearchProducts handles GET /api/products/advanced-search
@@ -169,7 +220,18 @@ func (h *Handler) AdvancedSearchProducts(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Advanced search completed successfully", response)
+	if response.Degraded {
+		c.Header(search.SearchDegradedHeader, "true")
+	}
+	h.presentProducts(c, response.Products)
+	data := gin.H{"products": response.Products}
+	if response.Suggestions != nil {
+		data["suggestions"] = response.Suggestions
+	}
+	if response.Facets != nil {
+		data["facets"] = response.Facets
+	}
+	utils.PaginatedResponseWith(c, http.StatusOK, "Advanced search completed successfully", data, response.Pagination)
 }
 
 // GetSearchSuggestions handles GET /api/products/suggestions
@@ -190,15 +252,45 @@ func (h *Handler) GetSearchSuggestions(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "SUGGESTIONS_ERROR", "Failed to get suggestions", err.Error())
 		return
 	}
+	if h.service.SearchDegraded() {
+		c.Header(search.SearchDegradedHeader, "true")
+	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Suggestions retrieved successfully", gin.H{
 		"suggestions": suggestions,
 	})
 }
 
+// Suggest handles GET /api/products/suggest
+func (h *Handler) Suggest(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "MISSING_QUERY", "Query parameter is required", nil)
+		return
+	}
+	size, _ := strconv.Atoi(c.DefaultQuery("size", strconv.Itoa(search.DefaultTypeaheadSize)))
+
+	response, err := h.service.Typeahead(query, size)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "SUGGESTIONS_ERROR", "Failed to get suggestions", err.Error())
+		return
+	}
+	if response.Degraded {
+		c.Header(search.SearchDegradedHeader, "true")
+	}
+
+	formatter := h.formatter(c)
+	for i := range response.Suggestions {
+		if price := response.Suggestions[i].Price; price != nil {
+			response.Suggestions[i].PriceDisplay = formatter.Price(*price)
+		}
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Suggestions retrieved successfully", response)
+}
+
 // GetCategories handles GET /api/categories
 func (h *Handler) GetCategories(c *gin.Context) {
-	categories, err := h.service.GetCategories()
+	categories, err := h.service.GetCategories(c.Request.Context())
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "FETCH_CATEGORIES_ERROR", "Failed to fetch categories", err.Error())
 		return
@@ -218,7 +310,7 @@ func (h *Handler) GetCategoryByID(c *gin.Context) {
 		return
 	}
 
-	category, err := h.service.GetCategoryByID(id)
+	category, err := h.service.GetCategoryByID(c.Request.Context(), id)
 	if err != nil {
 		if err.Error() == "category not found" {
 			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
@@ -231,6 +323,21 @@ func (h *Handler) GetCategoryByID(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Category retrieved successfully", category)
 }
 
+// GetCategorySpecSchema handles GET /api/categories/:id/spec-schema
+func (h *Handler) GetCategorySpecSchema(c *gin.Context) {
+	schema, err := h.service.CategorySpecSchema(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err.Error() == "category not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "FETCH_SPEC_SCHEMA_ERROR", "Failed to fetch category specification schema", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Category specification schema retrieved successfully", schema)
+}
+
 // CreateCategory handles POST /api/categories
 func (h *Handler) CreateCategory(c *gin.Context) {
 	var req CreateCategoryRequest
@@ -249,7 +356,7 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 		return
 	}
 
-	category, err := h.service.CreateCategory(req)
+	category, err := h.service.CreateCategory(c.Request.Context(), req)
 	if err != nil {
 		if err.Error() == "category with slug already exists" {
 			utils.ErrorResponse(c, http.StatusConflict, "CATEGORY_EXISTS", "Category with this slug already exists", nil)
@@ -259,6 +366,10 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 			utils.ErrorResponse(c, http.StatusBadRequest, "PARENT_CATEGORY_NOT_FOUND", "Parent category not found", nil)
 			return
 		}
+		if errors.Is(err, ErrInvalidAttributeSchema) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_ATTRIBUTES", "Invalid category attributes", err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "CREATE_CATEGORY_ERROR", "Failed to create category", err.Error())
 		return
 	}
@@ -266,6 +377,194 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusCreated, "Category created successfully", category)
 }
 
+// UpdateCategoryPolicy handles PUT /api/categories/:id/policy
+func (h *Handler) UpdateCategoryPolicy(c *gin.Context) {
+	var req CategoryPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	category, err := h.service.UpdateCategoryPolicy(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		if err.Error() == "category not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_CATEGORY_POLICY_ERROR", "Failed to update category policy", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Category policy updated successfully", category)
+}
+
+// UpdateCategoryAttributes handles PUT /api/categories/:id/attributes
+func (h *Handler) UpdateCategoryAttributes(c *gin.Context) {
+	var req UpdateCategoryAttributesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	category, err := h.service.UpdateCategoryAttributes(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAttributeSchema) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_ATTRIBUTES", "Invalid category attributes", err.Error())
+			return
+		}
+		if err.Error() == "category not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_CATEGORY_ATTRIBUTES_ERROR", "Failed to update category attributes", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Category attributes updated successfully", category)
+}
+
+// UpdateCategorySerialTracking handles PUT /api/categories/:id/serial-tracking
+func (h *Handler) UpdateCategorySerialTracking(c *gin.Context) {
+	var req UpdateCategorySerialTrackingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	category, err := h.service.UpdateCategorySerialTracking(c.Request.Context(), c.Param("id"), *req.SerialTracked)
+	if err != nil {
+		if err.Error() == "category not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_CATEGORY_SERIAL_TRACKING_ERROR", "Failed to update category serial tracking", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Category serial tracking updated successfully", category)
+}
+
+// UpdateCategoryWarranty handles PUT /api/categories/:id/warranty
+func (h *Handler) UpdateCategoryWarranty(c *gin.Context) {
+	var req UpdateCategoryWarrantyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	category, err := h.service.UpdateCategoryWarranty(c.Request.Context(), c.Param("id"), *req.WarrantyMonths)
+	if err != nil {
+		if err.Error() == "category not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_CATEGORY_WARRANTY_ERROR", "Failed to update category warranty", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Category warranty updated successfully", category)
+}
+
+// GetCategoryTree handles GET /api/categories/tree
+func (h *Handler) GetCategoryTree(c *gin.Context) {
+	tree, err := h.service.GetCategoryTree(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "FETCH_CATEGORY_TREE_ERROR", "Failed to fetch category tree", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Category tree retrieved successfully", tree)
+}
+
+// UpdateCategory handles PUT /api/categories/:id (admin only)
+func (h *Handler) UpdateCategory(c *gin.Context) {
+	var req UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	category, err := h.service.UpdateCategory(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		switch err.Error() {
+		case "category not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
+		case "category with slug already exists":
+			utils.ErrorResponse(c, http.StatusConflict, "CATEGORY_EXISTS", "Category with this slug already exists", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_CATEGORY_ERROR", "Failed to update category", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Category updated successfully", category)
+}
+
+// ReorderCategories handles PUT /api/categories/order (admin only)
+func (h *Handler) ReorderCategories(c *gin.Context) {
+	var req ReorderCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	if err := h.service.ReorderCategories(c.Request.Context(), req.Categories); err != nil {
+		if err.Error() == "category not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "REORDER_CATEGORIES_ERROR", "Failed to reorder categories", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Categories reordered successfully", nil)
+}
+
+// MoveCategory handles PUT /api/categories/:id/parent (admin only)
+func (h *Handler) MoveCategory(c *gin.Context) {
+	var req MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	category, err := h.service.MoveCategory(c.Request.Context(), c.Param("id"), req.ParentID)
+	if err != nil {
+		switch {
+		case err.Error() == "category not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
+		case err.Error() == "parent category not found":
+			utils.ErrorResponse(c, http.StatusBadRequest, "PARENT_CATEGORY_NOT_FOUND", "Parent category not found", nil)
+		case errors.Is(err, ErrCategoryCycle):
+			utils.ErrorResponse(c, http.StatusConflict, "CATEGORY_CYCLE", "A category cannot be moved under itself or one of its subcategories", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "MOVE_CATEGORY_ERROR", "Failed to move category", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Category moved successfully", category)
+}
+
+// DeleteCategory handles DELETE /api/categories/:id (admin only)
+func (h *Handler) DeleteCategory(c *gin.Context) {
+	if err := h.service.DeleteCategory(c.Request.Context(), c.Param("id")); err != nil {
+		switch {
+		case err.Error() == "category not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
+		case errors.Is(err, ErrCategoryHasChildren):
+			utils.ErrorResponse(c, http.StatusConflict, "CATEGORY_HAS_CHILDREN", "Move or delete the subcategories first", nil)
+		case errors.Is(err, ErrCategoryHasProducts):
+			utils.ErrorResponse(c, http.StatusConflict, "CATEGORY_HAS_PRODUCTS", "Move or delete the category's products first", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "DELETE_CATEGORY_ERROR", "Failed to delete category", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Category deleted successfully", nil)
+}
+
 // Admin Product Management Handlers
 
 // CreateProduct handles POST /api/admin/products
@@ -294,7 +593,7 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.CreateProduct(req)
+	product, err := h.service.CreateProduct(c.Request.Context(), req)
 	if err != nil {
 		if err.Error() == "category not found" {
 			utils.ErrorResponse(c, http.StatusBadRequest, "CATEGORY_NOT_FOUND", "Category not found", nil)
@@ -304,6 +603,10 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 			utils.ErrorResponse(c, http.StatusConflict, "SKU_EXISTS", "Product with this SKU already exists", nil)
 			return
 		}
+		if errors.Is(err, ErrInvalidSpecifications) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SPECIFICATIONS", "Specifications do not match the category attributes", err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "CREATE_PRODUCT_ERROR", "Failed to create product", err.Error())
 		return
 	}
@@ -311,6 +614,30 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusCreated, "Product created successfully", product)
 }
 
+// bindIfMatch takes the product version an update is based on from the If-Match header, overriding the one
+// in the body. It answers 400 INVALID_VERSION and returns false for a malformed header.
+func (h *Handler) bindIfMatch(c *gin.Context, version **int64) bool {
+	ifMatch, err := ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_VERSION", "If-Match must carry a product version", err.Error())
+		return false
+	}
+	if ifMatch != nil {
+		*version = ifMatch
+	}
+	return true
+}
+
+// versionConflict answers 409 VERSION_CONFLICT with the current product when err is a version conflict
+func versionConflict(c *gin.Context, err error) bool {
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	utils.ErrorResponse(c, http.StatusConflict, "VERSION_CONFLICT", "Product was changed by someone else; reload it and try again", conflict)
+	return true
+}
+
 // UpdateProduct handles PUT /api/admin/products/:id
 func (h *Handler) UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
@@ -330,13 +657,19 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_PRICE", "Product price must be greater than 0", nil)
 		return
 	}
+	if !h.bindIfMatch(c, &req.Version) {
+		return
+	}
 
-	product, err := h.service.UpdateProduct(id, req)
+	product, err := h.service.UpdateProduct(c.Request.Context(), id, req)
 	if err != nil {
 		if err.Error() == "product not found" {
 			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
 			return
 		}
+		if versionConflict(c, err) {
+			return
+		}
 		if err.Error() == "category not found" {
 			utils.ErrorResponse(c, http.StatusBadRequest, "CATEGORY_NOT_FOUND", "Category not found", nil)
 			return
@@ -345,14 +678,25 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 			utils.ErrorResponse(c, http.StatusConflict, "SKU_EXISTS", "Product with this SKU already exists", nil)
 			return
 		}
+		if errors.Is(err, ErrInvalidSpecifications) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SPECIFICATIONS", "Specifications do not match the category attributes", err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_PRODUCT_ERROR", "Failed to update product", err.Error())
 		return
 	}
 
+	// Deactivating does not touch open orders or carts, but the admin should know they exist
+	if req.IsActive != nil && !*req.IsActive {
+		if references, err := h.service.ProductReferences(c.Request.Context(), id, time.Now()); err == nil && len(references) > 0 {
+			c.Header(ProductInUseWarningHeader, "deactivated product is referenced by "+describeReferences(references))
+		}
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "Product updated successfully", product)
 }
 
-// DeleteProduct handles DELETE /api/admin/products/:id (soft delete)
+// DeleteProduct handles DELETE /api/admin/products/:id (soft delete, ?force=true overrides the in-use check)
 func (h *Handler) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -360,12 +704,18 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	err := h.service.DeleteProduct(id)
+	force := c.Query("force") == "true"
+	err := h.service.DeleteProduct(c.Request.Context(), id, force)
 	if err != nil {
 		if err.Error() == "product not found" {
 			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
 			return
 		}
+		var inUse *ProductInUseError
+		if errors.As(err, &inUse) {
+			utils.ErrorResponse(c, http.StatusConflict, "PRODUCT_IN_USE", "Product is referenced by open orders or active carts; retry with force=true to delete it anyway", inUse)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "DELETE_PRODUCT_ERROR", "Failed to delete product", err.Error())
 		return
 	}
@@ -373,6 +723,107 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Product deleted successfully", nil)
 }
 
+// BulkUpdateProducts handles POST /api/admin/products/bulk. It activates, deactivates, reprices, moves or
+// deletes the listed or matching products and reports the outcome for each.
+func (h *Handler) BulkUpdateProducts(c *gin.Context) {
+	var req BulkProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	result, err := h.service.BulkUpdateProducts(c.Request.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidBulkRequest):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_BULK_REQUEST", "Invalid bulk operation", err.Error())
+		case err.Error() == "category not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "CATEGORY_NOT_FOUND", "Category not found", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "BULK_UPDATE_ERROR", "Failed to run bulk operation", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bulk operation completed", result)
+}
+
+// GetPriceAt handles GET /api/admin/products/:id/price-at?timestamp= or ?orderId=
+func (h *Handler) GetPriceAt(c *gin.Context) {
+	id := c.Param("id")
+
+	var (
+		point *PricePoint
+		err   error
+	)
+	if orderID := c.Query("orderId"); orderID != "" {
+		point, err = h.service.PriceAtOrder(c.Request.Context(), id, orderID)
+	} else {
+		at, parseErr := time.Parse(time.RFC3339, c.Query("timestamp"))
+		if parseErr != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_TIMESTAMP", "timestamp must be an RFC 3339 time, or pass orderId", nil)
+			return
+		}
+		point, err = h.service.PriceAt(c.Request.Context(), id, at)
+	}
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+		case err.Error() == "order not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+		case errors.Is(err, ErrOrderItemNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_ITEM_NOT_FOUND", "The order does not contain this product", nil)
+		case errors.Is(err, ErrPriceNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "PRICE_NOT_FOUND", "No price is recorded for the product at that time", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "PRICE_LOOKUP_ERROR", "Failed to look up the price", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Price retrieved successfully", point)
+}
+
+// RestoreProduct handles POST /api/admin/products/:id/restore (admin only)
+func (h *Handler) RestoreProduct(c *gin.Context) {
+	product, err := h.service.RestoreProduct(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+		case errors.Is(err, ErrProductNotDeleted):
+			utils.ErrorResponse(c, http.StatusConflict, "PRODUCT_NOT_DELETED", "Product is not deleted", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "RESTORE_PRODUCT_ERROR", "Failed to restore product", err.Error())
+		}
+		return
+	}
+
+	h.presentProduct(c, product)
+	utils.SuccessResponse(c, http.StatusOK, "Product restored successfully", product)
+}
+
+// PurgeProduct handles DELETE /api/admin/products/:id/purge (admin only). Only soft deleted products that were
+// never ordered can be purged.
+func (h *Handler) PurgeProduct(c *gin.Context) {
+	if err := h.service.PurgeProduct(c.Request.Context(), c.Param("id")); err != nil {
+		switch {
+		case err.Error() == "product not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+		case errors.Is(err, ErrProductNotDeleted):
+			utils.ErrorResponse(c, http.StatusConflict, "PRODUCT_NOT_DELETED", "Only deleted products can be purged; delete the product first", nil)
+		case errors.Is(err, ErrProductOrdered):
+			utils.ErrorResponse(c, http.StatusConflict, "PRODUCT_ORDERED", "Product has been ordered and must stay for its orders", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "PURGE_PRODUCT_ERROR", "Failed to purge product", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product purged successfully", nil)
+}
+
 // UpdateInventory handles PUT /api/admin/products/:id/inventory
 func (h *Handler) UpdateInventory(c *gin.Context) {
 	id := c.Param("id")
@@ -392,12 +843,19 @@ func (h *Handler) UpdateInventory(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.UpdateInventory(id, req.Inventory)
+	if !h.bindIfMatch(c, &req.Version) {
+		return
+	}
+
+	product, err := h.service.UpdateInventory(c.Request.Context(), id, req.Inventory, req.Version)
 	if err != nil {
 		if err.Error() == "product not found" {
 			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
 			return
 		}
+		if versionConflict(c, err) {
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_INVENTORY_ERROR", "Failed to update inventory", err.Error())
 		return
 	}
@@ -447,6 +905,12 @@ func (h *Handler) GetAllProductsAdmin(c *gin.Context) {
 		}
 	}
 
+	if deletedStr := c.Query("deleted"); deletedStr != "" {
+		if deleted, err := strconv.ParseBool(deletedStr); err == nil {
+			filters.Deleted = &deleted
+		}
+	}
+
 	if search := c.Query("search"); search != "" {
 		filters.Search = &search
 	}
@@ -458,11 +922,441 @@ func (h *Handler) GetAllProductsAdmin(c *gin.Context) {
 	}
 
 	// Get products
-	response, err := h.service.GetAllProductsAdmin(filters, sort, pagination)
+	response, err := h.service.GetAllProductsAdmin(c.Request.Context(), filters, sort, pagination)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "FETCH_PRODUCTS_ERROR", "Failed to fetch products", err.Error())
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Products retrieved successfully", response)
+	h.presentProducts(c, response.Products)
+	utils.PaginatedResponse(c, http.StatusOK, "Products retrieved successfully", "products", response.Products, response.Pagination)
+}
+
+// SuggestCategories handles GET /api/admin/products/categorize (admin only)
+func (h *Handler) SuggestCategories(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "MISSING_NAME", "Product name is required", nil)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "3"))
+
+	suggestions, err := h.service.SuggestCategories(c.Request.Context(), name, c.Query("description"), limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "CATEGORIZE_ERROR", "Failed to suggest categories", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Category suggestions retrieved successfully", suggestions)
+}
+
+// UploadProductDocument handles POST /api/admin/products/:id/documents
+func (h *Handler) UploadProductDocument(c *gin.Context) {
+	// Leave room for the multipart framing and form fields around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxDocumentSize+1<<20)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "DOCUMENT_TOO_LARGE", "Document is too large", gin.H{"maxSize": MaxDocumentSize})
+			return
+		}
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DOCUMENT", "A document file is required", err.Error())
+		return
+	}
+	if file.Size > MaxDocumentSize {
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "DOCUMENT_TOO_LARGE", "Document is too large", gin.H{"maxSize": MaxDocumentSize})
+		return
+	}
+	sortOrder, _ := strconv.Atoi(c.DefaultPostForm("sortOrder", "0"))
+
+	content, err := file.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DOCUMENT", "Failed to read document", err.Error())
+		return
+	}
+	defer content.Close()
+
+	document, err := h.service.AddProductDocument(c.Request.Context(), c.Param("id"), UploadDocumentRequest{
+		Kind:      c.PostForm("kind"),
+		Title:     c.PostForm("title"),
+		FileName:  file.Filename,
+		SortOrder: sortOrder,
+	}, content)
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+		case errors.Is(err, ErrDocumentTooLarge):
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "DOCUMENT_TOO_LARGE", "Document is too large", gin.H{"maxSize": MaxDocumentSize})
+		case errors.Is(err, ErrUnsupportedDocumentType):
+			utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_DOCUMENT_TYPE", "Only PDF and plain text documents are accepted", err.Error())
+		case errors.Is(err, ErrInvalidDocument):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DOCUMENT", "Invalid document", err.Error())
+		case errors.Is(err, ErrDocumentStorageUnavailable):
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, "DOCUMENT_STORAGE_UNAVAILABLE", "Document storage is not configured", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "UPLOAD_DOCUMENT_ERROR", "Failed to upload document", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Document uploaded successfully", document)
+}
+
+// UploadProductImage handles POST /api/admin/products/:id/images
+func (h *Handler) UploadProductImage(c *gin.Context) {
+	// Leave room for the multipart framing around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, media.MaxImageSize+1<<20)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMAGE_TOO_LARGE", "Image is too large", gin.H{"maxSize": media.MaxImageSize})
+			return
+		}
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_IMAGE", "An image file is required", err.Error())
+		return
+	}
+	if file.Size > media.MaxImageSize {
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMAGE_TOO_LARGE", "Image is too large", gin.H{"maxSize": media.MaxImageSize})
+		return
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_IMAGE", "Failed to read image", err.Error())
+		return
+	}
+	defer content.Close()
+
+	product, err := h.service.AddProductImage(c.Request.Context(), c.Param("id"), content)
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+		case errors.Is(err, media.ErrImageTooLarge):
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMAGE_TOO_LARGE", "Image is too large", gin.H{"maxSize": media.MaxImageSize})
+		case errors.Is(err, media.ErrUnsupportedImage):
+			utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_IMAGE_TYPE", "Only JPEG, PNG and GIF images are accepted", err.Error())
+		case errors.Is(err, ErrImageStorageUnavailable):
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, "IMAGE_STORAGE_UNAVAILABLE", "Image storage is not configured", nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "UPLOAD_IMAGE_ERROR", "Failed to upload image", err.Error())
+		}
+		return
+	}
+
+	h.presentProduct(c, product)
+	utils.SuccessResponse(c, http.StatusCreated, "Image uploaded successfully", product)
+}
+
+// DeleteProductDocument handles DELETE /api/admin/products/:id/documents/:documentId
+func (h *Handler) DeleteProductDocument(c *gin.Context) {
+	if err := h.service.DeleteProductDocument(c.Request.Context(), c.Param("id"), c.Param("documentId")); err != nil {
+		if errors.Is(err, ErrDocumentNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "DOCUMENT_NOT_FOUND", "Document not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "DELETE_DOCUMENT_ERROR", "Failed to delete document", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Document deleted successfully", nil)
+}
+
+// GetProductTranslations handles GET /api/admin/products/:id/translations
+func (h *Handler) GetProductTranslations(c *gin.Context) {
+	translations, err := h.service.GetTranslations(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err.Error() == "product not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "FETCH_TRANSLATIONS_ERROR", "Failed to fetch translations", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Translations retrieved successfully", translations)
+}
+
+// UpsertProductTranslation handles PUT /api/admin/products/:id/translations/:locale. It creates the
+// translation of the product in the locale or replaces the one it has.
+func (h *Handler) UpsertProductTranslation(c *gin.Context) {
+	var req UpsertTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	translation, err := h.service.UpsertTranslation(c.Request.Context(), c.Param("id"), c.Param("locale"), &req)
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+		case errors.Is(err, ErrInvalidLocale):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_LOCALE", "Locale must be a language tag such as fr or fr-FR", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "SAVE_TRANSLATION_ERROR", "Failed to save translation", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Translation saved successfully", translation)
+}
+
+// DeleteProductTranslation handles DELETE /api/admin/products/:id/translations/:locale
+func (h *Handler) DeleteProductTranslation(c *gin.Context) {
+	if err := h.service.DeleteTranslation(c.Request.Context(), c.Param("id"), c.Param("locale")); err != nil {
+		switch {
+		case err.Error() == "product not found":
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+		case errors.Is(err, ErrTranslationNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "TRANSLATION_NOT_FOUND", "Translation not found", nil)
+		case errors.Is(err, ErrInvalidLocale):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_LOCALE", "Locale must be a language tag such as fr or fr-FR", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "DELETE_TRANSLATION_ERROR", "Failed to delete translation", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Translation deleted successfully", nil)
+}
+
+// GetActivePromotions handles GET /api/promotions/active
+func (h *Handler) GetActivePromotions(c *gin.Context) {
+	promotions, err := h.service.ActivePromotions(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "FETCH_PROMOTIONS_ERROR", "Failed to fetch promotions", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Promotions retrieved successfully", promotions)
+}
+
+// GetPromotions handles GET /api/admin/promotions?status=
+func (h *Handler) GetPromotions(c *gin.Context) {
+	promotions, err := h.service.GetPromotions(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "FETCH_PROMOTIONS_ERROR", "Failed to fetch promotions", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Promotions retrieved successfully", promotions)
+}
+
+// GetPromotion handles GET /api/admin/promotions/:id
+func (h *Handler) GetPromotion(c *gin.Context) {
+	promotion, err := h.service.GetPromotion(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.promotionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Promotion retrieved successfully", promotion)
+}
+
+// CreatePromotion handles POST /api/admin/promotions
+func (h *Handler) CreatePromotion(c *gin.Context) {
+	var req CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	promotion, err := h.service.CreatePromotion(c.Request.Context(), req)
+	if err != nil {
+		h.promotionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Promotion created successfully", promotion)
+}
+
+// CancelPromotion handles POST /api/admin/promotions/:id/cancel. A running promotion ends right away and
+// its products go back to their prices.
+func (h *Handler) CancelPromotion(c *gin.Context) {
+	promotion, err := h.service.CancelPromotion(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.promotionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Promotion cancelled successfully", promotion)
+}
+
+// GeneratePromotionCodes handles POST /api/admin/promotions/:id/codes/generate (admin only). It creates a
+// batch of single-use codes for a promotion that requires one and returns them.
+func (h *Handler) GeneratePromotionCodes(c *gin.Context) {
+	var req GeneratePromotionCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	batch, err := h.service.GeneratePromotionCodes(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.promotionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Promotion codes generated successfully", batch)
+}
+
+// GetPromotionCodes handles GET /api/admin/promotions/:id/codes?batchId=&status= (admin only), with when,
+// by whom and on which order each code was used
+func (h *Handler) GetPromotionCodes(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+	filters := PromotionCodeFilters{BatchID: c.Query("batchId"), Status: c.Query("status")}
+	codes, total, err := h.service.GetPromotionCodes(c.Request.Context(), c.Param("id"), filters, page, limit)
+	if err != nil {
+		h.promotionError(c, err)
+		return
+	}
+
+	utils.PaginatedResponse(c, http.StatusOK, "Promotion codes retrieved successfully", "codes", codes, utils.NewPagination(page, limit, total))
+}
+
+// promotionCodeHeader are the CSV columns of exported promotion codes
+var promotionCodeHeader = []string{"code", "batch_id", "status", "used_at", "user_id", "order_id", "created_at"}
+
+// ExportPromotionCodes handles GET /api/admin/promotions/:id/codes/export?batchId=&status= (admin only). The
+// codes are streamed as a CSV attachment to hand to an influencer or print shop. The response starts with the
+// first code, so a request that fails up front still gets a JSON error; a failure halfway can only be logged.
+func (h *Handler) ExportPromotionCodes(c *gin.Context) {
+	w := csv.NewWriter(c.Writer)
+	started := false
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename=promotion-codes-"+c.Param("id")+".csv")
+		c.Status(http.StatusOK)
+		_ = w.Write(promotionCodeHeader)
+	}
+
+	filters := PromotionCodeFilters{BatchID: c.Query("batchId"), Status: c.Query("status")}
+	err := h.service.EachPromotionCode(c.Request.Context(), c.Param("id"), filters, func(code models.PromotionCode) error {
+		start()
+		return w.Write([]string{
+			code.Code,
+			code.BatchID,
+			code.Status(),
+			formatOptionalTime(code.UsedAt),
+			stringValue(code.UsedBy),
+			stringValue(code.OrderID),
+			code.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	})
+	if err != nil && !started {
+		h.promotionError(c, err)
+		return
+	}
+	if err != nil {
+		logger.Named("products").Error("Promotion code export failed midway", err, map[string]interface{}{"promotionId": c.Param("id")})
+	}
+	// Without matching codes the file only has its header
+	start()
+	w.Flush()
+}
+
+// formatOptionalTime writes a time in RFC 3339, or nothing when it is unset
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// stringValue returns the string s points to, or an empty string
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// DeactivatePromotionCodes handles POST /api/admin/promotions/:id/codes/deactivate (admin only). The unused
+// codes of a batch or list stop working; codes that were already used are left as they are.
+func (h *Handler) DeactivatePromotionCodes(c *gin.Context) {
+	var req DeactivatePromotionCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	deactivated, err := h.service.DeactivatePromotionCodes(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.promotionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Promotion codes deactivated successfully", gin.H{"deactivated": deactivated})
+}
+
+// promotionError answers a failed promotion request
+func (h *Handler) promotionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrPromotionNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "PROMOTION_NOT_FOUND", "Promotion not found", nil)
+	case errors.Is(err, ErrInvalidPromotion):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_PROMOTION", "Invalid promotion", err.Error())
+	case errors.Is(err, ErrInvalidCodeBatch):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_CODE_BATCH", "Invalid promotion code request", err.Error())
+	case errors.Is(err, ErrPromotionFinished):
+		utils.ErrorResponse(c, http.StatusConflict, "PROMOTION_FINISHED", "Promotion has already ended", nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "PROMOTION_ERROR", "Failed to process promotion", err.Error())
+	}
+}
+
+// ReindexSearch handles POST /api/admin/search/reindex
+func (h *Handler) ReindexSearch(c *gin.Context) {
+	var req ReindexSearchRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+			return
+		}
+	}
+
+	progress, err := h.service.StartSearchReindex(req.BatchSize)
+	if err != nil {
+		switch {
+		case errors.Is(err, search.ErrSearchUnavailable):
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, "SEARCH_UNAVAILABLE", "Search index is not available", nil)
+		case errors.Is(err, search.ErrReindexRunning):
+			utils.ErrorResponse(c, http.StatusConflict, "REINDEX_RUNNING", "A reindex is already running", h.service.SearchReindexStatus())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "REINDEX_ERROR", "Failed to start reindex", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "Reindex started", progress)
+}
+
+// GetSearchReindexStatus handles GET /api/admin/search/reindex
+func (h *Handler) GetSearchReindexStatus(c *gin.Context) {
+	progress := h.service.SearchReindexStatus()
+	if progress == nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "REINDEX_NOT_FOUND", "No reindex has run", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reindex status retrieved successfully", progress)
+}
+
+// GetSearchStatus handles GET /api/admin/search/status
+func (h *Handler) GetSearchStatus(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Search status retrieved successfully", gin.H{
+		"degraded": h.service.SearchDegraded(),
+		"breaker":  h.service.SearchBreakerStatus(),
+	})
 }
@@ -7,10 +7,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"ecommerce-website/internal/auth"
 	"ecommerce-website/internal/config"
 	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/search"
 	"ecommerce-website/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -40,7 +42,7 @@ func (suite *ProductHandlerTestSuite) SetupSuite() {
 	suite.Require().NoError(err)
 
 	// Auto-migrate the schema
-	err = db.AutoMigrate(&models.Category{}, &models.Product{}, &models.User{}, &models.Order{}, &models.OrderItem{})
+	err = db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductDocument{}, &models.User{}, &models.Order{}, &models.OrderItem{}, &models.InventoryReservation{}, &models.ProductPrice{})
 	suite.Require().NoError(err)
 
 	suite.db = db
@@ -55,7 +57,7 @@ func (suite *ProductHandlerTestSuite) SetupSuite() {
 
 	// Setup router
 	suite.router = gin.New()
-	SetupRoutes(suite.router, suite.handler, suite.authService)
+	SetupRoutes(suite.router.Group("/api"), suite.handler, suite.authService)
 
 	// Create test users and tokens
 	suite.setupTestUsers()
@@ -96,6 +98,7 @@ func (suite *ProductHandlerTestSuite) SetupTest() {
 	// Clean up tables before each test (except users)
 	suite.db.Exec("DELETE FROM order_items")
 	suite.db.Exec("DELETE FROM orders")
+	suite.db.Exec("DELETE FROM inventory_reservations")
 	suite.db.Exec("DELETE FROM products")
 	suite.db.Exec("DELETE FROM categories")
 }
@@ -147,7 +150,7 @@ func (suite *ProductHandlerTestSuite) TestGetProducts_Success() {
 	data := response.Data.(map[string]interface{})
 	products := data["products"].([]interface{})
 	assert.Len(suite.T(), products, 2)
-	assert.Equal(suite.T(), float64(2), data["total"])
+	assert.Equal(suite.T(), float64(2), data["pagination"].(map[string]interface{})["total"])
 }
 
 func (suite *ProductHandlerTestSuite) TestGetProducts_WithFilters() {
@@ -169,7 +172,7 @@ func (suite *ProductHandlerTestSuite) TestGetProducts_WithFilters() {
 	assert.NoError(suite.T(), err)
 
 	data := response.Data.(map[string]interface{})
-	assert.Equal(suite.T(), float64(0), data["total"]) // No products in range
+	assert.Equal(suite.T(), float64(0), data["pagination"].(map[string]interface{})["total"]) // No products in range
 }
 
 func (suite *ProductHandlerTestSuite) TestGetProducts_WithPagination() {
@@ -194,10 +197,10 @@ func (suite *ProductHandlerTestSuite) TestGetProducts_WithPagination() {
 	data := response.Data.(map[string]interface{})
 	products := data["products"].([]interface{})
 	assert.Len(suite.T(), products, 2)
-	assert.Equal(suite.T(), float64(5), data["total"])
-	assert.Equal(suite.T(), float64(3), data["totalPages"])
-	assert.True(suite.T(), data["hasNext"].(bool))
-	assert.False(suite.T(), data["hasPrevious"].(bool))
+	assert.Equal(suite.T(), float64(5), data["pagination"].(map[string]interface{})["total"])
+	assert.Equal(suite.T(), float64(3), data["pagination"].(map[string]interface{})["totalPages"])
+	assert.True(suite.T(), data["pagination"].(map[string]interface{})["hasNext"].(bool))
+	assert.False(suite.T(), data["pagination"].(map[string]interface{})["hasPrevious"].(bool))
 }
 
 func (suite *ProductHandlerTestSuite) TestGetProductByID_Success() {
@@ -224,6 +227,29 @@ func (suite *ProductHandlerTestSuite) TestGetProductByID_Success() {
 	assert.Equal(suite.T(), "TestProduct", productData["name"])
 }
 
+func (suite *ProductHandlerTestSuite) TestGetProductByID_DisplayStrings() {
+	category := suite.createTestCategory()
+	product := suite.createTestProduct(category.ID, "Kettle", 1499.5)
+	weight := 1250.0
+	suite.db.Model(product).Update("weight_grams", weight)
+
+	req, _ := http.NewRequest("GET", "/api/products/"+product.ID, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response utils.ApiResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+
+	productData := response.Data.(map[string]interface{})
+	assert.Equal(suite.T(), 1499.5, productData["price"])
+	assert.Equal(suite.T(), "₹1,499.50", productData["priceDisplay"])
+	assert.Equal(suite.T(), "1.25 kg", productData["weightDisplay"])
+	assert.NotContains(suite.T(), productData, "compareAtPriceDisplay")
+}
+
 func (suite *ProductHandlerTestSuite) TestGetProductByID_NotFound() {
 	// Make request for non-existent product
 	req, _ := http.NewRequest("GET", "/api/products/non-existent", nil)
@@ -297,6 +323,20 @@ func (suite *ProductHandlerTestSuite) TestSearchProducts_EmptyQuery() {
 	assert.Equal(suite.T(), "Search query is required", response.Error.Message)
 }
 
+func (suite *ProductHandlerTestSuite) TestSuggestCategories_BlankName() {
+	req, _ := http.NewRequest("GET", "/api/admin/products/categorize?name=%20%20", nil)
+	req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var response utils.ApiResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "MISSING_NAME", response.Error.Code)
+}
+
 func (suite *ProductHandlerTestSuite) TestGetCategories_Success() {
 	// Setup test data
 	category1 := suite.createTestCategory()
@@ -568,6 +608,88 @@ func (suite *ProductHandlerTestSuite) TestDeleteProduct_Success() {
 	assert.NotNil(suite.T(), deletedProduct.DeletedAt)
 }
 
+func (suite *ProductHandlerTestSuite) TestDeleteProduct_InUse() {
+	category := suite.createTestCategory()
+	product := suite.createTestProduct(category.ID, "Ordered Product", 49.99)
+
+	open := &models.Order{UserID: "user-1", Status: "processing", Subtotal: 99.98, Total: 99.98}
+	suite.Require().NoError(suite.db.Create(open).Error)
+	suite.Require().NoError(suite.db.Create(&models.OrderItem{OrderID: open.ID, ProductID: product.ID, Quantity: 2, Price: 49.99, Total: 99.98}).Error)
+	delivered := &models.Order{UserID: "user-1", Status: "delivered", Subtotal: 49.99, Total: 49.99}
+	suite.Require().NoError(suite.db.Create(delivered).Error)
+	suite.Require().NoError(suite.db.Create(&models.OrderItem{OrderID: delivered.ID, ProductID: product.ID, Quantity: 1, Price: 49.99, Total: 49.99}).Error)
+	suite.Require().NoError(suite.db.Create(&models.InventoryReservation{ProductID: product.ID, Quantity: 1, HolderType: "cart", HolderID: "session-1", ExpiresAt: time.Now().Add(time.Hour)}).Error)
+	suite.Require().NoError(suite.db.Create(&models.InventoryReservation{ProductID: product.ID, Quantity: 1, HolderType: "cart", HolderID: "session-2", ExpiresAt: time.Now().Add(-time.Minute)}).Error)
+
+	req, _ := http.NewRequest("DELETE", "/api/admin/products/"+product.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusConflict, w.Code)
+
+	var response struct {
+		Error struct {
+			Code    string            `json:"code"`
+			Details ProductInUseError `json:"details"`
+		} `json:"error"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "PRODUCT_IN_USE", response.Error.Code)
+	assert.Equal(suite.T(), []ProductReference{
+		{Type: ReferenceOrder, ID: open.ID, Status: "processing", Quantity: 2},
+		{Type: ReferenceCart, ID: "session-1", Quantity: 1},
+	}, response.Error.Details.References)
+
+	// Deactivating is allowed but warns about the references
+	body, _ := json.Marshal(map[string]interface{}{"isActive": false})
+	req, _ = http.NewRequest("PUT", "/api/admin/products/"+product.ID, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Header().Get(ProductInUseWarningHeader), "1 open order(s) and 1 active cart(s)")
+
+	req, _ = http.NewRequest("DELETE", "/api/admin/products/"+product.ID+"?force=true", nil)
+	req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ProductHandlerTestSuite) TestReindexSearch_Unavailable() {
+	req, _ := http.NewRequest("POST", "/api/admin/search/reindex", nil)
+	req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/admin/search/reindex", nil)
+	req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/admin/search/status", nil)
+	req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"degraded":true`)
+
+	req, _ = http.NewRequest("GET", "/api/products/advanced-search?q=anything", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "true", w.Header().Get(search.SearchDegradedHeader))
+
+	req, _ = http.NewRequest("POST", "/api/admin/search/reindex", nil)
+	req.Header.Set("Authorization", "Bearer "+suite.userToken)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
 func (suite *ProductHandlerTestSuite) TestDeleteProduct_NotFound() {
 	req, _ := http.NewRequest("DELETE", "/api/admin/products/non-existent", nil)
 	req.Header.Set("Authorization", "Bearer "+suite.adminToken)
@@ -661,7 +783,7 @@ func (suite *ProductHandlerTestSuite) TestGetAllProductsAdmin_Success() {
 	data := response.Data.(map[string]interface{})
 	products := data["products"].([]interface{})
 	assert.Len(suite.T(), products, 2)
-	assert.Equal(suite.T(), float64(2), data["total"])
+	assert.Equal(suite.T(), float64(2), data["pagination"].(map[string]interface{})["total"])
 }
 
 func (suite *ProductHandlerTestSuite) TestGetAllProductsAdmin_WithFilters() {
@@ -693,7 +815,7 @@ func (suite *ProductHandlerTestSuite) TestGetAllProductsAdmin_WithFilters() {
 	data := response.Data.(map[string]interface{})
 	products := data["products"].([]interface{})
 	assert.Len(suite.T(), products, 1)
-	assert.Equal(suite.T(), float64(1), data["total"])
+	assert.Equal(suite.T(), float64(1), data["pagination"].(map[string]interface{})["total"])
 
 	product := products[0].(map[string]interface{})
 	assert.Equal(suite.T(), "Active Product", product["name"])
@@ -0,0 +1,68 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	imageutils "ecommerce-website/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrImageStorageUnavailable = errors.New("image storage is not configured")
+
+// AddProductImage stores an uploaded image with its size and format variants in the media store and appends
+// its URL to the product's images
+func (s *Service) AddProductImage(ctx context.Context, productID string, content io.Reader) (*models.Product, error) {
+	if s.images == nil {
+		return nil, ErrImageStorageUnavailable
+	}
+
+	var product models.Product
+	if err := s.db.WithContext(ctx).First(&product, "id = ?", productID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("failed to fetch product: %w", err)
+	}
+
+	key, _, err := s.images.Store("products/"+productID+"/images/"+uuid.New().String(), content)
+	if err != nil {
+		return nil, err
+	}
+	images := append(models.StringArray{}, product.Images...)
+	images = append(images, s.media.URL(key))
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Update("images", images).Error; err != nil {
+			return fmt.Errorf("failed to save product image: %w", err)
+		}
+		return s.queueChange(tx, product.ID, true)
+	}); err != nil {
+		s.removeImageFiles(key)
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Preload("Category").First(&product, "id = ?", productID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load updated product: %w", err)
+	}
+	s.syncSearch(&product, false)
+	s.publishUpdate(ctx, &product)
+	return &product, nil
+}
+
+// removeImageFiles deletes a stored image and its variants; files left behind only cost disk space
+func (s *Service) removeImageFiles(key string) {
+	if err := s.images.Delete(key); err != nil {
+		logger.Named("products").Warn("Failed to delete image files", map[string]interface{}{"key": key, "error": err.Error()})
+	}
+}
+
+// fillImageURLs sets the delivery URLs of the product's images at the named size
+func fillImageURLs(product *models.Product, size string) {
+	product.ImageURLs = imageutils.ImageURLs(product.Images, size)
+}
@@ -0,0 +1,77 @@
+package products
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_UploadProductImage(t *testing.T) {
+	service, helpers, dir := setupDocumentsTest(t)
+	category := helpers.CreateTestCategory("cat-images", "Cameras", "cameras")
+	helpers.CreateTestProduct("prod-images", "Camera", "SKU-IMAGES", category.ID, 899, 4)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handler := NewHandler(service)
+	r.POST("/products/:id/images", handler.UploadProductImage)
+	r.GET("/products/:id", handler.GetProductByID)
+
+	var picture bytes.Buffer
+	require.NoError(t, png.Encode(&picture, image.NewNRGBA(image.Rect(0, 0, 640, 480))))
+	upload := func(productID string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "camera.png")
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/products/"+productID+"/images?size=small", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload("prod-images", picture.Bytes())
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response struct {
+		Data models.Product `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data.Images, 3, "appended to the existing images")
+	uploaded := response.Data.Images[2]
+	assert.True(t, strings.HasPrefix(uploaded, "https://cdn.example.com/media/products/prod-images/images/"))
+	assert.True(t, strings.HasSuffix(uploaded, "/original.png"))
+	assert.Equal(t, strings.TrimSuffix(uploaded, "original.png")+"small.jpeg", response.Data.ImageURLs[2])
+
+	key := strings.TrimPrefix(strings.TrimSuffix(uploaded, "original.png"), "https://cdn.example.com/media/")
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key), "xlarge.jpeg"))
+	assert.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/prod-images?size=thumbnail", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, strings.TrimSuffix(uploaded, "original.png")+"thumbnail.jpeg", response.Data.ImageURLs[2])
+	assert.Equal(t, "test1.jpg", response.Data.ImageURLs[0], "other images are left to the CDN, none is configured")
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, upload("prod-images", []byte("%PDF-1.4")).Code)
+	assert.Equal(t, http.StatusNotFound, upload("missing", picture.Bytes()).Code)
+}
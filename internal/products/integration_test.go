@@ -42,7 +42,7 @@ func (suite *ProductIntegrationTestSuite) SetupSuite() {
 	suite.Require().NoError(err)
 
 	// Auto-migrate the schema
-	err = db.AutoMigrate(&models.Category{}, &models.Product{}, &models.User{}, &models.Order{}, &models.OrderItem{})
+	err = db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductDocument{}, &models.User{}, &models.Order{}, &models.OrderItem{}, &models.InventoryReservation{}, &models.ProductPrice{})
 	suite.Require().NoError(err)
 
 	suite.db = db
@@ -58,7 +58,7 @@ func (suite *ProductIntegrationTestSuite) SetupSuite() {
 
 	// Setup router
 	suite.router = gin.New()
-	SetupRoutes(suite.router, suite.handler, suite.authService)
+	SetupRoutes(suite.router.Group("/api"), suite.handler, suite.authService)
 
 	// Create admin user and token
 	suite.setupAdminUser()
@@ -363,10 +363,10 @@ func (suite *ProductIntegrationTestSuite) TestPaginationWorkflow() {
 	data := response.Data.(map[string]interface{})
 	products := data["products"].([]interface{})
 	assert.Len(suite.T(), products, 10)
-	assert.Equal(suite.T(), float64(25), data["total"])
-	assert.Equal(suite.T(), float64(3), data["totalPages"])
-	assert.True(suite.T(), data["hasNext"].(bool))
-	assert.False(suite.T(), data["hasPrevious"].(bool))
+	assert.Equal(suite.T(), float64(25), data["pagination"].(map[string]interface{})["total"])
+	assert.Equal(suite.T(), float64(3), data["pagination"].(map[string]interface{})["totalPages"])
+	assert.True(suite.T(), data["pagination"].(map[string]interface{})["hasNext"].(bool))
+	assert.False(suite.T(), data["pagination"].(map[string]interface{})["hasPrevious"].(bool))
 
 	// Test middle page
 	req, _ = http.NewRequest("GET", "/api/products?page=2&page_size=10", nil)
@@ -380,8 +380,8 @@ func (suite *ProductIntegrationTestSuite) TestPaginationWorkflow() {
 	data = response.Data.(map[string]interface{})
 	products = data["products"].([]interface{})
 	assert.Len(suite.T(), products, 10)
-	assert.True(suite.T(), data["hasNext"].(bool))
-	assert.True(suite.T(), data["hasPrevious"].(bool))
+	assert.True(suite.T(), data["pagination"].(map[string]interface{})["hasNext"].(bool))
+	assert.True(suite.T(), data["pagination"].(map[string]interface{})["hasPrevious"].(bool))
 
 	// Test last page
 	req, _ = http.NewRequest("GET", "/api/products?page=3&page_size=10", nil)
@@ -395,8 +395,8 @@ func (suite *ProductIntegrationTestSuite) TestPaginationWorkflow() {
 	data = response.Data.(map[string]interface{})
 	products = data["products"].([]interface{})
 	assert.Len(suite.T(), products, 5) // Remaining 5 products
-	assert.False(suite.T(), data["hasNext"].(bool))
-	assert.True(suite.T(), data["hasPrevious"].(bool))
+	assert.False(suite.T(), data["pagination"].(map[string]interface{})["hasNext"].(bool))
+	assert.True(suite.T(), data["pagination"].(map[string]interface{})["hasPrevious"].(bool))
 }
 
 func (suite *ProductIntegrationTestSuite) TestSortingWorkflow() {
@@ -543,7 +543,7 @@ func (suite *ProductIntegrationTestSuite) TestAdminProductManagementWorkflow() {
 	adminData := adminListResponse.Data.(map[string]interface{})
 	adminProducts := adminData["products"].([]interface{})
 	assert.Len(suite.T(), adminProducts, 1)
-	assert.Equal(suite.T(), float64(1), adminData["total"])
+	assert.Equal(suite.T(), float64(1), adminData["pagination"].(map[string]interface{})["total"])
 
 	// Test 5: Soft delete the product
 	req, _ = http.NewRequest("DELETE", "/api/admin/products/"+productID, nil)
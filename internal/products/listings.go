@@ -0,0 +1,228 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// listingRebuildBatch is how many products a rebuild loads at a time
+	listingRebuildBatch = 500
+)
+
+// listingSortColumns maps the sort fields of the product list to listing columns
+var listingSortColumns = map[string]string{
+	"name":       "name",
+	"price":      "effective_price",
+	"created_at": "product_created_at",
+}
+
+// SubscribeListings keeps the product_listings read model current from product and inventory events
+func (s *Service) SubscribeListings() {
+	for _, event := range []string{eventbus.EventProductUpdated, eventbus.EventProductDeleted, eventbus.EventInventoryChanged} {
+		eventbus.Subscribe(event, func(tx *gorm.DB, event, productID string) error {
+			return refreshListing(tx, productID, time.Now())
+		})
+	}
+}
+
+// buildListing denormalizes a product and its category into a listing row
+func buildListing(product *models.Product, refreshedAt time.Time) models.ProductListing {
+	listing := models.ProductListing{
+		ProductID:        product.ID,
+		Name:             product.Name,
+		Description:      product.Description,
+		SKU:              product.SKU,
+		Price:            product.Price,
+		CompareAtPrice:   product.CompareAtPrice,
+		EffectivePrice:   product.Price,
+		Inventory:        product.Inventory,
+		InStock:          product.Inventory > 0,
+		LowStock:         product.Inventory > 0 && product.Inventory <= product.StockThreshold(),
+		CategoryID:       product.CategoryID,
+		CategoryName:     product.Category.Name,
+		CategorySlug:     product.Category.Slug,
+		Images:           product.Images,
+		Specifications:   product.Specifications,
+		SEOTitle:         product.SEOTitle,
+		SEODescription:   product.SEODescription,
+		WeightGrams:      product.WeightGrams,
+		ProductCreatedAt: product.CreatedAt,
+		ProductUpdatedAt: product.UpdatedAt,
+		RefreshedAt:      refreshedAt,
+	}
+	if product.CompareAtPrice != nil && *product.CompareAtPrice > listing.EffectivePrice {
+		listing.DiscountPercent = int(math.Round((1 - listing.EffectivePrice / *product.CompareAtPrice) * 100))
+	}
+	return listing
+}
+
+// refreshListing rebuilds the listing of one product, removing it when the product is deleted or inactive
+func refreshListing(tx *gorm.DB, productID string, now time.Time) error {
+	var product models.Product
+	err := tx.Preload("Category").Where("id = ? AND is_active = ?", productID, true).First(&product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := tx.Where("product_id = ?", productID).Delete(&models.ProductListing{}).Error; err != nil {
+			return fmt.Errorf("failed to remove product listing: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load product for listing: %w", err)
+	}
+
+	listing := buildListing(&product, now)
+	if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&listing).Error; err != nil {
+		return fmt.Errorf("failed to refresh product listing: %w", err)
+	}
+	return nil
+}
+
+// RebuildListings rebuilds every listing from the catalog and drops listings of products that are no longer
+// listed. Once it has completed the product list is served from the listings.
+func (s *Service) RebuildListings(ctx context.Context) error {
+	started := time.Now()
+	db := s.db.WithContext(ctx)
+
+	var products []models.Product
+	if err := db.Preload("Category").Where("is_active = ?", true).
+		FindInBatches(&products, listingRebuildBatch, func(tx *gorm.DB, batch int) error {
+			listings := make([]models.ProductListing, len(products))
+			for i := range products {
+				listings[i] = buildListing(&products[i], started)
+			}
+			return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&listings).Error
+		}).Error; err != nil {
+		return fmt.Errorf("failed to rebuild product listings: %w", err)
+	}
+	if err := db.Where("refreshed_at < ?", started).Delete(&models.ProductListing{}).Error; err != nil {
+		return fmt.Errorf("failed to prune product listings: %w", err)
+	}
+
+	s.listingsReady.Store(true)
+	return nil
+}
+
+// StartListingRebuilds serves the product list from listings already built by an earlier run, or rebuilds them
+// first, then rebuilds them every interval as a backstop for changes made outside the event pipeline
+func (s *Service) StartListingRebuilds(ctx context.Context, interval time.Duration) {
+	var built int64
+	if err := s.db.WithContext(ctx).Model(&models.ProductListing{}).Count(&built).Error; err == nil && built > 0 {
+		s.listingsReady.Store(true)
+	}
+
+	go func() {
+		if !s.listingsReady.Load() {
+			s.rebuildListings(ctx)
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.rebuildListings(ctx)
+			}
+		}
+	}()
+}
+
+// rebuildListings runs a rebuild, logging its outcome
+func (s *Service) rebuildListings(ctx context.Context) {
+	if err := s.RebuildListings(ctx); err != nil {
+		if ctx.Err() == nil {
+			logger.Named("products").Warn("Failed to rebuild product listings", map[string]interface{}{"error": err.Error()})
+		}
+	}
+}
+
+// listProducts serves the storefront product list from the listings read model
+func (s *Service) listProducts(ctx context.Context, filters ProductFilters, sort ProductSort, pagination PaginationParams) (*ProductListResponse, error) {
+	query := s.reader.WithContext(ctx).Model(&models.ProductListing{})
+	if filters.CategoryID != nil {
+		query = query.Where("category_id = ?", *filters.CategoryID)
+	}
+	if filters.MinPrice != nil {
+		query = query.Where("effective_price >= ?", *filters.MinPrice)
+	}
+	if filters.MaxPrice != nil {
+		query = query.Where("effective_price <= ?", *filters.MaxPrice)
+	}
+	if filters.InStock != nil && *filters.InStock {
+		query = query.Where("in_stock = ?", true)
+	}
+	if filters.Search != nil && *filters.Search != "" {
+		searchTerm := "%" + strings.ToLower(*filters.Search) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	orderClause := "product_created_at DESC"
+	if column, ok := listingSortColumns[sort.Field]; ok {
+		order := "ASC"
+		if strings.ToUpper(sort.Order) == "DESC" {
+			order = "DESC"
+		}
+		orderClause = fmt.Sprintf("%s %s", column, order)
+	}
+
+	var listings []models.ProductListing
+	offset := (pagination.Page - 1) * pagination.PageSize
+	if err := query.Order(orderClause).Offset(offset).Limit(pagination.PageSize).Find(&listings).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch products: %w", err)
+	}
+
+	products := make([]models.Product, len(listings))
+	for i, listing := range listings {
+		products[i] = listingProduct(listing)
+	}
+	return &ProductListResponse{
+		Products:   products,
+		Pagination: utils.NewPagination(pagination.Page, pagination.PageSize, total),
+	}, nil
+}
+
+// listingProduct turns a listing back into the product shape the list endpoint has always returned
+func listingProduct(listing models.ProductListing) models.Product {
+	return models.Product{
+		ID:             listing.ProductID,
+		Name:           listing.Name,
+		Description:    listing.Description,
+		Price:          listing.Price,
+		CompareAtPrice: listing.CompareAtPrice,
+		SKU:            listing.SKU,
+		Inventory:      listing.Inventory,
+		IsActive:       true,
+		CategoryID:     listing.CategoryID,
+		Images:         listing.Images,
+		Specifications: listing.Specifications,
+		SEOTitle:       listing.SEOTitle,
+		SEODescription: listing.SEODescription,
+		WeightGrams:    listing.WeightGrams,
+		CreatedAt:      listing.ProductCreatedAt,
+		UpdatedAt:      listing.ProductUpdatedAt,
+		Category: models.Category{
+			ID:   listing.CategoryID,
+			Name: listing.CategoryName,
+			Slug: listing.CategorySlug,
+		},
+	}
+}
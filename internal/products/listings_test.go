@@ -0,0 +1,85 @@
+package products
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupListingsTest(t *testing.T) (*Service, *gorm.DB, *TestHelpers) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductPrice{}, &models.ProductListing{}))
+	return NewService(db), db, NewTestHelpers(db)
+}
+
+func TestService_RebuildListings(t *testing.T) {
+	service, db, helpers := setupListingsTest(t)
+	category := helpers.CreateTestCategory("cat-1", "Audio", "audio")
+	helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 80, 3)
+	helpers.CreateTestProduct("p2", "Speaker", "SKU-2", category.ID, 50, 0)
+	inactive := helpers.CreateTestProduct("p3", "Old Radio", "SKU-3", category.ID, 20, 9)
+	require.NoError(t, db.Model(inactive).Update("is_active", false).Error)
+	compareAt := 100.0
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", "p1").Update("compare_at_price", compareAt).Error)
+
+	// Until listings are built the list is served from the products table
+	response, err := service.GetProducts(context.Background(), ProductFilters{}, ProductSort{}, PaginationParams{})
+	require.NoError(t, err)
+	assert.Len(t, response.Products, 2)
+
+	require.NoError(t, service.RebuildListings(context.Background()))
+
+	var listing models.ProductListing
+	require.NoError(t, db.First(&listing, "product_id = ?", "p1").Error)
+	assert.Equal(t, "Audio", listing.CategoryName)
+	assert.Equal(t, 80.0, listing.EffectivePrice)
+	assert.Equal(t, 20, listing.DiscountPercent)
+	assert.True(t, listing.InStock)
+	assert.True(t, listing.LowStock)
+	var count int64
+	db.Model(&models.ProductListing{}).Count(&count)
+	assert.Equal(t, int64(2), count, "inactive products are not listed")
+
+	inStock := true
+	response, err = service.GetProducts(context.Background(), ProductFilters{InStock: &inStock}, ProductSort{Field: "price", Order: "asc"}, PaginationParams{})
+	require.NoError(t, err)
+	require.Len(t, response.Products, 1)
+	assert.Equal(t, "p1", response.Products[0].ID)
+	assert.Equal(t, "Audio", response.Products[0].Category.Name)
+	assert.Equal(t, int64(1), response.Pagination.Total)
+}
+
+func TestRefreshListing(t *testing.T) {
+	_, db, helpers := setupListingsTest(t)
+	category := helpers.CreateTestCategory("cat-1", "Audio", "audio")
+	product := helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 80, 10)
+	now := time.Now()
+
+	require.NoError(t, refreshListing(db, product.ID, now))
+	var listing models.ProductListing
+	require.NoError(t, db.First(&listing, "product_id = ?", product.ID).Error)
+	assert.Equal(t, 10, listing.Inventory)
+	assert.False(t, listing.LowStock)
+
+	// A stock change is picked up on the next refresh
+	require.NoError(t, db.Model(product).Update("inventory", 0).Error)
+	require.NoError(t, refreshListing(db, product.ID, now))
+	require.NoError(t, db.First(&listing, "product_id = ?", product.ID).Error)
+	assert.False(t, listing.InStock)
+
+	// Deleted products are removed from the listings
+	require.NoError(t, db.Delete(product).Error)
+	require.NoError(t, refreshListing(db, product.ID, now))
+	assert.ErrorIs(t, db.First(&listing, "product_id = ?", product.ID).Error, gorm.ErrRecordNotFound)
+}
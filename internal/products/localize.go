@@ -0,0 +1,30 @@
+package products
+
+import (
+	"context"
+
+	"ecommerce-website/internal/formatting"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+)
+
+// DisplayFormatter returns the formatter configured for a store's catalog. Prices are shown in the base
+// currency they are charged in, written the way the store's locale writes numbers.
+func (s *Service) DisplayFormatter(ctx context.Context, storeID string) formatting.Formatter {
+	reader := s.settings.ForStore(storeID)
+	return formatting.New(
+		reader.GetString(ctx, settings.KeyCatalogLocale),
+		reader.GetString(ctx, settings.KeyCatalogWeightUnits),
+	)
+}
+
+// localizeProduct fills a product's display strings next to its raw values
+func localizeProduct(f formatting.Formatter, product *models.Product) {
+	product.PriceDisplay = f.Price(product.Price)
+	if product.CompareAtPrice != nil {
+		product.CompareAtPriceDisplay = f.Price(*product.CompareAtPrice)
+	}
+	if product.WeightGrams != nil {
+		product.WeightDisplay = f.Weight(*product.WeightGrams)
+	}
+}
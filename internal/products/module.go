@@ -0,0 +1,79 @@
+package products
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/health"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/media"
+	"ecommerce-website/internal/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// searchProbeInterval is how often an unavailable search backend is probed for recovery
+	searchProbeInterval = 30 * time.Second
+	// listingRebuildInterval is how often the product_listings read model is rebuilt from the catalog
+	listingRebuildInterval = 6 * time.Hour
+	// promotionInterval is how often promotions that are due are started and ended
+	promotionInterval = time.Minute
+)
+
+// Module wires the product catalog into the application
+type Module struct {
+	service       *Service
+	handler       *Handler
+	authService   *auth.Service
+	searchBackend string
+}
+
+// NewModule creates the products module
+func NewModule(deps app.Deps) app.Module {
+	store := media.NewLocalStore(deps.Config.MediaDir, deps.Config.CDNBaseURL)
+	searchService, err := search.NewServiceFor(deps.ReadDB, deps.Config.SearchBackend)
+	if err != nil {
+		logger.Named("search").Warn("Search backend not usable, falling back to Elasticsearch", map[string]interface{}{"error": err.Error()})
+		searchService = search.NewService(deps.ReadDB)
+	}
+	service := newService(deps.DB, searchService).WithReader(deps.ReadDB)
+	service.media = store
+	if deps.Outbox != nil {
+		service.WithOutbox(deps.Outbox)
+	}
+	service.SubscribeListings()
+	backend := deps.Config.SearchBackend
+	if backend == "" {
+		backend = search.BackendElasticsearch
+	}
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth, searchBackend: backend}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "products"
+}
+
+// StartJobs probes Elasticsearch in the background while search runs degraded, keeps the product listings
+// read model built and starts and ends promotions
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.searchService.StartRecoveryProbe(ctx, searchProbeInterval)
+	m.service.StartListingRebuilds(ctx, listingRebuildInterval)
+	m.service.StartPromotionWorker(ctx, promotionInterval)
+}
+
+// Dependencies checks the search backend. It is optional: while it is down, search is served by the database.
+func (m *Module) Dependencies() []health.Check {
+	return []health.Check{{
+		Name: m.searchBackend,
+		Ping: func(ctx context.Context) error { return m.service.searchService.Ping() },
+	}}
+}
+
+// RegisterAPIRoutes sets up the catalog routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,141 @@
+package products
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/outbox"
+	"ecommerce-website/internal/webhooks"
+
+	"gorm.io/gorm"
+)
+
+// Outbox topics of catalog changes
+const (
+	// TopicSyncSearch brings the search document of a product in line with the catalog
+	TopicSyncSearch = "search.sync_product"
+	// TopicProductUpdated queues the product.updated webhooks and bus event of a product
+	TopicProductUpdated = "products.updated"
+)
+
+// productMessage is the payload of the catalog outbox messages. It carries the product ID only; the product
+// is loaded again when the message is delivered.
+type productMessage struct {
+	ProductID string `json:"productId"`
+}
+
+// WithOutbox makes the service update the search index and publish product updates through the outbox: every
+// catalog change records them in its transaction, and they are retried until delivered. It returns the
+// service for chaining after a constructor.
+func (s *Service) WithOutbox(dispatcher *outbox.Dispatcher) *Service {
+	s.outbox = dispatcher
+	dispatcher.Register(TopicSyncSearch, s.runSyncSearch)
+	dispatcher.Register(TopicProductUpdated, s.runProductUpdated)
+	return s
+}
+
+// queueChange records the side effects of a catalog change in its transaction: a search update, and the
+// product.updated webhooks and bus event when updated is set. Without an outbox it records nothing, and
+// syncSearch and publishUpdate run them once the change is committed.
+func (s *Service) queueChange(tx *gorm.DB, productID string, updated bool) error {
+	if s.outbox == nil {
+		return nil
+	}
+	if err := outbox.Enqueue(tx, TopicSyncSearch, productMessage{ProductID: productID}); err != nil {
+		return err
+	}
+	if updated {
+		return outbox.Enqueue(tx, TopicProductUpdated, productMessage{ProductID: productID})
+	}
+	return nil
+}
+
+// syncSearch updates the search document of a product after a committed catalog change: it is indexed
+// again along with its translations, or removed when deleted. With an outbox the update is already recorded and the dispatcher is only
+// woken to deliver it; otherwise the index is updated right away, and failures are logged and never fail
+// the change.
+func (s *Service) syncSearch(product *models.Product, deleted bool) {
+	if s.outbox != nil {
+		s.outbox.Notify()
+		return
+	}
+
+	if deleted {
+		if err := s.searchService.DeleteProduct(product.ID); err != nil {
+			logger.Named("products").Warn("Failed to delete product from search index", map[string]interface{}{"error": err.Error()})
+		}
+		return
+	}
+	indexed, err := s.withTranslations(product)
+	if err != nil {
+		logger.Named("products").Warn("Failed to index product in search", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	if err := s.searchService.IndexProduct(indexed); err != nil {
+		logger.Named("products").Warn("Failed to index product in search", map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// syncProducts indexes and announces products changed together after their change is committed
+func (s *Service) syncProducts(ctx context.Context, productIDs []string) {
+	if len(productIDs) == 0 {
+		return
+	}
+	var products []models.Product
+	if err := s.db.WithContext(ctx).Unscoped().Preload("Category").Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		logger.Named("products").Warn("Failed to load changed products", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	for i := range products {
+		s.syncSearch(&products[i], products[i].DeletedAt.Valid)
+		s.publishUpdate(ctx, &products[i])
+	}
+}
+
+// runSyncSearch indexes the product as it is when the message is delivered, so messages for several changes
+// of the same product all leave the latest version in the index
+func (s *Service) runSyncSearch(ctx context.Context, payload json.RawMessage) error {
+	var message productMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return fmt.Errorf("invalid search sync message: %w", err)
+	}
+
+	var product models.Product
+	err := s.db.WithContext(ctx).Unscoped().Preload("Category").Preload("Translations").First(&product, "id = ?", message.ProductID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && product.DeletedAt.Valid) {
+		return s.searchService.DeleteProduct(message.ProductID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load product: %w", err)
+	}
+	return s.searchService.IndexProduct(&product)
+}
+
+// runProductUpdated queues the product.updated webhooks and bus event with the product as it is when the
+// message is delivered. A product deleted since has nothing left to announce.
+func (s *Service) runProductUpdated(ctx context.Context, payload json.RawMessage) error {
+	var message productMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return fmt.Errorf("invalid product update message: %w", err)
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		err := tx.First(&product, "id = ?", message.ProductID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load product: %w", err)
+		}
+		if err := webhooks.PublishProduct(tx, &product); err != nil {
+			return err
+		}
+		return eventbus.PublishProduct(tx, &product)
+	})
+}
@@ -0,0 +1,48 @@
+package products
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/outbox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Outbox(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	dispatcher := outbox.NewDispatcher(db)
+	service := NewService(db).WithOutbox(dispatcher)
+	helpers := NewTestHelpers(db)
+	ctx := context.Background()
+
+	category := helpers.CreateTestCategory("cat-1", "Audio", "audio")
+	helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 100, 5)
+
+	_, err := service.UpdateInventory(ctx, "p1", 3, nil)
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteProduct(ctx, "p1", false))
+
+	// A change that fails records nothing
+	_, err = service.UpdateInventory(ctx, "missing", 3, nil)
+	require.Error(t, err)
+
+	var topics []string
+	require.NoError(t, db.Model(&models.OutboxMessage{}).Order("created_at").Pluck("topic", &topics).Error)
+	assert.ElementsMatch(t, []string{TopicSyncSearch, TopicProductUpdated, TopicSyncSearch}, topics,
+		"catalog changes record a search update each, and updates a product update")
+
+	// Without Elasticsearch the search updates succeed as no-ops, including the one for the deleted product,
+	// and the product update of the deleted product has nothing left to announce
+	delivered, err := dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+	stats, err := dispatcher.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, outbox.Stats{Delivered: 3}, *stats)
+}
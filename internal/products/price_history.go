@@ -0,0 +1,148 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPriceNotFound is returned for a time before the product existed
+	ErrPriceNotFound = errors.New("no price recorded at that time")
+	// ErrOrderItemNotFound is returned when the order does not contain the product
+	ErrOrderItemNotFound = errors.New("order does not contain the product")
+)
+
+// ChargedPrice compares what an order was charged for a product with the catalog price at order time
+type ChargedPrice struct {
+	OrderID    string  `json:"orderId"`
+	Quantity   int     `json:"quantity"`
+	UnitPrice  float64 `json:"unitPrice"`
+	Difference float64 `json:"difference"` // charged unit price minus the catalog price at order time
+}
+
+// PricePoint is the catalog price of a product at a point in time
+type PricePoint struct {
+	ProductID      string        `json:"productId"`
+	At             time.Time     `json:"at"`
+	Price          float64       `json:"price"`
+	CompareAtPrice *float64      `json:"compareAtPrice,omitempty"`
+	EffectiveFrom  time.Time     `json:"effectiveFrom"`
+	CurrentPrice   float64       `json:"currentPrice"`
+	Charged        *ChargedPrice `json:"charged,omitempty"`
+}
+
+// recordPrice appends a price history entry for the product's current price
+func recordPrice(tx *gorm.DB, product *models.Product, effectiveFrom time.Time) error {
+	entry := models.ProductPrice{
+		ProductID:      product.ID,
+		Price:          product.Price,
+		CompareAtPrice: product.CompareAtPrice,
+		EffectiveFrom:  effectiveFrom,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record price history: %w", err)
+	}
+	return nil
+}
+
+// ensurePriceBaseline records the price a product had before its first tracked change. Products
+// created before price history existed are assumed to have kept their price since creation.
+func ensurePriceBaseline(tx *gorm.DB, product *models.Product) error {
+	var count int64
+	if err := tx.Model(&models.ProductPrice{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check price history: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	return recordPrice(tx, product, product.CreatedAt)
+}
+
+// priceChanged reports whether an update changes the price or compare-at price of product
+func priceChanged(product *models.Product, req UpdateProductRequest) bool {
+	if req.Price != nil && *req.Price != product.Price {
+		return true
+	}
+	if req.CompareAtPrice != nil && (product.CompareAtPrice == nil || *req.CompareAtPrice != *product.CompareAtPrice) {
+		return true
+	}
+	return false
+}
+
+// PriceAt returns the catalog price of a product at the given time from its price history
+func (s *Service) PriceAt(ctx context.Context, productID string, at time.Time) (*PricePoint, error) {
+	var product models.Product
+	if err := s.db.WithContext(ctx).Unscoped().Where("id = ?", productID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+
+	var entries []models.ProductPrice
+	if err := s.db.WithContext(ctx).Where("product_id = ? AND effective_from <= ?", productID, at).
+		Order("effective_from DESC, created_at DESC").Limit(1).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to read price history: %w", err)
+	}
+
+	point := &PricePoint{ProductID: productID, At: at, CurrentPrice: product.Price}
+	if len(entries) > 0 {
+		point.Price = entries[0].Price
+		point.CompareAtPrice = entries[0].CompareAtPrice
+		point.EffectiveFrom = entries[0].EffectiveFrom
+		return point, nil
+	}
+
+	// Without any history the price has not changed since the product was created
+	var tracked int64
+	if err := s.db.WithContext(ctx).Model(&models.ProductPrice{}).Where("product_id = ?", productID).Count(&tracked).Error; err != nil {
+		return nil, fmt.Errorf("failed to read price history: %w", err)
+	}
+	if tracked > 0 || at.Before(product.CreatedAt) {
+		return nil, ErrPriceNotFound
+	}
+	point.Price = product.Price
+	point.CompareAtPrice = product.CompareAtPrice
+	point.EffectiveFrom = product.CreatedAt
+	return point, nil
+}
+
+// PriceAtOrder returns the catalog price of a product when the order was placed, together with what the
+// order was charged for it, for investigating price disputes
+func (s *Service) PriceAtOrder(ctx context.Context, productID, orderID string) (*PricePoint, error) {
+	var order models.Order
+	if err := s.db.WithContext(ctx).Where("id = ?", orderID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, fmt.Errorf("failed to find order: %w", err)
+	}
+
+	var items []models.OrderItem
+	if err := s.db.WithContext(ctx).Where("order_id = ? AND product_id = ?", orderID, productID).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to find order items: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, ErrOrderItemNotFound
+	}
+
+	point, err := s.PriceAt(ctx, productID, order.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	charged := &ChargedPrice{OrderID: orderID, UnitPrice: items[0].Price}
+	for _, item := range items {
+		charged.Quantity += item.Quantity
+	}
+	charged.Difference = math.Round((charged.UnitPrice-point.Price)*100) / 100
+	point.Charged = charged
+	return point, nil
+}
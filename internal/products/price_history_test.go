@@ -0,0 +1,78 @@
+package products
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestService_PriceAt(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductPrice{}, &models.Order{}, &models.OrderItem{}))
+	service, helpers := NewService(db), NewTestHelpers(db)
+
+	category := helpers.CreateTestCategory("cat-1", "Audio", "audio")
+	created := time.Now().Add(-30 * 24 * time.Hour)
+	product := helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 100, 5)
+	require.NoError(t, db.Model(product).Update("created_at", created).Error)
+	helpers.CreateTestProduct("p2", "Speaker", "SKU-2", category.ID, 50, 5)
+
+	// Products without history kept their price since creation
+	point, err := service.PriceAt(context.Background(), "p2", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, point.Price)
+
+	// Updates that do not change the price are not recorded
+	name := "Wireless Headphones"
+	_, err = service.UpdateProduct(context.Background(), "p1", UpdateProductRequest{Name: &name, Price: &product.Price})
+	require.NoError(t, err)
+	var count int64
+	db.Model(&models.ProductPrice{}).Where("product_id = ?", "p1").Count(&count)
+	assert.Zero(t, count)
+
+	newPrice := 120.0
+	_, err = service.UpdateProduct(context.Background(), "p1", UpdateProductRequest{Price: &newPrice})
+	require.NoError(t, err)
+	db.Model(&models.ProductPrice{}).Where("product_id = ?", "p1").Count(&count)
+	assert.Equal(t, int64(2), count, "the price before the first change is kept as a baseline")
+
+	point, err = service.PriceAt(context.Background(), "p1", created.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, point.Price)
+	assert.Equal(t, 120.0, point.CurrentPrice)
+	assert.WithinDuration(t, created, point.EffectiveFrom, time.Second)
+
+	point, err = service.PriceAt(context.Background(), "p1", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 120.0, point.Price)
+
+	_, err = service.PriceAt(context.Background(), "p1", created.Add(-time.Hour))
+	assert.ErrorIs(t, err, ErrPriceNotFound)
+	_, err = service.PriceAt(context.Background(), "missing", time.Now())
+	assert.EqualError(t, err, "product not found")
+
+	// Disputes compare the charged price with the catalog price when the order was placed
+	order := &models.Order{UserID: "user-1", Status: "delivered", Subtotal: 220, Total: 220, CreatedAt: created.Add(10 * 24 * time.Hour)}
+	require.NoError(t, db.Create(order).Error)
+	require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: "p1", Quantity: 2, Price: 110, Total: 220}).Error)
+
+	point, err = service.PriceAtOrder(context.Background(), "p1", order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, point.Price)
+	require.NotNil(t, point.Charged)
+	assert.Equal(t, ChargedPrice{OrderID: order.ID, Quantity: 2, UnitPrice: 110, Difference: 10}, *point.Charged)
+
+	_, err = service.PriceAtOrder(context.Background(), "p2", order.ID)
+	assert.ErrorIs(t, err, ErrOrderItemNotFound)
+}
@@ -0,0 +1,324 @@
+package products
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxPromotionCodes is how many codes one batch may generate
+	maxPromotionCodes = 10000
+	// defaultCodePattern gives codes like 7KQM-X2PD
+	defaultCodePattern = "XXXX-XXXX"
+	// codeAlphabet is what X stands for: letters and digits without the ones read as each other (0/O, 1/I/L)
+	codeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	// codeDigits is what # stands for
+	codeDigits = "0123456789"
+	// codeSpaceFactor is how many times more codes a pattern must be able to give than a batch asks for,
+	// so random codes rarely collide and cannot be found by guessing
+	codeSpaceFactor = 100
+	// codeGenerationAttempts is how many rounds generation replaces codes that are already taken
+	codeGenerationAttempts = 5
+	// codeBatchSize is how many codes are checked, inserted or exported per query
+	codeBatchSize = 500
+)
+
+var ErrInvalidCodeBatch = errors.New("invalid code batch")
+
+// GeneratePromotionCodesRequest asks for a batch of single-use codes. In the pattern X is a random letter or
+// digit, # a random digit, and letters, digits and dashes stand for themselves; the prefix comes before it.
+type GeneratePromotionCodesRequest struct {
+	Count   int    `json:"count" binding:"required,min=1,max=10000"`
+	Prefix  string `json:"prefix" binding:"max=20"`
+	Pattern string `json:"pattern" binding:"max=40"`
+}
+
+// PromotionCodeBatch is a generated batch of codes
+type PromotionCodeBatch struct {
+	BatchID     string   `json:"batchId"`
+	PromotionID string   `json:"promotionId"`
+	Count       int      `json:"count"`
+	Codes       []string `json:"codes"`
+}
+
+// PromotionCodeFilters narrows the codes of a promotion to a batch and a status
+type PromotionCodeFilters struct {
+	BatchID string
+	Status  string // active, used or inactive; empty for all
+}
+
+// DeactivatePromotionCodesRequest names the codes to deactivate: a whole batch, a list of codes, or both
+type DeactivatePromotionCodesRequest struct {
+	BatchID string   `json:"batchId"`
+	Codes   []string `json:"codes" binding:"max=10000"`
+}
+
+// normalizeCode uppercases a code and trims the spaces around it
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// validCodeText reports whether text only has letters, digits and dashes
+func validCodeText(text string) bool {
+	for _, r := range text {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// codeSpace returns how many different codes a pattern can give
+func codeSpace(pattern string) float64 {
+	space := 1.0
+	for _, r := range pattern {
+		switch r {
+		case 'X':
+			space *= float64(len(codeAlphabet))
+		case '#':
+			space *= float64(len(codeDigits))
+		}
+	}
+	return space
+}
+
+// randomCode fills a pattern with random characters after prefix
+func randomCode(prefix, pattern string) (string, error) {
+	var code strings.Builder
+	code.WriteString(prefix)
+	for _, r := range pattern {
+		alphabet := ""
+		switch r {
+		case 'X':
+			alphabet = codeAlphabet
+		case '#':
+			alphabet = codeDigits
+		default:
+			code.WriteRune(r)
+			continue
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate code: %w", err)
+		}
+		code.WriteByte(alphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// GeneratePromotionCodes creates a batch of unique single-use codes for a scheduled or running promotion that
+// requires a code. Codes are random, so they cannot be worked out from each other; ones that are already
+// taken are generated again.
+func (s *Service) GeneratePromotionCodes(ctx context.Context, promotionID string, req GeneratePromotionCodesRequest) (*PromotionCodeBatch, error) {
+	prefix := normalizeCode(req.Prefix)
+	pattern := normalizeCode(req.Pattern)
+	if pattern == "" {
+		pattern = defaultCodePattern
+	}
+	switch {
+	case req.Count < 1 || req.Count > maxPromotionCodes:
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidCodeBatch, maxPromotionCodes)
+	case !validCodeText(prefix) || !validCodeText(strings.NewReplacer("#", "").Replace(pattern)):
+		return nil, fmt.Errorf("%w: prefix and pattern may only have letters, digits and dashes", ErrInvalidCodeBatch)
+	case codeSpace(pattern) < float64(req.Count)*codeSpaceFactor:
+		return nil, fmt.Errorf("%w: pattern %s has too few random characters for %d codes", ErrInvalidCodeBatch, pattern, req.Count)
+	}
+
+	promotion, err := s.codePromotion(ctx, promotionID)
+	if err != nil {
+		return nil, err
+	}
+	if promotion.Status != models.PromotionScheduled && promotion.Status != models.PromotionActive {
+		return nil, ErrPromotionFinished
+	}
+
+	codes := make(map[string]bool, req.Count)
+	for attempt := 0; attempt < codeGenerationAttempts && len(codes) < req.Count; attempt++ {
+		fresh := make([]string, 0, req.Count-len(codes))
+		for len(codes) < req.Count {
+			code, err := randomCode(prefix, pattern)
+			if err != nil {
+				return nil, err
+			}
+			if !codes[code] {
+				codes[code] = true
+				fresh = append(fresh, code)
+			}
+		}
+		taken, err := s.takenCodes(ctx, fresh)
+		if err != nil {
+			return nil, err
+		}
+		for _, code := range taken {
+			delete(codes, code)
+		}
+	}
+	if len(codes) < req.Count {
+		return nil, fmt.Errorf("%w: could not find %d unused codes for pattern %s", ErrInvalidCodeBatch, req.Count, pattern)
+	}
+
+	batch := &PromotionCodeBatch{BatchID: uuid.New().String(), PromotionID: promotion.ID, Count: len(codes), Codes: make([]string, 0, len(codes))}
+	rows := make([]models.PromotionCode, 0, len(codes))
+	for code := range codes {
+		batch.Codes = append(batch.Codes, code)
+		rows = append(rows, models.PromotionCode{PromotionID: promotion.ID, BatchID: batch.BatchID, Code: code, IsActive: true})
+	}
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&rows, codeBatchSize).Error
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save promotion codes: %w", err)
+	}
+	logger.Named("products").Info("Promotion codes generated", map[string]interface{}{"promotionId": promotion.ID, "batchId": batch.BatchID, "count": batch.Count})
+	return batch, nil
+}
+
+// codePromotion returns a promotion that codes can be generated for
+func (s *Service) codePromotion(ctx context.Context, promotionID string) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := s.db.WithContext(ctx).First(&promotion, "id = ?", promotionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch promotion: %w", err)
+	}
+	if !promotion.RequiresCode {
+		return nil, fmt.Errorf("%w: the promotion does not require a code", ErrInvalidCodeBatch)
+	}
+	return &promotion, nil
+}
+
+// takenCodes returns which of codes already exist
+func (s *Service) takenCodes(ctx context.Context, codes []string) ([]string, error) {
+	var taken []string
+	for start := 0; start < len(codes); start += codeBatchSize {
+		end := min(start+codeBatchSize, len(codes))
+		var found []string
+		if err := s.db.WithContext(ctx).Model(&models.PromotionCode{}).Where("code IN ?", codes[start:end]).
+			Pluck("code", &found).Error; err != nil {
+			return nil, fmt.Errorf("failed to check promotion codes: %w", err)
+		}
+		taken = append(taken, found...)
+	}
+	return taken, nil
+}
+
+// promotionCodesQuery selects the codes of a promotion matching filters
+func (s *Service) promotionCodesQuery(ctx context.Context, promotionID string, filters PromotionCodeFilters) (*gorm.DB, error) {
+	query := s.db.WithContext(ctx).Model(&models.PromotionCode{}).Where("promotion_id = ?", promotionID)
+	if filters.BatchID != "" {
+		query = query.Where("batch_id = ?", filters.BatchID)
+	}
+	switch filters.Status {
+	case "":
+	case models.PromotionCodeActive:
+		query = query.Where("is_active = ? AND used_at IS NULL", true)
+	case models.PromotionCodeUsed:
+		query = query.Where("used_at IS NOT NULL")
+	case models.PromotionCodeInactive:
+		query = query.Where("is_active = ? AND used_at IS NULL", false)
+	default:
+		return nil, fmt.Errorf("%w: status must be active, used or inactive", ErrInvalidCodeBatch)
+	}
+	return query, nil
+}
+
+// GetPromotionCodes returns a page of a promotion's codes, newest batch first, with how each was used
+func (s *Service) GetPromotionCodes(ctx context.Context, promotionID string, filters PromotionCodeFilters, page, limit int) ([]models.PromotionCode, int64, error) {
+	if _, err := s.GetPromotion(ctx, promotionID); err != nil {
+		return nil, 0, err
+	}
+	query, err := s.promotionCodesQuery(ctx, promotionID, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count promotion codes: %w", err)
+	}
+	codes := []models.PromotionCode{}
+	if err := query.Order("created_at DESC, code").Offset((page - 1) * limit).Limit(limit).Find(&codes).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch promotion codes: %w", err)
+	}
+	return codes, total, nil
+}
+
+// EachPromotionCode calls fn with every code of a promotion matching filters, reading them in batches so a
+// large export is never held in memory
+func (s *Service) EachPromotionCode(ctx context.Context, promotionID string, filters PromotionCodeFilters, fn func(models.PromotionCode) error) error {
+	if _, err := s.GetPromotion(ctx, promotionID); err != nil {
+		return err
+	}
+	query, err := s.promotionCodesQuery(ctx, promotionID, filters)
+	if err != nil {
+		return err
+	}
+	var codes []models.PromotionCode
+	result := query.Order("id").FindInBatches(&codes, codeBatchSize, func(tx *gorm.DB, batch int) error {
+		for _, code := range codes {
+			if err := fn(code); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to export promotion codes: %w", result.Error)
+	}
+	return nil
+}
+
+// DeactivatePromotionCodes deactivates the unused codes of a promotion in a batch or list, returning how many
+// were deactivated. Codes already used keep their usage.
+func (s *Service) DeactivatePromotionCodes(ctx context.Context, promotionID string, req DeactivatePromotionCodesRequest) (int64, error) {
+	if req.BatchID == "" && len(req.Codes) == 0 {
+		return 0, fmt.Errorf("%w: give a batchId or codes to deactivate", ErrInvalidCodeBatch)
+	}
+	if _, err := s.GetPromotion(ctx, promotionID); err != nil {
+		return 0, err
+	}
+
+	codes := make([]string, 0, len(req.Codes))
+	for _, code := range req.Codes {
+		if code = normalizeCode(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	now := time.Now()
+	var deactivated int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deactivate := func(scope func(*gorm.DB) *gorm.DB) error {
+			result := scope(tx.Model(&models.PromotionCode{})).
+				Where("promotion_id = ? AND is_active = ? AND used_at IS NULL", promotionID, true).
+				Updates(map[string]interface{}{"is_active": false, "deactivated_at": now})
+			deactivated += result.RowsAffected
+			return result.Error
+		}
+		if req.BatchID != "" {
+			if err := deactivate(func(db *gorm.DB) *gorm.DB { return db.Where("batch_id = ?", req.BatchID) }); err != nil {
+				return err
+			}
+		}
+		for start := 0; start < len(codes); start += codeBatchSize {
+			chunk := codes[start:min(start+codeBatchSize, len(codes))]
+			if err := deactivate(func(db *gorm.DB) *gorm.DB { return db.Where("code IN ?", chunk) }); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to deactivate promotion codes: %w", err)
+	}
+	return deactivated, nil
+}
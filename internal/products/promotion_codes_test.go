@@ -0,0 +1,200 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_PromotionCodes(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	service := NewService(db)
+	helpers := NewTestHelpers(db)
+	ctx := context.Background()
+
+	category := helpers.CreateTestCategory("cat-codes", "Audio", "audio")
+	helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 100, 5)
+
+	campaign, err := service.CreatePromotion(ctx, CreatePromotionRequest{
+		Name: "Influencer", DiscountType: models.PromotionPercentOff, DiscountValue: 20, RequiresCode: true,
+		CategoryIDs: []string{category.ID}, StartsAt: time.Now().Add(-time.Minute), EndsAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.PromotionActive, campaign.Status)
+
+	t.Run("leaves prices alone", func(t *testing.T) {
+		var product models.Product
+		require.NoError(t, db.First(&product, "id = ?", "p1").Error)
+		assert.Equal(t, 100.0, product.Price, "a promotion that requires a code only discounts at checkout")
+		assert.Nil(t, product.CompareAtPrice)
+
+		active, err := service.ActivePromotions(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, active, "code promotions are not advertised")
+	})
+
+	t.Run("validates", func(t *testing.T) {
+		for _, req := range []GeneratePromotionCodesRequest{
+			{Count: 0},
+			{Count: 10, Prefix: "summer sale"},
+			{Count: 10, Pattern: "XX_XX"},
+			{Count: 10, Pattern: "SALE"},
+			{Count: 100, Pattern: "##"},
+		} {
+			_, err := service.GeneratePromotionCodes(ctx, campaign.ID, req)
+			assert.ErrorIs(t, err, ErrInvalidCodeBatch, "%+v", req)
+		}
+
+		sale, err := service.CreatePromotion(ctx, CreatePromotionRequest{
+			Name: "Sale", DiscountType: models.PromotionAmountOff, DiscountValue: 5,
+			CategoryIDs: []string{category.ID}, StartsAt: time.Now().Add(time.Hour), EndsAt: time.Now().Add(2 * time.Hour),
+		})
+		require.NoError(t, err)
+		_, err = service.GeneratePromotionCodes(ctx, sale.ID, GeneratePromotionCodesRequest{Count: 1})
+		assert.ErrorIs(t, err, ErrInvalidCodeBatch, "a promotion that reprices products takes no codes")
+
+		_, err = service.GeneratePromotionCodes(ctx, "missing", GeneratePromotionCodesRequest{Count: 1})
+		assert.ErrorIs(t, err, ErrPromotionNotFound)
+	})
+
+	batch, err := service.GeneratePromotionCodes(ctx, campaign.ID, GeneratePromotionCodesRequest{Count: 50, Prefix: "insta-", Pattern: "xxxx##"})
+	require.NoError(t, err)
+	other, err := service.GeneratePromotionCodes(ctx, campaign.ID, GeneratePromotionCodesRequest{Count: 5})
+	require.NoError(t, err)
+
+	t.Run("generates", func(t *testing.T) {
+		assert.Equal(t, 50, batch.Count)
+		unique := make(map[string]bool)
+		for _, code := range batch.Codes {
+			assert.Regexp(t, `^INSTA-[A-Z2-9]{4}[0-9]{2}$`, code)
+			assert.NotRegexp(t, `[01OIL]`, code[len("INSTA-"):len("INSTA-")+4], "look-alike characters are left out")
+			unique[code] = true
+		}
+		assert.Len(t, unique, 50)
+		assert.Regexp(t, `^[A-Z2-9]{4}-[A-Z2-9]{4}$`, other.Codes[0])
+
+		codes, total, err := service.GetPromotionCodes(ctx, campaign.ID, PromotionCodeFilters{BatchID: batch.BatchID}, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), total)
+		assert.Len(t, codes, 20)
+		assert.Equal(t, models.PromotionCodeActive, codes[0].Status())
+	})
+
+	t.Run("deactivates", func(t *testing.T) {
+		used := batch.Codes[0]
+		require.NoError(t, db.Model(&models.PromotionCode{}).Where("code = ?", used).
+			Updates(map[string]interface{}{"used_at": time.Now(), "used_by": "user-1", "order_id": "order-1"}).Error)
+
+		_, err := service.DeactivatePromotionCodes(ctx, campaign.ID, DeactivatePromotionCodesRequest{})
+		assert.ErrorIs(t, err, ErrInvalidCodeBatch)
+
+		n, err := service.DeactivatePromotionCodes(ctx, campaign.ID, DeactivatePromotionCodesRequest{Codes: []string{strings.ToLower(other.Codes[0])}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		n, err = service.DeactivatePromotionCodes(ctx, campaign.ID, DeactivatePromotionCodesRequest{BatchID: batch.BatchID})
+		require.NoError(t, err)
+		assert.Equal(t, int64(49), n, "a used code keeps its usage")
+
+		_, total, err := service.GetPromotionCodes(ctx, campaign.ID, PromotionCodeFilters{Status: models.PromotionCodeInactive}, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), total)
+		codes, total, err := service.GetPromotionCodes(ctx, campaign.ID, PromotionCodeFilters{Status: models.PromotionCodeUsed}, 1, 20)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), total)
+		assert.Equal(t, used, codes[0].Code)
+		require.NotNil(t, codes[0].UsedBy)
+		assert.Equal(t, "user-1", *codes[0].UsedBy)
+		_, total, err = service.GetPromotionCodes(ctx, campaign.ID, PromotionCodeFilters{Status: models.PromotionCodeActive}, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+
+		_, _, err = service.GetPromotionCodes(ctx, campaign.ID, PromotionCodeFilters{Status: "expired"}, 1, 20)
+		assert.ErrorIs(t, err, ErrInvalidCodeBatch)
+	})
+
+	t.Run("ended promotion", func(t *testing.T) {
+		_, err := service.CancelPromotion(ctx, campaign.ID)
+		require.NoError(t, err)
+		_, err = service.GeneratePromotionCodes(ctx, campaign.ID, GeneratePromotionCodesRequest{Count: 1})
+		assert.ErrorIs(t, err, ErrPromotionFinished)
+	})
+}
+
+func TestHandler_PromotionCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	helpers := NewTestHelpers(db)
+	category := helpers.CreateTestCategory("cat-codes", "Audio", "audio")
+	helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 100, 5)
+	service := NewService(db)
+	campaign, err := service.CreatePromotion(context.Background(), CreatePromotionRequest{
+		Name: "Print", DiscountType: models.PromotionAmountOff, DiscountValue: 10, RequiresCode: true,
+		CategoryIDs: []string{category.ID}, StartsAt: time.Now().Add(time.Hour), EndsAt: time.Now().Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	handler := NewHandler(service)
+	router := gin.New()
+	router.POST("/api/admin/promotions/:id/codes/generate", handler.GeneratePromotionCodes)
+	router.GET("/api/admin/promotions/:id/codes", handler.GetPromotionCodes)
+	router.GET("/api/admin/promotions/:id/codes/export", handler.ExportPromotionCodes)
+	router.POST("/api/admin/promotions/:id/codes/deactivate", handler.DeactivatePromotionCodes)
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+	base := "/api/admin/promotions/" + campaign.ID + "/codes"
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, base+"/generate", `{"count":0}`).Code)
+	w := request(http.MethodPost, base+"/generate", `{"count":3,"pattern":"##"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_CODE_BATCH")
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/api/admin/promotions/missing/codes/generate", `{"count":3}`).Code)
+
+	w = request(http.MethodPost, base+"/generate", `{"count":3,"prefix":"MAG"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"count":3`)
+
+	w = request(http.MethodGet, base+"?status=active", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"codes"`)
+	assert.Contains(t, w.Body.String(), `"total":3`)
+
+	w = request(http.MethodGet, base+"/export", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Equal(t, "code", rows[0][0])
+	assert.True(t, strings.HasPrefix(rows[1][0], "MAG"))
+	assert.Equal(t, models.PromotionCodeActive, rows[1][2])
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, base+"/export?status=expired", "").Code)
+
+	w = request(http.MethodPost, base+"/deactivate", `{"codes":["`+strings.ToLower(rows[1][0])+`"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"deactivated":1`)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, base+"/deactivate", `{}`).Code)
+}
@@ -0,0 +1,364 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/middleware"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPromotion  = errors.New("invalid promotion")
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrPromotionFinished = errors.New("promotion has already ended")
+)
+
+// CreatePromotionRequest schedules a flash sale on the products of some categories or with some tags
+type CreatePromotionRequest struct {
+	Name          string    `json:"name" binding:"required,max=255"`
+	Description   string    `json:"description"`
+	DiscountType  string    `json:"discountType" binding:"required,oneof=percentage amount"`
+	DiscountValue float64   `json:"discountValue" binding:"required,gt=0"`
+	CategoryIDs   []string  `json:"categoryIds,omitempty"`
+	Tags          []string  `json:"tags,omitempty"`
+	StartsAt      time.Time `json:"startsAt" binding:"required"`
+	EndsAt        time.Time `json:"endsAt" binding:"required"`
+	// RequiresCode leaves prices alone; the discount is only given to orders redeeming one of the codes
+	// generated for the promotion
+	RequiresCode bool `json:"requiresCode"`
+}
+
+// normalizeTags lowercases and trims tags, dropping empty and repeated ones
+func normalizeTags(tags []string) models.StringArray {
+	normalized := models.StringArray{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// CreatePromotion schedules a promotion. One whose start has already come is started right away.
+func (s *Service) CreatePromotion(ctx context.Context, req CreatePromotionRequest) (*models.Promotion, error) {
+	now := time.Now()
+	promotion := models.Promotion{
+		Name:          strings.TrimSpace(req.Name),
+		Description:   req.Description,
+		DiscountType:  req.DiscountType,
+		DiscountValue: req.DiscountValue,
+		CategoryIDs:   models.StringArray{},
+		Tags:          normalizeTags(req.Tags),
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+		RequiresCode:  req.RequiresCode,
+		Status:        models.PromotionScheduled,
+	}
+	seen := make(map[string]bool, len(req.CategoryIDs))
+	for _, categoryID := range req.CategoryIDs {
+		if categoryID != "" && !seen[categoryID] {
+			seen[categoryID] = true
+			promotion.CategoryIDs = append(promotion.CategoryIDs, categoryID)
+		}
+	}
+
+	switch {
+	case len(promotion.CategoryIDs) == 0 && len(promotion.Tags) == 0:
+		return nil, fmt.Errorf("%w: give the categories or tags of the products on sale", ErrInvalidPromotion)
+	case promotion.DiscountType == models.PromotionPercentOff && promotion.DiscountValue >= 100:
+		return nil, fmt.Errorf("%w: a percentage discount must be below 100", ErrInvalidPromotion)
+	case !promotion.EndsAt.After(promotion.StartsAt):
+		return nil, fmt.Errorf("%w: endsAt must be after startsAt", ErrInvalidPromotion)
+	case !promotion.EndsAt.After(now):
+		return nil, fmt.Errorf("%w: endsAt must be in the future", ErrInvalidPromotion)
+	}
+	if len(promotion.CategoryIDs) > 0 {
+		var found int64
+		if err := s.db.WithContext(ctx).Model(&models.Category{}).Where("id IN ?", []string(promotion.CategoryIDs)).Count(&found).Error; err != nil {
+			return nil, fmt.Errorf("failed to verify categories: %w", err)
+		}
+		if int(found) != len(promotion.CategoryIDs) {
+			return nil, fmt.Errorf("%w: unknown category", ErrInvalidPromotion)
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Create(&promotion).Error; err != nil {
+		return nil, fmt.Errorf("failed to create promotion: %w", err)
+	}
+	if !promotion.StartsAt.After(now) {
+		if err := s.startPromotion(ctx, promotion.ID, now); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetPromotion(ctx, promotion.ID)
+}
+
+// GetPromotion returns a promotion with the products it has on sale
+func (s *Service) GetPromotion(ctx context.Context, id string) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := s.db.WithContext(ctx).Preload("Products").First(&promotion, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch promotion: %w", err)
+	}
+	return &promotion, nil
+}
+
+// GetPromotions returns the promotions with the given status, or all of them, latest start first
+func (s *Service) GetPromotions(ctx context.Context, status string) ([]models.Promotion, error) {
+	promotions := []models.Promotion{}
+	query := s.db.WithContext(ctx).Order("starts_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&promotions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch promotions: %w", err)
+	}
+	return promotions, nil
+}
+
+// ActivePromotions returns the running promotions with the products they have on sale, ending soonest first.
+// Promotions that require a code are left out, so their codes stay with the people they were given to.
+func (s *Service) ActivePromotions(ctx context.Context) ([]models.Promotion, error) {
+	promotions := []models.Promotion{}
+	if err := s.reader.WithContext(ctx).Preload("Products").
+		Where("status = ? AND ends_at > ? AND requires_code = ?", models.PromotionActive, time.Now(), false).
+		Order("ends_at ASC").Find(&promotions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch promotions: %w", err)
+	}
+	return promotions, nil
+}
+
+// CancelPromotion cancels a scheduled promotion, or ends a running one early and restores its products' prices
+func (s *Service) CancelPromotion(ctx context.Context, id string) (*models.Promotion, error) {
+	promotion, err := s.GetPromotion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ended, err := s.endPromotion(ctx, promotion.ID, models.PromotionCancelled, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ended {
+		return nil, ErrPromotionFinished
+	}
+	return s.GetPromotion(ctx, id)
+}
+
+// ProcessPromotions ends the promotions whose time is up and then starts those whose time has come, so a
+// product leaving one sale can join the next
+func (s *Service) ProcessPromotions(ctx context.Context, now time.Time) error {
+	var ending []string
+	if err := s.db.WithContext(ctx).Model(&models.Promotion{}).
+		Where("status IN ? AND ends_at <= ?", []string{models.PromotionScheduled, models.PromotionActive}, now).
+		Order("ends_at").Pluck("id", &ending).Error; err != nil {
+		return fmt.Errorf("failed to fetch ending promotions: %w", err)
+	}
+	for _, id := range ending {
+		if _, err := s.endPromotion(ctx, id, models.PromotionEnded, now); err != nil {
+			return err
+		}
+	}
+
+	var starting []string
+	if err := s.db.WithContext(ctx).Model(&models.Promotion{}).
+		Where("status = ? AND starts_at <= ?", models.PromotionScheduled, now).
+		Order("starts_at").Pluck("id", &starting).Error; err != nil {
+		return fmt.Errorf("failed to fetch starting promotions: %w", err)
+	}
+	for _, id := range starting {
+		if err := s.startPromotion(ctx, id, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startPromotion puts the products a scheduled promotion covers on sale. The price they had becomes their
+// compare-at price unless they already show a higher one. Products on another sale are left to it. A promotion
+// that requires a code only becomes active, so its codes can be redeemed.
+func (s *Service) startPromotion(ctx context.Context, id string, now time.Time) error {
+	var changed []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Promotion{}).Where("id = ? AND status = ?", id, models.PromotionScheduled).
+			Updates(map[string]interface{}{"status": models.PromotionActive, "started_at": now})
+		if result.Error != nil {
+			return fmt.Errorf("failed to start promotion: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil // started or cancelled meanwhile
+		}
+		var promotion models.Promotion
+		if err := tx.First(&promotion, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to fetch promotion: %w", err)
+		}
+		if promotion.RequiresCode {
+			return nil
+		}
+
+		var onSale []string
+		if err := tx.Model(&models.PromotionProduct{}).Pluck("product_id", &onSale).Error; err != nil {
+			return fmt.Errorf("failed to fetch products on sale: %w", err)
+		}
+		skip := make(map[string]bool, len(onSale))
+		for _, productID := range onSale {
+			skip[productID] = true
+		}
+
+		var products []models.Product
+		query := tx.Order("id")
+		if len(promotion.Tags) == 0 {
+			query = query.Where("category_id IN ?", []string(promotion.CategoryIDs))
+		}
+		if err := query.Find(&products).Error; err != nil {
+			return fmt.Errorf("failed to fetch products: %w", err)
+		}
+
+		for i := range products {
+			product := &products[i]
+			if skip[product.ID] || !promotion.Covers(product) {
+				continue
+			}
+			salePrice := promotion.SalePrice(product.Price)
+			if salePrice <= 0 || salePrice >= product.Price {
+				continue
+			}
+			if err := ensurePriceBaseline(tx, product); err != nil {
+				return err
+			}
+			entry := models.PromotionProduct{
+				PromotionID:            promotion.ID,
+				ProductID:              product.ID,
+				OriginalPrice:          product.Price,
+				OriginalCompareAtPrice: product.CompareAtPrice,
+				SalePrice:              salePrice,
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return fmt.Errorf("failed to record product on sale: %w", err)
+			}
+			compareAt := product.CompareAtPrice
+			if compareAt == nil || *compareAt < product.Price {
+				compareAt = &entry.OriginalPrice
+			}
+			if err := s.repriceForPromotion(tx, product, salePrice, compareAt, now); err != nil {
+				return err
+			}
+			changed = append(changed, product.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(changed) > 0 {
+		logger.Named("products").Info("Promotion started", map[string]interface{}{"promotionId": id, "products": len(changed)})
+	}
+	s.syncRepriced(ctx, changed)
+	return nil
+}
+
+// endPromotion finishes a scheduled or running promotion with status and restores the prices of the products
+// it had on sale. A product whose price was changed during the sale keeps the new price. It returns false when
+// the promotion had already finished.
+func (s *Service) endPromotion(ctx context.Context, id, status string, now time.Time) (bool, error) {
+	var ended bool
+	var changed []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Promotion{}).
+			Where("id = ? AND status IN ?", id, []string{models.PromotionScheduled, models.PromotionActive}).
+			Updates(map[string]interface{}{"status": status, "ended_at": now})
+		if result.Error != nil {
+			return fmt.Errorf("failed to end promotion: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		ended = true
+
+		var entries []models.PromotionProduct
+		if err := tx.Where("promotion_id = ?", id).Find(&entries).Error; err != nil {
+			return fmt.Errorf("failed to fetch products on sale: %w", err)
+		}
+		for _, entry := range entries {
+			var product models.Product
+			if err := tx.Unscoped().First(&product, "id = ?", entry.ProductID).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					continue
+				}
+				return fmt.Errorf("failed to fetch product: %w", err)
+			}
+			if product.Price != entry.SalePrice {
+				continue
+			}
+			if err := s.repriceForPromotion(tx, &product, entry.OriginalPrice, entry.OriginalCompareAtPrice, now); err != nil {
+				return err
+			}
+			changed = append(changed, product.ID)
+		}
+		if err := tx.Where("promotion_id = ?", id).Delete(&models.PromotionProduct{}).Error; err != nil {
+			return fmt.Errorf("failed to release products on sale: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if ended {
+		logger.Named("products").Info("Promotion ended", map[string]interface{}{"promotionId": id, "status": status, "products": len(changed)})
+	}
+	s.syncRepriced(ctx, changed)
+	return ended, nil
+}
+
+// repriceForPromotion sets a product's price and compare-at price in tx, recording them in its price history
+func (s *Service) repriceForPromotion(tx *gorm.DB, product *models.Product, price float64, compareAt *float64, now time.Time) error {
+	if err := updateVersioned(tx, product, nil, map[string]interface{}{"price": price, "compare_at_price": compareAt}); err != nil {
+		return err
+	}
+	product.Price = price
+	product.CompareAtPrice = compareAt
+	if err := recordPrice(tx, product, now); err != nil {
+		return err
+	}
+	return s.queueChange(tx, product.ID, true)
+}
+
+// syncRepriced updates the search index, announcements and cached catalog responses after promotions
+// changed the prices of products
+func (s *Service) syncRepriced(ctx context.Context, productIDs []string) {
+	if len(productIDs) == 0 {
+		return
+	}
+	s.syncProducts(ctx, productIDs)
+	middleware.InvalidateCatalogCache()
+}
+
+// StartPromotionWorker starts and ends promotions every interval until ctx is cancelled
+func (s *Service) StartPromotionWorker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := s.ProcessPromotions(ctx, now); err != nil && ctx.Err() == nil {
+					logger.Named("products").Warn("Failed to process promotions", map[string]interface{}{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,184 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Promotions(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	service := NewService(db)
+	helpers := NewTestHelpers(db)
+	ctx := context.Background()
+
+	audio := helpers.CreateTestCategory("cat-audio", "Audio", "audio")
+	video := helpers.CreateTestCategory("cat-video", "Video", "video")
+	helpers.CreateTestProduct("p1", "Headphones", "SKU-1", audio.ID, 100, 5)
+	helpers.CreateTestProduct("p2", "Speaker", "SKU-2", audio.ID, 40, 5)
+	helpers.CreateTestProduct("p3", "Projector", "SKU-3", video.ID, 300, 5)
+	msrp := 350.0
+	require.NoError(t, db.Model(&models.Product{ID: "p3"}).Updates(map[string]interface{}{
+		"tags": models.StringArray{"summer"}, "compare_at_price": msrp,
+	}).Error)
+
+	price := func(id string) (float64, *float64) {
+		var product models.Product
+		require.NoError(t, db.First(&product, "id = ?", id).Error)
+		return product.Price, product.CompareAtPrice
+	}
+
+	start := time.Now().Add(time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	t.Run("validates", func(t *testing.T) {
+		for _, req := range []CreatePromotionRequest{
+			{Name: "Sale", DiscountType: models.PromotionPercentOff, DiscountValue: 10, StartsAt: start, EndsAt: end},
+			{Name: "Sale", DiscountType: models.PromotionPercentOff, DiscountValue: 100, Tags: []string{"summer"}, StartsAt: start, EndsAt: end},
+			{Name: "Sale", DiscountType: models.PromotionAmountOff, DiscountValue: 5, Tags: []string{"summer"}, StartsAt: end, EndsAt: start},
+			{Name: "Sale", DiscountType: models.PromotionAmountOff, DiscountValue: 5, CategoryIDs: []string{"missing"}, StartsAt: start, EndsAt: end},
+		} {
+			_, err := service.CreatePromotion(ctx, req)
+			assert.ErrorIs(t, err, ErrInvalidPromotion)
+		}
+	})
+
+	sale, err := service.CreatePromotion(ctx, CreatePromotionRequest{
+		Name: "Summer sale", DiscountType: models.PromotionPercentOff, DiscountValue: 25,
+		CategoryIDs: []string{audio.ID}, Tags: []string{" Summer "}, StartsAt: start, EndsAt: end,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.PromotionScheduled, sale.Status)
+	assert.Equal(t, models.StringArray{"summer"}, sale.Tags)
+
+	// An overlapping promotion starting at the same time leaves the products it shares to the first one
+	overlap, err := service.CreatePromotion(ctx, CreatePromotionRequest{
+		Name: "Speaker deal", DiscountType: models.PromotionAmountOff, DiscountValue: 50,
+		CategoryIDs: []string{audio.ID}, StartsAt: start.Add(time.Minute), EndsAt: end,
+	})
+	require.NoError(t, err)
+
+	t.Run("starts", func(t *testing.T) {
+		require.NoError(t, service.ProcessPromotions(ctx, start.Add(2*time.Minute)))
+
+		started, err := service.GetPromotion(ctx, sale.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.PromotionActive, started.Status)
+		assert.Len(t, started.Products, 3, "the category and the tag both select products")
+
+		p1, compareAt := price("p1")
+		assert.Equal(t, 75.0, p1)
+		require.NotNil(t, compareAt)
+		assert.Equal(t, 100.0, *compareAt, "the price before the sale is shown as the compare-at price")
+		p3, compareAt := price("p3")
+		assert.Equal(t, 225.0, p3)
+		assert.Equal(t, msrp, *compareAt, "a higher compare-at price is kept")
+
+		other, err := service.GetPromotion(ctx, overlap.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.PromotionActive, other.Status)
+		assert.Empty(t, other.Products)
+
+		active, err := service.ActivePromotions(ctx)
+		require.NoError(t, err)
+		assert.Len(t, active, 2)
+
+		var history int64
+		require.NoError(t, db.Model(&models.ProductPrice{}).Where("product_id = ?", "p1").Count(&history).Error)
+		assert.Equal(t, int64(2), history, "sale prices are kept in the price history")
+	})
+
+	t.Run("ends", func(t *testing.T) {
+		// A price changed by hand during the sale is kept when it ends
+		manual := 32.0
+		_, err := service.UpdateProduct(ctx, "p2", UpdateProductRequest{Price: &manual})
+		require.NoError(t, err)
+
+		require.NoError(t, service.ProcessPromotions(ctx, end))
+		ended, err := service.GetPromotion(ctx, sale.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.PromotionEnded, ended.Status)
+		assert.Empty(t, ended.Products)
+
+		p1, compareAt := price("p1")
+		assert.Equal(t, 100.0, p1)
+		assert.Nil(t, compareAt)
+		p2, _ := price("p2")
+		assert.Equal(t, manual, p2)
+		p3, compareAt := price("p3")
+		assert.Equal(t, 300.0, p3)
+		assert.Equal(t, msrp, *compareAt)
+	})
+
+	t.Run("cancels", func(t *testing.T) {
+		flash, err := service.CreatePromotion(ctx, CreatePromotionRequest{
+			Name: "Flash", DiscountType: models.PromotionAmountOff, DiscountValue: 10,
+			Tags: []string{"summer"}, StartsAt: time.Now().Add(-time.Minute), EndsAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.PromotionActive, flash.Status, "a promotion whose start has passed starts right away")
+		p3, _ := price("p3")
+		assert.Equal(t, 290.0, p3)
+
+		cancelled, err := service.CancelPromotion(ctx, flash.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.PromotionCancelled, cancelled.Status)
+		p3, _ = price("p3")
+		assert.Equal(t, 300.0, p3)
+
+		_, err = service.CancelPromotion(ctx, flash.ID)
+		assert.ErrorIs(t, err, ErrPromotionFinished)
+		_, err = service.CancelPromotion(ctx, "missing")
+		assert.ErrorIs(t, err, ErrPromotionNotFound)
+	})
+}
+
+func TestHandler_Promotions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	helpers := NewTestHelpers(db)
+	category := helpers.CreateTestCategory("cat-promo", "Audio", "audio")
+	helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 100, 5)
+
+	handler := NewHandler(NewService(db))
+	router := gin.New()
+	router.GET("/api/promotions/active", handler.GetActivePromotions)
+	router.POST("/api/admin/promotions", handler.CreatePromotion)
+	router.POST("/api/admin/promotions/:id/cancel", handler.CancelPromotion)
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	ends := time.Now().Add(time.Hour).Format(time.RFC3339)
+	starts := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/api/admin/promotions",
+		`{"name":"Sale","discountType":"bogo","discountValue":10,"categoryIds":["cat-promo"],"startsAt":"`+starts+`","endsAt":"`+ends+`"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/api/admin/promotions",
+		`{"name":"Sale","discountType":"percentage","discountValue":10,"startsAt":"`+starts+`","endsAt":"`+ends+`"}`).Code)
+	assert.Equal(t, http.StatusCreated, request(http.MethodPost, "/api/admin/promotions",
+		`{"name":"Sale","discountType":"percentage","discountValue":10,"categoryIds":["cat-promo"],"startsAt":"`+starts+`","endsAt":"`+ends+`"}`).Code)
+
+	w := request(http.MethodGet, "/api/promotions/active", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"salePrice":90`)
+
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/api/admin/promotions/missing/cancel", "").Code)
+}
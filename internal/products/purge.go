@@ -0,0 +1,134 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProductNotDeleted = errors.New("product is not deleted")
+	ErrProductOrdered    = errors.New("product has been ordered")
+)
+
+// productOwnedTables are the rows that only describe a product and go with it when it is purged. Rows that
+// record orders, such as order items, serials and warranties, keep a product from being purged instead.
+var productOwnedTables = []interface{}{
+	&models.ProductTranslation{},
+	&models.PromotionProduct{},
+	&models.ProductLink{},
+	&models.ProductPrice{},
+	&models.ProductListing{},
+	&models.WishlistItem{},
+	&models.UserCartItem{},
+	&models.InventoryReservation{},
+}
+
+// findDeletedProduct loads a soft deleted product
+func (s *Service) findDeletedProduct(ctx context.Context, id string) (*models.Product, error) {
+	var product models.Product
+	if err := s.db.WithContext(ctx).Unscoped().Preload("Documents").Where("id = ?", id).First(&product).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+	if !product.DeletedAt.Valid {
+		return nil, ErrProductNotDeleted
+	}
+	return &product, nil
+}
+
+// RestoreProduct brings back a soft deleted product as it was when it was deleted
+func (s *Service) RestoreProduct(ctx context.Context, id string) (*models.Product, error) {
+	product, err := s.findDeletedProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(product).Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore product: %w", err)
+		}
+		product.DeletedAt = gorm.DeletedAt{}
+		if err := eventbus.PublishProduct(tx, product); err != nil {
+			return err
+		}
+		return s.queueChange(tx, product.ID, true)
+	}); err != nil {
+		return nil, err
+	}
+	s.invalidateCategoryIndex()
+
+	var restored models.Product
+	if err := s.db.WithContext(ctx).Preload("Category").First(&restored, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("failed to load restored product: %w", err)
+	}
+	s.syncSearch(&restored, false)
+	s.publishUpdate(ctx, &restored)
+	return &restored, nil
+}
+
+// PurgeProduct permanently deletes a soft deleted product with its prices, documents, images and the cart,
+// wishlist and reservation rows still pointing at it, and removes it from the search index. A product that
+// was ever ordered stays soft deleted, as its orders still refer to it.
+func (s *Service) PurgeProduct(ctx context.Context, id string) error {
+	product, err := s.findDeletedProduct(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var ordered int64
+	if err := s.db.WithContext(ctx).Model(&models.OrderItem{}).Where("product_id = ?", id).Count(&ordered).Error; err != nil {
+		return fmt.Errorf("failed to check orders: %w", err)
+	}
+	if ordered > 0 {
+		return ErrProductOrdered
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, table := range append([]interface{}{&models.ProductDocument{}}, productOwnedTables...) {
+			if err := tx.Where("product_id = ?", id).Delete(table).Error; err != nil {
+				return fmt.Errorf("failed to purge product data: %w", err)
+			}
+		}
+		if err := tx.Where("related_product_id = ?", id).Delete(&models.ProductLink{}).Error; err != nil {
+			return fmt.Errorf("failed to purge product data: %w", err)
+		}
+		if err := tx.Unscoped().Delete(product).Error; err != nil {
+			return fmt.Errorf("failed to purge product: %w", err)
+		}
+		return s.queueChange(tx, product.ID, false)
+	}); err != nil {
+		return err
+	}
+
+	for _, document := range product.Documents {
+		s.removeDocumentFile(document.StorageKey)
+	}
+	s.removeProductImages(product)
+	s.syncSearch(product, true)
+	logger.Named("products").Info("Product purged", map[string]interface{}{"productId": product.ID, "sku": product.SKU})
+	return nil
+}
+
+// removeProductImages deletes the files of the product's images kept in the media store. Images linked from
+// elsewhere are left alone.
+func (s *Service) removeProductImages(product *models.Product) {
+	if s.media == nil || s.images == nil {
+		return
+	}
+	prefix := s.media.URL("")
+	for _, url := range product.Images {
+		if key, ok := strings.CutPrefix(url, prefix); ok && strings.HasPrefix(key, "products/"+product.ID+"/") {
+			s.removeImageFiles(key)
+		}
+	}
+}
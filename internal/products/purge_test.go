@@ -0,0 +1,109 @@
+package products
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/media"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_RestoreProduct(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	service := NewService(db)
+	helpers := NewTestHelpers(db)
+	ctx := context.Background()
+
+	category := helpers.CreateTestCategory("cat-1", "Audio", "audio")
+	helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 100, 5)
+
+	_, err := service.RestoreProduct(ctx, "p1")
+	assert.ErrorIs(t, err, ErrProductNotDeleted)
+
+	require.NoError(t, service.DeleteProduct(ctx, "p1", false))
+	deleted := true
+	list, err := service.GetAllProductsAdmin(ctx, AdminProductFilters{Deleted: &deleted}, ProductSort{}, PaginationParams{})
+	require.NoError(t, err)
+	require.Len(t, list.Products, 1)
+
+	restored, err := service.RestoreProduct(ctx, "p1")
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+	assert.Equal(t, "Audio", restored.Category.Name)
+	_, err = service.GetProductByID(ctx, "p1")
+	require.NoError(t, err)
+
+	list, err = service.GetAllProductsAdmin(ctx, AdminProductFilters{Deleted: &deleted}, ProductSort{}, PaginationParams{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Products)
+
+	_, err = service.RestoreProduct(ctx, "missing")
+	assert.EqualError(t, err, "product not found")
+}
+
+func TestService_PurgeProduct(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	dir := t.TempDir()
+	store := media.NewLocalStore(dir, "https://cdn.example.com")
+	service := NewServiceWithMedia(db, store)
+	helpers := NewTestHelpers(db)
+	ctx := context.Background()
+
+	category := helpers.CreateTestCategory("cat-1", "Audio", "audio")
+	product := helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 100, 5)
+	imageKey := "products/p1/images/front"
+	require.NoError(t, store.Put(imageKey, strings.NewReader("image")))
+	document := models.ProductDocument{ProductID: "p1", Kind: "manual", Title: "Manual", FileName: "manual.pdf", ContentType: "application/pdf", StorageKey: "products/p1/documents/manual.pdf"}
+	require.NoError(t, store.Put(document.StorageKey, strings.NewReader("pdf")))
+	require.NoError(t, db.Create(&document).Error)
+	require.NoError(t, db.Model(product).Update("images", models.StringArray{store.URL(imageKey), "https://elsewhere.example.com/p1.jpg"}).Error)
+	userID := "user-1"
+	require.NoError(t, db.Create(&models.WishlistItem{UserID: &userID, ProductID: "p1"}).Error)
+	require.NoError(t, db.Create(&models.UserCartItem{UserID: "user-1", ProductID: "p1", Quantity: 1, Price: 100}).Error)
+
+	assert.ErrorIs(t, service.PurgeProduct(ctx, "p1"), ErrProductNotDeleted)
+
+	require.NoError(t, service.DeleteProduct(ctx, "p1", true))
+	require.NoError(t, service.PurgeProduct(ctx, "p1"))
+
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&models.Product{}).Where("id = ?", "p1").Count(&count).Error)
+	assert.Zero(t, count)
+	for _, table := range []interface{}{&models.ProductDocument{}, &models.WishlistItem{}, &models.UserCartItem{}} {
+		require.NoError(t, db.Model(table).Where("product_id = ?", "p1").Count(&count).Error)
+		assert.Zero(t, count)
+	}
+	for _, key := range []string{imageKey, document.StorageKey} {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key)))
+		assert.True(t, os.IsNotExist(err), "%s is removed", key)
+	}
+
+	assert.EqualError(t, service.PurgeProduct(ctx, "p1"), "product not found")
+}
+
+func TestService_PurgeProductOrdered(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	service := NewService(db)
+	helpers := NewTestHelpers(db)
+	ctx := context.Background()
+
+	category := helpers.CreateTestCategory("cat-1", "Audio", "audio")
+	helpers.CreateTestProduct("p1", "Headphones", "SKU-1", category.ID, 100, 5)
+	order := models.Order{UserID: "user-1", Status: "delivered", Total: 100}
+	require.NoError(t, db.Create(&order).Error)
+	require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: "p1", Quantity: 1, Price: 100, Total: 100}).Error)
+
+	require.NoError(t, service.DeleteProduct(ctx, "p1", true))
+	assert.ErrorIs(t, service.PurgeProduct(ctx, "p1"), ErrProductOrdered)
+}
@@ -0,0 +1,98 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+)
+
+// ProductInUseWarningHeader is set when a product that open orders or active carts reference is deactivated
+const ProductInUseWarningHeader = "X-Product-In-Use"
+
+// ErrProductInUse is returned when deleting a product that open orders or active carts still reference
+var ErrProductInUse = errors.New("product is referenced by open orders or active carts")
+
+// Kinds of references that keep a product from being deleted
+const (
+	ReferenceOrder = "order"
+	ReferenceCart  = "cart"
+)
+
+// openOrderStatuses are the order statuses that still need the product to be fulfilled
+var openOrderStatuses = []string{"pending", "paid", "processing"}
+
+// cartHolderType matches the holder type the inventory package uses for cart reservations
+const cartHolderType = "cart"
+
+// ProductReference is an open order or active cart that holds a product
+type ProductReference struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Status   string `json:"status,omitempty"`
+	Quantity int    `json:"quantity"`
+}
+
+// ProductInUseError lists the references blocking the deletion of a product
+type ProductInUseError struct {
+	ProductID  string             `json:"productId"`
+	References []ProductReference `json:"references"`
+}
+
+func (e *ProductInUseError) Error() string {
+	return fmt.Sprintf("%s: %d reference(s)", ErrProductInUse.Error(), len(e.References))
+}
+
+func (e *ProductInUseError) Unwrap() error {
+	return ErrProductInUse
+}
+
+// ProductReferences returns the open orders and active cart reservations that reference the product
+func (s *Service) ProductReferences(ctx context.Context, productID string, now time.Time) ([]ProductReference, error) {
+	var orderRefs []struct {
+		OrderID  string
+		Status   string
+		Quantity int
+	}
+	if err := s.db.WithContext(ctx).Table("order_items").
+		Select("order_items.order_id, orders.status, SUM(order_items.quantity) AS quantity").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("order_items.product_id = ? AND orders.status IN ?", productID, openOrderStatuses).
+		Group("order_items.order_id, orders.status").
+		Order("order_items.order_id").
+		Scan(&orderRefs).Error; err != nil {
+		return nil, fmt.Errorf("failed to check open orders: %w", err)
+	}
+
+	var holds []models.InventoryReservation
+	if err := s.db.WithContext(ctx).Where("product_id = ? AND holder_type = ? AND released_at IS NULL AND expires_at > ?",
+		productID, cartHolderType, now).
+		Order("created_at").
+		Find(&holds).Error; err != nil {
+		return nil, fmt.Errorf("failed to check active carts: %w", err)
+	}
+
+	references := make([]ProductReference, 0, len(orderRefs)+len(holds))
+	for _, ref := range orderRefs {
+		references = append(references, ProductReference{Type: ReferenceOrder, ID: ref.OrderID, Status: ref.Status, Quantity: ref.Quantity})
+	}
+	for _, hold := range holds {
+		references = append(references, ProductReference{Type: ReferenceCart, ID: hold.HolderID, Quantity: hold.Quantity})
+	}
+	return references, nil
+}
+
+// describeReferences summarises references for a warning, e.g. "2 open order(s) and 1 active cart(s)"
+func describeReferences(references []ProductReference) string {
+	orders, carts := 0, 0
+	for _, ref := range references {
+		if ref.Type == ReferenceOrder {
+			orders++
+		} else {
+			carts++
+		}
+	}
+	return fmt.Sprintf("%d open order(s) and %d active cart(s)", orders, carts)
+}
@@ -2,39 +2,90 @@ package products
 
 import (
 	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes configures product and category routes
-func SetupRoutes(router *gin.Engine, handler *Handler, authService *auth.Service) {
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
 	// Product routes
-	products := router.Group("/api/products")
+	products := api.Group("/products")
 	{
 		products.GET("", handler.GetProducts)
 		products.GET("/search", handler.SearchProducts)
 		products.GET("/advanced-search", handler.AdvancedSearchProducts)
 		products.GET("/suggestions", handler.GetSearchSuggestions)
+		products.GET("/suggest", handler.Suggest)
 		products.GET("/:id", handler.GetProductByID)
 	}
 
 	// Category routes
-	categories := router.Group("/api/categories")
+	categories := api.Group("/categories")
 	{
 		categories.GET("", handler.GetCategories)
+		categories.GET("/tree", handler.GetCategoryTree)
 		categories.GET("/:id", handler.GetCategoryByID)
+		categories.GET("/:id/spec-schema", handler.GetCategorySpecSchema)
 		categories.POST("", authService.AuthMiddleware(), authService.RequireAdminMiddleware(), handler.CreateCategory)
+		categories.PUT("/order", authService.AuthMiddleware(), authService.RequireAdminMiddleware(), handler.ReorderCategories)
+		categories.PUT("/:id", authService.AuthMiddleware(), authService.RequireAdminMiddleware(), handler.UpdateCategory)
+		categories.PUT("/:id/parent", authService.AuthMiddleware(), authService.RequireAdminMiddleware(), handler.MoveCategory)
+		categories.DELETE("/:id", authService.AuthMiddleware(), authService.RequireAdminMiddleware(), handler.DeleteCategory)
+		categories.PUT("/:id/policy", authService.AuthMiddleware(), authService.RequireAdminMiddleware(), handler.UpdateCategoryPolicy)
+		categories.PUT("/:id/attributes", authService.AuthMiddleware(), authService.RequireAdminMiddleware(), handler.UpdateCategoryAttributes)
+		categories.PUT("/:id/serial-tracking", authService.AuthMiddleware(), authService.RequireAdminMiddleware(), handler.UpdateCategorySerialTracking)
+		categories.PUT("/:id/warranty", authService.AuthMiddleware(), authService.RequireAdminMiddleware(), handler.UpdateCategoryWarranty)
 	}
 
+	// Storefront promotion routes
+	api.GET("/promotions/active", handler.GetActivePromotions)
+
 	// Admin product routes
-	adminProducts := router.Group("/api/admin/products")
+	adminProducts := api.Group("/admin/products")
 	adminProducts.Use(authService.AuthMiddleware())
 	adminProducts.Use(authService.AdminMiddleware())
 	{
 		adminProducts.GET("", handler.GetAllProductsAdmin)
+		adminProducts.GET("/categorize", handler.SuggestCategories)
 		adminProducts.POST("", handler.CreateProduct)
+		adminProducts.POST("/bulk", middleware.ReplayProtectionMiddleware(middleware.SensitiveReplayProtection), middleware.TimeoutMiddleware(middleware.LongRequestTimeout), handler.BulkUpdateProducts)
 		adminProducts.PUT("/:id", handler.UpdateProduct)
 		adminProducts.DELETE("/:id", handler.DeleteProduct)
+		adminProducts.POST("/:id/restore", handler.RestoreProduct)
+		adminProducts.DELETE("/:id/purge", middleware.ReplayProtectionMiddleware(middleware.SensitiveReplayProtection), handler.PurgeProduct)
 		adminProducts.PUT("/:id/inventory", handler.UpdateInventory)
+		adminProducts.GET("/:id/price-at", handler.GetPriceAt)
+		adminProducts.POST("/:id/documents", middleware.TimeoutMiddleware(middleware.LongRequestTimeout), handler.UploadProductDocument)
+		adminProducts.DELETE("/:id/documents/:documentId", handler.DeleteProductDocument)
+		adminProducts.GET("/:id/translations", handler.GetProductTranslations)
+		adminProducts.PUT("/:id/translations/:locale", handler.UpsertProductTranslation)
+		adminProducts.DELETE("/:id/translations/:locale", handler.DeleteProductTranslation)
+		adminProducts.POST("/:id/images", middleware.TimeoutMiddleware(middleware.LongRequestTimeout), handler.UploadProductImage)
+	}
+
+	// Admin promotion routes
+	adminPromotions := api.Group("/admin/promotions")
+	adminPromotions.Use(authService.AuthMiddleware())
+	adminPromotions.Use(authService.AdminMiddleware())
+	{
+		adminPromotions.GET("", handler.GetPromotions)
+		adminPromotions.POST("", handler.CreatePromotion)
+		adminPromotions.GET("/:id", handler.GetPromotion)
+		adminPromotions.POST("/:id/cancel", handler.CancelPromotion)
+		adminPromotions.POST("/:id/codes/generate", middleware.ReplayProtectionMiddleware(middleware.SensitiveReplayProtection), handler.GeneratePromotionCodes)
+		adminPromotions.GET("/:id/codes", handler.GetPromotionCodes)
+		adminPromotions.GET("/:id/codes/export", handler.ExportPromotionCodes)
+		adminPromotions.POST("/:id/codes/deactivate", middleware.ReplayProtectionMiddleware(middleware.SensitiveReplayProtection), handler.DeactivatePromotionCodes)
+	}
+
+	// Admin search index routes
+	adminSearch := api.Group("/admin/search")
+	adminSearch.Use(authService.AuthMiddleware())
+	adminSearch.Use(authService.AdminMiddleware())
+	{
+		adminSearch.POST("/reindex", handler.ReindexSearch)
+		adminSearch.GET("/reindex", handler.GetSearchReindexStatus)
+		adminSearch.GET("/status", handler.GetSearchStatus)
 	}
 }
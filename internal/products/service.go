@@ -1,29 +1,66 @@
 package products
 
 import (
+	"context"
 	"fmt"
-	"math"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"ecommerce-website/internal/eventbus"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/media"
 	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/outbox"
 	"ecommerce-website/internal/search"
+	"ecommerce-website/internal/settings"
+	"ecommerce-website/internal/webhooks"
+	"ecommerce-website/pkg/utils"
 
 	"gorm.io/gorm"
 )
 
 type Service struct {
 	db            *gorm.DB
+	reader        *gorm.DB // serves the storefront catalog reads; a read replica when configured
 	searchService *search.Service
+	settings      settings.ServiceInterface
+	categoryIndex categoryIndexCache
+	media         media.Store
+	images        *media.ImagePipeline
+	outbox        *outbox.Dispatcher // updates the search index in the background when set
+	listingsReady atomic.Bool        // the product list is served from product_listings once they are built
 }
 
 func NewService(db *gorm.DB) *Service {
-	searchService := search.NewService(db)
+	return newService(db, search.NewService(db))
+}
+
+// newService creates a products service searching with searchService
+func newService(db *gorm.DB, searchService *search.Service) *Service {
 	return &Service{
 		db:            db,
+		reader:        db,
 		searchService: searchService,
+		settings:      settings.NewService(db),
 	}
 }
 
+// NewServiceWithMedia creates a products service that keeps product documents and images in store
+func NewServiceWithMedia(db *gorm.DB, store media.Store) *Service {
+	service := NewService(db)
+	service.media = store
+	service.images = media.NewImagePipeline(store)
+	return service
+}
+
+// WithReader makes the service serve storefront catalog reads, which tolerate replication lag, from
+// reader. Admin reads and writes stay on the primary. It returns the service for chaining after a constructor.
+func (s *Service) WithReader(reader *gorm.DB) *Service {
+	s.reader = reader
+	return s
+}
+
 // ProductFilters represents filters for product queries
 type ProductFilters struct {
 	CategoryID *string
@@ -47,22 +84,27 @@ type PaginationParams struct {
 
 // ProductListResponse represents paginated product response
 type ProductListResponse struct {
-	Products    []models.Product `json:"products"`
-	Total       int64            `json:"total"`
-	Page        int              `json:"page"`
-	PageSize    int              `json:"pageSize"`
-	TotalPages  int              `json:"totalPages"`
-	HasNext     bool             `json:"hasNext"`
-	HasPrevious bool             `json:"hasPrevious"`
+	Products   []models.Product `json:"products"`
+	Pagination utils.Pagination `json:"pagination"`
 }
 
 // GetProducts retrieves products with filtering, sorting, and pagination
-func (s *Service) GetProducts(filters ProductFilters, sort ProductSort, pagination PaginationParams) (*ProductListResponse, error) {
+func (s *Service) GetProducts(ctx context.Context, filters ProductFilters, sort ProductSort, pagination PaginationParams) (*ProductListResponse, error) {
+	if pagination.PageSize <= 0 {
+		pagination.PageSize = 20 // default page size
+	}
+	if pagination.Page <= 0 {
+		pagination.Page = 1
+	}
+	if s.listingsReady.Load() {
+		return s.listProducts(ctx, filters, sort, pagination)
+	}
+
 	var products []models.Product
 	var total int64
 
 	// Build base query
-	query := s.db.Model(&models.Product{}).
+	query := s.reader.WithContext(ctx).Model(&models.Product{}).
 		Preload("Category").
 		Where("is_active = ?", true)
 
@@ -113,39 +155,23 @@ func (s *Service) GetProducts(filters ProductFilters, sort ProductSort, paginati
 	query = query.Order(orderClause)
 
 	// Apply pagination
-	if pagination.PageSize <= 0 {
-		pagination.PageSize = 20 // default page size
-	}
-	if pagination.Page <= 0 {
-		pagination.Page = 1
-	}
-
 	offset := (pagination.Page - 1) * pagination.PageSize
 	if err := query.Offset(offset).Limit(pagination.PageSize).Find(&products).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch products: %w", err)
 	}
 
-	// Calculate pagination info
-	totalPages := int(math.Ceil(float64(total) / float64(pagination.PageSize)))
-	hasNext := pagination.Page < totalPages
-	hasPrevious := pagination.Page > 1
-
 	return &ProductListResponse{
-		Products:    products,
-		Total:       total,
-		Page:        pagination.Page,
-		PageSize:    pagination.PageSize,
-		TotalPages:  totalPages,
-		HasNext:     hasNext,
-		HasPrevious: hasPrevious,
+		Products:   products,
+		Pagination: utils.NewPagination(pagination.Page, pagination.PageSize, total),
 	}, nil
 }
 
 // GetProductByID retrieves a single product by ID
-func (s *Service) GetProductByID(id string) (*models.Product, error) {
+func (s *Service) GetProductByID(ctx context.Context, id string) (*models.Product, error) {
 	var product models.Product
 
-	if err := s.db.Preload("Category").
+	if err := s.reader.WithContext(ctx).Preload("Category").
+		Preload("Documents", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order, created_at") }).
 		Where("id = ? AND is_active = ?", id, true).
 		First(&product).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -154,16 +180,17 @@ func (s *Service) GetProductByID(id string) (*models.Product, error) {
 		return nil, fmt.Errorf("failed to fetch product: %w", err)
 	}
 
+	s.fillDocumentURLs(&product)
 	return &product, nil
 }
 
 // SearchProducts performs text search on products
-func (s *Service) SearchProducts(query string, pagination PaginationParams) (*ProductListResponse, error) {
+func (s *Service) SearchProducts(ctx context.Context, query string, pagination PaginationParams) (*ProductListResponse, error) {
 	filters := ProductFilters{
 		Search: &query,
 	}
 
-	return s.GetProducts(filters, ProductSort{}, pagination)
+	return s.GetProducts(ctx, filters, ProductSort{}, pagination)
 }
 
 // AdvancedSearchProducts performs advanced search with Elasticsearch integration
@@ -192,10 +219,8 @@ func (s *Service) AdvancedSearchProducts(filters AdvancedSearchFilters, sort Adv
 	// Convert search.SearchResponse to AdvancedSearchResponse
 	advancedResponse := &AdvancedSearchResponse{
 		Products:   searchResponse.Products,
-		Total:      searchResponse.Total,
-		Page:       searchResponse.Page,
-		PageSize:   searchResponse.PageSize,
-		TotalPages: searchResponse.TotalPages,
+		Pagination: searchResponse.Pagination,
+		Degraded:   searchResponse.Degraded,
 	}
 
 	// Add suggestions if available
@@ -233,24 +258,34 @@ func (s *Service) AdvancedSearchProducts(filters AdvancedSearchFilters, sort Adv
 	return advancedResponse, nil
 }
 
+// Typeahead returns product, category and brand suggestions for a search box prefix
+func (s *Service) Typeahead(query string, size int) (*search.TypeaheadResponse, error) {
+	return s.searchService.Typeahead(query, size)
+}
+
+// SearchDegraded reports whether search is served by the database fallback instead of Elasticsearch
+func (s *Service) SearchDegraded() bool {
+	return s.searchService.Degraded()
+}
+
 // GetSearchSuggestions returns search suggestions
 func (s *Service) GetSearchSuggestions(query string, size int) ([]string, error) {
 	return s.searchService.GetSuggestions(query, size)
 }
 
 // buildFacets builds facets for advanced search
-func (s *Service) buildFacets(filters ProductFilters) (*SearchFacets, error) {
+func (s *Service) buildFacets(ctx context.Context, filters ProductFilters) (*SearchFacets, error) {
 	facets := &SearchFacets{}
 
 	// Build category facets
-	categoryFacets, err := s.buildCategoryFacets(filters)
+	categoryFacets, err := s.buildCategoryFacets(ctx, filters)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build category facets: %w", err)
 	}
 	facets.Categories = categoryFacets
 
 	// Build price range facets
-	priceRangeFacets, err := s.buildPriceRangeFacets(filters)
+	priceRangeFacets, err := s.buildPriceRangeFacets(ctx, filters)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build price range facets: %w", err)
 	}
@@ -260,7 +295,7 @@ func (s *Service) buildFacets(filters ProductFilters) (*SearchFacets, error) {
 }
 
 // buildCategoryFacets builds category facets
-func (s *Service) buildCategoryFacets(filters ProductFilters) ([]CategoryFacet, error) {
+func (s *Service) buildCategoryFacets(ctx context.Context, filters ProductFilters) ([]CategoryFacet, error) {
 	type CategoryCount struct {
 		CategoryID   string `json:"category_id"`
 		CategoryName string `json:"category_name"`
@@ -269,7 +304,7 @@ func (s *Service) buildCategoryFacets(filters ProductFilters) ([]CategoryFacet,
 
 	var categoryCounts []CategoryCount
 
-	query := s.db.Table("products").
+	query := s.reader.WithContext(ctx).Table("products").
 		Select("products.category_id, categories.name as category_name, COUNT(*) as count").
 		Joins("LEFT JOIN categories ON products.category_id = categories.id").
 		Where("products.is_active = ? AND categories.is_active = ?", true, true).
@@ -310,7 +345,7 @@ func (s *Service) buildCategoryFacets(filters ProductFilters) ([]CategoryFacet,
 }
 
 // buildPriceRangeFacets builds price range facets
-func (s *Service) buildPriceRangeFacets(filters ProductFilters) ([]PriceRangeFacet, error) {
+func (s *Service) buildPriceRangeFacets(ctx context.Context, filters ProductFilters) ([]PriceRangeFacet, error) {
 	priceRanges := []struct {
 		Range string
 		Min   float64
@@ -326,7 +361,7 @@ func (s *Service) buildPriceRangeFacets(filters ProductFilters) ([]PriceRangeFac
 	var facets []PriceRangeFacet
 
 	for _, pr := range priceRanges {
-		query := s.db.Model(&models.Product{}).
+		query := s.reader.WithContext(ctx).Model(&models.Product{}).
 			Where("is_active = ?", true)
 
 		// Apply filters (excluding price filter for facets)
@@ -371,10 +406,10 @@ func (s *Service) buildPriceRangeFacets(filters ProductFilters) ([]PriceRangeFac
 }
 
 // GetCategories retrieves all active categories
-func (s *Service) GetCategories() ([]models.Category, error) {
+func (s *Service) GetCategories(ctx context.Context) ([]models.Category, error) {
 	var categories []models.Category
 
-	if err := s.db.Where("is_active = ?", true).
+	if err := s.reader.WithContext(ctx).Where("is_active = ?", true).
 		Order("sort_order ASC, name ASC").
 		Find(&categories).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch categories: %w", err)
@@ -384,10 +419,10 @@ func (s *Service) GetCategories() ([]models.Category, error) {
 }
 
 // GetCategoryByID retrieves a single category by ID
-func (s *Service) GetCategoryByID(id string) (*models.Category, error) {
+func (s *Service) GetCategoryByID(ctx context.Context, id string) (*models.Category, error) {
 	var category models.Category
 
-	if err := s.db.Where("id = ? AND is_active = ?", id, true).
+	if err := s.reader.WithContext(ctx).Where("id = ? AND is_active = ?", id, true).
 		First(&category).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("category not found")
@@ -399,10 +434,10 @@ func (s *Service) GetCategoryByID(id string) (*models.Category, error) {
 }
 
 // CreateCategory creates a new category
-func (s *Service) CreateCategory(req CreateCategoryRequest) (*models.Category, error) {
+func (s *Service) CreateCategory(ctx context.Context, req CreateCategoryRequest) (*models.Category, error) {
 	// Check if slug already exists
 	var existingCategory models.Category
-	if err := s.db.Where("slug = ?", req.Slug).First(&existingCategory).Error; err == nil {
+	if err := s.db.WithContext(ctx).Where("slug = ?", req.Slug).First(&existingCategory).Error; err == nil {
 		return nil, fmt.Errorf("category with slug already exists")
 	} else if err != gorm.ErrRecordNotFound {
 		return nil, fmt.Errorf("failed to check slug uniqueness: %w", err)
@@ -411,7 +446,7 @@ func (s *Service) CreateCategory(req CreateCategoryRequest) (*models.Category, e
 	// Check if parent category exists (if provided)
 	if req.ParentID != nil {
 		var parentCategory models.Category
-		if err := s.db.Where("id = ? AND is_active = ?", *req.ParentID, true).First(&parentCategory).Error; err != nil {
+		if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", *req.ParentID, true).First(&parentCategory).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return nil, fmt.Errorf("parent category not found")
 			}
@@ -430,23 +465,44 @@ func (s *Service) CreateCategory(req CreateCategoryRequest) (*models.Category, e
 		sortOrder = *req.SortOrder
 	}
 
+	policy := models.DefaultReturnPolicy()
+	if req.ReturnPolicy != nil {
+		policy = req.ReturnPolicy.apply(policy)
+	}
+
+	attributes, err := attributeSchema(req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create category
 	category := models.Category{
-		Name:        req.Name,
-		Slug:        req.Slug,
-		Description: req.Description,
-		ParentID:    req.ParentID,
-		IsActive:    isActive,
-		SortOrder:   sortOrder,
+		Name:           req.Name,
+		Slug:           req.Slug,
+		Description:    req.Description,
+		ParentID:       req.ParentID,
+		IsActive:       isActive,
+		SortOrder:      sortOrder,
+		ReturnPolicy:   policy,
+		Attributes:     attributes,
+		SerialTracked:  req.SerialTracked,
+		WarrantyMonths: req.WarrantyMonths,
 	}
 
-	if err := s.db.Create(&category).Error; err != nil {
+	if err := s.db.WithContext(ctx).Create(&category).Error; err != nil {
 		return nil, fmt.Errorf("failed to create category: %w", err)
 	}
 
+	// Zero values are skipped on insert in favour of column defaults, so persist the policy explicitly
+	if req.ReturnPolicy != nil {
+		if err := s.saveReturnPolicy(ctx, category.ID, policy); err != nil {
+			return nil, err
+		}
+	}
+
 	// Load the parent relationship if exists
 	if req.ParentID != nil {
-		if err := s.db.Preload("Parent").First(&category, "id = ?", category.ID).Error; err != nil {
+		if err := s.db.WithContext(ctx).Preload("Parent").First(&category, "id = ?", category.ID).Error; err != nil {
 			return nil, fmt.Errorf("failed to load created category: %w", err)
 		}
 	}
@@ -454,22 +510,114 @@ func (s *Service) CreateCategory(req CreateCategoryRequest) (*models.Category, e
 	return &category, nil
 }
 
+// UpdateCategoryPolicy updates the return and cancellation policy of a category
+func (s *Service) UpdateCategoryPolicy(ctx context.Context, id string, req CategoryPolicyRequest) (*models.Category, error) {
+	var category models.Category
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("category not found")
+		}
+		return nil, fmt.Errorf("failed to fetch category: %w", err)
+	}
+
+	category.ReturnPolicy = req.apply(category.ReturnPolicy)
+	if err := s.saveReturnPolicy(ctx, category.ID, category.ReturnPolicy); err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// UpdateCategoryAttributes replaces the attribute schema of a category
+func (s *Service) UpdateCategoryAttributes(ctx context.Context, id string, req UpdateCategoryAttributesRequest) (*models.Category, error) {
+	attributes, err := attributeSchema(req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var category models.Category
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("category not found")
+		}
+		return nil, fmt.Errorf("failed to fetch category: %w", err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&category).Update("attributes", attributes).Error; err != nil {
+		return nil, fmt.Errorf("failed to save category attributes: %w", err)
+	}
+	category.Attributes = attributes
+
+	return &category, nil
+}
+
+// UpdateCategorySerialTracking turns serial number capture on or off for a category; serials already recorded are kept
+func (s *Service) UpdateCategorySerialTracking(ctx context.Context, id string, tracked bool) (*models.Category, error) {
+	var category models.Category
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("category not found")
+		}
+		return nil, fmt.Errorf("failed to fetch category: %w", err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&category).Update("serial_tracked", tracked).Error; err != nil {
+		return nil, fmt.Errorf("failed to save category serial tracking: %w", err)
+	}
+	category.SerialTracked = tracked
+
+	return &category, nil
+}
+
+// UpdateCategoryWarranty sets the warranty period of a category; warranties already registered keep their expiry
+func (s *Service) UpdateCategoryWarranty(ctx context.Context, id string, months int) (*models.Category, error) {
+	var category models.Category
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("category not found")
+		}
+		return nil, fmt.Errorf("failed to fetch category: %w", err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&category).Update("warranty_months", months).Error; err != nil {
+		return nil, fmt.Errorf("failed to save category warranty: %w", err)
+	}
+	category.WarrantyMonths = months
+
+	return &category, nil
+}
+
+// saveReturnPolicy writes every policy column, including zero values
+func (s *Service) saveReturnPolicy(ctx context.Context, categoryID string, policy models.ReturnPolicy) error {
+	if err := s.db.WithContext(ctx).Model(&models.Category{}).Where("id = ?", categoryID).Updates(map[string]interface{}{
+		"policy_returnable":                policy.Returnable,
+		"policy_return_window_days":        policy.ReturnWindowDays,
+		"policy_cancellation_cutoff_hours": policy.CancellationCutoffHours,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to save category policy: %w", err)
+	}
+	return nil
+}
+
 // Admin Product Management Methods
 
 // CreateProduct creates a new product
-func (s *Service) CreateProduct(req CreateProductRequest) (*models.Product, error) {
+func (s *Service) CreateProduct(ctx context.Context, req CreateProductRequest) (*models.Product, error) {
 	// Check if category exists
 	var category models.Category
-	if err := s.db.Where("id = ? AND is_active = ?", req.CategoryID, true).First(&category).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", req.CategoryID, true).First(&category).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("category not found")
 		}
 		return nil, fmt.Errorf("failed to verify category: %w", err)
 	}
+	if err := validateSpecifications(category.Attributes, req.Specifications); err != nil {
+		return nil, err
+	}
 
 	// Check if SKU already exists
 	var existingProduct models.Product
-	if err := s.db.Where("sku = ?", req.SKU).First(&existingProduct).Error; err == nil {
+	if err := s.db.WithContext(ctx).Where("sku = ?", req.SKU).First(&existingProduct).Error; err == nil {
 		return nil, fmt.Errorf("sku already exists")
 	} else if err != gorm.ErrRecordNotFound {
 		return nil, fmt.Errorf("failed to check SKU uniqueness: %w", err)
@@ -483,43 +631,56 @@ func (s *Service) CreateProduct(req CreateProductRequest) (*models.Product, erro
 
 	// Create product
 	product := models.Product{
-		Name:           req.Name,
-		Description:    req.Description,
-		Price:          req.Price,
-		CompareAtPrice: req.CompareAtPrice,
-		SKU:            req.SKU,
-		Inventory:      req.Inventory,
-		CategoryID:     req.CategoryID,
-		Images:         models.StringArray(req.Images),
-		Specifications: models.JSONB(req.Specifications),
-		SEOTitle:       req.SEOTitle,
-		SEODescription: req.SEODescription,
-		IsActive:       isActive,
-	}
-
-	if err := s.db.Create(&product).Error; err != nil {
-		return nil, fmt.Errorf("failed to create product: %w", err)
+		Name:              req.Name,
+		Description:       req.Description,
+		Price:             req.Price,
+		CompareAtPrice:    req.CompareAtPrice,
+		SKU:               req.SKU,
+		Inventory:         req.Inventory,
+		LowStockThreshold: req.LowStockThreshold,
+		CategoryID:        req.CategoryID,
+		Images:            models.StringArray(req.Images),
+		Tags:              normalizeTags(req.Tags),
+		Specifications:    models.JSONB(req.Specifications),
+		SEOTitle:          req.SEOTitle,
+		SEODescription:    req.SEODescription,
+		WeightGrams:       req.WeightGrams,
+		IsActive:          isActive,
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return fmt.Errorf("failed to create product: %w", err)
+		}
+		if err := recordPrice(tx, &product, product.CreatedAt); err != nil {
+			return err
+		}
+		if err := s.queueChange(tx, product.ID, false); err != nil {
+			return err
+		}
+		return eventbus.PublishProduct(tx, &product)
+	}); err != nil {
+		return nil, err
 	}
+	s.invalidateCategoryIndex()
 
 	// Load the category relationship
-	if err := s.db.Preload("Category").First(&product, "id = ?", product.ID).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Category").First(&product, "id = ?", product.ID).Error; err != nil {
 		return nil, fmt.Errorf("failed to load created product: %w", err)
 	}
 
 	// Index the product in search service
-	if err := s.searchService.IndexProduct(&product); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Warning: Failed to index product in search: %v\n", err)
-	}
+	s.syncSearch(&product, false)
 
 	return &product, nil
 }
 
-// UpdateProduct updates an existing product
-func (s *Service) UpdateProduct(id string, req UpdateProductRequest) (*models.Product, error) {
+// UpdateProduct updates an existing product. With req.Version the update is rejected with a
+// *VersionConflictError when the product has changed since that version.
+func (s *Service) UpdateProduct(ctx context.Context, id string, req UpdateProductRequest) (*models.Product, error) {
 	// Find the product (including soft deleted ones for admin)
 	var product models.Product
-	if err := s.db.Unscoped().Where("id = ?", id).First(&product).Error; err != nil {
+	if err := s.db.WithContext(ctx).Unscoped().Where("id = ?", id).First(&product).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("product not found")
 		}
@@ -529,7 +690,7 @@ func (s *Service) UpdateProduct(id string, req UpdateProductRequest) (*models.Pr
 	// Check if category exists (if being updated)
 	if req.CategoryID != nil {
 		var category models.Category
-		if err := s.db.Where("id = ? AND is_active = ?", *req.CategoryID, true).First(&category).Error; err != nil {
+		if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", *req.CategoryID, true).First(&category).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return nil, fmt.Errorf("category not found")
 			}
@@ -537,10 +698,17 @@ func (s *Service) UpdateProduct(id string, req UpdateProductRequest) (*models.Pr
 		}
 	}
 
+	// Validate the specifications the product ends up with against the schema of its (new) category
+	if req.Specifications != nil || req.CategoryID != nil {
+		if err := s.validateProductSpecifications(ctx, product, req); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check SKU uniqueness (if being updated)
 	if req.SKU != nil && *req.SKU != product.SKU {
 		var existingProduct models.Product
-		if err := s.db.Where("sku = ? AND id != ?", *req.SKU, id).First(&existingProduct).Error; err == nil {
+		if err := s.db.WithContext(ctx).Where("sku = ? AND id != ?", *req.SKU, id).First(&existingProduct).Error; err == nil {
 			return nil, fmt.Errorf("sku already exists")
 		} else if err != gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("failed to check SKU uniqueness: %w", err)
@@ -568,12 +736,18 @@ func (s *Service) UpdateProduct(id string, req UpdateProductRequest) (*models.Pr
 	if req.Inventory != nil {
 		updates["inventory"] = *req.Inventory
 	}
+	if req.LowStockThreshold != nil {
+		updates["low_stock_threshold"] = *req.LowStockThreshold
+	}
 	if req.CategoryID != nil {
 		updates["category_id"] = *req.CategoryID
 	}
 	if req.Images != nil {
 		updates["images"] = models.StringArray(req.Images)
 	}
+	if req.Tags != nil {
+		updates["tags"] = normalizeTags(req.Tags)
+	}
 	if req.Specifications != nil {
 		updates["specifications"] = models.JSONB(req.Specifications)
 	}
@@ -586,56 +760,121 @@ func (s *Service) UpdateProduct(id string, req UpdateProductRequest) (*models.Pr
 	if req.IsActive != nil {
 		updates["is_active"] = *req.IsActive
 	}
+	if req.WeightGrams != nil {
+		updates["weight_grams"] = *req.WeightGrams
+	}
 
-	// Perform update
-	if err := s.db.Model(&product).Updates(updates).Error; err != nil {
-		return nil, fmt.Errorf("failed to update product: %w", err)
+	// Perform update, keeping the price history when the price changes
+	repriced := priceChanged(&product, req)
+	previousInventory := product.Inventory
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if repriced {
+			if err := ensurePriceBaseline(tx, &product); err != nil {
+				return err
+			}
+		}
+		if err := updateVersioned(tx, &product, req.Version, updates); err != nil {
+			return err
+		}
+		if req.Inventory != nil {
+			if err := eventbus.PublishInventoryChanged(tx, product.ID, *req.Inventory-previousInventory, eventbus.InventoryAdjusted); err != nil {
+				return err
+			}
+		}
+		if repriced {
+			if req.Price != nil {
+				product.Price = *req.Price
+			}
+			if req.CompareAtPrice != nil {
+				product.CompareAtPrice = req.CompareAtPrice
+			}
+			if err := recordPrice(tx, &product, time.Now()); err != nil {
+				return err
+			}
+		}
+		return s.queueChange(tx, product.ID, true)
+	}); err != nil {
+		return nil, err
 	}
+	s.invalidateCategoryIndex()
 
 	// Load updated product with category
-	if err := s.db.Preload("Category").First(&product, "id = ?", id).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Category").First(&product, "id = ?", id).Error; err != nil {
 		return nil, fmt.Errorf("failed to load updated product: %w", err)
 	}
 
 	// Re-index the product in search service
-	if err := s.searchService.IndexProduct(&product); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Warning: Failed to re-index product in search: %v\n", err)
-	}
+	s.syncSearch(&product, false)
+	s.publishUpdate(ctx, &product)
 
 	return &product, nil
 }
 
-// DeleteProduct soft deletes a product
-func (s *Service) DeleteProduct(id string) error {
+// publishUpdate sends the product.updated webhook and bus event for a changed product. With an outbox they are
+// already recorded with the change; otherwise failures are logged and never fail the change.
+func (s *Service) publishUpdate(ctx context.Context, product *models.Product) {
+	if s.outbox != nil {
+		return
+	}
+	if err := webhooks.PublishProduct(s.db.WithContext(ctx), product); err != nil {
+		logger.Named("products").Warn("Failed to queue product webhooks", map[string]interface{}{"productId": product.ID, "error": err.Error()})
+	}
+	if err := eventbus.PublishProduct(s.db.WithContext(ctx), product); err != nil {
+		logger.Named("products").Warn("Failed to record product bus event", map[string]interface{}{"productId": product.ID, "error": err.Error()})
+	}
+}
+
+// DeleteProduct soft deletes a product. A product still referenced by open orders or active carts is
+// only deleted when force is set; otherwise a *ProductInUseError listing the references is returned.
+func (s *Service) DeleteProduct(ctx context.Context, id string, force bool) error {
 	// Find the product
 	var product models.Product
-	if err := s.db.Where("id = ?", id).First(&product).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&product).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("product not found")
 		}
 		return fmt.Errorf("failed to find product: %w", err)
 	}
 
+	references, err := s.ProductReferences(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(references) > 0 {
+		if !force {
+			return &ProductInUseError{ProductID: id, References: references}
+		}
+		logger.Named("products").Warn("Deleting product still in use", map[string]interface{}{
+			"productId": id, "references": describeReferences(references),
+		})
+	}
+
 	// Soft delete the product
-	if err := s.db.Delete(&product).Error; err != nil {
-		return fmt.Errorf("failed to delete product: %w", err)
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&product).Error; err != nil {
+			return fmt.Errorf("failed to delete product: %w", err)
+		}
+		if err := s.queueChange(tx, product.ID, false); err != nil {
+			return err
+		}
+		return eventbus.PublishProductDeleted(tx, &product)
+	}); err != nil {
+		return err
 	}
+	s.invalidateCategoryIndex()
 
 	// Remove the product from search index
-	if err := s.searchService.DeleteProduct(id); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Warning: Failed to delete product from search index: %v\n", err)
-	}
+	s.syncSearch(&product, true)
 
 	return nil
 }
 
-// UpdateInventory updates the inventory level of a product
-func (s *Service) UpdateInventory(id string, inventory int) (*models.Product, error) {
+// UpdateInventory updates the inventory level of a product. With a version the update is rejected with a
+// *VersionConflictError when the product has changed since, such as by orders taking stock.
+func (s *Service) UpdateInventory(ctx context.Context, id string, inventory int, version *int64) (*models.Product, error) {
 	// Find the product
 	var product models.Product
-	if err := s.db.Where("id = ?", id).First(&product).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&product).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("product not found")
 		}
@@ -643,57 +882,55 @@ func (s *Service) UpdateInventory(id string, inventory int) (*models.Product, er
 	}
 
 	// Update inventory
-	if err := s.db.Model(&product).Update("inventory", inventory).Error; err != nil {
-		return nil, fmt.Errorf("failed to update inventory: %w", err)
+	delta := inventory - product.Inventory
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, &product, version, map[string]interface{}{"inventory": inventory}); err != nil {
+			return err
+		}
+		if err := eventbus.PublishInventoryChanged(tx, product.ID, delta, eventbus.InventoryAdjusted); err != nil {
+			return err
+		}
+		return s.queueChange(tx, product.ID, true)
+	}); err != nil {
+		return nil, err
 	}
 
 	// Load updated product with category
-	if err := s.db.Preload("Category").First(&product, "id = ?", id).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Category").First(&product, "id = ?", id).Error; err != nil {
 		return nil, fmt.Errorf("failed to load updated product: %w", err)
 	}
 
 	// Re-index the product in search service
-	if err := s.searchService.IndexProduct(&product); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Warning: Failed to re-index product in search: %v\n", err)
-	}
+	s.syncSearch(&product, false)
+	s.publishUpdate(ctx, &product)
 
 	return &product, nil
 }
 
+// StartSearchReindex starts bulk-indexing the whole catalog into the search index in the background
+func (s *Service) StartSearchReindex(batchSize int) (*search.ReindexProgress, error) {
+	return s.searchService.StartReindex(batchSize)
+}
+
+// SearchReindexStatus returns the progress of the running or most recent search reindex, or nil if none has run
+func (s *Service) SearchReindexStatus() *search.ReindexProgress {
+	return s.searchService.ReindexStatus()
+}
+
+// SearchBreakerStatus returns the state of the Elasticsearch circuit breaker
+func (s *Service) SearchBreakerStatus() search.BreakerStatus {
+	return s.searchService.BreakerStatus()
+}
+
 // GetAllProductsAdmin retrieves all products including inactive ones for admin
-func (s *Service) GetAllProductsAdmin(filters AdminProductFilters, sort ProductSort, pagination PaginationParams) (*AdminProductListResponse, error) {
+func (s *Service) GetAllProductsAdmin(ctx context.Context, filters AdminProductFilters, sort ProductSort, pagination PaginationParams) (*AdminProductListResponse, error) {
 	var products []models.Product
 	var total int64
 
 	// Build base query (include soft deleted products)
-	query := s.db.Unscoped().Model(&models.Product{}).Preload("Category")
-
-	// Apply filters
-	if filters.CategoryID != nil {
-		query = query.Where("category_id = ?", *filters.CategoryID)
-	}
-
-	if filters.MinPrice != nil {
-		query = query.Where("price >= ?", *filters.MinPrice)
-	}
-
-	if filters.MaxPrice != nil {
-		query = query.Where("price <= ?", *filters.MaxPrice)
-	}
-
-	if filters.InStock != nil && *filters.InStock {
-		query = query.Where("inventory > 0")
-	}
+	query := s.db.WithContext(ctx).Unscoped().Model(&models.Product{}).Preload("Category")
 
-	if filters.IsActive != nil {
-		query = query.Where("is_active = ?", *filters.IsActive)
-	}
-
-	if filters.Search != nil && *filters.Search != "" {
-		searchTerm := "%" + strings.ToLower(*filters.Search) + "%"
-		query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ? OR LOWER(sku) LIKE ?", searchTerm, searchTerm, searchTerm)
-	}
+	query = applyAdminProductFilters(query, filters)
 
 	// Count total records
 	if err := query.Count(&total).Error; err != nil {
@@ -734,18 +971,43 @@ func (s *Service) GetAllProductsAdmin(filters AdminProductFilters, sort ProductS
 		return nil, fmt.Errorf("failed to fetch products: %w", err)
 	}
 
-	// Calculate pagination info
-	totalPages := int(math.Ceil(float64(total) / float64(pagination.PageSize)))
-	hasNext := pagination.Page < totalPages
-	hasPrevious := pagination.Page > 1
-
 	return &AdminProductListResponse{
-		Products:    products,
-		Total:       total,
-		Page:        pagination.Page,
-		PageSize:    pagination.PageSize,
-		TotalPages:  totalPages,
-		HasNext:     hasNext,
-		HasPrevious: hasPrevious,
+		Products:   products,
+		Pagination: utils.NewPagination(pagination.Page, pagination.PageSize, total),
 	}, nil
 }
+
+// applyAdminProductFilters narrows an admin product query to the products matching filters
+func applyAdminProductFilters(query *gorm.DB, filters AdminProductFilters) *gorm.DB {
+	if filters.CategoryID != nil {
+		query = query.Where("category_id = ?", *filters.CategoryID)
+	}
+
+	if filters.MinPrice != nil {
+		query = query.Where("price >= ?", *filters.MinPrice)
+	}
+
+	if filters.MaxPrice != nil {
+		query = query.Where("price <= ?", *filters.MaxPrice)
+	}
+
+	if filters.InStock != nil && *filters.InStock {
+		query = query.Where("inventory > 0")
+	}
+
+	if filters.IsActive != nil {
+		query = query.Where("is_active = ?", *filters.IsActive)
+	}
+
+	if filters.Deleted != nil && *filters.Deleted {
+		query = query.Where("deleted_at IS NOT NULL")
+	} else if filters.Deleted != nil {
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	if filters.Search != nil && *filters.Search != "" {
+		searchTerm := "%" + strings.ToLower(*filters.Search) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ? OR LOWER(sku) LIKE ?", searchTerm, searchTerm, searchTerm)
+	}
+	return query
+}
@@ -0,0 +1,252 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"ecommerce-website/internal/models"
+)
+
+// ErrInvalidSpecifications is returned when product specifications do not match the category's attribute schema
+var ErrInvalidSpecifications = errors.New("invalid specifications")
+
+// Filter widgets the storefront renders for an attribute
+const (
+	SpecWidgetRange    = "range"    // slider between the observed min and max
+	SpecWidgetCheckbox = "checkbox" // one checkbox per value
+	SpecWidgetToggle   = "toggle"   // yes/no switch
+)
+
+// maxTextSpecValues caps the values listed for a free text attribute
+const maxTextSpecValues = 50
+
+// SpecSchema is the attribute schema of a category with the values its active products actually carry
+type SpecSchema struct {
+	CategoryID   string          `json:"categoryId"`
+	ProductCount int             `json:"productCount"`
+	Attributes   []SpecAttribute `json:"attributes"`
+}
+
+// SpecAttribute is one attribute of a SpecSchema. Numeric attributes report their observed range, the others
+// how many products carry each value; ProductCount is the number of products with a usable value.
+type SpecAttribute struct {
+	Key          string           `json:"key"`
+	Label        string           `json:"label"`
+	Unit         string           `json:"unit,omitempty"`
+	Type         string           `json:"type"`
+	Widget       string           `json:"widget"`
+	ProductCount int              `json:"productCount"`
+	Min          *float64         `json:"min,omitempty"`
+	Max          *float64         `json:"max,omitempty"`
+	Values       []SpecValueCount `json:"values,omitempty"`
+}
+
+// SpecValueCount is the number of products carrying one value of an attribute
+type SpecValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// validateSpecifications checks the specification values of the keys in schema against their types.
+// Keys outside the schema and null values are allowed, as not every product fills in every attribute.
+func validateSpecifications(schema models.AttributeSchema, specs map[string]interface{}) error {
+	for _, attribute := range schema {
+		value, ok := specs[attribute.Key]
+		if !ok || value == nil {
+			continue
+		}
+
+		switch attribute.ValueType() {
+		case models.AttributeTypeNumber:
+			if _, isText := value.(string); isText {
+				return fmt.Errorf("%w: %q must be a number, not text", ErrInvalidSpecifications, attribute.Key)
+			}
+			if _, ok := specNumber(value); !ok {
+				return fmt.Errorf("%w: %q must be a number", ErrInvalidSpecifications, attribute.Key)
+			}
+		case models.AttributeTypeBoolean:
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("%w: %q must be true or false", ErrInvalidSpecifications, attribute.Key)
+			}
+		case models.AttributeTypeEnum:
+			text, ok := value.(string)
+			if !ok || !containsString(attribute.Options, text) {
+				return fmt.Errorf("%w: %q must be one of %v", ErrInvalidSpecifications, attribute.Key, attribute.Options)
+			}
+		default:
+			if _, ok := specText(value); !ok {
+				return fmt.Errorf("%w: %q must be text", ErrInvalidSpecifications, attribute.Key)
+			}
+		}
+	}
+	return nil
+}
+
+// validateProductSpecifications validates the specifications product has after applying req against the
+// schema of the category it has after req
+func (s *Service) validateProductSpecifications(ctx context.Context, product models.Product, req UpdateProductRequest) error {
+	categoryID := product.CategoryID
+	if req.CategoryID != nil {
+		categoryID = *req.CategoryID
+	}
+	specs := map[string]interface{}(product.Specifications)
+	if req.Specifications != nil {
+		specs = req.Specifications
+	}
+
+	var category models.Category
+	if err := s.db.WithContext(ctx).Unscoped().Select("id", "attributes").Where("id = ?", categoryID).First(&category).Error; err != nil {
+		return fmt.Errorf("failed to load category schema: %w", err)
+	}
+	return validateSpecifications(category.Attributes, specs)
+}
+
+// specNumber returns value as a number. Numeric strings are read too, as products saved before the schema was
+// typed may carry them; validateSpecifications rejects them for new values.
+func specNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil && !math.IsNaN(number) && !math.IsInf(number, 0)
+	}
+	return 0, false
+}
+
+// specText returns a scalar value as text
+func specText(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CategorySpecSchema returns the attribute schema of an active category with the value distribution over its
+// active products, so the storefront can build filter widgets from it
+func (s *Service) CategorySpecSchema(ctx context.Context, categoryID string) (*SpecSchema, error) {
+	category, err := s.GetCategoryByID(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []models.JSONB
+	if err := s.reader.WithContext(ctx).Model(&models.Product{}).
+		Where("category_id = ? AND is_active = ?", category.ID, true).
+		Pluck("specifications", &specs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch product specifications: %w", err)
+	}
+
+	schema := &SpecSchema{
+		CategoryID:   category.ID,
+		ProductCount: len(specs),
+		Attributes:   make([]SpecAttribute, 0, len(category.Attributes)),
+	}
+	for _, attribute := range category.Attributes {
+		schema.Attributes = append(schema.Attributes, specAttribute(attribute, specs))
+	}
+	return schema, nil
+}
+
+// specAttribute summarizes the values products carry for one attribute. Values that do not match the
+// attribute's type are left out.
+func specAttribute(attribute models.CategoryAttribute, specs []models.JSONB) SpecAttribute {
+	result := SpecAttribute{
+		Key:   attribute.Key,
+		Label: attribute.Label,
+		Unit:  attribute.Unit,
+		Type:  attribute.ValueType(),
+	}
+
+	counts := make(map[string]int)
+	for _, spec := range specs {
+		value, ok := spec[attribute.Key]
+		if !ok || value == nil {
+			continue
+		}
+
+		switch result.Type {
+		case models.AttributeTypeNumber:
+			number, ok := specNumber(value)
+			if !ok {
+				continue
+			}
+			if result.Min == nil || number < *result.Min {
+				result.Min = &number
+			}
+			if result.Max == nil || number > *result.Max {
+				result.Max = &number
+			}
+		case models.AttributeTypeBoolean:
+			flag, ok := value.(bool)
+			if !ok {
+				continue
+			}
+			counts[strconv.FormatBool(flag)]++
+		case models.AttributeTypeEnum:
+			text, ok := value.(string)
+			if !ok || !containsString(attribute.Options, text) {
+				continue
+			}
+			counts[text]++
+		default:
+			text, ok := specText(value)
+			if !ok || text == "" {
+				continue
+			}
+			counts[text]++
+		}
+		result.ProductCount++
+	}
+
+	switch result.Type {
+	case models.AttributeTypeNumber:
+		result.Widget = SpecWidgetRange
+	case models.AttributeTypeBoolean:
+		result.Widget = SpecWidgetToggle
+		result.Values = []SpecValueCount{{Value: "true", Count: counts["true"]}, {Value: "false", Count: counts["false"]}}
+	case models.AttributeTypeEnum:
+		// Options keep their schema order and stay listed without products, so checkboxes do not move around
+		result.Widget = SpecWidgetCheckbox
+		for _, option := range attribute.Options {
+			result.Values = append(result.Values, SpecValueCount{Value: option, Count: counts[option]})
+		}
+	default:
+		result.Widget = SpecWidgetCheckbox
+		for value, count := range counts {
+			result.Values = append(result.Values, SpecValueCount{Value: value, Count: count})
+		}
+		sort.Slice(result.Values, func(i, j int) bool {
+			if result.Values[i].Count != result.Values[j].Count {
+				return result.Values[i].Count > result.Values[j].Count
+			}
+			return result.Values[i].Value < result.Values[j].Value
+		})
+		if len(result.Values) > maxTextSpecValues {
+			result.Values = result.Values[:maxTextSpecValues]
+		}
+	}
+	return result
+}
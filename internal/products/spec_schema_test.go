@@ -0,0 +1,113 @@
+package products
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_CategorySpecSchema(t *testing.T) {
+	service, helpers := setupCategorizeTest(t)
+	db := helpers.db
+
+	category, err := service.CreateCategory(context.Background(), CreateCategoryRequest{
+		Name: "Laptops",
+		Slug: "laptops",
+		Attributes: []CategoryAttributeRequest{
+			{Key: "ram", Label: "Memory", Unit: "GB", Type: models.AttributeTypeNumber},
+			{Key: "os", Type: models.AttributeTypeEnum, Options: []string{"Windows", "macOS", "Linux"}},
+			{Key: "touch", Type: models.AttributeTypeBoolean},
+			{Key: "brand"},
+		},
+	})
+	require.NoError(t, err)
+
+	specs := []models.JSONB{
+		{"ram": 8.0, "os": "Windows", "touch": true, "brand": "Acme"},
+		{"ram": 16.0, "os": "Windows", "touch": false, "brand": "Acme"},
+		{"ram": "32", "os": "BeOS", "brand": "Zeta"}, // saved before the schema was typed
+		{"colour": "red"},
+	}
+	for i, spec := range specs {
+		product := helpers.CreateTestProduct(string(rune('a'+i)), "Laptop", "SKU-"+string(rune('A'+i)), category.ID, 1000, 1)
+		require.NoError(t, db.Model(product).Update("specifications", spec).Error)
+	}
+	hidden := helpers.CreateTestProduct("hidden", "Laptop", "SKU-HIDDEN", category.ID, 1000, 1)
+	require.NoError(t, db.Model(hidden).Updates(map[string]interface{}{"specifications": models.JSONB{"ram": 64.0}, "is_active": false}).Error)
+
+	schema, err := service.CategorySpecSchema(context.Background(), category.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, schema.ProductCount)
+	require.Len(t, schema.Attributes, 4)
+
+	ram, os, touch, brand := schema.Attributes[0], schema.Attributes[1], schema.Attributes[2], schema.Attributes[3]
+	assert.Equal(t, SpecWidgetRange, ram.Widget)
+	assert.Equal(t, "GB", ram.Unit)
+	assert.Equal(t, 3, ram.ProductCount)
+	require.NotNil(t, ram.Min)
+	assert.Equal(t, 8.0, *ram.Min)
+	assert.Equal(t, 32.0, *ram.Max, "inactive products are left out")
+
+	assert.Equal(t, SpecWidgetCheckbox, os.Widget)
+	assert.Equal(t, []SpecValueCount{{"Windows", 2}, {"macOS", 0}, {"Linux", 0}}, os.Values)
+
+	assert.Equal(t, SpecWidgetToggle, touch.Widget)
+	assert.Equal(t, []SpecValueCount{{"true", 1}, {"false", 1}}, touch.Values)
+
+	assert.Equal(t, models.AttributeTypeText, brand.Type)
+	assert.Equal(t, []SpecValueCount{{"Acme", 2}, {"Zeta", 1}}, brand.Values)
+
+	_, err = service.CategorySpecSchema(context.Background(), "missing")
+	assert.EqualError(t, err, "category not found")
+}
+
+func TestService_ValidateSpecifications(t *testing.T) {
+	service, helpers := setupCategorizeTest(t)
+
+	laptops, err := service.CreateCategory(context.Background(), CreateCategoryRequest{
+		Name: "Laptops",
+		Slug: "laptops",
+		Attributes: []CategoryAttributeRequest{
+			{Key: "ram", Type: models.AttributeTypeNumber},
+			{Key: "os", Type: models.AttributeTypeEnum, Options: []string{"Windows", "Linux"}},
+		},
+	})
+	require.NoError(t, err)
+	books := helpers.CreateTestCategory("cat-books", "Books", "books")
+	product := helpers.CreateTestProduct("p1", "Notebook", "SKU-1", books.ID, 10, 1)
+	require.NoError(t, helpers.db.Model(product).Update("specifications", models.JSONB{"ram": "lots"}).Error)
+
+	valid := map[string]interface{}{"ram": 16.0, "os": "Linux", "colour": "grey"}
+	_, err = service.UpdateProduct(context.Background(), "p1", UpdateProductRequest{Specifications: valid})
+	require.NoError(t, err)
+
+	for name, specs := range map[string]map[string]interface{}{
+		"numeric text":   {"ram": "16"},
+		"unknown option": {"os": "BeOS"},
+	} {
+		_, err := service.UpdateProduct(context.Background(), "p1", UpdateProductRequest{CategoryID: &laptops.ID, Specifications: specs})
+		assert.ErrorIs(t, err, ErrInvalidSpecifications, name)
+	}
+
+	// Moving a product checks the specifications it already has against the new schema
+	other := helpers.CreateTestProduct("p2", "Old laptop", "SKU-2", books.ID, 10, 1)
+	require.NoError(t, helpers.db.Model(other).Update("specifications", models.JSONB{"os": "DOS"}).Error)
+	_, err = service.UpdateProduct(context.Background(), "p2", UpdateProductRequest{CategoryID: &laptops.ID})
+	assert.ErrorIs(t, err, ErrInvalidSpecifications)
+
+	t.Run("schema", func(t *testing.T) {
+		_, err := service.UpdateCategoryAttributes(context.Background(), laptops.ID, UpdateCategoryAttributesRequest{
+			Attributes: []CategoryAttributeRequest{{Key: "os", Type: models.AttributeTypeEnum}},
+		})
+		assert.ErrorIs(t, err, ErrInvalidAttributeSchema)
+
+		_, err = service.UpdateCategoryAttributes(context.Background(), laptops.ID, UpdateCategoryAttributesRequest{
+			Attributes: []CategoryAttributeRequest{{Key: "ram", Type: models.AttributeTypeNumber, Options: []string{"8"}}},
+		})
+		assert.ErrorIs(t, err, ErrInvalidAttributeSchema)
+	})
+}
@@ -0,0 +1,280 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidLocale       = errors.New("invalid locale")
+	ErrTranslationNotFound = errors.New("translation not found")
+)
+
+// localePattern matches BCP 47 language tags such as "fr", "fr-FR" or "zh-Hant-TW"
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// UpsertTranslationRequest is the name, description and SEO fields of a product in one locale
+type UpsertTranslationRequest struct {
+	Name           string  `json:"name" binding:"required,max=255"`
+	Description    string  `json:"description"`
+	SEOTitle       *string `json:"seoTitle,omitempty" binding:"omitempty,max=255"`
+	SEODescription *string `json:"seoDescription,omitempty"`
+}
+
+// NormalizeLocale validates a language tag and returns it in its canonical case: "fr-fr" becomes "fr-FR" and
+// "zh-hant-tw" becomes "zh-Hant-TW"
+func NormalizeLocale(locale string) (string, error) {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if !localePattern.MatchString(locale) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidLocale, locale)
+	}
+	parts := strings.Split(locale, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch {
+		case len(parts[i]) == 2 || (len(parts[i]) == 3 && parts[i][0] >= '0' && parts[i][0] <= '9'):
+			parts[i] = strings.ToUpper(parts[i])
+		case len(parts[i]) == 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// localeLanguage returns the language subtag of a normalized locale
+func localeLanguage(locale string) string {
+	language, _, _ := strings.Cut(locale, "-")
+	return language
+}
+
+// AcceptedLocales returns the locales of an Accept-Language header, most preferred first. The wildcard,
+// locales refused with q=0 and malformed entries are left out.
+func AcceptedLocales(header string) []string {
+	type accepted struct {
+		locale  string
+		quality float64
+	}
+	var entries []accepted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		locale, err := NormalizeLocale(tag)
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if quality, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		entries = append(entries, accepted{locale, quality})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].quality > entries[j].quality })
+
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		locales = append(locales, entry.locale)
+	}
+	return locales
+}
+
+// pickTranslation returns the translation for the first accepted locale it has one for: the exact locale,
+// or else one of the same language, preferring the bare language. A locale of the language the catalog is
+// written in stops the search, as the product itself is already in it.
+func pickTranslation(translations []models.ProductTranslation, accepted []string, catalogLocale string) *models.ProductTranslation {
+	for _, locale := range accepted {
+		var sameLanguage *models.ProductTranslation
+		for i := range translations {
+			translation := &translations[i]
+			if translation.Locale == locale {
+				return translation
+			}
+			if localeLanguage(translation.Locale) == localeLanguage(locale) &&
+				(sameLanguage == nil || translation.Locale == localeLanguage(locale)) {
+				sameLanguage = translation
+			}
+		}
+		if sameLanguage != nil {
+			return sameLanguage
+		}
+		if catalogLocale != "" && localeLanguage(locale) == localeLanguage(catalogLocale) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// applyTranslation replaces a product's name, description and SEO fields with their translation. SEO fields
+// the translation leaves out keep the catalog's.
+func applyTranslation(product *models.Product, translation *models.ProductTranslation) {
+	product.Name = translation.Name
+	product.Description = translation.Description
+	if translation.SEOTitle != nil {
+		product.SEOTitle = translation.SEOTitle
+	}
+	if translation.SEODescription != nil {
+		product.SEODescription = translation.SEODescription
+	}
+	product.Locale = translation.Locale
+}
+
+// TranslateProducts resolves each product to its best translation for the accepted locales, most preferred
+// first. catalogLocale is the locale the catalog itself is written in; products without a fitting
+// translation are left as they are.
+func (s *Service) TranslateProducts(ctx context.Context, products []models.Product, accepted []string, catalogLocale string) error {
+	if len(products) == 0 || len(accepted) == 0 {
+		return nil
+	}
+	if normalized, err := NormalizeLocale(catalogLocale); err == nil {
+		catalogLocale = normalized
+	}
+
+	ids := make([]string, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+	languages := make([]string, len(accepted))
+	for i, locale := range accepted {
+		languages[i] = localeLanguage(locale)
+	}
+	var translations []models.ProductTranslation
+	query := s.reader.WithContext(ctx).Where("product_id IN ?", ids)
+	conditions := s.reader.Where("locale IN ?", languages)
+	for _, language := range languages {
+		conditions = conditions.Or("locale LIKE ?", language+"-%")
+	}
+	if err := query.Where(conditions).Order("locale").Find(&translations).Error; err != nil {
+		return fmt.Errorf("failed to fetch translations: %w", err)
+	}
+
+	byProduct := make(map[string][]models.ProductTranslation)
+	for _, translation := range translations {
+		byProduct[translation.ProductID] = append(byProduct[translation.ProductID], translation)
+	}
+	for i := range products {
+		if translation := pickTranslation(byProduct[products[i].ID], accepted, catalogLocale); translation != nil {
+			applyTranslation(&products[i], translation)
+		}
+	}
+	return nil
+}
+
+// GetTranslations returns the translations of a product, ordered by locale
+func (s *Service) GetTranslations(ctx context.Context, productID string) ([]models.ProductTranslation, error) {
+	if err := s.db.WithContext(ctx).Select("id").First(&models.Product{}, "id = ?", productID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("failed to fetch product: %w", err)
+	}
+
+	translations := []models.ProductTranslation{}
+	if err := s.db.WithContext(ctx).Where("product_id = ?", productID).Order("locale").Find(&translations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch translations: %w", err)
+	}
+	return translations, nil
+}
+
+// UpsertTranslation sets the translation of a product in a locale, replacing the one it has, and indexes
+// the product again so it can be searched in that language
+func (s *Service) UpsertTranslation(ctx context.Context, productID, locale string, req *UpsertTranslationRequest) (*models.ProductTranslation, error) {
+	locale, err := NormalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	var product models.Product
+	var translation models.ProductTranslation
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Category").First(&product, "id = ?", productID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("product not found")
+			}
+			return fmt.Errorf("failed to fetch product: %w", err)
+		}
+
+		err := tx.Where("product_id = ? AND locale = ?", productID, locale).First(&translation).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return fmt.Errorf("failed to fetch translation: %w", err)
+		}
+		translation.ProductID = productID
+		translation.Locale = locale
+		translation.Name = strings.TrimSpace(req.Name)
+		translation.Description = req.Description
+		translation.SEOTitle = req.SEOTitle
+		translation.SEODescription = req.SEODescription
+		if err := tx.Save(&translation).Error; err != nil {
+			return fmt.Errorf("failed to save translation: %w", err)
+		}
+		return s.queueChange(tx, productID, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.syncSearch(&product, false)
+	return &translation, nil
+}
+
+// DeleteTranslation removes the translation of a product in a locale
+func (s *Service) DeleteTranslation(ctx context.Context, productID, locale string) error {
+	locale, err := NormalizeLocale(locale)
+	if err != nil {
+		return err
+	}
+
+	var product models.Product
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Category").First(&product, "id = ?", productID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("product not found")
+			}
+			return fmt.Errorf("failed to fetch product: %w", err)
+		}
+		result := tx.Where("product_id = ? AND locale = ?", productID, locale).Delete(&models.ProductTranslation{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete translation: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrTranslationNotFound
+		}
+		return s.queueChange(tx, productID, true)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.syncSearch(&product, false)
+	return nil
+}
+
+// withTranslations returns a copy of a product carrying its translations, for the search index. The product
+// itself is left alone so the translations never leak into the response of the change being indexed.
+func (s *Service) withTranslations(product *models.Product) (*models.Product, error) {
+	indexed := *product
+	indexed.Translations = nil
+	if err := s.db.Where("product_id = ?", product.ID).Order("locale").Find(&indexed.Translations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch translations: %w", err)
+	}
+	return &indexed, nil
+}
+
+// logTranslationError logs a failure to translate the catalog; the request is answered untranslated
+func logTranslationError(err error) {
+	logger.Named("products").Warn("Failed to translate products", map[string]interface{}{"error": err.Error()})
+}
@@ -0,0 +1,169 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/search"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTranslationsTest(t *testing.T) (*Service, *TestHelpers, *search.Service) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductDocument{}, &models.ProductTranslation{}))
+
+	searchService, err := search.NewServiceFor(db, search.BackendMemory)
+	require.NoError(t, err)
+	return newService(db, searchService), NewTestHelpers(db), searchService
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	for input, want := range map[string]string{
+		"fr":         "fr",
+		"FR-fr":      "fr-FR",
+		"pt_br":      "pt-BR",
+		"zh-hant-tw": "zh-Hant-TW",
+		"es-419":     "es-419",
+	} {
+		got, err := NormalizeLocale(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got)
+	}
+	for _, input := range []string{"", "*", "f", "fr-", "en US"} {
+		_, err := NormalizeLocale(input)
+		assert.ErrorIs(t, err, ErrInvalidLocale, input)
+	}
+}
+
+func TestAcceptedLocales(t *testing.T) {
+	assert.Equal(t, []string{"fr-CA", "fr", "en"}, AcceptedLocales("en;q=0.5, fr-ca, *;q=0.8, fr;q=0.9, de;q=0"))
+	assert.Empty(t, AcceptedLocales(""))
+	assert.Empty(t, AcceptedLocales("*"))
+}
+
+func TestService_ProductTranslations(t *testing.T) {
+	service, helpers, searchService := setupTranslationsTest(t)
+	ctx := context.Background()
+	category := helpers.CreateTestCategory("cat-tr", "Kitchen", "kitchen")
+	helpers.CreateTestProduct("prod-tr", "Coffee Grinder", "SKU-TR", category.ID, 80, 5)
+	helpers.CreateTestProduct("prod-plain", "Kettle", "SKU-KT", category.ID, 30, 5)
+
+	_, err := service.UpsertTranslation(ctx, "missing", "fr", &UpsertTranslationRequest{Name: "Moulin"})
+	assert.EqualError(t, err, "product not found")
+	_, err = service.UpsertTranslation(ctx, "prod-tr", "not a locale", &UpsertTranslationRequest{Name: "Moulin"})
+	assert.ErrorIs(t, err, ErrInvalidLocale)
+
+	seoTitle := "Moulin à café | Boutique"
+	french, err := service.UpsertTranslation(ctx, "prod-tr", "fr", &UpsertTranslationRequest{Name: "Moulin", Description: "Moulin à meules", SEOTitle: &seoTitle})
+	require.NoError(t, err)
+	updated, err := service.UpsertTranslation(ctx, "prod-tr", "FR", &UpsertTranslationRequest{Name: "Moulin à café", Description: "Moulin à meules", SEOTitle: &seoTitle})
+	require.NoError(t, err)
+	assert.Equal(t, french.ID, updated.ID, "a locale has one translation")
+	_, err = service.UpsertTranslation(ctx, "prod-tr", "fr-ca", &UpsertTranslationRequest{Name: "Moulin à café québécois"})
+	require.NoError(t, err)
+	_, err = service.UpsertTranslation(ctx, "prod-tr", "de", &UpsertTranslationRequest{Name: "Kaffeemühle"})
+	require.NoError(t, err)
+
+	translations, err := service.GetTranslations(ctx, "prod-tr")
+	require.NoError(t, err)
+	require.Len(t, translations, 3)
+	assert.Equal(t, []string{"de", "fr", "fr-CA"}, []string{translations[0].Locale, translations[1].Locale, translations[2].Locale})
+
+	t.Run("resolution", func(t *testing.T) {
+		for _, tc := range []struct {
+			header, locale, name string
+		}{
+			{"fr-CA", "fr-CA", "Moulin à café québécois"},
+			{"fr-BE, de;q=0.5", "fr", "Moulin à café"},
+			{"it, de;q=0.5", "de", "Kaffeemühle"},
+			{"en-US, fr;q=0.9", "", "Coffee Grinder"},
+			{"", "", "Coffee Grinder"},
+		} {
+			products := []models.Product{{ID: "prod-tr", Name: "Coffee Grinder"}, {ID: "prod-plain", Name: "Kettle"}}
+			require.NoError(t, service.TranslateProducts(ctx, products, AcceptedLocales(tc.header), "en-IN"))
+			assert.Equal(t, tc.locale, products[0].Locale, tc.header)
+			assert.Equal(t, tc.name, products[0].Name, tc.header)
+			assert.Equal(t, "Kettle", products[1].Name, "products without translations keep their name")
+		}
+
+		products := []models.Product{{ID: "prod-tr"}}
+		require.NoError(t, service.TranslateProducts(ctx, products, []string{"fr"}, "en-IN"))
+		require.NotNil(t, products[0].SEOTitle)
+		assert.Equal(t, seoTitle, *products[0].SEOTitle)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		query := "kaffeemühle"
+		result, err := searchService.SearchProducts(search.SearchFilters{Search: &query}, search.SearchSort{}, 1, 20, false)
+		require.NoError(t, err)
+		require.Len(t, result.Products, 1)
+		assert.Equal(t, "prod-tr", result.Products[0].ID)
+
+		require.NoError(t, service.DeleteTranslation(ctx, "prod-tr", "de"))
+		result, err = searchService.SearchProducts(search.SearchFilters{Search: &query}, search.SearchSort{}, 1, 20, false)
+		require.NoError(t, err)
+		assert.Empty(t, result.Products, "deleted translations leave the index")
+
+		assert.ErrorIs(t, service.DeleteTranslation(ctx, "prod-tr", "de"), ErrTranslationNotFound)
+	})
+}
+
+func TestHandler_ProductTranslations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, helpers, _ := setupTranslationsTest(t)
+	category := helpers.CreateTestCategory("cat-tr", "Kitchen", "kitchen")
+	helpers.CreateTestProduct("prod-tr", "Coffee Grinder", "SKU-TR", category.ID, 80, 5)
+	handler := NewHandler(service)
+	router := gin.New()
+	router.GET("/api/products/:id", handler.GetProductByID)
+	router.PUT("/api/admin/products/:id/translations/:locale", handler.UpsertProductTranslation)
+
+	put := func(locale, body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/products/prod-tr/translations/"+locale, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusBadRequest, put("fr", `{}`), "name is required")
+	assert.Equal(t, http.StatusBadRequest, put("f", `{"name":"Moulin"}`))
+	assert.Equal(t, http.StatusOK, put("fr", `{"name":"Moulin à café"}`))
+
+	get := func(acceptLanguage string) (*httptest.ResponseRecorder, models.Product) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/products/prod-tr", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data models.Product `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response.Data
+	}
+
+	w, product := get("fr-FR,fr;q=0.9")
+	assert.Equal(t, "Moulin à café", product.Name)
+	assert.Equal(t, "fr", product.Locale)
+	assert.Equal(t, "fr", w.Header().Get("Content-Language"))
+	assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+
+	w, product = get("en")
+	assert.Equal(t, "Coffee Grinder", product.Name)
+	assert.Empty(t, product.Locale)
+	assert.Empty(t, w.Header().Get("Content-Language"))
+}
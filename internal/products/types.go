@@ -1,42 +1,59 @@
 package products
 
-import "ecommerce-website/internal/models"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+)
 
 // CreateProductRequest represents the request body for creating a product
 type CreateProductRequest struct {
-	Name           string                 `json:"name" binding:"required"`
-	Description    string                 `json:"description"`
-	Price          float64                `json:"price" binding:"required"`
-	CompareAtPrice *float64               `json:"compareAtPrice,omitempty"`
-	SKU            string                 `json:"sku" binding:"required"`
-	Inventory      int                    `json:"inventory"`
-	CategoryID     string                 `json:"categoryId" binding:"required"`
-	Images         []string               `json:"images"`
-	Specifications map[string]interface{} `json:"specifications"`
-	SEOTitle       *string                `json:"seoTitle,omitempty"`
-	SEODescription *string                `json:"seoDescription,omitempty"`
-	IsActive       *bool                  `json:"isActive,omitempty"`
+	Name              string                 `json:"name" binding:"required"`
+	Description       string                 `json:"description"`
+	Price             float64                `json:"price" binding:"required"`
+	CompareAtPrice    *float64               `json:"compareAtPrice,omitempty"`
+	SKU               string                 `json:"sku" binding:"required"`
+	Inventory         int                    `json:"inventory"`
+	LowStockThreshold *int                   `json:"lowStockThreshold,omitempty" binding:"omitempty,min=0"`
+	CategoryID        string                 `json:"categoryId" binding:"required"`
+	Images            []string               `json:"images"`
+	Tags              []string               `json:"tags,omitempty"`
+	Specifications    map[string]interface{} `json:"specifications"`
+	SEOTitle          *string                `json:"seoTitle,omitempty"`
+	SEODescription    *string                `json:"seoDescription,omitempty"`
+	IsActive          *bool                  `json:"isActive,omitempty"`
+	WeightGrams       *float64               `json:"weightGrams,omitempty" binding:"omitempty,min=0"`
 }
 
 // UpdateProductRequest represents the request body for updating a product
 type UpdateProductRequest struct {
-	Name           *string                `json:"name,omitempty"`
-	Description    *string                `json:"description,omitempty"`
-	Price          *float64               `json:"price,omitempty"`
-	CompareAtPrice *float64               `json:"compareAtPrice,omitempty"`
-	SKU            *string                `json:"sku,omitempty"`
-	Inventory      *int                   `json:"inventory,omitempty"`
-	CategoryID     *string                `json:"categoryId,omitempty"`
-	Images         []string               `json:"images,omitempty"`
-	Specifications map[string]interface{} `json:"specifications,omitempty"`
-	SEOTitle       *string                `json:"seoTitle,omitempty"`
-	SEODescription *string                `json:"seoDescription,omitempty"`
-	IsActive       *bool                  `json:"isActive,omitempty"`
+	Name              *string                `json:"name,omitempty"`
+	Description       *string                `json:"description,omitempty"`
+	Price             *float64               `json:"price,omitempty"`
+	CompareAtPrice    *float64               `json:"compareAtPrice,omitempty"`
+	SKU               *string                `json:"sku,omitempty"`
+	Inventory         *int                   `json:"inventory,omitempty"`
+	LowStockThreshold *int                   `json:"lowStockThreshold,omitempty" binding:"omitempty,min=0"`
+	CategoryID        *string                `json:"categoryId,omitempty"`
+	Images            []string               `json:"images,omitempty"`
+	Tags              []string               `json:"tags,omitempty"`
+	Specifications    map[string]interface{} `json:"specifications,omitempty"`
+	SEOTitle          *string                `json:"seoTitle,omitempty"`
+	SEODescription    *string                `json:"seoDescription,omitempty"`
+	IsActive          *bool                  `json:"isActive,omitempty"`
+	WeightGrams       *float64               `json:"weightGrams,omitempty" binding:"omitempty,min=0"`
+	// Version the changes are based on; the update is rejected when the product has changed since.
+	// An If-Match header carrying the version does the same.
+	Version *int64 `json:"version,omitempty" binding:"omitempty,min=1"`
 }
 
 // UpdateInventoryRequest represents the request body for updating inventory
 type UpdateInventoryRequest struct {
-	Inventory int `json:"inventory" binding:"required"`
+	Inventory int    `json:"inventory" binding:"required"`
+	Version   *int64 `json:"version,omitempty" binding:"omitempty,min=1"`
 }
 
 // AdminProductFilters represents filters for admin product queries (includes inactive products)
@@ -46,18 +63,14 @@ type AdminProductFilters struct {
 	MaxPrice   *float64
 	InStock    *bool
 	IsActive   *bool
+	Deleted    *bool // only soft deleted products when true, only live ones when false
 	Search     *string
 }
 
 // AdminProductListResponse represents paginated admin product response
 type AdminProductListResponse struct {
-	Products    []models.Product `json:"products"`
-	Total       int64            `json:"total"`
-	Page        int              `json:"page"`
-	PageSize    int              `json:"pageSize"`
-	TotalPages  int              `json:"totalPages"`
-	HasNext     bool             `json:"hasNext"`
-	HasPrevious bool             `json:"hasPrevious"`
+	Products   []models.Product `json:"products"`
+	Pagination utils.Pagination `json:"pagination"`
 }
 
 // Advanced Search Types
@@ -80,12 +93,10 @@ type AdvancedSearchSort struct {
 // AdvancedSearchResponse represents advanced search response with facets
 type AdvancedSearchResponse struct {
 	Products    []models.Product `json:"products"`
-	Total       int64            `json:"total"`
-	Page        int              `json:"page"`
-	PageSize    int              `json:"pageSize"`
-	TotalPages  int              `json:"totalPages"`
+	Pagination  utils.Pagination `json:"pagination"`
 	Suggestions []string         `json:"suggestions,omitempty"`
 	Facets      *SearchFacets    `json:"facets,omitempty"`
+	Degraded    bool             `json:"degraded,omitempty"` // served by the database fallback
 }
 
 // SearchFacets represents search facets for filtering
@@ -111,10 +122,170 @@ type PriceRangeFacet struct {
 
 // CreateCategoryRequest represents the request body for creating a category
 type CreateCategoryRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Slug        string  `json:"slug" binding:"required"`
+	Name         string                     `json:"name" binding:"required"`
+	Slug         string                     `json:"slug" binding:"required"`
+	Description  *string                    `json:"description,omitempty"`
+	ParentID     *string                    `json:"parentId,omitempty"`
+	IsActive     *bool                      `json:"isActive,omitempty"`
+	SortOrder    *int                       `json:"sortOrder,omitempty"`
+	ReturnPolicy *CategoryPolicyRequest     `json:"returnPolicy,omitempty"`
+	Attributes   []CategoryAttributeRequest `json:"attributes,omitempty" binding:"omitempty,dive"`
+	// SerialTracked requires a serial number or IMEI for every unit of the category's products when it ships
+	SerialTracked bool `json:"serialTracked,omitempty"`
+	// WarrantyMonths registers a warranty of this many months for delivered units of the category's products
+	WarrantyMonths int `json:"warrantyMonths,omitempty" binding:"omitempty,min=0,max=120"`
+}
+
+// CategoryAttributeRequest describes one attribute of a category's specification schema
+type CategoryAttributeRequest struct {
+	Key   string `json:"key" binding:"required,max=64"`
+	Label string `json:"label,omitempty" binding:"max=128"`
+	Unit  string `json:"unit,omitempty" binding:"max=32"`
+	// Type is the value type products must use for the attribute; text when omitted
+	Type string `json:"type,omitempty" binding:"omitempty,oneof=text number enum boolean"`
+	// Options lists the allowed values of an enum attribute
+	Options []string `json:"options,omitempty" binding:"omitempty,max=100,dive,max=64"`
+}
+
+// UpdateCategoryAttributesRequest represents the request body for replacing a category's attribute schema
+type UpdateCategoryAttributesRequest struct {
+	Attributes []CategoryAttributeRequest `json:"attributes" binding:"dive"`
+}
+
+// UpdateCategorySerialTrackingRequest turns serial number capture on or off for a category
+type UpdateCategorySerialTrackingRequest struct {
+	SerialTracked *bool `json:"serialTracked" binding:"required"`
+}
+
+// ReindexSearchRequest starts a reindex of the catalog; BatchSize is the number of products per bulk request
+type ReindexSearchRequest struct {
+	BatchSize int `json:"batchSize" binding:"omitempty,min=1,max=5000"`
+}
+
+// UpdateCategoryWarrantyRequest sets the warranty period of a category's products; 0 stops registering warranties
+type UpdateCategoryWarrantyRequest struct {
+	WarrantyMonths *int `json:"warrantyMonths" binding:"required,min=0,max=120"`
+}
+
+// UpdateCategoryRequest represents the request body for updating a category; omitted fields are left unchanged
+type UpdateCategoryRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1"`
+	Slug        *string `json:"slug,omitempty" binding:"omitempty,min=1"`
 	Description *string `json:"description,omitempty"`
-	ParentID    *string `json:"parentId,omitempty"`
 	IsActive    *bool   `json:"isActive,omitempty"`
 	SortOrder   *int    `json:"sortOrder,omitempty"`
 }
+
+// ReorderCategoriesRequest sets the sort order of several categories at once
+type ReorderCategoriesRequest struct {
+	Categories []CategoryOrder `json:"categories" binding:"required,min=1,dive"`
+}
+
+// CategoryOrder is the sort order of one category
+type CategoryOrder struct {
+	ID        string `json:"id" binding:"required"`
+	SortOrder *int   `json:"sortOrder" binding:"required"`
+}
+
+// MoveCategoryRequest moves a category under another parent; a null parent makes it a top level category
+type MoveCategoryRequest struct {
+	ParentID *string `json:"parentId"`
+}
+
+// CategoryTreeNode is a category in the category tree. ProductCount counts the active products of the
+// category and all of its descendants.
+type CategoryTreeNode struct {
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	Slug         string              `json:"slug"`
+	Description  *string             `json:"description,omitempty"`
+	ParentID     *string             `json:"parentId,omitempty"`
+	SortOrder    int                 `json:"sortOrder"`
+	ProductCount int64               `json:"productCount"`
+	Children     []*CategoryTreeNode `json:"children"`
+}
+
+// ErrInvalidAttributeSchema is returned for attribute schemas with blank or duplicate keys, or enum
+// attributes without options
+var ErrInvalidAttributeSchema = errors.New("invalid attribute schema")
+
+// attributeSchema converts attribute requests into a schema, defaulting labels to keys.
+// Duplicate keys are rejected, and only enum attributes take options.
+func attributeSchema(attributes []CategoryAttributeRequest) (models.AttributeSchema, error) {
+	schema := make(models.AttributeSchema, 0, len(attributes))
+	seen := make(map[string]bool, len(attributes))
+	for _, attribute := range attributes {
+		key := strings.TrimSpace(attribute.Key)
+		if key == "" {
+			return nil, fmt.Errorf("%w: attribute key is required", ErrInvalidAttributeSchema)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate attribute key %q", ErrInvalidAttributeSchema, key)
+		}
+		seen[key] = true
+
+		label := strings.TrimSpace(attribute.Label)
+		if label == "" {
+			label = key
+		}
+		options, err := attributeOptions(key, attribute)
+		if err != nil {
+			return nil, err
+		}
+		schema = append(schema, models.CategoryAttribute{
+			Key:     key,
+			Label:   label,
+			Unit:    strings.TrimSpace(attribute.Unit),
+			Type:    attribute.Type,
+			Options: options,
+		})
+	}
+	return schema, nil
+}
+
+// attributeOptions returns the trimmed options of an enum attribute
+func attributeOptions(key string, attribute CategoryAttributeRequest) ([]string, error) {
+	if attribute.Type != models.AttributeTypeEnum {
+		if len(attribute.Options) > 0 {
+			return nil, fmt.Errorf("%w: only enum attributes take options (%q)", ErrInvalidAttributeSchema, key)
+		}
+		return nil, nil
+	}
+
+	options := make([]string, 0, len(attribute.Options))
+	seen := make(map[string]bool, len(attribute.Options))
+	for _, option := range attribute.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || seen[option] {
+			return nil, fmt.Errorf("%w: options of %q must be distinct and not blank", ErrInvalidAttributeSchema, key)
+		}
+		seen[option] = true
+		options = append(options, option)
+	}
+	if len(options) == 0 {
+		return nil, fmt.Errorf("%w: enum attribute %q needs options", ErrInvalidAttributeSchema, key)
+	}
+	return options, nil
+}
+
+// CategoryPolicyRequest represents the request body for setting a category's return and cancellation policy.
+// Omitted fields keep their current (or default) value.
+type CategoryPolicyRequest struct {
+	Returnable              *bool `json:"returnable,omitempty"`
+	ReturnWindowDays        *int  `json:"returnWindowDays,omitempty" binding:"omitempty,min=0,max=365"`
+	CancellationCutoffHours *int  `json:"cancellationCutoffHours,omitempty" binding:"omitempty,min=0,max=720"`
+}
+
+// apply overlays the fields set in the request onto policy
+func (r CategoryPolicyRequest) apply(policy models.ReturnPolicy) models.ReturnPolicy {
+	if r.Returnable != nil {
+		policy.Returnable = *r.Returnable
+	}
+	if r.ReturnWindowDays != nil {
+		policy.ReturnWindowDays = *r.ReturnWindowDays
+	}
+	if r.CancellationCutoffHours != nil {
+		policy.CancellationCutoffHours = *r.CancellationCutoffHours
+	}
+	return policy
+}
@@ -0,0 +1,69 @@
+package products
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVersionConflict = errors.New("product was changed since the given version")
+	ErrInvalidVersion  = errors.New("invalid version")
+)
+
+// VersionConflictError carries the product as it is now when an update was based on an older version of it
+type VersionConflictError struct {
+	ProductID      string          `json:"productId"`
+	CurrentVersion int64           `json:"currentVersion"`
+	Product        *models.Product `json:"product"`
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s: product %s is at version %d", ErrVersionConflict.Error(), e.ProductID, e.CurrentVersion)
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
+// ParseIfMatch reads the product version of an If-Match header such as "3" or W/"3". An empty header
+// returns nil, leaving the update unversioned.
+func ParseIfMatch(header string) (*int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, nil
+	}
+	value := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version < 1 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidVersion, header)
+	}
+	return &version, nil
+}
+
+// updateVersioned applies updates to a product. With a version the update only goes through while the
+// product is still at that version, and a *VersionConflictError with the current product is returned
+// otherwise. The version itself goes up through the product's BeforeUpdate hook.
+func updateVersioned(tx *gorm.DB, product *models.Product, version *int64, updates map[string]interface{}) error {
+	query := tx.Model(product)
+	if version != nil {
+		query = query.Where("version = ?", *version)
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update product: %w", result.Error)
+	}
+	if version != nil && result.RowsAffected == 0 {
+		var current models.Product
+		if err := tx.Unscoped().Preload("Category").First(&current, "id = ?", product.ID).Error; err != nil {
+			return fmt.Errorf("failed to load product: %w", err)
+		}
+		return &VersionConflictError{ProductID: product.ID, CurrentVersion: current.Version, Product: &current}
+	}
+	return nil
+}
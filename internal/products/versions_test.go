@@ -0,0 +1,126 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	for header, want := range map[string]int64{`3`: 3, `"12"`: 12, `W/"7"`: 7} {
+		version, err := ParseIfMatch(header)
+		require.NoError(t, err, header)
+		require.NotNil(t, version)
+		assert.Equal(t, want, *version)
+	}
+
+	version, err := ParseIfMatch("")
+	require.NoError(t, err)
+	assert.Nil(t, version, "an update without If-Match is unversioned")
+
+	for _, header := range []string{"*", `"abc"`, "0", "-2"} {
+		_, err := ParseIfMatch(header)
+		assert.ErrorIs(t, err, ErrInvalidVersion, header)
+	}
+}
+
+func TestService_OptimisticLocking(t *testing.T) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	service := NewService(db)
+	helpers := NewTestHelpers(db)
+	ctx := context.Background()
+
+	category := helpers.CreateTestCategory("cat-v", "Audio", "audio")
+	created := helpers.CreateTestProduct("prod-v", "Headphones", "SKU-V", category.ID, 100, 5)
+	assert.Equal(t, int64(1), created.Version)
+
+	name := "Wireless Headphones"
+	version := int64(1)
+	updated, err := service.UpdateProduct(ctx, "prod-v", UpdateProductRequest{Name: &name, Version: &version})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version, "every change moves the version on")
+
+	// A second update based on version 1 lost the race
+	stale := "Stale Headphones"
+	_, err = service.UpdateProduct(ctx, "prod-v", UpdateProductRequest{Name: &stale, Version: &version})
+	var conflict *VersionConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Equal(t, int64(2), conflict.CurrentVersion)
+	assert.Equal(t, name, conflict.Product.Name, "the conflict carries the product as it is now")
+
+	_, err = service.UpdateInventory(ctx, "prod-v", 9, &version)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	current := int64(2)
+	restocked, err := service.UpdateInventory(ctx, "prod-v", 9, &current)
+	require.NoError(t, err)
+	assert.Equal(t, 9, restocked.Inventory)
+	assert.Equal(t, int64(3), restocked.Version)
+
+	// Unversioned updates still go through, and still move the version on
+	restocked, err = service.UpdateInventory(ctx, "prod-v", 4, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), restocked.Version)
+}
+
+func TestHandler_UpdateProductVersionConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	db := database.GetDB()
+	helpers := NewTestHelpers(db)
+	category := helpers.CreateTestCategory("cat-v", "Audio", "audio")
+	helpers.CreateTestProduct("prod-v", "Headphones", "SKU-V", category.ID, 100, 5)
+
+	handler := NewHandler(NewService(db))
+	router := gin.New()
+	router.PUT("/api/admin/products/:id", handler.UpdateProduct)
+	router.PUT("/api/admin/products/:id/inventory", handler.UpdateInventory)
+
+	put := func(path, ifMatch, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, put("/api/admin/products/prod-v", `"1"`, `{"name":"Studio Headphones"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, put("/api/admin/products/prod-v", "*", `{"name":"Studio Headphones"}`).Code)
+
+	w := put("/api/admin/products/prod-v", "", `{"name":"Old Headphones","version":1}`)
+	require.Equal(t, http.StatusConflict, w.Code)
+	var response struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				CurrentVersion int64 `json:"currentVersion"`
+				Product        struct {
+					Name string `json:"name"`
+				} `json:"product"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "VERSION_CONFLICT", response.Error.Code)
+	assert.Equal(t, int64(2), response.Error.Details.CurrentVersion)
+	assert.Equal(t, "Studio Headphones", response.Error.Details.Product.Name)
+
+	assert.Equal(t, http.StatusConflict, put("/api/admin/products/prod-v/inventory", `W/"1"`, `{"inventory":3}`).Code)
+	assert.Equal(t, http.StatusOK, put("/api/admin/products/prod-v/inventory", `W/"2"`, `{"inventory":3}`).Code)
+}
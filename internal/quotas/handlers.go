@@ -0,0 +1,40 @@
+package quotas
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new quotas handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetStoreUsage handles GET /api/admin/quotas/:storeId (admin only)
+func (h *Handler) GetStoreUsage(c *gin.Context) {
+	storeID := c.Param("storeId")
+	usage, err := h.service.GetUsage(c.Request.Context(), storeID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidStoreID) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_STORE_ID", "Invalid store ID", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_QUOTA_USAGE_FAILED", "Failed to get quota usage", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Quota usage retrieved successfully", gin.H{
+		"storeId": storeID,
+		"quotas":  usage,
+	})
+}
@@ -0,0 +1,143 @@
+package quotas
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StoreHeader names the store a request is made for; requests without it belong to DefaultStoreID. Only known
+// stores are accepted, so made-up IDs cannot start fresh quota counters.
+const StoreHeader = "X-Store-ID"
+
+// StoreIDKey is the context key the resolved store ID is stored under
+const StoreIDKey = "store_id"
+
+// Quota response headers describe the most specific quota the request was charged against
+const (
+	HeaderQuotaName      = "X-Quota-Name"
+	HeaderQuotaLimit     = "X-Quota-Limit"
+	HeaderQuotaRemaining = "X-Quota-Remaining"
+	HeaderQuotaReset     = "X-Quota-Reset"
+)
+
+// routeQuota charges an extra quota for requests to a specific route. A path segment starting with ':'
+// matches any value, as in gin routes.
+type routeQuota struct {
+	method string
+	path   string
+	quota  string
+	match  func(c *gin.Context) bool // optional further condition
+}
+
+// routeQuotas are charged on top of the API request quota. Every export, and every report query an admin
+// submits, counts against the exports quota.
+var routeQuotas = []routeQuota{
+	{method: http.MethodPost, path: "/api/orders/create", quota: QuotaOrders},
+	{method: http.MethodGet, path: "/api/admin/reports/product-performance", quota: QuotaExports,
+		match: func(c *gin.Context) bool { return c.Query("format") == "csv" }},
+	{method: http.MethodGet, path: "/api/admin/reports/sales", quota: QuotaExports},
+	{method: http.MethodPost, path: "/api/admin/reports/queries", quota: QuotaExports},
+	{method: http.MethodGet, path: "/api/admin/promotions/:id/codes/export", quota: QuotaExports},
+}
+
+// matches reports whether a request with the unversioned path is made to the route
+func (rq routeQuota) matches(c *gin.Context, path string) bool {
+	if c.Request.Method != rq.method {
+		return false
+	}
+	want, got := strings.Split(rq.path, "/"), strings.Split(path, "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i, segment := range want {
+		if strings.HasPrefix(segment, ":") {
+			if got[i] == "" {
+				return false
+			}
+		} else if segment != got[i] {
+			return false
+		}
+	}
+	return rq.match == nil || rq.match(c)
+}
+
+// Middleware resolves the store of each API request and enforces its quotas
+func Middleware(service ServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			c.Next()
+			return
+		}
+
+		storeID := strings.TrimSpace(c.GetHeader(StoreHeader))
+		if storeID == "" {
+			storeID = DefaultStoreID
+		}
+		if err := ValidateStoreID(storeID); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_STORE_ID", "Invalid store ID", err.Error())
+			c.Abort()
+			return
+		}
+		known, err := service.KnownStore(c.Request.Context(), storeID)
+		if err != nil {
+			logger.Named("quotas").Warn("Failed to look up store", map[string]interface{}{"store_id": storeID, "error": err.Error()})
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, "STORE_LOOKUP_FAILED", "Failed to look up the store", nil)
+			c.Abort()
+			return
+		}
+		if !known {
+			utils.ErrorResponse(c, http.StatusBadRequest, "UNKNOWN_STORE", "Unknown store", gin.H{"storeId": storeID})
+			c.Abort()
+			return
+		}
+		c.Set(StoreIDKey, storeID)
+
+		quotas := []string{QuotaAPIRequests}
+		path := utils.UnversionedPath(c.Request.URL.Path)
+		for _, rq := range routeQuotas {
+			if rq.matches(c, path) {
+				quotas = append(quotas, rq.quota)
+			}
+		}
+
+		for _, quota := range quotas {
+			usage, err := service.Consume(c.Request.Context(), storeID, quota)
+			if err != nil && !errors.Is(err, ErrQuotaExceeded) {
+				// Quotas protect shared infrastructure; failing to count must not take the store down
+				logger.Named("quotas").Warn("Failed to count store quota", map[string]interface{}{
+					"store_id": storeID, "quota": quota, "error": err.Error(),
+				})
+				continue
+			}
+			if usage.Limit > 0 {
+				setQuotaHeaders(c, usage)
+			}
+			if errors.Is(err, ErrQuotaExceeded) {
+				retryAfter := int(time.Until(usage.ResetAt).Seconds()) + 1
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				utils.ErrorResponse(c, http.StatusTooManyRequests, "QUOTA_EXCEEDED",
+					"Store quota exceeded: "+quota, gin.H{"quota": quota, "limit": usage.Limit, "resetAt": usage.ResetAt})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// setQuotaHeaders reports a quota's usage on the response
+func setQuotaHeaders(c *gin.Context, usage *Usage) {
+	c.Header(HeaderQuotaName, usage.Quota)
+	c.Header(HeaderQuotaLimit, strconv.FormatInt(usage.Limit, 10))
+	c.Header(HeaderQuotaRemaining, strconv.FormatInt(usage.Remaining, 10))
+	c.Header(HeaderQuotaReset, strconv.FormatInt(usage.ResetAt.Unix(), 10))
+}
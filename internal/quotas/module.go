@@ -0,0 +1,37 @@
+package quotas
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/settings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires per-store quotas into the application
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the quotas module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.Redis, settings.NewService(deps.DB))
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "quotas"
+}
+
+// Middleware resolves the store of each request and enforces its quotas
+func (m *Module) Middleware() []gin.HandlerFunc {
+	return []gin.HandlerFunc{Middleware(m.service)}
+}
+
+// RegisterAPIRoutes sets up the admin quota usage routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
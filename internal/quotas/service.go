@@ -0,0 +1,204 @@
+package quotas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"ecommerce-website/internal/settings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultStoreID is the store requests belong to when they do not name one
+const DefaultStoreID = "default"
+
+// Quotas enforced per store
+const (
+	QuotaAPIRequests = "api_requests"
+	QuotaOrders      = "orders"
+	QuotaExports     = "exports"
+)
+
+var (
+	ErrQuotaExceeded  = errors.New("store quota exceeded")
+	ErrInvalidStoreID = errors.New("invalid store id")
+	ErrUnknownQuota   = errors.New("unknown quota")
+)
+
+var storeIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// definition is the setting that limits a quota and the window it is counted over
+type definition struct {
+	settingKey string
+	window     time.Duration
+}
+
+var definitions = map[string]definition{
+	QuotaAPIRequests: {settings.KeyQuotaAPIRequests, time.Minute},
+	QuotaOrders:      {settings.KeyQuotaOrders, time.Minute},
+	QuotaExports:     {settings.KeyQuotaExports, time.Hour},
+}
+
+// quotaOrder lists the quotas in the order usage is reported
+var quotaOrder = []string{QuotaAPIRequests, QuotaOrders, QuotaExports}
+
+// Usage is a store's consumption of one quota in the current window. A zero limit means unlimited.
+type Usage struct {
+	Quota     string    `json:"quota"`
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// ServiceInterface defines the interface for the quotas service
+type ServiceInterface interface {
+	Consume(ctx context.Context, storeID, quota string) (*Usage, error)
+	GetUsage(ctx context.Context, storeID string) ([]Usage, error)
+	KnownStore(ctx context.Context, storeID string) (bool, error)
+}
+
+type Service struct {
+	redisClient *redis.Client
+	settings    settings.ServiceInterface
+	now         func() time.Time
+
+	mu    sync.Mutex
+	local map[string]localCounter // used when Redis is unavailable; counts only this instance's requests
+}
+
+// localCounter is an in-memory window counter
+type localCounter struct {
+	count   int64
+	resetAt time.Time
+}
+
+// NewService creates a new quotas service. Limits are read per store from settings; counters live in
+// Redis so every instance shares them, falling back to in-memory counters when Redis is unavailable.
+func NewService(redisClient *redis.Client, settingsService settings.ServiceInterface) *Service {
+	return &Service{
+		redisClient: redisClient,
+		settings:    settingsService,
+		now:         time.Now,
+		local:       make(map[string]localCounter),
+	}
+}
+
+// ValidateStoreID reports whether id can name a store
+func ValidateStoreID(id string) error {
+	if !storeIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: %q", ErrInvalidStoreID, id)
+	}
+	return nil
+}
+
+// KnownStore reports whether id names a store: the default store, or one an admin has given settings of its own
+func (s *Service) KnownStore(ctx context.Context, storeID string) (bool, error) {
+	if storeID == DefaultStoreID {
+		return true, nil
+	}
+	return s.settings.HasStore(ctx, storeID)
+}
+
+// Consume counts one unit against a store's quota. When the quota is exhausted the usage is
+// returned together with ErrQuotaExceeded.
+func (s *Service) Consume(ctx context.Context, storeID, quota string) (*Usage, error) {
+	if err := ValidateStoreID(storeID); err != nil {
+		return nil, err
+	}
+	def, ok := definitions[quota]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownQuota, quota)
+	}
+
+	usage := s.window(ctx, storeID, quota, def)
+	if usage.Limit == 0 {
+		return usage, nil
+	}
+
+	used := s.incr(ctx, counterKey(storeID, quota, usage.ResetAt), def.window, usage.ResetAt)
+	usage.Used = used
+	usage.Remaining = max(usage.Limit-used, 0)
+	if used > usage.Limit {
+		return usage, ErrQuotaExceeded
+	}
+	return usage, nil
+}
+
+// GetUsage returns a store's current usage of every quota
+func (s *Service) GetUsage(ctx context.Context, storeID string) ([]Usage, error) {
+	if err := ValidateStoreID(storeID); err != nil {
+		return nil, err
+	}
+	usages := make([]Usage, 0, len(quotaOrder))
+	for _, quota := range quotaOrder {
+		usage := s.window(ctx, storeID, quota, definitions[quota])
+		used := s.get(ctx, counterKey(storeID, quota, usage.ResetAt))
+		usage.Used = used
+		if usage.Limit > 0 {
+			usage.Remaining = max(usage.Limit-used, 0)
+		}
+		usages = append(usages, *usage)
+	}
+	return usages, nil
+}
+
+// window returns the empty usage of the current fixed window of a quota with the store's limit
+func (s *Service) window(ctx context.Context, storeID, quota string, def definition) *Usage {
+	limit := s.settings.ForStore(storeID).GetInt(ctx, def.settingKey)
+	if limit < 0 {
+		limit = 0
+	}
+	resetAt := s.now().Truncate(def.window).Add(def.window)
+	return &Usage{Quota: quota, Limit: limit, Remaining: limit, ResetAt: resetAt}
+}
+
+// counterKey names the counter of one store quota window
+func counterKey(storeID, quota string, resetAt time.Time) string {
+	return fmt.Sprintf("quota:%s:%s:%d", storeID, quota, resetAt.Unix())
+}
+
+// incr increments a window counter, expiring it with the window. Redis errors fall back to the local counter
+// so a Redis outage degrades quotas to per-instance limits instead of failing requests.
+func (s *Service) incr(ctx context.Context, key string, window time.Duration, resetAt time.Time) int64 {
+	if s.redisClient != nil {
+		pipe := s.redisClient.TxPipeline()
+		incr := pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, window)
+		if _, err := pipe.Exec(ctx); err == nil {
+			return incr.Val()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for k, counter := range s.local {
+		if !now.Before(counter.resetAt) {
+			delete(s.local, k)
+		}
+	}
+	counter := s.local[key]
+	counter.count++
+	counter.resetAt = resetAt
+	s.local[key] = counter
+	return counter.count
+}
+
+// get reads a window counter
+func (s *Service) get(ctx context.Context, key string) int64 {
+	if s.redisClient != nil {
+		value, err := s.redisClient.Get(ctx, key).Int64()
+		if err == nil || err == redis.Nil {
+			return value
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.local[key].count
+}
@@ -0,0 +1,191 @@
+package quotas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupQuotaTest returns a service without Redis whose store "small" may serve two API requests
+// and one order per minute
+func setupQuotaTest(t *testing.T) *Service {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Setting{}))
+	require.NoError(t, db.Create(&[]models.Setting{
+		{Key: settings.KeyQuotaAPIRequests, Scope: models.SettingScopeStore, ScopeID: "small", Type: models.SettingTypeInt, Value: "2"},
+		{Key: settings.KeyQuotaOrders, Scope: models.SettingScopeStore, ScopeID: "small", Type: models.SettingTypeInt, Value: "1"},
+		{Key: settings.KeyQuotaExports, Scope: models.SettingScopeStore, ScopeID: "small", Type: models.SettingTypeInt, Value: "0"},
+	}).Error)
+
+	service := NewService(nil, settings.NewService(db))
+	now := time.Date(2025, 3, 10, 12, 0, 30, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestService_Consume(t *testing.T) {
+	service := setupQuotaTest(t)
+	ctx := context.Background()
+
+	usage, err := service.Consume(ctx, "small", QuotaAPIRequests)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), usage.Limit)
+	assert.Equal(t, int64(1), usage.Remaining)
+	assert.Equal(t, time.Date(2025, 3, 10, 12, 1, 0, 0, time.UTC), usage.ResetAt)
+
+	_, err = service.Consume(ctx, "small", QuotaAPIRequests)
+	require.NoError(t, err)
+	usage, err = service.Consume(ctx, "small", QuotaAPIRequests)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.Equal(t, int64(0), usage.Remaining)
+
+	t.Run("stores are counted separately and use global defaults", func(t *testing.T) {
+		usage, err := service.Consume(ctx, DefaultStoreID, QuotaAPIRequests)
+		require.NoError(t, err)
+		assert.Equal(t, int64(600), usage.Limit)
+		assert.Equal(t, int64(1), usage.Used)
+	})
+
+	t.Run("a zero limit disables the quota", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			usage, err := service.Consume(ctx, "small", QuotaExports)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), usage.Limit)
+		}
+	})
+
+	t.Run("counters reset with the window", func(t *testing.T) {
+		service.now = func() time.Time { return time.Date(2025, 3, 10, 12, 1, 5, 0, time.UTC) }
+		usage, err := service.Consume(ctx, "small", QuotaAPIRequests)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), usage.Used)
+	})
+
+	t.Run("rejects invalid input", func(t *testing.T) {
+		_, err := service.Consume(ctx, "Bad Store", QuotaAPIRequests)
+		assert.ErrorIs(t, err, ErrInvalidStoreID)
+		_, err = service.Consume(ctx, "small", "uploads")
+		assert.ErrorIs(t, err, ErrUnknownQuota)
+	})
+}
+
+func TestService_GetUsage(t *testing.T) {
+	service := setupQuotaTest(t)
+	ctx := context.Background()
+
+	_, err := service.Consume(ctx, "small", QuotaAPIRequests)
+	require.NoError(t, err)
+
+	usage, err := service.GetUsage(ctx, "small")
+	require.NoError(t, err)
+	require.Len(t, usage, 3)
+	assert.Equal(t, Usage{Quota: QuotaAPIRequests, Limit: 2, Used: 1, Remaining: 1, ResetAt: usage[0].ResetAt}, usage[0])
+	assert.Equal(t, QuotaOrders, usage[1].Quota)
+	assert.Equal(t, int64(0), usage[1].Used)
+	assert.Equal(t, time.Date(2025, 3, 10, 13, 0, 0, 0, time.UTC), usage[2].ResetAt, "exports are counted per hour")
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := setupQuotaTest(t)
+
+	r := gin.New()
+	r.Use(Middleware(service))
+	r.POST("/api/orders/create", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(StoreIDKey))
+	})
+
+	request := func(storeID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/orders/create", nil)
+		if storeID != "" {
+			req.Header.Set(StoreHeader, storeID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("small")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "small", w.Body.String())
+	assert.Equal(t, QuotaOrders, w.Header().Get(HeaderQuotaName))
+	assert.Equal(t, "0", w.Header().Get(HeaderQuotaRemaining))
+
+	w = request("small")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "the second order in the minute exceeds the orders quota")
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	w = request("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, DefaultStoreID, w.Body.String())
+
+	w = request("../etc")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request("made-up")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a store without settings of its own is not a store")
+	assert.Contains(t, w.Body.String(), "UNKNOWN_STORE")
+	assert.Empty(t, w.Header().Get(HeaderQuotaName), "nothing is counted for it")
+}
+
+func TestMiddleware_ChargesExports(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Setting{}))
+	require.NoError(t, db.Create(&models.Setting{
+		Key: settings.KeyQuotaExports, Scope: models.SettingScopeStore, ScopeID: "reporting", Type: models.SettingTypeInt, Value: "1",
+	}).Error)
+
+	// Each router counts with a service of its own, so every route starts with a fresh exports quota
+	newRouter := func() *gin.Engine {
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		r := gin.New()
+		r.Use(Middleware(NewService(nil, settings.NewService(db))))
+		r.GET("/api/admin/reports/product-performance", ok)
+		r.GET("/api/admin/reports/sales", ok)
+		r.POST("/api/admin/reports/queries", ok)
+		r.GET("/api/admin/promotions/:id/codes/export", ok)
+		r.GET("/api/admin/promotions/:id/codes", ok)
+		return r
+	}
+	request := func(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(StoreHeader, "reporting")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/api/admin/reports/product-performance?format=csv"},
+		{http.MethodGet, "/api/admin/reports/sales?format=pdf"},
+		{http.MethodPost, "/api/admin/reports/queries"},
+		{http.MethodGet, "/api/admin/promotions/promo-1/codes/export"},
+	} {
+		r := newRouter()
+		w := request(r, route.method, route.path)
+		assert.Equal(t, http.StatusOK, w.Code, route.path)
+		assert.Equal(t, QuotaExports, w.Header().Get(HeaderQuotaName), route.path)
+		assert.Equal(t, http.StatusTooManyRequests, request(r, route.method, route.path).Code, "%s is an export", route.path)
+	}
+
+	r := newRouter()
+	for _, path := range []string{"/api/admin/reports/product-performance", "/api/admin/promotions/promo-1/codes"} {
+		w := request(r, http.MethodGet, path)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, QuotaAPIRequests, w.Header().Get(HeaderQuotaName), "%s is not an export", path)
+	}
+}
@@ -0,0 +1,104 @@
+package recommendations
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new recommendations handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// GetRecommendations handles GET /api/products/:id/recommendations
+func (h *Handler) GetRecommendations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DefaultLimit)))
+
+	recommendations, err := h.service.GetRecommendations(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "FETCH_RECOMMENDATIONS_ERROR", "Failed to fetch recommendations", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Recommendations retrieved successfully", recommendations)
+}
+
+// GetRelatedProducts handles GET /api/products/:id/related?type=&limit=
+func (h *Handler) GetRelatedProducts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DefaultLimit)))
+
+	related, err := h.service.GetRelatedProducts(c.Request.Context(), c.Param("id"), c.Query("type"), limit)
+	if err != nil {
+		h.linkError(c, err, "FETCH_RELATED_PRODUCTS_ERROR", "Failed to fetch related products")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Related products retrieved successfully", related)
+}
+
+// GetProductLinks handles GET /api/admin/products/:id/related
+func (h *Handler) GetProductLinks(c *gin.Context) {
+	links, err := h.service.GetProductLinks(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.linkError(c, err, "FETCH_PRODUCT_LINKS_ERROR", "Failed to fetch product links")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product links retrieved successfully", links)
+}
+
+// SaveProductLink handles POST /api/admin/products/:id/related. It links a related product, cross-sell or
+// upsell to the product, or moves an existing link to a new position.
+func (h *Handler) SaveProductLink(c *gin.Context) {
+	var req SaveProductLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	link, err := h.service.SaveProductLink(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.linkError(c, err, "SAVE_PRODUCT_LINK_ERROR", "Failed to save product link")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product link saved successfully", link)
+}
+
+// DeleteProductLink handles DELETE /api/admin/products/:id/related/:relatedId?type=. Without a type the
+// related product is unlinked for every type.
+func (h *Handler) DeleteProductLink(c *gin.Context) {
+	if err := h.service.DeleteProductLinks(c.Request.Context(), c.Param("id"), c.Param("relatedId"), c.Query("type")); err != nil {
+		h.linkError(c, err, "DELETE_PRODUCT_LINK_ERROR", "Failed to delete product link")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product link deleted successfully", nil)
+}
+
+// linkError answers a failed related products request, with code and message for unexpected errors
+func (h *Handler) linkError(c *gin.Context, err error, code, message string) {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+	case errors.Is(err, ErrLinkNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_LINK_NOT_FOUND", "Product link not found", nil)
+	case errors.Is(err, ErrInvalidLink):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_PRODUCT_LINK", "Invalid product link", err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, code, message, err.Error())
+	}
+}
@@ -0,0 +1,48 @@
+package recommendations
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// computeInterval is how often co-purchases are recomputed from order items
+const computeInterval = 6 * time.Hour
+
+// Module wires "customers also bought" recommendations and curated related products into the application
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the recommendations module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB, deps.Redis).WithReader(deps.ReadDB)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "recommendations"
+}
+
+// Models returns the curated product links table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.ProductLink{}}
+}
+
+// StartJobs recomputes co-purchases in the background
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartComputation(ctx, computeInterval)
+}
+
+// RegisterAPIRoutes sets up the recommendation routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	RegisterRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,183 @@
+package recommendations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// SourceManual marks related products curated by an admin
+	SourceManual = "manual"
+	// SourceCategory marks related products suggested from the product's category when none are curated
+	SourceCategory = "category"
+)
+
+var (
+	ErrInvalidLink  = errors.New("invalid product link")
+	ErrLinkNotFound = errors.New("product link not found")
+)
+
+// linkTypes are the kinds of curated product links
+var linkTypes = map[string]bool{models.LinkRelated: true, models.LinkCrossSell: true, models.LinkUpsell: true}
+
+// SaveProductLinkRequest links a product to another as a related product, cross-sell or upsell
+type SaveProductLinkRequest struct {
+	RelatedProductID string `json:"relatedProductId" binding:"required"`
+	Type             string `json:"type" binding:"required,oneof=related cross_sell upsell"`
+	// Position orders the links of a type, lowest first
+	Position int `json:"position" binding:"min=0"`
+}
+
+// RelatedProduct is a product shown alongside another, with how it is related and where the link came from
+type RelatedProduct struct {
+	Product models.Product `json:"product"`
+	Type    string         `json:"type"`
+	Source  string         `json:"source"`
+}
+
+// GetRelatedProducts returns the active products curated for a product, of linkType or of every type when it
+// is empty, in position order. Without curated links the best sellers of the product's category are
+// suggested as related products instead.
+func (s *Service) GetRelatedProducts(ctx context.Context, productID, linkType string, limit int) ([]RelatedProduct, error) {
+	if linkType != "" && !linkTypes[linkType] {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidLink, linkType)
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	var product models.Product
+	if err := s.reader.WithContext(ctx).Select("id", "category_id").Where("id = ? AND is_active = ?", productID, true).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch product: %w", err)
+	}
+
+	var links []models.ProductLink
+	query := s.reader.WithContext(ctx).
+		Joins("JOIN products ON products.id = product_links.related_product_id AND products.is_active = ? AND products.deleted_at IS NULL", true).
+		Where("product_links.product_id = ?", productID)
+	if linkType != "" {
+		query = query.Where("product_links.type = ?", linkType)
+	}
+	if err := query.Order("product_links.type, product_links.position, product_links.created_at").
+		Limit(limit).Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch product links: %w", err)
+	}
+
+	if len(links) == 0 {
+		suggestions, err := s.categoryBestSellers(ctx, product.CategoryID, map[string]bool{productID: true}, limit)
+		if err != nil {
+			return nil, err
+		}
+		related := make([]RelatedProduct, len(suggestions))
+		for i, suggestion := range suggestions {
+			related[i] = RelatedProduct{Product: suggestion.Product, Type: models.LinkRelated, Source: SourceCategory}
+		}
+		return related, nil
+	}
+
+	ids := make([]string, len(links))
+	for i, link := range links {
+		ids[i] = link.RelatedProductID
+	}
+	products, err := s.activeProducts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	related := make([]RelatedProduct, 0, len(links))
+	for _, link := range links {
+		if p, ok := products[link.RelatedProductID]; ok {
+			related = append(related, RelatedProduct{Product: p, Type: link.Type, Source: SourceManual})
+		}
+	}
+	return related, nil
+}
+
+// GetProductLinks returns every link curated for a product, inactive related products included, for admins
+func (s *Service) GetProductLinks(ctx context.Context, productID string) ([]models.ProductLink, error) {
+	if err := s.findProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	links := []models.ProductLink{}
+	if err := s.db.WithContext(ctx).Preload("RelatedProduct").Where("product_id = ?", productID).
+		Order("type, position, created_at").Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch product links: %w", err)
+	}
+	return links, nil
+}
+
+// SaveProductLink links a product to another, or moves an existing link of the same type to a new position
+func (s *Service) SaveProductLink(ctx context.Context, productID string, req SaveProductLinkRequest) (*models.ProductLink, error) {
+	if !linkTypes[req.Type] {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidLink, req.Type)
+	}
+	if req.RelatedProductID == productID {
+		return nil, fmt.Errorf("%w: a product cannot be related to itself", ErrInvalidLink)
+	}
+	if err := s.findProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	if err := s.findProduct(ctx, req.RelatedProductID); err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			return nil, fmt.Errorf("%w: related product not found", ErrInvalidLink)
+		}
+		return nil, err
+	}
+
+	var link models.ProductLink
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("product_id = ? AND related_product_id = ? AND type = ?", productID, req.RelatedProductID, req.Type).First(&link).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to fetch product link: %w", err)
+		}
+		link.ProductID = productID
+		link.RelatedProductID = req.RelatedProductID
+		link.Type = req.Type
+		link.Position = req.Position
+		if err := tx.Save(&link).Error; err != nil {
+			return fmt.Errorf("failed to save product link: %w", err)
+		}
+		return tx.Preload("RelatedProduct").First(&link, "id = ?", link.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// DeleteProductLinks unlinks a related product from a product, for linkType or for every type when it is empty
+func (s *Service) DeleteProductLinks(ctx context.Context, productID, relatedProductID, linkType string) error {
+	query := s.db.WithContext(ctx).Where("product_id = ? AND related_product_id = ?", productID, relatedProductID)
+	if linkType != "" {
+		query = query.Where("type = ?", linkType)
+	}
+	result := query.Delete(&models.ProductLink{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete product link: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// findProduct checks a live product exists on the primary
+func (s *Service) findProduct(ctx context.Context, productID string) error {
+	if err := s.db.WithContext(ctx).Select("id").First(&models.Product{}, "id = ?", productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return fmt.Errorf("failed to fetch product: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package recommendations
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func relatedIDs(related []RelatedProduct) []string {
+	ids := make([]string, len(related))
+	for i, r := range related {
+		ids[i] = r.Product.ID
+	}
+	return ids
+}
+
+func TestService_RelatedProducts(t *testing.T) {
+	service := setupTestService(t)
+	ctx := context.Background()
+
+	audio := createCategory(t)
+	cables := createCategory(t)
+	headphones := createProduct(t, audio, "Headphones")
+	speaker := createProduct(t, audio, "Speaker")
+	premium := createProduct(t, audio, "Premium Headphones")
+	cable := createProduct(t, cables, "Cable")
+	adapter := createProduct(t, cables, "Adapter")
+	createOrder(t, "delivered", speaker)
+
+	// Without curated links the category's best sellers are suggested
+	related, err := service.GetRelatedProducts(ctx, headphones.ID, "", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{speaker.ID, premium.ID}, relatedIDs(related))
+	assert.Equal(t, SourceCategory, related[0].Source)
+	assert.Equal(t, models.LinkRelated, related[0].Type)
+
+	_, err = service.SaveProductLink(ctx, headphones.ID, SaveProductLinkRequest{RelatedProductID: headphones.ID, Type: models.LinkRelated})
+	assert.ErrorIs(t, err, ErrInvalidLink)
+	_, err = service.SaveProductLink(ctx, headphones.ID, SaveProductLinkRequest{RelatedProductID: "missing", Type: models.LinkRelated})
+	assert.ErrorIs(t, err, ErrInvalidLink)
+	_, err = service.SaveProductLink(ctx, "missing", SaveProductLinkRequest{RelatedProductID: cable.ID, Type: models.LinkCrossSell})
+	assert.ErrorIs(t, err, ErrProductNotFound)
+
+	_, err = service.SaveProductLink(ctx, headphones.ID, SaveProductLinkRequest{RelatedProductID: cable.ID, Type: models.LinkCrossSell, Position: 2})
+	require.NoError(t, err)
+	_, err = service.SaveProductLink(ctx, headphones.ID, SaveProductLinkRequest{RelatedProductID: adapter.ID, Type: models.LinkCrossSell, Position: 1})
+	require.NoError(t, err)
+	link, err := service.SaveProductLink(ctx, headphones.ID, SaveProductLinkRequest{RelatedProductID: premium.ID, Type: models.LinkUpsell})
+	require.NoError(t, err)
+	require.NotNil(t, link.RelatedProduct)
+	assert.Equal(t, premium.Name, link.RelatedProduct.Name)
+
+	// Saving a link again moves it rather than adding another
+	_, err = service.SaveProductLink(ctx, headphones.ID, SaveProductLinkRequest{RelatedProductID: cable.ID, Type: models.LinkCrossSell, Position: 0})
+	require.NoError(t, err)
+	links, err := service.GetProductLinks(ctx, headphones.ID)
+	require.NoError(t, err)
+	assert.Len(t, links, 3)
+
+	related, err = service.GetRelatedProducts(ctx, headphones.ID, "", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{cable.ID, adapter.ID, premium.ID}, relatedIDs(related))
+	assert.Equal(t, SourceManual, related[0].Source)
+	assert.Equal(t, models.LinkCrossSell, related[0].Type)
+
+	related, err = service.GetRelatedProducts(ctx, headphones.ID, models.LinkUpsell, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{premium.ID}, relatedIDs(related))
+	_, err = service.GetRelatedProducts(ctx, headphones.ID, "bundle", 5)
+	assert.ErrorIs(t, err, ErrInvalidLink)
+
+	// Inactive products are left out of the storefront but still listed for admins
+	require.NoError(t, database.GetDB().Model(&adapter).Update("is_active", false).Error)
+	related, err = service.GetRelatedProducts(ctx, headphones.ID, models.LinkCrossSell, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{cable.ID}, relatedIDs(related))
+	links, err = service.GetProductLinks(ctx, headphones.ID)
+	require.NoError(t, err)
+	assert.Len(t, links, 3)
+
+	require.NoError(t, service.DeleteProductLinks(ctx, headphones.ID, cable.ID, ""))
+	assert.ErrorIs(t, service.DeleteProductLinks(ctx, headphones.ID, cable.ID, ""), ErrLinkNotFound)
+
+	// Links are one way
+	related, err = service.GetRelatedProducts(ctx, premium.ID, "", 5)
+	require.NoError(t, err)
+	assert.Equal(t, SourceCategory, related[0].Source)
+}
@@ -0,0 +1,22 @@
+package recommendations
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registers product recommendation and related product routes
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	router.GET("/products/:id/recommendations", handler.GetRecommendations)
+	router.GET("/products/:id/related", handler.GetRelatedProducts)
+
+	admin := router.Group("/admin/products")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("/:id/related", handler.GetProductLinks)
+		admin.POST("/:id/related", handler.SaveProductLink)
+		admin.DELETE("/:id/related/:relatedId", handler.DeleteProductLink)
+	}
+}
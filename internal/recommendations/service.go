@@ -0,0 +1,335 @@
+package recommendations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	recommendationsKeyPrefix = "recommendations:"
+
+	// lookback is how far back orders count towards co-purchases
+	lookback = 180 * 24 * time.Hour
+	// maxRelated is how many co-purchased products are kept per product
+	maxRelated = 20
+
+	// DefaultLimit and MaxLimit bound the number of recommendations returned for a product
+	DefaultLimit = 8
+	MaxLimit     = 20
+)
+
+const (
+	// ReasonBoughtTogether marks products bought in the same orders as the product
+	ReasonBoughtTogether = "bought_together"
+	// ReasonCategoryBestSeller marks best sellers of the product's category filling up the list
+	ReasonCategoryBestSeller = "category_best_seller"
+)
+
+// excludedStatuses are the order statuses whose items do not count as purchases
+var excludedStatuses = []string{"cancelled", "refunded"}
+
+var ErrProductNotFound = errors.New("product not found")
+
+// Related is a product bought together with another, with the number of orders containing both
+type Related struct {
+	ProductID string `json:"productId"`
+	Orders    int64  `json:"orders"`
+}
+
+// Recommendation is a recommended product and why it was recommended
+type Recommendation struct {
+	Product models.Product `json:"product"`
+	Reason  string         `json:"reason"`
+	// Orders is the number of orders the product was bought in together with the viewed product, or for
+	// category best sellers the number of orders it was bought in at all
+	Orders int64 `json:"orders"`
+}
+
+// ServiceInterface defines the interface for the recommendations service
+type ServiceInterface interface {
+	GetRecommendations(ctx context.Context, productID string, limit int) ([]Recommendation, error)
+	GetRelatedProducts(ctx context.Context, productID, linkType string, limit int) ([]RelatedProduct, error)
+	GetProductLinks(ctx context.Context, productID string) ([]models.ProductLink, error)
+	SaveProductLink(ctx context.Context, productID string, req SaveProductLinkRequest) (*models.ProductLink, error)
+	DeleteProductLinks(ctx context.Context, productID, relatedProductID, linkType string) error
+}
+
+type Service struct {
+	db          *gorm.DB // curated product links are written here
+	reader      *gorm.DB // serves recommendation reads; a read replica when configured
+	redisClient *redis.Client
+	now         func() time.Time
+
+	mu    sync.RWMutex
+	local map[string][]Related // used when Redis is not configured
+}
+
+// NewService creates a new recommendations service. Co-purchases are cached in Redis, or in memory when no
+// Redis client is given.
+func NewService(db *gorm.DB, redisClient *redis.Client) *Service {
+	return &Service{
+		db:          db,
+		reader:      db,
+		redisClient: redisClient,
+		now:         time.Now,
+		local:       make(map[string][]Related),
+	}
+}
+
+// WithReader makes the service read orders and products from reader, such as a read replica. Curated
+// product links are still written to the primary. It returns the service for chaining after NewService.
+func (s *Service) WithReader(reader *gorm.DB) *Service {
+	s.reader = reader
+	return s
+}
+
+// ComputeCoPurchases counts, for every pair of products, the orders of the lookback window containing both
+// and caches the most frequent partners of each product. Entries expire after ttl, so products that stop
+// being bought together drop out once a later run no longer finds them.
+func (s *Service) ComputeCoPurchases(ctx context.Context, ttl time.Duration) (int, error) {
+	var pairs []struct {
+		ProductID string
+		RelatedID string
+		Orders    int64
+	}
+	if err := s.reader.WithContext(ctx).Table("order_items a").
+		Select("a.product_id AS product_id, b.product_id AS related_id, COUNT(DISTINCT a.order_id) AS orders").
+		Joins("JOIN order_items b ON b.order_id = a.order_id AND b.product_id <> a.product_id").
+		Joins("JOIN orders o ON o.id = a.order_id").
+		Where("o.status NOT IN ? AND o.created_at >= ?", excludedStatuses, s.now().Add(-lookback)).
+		Group("a.product_id, b.product_id").
+		Scan(&pairs).Error; err != nil {
+		return 0, fmt.Errorf("failed to count co-purchases: %w", err)
+	}
+
+	related := make(map[string][]Related)
+	for _, pair := range pairs {
+		related[pair.ProductID] = append(related[pair.ProductID], Related{ProductID: pair.RelatedID, Orders: pair.Orders})
+	}
+	for productID, list := range related {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Orders != list[j].Orders {
+				return list[i].Orders > list[j].Orders
+			}
+			return list[i].ProductID < list[j].ProductID
+		})
+		if len(list) > maxRelated {
+			list = list[:maxRelated]
+		}
+		related[productID] = list
+	}
+
+	if err := s.store(ctx, related, ttl); err != nil {
+		return 0, err
+	}
+	return len(related), nil
+}
+
+// StartComputation computes co-purchases right away and then every interval until ctx is cancelled
+func (s *Service) StartComputation(ctx context.Context, interval time.Duration) {
+	go func() {
+		s.compute(ctx, 2*interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.compute(ctx, 2*interval)
+			}
+		}
+	}()
+}
+
+// compute runs a computation, logging its outcome
+func (s *Service) compute(ctx context.Context, ttl time.Duration) {
+	started := time.Now()
+	products, err := s.ComputeCoPurchases(ctx, ttl)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Named("recommendations").Warn("Failed to compute co-purchases", map[string]interface{}{"error": err.Error()})
+		}
+		return
+	}
+	logger.Named("recommendations").Info("Computed co-purchases", map[string]interface{}{
+		"products":    products,
+		"duration_ms": time.Since(started).Milliseconds(),
+	})
+}
+
+// GetRecommendations returns active products customers bought together with a product, most frequent first.
+// When there are fewer than limit, the list is filled up with the best sellers of the product's category.
+func (s *Service) GetRecommendations(ctx context.Context, productID string, limit int) ([]Recommendation, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	var product models.Product
+	if err := s.reader.WithContext(ctx).Select("id", "category_id").Where("id = ? AND is_active = ?", productID, true).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch product: %w", err)
+	}
+
+	related, err := s.load(ctx, productID)
+	if err != nil {
+		// The cache only speeds up recommendations; fall back to best sellers without it
+		logger.Named("recommendations").Warn("Failed to load co-purchases", map[string]interface{}{
+			"product_id": productID,
+			"error":      err.Error(),
+		})
+	}
+
+	recommendations := make([]Recommendation, 0, limit)
+	seen := map[string]bool{productID: true}
+	if len(related) > 0 {
+		ids := make([]string, len(related))
+		for i, r := range related {
+			ids[i] = r.ProductID
+		}
+		products, err := s.activeProducts(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range related {
+			if p, ok := products[r.ProductID]; ok && len(recommendations) < limit {
+				recommendations = append(recommendations, Recommendation{Product: p, Reason: ReasonBoughtTogether, Orders: r.Orders})
+				seen[r.ProductID] = true
+			}
+		}
+	}
+
+	if len(recommendations) < limit {
+		bestSellers, err := s.categoryBestSellers(ctx, product.CategoryID, seen, limit-len(recommendations))
+		if err != nil {
+			return nil, err
+		}
+		recommendations = append(recommendations, bestSellers...)
+	}
+	return recommendations, nil
+}
+
+// activeProducts loads the active products among ids
+func (s *Service) activeProducts(ctx context.Context, ids []string) (map[string]models.Product, error) {
+	var products []models.Product
+	if err := s.reader.WithContext(ctx).Preload("Category").Where("id IN ? AND is_active = ?", ids, true).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch products: %w", err)
+	}
+	byID := make(map[string]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+	return byID, nil
+}
+
+// categoryBestSellers returns the active, in-stock products of a category bought in the most orders of the
+// lookback window, newest first among equals, leaving out the products in exclude
+func (s *Service) categoryBestSellers(ctx context.Context, categoryID string, exclude map[string]bool, limit int) ([]Recommendation, error) {
+	excluded := make([]string, 0, len(exclude))
+	for id := range exclude {
+		excluded = append(excluded, id)
+	}
+
+	sales := s.reader.Table("order_items oi").
+		Select("oi.product_id, COUNT(DISTINCT oi.order_id) AS orders").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Where("o.status NOT IN ? AND o.created_at >= ?", excludedStatuses, s.now().Add(-lookback)).
+		Group("oi.product_id")
+
+	var rows []struct {
+		ID     string
+		Orders int64
+	}
+	if err := s.reader.WithContext(ctx).Model(&models.Product{}).
+		Select("products.id, COALESCE(sales.orders, 0) AS orders").
+		Joins("LEFT JOIN (?) AS sales ON sales.product_id = products.id", sales).
+		Where("products.category_id = ? AND products.is_active = ? AND products.inventory > 0 AND products.id NOT IN ?", categoryID, true, excluded).
+		Order("orders DESC, products.created_at DESC").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch category best sellers: %w", err)
+	}
+	if len(rows) == 0 {
+		return []Recommendation{}, nil
+	}
+
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	products, err := s.activeProducts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := make([]Recommendation, 0, len(rows))
+	for _, row := range rows {
+		if p, ok := products[row.ID]; ok {
+			recommendations = append(recommendations, Recommendation{Product: p, Reason: ReasonCategoryBestSeller, Orders: row.Orders})
+		}
+	}
+	return recommendations, nil
+}
+
+// store caches the co-purchases of every product
+func (s *Service) store(ctx context.Context, related map[string][]Related, ttl time.Duration) error {
+	if s.redisClient == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.local = related
+		return nil
+	}
+
+	pipe := s.redisClient.Pipeline()
+	for productID, list := range related {
+		data, err := json.Marshal(list)
+		if err != nil {
+			return fmt.Errorf("failed to marshal co-purchases: %w", err)
+		}
+		pipe.Set(ctx, recommendationsKeyPrefix+productID, data, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save co-purchases to Redis: %w", err)
+	}
+	return nil
+}
+
+// load reads the cached co-purchases of a product
+func (s *Service) load(ctx context.Context, productID string) ([]Related, error) {
+	if s.redisClient == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.local[productID], nil
+	}
+
+	data, err := s.redisClient.Get(ctx, recommendationsKeyPrefix+productID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get co-purchases from Redis: %w", err)
+	}
+
+	var related []Related
+	if err := json.Unmarshal([]byte(data), &related); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal co-purchases: %w", err)
+	}
+	return related, nil
+}
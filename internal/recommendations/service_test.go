@@ -0,0 +1,108 @@
+package recommendations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestService(t *testing.T) *Service {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	return NewService(database.GetDB(), nil)
+}
+
+func createCategory(t *testing.T) models.Category {
+	category := models.Category{Name: "Audio", Slug: uuid.New().String(), IsActive: true}
+	require.NoError(t, database.GetDB().Create(&category).Error)
+	return category
+}
+
+func createProduct(t *testing.T, category models.Category, name string) models.Product {
+	product := models.Product{Name: name, SKU: uuid.New().String(), Price: 50, Inventory: 10, CategoryID: category.ID, IsActive: true}
+	require.NoError(t, database.GetDB().Create(&product).Error)
+	return product
+}
+
+// createOrder creates an order containing one unit of each product for a new customer
+func createOrder(t *testing.T, status string, products ...models.Product) {
+	db := database.GetDB()
+	user := models.User{Email: uuid.New().String() + "@example.com", Password: "hashed", FirstName: "Sam", LastName: "Lee"}
+	require.NoError(t, db.Create(&user).Error)
+	order := models.Order{UserID: user.ID, Status: status, Subtotal: 100, Total: 100}
+	require.NoError(t, db.Create(&order).Error)
+	for _, product := range products {
+		require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: product.ID, Quantity: 1, Price: product.Price}).Error)
+	}
+}
+
+func productIDs(recommendations []Recommendation) []string {
+	ids := make([]string, len(recommendations))
+	for i, r := range recommendations {
+		ids[i] = r.Product.ID
+	}
+	return ids
+}
+
+func TestService_GetRecommendations(t *testing.T) {
+	service := setupTestService(t)
+	ctx := context.Background()
+
+	audio := createCategory(t)
+	cables := createCategory(t)
+	headphones := createProduct(t, audio, "Headphones")
+	amplifier := createProduct(t, audio, "Amplifier")
+	speaker := createProduct(t, audio, "Speaker")
+	turntable := createProduct(t, audio, "Turntable")
+	cable := createProduct(t, cables, "Cable")
+	adapter := createProduct(t, cables, "Adapter")
+
+	createOrder(t, "delivered", headphones, cable, amplifier)
+	createOrder(t, "shipped", headphones, cable)
+	createOrder(t, "delivered", headphones, cable)
+	createOrder(t, "pending", headphones, adapter)
+	createOrder(t, "processing", headphones, adapter)
+	createOrder(t, "cancelled", headphones, adapter)
+	createOrder(t, "cancelled", headphones, adapter)
+	createOrder(t, "delivered", speaker)
+	createOrder(t, "delivered", speaker)
+
+	products, err := service.ComputeCoPurchases(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 4, products)
+
+	recommendations, err := service.GetRecommendations(ctx, headphones.ID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{cable.ID, adapter.ID, amplifier.ID}, productIDs(recommendations), "cancelled orders do not count")
+	assert.Equal(t, ReasonBoughtTogether, recommendations[0].Reason)
+	assert.Equal(t, int64(3), recommendations[0].Orders)
+
+	// Fewer co-purchases than asked for are filled up with best sellers of the category
+	recommendations, err = service.GetRecommendations(ctx, headphones.ID, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{cable.ID, adapter.ID, amplifier.ID, speaker.ID, turntable.ID}, productIDs(recommendations))
+	assert.Equal(t, ReasonCategoryBestSeller, recommendations[3].Reason)
+	assert.Equal(t, int64(2), recommendations[3].Orders)
+
+	// Inactive and out of stock products are not recommended
+	require.NoError(t, database.GetDB().Model(&cable).Update("is_active", false).Error)
+	require.NoError(t, database.GetDB().Model(&turntable).Update("inventory", 0).Error)
+	recommendations, err = service.GetRecommendations(ctx, headphones.ID, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{adapter.ID, amplifier.ID, speaker.ID}, productIDs(recommendations))
+
+	// Products never bought together only get best sellers
+	recommendations, err = service.GetRecommendations(ctx, turntable.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{headphones.ID, speaker.ID, amplifier.ID}, productIDs(recommendations))
+
+	_, err = service.GetRecommendations(ctx, "missing", 5)
+	assert.ErrorIs(t, err, ErrProductNotFound)
+}
@@ -0,0 +1,150 @@
+package reportqueries
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the admin report query endpoints
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new report query handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// QueryResponse is a query with the links to poll it and, once it has succeeded, fetch its result
+type QueryResponse struct {
+	models.ReportQuery
+	StatusURL string `json:"statusUrl"`
+	ResultURL string `json:"resultUrl,omitempty"`
+}
+
+// queryResponse adds the links to a query, under the API version of the request
+func queryResponse(c *gin.Context, query models.ReportQuery) QueryResponse {
+	response := QueryResponse{ReportQuery: query, StatusURL: utils.APIPath(c, "/admin/reports/queries/"+query.ID)}
+	if query.Status == models.ReportQuerySucceeded {
+		response.ResultURL = response.StatusURL + "/result"
+	}
+	return response
+}
+
+// writeError maps report query errors to responses
+func writeError(c *gin.Context, err error, code, message string) {
+	switch {
+	case errors.Is(err, ErrQueryNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "REPORT_QUERY_NOT_FOUND", "Report query not found", nil)
+	case errors.Is(err, ErrUnknownKind), errors.Is(err, ErrInvalidParams), errors.Is(err, ErrInvalidPeriod):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REPORT_QUERY", err.Error(), gin.H{"kinds": []string{KindSalesSummary, KindTopProducts}})
+	case errors.Is(err, ErrTooManyQueries):
+		utils.ErrorResponse(c, http.StatusTooManyRequests, "TOO_MANY_REPORT_QUERIES", err.Error(), nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, code, message, err.Error())
+	}
+}
+
+// Submit handles POST /api/admin/reports/queries, queueing a report to be built in the background
+func (h *Handler) Submit(c *gin.Context) {
+	var req QueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+	query, err := h.service.Submit(c.Request.Context(), c.GetString("user_id"), req)
+	if err != nil {
+		writeError(c, err, "SUBMIT_REPORT_QUERY_FAILED", "Failed to submit report query")
+		return
+	}
+	c.Header("Location", utils.APIPath(c, "/admin/reports/queries/"+query.ID))
+	utils.SuccessResponse(c, http.StatusAccepted, "Report query queued", queryResponse(c, *query))
+}
+
+// List handles GET /api/admin/reports/queries, the signed-in admin's queries
+func (h *Handler) List(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+	queries, total, err := h.service.List(c.Request.Context(), c.GetString("user_id"), page, limit)
+	if err != nil {
+		writeError(c, err, "GET_REPORT_QUERIES_FAILED", "Failed to get report queries")
+		return
+	}
+	responses := make([]QueryResponse, len(queries))
+	for i, query := range queries {
+		responses[i] = queryResponse(c, query)
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Report queries retrieved successfully", "queries", responses, utils.NewPagination(page, limit, total))
+}
+
+// Get handles GET /api/admin/reports/queries/:id, for polling a query's status
+func (h *Handler) Get(c *gin.Context) {
+	query, err := h.service.Get(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		writeError(c, err, "GET_REPORT_QUERY_FAILED", "Failed to get report query")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Report query retrieved successfully", queryResponse(c, *query))
+}
+
+// GetResult handles GET /api/admin/reports/queries/:id/result, returning the report as JSON or, with
+// format=csv, as a CSV download
+func (h *Handler) GetResult(c *gin.Context) {
+	query, table, err := h.service.Result(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if errors.Is(err, ErrQueryNotSucceeded) {
+		utils.ErrorResponse(c, http.StatusConflict, "REPORT_QUERY_NOT_READY", "Report query has not succeeded", gin.H{"status": query.Status, "error": query.Error})
+		return
+	}
+	if err != nil {
+		writeError(c, err, "GET_REPORT_RESULT_FAILED", "Failed to get report result")
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		writeCSV(c, query, table)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Report result retrieved successfully", gin.H{
+		"query":   queryResponse(c, *query),
+		"columns": table.Columns,
+		"rows":    table.Rows,
+	})
+}
+
+// writeCSV sends a report table as a CSV attachment
+func writeCSV(c *gin.Context, query *models.ReportQuery, table *Table) {
+	filename := fmt.Sprintf("%s-%s-%s.csv", query.Kind, query.From.Format("20060102"), query.To.AddDate(0, 0, -1).Format("20060102"))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(table.Columns)
+	for _, row := range table.Rows {
+		record := make([]string, len(table.Columns))
+		for i, column := range table.Columns {
+			record[i] = formatValue(row[column])
+		}
+		_ = w.Write(record)
+	}
+	w.Flush()
+}
+
+// formatValue renders a table cell for CSV; numbers decoded from JSON are float64
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,94 @@
+package reportqueries
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ecommerce-website/internal/reports"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestRouter(service ServiceInterface, adminID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", adminID)
+		c.Next()
+	})
+	handler := NewHandler(service)
+	r.POST("/api/admin/reports/queries", handler.Submit)
+	r.GET("/api/admin/reports/queries", handler.List)
+	r.GET("/api/admin/reports/queries/:id", handler.Get)
+	r.GET("/api/admin/reports/queries/:id/result", handler.GetResult)
+	return r
+}
+
+func TestHandler_SubmitPollAndDownload(t *testing.T) {
+	service := setupTestService(t, &fakeSales{orders: []reports.SalesOrderRow{
+		{OrderID: "o1", CreatedAt: day("2024-03-04"), Units: 2, Subtotal: 100, Total: 118.5, Net: 118.5},
+	}})
+	r := setupTestRouter(service, "admin-1")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/reports/queries",
+		strings.NewReader(`{"kind":"sales_summary","from":"2024-01-01","to":"2024-12-31"}`)))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var submitted struct {
+		Data QueryResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &submitted))
+	statusURL := submitted.Data.StatusURL
+	assert.Equal(t, statusURL, w.Header().Get("Location"))
+	assert.Empty(t, submitted.Data.ResultURL)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, statusURL+"/result", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	_, err := service.RunNext(context.Background())
+	require.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, statusURL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var polled struct {
+		Data QueryResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &polled))
+	assert.Equal(t, "succeeded", polled.Data.Status)
+	assert.Equal(t, statusURL+"/result", polled.Data.ResultURL)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, statusURL+"/result?format=csv", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "sales_summary-20240101-20241231.csv")
+	assert.Equal(t, "period,orders,units,subtotal,tax,shipping,total,refunded,net\n2024-03,1,2,100,0,0,118.5,0,118.5\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	setupTestRouter(service, "admin-2").ServeHTTP(w, httptest.NewRequest(http.MethodGet, statusURL, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandler_SubmitErrors(t *testing.T) {
+	service := setupTestService(t, &fakeSales{})
+	r := setupTestRouter(service, "admin-1")
+	submit := func(body string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/reports/queries", strings.NewReader(body)))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, submit(`{"kind":"sales_summary"}`))
+	assert.Equal(t, http.StatusBadRequest, submit(`{"kind":"churn","from":"2024-01-01","to":"2024-12-31"}`))
+	for i := 0; i < MaxActivePerAdmin; i++ {
+		assert.Equal(t, http.StatusAccepted, submit(`{"kind":"top_products","from":"2024-01-01","to":"2024-12-31"}`))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, submit(`{"kind":"top_products","from":"2024-01-01","to":"2024-12-31"}`))
+}
@@ -0,0 +1,55 @@
+package reportqueries
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// runners is how many queries one instance builds at a time
+	runners = 2
+	// pollInterval is how often an idle runner checks for queued queries
+	pollInterval = 2 * time.Second
+	// cleanupInterval is how often interrupted and expired queries are cleaned up
+	cleanupInterval = time.Hour
+)
+
+// Module runs expensive admin reports in the background, off the HTTP workers
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the report queries module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB, reports.NewService(deps.DB))
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "reportqueries"
+}
+
+// Models returns the report query table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.ReportQuery{}}
+}
+
+// StartJobs starts the query runners
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartRunners(ctx, runners, pollInterval, cleanupInterval)
+}
+
+// RegisterAPIRoutes sets up the admin report query routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,20 @@
+package reportqueries
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin report query routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin/reports/queries")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.POST("", handler.Submit)
+		admin.GET("", handler.List)
+		admin.GET("/:id", handler.Get)
+		admin.GET("/:id/result", handler.GetResult)
+	}
+}
@@ -0,0 +1,439 @@
+package reportqueries
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/reports"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Report kinds that can be queried
+const (
+	KindSalesSummary = "sales_summary" // sales totals per month or year
+	KindTopProducts  = "top_products"  // best selling products by revenue
+)
+
+// Granularities of a sales summary
+const (
+	GranularityMonth = "month"
+	GranularityYear  = "year"
+)
+
+const (
+	// MaxActivePerAdmin is how many queries one admin may have queued or running at once
+	MaxActivePerAdmin = 2
+	// MaxQueryDays bounds the period of one query
+	MaxQueryDays = 10 * 366
+	// defaultTopProducts and maxTopProducts bound the rows of a top products report
+	defaultTopProducts = 50
+	maxTopProducts     = 500
+	// queryTimeout bounds building one report; a query still running after it is failed as interrupted
+	queryTimeout = 30 * time.Minute
+	// retainResults is how long finished queries and their results are kept
+	retainResults = 7 * 24 * time.Hour
+)
+
+var (
+	ErrUnknownKind       = errors.New("unknown report kind")
+	ErrInvalidPeriod     = fmt.Errorf("period must start before it ends and span at most %d days", MaxQueryDays)
+	ErrInvalidParams     = errors.New("invalid report parameters")
+	ErrTooManyQueries    = fmt.Errorf("at most %d report queries may be queued or running at once", MaxActivePerAdmin)
+	ErrQueryNotFound     = errors.New("report query not found")
+	ErrQueryNotSucceeded = errors.New("report query has not succeeded")
+)
+
+// QueryRequest submits a report query. From and To are inclusive days, formatted YYYY-MM-DD.
+type QueryRequest struct {
+	Kind        string `json:"kind" binding:"required"`
+	From        string `json:"from" binding:"required"`
+	To          string `json:"to" binding:"required"`
+	Granularity string `json:"granularity"`
+	Limit       int    `json:"limit"`
+}
+
+// Table is a finished report: its columns in order and one value per column in each row
+type Table struct {
+	Columns []string                 `json:"columns"`
+	Rows    []map[string]interface{} `json:"rows"`
+}
+
+// SalesSource streams the orders and order lines the reports are built from
+type SalesSource interface {
+	StreamSalesOrders(ctx context.Context, from, to time.Time, fn func(reports.SalesOrderRow) error) error
+	StreamSalesItems(ctx context.Context, from, to time.Time, fn func(reports.SalesItemRow) error) error
+}
+
+// ServiceInterface defines the report query operations used by the handlers
+type ServiceInterface interface {
+	Submit(ctx context.Context, adminID string, req QueryRequest) (*models.ReportQuery, error)
+	Get(ctx context.Context, adminID, id string) (*models.ReportQuery, error)
+	List(ctx context.Context, adminID string, page, limit int) ([]models.ReportQuery, int64, error)
+	Result(ctx context.Context, adminID, id string) (*models.ReportQuery, *Table, error)
+}
+
+// Service queues report queries and builds them in the background
+type Service struct {
+	db    *gorm.DB
+	sales SalesSource
+	now   func() time.Time
+}
+
+// NewService creates a report query service building reports from sales
+func NewService(db *gorm.DB, sales SalesSource) *Service {
+	return &Service{db: db, sales: sales, now: time.Now}
+}
+
+// Submit validates a query and queues it for the admin, unless they already have MaxActivePerAdmin queries
+// queued or running
+func (s *Service) Submit(ctx context.Context, adminID string, req QueryRequest) (*models.ReportQuery, error) {
+	query, err := newQuery(adminID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the admin's account row so two submissions at once cannot both pass the limit
+		var admin []models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", adminID).Find(&admin).Error; err != nil {
+			return err
+		}
+		var active int64
+		if err := tx.Model(&models.ReportQuery{}).
+			Where("admin_id = ? AND status IN ?", adminID, []string{models.ReportQueryQueued, models.ReportQueryRunning}).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active >= MaxActivePerAdmin {
+			return ErrTooManyQueries
+		}
+		return tx.Create(query).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// newQuery builds a queued query from a request
+func newQuery(adminID string, req QueryRequest) (*models.ReportQuery, error) {
+	from, err := time.Parse("2006-01-02", req.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidParams)
+	}
+	to, err := time.Parse("2006-01-02", req.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidParams)
+	}
+	end := to.AddDate(0, 0, 1)
+	if !from.Before(end) || end.Sub(from) > MaxQueryDays*24*time.Hour {
+		return nil, ErrInvalidPeriod
+	}
+
+	query := &models.ReportQuery{AdminID: adminID, Kind: req.Kind, From: from, To: end, Status: models.ReportQueryQueued}
+	switch req.Kind {
+	case KindSalesSummary:
+		query.Granularity = req.Granularity
+		if query.Granularity == "" {
+			query.Granularity = GranularityMonth
+		}
+		if query.Granularity != GranularityMonth && query.Granularity != GranularityYear {
+			return nil, fmt.Errorf("%w: granularity must be month or year", ErrInvalidParams)
+		}
+	case KindTopProducts:
+		query.Limit = req.Limit
+		if query.Limit == 0 {
+			query.Limit = defaultTopProducts
+		}
+		if query.Limit < 1 || query.Limit > maxTopProducts {
+			return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidParams, maxTopProducts)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, req.Kind)
+	}
+	return query, nil
+}
+
+// Get returns one of the admin's queries
+func (s *Service) Get(ctx context.Context, adminID, id string) (*models.ReportQuery, error) {
+	var query models.ReportQuery
+	if err := s.db.WithContext(ctx).Where("id = ? AND admin_id = ?", id, adminID).First(&query).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQueryNotFound
+		}
+		return nil, err
+	}
+	return &query, nil
+}
+
+// List returns the admin's queries, newest first
+func (s *Service) List(ctx context.Context, adminID string, page, limit int) ([]models.ReportQuery, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.ReportQuery{}).Where("admin_id = ?", adminID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var queries []models.ReportQuery
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&queries).Error; err != nil {
+		return nil, 0, err
+	}
+	return queries, total, nil
+}
+
+// Result returns one of the admin's succeeded queries with its report
+func (s *Service) Result(ctx context.Context, adminID, id string) (*models.ReportQuery, *Table, error) {
+	query, err := s.Get(ctx, adminID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if query.Status != models.ReportQuerySucceeded {
+		return query, nil, ErrQueryNotSucceeded
+	}
+	var table Table
+	if err := json.Unmarshal([]byte(query.Result), &table); err != nil {
+		return nil, nil, fmt.Errorf("failed to read report result: %w", err)
+	}
+	return query, &table, nil
+}
+
+// RunNext claims the oldest queued query and builds it, reporting whether there was one. The claim is an
+// update conditioned on the query still being queued, so runners on several instances never build it twice.
+func (s *Service) RunNext(ctx context.Context) (bool, error) {
+	var query models.ReportQuery
+	err := s.db.WithContext(ctx).Where("status = ?", models.ReportQueryQueued).Order("created_at ASC").First(&query).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	started := s.now()
+	result := s.db.WithContext(ctx).Model(&models.ReportQuery{}).
+		Where("id = ? AND status = ?", query.ID, models.ReportQueryQueued).
+		Updates(map[string]interface{}{"status": models.ReportQueryRunning, "started_at": started})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return true, nil
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	table, buildErr := s.build(runCtx, &query)
+	cancel()
+
+	updates := map[string]interface{}{"finished_at": s.now()}
+	if buildErr == nil {
+		encoded, err := json.Marshal(table)
+		if err != nil {
+			buildErr = err
+		} else {
+			updates["status"] = models.ReportQuerySucceeded
+			updates["result"] = string(encoded)
+			updates["result_rows"] = len(table.Rows)
+		}
+	}
+	if buildErr != nil {
+		updates["status"] = models.ReportQueryFailed
+		updates["error"] = buildErr.Error()
+		logger.Named("reportqueries").Warn("Report query failed", map[string]interface{}{"query_id": query.ID, "kind": query.Kind, "error": buildErr.Error()})
+	}
+	// Record the outcome even when ctx was cancelled mid-run, so the query does not stay running
+	if err := s.db.Model(&models.ReportQuery{}).Where("id = ?", query.ID).Updates(updates).Error; err != nil {
+		return true, fmt.Errorf("failed to record report query: %w", err)
+	}
+	return true, nil
+}
+
+// build runs a query and returns its report
+func (s *Service) build(ctx context.Context, query *models.ReportQuery) (*Table, error) {
+	switch query.Kind {
+	case KindSalesSummary:
+		return s.salesSummary(ctx, query)
+	case KindTopProducts:
+		return s.topProducts(ctx, query)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, query.Kind)
+	}
+}
+
+// salesSummary totals the orders of the period per month or year, oldest period first
+func (s *Service) salesSummary(ctx context.Context, query *models.ReportQuery) (*Table, error) {
+	layout := "2006-01"
+	if query.Granularity == GranularityYear {
+		layout = "2006"
+	}
+
+	type totals struct {
+		orders, units                                 int64
+		subtotal, tax, shipping, total, refunded, net float64
+	}
+	var periods []string
+	byPeriod := make(map[string]*totals)
+	err := s.sales.StreamSalesOrders(ctx, query.From, query.To, func(row reports.SalesOrderRow) error {
+		period := row.CreatedAt.UTC().Format(layout)
+		t, ok := byPeriod[period]
+		if !ok {
+			t = &totals{}
+			byPeriod[period] = t
+			periods = append(periods, period)
+		}
+		t.orders++
+		t.units += row.Units
+		t.subtotal += row.Subtotal
+		t.tax += row.Tax
+		t.shipping += row.Shipping
+		t.total += row.Total
+		t.refunded += row.Refunded
+		t.net += row.Net
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	table := &Table{Columns: []string{"period", "orders", "units", "subtotal", "tax", "shipping", "total", "refunded", "net"}}
+	for _, period := range periods {
+		t := byPeriod[period]
+		table.Rows = append(table.Rows, map[string]interface{}{
+			"period":   period,
+			"orders":   t.orders,
+			"units":    t.units,
+			"subtotal": roundAmount(t.subtotal),
+			"tax":      roundAmount(t.tax),
+			"shipping": roundAmount(t.shipping),
+			"total":    roundAmount(t.total),
+			"refunded": roundAmount(t.refunded),
+			"net":      roundAmount(t.net),
+		})
+	}
+	return table, nil
+}
+
+// topProducts ranks the products sold in the period by revenue
+func (s *Service) topProducts(ctx context.Context, query *models.ReportQuery) (*Table, error) {
+	type product struct {
+		id, sku, name string
+		orders        map[string]bool
+		units         int64
+		revenue       float64
+	}
+	byProduct := make(map[string]*product)
+	err := s.sales.StreamSalesItems(ctx, query.From, query.To, func(row reports.SalesItemRow) error {
+		p, ok := byProduct[row.ProductID]
+		if !ok {
+			p = &product{id: row.ProductID, sku: row.SKU, name: row.ProductName, orders: make(map[string]bool)}
+			byProduct[row.ProductID] = p
+		}
+		p.orders[row.OrderID] = true
+		p.units += row.Quantity
+		p.revenue += row.LineTotal
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]*product, 0, len(byProduct))
+	for _, p := range byProduct {
+		ranked = append(ranked, p)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].revenue != ranked[j].revenue {
+			return ranked[i].revenue > ranked[j].revenue
+		}
+		return ranked[i].id < ranked[j].id
+	})
+	if len(ranked) > query.Limit {
+		ranked = ranked[:query.Limit]
+	}
+
+	table := &Table{Columns: []string{"rank", "productId", "sku", "name", "orders", "units", "revenue"}}
+	for i, p := range ranked {
+		table.Rows = append(table.Rows, map[string]interface{}{
+			"rank":      i + 1,
+			"productId": p.id,
+			"sku":       p.sku,
+			"name":      p.name,
+			"orders":    len(p.orders),
+			"units":     p.units,
+			"revenue":   roundAmount(p.revenue),
+		})
+	}
+	return table, nil
+}
+
+// roundAmount rounds a rupee amount to paise
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Cleanup fails queries left running past queryTimeout, as when the instance building them stopped, and
+// deletes finished queries past retention
+func (s *Service) Cleanup(ctx context.Context) error {
+	now := s.now()
+	if err := s.db.WithContext(ctx).Model(&models.ReportQuery{}).
+		Where("status = ? AND started_at < ?", models.ReportQueryRunning, now.Add(-queryTimeout-time.Minute)).
+		Updates(map[string]interface{}{"status": models.ReportQueryFailed, "error": "interrupted", "finished_at": now}).Error; err != nil {
+		return fmt.Errorf("failed to fail interrupted report queries: %w", err)
+	}
+	if err := s.db.WithContext(ctx).
+		Where("status IN ? AND finished_at < ?", []string{models.ReportQuerySucceeded, models.ReportQueryFailed}, now.Add(-retainResults)).
+		Delete(&models.ReportQuery{}).Error; err != nil {
+		return fmt.Errorf("failed to delete old report queries: %w", err)
+	}
+	return nil
+}
+
+// StartRunners starts workers goroutines building queued queries, each checking for one every interval, and
+// cleans up old queries every cleanupInterval, until ctx is cancelled
+func (s *Service) StartRunners(ctx context.Context, workers int, interval, cleanupInterval time.Duration) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					// Drain the queue before waiting again
+					for ctx.Err() == nil {
+						ran, err := s.RunNext(ctx)
+						if err != nil && ctx.Err() == nil {
+							logger.Named("reportqueries").Warn("Failed to run report query", map[string]interface{}{"error": err.Error()})
+						}
+						if !ran || err != nil {
+							break
+						}
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		ticker := time.NewTicker(cleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Cleanup(ctx); err != nil && ctx.Err() == nil {
+					logger.Named("reportqueries").Warn("Failed to clean up report queries", map[string]interface{}{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
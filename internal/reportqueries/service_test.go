@@ -0,0 +1,222 @@
+package reportqueries
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/reports"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSales streams fixed orders and order lines
+type fakeSales struct {
+	orders []reports.SalesOrderRow
+	items  []reports.SalesItemRow
+	err    error
+}
+
+func (f *fakeSales) StreamSalesOrders(ctx context.Context, from, to time.Time, fn func(reports.SalesOrderRow) error) error {
+	if f.err != nil {
+		return f.err
+	}
+	for _, row := range f.orders {
+		if row.CreatedAt.Before(from) || !row.CreatedAt.Before(to) {
+			continue
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeSales) StreamSalesItems(ctx context.Context, from, to time.Time, fn func(reports.SalesItemRow) error) error {
+	if f.err != nil {
+		return f.err
+	}
+	for _, row := range f.items {
+		if row.CreatedAt.Before(from) || !row.CreatedAt.Before(to) {
+			continue
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setupTestService(t *testing.T, sales SalesSource) *Service {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	return NewService(database.GetDB(), sales)
+}
+
+func day(value string) time.Time {
+	t, _ := time.Parse("2006-01-02", value)
+	return t
+}
+
+func TestService_SubmitValidates(t *testing.T) {
+	service := setupTestService(t, &fakeSales{})
+	ctx := context.Background()
+
+	_, err := service.Submit(ctx, "admin-1", QueryRequest{Kind: "churn", From: "2024-01-01", To: "2024-12-31"})
+	assert.ErrorIs(t, err, ErrUnknownKind)
+	_, err = service.Submit(ctx, "admin-1", QueryRequest{Kind: KindSalesSummary, From: "2024-12-31", To: "2024-01-01"})
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+	_, err = service.Submit(ctx, "admin-1", QueryRequest{Kind: KindSalesSummary, From: "2000-01-01", To: "2024-01-01"})
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+	_, err = service.Submit(ctx, "admin-1", QueryRequest{Kind: KindSalesSummary, From: "2024-01-01", To: "2024-12-31", Granularity: "week"})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+	_, err = service.Submit(ctx, "admin-1", QueryRequest{Kind: KindTopProducts, From: "2024-01-01", To: "2024-12-31", Limit: maxTopProducts + 1})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+
+	query, err := service.Submit(ctx, "admin-1", QueryRequest{Kind: KindTopProducts, From: "2024-01-01", To: "2024-12-31"})
+	require.NoError(t, err)
+	assert.Equal(t, models.ReportQueryQueued, query.Status)
+	assert.Equal(t, defaultTopProducts, query.Limit)
+	assert.Equal(t, day("2025-01-01"), query.To.UTC(), "the last day is included")
+}
+
+func TestService_SubmitLimitsActiveQueriesPerAdmin(t *testing.T) {
+	service := setupTestService(t, &fakeSales{})
+	ctx := context.Background()
+	req := QueryRequest{Kind: KindSalesSummary, From: "2020-01-01", To: "2024-12-31"}
+
+	for i := 0; i < MaxActivePerAdmin; i++ {
+		_, err := service.Submit(ctx, "admin-1", req)
+		require.NoError(t, err)
+	}
+	_, err := service.Submit(ctx, "admin-1", req)
+	assert.ErrorIs(t, err, ErrTooManyQueries)
+	_, err = service.Submit(ctx, "admin-2", req)
+	assert.NoError(t, err, "the limit is per admin")
+
+	// Finishing a query frees a slot
+	ran, err := service.RunNext(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+	_, err = service.Submit(ctx, "admin-1", req)
+	assert.NoError(t, err)
+}
+
+func TestService_RunNextBuildsSalesSummary(t *testing.T) {
+	sales := &fakeSales{orders: []reports.SalesOrderRow{
+		{OrderID: "o1", CreatedAt: day("2023-03-04"), Units: 2, Subtotal: 100, Tax: 18, Total: 118, Net: 118},
+		{OrderID: "o2", CreatedAt: day("2023-11-20"), Units: 1, Subtotal: 50.1, Tax: 9.02, Total: 59.12, Refunded: 59.12},
+		{OrderID: "o3", CreatedAt: day("2024-02-01"), Units: 3, Subtotal: 30, Total: 30, Net: 30},
+		{OrderID: "o4", CreatedAt: day("2025-02-01"), Units: 9, Subtotal: 900, Total: 900, Net: 900},
+	}}
+	service := setupTestService(t, sales)
+	ctx := context.Background()
+
+	query, err := service.Submit(ctx, "admin-1", QueryRequest{Kind: KindSalesSummary, From: "2023-01-01", To: "2024-12-31", Granularity: GranularityYear})
+	require.NoError(t, err)
+	_, _, err = service.Result(ctx, "admin-1", query.ID)
+	assert.ErrorIs(t, err, ErrQueryNotSucceeded)
+
+	ran, err := service.RunNext(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+	ran, err = service.RunNext(ctx)
+	require.NoError(t, err)
+	assert.False(t, ran)
+
+	_, _, err = service.Result(ctx, "admin-2", query.ID)
+	assert.ErrorIs(t, err, ErrQueryNotFound, "admins only see their own queries")
+	done, table, err := service.Result(ctx, "admin-1", query.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReportQuerySucceeded, done.Status)
+	assert.Equal(t, 2, done.ResultRows)
+	require.Len(t, table.Rows, 2)
+	assert.Equal(t, "2023", table.Rows[0]["period"])
+	assert.EqualValues(t, 2, table.Rows[0]["orders"])
+	assert.EqualValues(t, 150.1, table.Rows[0]["subtotal"])
+	assert.EqualValues(t, 59.12, table.Rows[0]["refunded"])
+	assert.Equal(t, "2024", table.Rows[1]["period"])
+	assert.EqualValues(t, 3, table.Rows[1]["units"])
+}
+
+func TestService_RunNextBuildsTopProducts(t *testing.T) {
+	sales := &fakeSales{items: []reports.SalesItemRow{
+		{OrderID: "o1", CreatedAt: day("2024-01-02"), ProductID: "p1", SKU: "A", ProductName: "Alpha", Quantity: 1, LineTotal: 10},
+		{OrderID: "o1", CreatedAt: day("2024-01-02"), ProductID: "p2", SKU: "B", ProductName: "Beta", Quantity: 2, LineTotal: 40},
+		{OrderID: "o2", CreatedAt: day("2024-05-02"), ProductID: "p1", SKU: "A", ProductName: "Alpha", Quantity: 4, LineTotal: 40},
+		{OrderID: "o3", CreatedAt: day("2024-06-02"), ProductID: "p3", SKU: "C", ProductName: "Gamma", Quantity: 1, LineTotal: 5},
+	}}
+	service := setupTestService(t, sales)
+	ctx := context.Background()
+
+	query, err := service.Submit(ctx, "admin-1", QueryRequest{Kind: KindTopProducts, From: "2024-01-01", To: "2024-12-31", Limit: 2})
+	require.NoError(t, err)
+	_, err = service.RunNext(ctx)
+	require.NoError(t, err)
+
+	_, table, err := service.Result(ctx, "admin-1", query.ID)
+	require.NoError(t, err)
+	require.Len(t, table.Rows, 2)
+	assert.Equal(t, "p1", table.Rows[0]["productId"], "ties on revenue are ranked by product ID")
+	assert.EqualValues(t, 2, table.Rows[0]["orders"])
+	assert.EqualValues(t, 5, table.Rows[0]["units"])
+	assert.Equal(t, "p2", table.Rows[1]["productId"])
+	assert.EqualValues(t, 2, table.Rows[1]["rank"])
+}
+
+func TestService_RunNextRecordsFailures(t *testing.T) {
+	service := setupTestService(t, &fakeSales{err: errors.New("replica unavailable")})
+	ctx := context.Background()
+
+	query, err := service.Submit(ctx, "admin-1", QueryRequest{Kind: KindSalesSummary, From: "2024-01-01", To: "2024-12-31"})
+	require.NoError(t, err)
+	ran, err := service.RunNext(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	failed, err := service.Get(ctx, "admin-1", query.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReportQueryFailed, failed.Status)
+	assert.Equal(t, "replica unavailable", failed.Error)
+	assert.NotNil(t, failed.FinishedAt)
+}
+
+func TestService_Cleanup(t *testing.T) {
+	service := setupTestService(t, &fakeSales{})
+	ctx := context.Background()
+	db := database.GetDB()
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	stale := now.Add(-queryTimeout - 2*time.Minute)
+	recent := now.Add(-time.Minute)
+	old := now.Add(-retainResults - time.Hour)
+	queries := []models.ReportQuery{
+		{AdminID: "admin-1", Kind: KindSalesSummary, Status: models.ReportQueryRunning, StartedAt: &stale},
+		{AdminID: "admin-1", Kind: KindSalesSummary, Status: models.ReportQueryRunning, StartedAt: &recent},
+		{AdminID: "admin-1", Kind: KindSalesSummary, Status: models.ReportQuerySucceeded, FinishedAt: &old},
+		{AdminID: "admin-1", Kind: KindSalesSummary, Status: models.ReportQuerySucceeded, FinishedAt: &recent},
+	}
+	for i := range queries {
+		require.NoError(t, db.Create(&queries[i]).Error)
+	}
+
+	require.NoError(t, service.Cleanup(ctx))
+
+	var interrupted models.ReportQuery
+	require.NoError(t, db.First(&interrupted, "id = ?", queries[0].ID).Error)
+	assert.Equal(t, models.ReportQueryFailed, interrupted.Status)
+	assert.Equal(t, "interrupted", interrupted.Error)
+
+	var running models.ReportQuery
+	require.NoError(t, db.First(&running, "id = ?", queries[1].ID).Error)
+	assert.Equal(t, models.ReportQueryRunning, running.Status)
+
+	var remaining int64
+	require.NoError(t, db.Model(&models.ReportQuery{}).Where("id IN ?", []string{queries[2].ID, queries[3].ID}).Count(&remaining).Error)
+	assert.EqualValues(t, 1, remaining, "only the old finished query is deleted")
+}
@@ -0,0 +1,232 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ecommerce-website/internal/cart"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FunnelSteps lists the checkout funnel steps in order
+var FunnelSteps = []string{
+	models.FunnelStepCartAdd,
+	models.FunnelStepCheckoutStart,
+	models.FunnelStepAddressSubmit,
+	models.FunnelStepPaymentAttempt,
+	models.FunnelStepOrderSuccess,
+}
+
+// funnelRoutes maps the successful requests that mark a funnel event, by method and route, to the event
+var funnelRoutes = map[string]string{
+	"POST /api/cart/add":              models.FunnelStepCartAdd,
+	"DELETE /api/cart/remove":         models.FunnelEventCartRemove,
+	"POST /api/shipping/rates":        models.FunnelStepCheckoutStart,
+	"POST /api/users/addresses":       models.FunnelStepAddressSubmit,
+	"POST /api/payments/create-order": models.FunnelStepPaymentAttempt,
+	"POST /api/payments/verify":       models.FunnelStepOrderSuccess,
+}
+
+// ClientFunnelSteps are the steps the storefront may report itself, for checkout pages that make no API
+// call of their own (opening checkout, entering a guest address). Payment and order steps are only
+// recorded from the requests that perform them.
+var ClientFunnelSteps = map[string]bool{
+	models.FunnelStepCheckoutStart: true,
+	models.FunnelStepAddressSubmit: true,
+}
+
+// sessionCookie is the cart session cookie that identifies guest shoppers
+const sessionCookie = "session_id"
+
+// FunnelEventInput is a funnel event to record; at least one of SessionID and UserID is needed
+type FunnelEventInput struct {
+	Step      string
+	SessionID string
+	UserID    string
+	At        time.Time
+}
+
+// FunnelStep is one step of the funnel report
+type FunnelStep struct {
+	Step string `json:"step"`
+	// Sessions counts shopper journeys that reached this step and every earlier one in the period
+	Sessions int64 `json:"sessions"`
+	// DropOff counts journeys of the previous step that did not reach this one
+	DropOff     int64   `json:"dropOff"`
+	DropOffRate float64 `json:"dropOffRate"`
+	// Conversion is the share of journeys of the first step that reached this one
+	Conversion float64 `json:"conversion"`
+}
+
+// FunnelReport shows how shoppers progress from adding to the cart to a paid order
+type FunnelReport struct {
+	Steps []FunnelStep `json:"steps"`
+	// CartRemovals counts journeys that removed something from their cart
+	CartRemovals int64 `json:"cartRemovals"`
+}
+
+// RecordFunnelEvent stores a funnel event and stitches the session to the user: once a session is seen
+// signed in, its earlier guest events are attributed to the user, and later guest events of the session
+// inherit the user too, so a journey that crosses sign-in counts once.
+func (s *Service) RecordFunnelEvent(ctx context.Context, input FunnelEventInput) error {
+	if input.SessionID == "" && input.UserID == "" {
+		return nil
+	}
+	if input.At.IsZero() {
+		input.At = time.Now()
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userID := input.UserID
+		if userID == "" {
+			var stitched []string
+			if err := tx.Model(&models.FunnelEvent{}).
+				Where("session_id = ? AND user_id IS NOT NULL", input.SessionID).
+				Limit(1).Pluck("user_id", &stitched).Error; err != nil {
+				return err
+			}
+			if len(stitched) > 0 {
+				userID = stitched[0]
+			}
+		}
+
+		event := models.FunnelEvent{Step: input.Step, CreatedAt: input.At}
+		if input.SessionID != "" {
+			event.SessionID = &input.SessionID
+		}
+		if userID != "" {
+			event.UserID = &userID
+		}
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+
+		if input.SessionID != "" && input.UserID != "" {
+			return tx.Model(&models.FunnelEvent{}).
+				Where("session_id = ? AND user_id IS NULL", input.SessionID).
+				Update("user_id", input.UserID).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record funnel event: %w", err)
+	}
+	return nil
+}
+
+// GetFunnel reports how many shopper journeys reached each funnel step over the days [from, to]. A journey
+// is a signed-in user, or a guest session that never signed in.
+func (s *Service) GetFunnel(ctx context.Context, from, to time.Time) (*FunnelReport, error) {
+	var rows []struct {
+		Journey string
+		Step    string
+	}
+	if err := s.db.WithContext(ctx).Model(&models.FunnelEvent{}).
+		Select("COALESCE(user_id, session_id) AS journey, step").
+		Where("created_at >= ? AND created_at < ?", utils.StartOfDay(from), utils.StartOfDay(to).AddDate(0, 0, 1)).
+		Group("COALESCE(user_id, session_id), step").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get funnel events: %w", err)
+	}
+
+	journeys := make(map[string]map[string]bool)
+	for _, row := range rows {
+		if journeys[row.Journey] == nil {
+			journeys[row.Journey] = make(map[string]bool)
+		}
+		journeys[row.Journey][row.Step] = true
+	}
+
+	report := &FunnelReport{Steps: make([]FunnelStep, len(FunnelSteps))}
+	for _, steps := range journeys {
+		if steps[models.FunnelEventCartRemove] {
+			report.CartRemovals++
+		}
+		for i, step := range FunnelSteps {
+			if !steps[step] {
+				break
+			}
+			report.Steps[i].Sessions++
+		}
+	}
+
+	for i, step := range FunnelSteps {
+		current := &report.Steps[i]
+		current.Step = step
+		if i == 0 {
+			if current.Sessions > 0 {
+				current.Conversion = 1
+			}
+			continue
+		}
+		previous := report.Steps[i-1].Sessions
+		current.DropOff = previous - current.Sessions
+		if previous > 0 {
+			current.DropOffRate = float64(current.DropOff) / float64(previous)
+		}
+		if first := report.Steps[0].Sessions; first > 0 {
+			current.Conversion = float64(current.Sessions) / float64(first)
+		}
+	}
+
+	return report, nil
+}
+
+// maxPendingFunnelWrites bounds how many funnel event writes may be in flight at once
+const maxPendingFunnelWrites = 32
+
+// TrackFunnel records a funnel event for every successful request that performs a funnel step. Like
+// product views, writes run in the background and are dropped when too many are already in flight.
+func TrackFunnel(service ServiceInterface) gin.HandlerFunc {
+	pending := make(chan struct{}, maxPendingFunnelWrites)
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		step, ok := funnelRoutes[c.Request.Method+" "+utils.UnversionedPath(c.FullPath())]
+		if !ok || c.Writer.Status() < http.StatusOK || c.Writer.Status() >= http.StatusMultipleChoices {
+			return
+		}
+
+		select {
+		case pending <- struct{}{}:
+		default:
+			return
+		}
+
+		input := FunnelEventInput{Step: step, SessionID: funnelSession(c), UserID: c.GetString("user_id"), At: time.Now()}
+		go func() {
+			defer func() { <-pending }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), viewWriteTimeout)
+			defer cancel()
+			if err := service.RecordFunnelEvent(ctx, input); err != nil {
+				logger.Named("reports").Warn("Failed to record funnel event", map[string]interface{}{"step": step, "error": err.Error()})
+			}
+		}()
+	}
+}
+
+// funnelSession returns the cart session of the request, including one the response has just issued
+func funnelSession(c *gin.Context) string {
+	sessionID, err := c.Cookie(sessionCookie)
+	if err != nil || sessionID == "" {
+		for _, cookie := range (&http.Response{Header: c.Writer.Header()}).Cookies() {
+			if cookie.Name == sessionCookie {
+				sessionID = cookie.Value
+			}
+		}
+	}
+	// User cart IDs are not sessions, and real session IDs fit the column
+	if _, isUserCart := cart.CartOwner(sessionID); isUserCart || len(sessionID) > 64 {
+		return ""
+	}
+	return sessionID
+}
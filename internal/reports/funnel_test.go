@@ -0,0 +1,129 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Funnel(t *testing.T) {
+	setupTestDB(t)
+	service := NewService(database.GetDB())
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	record := func(step, sessionID, userID string) {
+		require.NoError(t, service.RecordFunnelEvent(ctx, FunnelEventInput{Step: step, SessionID: sessionID, UserID: userID, At: day}))
+	}
+
+	// A guest adds to the cart, signs in at checkout and pays: one journey
+	record(models.FunnelStepCartAdd, "s1", "")
+	record(models.FunnelStepCheckoutStart, "s1", "u1")
+	record(models.FunnelStepAddressSubmit, "s1", "")
+	record(models.FunnelStepPaymentAttempt, "", "u1")
+	record(models.FunnelStepOrderSuccess, "", "u1")
+	// A guest abandons after starting checkout
+	record(models.FunnelStepCartAdd, "s2", "")
+	record(models.FunnelEventCartRemove, "s2", "")
+	record(models.FunnelStepCheckoutStart, "s2", "")
+	// A guest skips the cart step; without it the rest of the journey does not count
+	record(models.FunnelStepCheckoutStart, "s3", "")
+	// Events outside the period are ignored
+	require.NoError(t, service.RecordFunnelEvent(ctx, FunnelEventInput{Step: models.FunnelStepCartAdd, SessionID: "s4", At: day.AddDate(0, 0, -3)}))
+
+	var guestEvents int64
+	database.GetDB().Model(&models.FunnelEvent{}).Where("session_id = ? AND user_id IS NULL", "s1").Count(&guestEvents)
+	assert.Zero(t, guestEvents, "events of a session are stitched to the user who signed in")
+
+	report, err := service.GetFunnel(ctx, day, day)
+	require.NoError(t, err)
+	require.Len(t, report.Steps, len(FunnelSteps))
+
+	sessions := make([]int64, len(report.Steps))
+	for i, step := range report.Steps {
+		assert.Equal(t, FunnelSteps[i], step.Step)
+		sessions[i] = step.Sessions
+	}
+	assert.Equal(t, []int64{2, 2, 1, 1, 1}, sessions)
+	assert.Equal(t, int64(1), report.Steps[2].DropOff)
+	assert.InDelta(t, 0.5, report.Steps[2].DropOffRate, 0.001)
+	assert.InDelta(t, 0.5, report.Steps[4].Conversion, 0.001)
+	assert.Equal(t, int64(1), report.CartRemovals)
+}
+
+func TestTrackFunnel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorded := make(chan FunnelEventInput, 2)
+	mockService := new(MockService)
+	mockService.On("RecordFunnelEvent", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { recorded <- args.Get(1).(FunnelEventInput) }).
+		Return(nil)
+
+	r := gin.New()
+	r.Use(TrackFunnel(mockService))
+	r.POST("/api/cart/add", func(c *gin.Context) {
+		c.SetCookie(sessionCookie, "guest-session", 3600, "/", "", false, true)
+		c.Status(http.StatusOK)
+	})
+	r.DELETE("/api/cart/remove", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+	r.GET("/api/cart", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, request := range []struct{ method, url string }{
+		{"GET", "/api/cart"},
+		{"DELETE", "/api/cart/remove"},
+		{"POST", "/api/cart/add"},
+	} {
+		req, _ := http.NewRequest(request.method, request.url, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	select {
+	case input := <-recorded:
+		assert.Equal(t, models.FunnelStepCartAdd, input.Step)
+		assert.Equal(t, "guest-session", input.SessionID, "a session issued by the response is used")
+	case <-time.After(time.Second):
+		t.Fatal("expected funnel event to be recorded")
+	}
+	mockService.AssertNumberOfCalls(t, "RecordFunnelEvent", 1)
+}
+
+func TestHandler_RecordFunnelStep(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("RecordFunnelEvent", mock.Anything, mock.MatchedBy(func(input FunnelEventInput) bool {
+		return input.Step == models.FunnelStepCheckoutStart && input.SessionID == "guest-session"
+	})).Return(nil)
+
+	r := gin.New()
+	r.POST("/api/analytics/events", NewHandler(mockService).RecordFunnelStep)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/analytics/events", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: sessionCookie, Value: "guest-session"})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(`{"step":"checkout_start"}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	w = send(`{"step":"order_success"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_FUNNEL_STEP")
+	mockService.AssertNumberOfCalls(t, "RecordFunnelEvent", 1)
+}
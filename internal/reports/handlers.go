@@ -0,0 +1,459 @@
+package reports
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/pdf"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new reports handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetProductPerformance handles GET /api/admin/reports/product-performance (admin only)
+func (h *Handler) GetProductPerformance(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", err.Error(), nil)
+		return
+	}
+
+	sortBy := c.DefaultQuery("sortBy", "revenue")
+	if !ValidSortFields[sortBy] {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SORT_FIELD", fmt.Sprintf("Cannot sort by %s", sortBy), nil)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	rows, err := h.service.GetProductPerformance(c.Request.Context(), ProductPerformanceQuery{
+		From:      from,
+		To:        to,
+		SortBy:    sortBy,
+		SortOrder: c.DefaultQuery("sortOrder", "desc"),
+		Limit:     limit,
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "REPORT_FAILED", "Failed to build product performance report", err.Error())
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		writeProductPerformanceCSV(c, rows, from, to)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product performance report retrieved successfully", gin.H{
+		"from":     from.Format("2006-01-02"),
+		"to":       to.Format("2006-01-02"),
+		"products": rows,
+	})
+}
+
+// RefreshProductStats handles POST /api/admin/reports/product-performance/refresh (admin only)
+func (h *Handler) RefreshProductStats(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", err.Error(), nil)
+		return
+	}
+
+	if err := h.service.AggregateProductStats(c.Request.Context(), from, to); err != nil {
+		if errors.Is(err, ErrDateRangeTooLarge) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", err.Error(), nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "AGGREGATION_FAILED", "Failed to refresh product stats", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product stats refreshed successfully", gin.H{
+		"from": from.Format("2006-01-02"),
+		"to":   to.Format("2006-01-02"),
+	})
+}
+
+// GetSourceAttribution handles GET /api/admin/reports/cart-sources (admin only)
+func (h *Handler) GetSourceAttribution(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", err.Error(), nil)
+		return
+	}
+
+	rows, err := h.service.GetSourceAttribution(c.Request.Context(), from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "REPORT_FAILED", "Failed to build cart source report", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cart source report retrieved successfully", gin.H{
+		"from":    from.Format("2006-01-02"),
+		"to":      to.Format("2006-01-02"),
+		"sources": rows,
+	})
+}
+
+// Sales report formats and levels
+const (
+	SalesFormatCSV  = "csv"
+	SalesFormatPDF  = "pdf"
+	SalesLevelOrder = "order"
+	SalesLevelItem  = "item"
+)
+
+// ExportSales handles GET /api/admin/reports/sales (admin only). from and to are inclusive days; level
+// picks one row per order (default) or per order line.
+func (h *Handler) ExportSales(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", err.Error(), nil)
+		return
+	}
+
+	format := c.DefaultQuery("format", SalesFormatCSV)
+	if format != SalesFormatCSV && format != SalesFormatPDF {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_FORMAT", "format must be csv or pdf", nil)
+		return
+	}
+	level := c.DefaultQuery("level", SalesLevelOrder)
+	if level != SalesLevelOrder && level != SalesLevelItem {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_LEVEL", "level must be order or item", nil)
+		return
+	}
+
+	from, end := utils.StartOfDay(from), utils.StartOfDay(to).AddDate(0, 0, 1)
+	filename := fmt.Sprintf("sales-%ss-%s-%s.%s", level, from.Format("20060102"), utils.StartOfDay(to).Format("20060102"), format)
+	if format == SalesFormatPDF {
+		h.writeSalesPDF(c, level, from, end, filename)
+		return
+	}
+	h.writeSalesCSV(c, level, from, end, filename)
+}
+
+// salesExportError reports a sales report that failed before anything was written
+func salesExportError(c *gin.Context, err error) {
+	if errors.Is(err, ErrSalesRangeTooLarge) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", err.Error(), nil)
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, "REPORT_FAILED", "Failed to build sales report", err.Error())
+}
+
+// FunnelStepRequest is a funnel step reported by the storefront
+type FunnelStepRequest struct {
+	Step string `json:"step" binding:"required"`
+}
+
+// RecordFunnelStep handles POST /api/analytics/events, for funnel steps only the storefront sees
+func (h *Handler) RecordFunnelStep(c *gin.Context) {
+	var req FunnelStepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+	if !ClientFunnelSteps[req.Step] {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_FUNNEL_STEP", fmt.Sprintf("Step %s cannot be reported", req.Step), nil)
+		return
+	}
+
+	input := FunnelEventInput{Step: req.Step, SessionID: funnelSession(c), UserID: c.GetString("user_id"), At: time.Now()}
+	if err := h.service.RecordFunnelEvent(c.Request.Context(), input); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "RECORD_EVENT_FAILED", "Failed to record event", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "Event recorded", nil)
+}
+
+// GetFunnel handles GET /api/admin/analytics/funnel (admin only)
+func (h *Handler) GetFunnel(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_DATE_RANGE", err.Error(), nil)
+		return
+	}
+
+	report, err := h.service.GetFunnel(c.Request.Context(), from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "REPORT_FAILED", "Failed to build funnel report", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Funnel report retrieved successfully", gin.H{
+		"from":         from.Format("2006-01-02"),
+		"to":           to.Format("2006-01-02"),
+		"steps":        report.Steps,
+		"cartRemovals": report.CartRemovals,
+	})
+}
+
+// maxPendingViewWrites bounds how many product view writes may be in flight at once
+const maxPendingViewWrites = 32
+
+// viewWriteTimeout bounds how long one product view write may take
+const viewWriteTimeout = 5 * time.Second
+
+// TrackProductViews records a view for every successful product detail request. Writes run in the
+// background so they never slow the response; when maxPendingViewWrites are already in flight the
+// view is dropped rather than piling up goroutines behind a slow database.
+func TrackProductViews(service ServiceInterface) gin.HandlerFunc {
+	pending := make(chan struct{}, maxPendingViewWrites)
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Request.Method != http.MethodGet || utils.UnversionedPath(c.FullPath()) != "/api/products/:id" || c.Writer.Status() != http.StatusOK {
+			return
+		}
+
+		select {
+		case pending <- struct{}{}:
+		default:
+			return
+		}
+
+		productID := c.Param("id")
+		go func() {
+			defer func() { <-pending }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), viewWriteTimeout)
+			defer cancel()
+			if err := service.RecordProductView(ctx, productID, time.Now()); err != nil {
+				logger.Named("reports").Warn("Failed to record product view", map[string]interface{}{"product_id": productID, "error": err.Error()})
+			}
+		}()
+	}
+}
+
+// parseDateRange reads from/to query parameters (YYYY-MM-DD), defaulting to the last 30 days
+func parseDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -30)
+
+	if v := c.Query("from"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return from, to, fmt.Errorf("from must be in YYYY-MM-DD format")
+		}
+		from = parsed
+	}
+
+	if v := c.Query("to"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return from, to, fmt.Errorf("to must be in YYYY-MM-DD format")
+		}
+		to = parsed
+	}
+
+	if from.After(to) {
+		return from, to, fmt.Errorf("from must not be after to")
+	}
+
+	return from, to, nil
+}
+
+// writeProductPerformanceCSV streams the report as a CSV attachment
+func writeProductPerformanceCSV(c *gin.Context, rows []ProductPerformance, from, to time.Time) {
+	filename := fmt.Sprintf("product-performance-%s-%s.csv", from.Format("20060102"), to.Format("20060102"))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"product_id", "name", "sku", "views", "orders", "units_sold", "refunded_units", "revenue", "refund_rate", "conversion", "current_stock"})
+	for _, row := range rows {
+		_ = w.Write([]string{
+			row.ProductID,
+			row.Name,
+			row.SKU,
+			strconv.FormatInt(row.Views, 10),
+			strconv.FormatInt(row.Orders, 10),
+			strconv.FormatInt(row.UnitsSold, 10),
+			strconv.FormatInt(row.RefundedUnits, 10),
+			strconv.FormatFloat(row.Revenue, 'f', 2, 64),
+			strconv.FormatFloat(row.RefundRate, 'f', 4, 64),
+			strconv.FormatFloat(row.Conversion, 'f', 4, 64),
+			strconv.Itoa(row.CurrentStock),
+		})
+	}
+	w.Flush()
+}
+
+// salesOrderHeader and salesItemHeader are the CSV columns of the sales report levels
+var (
+	salesOrderHeader = []string{"order_id", "created_at", "status", "customer_email", "billing_name", "billing_state", "billing_country",
+		"units", "subtotal", "tax", "shipping", "total", "refunded", "net"}
+	salesItemHeader = []string{"order_id", "created_at", "status", "customer_email", "product_id", "sku", "product_name",
+		"quantity", "unit_price", "line_total"}
+)
+
+// formatAmount writes a money amount with two decimals
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// writeSalesCSV streams the sales report as a CSV attachment, one database row at a time. The response
+// starts with the first row, so a query that fails up front still gets a JSON error; a failure halfway
+// can only be logged and leaves the file truncated.
+func (h *Handler) writeSalesCSV(c *gin.Context, level string, from, to time.Time, filename string) {
+	w := csv.NewWriter(c.Writer)
+	header := salesOrderHeader
+	if level == SalesLevelItem {
+		header = salesItemHeader
+	}
+	started := false
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Status(http.StatusOK)
+		_ = w.Write(header)
+	}
+
+	var err error
+	if level == SalesLevelItem {
+		err = h.service.EachSalesItem(c.Request.Context(), from, to, func(row SalesItemRow) error {
+			start()
+			return w.Write([]string{
+				row.OrderID,
+				row.CreatedAt.UTC().Format(time.RFC3339),
+				row.Status,
+				row.CustomerEmail,
+				row.ProductID,
+				row.SKU,
+				row.ProductName,
+				strconv.FormatInt(row.Quantity, 10),
+				formatAmount(row.UnitPrice),
+				formatAmount(row.LineTotal),
+			})
+		})
+	} else {
+		err = h.service.EachSalesOrder(c.Request.Context(), from, to, func(row SalesOrderRow) error {
+			start()
+			return w.Write([]string{
+				row.OrderID,
+				row.CreatedAt.UTC().Format(time.RFC3339),
+				row.Status,
+				row.CustomerEmail,
+				row.BillingName,
+				row.BillingState,
+				row.BillingCountry,
+				strconv.FormatInt(row.Units, 10),
+				formatAmount(row.Subtotal),
+				formatAmount(row.Tax),
+				formatAmount(row.Shipping),
+				formatAmount(row.Total),
+				formatAmount(row.Refunded),
+				formatAmount(row.Net),
+			})
+		})
+	}
+
+	if err != nil && !started {
+		salesExportError(c, err)
+		return
+	}
+	if err != nil {
+		logger.Named("reports").Error("Sales report export failed midway", err, map[string]interface{}{"level": level})
+	}
+	start()
+	w.Flush()
+}
+
+// fitColumn pads or cuts s to exactly width characters for the fixed-width PDF layout
+func fitColumn(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+// writeSalesPDF renders the sales report as a fixed-width table with totals
+func (h *Handler) writeSalesPDF(c *gin.Context, level string, from, to time.Time, filename string) {
+	title := "SALES REPORT - ORDERS"
+	if level == SalesLevelItem {
+		title = "SALES REPORT - LINE ITEMS"
+	}
+	lines := []string{
+		title,
+		fmt.Sprintf("Period: %s to %s (UTC)", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02")),
+		fmt.Sprintf("Generated: %s", time.Now().UTC().Format("2006-01-02 15:04 UTC")),
+		"",
+	}
+
+	var err error
+	if level == SalesLevelItem {
+		header := fmt.Sprintf("%s  %s  %s  %s  %s  %6s %11s %11s", fitColumn("Date", 10), fitColumn("Order", 8), fitColumn("Status", 10),
+			fitColumn("SKU", 16), fitColumn("Product", 40), "Qty", "Unit price", "Line total")
+		lines = append(lines, header, strings.Repeat("-", len(header)))
+
+		var count, units int64
+		var total float64
+		err = h.service.EachSalesItem(c.Request.Context(), from, to, func(row SalesItemRow) error {
+			lines = append(lines, fmt.Sprintf("%s  %s  %s  %s  %s  %6d %11.2f %11.2f", row.CreatedAt.UTC().Format("2006-01-02"),
+				fitColumn(row.OrderID, 8), fitColumn(row.Status, 10), fitColumn(row.SKU, 16), fitColumn(row.ProductName, 40),
+				row.Quantity, row.UnitPrice, row.LineTotal))
+			count++
+			units += row.Quantity
+			total += row.LineTotal
+			return nil
+		})
+		lines = append(lines, strings.Repeat("-", len(header)),
+			fmt.Sprintf("%s  %6d %11s %11.2f", fitColumn(fmt.Sprintf("%d lines", count), 94), units, "", total))
+	} else {
+		header := fmt.Sprintf("%s  %s  %s  %s  %6s %10s %10s %10s %10s %10s %10s", fitColumn("Date", 10), fitColumn("Order", 8),
+			fitColumn("Status", 10), fitColumn("Customer", 30), "Units", "Subtotal", "Tax", "Shipping", "Total", "Refunded", "Net")
+		lines = append(lines, header, strings.Repeat("-", len(header)))
+
+		var totals SalesOrderRow
+		var count int64
+		err = h.service.EachSalesOrder(c.Request.Context(), from, to, func(row SalesOrderRow) error {
+			lines = append(lines, fmt.Sprintf("%s  %s  %s  %s  %6d %10.2f %10.2f %10.2f %10.2f %10.2f %10.2f", row.CreatedAt.UTC().Format("2006-01-02"),
+				fitColumn(row.OrderID, 8), fitColumn(row.Status, 10), fitColumn(row.CustomerEmail, 30),
+				row.Units, row.Subtotal, row.Tax, row.Shipping, row.Total, row.Refunded, row.Net))
+			count++
+			totals.Units += row.Units
+			totals.Subtotal += row.Subtotal
+			totals.Tax += row.Tax
+			totals.Shipping += row.Shipping
+			totals.Total += row.Total
+			totals.Refunded += row.Refunded
+			totals.Net += row.Net
+			return nil
+		})
+		lines = append(lines, strings.Repeat("-", len(header)),
+			fmt.Sprintf("%s  %6d %10.2f %10.2f %10.2f %10.2f %10.2f %10.2f", fitColumn(fmt.Sprintf("%d orders", count), 66),
+				totals.Units, totals.Subtotal, totals.Tax, totals.Shipping, totals.Total, totals.Refunded, totals.Net))
+	}
+	if err != nil {
+		salesExportError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/pdf", pdf.Render(lines, pdf.LetterLandscapeMono))
+}
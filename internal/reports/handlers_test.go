@@ -0,0 +1,347 @@
+package reports
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the reports service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) RecordProductView(ctx context.Context, productID string, at time.Time) error {
+	args := m.Called(ctx, productID, at)
+	return args.Error(0)
+}
+
+func (m *MockService) AggregateProductStats(ctx context.Context, from, to time.Time) error {
+	args := m.Called(ctx, from, to)
+	return args.Error(0)
+}
+
+func (m *MockService) GetProductPerformance(ctx context.Context, query ProductPerformanceQuery) ([]ProductPerformance, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ProductPerformance), args.Error(1)
+}
+
+func (m *MockService) GetSourceAttribution(ctx context.Context, from, to time.Time) ([]SourceAttribution, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]SourceAttribution), args.Error(1)
+}
+
+func (m *MockService) RecordFunnelEvent(ctx context.Context, input FunnelEventInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockService) GetFunnel(ctx context.Context, from, to time.Time) (*FunnelReport, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*FunnelReport), args.Error(1)
+}
+
+func (m *MockService) EachSalesOrder(ctx context.Context, from, to time.Time, fn func(SalesOrderRow) error) error {
+	args := m.Called(ctx, from, to)
+	if rows, ok := args.Get(0).([]SalesOrderRow); ok {
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockService) EachSalesItem(ctx context.Context, from, to time.Time, fn func(SalesItemRow) error) error {
+	args := m.Called(ctx, from, to)
+	if rows, ok := args.Get(0).([]SalesItemRow); ok {
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func TestHandler_GetProductPerformance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rows := []ProductPerformance{{ProductID: "p1", Name: "Phone", SKU: "SKU-1", UnitsSold: 3, Revenue: 300, CurrentStock: 7}}
+
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "json report",
+			url:  "/api/admin/reports/product-performance?from=2025-03-01&to=2025-03-31",
+			setupMock: func(m *MockService) {
+				m.On("GetProductPerformance", mock.Anything, mock.MatchedBy(func(q ProductPerformanceQuery) bool {
+					return q.SortBy == "revenue" && q.From.Day() == 1 && q.To.Day() == 31
+				})).Return(rows, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"unitsSold":3`,
+		},
+		{
+			name: "csv export",
+			url:  "/api/admin/reports/product-performance?format=csv&sortBy=unitsSold",
+			setupMock: func(m *MockService) {
+				m.On("GetProductPerformance", mock.Anything, mock.Anything).Return(rows, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "p1,Phone,SKU-1,0,0,3,0,300.00",
+		},
+		{
+			name:           "invalid date",
+			url:            "/api/admin/reports/product-performance?from=03-01-2025",
+			setupMock:      func(m *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID_DATE_RANGE",
+		},
+		{
+			name:           "from after to",
+			url:            "/api/admin/reports/product-performance?from=2025-04-01&to=2025-03-01",
+			setupMock:      func(m *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID_DATE_RANGE",
+		},
+		{
+			name:           "invalid sort field",
+			url:            "/api/admin/reports/product-performance?sortBy=color",
+			setupMock:      func(m *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID_SORT_FIELD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := NewHandler(mockService)
+
+			r := gin.New()
+			r.GET("/api/admin/reports/product-performance", handler.GetProductPerformance)
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.True(t, strings.Contains(w.Body.String(), tt.expectedBody), w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTrackProductViews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorded := make(chan string, 1)
+	mockService := new(MockService)
+	mockService.On("RecordProductView", mock.Anything, "p1", mock.Anything).
+		Run(func(args mock.Arguments) { recorded <- args.String(1) }).
+		Return(nil)
+
+	r := gin.New()
+	r.Use(TrackProductViews(mockService))
+	r.GET("/api/products/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api/products", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, url := range []string{"/api/products", "/api/products/p1"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	select {
+	case productID := <-recorded:
+		assert.Equal(t, "p1", productID)
+	case <-time.After(time.Second):
+		t.Fatal("expected product view to be recorded")
+	}
+	mockService.AssertNumberOfCalls(t, "RecordProductView", 1)
+}
+
+func TestHandler_RefreshProductStatsRejectsLongRanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("AggregateProductStats", mock.Anything, mock.Anything, mock.Anything).Return(ErrDateRangeTooLarge)
+
+	r := gin.New()
+	r.POST("/api/admin/reports/product-performance/refresh", NewHandler(mockService).RefreshProductStats)
+
+	req, _ := http.NewRequest("POST", "/api/admin/reports/product-performance/refresh?from=2024-01-01&to=2025-01-01", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_DATE_RANGE")
+}
+
+func TestTrackProductViewsBoundsPendingWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	started := make(chan struct{}, 2*maxPendingViewWrites)
+	release := make(chan struct{})
+	mockService := new(MockService)
+	mockService.On("RecordProductView", mock.Anything, "p1", mock.Anything).
+		Run(func(args mock.Arguments) {
+			started <- struct{}{}
+			<-release
+		}).
+		Return(nil)
+
+	r := gin.New()
+	r.Use(TrackProductViews(mockService))
+	r.GET("/api/products/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i := 0; i < 2*maxPendingViewWrites; i++ {
+		req, _ := http.NewRequest("GET", "/api/products/p1", nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	for i := 0; i < maxPendingViewWrites; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("expected pending view writes to start")
+		}
+	}
+	select {
+	case <-started:
+		t.Fatal("views beyond the pending limit should be dropped")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+}
+
+func TestHandler_ExportSales(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	at := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	orders := []SalesOrderRow{{OrderID: "order-0001-abcd", CreatedAt: at, Status: "delivered", CustomerEmail: "a@example.com",
+		BillingName: "Asha Rao", Units: 3, Subtotal: 210, Tax: 18.9, Shipping: 5, Total: 233.9, Refunded: 33.9, Net: 200}}
+	items := []SalesItemRow{{OrderID: "order-0001-abcd", CreatedAt: at, Status: "delivered", CustomerEmail: "a@example.com",
+		ProductID: "p1", SKU: "SKU-1", ProductName: "Phone", Quantity: 2, UnitPrice: 100, LineTotal: 200}}
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{
+			name: "order csv",
+			url:  "/api/admin/reports/sales?from=2025-03-01&to=2025-03-31",
+			setupMock: func(m *MockService) {
+				m.On("EachSalesOrder", mock.Anything, from, end).Return(orders, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/csv",
+			expectedBody:   "order-0001-abcd,2025-03-10T14:00:00Z,delivered,a@example.com,Asha Rao,,,3,210.00,18.90,5.00,233.90,33.90,200.00",
+		},
+		{
+			name: "empty item csv keeps the header",
+			url:  "/api/admin/reports/sales?from=2025-03-01&to=2025-03-31&level=item",
+			setupMock: func(m *MockService) {
+				m.On("EachSalesItem", mock.Anything, from, end).Return([]SalesItemRow{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/csv",
+			expectedBody:   "order_id,created_at,status,customer_email,product_id,sku,product_name,quantity,unit_price,line_total",
+		},
+		{
+			name: "item pdf",
+			url:  "/api/admin/reports/sales?from=2025-03-01&to=2025-03-31&level=item&format=pdf",
+			setupMock: func(m *MockService) {
+				m.On("EachSalesItem", mock.Anything, from, end).Return(items, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/pdf",
+			expectedBody:   "SALES REPORT - LINE ITEMS",
+		},
+		{
+			name: "failure before the first row",
+			url:  "/api/admin/reports/sales?from=2025-03-01&to=2025-03-31",
+			setupMock: func(m *MockService) {
+				m.On("EachSalesOrder", mock.Anything, from, end).Return(nil, assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "REPORT_FAILED",
+		},
+		{
+			name: "range too large",
+			url:  "/api/admin/reports/sales?from=2023-01-01&to=2025-01-01&format=pdf",
+			setupMock: func(m *MockService) {
+				m.On("EachSalesOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, ErrSalesRangeTooLarge)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID_DATE_RANGE",
+		},
+		{
+			name:           "invalid format",
+			url:            "/api/admin/reports/sales?format=xlsx",
+			setupMock:      func(m *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID_FORMAT",
+		},
+		{
+			name:           "invalid level",
+			url:            "/api/admin/reports/sales?level=customer",
+			setupMock:      func(m *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID_LEVEL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := NewHandler(mockService)
+
+			r := gin.New()
+			r.GET("/api/admin/reports/sales", handler.ExportSales)
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedType != "" {
+				assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "sales-")
+			}
+			assert.True(t, strings.Contains(w.Body.String(), tt.expectedBody), w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
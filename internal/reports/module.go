@@ -0,0 +1,53 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// aggregationInterval is how often product stats are rolled up in the background
+const aggregationInterval = time.Hour
+
+// Module wires product and funnel analytics into the application
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the reports module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "reports"
+}
+
+// Models returns the product stats and funnel event tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.ProductDailyStat{}, &models.FunnelEvent{}}
+}
+
+// Middleware records product page views and cart and checkout funnel events
+func (m *Module) Middleware() []gin.HandlerFunc {
+	return []gin.HandlerFunc{TrackProductViews(m.service), TrackFunnel(m.service)}
+}
+
+// StartJobs keeps product stats aggregated in the background
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartAggregator(ctx, aggregationInterval)
+}
+
+// RegisterAPIRoutes sets up the report, analytics and funnel event routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,30 @@
+package reports
+
+import (
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin report and analytics routes, and the storefront funnel event route
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	api.POST("/analytics/events", authService.OptionalAuthMiddleware(), handler.RecordFunnelStep)
+
+	admin := api.Group("/admin/reports")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("/product-performance", handler.GetProductPerformance)
+		admin.POST("/product-performance/refresh", handler.RefreshProductStats)
+		admin.GET("/cart-sources", handler.GetSourceAttribution)
+		admin.GET("/sales", middleware.TimeoutMiddleware(middleware.LongRequestTimeout), handler.ExportSales)
+	}
+
+	analytics := api.Group("/admin/analytics")
+	analytics.Use(authService.AuthMiddleware())
+	analytics.Use(authService.AdminMiddleware())
+	{
+		analytics.GET("/funnel", handler.GetFunnel)
+	}
+}
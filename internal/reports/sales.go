@@ -0,0 +1,160 @@
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MaxSalesReportDays bounds the period of one sales report export
+const MaxSalesReportDays = 366
+
+// ErrSalesRangeTooLarge is returned when a sales report spans more than MaxSalesReportDays
+var ErrSalesRangeTooLarge = fmt.Errorf("date range must not exceed %d days", MaxSalesReportDays)
+
+// SalesOrderRow is one order of the order-level sales report. Refunded is what processed refunds gave
+// back, so Net is what the store kept.
+type SalesOrderRow struct {
+	OrderID        string
+	CreatedAt      time.Time
+	Status         string
+	CustomerEmail  string
+	BillingName    string
+	BillingState   string
+	BillingCountry string
+	Units          int64
+	Subtotal       float64
+	Tax            float64
+	Shipping       float64
+	Total          float64
+	Refunded       float64
+	Net            float64
+}
+
+// SalesItemRow is one order line of the line-item-level sales report
+type SalesItemRow struct {
+	OrderID       string
+	CreatedAt     time.Time
+	Status        string
+	CustomerEmail string
+	ProductID     string
+	SKU           string
+	ProductName   string
+	Quantity      int64
+	UnitPrice     float64
+	LineTotal     float64
+}
+
+// salesReportStatuses are the orders a sales report lists: sales, and refunded orders so the refunds
+// that reversed them show up
+var salesReportStatuses = append([]string{refundedOrderStatus}, salesOrderStatuses...)
+
+// salesReportRange checks the report period [from, to)
+func salesReportRange(from, to time.Time) error {
+	if to.Sub(from) > MaxSalesReportDays*24*time.Hour {
+		return ErrSalesRangeTooLarge
+	}
+	return nil
+}
+
+// EachSalesOrder calls fn with every order placed in [from, to), oldest first. Rows are read from the
+// database one at a time so large periods can be streamed.
+func (s *Service) EachSalesOrder(ctx context.Context, from, to time.Time, fn func(SalesOrderRow) error) error {
+	if err := salesReportRange(from, to); err != nil {
+		return err
+	}
+	return s.StreamSalesOrders(ctx, from, to, fn)
+}
+
+// StreamSalesOrders is EachSalesOrder without the period limit, for reports built in the background
+func (s *Service) StreamSalesOrders(ctx context.Context, from, to time.Time, fn func(SalesOrderRow) error) error {
+	db := s.db.WithContext(ctx)
+	rows, err := db.Table("orders").
+		Select(`orders.id AS order_id,
+			orders.created_at AS created_at,
+			orders.status AS status,
+			users.email AS customer_email,
+			orders.billing_first_name || ' ' || orders.billing_last_name AS billing_name,
+			orders.billing_state AS billing_state,
+			orders.billing_country AS billing_country,
+			(SELECT COALESCE(SUM(order_items.quantity), 0) FROM order_items WHERE order_items.order_id = orders.id) AS units,
+			orders.subtotal AS subtotal,
+			orders.tax AS tax,
+			orders.shipping AS shipping,
+			orders.total AS total,
+			(SELECT COALESCE(SUM(refunds.amount), 0) FROM refunds WHERE refunds.order_id = orders.id AND refunds.status = ?) / 100.0 AS refunded`,
+			models.RefundStatusProcessed).
+		Joins("LEFT JOIN users ON users.id = orders.user_id").
+		Where("orders.created_at >= ? AND orders.created_at < ?", from, to).
+		Where("orders.status IN ?", salesReportStatuses).
+		Order("orders.created_at ASC, orders.id ASC").
+		Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query sales orders: %w", err)
+	}
+	defer rows.Close()
+
+	return eachRow(db, rows, func(row *SalesOrderRow) error {
+		row.Net = row.Total - row.Refunded
+		return fn(*row)
+	})
+}
+
+// EachSalesItem calls fn with every line of the orders placed in [from, to), oldest order first
+func (s *Service) EachSalesItem(ctx context.Context, from, to time.Time, fn func(SalesItemRow) error) error {
+	if err := salesReportRange(from, to); err != nil {
+		return err
+	}
+	return s.StreamSalesItems(ctx, from, to, fn)
+}
+
+// StreamSalesItems is EachSalesItem without the period limit, for reports built in the background
+func (s *Service) StreamSalesItems(ctx context.Context, from, to time.Time, fn func(SalesItemRow) error) error {
+	db := s.db.WithContext(ctx)
+	rows, err := db.Table("order_items").
+		Select(`orders.id AS order_id,
+			orders.created_at AS created_at,
+			orders.status AS status,
+			users.email AS customer_email,
+			order_items.product_id AS product_id,
+			products.sku AS sku,
+			products.name AS product_name,
+			order_items.quantity AS quantity,
+			order_items.price AS unit_price,
+			order_items.total AS line_total`).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Joins("LEFT JOIN users ON users.id = orders.user_id").
+		Joins("LEFT JOIN products ON products.id = order_items.product_id").
+		Where("orders.created_at >= ? AND orders.created_at < ?", from, to).
+		Where("orders.status IN ?", salesReportStatuses).
+		Order("orders.created_at ASC, orders.id ASC, products.sku ASC").
+		Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query sales items: %w", err)
+	}
+	defer rows.Close()
+
+	return eachRow(db, rows, func(row *SalesItemRow) error { return fn(*row) })
+}
+
+// eachRow scans every remaining row of rows into a fresh T and passes it to fn
+func eachRow[T any](db *gorm.DB, rows *sql.Rows, fn func(*T) error) error {
+	for rows.Next() {
+		var row T
+		if err := db.ScanRows(rows, &row); err != nil {
+			return fmt.Errorf("failed to read sales row: %w", err)
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read sales rows: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ServiceInterface defines the interface for reports service
+type ServiceInterface interface {
+	RecordProductView(ctx context.Context, productID string, at time.Time) error
+	AggregateProductStats(ctx context.Context, from, to time.Time) error
+	GetProductPerformance(ctx context.Context, query ProductPerformanceQuery) ([]ProductPerformance, error)
+	GetSourceAttribution(ctx context.Context, from, to time.Time) ([]SourceAttribution, error)
+	RecordFunnelEvent(ctx context.Context, input FunnelEventInput) error
+	GetFunnel(ctx context.Context, from, to time.Time) (*FunnelReport, error)
+	EachSalesOrder(ctx context.Context, from, to time.Time, fn func(SalesOrderRow) error) error
+	EachSalesItem(ctx context.Context, from, to time.Time, fn func(SalesItemRow) error) error
+}
+
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new reports service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// ProductPerformanceQuery holds the filters and sort of a product performance report
+type ProductPerformanceQuery struct {
+	From      time.Time
+	To        time.Time
+	SortBy    string
+	SortOrder string
+	Limit     int
+}
+
+// ProductPerformance is one row of the product performance report
+type ProductPerformance struct {
+	ProductID     string  `json:"productId"`
+	Name          string  `json:"name"`
+	SKU           string  `json:"sku"`
+	Views         int64   `json:"views"`
+	Orders        int64   `json:"orders"`
+	UnitsSold     int64   `json:"unitsSold"`
+	RefundedUnits int64   `json:"refundedUnits"`
+	Revenue       float64 `json:"revenue"`
+	RefundRate    float64 `json:"refundRate"`
+	Conversion    float64 `json:"conversion"`
+	CurrentStock  int     `json:"currentStock"`
+}
+
+// SourceAttribution is the sales driven by one discovery surface (cart line source)
+type SourceAttribution struct {
+	Source       string  `json:"source"`
+	Orders       int64   `json:"orders"`
+	UnitsSold    int64   `json:"unitsSold"`
+	Revenue      float64 `json:"revenue"`
+	RevenueShare float64 `json:"revenueShare"`
+}
+
+// unattributedSource labels order items added without a source
+const unattributedSource = "unattributed"
+
+// ValidSortFields lists the fields the product performance report can be sorted by
+var ValidSortFields = map[string]bool{
+	"revenue":    true,
+	"unitsSold":  true,
+	"orders":     true,
+	"views":      true,
+	"refundRate": true,
+	"conversion": true,
+	"stock":      true,
+	"name":       true,
+}
+
+// salesOrderStatuses are the order statuses that count as sales: paid and everything after it.
+// Pending, cancelled and payment_failed orders never took money; refunded orders gave it back.
+var salesOrderStatuses = []string{"paid", "processing", "shipped", "delivered"}
+
+// refundedOrderStatus orders still count their units as sold so refund rates can be reported
+const refundedOrderStatus = "refunded"
+
+// MaxAggregationDays bounds how many days one AggregateProductStats call may recompute
+const MaxAggregationDays = 92
+
+// ErrDateRangeTooLarge is returned when a stats refresh spans more than MaxAggregationDays
+var ErrDateRangeTooLarge = fmt.Errorf("date range must not exceed %d days", MaxAggregationDays)
+
+// RecordProductView increments the view counter of a product for the day of at
+func (s *Service) RecordProductView(ctx context.Context, productID string, at time.Time) error {
+	stat := models.ProductDailyStat{
+		ProductID: productID,
+		Date:      utils.StartOfDay(at),
+		Views:     1,
+	}
+
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "product_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"views":      gorm.Expr("product_daily_stats.views + ?", 1),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&stat).Error
+	if err != nil {
+		return fmt.Errorf("failed to record product view: %w", err)
+	}
+	return nil
+}
+
+// salesRow is the per-product, per-day sales aggregate computed from orders
+type salesRow struct {
+	ProductID     string
+	Orders        int64
+	UnitsSold     int64
+	RefundedUnits int64
+	Revenue       float64
+}
+
+// AggregateProductStats recomputes the sales columns of the stats table for every day in [from, to].
+// Units sold include refunded units; orders and revenue only count orders that kept their payment.
+func (s *Service) AggregateProductStats(ctx context.Context, from, to time.Time) error {
+	if days := int(utils.StartOfDay(to).Sub(utils.StartOfDay(from)).Hours()/24) + 1; days > MaxAggregationDays {
+		return ErrDateRangeTooLarge
+	}
+
+	db := s.db.WithContext(ctx)
+	statuses := append([]string{refundedOrderStatus}, salesOrderStatuses...)
+
+	for day := utils.StartOfDay(from); !day.After(utils.StartOfDay(to)); day = day.AddDate(0, 0, 1) {
+		var rows []salesRow
+		if err := db.Table("order_items").
+			Select(`order_items.product_id AS product_id,
+				COUNT(DISTINCT CASE WHEN orders.status <> ? THEN order_items.order_id END) AS orders,
+				SUM(order_items.quantity) AS units_sold,
+				SUM(CASE WHEN orders.status = ? THEN order_items.quantity ELSE 0 END) AS refunded_units,
+				SUM(CASE WHEN orders.status <> ? THEN order_items.total ELSE 0 END) AS revenue`,
+				refundedOrderStatus, refundedOrderStatus, refundedOrderStatus).
+			Joins("JOIN orders ON orders.id = order_items.order_id").
+			Where("orders.created_at >= ? AND orders.created_at < ?", day, day.AddDate(0, 0, 1)).
+			Where("orders.status IN ?", statuses).
+			Group("order_items.product_id").
+			Scan(&rows).Error; err != nil {
+			return fmt.Errorf("failed to aggregate sales for %s: %w", day.Format("2006-01-02"), err)
+		}
+
+		// Reset sales columns first so products without sales on a re-aggregated day drop to zero
+		if err := db.Model(&models.ProductDailyStat{}).Where("date = ?", day).Updates(map[string]interface{}{
+			"orders":         0,
+			"units_sold":     0,
+			"refunded_units": 0,
+			"revenue":        0,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to reset stats for %s: %w", day.Format("2006-01-02"), err)
+		}
+
+		for _, row := range rows {
+			stat := models.ProductDailyStat{
+				ProductID:     row.ProductID,
+				Date:          day,
+				Orders:        row.Orders,
+				UnitsSold:     row.UnitsSold,
+				RefundedUnits: row.RefundedUnits,
+				Revenue:       row.Revenue,
+			}
+			if err := db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "product_id"}, {Name: "date"}},
+				DoUpdates: clause.AssignmentColumns([]string{"orders", "units_sold", "refunded_units", "revenue", "updated_at"}),
+			}).Create(&stat).Error; err != nil {
+				return fmt.Errorf("failed to store stats for product %s: %w", row.ProductID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetProductPerformance returns per-product performance over the query period from the stats table
+func (s *Service) GetProductPerformance(ctx context.Context, query ProductPerformanceQuery) ([]ProductPerformance, error) {
+	var rows []ProductPerformance
+	if err := s.db.WithContext(ctx).Table("product_daily_stats").
+		Select(`product_daily_stats.product_id AS product_id,
+			products.name AS name,
+			products.sku AS sku,
+			products.inventory AS current_stock,
+			SUM(product_daily_stats.views) AS views,
+			SUM(product_daily_stats.orders) AS orders,
+			SUM(product_daily_stats.units_sold) AS units_sold,
+			SUM(product_daily_stats.refunded_units) AS refunded_units,
+			SUM(product_daily_stats.revenue) AS revenue`).
+		Joins("JOIN products ON products.id = product_daily_stats.product_id").
+		Where("product_daily_stats.date >= ? AND product_daily_stats.date <= ?", utils.StartOfDay(query.From), utils.StartOfDay(query.To)).
+		Group("product_daily_stats.product_id, products.name, products.sku, products.inventory").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get product performance: %w", err)
+	}
+
+	for i := range rows {
+		if rows[i].UnitsSold > 0 {
+			rows[i].RefundRate = float64(rows[i].RefundedUnits) / float64(rows[i].UnitsSold)
+		}
+		if rows[i].Views > 0 {
+			rows[i].Conversion = float64(rows[i].Orders) / float64(rows[i].Views)
+		}
+	}
+
+	sortPerformance(rows, query.SortBy, query.SortOrder == "asc")
+
+	if query.Limit > 0 && len(rows) > query.Limit {
+		rows = rows[:query.Limit]
+	}
+
+	return rows, nil
+}
+
+// GetSourceAttribution aggregates sales per cart line source over the days [from, to], highest revenue first
+func (s *Service) GetSourceAttribution(ctx context.Context, from, to time.Time) ([]SourceAttribution, error) {
+	var rows []SourceAttribution
+	if err := s.db.WithContext(ctx).Table("order_items").
+		Select(`COALESCE(order_items.source, '') AS source,
+			COUNT(DISTINCT order_items.order_id) AS orders,
+			SUM(order_items.quantity) AS units_sold,
+			SUM(order_items.total) AS revenue`).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.created_at >= ? AND orders.created_at < ?", utils.StartOfDay(from), utils.StartOfDay(to).AddDate(0, 0, 1)).
+		Where("orders.status IN ?", salesOrderStatuses).
+		Group("COALESCE(order_items.source, '')").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get source attribution: %w", err)
+	}
+
+	var total float64
+	for i := range rows {
+		if rows[i].Source == "" {
+			rows[i].Source = unattributedSource
+		}
+		total += rows[i].Revenue
+	}
+	for i := range rows {
+		if total > 0 {
+			rows[i].RevenueShare = rows[i].Revenue / total
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Revenue > rows[j].Revenue })
+
+	return rows, nil
+}
+
+// sortPerformance orders report rows by the requested field
+func sortPerformance(rows []ProductPerformance, sortBy string, ascending bool) {
+	key := func(p ProductPerformance) float64 {
+		switch sortBy {
+		case "unitsSold":
+			return float64(p.UnitsSold)
+		case "orders":
+			return float64(p.Orders)
+		case "views":
+			return float64(p.Views)
+		case "refundRate":
+			return p.RefundRate
+		case "conversion":
+			return p.Conversion
+		case "stock":
+			return float64(p.CurrentStock)
+		default:
+			return p.Revenue
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if sortBy == "name" {
+			if ascending {
+				return rows[i].Name < rows[j].Name
+			}
+			return rows[i].Name > rows[j].Name
+		}
+		if ascending {
+			return key(rows[i]) < key(rows[j])
+		}
+		return key(rows[i]) > key(rows[j])
+	})
+}
+
+// StartAggregator periodically refreshes yesterday's and today's stats until ctx is cancelled
+func (s *Service) StartAggregator(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			now := time.Now()
+			if err := s.AggregateProductStats(ctx, now.AddDate(0, 0, -1), now); err != nil {
+				logger.Named("reports").Warn("Failed to aggregate product stats", map[string]interface{}{"error": err.Error()})
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,192 @@
+package reports
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) {
+	err := database.InitializeTest(&config.Config{})
+	require.NoError(t, err)
+}
+
+// seedSales creates two products with orders on the given day and returns their IDs
+func seedSales(t *testing.T, day time.Time) (string, string) {
+	db := database.GetDB()
+
+	user := models.User{Email: "reports@example.com", Password: "hashed", FirstName: "R", LastName: "User"}
+	require.NoError(t, db.Create(&user).Error)
+
+	category := models.Category{Name: "Reports", Slug: "reports", IsActive: true}
+	require.NoError(t, db.Create(&category).Error)
+
+	phone := models.Product{Name: "Phone", SKU: "RPT-PHONE", Price: 100, Inventory: 7, IsActive: true, CategoryID: category.ID}
+	case_ := models.Product{Name: "Case", SKU: "RPT-CASE", Price: 10, Inventory: 50, IsActive: true, CategoryID: category.ID}
+	require.NoError(t, db.Create(&phone).Error)
+	require.NoError(t, db.Create(&case_).Error)
+
+	createOrder := func(status string, items map[string]int, price map[string]float64) {
+		order := models.Order{UserID: user.ID, Status: status, CreatedAt: day.Add(2 * time.Hour)}
+		require.NoError(t, db.Create(&order).Error)
+		for productID, qty := range items {
+			item := models.OrderItem{OrderID: order.ID, ProductID: productID, Quantity: qty, Price: price[productID]}
+			require.NoError(t, db.Create(&item).Error)
+		}
+	}
+
+	prices := map[string]float64{phone.ID: 100, case_.ID: 10}
+	createOrder("delivered", map[string]int{phone.ID: 2, case_.ID: 1}, prices)
+	createOrder("refunded", map[string]int{phone.ID: 1}, prices)
+	createOrder("cancelled", map[string]int{case_.ID: 5}, prices)
+	createOrder("pending", map[string]int{case_.ID: 4}, prices)
+	createOrder("payment_failed", map[string]int{phone.ID: 3}, prices)
+
+	return phone.ID, case_.ID
+}
+
+func TestService_ProductPerformance(t *testing.T) {
+	setupTestDB(t)
+	service := NewService(database.GetDB())
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	phoneID, caseID := seedSales(t, day)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, service.RecordProductView(ctx, phoneID, day.Add(time.Hour)))
+	}
+	require.NoError(t, service.RecordProductView(ctx, caseID, day.Add(time.Hour)))
+
+	require.NoError(t, service.AggregateProductStats(ctx, day, day))
+	// Re-aggregating the same day must not double count
+	require.NoError(t, service.AggregateProductStats(ctx, day, day))
+
+	rows, err := service.GetProductPerformance(ctx, ProductPerformanceQuery{From: day, To: day, SortBy: "revenue"})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	phone := rows[0]
+	assert.Equal(t, phoneID, phone.ProductID)
+	assert.Equal(t, int64(3), phone.UnitsSold)
+	assert.Equal(t, int64(1), phone.RefundedUnits)
+	assert.Equal(t, 200.0, phone.Revenue, "refunded orders give their revenue back")
+	assert.Equal(t, int64(1), phone.Orders)
+	assert.Equal(t, int64(4), phone.Views)
+	assert.InDelta(t, 1.0/3.0, phone.RefundRate, 0.0001)
+	assert.InDelta(t, 0.25, phone.Conversion, 0.0001)
+	assert.Equal(t, 7, phone.CurrentStock)
+
+	accessory := rows[1]
+	assert.Equal(t, caseID, accessory.ProductID)
+	assert.Equal(t, int64(1), accessory.UnitsSold, "cancelled and pending orders are excluded")
+
+	rows, err = service.GetProductPerformance(ctx, ProductPerformanceQuery{From: day, To: day, SortBy: "stock", SortOrder: "desc", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, caseID, rows[0].ProductID)
+
+	rows, err = service.GetProductPerformance(ctx, ProductPerformanceQuery{From: day.AddDate(0, 0, 1), To: day.AddDate(0, 0, 2)})
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestService_SourceAttribution(t *testing.T) {
+	setupTestDB(t)
+	service := NewService(database.GetDB())
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	phoneID, _ := seedSales(t, day)
+	require.NoError(t, database.GetDB().Model(&models.OrderItem{}).
+		Where("product_id = ?", phoneID).Update("source", models.CartSourceSearch).Error)
+
+	rows, err := service.GetSourceAttribution(ctx, day, day)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, models.CartSourceSearch, rows[0].Source)
+	assert.Equal(t, int64(2), rows[0].UnitsSold, "refunded and payment_failed orders are excluded")
+	assert.Equal(t, 200.0, rows[0].Revenue)
+	assert.InDelta(t, 200.0/210.0, rows[0].RevenueShare, 0.0001)
+
+	assert.Equal(t, unattributedSource, rows[1].Source)
+	assert.Equal(t, int64(1), rows[1].UnitsSold, "cancelled and pending orders are excluded")
+}
+
+func TestService_AggregateProductStatsRejectsLongRanges(t *testing.T) {
+	setupTestDB(t)
+	service := NewService(database.GetDB())
+
+	to := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	err := service.AggregateProductStats(context.Background(), to.AddDate(0, 0, -MaxAggregationDays), to)
+	assert.ErrorIs(t, err, ErrDateRangeTooLarge)
+
+	assert.NoError(t, service.AggregateProductStats(context.Background(), to.AddDate(0, 0, -(MaxAggregationDays-1)), to))
+}
+
+func TestService_SalesReport(t *testing.T) {
+	setupTestDB(t)
+	service := NewService(database.GetDB())
+	db := database.GetDB()
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	seedSales(t, day)
+	require.NoError(t, db.Model(&models.OrderItem{}).Where("1 = 1").Update("total", gorm.Expr("price * quantity")).Error)
+	require.NoError(t, db.Model(&models.Order{}).Where("status = ?", "refunded").Updates(map[string]interface{}{"subtotal": 100, "total": 100}).Error)
+
+	var refunded models.Order
+	require.NoError(t, db.Where("status = ?", "refunded").First(&refunded).Error)
+	require.NoError(t, db.Create(&models.Refund{OrderID: refunded.ID, PaymentID: "pay-1", Amount: 6000, Status: models.RefundStatusProcessed}).Error)
+	require.NoError(t, db.Create(&models.Refund{OrderID: refunded.ID, PaymentID: "pay-1", Amount: 4000, Status: models.RefundStatusPending}).Error)
+
+	orders := []SalesOrderRow{}
+	require.NoError(t, service.EachSalesOrder(ctx, day, day.AddDate(0, 0, 1), func(row SalesOrderRow) error {
+		orders = append(orders, row)
+		return nil
+	}))
+	require.Len(t, orders, 2, "only sales and refunded orders are listed")
+	byStatus := map[string]SalesOrderRow{}
+	for _, row := range orders {
+		byStatus[row.Status] = row
+	}
+	assert.Equal(t, int64(3), byStatus["delivered"].Units)
+	assert.Equal(t, "reports@example.com", byStatus["delivered"].CustomerEmail)
+	assert.Equal(t, 60.0, byStatus["refunded"].Refunded, "only processed refunds count")
+	assert.Equal(t, 40.0, byStatus["refunded"].Net)
+
+	items := []SalesItemRow{}
+	require.NoError(t, service.EachSalesItem(ctx, day, day.AddDate(0, 0, 1), func(row SalesItemRow) error {
+		items = append(items, row)
+		return nil
+	}))
+	require.Len(t, items, 3)
+	var phoneUnits int64
+	for _, item := range items {
+		if item.SKU == "RPT-PHONE" {
+			phoneUnits += item.Quantity
+			assert.Equal(t, 100.0*float64(item.Quantity), item.LineTotal)
+		}
+	}
+	assert.Equal(t, int64(3), phoneUnits)
+
+	// The period is [from, to), so the next day is empty
+	count := 0
+	require.NoError(t, service.EachSalesOrder(ctx, day.AddDate(0, 0, 1), day.AddDate(0, 0, 2), func(SalesOrderRow) error {
+		count++
+		return nil
+	}))
+	assert.Zero(t, count)
+
+	err := service.EachSalesItem(ctx, day.AddDate(-2, 0, 0), day, func(SalesItemRow) error { return nil })
+	assert.ErrorIs(t, err, ErrSalesRangeTooLarge)
+}
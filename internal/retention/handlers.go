@@ -0,0 +1,88 @@
+package retention
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the admin data retention endpoints
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new retention handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// ListRules handles GET /api/admin/retention/rules (admin only)
+func (h *Handler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_RETENTION_RULES_FAILED", "Failed to get retention rules", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Retention rules retrieved successfully", rules)
+}
+
+// UpdateRule handles PUT /api/admin/retention/rules/:target (admin only)
+func (h *Handler) UpdateRule(c *gin.Context) {
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	rule, err := h.service.UpdateRule(c.Param("target"), req)
+	if err != nil {
+		if errors.Is(err, ErrRuleNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "RETENTION_RULE_NOT_FOUND", "Retention rule not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "UPDATE_RETENTION_RULE_FAILED", "Failed to update retention rule", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Retention rule updated successfully", rule)
+}
+
+// RunRule handles POST /api/admin/retention/rules/:target/run (admin only). The dryRun query parameter
+// overrides the rule's own mode.
+func (h *Handler) RunRule(c *gin.Context) {
+	var dryRun *bool
+	if value := c.Query("dryRun"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "dryRun must be true or false", nil)
+			return
+		}
+		dryRun = &parsed
+	}
+
+	run, err := h.service.RunRule(c.Request.Context(), c.Param("target"), dryRun)
+	if err != nil {
+		if errors.Is(err, ErrRuleNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "RETENTION_RULE_NOT_FOUND", "Retention rule not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "RETENTION_RUN_FAILED", "Retention rule failed", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Retention rule ran successfully", run)
+}
+
+// ListRuns handles GET /api/admin/retention/runs (admin only), optionally for one target
+func (h *Handler) ListRuns(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	runs, total, err := h.service.ListRuns(c.Query("target"), page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_RETENTION_RUNS_FAILED", "Failed to get retention runs", err.Error())
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Retention runs retrieved successfully", "runs", runs, utils.NewPagination(page, limit, total))
+}
@@ -0,0 +1,100 @@
+package retention
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the retention service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) ListRules() ([]RuleStatus, error) {
+	args := m.Called()
+	return args.Get(0).([]RuleStatus), args.Error(1)
+}
+
+func (m *MockService) UpdateRule(target string, req RuleRequest) (*models.RetentionRule, error) {
+	args := m.Called(target, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RetentionRule), args.Error(1)
+}
+
+func (m *MockService) RunRule(ctx context.Context, target string, dryRun *bool) (*models.RetentionRun, error) {
+	args := m.Called(target, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RetentionRun), args.Error(1)
+}
+
+func (m *MockService) ListRuns(target string, page, limit int) ([]models.RetentionRun, int64, error) {
+	args := m.Called(target, page, limit)
+	return args.Get(0).([]models.RetentionRun), args.Get(1).(int64), args.Error(2)
+}
+
+func TestHandler_RunRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("RunRule", models.RetentionEmailLogs, mock.MatchedBy(func(dryRun *bool) bool { return dryRun != nil && *dryRun })).
+		Return(&models.RetentionRun{ID: "run-1", Target: models.RetentionEmailLogs, DryRun: true, Matched: 3}, nil)
+	mockService.On("RunRule", "orders", (*bool)(nil)).Return(nil, ErrRuleNotFound)
+
+	r := gin.New()
+	r.POST("/api/admin/retention/rules/:target/run", NewHandler(mockService).RunRule)
+
+	send := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/api/admin/retention/rules/email_logs/run?dryRun=true")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"matched":3`)
+
+	w = send("/api/admin/retention/rules/orders/run")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "RETENTION_RULE_NOT_FOUND")
+
+	w = send("/api/admin/retention/rules/email_logs/run?dryRun=maybe")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandler_UpdateRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("UpdateRule", models.RetentionGuestCarts, mock.Anything).
+		Return(&models.RetentionRule{Target: models.RetentionGuestCarts, RetentionDays: 14}, nil)
+
+	r := gin.New()
+	r.PUT("/api/admin/retention/rules/:target", NewHandler(mockService).UpdateRule)
+
+	for body, code := range map[string]int{
+		`{"retentionDays":14,"dryRun":false}`: http.StatusOK,
+		`{"retentionDays":0}`:                 http.StatusBadRequest,
+		`{"dryRun":false}`:                    http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest("PUT", "/api/admin/retention/rules/guest_carts", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, body)
+	}
+	mockService.AssertNumberOfCalls(t, "UpdateRule", 1)
+}
@@ -0,0 +1,53 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/cart"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sweepInterval is how often the sweeper looks for retention rules that are due
+const sweepInterval = time.Hour
+
+// Module wires data retention rules and their scheduled runs into the application
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the retention module
+func NewModule(deps app.Deps) app.Module {
+	var carts CartPurger
+	if deps.Redis != nil {
+		carts = cart.NewService()
+	}
+	service := NewService(deps.DB, carts)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "retention"
+}
+
+// Models returns the retention rule and run tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.RetentionRule{}, &models.RetentionRun{}}
+}
+
+// StartJobs runs due retention rules in the background
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartSweeper(ctx, sweepInterval)
+}
+
+// RegisterAPIRoutes sets up the admin retention routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,20 @@
+package retention
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin data retention routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin/retention")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("/rules", handler.ListRules)
+		admin.PUT("/rules/:target", handler.UpdateRule)
+		admin.POST("/rules/:target/run", handler.RunRule)
+		admin.GET("/runs", handler.ListRuns)
+	}
+}
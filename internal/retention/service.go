@@ -0,0 +1,406 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// runEvery is how often the sweeper runs each enabled rule
+	runEvery = 24 * time.Hour
+	// sampleSize caps the matched IDs a run report lists
+	sampleSize = 20
+	// anonymizedEmailDomain is the reserved domain anonymized accounts get addresses under
+	anonymizedEmailDomain = "anonymized.invalid"
+)
+
+var (
+	ErrRuleNotFound     = errors.New("retention rule not found")
+	ErrCartsUnavailable = errors.New("cart store is not configured")
+)
+
+// defaultRules are created the first time rules are read. They start in dry-run mode, so nothing is removed
+// until an admin has checked the reports and switched a rule over.
+var defaultRules = []models.RetentionRule{
+	{Target: models.RetentionGuestCarts, RetentionDays: 30, Enabled: true, DryRun: true},
+	{Target: models.RetentionInactiveAccounts, RetentionDays: 3 * 365, Enabled: true, DryRun: true},
+	{Target: models.RetentionEmailLogs, RetentionDays: 90, Enabled: true, DryRun: true},
+	{Target: models.RetentionFunnelEvents, RetentionDays: 365, Enabled: true, DryRun: true},
+}
+
+// CartPurger removes guest carts from the cart store
+type CartPurger interface {
+	PurgeGuestCarts(ctx context.Context, cutoff time.Time, dryRun bool) ([]string, error)
+}
+
+// RuleRequest changes the retention period or mode of a rule
+type RuleRequest struct {
+	RetentionDays int   `json:"retentionDays" binding:"required,min=1,max=3650"`
+	Enabled       *bool `json:"enabled"`
+	DryRun        *bool `json:"dryRun"`
+}
+
+// RuleStatus is a retention rule with the report of its last run, if it has run
+type RuleStatus struct {
+	models.RetentionRule
+	LastRun *models.RetentionRun `json:"lastRun"`
+}
+
+// ServiceInterface defines the retention operations used by the handlers
+type ServiceInterface interface {
+	ListRules() ([]RuleStatus, error)
+	UpdateRule(target string, req RuleRequest) (*models.RetentionRule, error)
+	RunRule(ctx context.Context, target string, dryRun *bool) (*models.RetentionRun, error)
+	ListRuns(target string, page, limit int) ([]models.RetentionRun, int64, error)
+}
+
+// Service applies data retention rules
+type Service struct {
+	db    *gorm.DB
+	carts CartPurger
+	now   func() time.Time
+}
+
+// NewService creates a retention service. Without carts the guest cart rule fails its runs.
+func NewService(db *gorm.DB, carts CartPurger) *Service {
+	return &Service{db: db, carts: carts, now: time.Now}
+}
+
+// ensureRules creates the default rules that do not exist yet
+func (s *Service) ensureRules() error {
+	rules := make([]models.RetentionRule, len(defaultRules))
+	copy(rules, defaultRules)
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rules).Error; err != nil {
+		return fmt.Errorf("failed to create default retention rules: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) rules() ([]models.RetentionRule, error) {
+	if err := s.ensureRules(); err != nil {
+		return nil, err
+	}
+	var rules []models.RetentionRule
+	if err := s.db.Order("target ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch retention rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (s *Service) rule(target string) (*models.RetentionRule, error) {
+	if err := s.ensureRules(); err != nil {
+		return nil, err
+	}
+	var rule models.RetentionRule
+	if err := s.db.First(&rule, "target = ?", target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch retention rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// lastRun returns the latest run of target, or nil if it never ran
+func (s *Service) lastRun(target string) (*models.RetentionRun, error) {
+	var run models.RetentionRun
+	err := s.db.Where("target = ?", target).Order("started_at DESC").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch last retention run: %w", err)
+	}
+	return &run, nil
+}
+
+// ListRules returns every retention rule with its last run
+func (s *Service) ListRules() ([]RuleStatus, error) {
+	rules, err := s.rules()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]RuleStatus, 0, len(rules))
+	for _, rule := range rules {
+		run, err := s.lastRun(rule.Target)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, RuleStatus{RetentionRule: rule, LastRun: run})
+	}
+	return statuses, nil
+}
+
+// UpdateRule changes the retention period of a rule and, when given, whether it is enabled or in dry-run mode
+func (s *Service) UpdateRule(target string, req RuleRequest) (*models.RetentionRule, error) {
+	rule, err := s.rule(target)
+	if err != nil {
+		return nil, err
+	}
+	rule.RetentionDays = req.RetentionDays
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.DryRun != nil {
+		rule.DryRun = *req.DryRun
+	}
+	if err := s.db.Save(rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to update retention rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ListRuns returns the run reports of a rule, or of every rule when target is empty, newest first
+func (s *Service) ListRuns(target string, page, limit int) ([]models.RetentionRun, int64, error) {
+	query := s.db.Model(&models.RetentionRun{})
+	if target != "" {
+		query = query.Where("target = ?", target)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count retention runs: %w", err)
+	}
+	var runs []models.RetentionRun
+	if err := query.Order("started_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&runs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch retention runs: %w", err)
+	}
+	return runs, total, nil
+}
+
+// RunRule runs a rule now, enabled or not. dryRun overrides the rule's own mode when set.
+func (s *Service) RunRule(ctx context.Context, target string, dryRun *bool) (*models.RetentionRun, error) {
+	rule, err := s.rule(target)
+	if err != nil {
+		return nil, err
+	}
+	mode := rule.DryRun
+	if dryRun != nil {
+		mode = *dryRun
+	}
+	return s.run(ctx, *rule, mode)
+}
+
+// RunDue runs the enabled rules that have not run in the last day and returns their reports
+func (s *Service) RunDue(ctx context.Context) ([]models.RetentionRun, error) {
+	rules, err := s.rules()
+	if err != nil {
+		return nil, err
+	}
+	var runs []models.RetentionRun
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		last, err := s.lastRun(rule.Target)
+		if err != nil {
+			return runs, err
+		}
+		if last != nil && s.now().Sub(last.StartedAt) < runEvery {
+			continue
+		}
+		run, err := s.run(ctx, rule, rule.DryRun)
+		if err != nil {
+			logger.Named("retention").Warn("Retention rule failed", map[string]interface{}{"target": rule.Target, "error": err.Error()})
+		}
+		if run != nil {
+			runs = append(runs, *run)
+		}
+	}
+	return runs, nil
+}
+
+// run applies rule and stores the report. A failed run is stored with its error and the error is returned too.
+func (s *Service) run(ctx context.Context, rule models.RetentionRule, dryRun bool) (*models.RetentionRun, error) {
+	run := models.RetentionRun{
+		Target:    rule.Target,
+		DryRun:    dryRun,
+		StartedAt: s.now(),
+		Sample:    models.StringArray{},
+	}
+	run.Cutoff = run.StartedAt.AddDate(0, 0, -rule.RetentionDays)
+
+	var runErr error
+	switch rule.Target {
+	case models.RetentionGuestCarts:
+		runErr = s.purgeGuestCarts(ctx, &run)
+	case models.RetentionInactiveAccounts:
+		runErr = s.anonymizeInactiveAccounts(ctx, &run)
+	case models.RetentionEmailLogs:
+		runErr = s.purgeEmailLogs(ctx, &run)
+	case models.RetentionFunnelEvents:
+		runErr = s.purgeRows(ctx, &run, &models.FunnelEvent{}, "created_at")
+	default:
+		runErr = fmt.Errorf("unknown retention target %q", rule.Target)
+	}
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+	run.FinishedAt = s.now()
+
+	if err := s.db.Create(&run).Error; err != nil {
+		return nil, fmt.Errorf("failed to save retention run: %w", err)
+	}
+	logger.Named("retention").Info("Retention rule ran", map[string]interface{}{
+		"target":   run.Target,
+		"dry_run":  run.DryRun,
+		"matched":  run.Matched,
+		"affected": run.Affected,
+	})
+	return &run, runErr
+}
+
+func (s *Service) purgeGuestCarts(ctx context.Context, run *models.RetentionRun) error {
+	if s.carts == nil {
+		return ErrCartsUnavailable
+	}
+	sessions, err := s.carts.PurgeGuestCarts(ctx, run.Cutoff, run.DryRun)
+	run.Matched = int64(len(sessions))
+	if !run.DryRun {
+		run.Affected = run.Matched
+	}
+	run.Sample = sample(sessions)
+	return err
+}
+
+func (s *Service) purgeEmailLogs(ctx context.Context, run *models.RetentionRun) error {
+	if err := s.purgeRows(ctx, run, &models.EmailLog{}, "created_at"); err != nil {
+		return err
+	}
+	// Bounce and complaint events go too; the suppression list built from them is kept
+	events := models.RetentionRun{Cutoff: run.Cutoff, DryRun: run.DryRun}
+	err := s.purgeRows(ctx, &events, &models.EmailEvent{}, "occurred_at")
+	run.Matched += events.Matched
+	run.Affected += events.Affected
+	return err
+}
+
+// purgeRows deletes the rows of model whose column is before the run's cutoff
+func (s *Service) purgeRows(ctx context.Context, run *models.RetentionRun, model interface{}, column string) error {
+	db := s.db.WithContext(ctx)
+	where := column + " < ?"
+
+	if err := db.Model(model).Where(where, run.Cutoff).Count(&run.Matched).Error; err != nil {
+		return fmt.Errorf("failed to count expired records: %w", err)
+	}
+	var ids []string
+	if err := db.Model(model).Where(where, run.Cutoff).Order(column+" ASC").Limit(sampleSize).Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to sample expired records: %w", err)
+	}
+	run.Sample = ids
+	if run.DryRun || run.Matched == 0 {
+		return nil
+	}
+
+	result := db.Where(where, run.Cutoff).Delete(model)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete expired records: %w", result.Error)
+	}
+	run.Affected = result.RowsAffected
+	return nil
+}
+
+// inactiveAccounts selects customer accounts without a sign-in, profile change or order since cutoff that are
+// not anonymized yet
+func (s *Service) inactiveAccounts(ctx context.Context, cutoff time.Time) *gorm.DB {
+	return s.db.WithContext(ctx).Model(&models.User{}).
+		Where("role = ? AND anonymized_at IS NULL", "customer").
+		Where("COALESCE(last_active_at, created_at) < ? AND updated_at < ?", cutoff, cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id AND orders.created_at >= ?)", cutoff)
+}
+
+// anonymizeInactiveAccounts removes the personal data of inactive customer accounts. Their orders stay for the
+// books, with the addresses they were shipped to; saved addresses, wishlists and saved carts are deleted and the
+// account can no longer sign in.
+func (s *Service) anonymizeInactiveAccounts(ctx context.Context, run *models.RetentionRun) error {
+	var ids []string
+	if err := s.inactiveAccounts(ctx, run.Cutoff).Order("created_at ASC").Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to find inactive accounts: %w", err)
+	}
+	run.Matched = int64(len(ids))
+	run.Sample = sample(ids)
+	if run.DryRun {
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := s.anonymizeAccount(ctx, id, run.StartedAt); err != nil {
+			return err
+		}
+		run.Affected++
+	}
+	return nil
+}
+
+func (s *Service) anonymizeAccount(ctx context.Context, userID string, now time.Time) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Address{}).Error; err != nil {
+			return fmt.Errorf("failed to delete addresses of user %s: %w", userID, err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.WishlistItem{}).Error; err != nil {
+			return fmt.Errorf("failed to delete wishlist of user %s: %w", userID, err)
+		}
+		if err := tx.Where("owner = ?", "user:"+userID).Delete(&models.WishlistRevision{}).Error; err != nil {
+			return fmt.Errorf("failed to delete wishlist of user %s: %w", userID, err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserCartItem{}).Error; err != nil {
+			return fmt.Errorf("failed to delete saved cart of user %s: %w", userID, err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserCart{}).Error; err != nil {
+			return fmt.Errorf("failed to delete saved cart of user %s: %w", userID, err)
+		}
+		err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"email":                    fmt.Sprintf("%s@%s", userID, anonymizedEmailDomain),
+			"password":                 "",
+			"first_name":               "Anonymized",
+			"last_name":                "Customer",
+			"phone":                    nil,
+			"is_active":                false,
+			"email_verified":           false,
+			"email_verification_token": nil,
+			"password_reset_token":     nil,
+			"password_reset_expiry":    nil,
+			"marketing_opt_out":        true,
+			"totp_secret":              nil,
+			"totp_enabled":             false,
+			"anonymized_at":            now,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to anonymize user %s: %w", userID, err)
+		}
+		return nil
+	})
+}
+
+func sample(ids []string) models.StringArray {
+	if len(ids) > sampleSize {
+		ids = ids[:sampleSize]
+	}
+	return models.StringArray(append([]string{}, ids...))
+}
+
+// StartSweeper checks every interval for enabled rules that are due and runs them until ctx is cancelled
+func (s *Service) StartSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.RunDue(ctx); err != nil {
+					logger.Named("retention").Warn("Failed to run retention rules", map[string]interface{}{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
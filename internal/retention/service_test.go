@@ -0,0 +1,195 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCarts is a cart store holding guest carts by session ID and last update
+type fakeCarts struct {
+	carts map[string]time.Time
+}
+
+func (f *fakeCarts) PurgeGuestCarts(ctx context.Context, cutoff time.Time, dryRun bool) ([]string, error) {
+	var sessions []string
+	for session, updatedAt := range f.carts {
+		if updatedAt.Before(cutoff) {
+			sessions = append(sessions, session)
+			if !dryRun {
+				delete(f.carts, session)
+			}
+		}
+	}
+	return sessions, nil
+}
+
+func setupTestService(t *testing.T, now time.Time) (*Service, *fakeCarts) {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	carts := &fakeCarts{carts: make(map[string]time.Time)}
+	service := NewService(database.GetDB(), carts)
+	service.now = func() time.Time { return now }
+	return service, carts
+}
+
+// createCustomer creates a customer last active at lastActive
+func createCustomer(t *testing.T, lastActive time.Time) models.User {
+	db := database.GetDB()
+	user := models.User{Email: uuid.New().String() + "@example.com", Password: "hashed", FirstName: "Sam", LastName: "Lee", Role: "customer"}
+	require.NoError(t, db.Create(&user).Error)
+	require.NoError(t, db.Model(&user).UpdateColumns(map[string]interface{}{"created_at": lastActive, "updated_at": lastActive}).Error)
+	require.NoError(t, db.Create(&models.Address{UserID: user.ID, Type: "shipping", FirstName: "Sam", LastName: "Lee", Address1: "1 Main St", City: "Springfield", State: "IL", PostalCode: "62701", Country: "US"}).Error)
+	return user
+}
+
+func TestService_Rules(t *testing.T) {
+	service, _ := setupTestService(t, time.Now())
+
+	rules, err := service.ListRules()
+	require.NoError(t, err)
+	require.Len(t, rules, len(defaultRules))
+	for _, rule := range rules {
+		assert.True(t, rule.DryRun, "rules start in dry-run mode")
+		assert.Nil(t, rule.LastRun)
+	}
+
+	disabled, live := false, false
+	rule, err := service.UpdateRule(models.RetentionEmailLogs, RuleRequest{RetentionDays: 30, Enabled: &disabled, DryRun: &live})
+	require.NoError(t, err)
+	assert.Equal(t, 30, rule.RetentionDays)
+	assert.False(t, rule.Enabled)
+	assert.False(t, rule.DryRun)
+
+	// Saved changes survive the defaults being ensured again
+	rules, err = service.ListRules()
+	require.NoError(t, err)
+	for _, status := range rules {
+		if status.Target == models.RetentionEmailLogs {
+			assert.Equal(t, 30, status.RetentionDays)
+			assert.False(t, status.Enabled)
+		}
+	}
+
+	_, err = service.UpdateRule("orders", RuleRequest{RetentionDays: 30})
+	assert.ErrorIs(t, err, ErrRuleNotFound)
+}
+
+func TestService_RunRule(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	service, carts := setupTestService(t, now)
+	db := database.GetDB()
+	ctx := context.Background()
+
+	t.Run("email logs", func(t *testing.T) {
+		old := models.EmailLog{Kind: "order_status", Recipient: "a@example.com", Status: models.EmailLogSent, CreatedAt: now.AddDate(0, 0, -100)}
+		recent := models.EmailLog{Kind: "order_status", Recipient: "b@example.com", Status: models.EmailLogSent, CreatedAt: now.AddDate(0, 0, -10)}
+		require.NoError(t, db.Create(&old).Error)
+		require.NoError(t, db.Create(&recent).Error)
+		require.NoError(t, db.Create(&models.EmailEvent{Type: models.EmailEventBounce, Email: "a@example.com", OccurredAt: now.AddDate(0, 0, -100)}).Error)
+
+		run, err := service.RunRule(ctx, models.RetentionEmailLogs, nil)
+		require.NoError(t, err)
+		assert.True(t, run.DryRun)
+		assert.Equal(t, int64(2), run.Matched)
+		assert.Equal(t, int64(0), run.Affected)
+		assert.Equal(t, []string{old.ID}, []string(run.Sample))
+
+		live := false
+		run, err = service.RunRule(ctx, models.RetentionEmailLogs, &live)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), run.Affected)
+
+		var logs, events int64
+		db.Model(&models.EmailLog{}).Count(&logs)
+		db.Model(&models.EmailEvent{}).Count(&events)
+		assert.Equal(t, int64(1), logs)
+		assert.Equal(t, int64(0), events)
+	})
+
+	t.Run("inactive accounts", func(t *testing.T) {
+		inactive := createCustomer(t, now.AddDate(-4, 0, 0))
+		signedIn := createCustomer(t, now.AddDate(-4, 0, 0))
+		require.NoError(t, db.Model(&signedIn).UpdateColumn("last_active_at", now.AddDate(0, -1, 0)).Error)
+		ordered := createCustomer(t, now.AddDate(-4, 0, 0))
+		require.NoError(t, db.Create(&models.Order{UserID: ordered.ID, Status: "delivered", Total: 10, CreatedAt: now.AddDate(-1, 0, 0)}).Error)
+
+		live := false
+		run, err := service.RunRule(ctx, models.RetentionInactiveAccounts, &live)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), run.Matched)
+		assert.Equal(t, int64(1), run.Affected)
+
+		var user models.User
+		require.NoError(t, db.First(&user, "id = ?", inactive.ID).Error)
+		assert.Equal(t, inactive.ID+"@anonymized.invalid", user.Email)
+		assert.Equal(t, "Anonymized", user.FirstName)
+		assert.False(t, user.IsActive)
+		assert.NotNil(t, user.AnonymizedAt)
+		var addresses int64
+		db.Model(&models.Address{}).Where("user_id = ?", inactive.ID).Count(&addresses)
+		assert.Equal(t, int64(0), addresses)
+
+		// Anonymized accounts are not matched again
+		run, err = service.RunRule(ctx, models.RetentionInactiveAccounts, &live)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), run.Matched)
+	})
+
+	t.Run("guest carts", func(t *testing.T) {
+		carts.carts["stale"] = now.AddDate(0, 0, -31)
+		carts.carts["fresh"] = now.AddDate(0, 0, -1)
+
+		run, err := service.RunRule(ctx, models.RetentionGuestCarts, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stale"}, []string(run.Sample))
+		assert.Len(t, carts.carts, 2)
+
+		service.carts = nil
+		run, err = service.RunRule(ctx, models.RetentionGuestCarts, nil)
+		assert.ErrorIs(t, err, ErrCartsUnavailable)
+		assert.Equal(t, ErrCartsUnavailable.Error(), run.Error, "failed runs are reported too")
+	})
+}
+
+func TestService_RunDue(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	service, _ := setupTestService(t, now)
+	ctx := context.Background()
+
+	disabled := false
+	_, err := service.UpdateRule(models.RetentionFunnelEvents, RuleRequest{RetentionDays: 365, Enabled: &disabled})
+	require.NoError(t, err)
+
+	runs, err := service.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Len(t, runs, len(defaultRules)-1, "disabled rules do not run")
+
+	service.now = func() time.Time { return now.Add(time.Hour) }
+	runs, err = service.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, runs, "rules run once a day")
+
+	service.now = func() time.Time { return now.Add(25 * time.Hour) }
+	runs, err = service.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Len(t, runs, len(defaultRules)-1)
+
+	rules, err := service.ListRules()
+	require.NoError(t, err)
+	for _, rule := range rules {
+		if rule.Target == models.RetentionFunnelEvents {
+			assert.Nil(t, rule.LastRun)
+			continue
+		}
+		require.NotNil(t, rule.LastRun, rule.Target)
+		assert.True(t, rule.LastRun.StartedAt.Equal(now.Add(25*time.Hour)))
+	}
+}
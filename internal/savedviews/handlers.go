@@ -0,0 +1,102 @@
+package savedviews
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the admin saved view endpoints
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new saved view handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// currentAdmin returns the signed-in admin, as set by the auth middleware
+func currentAdmin(c *gin.Context) Admin {
+	return Admin{ID: c.GetString("user_id"), Email: c.GetString("user_email"), Role: c.GetString("admin_role")}
+}
+
+// writeError maps saved view errors to responses
+func writeError(c *gin.Context, err error, code, message string) {
+	switch {
+	case errors.Is(err, ErrViewNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "SAVED_VIEW_NOT_FOUND", "Saved view not found", nil)
+	case errors.Is(err, ErrViewExists):
+		utils.ErrorResponse(c, http.StatusConflict, "SAVED_VIEW_EXISTS", "You already have a saved view with this name", nil)
+	case errors.Is(err, ErrInvalidResource):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_RESOURCE", "Saved views exist for orders, products and customers", nil)
+	case errors.Is(err, ErrResourceNotAllowed):
+		utils.ErrorResponse(c, http.StatusForbidden, "INSUFFICIENT_ADMIN_ROLE", "Your admin role does not allow this grid", nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, code, message, err.Error())
+	}
+}
+
+// ListViews handles GET /api/admin/views, optionally for one resource
+func (h *Handler) ListViews(c *gin.Context) {
+	views, err := h.service.List(currentAdmin(c), c.Query("resource"))
+	if err != nil {
+		writeError(c, err, "GET_SAVED_VIEWS_FAILED", "Failed to get saved views")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Saved views retrieved successfully", views)
+}
+
+// GetView handles GET /api/admin/views/:id
+func (h *Handler) GetView(c *gin.Context) {
+	view, err := h.service.Get(currentAdmin(c), c.Param("id"))
+	if err != nil {
+		writeError(c, err, "GET_SAVED_VIEW_FAILED", "Failed to get saved view")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Saved view retrieved successfully", view)
+}
+
+// CreateView handles POST /api/admin/views
+func (h *Handler) CreateView(c *gin.Context) {
+	var req ViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	view, err := h.service.Create(currentAdmin(c), req)
+	if err != nil {
+		writeError(c, err, "CREATE_SAVED_VIEW_FAILED", "Failed to save view")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusCreated, "View saved successfully", view)
+}
+
+// UpdateView handles PUT /api/admin/views/:id; only the owner can change a view
+func (h *Handler) UpdateView(c *gin.Context) {
+	var req ViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	view, err := h.service.Update(currentAdmin(c), c.Param("id"), req)
+	if err != nil {
+		writeError(c, err, "UPDATE_SAVED_VIEW_FAILED", "Failed to update saved view")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Saved view updated successfully", view)
+}
+
+// DeleteView handles DELETE /api/admin/views/:id; only the owner can delete a view
+func (h *Handler) DeleteView(c *gin.Context) {
+	if err := h.service.Delete(currentAdmin(c), c.Param("id")); err != nil {
+		writeError(c, err, "DELETE_SAVED_VIEW_FAILED", "Failed to delete saved view")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Saved view deleted successfully", nil)
+}
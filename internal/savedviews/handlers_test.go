@@ -0,0 +1,99 @@
+package savedviews
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the saved view service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) List(admin Admin, resource string) ([]models.SavedView, error) {
+	args := m.Called(admin, resource)
+	return args.Get(0).([]models.SavedView), args.Error(1)
+}
+
+func (m *MockService) Get(admin Admin, id string) (*models.SavedView, error) {
+	args := m.Called(admin, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SavedView), args.Error(1)
+}
+
+func (m *MockService) Create(admin Admin, req ViewRequest) (*models.SavedView, error) {
+	args := m.Called(admin, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SavedView), args.Error(1)
+}
+
+func (m *MockService) Update(admin Admin, id string, req ViewRequest) (*models.SavedView, error) {
+	args := m.Called(admin, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SavedView), args.Error(1)
+}
+
+func (m *MockService) Delete(admin Admin, id string) error {
+	return m.Called(admin, id).Error(0)
+}
+
+func TestHandler_CreateView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := Admin{ID: "admin-2", Email: "help@example.com", Role: models.AdminRoleSupport}
+	mockService := new(MockService)
+	mockService.On("Create", admin, mock.MatchedBy(func(req ViewRequest) bool { return req.Resource == models.SavedViewOrders })).
+		Return(&models.SavedView{ID: "view-1", Name: "Late orders"}, nil)
+	mockService.On("Create", admin, mock.MatchedBy(func(req ViewRequest) bool { return req.Resource == models.SavedViewProducts })).
+		Return(nil, ErrResourceNotAllowed)
+
+	r := gin.New()
+	r.POST("/api/admin/views", func(c *gin.Context) {
+		c.Set("user_id", admin.ID)
+		c.Set("user_email", admin.Email)
+		c.Set("admin_role", admin.Role)
+	}, NewHandler(mockService).CreateView)
+
+	for body, code := range map[string]int{
+		`{"resource":"orders","name":"Late orders","state":{"status":"processing"}}`: http.StatusCreated,
+		`{"resource":"products","name":"Mine"}`:                                      http.StatusForbidden,
+		`{"resource":"orders"}`:                                                      http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest("POST", "/api/admin/views", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, body)
+	}
+	mockService.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestHandler_DeleteView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("Delete", mock.Anything, "missing").Return(ErrViewNotFound)
+
+	r := gin.New()
+	r.DELETE("/api/admin/views/:id", NewHandler(mockService).DeleteView)
+
+	req, _ := http.NewRequest("DELETE", "/api/admin/views/missing", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "SAVED_VIEW_NOT_FOUND")
+}
@@ -0,0 +1,35 @@
+package savedviews
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires admin saved views into the application
+type Module struct {
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the saved views module
+func NewModule(deps app.Deps) app.Module {
+	return &Module{handler: NewHandler(NewService(deps.DB)), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "savedviews"
+}
+
+// Models returns the saved view table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.SavedView{}}
+}
+
+// RegisterAPIRoutes sets up the saved view routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,21 @@
+package savedviews
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin saved view routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin/views")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.ListViews)
+		admin.POST("", handler.CreateView)
+		admin.GET("/:id", handler.GetView)
+		admin.PUT("/:id", handler.UpdateView)
+		admin.DELETE("/:id", handler.DeleteView)
+	}
+}
@@ -0,0 +1,208 @@
+package savedviews
+
+import (
+	"errors"
+	"strings"
+
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// resourceRoutes are the admin list endpoints whose grids can have saved views. An admin may only keep and
+// see views of grids their admin role can use.
+var resourceRoutes = map[string]string{
+	models.SavedViewOrders:    "/api/admin/orders",
+	models.SavedViewProducts:  "/api/admin/products",
+	models.SavedViewCustomers: "/api/admin/customers",
+}
+
+var (
+	ErrViewNotFound       = errors.New("saved view not found")
+	ErrViewExists         = errors.New("a saved view with this name already exists")
+	ErrInvalidResource    = errors.New("unknown saved view resource")
+	ErrResourceNotAllowed = errors.New("admin role cannot use this grid")
+)
+
+// Admin identifies the admin working with saved views
+type Admin struct {
+	ID    string
+	Email string
+	Role  string
+}
+
+// ViewRequest creates or replaces a saved view
+type ViewRequest struct {
+	Resource string       `json:"resource" binding:"required"`
+	Name     string       `json:"name" binding:"required,max=100"`
+	State    models.JSONB `json:"state"`
+	Shared   bool         `json:"shared"`
+}
+
+// ServiceInterface defines the saved view operations used by the handlers
+type ServiceInterface interface {
+	List(admin Admin, resource string) ([]models.SavedView, error)
+	Get(admin Admin, id string) (*models.SavedView, error)
+	Create(admin Admin, req ViewRequest) (*models.SavedView, error)
+	Update(admin Admin, id string, req ViewRequest) (*models.SavedView, error)
+	Delete(admin Admin, id string) error
+}
+
+// Service keeps admin saved views
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a saved view service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// allowedResources returns the resources the admin role may keep views of
+func allowedResources(role string) []string {
+	var resources []string
+	for resource, route := range resourceRoutes {
+		if auth.AdminAllowed(role, route) {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+// checkResource validates a resource against the admin's role
+func checkResource(admin Admin, resource string) error {
+	route, ok := resourceRoutes[resource]
+	if !ok {
+		return ErrInvalidResource
+	}
+	if !auth.AdminAllowed(admin.Role, route) {
+		return ErrResourceNotAllowed
+	}
+	return nil
+}
+
+// List returns the admin's own views and those other staff shared, optionally of one resource, by name
+func (s *Service) List(admin Admin, resource string) ([]models.SavedView, error) {
+	resources := allowedResources(admin.Role)
+	if resource != "" {
+		if err := checkResource(admin, resource); err != nil {
+			return nil, err
+		}
+		resources = []string{resource}
+	}
+
+	var views []models.SavedView
+	if err := s.db.Where("resource IN ? AND (owner_id = ? OR shared = ?)", resources, admin.ID, true).
+		Order("resource ASC, name ASC").Find(&views).Error; err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+// Get returns a view the admin owns or that was shared with them
+func (s *Service) Get(admin Admin, id string) (*models.SavedView, error) {
+	var view models.SavedView
+	if err := s.db.Where("id = ? AND resource IN ? AND (owner_id = ? OR shared = ?)", id, allowedResources(admin.Role), admin.ID, true).
+		First(&view).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrViewNotFound
+		}
+		return nil, err
+	}
+	return &view, nil
+}
+
+// Create saves a new view for the admin; names are unique per admin and resource
+func (s *Service) Create(admin Admin, req ViewRequest) (*models.SavedView, error) {
+	if err := checkResource(admin, req.Resource); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkName(admin.ID, req.Resource, name, ""); err != nil {
+		return nil, err
+	}
+
+	view := models.SavedView{
+		OwnerID:    admin.ID,
+		OwnerEmail: admin.Email,
+		Resource:   req.Resource,
+		Name:       name,
+		State:      req.State,
+		Shared:     req.Shared,
+	}
+	if view.State == nil {
+		view.State = models.JSONB{}
+	}
+	if err := s.db.Create(&view).Error; err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// Update replaces one of the admin's own views; views shared by others are read-only
+func (s *Service) Update(admin Admin, id string, req ViewRequest) (*models.SavedView, error) {
+	if err := checkResource(admin, req.Resource); err != nil {
+		return nil, err
+	}
+	view, err := s.owned(admin, id)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkName(admin.ID, req.Resource, name, view.ID); err != nil {
+		return nil, err
+	}
+
+	state := req.State
+	if state == nil {
+		state = models.JSONB{}
+	}
+	if err := s.db.Model(view).Updates(map[string]interface{}{
+		"resource": req.Resource,
+		"name":     name,
+		"state":    state,
+		"shared":   req.Shared,
+	}).Error; err != nil {
+		return nil, err
+	}
+	view.Resource, view.Name, view.State, view.Shared = req.Resource, name, state, req.Shared
+	return view, nil
+}
+
+// Delete removes one of the admin's own views
+func (s *Service) Delete(admin Admin, id string) error {
+	view, err := s.owned(admin, id)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(view).Error
+}
+
+// owned loads a view of the admin's own
+func (s *Service) owned(admin Admin, id string) (*models.SavedView, error) {
+	var view models.SavedView
+	if err := s.db.Where("id = ? AND owner_id = ?", id, admin.ID).First(&view).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrViewNotFound
+		}
+		return nil, err
+	}
+	return &view, nil
+}
+
+// checkName rejects a name the owner already uses for another view of the resource
+func (s *Service) checkName(ownerID, resource, name, exceptID string) error {
+	query := s.db.Model(&models.SavedView{}).Where("owner_id = ? AND resource = ? AND name = ?", ownerID, resource, name)
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrViewExists
+	}
+	return nil
+}
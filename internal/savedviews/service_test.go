@@ -0,0 +1,68 @@
+package savedviews
+
+import (
+	"testing"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestService(t *testing.T) *Service {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	return NewService(database.GetDB())
+}
+
+func TestService_SavedViews(t *testing.T) {
+	service := setupTestService(t)
+	owner := Admin{ID: "admin-1", Email: "ops@example.com", Role: models.AdminRoleSuper}
+	support := Admin{ID: "admin-2", Email: "help@example.com", Role: models.AdminRoleSupport}
+	catalog := Admin{ID: "admin-3", Email: "cat@example.com", Role: models.AdminRoleCatalogManager}
+
+	pending, err := service.Create(owner, ViewRequest{Resource: models.SavedViewOrders, Name: " Pending gifts ", State: models.JSONB{"status": "pending", "sort": "-createdAt"}, Shared: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Pending gifts", pending.Name)
+	_, err = service.Create(owner, ViewRequest{Resource: models.SavedViewOrders, Name: "Pending gifts"})
+	assert.ErrorIs(t, err, ErrViewExists)
+	private, err := service.Create(owner, ViewRequest{Resource: models.SavedViewProducts, Name: "Low stock", State: models.JSONB{"inventoryBelow": 5}})
+	require.NoError(t, err)
+	_, err = service.Create(owner, ViewRequest{Resource: "invoices", Name: "All"})
+	assert.ErrorIs(t, err, ErrInvalidResource)
+	_, err = service.Create(support, ViewRequest{Resource: models.SavedViewProducts, Name: "Mine"})
+	assert.ErrorIs(t, err, ErrResourceNotAllowed)
+
+	// Other staff see shared views of grids they can use, but cannot change them
+	views, err := service.List(support, "")
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, pending.ID, views[0].ID)
+	assert.Equal(t, "pending", views[0].State["status"])
+	_, err = service.Update(support, pending.ID, ViewRequest{Resource: models.SavedViewOrders, Name: "Hijacked"})
+	assert.ErrorIs(t, err, ErrViewNotFound)
+	assert.ErrorIs(t, service.Delete(support, pending.ID), ErrViewNotFound)
+	_, err = service.Get(support, private.ID)
+	assert.ErrorIs(t, err, ErrViewNotFound)
+
+	views, err = service.List(catalog, "")
+	require.NoError(t, err)
+	assert.Empty(t, views, "the shared orders view is outside the catalog manager's grids")
+
+	views, err = service.List(owner, models.SavedViewProducts)
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, private.ID, views[0].ID)
+
+	updated, err := service.Update(owner, pending.ID, ViewRequest{Resource: models.SavedViewOrders, Name: "Pending", State: models.JSONB{"status": "pending"}})
+	require.NoError(t, err)
+	assert.False(t, updated.Shared)
+	views, err = service.List(support, models.SavedViewOrders)
+	require.NoError(t, err)
+	assert.Empty(t, views)
+
+	require.NoError(t, service.Delete(owner, pending.ID))
+	_, err = service.Get(owner, pending.ID)
+	assert.ErrorIs(t, err, ErrViewNotFound)
+}
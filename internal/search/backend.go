@@ -0,0 +1,59 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Search backends selectable with SEARCH_BACKEND
+const (
+	BackendElasticsearch = "elasticsearch"
+	BackendMemory        = "memory"
+)
+
+// ErrUnknownBackend is returned for a SEARCH_BACKEND that is not a known backend
+var ErrUnknownBackend = errors.New("unknown search backend")
+
+// Backend is a product search index. ElasticsearchService is the production backend; MemoryBackend keeps the
+// index in process for tests and small deployments.
+type Backend interface {
+	Ping() error
+	IndexProduct(product *models.Product) error
+	BulkIndexProducts(products []models.Product) (map[string]string, error)
+	RefreshIndex() error
+	DeleteProduct(productID string) error
+	SearchProducts(filters SearchFilters, sort SearchSort, page, pageSize int, includeFacets bool) (*SearchResponse, error)
+	GetSuggestions(query string, size int) ([]string, error)
+	Typeahead(query string, size int) ([]Suggestion, []string, error)
+}
+
+// NewServiceWithBackend creates a search service on backend. A nil backend searches the database only.
+func NewServiceWithBackend(db *gorm.DB, backend Backend) *Service {
+	return &Service{
+		db:             db,
+		backend:        backend,
+		fallbackSearch: backend == nil,
+		breaker:        NewCircuitBreaker(DefaultBreakerThreshold, DefaultBreakerCooldown),
+	}
+}
+
+// NewServiceFor creates a search service on the named backend: elasticsearch (the default when name is empty)
+// or memory. The memory index is loaded from the catalog before it is returned.
+func NewServiceFor(db *gorm.DB, name string) (*Service, error) {
+	switch name {
+	case "", BackendElasticsearch:
+		return NewService(db), nil
+	case BackendMemory:
+		service := NewServiceWithBackend(db, NewMemoryBackend())
+		if _, err := service.ReindexAllProducts(DefaultReindexBatchSize, nil); err != nil {
+			return nil, fmt.Errorf("failed to load in-memory search index: %w", err)
+		}
+		return service, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, name)
+	}
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// Defaults for the Elasticsearch circuit breaker
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// SearchDegradedHeader is set on search responses served by the database fallback instead of Elasticsearch
+const SearchDegradedHeader = "X-Search-Degraded"
+
+// BreakerStatus is a snapshot of a circuit breaker
+type BreakerStatus struct {
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"`
+	OpenedAt  *time.Time `json:"openedAt,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+}
+
+// CircuitBreaker stops calls to Elasticsearch after threshold consecutive failures. Once cooldown has
+// passed a single trial call is let through; its outcome closes the breaker or opens it again.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     string
+	failures  int
+	openedAt  time.Time
+	lastError string
+	probing   bool
+}
+
+// NewCircuitBreaker creates a closed breaker
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+// Allow reports whether a call may go through at now
+func (b *CircuitBreaker) Allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	default:
+		// Only one trial call at a time while half open
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+}
+
+// RecordSuccess closes the breaker and resets the failure count. It returns true if the breaker was not closed.
+func (b *CircuitBreaker) RecordSuccess() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	recovered := b.state != BreakerClosed
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+	b.lastError = ""
+	return recovered
+}
+
+// RecordFailure counts a failed call and opens the breaker once the threshold is reached or a trial call
+// fails. It returns true if this failure opened the breaker.
+func (b *CircuitBreaker) RecordFailure(err error, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if err != nil {
+		b.lastError = err.Error()
+	}
+	wasOpen := b.state == BreakerOpen
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = now
+	}
+	b.probing = false
+	return !wasOpen && b.state == BreakerOpen
+}
+
+// Status returns a snapshot of the breaker
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{State: b.state, Failures: b.failures, LastError: b.lastError}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
@@ -0,0 +1,74 @@
+package search
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, time.Minute)
+	failure := errors.New("connection refused")
+
+	assert.True(t, breaker.Allow(now))
+	assert.False(t, breaker.RecordFailure(failure, now))
+	assert.True(t, breaker.RecordFailure(failure, now), "second failure opens the breaker")
+	assert.Equal(t, BreakerOpen, breaker.Status().State)
+	assert.Equal(t, "connection refused", breaker.Status().LastError)
+	assert.False(t, breaker.Allow(now.Add(30*time.Second)))
+
+	// After the cooldown one trial call goes through
+	later := now.Add(2 * time.Minute)
+	assert.True(t, breaker.Allow(later))
+	assert.Equal(t, BreakerHalfOpen, breaker.Status().State)
+	assert.False(t, breaker.Allow(later))
+
+	// A failed trial opens it again for another cooldown
+	assert.True(t, breaker.RecordFailure(failure, later))
+	assert.False(t, breaker.Allow(later.Add(30*time.Second)))
+
+	assert.True(t, breaker.Allow(later.Add(2*time.Minute)))
+	assert.True(t, breaker.RecordSuccess())
+	status := breaker.Status()
+	assert.Equal(t, BreakerClosed, status.State)
+	assert.Zero(t, status.Failures)
+	assert.Nil(t, status.OpenedAt)
+	assert.False(t, breaker.RecordSuccess())
+}
+
+func TestService_SearchFallsBackWhenElasticsearchFails(t *testing.T) {
+	db := setupTestDB()
+	require.NoError(t, db.Create(&models.Category{ID: "cat-1", Name: "Electronics", Slug: "electronics", IsActive: true}).Error)
+	require.NoError(t, db.Create(&models.Product{ID: "prod-1", Name: "Smartphone", Price: 599.99, SKU: "PHONE-001", Inventory: 10, CategoryID: "cat-1", IsActive: true}).Error)
+
+	// Nothing listens on this address, so every Elasticsearch call fails
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{"http://127.0.0.1:1"}, DisableRetry: true})
+	require.NoError(t, err)
+	service := &Service{db: db, backend: &ElasticsearchService{client: client}, breaker: NewCircuitBreaker(2, time.Hour)}
+	assert.False(t, service.Degraded())
+
+	query := "smart"
+	for i := 0; i < 3; i++ {
+		result, err := service.SearchProducts(SearchFilters{Search: &query}, SearchSort{Field: "created_at", Order: "desc"}, 1, 20, false)
+		require.NoError(t, err)
+		assert.True(t, result.Degraded)
+		assert.Len(t, result.Products, 1)
+	}
+	assert.True(t, service.Degraded())
+	assert.Equal(t, BreakerOpen, service.BreakerStatus().State)
+	assert.Equal(t, 2, service.BreakerStatus().Failures, "calls are not attempted while the breaker is open")
+
+	// Index updates are skipped while the breaker is open
+	assert.ErrorIs(t, service.IndexProduct(&models.Product{ID: "prod-1"}), ErrSearchUnavailable)
+
+	// A failed recovery probe keeps it open
+	service.probeRecovery()
+	assert.Equal(t, BreakerOpen, service.BreakerStatus().State)
+}
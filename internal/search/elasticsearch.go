@@ -5,10 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
 
+	"ecommerce-website/internal/logger"
 	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
@@ -35,12 +36,10 @@ type SearchSort struct {
 
 type SearchResponse struct {
 	Products    []models.Product `json:"products"`
-	Total       int64            `json:"total"`
-	Page        int              `json:"page"`
-	PageSize    int              `json:"pageSize"`
-	TotalPages  int              `json:"totalPages"`
+	Pagination  utils.Pagination `json:"pagination"`
 	Suggestions []string         `json:"suggestions,omitempty"`
 	Facets      *SearchFacets    `json:"facets,omitempty"`
+	Degraded    bool             `json:"degraded,omitempty"` // served by the database fallback
 }
 
 type SearchFacets struct {
@@ -76,19 +75,20 @@ func NewElasticsearchService() (*ElasticsearchService, error) {
 	service := &ElasticsearchService{client: client}
 
 	// Test connection and initialize index
-	if err := service.testConnection(); err != nil {
+	if err := service.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to connect to Elasticsearch: %w", err)
 	}
 
 	// Initialize index
 	if err := service.initializeIndex(); err != nil {
-		log.Printf("Warning: Failed to initialize Elasticsearch index: %v", err)
+		logger.Named("search").Warn("Failed to initialize Elasticsearch index", map[string]interface{}{"error": err.Error()})
 	}
 
 	return service, nil
 }
 
-func (es *ElasticsearchService) testConnection() error {
+// Ping checks that the cluster answers
+func (es *ElasticsearchService) Ping() error {
 	req := esapi.InfoRequest{}
 	res, err := req.Do(context.Background(), es.client)
 	if err != nil {
@@ -156,6 +156,15 @@ func (es *ElasticsearchService) initializeIndex() error {
 				"specifications": {"type": "object"},
 				"seoTitle": {"type": "text"},
 				"seoDescription": {"type": "text"},
+				"translations": {
+					"properties": {
+						"locale": {"type": "keyword"},
+						"name": {"type": "text", "analyzer": "standard"},
+						"description": {"type": "text", "analyzer": "standard"},
+						"seoTitle": {"type": "text"},
+						"seoDescription": {"type": "text"}
+					}
+				},
 				"createdAt": {"type": "date"},
 				"updatedAt": {"type": "date"},
 				"popularity": {"type": "float"}
@@ -192,8 +201,8 @@ func (es *ElasticsearchService) initializeIndex() error {
 	return nil
 }
 
-func (es *ElasticsearchService) IndexProduct(product *models.Product) error {
-	// Prepare document for indexing
+// productDocument builds the search document of a product
+func productDocument(product *models.Product) map[string]interface{} {
 	doc := map[string]interface{}{
 		"id":             product.ID,
 		"name":           product.Name,
@@ -218,6 +227,28 @@ func (es *ElasticsearchService) IndexProduct(product *models.Product) error {
 		doc["categoryName"] = product.Category.Name
 	}
 
+	// Translated names and descriptions make the product searchable in every language it is translated to
+	if len(product.Translations) > 0 {
+		translations := make([]map[string]interface{}, 0, len(product.Translations))
+		for _, translation := range product.Translations {
+			translations = append(translations, map[string]interface{}{
+				"locale":         translation.Locale,
+				"name":           translation.Name,
+				"description":    translation.Description,
+				"seoTitle":       translation.SEOTitle,
+				"seoDescription": translation.SEODescription,
+			})
+		}
+		doc["translations"] = translations
+	}
+
+	return doc
+}
+
+func (es *ElasticsearchService) IndexProduct(product *models.Product) error {
+	// Prepare document for indexing
+	doc := productDocument(product)
+
 	docBytes, err := json.Marshal(doc)
 	if err != nil {
 		return fmt.Errorf("failed to marshal document: %w", err)
@@ -243,6 +274,105 @@ func (es *ElasticsearchService) IndexProduct(product *models.Product) error {
 	return nil
 }
 
+// buildBulkBody builds the NDJSON body of a bulk request indexing products
+func buildBulkBody(products []models.Product) ([]byte, error) {
+	var buf bytes.Buffer
+	for i := range products {
+		action := map[string]interface{}{
+			"index": map[string]interface{}{"_index": ProductIndex, "_id": products[i].ID},
+		}
+		actionBytes, err := json.Marshal(action)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal bulk action: %w", err)
+		}
+		docBytes, err := json.Marshal(productDocument(&products[i]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal document: %w", err)
+		}
+		buf.Write(actionBytes)
+		buf.WriteByte('\n')
+		buf.Write(docBytes)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// bulkResponse is the part of a bulk API response needed to count failed documents
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		ID     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// failedIDs returns the IDs of the documents the bulk request failed to index, with their reasons
+func (r *bulkResponse) failedIDs() map[string]string {
+	failed := make(map[string]string)
+	if !r.Errors {
+		return failed
+	}
+	for _, item := range r.Items {
+		for _, result := range item {
+			if result.Error != nil {
+				failed[result.ID] = result.Error.Type + ": " + result.Error.Reason
+			}
+		}
+	}
+	return failed
+}
+
+// BulkIndexProducts indexes products with a single bulk request and returns the IDs of the products that
+// failed, with their reasons. The index is not refreshed; call RefreshIndex once all batches are sent.
+func (es *ElasticsearchService) BulkIndexProducts(products []models.Product) (map[string]string, error) {
+	if len(products) == 0 {
+		return map[string]string{}, nil
+	}
+
+	body, err := buildBulkBody(products)
+	if err != nil {
+		return nil, err
+	}
+
+	req := esapi.BulkRequest{Body: bytes.NewReader(body)}
+	res, err := req.Do(context.Background(), es.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute bulk request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("bulk request failed: %s", res.String())
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+
+	return result.failedIDs(), nil
+}
+
+// RefreshIndex makes recently indexed products searchable
+func (es *ElasticsearchService) RefreshIndex() error {
+	req := esapi.IndicesRefreshRequest{Index: []string{ProductIndex}}
+	res, err := req.Do(context.Background(), es.client)
+	if err != nil {
+		return fmt.Errorf("failed to refresh index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("failed to refresh index: %s", res.String())
+	}
+
+	return nil
+}
+
 func (es *ElasticsearchService) DeleteProduct(productID string) error {
 	req := esapi.DeleteRequest{
 		Index:      ProductIndex,
@@ -372,7 +502,7 @@ func (es *ElasticsearchService) buildSearchQuery(filters SearchFilters) map[stri
 		must = append(must, map[string]interface{}{
 			"multi_match": map[string]interface{}{
 				"query":     *filters.Search,
-				"fields":    []string{"name^3", "description^2", "categoryName", "sku"},
+				"fields":    []string{"name^3", "description^2", "translations.name^3", "translations.description^2", "categoryName", "sku"},
 				"type":      "best_fields",
 				"fuzziness": "AUTO",
 			},
@@ -501,21 +631,16 @@ func (es *ElasticsearchService) parseSearchResponse(result map[string]interface{
 
 		product, err := es.mapSourceToProduct(source)
 		if err != nil {
-			log.Printf("Error mapping product: %v", err)
+			logger.Named("search").Warn("Failed to map search hit to product", map[string]interface{}{"error": err.Error()})
 			continue
 		}
 
 		products = append(products, *product)
 	}
 
-	totalPages := int((int64(totalValue) + int64(pageSize) - 1) / int64(pageSize))
-
 	response := &SearchResponse{
 		Products:   products,
-		Total:      int64(totalValue),
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
+		Pagination: utils.NewPagination(page, pageSize, int64(totalValue)),
 	}
 
 	// Parse facets if available
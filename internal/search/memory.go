@@ -0,0 +1,378 @@
+package search
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+)
+
+// Weights of the indexed product fields, matching the boosts of the Elasticsearch query
+const (
+	nameWeight        = 3.0
+	descriptionWeight = 2.0
+	categoryWeight    = 1.0
+	skuWeight         = 1.0
+)
+
+// maxCategoryFacets caps the category facets returned, as the Elasticsearch terms aggregation does
+const maxCategoryFacets = 20
+
+// priceRangeFacets are the price buckets reported as facets; a zero Max is open-ended
+var priceRangeFacets = []PriceRangeFacet{
+	{Range: "0-25", Min: 0, Max: 25},
+	{Range: "25-50", Min: 25, Max: 50},
+	{Range: "50-100", Min: 50, Max: 100},
+	{Range: "100-250", Min: 100, Max: 250},
+	{Range: "250+", Min: 250},
+}
+
+// MemoryBackend keeps the product index in process: an inverted index over the name, description, category
+// name and SKU of each product, and the name and description of each of its translations. It answers the same
+// queries as Elasticsearch, matching whole words and word prefixes instead of fuzzy terms, so it suits tests
+// and catalogs small enough to hold in memory.
+type MemoryBackend struct {
+	mu       sync.RWMutex
+	products map[string]models.Product
+	postings map[string]map[string]float64 // term -> product ID -> weight
+}
+
+// NewMemoryBackend creates an empty in-memory index
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		products: make(map[string]models.Product),
+		postings: make(map[string]map[string]float64),
+	}
+}
+
+// tokenize splits text into lowercase words
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// indexedField is searchable text of a product with the weight of the field it comes from
+type indexedField struct {
+	text   string
+	weight float64
+}
+
+func indexedFields(product *models.Product) []indexedField {
+	fields := []indexedField{
+		{product.Name, nameWeight},
+		{product.Description, descriptionWeight},
+		{product.Category.Name, categoryWeight},
+		{product.SKU, skuWeight},
+	}
+	for _, translation := range product.Translations {
+		fields = append(fields, indexedField{translation.Name, nameWeight}, indexedField{translation.Description, descriptionWeight})
+	}
+	return fields
+}
+
+// Ping always succeeds; the index lives in process
+func (m *MemoryBackend) Ping() error {
+	return nil
+}
+
+// IndexProduct adds or replaces a product in the index
+func (m *MemoryBackend) IndexProduct(product *models.Product) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(product.ID)
+	m.add(*product)
+	return nil
+}
+
+// BulkIndexProducts indexes products; no product fails
+func (m *MemoryBackend) BulkIndexProducts(products []models.Product) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, product := range products {
+		m.remove(product.ID)
+		m.add(product)
+	}
+	return map[string]string{}, nil
+}
+
+// RefreshIndex is a no-op; indexed products are searchable at once
+func (m *MemoryBackend) RefreshIndex() error {
+	return nil
+}
+
+// DeleteProduct removes a product from the index
+func (m *MemoryBackend) DeleteProduct(productID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(productID)
+	return nil
+}
+
+func (m *MemoryBackend) add(product models.Product) {
+	m.products[product.ID] = product
+	for _, field := range indexedFields(&product) {
+		for _, term := range tokenize(field.text) {
+			if m.postings[term] == nil {
+				m.postings[term] = make(map[string]float64)
+			}
+			m.postings[term][product.ID] += field.weight
+		}
+	}
+}
+
+func (m *MemoryBackend) remove(productID string) {
+	product, ok := m.products[productID]
+	if !ok {
+		return
+	}
+	delete(m.products, productID)
+	for _, field := range indexedFields(&product) {
+		for _, term := range tokenize(field.text) {
+			delete(m.postings[term], productID)
+			if len(m.postings[term]) == 0 {
+				delete(m.postings, term)
+			}
+		}
+	}
+}
+
+// scores returns the relevance of each product matching any word of query. A whole-word match counts its
+// field weight, a match on the start of a word half of it.
+func (m *MemoryBackend) scores(query string) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, word := range tokenize(query) {
+		for term, postings := range m.postings {
+			factor := 0.0
+			switch {
+			case term == word:
+				factor = 1
+			case strings.HasPrefix(term, word):
+				factor = 0.5
+			default:
+				continue
+			}
+			for id, weight := range postings {
+				scores[id] += weight * factor
+			}
+		}
+	}
+	return scores
+}
+
+// matches reports whether an active product passes the filters
+func matches(product *models.Product, filters SearchFilters) bool {
+	if !product.IsActive {
+		return false
+	}
+	if filters.CategoryID != nil && product.CategoryID != *filters.CategoryID {
+		return false
+	}
+	if filters.MinPrice != nil && product.Price < *filters.MinPrice {
+		return false
+	}
+	if filters.MaxPrice != nil && product.Price > *filters.MaxPrice {
+		return false
+	}
+	if filters.InStock != nil && *filters.InStock && product.Inventory <= 0 {
+		return false
+	}
+	return true
+}
+
+// SearchProducts returns the page of products matching the filters, sorted by the sort field and then by
+// relevance, with category and price facets over all matches when includeFacets is set
+func (m *MemoryBackend) SearchProducts(filters SearchFilters, sort SearchSort, page, pageSize int, includeFacets bool) (*SearchResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var scores map[string]float64
+	if filters.Search != nil && strings.TrimSpace(*filters.Search) != "" {
+		scores = m.scores(*filters.Search)
+	}
+	var hits []models.Product
+	for id := range m.products {
+		product := m.products[id]
+		if scores != nil && scores[id] == 0 {
+			continue
+		}
+		if matches(&product, filters) {
+			// Translations are only indexed; Elasticsearch hits do not carry them either
+			product.Translations = nil
+			hits = append(hits, product)
+		}
+	}
+	sortHits(hits, sort, scores)
+
+	response := &SearchResponse{
+		Products:   []models.Product{},
+		Pagination: utils.NewPagination(page, pageSize, int64(len(hits))),
+	}
+	if from := (page - 1) * pageSize; from < len(hits) {
+		to := from + pageSize
+		if to > len(hits) {
+			to = len(hits)
+		}
+		response.Products = hits[from:to]
+	}
+	if includeFacets {
+		response.Facets = facets(hits)
+	}
+	return response, nil
+}
+
+// sortHits orders products by the sort field (created_at descending by default), then by relevance
+func sortHits(hits []models.Product, by SearchSort, scores map[string]float64) {
+	descending := by.Order != "asc"
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		var order int
+		switch by.Field {
+		case "name":
+			order = strings.Compare(a.Name, b.Name)
+		case "price":
+			order = cmp.Compare(a.Price, b.Price)
+		case "popularity":
+			// Popularity is not tracked yet; every product scores the same
+		default:
+			order = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if order != 0 {
+			return (order < 0) != descending
+		}
+		if scores[a.ID] != scores[b.ID] {
+			return scores[a.ID] > scores[b.ID]
+		}
+		return a.ID < b.ID
+	})
+}
+
+// facets counts the products per category and price range
+func facets(products []models.Product) *SearchFacets {
+	result := &SearchFacets{}
+
+	counts := make(map[string]*CategoryFacet)
+	for _, product := range products {
+		facet, ok := counts[product.CategoryID]
+		if !ok {
+			name := product.Category.Name
+			if name == "" {
+				name = product.CategoryID
+			}
+			facet = &CategoryFacet{ID: product.CategoryID, Name: name}
+			counts[product.CategoryID] = facet
+		}
+		facet.Count++
+	}
+	for _, facet := range counts {
+		result.Categories = append(result.Categories, *facet)
+	}
+	sort.Slice(result.Categories, func(i, j int) bool {
+		if result.Categories[i].Count != result.Categories[j].Count {
+			return result.Categories[i].Count > result.Categories[j].Count
+		}
+		return result.Categories[i].ID < result.Categories[j].ID
+	})
+	if len(result.Categories) > maxCategoryFacets {
+		result.Categories = result.Categories[:maxCategoryFacets]
+	}
+
+	for _, bucket := range priceRangeFacets {
+		facet := bucket
+		for _, product := range products {
+			if product.Price >= bucket.Min && (bucket.Max == 0 || product.Price < bucket.Max) {
+				facet.Count++
+			}
+		}
+		if facet.Max == 0 {
+			facet.Max = 999999 // For open-ended ranges
+		}
+		result.PriceRanges = append(result.PriceRanges, facet)
+	}
+	return result
+}
+
+// activeByName returns the active products whose name starts with prefix, ignoring case, sorted by name
+func (m *MemoryBackend) activeByName(prefix string) []models.Product {
+	prefix = strings.ToLower(prefix)
+	var products []models.Product
+	for _, product := range m.products {
+		if product.IsActive && strings.HasPrefix(strings.ToLower(product.Name), prefix) {
+			products = append(products, product)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool {
+		if products[i].Name != products[j].Name {
+			return products[i].Name < products[j].Name
+		}
+		return products[i].ID < products[j].ID
+	})
+	return products
+}
+
+// GetSuggestions returns up to size distinct names of active products starting with query
+func (m *MemoryBackend) GetSuggestions(query string, size int) ([]string, error) {
+	if size <= 0 {
+		size = 5
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	suggestions := []string{}
+	seen := make(map[string]bool)
+	for _, product := range m.activeByName(query) {
+		if seen[product.Name] || len(suggestions) == size {
+			continue
+		}
+		seen[product.Name] = true
+		suggestions = append(suggestions, product.Name)
+	}
+	return suggestions, nil
+}
+
+// Typeahead returns up to size active products and brands whose names start with query
+func (m *MemoryBackend) Typeahead(query string, size int) ([]Suggestion, []string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var products []Suggestion
+	seen := make(map[string]bool)
+	for _, product := range m.activeByName(query) {
+		if seen[product.Name] || len(products) == size {
+			continue
+		}
+		seen[product.Name] = true
+		products = append(products, productSuggestion(product.ID, product.Name, product.Price, product.CompareAtPrice, product.Images))
+	}
+
+	prefix := strings.ToLower(query)
+	seenBrands := make(map[string]bool)
+	var brands []string
+	for _, product := range m.products {
+		brand, _ := product.Specifications["brand"].(string)
+		brand = strings.TrimSpace(brand)
+		key := strings.ToLower(brand)
+		if !product.IsActive || brand == "" || seenBrands[key] || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		seenBrands[key] = true
+		brands = append(brands, brand)
+	}
+	sort.Strings(brands)
+	if len(brands) > size {
+		brands = brands[:size]
+	}
+	return products, brands, nil
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBackend_Search(t *testing.T) {
+	db := setupTestDB()
+	require.NoError(t, db.Create(&models.Category{ID: "cat-1", Name: "Electronics", Slug: "electronics", IsActive: true}).Error)
+	require.NoError(t, db.Create(&models.Category{ID: "cat-2", Name: "Kitchen", Slug: "kitchen", IsActive: true}).Error)
+	created := time.Now().Add(-time.Hour)
+	products := []models.Product{
+		{ID: "p1", Name: "Smartphone X", Description: "Phone with a great camera", Price: 599, SKU: "PHONE-1", Inventory: 3, CategoryID: "cat-1", IsActive: true, CreatedAt: created},
+		{ID: "p2", Name: "Camera Strap", Description: "Leather strap", Price: 20, SKU: "STRAP-1", Inventory: 0, CategoryID: "cat-1", IsActive: true, CreatedAt: created.Add(time.Minute),
+			Specifications: models.JSONB{"brand": "Sony"}},
+		{ID: "p3", Name: "Coffee Grinder", Description: "Burr grinder", Price: 80, SKU: "GRIND-1", Inventory: 5, CategoryID: "cat-2", IsActive: true, CreatedAt: created.Add(2 * time.Minute)},
+		{ID: "p4", Name: "Camera Bag", Price: 45, SKU: "BAG-1", Inventory: 5, CategoryID: "cat-1", CreatedAt: created.Add(3 * time.Minute)},
+	}
+	for i := range products {
+		require.NoError(t, db.Create(&products[i]).Error)
+	}
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", "p4").Update("is_active", false).Error)
+	require.NoError(t, db.Create(&models.ProductTranslation{ProductID: "p3", Locale: "fr", Name: "Moulin à café", Description: "Moulin à meules"}).Error)
+
+	// The memory backend is loaded from the catalog when the service is created
+	service, err := NewServiceFor(db, BackendMemory)
+	require.NoError(t, err)
+	assert.False(t, service.Degraded())
+
+	query := "camera"
+	result, err := service.SearchProducts(SearchFilters{Search: &query}, SearchSort{Field: "price", Order: "asc"}, 1, 20, true)
+	require.NoError(t, err)
+	assert.False(t, result.Degraded)
+	require.Len(t, result.Products, 2, "inactive products are not found")
+	assert.Equal(t, "p2", result.Products[0].ID)
+	assert.Equal(t, "p1", result.Products[1].ID)
+	require.Len(t, result.Facets.Categories, 1)
+	assert.Equal(t, CategoryFacet{ID: "cat-1", Name: "Electronics", Count: 2}, result.Facets.Categories[0])
+	assert.EqualValues(t, 1, result.Facets.PriceRanges[0].Count)
+
+	// Word prefixes and SKUs match; filters narrow the hits
+	query = "grind"
+	result, err = service.SearchProducts(SearchFilters{Search: &query}, SearchSort{}, 1, 20, false)
+	require.NoError(t, err)
+	require.Len(t, result.Products, 1)
+	assert.Equal(t, "p3", result.Products[0].ID)
+
+	inStock := true
+	result, err = service.SearchProducts(SearchFilters{InStock: &inStock}, SearchSort{}, 1, 1, false)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, result.Pagination.Total)
+	require.Len(t, result.Products, 1)
+	assert.Equal(t, "p3", result.Products[0].ID, "newest first by default")
+
+	// Translated names are searchable, and hits come back without their translations
+	query = "moulin"
+	result, err = service.SearchProducts(SearchFilters{Search: &query}, SearchSort{}, 1, 20, false)
+	require.NoError(t, err)
+	require.Len(t, result.Products, 1)
+	assert.Equal(t, "p3", result.Products[0].ID)
+	assert.Nil(t, result.Products[0].Translations)
+
+	// Index updates and deletes are visible at once
+	products[2].Name = "Espresso Grinder"
+	require.NoError(t, service.IndexProduct(&products[2]))
+	require.NoError(t, service.DeleteProduct("p1"))
+	query = "espresso"
+	result, err = service.SearchProducts(SearchFilters{Search: &query}, SearchSort{}, 1, 20, false)
+	require.NoError(t, err)
+	assert.Len(t, result.Products, 1)
+	query = "smartphone"
+	result, err = service.SearchProducts(SearchFilters{Search: &query}, SearchSort{}, 1, 20, false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Products)
+
+	suggestions, err := service.GetSuggestions("cam", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Camera Strap"}, suggestions)
+
+	typeahead, err := service.Typeahead("so", 5)
+	require.NoError(t, err)
+	assert.False(t, typeahead.Degraded)
+	require.Len(t, typeahead.Suggestions, 1)
+	assert.Equal(t, Suggestion{Type: SuggestionBrand, Text: "Sony"}, typeahead.Suggestions[0])
+}
+
+func TestNewServiceFor_UnknownBackend(t *testing.T) {
+	_, err := NewServiceFor(setupTestDB(), "solr")
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+}
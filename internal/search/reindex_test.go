@@ -0,0 +1,117 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildBulkBody(t *testing.T) {
+	products := []models.Product{
+		{ID: "prod-1", Name: "Phone", Category: models.Category{ID: "cat-1", Name: "Electronics"}},
+		{ID: "prod-2", Name: "Shirt"},
+	}
+
+	body, err := buildBulkBody(products)
+	require.NoError(t, err)
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 4)
+
+	action := lines[0]["index"].(map[string]interface{})
+	assert.Equal(t, ProductIndex, action["_index"])
+	assert.Equal(t, "prod-1", action["_id"])
+	assert.Equal(t, "Phone", lines[1]["name"])
+	assert.Equal(t, "Electronics", lines[1]["categoryName"])
+	assert.Equal(t, "prod-2", lines[2]["index"].(map[string]interface{})["_id"])
+	assert.NotContains(t, lines[3], "categoryName")
+}
+
+func TestBulkResponse_FailedIDs(t *testing.T) {
+	raw := `{"errors":true,"items":[
+		{"index":{"_id":"prod-1","status":201}},
+		{"index":{"_id":"prod-2","status":400,"error":{"type":"mapper_parsing_exception","reason":"bad price"}}}
+	]}`
+	var res bulkResponse
+	require.NoError(t, json.Unmarshal([]byte(raw), &res))
+
+	failed := res.failedIDs()
+	assert.Equal(t, map[string]string{"prod-2": "mapper_parsing_exception: bad price"}, failed)
+
+	res.Errors = false
+	assert.Empty(t, res.failedIDs())
+}
+
+func TestReindexProducts_Batches(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&models.Category{ID: "cat-1", Name: "Electronics", Slug: "electronics", IsActive: true})
+	for i := 1; i <= 5; i++ {
+		db.Create(&models.Product{
+			ID: fmt.Sprintf("prod-%d", i), Name: fmt.Sprintf("Product %d", i), SKU: fmt.Sprintf("SKU-%d", i),
+			Price: 10, CategoryID: "cat-1", IsActive: i != 5,
+		})
+	}
+
+	var batches [][]string
+	index := func(products []models.Product) (map[string]string, error) {
+		var ids []string
+		for _, p := range products {
+			assert.Equal(t, "Electronics", p.Category.Name)
+			ids = append(ids, p.ID)
+		}
+		batches = append(batches, ids)
+		if len(batches) == 2 {
+			return map[string]string{ids[0]: "rejected"}, nil
+		}
+		return map[string]string{}, nil
+	}
+	var reports []ReindexProgress
+
+	progress, err := reindexProducts(db, 2, index, func(p ReindexProgress) { reports = append(reports, p) })
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"prod-1", "prod-2"}, {"prod-3", "prod-4"}, {"prod-5"}}, batches)
+	assert.Equal(t, ReindexCompleted, progress.Status)
+	assert.Equal(t, int64(5), progress.Total)
+	assert.Equal(t, int64(4), progress.Indexed)
+	assert.Equal(t, int64(1), progress.Failed)
+	assert.NotNil(t, progress.FinishedAt)
+	require.Len(t, reports, 4)
+	assert.Equal(t, int64(0), reports[0].Indexed)
+	assert.Equal(t, int64(2), reports[1].Indexed)
+}
+
+func TestReindexProducts_IndexError(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&models.Product{ID: "prod-1", Name: "Product", SKU: "SKU-1", Price: 10, CategoryID: "cat-1"})
+
+	_, err := reindexProducts(db, 0, func([]models.Product) (map[string]string, error) {
+		return nil, errors.New("cluster unreachable")
+	}, nil)
+	assert.ErrorContains(t, err, "cluster unreachable")
+}
+
+func TestReindex_Unavailable(t *testing.T) {
+	service := &Service{db: setupTestDB(), fallbackSearch: true}
+
+	_, err := service.ReindexAllProducts(100, nil)
+	assert.ErrorIs(t, err, ErrSearchUnavailable)
+
+	_, err = service.StartReindex(100)
+	assert.ErrorIs(t, err, ErrSearchUnavailable)
+	assert.Nil(t, service.ReindexStatus())
+}
@@ -1,97 +1,363 @@
 package search
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"strings"
+	"sync"
+	"time"
 
+	"ecommerce-website/internal/logger"
 	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
 
 	"gorm.io/gorm"
 )
 
+var (
+	ErrSearchUnavailable = errors.New("Elasticsearch not available")
+	ErrReindexRunning    = errors.New("a reindex is already running")
+)
+
 type Service struct {
 	db             *gorm.DB
-	elasticsearch  *ElasticsearchService
+	esMu           sync.RWMutex
+	backend        Backend
 	fallbackSearch bool
+	breaker        *CircuitBreaker
+
+	reindexMu sync.Mutex
+	reindex   *ReindexProgress
 }
 
 func NewService(db *gorm.DB) *Service {
+	breaker := NewCircuitBreaker(DefaultBreakerThreshold, DefaultBreakerCooldown)
 	es, err := NewElasticsearchService()
 	if err != nil {
-		log.Printf("Warning: Elasticsearch not available, falling back to database search: %v", err)
+		logger.Named("search").Warn("Elasticsearch not available, falling back to database search", map[string]interface{}{"error": err.Error()})
 		return &Service{
 			db:             db,
-			elasticsearch:  nil,
+			backend:        nil,
 			fallbackSearch: true,
+			breaker:        breaker,
 		}
 	}
 
 	return &Service{
 		db:             db,
-		elasticsearch:  es,
+		backend:        es,
 		fallbackSearch: false,
+		breaker:        breaker,
+	}
+}
+
+// client returns the search backend, or nil when search runs on the database only
+func (s *Service) client() Backend {
+	s.esMu.RLock()
+	defer s.esMu.RUnlock()
+	if s.fallbackSearch {
+		return nil
+	}
+	return s.backend
+}
+
+// Degraded reports whether searches are currently served by the database fallback
+func (s *Service) Degraded() bool {
+	if s.client() == nil {
+		return true
+	}
+	return s.breaker != nil && s.breaker.Status().State != BreakerClosed
+}
+
+// Ping checks that the search backend answers. Without a backend, while search runs on the database only,
+// it returns ErrSearchUnavailable.
+func (s *Service) Ping() error {
+	backend := s.client()
+	if backend == nil {
+		return ErrSearchUnavailable
+	}
+	return backend.Ping()
+}
+
+// BreakerStatus returns the state of the Elasticsearch circuit breaker
+func (s *Service) BreakerStatus() BreakerStatus {
+	if s.client() == nil {
+		return BreakerStatus{State: BreakerOpen, LastError: ErrSearchUnavailable.Error()}
+	}
+	return s.breaker.Status()
+}
+
+// guarded returns the search backend if the circuit breaker lets a call through at now
+func (s *Service) guarded(now time.Time) Backend {
+	es := s.client()
+	if es == nil || s.breaker == nil || !s.breaker.Allow(now) {
+		return nil
+	}
+	return es
+}
+
+// recordResult feeds the outcome of an Elasticsearch call to the circuit breaker
+func (s *Service) recordResult(err error) {
+	if err == nil {
+		if s.breaker.RecordSuccess() {
+			logger.Named("search").Info("Elasticsearch recovered, search circuit closed", nil)
+		}
+		return
+	}
+	if s.breaker.RecordFailure(err, time.Now()) {
+		logger.Named("search").Error("Elasticsearch failing, search circuit opened", err, nil)
 	}
 }
 
-// SearchProducts performs advanced product search
+// SearchProducts performs advanced product search. Elasticsearch is used while its circuit breaker is
+// closed; otherwise, or when the call fails, the database search serves the request and the response is
+// marked degraded.
 func (s *Service) SearchProducts(filters SearchFilters, sort SearchSort, page, pageSize int, includeFacets bool) (*SearchResponse, error) {
-	// Use Elasticsearch if available
-	if !s.fallbackSearch && s.elasticsearch != nil {
-		return s.elasticsearch.SearchProducts(filters, sort, page, pageSize, includeFacets)
+	if es := s.guarded(time.Now()); es != nil {
+		response, err := es.SearchProducts(filters, sort, page, pageSize, includeFacets)
+		s.recordResult(err)
+		if err == nil {
+			return response, nil
+		}
+		logger.Named("search").Warn("Elasticsearch search failed, falling back to database search", map[string]interface{}{"error": err.Error()})
 	}
 
 	// Fallback to database search
-	return s.fallbackDatabaseSearch(filters, sort, page, pageSize)
+	response, err := s.fallbackDatabaseSearch(filters, sort, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	response.Degraded = true
+	return response, nil
 }
 
 // GetSuggestions returns search suggestions
 func (s *Service) GetSuggestions(query string, size int) ([]string, error) {
-	if !s.fallbackSearch && s.elasticsearch != nil {
-		return s.elasticsearch.GetSuggestions(query, size)
+	if es := s.guarded(time.Now()); es != nil {
+		suggestions, err := es.GetSuggestions(query, size)
+		s.recordResult(err)
+		if err == nil {
+			return suggestions, nil
+		}
+		logger.Named("search").Warn("Elasticsearch suggestions failed, falling back to database", map[string]interface{}{"error": err.Error()})
 	}
 
 	// Fallback to database-based suggestions
 	return s.fallbackDatabaseSuggestions(query, size)
 }
 
-// IndexProduct indexes a product in Elasticsearch
+// IndexProduct indexes a product in Elasticsearch. While the circuit is open the update is skipped;
+// a reindex brings the index back in line.
 func (s *Service) IndexProduct(product *models.Product) error {
-	if s.fallbackSearch || s.elasticsearch == nil {
+	es := s.client()
+	if es == nil {
 		return nil // No-op if Elasticsearch is not available
 	}
+	if s.breaker != nil && s.breaker.Status().State == BreakerOpen {
+		return ErrSearchUnavailable
+	}
 
-	return s.elasticsearch.IndexProduct(product)
+	return es.IndexProduct(product)
 }
 
 // DeleteProduct removes a product from Elasticsearch index
 func (s *Service) DeleteProduct(productID string) error {
-	if s.fallbackSearch || s.elasticsearch == nil {
+	es := s.client()
+	if es == nil {
 		return nil // No-op if Elasticsearch is not available
 	}
+	if s.breaker != nil && s.breaker.Status().State == BreakerOpen {
+		return ErrSearchUnavailable
+	}
 
-	return s.elasticsearch.DeleteProduct(productID)
+	return es.DeleteProduct(productID)
 }
 
-// ReindexAllProducts reindexes all products from database to Elasticsearch
-func (s *Service) ReindexAllProducts() error {
-	if s.fallbackSearch || s.elasticsearch == nil {
-		return fmt.Errorf("Elasticsearch not available")
+// probeRecovery checks an unavailable Elasticsearch: it connects if search started without it, and
+// closes the circuit breaker once the cluster answers again
+func (s *Service) probeRecovery() {
+	es := s.client()
+	if es == nil {
+		connected, err := NewElasticsearchService()
+		if err != nil {
+			return
+		}
+		s.esMu.Lock()
+		s.backend = connected
+		s.fallbackSearch = false
+		s.esMu.Unlock()
+		s.breaker.RecordSuccess()
+		logger.Named("search").Info("Connected to Elasticsearch, search no longer degraded; reindex to pick up changes made meanwhile", nil)
+		return
 	}
 
-	var products []models.Product
-	if err := s.db.Preload("Category").Where("is_active = ?", true).Find(&products).Error; err != nil {
-		return fmt.Errorf("failed to fetch products for reindexing: %w", err)
+	if s.breaker.Status().State == BreakerClosed {
+		return
 	}
+	s.recordResult(es.Ping())
+}
 
-	for _, product := range products {
-		if err := s.elasticsearch.IndexProduct(&product); err != nil {
-			log.Printf("Failed to index product %s: %v", product.ID, err)
+// StartRecoveryProbe periodically probes Elasticsearch while search is degraded until ctx is cancelled
+func (s *Service) StartRecoveryProbe(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.probeRecovery()
+			}
+		}
+	}()
+}
+
+// DefaultReindexBatchSize is how many products are sent in each bulk request when reindexing
+const DefaultReindexBatchSize = 500
+
+// maxReindexBatchSize caps the products sent in one bulk request
+const maxReindexBatchSize = 5000
+
+// Reindex job statuses
+const (
+	ReindexRunning   = "running"
+	ReindexCompleted = "completed"
+	ReindexFailed    = "failed"
+)
+
+// ReindexProgress reports how far a reindex of the catalog has got
+type ReindexProgress struct {
+	Status     string     `json:"status"`
+	Total      int64      `json:"total"`
+	Indexed    int64      `json:"indexed"`
+	Failed     int64      `json:"failed"`
+	BatchSize  int        `json:"batchSize"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// bulkIndexFunc indexes a batch of products and returns the IDs of those that failed, with their reasons
+type bulkIndexFunc func(products []models.Product) (map[string]string, error)
+
+// ReindexAllProducts bulk-indexes every product from the database into Elasticsearch in batches of batchSize,
+// calling onProgress, if set, after each batch
+func (s *Service) ReindexAllProducts(batchSize int, onProgress func(ReindexProgress)) (*ReindexProgress, error) {
+	es := s.client()
+	if es == nil {
+		return nil, ErrSearchUnavailable
+	}
+
+	progress, err := reindexProducts(s.db, batchSize, es.BulkIndexProducts, onProgress)
+	if err != nil {
+		return progress, err
+	}
+	if err := es.RefreshIndex(); err != nil {
+		logger.Named("search").Warn("Failed to refresh index after reindex", map[string]interface{}{"error": err.Error()})
+	}
+
+	logger.Named("search").Info("Reindexed products", map[string]interface{}{
+		"total": progress.Total, "indexed": progress.Indexed, "failed": progress.Failed,
+	})
+	return progress, nil
+}
+
+// reindexProducts walks the products table in ID order and hands each batch to index
+func reindexProducts(db *gorm.DB, batchSize int, index bulkIndexFunc, onProgress func(ReindexProgress)) (*ReindexProgress, error) {
+	if batchSize <= 0 || batchSize > maxReindexBatchSize {
+		batchSize = DefaultReindexBatchSize
+	}
+	progress := &ReindexProgress{Status: ReindexRunning, BatchSize: batchSize, StartedAt: time.Now()}
+
+	if err := db.Model(&models.Product{}).Count(&progress.Total).Error; err != nil {
+		return progress, fmt.Errorf("failed to count products for reindexing: %w", err)
+	}
+	if onProgress != nil {
+		onProgress(*progress)
+	}
+
+	lastID := ""
+	for {
+		var batch []models.Product
+		if err := db.Preload("Category").Preload("Translations").Where("id > ?", lastID).Order("id").Limit(batchSize).Find(&batch).Error; err != nil {
+			return progress, fmt.Errorf("failed to fetch products for reindexing: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		lastID = batch[len(batch)-1].ID
+
+		failed, err := index(batch)
+		if err != nil {
+			return progress, fmt.Errorf("failed to index products: %w", err)
+		}
+		for id, reason := range failed {
+			logger.Named("search").Warn("Failed to index product", map[string]interface{}{"product_id": id, "reason": reason})
+		}
+		progress.Failed += int64(len(failed))
+		progress.Indexed += int64(len(batch) - len(failed))
+		if onProgress != nil {
+			onProgress(*progress)
 		}
 	}
 
-	log.Printf("Reindexed %d products", len(products))
-	return nil
+	finished := time.Now()
+	progress.Status = ReindexCompleted
+	progress.FinishedAt = &finished
+	return progress, nil
+}
+
+// StartReindex starts reindexing the catalog in the background and returns its initial progress.
+// Only one reindex runs at a time.
+func (s *Service) StartReindex(batchSize int) (*ReindexProgress, error) {
+	if s.client() == nil {
+		return nil, ErrSearchUnavailable
+	}
+
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	if s.reindex != nil && s.reindex.Status == ReindexRunning {
+		return nil, ErrReindexRunning
+	}
+	s.reindex = &ReindexProgress{Status: ReindexRunning, StartedAt: time.Now()}
+	started := *s.reindex
+
+	go func() {
+		progress, err := s.ReindexAllProducts(batchSize, s.setReindexProgress)
+		if err != nil {
+			logger.Named("search").Error("Reindex failed", err, nil)
+			if progress == nil {
+				progress = &ReindexProgress{StartedAt: started.StartedAt}
+			}
+			finished := time.Now()
+			progress.Status = ReindexFailed
+			progress.FinishedAt = &finished
+			progress.Error = err.Error()
+		}
+		s.setReindexProgress(*progress)
+	}()
+
+	return &started, nil
+}
+
+// ReindexStatus returns the progress of the running or most recent reindex, or nil if none has run
+func (s *Service) ReindexStatus() *ReindexProgress {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	if s.reindex == nil {
+		return nil
+	}
+	progress := *s.reindex
+	return &progress
+}
+
+func (s *Service) setReindexProgress(progress ReindexProgress) {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	s.reindex = &progress
 }
 
 // fallbackDatabaseSearch performs search using database queries
@@ -163,15 +429,9 @@ func (s *Service) fallbackDatabaseSearch(filters SearchFilters, sort SearchSort,
 		return nil, fmt.Errorf("failed to fetch products: %w", err)
 	}
 
-	// Calculate pagination info
-	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
-
 	return &SearchResponse{
 		Products:   products,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
+		Pagination: utils.NewPagination(page, pageSize, total),
 	}, nil
 }
 
@@ -17,7 +17,7 @@ func setupTestDB() *gorm.DB {
 	}
 
 	// Migrate the schema
-	db.AutoMigrate(&models.Product{}, &models.Category{})
+	db.AutoMigrate(&models.Product{}, &models.Category{}, &models.ProductTranslation{})
 
 	return db
 }
@@ -49,7 +49,7 @@ func TestService_FallbackSearch(t *testing.T) {
 
 	service := &Service{
 		db:             db,
-		elasticsearch:  nil,
+		backend:        nil,
 		fallbackSearch: true,
 	}
 
@@ -67,7 +67,7 @@ func TestService_FallbackSearch(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
-	assert.Equal(t, int64(1), result.Total)
+	assert.Equal(t, int64(1), result.Pagination.Total)
 	assert.Len(t, result.Products, 1)
 	assert.Equal(t, "Smartphone", result.Products[0].Name)
 
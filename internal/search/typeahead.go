@@ -0,0 +1,266 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	imageutils "ecommerce-website/internal/utils"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// Typeahead suggestion types
+const (
+	SuggestionProduct  = "product"
+	SuggestionCategory = "category"
+	SuggestionBrand    = "brand"
+)
+
+// DefaultTypeaheadSize and MaxTypeaheadSize bound the suggestions returned per type
+const (
+	DefaultTypeaheadSize = 5
+	MaxTypeaheadSize     = 10
+)
+
+// brandCandidates caps the products scanned for brand names by the database fallback
+const brandCandidates = 200
+
+// Suggestion is one entry of a typeahead dropdown. Product suggestions carry what the storefront needs to
+// render a rich row; category suggestions carry the slug to link to.
+type Suggestion struct {
+	Type           string   `json:"type"`
+	Text           string   `json:"text"`
+	ID             string   `json:"id,omitempty"`
+	Slug           string   `json:"slug,omitempty"`
+	Thumbnail      string   `json:"thumbnail,omitempty"`
+	Price          *float64 `json:"price,omitempty"`
+	CompareAtPrice *float64 `json:"compareAtPrice,omitempty"`
+	PriceDisplay   string   `json:"priceDisplay,omitempty"`
+}
+
+// TypeaheadResponse holds product, category and brand suggestions for a prefix, in that order
+type TypeaheadResponse struct {
+	Suggestions []Suggestion `json:"suggestions"`
+	Degraded    bool         `json:"degraded,omitempty"` // product and brand suggestions came from the database
+}
+
+// Typeahead returns up to size product, category and brand suggestions each for the prefix query.
+// Products and brands come from Elasticsearch while it is available, otherwise from database prefix matching.
+func (s *Service) Typeahead(query string, size int) (*TypeaheadResponse, error) {
+	query = strings.TrimSpace(query)
+	if size <= 0 || size > MaxTypeaheadSize {
+		size = DefaultTypeaheadSize
+	}
+
+	var (
+		products []Suggestion
+		brands   []string
+		degraded = true
+	)
+	if es := s.guarded(time.Now()); es != nil {
+		var err error
+		products, brands, err = es.Typeahead(query, size)
+		s.recordResult(err)
+		if err == nil {
+			degraded = false
+		} else {
+			logger.Named("search").Warn("Elasticsearch typeahead failed, falling back to database", map[string]interface{}{"error": err.Error()})
+		}
+	}
+	if degraded {
+		var err error
+		if products, err = s.databaseProductSuggestions(query, size); err != nil {
+			return nil, err
+		}
+		if brands, err = s.databaseBrandSuggestions(query, size); err != nil {
+			return nil, err
+		}
+	}
+
+	categories, err := s.categorySuggestions(query, size)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]Suggestion, 0, len(products)+len(categories)+len(brands))
+	suggestions = append(suggestions, products...)
+	suggestions = append(suggestions, categories...)
+	for _, brand := range brands {
+		suggestions = append(suggestions, Suggestion{Type: SuggestionBrand, Text: brand})
+	}
+	return &TypeaheadResponse{Suggestions: suggestions, Degraded: degraded}, nil
+}
+
+// productSuggestion builds the suggestion of a product
+func productSuggestion(id, name string, price float64, compareAtPrice *float64, images []string) Suggestion {
+	suggestion := Suggestion{Type: SuggestionProduct, Text: name, ID: id, Price: &price, CompareAtPrice: compareAtPrice}
+	if len(images) > 0 {
+		suggestion.Thumbnail = imageutils.ImageURL(images[0], "thumbnail")
+	}
+	return suggestion
+}
+
+// categorySuggestions matches active category names by prefix
+func (s *Service) categorySuggestions(query string, size int) ([]Suggestion, error) {
+	var categories []models.Category
+	if err := s.db.Select("id", "name", "slug").
+		Where("is_active = ? AND LOWER(name) LIKE ?", true, strings.ToLower(query)+"%").
+		Order("sort_order, name").Limit(size).Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch category suggestions: %w", err)
+	}
+
+	suggestions := make([]Suggestion, 0, len(categories))
+	for _, category := range categories {
+		suggestions = append(suggestions, Suggestion{Type: SuggestionCategory, Text: category.Name, ID: category.ID, Slug: category.Slug})
+	}
+	return suggestions, nil
+}
+
+// databaseProductSuggestions matches active product names by prefix
+func (s *Service) databaseProductSuggestions(query string, size int) ([]Suggestion, error) {
+	var products []models.Product
+	if err := s.db.Select("id", "name", "price", "compare_at_price", "images").
+		Where("is_active = ? AND LOWER(name) LIKE ?", true, strings.ToLower(query)+"%").
+		Order("name").Limit(size).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch product suggestions: %w", err)
+	}
+
+	suggestions := make([]Suggestion, 0, len(products))
+	for _, product := range products {
+		suggestions = append(suggestions, productSuggestion(product.ID, product.Name, product.Price, product.CompareAtPrice, product.Images))
+	}
+	return suggestions, nil
+}
+
+// databaseBrandSuggestions finds the brand specification values of active products starting with query
+func (s *Service) databaseBrandSuggestions(query string, size int) ([]string, error) {
+	prefix := strings.ToLower(query)
+
+	// Narrow down in SQL, then match the brand attribute itself
+	var products []models.Product
+	if err := s.db.Select("id", "specifications").
+		Where("is_active = ? AND LOWER(CAST(specifications AS TEXT)) LIKE ?", true, "%"+prefix+"%").
+		Limit(brandCandidates).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch brand suggestions: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var brands []string
+	for _, product := range products {
+		brand, _ := product.Specifications["brand"].(string)
+		brand = strings.TrimSpace(brand)
+		key := strings.ToLower(brand)
+		if brand == "" || seen[key] || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		seen[key] = true
+		brands = append(brands, brand)
+	}
+	sort.Strings(brands)
+	if len(brands) > size {
+		brands = brands[:size]
+	}
+	return brands, nil
+}
+
+// Typeahead runs the completion suggester on product names and collects brands starting with query
+func (es *ElasticsearchService) Typeahead(query string, size int) ([]Suggestion, []string, error) {
+	searchBody := map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"match_phrase_prefix": map[string]interface{}{"specifications.brand": query},
+		},
+		"aggs": map[string]interface{}{
+			"brands": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "specifications.brand.keyword", "size": size},
+			},
+		},
+		"_source": []string{"id", "name", "price", "compareAtPrice", "images", "isActive"},
+		"suggest": map[string]interface{}{
+			"product_suggest": map[string]interface{}{
+				"prefix": query,
+				"completion": map[string]interface{}{
+					"field":           "name.suggest",
+					"size":            size * 2, // inactive products are dropped afterwards
+					"skip_duplicates": true,
+				},
+			},
+		},
+	}
+
+	searchBytes, err := json.Marshal(searchBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal typeahead query: %w", err)
+	}
+
+	req := esapi.SearchRequest{
+		Index: []string{ProductIndex},
+		Body:  bytes.NewReader(searchBytes),
+	}
+
+	res, err := req.Do(context.Background(), es.client)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute typeahead: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, nil, fmt.Errorf("typeahead error: %s", res.String())
+	}
+
+	var result typeaheadResult
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode typeahead response: %w", err)
+	}
+	products, brands := result.suggestions(size)
+	return products, brands, nil
+}
+
+// typeaheadResult is the part of a typeahead search response that is read
+type typeaheadResult struct {
+	Suggest map[string][]struct {
+		Options []struct {
+			ID     string `json:"_id"`
+			Source struct {
+				Name           string   `json:"name"`
+				Price          float64  `json:"price"`
+				CompareAtPrice *float64 `json:"compareAtPrice"`
+				Images         []string `json:"images"`
+				IsActive       bool     `json:"isActive"`
+			} `json:"_source"`
+		} `json:"options"`
+	} `json:"suggest"`
+	Aggregations struct {
+		Brands struct {
+			Buckets []struct {
+				Key string `json:"key"`
+			} `json:"buckets"`
+		} `json:"brands"`
+	} `json:"aggregations"`
+}
+
+// suggestions returns up to size active product suggestions and brand names from the response
+func (r *typeaheadResult) suggestions(size int) ([]Suggestion, []string) {
+	var products []Suggestion
+	for _, entry := range r.Suggest["product_suggest"] {
+		for _, option := range entry.Options {
+			if !option.Source.IsActive || len(products) == size {
+				continue
+			}
+			products = append(products, productSuggestion(option.ID, option.Source.Name, option.Source.Price, option.Source.CompareAtPrice, option.Source.Images))
+		}
+	}
+
+	var brands []string
+	for _, bucket := range r.Aggregations.Brands.Buckets {
+		brands = append(brands, bucket.Key)
+	}
+	return products, brands
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"encoding/json"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_TypeaheadFallback(t *testing.T) {
+	db := setupTestDB()
+	require.NoError(t, db.Create(&models.Category{ID: "cat-1", Name: "Sound", Slug: "sound", IsActive: true}).Error)
+	require.NoError(t, db.Create(&models.Category{ID: "cat-2", Name: "Sony Outlet", Slug: "sony-outlet", IsActive: true}).Error)
+	products := []models.Product{
+		{ID: "p1", Name: "Sony Headphones", SKU: "SKU-1", Price: 199, CategoryID: "cat-1", IsActive: true,
+			Images: models.StringArray{"sony-1.jpg", "sony-2.jpg"}, Specifications: models.JSONB{"brand": "Sony"}},
+		{ID: "p2", Name: "Soundbar", SKU: "SKU-2", Price: 299, CategoryID: "cat-1", IsActive: true,
+			Specifications: models.JSONB{"brand": "Sonos"}},
+		{ID: "p3", Name: "Speaker", SKU: "SKU-3", Price: 99, CategoryID: "cat-1", IsActive: true,
+			Specifications: models.JSONB{"brand": "JBL", "color": "sonic blue"}},
+		{ID: "p4", Name: "Sony Radio", SKU: "SKU-4", Price: 49, CategoryID: "cat-1", IsActive: true,
+			Specifications: models.JSONB{"brand": "sony"}},
+	}
+	require.NoError(t, db.Create(&products).Error)
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", "p4").Update("is_active", false).Error)
+
+	service := &Service{db: db, fallbackSearch: true}
+	response, err := service.Typeahead("  so ", 5)
+	require.NoError(t, err)
+	assert.True(t, response.Degraded)
+
+	var texts []string
+	for _, suggestion := range response.Suggestions {
+		texts = append(texts, suggestion.Type+":"+suggestion.Text)
+	}
+	assert.Equal(t, []string{
+		"product:Sony Headphones", "product:Soundbar",
+		"category:Sony Outlet", "category:Sound",
+		"brand:Sonos", "brand:Sony",
+	}, texts)
+
+	first := response.Suggestions[0]
+	assert.Equal(t, "p1", first.ID)
+	assert.Equal(t, "sony-1.jpg", first.Thumbnail)
+	require.NotNil(t, first.Price)
+	assert.Equal(t, 199.0, *first.Price)
+	assert.Equal(t, "sony-outlet", response.Suggestions[2].Slug)
+
+	response, err = service.Typeahead("so", 1)
+	require.NoError(t, err)
+	assert.Len(t, response.Suggestions, 3)
+}
+
+func TestTypeaheadResult_Suggestions(t *testing.T) {
+	body := `{
+		"suggest": {"product_suggest": [{"options": [
+			{"_id": "p1", "_source": {"name": "Sony Headphones", "price": 199, "images": ["a.jpg"], "isActive": true}},
+			{"_id": "p2", "_source": {"name": "Sony Radio", "price": 49, "isActive": false}},
+			{"_id": "p3", "_source": {"name": "Sony Speaker", "price": 99, "compareAtPrice": 129, "isActive": true}}
+		]}]},
+		"aggregations": {"brands": {"buckets": [{"key": "Sony", "doc_count": 3}, {"key": "Sonos", "doc_count": 1}]}}
+	}`
+	var result typeaheadResult
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+
+	products, brands := result.suggestions(5)
+	require.Len(t, products, 2)
+	assert.Equal(t, "a.jpg", products[0].Thumbnail)
+	assert.Equal(t, "p3", products[1].ID)
+	require.NotNil(t, products[1].CompareAtPrice)
+	assert.Equal(t, 129.0, *products[1].CompareAtPrice)
+	assert.Equal(t, []string{"Sony", "Sonos"}, brands)
+
+	products, _ = result.suggestions(1)
+	assert.Len(t, products, 1)
+}
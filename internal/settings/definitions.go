@@ -0,0 +1,72 @@
+package settings
+
+import "ecommerce-website/internal/models"
+
+// Keys of the settings read by the tax, shipping, checkout, orders, finance, payments, quotas, catalog, invoice and email modules
+const (
+	KeyTaxRate               = "tax.rate"
+	KeyAdditionalShipmentFee = "shipping.additional_shipment_fee"
+	KeyFreeShippingThreshold = "shipping.free_shipping_threshold"
+	KeyMinOrderAmount        = "checkout.min_order_amount"
+	KeySupportEmail          = "email.support_email"
+	KeyOrderEventSourcing    = "orders.event_sourcing"
+	KeyReviewRequestDelay    = "orders.review_request_delay_days"
+	KeyReviewRequestInterval = "orders.review_request_min_interval_days"
+	KeyAdjustmentApproval    = "finance.adjustment_approval_threshold"
+	KeyPaymentCanaryPercent  = "payments.canary_percent"
+	KeyPaymentCanaryMaxError = "payments.canary_max_error_rate"
+	KeyPaymentCanaryMinCount = "payments.canary_min_attempts"
+	KeyQuotaAPIRequests      = "quota.api_requests_per_minute"
+	KeyQuotaOrders           = "quota.orders_per_minute"
+	KeyQuotaExports          = "quota.exports_per_hour"
+	KeyCatalogLocale         = "catalog.locale"
+	KeyCatalogWeightUnits    = "catalog.weight_units"
+	KeyInvoiceCompanyName    = "invoice.company_name"
+	KeyInvoiceCompanyAddress = "invoice.company_address"
+	KeyInvoiceTaxID          = "invoice.tax_id"
+	KeyReturnAddress         = "returns.address"
+)
+
+// Definition describes a known setting: its type and the value used until an admin overrides it
+type Definition struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Default     string `json:"default"`
+	Description string `json:"description"`
+}
+
+// Definitions lists the settings the application reads. Admins may store other keys too,
+// but known keys must keep their declared type.
+var Definitions = []Definition{
+	{KeyTaxRate, models.SettingTypeFloat, "0", "Tax charged on the order subtotal, as a fraction (0.18 = 18%)"},
+	{KeyAdditionalShipmentFee, models.SettingTypeFloat, "50", "Shipping charged for each shipment after the first in multi-address orders"},
+	{KeyFreeShippingThreshold, models.SettingTypeFloat, "0", "Order subtotal from which shipping is free; 0 disables free shipping"},
+	{KeyMinOrderAmount, models.SettingTypeFloat, "0", "Smallest order subtotal accepted at checkout; 0 disables the minimum"},
+	{KeySupportEmail, models.SettingTypeString, "", "Customer support address shown in order emails"},
+	{KeyOrderEventSourcing, models.SettingTypeBool, "false", "Record every order change in the order event log"},
+	{KeyReviewRequestDelay, models.SettingTypeInt, "7", "Days after delivery the customer is emailed a review request; 0 disables review requests"},
+	{KeyReviewRequestInterval, models.SettingTypeInt, "30", "Fewest days between two review requests to the same customer; 0 removes the cap"},
+	{KeyAdjustmentApproval, models.SettingTypeFloat, "5000", "Returnless refunds and goodwill credits above this amount need a second admin's approval; 0 requires approval for all"},
+	{KeyPaymentCanaryPercent, models.SettingTypeInt, "0", "Percentage of new payment intents created with the canary payment account; reset to 0 when the canary fails"},
+	{KeyPaymentCanaryMaxError, models.SettingTypeFloat, "0.2", "Canary error rate (failed payments and refused orders over attempts) above which payments fall back to the primary account"},
+	{KeyPaymentCanaryMinCount, models.SettingTypeInt, "20", "Fewest canary payment attempts before its error rate can trigger the fallback"},
+	{KeyQuotaAPIRequests, models.SettingTypeInt, "600", "API requests a store may serve per minute; 0 disables the quota"},
+	{KeyQuotaOrders, models.SettingTypeInt, "30", "Orders a store may accept per minute; 0 disables the quota"},
+	{KeyQuotaExports, models.SettingTypeInt, "10", "Exports and report queries a store may run per hour; 0 disables the quota"},
+	{KeyCatalogLocale, models.SettingTypeString, "en-IN", "Locale catalog prices and weights are written in (en-IN, en-US, en-GB, de-DE, fr-FR, ja-JP); prices stay in INR"},
+	{KeyCatalogWeightUnits, models.SettingTypeString, "metric", "Unit system catalog weights are displayed in (metric or imperial)"},
+	{KeyInvoiceCompanyName, models.SettingTypeString, "", "Seller name printed at the top of invoices"},
+	{KeyInvoiceCompanyAddress, models.SettingTypeString, "", "Seller address printed on invoices; separate lines with newlines"},
+	{KeyInvoiceTaxID, models.SettingTypeString, "", "Seller tax registration number (such as a GSTIN) printed on invoices; left out when empty"},
+	{KeyReturnAddress, models.SettingTypeString, "", "Warehouse address returns are shipped to, printed on return labels; separate lines with newlines. Falls back to the invoice address"},
+}
+
+// definition returns the definition of a known key
+func definition(key string) (Definition, bool) {
+	for _, def := range Definitions {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
@@ -0,0 +1,88 @@
+package settings
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new settings handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// ListSettings handles GET /api/admin/settings (admin only)
+func (h *Handler) ListSettings(c *gin.Context) {
+	settings, err := h.service.ListSettings(c.Query("scope"), c.Query("scopeId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_SETTINGS_FAILED", "Failed to get settings", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Settings retrieved successfully", gin.H{
+		"settings":    settings,
+		"definitions": Definitions,
+	})
+}
+
+// GetSetting handles GET /api/admin/settings/:key (admin only)
+func (h *Handler) GetSetting(c *gin.Context) {
+	setting, err := h.service.GetSetting(c.Param("key"), c.Query("scope"), c.Query("scopeId"))
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "SETTING_NOT_FOUND", "Setting not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_SETTING_FAILED", "Failed to get setting", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Setting retrieved successfully", setting)
+}
+
+// SetSetting handles PUT /api/admin/settings/:key (admin only)
+func (h *Handler) SetSetting(c *gin.Context) {
+	var req SetSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+		return
+	}
+
+	adminEmail, _ := c.Get("user_email")
+	updatedBy, _ := adminEmail.(string)
+
+	setting, err := h.service.SetSetting(c.Param("key"), &req, updatedBy)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSetting) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SETTING", err.Error(), nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "SET_SETTING_FAILED", "Failed to save setting", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Setting saved successfully", setting)
+}
+
+// DeleteSetting handles DELETE /api/admin/settings/:key (admin only)
+func (h *Handler) DeleteSetting(c *gin.Context) {
+	if err := h.service.DeleteSetting(c.Param("key"), c.Query("scope"), c.Query("scopeId")); err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "SETTING_NOT_FOUND", "Setting not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "DELETE_SETTING_FAILED", "Failed to delete setting", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Setting deleted successfully", nil)
+}
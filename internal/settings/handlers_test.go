@@ -0,0 +1,74 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSettingsRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	service, _ := setupSettingsTest(t)
+	handler := NewHandler(service)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_email", "admin@example.com")
+		c.Next()
+	})
+	router.GET("/api/admin/settings", handler.ListSettings)
+	router.GET("/api/admin/settings/:key", handler.GetSetting)
+	router.PUT("/api/admin/settings/:key", handler.SetSetting)
+	router.DELETE("/api/admin/settings/:key", handler.DeleteSetting)
+	return router
+}
+
+func TestHandler_SettingsCRUD(t *testing.T) {
+	router := setupSettingsRouter(t)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/admin/settings/"+KeyFreeShippingThreshold, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(http.MethodPut, "/api/admin/settings/"+KeyFreeShippingThreshold, `{"value":"999"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"updatedBy":"admin@example.com"`)
+
+	w = do(http.MethodPut, "/api/admin/settings/"+KeyFreeShippingThreshold, `{"value":"lots"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_SETTING")
+
+	w = do(http.MethodGet, "/api/admin/settings", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Data struct {
+			Settings []struct {
+				Key   string `json:"key"`
+				Value string `json:"value"`
+			} `json:"settings"`
+			Definitions []Definition `json:"definitions"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data.Settings, 1)
+	assert.Equal(t, "999", list.Data.Settings[0].Value)
+	assert.Len(t, list.Data.Definitions, len(Definitions))
+
+	w = do(http.MethodDelete, "/api/admin/settings/"+KeyFreeShippingThreshold, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = do(http.MethodDelete, "/api/admin/settings/"+KeyFreeShippingThreshold, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,36 @@
+package settings
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires the admin settings API into the application. Other modules read settings through
+// their own cached readers, so admin changes reach them within the cache TTL.
+type Module struct {
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the settings module
+func NewModule(deps app.Deps) app.Module {
+	return &Module{handler: NewHandler(NewService(deps.DB)), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "settings"
+}
+
+// Models returns the settings table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.Setting{}}
+}
+
+// RegisterAPIRoutes sets up the admin settings routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,20 @@
+package settings
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin settings routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin/settings")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.ListSettings)
+		admin.GET("/:key", handler.GetSetting)
+		admin.PUT("/:key", handler.SetSetting)
+		admin.DELETE("/:key", handler.DeleteSetting)
+	}
+}
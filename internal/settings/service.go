@@ -0,0 +1,398 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// cacheTTL bounds how long a value is served from memory; admin changes reach every instance within it
+const cacheTTL = 30 * time.Second
+
+// cacheLimit bounds how many values are cached. A full cache drops its expired values, and starts over when
+// none have expired, so lookups for ever new stores cannot grow it without bound.
+const cacheLimit = 10000
+
+var (
+	ErrSettingNotFound = errors.New("setting not found")
+	ErrInvalidSetting  = errors.New("invalid setting")
+)
+
+var keyPattern = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_]+)*$`)
+
+// Reader reads typed settings, falling back to the definition default when a value is not set
+type Reader interface {
+	GetString(ctx context.Context, key string) string
+	GetInt(ctx context.Context, key string) int64
+	GetFloat(ctx context.Context, key string) float64
+	GetBool(ctx context.Context, key string) bool
+}
+
+// ServiceInterface defines the interface for the settings service
+type ServiceInterface interface {
+	Reader
+	ForStore(storeID string) Reader
+	HasStore(ctx context.Context, storeID string) (bool, error)
+	ListSettings(scope, scopeID string) ([]models.Setting, error)
+	GetSetting(key, scope, scopeID string) (*models.Setting, error)
+	SetSetting(key string, req *SetSettingRequest, updatedBy string) (*models.Setting, error)
+	DeleteSetting(key, scope, scopeID string) error
+}
+
+// SetSettingRequest represents the request to create or update a setting
+type SetSettingRequest struct {
+	Scope       string `json:"scope" binding:"omitempty,oneof=global store"`
+	ScopeID     string `json:"scopeId" binding:"max=100"`
+	Type        string `json:"type" binding:"omitempty,oneof=string int float bool json"`
+	Value       string `json:"value"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+type cachedValue struct {
+	value     string
+	found     bool
+	expiresAt time.Time
+}
+
+type Service struct {
+	db *gorm.DB
+
+	mu         sync.RWMutex
+	cache      map[string]cachedValue
+	cacheLimit int
+}
+
+// NewService creates a new settings service. A nil db serves definition defaults only.
+func NewService(db *gorm.DB) *Service {
+	return &Service{
+		db:         db,
+		cache:      make(map[string]cachedValue),
+		cacheLimit: cacheLimit,
+	}
+}
+
+// GetString returns a global string setting
+func (s *Service) GetString(ctx context.Context, key string) string {
+	return s.resolve(ctx, key, "")
+}
+
+// GetInt returns a global integer setting
+func (s *Service) GetInt(ctx context.Context, key string) int64 {
+	return parseInt(key, s.resolve(ctx, key, ""))
+}
+
+// GetFloat returns a global float setting
+func (s *Service) GetFloat(ctx context.Context, key string) float64 {
+	return parseFloat(key, s.resolve(ctx, key, ""))
+}
+
+// GetBool returns a global boolean setting
+func (s *Service) GetBool(ctx context.Context, key string) bool {
+	return parseBool(key, s.resolve(ctx, key, ""))
+}
+
+// ForStore returns a reader that prefers a store's own values over global ones
+func (s *Service) ForStore(storeID string) Reader {
+	return &storeReader{service: s, storeID: storeID}
+}
+
+// HasStore reports whether a store has settings of its own. Stores are set up by giving them settings, so an
+// ID without any names no store.
+func (s *Service) HasStore(ctx context.Context, storeID string) (bool, error) {
+	if s.db == nil || storeID == "" {
+		return false, nil
+	}
+
+	cacheKey := cacheKey("", models.SettingScopeStore, storeID)
+	s.mu.RLock()
+	cached, hit := s.cache[cacheKey]
+	s.mu.RUnlock()
+	if hit && time.Now().Before(cached.expiresAt) {
+		return cached.found, nil
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Setting{}).
+		Where("scope = ? AND scope_id = ?", models.SettingScopeStore, storeID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to look up store settings: %w", err)
+	}
+	s.remember(cacheKey, cachedValue{found: count > 0, expiresAt: time.Now().Add(cacheTTL)})
+	return count > 0, nil
+}
+
+// resolve returns the raw value of a key: the store value, else the global value, else the default
+func (s *Service) resolve(ctx context.Context, key, storeID string) string {
+	if storeID != "" {
+		if value, ok := s.lookup(ctx, key, models.SettingScopeStore, storeID); ok {
+			return value
+		}
+	}
+	if value, ok := s.lookup(ctx, key, models.SettingScopeGlobal, ""); ok {
+		return value
+	}
+	def, _ := definition(key)
+	return def.Default
+}
+
+// lookup reads a stored value through the in-process cache
+func (s *Service) lookup(ctx context.Context, key, scope, scopeID string) (string, bool) {
+	if s.db == nil {
+		return "", false
+	}
+
+	cacheKey := cacheKey(key, scope, scopeID)
+	s.mu.RLock()
+	cached, hit := s.cache[cacheKey]
+	s.mu.RUnlock()
+	if hit && time.Now().Before(cached.expiresAt) {
+		return cached.value, cached.found
+	}
+
+	var setting models.Setting
+	err := s.db.WithContext(ctx).Where("key = ? AND scope = ? AND scope_id = ?", key, scope, scopeID).First(&setting).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		// Serve the default rather than fail the caller; the next read retries
+		logger.Named("settings").Warn("Failed to read setting", map[string]interface{}{"key": key, "error": err.Error()})
+		return "", false
+	}
+
+	entry := cachedValue{value: setting.Value, found: err == nil, expiresAt: time.Now().Add(cacheTTL)}
+	s.remember(cacheKey, entry)
+
+	return entry.value, entry.found
+}
+
+// remember caches a value, making room first when the cache is full
+func (s *Service) remember(cacheKey string, entry cachedValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, cached := s.cache[cacheKey]; !cached && len(s.cache) >= s.cacheLimit {
+		now := time.Now()
+		for key, value := range s.cache {
+			if !now.Before(value.expiresAt) {
+				delete(s.cache, key)
+			}
+		}
+		if len(s.cache) >= s.cacheLimit {
+			s.cache = make(map[string]cachedValue)
+		}
+	}
+	s.cache[cacheKey] = entry
+}
+
+// invalidate drops a cached value after it changed, along with whether its store has settings
+func (s *Service) invalidate(key, scope, scopeID string) {
+	s.mu.Lock()
+	delete(s.cache, cacheKey(key, scope, scopeID))
+	if scope == models.SettingScopeStore {
+		delete(s.cache, cacheKey("", scope, scopeID))
+	}
+	s.mu.Unlock()
+}
+
+// ListSettings returns the stored settings, optionally limited to one scope
+func (s *Service) ListSettings(scope, scopeID string) ([]models.Setting, error) {
+	query := s.db.Model(&models.Setting{})
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+		if scopeID != "" {
+			query = query.Where("scope_id = ?", scopeID)
+		}
+	}
+
+	var settings []models.Setting
+	if err := query.Order("key ASC, scope ASC, scope_id ASC").Find(&settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to list settings: %w", err)
+	}
+	return settings, nil
+}
+
+// GetSetting returns one stored setting
+func (s *Service) GetSetting(key, scope, scopeID string) (*models.Setting, error) {
+	scope, scopeID = normalizeScope(scope, scopeID)
+
+	var setting models.Setting
+	if err := s.db.Where("key = ? AND scope = ? AND scope_id = ?", key, scope, scopeID).First(&setting).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrSettingNotFound
+		}
+		return nil, fmt.Errorf("failed to get setting: %w", err)
+	}
+	return &setting, nil
+}
+
+// SetSetting creates or updates a setting after checking its value parses as its type
+func (s *Service) SetSetting(key string, req *SetSettingRequest, updatedBy string) (*models.Setting, error) {
+	if !keyPattern.MatchString(key) || len(key) > 100 {
+		return nil, fmt.Errorf("%w: key must be lowercase dot-separated words", ErrInvalidSetting)
+	}
+	scope, scopeID := normalizeScope(req.Scope, req.ScopeID)
+	if scope == models.SettingScopeStore && scopeID == "" {
+		return nil, fmt.Errorf("%w: store settings need a scopeId", ErrInvalidSetting)
+	}
+
+	settingType := req.Type
+	if def, known := definition(key); known {
+		if settingType != "" && settingType != def.Type {
+			return nil, fmt.Errorf("%w: %s is a %s setting", ErrInvalidSetting, key, def.Type)
+		}
+		settingType = def.Type
+	}
+	if settingType == "" {
+		return nil, fmt.Errorf("%w: type is required for custom settings", ErrInvalidSetting)
+	}
+	if err := validateValue(settingType, req.Value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSetting, err)
+	}
+
+	setting, err := s.GetSetting(key, scope, scopeID)
+	if err != nil && !errors.Is(err, ErrSettingNotFound) {
+		return nil, err
+	}
+
+	if setting == nil {
+		setting = &models.Setting{
+			Key:         key,
+			Scope:       scope,
+			ScopeID:     scopeID,
+			Type:        settingType,
+			Value:       req.Value,
+			Description: req.Description,
+			UpdatedBy:   updatedBy,
+		}
+		if err := s.db.Create(setting).Error; err != nil {
+			return nil, fmt.Errorf("failed to create setting: %w", err)
+		}
+	} else {
+		// Updates with a map so empty values are written too
+		if err := s.db.Model(setting).Updates(map[string]interface{}{
+			"type":        settingType,
+			"value":       req.Value,
+			"description": req.Description,
+			"updated_by":  updatedBy,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to update setting: %w", err)
+		}
+		setting.Type = settingType
+		setting.Value = req.Value
+		setting.Description = req.Description
+		setting.UpdatedBy = updatedBy
+	}
+
+	s.invalidate(key, scope, scopeID)
+	return setting, nil
+}
+
+// DeleteSetting removes a stored setting so reads fall back to the next scope or the default
+func (s *Service) DeleteSetting(key, scope, scopeID string) error {
+	scope, scopeID = normalizeScope(scope, scopeID)
+
+	result := s.db.Where("key = ? AND scope = ? AND scope_id = ?", key, scope, scopeID).Delete(&models.Setting{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete setting: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSettingNotFound
+	}
+
+	s.invalidate(key, scope, scopeID)
+	return nil
+}
+
+// storeReader resolves settings for one store
+type storeReader struct {
+	service *Service
+	storeID string
+}
+
+func (r *storeReader) GetString(ctx context.Context, key string) string {
+	return r.service.resolve(ctx, key, r.storeID)
+}
+
+func (r *storeReader) GetInt(ctx context.Context, key string) int64 {
+	return parseInt(key, r.service.resolve(ctx, key, r.storeID))
+}
+
+func (r *storeReader) GetFloat(ctx context.Context, key string) float64 {
+	return parseFloat(key, r.service.resolve(ctx, key, r.storeID))
+}
+
+func (r *storeReader) GetBool(ctx context.Context, key string) bool {
+	return parseBool(key, r.service.resolve(ctx, key, r.storeID))
+}
+
+func cacheKey(key, scope, scopeID string) string {
+	return scope + "|" + scopeID + "|" + key
+}
+
+// normalizeScope defaults to the global scope, which never carries a scope ID
+func normalizeScope(scope, scopeID string) (string, string) {
+	if scope == "" || scope == models.SettingScopeGlobal {
+		return models.SettingScopeGlobal, ""
+	}
+	return scope, scopeID
+}
+
+// validateValue checks that a raw value parses as the setting type
+func validateValue(settingType, value string) error {
+	var err error
+	switch settingType {
+	case models.SettingTypeString:
+	case models.SettingTypeInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case models.SettingTypeFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case models.SettingTypeBool:
+		_, err = strconv.ParseBool(value)
+	case models.SettingTypeJSON:
+		if !json.Valid([]byte(value)) {
+			err = errors.New("not valid JSON")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", settingType)
+	}
+	if err != nil {
+		return fmt.Errorf("value %q is not a valid %s", value, settingType)
+	}
+	return nil
+}
+
+// The parse helpers fall back to the definition default if a stored value is unreadable
+
+func parseInt(key, value string) int64 {
+	if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return parsed
+	}
+	def, _ := definition(key)
+	parsed, _ := strconv.ParseInt(def.Default, 10, 64)
+	return parsed
+}
+
+func parseFloat(key, value string) float64 {
+	if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+		return parsed
+	}
+	def, _ := definition(key)
+	parsed, _ := strconv.ParseFloat(def.Default, 64)
+	return parsed
+}
+
+func parseBool(key, value string) bool {
+	if parsed, err := strconv.ParseBool(value); err == nil {
+		return parsed
+	}
+	def, _ := definition(key)
+	parsed, _ := strconv.ParseBool(def.Default)
+	return parsed
+}
@@ -0,0 +1,130 @@
+package settings
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupSettingsTest(t *testing.T) (*Service, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Setting{}))
+
+	return NewService(db), db
+}
+
+func TestService_Reader(t *testing.T) {
+	service, db := setupSettingsTest(t)
+	ctx := context.Background()
+
+	t.Run("unset keys use the definition default", func(t *testing.T) {
+		assert.Equal(t, 50.0, service.GetFloat(ctx, KeyAdditionalShipmentFee))
+		assert.Equal(t, 0.0, service.GetFloat(ctx, KeyTaxRate))
+		assert.Equal(t, "", service.GetString(ctx, KeySupportEmail))
+	})
+
+	t.Run("stored global value wins over the default", func(t *testing.T) {
+		_, err := service.SetSetting(KeyTaxRate, &SetSettingRequest{Value: "0.18"}, "admin@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 0.18, service.GetFloat(ctx, KeyTaxRate))
+	})
+
+	t.Run("store value overrides global for that store only", func(t *testing.T) {
+		_, err := service.SetSetting(KeyTaxRate, &SetSettingRequest{Scope: models.SettingScopeStore, ScopeID: "in-south", Value: "0.05"}, "admin@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 0.05, service.ForStore("in-south").GetFloat(ctx, KeyTaxRate))
+		assert.Equal(t, 0.18, service.ForStore("in-north").GetFloat(ctx, KeyTaxRate))
+		assert.Equal(t, 0.18, service.GetFloat(ctx, KeyTaxRate))
+	})
+
+	t.Run("reads are served from the in-process cache", func(t *testing.T) {
+		// A change made behind the service's back is not seen until the cache entry expires
+		require.NoError(t, db.Model(&models.Setting{}).Where("key = ? AND scope = ?", KeyTaxRate, models.SettingScopeGlobal).Update("value", "0.5").Error)
+		assert.Equal(t, 0.18, service.GetFloat(ctx, KeyTaxRate))
+
+		// Writes through the service invalidate it immediately
+		_, err := service.SetSetting(KeyTaxRate, &SetSettingRequest{Value: "0.12"}, "admin@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 0.12, service.GetFloat(ctx, KeyTaxRate))
+	})
+
+	t.Run("deleting falls back to the default", func(t *testing.T) {
+		require.NoError(t, service.DeleteSetting(KeyTaxRate, "", ""))
+		assert.Equal(t, 0.0, service.GetFloat(ctx, KeyTaxRate))
+		assert.ErrorIs(t, service.DeleteSetting(KeyTaxRate, "", ""), ErrSettingNotFound)
+	})
+
+	t.Run("nil db serves defaults", func(t *testing.T) {
+		assert.Equal(t, 50.0, NewService(nil).GetFloat(ctx, KeyAdditionalShipmentFee))
+	})
+}
+
+func TestService_HasStore(t *testing.T) {
+	service, _ := setupSettingsTest(t)
+	ctx := context.Background()
+
+	known, err := service.HasStore(ctx, "in-south")
+	require.NoError(t, err)
+	assert.False(t, known)
+
+	// Giving a store its first setting sets it up right away, despite the cached miss
+	_, err = service.SetSetting(KeyTaxRate, &SetSettingRequest{Scope: models.SettingScopeStore, ScopeID: "in-south", Value: "0.05"}, "admin@example.com")
+	require.NoError(t, err)
+	known, err = service.HasStore(ctx, "in-south")
+	require.NoError(t, err)
+	assert.True(t, known)
+}
+
+func TestService_CacheIsBounded(t *testing.T) {
+	service, _ := setupSettingsTest(t)
+	service.cacheLimit = 3
+	ctx := context.Background()
+
+	for _, store := range []string{"a", "b", "c", "d", "e"} {
+		service.ForStore(store).GetFloat(ctx, KeyTaxRate)
+		_, err := service.HasStore(ctx, store)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(service.cache), 3)
+	}
+}
+
+func TestService_SetSettingValidation(t *testing.T) {
+	service, _ := setupSettingsTest(t)
+
+	tests := []struct {
+		name string
+		key  string
+		req  SetSettingRequest
+	}{
+		{"value does not parse as the known type", KeyTaxRate, SetSettingRequest{Value: "eighteen"}},
+		{"known key cannot change type", KeyTaxRate, SetSettingRequest{Type: models.SettingTypeString, Value: "0.1"}},
+		{"custom key needs a type", "banner.text", SetSettingRequest{Value: "Sale"}},
+		{"store scope needs a store", KeyTaxRate, SetSettingRequest{Scope: models.SettingScopeStore, Value: "0.1"}},
+		{"malformed key", "Tax Rate", SetSettingRequest{Type: models.SettingTypeString, Value: "x"}},
+		{"invalid json", "checkout.banner", SetSettingRequest{Type: models.SettingTypeJSON, Value: "{"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SetSetting(tt.key, &tt.req, "admin@example.com")
+			assert.ErrorIs(t, err, ErrInvalidSetting)
+		})
+	}
+
+	t.Run("custom typed key is stored", func(t *testing.T) {
+		setting, err := service.SetSetting("checkout.gift_wrap_enabled", &SetSettingRequest{Type: models.SettingTypeBool, Value: "true"}, "admin@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, models.SettingScopeGlobal, setting.Scope)
+		assert.True(t, service.GetBool(context.Background(), "checkout.gift_wrap_enabled"))
+	})
+}
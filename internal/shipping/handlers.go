@@ -0,0 +1,194 @@
+package shipping
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/internal/cart"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new shipping handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// GetRates handles POST /api/shipping/rates. The caller's cart is quoted: the user's cart when
+// signed in, otherwise the guest session cart.
+func (h *Handler) GetRates(c *gin.Context) {
+	var req RatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	cartID := ""
+	if userID := c.GetString("user_id"); userID != "" {
+		cartID = cart.UserCartID(userID)
+	} else if sessionID, err := c.Cookie("session_id"); err == nil {
+		// A forged user cart ID is treated as no session
+		if _, isUserCart := cart.CartOwner(sessionID); !isUserCart {
+			cartID = sessionID
+		}
+	}
+	if cartID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "EMPTY_CART", "Cart is empty", nil)
+		return
+	}
+
+	rates, err := h.service.CartRates(c.Request.Context(), cartID, &req)
+	if err != nil {
+		if errors.Is(err, ErrEmptyCart) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "EMPTY_CART", "Cart is empty", nil)
+			return
+		}
+		if errors.Is(err, ErrAddressNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "ADDRESS_NOT_FOUND", "Address not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get shipping rates", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Shipping rates retrieved successfully", gin.H{"rates": rates})
+}
+
+// ListMethods handles GET /api/admin/shipping/methods (admin only)
+func (h *Handler) ListMethods(c *gin.Context) {
+	methods, err := h.service.ListMethods()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get shipping methods", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Shipping methods retrieved successfully", gin.H{"methods": methods})
+}
+
+// CreateMethod handles POST /api/admin/shipping/methods (admin only)
+func (h *Handler) CreateMethod(c *gin.Context) {
+	var req MethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	method, err := h.service.CreateMethod(&req)
+	if err != nil {
+		h.methodError(c, err, "Failed to create shipping method")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Shipping method created successfully", method)
+}
+
+// UpdateMethod handles PUT /api/admin/shipping/methods/:id (admin only)
+func (h *Handler) UpdateMethod(c *gin.Context) {
+	var req MethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	method, err := h.service.UpdateMethod(c.Param("id"), &req)
+	if err != nil {
+		h.methodError(c, err, "Failed to update shipping method")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Shipping method updated successfully", method)
+}
+
+// DeleteMethod handles DELETE /api/admin/shipping/methods/:id (admin only)
+func (h *Handler) DeleteMethod(c *gin.Context) {
+	if err := h.service.DeleteMethod(c.Param("id")); err != nil {
+		h.methodError(c, err, "Failed to delete shipping method")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Shipping method deleted successfully", nil)
+}
+
+// methodError writes the response for a failed shipping method operation
+func (h *Handler) methodError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrMethodNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "SHIPPING_METHOD_NOT_FOUND", "Shipping method not found", nil)
+	case errors.Is(err, ErrInvalidMethod):
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid shipping method", err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", message, nil)
+	}
+}
+
+// ListZones handles GET /api/admin/shipping/zones (admin only)
+func (h *Handler) ListZones(c *gin.Context) {
+	zones, err := h.service.ListZones()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get delivery zones", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Delivery zones retrieved successfully", gin.H{"zones": zones})
+}
+
+// CreateZone handles POST /api/admin/shipping/zones (admin only)
+func (h *Handler) CreateZone(c *gin.Context) {
+	var req ZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	zone, err := h.service.CreateZone(&req)
+	if err != nil {
+		h.zoneError(c, err, "Failed to create delivery zone")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Delivery zone created successfully", zone)
+}
+
+// UpdateZone handles PUT /api/admin/shipping/zones/:id (admin only)
+func (h *Handler) UpdateZone(c *gin.Context) {
+	var req ZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	zone, err := h.service.UpdateZone(c.Param("id"), &req)
+	if err != nil {
+		h.zoneError(c, err, "Failed to update delivery zone")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Delivery zone updated successfully", zone)
+}
+
+// DeleteZone handles DELETE /api/admin/shipping/zones/:id (admin only)
+func (h *Handler) DeleteZone(c *gin.Context) {
+	if err := h.service.DeleteZone(c.Param("id")); err != nil {
+		h.zoneError(c, err, "Failed to delete delivery zone")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Delivery zone deleted successfully", nil)
+}
+
+// zoneError writes the response for a failed delivery zone operation
+func (h *Handler) zoneError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrZoneNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "DELIVERY_ZONE_NOT_FOUND", "Delivery zone not found", nil)
+	case errors.Is(err, ErrInvalidZone):
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid delivery zone", err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", message, nil)
+	}
+}
@@ -0,0 +1,37 @@
+package shipping
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/cart"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires shipping methods and rate quotes into the application
+type Module struct {
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the shipping module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB, cart.NewService())
+	return &Module{handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "shipping"
+}
+
+// Models returns the shipping method and delivery zone tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.ShippingMethod{}, &models.DeliveryZone{}}
+}
+
+// RegisterAPIRoutes sets up the shipping routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,33 @@
+package shipping
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the shipping rate and admin shipping method and delivery zone routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	// Guests and signed-in shoppers quote their own cart
+	api.POST("/shipping/rates", authService.OptionalAuthMiddleware(), handler.GetRates)
+
+	admin := api.Group("/admin/shipping/methods")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.ListMethods)
+		admin.POST("", handler.CreateMethod)
+		admin.PUT("/:id", handler.UpdateMethod)
+		admin.DELETE("/:id", handler.DeleteMethod)
+	}
+
+	zones := api.Group("/admin/shipping/zones")
+	zones.Use(authService.AuthMiddleware())
+	zones.Use(authService.AdminMiddleware())
+	{
+		zones.GET("", handler.ListZones)
+		zones.POST("", handler.CreateZone)
+		zones.PUT("/:id", handler.UpdateZone)
+		zones.DELETE("/:id", handler.DeleteZone)
+	}
+}
@@ -0,0 +1,318 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"ecommerce-website/internal/cart"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMethodNotFound    = errors.New("shipping method not found")
+	ErrMethodUnavailable = errors.New("shipping method is not available for this destination")
+	ErrInvalidMethod     = errors.New("invalid shipping method")
+	ErrEmptyCart         = errors.New("cart is empty")
+	ErrAddressNotFound   = errors.New("address not found")
+)
+
+// MethodRequest represents the request body for creating or replacing a shipping method
+type MethodRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Description   string   `json:"description" binding:"max=500"`
+	Type          string   `json:"type" binding:"required,oneof=flat weight free_over_threshold"`
+	Rate          float64  `json:"rate" binding:"min=0"`
+	PerKg         float64  `json:"perKg" binding:"min=0"`
+	FreeThreshold float64  `json:"freeThreshold" binding:"min=0"`
+	Countries     []string `json:"countries,omitempty" binding:"omitempty,dive,len=2"`
+	Zones         []string `json:"zones,omitempty"`
+	SortOrder     int      `json:"sortOrder"`
+	IsActive      *bool    `json:"isActive,omitempty"`
+}
+
+// RatesRequest represents the request body for quoting the caller's cart. Signed-in shoppers may quote
+// to one of their saved addresses by AddressID instead.
+type RatesRequest struct {
+	Country    string `json:"country" binding:"required_without=AddressID,omitempty,len=2"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postalCode,omitempty"`
+	AddressID  string `json:"addressId,omitempty"`
+}
+
+// Parcel is what a shipping method prices: where it goes, the delivery zone it goes to if any, what the
+// order is worth and how much it weighs
+type Parcel struct {
+	Country     string
+	Zone        *models.DeliveryZone
+	Subtotal    float64
+	WeightGrams float64
+}
+
+// Rate is the price of shipping a parcel with one method
+type Rate struct {
+	MethodID    string  `json:"methodId"`
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Type        string  `json:"type"`
+	Cost        float64 `json:"cost"`
+	Zone        string  `json:"zone,omitempty"`
+}
+
+// ServiceInterface defines the interface for the shipping service
+type ServiceInterface interface {
+	CartRates(ctx context.Context, cartID string, req *RatesRequest) ([]Rate, error)
+	Method(ctx context.Context, id string) (*models.ShippingMethod, error)
+	ZoneFor(ctx context.Context, userID string, address models.OrderAddress) (*models.DeliveryZone, error)
+	ListMethods() ([]models.ShippingMethod, error)
+	CreateMethod(req *MethodRequest) (*models.ShippingMethod, error)
+	UpdateMethod(id string, req *MethodRequest) (*models.ShippingMethod, error)
+	DeleteMethod(id string) error
+	ListZones() ([]models.DeliveryZone, error)
+	CreateZone(req *ZoneRequest) (*models.DeliveryZone, error)
+	UpdateZone(id string, req *ZoneRequest) (*models.DeliveryZone, error)
+	DeleteZone(id string) error
+}
+
+type Service struct {
+	db          *gorm.DB
+	cartService cart.ServiceInterface
+}
+
+// NewService creates a new shipping service quoting carts from cartService
+func NewService(db *gorm.DB, cartService cart.ServiceInterface) *Service {
+	return &Service{db: db, cartService: cartService}
+}
+
+// CartRates prices every active shipping method that ships to the destination for the contents of a cart,
+// cheapest first
+func (s *Service) CartRates(ctx context.Context, cartID string, req *RatesRequest) ([]Rate, error) {
+	cartData, err := s.cartService.GetCartWithProducts(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+	if cartData.IsEmpty() {
+		return nil, ErrEmptyCart
+	}
+
+	parcel := Parcel{Country: req.Country}
+	if req.AddressID != "" {
+		// Only the owner of a user cart can quote to their saved addresses
+		userID, _ := cart.CartOwner(cartID)
+		var address models.Address
+		if err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", req.AddressID, userID).First(&address).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrAddressNotFound
+			}
+			return nil, fmt.Errorf("failed to fetch address: %w", err)
+		}
+		parcel.Country = address.Country
+		if parcel.Zone, err = s.savedZone(ctx, &address); err != nil {
+			return nil, err
+		}
+	} else if parcel.Zone, err = s.matchZone(ctx, req.Country, req.PostalCode, nil); err != nil {
+		return nil, err
+	}
+	for _, item := range cartData.Items {
+		parcel.Subtotal += item.Price * float64(item.Quantity)
+		if item.Product.WeightGrams != nil {
+			parcel.WeightGrams += *item.Product.WeightGrams * float64(item.Quantity)
+		}
+	}
+
+	var methods []models.ShippingMethod
+	if err := s.db.WithContext(ctx).Where("is_active = ?", true).Order("sort_order, name").Find(&methods).Error; err != nil {
+		return nil, fmt.Errorf("failed to load shipping methods: %w", err)
+	}
+
+	rates := []Rate{}
+	for i := range methods {
+		cost, ok := Price(&methods[i], parcel)
+		if !ok {
+			continue
+		}
+		rate := Rate{
+			MethodID:    methods[i].ID,
+			Name:        methods[i].Name,
+			Description: methods[i].Description,
+			Type:        methods[i].Type,
+			Cost:        cost,
+		}
+		if parcel.Zone != nil {
+			rate.Zone = parcel.Zone.Name
+		}
+		rates = append(rates, rate)
+	}
+	// Methods of the same price keep their configured order
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].Cost < rates[j].Cost })
+	return rates, nil
+}
+
+// Method returns an active shipping method for pricing an order
+func (s *Service) Method(ctx context.Context, id string) (*models.ShippingMethod, error) {
+	var method models.ShippingMethod
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", id, true).First(&method).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMethodNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch shipping method: %w", err)
+	}
+	return &method, nil
+}
+
+// ListMethods returns every shipping method in display order
+func (s *Service) ListMethods() ([]models.ShippingMethod, error) {
+	methods := []models.ShippingMethod{}
+	if err := s.db.Order("sort_order, name").Find(&methods).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch shipping methods: %w", err)
+	}
+	return methods, nil
+}
+
+// CreateMethod adds a shipping method
+func (s *Service) CreateMethod(req *MethodRequest) (*models.ShippingMethod, error) {
+	method := models.ShippingMethod{IsActive: true}
+	if err := applyMethod(&method, req); err != nil {
+		return nil, err
+	}
+	active := method.IsActive
+	if err := s.db.Create(&method).Error; err != nil {
+		return nil, fmt.Errorf("failed to create shipping method: %w", err)
+	}
+	// Inactive methods must be saved explicitly; false is skipped on insert in favour of the column default
+	if !active {
+		if err := s.db.Model(&method).Update("is_active", false).Error; err != nil {
+			return nil, fmt.Errorf("failed to create shipping method: %w", err)
+		}
+	}
+	return &method, nil
+}
+
+// UpdateMethod replaces a shipping method; orders keep the shipping they were charged
+func (s *Service) UpdateMethod(id string, req *MethodRequest) (*models.ShippingMethod, error) {
+	var method models.ShippingMethod
+	if err := s.db.Where("id = ?", id).First(&method).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMethodNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch shipping method: %w", err)
+	}
+	if err := applyMethod(&method, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&method).Error; err != nil {
+		return nil, fmt.Errorf("failed to update shipping method: %w", err)
+	}
+	return &method, nil
+}
+
+// DeleteMethod removes a shipping method
+func (s *Service) DeleteMethod(id string) error {
+	result := s.db.Where("id = ?", id).Delete(&models.ShippingMethod{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete shipping method: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrMethodNotFound
+	}
+	return nil
+}
+
+// applyMethod copies a normalized request onto a method
+func applyMethod(method *models.ShippingMethod, req *MethodRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidMethod)
+	}
+	switch req.Type {
+	case models.ShippingTypeFlat, models.ShippingTypeWeight, models.ShippingTypeFreeOverThreshold:
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidMethod, req.Type)
+	}
+	if req.Rate < 0 || req.PerKg < 0 || req.FreeThreshold < 0 {
+		return fmt.Errorf("%w: rates must not be negative", ErrInvalidMethod)
+	}
+	countries := models.StringArray{}
+	for _, country := range req.Countries {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if len(country) != 2 {
+			return fmt.Errorf("%w: countries must be two-letter codes", ErrInvalidMethod)
+		}
+		countries = append(countries, country)
+	}
+	zones := models.StringArray{}
+	for _, zone := range req.Zones {
+		if zone = strings.TrimSpace(zone); zone != "" {
+			zones = append(zones, zone)
+		}
+	}
+
+	method.Name = name
+	method.Description = strings.TrimSpace(req.Description)
+	method.Type = req.Type
+	method.Rate = req.Rate
+	method.PerKg = req.PerKg
+	method.FreeThreshold = req.FreeThreshold
+	method.Countries = countries
+	method.Zones = zones
+	method.SortOrder = req.SortOrder
+	if req.IsActive != nil {
+		method.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// Price returns what method charges for a parcel, including the surcharge of the parcel's delivery zone, and
+// false when it does not ship to the parcel's country or zone
+func Price(method *models.ShippingMethod, parcel Parcel) (float64, bool) {
+	if len(method.Countries) > 0 {
+		ships := false
+		for _, country := range method.Countries {
+			if strings.EqualFold(country, strings.TrimSpace(parcel.Country)) {
+				ships = true
+				break
+			}
+		}
+		if !ships {
+			return 0, false
+		}
+	}
+
+	if len(method.Zones) > 0 {
+		if parcel.Zone == nil || !containsZone(method.Zones, parcel.Zone.ID) {
+			return 0, false
+		}
+	}
+
+	var cost float64
+	switch method.Type {
+	case models.ShippingTypeWeight:
+		// Every started kilogram is charged
+		cost = method.Rate + method.PerKg*math.Ceil(parcel.WeightGrams/1000)
+	case models.ShippingTypeFreeOverThreshold:
+		if parcel.Subtotal < method.FreeThreshold {
+			cost = method.Rate
+		}
+	default:
+		cost = method.Rate
+	}
+	if parcel.Zone != nil {
+		cost += parcel.Zone.Surcharge
+	}
+	return math.Round(cost*100) / 100, true
+}
+
+// containsZone reports whether zones lists the zone id
+func containsZone(zones models.StringArray, id string) bool {
+	for _, zone := range zones {
+		if zone == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package shipping
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/cart"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeCart serves fixed carts; other cart operations are not used by the shipping service
+type fakeCart struct {
+	cart.ServiceInterface
+	carts map[string]*models.Cart
+}
+
+func (f *fakeCart) GetCartWithProducts(ctx context.Context, sessionID string) (*models.Cart, error) {
+	if c, ok := f.carts[sessionID]; ok {
+		return c, nil
+	}
+	return &models.Cart{SessionID: sessionID}, nil
+}
+
+func setupShippingTest(t *testing.T) (*Service, *fakeCart) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.ShippingMethod{}, &models.DeliveryZone{}, &models.Address{}))
+
+	carts := &fakeCart{carts: map[string]*models.Cart{}}
+	return NewService(db, carts), carts
+}
+
+func weight(grams float64) *float64 {
+	return &grams
+}
+
+func TestPrice(t *testing.T) {
+	flat := &models.ShippingMethod{Type: models.ShippingTypeFlat, Rate: 5}
+	byWeight := &models.ShippingMethod{Type: models.ShippingTypeWeight, Rate: 2, PerKg: 1.5}
+	freeOver := &models.ShippingMethod{Type: models.ShippingTypeFreeOverThreshold, Rate: 7.5, FreeThreshold: 100}
+	domestic := &models.ShippingMethod{Type: models.ShippingTypeFlat, Rate: 3, Countries: models.StringArray{"IN"}}
+
+	cost, ok := Price(flat, Parcel{Country: "US", Subtotal: 500})
+	assert.True(t, ok)
+	assert.Equal(t, 5.0, cost)
+
+	cost, _ = Price(byWeight, Parcel{Country: "US", WeightGrams: 2100})
+	assert.Equal(t, 6.5, cost, "every started kilogram is charged")
+	cost, _ = Price(byWeight, Parcel{Country: "US"})
+	assert.Equal(t, 2.0, cost)
+
+	cost, _ = Price(freeOver, Parcel{Country: "US", Subtotal: 99.99})
+	assert.Equal(t, 7.5, cost)
+	cost, _ = Price(freeOver, Parcel{Country: "US", Subtotal: 100})
+	assert.Zero(t, cost)
+
+	_, ok = Price(domestic, Parcel{Country: "US"})
+	assert.False(t, ok)
+	cost, ok = Price(domestic, Parcel{Country: "in"})
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, cost)
+}
+
+func TestService_CartRates(t *testing.T) {
+	service, carts := setupShippingTest(t)
+	ctx := context.Background()
+	inactive := false
+
+	standard, err := service.CreateMethod(&MethodRequest{Name: "Standard", Type: models.ShippingTypeFreeOverThreshold, Rate: 8, FreeThreshold: 50})
+	require.NoError(t, err)
+	express, err := service.CreateMethod(&MethodRequest{Name: "Express", Type: models.ShippingTypeWeight, Rate: 10, PerKg: 2, SortOrder: 1})
+	require.NoError(t, err)
+	_, err = service.CreateMethod(&MethodRequest{Name: "Local courier", Type: models.ShippingTypeFlat, Rate: 1, Countries: []string{"in"}})
+	require.NoError(t, err)
+	_, err = service.CreateMethod(&MethodRequest{Name: "Retired", Type: models.ShippingTypeFlat, Rate: 0, IsActive: &inactive})
+	require.NoError(t, err)
+
+	_, err = service.CartRates(ctx, "empty", &RatesRequest{Country: "US"})
+	assert.ErrorIs(t, err, ErrEmptyCart)
+
+	carts.carts["guest"] = &models.Cart{Items: []models.CartItem{
+		{ProductID: "mug", Quantity: 2, Price: 20, Product: models.Product{WeightGrams: weight(400)}},
+		{ProductID: "card", Quantity: 1, Price: 5, Product: models.Product{}},
+	}}
+	rates, err := service.CartRates(ctx, "guest", &RatesRequest{Country: "US"})
+	require.NoError(t, err)
+	assert.Equal(t, []Rate{
+		{MethodID: standard.ID, Name: "Standard", Type: models.ShippingTypeFreeOverThreshold, Cost: 8},
+		{MethodID: express.ID, Name: "Express", Type: models.ShippingTypeWeight, Cost: 12},
+	}, rates, "inactive methods and methods for other countries are not offered")
+
+	rates, err = service.CartRates(ctx, "guest", &RatesRequest{Country: "IN"})
+	require.NoError(t, err)
+	require.Len(t, rates, 3)
+	assert.Equal(t, "Local courier", rates[0].Name, "cheapest first")
+}
+
+func TestService_Methods(t *testing.T) {
+	service, _ := setupShippingTest(t)
+	ctx := context.Background()
+	inactive := false
+
+	method, err := service.CreateMethod(&MethodRequest{Name: " Standard ", Type: models.ShippingTypeFlat, Rate: 5, Countries: []string{"us", "ca"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Standard", method.Name)
+	assert.Equal(t, models.StringArray{"US", "CA"}, method.Countries)
+
+	_, err = service.CreateMethod(&MethodRequest{Name: "Odd", Type: "teleport"})
+	assert.ErrorIs(t, err, ErrInvalidMethod)
+
+	stored, err := service.Method(ctx, method.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StringArray{"US", "CA"}, stored.Countries)
+
+	_, err = service.UpdateMethod(method.ID, &MethodRequest{Name: "Standard", Type: models.ShippingTypeFlat, Rate: 6, IsActive: &inactive})
+	require.NoError(t, err)
+	_, err = service.Method(ctx, method.ID)
+	assert.ErrorIs(t, err, ErrMethodNotFound, "inactive methods cannot be chosen")
+
+	methods, err := service.ListMethods()
+	require.NoError(t, err)
+	require.Len(t, methods, 1)
+	assert.Equal(t, 6.0, methods[0].Rate)
+
+	require.NoError(t, service.DeleteMethod(method.ID))
+	assert.ErrorIs(t, service.DeleteMethod(method.ID), ErrMethodNotFound)
+	_, err = service.UpdateMethod(method.ID, &MethodRequest{Name: "Standard", Type: models.ShippingTypeFlat})
+	assert.ErrorIs(t, err, ErrMethodNotFound)
+}
+
+func TestService_Zones(t *testing.T) {
+	service, carts := setupShippingTest(t)
+	ctx := context.Background()
+	lat, lng := 12.97, 77.59
+
+	_, err := service.CreateZone(&ZoneRequest{Name: "Radius only", Country: "IN", RadiusKm: 10})
+	assert.ErrorIs(t, err, ErrInvalidZone, "a radius needs a center")
+
+	city, err := service.CreateZone(&ZoneRequest{Name: "Bengaluru", Country: "in", PostalPrefixes: []string{" 560 "}, CenterLat: &lat, CenterLng: &lng, RadiusKm: 30})
+	require.NoError(t, err)
+	assert.Equal(t, "IN", city.Country)
+	assert.Equal(t, models.StringArray{"560"}, city.PostalPrefixes)
+	remote, err := service.CreateZone(&ZoneRequest{Name: "Rest of India", Country: "IN", Surcharge: 4, Priority: 10})
+	require.NoError(t, err)
+
+	_, err = service.CreateMethod(&MethodRequest{Name: "Same day", Type: models.ShippingTypeFlat, Rate: 6, Zones: []string{city.ID}})
+	require.NoError(t, err)
+	_, err = service.CreateMethod(&MethodRequest{Name: "Standard", Type: models.ShippingTypeFlat, Rate: 3, Countries: []string{"IN"}})
+	require.NoError(t, err)
+
+	carts.carts["guest"] = &models.Cart{Items: []models.CartItem{{ProductID: "mug", Quantity: 1, Price: 20}}}
+	rates, err := service.CartRates(ctx, "guest", &RatesRequest{Country: "IN", PostalCode: "560001"})
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+	assert.Equal(t, "Standard", rates[0].Name)
+	assert.Equal(t, "Bengaluru", rates[0].Zone)
+	assert.Equal(t, "Same day", rates[1].Name)
+
+	rates, err = service.CartRates(ctx, "guest", &RatesRequest{Country: "IN", PostalCode: "110001"})
+	require.NoError(t, err)
+	require.Len(t, rates, 1, "same day delivery is limited to its zone")
+	assert.Equal(t, 7.0, rates[0].Cost, "the zone surcharge is added")
+
+	// A saved address outside the postal prefixes is zoned by its geocoded location
+	userCart := cart.UserCartID("u1")
+	carts.carts[userCart] = carts.carts["guest"]
+	near := models.Address{UserID: "u1", Type: "shipping", FirstName: "A", LastName: "B", Address1: "Airport Road", City: "Bengaluru", State: "KA", PostalCode: "562157", Country: "IN", Latitude: &lat, Longitude: &lng}
+	require.NoError(t, service.db.Create(&near).Error)
+	rates, err = service.CartRates(ctx, userCart, &RatesRequest{AddressID: near.ID})
+	require.NoError(t, err)
+	assert.Len(t, rates, 2)
+	_, err = service.CartRates(ctx, "guest", &RatesRequest{AddressID: near.ID})
+	assert.ErrorIs(t, err, ErrAddressNotFound, "guests cannot quote to saved addresses")
+
+	// Checkout uses the zone stored on the matching saved address
+	require.NoError(t, service.db.Model(&near).Update("delivery_zone_id", city.ID).Error)
+	zone, err := service.ZoneFor(ctx, "u1", models.OrderAddress{Address1: "Airport Road", PostalCode: "562157", Country: "IN"})
+	require.NoError(t, err)
+	assert.Equal(t, city.ID, zone.ID)
+	zone, err = service.ZoneFor(ctx, "u2", models.OrderAddress{Address1: "Airport Road", PostalCode: "562157", Country: "IN"})
+	require.NoError(t, err)
+	assert.Equal(t, remote.ID, zone.ID)
+
+	require.NoError(t, service.DeleteZone(city.ID))
+	assert.ErrorIs(t, service.DeleteZone(city.ID), ErrZoneNotFound)
+	var stored models.Address
+	require.NoError(t, service.db.First(&stored, "id = ?", near.ID).Error)
+	assert.Nil(t, stored.DeliveryZoneID)
+	zones, err := service.ListZones()
+	require.NoError(t, err)
+	assert.Len(t, zones, 1)
+}
@@ -0,0 +1,185 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ecommerce-website/internal/geo"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrZoneNotFound = errors.New("delivery zone not found")
+	ErrInvalidZone  = errors.New("invalid delivery zone")
+)
+
+// ZoneRequest represents the request body for creating or replacing a delivery zone
+type ZoneRequest struct {
+	Name           string   `json:"name" binding:"required,max=100"`
+	Country        string   `json:"country" binding:"required,len=2"`
+	PostalPrefixes []string `json:"postalPrefixes,omitempty"`
+	CenterLat      *float64 `json:"centerLat,omitempty" binding:"omitempty,min=-90,max=90"`
+	CenterLng      *float64 `json:"centerLng,omitempty" binding:"omitempty,min=-180,max=180"`
+	RadiusKm       float64  `json:"radiusKm" binding:"min=0"`
+	Surcharge      float64  `json:"surcharge" binding:"min=0"`
+	Priority       int      `json:"priority"`
+	IsActive       *bool    `json:"isActive,omitempty"`
+}
+
+// ListZones returns every delivery zone by priority
+func (s *Service) ListZones() ([]models.DeliveryZone, error) {
+	zones := []models.DeliveryZone{}
+	if err := s.db.Order("country, priority, name").Find(&zones).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch delivery zones: %w", err)
+	}
+	return zones, nil
+}
+
+// CreateZone adds a delivery zone. Saved addresses are assigned to it by the geocoding backfill.
+func (s *Service) CreateZone(req *ZoneRequest) (*models.DeliveryZone, error) {
+	zone := models.DeliveryZone{IsActive: true}
+	if err := applyZone(&zone, req); err != nil {
+		return nil, err
+	}
+	active := zone.IsActive
+	if err := s.db.Create(&zone).Error; err != nil {
+		return nil, fmt.Errorf("failed to create delivery zone: %w", err)
+	}
+	// Inactive zones must be saved explicitly; false is skipped on insert in favour of the column default
+	if !active {
+		if err := s.db.Model(&zone).Update("is_active", false).Error; err != nil {
+			return nil, fmt.Errorf("failed to create delivery zone: %w", err)
+		}
+	}
+	return &zone, nil
+}
+
+// UpdateZone replaces a delivery zone
+func (s *Service) UpdateZone(id string, req *ZoneRequest) (*models.DeliveryZone, error) {
+	var zone models.DeliveryZone
+	if err := s.db.Where("id = ?", id).First(&zone).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrZoneNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch delivery zone: %w", err)
+	}
+	if err := applyZone(&zone, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&zone).Error; err != nil {
+		return nil, fmt.Errorf("failed to update delivery zone: %w", err)
+	}
+	return &zone, nil
+}
+
+// DeleteZone removes a delivery zone; addresses in it are left unzoned until they are next located
+func (s *Service) DeleteZone(id string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&models.DeliveryZone{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete delivery zone: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrZoneNotFound
+		}
+		if err := tx.Model(&models.Address{}).Where("delivery_zone_id = ?", id).Update("delivery_zone_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to unzone addresses: %w", err)
+		}
+		return nil
+	})
+}
+
+// applyZone copies a normalized request onto a zone
+func applyZone(zone *models.DeliveryZone, req *ZoneRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidZone)
+	}
+	country := strings.ToUpper(strings.TrimSpace(req.Country))
+	if len(country) != 2 {
+		return fmt.Errorf("%w: country must be a two-letter code", ErrInvalidZone)
+	}
+	if req.RadiusKm < 0 || req.Surcharge < 0 {
+		return fmt.Errorf("%w: radius and surcharge must not be negative", ErrInvalidZone)
+	}
+	if req.RadiusKm > 0 && (req.CenterLat == nil || req.CenterLng == nil) {
+		return fmt.Errorf("%w: a radius needs a center", ErrInvalidZone)
+	}
+	prefixes := models.StringArray{}
+	for _, prefix := range req.PostalPrefixes {
+		if prefix = strings.ToUpper(strings.TrimSpace(prefix)); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	zone.Name = name
+	zone.Country = country
+	zone.PostalPrefixes = prefixes
+	zone.CenterLat = req.CenterLat
+	zone.CenterLng = req.CenterLng
+	zone.RadiusKm = req.RadiusKm
+	zone.Surcharge = req.Surcharge
+	zone.Priority = req.Priority
+	if req.IsActive != nil {
+		zone.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// ZoneFor returns the delivery zone a shipping address falls in, or nil when it is in none. When the
+// address is one of the user's saved addresses its geocoded zone is used, so radius zones apply at
+// checkout; otherwise the address is zoned by postal code.
+func (s *Service) ZoneFor(ctx context.Context, userID string, address models.OrderAddress) (*models.DeliveryZone, error) {
+	if userID != "" {
+		var saved models.Address
+		err := s.db.WithContext(ctx).
+			Where("user_id = ? AND country = ? AND postal_code = ? AND address1 = ? AND delivery_zone_id IS NOT NULL",
+				userID, address.Country, address.PostalCode, address.Address1).
+			Order("updated_at DESC").
+			First(&saved).Error
+		if err == nil {
+			return s.savedZone(ctx, &saved)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to fetch saved address: %w", err)
+		}
+	}
+	return s.matchZone(ctx, address.Country, address.PostalCode, nil)
+}
+
+// savedZone returns the zone of a saved address, rematching it when the zone has since been deactivated
+func (s *Service) savedZone(ctx context.Context, address *models.Address) (*models.DeliveryZone, error) {
+	if address.DeliveryZoneID != nil {
+		var zone models.DeliveryZone
+		err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", *address.DeliveryZoneID, true).First(&zone).Error
+		if err == nil {
+			return &zone, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to fetch delivery zone: %w", err)
+		}
+	}
+	var point *geo.Point
+	if address.Latitude != nil && address.Longitude != nil {
+		point = &geo.Point{Lat: *address.Latitude, Lng: *address.Longitude}
+	}
+	return s.matchZone(ctx, address.Country, address.PostalCode, point)
+}
+
+// matchZone returns the active zone covering a location
+func (s *Service) matchZone(ctx context.Context, country, postalCode string, point *geo.Point) (*models.DeliveryZone, error) {
+	zones, err := geo.NewService(s.db, nil).Zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	zone := geo.MatchZone(zones, country, postalCode, point)
+	if zone == nil {
+		return nil, nil
+	}
+	matched := *zone
+	return &matched, nil
+}
@@ -0,0 +1,115 @@
+package sla
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the admin SLA policy, timer and queue endpoints
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new SLA handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// ListPolicies handles GET /api/admin/sla/policies (admin only)
+func (h *Handler) ListPolicies(c *gin.Context) {
+	policies, err := h.service.ListPolicies()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_SLA_POLICIES_FAILED", "Failed to get SLA policies", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "SLA policies retrieved successfully", policies)
+}
+
+// CreatePolicy handles POST /api/admin/sla/policies (admin only)
+func (h *Handler) CreatePolicy(c *gin.Context) {
+	var req PolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	policy, err := h.service.CreatePolicy(req)
+	if err != nil {
+		policyError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusCreated, "SLA policy created successfully", policy)
+}
+
+// UpdatePolicy handles PUT /api/admin/sla/policies/:id (admin only)
+func (h *Handler) UpdatePolicy(c *gin.Context) {
+	var req PolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	policy, err := h.service.UpdatePolicy(c.Param("id"), req)
+	if err != nil {
+		policyError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "SLA policy updated successfully", policy)
+}
+
+// DeletePolicy handles DELETE /api/admin/sla/policies/:id (admin only)
+func (h *Handler) DeletePolicy(c *gin.Context) {
+	if err := h.service.DeletePolicy(c.Param("id")); err != nil {
+		policyError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "SLA policy deleted successfully", nil)
+}
+
+// GetOrderTimers handles GET /api/admin/orders/:id/sla (admin only)
+func (h *Handler) GetOrderTimers(c *gin.Context) {
+	timers, err := h.service.GetOrderTimers(c.Param("id"), time.Now())
+	if err != nil {
+		if errors.Is(err, ErrOrderNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_SLA_TIMERS_FAILED", "Failed to get SLA timers", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "SLA timers retrieved successfully", timers)
+}
+
+// ListQueue handles GET /api/admin/sla/queue (admin only); breached orders are listed unless state=at_risk
+func (h *Handler) ListQueue(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	query := QueueQuery{State: c.DefaultQuery("state", TimerBreached), PolicyID: c.Query("policyId"), Page: page, Limit: limit}
+	items, total, err := h.service.ListQueue(query, time.Now())
+	if err != nil {
+		if errors.Is(err, ErrInvalidState) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error(), nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_SLA_QUEUE_FAILED", "Failed to get SLA queue", err.Error())
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "SLA queue retrieved successfully", "orders", items, utils.NewPagination(page, limit, total))
+}
+
+// policyError writes the response for a failed policy change
+func policyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrPolicyNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "SLA_POLICY_NOT_FOUND", "SLA policy not found", nil)
+	case errors.Is(err, ErrInvalidPolicy):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SLA_POLICY", err.Error(), nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "SLA_POLICY_FAILED", "Failed to save SLA policy", err.Error())
+	}
+}
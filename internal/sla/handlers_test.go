@@ -0,0 +1,122 @@
+package sla
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the SLA service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) ListPolicies() ([]models.SLAPolicy, error) {
+	args := m.Called()
+	return args.Get(0).([]models.SLAPolicy), args.Error(1)
+}
+
+func (m *MockService) CreatePolicy(req PolicyRequest) (*models.SLAPolicy, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SLAPolicy), args.Error(1)
+}
+
+func (m *MockService) UpdatePolicy(id string, req PolicyRequest) (*models.SLAPolicy, error) {
+	args := m.Called(id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SLAPolicy), args.Error(1)
+}
+
+func (m *MockService) DeletePolicy(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockService) GetOrderTimers(orderID string, now time.Time) ([]Timer, error) {
+	args := m.Called(orderID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Timer), args.Error(1)
+}
+
+func (m *MockService) ListQueue(query QueueQuery, now time.Time) ([]QueueItem, int64, error) {
+	args := m.Called(query, now)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]QueueItem), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockService) SendDueAlerts(now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+func TestHandler_CreatePolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("CreatePolicy", mock.MatchedBy(func(req PolicyRequest) bool { return req.TargetStatus == "shipped" })).
+		Return(&models.SLAPolicy{ID: "policy-1", Name: "Ship in 48h"}, nil)
+	mockService.On("CreatePolicy", mock.Anything).Return(nil, ErrInvalidPolicy)
+
+	r := gin.New()
+	r.POST("/api/admin/sla/policies", NewHandler(mockService).CreatePolicy)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/admin/sla/policies", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(`{"name":"Ship in 48h","startStatus":"paid","targetStatus":"shipped","targetHours":48}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = send(`{"name":"Backwards","startStatus":"shipped","targetStatus":"paid","targetHours":48}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_SLA_POLICY")
+
+	w = send(`{"name":"No deadline","startStatus":"paid","targetStatus":"shipped"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_REQUEST")
+}
+
+func TestHandler_ListQueue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("ListQueue", QueueQuery{State: TimerBreached, Page: 1, Limit: 20}, mock.Anything).
+		Return([]QueueItem{{OrderID: "order-1", State: TimerBreached}}, int64(1), nil)
+	mockService.On("ListQueue", QueueQuery{State: "running", Page: 1, Limit: 20}, mock.Anything).
+		Return(nil, int64(0), ErrInvalidState)
+
+	r := gin.New()
+	r.GET("/api/admin/sla/queue", NewHandler(mockService).ListQueue)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/admin/sla/queue", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "order-1")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/admin/sla/queue?state=running", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,48 @@
+package sla
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// alertInterval is how often open orders are checked against the SLA policies
+const alertInterval = 5 * time.Minute
+
+// Module wires fulfillment SLA policies, timers and alerts into the application
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the SLA module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "sla"
+}
+
+// Models returns the SLA policy and alert tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.SLAPolicy{}, &models.SLAAlert{}}
+}
+
+// StartJobs raises alerts for orders approaching or past their deadlines in the background
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartAlertSweeper(ctx, alertInterval)
+}
+
+// RegisterAPIRoutes sets up the admin SLA routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
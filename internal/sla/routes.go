@@ -0,0 +1,22 @@
+package sla
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin SLA routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("/sla/policies", handler.ListPolicies)
+		admin.POST("/sla/policies", handler.CreatePolicy)
+		admin.PUT("/sla/policies/:id", handler.UpdatePolicy)
+		admin.DELETE("/sla/policies/:id", handler.DeletePolicy)
+		admin.GET("/sla/queue", handler.ListQueue)
+		admin.GET("/orders/:id/sla", handler.GetOrderTimers)
+	}
+}
@@ -0,0 +1,448 @@
+package sla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/monitoring"
+	"ecommerce-website/internal/orderhistory"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Timer states of an order under a policy
+const (
+	TimerRunning  = "running"
+	TimerAtRisk   = "at_risk"
+	TimerBreached = "breached"
+	TimerMet      = "met"
+	TimerMissed   = "missed"
+	TimerStopped  = "stopped"
+)
+
+var (
+	ErrPolicyNotFound = errors.New("SLA policy not found")
+	ErrOrderNotFound  = errors.New("order not found")
+	ErrInvalidPolicy  = errors.New("invalid SLA policy")
+	ErrInvalidState   = errors.New("state must be breached or at_risk")
+)
+
+// fulfillmentStatuses are the order statuses in the order an order moves through them
+var fulfillmentStatuses = []string{"pending", "paid", "processing", "shipped", "delivered"}
+
+// statusRank returns the position of status in the fulfillment flow, or -1 for cancelled, refunded and unknown statuses
+func statusRank(status string) int {
+	for i, s := range fulfillmentStatuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// PolicyRequest creates or replaces an SLA policy
+type PolicyRequest struct {
+	Name         string `json:"name" binding:"required,max=100"`
+	StartStatus  string `json:"startStatus" binding:"required"`
+	TargetStatus string `json:"targetStatus" binding:"required"`
+	TargetHours  int    `json:"targetHours" binding:"required,gt=0"`
+	WarningHours int    `json:"warningHours" binding:"gte=0"`
+	Active       *bool  `json:"active"`
+}
+
+// Timer is the progress of one order against one policy
+type Timer struct {
+	PolicyID   string     `json:"policyId"`
+	PolicyName string     `json:"policyName"`
+	State      string     `json:"state"`
+	StartedAt  time.Time  `json:"startedAt"`
+	DueAt      time.Time  `json:"dueAt"`
+	StoppedAt  *time.Time `json:"stoppedAt,omitempty"`
+}
+
+// QueueItem is an open order that breached, or is about to breach, a policy
+type QueueItem struct {
+	OrderID     string    `json:"orderId"`
+	OrderStatus string    `json:"orderStatus"`
+	Total       float64   `json:"total"`
+	PolicyID    string    `json:"policyId"`
+	PolicyName  string    `json:"policyName"`
+	State       string    `json:"state"`
+	StartedAt   time.Time `json:"startedAt"`
+	DueAt       time.Time `json:"dueAt"`
+}
+
+// QueueQuery filters the SLA queue
+type QueueQuery struct {
+	State    string
+	PolicyID string
+	Page     int
+	Limit    int
+}
+
+// ServiceInterface defines the interface for the SLA service
+type ServiceInterface interface {
+	ListPolicies() ([]models.SLAPolicy, error)
+	CreatePolicy(req PolicyRequest) (*models.SLAPolicy, error)
+	UpdatePolicy(id string, req PolicyRequest) (*models.SLAPolicy, error)
+	DeletePolicy(id string) error
+	GetOrderTimers(orderID string, now time.Time) ([]Timer, error)
+	ListQueue(query QueueQuery, now time.Time) ([]QueueItem, int64, error)
+	SendDueAlerts(now time.Time) (int, error)
+}
+
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new SLA service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// validatePolicy checks that a policy starts before it ends in the fulfillment flow and warns before its deadline
+func validatePolicy(req PolicyRequest) error {
+	start, target := statusRank(req.StartStatus), statusRank(req.TargetStatus)
+	if start < 0 || target < 0 {
+		return fmt.Errorf("%w: statuses must be one of pending, paid, processing, shipped, delivered", ErrInvalidPolicy)
+	}
+	if start >= target {
+		return fmt.Errorf("%w: target status must come after the start status", ErrInvalidPolicy)
+	}
+	if req.WarningHours >= req.TargetHours {
+		return fmt.Errorf("%w: warning hours must be less than target hours", ErrInvalidPolicy)
+	}
+	return nil
+}
+
+// ListPolicies returns every SLA policy, oldest first
+func (s *Service) ListPolicies() ([]models.SLAPolicy, error) {
+	var policies []models.SLAPolicy
+	if err := s.db.Order("created_at ASC").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to get SLA policies: %w", err)
+	}
+	return policies, nil
+}
+
+// CreatePolicy adds an SLA policy, active unless the request says otherwise
+func (s *Service) CreatePolicy(req PolicyRequest) (*models.SLAPolicy, error) {
+	if err := validatePolicy(req); err != nil {
+		return nil, err
+	}
+	policy := models.SLAPolicy{
+		Name:         req.Name,
+		StartStatus:  req.StartStatus,
+		TargetStatus: req.TargetStatus,
+		TargetHours:  req.TargetHours,
+		WarningHours: req.WarningHours,
+		Active:       req.Active == nil || *req.Active,
+	}
+	active := policy.Active
+	if err := s.db.Create(&policy).Error; err != nil {
+		return nil, fmt.Errorf("failed to create SLA policy: %w", err)
+	}
+	// Inactive policies must be saved explicitly; false is skipped on insert in favour of the column default
+	if !active {
+		if err := s.db.Model(&policy).Update("active", false).Error; err != nil {
+			return nil, fmt.Errorf("failed to create SLA policy: %w", err)
+		}
+	}
+	return &policy, nil
+}
+
+// UpdatePolicy replaces an SLA policy; alerts already raised under it are kept
+func (s *Service) UpdatePolicy(id string, req PolicyRequest) (*models.SLAPolicy, error) {
+	if err := validatePolicy(req); err != nil {
+		return nil, err
+	}
+	var policy models.SLAPolicy
+	if err := s.db.First(&policy, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPolicyNotFound
+		}
+		return nil, fmt.Errorf("failed to get SLA policy: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"name":          req.Name,
+		"start_status":  req.StartStatus,
+		"target_status": req.TargetStatus,
+		"target_hours":  req.TargetHours,
+		"warning_hours": req.WarningHours,
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+	if err := s.db.Model(&policy).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update SLA policy: %w", err)
+	}
+	if err := s.db.First(&policy, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get SLA policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// DeletePolicy removes an SLA policy and the record of its alerts
+func (s *Service) DeletePolicy(id string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.SLAPolicy{}, "id = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete SLA policy: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrPolicyNotFound
+		}
+		if err := tx.Delete(&models.SLAAlert{}, "policy_id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to delete SLA alerts: %w", err)
+		}
+		return nil
+	})
+}
+
+// activePolicies returns the policies orders are timed against
+func (s *Service) activePolicies(policyID string) ([]models.SLAPolicy, error) {
+	query := s.db.Where("active = ?", true)
+	if policyID != "" {
+		query = query.Where("id = ?", policyID)
+	}
+	var policies []models.SLAPolicy
+	if err := query.Order("created_at ASC").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to get SLA policies: %w", err)
+	}
+	return policies, nil
+}
+
+// GetOrderTimers computes the order's timer under every active policy from its status history. Policies
+// whose start status the order never entered have no timer.
+func (s *Service) GetOrderTimers(orderID string, now time.Time) ([]Timer, error) {
+	var order models.Order
+	if err := s.db.Select("id").First(&order, "id = ?", orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	history, err := orderhistory.Load(s.db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := s.activePolicies("")
+	if err != nil {
+		return nil, err
+	}
+
+	timers := []Timer{}
+	for _, policy := range policies {
+		if timer, ok := orderTimer(policy, history, now); ok {
+			timers = append(timers, timer)
+		}
+	}
+	return timers, nil
+}
+
+// orderTimer runs a policy's timer over an order's status history, oldest first
+func orderTimer(policy models.SLAPolicy, history []models.OrderStatusHistory, now time.Time) (Timer, bool) {
+	timer := Timer{PolicyID: policy.ID, PolicyName: policy.Name}
+	started := false
+	for _, entry := range history {
+		if !started {
+			if entry.NewStatus == policy.StartStatus {
+				started = true
+				timer.StartedAt = entry.CreatedAt
+				timer.DueAt = entry.CreatedAt.Add(time.Duration(policy.TargetHours) * time.Hour)
+			}
+			continue
+		}
+
+		rank := statusRank(entry.NewStatus)
+		if rank < 0 {
+			// Cancelled or refunded before the target was reached
+			stoppedAt := entry.CreatedAt
+			timer.State, timer.StoppedAt = TimerStopped, &stoppedAt
+			return timer, true
+		}
+		if rank >= statusRank(policy.TargetStatus) {
+			stoppedAt := entry.CreatedAt
+			timer.State, timer.StoppedAt = TimerMet, &stoppedAt
+			if stoppedAt.After(timer.DueAt) {
+				timer.State = TimerMissed
+			}
+			return timer, true
+		}
+	}
+	if !started {
+		return timer, false
+	}
+
+	timer.State = timerState(policy, timer.DueAt, now)
+	return timer, true
+}
+
+// timerState is the state of a running timer due at dueAt
+func timerState(policy models.SLAPolicy, dueAt, now time.Time) string {
+	switch {
+	case !now.Before(dueAt):
+		return TimerBreached
+	case policy.WarningHours > 0 && !now.Before(dueAt.Add(-time.Duration(policy.WarningHours)*time.Hour)):
+		return TimerAtRisk
+	default:
+		return TimerRunning
+	}
+}
+
+// openOrder is an order still on its way to a policy's target status
+type openOrder struct {
+	OrderID   string
+	Status    string
+	Total     float64
+	StartedAt time.Time
+}
+
+// dueOrders returns the orders whose timer under policy is at risk or breached at now
+func (s *Service) dueOrders(policy models.SLAPolicy, now time.Time) ([]QueueItem, error) {
+	open := fulfillmentStatuses[statusRank(policy.StartStatus):statusRank(policy.TargetStatus)]
+	deadline := time.Duration(policy.TargetHours) * time.Hour
+	warnFrom := now.Add(-(deadline - time.Duration(policy.WarningHours)*time.Hour))
+
+	var rows []openOrder
+	if err := s.db.Table("order_status_history AS h").
+		Select("h.order_id AS order_id, o.status AS status, o.total AS total, h.created_at AS started_at").
+		Joins("JOIN orders o ON o.id = h.order_id").
+		Where("h.new_status = ? AND o.status IN ? AND h.created_at <= ?", policy.StartStatus, open, warnFrom).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get open orders: %w", err)
+	}
+
+	// An order that entered the start status more than once is timed from the first time
+	earliest := make(map[string]openOrder, len(rows))
+	for _, row := range rows {
+		if seen, ok := earliest[row.OrderID]; !ok || row.StartedAt.Before(seen.StartedAt) {
+			earliest[row.OrderID] = row
+		}
+	}
+
+	items := make([]QueueItem, 0, len(earliest))
+	for _, row := range earliest {
+		dueAt := row.StartedAt.Add(deadline)
+		items = append(items, QueueItem{
+			OrderID:     row.OrderID,
+			OrderStatus: row.Status,
+			Total:       row.Total,
+			PolicyID:    policy.ID,
+			PolicyName:  policy.Name,
+			State:       timerState(policy, dueAt, now),
+			StartedAt:   row.StartedAt,
+			DueAt:       dueAt,
+		})
+	}
+	return items, nil
+}
+
+// ListQueue returns open orders in the given state under the active policies, the most overdue first
+func (s *Service) ListQueue(query QueueQuery, now time.Time) ([]QueueItem, int64, error) {
+	if query.State != TimerBreached && query.State != TimerAtRisk {
+		return nil, 0, ErrInvalidState
+	}
+	policies, err := s.activePolicies(query.PolicyID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := []QueueItem{}
+	for _, policy := range policies {
+		due, err := s.dueOrders(policy, now)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, item := range due {
+			if item.State == query.State {
+				items = append(items, item)
+			}
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].DueAt.Equal(items[j].DueAt) {
+			return items[i].DueAt.Before(items[j].DueAt)
+		}
+		return items[i].OrderID < items[j].OrderID
+	})
+
+	total := int64(len(items))
+	start := (query.Page - 1) * query.Limit
+	if start >= len(items) {
+		return []QueueItem{}, total, nil
+	}
+	end := start + query.Limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], total, nil
+}
+
+// SendDueAlerts raises an alert for every open order that became at risk or breached a policy since the last
+// sweep, and returns how many were raised. Each alert is claimed before it is raised so concurrent sweeps
+// never raise it twice.
+func (s *Service) SendDueAlerts(now time.Time) (int, error) {
+	policies, err := s.activePolicies("")
+	if err != nil {
+		return 0, err
+	}
+
+	raised := 0
+	for _, policy := range policies {
+		due, err := s.dueOrders(policy, now)
+		if err != nil {
+			return raised, err
+		}
+		for _, item := range due {
+			claim := s.db.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.SLAAlert{PolicyID: policy.ID, OrderID: item.OrderID, Level: item.State})
+			if claim.Error != nil {
+				return raised, fmt.Errorf("failed to record SLA alert: %w", claim.Error)
+			}
+			if claim.RowsAffected == 0 {
+				continue
+			}
+
+			metadata := map[string]interface{}{
+				"order_id":  item.OrderID,
+				"policy_id": policy.ID,
+				"due_at":    item.DueAt,
+			}
+			if item.State == TimerBreached {
+				monitoring.CreateAlert(monitoring.AlertCritical, "Order breached SLA",
+					fmt.Sprintf("Order %s missed \"%s\" due %s", item.OrderID, policy.Name, item.DueAt.Format(time.RFC3339)), metadata)
+			} else {
+				monitoring.CreateAlert(monitoring.AlertWarning, "Order approaching SLA breach",
+					fmt.Sprintf("Order %s is due under \"%s\" at %s", item.OrderID, policy.Name, item.DueAt.Format(time.RFC3339)), metadata)
+			}
+			raised++
+		}
+	}
+	return raised, nil
+}
+
+// StartAlertSweeper periodically raises SLA alerts until ctx is cancelled
+func (s *Service) StartAlertSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if _, err := s.SendDueAlerts(now); err != nil {
+					logger.Named("sla").Warn("Failed to raise SLA alerts", map[string]interface{}{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,158 @@
+package sla
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestService(t *testing.T) *Service {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	return NewService(database.GetDB())
+}
+
+// createOrder creates an order in status that went through transitions, given as status and time pairs
+func createOrder(t *testing.T, status string, transitions ...interface{}) models.Order {
+	db := database.GetDB()
+	user := models.User{Email: uuid.New().String() + "@example.com", Password: "hashed", FirstName: "Sam", LastName: "Lee"}
+	require.NoError(t, db.Create(&user).Error)
+	order := models.Order{UserID: user.ID, Status: status, Subtotal: 100, Total: 100}
+	require.NoError(t, db.Create(&order).Error)
+
+	oldStatus := ""
+	for i := 0; i < len(transitions); i += 2 {
+		newStatus := transitions[i].(string)
+		require.NoError(t, db.Create(&models.OrderStatusHistory{
+			OrderID: order.ID, OldStatus: oldStatus, NewStatus: newStatus, ActorRole: models.OrderActorSystem, CreatedAt: transitions[i+1].(time.Time),
+		}).Error)
+		oldStatus = newStatus
+	}
+	return order
+}
+
+func TestService_PolicyValidation(t *testing.T) {
+	service := setupTestService(t)
+
+	for _, req := range []PolicyRequest{
+		{Name: "Backwards", StartStatus: "shipped", TargetStatus: "paid", TargetHours: 48},
+		{Name: "Unknown", StartStatus: "paid", TargetStatus: "packed", TargetHours: 48},
+		{Name: "Late warning", StartStatus: "paid", TargetStatus: "shipped", TargetHours: 48, WarningHours: 48},
+	} {
+		_, err := service.CreatePolicy(req)
+		assert.True(t, errors.Is(err, ErrInvalidPolicy), req.Name)
+	}
+
+	inactive := false
+	policy, err := service.CreatePolicy(PolicyRequest{Name: "Ship in 48h", StartStatus: "paid", TargetStatus: "shipped", TargetHours: 48, Active: &inactive})
+	require.NoError(t, err)
+	policies, err := service.ListPolicies()
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.False(t, policies[0].Active)
+
+	active := true
+	updated, err := service.UpdatePolicy(policy.ID, PolicyRequest{Name: "Ship in 24h", StartStatus: "paid", TargetStatus: "shipped", TargetHours: 24, WarningHours: 4, Active: &active})
+	require.NoError(t, err)
+	assert.True(t, updated.Active)
+	assert.Equal(t, 24, updated.TargetHours)
+
+	require.NoError(t, service.DeletePolicy(policy.ID))
+	assert.True(t, errors.Is(service.DeletePolicy(policy.ID), ErrPolicyNotFound))
+}
+
+func TestService_OrderTimers(t *testing.T) {
+	service := setupTestService(t)
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	hours := func(h int) time.Time { return now.Add(-time.Duration(h) * time.Hour) }
+
+	_, err := service.CreatePolicy(PolicyRequest{Name: "Ship in 48h", StartStatus: "paid", TargetStatus: "shipped", TargetHours: 48, WarningHours: 12})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		order  models.Order
+		state  string
+		noTime bool
+	}{
+		{"running", createOrder(t, "paid", "pending", hours(11), "paid", hours(10)), TimerRunning, false},
+		{"at risk", createOrder(t, "processing", "paid", hours(40), "processing", hours(30)), TimerAtRisk, false},
+		{"breached", createOrder(t, "paid", "paid", hours(50)), TimerBreached, false},
+		{"met", createOrder(t, "delivered", "paid", hours(100), "shipped", hours(80), "delivered", hours(60)), TimerMet, false},
+		{"missed", createOrder(t, "shipped", "paid", hours(100), "shipped", hours(30)), TimerMissed, false},
+		{"stopped", createOrder(t, "cancelled", "paid", hours(100), "cancelled", hours(90)), TimerStopped, false},
+		{"not started", createOrder(t, "pending", "pending", hours(100)), "", true},
+	}
+	for _, tc := range cases {
+		timers, err := service.GetOrderTimers(tc.order.ID, now)
+		require.NoError(t, err, tc.name)
+		if tc.noTime {
+			assert.Empty(t, timers, tc.name)
+			continue
+		}
+		require.Len(t, timers, 1, tc.name)
+		assert.Equal(t, tc.state, timers[0].State, tc.name)
+	}
+
+	_, err = service.GetOrderTimers("missing", now)
+	assert.True(t, errors.Is(err, ErrOrderNotFound))
+}
+
+func TestService_QueueAndAlerts(t *testing.T) {
+	service := setupTestService(t)
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	hours := func(h int) time.Time { return now.Add(-time.Duration(h) * time.Hour) }
+
+	policy, err := service.CreatePolicy(PolicyRequest{Name: "Ship in 48h", StartStatus: "paid", TargetStatus: "shipped", TargetHours: 48, WarningHours: 12})
+	require.NoError(t, err)
+
+	oldest := createOrder(t, "processing", "paid", hours(70), "processing", hours(60))
+	breached := createOrder(t, "paid", "paid", hours(50))
+	atRisk := createOrder(t, "paid", "paid", hours(40))
+	createOrder(t, "paid", "paid", hours(10))                          // running
+	createOrder(t, "shipped", "paid", hours(70), "shipped", hours(20)) // shipped late, no longer open
+	createOrder(t, "cancelled", "paid", hours(70), "cancelled", hours(65))
+	// Paid again after a failed capture; timed from the first payment
+	repaid := createOrder(t, "paid", "paid", hours(55), "pending", hours(54), "paid", hours(30))
+
+	items, total, err := service.ListQueue(QueueQuery{State: TimerBreached, Page: 1, Limit: 2}, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, items, 2)
+	assert.Equal(t, oldest.ID, items[0].OrderID, "the most overdue order comes first")
+	assert.Equal(t, repaid.ID, items[1].OrderID)
+	assert.Equal(t, policy.ID, items[0].PolicyID)
+
+	items, _, err = service.ListQueue(QueueQuery{State: TimerBreached, Page: 2, Limit: 2}, now)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, breached.ID, items[0].OrderID)
+
+	items, total, err = service.ListQueue(QueueQuery{State: TimerAtRisk, Page: 1, Limit: 20}, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, atRisk.ID, items[0].OrderID)
+
+	_, _, err = service.ListQueue(QueueQuery{State: "running", Page: 1, Limit: 20}, now)
+	assert.True(t, errors.Is(err, ErrInvalidState))
+
+	raised, err := service.SendDueAlerts(now)
+	require.NoError(t, err)
+	assert.Equal(t, 4, raised)
+
+	raised, err = service.SendDueAlerts(now)
+	require.NoError(t, err)
+	assert.Zero(t, raised, "each alert is raised once")
+
+	// The at-risk order breaches later and is alerted again at the new level
+	raised, err = service.SendDueAlerts(now.Add(9 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, raised)
+}
@@ -0,0 +1,159 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SMS drivers selectable with SMS_DRIVER
+const (
+	DriverTwilio = "twilio"
+	DriverMSG91  = "msg91"
+)
+
+const (
+	// sendTimeout bounds handing one message to the provider
+	sendTimeout = 10 * time.Second
+	// responseExcerpt caps the bytes of a failed provider response kept as the error
+	responseExcerpt = 512
+
+	defaultTwilioURL = "https://api.twilio.com"
+	defaultMSG91URL  = "https://control.msg91.com"
+)
+
+var ErrUnknownDriver = errors.New("unknown SMS driver")
+
+// Notifier sends a text message to a phone number
+type Notifier interface {
+	Send(ctx context.Context, to, body string) error
+}
+
+// Config selects and configures an SMS provider
+type Config struct {
+	Driver     string
+	AccountID  string // Twilio account SID
+	AuthToken  string // Twilio auth token or MSG91 auth key
+	From       string // Twilio sending number or MSG91 sender ID
+	TemplateID string // MSG91 flow whose ##body## variable receives the message
+	BaseURL    string // overrides the provider's public endpoint
+}
+
+// NewNotifier creates the notifier for cfg.Driver, or returns nil when the driver is empty and no text
+// messages are sent
+func NewNotifier(cfg Config) (Notifier, error) {
+	client := &http.Client{Timeout: sendTimeout}
+	switch cfg.Driver {
+	case "":
+		return nil, nil
+	case DriverTwilio:
+		if cfg.AccountID == "" || cfg.AuthToken == "" || cfg.From == "" {
+			return nil, errors.New("the twilio driver needs SMS_ACCOUNT_ID, SMS_AUTH_TOKEN and SMS_FROM")
+		}
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = defaultTwilioURL
+		}
+		return &twilioNotifier{config: cfg, client: client}, nil
+	case DriverMSG91:
+		if cfg.AuthToken == "" || cfg.TemplateID == "" {
+			return nil, errors.New("the msg91 driver needs SMS_AUTH_TOKEN and SMS_TEMPLATE_ID")
+		}
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = defaultMSG91URL
+		}
+		return &msg91Notifier{config: cfg, client: client}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, cfg.Driver)
+	}
+}
+
+// twilioNotifier sends messages with the Twilio Messages API
+type twilioNotifier struct {
+	config Config
+	client *http.Client
+}
+
+func (n *twilioNotifier) Send(ctx context.Context, to, body string) error {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", n.config.From)
+	form.Set("Body", body)
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", strings.TrimRight(n.config.BaseURL, "/"), url.PathEscape(n.config.AccountID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(n.config.AccountID, n.config.AuthToken)
+	return do(n.client, req, nil)
+}
+
+// msg91Notifier sends messages through an MSG91 flow, which MSG91 requires for DLT-registered templates
+type msg91Notifier struct {
+	config Config
+	client *http.Client
+}
+
+func (n *msg91Notifier) Send(ctx context.Context, to, body string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"template_id": n.config.TemplateID,
+		"sender":      n.config.From,
+		"short_url":   "0",
+		"recipients": []map[string]string{
+			// MSG91 takes the number with its country code but without the plus sign
+			{"mobiles": strings.TrimPrefix(to, "+"), "body": body},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode SMS request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(n.config.BaseURL, "/")+"/api/v5/flow", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("authkey", n.config.AuthToken)
+
+	var response struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if err := do(n.client, req, &response); err != nil {
+		return err
+	}
+	// MSG91 reports some failures with a 200 response
+	if response.Type == "error" {
+		return fmt.Errorf("SMS provider rejected the message: %s", response.Message)
+	}
+	return nil
+}
+
+// do sends req and decodes a JSON response body into out when it is not nil
+func do(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, responseExcerpt))
+		return fmt.Errorf("SMS provider responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(excerpt)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to read SMS response: %w", err)
+	}
+	return nil
+}
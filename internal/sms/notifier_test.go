@@ -0,0 +1,108 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNotifier(t *testing.T) {
+	notifier, err := NewNotifier(Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, notifier)
+
+	_, err = NewNotifier(Config{Driver: "pager"})
+	assert.ErrorIs(t, err, ErrUnknownDriver)
+	_, err = NewNotifier(Config{Driver: DriverTwilio, AccountID: "AC1"})
+	assert.Error(t, err, "twilio needs a token and a sending number")
+	_, err = NewNotifier(Config{Driver: DriverMSG91, AuthToken: "key"})
+	assert.Error(t, err, "msg91 needs a flow")
+}
+
+func TestTwilioNotifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC1/Messages.json", r.URL.Path)
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "AC1", user)
+		assert.Equal(t, "token", password)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "+15550001111", r.PostForm.Get("From"))
+		if r.PostForm.Get("To") == "+1" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message":"invalid number"}`))
+			return
+		}
+		assert.Equal(t, "hello", r.PostForm.Get("Body"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(Config{Driver: DriverTwilio, AccountID: "AC1", AuthToken: "token", From: "+15550001111", BaseURL: server.URL})
+	require.NoError(t, err)
+	assert.NoError(t, notifier.Send(context.Background(), "+15552223333", "hello"))
+	err = notifier.Send(context.Background(), "+1", "hello")
+	assert.ErrorContains(t, err, "invalid number")
+}
+
+func TestMSG91Notifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v5/flow", r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get("authkey"))
+		var body struct {
+			TemplateID string              `json:"template_id"`
+			Recipients []map[string]string `json:"recipients"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "flow-1", body.TemplateID)
+		require.Len(t, body.Recipients, 1)
+		if body.Recipients[0]["mobiles"] != "919876543210" {
+			_, _ = w.Write([]byte(`{"type":"error","message":"invalid mobile"}`))
+			return
+		}
+		assert.Equal(t, "hello", body.Recipients[0]["body"])
+		_, _ = w.Write([]byte(`{"type":"success","message":"queued"}`))
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(Config{Driver: DriverMSG91, AuthToken: "key", TemplateID: "flow-1", BaseURL: server.URL})
+	require.NoError(t, err)
+	assert.NoError(t, notifier.Send(context.Background(), "+919876543210", "hello"))
+	assert.ErrorContains(t, notifier.Send(context.Background(), "12", "hello"), "invalid mobile")
+}
+
+// recordingNotifier keeps the messages it is asked to send
+type recordingNotifier struct {
+	sent map[string]string
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, to, body string) error {
+	n.sent[to] = body
+	return nil
+}
+
+func TestService_SendOrderStatusUpdate(t *testing.T) {
+	phone := " +919876543210 "
+	order := &models.Order{ID: "0123456789abcdef", User: models.User{Phone: &phone}}
+
+	assert.NoError(t, NewService(nil).SendOrderStatusUpdate(order, "paid", "shipped"), "nothing is sent without a provider")
+
+	notifier := &recordingNotifier{sent: map[string]string{}}
+	service := NewService(notifier)
+	require.NoError(t, service.SendOrderStatusUpdate(order, "paid", "shipped"))
+	assert.Empty(t, notifier.sent, "customers who did not opt in get no texts")
+
+	order.User.OrderSMSOptIn = true
+	require.NoError(t, service.SendOrderStatusUpdate(order, "paid", "shipped"))
+	assert.Equal(t, map[string]string{"+919876543210": "Order #01234567: shipped and on its way to you."}, notifier.sent)
+
+	order.User.Phone = nil
+	assert.Empty(t, Recipient(&order.User))
+	assert.Equal(t, "Order #abc: now on_hold.", OrderStatusMessage("abc", "on_hold"))
+}
@@ -0,0 +1,50 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/outbox"
+
+	"gorm.io/gorm"
+)
+
+// TopicOrderStatus is the outbox topic of queued order status texts
+const TopicOrderStatus = "sms.order_status"
+
+// orderStatusMessage is the payload of a queued order status text
+type orderStatusMessage struct {
+	OrderID   string `json:"orderId"`
+	OldStatus string `json:"oldStatus"`
+	NewStatus string `json:"newStatus"`
+}
+
+// QueueOrderStatusUpdate records the order status text in tx, the transaction that changed the status
+func QueueOrderStatusUpdate(tx *gorm.DB, orderID, oldStatus, newStatus string) error {
+	return outbox.Enqueue(tx, TopicOrderStatus, orderStatusMessage{OrderID: orderID, OldStatus: oldStatus, NewStatus: newStatus})
+}
+
+// RegisterOutbox makes dispatcher send the queued order status texts with service. Like queued emails the
+// messages carry IDs only, so the customer's preferences are checked again when the text goes out.
+func RegisterOutbox(dispatcher *outbox.Dispatcher, service *Service, db *gorm.DB) {
+	dispatcher.Register(TopicOrderStatus, func(ctx context.Context, payload json.RawMessage) error {
+		var message orderStatusMessage
+		if err := json.Unmarshal(payload, &message); err != nil {
+			return fmt.Errorf("invalid order status SMS message: %w", err)
+		}
+		var order models.Order
+		err := db.WithContext(ctx).Preload("User").First(&order, "id = ?", message.OrderID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Named("sms").Warn("Order of queued SMS no longer exists", map[string]interface{}{"order_id": message.OrderID})
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load order: %w", err)
+		}
+		return service.SendOrderStatusUpdate(&order, message.OldStatus, message.NewStatus)
+	})
+}
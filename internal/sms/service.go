@@ -0,0 +1,74 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+)
+
+// statusMessages are the texts sent for each order status, kept short to fit one message
+var statusMessages = map[string]string{
+	"paid":       "payment received, we are getting it ready.",
+	"processing": "being prepared for shipment.",
+	"shipped":    "shipped and on its way to you.",
+	"delivered":  "delivered. Enjoy!",
+	"cancelled":  "cancelled.",
+	"refunded":   "refunded.",
+}
+
+// Service texts customers about their orders. Only customers who opted in to order texts and have a phone
+// number on their profile receive them.
+type Service struct {
+	notifier Notifier
+}
+
+// NewService creates a service sending texts with notifier; with a nil notifier it sends nothing
+func NewService(notifier Notifier) *Service {
+	return &Service{notifier: notifier}
+}
+
+// Enabled reports whether an SMS provider is configured
+func (s *Service) Enabled() bool {
+	return s != nil && s.notifier != nil
+}
+
+// SendOrderStatusUpdate texts the purchaser that their order moved to newStatus. The order must have its
+// user loaded.
+func (s *Service) SendOrderStatusUpdate(order *models.Order, oldStatus, newStatus string) error {
+	if !s.Enabled() {
+		return nil
+	}
+	phone := Recipient(&order.User)
+	if phone == "" {
+		return nil
+	}
+	if err := s.notifier.Send(context.Background(), phone, OrderStatusMessage(order.ID, newStatus)); err != nil {
+		return fmt.Errorf("failed to send order status SMS: %w", err)
+	}
+	logger.Named("sms").Info("Order status update SMS sent", map[string]interface{}{"order_id": order.ID, "status": newStatus})
+	return nil
+}
+
+// Recipient returns the number order texts go to, or "" when the user has not opted in or has no phone
+func Recipient(user *models.User) string {
+	if !user.OrderSMSOptIn || user.Phone == nil {
+		return ""
+	}
+	return strings.TrimSpace(*user.Phone)
+}
+
+// OrderStatusMessage returns the text announcing an order's new status
+func OrderStatusMessage(orderID, status string) string {
+	reference := orderID
+	if len(reference) > 8 {
+		reference = reference[:8]
+	}
+	message, ok := statusMessages[status]
+	if !ok {
+		message = "now " + status + "."
+	}
+	return fmt.Sprintf("Order #%s: %s", reference, message)
+}
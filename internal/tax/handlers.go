@@ -0,0 +1,86 @@
+package tax
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new tax rules handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// ListRules handles GET /api/admin/tax/rules (admin only)
+func (h *Handler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get tax rules", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tax rules retrieved successfully", gin.H{"rules": rules})
+}
+
+// CreateRule handles POST /api/admin/tax/rules (admin only)
+func (h *Handler) CreateRule(c *gin.Context) {
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	rule, err := h.service.CreateRule(&req)
+	if err != nil {
+		h.ruleError(c, err, "Failed to create tax rule")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Tax rule created successfully", rule)
+}
+
+// UpdateRule handles PUT /api/admin/tax/rules/:id (admin only)
+func (h *Handler) UpdateRule(c *gin.Context) {
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request data", err.Error())
+		return
+	}
+
+	rule, err := h.service.UpdateRule(c.Param("id"), &req)
+	if err != nil {
+		h.ruleError(c, err, "Failed to update tax rule")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tax rule updated successfully", rule)
+}
+
+// DeleteRule handles DELETE /api/admin/tax/rules/:id (admin only)
+func (h *Handler) DeleteRule(c *gin.Context) {
+	if err := h.service.DeleteRule(c.Param("id")); err != nil {
+		h.ruleError(c, err, "Failed to delete tax rule")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tax rule deleted successfully", nil)
+}
+
+// ruleError writes the response for a failed tax rule operation
+func (h *Handler) ruleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrRuleNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "TAX_RULE_NOT_FOUND", "Tax rule not found", nil)
+	case errors.Is(err, ErrInvalidRule):
+		utils.ErrorResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid tax rule", err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", message, nil)
+	}
+}
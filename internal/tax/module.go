@@ -0,0 +1,37 @@
+package tax
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires tax rule administration into the application
+type Module struct {
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the tax module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB, settings.NewService(deps.DB))
+	return &Module{handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "tax"
+}
+
+// Models returns the tax rules table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.TaxRule{}}
+}
+
+// RegisterAPIRoutes sets up the admin tax rule routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,20 @@
+package tax
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin tax rule routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin/tax/rules")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.ListRules)
+		admin.POST("", handler.CreateRule)
+		admin.PUT("/:id", handler.UpdateRule)
+		admin.DELETE("/:id", handler.DeleteRule)
+	}
+}
@@ -0,0 +1,202 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTaxName names the tax charged at the tax.rate setting where no rule covers the address
+const DefaultTaxName = "Tax"
+
+var (
+	ErrRuleNotFound = errors.New("tax rule not found")
+	ErrInvalidRule  = errors.New("invalid tax rule")
+)
+
+// RuleRequest represents the request body for creating or replacing a tax rule
+type RuleRequest struct {
+	Name     string  `json:"name" binding:"required,max=100"`
+	Country  string  `json:"country" binding:"required,len=2"`
+	State    string  `json:"state" binding:"max=100"`
+	Rate     float64 `json:"rate" binding:"min=0,max=1"`
+	IsActive *bool   `json:"isActive,omitempty"`
+}
+
+// ServiceInterface defines the interface for the tax service
+type ServiceInterface interface {
+	Table(ctx context.Context) (*Table, error)
+	ListRules() ([]models.TaxRule, error)
+	CreateRule(req *RuleRequest) (*models.TaxRule, error)
+	UpdateRule(id string, req *RuleRequest) (*models.TaxRule, error)
+	DeleteRule(id string) error
+}
+
+type Service struct {
+	db       *gorm.DB
+	settings settings.Reader
+}
+
+// NewService creates a new tax service. Addresses no rule covers are taxed at the tax.rate setting.
+func NewService(db *gorm.DB, settingsReader settings.Reader) *Service {
+	return &Service{db: db, settings: settingsReader}
+}
+
+// Table loads the active tax rules for pricing an order
+func (s *Service) Table(ctx context.Context) (*Table, error) {
+	var rules []models.TaxRule
+	if err := s.db.WithContext(ctx).Where("is_active = ?", true).Order("country, state, name").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to load tax rules: %w", err)
+	}
+	return &Table{rules: rules, defaultRate: s.settings.GetFloat(ctx, settings.KeyTaxRate)}, nil
+}
+
+// ListRules returns every tax rule, grouped by region
+func (s *Service) ListRules() ([]models.TaxRule, error) {
+	rules := []models.TaxRule{}
+	if err := s.db.Order("country, state, name").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch tax rules: %w", err)
+	}
+	return rules, nil
+}
+
+// CreateRule adds a tax rule
+func (s *Service) CreateRule(req *RuleRequest) (*models.TaxRule, error) {
+	rule := models.TaxRule{IsActive: true}
+	if err := applyRule(&rule, req); err != nil {
+		return nil, err
+	}
+	active := rule.IsActive
+	if err := s.db.Create(&rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create tax rule: %w", err)
+	}
+	// Inactive rules must be saved explicitly; false is skipped on insert in favour of the column default
+	if !active {
+		if err := s.db.Model(&rule).Update("is_active", false).Error; err != nil {
+			return nil, fmt.Errorf("failed to create tax rule: %w", err)
+		}
+	}
+	return &rule, nil
+}
+
+// UpdateRule replaces a tax rule
+func (s *Service) UpdateRule(id string, req *RuleRequest) (*models.TaxRule, error) {
+	var rule models.TaxRule
+	if err := s.db.Where("id = ?", id).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch tax rule: %w", err)
+	}
+	if err := applyRule(&rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to update tax rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// DeleteRule removes a tax rule; orders keep the taxes they were charged
+func (s *Service) DeleteRule(id string) error {
+	result := s.db.Where("id = ?", id).Delete(&models.TaxRule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete tax rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+// applyRule copies a normalized request onto a rule
+func applyRule(rule *models.TaxRule, req *RuleRequest) error {
+	name := strings.TrimSpace(req.Name)
+	country := strings.ToUpper(strings.TrimSpace(req.Country))
+	if name == "" || len(country) != 2 {
+		return fmt.Errorf("%w: name and a two-letter country code are required", ErrInvalidRule)
+	}
+	if req.Rate < 0 || req.Rate > 1 {
+		return fmt.Errorf("%w: rate must be between 0 and 1", ErrInvalidRule)
+	}
+
+	rule.Name = name
+	rule.Country = country
+	rule.State = strings.TrimSpace(req.State)
+	rule.Rate = req.Rate
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// Table is a snapshot of the tax rules in force, used to price one order
+type Table struct {
+	rules       []models.TaxRule
+	defaultRate float64
+}
+
+// Charge is an amount shipped to one address
+type Charge struct {
+	Address models.OrderAddress
+	Amount  float64
+}
+
+// Calculate taxes the charges by their shipping address. Lines of the same tax across charges are
+// combined and rounded once, so the order's tax is the sum of its breakdown.
+func (t *Table) Calculate(charges []Charge) (models.TaxBreakdown, float64) {
+	breakdown := models.TaxBreakdown{}
+	index := make(map[models.TaxLine]int)
+
+	for _, charge := range charges {
+		for _, line := range t.linesFor(charge.Address) {
+			if line.Rate == 0 {
+				continue
+			}
+			amount := charge.Amount * line.Rate
+			if i, ok := index[line]; ok {
+				breakdown[i].Amount += amount
+				continue
+			}
+			index[line] = len(breakdown)
+			line.Amount = amount
+			breakdown = append(breakdown, line)
+		}
+	}
+
+	var total float64
+	for i := range breakdown {
+		breakdown[i].Amount = math.Round(breakdown[i].Amount*100) / 100
+		total += breakdown[i].Amount
+	}
+	return breakdown, math.Round(total*100) / 100
+}
+
+// linesFor returns the taxes that apply to an address, without amounts
+func (t *Table) linesFor(address models.OrderAddress) []models.TaxLine {
+	country := strings.ToUpper(strings.TrimSpace(address.Country))
+	state := strings.TrimSpace(address.State)
+
+	var lines []models.TaxLine
+	for _, rule := range t.rules {
+		if rule.Country != country || (rule.State != "" && !strings.EqualFold(rule.State, state)) {
+			continue
+		}
+		jurisdiction := rule.Country
+		if rule.State != "" {
+			jurisdiction += "-" + rule.State
+		}
+		lines = append(lines, models.TaxLine{Name: rule.Name, Jurisdiction: jurisdiction, Rate: rule.Rate})
+	}
+	if len(lines) == 0 {
+		lines = append(lines, models.TaxLine{Name: DefaultTaxName, Rate: t.defaultRate})
+	}
+	return lines
+}
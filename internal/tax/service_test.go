@@ -0,0 +1,99 @@
+package tax
+
+import (
+	"context"
+	"testing"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTaxTest(t *testing.T) (*Service, *settings.Service) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.TaxRule{}, &models.Setting{}))
+
+	settingsService := settings.NewService(db)
+	return NewService(db, settingsService), settingsService
+}
+
+func TestTable_Calculate(t *testing.T) {
+	table := &Table{
+		rules: []models.TaxRule{
+			{Name: "CGST", Country: "IN", Rate: 0.09},
+			{Name: "SGST", Country: "IN", Rate: 0.09},
+			{Name: "Sales tax", Country: "US", State: "CA", Rate: 0.0725},
+		},
+		defaultRate: 0.05,
+	}
+	karnataka := models.OrderAddress{Country: "in", State: "KA"}
+	california := models.OrderAddress{Country: "US", State: "ca"}
+	texas := models.OrderAddress{Country: "US", State: "TX"}
+
+	breakdown, total := table.Calculate([]Charge{{Address: karnataka, Amount: 10.05}, {Address: karnataka, Amount: 10.05}})
+	assert.Equal(t, models.TaxBreakdown{
+		{Name: "CGST", Jurisdiction: "IN", Rate: 0.09, Amount: 1.81},
+		{Name: "SGST", Jurisdiction: "IN", Rate: 0.09, Amount: 1.81},
+	}, breakdown, "lines of the same tax are combined before rounding")
+	assert.Equal(t, 3.62, total)
+
+	breakdown, total = table.Calculate([]Charge{{Address: california, Amount: 200}, {Address: texas, Amount: 100}})
+	assert.Equal(t, models.TaxBreakdown{
+		{Name: "Sales tax", Jurisdiction: "US-CA", Rate: 0.0725, Amount: 14.5},
+		{Name: DefaultTaxName, Rate: 0.05, Amount: 5},
+	}, breakdown, "addresses without rules fall back to the default rate")
+	assert.Equal(t, 19.5, total)
+
+	breakdown, total = (&Table{}).Calculate([]Charge{{Address: texas, Amount: 100}})
+	assert.Empty(t, breakdown)
+	assert.Zero(t, total)
+}
+
+func TestService_Rules(t *testing.T) {
+	service, settingsService := setupTaxTest(t)
+	ctx := context.Background()
+	inactive := false
+
+	country, err := service.CreateRule(&RuleRequest{Name: " GST ", Country: "in", Rate: 0.18})
+	require.NoError(t, err)
+	assert.Equal(t, "GST", country.Name)
+	assert.Equal(t, "IN", country.Country)
+	assert.True(t, country.IsActive)
+
+	retired, err := service.CreateRule(&RuleRequest{Name: "Cess", Country: "IN", Rate: 0.01, IsActive: &inactive})
+	require.NoError(t, err)
+
+	_, err = service.CreateRule(&RuleRequest{Name: "Bad", Country: "IND", Rate: 0.1})
+	assert.ErrorIs(t, err, ErrInvalidRule)
+
+	_, err = settingsService.SetSetting(settings.KeyTaxRate, &settings.SetSettingRequest{Value: "0.1"}, "admin@example.com")
+	require.NoError(t, err)
+
+	table, err := service.Table(ctx)
+	require.NoError(t, err)
+	breakdown, total := table.Calculate([]Charge{{Address: models.OrderAddress{Country: "IN"}, Amount: 100}})
+	require.Len(t, breakdown, 1, "inactive rules are not applied")
+	assert.Equal(t, 18.0, total)
+	_, total = table.Calculate([]Charge{{Address: models.OrderAddress{Country: "FR"}, Amount: 100}})
+	assert.Equal(t, 10.0, total)
+
+	updated, err := service.UpdateRule(retired.ID, &RuleRequest{Name: "Cess", Country: "IN", State: "KA", Rate: 0.02})
+	require.NoError(t, err)
+	assert.Equal(t, "KA", updated.State)
+	assert.False(t, updated.IsActive, "omitted isActive keeps the current value")
+
+	_, err = service.UpdateRule("missing", &RuleRequest{Name: "X", Country: "IN"})
+	assert.ErrorIs(t, err, ErrRuleNotFound)
+
+	require.NoError(t, service.DeleteRule(country.ID))
+	assert.ErrorIs(t, service.DeleteRule(country.ID), ErrRuleNotFound)
+
+	rules, err := service.ListRules()
+	require.NoError(t, err)
+	assert.Len(t, rules, 1)
+}
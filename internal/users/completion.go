@@ -0,0 +1,228 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+)
+
+// Profile fields collected progressively after the first order
+const (
+	FieldPhone          = "phone"
+	FieldDefaultAddress = "default_address"
+)
+
+// Profile completion event actions
+const (
+	ActionShown     = "shown"
+	ActionDismissed = "dismissed"
+	ActionCompleted = "completed"
+)
+
+// nudgeSnooze is how long a dismissed field is not nudged again
+const nudgeSnooze = 7 * 24 * time.Hour
+
+// completedOrderStatuses are order statuses that count as a completed (paid) order
+var completedOrderStatuses = []string{"paid", "processing", "shipped", "delivered"}
+
+// profileFieldPrompts are the storefront prompts of each progressively collected field
+var profileFieldPrompts = map[string]string{
+	FieldPhone:          "Add a phone number so the courier can reach you",
+	FieldDefaultAddress: "Save a default address for faster checkout",
+}
+
+// profileFields lists the progressively collected fields in display order
+var profileFields = []string{FieldPhone, FieldDefaultAddress}
+
+var ErrInvalidProfileField = errors.New("invalid profile field")
+
+// ProfileFieldStatus is the completion state of one profile field
+type ProfileFieldStatus struct {
+	Field    string `json:"field"`
+	Complete bool   `json:"complete"`
+	Prompt   string `json:"prompt,omitempty"`
+	Nudge    bool   `json:"nudge"`
+}
+
+// ProfileCompletion summarises which profile data is missing and whether to nudge the user
+type ProfileCompletion struct {
+	Eligible bool                 `json:"eligible"`
+	Percent  int                  `json:"percent"`
+	Missing  []string             `json:"missing"`
+	Fields   []ProfileFieldStatus `json:"fields"`
+	Nudge    bool                 `json:"nudge"`
+}
+
+// UpdatePhoneRequest represents the request to complete the phone number
+type UpdatePhoneRequest struct {
+	Phone string `json:"phone" binding:"required,min=7,max=20"`
+}
+
+// CompletionEventRequest represents a frontend-reported nudge interaction
+type CompletionEventRequest struct {
+	Field  string `json:"field" binding:"required,oneof=phone default_address"`
+	Action string `json:"action" binding:"required,oneof=shown dismissed"`
+}
+
+// CompletionFieldStats aggregates nudge interactions of one field
+type CompletionFieldStats struct {
+	Field     string `json:"field"`
+	Shown     int64  `json:"shown"`
+	Dismissed int64  `json:"dismissed"`
+	Completed int64  `json:"completed"`
+}
+
+// GetProfileCompletion reports missing profile data; users are nudged only after their first completed order
+func (s *Service) GetProfileCompletion(userID string) (*ProfileCompletion, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var completedOrders int64
+	if err := s.db.Model(&models.Order{}).
+		Where("user_id = ? AND status IN ?", userID, completedOrderStatuses).
+		Count(&completedOrders).Error; err != nil {
+		return nil, fmt.Errorf("failed to count completed orders: %w", err)
+	}
+
+	complete := map[string]bool{
+		FieldPhone:          user.Phone != nil && *user.Phone != "",
+		FieldDefaultAddress: false,
+	}
+	for _, address := range user.Addresses {
+		if address.IsDefault {
+			complete[FieldDefaultAddress] = true
+			break
+		}
+	}
+
+	snoozed, err := s.snoozedFields(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	completion := &ProfileCompletion{
+		Eligible: completedOrders > 0,
+		Missing:  []string{},
+	}
+	done := 0
+	for _, field := range profileFields {
+		status := ProfileFieldStatus{Field: field, Complete: complete[field]}
+		if status.Complete {
+			done++
+		} else {
+			status.Prompt = profileFieldPrompts[field]
+			status.Nudge = completion.Eligible && !snoozed[field]
+			completion.Missing = append(completion.Missing, field)
+		}
+		completion.Nudge = completion.Nudge || status.Nudge
+		completion.Fields = append(completion.Fields, status)
+	}
+	completion.Percent = done * 100 / len(profileFields)
+
+	return completion, nil
+}
+
+// snoozedFields returns the fields dismissed within the snooze period
+func (s *Service) snoozedFields(userID string) (map[string]bool, error) {
+	var fields []string
+	if err := s.db.Model(&models.ProfileCompletionEvent{}).
+		Where("user_id = ? AND action = ? AND created_at > ?", userID, ActionDismissed, time.Now().Add(-nudgeSnooze)).
+		Distinct("field").Pluck("field", &fields).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dismissed fields: %w", err)
+	}
+
+	snoozed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		snoozed[field] = true
+	}
+	return snoozed, nil
+}
+
+// RecordCompletionEvent stores a progressive profiling interaction
+func (s *Service) RecordCompletionEvent(userID, field, action string) error {
+	if _, ok := profileFieldPrompts[field]; !ok {
+		return ErrInvalidProfileField
+	}
+
+	event := models.ProfileCompletionEvent{UserID: userID, Field: field, Action: action}
+	if err := s.db.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record completion event: %w", err)
+	}
+	return nil
+}
+
+// CompletePhone sets the user's phone number and tracks the completion
+func (s *Service) CompletePhone(userID, phone string) (*models.User, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Update("phone", phone).Error; err != nil {
+		return nil, err
+	}
+	user.Phone = &phone
+
+	if err := s.RecordCompletionEvent(userID, FieldPhone, ActionCompleted); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CompleteDefaultAddress saves a default address and tracks the completion
+func (s *Service) CompleteDefaultAddress(userID string, req CreateAddressRequest) (*models.Address, error) {
+	req.IsDefault = true
+	address, err := s.CreateAddress(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.RecordCompletionEvent(userID, FieldDefaultAddress, ActionCompleted); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// GetCompletionStats aggregates nudge interactions per field since the given time
+func (s *Service) GetCompletionStats(since time.Time) ([]CompletionFieldStats, error) {
+	type row struct {
+		Field  string
+		Action string
+		Count  int64
+	}
+	var rows []row
+	if err := s.db.Model(&models.ProfileCompletionEvent{}).
+		Select("field, action, COUNT(*) AS count").
+		Where("created_at >= ?", since).
+		Group("field, action").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get completion stats: %w", err)
+	}
+
+	byField := make(map[string]*CompletionFieldStats, len(profileFields))
+	stats := make([]CompletionFieldStats, len(profileFields))
+	for i, field := range profileFields {
+		stats[i].Field = field
+		byField[field] = &stats[i]
+	}
+	for _, r := range rows {
+		stat, ok := byField[r.Field]
+		if !ok {
+			continue
+		}
+		switch r.Action {
+		case ActionShown:
+			stat.Shown = r.Count
+		case ActionDismissed:
+			stat.Dismissed = r.Count
+		case ActionCompleted:
+			stat.Completed = r.Count
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,66 @@
+package users
+
+import (
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ProfileCompletion(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db)
+
+	user := &models.User{Email: "profile@example.com", Password: "hashed", FirstName: "Pro", LastName: "File", IsActive: true}
+	require.NoError(t, db.Create(user).Error)
+
+	// No completed order yet: fields are missing but not nudged
+	completion, err := service.GetProfileCompletion(user.ID)
+	require.NoError(t, err)
+	assert.False(t, completion.Eligible)
+	assert.False(t, completion.Nudge)
+	assert.Equal(t, []string{FieldPhone, FieldDefaultAddress}, completion.Missing)
+	assert.Equal(t, 0, completion.Percent)
+
+	require.NoError(t, db.Create(&models.Order{UserID: user.ID, Status: "pending", Total: 10, Subtotal: 10}).Error)
+	completion, err = service.GetProfileCompletion(user.ID)
+	require.NoError(t, err)
+	assert.False(t, completion.Eligible, "pending orders are not completed")
+
+	require.NoError(t, db.Create(&models.Order{UserID: user.ID, Status: "paid", Total: 10, Subtotal: 10}).Error)
+	completion, err = service.GetProfileCompletion(user.ID)
+	require.NoError(t, err)
+	assert.True(t, completion.Eligible, "a paid order is a completed order")
+	assert.True(t, completion.Nudge)
+
+	// Dismissing the phone nudge snoozes only that field
+	require.NoError(t, service.RecordCompletionEvent(user.ID, FieldPhone, ActionDismissed))
+	completion, err = service.GetProfileCompletion(user.ID)
+	require.NoError(t, err)
+	assert.False(t, completion.Fields[0].Nudge)
+	assert.True(t, completion.Fields[1].Nudge)
+
+	_, err = service.CompletePhone(user.ID, "+919876543210")
+	require.NoError(t, err)
+	_, err = service.CompleteDefaultAddress(user.ID, CreateAddressRequest{
+		Type: "shipping", FirstName: "Pro", LastName: "File", Address1: "1 Main St",
+		City: "Pune", State: "MH", PostalCode: "411001", Country: "IN",
+	})
+	require.NoError(t, err)
+
+	completion, err = service.GetProfileCompletion(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100, completion.Percent)
+	assert.Empty(t, completion.Missing)
+	assert.False(t, completion.Nudge)
+
+	stats, err := service.GetCompletionStats(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, CompletionFieldStats{Field: FieldPhone, Dismissed: 1, Completed: 1}, stats[0])
+	assert.Equal(t, CompletionFieldStats{Field: FieldDefaultAddress, Completed: 1}, stats[1])
+
+	assert.ErrorIs(t, service.RecordCompletionEvent(user.ID, "email", ActionShown), ErrInvalidProfileField)
+}
@@ -0,0 +1,439 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Reasons two customer accounts look like the same person
+const (
+	MatchPhone   = "phone"
+	MatchEmail   = "email"
+	MatchAddress = "address"
+)
+
+// maxMatchGroup skips phones, emails and addresses shared by more accounts than this; such values are
+// usually an office, a reseller or a placeholder rather than one person
+const maxMatchGroup = 10
+
+var (
+	ErrInvalidMerge    = errors.New("invalid merge")
+	ErrMergeNotAllowed = errors.New("only active customer accounts can be merged")
+)
+
+// MergeRequest merges the duplicate account into the primary one
+type MergeRequest struct {
+	PrimaryUserID   string `json:"primaryUserId" binding:"required"`
+	DuplicateUserID string `json:"duplicateUserId" binding:"required"`
+	Note            string `json:"note" binding:"max=1000"`
+}
+
+// CustomerSummary is what an admin needs to tell two candidate accounts apart
+type CustomerSummary struct {
+	ID         string    `json:"id"`
+	Email      string    `json:"email"`
+	FirstName  string    `json:"firstName"`
+	LastName   string    `json:"lastName"`
+	Phone      *string   `json:"phone,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	OrderCount int64     `json:"orderCount"`
+}
+
+// DuplicateCandidate is a pair of accounts that are likely the same customer. The older account is
+// suggested as the primary to keep.
+type DuplicateCandidate struct {
+	Primary   CustomerSummary `json:"primary"`
+	Duplicate CustomerSummary `json:"duplicate"`
+	Reasons   []string        `json:"reasons"`
+}
+
+// normalizePhone keeps the last ten digits of a phone number so country codes and formatting don't
+// matter; numbers with fewer than seven digits are ignored
+func normalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) < 7 {
+		return ""
+	}
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	return digits
+}
+
+// normalizeEmail lowercases an email and drops +tags, and dots for Gmail, so aliases of one mailbox match
+func normalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+	return local + "@" + domain
+}
+
+// addressKey identifies a street address regardless of case and spacing
+func addressKey(address models.Address) string {
+	street := strings.Join(strings.Fields(strings.ToLower(address.Address1)), " ")
+	postal := strings.ReplaceAll(strings.ToLower(address.PostalCode), " ", "")
+	if street == "" || postal == "" {
+		return ""
+	}
+	return street + "|" + postal + "|" + strings.ToLower(strings.TrimSpace(address.Country))
+}
+
+// matchKeys returns the phone, email and address keys of a customer, by match reason
+func matchKeys(user models.User, addresses []models.Address) map[string][]string {
+	keys := map[string][]string{MatchEmail: {normalizeEmail(user.Email)}}
+	if user.Phone != nil {
+		if phone := normalizePhone(*user.Phone); phone != "" {
+			keys[MatchPhone] = append(keys[MatchPhone], phone)
+		}
+	}
+	for _, address := range addresses {
+		if address.Phone != nil {
+			if phone := normalizePhone(*address.Phone); phone != "" {
+				keys[MatchPhone] = append(keys[MatchPhone], phone)
+			}
+		}
+		if key := addressKey(address); key != "" {
+			keys[MatchAddress] = append(keys[MatchAddress], key)
+		}
+	}
+	return keys
+}
+
+// matchReasons returns why two customers look like the same person, in a stable order
+func matchReasons(a, b map[string][]string) []string {
+	reasons := []string{}
+	for _, reason := range []string{MatchPhone, MatchEmail, MatchAddress} {
+		shared := false
+		for _, x := range a[reason] {
+			for _, y := range b[reason] {
+				shared = shared || x == y
+			}
+		}
+		if shared {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// FindDuplicates returns pairs of active customer accounts that share a phone number, an email mailbox or
+// a street address, those with the most reasons first, then the most recently created duplicates
+func (s *Service) FindDuplicates(page, limit int) ([]DuplicateCandidate, int64, error) {
+	var users []models.User
+	if err := s.db.Select("id, email, first_name, last_name, phone, created_at").
+		Where("role = ? AND is_active = ?", "customer", true).Find(&users).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get customers: %w", err)
+	}
+	byID := make(map[string]models.User, len(users))
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+		ids = append(ids, user.ID)
+	}
+
+	addresses := make(map[string][]models.Address)
+	if len(ids) > 0 {
+		var rows []models.Address
+		if err := s.db.Select("user_id, address1, postal_code, country, phone").
+			Where("user_id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to get addresses: %w", err)
+		}
+		for _, row := range rows {
+			addresses[row.UserID] = append(addresses[row.UserID], row)
+		}
+	}
+
+	// Group accounts by each key, then pair up the accounts of every group
+	groups := make(map[string]map[string]bool)
+	for _, user := range users {
+		for reason, values := range matchKeys(user, addresses[user.ID]) {
+			for _, value := range values {
+				key := reason + ":" + value
+				if groups[key] == nil {
+					groups[key] = make(map[string]bool)
+				}
+				groups[key][user.ID] = true
+			}
+		}
+	}
+
+	pairs := make(map[[2]string]map[string]bool)
+	for key, members := range groups {
+		if len(members) < 2 || len(members) > maxMatchGroup {
+			continue
+		}
+		reason := key[:strings.Index(key, ":")]
+		memberIDs := make([]string, 0, len(members))
+		for id := range members {
+			memberIDs = append(memberIDs, id)
+		}
+		for i := range memberIDs {
+			for j := i + 1; j < len(memberIDs); j++ {
+				primary, duplicate := byID[memberIDs[i]], byID[memberIDs[j]]
+				if duplicate.CreatedAt.Before(primary.CreatedAt) || (duplicate.CreatedAt.Equal(primary.CreatedAt) && duplicate.ID < primary.ID) {
+					primary, duplicate = duplicate, primary
+				}
+				pair := [2]string{primary.ID, duplicate.ID}
+				if pairs[pair] == nil {
+					pairs[pair] = make(map[string]bool)
+				}
+				pairs[pair][reason] = true
+			}
+		}
+	}
+
+	candidates := make([]DuplicateCandidate, 0, len(pairs))
+	for pair, reasons := range pairs {
+		candidate := DuplicateCandidate{Primary: summarize(byID[pair[0]]), Duplicate: summarize(byID[pair[1]]), Reasons: []string{}}
+		for _, reason := range []string{MatchPhone, MatchEmail, MatchAddress} {
+			if reasons[reason] {
+				candidate.Reasons = append(candidate.Reasons, reason)
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if len(a.Reasons) != len(b.Reasons) {
+			return len(a.Reasons) > len(b.Reasons)
+		}
+		if !a.Duplicate.CreatedAt.Equal(b.Duplicate.CreatedAt) {
+			return a.Duplicate.CreatedAt.After(b.Duplicate.CreatedAt)
+		}
+		return a.Duplicate.ID < b.Duplicate.ID
+	})
+
+	total := int64(len(candidates))
+	start := (page - 1) * limit
+	if start > len(candidates) {
+		start = len(candidates)
+	}
+	end := start + limit
+	if end > len(candidates) {
+		end = len(candidates)
+	}
+	candidates = candidates[start:end]
+
+	if err := s.countOrders(candidates); err != nil {
+		return nil, 0, err
+	}
+	return candidates, total, nil
+}
+
+// summarize returns the summary of a customer without the order count
+func summarize(user models.User) CustomerSummary {
+	return CustomerSummary{
+		ID:        user.ID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Phone:     user.Phone,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// countOrders fills in the order counts of the candidates' accounts
+func (s *Service) countOrders(candidates []DuplicateCandidate) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, 2*len(candidates))
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.Primary.ID, candidate.Duplicate.ID)
+	}
+
+	var rows []struct {
+		UserID string
+		Count  int64
+	}
+	if err := s.db.Model(&models.Order{}).Select("user_id, COUNT(*) AS count").
+		Where("user_id IN ?", ids).Group("user_id").Scan(&rows).Error; err != nil {
+		return fmt.Errorf("failed to count orders: %w", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.UserID] = row.Count
+	}
+	for i := range candidates {
+		candidates[i].Primary.OrderCount = counts[candidates[i].Primary.ID]
+		candidates[i].Duplicate.OrderCount = counts[candidates[i].Duplicate.ID]
+	}
+	return nil
+}
+
+// mergedTables are the records that follow a customer into the account they are merged into
+var mergedTables = []struct {
+	name  string
+	model interface{}
+}{
+	{"orders", &models.Order{}},
+	{"return_requests", &models.ReturnRequest{}},
+	{"order_adjustments", &models.OrderAdjustment{}},
+	{"store_credit_entries", &models.StoreCreditEntry{}},
+	{"warranties", &models.Warranty{}},
+	{"warranty_claims", &models.WarrantyClaim{}},
+	{"inventory_reservations", &models.InventoryReservation{}},
+	{"profile_completion_events", &models.ProfileCompletionEvent{}},
+	{"funnel_events", &models.FunnelEvent{}},
+}
+
+// MergeCustomers moves the orders, addresses, store credit, wishlist and other records of the duplicate
+// account to the primary one, deactivates the duplicate and records the merge for the audit trail.
+//
+// Credentials are never carried over: the primary keeps its own email, password and second factor. The
+// duplicate can no longer sign in or refresh its session, and its pending password reset, email
+// verification and magic links are voided. The duplicate's phone number is kept only if the primary has none.
+func (s *Service) MergeCustomers(req MergeRequest, adminID string, now time.Time) (*models.CustomerMerge, error) {
+	if req.PrimaryUserID == req.DuplicateUserID {
+		return nil, fmt.Errorf("%w: an account cannot be merged into itself", ErrInvalidMerge)
+	}
+
+	var merge models.CustomerMerge
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var primary, duplicate models.User
+		for _, u := range []struct {
+			id   string
+			user *models.User
+		}{{req.PrimaryUserID, &primary}, {req.DuplicateUserID, &duplicate}} {
+			if err := tx.Where("id = ?", u.id).First(u.user).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrUserNotFound
+				}
+				return fmt.Errorf("failed to get user: %w", err)
+			}
+			if u.user.Role != "customer" || !u.user.IsActive {
+				return ErrMergeNotAllowed
+			}
+		}
+
+		// Deactivating first claims the duplicate, so two admins can't merge it twice
+		result := tx.Model(&models.User{}).Where("id = ? AND is_active = ?", duplicate.ID, true).Updates(map[string]interface{}{
+			"is_active":                false,
+			"password_reset_token":     nil,
+			"password_reset_expiry":    nil,
+			"email_verification_token": nil,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to deactivate duplicate account: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrMergeNotAllowed
+		}
+		if err := tx.Model(&models.MagicLinkToken{}).Where("user_id = ? AND used_at IS NULL", duplicate.ID).
+			Update("used_at", now).Error; err != nil {
+			return fmt.Errorf("failed to void sign-in links: %w", err)
+		}
+
+		var primaryAddresses, duplicateAddresses []models.Address
+		if err := tx.Where("user_id = ?", primary.ID).Find(&primaryAddresses).Error; err != nil {
+			return fmt.Errorf("failed to get addresses: %w", err)
+		}
+		if err := tx.Where("user_id = ?", duplicate.ID).Find(&duplicateAddresses).Error; err != nil {
+			return fmt.Errorf("failed to get addresses: %w", err)
+		}
+		reasons := matchReasons(matchKeys(primary, primaryAddresses), matchKeys(duplicate, duplicateAddresses))
+
+		moved := models.JSONB{}
+		for _, table := range mergedTables {
+			result := tx.Model(table.model).Where("user_id = ?", duplicate.ID).Update("user_id", primary.ID)
+			if result.Error != nil {
+				return fmt.Errorf("failed to move %s: %w", table.name, result.Error)
+			}
+			moved[table.name] = result.RowsAffected
+		}
+
+		// The primary's default addresses stay the defaults
+		for _, address := range primaryAddresses {
+			if !address.IsDefault {
+				continue
+			}
+			if err := tx.Model(&models.Address{}).Where("user_id = ? AND type = ?", duplicate.ID, address.Type).
+				Update("is_default", false).Error; err != nil {
+				return fmt.Errorf("failed to update addresses: %w", err)
+			}
+		}
+		result = tx.Model(&models.Address{}).Where("user_id = ?", duplicate.ID).Update("user_id", primary.ID)
+		if result.Error != nil {
+			return fmt.Errorf("failed to move addresses: %w", result.Error)
+		}
+		moved["addresses"] = result.RowsAffected
+
+		// Products on both wishlists are kept once
+		if err := tx.Where("user_id = ? AND product_id IN (?)", duplicate.ID,
+			tx.Model(&models.WishlistItem{}).Select("product_id").Where("user_id = ?", primary.ID)).
+			Delete(&models.WishlistItem{}).Error; err != nil {
+			return fmt.Errorf("failed to merge wishlists: %w", err)
+		}
+		result = tx.Model(&models.WishlistItem{}).Where("user_id = ?", duplicate.ID).Update("user_id", primary.ID)
+		if result.Error != nil {
+			return fmt.Errorf("failed to move wishlist: %w", result.Error)
+		}
+		moved["wishlist_items"] = result.RowsAffected
+
+		if (primary.Phone == nil || *primary.Phone == "") && duplicate.Phone != nil && *duplicate.Phone != "" {
+			if err := tx.Model(&models.User{}).Where("id = ?", primary.ID).Update("phone", *duplicate.Phone).Error; err != nil {
+				return fmt.Errorf("failed to copy phone number: %w", err)
+			}
+		}
+
+		merge = models.CustomerMerge{
+			PrimaryUserID:   primary.ID,
+			DuplicateUserID: duplicate.ID,
+			DuplicateEmail:  duplicate.Email,
+			Reasons:         strings.Join(reasons, ","),
+			Note:            strings.TrimSpace(req.Note),
+			Moved:           moved,
+			MergedBy:        adminID,
+		}
+		if err := tx.Create(&merge).Error; err != nil {
+			return fmt.Errorf("failed to record merge: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Named("users").Info("Merged duplicate customer account", map[string]interface{}{
+		"primary_user_id": merge.PrimaryUserID, "duplicate_user_id": merge.DuplicateUserID, "merged_by": adminID,
+	})
+	return &merge, nil
+}
+
+// ListMerges returns the audit trail of customer merges, newest first
+func (s *Service) ListMerges(page, limit int) ([]models.CustomerMerge, int64, error) {
+	var total int64
+	if err := s.db.Model(&models.CustomerMerge{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count customer merges: %w", err)
+	}
+
+	merges := []models.CustomerMerge{}
+	if err := s.db.Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&merges).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get customer merges: %w", err)
+	}
+	return merges, total, nil
+}
@@ -0,0 +1,151 @@
+package users
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMergeTest(t *testing.T) *Service {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	return NewService(database.GetDB())
+}
+
+func createCustomer(t *testing.T, email string, phone *string, createdAt time.Time) models.User {
+	user := models.User{Email: email, Password: "hashed", FirstName: "Asha", LastName: "Rao", Phone: phone, Role: "customer", IsActive: true, CreatedAt: createdAt}
+	require.NoError(t, database.GetDB().Create(&user).Error)
+	return user
+}
+
+func createAddress(t *testing.T, userID, street, postal string, isDefault bool) models.Address {
+	address := models.Address{UserID: userID, Type: "shipping", FirstName: "Asha", LastName: "Rao", Address1: street,
+		City: "Pune", State: "MH", PostalCode: postal, Country: "IN", IsDefault: isDefault}
+	require.NoError(t, database.GetDB().Create(&address).Error)
+	return address
+}
+
+func TestNormalizeEmailAndPhone(t *testing.T) {
+	assert.Equal(t, "ashar@gmail.com", normalizeEmail(" Asha.R+shop@GoogleMail.com "))
+	assert.Equal(t, "asha.r@example.com", normalizeEmail("Asha.R+news@example.com"))
+	assert.Equal(t, "9876543210", normalizePhone("+91 98765-43210"))
+	assert.Equal(t, "", normalizePhone("12345"))
+}
+
+func TestService_FindDuplicates(t *testing.T) {
+	service := setupMergeTest(t)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	original := createCustomer(t, "asha.rao@gmail.com", stringPtr("+91 98765 43210"), base)
+	sameMailbox := createCustomer(t, "asharao+shop@gmail.com", stringPtr("09876543210"), base.Add(time.Hour))
+	sameAddress := createCustomer(t, "other@example.com", nil, base.Add(2*time.Hour))
+	stranger := createCustomer(t, "stranger@example.com", stringPtr("9123456780"), base.Add(3*time.Hour))
+	createAddress(t, original.ID, "12  MG Road", "411001", true)
+	createAddress(t, sameAddress.ID, "12 mg road", "411 001", true)
+	createAddress(t, stranger.ID, "1 Park Street", "700016", true)
+
+	candidates, total, err := service.FindDuplicates(1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, candidates, 2)
+
+	assert.Equal(t, original.ID, candidates[0].Primary.ID)
+	assert.Equal(t, sameMailbox.ID, candidates[0].Duplicate.ID)
+	assert.Equal(t, []string{MatchPhone, MatchEmail}, candidates[0].Reasons)
+
+	assert.Equal(t, original.ID, candidates[1].Primary.ID)
+	assert.Equal(t, sameAddress.ID, candidates[1].Duplicate.ID)
+	assert.Equal(t, []string{MatchAddress}, candidates[1].Reasons)
+
+	page, total, err := service.FindDuplicates(2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, page, 1)
+	assert.Equal(t, sameAddress.ID, page[0].Duplicate.ID)
+}
+
+func TestService_MergeCustomers(t *testing.T) {
+	service := setupMergeTest(t)
+	db := database.GetDB()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	primary := createCustomer(t, "asha@example.com", nil, now.AddDate(-1, 0, 0))
+	duplicate := createCustomer(t, "asha+old@example.com", stringPtr("9876543210"), now.AddDate(0, -1, 0))
+	require.NoError(t, db.Model(&duplicate).Updates(map[string]interface{}{
+		"password_reset_token": "reset-token", "email_verification_token": "verify-token",
+	}).Error)
+
+	createAddress(t, primary.ID, "12 MG Road", "411001", true)
+	movedAddress := createAddress(t, duplicate.ID, "7 FC Road", "411004", true)
+	require.NoError(t, db.Create(&models.Order{UserID: duplicate.ID, Status: "delivered", Subtotal: 10, Total: 10}).Error)
+	require.NoError(t, db.Create(&models.StoreCreditEntry{UserID: duplicate.ID, Amount: 5000, Reason: "goodwill"}).Error)
+	product := models.Product{Name: "Mug", SKU: "MUG-1", Price: 5, CategoryID: "cat-1"}
+	require.NoError(t, db.Create(&product).Error)
+	other := models.Product{Name: "Cup", SKU: "CUP-1", Price: 5, CategoryID: "cat-1"}
+	require.NoError(t, db.Create(&other).Error)
+	for _, item := range []models.WishlistItem{
+		{UserID: &primary.ID, ProductID: product.ID},
+		{UserID: &duplicate.ID, ProductID: product.ID},
+		{UserID: &duplicate.ID, ProductID: other.ID},
+	} {
+		require.NoError(t, db.Create(&item).Error)
+	}
+	link := models.MagicLinkToken{UserID: duplicate.ID, Fingerprint: "fp", ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, db.Create(&link).Error)
+
+	_, err := service.MergeCustomers(MergeRequest{PrimaryUserID: primary.ID, DuplicateUserID: primary.ID}, "admin-1", now)
+	assert.True(t, errors.Is(err, ErrInvalidMerge))
+	_, err = service.MergeCustomers(MergeRequest{PrimaryUserID: primary.ID, DuplicateUserID: "missing"}, "admin-1", now)
+	assert.True(t, errors.Is(err, ErrUserNotFound))
+
+	merge, err := service.MergeCustomers(MergeRequest{PrimaryUserID: primary.ID, DuplicateUserID: duplicate.ID, Note: "Same person"}, "admin-1", now)
+	require.NoError(t, err)
+	assert.Equal(t, "asha+old@example.com", merge.DuplicateEmail)
+	assert.Equal(t, "email", merge.Reasons)
+	assert.EqualValues(t, 1, merge.Moved["orders"])
+	assert.EqualValues(t, 1, merge.Moved["addresses"])
+	assert.EqualValues(t, 1, merge.Moved["wishlist_items"])
+
+	var orders, credit, wishlist int64
+	db.Model(&models.Order{}).Where("user_id = ?", primary.ID).Count(&orders)
+	db.Model(&models.StoreCreditEntry{}).Where("user_id = ?", primary.ID).Count(&credit)
+	db.Model(&models.WishlistItem{}).Where("user_id = ?", primary.ID).Count(&wishlist)
+	assert.Equal(t, int64(1), orders)
+	assert.Equal(t, int64(1), credit)
+	assert.Equal(t, int64(2), wishlist)
+
+	var address models.Address
+	require.NoError(t, db.First(&address, "id = ?", movedAddress.ID).Error)
+	assert.Equal(t, primary.ID, address.UserID)
+	assert.False(t, address.IsDefault, "the primary keeps its default address")
+
+	var merged, kept models.User
+	require.NoError(t, db.First(&merged, "id = ?", duplicate.ID).Error)
+	assert.False(t, merged.IsActive)
+	assert.Nil(t, merged.PasswordResetToken)
+	assert.Nil(t, merged.EmailVerificationToken)
+	require.NoError(t, db.First(&kept, "id = ?", primary.ID).Error)
+	assert.Equal(t, "asha@example.com", kept.Email)
+	require.NotNil(t, kept.Phone)
+	assert.Equal(t, "9876543210", *kept.Phone)
+
+	require.NoError(t, db.First(&link, "id = ?", link.ID).Error)
+	assert.NotNil(t, link.UsedAt)
+
+	// The duplicate is inactive now, so it cannot be merged again
+	_, err = service.MergeCustomers(MergeRequest{PrimaryUserID: primary.ID, DuplicateUserID: duplicate.ID}, "admin-1", now)
+	assert.True(t, errors.Is(err, ErrMergeNotAllowed))
+
+	merges, total, err := service.ListMerges(1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, merges, 1)
+	assert.Equal(t, "admin-1", merges[0].MergedBy)
+	assert.Equal(t, "Same person", merges[0].Note)
+}
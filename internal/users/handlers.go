@@ -1,7 +1,10 @@
 package users
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"ecommerce-website/pkg/utils"
 
@@ -59,6 +62,10 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 			utils.ErrorResponse(c, http.StatusNotFound, "user_not_found", "User not found", nil)
 			return
 		}
+		if err == ErrPhoneRequired {
+			utils.ErrorResponse(c, http.StatusBadRequest, "phone_required", "Add a phone number to receive order updates by text", nil)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to update user profile", nil)
 		return
 	}
@@ -213,3 +220,172 @@ func (h *Handler) DeleteAddress(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Address deleted successfully", nil)
 }
+
+// GetProfileCompletion handles GET /api/users/me/completion
+func (h *Handler) GetProfileCompletion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", "User not authenticated", nil)
+		return
+	}
+
+	completion, err := h.service.GetProfileCompletion(userID.(string))
+	if err != nil {
+		if err == ErrUserNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "user_not_found", "User not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to retrieve profile completion", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Profile completion retrieved successfully", completion)
+}
+
+// CompletePhone handles PUT /api/users/me/phone
+func (h *Handler) CompletePhone(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", "User not authenticated", nil)
+		return
+	}
+
+	var req UpdatePhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "validation_error", err.Error(), nil)
+		return
+	}
+
+	user, err := h.service.CompletePhone(userID.(string), req.Phone)
+	if err != nil {
+		if err == ErrUserNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "user_not_found", "User not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to update phone number", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Phone number saved successfully", user)
+}
+
+// CompleteDefaultAddress handles POST /api/users/me/default-address
+func (h *Handler) CompleteDefaultAddress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", "User not authenticated", nil)
+		return
+	}
+
+	var req CreateAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "validation_error", err.Error(), nil)
+		return
+	}
+
+	address, err := h.service.CompleteDefaultAddress(userID.(string), req)
+	if err != nil {
+		if err == ErrUserNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "user_not_found", "User not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to save default address", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Default address saved successfully", address)
+}
+
+// RecordCompletionEvent handles POST /api/users/me/completion/events
+func (h *Handler) RecordCompletionEvent(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", "User not authenticated", nil)
+		return
+	}
+
+	var req CompletionEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "validation_error", err.Error(), nil)
+		return
+	}
+
+	if err := h.service.RecordCompletionEvent(userID.(string), req.Field, req.Action); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to record event", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Event recorded successfully", nil)
+}
+
+// GetCompletionStats handles GET /api/admin/profile-completion/stats (admin only)
+func (h *Handler) GetCompletionStats(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "validation_error", "days must be a positive integer", nil)
+		return
+	}
+
+	stats, err := h.service.GetCompletionStats(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to retrieve profile completion stats", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Profile completion stats retrieved successfully", gin.H{
+		"days":   days,
+		"fields": stats,
+	})
+}
+
+// FindDuplicates handles GET /api/admin/customers/duplicates (admin only)
+func (h *Handler) FindDuplicates(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	candidates, total, err := h.service.FindDuplicates(page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to find duplicate customers", nil)
+		return
+	}
+
+	utils.PaginatedResponse(c, http.StatusOK, "Duplicate customers retrieved successfully", "candidates", candidates, utils.NewPagination(page, limit, total))
+}
+
+// MergeCustomers handles POST /api/admin/customers/merge (admin only)
+func (h *Handler) MergeCustomers(c *gin.Context) {
+	var req MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "validation_error", "Invalid request data", err.Error())
+		return
+	}
+
+	merge, err := h.service.MergeCustomers(req, c.GetString("user_id"), time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidMerge):
+			utils.ErrorResponse(c, http.StatusBadRequest, "invalid_merge", err.Error(), nil)
+		case errors.Is(err, ErrUserNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "user_not_found", "User not found", nil)
+		case errors.Is(err, ErrMergeNotAllowed):
+			utils.ErrorResponse(c, http.StatusConflict, "merge_not_allowed", err.Error(), nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to merge customers", nil)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Customers merged successfully", merge)
+}
+
+// ListMerges handles GET /api/admin/customers/merges (admin only)
+func (h *Handler) ListMerges(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	merges, total, err := h.service.ListMerges(page, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to retrieve customer merges", nil)
+		return
+	}
+
+	utils.PaginatedResponse(c, http.StatusOK, "Customer merges retrieved successfully", "merges", merges, utils.NewPagination(page, limit, total))
+}
@@ -430,6 +430,28 @@ func (suite *UserHandlerTestSuite) TestDeleteAddress() {
 	assert.Error(suite.T(), err) // Should not find the address
 }
 
+func (suite *UserHandlerTestSuite) TestMergeCustomers_Invalid() {
+	user := suite.createTestUser()
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/admin/customers/merge", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("user_id", "admin-1")
+		suite.handler.MergeCustomers(c)
+		return w
+	}
+
+	w := send(`{"primaryUserId":"` + user.ID + `"}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = send(`{"primaryUserId":"` + user.ID + `","duplicateUserId":"` + user.ID + `"}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "invalid_merge")
+}
+
 func TestUserHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(UserHandlerTestSuite))
 }
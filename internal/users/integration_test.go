@@ -38,7 +38,7 @@ func (suite *UserIntegrationTestSuite) SetupSuite() {
 	suite.router = gin.New()
 	userService := NewService(suite.db)
 	userHandler := NewHandler(userService)
-	SetupRoutes(suite.router, userHandler, suite.authService)
+	SetupRoutes(suite.router.Group("/api"), userHandler, suite.authService)
 }
 
 func (suite *UserIntegrationTestSuite) TearDownSuite() {
@@ -0,0 +1,43 @@
+package users
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/geo"
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires user profiles and addresses into the application
+type Module struct {
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the users module. Saved addresses are geocoded with GEOCODER_PROVIDER when it is set and
+// assigned delivery zones either way; an invalid provider is logged and leaves geocoding off.
+func NewModule(deps app.Deps) app.Module {
+	geocoder, err := geo.NewGeocoder(deps.Config.GeocoderProvider, deps.Config.GeocoderURL, deps.Config.GeocoderAPIKey)
+	if err != nil {
+		logger.Named("geo").Warn("Address geocoding disabled by invalid configuration", map[string]interface{}{"error": err.Error()})
+	}
+	service := NewService(deps.DB).WithLocator(geo.NewService(deps.DB, geocoder))
+	return &Module{handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "users"
+}
+
+// Models returns the customer merge audit table
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.CustomerMerge{}}
+}
+
+// RegisterAPIRoutes sets up the user routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
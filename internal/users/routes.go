@@ -7,9 +7,7 @@ import (
 )
 
 // SetupRoutes sets up the user profile management routes
-func SetupRoutes(r *gin.Engine, handler *Handler, authService *auth.Service) {
-	api := r.Group("/api")
-
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
 	// User profile routes (require authentication)
 	userRoutes := api.Group("/users")
 	userRoutes.Use(authService.AuthMiddleware())
@@ -27,5 +25,29 @@ func SetupRoutes(r *gin.Engine, handler *Handler, authService *auth.Service) {
 		userRoutes.GET("/addresses/:id", handler.GetAddress)
 		userRoutes.PUT("/addresses/:id", handler.UpdateAddress)
 		userRoutes.DELETE("/addresses/:id", handler.DeleteAddress)
+
+		// Progressive profiling
+		userRoutes.GET("/me/completion", handler.GetProfileCompletion)
+		userRoutes.POST("/me/completion/events", handler.RecordCompletionEvent)
+		userRoutes.PUT("/me/phone", handler.CompletePhone)
+		userRoutes.POST("/me/default-address", handler.CompleteDefaultAddress)
+	}
+
+	// Admin routes
+	admin := api.Group("/admin/profile-completion")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("/stats", handler.GetCompletionStats)
+	}
+
+	// Admin duplicate account tooling
+	customers := api.Group("/admin/customers")
+	customers.Use(authService.AuthMiddleware())
+	customers.Use(authService.AdminMiddleware())
+	{
+		customers.GET("/duplicates", handler.FindDuplicates)
+		customers.POST("/merge", handler.MergeCustomers)
+		customers.GET("/merges", handler.ListMerges)
 	}
 }
@@ -1,7 +1,9 @@
 package users
 
 import (
+	"context"
 	"errors"
+	"strings"
 
 	"ecommerce-website/internal/models"
 
@@ -9,13 +11,25 @@ import (
 )
 
 type Service struct {
-	db *gorm.DB
+	db      *gorm.DB
+	locator Locator
+}
+
+// Locator geocodes an address and assigns it a delivery zone without saving it
+type Locator interface {
+	Locate(ctx context.Context, address *models.Address) error
 }
 
 type UpdateProfileRequest struct {
 	FirstName string  `json:"firstName" binding:"required"`
 	LastName  string  `json:"lastName" binding:"required"`
 	Phone     *string `json:"phone,omitempty"`
+	// Opting out stops marketing and review request emails; omit to keep the current choice
+	MarketingOptOut *bool `json:"marketingOptOut,omitempty"`
+	// Opting out stops order status emails; order confirmations and refunds are still emailed
+	OrderEmailOptOut *bool `json:"orderEmailOptOut,omitempty"`
+	// Opting in texts order status updates to Phone, which must then be set
+	OrderSMSOptIn *bool `json:"orderSmsOptIn,omitempty"`
 }
 
 type CreateAddressRequest struct {
@@ -51,6 +65,7 @@ var (
 	ErrUserNotFound    = errors.New("user not found")
 	ErrAddressNotFound = errors.New("address not found")
 	ErrUnauthorized    = errors.New("unauthorized access to address")
+	ErrPhoneRequired   = errors.New("a phone number is required for order texts")
 )
 
 func NewService(db *gorm.DB) *Service {
@@ -59,6 +74,20 @@ func NewService(db *gorm.DB) *Service {
 	}
 }
 
+// WithLocator locates addresses with locator as they are saved
+func (s *Service) WithLocator(locator Locator) *Service {
+	s.locator = locator
+	return s
+}
+
+// locate geocodes and zones an address when a locator is configured
+func (s *Service) locate(address *models.Address) error {
+	if s.locator == nil {
+		return nil
+	}
+	return s.locator.Locate(context.Background(), address)
+}
+
 // GetProfile retrieves user profile information
 func (s *Service) GetProfile(userID string) (*models.User, error) {
 	var user models.User
@@ -88,6 +117,18 @@ func (s *Service) UpdateProfile(userID string, req UpdateProfileRequest) (*model
 	user.FirstName = req.FirstName
 	user.LastName = req.LastName
 	user.Phone = req.Phone
+	if req.MarketingOptOut != nil {
+		user.MarketingOptOut = *req.MarketingOptOut
+	}
+	if req.OrderEmailOptOut != nil {
+		user.OrderEmailOptOut = *req.OrderEmailOptOut
+	}
+	if req.OrderSMSOptIn != nil {
+		user.OrderSMSOptIn = *req.OrderSMSOptIn
+	}
+	if user.OrderSMSOptIn && (user.Phone == nil || strings.TrimSpace(*user.Phone) == "") {
+		return nil, ErrPhoneRequired
+	}
 
 	if err := s.db.Save(&user).Error; err != nil {
 		return nil, err
@@ -141,6 +182,9 @@ func (s *Service) CreateAddress(userID string, req CreateAddressRequest) (*model
 		Phone:      req.Phone,
 		IsDefault:  req.IsDefault,
 	}
+	if err := s.locate(&address); err != nil {
+		return nil, err
+	}
 
 	if err := s.db.Create(&address).Error; err != nil {
 		return nil, err
@@ -189,6 +233,10 @@ func (s *Service) UpdateAddress(userID, addressID string, req UpdateAddressReque
 		}
 	}
 
+	// The address is only located again when where it is changes
+	moved := address.Address1 != req.Address1 || address.City != req.City || address.State != req.State ||
+		address.PostalCode != req.PostalCode || address.Country != req.Country
+
 	// Update address fields
 	address.FirstName = req.FirstName
 	address.LastName = req.LastName
@@ -201,6 +249,11 @@ func (s *Service) UpdateAddress(userID, addressID string, req UpdateAddressReque
 	address.Country = req.Country
 	address.Phone = req.Phone
 	address.IsDefault = req.IsDefault
+	if moved {
+		if err := s.locate(&address); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.db.Save(&address).Error; err != nil {
 		return nil, err
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"context"
 	"testing"
 
 	"ecommerce-website/internal/models"
@@ -80,6 +81,32 @@ func (suite *UserServiceTestSuite) TestUpdateProfile() {
 	assert.Equal(suite.T(), req.LastName, updatedUser.LastName)
 	assert.Equal(suite.T(), req.Phone, updatedUser.Phone)
 	assert.Empty(suite.T(), updatedUser.Password) // Password should be removed
+	assert.False(suite.T(), updatedUser.MarketingOptOut)
+
+	// Marketing preference changes only when sent
+	optOut := true
+	req.MarketingOptOut = &optOut
+	updatedUser, err = suite.service.UpdateProfile(user.ID, req)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), updatedUser.MarketingOptOut)
+	req.MarketingOptOut = nil
+	updatedUser, err = suite.service.UpdateProfile(user.ID, req)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), updatedUser.MarketingOptOut)
+
+	// Order texts need a phone number; order emails are on until turned off
+	assert.False(suite.T(), updatedUser.OrderEmailOptOut)
+	optIn := true
+	req.OrderSMSOptIn = &optIn
+	req.OrderEmailOptOut = &optIn
+	updatedUser, err = suite.service.UpdateProfile(user.ID, req)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), updatedUser.OrderSMSOptIn)
+	assert.True(suite.T(), updatedUser.OrderEmailOptOut)
+	req.Phone = nil
+	_, err = suite.service.UpdateProfile(user.ID, req)
+	assert.Equal(suite.T(), ErrPhoneRequired, err)
+	req.Phone = stringPtr("9876543210")
 
 	// Test user not found
 	_, err = suite.service.UpdateProfile("nonexistent-id", req)
@@ -313,6 +340,42 @@ func (suite *UserServiceTestSuite) TestUpdateAddress() {
 	assert.Equal(suite.T(), ErrAddressNotFound, err)
 }
 
+// fakeLocator zones every address it locates
+type fakeLocator struct {
+	calls int
+}
+
+func (f *fakeLocator) Locate(ctx context.Context, address *models.Address) error {
+	f.calls++
+	zone := "zone-" + address.PostalCode
+	address.DeliveryZoneID = &zone
+	return nil
+}
+
+func (suite *UserServiceTestSuite) TestAddressesAreLocatedWhenTheyMove() {
+	user := suite.createTestUser()
+	locator := &fakeLocator{}
+	service := NewService(suite.db).WithLocator(locator)
+
+	address, err := service.CreateAddress(user.ID, CreateAddressRequest{
+		Type: "shipping", FirstName: "John", LastName: "Doe", Address1: "123 Main St",
+		City: "New York", State: "NY", PostalCode: "10001", Country: "US",
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "zone-10001", *address.DeliveryZoneID)
+
+	update := UpdateAddressRequest{FirstName: "Jane", LastName: "Doe", Address1: "123 Main St", City: "New York", State: "NY", PostalCode: "10001", Country: "US"}
+	_, err = service.UpdateAddress(user.ID, address.ID, update)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, locator.calls, "renaming the recipient does not relocate the address")
+
+	update.PostalCode = "10002"
+	updated, err := service.UpdateAddress(user.ID, address.ID, update)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, locator.calls)
+	assert.Equal(suite.T(), "zone-10002", *updated.DeliveryZoneID)
+}
+
 func (suite *UserServiceTestSuite) TestDeleteAddress() {
 	user := suite.createTestUser()
 	address := &models.Address{
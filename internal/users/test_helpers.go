@@ -122,7 +122,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	require.NoError(t, err)
 
 	// Migrate the schema
-	err = db.AutoMigrate(&models.User{}, &models.Address{}, &models.Order{}, &models.OrderItem{})
+	err = db.AutoMigrate(&models.User{}, &models.Address{}, &models.Order{}, &models.OrderItem{}, &models.ProfileCompletionEvent{})
 	require.NoError(t, err)
 
 	return db
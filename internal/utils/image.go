@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/url"
+	"path"
 	"strconv"
 	"strings"
 )
@@ -14,15 +18,138 @@ type ImageOptimizationConfig struct {
 	DefaultHeight int
 	Quality       int
 	Format        string
+	// SigningKey signs the image URLs handed out for the CDN; URLs are unsigned when it is empty
+	SigningKey string
+	// VariantFormats are the formats uploaded images have stored variants in, most preferred first
+	VariantFormats []string
 }
 
 // DefaultImageConfig provides default image optimization settings
 var DefaultImageConfig = ImageOptimizationConfig{
-	CDNBaseURL:    "", // Will be set from environment
-	DefaultWidth:  800,
-	DefaultHeight: 600,
-	Quality:       85,
-	Format:        "webp",
+	CDNBaseURL:     "", // Will be set from environment
+	DefaultWidth:   800,
+	DefaultHeight:  600,
+	Quality:        85,
+	Format:         "webp",
+	VariantFormats: []string{"jpeg"},
+}
+
+// ImageSize is a named size images are delivered at; images are scaled to fit within it, never enlarged
+type ImageSize struct {
+	Name    string
+	Width   int
+	Height  int
+	Quality int
+}
+
+// ImageSizes are the sizes uploaded images are stored in and the ?size= parameter accepts, smallest first
+var ImageSizes = []ImageSize{
+	{Name: "thumbnail", Width: 150, Height: 150, Quality: 80},
+	{Name: "small", Width: 300, Height: 300, Quality: 85},
+	{Name: "medium", Width: 600, Height: 600, Quality: 85},
+	{Name: "large", Width: 1200, Height: 1200, Quality: 90},
+	{Name: "xlarge", Width: 1920, Height: 1920, Quality: 90},
+}
+
+// ImageSizeNamed returns the image size called name
+func ImageSizeNamed(name string) (ImageSize, bool) {
+	for _, size := range ImageSizes {
+		if size.Name == name {
+			return size, true
+		}
+	}
+	return ImageSize{}, false
+}
+
+// imageOriginalName is the base name uploaded images are stored under; their variants are stored next to them
+const imageOriginalName = "original"
+
+// ImageOriginalKey returns the key an uploaded image with extension ext is stored under in dir
+func ImageOriginalKey(dir, ext string) string {
+	return dir + "/" + imageOriginalName + ext
+}
+
+// ImageVariantKey returns the key or URL of the variant of an uploaded image at the named size in format
+func ImageVariantKey(original, size, format string) string {
+	return original[:strings.LastIndex(original, "/")+1] + size + "." + format
+}
+
+// isImageOriginal reports whether imageURL is an image stored by the upload pipeline, which has stored variants
+func isImageOriginal(imageURL string) bool {
+	parsedURL, err := url.Parse(imageURL)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(parsedURL.Path, "/images/") && strings.HasPrefix(path.Base(parsedURL.Path), imageOriginalName+".")
+}
+
+// variantFormat returns the format uploaded images are delivered in: the preferred format when variants are
+// stored in it, otherwise the first stored one
+func variantFormat() string {
+	for _, format := range DefaultImageConfig.VariantFormats {
+		if format == DefaultImageConfig.Format {
+			return format
+		}
+	}
+	if len(DefaultImageConfig.VariantFormats) > 0 {
+		return DefaultImageConfig.VariantFormats[0]
+	}
+	return "jpeg"
+}
+
+// ImageURL returns the signed URL an image is delivered from at the named size; an empty or unknown size
+// delivers it at its default size. Uploaded images point at their stored variant, other images at the
+// CDN's on-the-fly transformation.
+func ImageURL(imageURL, size string) string {
+	if imageURL == "" {
+		return ""
+	}
+	spec, sized := ImageSizeNamed(size)
+	if isImageOriginal(imageURL) {
+		if sized {
+			imageURL = ImageVariantKey(imageURL, spec.Name, variantFormat())
+		}
+		return SignImageURL(imageURL)
+	}
+	if !sized {
+		return SignImageURL(OptimizeImageURL(imageURL, DefaultImageConfig.DefaultWidth, DefaultImageConfig.DefaultHeight, DefaultImageConfig.Quality, DefaultImageConfig.Format))
+	}
+	return SignImageURL(OptimizeImageURL(imageURL, spec.Width, spec.Height, spec.Quality, DefaultImageConfig.Format))
+}
+
+// ImageURLs returns the delivery URL of each image at the named size
+func ImageURLs(images []string, size string) []string {
+	if len(images) == 0 {
+		return nil
+	}
+	urls := make([]string, len(images))
+	for i, image := range images {
+		urls[i] = ImageURL(image, size)
+	}
+	return urls
+}
+
+// SignImageURL appends the signature the CDN verifies to a URL on the CDN: the hex HMAC-SHA256 of the path
+// and query under the signing key, as the s parameter. Other URLs are returned unchanged.
+func SignImageURL(imageURL string) string {
+	key := DefaultImageConfig.SigningKey
+	if key == "" || DefaultImageConfig.CDNBaseURL == "" || !strings.HasPrefix(imageURL, DefaultImageConfig.CDNBaseURL) {
+		return imageURL
+	}
+	parsedURL, err := url.Parse(imageURL)
+	if err != nil {
+		return imageURL
+	}
+
+	signed := parsedURL.EscapedPath()
+	separator := "?"
+	if parsedURL.RawQuery != "" {
+		signed += "?" + parsedURL.RawQuery
+		separator = "&"
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(signed))
+	return imageURL + separator + "s=" + hex.EncodeToString(mac.Sum(nil))
 }
 
 // OptimizeImageURL generates an optimized image URL with CDN parameters
@@ -76,22 +203,10 @@ func GenerateResponsiveImageURLs(originalURL string) map[string]string {
 		return nil
 	}
 
-	sizes := map[string]struct {
-		width   int
-		height  int
-		quality int
-	}{
-		"thumbnail": {150, 150, 80},
-		"small":     {300, 300, 85},
-		"medium":    {600, 600, 85},
-		"large":     {1200, 1200, 90},
-		"xlarge":    {1920, 1920, 90},
-	}
-
 	urls := make(map[string]string)
 
-	for size, config := range sizes {
-		urls[size] = OptimizeImageURL(originalURL, config.width, config.height, config.quality, DefaultImageConfig.Format)
+	for _, size := range ImageSizes {
+		urls[size.Name] = ImageURL(originalURL, size.Name)
 	}
 
 	return urls
@@ -179,7 +294,7 @@ func ProcessImageURLs(urls []string) []ImageResponse {
 	for i, url := range urls {
 		responses[i] = ImageResponse{
 			Original:   url,
-			Optimized:  OptimizeImageURL(url, DefaultImageConfig.DefaultWidth, DefaultImageConfig.DefaultHeight, DefaultImageConfig.Quality, DefaultImageConfig.Format),
+			Optimized:  ImageURL(url, ""),
 			Responsive: GenerateResponsiveImageURLs(url),
 		}
 	}
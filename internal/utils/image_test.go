@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -205,3 +208,46 @@ func TestProcessImageURLs(t *testing.T) {
 	emptyResult := ProcessImageURLs([]string{})
 	assert.Nil(t, emptyResult)
 }
+
+func TestImageURL(t *testing.T) {
+	DefaultImageConfig.CDNBaseURL = "https://cdn.example.com"
+	DefaultImageConfig.VariantFormats = []string{"webp", "jpeg"}
+	t.Cleanup(func() {
+		DefaultImageConfig.CDNBaseURL = ""
+		DefaultImageConfig.VariantFormats = []string{"jpeg"}
+	})
+
+	uploaded := "https://cdn.example.com/media/products/p1/images/a1/original.png"
+	assert.Equal(t, "https://cdn.example.com/media/products/p1/images/a1/thumbnail.webp", ImageURL(uploaded, "thumbnail"))
+	assert.Equal(t, uploaded, ImageURL(uploaded, ""))
+	assert.Equal(t, uploaded, ImageURL(uploaded, "huge"), "unknown sizes deliver the default size")
+
+	DefaultImageConfig.VariantFormats = []string{"jpeg"}
+	assert.Equal(t, "/media/images/a1/large.jpeg", ImageURL("/media/images/a1/original.jpg", "large"))
+
+	// Other images are transformed by the CDN
+	assert.Equal(t, "https://cdn.example.com/image.jpg?auto=compress%2Cformat&f=webp&h=300&q=85&w=300", ImageURL("https://example.com/image.jpg", "small"))
+	assert.Equal(t, []string{"https://cdn.example.com/a.jpg?auto=compress%2Cformat&f=webp&h=150&q=80&w=150"}, ImageURLs([]string{"https://example.com/a.jpg"}, "thumbnail"))
+	assert.Nil(t, ImageURLs(nil, "thumbnail"))
+	assert.Empty(t, ImageURL("", "small"))
+}
+
+func TestSignImageURL(t *testing.T) {
+	DefaultImageConfig.CDNBaseURL = "https://cdn.example.com"
+	t.Cleanup(func() {
+		DefaultImageConfig.CDNBaseURL = ""
+		DefaultImageConfig.SigningKey = ""
+	})
+
+	assert.Equal(t, "https://cdn.example.com/a.jpg", SignImageURL("https://cdn.example.com/a.jpg"), "unsigned without a key")
+
+	DefaultImageConfig.SigningKey = "secret"
+	sign := func(message string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(message))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	assert.Equal(t, "https://cdn.example.com/a.jpg?s="+sign("/a.jpg"), SignImageURL("https://cdn.example.com/a.jpg"))
+	assert.Equal(t, "https://cdn.example.com/a.jpg?w=150&s="+sign("/a.jpg?w=150"), SignImageURL("https://cdn.example.com/a.jpg?w=150"))
+	assert.Equal(t, "https://example.com/a.jpg", SignImageURL("https://example.com/a.jpg"), "only CDN URLs are signed")
+}
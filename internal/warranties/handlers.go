@@ -0,0 +1,135 @@
+package warranties
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the customer warranty and admin claim endpoints
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new warranties handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// ListWarranties handles GET /api/warranties
+func (h *Handler) ListWarranties(c *gin.Context) {
+	warranties, err := h.service.ListWarranties(c.GetString("user_id"), time.Now())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_WARRANTIES_FAILED", "Failed to get warranties", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Warranties retrieved successfully", warranties)
+}
+
+// GetWarranty handles GET /api/warranties/:id
+func (h *Handler) GetWarranty(c *gin.Context) {
+	warranty, err := h.service.GetWarranty(c.Param("id"), c.GetString("user_id"), time.Now())
+	if err != nil {
+		warrantyError(c, err, "GET_WARRANTY_FAILED", "Failed to get warranty")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Warranty retrieved successfully", warranty)
+}
+
+// SubmitClaim handles POST /api/warranties/:id/claims
+func (h *Handler) SubmitClaim(c *gin.Context) {
+	var req ClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	claim, err := h.service.SubmitClaim(c.Param("id"), c.GetString("user_id"), req, time.Now())
+	if err != nil {
+		warrantyError(c, err, "SUBMIT_WARRANTY_CLAIM_FAILED", "Failed to submit warranty claim")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusCreated, "Warranty claim submitted successfully", claim)
+}
+
+// ListMyClaims handles GET /api/warranties/claims
+func (h *Handler) ListMyClaims(c *gin.Context) {
+	claims, err := h.service.ListMyClaims(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_WARRANTY_CLAIMS_FAILED", "Failed to get warranty claims", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Warranty claims retrieved successfully", claims)
+}
+
+// ListClaims handles GET /api/admin/warranty-claims (admin only)
+func (h *Handler) ListClaims(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	status := c.Query("status")
+	switch status {
+	case "", models.WarrantyClaimSubmitted, models.WarrantyClaimInReview, models.WarrantyClaimApproved,
+		models.WarrantyClaimRejected, models.WarrantyClaimResolved:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Unknown claim status", nil)
+		return
+	}
+
+	claims, total, err := h.service.ListClaims(ClaimQuery{Status: status, Page: page, Limit: limit})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_WARRANTY_CLAIMS_FAILED", "Failed to get warranty claims", err.Error())
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Warranty claims retrieved successfully", "claims", claims, utils.NewPagination(page, limit, total))
+}
+
+// GetClaim handles GET /api/admin/warranty-claims/:id (admin only)
+func (h *Handler) GetClaim(c *gin.Context) {
+	claim, err := h.service.GetClaim(c.Param("id"))
+	if err != nil {
+		warrantyError(c, err, "GET_WARRANTY_CLAIM_FAILED", "Failed to get warranty claim")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Warranty claim retrieved successfully", claim)
+}
+
+// UpdateClaimStatus handles PUT /api/admin/warranty-claims/:id/status (admin only)
+func (h *Handler) UpdateClaimStatus(c *gin.Context) {
+	var req ClaimStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	claim, err := h.service.UpdateClaimStatus(c.Param("id"), req, c.GetString("user_id"))
+	if err != nil {
+		warrantyError(c, err, "UPDATE_WARRANTY_CLAIM_FAILED", "Failed to update warranty claim")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Warranty claim updated successfully", claim)
+}
+
+// warrantyError writes the response for a failed warranty or claim request
+func warrantyError(c *gin.Context, err error, code, message string) {
+	switch {
+	case errors.Is(err, ErrWarrantyNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "WARRANTY_NOT_FOUND", "Warranty not found", nil)
+	case errors.Is(err, ErrClaimNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "WARRANTY_CLAIM_NOT_FOUND", "Warranty claim not found", nil)
+	case errors.Is(err, ErrWarrantyExpired):
+		utils.ErrorResponse(c, http.StatusConflict, "WARRANTY_EXPIRED", err.Error(), nil)
+	case errors.Is(err, ErrClaimOpen):
+		utils.ErrorResponse(c, http.StatusConflict, "WARRANTY_CLAIM_OPEN", err.Error(), nil)
+	case errors.Is(err, ErrInvalidTransition):
+		utils.ErrorResponse(c, http.StatusConflict, "INVALID_CLAIM_TRANSITION", err.Error(), nil)
+	case errors.Is(err, ErrInvalidClaim):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_WARRANTY_CLAIM", err.Error(), nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, code, message, err.Error())
+	}
+}
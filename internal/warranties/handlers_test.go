@@ -0,0 +1,166 @@
+package warranties
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the warranties service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) RegisterDueWarranties(userID string, now time.Time) (int, error) {
+	args := m.Called(userID, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockService) ListWarranties(userID string, now time.Time) ([]models.Warranty, error) {
+	args := m.Called(userID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Warranty), args.Error(1)
+}
+
+func (m *MockService) GetWarranty(id, userID string, now time.Time) (*models.Warranty, error) {
+	args := m.Called(id, userID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Warranty), args.Error(1)
+}
+
+func (m *MockService) SubmitClaim(warrantyID, userID string, req ClaimRequest, now time.Time) (*models.WarrantyClaim, error) {
+	args := m.Called(warrantyID, userID, req, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WarrantyClaim), args.Error(1)
+}
+
+func (m *MockService) ListMyClaims(userID string) ([]models.WarrantyClaim, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.WarrantyClaim), args.Error(1)
+}
+
+func (m *MockService) ListClaims(query ClaimQuery) ([]models.WarrantyClaim, int64, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.WarrantyClaim), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockService) GetClaim(id string) (*models.WarrantyClaim, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WarrantyClaim), args.Error(1)
+}
+
+func (m *MockService) UpdateClaimStatus(id string, req ClaimStatusRequest, actorID string) (*models.WarrantyClaim, error) {
+	args := m.Called(id, req, actorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WarrantyClaim), args.Error(1)
+}
+
+func TestHandler_SubmitClaim(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("SubmitClaim", "warranty-1", "user-1", ClaimRequest{Description: "Screen flickers"}, mock.Anything).
+		Return(&models.WarrantyClaim{ID: "claim-1", Status: models.WarrantyClaimSubmitted}, nil)
+	mockService.On("SubmitClaim", "warranty-2", "user-1", mock.Anything, mock.Anything).Return(nil, ErrWarrantyExpired)
+	mockService.On("SubmitClaim", "missing", "user-1", mock.Anything, mock.Anything).Return(nil, ErrWarrantyNotFound)
+
+	r := gin.New()
+	r.POST("/api/warranties/:id/claims", func(c *gin.Context) { c.Set("user_id", "user-1") }, NewHandler(mockService).SubmitClaim)
+
+	send := func(id, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/warranties/"+id+"/claims", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("warranty-1", `{"description":"Screen flickers"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), "claim-1")
+
+	w = send("warranty-2", `{"description":"Screen flickers"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "WARRANTY_EXPIRED")
+
+	w = send("missing", `{"description":"Screen flickers"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = send("warranty-1", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_REQUEST")
+}
+
+func TestHandler_ListClaims(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("ListClaims", ClaimQuery{Status: models.WarrantyClaimSubmitted, Page: 1, Limit: 20}).
+		Return([]models.WarrantyClaim{{ID: "claim-1", Status: models.WarrantyClaimSubmitted}}, int64(1), nil)
+
+	r := gin.New()
+	r.GET("/api/admin/warranty-claims", NewHandler(mockService).ListClaims)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/admin/warranty-claims?status=submitted", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "claim-1")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/admin/warranty-claims?status=lost", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandler_UpdateClaimStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("UpdateClaimStatus", "claim-1", ClaimStatusRequest{Status: models.WarrantyClaimInReview}, "admin-1").
+		Return(&models.WarrantyClaim{ID: "claim-1", Status: models.WarrantyClaimInReview}, nil)
+	mockService.On("UpdateClaimStatus", "claim-1", ClaimStatusRequest{Status: models.WarrantyClaimResolved}, "admin-1").
+		Return(nil, ErrInvalidTransition)
+
+	r := gin.New()
+	r.PUT("/api/admin/warranty-claims/:id/status", func(c *gin.Context) { c.Set("user_id", "admin-1") }, NewHandler(mockService).UpdateClaimStatus)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "/api/admin/warranty-claims/claim-1/status", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send(`{"status":"in_review"}`).Code)
+
+	w := send(`{"status":"resolved"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_CLAIM_TRANSITION")
+}
@@ -0,0 +1,48 @@
+package warranties
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registrationInterval is how often delivered items are checked for warranties to register
+const registrationInterval = time.Hour
+
+// Module wires warranty registration and claims into the application
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the warranties module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "warranties"
+}
+
+// Models returns the warranty and claim tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.Warranty{}, &models.WarrantyClaim{}, &models.WarrantyClaimEvent{}}
+}
+
+// StartJobs registers warranties of newly delivered items in the background
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartRegistrationSweeper(ctx, registrationInterval)
+}
+
+// RegisterAPIRoutes sets up the warranty and claim routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
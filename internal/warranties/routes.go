@@ -0,0 +1,28 @@
+package warranties
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the customer warranty and admin claim routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	warranties := api.Group("/warranties")
+	warranties.Use(authService.AuthMiddleware())
+	{
+		warranties.GET("", handler.ListWarranties)
+		warranties.GET("/claims", handler.ListMyClaims)
+		warranties.GET("/:id", handler.GetWarranty)
+		warranties.POST("/:id/claims", handler.SubmitClaim)
+	}
+
+	admin := api.Group("/admin")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("/warranty-claims", handler.ListClaims)
+		admin.GET("/warranty-claims/:id", handler.GetClaim)
+		admin.PUT("/warranty-claims/:id/status", handler.UpdateClaimStatus)
+	}
+}
@@ -0,0 +1,352 @@
+package warranties
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// registerBatchSize caps how many warranties one sweep registers
+const registerBatchSize = 500
+
+var (
+	ErrWarrantyNotFound  = errors.New("warranty not found")
+	ErrWarrantyExpired   = errors.New("warranty has expired")
+	ErrClaimNotFound     = errors.New("warranty claim not found")
+	ErrClaimOpen         = errors.New("warranty already has an open claim")
+	ErrInvalidClaim      = errors.New("invalid warranty claim")
+	ErrInvalidTransition = errors.New("invalid claim status change")
+)
+
+// claimTransitions lists the statuses an admin can move a claim to from each status
+var claimTransitions = map[string][]string{
+	models.WarrantyClaimSubmitted: {models.WarrantyClaimInReview, models.WarrantyClaimRejected},
+	models.WarrantyClaimInReview:  {models.WarrantyClaimApproved, models.WarrantyClaimRejected},
+	models.WarrantyClaimApproved:  {models.WarrantyClaimResolved},
+}
+
+// openClaimStatuses are the statuses of claims still being handled
+var openClaimStatuses = []string{models.WarrantyClaimSubmitted, models.WarrantyClaimInReview, models.WarrantyClaimApproved}
+
+// ClaimRequest submits a warranty claim
+type ClaimRequest struct {
+	// Serial identifies the faulty unit when the warranty covers serial-tracked units
+	Serial      string `json:"serial" binding:"max=64"`
+	Description string `json:"description" binding:"required,max=2000"`
+}
+
+// ClaimStatusRequest moves a claim on in the claim workflow
+type ClaimStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note" binding:"max=2000"`
+	// Resolution tells the customer how the claim was settled, such as repaired or replaced
+	Resolution string `json:"resolution" binding:"max=2000"`
+}
+
+// ClaimQuery filters the admin claim list
+type ClaimQuery struct {
+	Status string
+	Page   int
+	Limit  int
+}
+
+// dueItem is a delivered order item in a category with a warranty period that has no warranty yet
+type dueItem struct {
+	OrderItemID         string
+	OrderID             string
+	UserID              string
+	ProductID           string
+	Quantity            int
+	WarrantyMonths      int
+	ShipmentDeliveredAt *time.Time
+	OrderDeliveredAt    *time.Time
+}
+
+// ServiceInterface defines the interface for the warranties service
+type ServiceInterface interface {
+	RegisterDueWarranties(userID string, now time.Time) (int, error)
+	ListWarranties(userID string, now time.Time) ([]models.Warranty, error)
+	GetWarranty(id, userID string, now time.Time) (*models.Warranty, error)
+	SubmitClaim(warrantyID, userID string, req ClaimRequest, now time.Time) (*models.WarrantyClaim, error)
+	ListMyClaims(userID string) ([]models.WarrantyClaim, error)
+	ListClaims(query ClaimQuery) ([]models.WarrantyClaim, int64, error)
+	GetClaim(id string) (*models.WarrantyClaim, error)
+	UpdateClaimStatus(id string, req ClaimStatusRequest, actorID string) (*models.WarrantyClaim, error)
+}
+
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new warranties service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// RegisterDueWarranties registers a warranty for every delivered order item in a category with a warranty
+// period, or only those of userID when set, and returns how many were registered. A warranty runs from the
+// delivery of the item's shipment, or of the order for items without one. Cancelled and refunded orders are
+// skipped, and registering twice is harmless.
+func (s *Service) RegisterDueWarranties(userID string, now time.Time) (int, error) {
+	query := s.db.Table("order_items AS oi").
+		Select("oi.id AS order_item_id, oi.order_id, o.user_id, oi.product_id, oi.quantity, c.warranty_months, "+
+			"sh.delivered_at AS shipment_delivered_at, o.delivered_at AS order_delivered_at").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Joins("JOIN products p ON p.id = oi.product_id").
+		Joins("JOIN categories c ON c.id = p.category_id").
+		Joins("LEFT JOIN shipments sh ON sh.id = oi.shipment_id").
+		Joins("LEFT JOIN warranties w ON w.order_item_id = oi.id").
+		Where("w.id IS NULL AND c.warranty_months > 0 AND o.status NOT IN ?", []string{"cancelled", "refunded"}).
+		Where("sh.delivered_at IS NOT NULL OR (oi.shipment_id IS NULL AND o.status = ? AND o.delivered_at IS NOT NULL)", "delivered")
+	if userID != "" {
+		query = query.Where("o.user_id = ?", userID)
+	}
+
+	var due []dueItem
+	if err := query.Order("oi.created_at").Limit(registerBatchSize).Scan(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to get items due a warranty: %w", err)
+	}
+
+	registered := 0
+	for _, item := range due {
+		startsAt := item.OrderDeliveredAt
+		if item.ShipmentDeliveredAt != nil {
+			startsAt = item.ShipmentDeliveredAt
+		}
+		if startsAt == nil || startsAt.After(now) {
+			continue
+		}
+
+		warranty := models.Warranty{
+			UserID:      item.UserID,
+			OrderID:     item.OrderID,
+			OrderItemID: item.OrderItemID,
+			ProductID:   item.ProductID,
+			Quantity:    item.Quantity,
+			Months:      item.WarrantyMonths,
+			StartsAt:    *startsAt,
+			ExpiresAt:   startsAt.AddDate(0, item.WarrantyMonths, 0),
+		}
+		result := s.db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "order_item_id"}}, DoNothing: true}).Create(&warranty)
+		if result.Error != nil {
+			return registered, fmt.Errorf("failed to register warranty: %w", result.Error)
+		}
+		registered += int(result.RowsAffected)
+	}
+	return registered, nil
+}
+
+// ListWarranties registers the customer's newly delivered warranties and returns all of them, newest first
+func (s *Service) ListWarranties(userID string, now time.Time) ([]models.Warranty, error) {
+	if _, err := s.RegisterDueWarranties(userID, now); err != nil {
+		return nil, err
+	}
+
+	warranties := []models.Warranty{}
+	if err := s.db.Preload("Product").Preload("Serials").Where("user_id = ?", userID).
+		Order("starts_at DESC").Find(&warranties).Error; err != nil {
+		return nil, fmt.Errorf("failed to get warranties: %w", err)
+	}
+	for i := range warranties {
+		warranties[i].Status = warranties[i].StatusAt(now)
+	}
+	return warranties, nil
+}
+
+// GetWarranty returns a warranty of the customer with its claims and their history
+func (s *Service) GetWarranty(id, userID string, now time.Time) (*models.Warranty, error) {
+	var warranty models.Warranty
+	if err := s.db.Preload("Product").Preload("Serials").
+		Preload("Claims", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		Preload("Claims.Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Where("id = ? AND user_id = ?", id, userID).First(&warranty).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWarrantyNotFound
+		}
+		return nil, fmt.Errorf("failed to get warranty: %w", err)
+	}
+	warranty.Status = warranty.StatusAt(now)
+	return &warranty, nil
+}
+
+// SubmitClaim opens a claim on an active warranty of the customer. A warranty has at most one open claim,
+// and when serials were recorded for its units the claim must name one of them.
+func (s *Service) SubmitClaim(warrantyID, userID string, req ClaimRequest, now time.Time) (*models.WarrantyClaim, error) {
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		return nil, fmt.Errorf("%w: a description of the fault is required", ErrInvalidClaim)
+	}
+	serial := strings.TrimSpace(req.Serial)
+
+	var claim models.WarrantyClaim
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var warranty models.Warranty
+		if err := tx.Preload("Serials").Where("id = ? AND user_id = ?", warrantyID, userID).First(&warranty).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrWarrantyNotFound
+			}
+			return fmt.Errorf("failed to get warranty: %w", err)
+		}
+		if warranty.StatusAt(now) == models.WarrantyExpired {
+			return ErrWarrantyExpired
+		}
+
+		if len(warranty.Serials) > 0 {
+			covered := false
+			for _, recorded := range warranty.Serials {
+				covered = covered || recorded.Serial == serial
+			}
+			if !covered {
+				return fmt.Errorf("%w: serial must be one of the units covered by this warranty", ErrInvalidClaim)
+			}
+		}
+
+		var open int64
+		if err := tx.Model(&models.WarrantyClaim{}).
+			Where("warranty_id = ? AND status IN ?", warranty.ID, openClaimStatuses).Count(&open).Error; err != nil {
+			return fmt.Errorf("failed to check open claims: %w", err)
+		}
+		if open > 0 {
+			return ErrClaimOpen
+		}
+
+		claim = models.WarrantyClaim{
+			WarrantyID:  warranty.ID,
+			UserID:      userID,
+			Serial:      serial,
+			Description: description,
+			Status:      models.WarrantyClaimSubmitted,
+		}
+		if err := tx.Create(&claim).Error; err != nil {
+			return fmt.Errorf("failed to submit warranty claim: %w", err)
+		}
+		event := models.WarrantyClaimEvent{ClaimID: claim.ID, Status: claim.Status, ActorID: userID}
+		if err := tx.Create(&event).Error; err != nil {
+			return fmt.Errorf("failed to record claim history: %w", err)
+		}
+		claim.Events = []models.WarrantyClaimEvent{event}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// ListMyClaims returns the customer's claims with their history, newest first
+func (s *Service) ListMyClaims(userID string) ([]models.WarrantyClaim, error) {
+	claims := []models.WarrantyClaim{}
+	if err := s.db.Preload("Warranty.Product").
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Where("user_id = ?", userID).Order("created_at DESC").Find(&claims).Error; err != nil {
+		return nil, fmt.Errorf("failed to get warranty claims: %w", err)
+	}
+	return claims, nil
+}
+
+// ListClaims returns claims for admins, oldest first so the longest-waiting claims are handled first,
+// optionally only those in one status
+func (s *Service) ListClaims(query ClaimQuery) ([]models.WarrantyClaim, int64, error) {
+	db := s.db.Model(&models.WarrantyClaim{})
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count warranty claims: %w", err)
+	}
+
+	claims := []models.WarrantyClaim{}
+	if err := db.Preload("Warranty.Product").Order("created_at ASC").
+		Limit(query.Limit).Offset((query.Page - 1) * query.Limit).Find(&claims).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get warranty claims: %w", err)
+	}
+	return claims, total, nil
+}
+
+// GetClaim returns a claim with its warranty, serials and history for admins
+func (s *Service) GetClaim(id string) (*models.WarrantyClaim, error) {
+	var claim models.WarrantyClaim
+	if err := s.db.Preload("Warranty.Product").Preload("Warranty.Serials").
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Where("id = ?", id).First(&claim).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClaimNotFound
+		}
+		return nil, fmt.Errorf("failed to get warranty claim: %w", err)
+	}
+	return &claim, nil
+}
+
+// UpdateClaimStatus moves a claim to its next status and records the change in its history. Claims go from
+// submitted to in_review, then to approved and finally resolved; they can be rejected until approved.
+func (s *Service) UpdateClaimStatus(id string, req ClaimStatusRequest, actorID string) (*models.WarrantyClaim, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var claim models.WarrantyClaim
+		if err := tx.Where("id = ?", id).First(&claim).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrClaimNotFound
+			}
+			return fmt.Errorf("failed to get warranty claim: %w", err)
+		}
+
+		allowed := false
+		for _, next := range claimTransitions[claim.Status] {
+			allowed = allowed || next == req.Status
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s claims cannot move to %s", ErrInvalidTransition, claim.Status, req.Status)
+		}
+
+		updates := map[string]interface{}{"status": req.Status}
+		if resolution := strings.TrimSpace(req.Resolution); resolution != "" {
+			updates["resolution"] = resolution
+		}
+		// Only move the claim if no other admin moved it meanwhile
+		result := tx.Model(&models.WarrantyClaim{}).Where("id = ? AND status = ?", claim.ID, claim.Status).Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update warranty claim: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: the claim was changed by someone else", ErrInvalidTransition)
+		}
+
+		event := models.WarrantyClaimEvent{ClaimID: claim.ID, Status: req.Status, Note: strings.TrimSpace(req.Note), ActorID: actorID}
+		if err := tx.Create(&event).Error; err != nil {
+			return fmt.Errorf("failed to record claim history: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetClaim(id)
+}
+
+// StartRegistrationSweeper periodically registers warranties of newly delivered items until ctx is cancelled
+func (s *Service) StartRegistrationSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if _, err := s.RegisterDueWarranties("", now); err != nil {
+					logger.Named("warranties").Warn("Failed to register warranties", map[string]interface{}{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,173 @@
+package warranties
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestService(t *testing.T) *Service {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	return NewService(database.GetDB())
+}
+
+// createProduct creates a product in a category with a warranty of months
+func createProduct(t *testing.T, months int) models.Product {
+	db := database.GetDB()
+	category := models.Category{Name: "Laptops", Slug: uuid.New().String(), WarrantyMonths: months}
+	require.NoError(t, db.Create(&category).Error)
+	product := models.Product{Name: "Laptop", SKU: uuid.New().String(), Price: 900, CategoryID: category.ID, IsActive: true}
+	require.NoError(t, db.Create(&product).Error)
+	return product
+}
+
+// createOrder creates an order of one unit of product for a new customer
+func createOrder(t *testing.T, product models.Product, status string, deliveredAt *time.Time) (models.Order, models.OrderItem) {
+	db := database.GetDB()
+	user := models.User{Email: uuid.New().String() + "@example.com", Password: "hashed", FirstName: "Sam", LastName: "Lee"}
+	require.NoError(t, db.Create(&user).Error)
+	order := models.Order{UserID: user.ID, Status: status, Subtotal: product.Price, Total: product.Price, DeliveredAt: deliveredAt}
+	require.NoError(t, db.Create(&order).Error)
+	item := models.OrderItem{OrderID: order.ID, ProductID: product.ID, Quantity: 1, Price: product.Price}
+	require.NoError(t, db.Create(&item).Error)
+	return order, item
+}
+
+func TestService_RegisterDueWarranties(t *testing.T) {
+	service := setupTestService(t)
+	db := database.GetDB()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	deliveredAt := now.AddDate(0, 0, -3)
+
+	covered := createProduct(t, 12)
+	uncovered := createProduct(t, 0)
+	delivered, item := createOrder(t, covered, "delivered", &deliveredAt)
+	createOrder(t, uncovered, "delivered", &deliveredAt)
+	createOrder(t, covered, "shipped", nil)
+	createOrder(t, covered, "refunded", &deliveredAt)
+
+	// Items of a split order are covered from the delivery of their own shipment
+	split, splitItem := createOrder(t, covered, "shipped", nil)
+	shipmentDelivered := now.AddDate(0, 0, -1)
+	shipment := models.Shipment{OrderID: split.ID, GroupKey: "home", Status: "delivered", DeliveredAt: &shipmentDelivered}
+	require.NoError(t, db.Create(&shipment).Error)
+	require.NoError(t, db.Model(&splitItem).Update("shipment_id", shipment.ID).Error)
+
+	registered, err := service.RegisterDueWarranties("", now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, registered)
+
+	registered, err = service.RegisterDueWarranties("", now)
+	require.NoError(t, err)
+	assert.Zero(t, registered)
+
+	warranties, err := service.ListWarranties(delivered.UserID, now)
+	require.NoError(t, err)
+	require.Len(t, warranties, 1)
+	assert.Equal(t, item.ID, warranties[0].OrderItemID)
+	assert.Equal(t, "Laptop", warranties[0].Product.Name)
+	assert.True(t, deliveredAt.Equal(warranties[0].StartsAt))
+	assert.True(t, deliveredAt.AddDate(1, 0, 0).Equal(warranties[0].ExpiresAt))
+	assert.Equal(t, models.WarrantyActive, warranties[0].Status)
+
+	warranties, err = service.ListWarranties(split.UserID, now)
+	require.NoError(t, err)
+	require.Len(t, warranties, 1)
+	assert.True(t, shipmentDelivered.Equal(warranties[0].StartsAt))
+
+	expired, err := service.GetWarranty(warranties[0].ID, split.UserID, now.AddDate(2, 0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, models.WarrantyExpired, expired.Status)
+
+	_, err = service.GetWarranty(warranties[0].ID, delivered.UserID, now)
+	assert.True(t, errors.Is(err, ErrWarrantyNotFound))
+}
+
+func TestService_ClaimWorkflow(t *testing.T) {
+	service := setupTestService(t)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	deliveredAt := now.AddDate(0, -1, 0)
+
+	order, _ := createOrder(t, createProduct(t, 12), "delivered", &deliveredAt)
+	warranties, err := service.ListWarranties(order.UserID, now)
+	require.NoError(t, err)
+	require.Len(t, warranties, 1)
+	warranty := warranties[0]
+
+	_, err = service.SubmitClaim(warranty.ID, order.UserID, ClaimRequest{Description: "  "}, now)
+	assert.True(t, errors.Is(err, ErrInvalidClaim))
+	_, err = service.SubmitClaim(warranty.ID, order.UserID, ClaimRequest{Description: "Screen flickers"}, now.AddDate(1, 0, 0))
+	assert.True(t, errors.Is(err, ErrWarrantyExpired))
+	_, err = service.SubmitClaim(warranty.ID, "someone-else", ClaimRequest{Description: "Screen flickers"}, now)
+	assert.True(t, errors.Is(err, ErrWarrantyNotFound))
+
+	claim, err := service.SubmitClaim(warranty.ID, order.UserID, ClaimRequest{Description: "Screen flickers"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, models.WarrantyClaimSubmitted, claim.Status)
+
+	_, err = service.SubmitClaim(warranty.ID, order.UserID, ClaimRequest{Description: "Still flickers"}, now)
+	assert.True(t, errors.Is(err, ErrClaimOpen))
+
+	_, err = service.UpdateClaimStatus(claim.ID, ClaimStatusRequest{Status: models.WarrantyClaimResolved}, "admin-1")
+	assert.True(t, errors.Is(err, ErrInvalidTransition))
+	_, err = service.UpdateClaimStatus("missing", ClaimStatusRequest{Status: models.WarrantyClaimInReview}, "admin-1")
+	assert.True(t, errors.Is(err, ErrClaimNotFound))
+
+	for _, status := range []string{models.WarrantyClaimInReview, models.WarrantyClaimApproved} {
+		claim, err = service.UpdateClaimStatus(claim.ID, ClaimStatusRequest{Status: status, Note: "checked"}, "admin-1")
+		require.NoError(t, err)
+	}
+	claim, err = service.UpdateClaimStatus(claim.ID, ClaimStatusRequest{Status: models.WarrantyClaimResolved, Resolution: "Screen replaced"}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.WarrantyClaimResolved, claim.Status)
+	assert.Equal(t, "Screen replaced", claim.Resolution)
+	require.Len(t, claim.Events, 4)
+	assert.Equal(t, order.UserID, claim.Events[0].ActorID)
+	assert.Equal(t, "admin-1", claim.Events[3].ActorID)
+
+	queue, total, err := service.ListClaims(ClaimQuery{Status: models.WarrantyClaimResolved, Page: 1, Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, queue, 1)
+	assert.Equal(t, "Laptop", queue[0].Warranty.Product.Name)
+
+	// A resolved claim no longer blocks a new one
+	_, err = service.SubmitClaim(warranty.ID, order.UserID, ClaimRequest{Description: "Keyboard failed"}, now)
+	require.NoError(t, err)
+	mine, err := service.ListMyClaims(order.UserID)
+	require.NoError(t, err)
+	assert.Len(t, mine, 2)
+}
+
+func TestService_ClaimSerial(t *testing.T) {
+	service := setupTestService(t)
+	db := database.GetDB()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	deliveredAt := now.AddDate(0, 0, -10)
+
+	product := createProduct(t, 24)
+	order, item := createOrder(t, product, "delivered", &deliveredAt)
+	require.NoError(t, db.Create(&models.OrderItemSerial{
+		OrderID: order.ID, OrderItemID: item.ID, ShipmentID: "shipment-1", ProductID: product.ID, Serial: "SN-100",
+	}).Error)
+
+	warranties, err := service.ListWarranties(order.UserID, now)
+	require.NoError(t, err)
+	require.Len(t, warranties, 1)
+	require.Len(t, warranties[0].Serials, 1)
+
+	_, err = service.SubmitClaim(warranties[0].ID, order.UserID, ClaimRequest{Serial: "SN-999", Description: "Dead on boot"}, now)
+	assert.True(t, errors.Is(err, ErrInvalidClaim))
+
+	claim, err := service.SubmitClaim(warranties[0].ID, order.UserID, ClaimRequest{Serial: " SN-100 ", Description: "Dead on boot"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "SN-100", claim.Serial)
+}
@@ -0,0 +1,174 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Store events endpoints can subscribe to
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderStatusChanged = "order.status_changed"
+	EventProductUpdated     = "product.updated"
+)
+
+// SupportedEvents lists every event type an endpoint can subscribe to
+var SupportedEvents = []string{EventOrderCreated, EventOrderStatusChanged, EventProductUpdated}
+
+// Envelope is the JSON body POSTed to an endpoint. ID is the delivery ID, also sent in the X-Webhook-Id
+// header, so receivers can drop redelivered events they have already handled.
+type Envelope struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// OrderData is the order an order event is about, as it is once the change is made
+type OrderData struct {
+	ID              string              `json:"id"`
+	UserID          string              `json:"userId"`
+	Status          string              `json:"status"`
+	PreviousStatus  string              `json:"previousStatus,omitempty"`
+	Subtotal        float64             `json:"subtotal"`
+	Tax             float64             `json:"tax"`
+	Shipping        float64             `json:"shipping"`
+	Total           float64             `json:"total"`
+	ShippingAddress models.OrderAddress `json:"shippingAddress"`
+	Items           []OrderItemData     `json:"items"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
+}
+
+// OrderItemData is an order line
+type OrderItemData struct {
+	ProductID string  `json:"productId"`
+	Quantity  int     `json:"quantity"`
+	Price     float64 `json:"price"`
+	Total     float64 `json:"total"`
+}
+
+// ProductData is the product a product event is about
+type ProductData struct {
+	ID             string    `json:"id"`
+	SKU            string    `json:"sku"`
+	Name           string    `json:"name"`
+	Price          float64   `json:"price"`
+	CompareAtPrice *float64  `json:"compareAtPrice,omitempty"`
+	Inventory      int       `json:"inventory"`
+	IsActive       bool      `json:"isActive"`
+	CategoryID     string    `json:"categoryId"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// PublishOrder queues order.created for a new order, or order.status_changed when previousStatus is set, for
+// every active endpoint subscribed to it. It must run in the transaction that makes the change: the order is
+// read as that transaction sees it, and nothing is sent for a change that is rolled back.
+func PublishOrder(tx *gorm.DB, orderID, previousStatus string) error {
+	event := EventOrderStatusChanged
+	if previousStatus == "" {
+		event = EventOrderCreated
+	}
+	endpoints, err := subscribers(tx, event)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+
+	var order models.Order
+	if err := tx.Preload("Items").First(&order, "id = ?", orderID).Error; err != nil {
+		return fmt.Errorf("failed to load order for webhooks: %w", err)
+	}
+	data := OrderData{
+		ID:              order.ID,
+		UserID:          order.UserID,
+		Status:          order.Status,
+		PreviousStatus:  previousStatus,
+		Subtotal:        order.Subtotal,
+		Tax:             order.Tax,
+		Shipping:        order.Shipping,
+		Total:           order.Total,
+		ShippingAddress: order.ShippingAddress,
+		Items:           make([]OrderItemData, 0, len(order.Items)),
+		CreatedAt:       order.CreatedAt,
+		UpdatedAt:       order.UpdatedAt,
+	}
+	for _, item := range order.Items {
+		data.Items = append(data.Items, OrderItemData{ProductID: item.ProductID, Quantity: item.Quantity, Price: item.Price, Total: item.Total})
+	}
+	return enqueue(tx, endpoints, event, data)
+}
+
+// PublishProduct queues product.updated for every active endpoint subscribed to it
+func PublishProduct(db *gorm.DB, product *models.Product) error {
+	endpoints, err := subscribers(db, EventProductUpdated)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+	return enqueue(db, endpoints, EventProductUpdated, ProductData{
+		ID:             product.ID,
+		SKU:            product.SKU,
+		Name:           product.Name,
+		Price:          product.Price,
+		CompareAtPrice: product.CompareAtPrice,
+		Inventory:      product.Inventory,
+		IsActive:       product.IsActive,
+		CategoryID:     product.CategoryID,
+		UpdatedAt:      product.UpdatedAt,
+	})
+}
+
+// subscribers returns the active endpoints subscribed to event
+func subscribers(db *gorm.DB, event string) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	if err := db.Where("is_active = ?", true).Find(&endpoints).Error; err != nil {
+		return nil, fmt.Errorf("failed to load webhook endpoints: %w", err)
+	}
+	subscribed := endpoints[:0]
+	for _, endpoint := range endpoints {
+		if subscribes(endpoint, event) {
+			subscribed = append(subscribed, endpoint)
+		}
+	}
+	return subscribed, nil
+}
+
+// subscribes reports whether the endpoint receives event
+func subscribes(endpoint models.WebhookEndpoint, event string) bool {
+	for _, subscribed := range endpoint.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue stores a pending delivery of the event for each endpoint; the sweeper sends it on its next pass
+func enqueue(db *gorm.DB, endpoints []models.WebhookEndpoint, event string, data interface{}) error {
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		id := uuid.New().String()
+		body, err := json.Marshal(Envelope{ID: id, Event: event, CreatedAt: now, Data: data})
+		if err != nil {
+			return fmt.Errorf("failed to encode %s webhook: %w", event, err)
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			ID:            id,
+			EndpointID:    endpoint.ID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	if err := db.Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to queue %s webhooks: %w", event, err)
+	}
+	return nil
+}
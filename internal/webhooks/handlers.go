@@ -0,0 +1,119 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the admin webhook endpoints
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new webhook handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// endpointError writes the response for an endpoint operation that failed
+func endpointError(c *gin.Context, err error, code, message string) {
+	switch {
+	case errors.Is(err, ErrEndpointNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "WEBHOOK_ENDPOINT_NOT_FOUND", "Webhook endpoint not found", nil)
+	case errors.Is(err, ErrInvalidURL), errors.Is(err, ErrUnsupportedEvent):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error(), gin.H{"supportedEvents": SupportedEvents})
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, code, message, err.Error())
+	}
+}
+
+// ListEndpoints handles GET /api/admin/webhooks (admin only)
+func (h *Handler) ListEndpoints(c *gin.Context) {
+	endpoints, err := h.service.ListEndpoints()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "GET_WEBHOOKS_FAILED", "Failed to get webhook endpoints", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Webhook endpoints retrieved successfully", gin.H{"endpoints": endpoints, "supportedEvents": SupportedEvents})
+}
+
+// CreateEndpoint handles POST /api/admin/webhooks (admin only)
+func (h *Handler) CreateEndpoint(c *gin.Context) {
+	var req EndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	endpoint, err := h.service.CreateEndpoint(req)
+	if err != nil {
+		endpointError(c, err, "CREATE_WEBHOOK_FAILED", "Failed to create webhook endpoint")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusCreated, "Webhook endpoint created successfully; store the secret now, it will not be shown again", endpoint)
+}
+
+// UpdateEndpoint handles PUT /api/admin/webhooks/:id (admin only)
+func (h *Handler) UpdateEndpoint(c *gin.Context) {
+	var req EndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	endpoint, err := h.service.UpdateEndpoint(c.Param("id"), req)
+	if err != nil {
+		endpointError(c, err, "UPDATE_WEBHOOK_FAILED", "Failed to update webhook endpoint")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Webhook endpoint updated successfully", endpoint)
+}
+
+// DeleteEndpoint handles DELETE /api/admin/webhooks/:id (admin only)
+func (h *Handler) DeleteEndpoint(c *gin.Context) {
+	if err := h.service.DeleteEndpoint(c.Param("id")); err != nil {
+		endpointError(c, err, "DELETE_WEBHOOK_FAILED", "Failed to delete webhook endpoint")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Webhook endpoint deleted successfully", nil)
+}
+
+// RotateSecret handles POST /api/admin/webhooks/:id/rotate-secret (admin only)
+func (h *Handler) RotateSecret(c *gin.Context) {
+	endpoint, err := h.service.RotateSecret(c.Param("id"))
+	if err != nil {
+		endpointError(c, err, "ROTATE_WEBHOOK_SECRET_FAILED", "Failed to rotate webhook secret")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Webhook secret rotated successfully; store it now, it will not be shown again", endpoint)
+}
+
+// ListDeliveries handles GET /api/admin/webhooks/:id/deliveries (admin only), optionally in one status
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	page, limit := utils.PageParams(c)
+
+	deliveries, total, err := h.service.ListDeliveries(c.Param("id"), c.Query("status"), page, limit)
+	if err != nil {
+		endpointError(c, err, "GET_WEBHOOK_DELIVERIES_FAILED", "Failed to get webhook deliveries")
+		return
+	}
+	utils.PaginatedResponse(c, http.StatusOK, "Webhook deliveries retrieved successfully", "deliveries", deliveries, utils.NewPagination(page, limit, total))
+}
+
+// Redeliver handles POST /api/admin/webhooks/:id/deliveries/:deliveryId/redeliver (admin only)
+func (h *Handler) Redeliver(c *gin.Context) {
+	delivery, err := h.service.Redeliver(c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "WEBHOOK_DELIVERY_NOT_FOUND", "Webhook delivery not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "REDELIVER_WEBHOOK_FAILED", "Failed to queue webhook redelivery", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusAccepted, "Webhook delivery queued", delivery)
+}
@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Endpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(setupTestService(t))
+	r := gin.New()
+	r.GET("/webhooks", handler.ListEndpoints)
+	r.POST("/webhooks", handler.CreateEndpoint)
+	r.PUT("/webhooks/:id", handler.UpdateEndpoint)
+	r.DELETE("/webhooks/:id", handler.DeleteEndpoint)
+	r.GET("/webhooks/:id/deliveries", handler.ListDeliveries)
+
+	t.Run("unsupported event is rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url":"https://erp.example.com/hooks","events":["order.deleted"]}`)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "supportedEvents")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url":"https://erp.example.com/hooks","events":["order.created"]}`)))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Data EndpointWithSecret `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Data.Secret)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), created.Data.ID)
+	assert.NotContains(t, w.Body.String(), created.Data.Secret, "the secret is only shown on creation")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/webhooks/"+created.Data.ID, strings.NewReader(`{"url":"https://erp.example.com/v2/hooks","events":["order.created","product.updated"],"isActive":false}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"isActive":false`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/"+created.Data.ID+"/deliveries", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/webhooks/"+created.Data.ID, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/webhooks/"+created.Data.ID, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "WEBHOOK_ENDPOINT_NOT_FOUND")
+}
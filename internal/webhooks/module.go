@@ -0,0 +1,49 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sweepInterval is how often the sweeper sends due deliveries
+const sweepInterval = 5 * time.Second
+
+// Module wires outbound webhooks into the application. Other modules publish events with PublishOrder and
+// PublishProduct; this module sends them.
+type Module struct {
+	service     *Service
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the webhooks module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB)
+	return &Module{service: service, handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "webhooks"
+}
+
+// Models returns the webhook endpoint and delivery tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.WebhookEndpoint{}, &models.WebhookDelivery{}}
+}
+
+// StartJobs sends queued deliveries in the background
+func (m *Module) StartJobs(ctx context.Context) {
+	m.service.StartSweeper(ctx, sweepInterval)
+}
+
+// RegisterAPIRoutes sets up the admin webhook routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	SetupRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,23 @@
+package webhooks
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes sets up the admin webhook endpoint routes
+func SetupRoutes(api *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	admin := api.Group("/admin/webhooks")
+	admin.Use(authService.AuthMiddleware())
+	admin.Use(authService.AdminMiddleware())
+	{
+		admin.GET("", handler.ListEndpoints)
+		admin.POST("", handler.CreateEndpoint)
+		admin.PUT("/:id", handler.UpdateEndpoint)
+		admin.DELETE("/:id", handler.DeleteEndpoint)
+		admin.POST("/:id/rotate-secret", handler.RotateSecret)
+		admin.GET("/:id/deliveries", handler.ListDeliveries)
+		admin.POST("/:id/deliveries/:deliveryId/redeliver", handler.Redeliver)
+	}
+}
@@ -0,0 +1,414 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxAttempts is how often a delivery is sent before it is marked failed
+	MaxAttempts = 8
+	// baseBackoff is the delay before the first retry; each further retry doubles it up to maxBackoff
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+	// claimTimeout is how long a claimed delivery waits before another pass may send it again, in case the
+	// instance sending it stops before recording the outcome
+	claimTimeout = time.Minute
+	// requestTimeout bounds a single POST to an endpoint
+	requestTimeout = 10 * time.Second
+	// sendBatch caps the deliveries one sweeper pass sends
+	sendBatch = 50
+	// responseExcerpt caps the bytes of a failed response kept as the delivery error
+	responseExcerpt = 512
+	// secretPrefix marks signing secrets so they are recognisable in receiver configs
+	secretPrefix = "whsec_"
+)
+
+// Request headers sent with every delivery. The signature is the hex HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with the endpoint secret, prefixed with "sha256=".
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrUnsupportedEvent = errors.New("unsupported webhook event")
+	ErrInvalidURL       = errors.New("webhook URL must be an absolute http or https URL")
+)
+
+// EndpointRequest registers or changes an endpoint
+type EndpointRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=500"`
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events" binding:"required,min=1"`
+	IsActive    *bool    `json:"isActive"`
+}
+
+// EndpointWithSecret is returned when an endpoint is created or its secret rotated, the only times the signing
+// secret is visible
+type EndpointWithSecret struct {
+	models.WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+// ServiceInterface defines the webhook operations used by the admin handlers
+type ServiceInterface interface {
+	ListEndpoints() ([]models.WebhookEndpoint, error)
+	CreateEndpoint(req EndpointRequest) (*EndpointWithSecret, error)
+	UpdateEndpoint(id string, req EndpointRequest) (*models.WebhookEndpoint, error)
+	DeleteEndpoint(id string) error
+	RotateSecret(id string) (*EndpointWithSecret, error)
+	ListDeliveries(endpointID, status string, page, limit int) ([]models.WebhookDelivery, int64, error)
+	Redeliver(endpointID, deliveryID string) (*models.WebhookDelivery, error)
+}
+
+// Service manages webhook endpoints and sends their deliveries
+type Service struct {
+	db     *gorm.DB
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewService creates a webhook service
+func NewService(db *gorm.DB) *Service {
+	return &Service{
+		db:     db,
+		client: &http.Client{Timeout: requestTimeout},
+		now:    time.Now,
+	}
+}
+
+// validate checks the URL and event types of an endpoint request
+func (req EndpointRequest) validate() error {
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidURL
+	}
+	for _, event := range req.Events {
+		if !supported(event) {
+			return fmt.Errorf("%w: %s", ErrUnsupportedEvent, event)
+		}
+	}
+	return nil
+}
+
+// supported reports whether event is one endpoints can subscribe to
+func supported(event string) bool {
+	for _, known := range SupportedEvents {
+		if known == event {
+			return true
+		}
+	}
+	return false
+}
+
+// newSecret generates an endpoint signing secret
+func newSecret() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return secretPrefix + hex.EncodeToString(raw), nil
+}
+
+// ListEndpoints returns every endpoint, newest first
+func (s *Service) ListEndpoints() ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	if err := s.db.Order("created_at DESC").Find(&endpoints).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// endpoint returns the endpoint with the given ID
+func (s *Service) endpoint(id string) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := s.db.First(&endpoint, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return &endpoint, nil
+}
+
+// CreateEndpoint registers an endpoint with a new signing secret. Endpoints are active unless the request
+// says otherwise.
+func (s *Service) CreateEndpoint(req EndpointRequest) (*EndpointWithSecret, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	endpoint := models.WebhookEndpoint{
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      models.StringArray(req.Events),
+		Secret:      secret,
+		IsActive:    true,
+	}
+	if err := s.db.Create(&endpoint).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	// is_active defaults to true in the column, so an inactive endpoint is stored by an update after creating it
+	if req.IsActive != nil && !*req.IsActive {
+		if err := s.db.Model(&endpoint).Update("is_active", false).Error; err != nil {
+			return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+		}
+	}
+	return &EndpointWithSecret{WebhookEndpoint: endpoint, Secret: secret}, nil
+}
+
+// UpdateEndpoint changes the URL, description and events of an endpoint and, when given, whether it is active.
+// Deliveries already queued keep going to the endpoint's new URL.
+func (s *Service) UpdateEndpoint(id string, req EndpointRequest) (*models.WebhookEndpoint, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	endpoint, err := s.endpoint(id)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.URL = req.URL
+	endpoint.Description = req.Description
+	endpoint.Events = models.StringArray(req.Events)
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+	if err := s.db.Save(endpoint).Error; err != nil {
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// DeleteEndpoint removes an endpoint with its delivery history
+func (s *Service) DeleteEndpoint(id string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.WebhookEndpoint{}, "id = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete webhook endpoint: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrEndpointNotFound
+		}
+		if err := tx.Where("endpoint_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+		return nil
+	})
+}
+
+// RotateSecret replaces the signing secret of an endpoint. Deliveries sent from then on, including retries of
+// earlier events, are signed with the new secret.
+func (s *Service) RotateSecret(id string) (*EndpointWithSecret, error) {
+	endpoint, err := s.endpoint(id)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(endpoint).Update("secret", secret).Error; err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+	return &EndpointWithSecret{WebhookEndpoint: *endpoint, Secret: secret}, nil
+}
+
+// ListDeliveries returns the deliveries of an endpoint, optionally in one status, newest first
+func (s *Service) ListDeliveries(endpointID, status string, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.endpoint(endpointID); err != nil {
+		return nil, 0, err
+	}
+	query := s.db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch webhook deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// Redeliver queues a delivery to be sent again on the next sweeper pass with a fresh set of attempts.
+// The payload is unchanged, so the receiver sees the same event ID.
+func (s *Service) Redeliver(endpointID, deliveryID string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := s.db.First(&delivery, "id = ? AND endpoint_id = ?", deliveryID, endpointID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	now := s.now()
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	delivery.LastError = ""
+	if err := s.db.Save(&delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue webhook redelivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// SendDue sends the pending deliveries whose next attempt is due and returns how many were attempted.
+// Each delivery is claimed before it is sent so instances sweeping at the same time never send it twice.
+func (s *Service) SendDue(ctx context.Context) (int, error) {
+	now := s.now()
+	var due []models.WebhookDelivery
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC").Limit(sendBatch).Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to load due webhook deliveries: %w", err)
+	}
+
+	sent := 0
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		claimedUntil := now.Add(claimTimeout)
+		result := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND attempts = ?", delivery.ID, models.WebhookDeliveryPending, delivery.Attempts).
+			Updates(map[string]interface{}{"attempts": delivery.Attempts + 1, "next_attempt_at": claimedUntil})
+		if result.Error != nil {
+			return sent, fmt.Errorf("failed to claim webhook delivery: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		delivery.Attempts++
+		if err := s.attempt(ctx, &delivery); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// attempt sends a claimed delivery and records the outcome: delivered, retried after a backoff, or failed
+// once its attempts are used up or its endpoint is gone
+func (s *Service) attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	var status int
+	var sendErr error
+	endpoint, err := s.endpoint(delivery.EndpointID)
+	switch {
+	case errors.Is(err, ErrEndpointNotFound):
+		sendErr = ErrEndpointNotFound
+	case err != nil:
+		return err
+	case !endpoint.IsActive:
+		sendErr = errors.New("webhook endpoint is disabled")
+	default:
+		status, sendErr = s.post(ctx, endpoint, delivery)
+	}
+
+	updates := map[string]interface{}{"response_status": status}
+	fields := map[string]interface{}{"delivery_id": delivery.ID, "endpoint_id": delivery.EndpointID, "event": delivery.Event, "attempt": delivery.Attempts}
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.WebhookDeliveryDelivered
+		updates["delivered_at"] = s.now()
+		updates["next_attempt_at"] = nil
+		updates["last_error"] = ""
+	case delivery.Attempts >= MaxAttempts || endpoint == nil || !endpoint.IsActive:
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["next_attempt_at"] = nil
+		updates["last_error"] = sendErr.Error()
+		fields["error"] = sendErr.Error()
+		logger.Named("webhooks").Warn("Webhook delivery failed", fields)
+	default:
+		retryAt := s.now().Add(utils.Backoff(delivery.Attempts, baseBackoff, maxBackoff))
+		updates["next_attempt_at"] = retryAt
+		updates["last_error"] = sendErr.Error()
+		fields["error"] = sendErr.Error()
+		fields["retry_at"] = retryAt
+		logger.Named("webhooks").Info("Webhook delivery failed, retrying", fields)
+	}
+	if err := s.db.Model(delivery).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// post sends the delivery payload to the endpoint, signed with the endpoint secret. Any 2xx response
+// is a success; the response status is returned whenever the endpoint answered.
+func (s *Service) post(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(endpoint.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, responseExcerpt))
+	return resp.StatusCode, fmt.Errorf("endpoint responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(excerpt)))
+}
+
+// Sign returns the hex HMAC-SHA256 signature of a delivery body sent at timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// StartSweeper sends due deliveries every interval until ctx is cancelled
+func (s *Service) StartSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.SendDue(ctx); err != nil && ctx.Err() == nil {
+					logger.Named("webhooks").Warn("Failed to send webhook deliveries", map[string]interface{}{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce-website/internal/config"
+	"ecommerce-website/internal/database"
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestService(t *testing.T) *Service {
+	require.NoError(t, database.InitializeTest(&config.Config{}))
+	return NewService(database.GetDB())
+}
+
+// createOrder stores a pending order with one line
+func createOrder(t *testing.T) models.Order {
+	db := database.GetDB()
+	order := models.Order{UserID: "user-1", Status: "pending", Subtotal: 20, Total: 20}
+	require.NoError(t, db.Create(&order).Error)
+	require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: "product-1", Quantity: 2, Price: 10, Total: 20}).Error)
+	return order
+}
+
+// receiver is a webhook endpoint that answers with status and remembers the requests it got
+type receiver struct {
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	w.WriteHeader(r.status)
+}
+
+func TestService_CreateEndpointValidation(t *testing.T) {
+	service := setupTestService(t)
+
+	_, err := service.CreateEndpoint(EndpointRequest{URL: "ftp://erp.example.com/hooks", Events: []string{EventOrderCreated}})
+	assert.ErrorIs(t, err, ErrInvalidURL)
+
+	_, err = service.CreateEndpoint(EndpointRequest{URL: "https://erp.example.com/hooks", Events: []string{"order.deleted"}})
+	assert.ErrorIs(t, err, ErrUnsupportedEvent)
+
+	inactive := false
+	created, err := service.CreateEndpoint(EndpointRequest{URL: "https://erp.example.com/hooks", Events: []string{EventOrderCreated}, IsActive: &inactive})
+	require.NoError(t, err)
+	assert.Contains(t, created.Secret, secretPrefix)
+
+	endpoints, err := service.ListEndpoints()
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	assert.False(t, endpoints[0].IsActive)
+
+	rotated, err := service.RotateSecret(created.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, created.Secret, rotated.Secret)
+}
+
+func TestService_DeliversSignedEvents(t *testing.T) {
+	service := setupTestService(t)
+	ctx := context.Background()
+	erp := &receiver{status: http.StatusOK}
+	server := httptest.NewServer(erp)
+	defer server.Close()
+
+	subscribed, err := service.CreateEndpoint(EndpointRequest{URL: server.URL, Events: []string{EventOrderCreated, EventOrderStatusChanged}})
+	require.NoError(t, err)
+	_, err = service.CreateEndpoint(EndpointRequest{URL: server.URL + "/catalog", Events: []string{EventProductUpdated}})
+	require.NoError(t, err)
+
+	order := createOrder(t)
+	require.NoError(t, PublishOrder(database.GetDB(), order.ID, ""))
+
+	sent, err := service.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "only the subscribed endpoint gets the event")
+	require.Len(t, erp.requests, 1)
+
+	req, body := erp.requests[0], erp.bodies[0]
+	assert.Equal(t, EventOrderCreated, req.Header.Get(HeaderEvent))
+	assert.Equal(t, "sha256="+Sign(subscribed.Secret, req.Header.Get(HeaderTimestamp), body), req.Header.Get(HeaderSignature))
+
+	var envelope struct {
+		ID    string    `json:"id"`
+		Event string    `json:"event"`
+		Data  OrderData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &envelope))
+	assert.Equal(t, req.Header.Get(HeaderID), envelope.ID)
+	assert.Equal(t, order.ID, envelope.Data.ID)
+	require.Len(t, envelope.Data.Items, 1)
+	assert.Equal(t, 2, envelope.Data.Items[0].Quantity)
+
+	deliveries, total, err := service.ListDeliveries(subscribed.ID, models.WebhookDeliveryDelivered, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, http.StatusOK, deliveries[0].ResponseStatus)
+
+	sent, err = service.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "delivered events are not sent again")
+}
+
+func TestService_RetriesWithBackoff(t *testing.T) {
+	service := setupTestService(t)
+	ctx := context.Background()
+	erp := &receiver{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(erp)
+	defer server.Close()
+
+	endpoint, err := service.CreateEndpoint(EndpointRequest{URL: server.URL, Events: []string{EventOrderStatusChanged}})
+	require.NoError(t, err)
+	order := createOrder(t)
+	require.NoError(t, PublishOrder(database.GetDB(), order.ID, "pending"))
+
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	_, err = service.SendDue(ctx)
+	require.NoError(t, err)
+
+	deliveries, _, err := service.ListDeliveries(endpoint.ID, "", 1, 20)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.ResponseStatus)
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.WithinDuration(t, now.Add(baseBackoff), *delivery.NextAttemptAt, time.Second)
+
+	sent, err := service.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "the retry waits for its backoff")
+
+	for attempt := 2; attempt <= MaxAttempts; attempt++ {
+		now = now.Add(maxBackoff)
+		sent, err := service.SendDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+	}
+	assert.Len(t, erp.requests, MaxAttempts)
+	for _, body := range erp.bodies {
+		assert.Equal(t, erp.bodies[0], body, "every attempt sends the same payload")
+	}
+
+	deliveries, _, err = service.ListDeliveries(endpoint.ID, models.WebhookDeliveryFailed, 1, 20)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Contains(t, deliveries[0].LastError, "503")
+
+	erp.status = http.StatusNoContent
+	_, err = service.Redeliver(endpoint.ID, deliveries[0].ID)
+	require.NoError(t, err)
+	sent, err = service.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	_, total, err := service.ListDeliveries(endpoint.ID, models.WebhookDeliveryDelivered, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
@@ -0,0 +1,239 @@
+package wishlist
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"ecommerce-website/internal/logger"
+	"ecommerce-website/internal/models"
+	"ecommerce-website/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new wishlist handler
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// AddRequest represents the request body for saving a product to the wishlist
+type AddRequest struct {
+	ProductID string `json:"productId" binding:"required"`
+}
+
+// MoveToCartRequest represents the optional request body for moving a wishlist item to the cart
+type MoveToCartRequest struct {
+	Quantity int `json:"quantity" binding:"omitempty,min=1"`
+}
+
+// Wishlist change operations reported in a Delta
+const (
+	OpAdd        = "add"
+	OpRemove     = "remove"
+	OpMoveToCart = "move_to_cart"
+)
+
+// State is a wishlist with the revision that mutations are checked against
+type State struct {
+	Items    []models.WishlistItem `json:"items"`
+	Revision int64                 `json:"revision"`
+}
+
+// Delta describes the change a wishlist mutation applied
+type Delta struct {
+	Op        string `json:"op"`
+	ProductID string `json:"productId"`
+}
+
+// MutationResult is the response to a wishlist mutation: the wishlist at its new revision and the change
+// that produced it. Item is the saved item for an add; Cart is the updated cart for a move to cart.
+type MutationResult struct {
+	State
+	Delta Delta                `json:"delta"`
+	Item  *models.WishlistItem `json:"item,omitempty"`
+	Cart  *models.Cart         `json:"cart,omitempty"`
+}
+
+// GetWishlist handles GET /api/wishlist
+func (h *Handler) GetWishlist(c *gin.Context) {
+	state, err := h.state(h.owner(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "WISHLIST_FETCH_ERROR", "Failed to get wishlist", err.Error())
+		return
+	}
+
+	utils.SetRevision(c, state.Revision)
+	utils.SuccessResponse(c, http.StatusOK, "Wishlist retrieved successfully", state)
+}
+
+// AddItem handles POST /api/wishlist
+func (h *Handler) AddItem(c *gin.Context) {
+	var req AddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	owner := h.owner(c)
+	if !h.checkRevision(c, owner) {
+		return
+	}
+
+	item, err := h.service.Add(owner, req.ProductID)
+	if err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "WISHLIST_ADD_ERROR", "Failed to add item to wishlist", err.Error())
+		return
+	}
+
+	h.respondMutation(c, owner, "Item added to wishlist successfully", MutationResult{
+		Delta: Delta{Op: OpAdd, ProductID: req.ProductID},
+		Item:  item,
+	})
+}
+
+// RemoveItem handles DELETE /api/wishlist/:productId
+func (h *Handler) RemoveItem(c *gin.Context) {
+	owner := h.owner(c)
+	if !h.checkRevision(c, owner) {
+		return
+	}
+
+	if err := h.service.Remove(owner, c.Param("productId")); err != nil {
+		if errors.Is(err, ErrItemNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "WISHLIST_ITEM_NOT_FOUND", "Product is not in the wishlist", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "WISHLIST_REMOVE_ERROR", "Failed to remove item from wishlist", err.Error())
+		return
+	}
+
+	h.respondMutation(c, owner, "Item removed from wishlist successfully", MutationResult{
+		Delta: Delta{Op: OpRemove, ProductID: c.Param("productId")},
+	})
+}
+
+// MoveToCart handles POST /api/wishlist/:productId/move-to-cart
+func (h *Handler) MoveToCart(c *gin.Context) {
+	var req MoveToCartRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+			return
+		}
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	owner := h.owner(c)
+	if !h.checkRevision(c, owner) {
+		return
+	}
+
+	cart, err := h.service.MoveToCart(c.Request.Context(), owner, c.Param("productId"), req.Quantity)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case errors.Is(err, ErrItemNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "WISHLIST_ITEM_NOT_FOUND", "Product is not in the wishlist", nil)
+		case errMsg == "product is not available":
+			utils.ErrorResponse(c, http.StatusBadRequest, "PRODUCT_NOT_AVAILABLE", "Product is not available", errMsg)
+		case strings.Contains(errMsg, "insufficient inventory"):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INSUFFICIENT_INVENTORY", errMsg, errMsg)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "WISHLIST_MOVE_ERROR", "Failed to move item to cart", errMsg)
+		}
+		return
+	}
+
+	h.respondMutation(c, owner, "Item moved to cart successfully", MutationResult{
+		Delta: Delta{Op: OpMoveToCart, ProductID: c.Param("productId")},
+		Cart:  cart,
+	})
+}
+
+// state returns the owner's wishlist and its revision
+func (h *Handler) state(owner Owner) (State, error) {
+	revision, err := h.service.Revision(owner)
+	if err != nil {
+		return State{}, err
+	}
+	items, err := h.service.List(owner)
+	if err != nil {
+		return State{}, err
+	}
+	return State{Items: items, Revision: revision}, nil
+}
+
+// checkRevision rejects a mutation whose If-Match revision is not the wishlist's current one with 409 and the
+// current wishlist. Mutations without If-Match are applied to whatever the wishlist is.
+func (h *Handler) checkRevision(c *gin.Context, owner Owner) bool {
+	expected, hasExpected, err := utils.ExpectedRevision(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REVISION", "Invalid revision", err.Error())
+		return false
+	}
+	if !hasExpected {
+		return true
+	}
+
+	state, err := h.state(owner)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "WISHLIST_FETCH_ERROR", "Failed to get wishlist", err.Error())
+		return false
+	}
+	if state.Revision != expected {
+		utils.SetRevision(c, state.Revision)
+		utils.ErrorResponse(c, http.StatusConflict, "STALE_REVISION", ErrStaleRevision.Error(), state)
+		return false
+	}
+	return true
+}
+
+// respondMutation fills result with the wishlist after the change and sends it
+func (h *Handler) respondMutation(c *gin.Context, owner Owner, message string, result MutationResult) {
+	state, err := h.state(owner)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "WISHLIST_FETCH_ERROR", "Failed to get wishlist", err.Error())
+		return
+	}
+	result.State = state
+
+	utils.SetRevision(c, state.Revision)
+	utils.SuccessResponse(c, http.StatusOK, message, result)
+}
+
+// owner resolves the wishlist owner of a request. Once a guest signs in, the items saved under
+// their session are merged into their account.
+func (h *Handler) owner(c *gin.Context) Owner {
+	owner := Owner{UserID: c.GetString("user_id"), SessionID: h.getOrCreateSessionID(c)}
+	if owner.UserID != "" {
+		if _, err := h.service.MergeGuest(owner.SessionID, owner.UserID); err != nil {
+			logger.Named("wishlist").Warn("Failed to merge guest wishlist", map[string]interface{}{
+				"user_id": owner.UserID,
+				"error":   err.Error(),
+			})
+		}
+	}
+	return owner
+}
+
+// getOrCreateSessionID gets the session ID from the cookie shared with the cart, creating one if missing
+func (h *Handler) getOrCreateSessionID(c *gin.Context) string {
+	sessionID, err := c.Cookie("session_id")
+	if err != nil || sessionID == "" {
+		sessionID = uuid.New().String()
+		c.SetCookie("session_id", sessionID, 86400, "/", "", false, true)
+	}
+	return sessionID
+}
@@ -0,0 +1,65 @@
+package wishlist
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_RevisionedMutations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, service, _ := setupWishlistTest(t)
+	mug := createWishlistProduct(t, db, "MUG")
+	lamp := createWishlistProduct(t, db, "LAMP")
+
+	handler := NewHandler(service)
+	router := gin.New()
+	router.GET("/wishlist", handler.GetWishlist)
+	router.POST("/wishlist", handler.AddItem)
+
+	send := func(method, body, ifMatch string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(method, "/wishlist", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "guest-session"})
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	w, response := send("POST", `{"productId":"`+mug.ID+`"}`, "0")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	data := response["data"].(map[string]interface{})
+	assert.EqualValues(t, 1, data["revision"])
+	assert.Len(t, data["items"], 1)
+	assert.Equal(t, map[string]interface{}{"op": OpAdd, "productId": mug.ID}, data["delta"])
+	assert.NotNil(t, data["item"])
+
+	// A change based on the earlier revision is rejected with the current wishlist
+	w, response = send("POST", `{"productId":"`+lamp.ID+`"}`, "0")
+	require.Equal(t, http.StatusConflict, w.Code)
+	errorDetail := response["error"].(map[string]interface{})
+	assert.Equal(t, "STALE_REVISION", errorDetail["code"])
+	current := errorDetail["details"].(map[string]interface{})
+	assert.EqualValues(t, 1, current["revision"])
+	assert.Len(t, current["items"], 1)
+
+	w, _ = send("POST", `{"productId":"`+lamp.ID+`"}`, "not-a-revision")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, response = send("GET", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.EqualValues(t, 1, response["data"].(map[string]interface{})["revision"])
+}
@@ -0,0 +1,37 @@
+package wishlist
+
+import (
+	"ecommerce-website/internal/app"
+	"ecommerce-website/internal/auth"
+	"ecommerce-website/internal/cart"
+	"ecommerce-website/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module wires saved-for-later wishlists into the application
+type Module struct {
+	handler     *Handler
+	authService *auth.Service
+}
+
+// NewModule creates the wishlist module
+func NewModule(deps app.Deps) app.Module {
+	service := NewService(deps.DB, cart.NewService())
+	return &Module{handler: NewHandler(service), authService: deps.Auth}
+}
+
+// Name returns the module name
+func (m *Module) Name() string {
+	return "wishlist"
+}
+
+// Models returns the wishlist tables
+func (m *Module) Models() []interface{} {
+	return []interface{}{&models.WishlistItem{}, &models.WishlistRevision{}}
+}
+
+// RegisterAPIRoutes sets up the wishlist routes
+func (m *Module) RegisterAPIRoutes(api *gin.RouterGroup, version string) {
+	RegisterRoutes(api, m.handler, m.authService)
+}
@@ -0,0 +1,20 @@
+package wishlist
+
+import (
+	"ecommerce-website/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registers wishlist routes. Guests and signed-in users share the endpoints;
+// a valid token switches to the user's wishlist.
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler, authService *auth.Service) {
+	wishlistGroup := router.Group("/wishlist")
+	wishlistGroup.Use(authService.OptionalAuthMiddleware())
+	{
+		wishlistGroup.GET("", handler.GetWishlist)
+		wishlistGroup.POST("", handler.AddItem)
+		wishlistGroup.DELETE("/:productId", handler.RemoveItem)
+		wishlistGroup.POST("/:productId/move-to-cart", handler.MoveToCart)
+	}
+}
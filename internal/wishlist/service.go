@@ -0,0 +1,263 @@
+package wishlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-website/internal/cart"
+	"ecommerce-website/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNoOwner         = errors.New("wishlist owner is required")
+	ErrProductNotFound = errors.New("product not found")
+	ErrItemNotFound    = errors.New("product is not in the wishlist")
+	ErrStaleRevision   = errors.New("wishlist has changed since the revision the change was based on")
+)
+
+// Owner identifies whose wishlist is used: the signed-in user when UserID is set, otherwise the
+// guest session. Items are moved into the same owner's cart.
+type Owner struct {
+	UserID    string
+	SessionID string
+}
+
+// key identifies the owner's wishlist revision
+func (o Owner) key() string {
+	if o.UserID != "" {
+		return "user:" + o.UserID
+	}
+	return "session:" + o.SessionID
+}
+
+// ServiceInterface defines the interface for the wishlist service
+type ServiceInterface interface {
+	List(owner Owner) ([]models.WishlistItem, error)
+	Revision(owner Owner) (int64, error)
+	Add(owner Owner, productID string) (*models.WishlistItem, error)
+	Remove(owner Owner, productID string) error
+	MoveToCart(ctx context.Context, owner Owner, productID string, quantity int) (*models.Cart, error)
+	MergeGuest(sessionID, userID string) (int, error)
+}
+
+type Service struct {
+	db   *gorm.DB
+	cart cart.ServiceInterface
+}
+
+// NewService creates a new wishlist service. Items are stored in the database so they outlive
+// the guest session; moving an item to the cart goes through the cart service.
+func NewService(db *gorm.DB, cartService cart.ServiceInterface) *Service {
+	return &Service{db: db, cart: cartService}
+}
+
+// scope restricts a query to the owner's items
+func (s *Service) scope(owner Owner) (*gorm.DB, error) {
+	return ownedBy(s.db, owner)
+}
+
+// ownedBy restricts a query on db, which may be a transaction, to the owner's items
+func ownedBy(db *gorm.DB, owner Owner) (*gorm.DB, error) {
+	switch {
+	case owner.UserID != "":
+		return db.Where("user_id = ?", owner.UserID), nil
+	case owner.SessionID != "":
+		return db.Where("session_id = ? AND user_id IS NULL", owner.SessionID), nil
+	default:
+		return nil, ErrNoOwner
+	}
+}
+
+// List returns the owner's wishlist, newest first
+func (s *Service) List(owner Owner) ([]models.WishlistItem, error) {
+	query, err := s.scope(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []models.WishlistItem{}
+	if err := query.Preload("Product").Order("created_at DESC").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch wishlist: %w", err)
+	}
+	return items, nil
+}
+
+// Revision returns the owner's wishlist revision, which goes up by one with every change; an untouched
+// wishlist is at revision 0
+func (s *Service) Revision(owner Owner) (int64, error) {
+	if _, err := s.scope(owner); err != nil {
+		return 0, err
+	}
+	var revisions []models.WishlistRevision
+	if err := s.db.Where("owner = ?", owner.key()).Limit(1).Find(&revisions).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch wishlist revision: %w", err)
+	}
+	if len(revisions) == 0 {
+		return 0, nil
+	}
+	return revisions[0].Revision, nil
+}
+
+// bumpRevision moves the owner's wishlist to its next revision
+func bumpRevision(tx *gorm.DB, owner Owner) error {
+	revision := models.WishlistRevision{Owner: owner.key(), Revision: 1}
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "owner"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"revision":   gorm.Expr("wishlist_revisions.revision + 1"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&revision).Error
+	if err != nil {
+		return fmt.Errorf("failed to update wishlist revision: %w", err)
+	}
+	return nil
+}
+
+// Add saves a product to the owner's wishlist; saving a product twice returns the existing item
+func (s *Service) Add(owner Owner, productID string) (*models.WishlistItem, error) {
+	query, err := s.scope(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	var product models.Product
+	if err := s.db.Where("id = ? AND is_active = ?", productID, true).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch product: %w", err)
+	}
+
+	var existing models.WishlistItem
+	err = query.Where("product_id = ?", productID).First(&existing).Error
+	if err == nil {
+		existing.Product = product
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch wishlist item: %w", err)
+	}
+
+	item := models.WishlistItem{ProductID: productID}
+	if owner.UserID != "" {
+		item.UserID = &owner.UserID
+	} else {
+		item.SessionID = &owner.SessionID
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&item).Error; err != nil {
+			return fmt.Errorf("failed to save wishlist item: %w", err)
+		}
+		return bumpRevision(tx, owner)
+	})
+	if err != nil {
+		return nil, err
+	}
+	item.Product = product
+	return &item, nil
+}
+
+// Remove deletes a product from the owner's wishlist
+func (s *Service) Remove(owner Owner, productID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		query, err := ownedBy(tx, owner)
+		if err != nil {
+			return err
+		}
+		result := query.Where("product_id = ?", productID).Delete(&models.WishlistItem{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to remove wishlist item: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrItemNotFound
+		}
+		return bumpRevision(tx, owner)
+	})
+}
+
+// MoveToCart adds a wishlist product to the owner's cart and removes it from the wishlist.
+// Cart errors (inactive product, insufficient inventory) leave the wishlist untouched.
+func (s *Service) MoveToCart(ctx context.Context, owner Owner, productID string, quantity int) (*models.Cart, error) {
+	query, err := s.scope(owner)
+	if err != nil {
+		return nil, err
+	}
+	var item models.WishlistItem
+	if err := query.Where("product_id = ?", productID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrItemNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch wishlist item: %w", err)
+	}
+
+	cartID := owner.SessionID
+	if owner.UserID != "" {
+		cartID = cart.UserCartID(owner.UserID)
+	}
+	updated, err := s.cart.AddItem(ctx, cartID, productID, quantity, models.CartSourceWishlist)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&item).Error; err != nil {
+			return fmt.Errorf("failed to remove wishlist item: %w", err)
+		}
+		return bumpRevision(tx, owner)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// MergeGuest moves a guest session's items into the user's wishlist after sign-in. Products the user
+// already saved are dropped from the guest list. It returns the number of items moved.
+func (s *Service) MergeGuest(sessionID, userID string) (int, error) {
+	if sessionID == "" || userID == "" {
+		return 0, nil
+	}
+
+	moved := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var guestItems []models.WishlistItem
+		if err := tx.Where("session_id = ? AND user_id IS NULL", sessionID).Find(&guestItems).Error; err != nil {
+			return fmt.Errorf("failed to fetch guest wishlist: %w", err)
+		}
+
+		for _, item := range guestItems {
+			var saved int64
+			if err := tx.Model(&models.WishlistItem{}).Where("user_id = ? AND product_id = ?", userID, item.ProductID).Count(&saved).Error; err != nil {
+				return fmt.Errorf("failed to check user wishlist: %w", err)
+			}
+			if saved > 0 {
+				if err := tx.Delete(&item).Error; err != nil {
+					return fmt.Errorf("failed to drop duplicate wishlist item: %w", err)
+				}
+				continue
+			}
+
+			if err := tx.Model(&item).Updates(map[string]interface{}{"user_id": userID, "session_id": nil}).Error; err != nil {
+				return fmt.Errorf("failed to move wishlist item: %w", err)
+			}
+			moved++
+		}
+		if len(guestItems) == 0 {
+			return nil
+		}
+		if err := bumpRevision(tx, Owner{SessionID: sessionID}); err != nil {
+			return err
+		}
+		return bumpRevision(tx, Owner{UserID: userID})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
@@ -0,0 +1,196 @@
+package wishlist
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ecommerce-website/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeCart records items added to session carts
+type fakeCart struct {
+	added map[string][]models.CartItem
+	err   error
+}
+
+func (f *fakeCart) GetCart(ctx context.Context, sessionID string) (*models.Cart, error) {
+	return &models.Cart{SessionID: sessionID, Items: f.added[sessionID]}, nil
+}
+
+func (f *fakeCart) GetCartWithProducts(ctx context.Context, sessionID string) (*models.Cart, error) {
+	return f.GetCart(ctx, sessionID)
+}
+
+func (f *fakeCart) ClearCart(ctx context.Context, sessionID string) error {
+	delete(f.added, sessionID)
+	return nil
+}
+
+func (f *fakeCart) AddItem(ctx context.Context, sessionID string, productID string, quantity int, source string) (*models.Cart, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.added[sessionID] = append(f.added[sessionID], models.CartItem{ProductID: productID, Quantity: quantity, Source: source})
+	return f.GetCart(ctx, sessionID)
+}
+
+func (f *fakeCart) UpdateItem(ctx context.Context, sessionID string, productID string, quantity int) (*models.Cart, error) {
+	return f.GetCart(ctx, sessionID)
+}
+
+func (f *fakeCart) RemoveItem(ctx context.Context, sessionID string, productID string) (*models.Cart, error) {
+	return f.GetCart(ctx, sessionID)
+}
+
+func (f *fakeCart) SaveCart(ctx context.Context, cart *models.Cart) error {
+	return nil
+}
+
+func setupWishlistTest(t *testing.T) (*gorm.DB, *Service, *fakeCart) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}, &models.WishlistItem{}, &models.WishlistRevision{}))
+
+	cart := &fakeCart{added: make(map[string][]models.CartItem)}
+	return db, NewService(db, cart), cart
+}
+
+func createWishlistProduct(t *testing.T, db *gorm.DB, sku string) *models.Product {
+	category := models.Category{Name: "Gifts " + sku, Slug: "gifts-" + sku, IsActive: true}
+	require.NoError(t, db.Create(&category).Error)
+	product := &models.Product{Name: sku, Price: 10, SKU: sku, Inventory: 5, IsActive: true, CategoryID: category.ID}
+	require.NoError(t, db.Create(product).Error)
+	return product
+}
+
+func TestService_AddListRemove(t *testing.T) {
+	db, service, _ := setupWishlistTest(t)
+	product := createWishlistProduct(t, db, "MUG")
+	guest := Owner{SessionID: "guest-session"}
+
+	first, err := service.Add(guest, product.ID)
+	require.NoError(t, err)
+	again, err := service.Add(guest, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, again.ID, "saving a product twice keeps one item")
+
+	_, err = service.Add(guest, "missing")
+	assert.ErrorIs(t, err, ErrProductNotFound)
+
+	items, err := service.List(guest)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "MUG", items[0].Product.Name)
+
+	others, err := service.List(Owner{SessionID: "other-session"})
+	require.NoError(t, err)
+	assert.Empty(t, others)
+
+	require.NoError(t, service.Remove(guest, product.ID))
+	assert.ErrorIs(t, service.Remove(guest, product.ID), ErrItemNotFound)
+
+	_, err = service.List(Owner{})
+	assert.ErrorIs(t, err, ErrNoOwner)
+}
+
+func TestService_MergeGuest(t *testing.T) {
+	db, service, _ := setupWishlistTest(t)
+	mug := createWishlistProduct(t, db, "MUG")
+	lamp := createWishlistProduct(t, db, "LAMP")
+	guest := Owner{SessionID: "guest-session"}
+	user := Owner{UserID: "user-1", SessionID: "guest-session"}
+
+	_, err := service.Add(guest, mug.ID)
+	require.NoError(t, err)
+	_, err = service.Add(guest, lamp.ID)
+	require.NoError(t, err)
+	_, err = service.Add(Owner{UserID: "user-1"}, mug.ID)
+	require.NoError(t, err)
+
+	moved, err := service.MergeGuest(guest.SessionID, user.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved, "products the user already saved are not duplicated")
+
+	items, err := service.List(user)
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	guestItems, err := service.List(guest)
+	require.NoError(t, err)
+	assert.Empty(t, guestItems)
+
+	moved, err = service.MergeGuest(guest.SessionID, user.UserID)
+	require.NoError(t, err)
+	assert.Zero(t, moved)
+}
+
+func TestService_MoveToCart(t *testing.T) {
+	db, service, cart := setupWishlistTest(t)
+	product := createWishlistProduct(t, db, "MUG")
+	owner := Owner{UserID: "user-1", SessionID: "session-1"}
+
+	_, err := service.Add(owner, product.ID)
+	require.NoError(t, err)
+
+	cart.err = errors.New("insufficient inventory: only 5 items available")
+	_, err = service.MoveToCart(context.Background(), owner, product.ID, 6)
+	assert.EqualError(t, err, "insufficient inventory: only 5 items available")
+	items, _ := service.List(owner)
+	assert.Len(t, items, 1, "a failed move keeps the wishlist item")
+
+	cart.err = nil
+	updated, err := service.MoveToCart(context.Background(), owner, product.ID, 2)
+	require.NoError(t, err)
+	require.Len(t, updated.Items, 1)
+	assert.Equal(t, 2, updated.Items[0].Quantity)
+	assert.Equal(t, models.CartSourceWishlist, updated.Items[0].Source)
+
+	items, _ = service.List(owner)
+	assert.Empty(t, items)
+
+	_, err = service.MoveToCart(context.Background(), owner, product.ID, 1)
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
+
+func TestService_Revision(t *testing.T) {
+	db, service, _ := setupWishlistTest(t)
+	mug := createWishlistProduct(t, db, "MUG")
+	lamp := createWishlistProduct(t, db, "LAMP")
+	guest := Owner{SessionID: "guest-session"}
+
+	revision, err := service.Revision(guest)
+	require.NoError(t, err)
+	assert.Zero(t, revision)
+
+	_, err = service.Add(guest, mug.ID)
+	require.NoError(t, err)
+	_, err = service.Add(guest, mug.ID)
+	require.NoError(t, err)
+	revision, _ = service.Revision(guest)
+	assert.EqualValues(t, 1, revision, "saving a product twice is one change")
+
+	_, err = service.Add(guest, lamp.ID)
+	require.NoError(t, err)
+	require.NoError(t, service.Remove(guest, lamp.ID))
+	assert.ErrorIs(t, service.Remove(guest, lamp.ID), ErrItemNotFound)
+	revision, _ = service.Revision(guest)
+	assert.EqualValues(t, 3, revision)
+
+	_, err = service.MergeGuest(guest.SessionID, "user-1")
+	require.NoError(t, err)
+	revision, _ = service.Revision(guest)
+	assert.EqualValues(t, 4, revision, "merging empties the guest list")
+	revision, _ = service.Revision(Owner{UserID: "user-1"})
+	assert.EqualValues(t, 1, revision)
+
+	_, err = service.MoveToCart(context.Background(), Owner{UserID: "user-1"}, mug.ID, 1)
+	require.NoError(t, err)
+	revision, _ = service.Revision(Owner{UserID: "user-1"})
+	assert.EqualValues(t, 2, revision)
+}
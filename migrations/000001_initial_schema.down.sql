@@ -0,0 +1,57 @@
+DROP TABLE IF EXISTS "audit_logs" CASCADE;
+DROP TABLE IF EXISTS "saved_views" CASCADE;
+DROP TABLE IF EXISTS "invariant_violations" CASCADE;
+DROP TABLE IF EXISTS "invariant_runs" CASCADE;
+DROP TABLE IF EXISTS "bus_events" CASCADE;
+DROP TABLE IF EXISTS "webhook_deliveries" CASCADE;
+DROP TABLE IF EXISTS "webhook_endpoints" CASCADE;
+DROP TABLE IF EXISTS "retention_runs" CASCADE;
+DROP TABLE IF EXISTS "retention_rules" CASCADE;
+DROP TABLE IF EXISTS "delivery_zones" CASCADE;
+DROP TABLE IF EXISTS "shipping_methods" CASCADE;
+DROP TABLE IF EXISTS "tax_rules" CASCADE;
+DROP TABLE IF EXISTS "wishlist_revisions" CASCADE;
+DROP TABLE IF EXISTS "wishlist_items" CASCADE;
+DROP TABLE IF EXISTS "order_item_serials" CASCADE;
+DROP TABLE IF EXISTS "order_status_history" CASCADE;
+DROP TABLE IF EXISTS "order_events" CASCADE;
+DROP TABLE IF EXISTS "profile_completion_events" CASCADE;
+DROP TABLE IF EXISTS "inventory_reservations" CASCADE;
+DROP TABLE IF EXISTS "settings" CASCADE;
+DROP TABLE IF EXISTS "return_requests" CASCADE;
+DROP TABLE IF EXISTS "api_keys" CASCADE;
+DROP TABLE IF EXISTS "report_queries" CASCADE;
+DROP TABLE IF EXISTS "funnel_events" CASCADE;
+DROP TABLE IF EXISTS "product_daily_stats" CASCADE;
+DROP TABLE IF EXISTS "email_events" CASCADE;
+DROP TABLE IF EXISTS "email_logs" CASCADE;
+DROP TABLE IF EXISTS "email_suppressions" CASCADE;
+DROP TABLE IF EXISTS "email_campaign_recipients" CASCADE;
+DROP TABLE IF EXISTS "email_campaigns" CASCADE;
+DROP TABLE IF EXISTS "user_cart_items" CASCADE;
+DROP TABLE IF EXISTS "user_carts" CASCADE;
+DROP TABLE IF EXISTS "product_listings" CASCADE;
+DROP TABLE IF EXISTS "product_prices" CASCADE;
+DROP TABLE IF EXISTS "customer_merges" CASCADE;
+DROP TABLE IF EXISTS "warranty_claim_events" CASCADE;
+DROP TABLE IF EXISTS "warranty_claims" CASCADE;
+DROP TABLE IF EXISTS "warranties" CASCADE;
+DROP TABLE IF EXISTS "sla_alerts" CASCADE;
+DROP TABLE IF EXISTS "sla_policies" CASCADE;
+DROP TABLE IF EXISTS "finance_journal_entries" CASCADE;
+DROP TABLE IF EXISTS "store_credit_entries" CASCADE;
+DROP TABLE IF EXISTS "order_adjustments" CASCADE;
+DROP TABLE IF EXISTS "refund_items" CASCADE;
+DROP TABLE IF EXISTS "refunds" CASCADE;
+DROP TABLE IF EXISTS "payment_events" CASCADE;
+DROP TABLE IF EXISTS "payments" CASCADE;
+DROP TABLE IF EXISTS "order_items" CASCADE;
+DROP TABLE IF EXISTS "shipments" CASCADE;
+DROP TABLE IF EXISTS "orders" CASCADE;
+DROP TABLE IF EXISTS "product_documents" CASCADE;
+DROP TABLE IF EXISTS "products" CASCADE;
+DROP TABLE IF EXISTS "categories" CASCADE;
+DROP TABLE IF EXISTS "addresses" CASCADE;
+DROP TABLE IF EXISTS "magic_link_tokens" CASCADE;
+DROP TABLE IF EXISTS "admin_login_events" CASCADE;
+DROP TABLE IF EXISTS "users" CASCADE;
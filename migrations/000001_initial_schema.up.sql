@@ -0,0 +1,1018 @@
+-- Schema of the models previously created by AutoMigrate on boot. Statements are idempotent so databases
+-- created that way can be brought under migrations by running this version over them.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" text,
+    "email" text NOT NULL,
+    "password" text NOT NULL,
+    "first_name" text NOT NULL,
+    "last_name" text NOT NULL,
+    "phone" text,
+    "role" varchar(20) DEFAULT 'customer',
+    "admin_role" varchar(30) NOT NULL DEFAULT '',
+    "is_active" boolean DEFAULT true,
+    "email_verified" boolean DEFAULT false,
+    "email_verification_token" varchar(255),
+    "password_reset_token" varchar(255),
+    "password_reset_expiry" timestamptz,
+    "marketing_opt_out" boolean DEFAULT false,
+    "email_bounced" boolean DEFAULT false,
+    "totp_secret" varchar(64),
+    "totp_enabled" boolean DEFAULT false,
+    "last_active_at" timestamptz,
+    "anonymized_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+
+CREATE TABLE IF NOT EXISTS "admin_login_events" (
+    "id" text,
+    "user_id" text NOT NULL,
+    "email" text NOT NULL,
+    "ip" varchar(45) NOT NULL,
+    "network" varchar(50) NOT NULL,
+    "user_agent" text,
+    "mfa" boolean NOT NULL DEFAULT false,
+    "new_location" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_admin_login_events_created_at" ON "admin_login_events" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_admin_login_events_email" ON "admin_login_events" ("email");
+
+CREATE TABLE IF NOT EXISTS "magic_link_tokens" (
+    "id" text,
+    "user_id" text NOT NULL,
+    "fingerprint" varchar(64) NOT NULL,
+    "ip" varchar(45),
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_magic_link_tokens_created_at" ON "magic_link_tokens" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_magic_link_tokens_user_id" ON "magic_link_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "addresses" (
+    "id" text,
+    "user_id" text NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "first_name" text NOT NULL,
+    "last_name" text NOT NULL,
+    "company" text,
+    "address1" text NOT NULL,
+    "address2" text,
+    "city" text NOT NULL,
+    "state" text NOT NULL,
+    "postal_code" text NOT NULL,
+    "country" text NOT NULL,
+    "phone" text,
+    "is_default" boolean DEFAULT false,
+    "latitude" decimal,
+    "longitude" decimal,
+    "geocoded_at" timestamptz,
+    "delivery_zone_id" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_addresses" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_addresses_delivery_zone_id" ON "addresses" ("delivery_zone_id");
+
+CREATE TABLE IF NOT EXISTS "categories" (
+    "id" text,
+    "name" text NOT NULL,
+    "slug" text NOT NULL,
+    "description" text,
+    "parent_id" text,
+    "is_active" boolean DEFAULT true,
+    "sort_order" bigint DEFAULT 0,
+    "policy_returnable" boolean DEFAULT true,
+    "policy_return_window_days" bigint DEFAULT 7,
+    "policy_cancellation_cutoff_hours" bigint DEFAULT 24,
+    "attributes" jsonb,
+    "serial_tracked" boolean DEFAULT false,
+    "warranty_months" bigint DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_categories_children" FOREIGN KEY ("parent_id") REFERENCES "categories"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_categories_deleted_at" ON "categories" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_categories_slug" ON "categories" ("slug");
+
+CREATE TABLE IF NOT EXISTS "products" (
+    "id" text,
+    "name" text NOT NULL,
+    "description" text,
+    "price" decimal NOT NULL,
+    "compare_at_price" decimal,
+    "sku" text NOT NULL,
+    "inventory" bigint DEFAULT 0,
+    "low_stock_threshold" bigint,
+    "is_active" boolean DEFAULT true,
+    "category_id" text NOT NULL,
+    "images" text[],
+    "specifications" jsonb,
+    "seo_title" text,
+    "seo_description" text,
+    "weight_grams" decimal,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_categories_products" FOREIGN KEY ("category_id") REFERENCES "categories"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_products_category_id" ON "products" ("category_id");
+CREATE INDEX IF NOT EXISTS "idx_products_deleted_at" ON "products" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_products_inventory" ON "products" ("inventory");
+CREATE INDEX IF NOT EXISTS "idx_products_is_active" ON "products" ("is_active");
+CREATE INDEX IF NOT EXISTS "idx_products_name" ON "products" ("name");
+CREATE INDEX IF NOT EXISTS "idx_products_price" ON "products" ("price");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_products_sku" ON "products" ("sku");
+
+CREATE TABLE IF NOT EXISTS "product_documents" (
+    "id" text,
+    "product_id" text NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "title" text NOT NULL,
+    "file_name" text NOT NULL,
+    "content_type" varchar(100) NOT NULL,
+    "size_bytes" bigint NOT NULL,
+    "storage_key" text NOT NULL,
+    "sort_order" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_products_documents" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_documents_product_id" ON "product_documents" ("product_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_product_documents_storage_key" ON "product_documents" ("storage_key");
+
+CREATE TABLE IF NOT EXISTS "orders" (
+    "id" text,
+    "user_id" text NOT NULL,
+    "status" varchar(20) DEFAULT 'pending',
+    "subtotal" decimal NOT NULL,
+    "tax" decimal DEFAULT 0,
+    "tax_breakdown" jsonb,
+    "shipping" decimal DEFAULT 0,
+    "shipping_method_id" text,
+    "shipping_method_name" text,
+    "total" decimal NOT NULL,
+    "shipping_first_name" text,
+    "shipping_last_name" text,
+    "shipping_company" text,
+    "shipping_address1" text,
+    "shipping_address2" text,
+    "shipping_city" text,
+    "shipping_state" text,
+    "shipping_postal_code" text,
+    "shipping_country" text,
+    "shipping_phone" text,
+    "billing_first_name" text,
+    "billing_last_name" text,
+    "billing_company" text,
+    "billing_address1" text,
+    "billing_address2" text,
+    "billing_city" text,
+    "billing_state" text,
+    "billing_postal_code" text,
+    "billing_country" text,
+    "billing_phone" text,
+    "payment_intent_id" text,
+    "notes" text,
+    "is_gift" boolean DEFAULT false,
+    "gift_message" text,
+    "gift_recipient_email" text,
+    "delivered_at" timestamptz,
+    "cancellation_reason" text,
+    "review_request_status" varchar(20) NOT NULL DEFAULT '',
+    "review_request_sent_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_orders" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_orders_shipping_method_id" ON "orders" ("shipping_method_id");
+CREATE INDEX IF NOT EXISTS "idx_orders_status" ON "orders" ("status");
+CREATE INDEX IF NOT EXISTS "idx_orders_total" ON "orders" ("total");
+CREATE INDEX IF NOT EXISTS "idx_orders_user_id" ON "orders" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "shipments" (
+    "id" text,
+    "order_id" text NOT NULL,
+    "group_key" text NOT NULL,
+    "address_first_name" text,
+    "address_last_name" text,
+    "address_company" text,
+    "address_address1" text,
+    "address_address2" text,
+    "address_city" text,
+    "address_state" text,
+    "address_postal_code" text,
+    "address_country" text,
+    "address_phone" text,
+    "shipping" decimal DEFAULT 0,
+    "delivery_zone_id" text,
+    "status" varchar(20) DEFAULT 'pending',
+    "tracking_number" text,
+    "shipped_at" timestamptz,
+    "delivered_at" timestamptz,
+    "delivery_source" varchar(20),
+    "delivery_photo_url" text,
+    "delivery_otp_verified" boolean,
+    "delivery_recipient_name" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_shipments" FOREIGN KEY ("order_id") REFERENCES "orders"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_shipments_delivery_zone_id" ON "shipments" ("delivery_zone_id");
+CREATE INDEX IF NOT EXISTS "idx_shipments_order_id" ON "shipments" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_shipments_status" ON "shipments" ("status");
+
+CREATE TABLE IF NOT EXISTS "order_items" (
+    "id" text,
+    "order_id" text NOT NULL,
+    "product_id" text NOT NULL,
+    "quantity" bigint NOT NULL,
+    "price" decimal NOT NULL,
+    "total" decimal NOT NULL,
+    "source" varchar(30),
+    "shipment_id" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_items" FOREIGN KEY ("order_id") REFERENCES "orders"("id"),
+    CONSTRAINT "fk_shipments_items" FOREIGN KEY ("shipment_id") REFERENCES "shipments"("id"),
+    CONSTRAINT "fk_products_order_items" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_items_order_id" ON "order_items" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_order_items_product_id" ON "order_items" ("product_id");
+CREATE INDEX IF NOT EXISTS "idx_order_items_shipment_id" ON "order_items" ("shipment_id");
+CREATE INDEX IF NOT EXISTS "idx_order_items_source" ON "order_items" ("source");
+
+CREATE TABLE IF NOT EXISTS "payments" (
+    "id" text,
+    "order_id" text NOT NULL,
+    "razorpay_order_id" text NOT NULL,
+    "razorpay_payment_id" text,
+    "razorpay_signature" text,
+    "amount" bigint NOT NULL,
+    "currency" text DEFAULT 'INR',
+    "status" varchar(20) DEFAULT 'created',
+    "provider" varchar(20) NOT NULL DEFAULT 'primary',
+    "method" text,
+    "amount_refunded" bigint NOT NULL DEFAULT 0,
+    "description" text,
+    "expires_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_payments_order" FOREIGN KEY ("order_id") REFERENCES "orders"("id"),
+    CONSTRAINT "uni_payments_razorpay_order_id" UNIQUE ("razorpay_order_id"),
+    CONSTRAINT "uni_payments_razorpay_payment_id" UNIQUE ("razorpay_payment_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_payments_expires_at" ON "payments" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_payments_order_id" ON "payments" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_payments_provider" ON "payments" ("provider");
+CREATE INDEX IF NOT EXISTS "idx_payments_status" ON "payments" ("status");
+
+CREATE TABLE IF NOT EXISTS "payment_events" (
+    "id" text,
+    "event_id" text NOT NULL,
+    "event" varchar(50) NOT NULL,
+    "razorpay_order_id" text,
+    "razorpay_payment_id" text,
+    "payload" text NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'received',
+    "error" text,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "processed_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_payment_events_event" ON "payment_events" ("event");
+CREATE INDEX IF NOT EXISTS "idx_payment_events_razorpay_order_id" ON "payment_events" ("razorpay_order_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_payment_events_event_id" ON "payment_events" ("event_id");
+
+CREATE TABLE IF NOT EXISTS "refunds" (
+    "id" text,
+    "order_id" text NOT NULL,
+    "payment_id" text NOT NULL,
+    "provider_refund_id" text,
+    "amount" bigint NOT NULL,
+    "currency" text DEFAULT 'INR',
+    "status" varchar(20) NOT NULL,
+    "reason" text,
+    "created_by" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_refunds_provider_refund_id" UNIQUE ("provider_refund_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_refunds_order_id" ON "refunds" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_refunds_payment_id" ON "refunds" ("payment_id");
+CREATE INDEX IF NOT EXISTS "idx_refunds_status" ON "refunds" ("status");
+
+CREATE TABLE IF NOT EXISTS "refund_items" (
+    "id" text,
+    "refund_id" text NOT NULL,
+    "order_item_id" text NOT NULL,
+    "product_id" text NOT NULL,
+    "quantity" bigint NOT NULL,
+    "restocked" boolean,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_refunds_items" FOREIGN KEY ("refund_id") REFERENCES "refunds"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_refund_items_order_item_id" ON "refund_items" ("order_item_id");
+CREATE INDEX IF NOT EXISTS "idx_refund_items_refund_id" ON "refund_items" ("refund_id");
+
+CREATE TABLE IF NOT EXISTS "order_adjustments" (
+    "id" text,
+    "order_id" text NOT NULL,
+    "user_id" text NOT NULL,
+    "type" varchar(30) NOT NULL,
+    "amount" bigint NOT NULL,
+    "currency" text DEFAULT 'INR',
+    "reason_code" varchar(40) NOT NULL,
+    "note" text,
+    "status" varchar(20) NOT NULL,
+    "requested_by" text NOT NULL,
+    "reviewed_by" text,
+    "reviewed_at" timestamptz,
+    "refund_id" text,
+    "store_credit_id" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_adjustments_order_id" ON "order_adjustments" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_order_adjustments_reason_code" ON "order_adjustments" ("reason_code");
+CREATE INDEX IF NOT EXISTS "idx_order_adjustments_status" ON "order_adjustments" ("status");
+CREATE INDEX IF NOT EXISTS "idx_order_adjustments_user_id" ON "order_adjustments" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "store_credit_entries" (
+    "id" text,
+    "user_id" text NOT NULL,
+    "amount" bigint NOT NULL,
+    "currency" text DEFAULT 'INR',
+    "order_id" text,
+    "adjustment_id" text,
+    "reason" text,
+    "created_by" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_store_credit_entries_adjustment_id" ON "store_credit_entries" ("adjustment_id");
+CREATE INDEX IF NOT EXISTS "idx_store_credit_entries_order_id" ON "store_credit_entries" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_store_credit_entries_user_id" ON "store_credit_entries" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "finance_journal_entries" (
+    "id" text,
+    "journal" varchar(20) NOT NULL,
+    "entry_type" varchar(30) NOT NULL,
+    "order_id" text,
+    "amount" bigint NOT NULL,
+    "currency" text DEFAULT 'INR',
+    "reason_code" text,
+    "reference" text,
+    "requested_by" text,
+    "approved_by" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_finance_journal_entries_created_at" ON "finance_journal_entries" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_finance_journal_entries_journal" ON "finance_journal_entries" ("journal");
+CREATE INDEX IF NOT EXISTS "idx_finance_journal_entries_order_id" ON "finance_journal_entries" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "sla_policies" (
+    "id" text,
+    "name" text NOT NULL,
+    "start_status" varchar(20) NOT NULL,
+    "target_status" varchar(20) NOT NULL,
+    "target_hours" bigint NOT NULL,
+    "warning_hours" bigint DEFAULT 0,
+    "active" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "sla_alerts" (
+    "id" text,
+    "policy_id" text NOT NULL,
+    "order_id" text NOT NULL,
+    "level" varchar(20) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_sla_alert" ON "sla_alerts" ("policy_id","order_id","level");
+
+CREATE TABLE IF NOT EXISTS "warranties" (
+    "id" text,
+    "user_id" text NOT NULL,
+    "order_id" text NOT NULL,
+    "order_item_id" text NOT NULL,
+    "product_id" text NOT NULL,
+    "quantity" bigint NOT NULL,
+    "months" bigint NOT NULL,
+    "starts_at" timestamptz NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_warranties_product" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_warranties_expires_at" ON "warranties" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_warranties_order_id" ON "warranties" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_warranties_product_id" ON "warranties" ("product_id");
+CREATE INDEX IF NOT EXISTS "idx_warranties_user_id" ON "warranties" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_warranties_order_item_id" ON "warranties" ("order_item_id");
+
+CREATE TABLE IF NOT EXISTS "warranty_claims" (
+    "id" text,
+    "warranty_id" text NOT NULL,
+    "user_id" text NOT NULL,
+    "serial" varchar(64),
+    "description" text NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "resolution" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_warranties_claims" FOREIGN KEY ("warranty_id") REFERENCES "warranties"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_warranty_claims_status" ON "warranty_claims" ("status");
+CREATE INDEX IF NOT EXISTS "idx_warranty_claims_user_id" ON "warranty_claims" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_warranty_claims_warranty_id" ON "warranty_claims" ("warranty_id");
+
+CREATE TABLE IF NOT EXISTS "warranty_claim_events" (
+    "id" text,
+    "claim_id" text NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "note" text,
+    "actor_id" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_warranty_claims_events" FOREIGN KEY ("claim_id") REFERENCES "warranty_claims"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_warranty_claim_events_claim_id" ON "warranty_claim_events" ("claim_id");
+
+CREATE TABLE IF NOT EXISTS "customer_merges" (
+    "id" text,
+    "primary_user_id" text NOT NULL,
+    "duplicate_user_id" text NOT NULL,
+    "duplicate_email" text NOT NULL,
+    "reasons" text,
+    "note" text,
+    "moved" jsonb,
+    "merged_by" text NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_customer_merges_created_at" ON "customer_merges" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_customer_merges_primary_user_id" ON "customer_merges" ("primary_user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_customer_merges_duplicate_user_id" ON "customer_merges" ("duplicate_user_id");
+
+CREATE TABLE IF NOT EXISTS "product_prices" (
+    "id" text,
+    "product_id" text NOT NULL,
+    "price" decimal NOT NULL,
+    "compare_at_price" decimal,
+    "effective_from" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_prices_effective" ON "product_prices" ("product_id","effective_from");
+
+CREATE TABLE IF NOT EXISTS "product_listings" (
+    "product_id" text,
+    "name" text NOT NULL,
+    "description" text,
+    "sku" text NOT NULL,
+    "price" decimal NOT NULL,
+    "compare_at_price" decimal,
+    "effective_price" decimal NOT NULL,
+    "discount_percent" bigint NOT NULL DEFAULT 0,
+    "inventory" bigint NOT NULL DEFAULT 0,
+    "in_stock" boolean NOT NULL,
+    "low_stock" boolean NOT NULL,
+    "category_id" text NOT NULL,
+    "category_name" text,
+    "category_slug" text,
+    "images" text[],
+    "specifications" jsonb,
+    "seo_title" text,
+    "seo_description" text,
+    "weight_grams" decimal,
+    "product_created_at" timestamptz,
+    "product_updated_at" timestamptz,
+    "refreshed_at" timestamptz,
+    PRIMARY KEY ("product_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_listings_category_id" ON "product_listings" ("category_id");
+CREATE INDEX IF NOT EXISTS "idx_product_listings_effective_price" ON "product_listings" ("effective_price");
+CREATE INDEX IF NOT EXISTS "idx_product_listings_in_stock" ON "product_listings" ("in_stock");
+CREATE INDEX IF NOT EXISTS "idx_product_listings_name" ON "product_listings" ("name");
+CREATE INDEX IF NOT EXISTS "idx_product_listings_product_created_at" ON "product_listings" ("product_created_at");
+CREATE INDEX IF NOT EXISTS "idx_product_listings_refreshed_at" ON "product_listings" ("refreshed_at");
+
+CREATE TABLE IF NOT EXISTS "user_carts" (
+    "user_id" text,
+    "revision" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("user_id")
+);
+
+CREATE TABLE IF NOT EXISTS "user_cart_items" (
+    "user_id" text,
+    "product_id" text,
+    "quantity" bigint NOT NULL,
+    "price" decimal NOT NULL,
+    "source" varchar(30),
+    "position" bigint NOT NULL DEFAULT 0,
+    "saved" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("user_id","product_id")
+);
+
+CREATE TABLE IF NOT EXISTS "email_campaigns" (
+    "id" text,
+    "name" text NOT NULL,
+    "subject" text NOT NULL,
+    "body" text NOT NULL,
+    "segment" jsonb,
+    "track_opens" boolean,
+    "batch_size" bigint NOT NULL,
+    "rate_per_minute" bigint NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "recipients" bigint,
+    "created_by" text NOT NULL,
+    "started_at" timestamptz,
+    "completed_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_email_campaigns_status" ON "email_campaigns" ("status");
+
+CREATE TABLE IF NOT EXISTS "email_campaign_recipients" (
+    "id" text,
+    "campaign_id" text NOT NULL,
+    "user_id" text NOT NULL,
+    "email" text NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "suppression_reason" text,
+    "error" text,
+    "open_token" text NOT NULL,
+    "sent_at" timestamptz,
+    "opened_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_email_campaign_recipients_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_campaign_recipient_status" ON "email_campaign_recipients" ("campaign_id","status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_campaign_recipient" ON "email_campaign_recipients" ("campaign_id","user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_email_campaign_recipients_open_token" ON "email_campaign_recipients" ("open_token");
+
+CREATE TABLE IF NOT EXISTS "email_suppressions" (
+    "id" text,
+    "email" text NOT NULL,
+    "reason" varchar(20) NOT NULL,
+    "detail" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_email_suppressions_email" ON "email_suppressions" ("email");
+
+CREATE TABLE IF NOT EXISTS "email_logs" (
+    "id" text,
+    "kind" varchar(30) NOT NULL,
+    "recipient" text NOT NULL,
+    "subject" text,
+    "message_id" text,
+    "status" varchar(20) NOT NULL,
+    "error" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_email_logs_created_at" ON "email_logs" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_email_logs_kind" ON "email_logs" ("kind");
+CREATE INDEX IF NOT EXISTS "idx_email_logs_message_id" ON "email_logs" ("message_id");
+CREATE INDEX IF NOT EXISTS "idx_email_logs_recipient" ON "email_logs" ("recipient");
+CREATE INDEX IF NOT EXISTS "idx_email_logs_status" ON "email_logs" ("status");
+
+CREATE TABLE IF NOT EXISTS "email_events" (
+    "id" text,
+    "type" varchar(20) NOT NULL,
+    "bounce_type" varchar(10),
+    "email" text NOT NULL,
+    "message_id" text,
+    "reason" text,
+    "user_id" text,
+    "occurred_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_email_events_email" ON "email_events" ("email");
+CREATE INDEX IF NOT EXISTS "idx_email_events_occurred_at" ON "email_events" ("occurred_at");
+CREATE INDEX IF NOT EXISTS "idx_email_events_type" ON "email_events" ("type");
+CREATE INDEX IF NOT EXISTS "idx_email_events_user_id" ON "email_events" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "product_daily_stats" (
+    "id" text,
+    "product_id" text NOT NULL,
+    "date" timestamptz NOT NULL,
+    "views" bigint DEFAULT 0,
+    "orders" bigint DEFAULT 0,
+    "units_sold" bigint DEFAULT 0,
+    "refunded_units" bigint DEFAULT 0,
+    "revenue" decimal DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_daily_stats_date" ON "product_daily_stats" ("date");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_product_daily_stats_product_date" ON "product_daily_stats" ("product_id","date");
+
+CREATE TABLE IF NOT EXISTS "funnel_events" (
+    "id" text,
+    "step" varchar(30) NOT NULL,
+    "session_id" varchar(64),
+    "user_id" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_funnel_events_created_at" ON "funnel_events" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_funnel_events_session_id" ON "funnel_events" ("session_id");
+CREATE INDEX IF NOT EXISTS "idx_funnel_events_step" ON "funnel_events" ("step");
+CREATE INDEX IF NOT EXISTS "idx_funnel_events_user_id" ON "funnel_events" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "report_queries" (
+    "id" text,
+    "admin_id" text NOT NULL,
+    "kind" varchar(50) NOT NULL,
+    "from" timestamptz NOT NULL,
+    "to" timestamptz NOT NULL,
+    "granularity" varchar(10),
+    "limit" bigint,
+    "status" varchar(20) NOT NULL DEFAULT 'queued',
+    "error" text,
+    "result" text,
+    "result_rows" bigint,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_report_queries_admin_id" ON "report_queries" ("admin_id");
+CREATE INDEX IF NOT EXISTS "idx_report_queries_created_at" ON "report_queries" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_report_queries_status" ON "report_queries" ("status");
+
+CREATE TABLE IF NOT EXISTS "api_keys" (
+    "id" text,
+    "name" text NOT NULL,
+    "prefix" varchar(16) NOT NULL,
+    "key_hash" text NOT NULL,
+    "is_active" boolean DEFAULT true,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_api_keys_is_active" ON "api_keys" ("is_active");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_keys_key_hash" ON "api_keys" ("key_hash");
+
+CREATE TABLE IF NOT EXISTS "return_requests" (
+    "id" text,
+    "order_id" text NOT NULL,
+    "order_item_id" text NOT NULL,
+    "user_id" text NOT NULL,
+    "quantity" bigint NOT NULL,
+    "reason" text,
+    "status" varchar(20) DEFAULT 'requested',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_return_requests_order_id" ON "return_requests" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_return_requests_order_item_id" ON "return_requests" ("order_item_id");
+CREATE INDEX IF NOT EXISTS "idx_return_requests_status" ON "return_requests" ("status");
+CREATE INDEX IF NOT EXISTS "idx_return_requests_user_id" ON "return_requests" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "settings" (
+    "id" text,
+    "key" varchar(100) NOT NULL,
+    "scope" varchar(20) NOT NULL,
+    "scope_id" varchar(100) NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "value" text NOT NULL,
+    "description" text,
+    "updated_by" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_settings_scope_key" ON "settings" ("key","scope","scope_id");
+
+CREATE TABLE IF NOT EXISTS "inventory_reservations" (
+    "id" text,
+    "product_id" text NOT NULL,
+    "quantity" bigint NOT NULL,
+    "holder_type" varchar(20) NOT NULL,
+    "holder_id" text NOT NULL,
+    "user_id" text,
+    "expires_at" timestamptz NOT NULL,
+    "released_at" timestamptz,
+    "release_reason" text,
+    "released_by" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_inventory_reservations_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_inventory_reservations_expires_at" ON "inventory_reservations" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_inventory_reservations_holder_id" ON "inventory_reservations" ("holder_id");
+CREATE INDEX IF NOT EXISTS "idx_inventory_reservations_product_id" ON "inventory_reservations" ("product_id");
+CREATE INDEX IF NOT EXISTS "idx_inventory_reservations_released_at" ON "inventory_reservations" ("released_at");
+CREATE INDEX IF NOT EXISTS "idx_inventory_reservations_user_id" ON "inventory_reservations" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "profile_completion_events" (
+    "id" text,
+    "user_id" text NOT NULL,
+    "field" varchar(30) NOT NULL,
+    "action" varchar(20) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_profile_completion_events_action" ON "profile_completion_events" ("action");
+CREATE INDEX IF NOT EXISTS "idx_profile_completion_events_created_at" ON "profile_completion_events" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_profile_completion_events_field" ON "profile_completion_events" ("field");
+CREATE INDEX IF NOT EXISTS "idx_profile_completion_events_user_id" ON "profile_completion_events" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "order_events" (
+    "id" text,
+    "order_id" text NOT NULL,
+    "sequence" bigint NOT NULL,
+    "type" varchar(40) NOT NULL,
+    "data" jsonb,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_events_type" ON "order_events" ("type");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_order_events_order_sequence" ON "order_events" ("order_id","sequence");
+
+CREATE TABLE IF NOT EXISTS "order_status_history" (
+    "id" text,
+    "order_id" text NOT NULL,
+    "old_status" varchar(20),
+    "new_status" varchar(20) NOT NULL,
+    "actor_id" text,
+    "actor_role" varchar(20) NOT NULL,
+    "note" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_status_history_order_id" ON "order_status_history" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "order_item_serials" (
+    "id" text,
+    "order_id" text NOT NULL,
+    "order_item_id" text NOT NULL,
+    "shipment_id" text NOT NULL,
+    "product_id" text NOT NULL,
+    "serial" varchar(64) NOT NULL,
+    "recorded_by" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_order_items_serials" FOREIGN KEY ("order_item_id") REFERENCES "order_items"("id"),
+    CONSTRAINT "fk_warranties_serials" FOREIGN KEY ("order_item_id") REFERENCES "warranties"("order_item_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_item_serials_order_id" ON "order_item_serials" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_order_item_serials_order_item_id" ON "order_item_serials" ("order_item_id");
+CREATE INDEX IF NOT EXISTS "idx_order_item_serials_serial" ON "order_item_serials" ("serial");
+CREATE INDEX IF NOT EXISTS "idx_order_item_serials_shipment_id" ON "order_item_serials" ("shipment_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_product_serial" ON "order_item_serials" ("product_id","serial");
+
+CREATE TABLE IF NOT EXISTS "wishlist_items" (
+    "id" text,
+    "user_id" text,
+    "session_id" text,
+    "product_id" text NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_wishlist_items_product" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_wishlist_session_product" ON "wishlist_items" ("session_id","product_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_wishlist_user_product" ON "wishlist_items" ("user_id","product_id");
+
+CREATE TABLE IF NOT EXISTS "wishlist_revisions" (
+    "owner" text,
+    "revision" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("owner")
+);
+
+CREATE TABLE IF NOT EXISTS "tax_rules" (
+    "id" text,
+    "name" text NOT NULL,
+    "country" varchar(2) NOT NULL,
+    "state" text NOT NULL DEFAULT '',
+    "rate" decimal NOT NULL,
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tax_rules_is_active" ON "tax_rules" ("is_active");
+CREATE INDEX IF NOT EXISTS "idx_tax_rules_region" ON "tax_rules" ("country","state");
+
+CREATE TABLE IF NOT EXISTS "shipping_methods" (
+    "id" text,
+    "name" text NOT NULL,
+    "description" text,
+    "type" varchar(30) NOT NULL,
+    "rate" decimal NOT NULL DEFAULT 0,
+    "per_kg" decimal NOT NULL DEFAULT 0,
+    "free_threshold" decimal NOT NULL DEFAULT 0,
+    "countries" text[],
+    "zones" text[],
+    "sort_order" bigint NOT NULL DEFAULT 0,
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_shipping_methods_is_active" ON "shipping_methods" ("is_active");
+
+CREATE TABLE IF NOT EXISTS "delivery_zones" (
+    "id" text,
+    "name" text NOT NULL,
+    "country" varchar(2) NOT NULL,
+    "postal_prefixes" text[],
+    "center_lat" decimal,
+    "center_lng" decimal,
+    "radius_km" decimal NOT NULL DEFAULT 0,
+    "surcharge" decimal NOT NULL DEFAULT 0,
+    "priority" bigint NOT NULL DEFAULT 0,
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_delivery_zones_country" ON "delivery_zones" ("country");
+CREATE INDEX IF NOT EXISTS "idx_delivery_zones_is_active" ON "delivery_zones" ("is_active");
+
+CREATE TABLE IF NOT EXISTS "retention_rules" (
+    "target" varchar(40),
+    "retention_days" bigint NOT NULL,
+    "enabled" boolean NOT NULL,
+    "dry_run" boolean NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("target")
+);
+
+CREATE TABLE IF NOT EXISTS "retention_runs" (
+    "id" text,
+    "target" varchar(40) NOT NULL,
+    "dry_run" boolean NOT NULL,
+    "cutoff" timestamptz,
+    "matched" bigint,
+    "affected" bigint,
+    "sample" text[],
+    "error" text,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_retention_runs_started_at" ON "retention_runs" ("started_at");
+CREATE INDEX IF NOT EXISTS "idx_retention_runs_target" ON "retention_runs" ("target");
+
+CREATE TABLE IF NOT EXISTS "webhook_endpoints" (
+    "id" text,
+    "url" text NOT NULL,
+    "description" text,
+    "events" text[],
+    "secret" text NOT NULL,
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_endpoints_is_active" ON "webhook_endpoints" ("is_active");
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" text,
+    "endpoint_id" text NOT NULL,
+    "event" varchar(50) NOT NULL,
+    "payload" text NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "response_status" bigint,
+    "last_error" text,
+    "delivered_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_created_at" ON "webhook_deliveries" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_endpoint_id" ON "webhook_deliveries" ("endpoint_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_next_attempt_at" ON "webhook_deliveries" ("next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_status" ON "webhook_deliveries" ("status");
+
+CREATE TABLE IF NOT EXISTS "bus_events" (
+    "id" text,
+    "type" varchar(50) NOT NULL,
+    "version" bigint NOT NULL,
+    "key" varchar(100) NOT NULL,
+    "payload" text NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "last_error" text,
+    "published_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_bus_events_created_at" ON "bus_events" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_bus_events_next_attempt_at" ON "bus_events" ("next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_bus_events_published_at" ON "bus_events" ("published_at");
+CREATE INDEX IF NOT EXISTS "idx_bus_events_type" ON "bus_events" ("type");
+
+CREATE TABLE IF NOT EXISTS "invariant_runs" (
+    "id" text,
+    "since" timestamptz,
+    "violations" bigint,
+    "error" text,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_invariant_runs_started_at" ON "invariant_runs" ("started_at");
+
+CREATE TABLE IF NOT EXISTS "invariant_violations" (
+    "id" text,
+    "run_id" text NOT NULL,
+    "invariant" varchar(40) NOT NULL,
+    "entity_id" text NOT NULL,
+    "detail" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_invariant_violations_entity_id" ON "invariant_violations" ("entity_id");
+CREATE INDEX IF NOT EXISTS "idx_invariant_violations_invariant" ON "invariant_violations" ("invariant");
+CREATE INDEX IF NOT EXISTS "idx_invariant_violations_run_id" ON "invariant_violations" ("run_id");
+
+CREATE TABLE IF NOT EXISTS "saved_views" (
+    "id" text,
+    "owner_id" text NOT NULL,
+    "owner_email" text,
+    "resource" varchar(20) NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "state" jsonb,
+    "shared" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_saved_views_resource" ON "saved_views" ("resource");
+CREATE INDEX IF NOT EXISTS "idx_saved_views_shared" ON "saved_views" ("shared");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_saved_view_owner_name" ON "saved_views" ("owner_id","resource","name");
+
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+    "id" text,
+    "actor_id" text NOT NULL,
+    "actor_email" text,
+    "actor_role" varchar(30),
+    "ip" varchar(45),
+    "user_agent" text,
+    "request_id" text,
+    "method" varchar(10) NOT NULL,
+    "route" text NOT NULL,
+    "path" text NOT NULL,
+    "action" varchar(100) NOT NULL,
+    "resource_type" varchar(50),
+    "resource_id" text,
+    "status" bigint,
+    "payload" jsonb,
+    "changes" jsonb,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_action" ON "audit_logs" ("action");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_actor_id" ON "audit_logs" ("actor_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_created_at" ON "audit_logs" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_resource" ON "audit_logs" ("resource_type","resource_id");
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_payments_razorpay_order_id;
+DROP INDEX IF EXISTS idx_order_items_order_product;
+DROP INDEX IF EXISTS idx_orders_created_at;
+DROP INDEX IF EXISTS idx_orders_user_status;
+DROP INDEX IF EXISTS idx_products_name_trgm;
+DROP INDEX IF EXISTS idx_products_inventory_active;
+DROP INDEX IF EXISTS idx_products_price_active;
+DROP INDEX IF EXISTS idx_products_category_active;
+DROP INDEX IF EXISTS idx_categories_sort_order;
+DROP INDEX IF EXISTS idx_categories_parent_active;
+DROP INDEX IF EXISTS idx_addresses_default;
+DROP INDEX IF EXISTS idx_addresses_user_type;
+DROP INDEX IF EXISTS idx_users_role;
+DROP INDEX IF EXISTS idx_users_email_active;
@@ -0,0 +1,31 @@
+-- Composite and search indexes for the storefront and admin queries; the trigram index needs pg_trgm.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- User indexes
+CREATE INDEX IF NOT EXISTS idx_users_email_active ON users(email, is_active);
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
+
+-- Address indexes
+CREATE INDEX IF NOT EXISTS idx_addresses_user_type ON addresses(user_id, type);
+CREATE INDEX IF NOT EXISTS idx_addresses_default ON addresses(user_id, is_default);
+
+-- Category indexes
+CREATE INDEX IF NOT EXISTS idx_categories_parent_active ON categories(parent_id, is_active);
+CREATE INDEX IF NOT EXISTS idx_categories_sort_order ON categories(sort_order);
+
+-- Product indexes
+CREATE INDEX IF NOT EXISTS idx_products_category_active ON products(category_id, is_active);
+CREATE INDEX IF NOT EXISTS idx_products_price_active ON products(price, is_active);
+CREATE INDEX IF NOT EXISTS idx_products_inventory_active ON products(inventory, is_active);
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin(name gin_trgm_ops);
+
+-- Order indexes
+CREATE INDEX IF NOT EXISTS idx_orders_user_status ON orders(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at);
+
+-- OrderItem indexes
+CREATE INDEX IF NOT EXISTS idx_order_items_order_product ON order_items(order_id, product_id);
+
+-- Payment indexes
+CREATE INDEX IF NOT EXISTS idx_payments_razorpay_order_id ON payments(razorpay_order_id);
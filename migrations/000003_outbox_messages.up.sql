@@ -0,0 +1,19 @@
+-- Side effects recorded in the transaction of a change and delivered by the outbox dispatcher
+
+CREATE TABLE IF NOT EXISTS "outbox_messages" (
+    "id" text,
+    "topic" varchar(50) NOT NULL,
+    "payload" text NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "last_error" text,
+    "delivered_at" timestamptz,
+    "failed_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_created_at" ON "outbox_messages" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_delivered_at" ON "outbox_messages" ("delivered_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_failed_at" ON "outbox_messages" ("failed_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_next_attempt_at" ON "outbox_messages" ("next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_topic" ON "outbox_messages" ("topic");
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_full_name_lower_trgm;
+DROP INDEX IF EXISTS idx_users_email_lower_trgm;
+DROP INDEX IF EXISTS idx_products_sku_lower_prefix;
+DROP INDEX IF EXISTS idx_orders_status_created_at;
+DROP INDEX IF EXISTS idx_orders_id_prefix;
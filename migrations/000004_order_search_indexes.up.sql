@@ -0,0 +1,8 @@
+-- Indexes for the admin order search: order ID and SKU prefixes, customer name and email substrings, and
+-- status filters over date ranges. The trigram indexes need pg_trgm, enabled in 000002.
+
+CREATE INDEX IF NOT EXISTS idx_orders_id_prefix ON orders(id text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_orders_status_created_at ON orders(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_products_sku_lower_prefix ON products(LOWER(sku) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_users_email_lower_trgm ON users USING gin(LOWER(email) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_full_name_lower_trgm ON users USING gin(LOWER(first_name || ' ' || last_name) gin_trgm_ops);
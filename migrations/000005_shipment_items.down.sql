@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "shipment_items";
+ALTER TABLE "shipments" DROP COLUMN IF EXISTS "carrier";
@@ -0,0 +1,15 @@
+-- Parcels of a shipment group: the carrier of each shipment and the item quantities it carries
+
+ALTER TABLE "shipments" ADD COLUMN IF NOT EXISTS "carrier" varchar(50);
+
+CREATE TABLE IF NOT EXISTS "shipment_items" (
+    "id" text,
+    "shipment_id" text NOT NULL,
+    "order_item_id" text NOT NULL,
+    "quantity" bigint NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_shipments_shipped_items" FOREIGN KEY ("shipment_id") REFERENCES "shipments"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_shipment_items_order_item_id" ON "shipment_items" ("order_item_id");
+CREATE INDEX IF NOT EXISTS "idx_shipment_items_shipment_id" ON "shipment_items" ("shipment_id");
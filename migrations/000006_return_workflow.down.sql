@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS "idx_return_requests_rma_number";
+ALTER TABLE "return_requests" DROP COLUMN IF EXISTS "completed_at";
+ALTER TABLE "return_requests" DROP COLUMN IF EXISTS "restocked";
+ALTER TABLE "return_requests" DROP COLUMN IF EXISTS "refund_id";
+ALTER TABLE "return_requests" DROP COLUMN IF EXISTS "rejection_reason";
+ALTER TABLE "return_requests" DROP COLUMN IF EXISTS "reviewed_at";
+ALTER TABLE "return_requests" DROP COLUMN IF EXISTS "reviewed_by";
+ALTER TABLE "return_requests" DROP COLUMN IF EXISTS "tracking_number";
+ALTER TABLE "return_requests" DROP COLUMN IF EXISTS "carrier";
+ALTER TABLE "return_requests" DROP COLUMN IF EXISTS "rma_number";
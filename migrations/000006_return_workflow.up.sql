@@ -0,0 +1,12 @@
+-- Return request workflow: admin review, the return label issued on approval, and the refund and restock on completion
+
+ALTER TABLE "return_requests" ADD COLUMN IF NOT EXISTS "rma_number" varchar(20);
+ALTER TABLE "return_requests" ADD COLUMN IF NOT EXISTS "carrier" varchar(50);
+ALTER TABLE "return_requests" ADD COLUMN IF NOT EXISTS "tracking_number" text;
+ALTER TABLE "return_requests" ADD COLUMN IF NOT EXISTS "reviewed_by" text;
+ALTER TABLE "return_requests" ADD COLUMN IF NOT EXISTS "reviewed_at" timestamptz;
+ALTER TABLE "return_requests" ADD COLUMN IF NOT EXISTS "rejection_reason" text;
+ALTER TABLE "return_requests" ADD COLUMN IF NOT EXISTS "refund_id" text;
+ALTER TABLE "return_requests" ADD COLUMN IF NOT EXISTS "restocked" boolean DEFAULT false;
+ALTER TABLE "return_requests" ADD COLUMN IF NOT EXISTS "completed_at" timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_return_requests_rma_number" ON "return_requests" ("rma_number");
@@ -0,0 +1,16 @@
+-- Product names, descriptions and SEO fields per locale, picked from the Accept-Language of catalog requests
+
+CREATE TABLE IF NOT EXISTS "product_translations" (
+    "id" text,
+    "product_id" text NOT NULL,
+    "locale" varchar(20) NOT NULL,
+    "name" text NOT NULL,
+    "description" text,
+    "seo_title" text,
+    "seo_description" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_products_translations" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_product_translations_product_locale" ON "product_translations" ("product_id", "locale");
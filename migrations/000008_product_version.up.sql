@@ -0,0 +1,3 @@
+-- Product version for optimistic locking of admin product and inventory updates
+
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "promotion_products";
+DROP TABLE IF EXISTS "promotions";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "tags";
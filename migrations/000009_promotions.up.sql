@@ -0,0 +1,40 @@
+-- Flash sales: time-boxed discounts on the products of some categories or tags, and the product tags they
+-- select by
+
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "tags" text[] DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS "promotions" (
+    "id" text,
+    "name" text NOT NULL,
+    "description" text,
+    "discount_type" text NOT NULL,
+    "discount_value" decimal NOT NULL,
+    "category_ids" text[],
+    "tags" text[],
+    "starts_at" timestamptz NOT NULL,
+    "ends_at" timestamptz NOT NULL,
+    "status" text NOT NULL DEFAULT 'scheduled',
+    "started_at" timestamptz,
+    "ended_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_promotions_starts_at" ON "promotions" ("starts_at");
+CREATE INDEX IF NOT EXISTS "idx_promotions_ends_at" ON "promotions" ("ends_at");
+CREATE INDEX IF NOT EXISTS "idx_promotions_status" ON "promotions" ("status");
+
+CREATE TABLE IF NOT EXISTS "promotion_products" (
+    "id" text,
+    "promotion_id" text NOT NULL,
+    "product_id" text NOT NULL,
+    "original_price" decimal NOT NULL,
+    "original_compare_at_price" decimal,
+    "sale_price" decimal NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_promotions_products" FOREIGN KEY ("promotion_id") REFERENCES "promotions"("id"),
+    CONSTRAINT "fk_promotion_products_product" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_promotion_products_promotion_id" ON "promotion_products" ("promotion_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_promotion_products_product_id" ON "promotion_products" ("product_id");
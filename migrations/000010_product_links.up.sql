@@ -0,0 +1,16 @@
+-- Related, cross-sell and upsell products curated by admins for each product
+
+CREATE TABLE IF NOT EXISTS "product_links" (
+    "id" text,
+    "product_id" text NOT NULL,
+    "related_product_id" text NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "position" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_product_links_product" FOREIGN KEY ("product_id") REFERENCES "products"("id"),
+    CONSTRAINT "fk_product_links_related_product" FOREIGN KEY ("related_product_id") REFERENCES "products"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_product_links_product_related_type" ON "product_links" ("product_id", "related_product_id", "type");
+CREATE INDEX IF NOT EXISTS "idx_product_links_related_product_id" ON "product_links" ("related_product_id");
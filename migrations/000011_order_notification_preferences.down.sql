@@ -0,0 +1,2 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "order_sms_opt_in";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "order_email_opt_out";
@@ -0,0 +1,4 @@
+-- Order notification preferences on the user profile: status emails can be turned off, status texts turned on
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "order_email_opt_out" boolean DEFAULT false;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "order_sms_opt_in" boolean DEFAULT false;
@@ -0,0 +1,14 @@
+-- Comments admins leave on orders, either internal or shown to the customer
+
+CREATE TABLE IF NOT EXISTS "order_comments" (
+    "id" text,
+    "order_id" text NOT NULL,
+    "author_id" text,
+    "body" text NOT NULL,
+    "visibility" varchar(20) NOT NULL DEFAULT 'internal',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_comments" FOREIGN KEY ("order_id") REFERENCES "orders"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_comments_order_id" ON "order_comments" ("order_id");
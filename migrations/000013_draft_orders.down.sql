@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "draft_order_items";
+DROP TABLE IF EXISTS "draft_orders";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "discount";
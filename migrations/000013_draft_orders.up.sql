@@ -0,0 +1,63 @@
+-- Draft orders admins put together on behalf of customers, and the manual discount they carry onto orders
+
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "discount" decimal DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "draft_orders" (
+    "id" text,
+    "user_id" text NOT NULL,
+    "created_by" text NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'open',
+    "shipping_first_name" text,
+    "shipping_last_name" text,
+    "shipping_company" text,
+    "shipping_address1" text,
+    "shipping_address2" text,
+    "shipping_city" text,
+    "shipping_state" text,
+    "shipping_postal_code" text,
+    "shipping_country" text,
+    "shipping_phone" text,
+    "billing_first_name" text,
+    "billing_last_name" text,
+    "billing_company" text,
+    "billing_address1" text,
+    "billing_address2" text,
+    "billing_city" text,
+    "billing_state" text,
+    "billing_postal_code" text,
+    "billing_country" text,
+    "billing_phone" text,
+    "notes" text,
+    "discount_type" varchar(20) NOT NULL DEFAULT '',
+    "discount_value" decimal DEFAULT 0,
+    "discount_reason" text,
+    "subtotal" decimal NOT NULL DEFAULT 0,
+    "discount" decimal NOT NULL DEFAULT 0,
+    "tax" decimal NOT NULL DEFAULT 0,
+    "tax_breakdown" jsonb,
+    "shipping" decimal NOT NULL DEFAULT 0,
+    "total" decimal NOT NULL DEFAULT 0,
+    "order_id" text,
+    "invoice_sent_at" timestamptz,
+    "completed_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_draft_orders" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_draft_orders_user_id" ON "draft_orders" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_draft_orders_status" ON "draft_orders" ("status");
+CREATE INDEX IF NOT EXISTS "idx_draft_orders_order_id" ON "draft_orders" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "draft_order_items" (
+    "id" text,
+    "draft_order_id" text NOT NULL,
+    "product_id" text NOT NULL,
+    "quantity" bigint NOT NULL,
+    "price" decimal NOT NULL,
+    "total" decimal NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_draft_orders_items" FOREIGN KEY ("draft_order_id") REFERENCES "draft_orders"("id"),
+    CONSTRAINT "fk_draft_order_items_product" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_draft_order_items_draft_order_id" ON "draft_order_items" ("draft_order_id");
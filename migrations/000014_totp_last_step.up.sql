@@ -0,0 +1,3 @@
+-- Time step of the last accepted two-factor code, so a code cannot be replayed while it is still valid
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_last_step" bigint;
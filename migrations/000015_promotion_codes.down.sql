@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "promotion_codes";
+ALTER TABLE "promotions" DROP COLUMN IF EXISTS "requires_code";
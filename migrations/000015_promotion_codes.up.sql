@@ -0,0 +1,22 @@
+-- Promotions redeemed with single-use codes instead of repricing products, and the codes generated for them
+
+ALTER TABLE "promotions" ADD COLUMN IF NOT EXISTS "requires_code" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS "promotion_codes" (
+    "id" text,
+    "promotion_id" text NOT NULL,
+    "batch_id" text NOT NULL,
+    "code" text NOT NULL,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "used_at" timestamptz,
+    "used_by" text,
+    "order_id" text,
+    "deactivated_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_promotion_codes_promotion" FOREIGN KEY ("promotion_id") REFERENCES "promotions"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_promotion_codes_promotion_id" ON "promotion_codes" ("promotion_id");
+CREATE INDEX IF NOT EXISTS "idx_promotion_codes_batch_id" ON "promotion_codes" ("batch_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_promotion_codes_code" ON "promotion_codes" ("code");
+CREATE INDEX IF NOT EXISTS "idx_promotion_codes_order_id" ON "promotion_codes" ("order_id");
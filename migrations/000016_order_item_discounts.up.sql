@@ -0,0 +1,3 @@
+-- Share of the order discount taken off each order line, so refunds pay back what was paid for an item
+
+ALTER TABLE "order_items" ADD COLUMN IF NOT EXISTS "discount" decimal NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+// Package migrations holds the versioned SQL migrations of the Postgres schema. Each version has an
+// NNNNNN_name.up.sql file and a .down.sql file reverting it; see cmd/migrate.
+package migrations
+
+import "embed"
+
+// Files are the migration files, embedded so the binaries carry the schema they expect
+//
+//go:embed *.sql
+var Files embed.FS
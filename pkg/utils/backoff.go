@@ -0,0 +1,16 @@
+package utils
+
+import "time"
+
+// Backoff returns the delay before retrying work that failed attempts times: base after the first failure,
+// doubling with each further one up to max
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, Backoff(0, 10*time.Second, time.Hour))
+	assert.Equal(t, 10*time.Second, Backoff(1, 10*time.Second, time.Hour))
+	assert.Equal(t, 20*time.Second, Backoff(2, 10*time.Second, time.Hour))
+	assert.Equal(t, 80*time.Second, Backoff(4, 10*time.Second, time.Hour))
+	assert.Equal(t, time.Hour, Backoff(20, 10*time.Second, time.Hour))
+	assert.Equal(t, time.Minute, Backoff(3, 45*time.Second, time.Minute), "capped even when not a doubling of base")
+}
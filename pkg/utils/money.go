@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+// ToPaise converts a rupee amount to paise, rounding so amounts compare without float truncation noise
+func ToPaise(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// StartOfDay truncates t to midnight UTC, the granularity of daily stats and reports
+func StartOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
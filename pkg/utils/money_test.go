@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToPaise(t *testing.T) {
+	assert.Equal(t, int64(1999), ToPaise(19.99))
+	assert.Equal(t, int64(30), ToPaise(0.1+0.2))
+	assert.Equal(t, int64(-1050), ToPaise(-10.5))
+	assert.Equal(t, int64(0), ToPaise(0))
+}
+
+func TestStartOfDay(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	assert.Equal(t, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), StartOfDay(time.Date(2024, 3, 10, 2, 0, 0, 0, ist)))
+	assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), StartOfDay(time.Date(2024, 3, 10, 23, 59, 59, 0, time.UTC)))
+}
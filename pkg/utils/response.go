@@ -1,35 +1,140 @@
 package utils
 
 import (
+	"context"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// APIVersion is the stable API version, also served under the unversioned /api prefix
+const APIVersion = "v1"
+
 type ApiResponse struct {
-	Success   bool        `json:"success"`
-	Message   string      `json:"message,omitempty"`
-	Data      interface{} `json:"data,omitempty"`
+	Success   bool         `json:"success"`
+	Message   string       `json:"message,omitempty"`
+	Data      interface{}  `json:"data,omitempty"`
 	Error     *ErrorDetail `json:"error,omitempty"`
-	Timestamp string      `json:"timestamp"`
+	Meta      *Meta        `json:"meta,omitempty"`
+	Timestamp string       `json:"timestamp"`
 }
 
+// Error codes of requests ended by their context rather than by the handler
+const (
+	CodeRequestTimeout      = "REQUEST_TIMEOUT"
+	CodeClientClosedRequest = "CLIENT_CLOSED_REQUEST"
+)
+
+// StatusClientClosedRequest is logged for requests the client abandoned; the client never sees it
+const StatusClientClosedRequest = 499
+
 type ErrorDetail struct {
 	Code    string      `json:"code"`
 	Message string      `json:"message"`
 	Details interface{} `json:"details,omitempty"`
 }
 
+// Meta carries machine-readable information about the request and response
+type Meta struct {
+	RequestID  string         `json:"requestId,omitempty"`
+	APIVersion string         `json:"apiVersion"`
+	Pagination *Pagination    `json:"pagination,omitempty"`
+	RateLimit  *RateLimitInfo `json:"rateLimit,omitempty"`
+}
+
+// Pagination describes the page of a list response
+type Pagination struct {
+	Page        int   `json:"page"`
+	Limit       int   `json:"limit"`
+	Total       int64 `json:"total"`
+	TotalPages  int   `json:"totalPages"`
+	HasNext     bool  `json:"hasNext"`
+	HasPrevious bool  `json:"hasPrevious"`
+}
+
+// RateLimitInfo mirrors the X-RateLimit-* headers set by the rate limiter
+type RateLimitInfo struct {
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Reset     int64 `json:"reset,omitempty"`
+}
+
+// NewPagination calculates pagination info for a page of results
+func NewPagination(page, limit int, total int64) Pagination {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+
+	return Pagination{
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+}
+
+// PageParams reads the page and limit query parameters of a list request. Page defaults to 1 and limit to 20,
+// which is also used for limits outside 1..100.
+func PageParams(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
 func SuccessResponse(c *gin.Context, statusCode int, message string, data interface{}) {
 	c.JSON(statusCode, ApiResponse{
 		Success:   true,
 		Message:   message,
 		Data:      data,
+		Meta:      buildMeta(c),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// PaginatedResponse writes a list response with items under the given key and
+// pagination info in both the data payload and the meta block
+func PaginatedResponse(c *gin.Context, statusCode int, message, key string, items interface{}, pagination Pagination) {
+	PaginatedResponseWith(c, statusCode, message, gin.H{key: items}, pagination)
+}
+
+// PaginatedResponseWith writes a page of results whose data carries more than the items,
+// such as search facets; pagination is added to data and to the meta block
+func PaginatedResponseWith(c *gin.Context, statusCode int, message string, data gin.H, pagination Pagination) {
+	meta := buildMeta(c)
+	meta.Pagination = &pagination
+	data["pagination"] = pagination
+
+	c.JSON(statusCode, ApiResponse{
+		Success:   true,
+		Message:   message,
+		Data:      data,
+		Meta:      meta,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	})
 }
 
 func ErrorResponse(c *gin.Context, statusCode int, code, message string, details interface{}) {
+	if statusCode >= http.StatusInternalServerError && c.Request != nil {
+		// A server error while the request context is done is most likely caused by it: a query cancelled
+		// at the request deadline or because the client went away
+		switch c.Request.Context().Err() {
+		case context.DeadlineExceeded:
+			statusCode, code, message = http.StatusGatewayTimeout, CodeRequestTimeout, "The request took too long to process"
+		case context.Canceled:
+			statusCode, code, message = StatusClientClosedRequest, CodeClientClosedRequest, "The client closed the request"
+		}
+	}
 	c.JSON(statusCode, ApiResponse{
 		Success: false,
 		Error: &ErrorDetail{
@@ -37,6 +142,31 @@ func ErrorResponse(c *gin.Context, statusCode int, code, message string, details
 			Message: message,
 			Details: details,
 		},
+		Meta:      buildMeta(c),
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	})
-}
\ No newline at end of file
+}
+
+// buildMeta collects the API version, request ID and rate limit state for the current request
+func buildMeta(c *gin.Context) *Meta {
+	meta := &Meta{APIVersion: APIVersion}
+	if c.Request != nil {
+		meta.APIVersion = PathAPIVersion(c.Request.URL.Path)
+	}
+
+	if requestID, exists := c.Get("request_id"); exists {
+		if id, ok := requestID.(string); ok {
+			meta.RequestID = id
+		}
+	}
+
+	header := c.Writer.Header()
+	if limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		info := &RateLimitInfo{Limit: limit}
+		info.Remaining, _ = strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+		info.Reset, _ = strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+		meta.RateLimit = info
+	}
+
+	return meta
+}
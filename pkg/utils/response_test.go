@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPagination(t *testing.T) {
+	p := NewPagination(2, 10, 25)
+	assert.Equal(t, 3, p.TotalPages)
+	assert.True(t, p.HasNext)
+	assert.True(t, p.HasPrevious)
+
+	last := NewPagination(3, 10, 25)
+	assert.False(t, last.HasNext)
+
+	empty := NewPagination(1, 10, 0)
+	assert.Equal(t, 0, empty.TotalPages)
+	assert.False(t, empty.HasNext)
+	assert.False(t, empty.HasPrevious)
+}
+
+func TestPageParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := map[string][2]int{
+		"":                  {1, 20},
+		"?page=3&limit=50":  {3, 50},
+		"?page=0&limit=500": {1, 20},
+		"?page=x&limit=-1":  {1, 20},
+	}
+	for query, want := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/"+query, nil)
+		page, limit := PageParams(c)
+		assert.Equal(t, want, [2]int{page, limit}, query)
+	}
+}
+
+func TestResponseMeta(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("request_id", "req-123")
+		c.Header("X-RateLimit-Limit", "100")
+		c.Header("X-RateLimit-Remaining", "42")
+		c.Next()
+	})
+	r.GET("/ok", func(c *gin.Context) {
+		SuccessResponse(c, http.StatusOK, "ok", nil)
+	})
+	r.GET("/list", func(c *gin.Context) {
+		PaginatedResponse(c, http.StatusOK, "listed", "items", []string{"a", "b"}, NewPagination(1, 2, 5))
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		ErrorResponse(c, http.StatusBadRequest, "BAD", "bad request", nil)
+	})
+
+	for _, path := range []string{"/ok", "/list", "/fail"} {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			r.ServeHTTP(w, req)
+
+			var response ApiResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.NotNil(t, response.Meta)
+			assert.Equal(t, "req-123", response.Meta.RequestID)
+			assert.Equal(t, APIVersion, response.Meta.APIVersion)
+			require.NotNil(t, response.Meta.RateLimit)
+			assert.Equal(t, 100, response.Meta.RateLimit.Limit)
+			assert.Equal(t, 42, response.Meta.RateLimit.Remaining)
+
+			if path == "/list" {
+				require.NotNil(t, response.Meta.Pagination)
+				assert.Equal(t, 3, response.Meta.Pagination.TotalPages)
+				data := response.Data.(map[string]interface{})
+				assert.Len(t, data["items"], 2)
+				assert.NotNil(t, data["pagination"])
+			} else {
+				assert.Nil(t, response.Meta.Pagination)
+			}
+		})
+	}
+}
+
+func TestPaginatedResponseWith(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/search", func(c *gin.Context) {
+		PaginatedResponseWith(c, http.StatusOK, "searched", gin.H{
+			"products":    []string{"a"},
+			"suggestions": []string{"apple"},
+		}, NewPagination(1, 20, 1))
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/search", nil)
+	r.ServeHTTP(w, req)
+
+	var response ApiResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	data := response.Data.(map[string]interface{})
+	assert.Contains(t, data, "products")
+	assert.Contains(t, data, "suggestions")
+	assert.Equal(t, float64(1), data["pagination"].(map[string]interface{})["total"])
+	require.NotNil(t, response.Meta.Pagination)
+	assert.Equal(t, int64(1), response.Meta.Pagination.Total)
+}
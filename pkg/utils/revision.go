@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidRevision is returned for an If-Match header that is not a revision number
+var ErrInvalidRevision = errors.New("If-Match must be a revision number")
+
+// ExpectedRevision returns the revision a mutation is based on, sent as If-Match: 7 (quoted or weak ETags are
+// accepted too), and false when the client did not send one
+func ExpectedRevision(c *gin.Context) (int64, bool, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" {
+		return 0, false, nil
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || revision < 0 {
+		return 0, false, ErrInvalidRevision
+	}
+	return revision, true, nil
+}
+
+// SetRevision reports a resource's revision as its ETag, for clients to send back in If-Match
+func SetRevision(c *gin.Context, revision int64) {
+	c.Header("ETag", `"`+strconv.FormatInt(revision, 10)+`"`)
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpectedRevision(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		header   string
+		revision int64
+		present  bool
+		invalid  bool
+	}{
+		{header: ""},
+		{header: "7", revision: 7, present: true},
+		{header: `"7"`, revision: 7, present: true},
+		{header: `W/"12"`, revision: 12, present: true},
+		{header: "abc", invalid: true},
+		{header: "-1", invalid: true},
+	}
+
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "/", nil)
+		if tc.header != "" {
+			c.Request.Header.Set("If-Match", tc.header)
+		}
+
+		revision, present, err := ExpectedRevision(c)
+		if tc.invalid {
+			assert.ErrorIs(t, err, ErrInvalidRevision, tc.header)
+			continue
+		}
+		assert.NoError(t, err, tc.header)
+		assert.Equal(t, tc.present, present, tc.header)
+		assert.Equal(t, tc.revision, revision, tc.header)
+	}
+}
+
+func TestSetRevision(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	SetRevision(c, 42)
+	assert.Equal(t, `"42"`, w.Header().Get("ETag"))
+}
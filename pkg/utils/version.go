@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersions are the versions the API is served under as /api/<version>, oldest first. Breaking changes
+// ship in a new version while the earlier ones keep their behavior.
+var APIVersions = []string{"v1"}
+
+// isAPIVersion reports whether segment names a served API version
+func isAPIVersion(segment string) bool {
+	for _, version := range APIVersions {
+		if segment == version {
+			return true
+		}
+	}
+	return false
+}
+
+// splitAPIVersion splits a versioned API path such as /api/v1/products into its version and the path after it
+func splitAPIVersion(path string) (version, rest string, ok bool) {
+	if !strings.HasPrefix(path, "/api/") {
+		return "", "", false
+	}
+	version, rest, _ = strings.Cut(strings.TrimPrefix(path, "/api/"), "/")
+	if !isAPIVersion(version) {
+		return "", "", false
+	}
+	if rest != "" {
+		rest = "/" + rest
+	}
+	return version, rest, true
+}
+
+// UnversionedPath removes the version from an API path, /api/v1/admin/orders becoming /api/admin/orders, so
+// code matching request paths treats every version alike. Other paths are returned unchanged.
+func UnversionedPath(path string) string {
+	if _, rest, ok := splitAPIVersion(path); ok {
+		return "/api" + rest
+	}
+	return path
+}
+
+// IsVersionedPath reports whether path is under one of the versioned API prefixes
+func IsVersionedPath(path string) bool {
+	_, _, ok := splitAPIVersion(path)
+	return ok
+}
+
+// PathAPIVersion returns the API version a path is served under. Unversioned API paths are served by the
+// stable APIVersion.
+func PathAPIVersion(path string) string {
+	if version, _, ok := splitAPIVersion(path); ok {
+		return version
+	}
+	return APIVersion
+}
+
+// APIPath returns the path of an API route under the prefix the request was made to, so links handed back
+// to clients stay in their version: APIPath(c, "/orders") is /api/v1/orders for a request to /api/v1
+func APIPath(c *gin.Context, route string) string {
+	if c.Request != nil {
+		if version, _, ok := splitAPIVersion(c.Request.URL.Path); ok {
+			return "/api/" + version + route
+		}
+	}
+	return "/api" + route
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnversionedPath(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/admin/orders": "/api/admin/orders",
+		"/api/v1":              "/api",
+		"/api/admin/orders":    "/api/admin/orders",
+		"/api/v9/orders":       "/api/v9/orders",
+		"/api/v1x/orders":      "/api/v1x/orders",
+		"/health":              "/health",
+	}
+	for path, expected := range tests {
+		assert.Equal(t, expected, UnversionedPath(path), path)
+	}
+	assert.True(t, IsVersionedPath("/api/v1/products"))
+	assert.False(t, IsVersionedPath("/api/products"))
+	assert.Equal(t, "v1", PathAPIVersion("/api/products"))
+}
+
+func TestAPIPath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for request, expected := range map[string]string{
+		"/api/v1/admin/reports/queries": "/api/v1/admin/reports/queries/q1",
+		"/api/admin/reports/queries":    "/api/admin/reports/queries/q1",
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, request, nil)
+		assert.Equal(t, expected, APIPath(c, "/admin/reports/queries/q1"))
+	}
+}
@@ -35,6 +35,8 @@ func setupTestDB(t *testing.T) (*gorm.DB, func()) {
 		&models.Product{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.Shipment{},
+		&models.Setting{},
 		&models.Cart{},
 		&models.CartItem{},
 	)
@@ -86,6 +88,8 @@ func setupTestDBWithPostgres(t *testing.T) (*gorm.DB, func()) {
 		&models.Product{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.Shipment{},
+		&models.Setting{},
 		&models.Cart{},
 		&models.CartItem{},
 	)
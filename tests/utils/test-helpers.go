@@ -37,6 +37,8 @@ func NewTestDatabase(t *testing.T) *TestDatabase {
 		&models.Product{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.Shipment{},
+		&models.Setting{},
 		&models.Cart{},
 		&models.CartItem{},
 	)